@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 
@@ -12,22 +14,28 @@ import (
 )
 
 type flags struct {
-	Version bool
-	Health  bool
-	EnvFile string
+	Version        bool
+	Health         bool
+	PrintConfig    bool
+	ValidateConfig bool
+	EnvFile        string
 }
 
 func parseFlags() flags {
 	// Parse command line flags
 	version := flag.Bool("version", false, "Show version information")
 	health := flag.Bool("health", false, "Run health check and exit")
+	printConfig := flag.Bool("print-config", false, "Print the effective configuration as JSON, secrets redacted, and exit")
+	validateConfig := flag.Bool("validate-config", false, "Check the configuration and exit, non-zero with every problem found when it is invalid")
 	envFile := flag.String("env-file", "", "Path to environment file (required)")
 	flag.Parse()
 
 	parsedFlags := flags{
-		Version: *version,
-		Health:  *health,
-		EnvFile: *envFile,
+		Version:        *version,
+		Health:         *health,
+		PrintConfig:    *printConfig,
+		ValidateConfig: *validateConfig,
+		EnvFile:        *envFile,
 	}
 
 	if *envFile == "" {
@@ -58,3 +66,33 @@ func (f flags) handleVersionFlag(cfg *config.Config) {
 		os.Exit(0)
 	}
 }
+
+// handlePrintConfigFlag writes the effective configuration to w as indented JSON, with
+// secrets redacted, and reports whether it did so the caller can exit without starting the
+// server
+func (f flags) handlePrintConfigFlag(cfg *config.Config, w io.Writer) (bool, error) {
+	if !f.PrintConfig {
+		return false, nil
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(cfg.Redacted()); err != nil {
+		return true, fmt.Errorf("encoding configuration: %w", err)
+	}
+	return true, nil
+}
+
+// handleValidateConfigFlag reports the outcome of loading the configuration, loadErr, to w and
+// returns the exit code for it: 0 when the configuration is valid and 1 otherwise. It reports
+// whether the flag was set so the caller can exit without connecting to the database.
+func (f flags) handleValidateConfigFlag(loadErr error, w io.Writer) (bool, int) {
+	if !f.ValidateConfig {
+		return false, 0
+	}
+	if loadErr != nil {
+		fmt.Fprintf(w, "Configuration is invalid:\n%v\n", loadErr)
+		return true, 1
+	}
+	fmt.Fprintln(w, "Configuration is valid")
+	return true, 0
+}
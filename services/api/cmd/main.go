@@ -16,13 +16,14 @@ var (
 )
 
 func main() {
-
-	fmt.Println(Version, Commit, BuildDate)
 	// Parse command line flags
 	flags := parseFlags()
 
 	// Load configuration
 	cfg, err := config.LoadConfig(flags.EnvFile)
+	if validated, code := flags.handleValidateConfigFlag(err, os.Stdout); validated {
+		os.Exit(code)
+	}
 	if err != nil {
 		slog.Error("Failed to load configuration", "error", err, "env_file", flags.EnvFile)
 		os.Exit(1)
@@ -30,6 +31,17 @@ func main() {
 
 	flags.handleVersionFlag(cfg)
 
+	// Nothing else may be written to stdout before the configuration, so it stays valid JSON
+	if printed, err := flags.handlePrintConfigFlag(cfg, os.Stdout); printed {
+		if err != nil {
+			slog.Error("Failed to print configuration", "error", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	fmt.Println(Version, Commit, BuildDate)
+
 	slog.Info("Starting Revenue Leak Detective API")
 
 	ctx := context.Background()
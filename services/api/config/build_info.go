@@ -13,12 +13,58 @@ func printEffectiveConfig(c *Config, logger *slog.Logger) {
 	logger.Info(fmt.Sprintf("environment: %s", c.Environment.Environment))
 	logger.Info(fmt.Sprintf("debug: %v", c.Environment.Debug))
 	logger.Info(fmt.Sprintf("log_level: %s", c.Environment.LogLevel.String()))
+	logger.Info(fmt.Sprintf("log_format: %s no_color=%v", c.Environment.LogFormat, c.Environment.NoColor))
+	logger.Info(fmt.Sprintf("log_scrub_pii: %v keys=%v", c.Environment.ScrubPII, c.Environment.PIIKeys))
+	logger.Info(fmt.Sprintf("log_exclude_paths: %v", c.Environment.LogExcludePaths))
+	logger.Info(fmt.Sprintf("log_bodies: %v paths=%v max_bytes=%d", c.Environment.LogBodies, c.Environment.LogBodyPaths, c.Environment.LogBodyMaxBytes))
+	logger.Info(fmt.Sprintf("dev_error_details: %v (honored: %v)", c.Environment.DevErrorDetails, c.ShowErrorDetails()))
 	logger.Info(fmt.Sprintf("http_port: %s", c.HTTP.Port))
+	logger.Info(fmt.Sprintf("health_paths: health=%s live=%s ready=%s", c.HTTP.HealthPath, c.HTTP.LivePath, c.HTTP.ReadyPath))
 	logger.Info(fmt.Sprintf("db_host: %s", c.Database.Host))
 	logger.Info(fmt.Sprintf("db_port: %s", c.Database.Port))
 	logger.Info(fmt.Sprintf("db_name: %s", c.Database.DBName))
 	logger.Info(fmt.Sprintf("db_user: %s", c.Database.User))
 	logger.Info(fmt.Sprintf("db_ssl_mode: %s", c.Database.SSLMode))
+	logger.Info(fmt.Sprintf("db_pool: health_check_period=%s max_conn_idle_time=%s acquire_timeout=%s acquire_warn_threshold=%s max_tx_per_tenant=%d", c.Database.HealthCheckPeriod, c.Database.MaxConnIdleTime, c.Database.AcquireTimeout, c.Database.AcquireWarnThreshold, c.Database.MaxTxPerTenant))
+	logger.Info(fmt.Sprintf("db_read_replica: %v", c.Database.ReplicaURL != ""))
+	logger.Info(fmt.Sprintf("db_session: application_name=%s search_path=%v", c.Database.ApplicationName, c.Database.SearchPath))
+	logger.Info(fmt.Sprintf("startup_require_migrated: %v", c.Database.RequireMigrated))
+	logger.Info(fmt.Sprintf("unknown_enum_policy: %s", c.Database.UnknownEnumPolicy))
+	logger.Info(fmt.Sprintf("max_request_bytes: %d", c.HTTP.MaxRequestBytes))
+	logger.Info(fmt.Sprintf("webhook_max_bytes: %d", c.HTTP.WebhookMaxBytes))
+	logger.Info(fmt.Sprintf("api_time_format: %s", c.HTTP.TimeFormat))
+	logger.Info(fmt.Sprintf("api_list_format: %s", c.HTTP.ListFormat))
+	logger.Info(fmt.Sprintf("admin_api_keys: %d configured", len(c.HTTP.AdminAPIKeys)))
+	logger.Info(fmt.Sprintf("stale_cache_ttl: %s", c.HTTP.StaleCacheTTL))
+	logger.Info(fmt.Sprintf("static_cache_max_age: %s", c.HTTP.StaticCacheMaxAge))
+	logger.Info(fmt.Sprintf("response_max_bytes: %d", c.HTTP.ResponseMaxBytes))
+	logger.Info(fmt.Sprintf("cors_allowed_origins: %v", c.HTTP.CORSAllowedOrigins))
+	logger.Info(fmt.Sprintf("tls_enabled: %v", c.HTTP.TLSEnabled()))
+	logger.Info(fmt.Sprintf("http2_cleartext: %v", c.HTTP.HTTP2Cleartext))
+	logger.Info(fmt.Sprintf("stripe_webhook_enabled: %v", c.Stripe.WebhookSecret != ""))
+	logger.Info(fmt.Sprintf("stripe_required: %v", c.Stripe.Enabled))
+	logger.Info(fmt.Sprintf("webhook_tolerance: %s", c.Stripe.WebhookTolerance))
+	logger.Info(fmt.Sprintf("shutdown_timeout_sigterm: %s", c.Shutdown.SIGTERMTimeout))
+	logger.Info(fmt.Sprintf("shutdown_timeout_sigint: %s", c.Shutdown.SIGINTTimeout))
+	logger.Info(fmt.Sprintf("pre_shutdown_delay: %s", c.Shutdown.PreShutdownDelay))
+	logger.Info(fmt.Sprintf("export_max_rows: %d", c.Export.MaxRows))
+	logger.Info(fmt.Sprintf("batch: max_size=%d oversize_action=%s", c.Batch.MaxSize, c.Batch.OversizeAction))
+	logger.Info(fmt.Sprintf("max_action_attempts: %d", c.Actions.MaxAttempts))
+	logger.Info(fmt.Sprintf("event_correlation: keys=%v window=%s", c.Correlation.Keys, c.Correlation.Window))
+	logger.Info(fmt.Sprintf("notifier: max_retries=%d circuit_threshold=%d circuit_cooldown=%s", c.Notifier.MaxRetries, c.Notifier.CircuitThreshold, c.Notifier.CircuitCooldown))
+	logger.Info(fmt.Sprintf("notification outbox: interval=%s max_attempts=%d base_backoff=%s max_backoff=%s", c.Notifier.OutboxInterval, c.Notifier.OutboxMaxAttempts, c.Notifier.OutboxBaseBackoff, c.Notifier.OutboxMaxBackoff))
+	logger.Info(fmt.Sprintf("slack_enabled: %v", c.Notifier.SlackEnabled))
+	logger.Info(fmt.Sprintf("email_enabled: %v", c.Notifier.EmailEnabled()))
+	logger.Info(fmt.Sprintf("jwt_enabled: %v", c.Auth.JWTEnabled))
+	logger.Info(fmt.Sprintf("event_age: max_age=%s stale_action=%s", c.EventAge.MaxAge, c.EventAge.StaleAction))
+	logger.Info(fmt.Sprintf("detection: volume_window=%s volume_baseline_windows=%d volume_factor=%g failure_rate_factor=%g duplicate_charge_window=%s dunning_window=%s min_leak_amounts=%v max_leaks_per_run=%d interval=%s concurrency=%d", c.Detection.VolumeWindow, c.Detection.VolumeBaselineWindows, c.Detection.VolumeFactor, c.Detection.FailureRateFactor, c.Detection.DuplicateChargeWindow, c.Detection.DunningWindow, c.Detection.MinLeakAmounts, c.Detection.MaxLeaksPerRun, c.Detection.Interval, c.Detection.Concurrency))
+	logger.Info(fmt.Sprintf("retention: event_retention=%s purge_interval=%s purge_batch_size=%d", c.Retention.EventRetention, c.Retention.PurgeInterval, c.Retention.PurgeBatchSize))
+	logger.Info(fmt.Sprintf("ingest queue: size=%d flush_interval=%s", c.IngestQueue.Size, c.IngestQueue.FlushInterval))
+	logger.Info(fmt.Sprintf("rate limit: rps=%g burst=%d refresh_interval=%s", c.RateLimit.RPS, c.RateLimit.Burst, c.RateLimit.RefreshInterval))
+	logger.Info(fmt.Sprintf("idempotency: store=%s ttl=%s max_keys=%d", c.Idempotency.Store, c.Idempotency.TTL, c.Idempotency.MaxKeys))
+	logger.Info(fmt.Sprintf("metrics: ingestion_lag_buckets=%v", c.Metrics.IngestionLagBuckets))
+	logger.Info(fmt.Sprintf("health: critical_components=%v ready_when_degraded=%v", c.Health.CriticalComponents, c.Health.ReadyWhenDegraded))
+	logger.Info(fmt.Sprintf("event pipeline: stages=%v", c.EventPipeline.Stages))
 }
 
 // printBuildInfo prints the build information
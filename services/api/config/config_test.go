@@ -4,6 +4,7 @@ import (
 	"log/slog"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -69,13 +70,89 @@ func TestLoadConfig(t *testing.T) {
 		require.NoError(t, err)
 
 		assert.Equal(t, "3030", cfg.HTTP.Port)
-		assert.Equal(t, slog.LevelInfo, cfg.Environment.LogLevel)
+		assert.Equal(t, slog.LevelDebug, cfg.Environment.LogLevel) // development default
+		assert.Equal(t, LogFormatAuto, cfg.Environment.LogFormat)
 		assert.Equal(t, "development", cfg.Environment.Environment)
 		assert.Equal(t, "unknown", cfg.Environment.ConfigVer)
+		assert.False(t, cfg.Environment.ScrubPII)
+		assert.Equal(t, []string{"email", "name", "first_name", "last_name", "customer_name", "phone", "address"}, cfg.Environment.PIIKeys)
+		assert.Empty(t, cfg.Environment.LogExcludePaths)
+		assert.False(t, cfg.Environment.LogBodies)
+		assert.Empty(t, cfg.Environment.LogBodyPaths)
+		assert.Equal(t, 2048, cfg.Environment.LogBodyMaxBytes)
+		assert.False(t, cfg.Environment.DevErrorDetails)
 		assert.Equal(t, "localhost", cfg.Database.Host)
 		assert.Equal(t, "5432", cfg.Database.Port)
 		assert.Equal(t, "postgres", cfg.Database.User)
 		assert.Equal(t, "revenue_leak_detective_dev", cfg.Database.DBName)
+		assert.Equal(t, time.Minute, cfg.Database.HealthCheckPeriod)
+		assert.Equal(t, 30*time.Minute, cfg.Database.MaxConnIdleTime)
+		assert.Equal(t, 5*time.Second, cfg.Database.AcquireTimeout)
+		assert.Equal(t, 100*time.Millisecond, cfg.Database.AcquireWarnThreshold)
+		assert.Zero(t, cfg.Database.MaxTxPerTenant)
+		assert.Empty(t, cfg.Database.ReplicaURL)
+		assert.Equal(t, "rdl-api", cfg.Database.ApplicationName)
+		assert.Empty(t, cfg.Database.SearchPath)
+		assert.False(t, cfg.Database.RequireMigrated)
+		assert.Equal(t, UnknownEnumMap, cfg.Database.UnknownEnumPolicy)
+		assert.Equal(t, []string{"customer_id", "amount"}, cfg.Correlation.Keys)
+		assert.Equal(t, 24*time.Hour, cfg.Correlation.Window)
+		assert.Equal(t, 3, cfg.Notifier.MaxRetries)
+		assert.Equal(t, 5, cfg.Notifier.CircuitThreshold)
+		assert.Equal(t, 30*time.Second, cfg.Notifier.CircuitCooldown)
+		assert.Equal(t, 30*time.Second, cfg.Notifier.OutboxInterval)
+		assert.Equal(t, 8, cfg.Notifier.OutboxMaxAttempts)
+		assert.Equal(t, time.Minute, cfg.Notifier.OutboxBaseBackoff)
+		assert.Equal(t, time.Hour, cfg.Notifier.OutboxMaxBackoff)
+		assert.Equal(t, time.Duration(0), cfg.EventAge.MaxAge)
+		assert.Equal(t, StaleActionSkip, cfg.EventAge.StaleAction)
+		assert.Equal(t, 500, cfg.Batch.MaxSize)
+		assert.Equal(t, BatchOversizeReject, cfg.Batch.OversizeAction)
+		assert.Equal(t, 5, cfg.Actions.MaxAttempts)
+		assert.Equal(t, 10*time.Minute, cfg.Actions.ClaimLease)
+		assert.Equal(t, time.Duration(0), cfg.Actions.ExecutorInterval)
+		assert.Equal(t, time.Duration(0), cfg.Shutdown.PreShutdownDelay)
+		assert.Equal(t, time.Hour, cfg.Detection.VolumeWindow)
+		assert.Equal(t, 24, cfg.Detection.VolumeBaselineWindows)
+		assert.Equal(t, 3.0, cfg.Detection.VolumeFactor)
+		assert.Equal(t, 3.0, cfg.Detection.FailureRateFactor)
+		assert.Equal(t, 10*time.Minute, cfg.Detection.DuplicateChargeWindow)
+		assert.Equal(t, 72*time.Hour, cfg.Detection.DunningWindow)
+		assert.Empty(t, cfg.Detection.MinLeakAmounts)
+		assert.Equal(t, 1000, cfg.Detection.MaxLeaksPerRun)
+		assert.Equal(t, time.Duration(0), cfg.Detection.Interval)
+		assert.Equal(t, 4, cfg.Detection.Concurrency)
+		assert.Equal(t, time.Duration(0), cfg.Retention.EventRetention)
+		assert.Equal(t, time.Hour, cfg.Retention.PurgeInterval)
+		assert.Equal(t, 1000, cfg.Retention.PurgeBatchSize)
+		assert.Equal(t, 0, cfg.IngestQueue.Size)
+		assert.Equal(t, 5*time.Second, cfg.IngestQueue.FlushInterval)
+		assert.Equal(t, []string{"database"}, cfg.Health.CriticalComponents)
+		assert.True(t, cfg.Health.ReadyWhenDegraded)
+		assert.Equal(t, []string{"validate", "persist", "metric"}, cfg.EventPipeline.Stages)
+		assert.Equal(t, 0.0, cfg.RateLimit.RPS)
+		assert.Equal(t, 0, cfg.RateLimit.Burst)
+		assert.Equal(t, 30*time.Second, cfg.RateLimit.RefreshInterval)
+		assert.Equal(t, IdempotencyStoreMemory, cfg.Idempotency.Store)
+		assert.Equal(t, 24*time.Hour, cfg.Idempotency.TTL)
+		assert.Equal(t, 100000, cfg.Idempotency.MaxKeys)
+		assert.Equal(t, []time.Duration{time.Second, 5 * time.Second, 30 * time.Second, time.Minute, 5 * time.Minute, 15 * time.Minute, time.Hour, 6 * time.Hour, 24 * time.Hour}, cfg.Metrics.IngestionLagBuckets)
+		assert.Equal(t, "flat", cfg.HTTP.ListFormat)
+		assert.Equal(t, int64(1048576), cfg.HTTP.MaxRequestBytes)
+		assert.Equal(t, int64(5242880), cfg.HTTP.WebhookMaxBytes)
+		assert.Empty(t, cfg.HTTP.AdminAPIKeys)
+		assert.Equal(t, 5*time.Minute, cfg.HTTP.StaleCacheTTL)
+		assert.Equal(t, 5*time.Minute, cfg.HTTP.StaticCacheMaxAge)
+		assert.Equal(t, int64(0), cfg.HTTP.ResponseMaxBytes)
+		assert.Equal(t, []string{"*"}, cfg.HTTP.CORSAllowedOrigins)
+		assert.False(t, cfg.HTTP.TLSEnabled())
+		assert.False(t, cfg.HTTP.HTTP2Cleartext)
+		assert.False(t, cfg.Stripe.Enabled)
+		assert.Equal(t, 5*time.Minute, cfg.Stripe.WebhookTolerance)
+		assert.False(t, cfg.Notifier.SlackEnabled)
+		assert.False(t, cfg.Notifier.EmailEnabled())
+		assert.Equal(t, "587", cfg.Notifier.SMTPPort)
+		assert.False(t, cfg.Auth.JWTEnabled)
 	})
 
 	t.Run("custom configuration from env vars", func(t *testing.T) {
@@ -158,6 +235,78 @@ func TestLoadConfig(t *testing.T) {
 		assert.Equal(t, slog.LevelInfo, cfg.Environment.LogLevel)
 	})
 
+	t.Run("health critical components", func(t *testing.T) {
+		t.Setenv(EnvHealthCriticalComponents, "Database, replica")
+		cfg, err := LoadConfig("")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"database", "replica"}, cfg.Health.CriticalComponents)
+
+		t.Setenv(EnvHealthCriticalComponents, "database,cache")
+		_, err = LoadConfig("")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrInvalidHealthComponent)
+	})
+
+	t.Run("event pipeline stages", func(t *testing.T) {
+		t.Setenv(EnvEventPipelineStages, "Validate, persist,detect,notify,metric")
+		cfg, err := LoadConfig("")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"validate", "persist", "detect", "notify", "metric"}, cfg.EventPipeline.Stages)
+
+		t.Setenv(EnvEventPipelineStages, "persist")
+		cfg, err = LoadConfig("")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"persist"}, cfg.EventPipeline.Stages)
+
+		for _, stages := range []string{",", "validate,metric", "persist,enrich", "persist,metric,metric", "metric,persist", "persist,validate", "persist,notify", "persist,notify,detect"} {
+			t.Setenv(EnvEventPipelineStages, stages)
+			_, err = LoadConfig("")
+			require.Error(t, err, stages)
+			assert.Contains(t, err.Error(), ErrInvalidPipelineStage)
+		}
+	})
+
+	t.Run("idempotency store", func(t *testing.T) {
+		t.Setenv(EnvIdempotencyStore, " Postgres ")
+		cfg, err := LoadConfig("")
+		require.NoError(t, err)
+		assert.Equal(t, IdempotencyStorePostgres, cfg.Idempotency.Store)
+
+		t.Setenv(EnvIdempotencyStore, "redis")
+		_, err = LoadConfig("")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrInvalidIdempotencyStore)
+	})
+
+	t.Run("ingestion lag buckets", func(t *testing.T) {
+		t.Setenv(EnvIngestionLagBuckets, "500ms, 10s,2m")
+		cfg, err := LoadConfig("")
+		require.NoError(t, err)
+		assert.Equal(t, []time.Duration{500 * time.Millisecond, 10 * time.Second, 2 * time.Minute}, cfg.Metrics.IngestionLagBuckets)
+
+		for _, value := range []string{"10s,5s", "1s,1s", "1s,-1m", "soon"} {
+			t.Setenv(EnvIngestionLagBuckets, value)
+			_, err = LoadConfig("")
+			require.Error(t, err, value)
+			assert.Contains(t, err.Error(), ErrInvalidBuckets, value)
+		}
+	})
+
+	t.Run("malformed CORS origin", func(t *testing.T) {
+		t.Setenv(EnvCORSOrigins, "https://app.example.com,https://admin.example.com/")
+		_, err := LoadConfig("")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrInvalidCORSOrigin)
+		assert.Contains(t, err.Error(), `"https://admin.example.com/" has a trailing slash`)
+	})
+
+	t.Run("TLS certificate without a key", func(t *testing.T) {
+		t.Setenv(EnvTLSCertFile, "/etc/rld/tls.crt")
+		_, err := LoadConfig("")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrInvalidTLSConfig)
+	})
+
 	t.Run("invalid environment", func(t *testing.T) {
 		require.NoError(t, os.Setenv("ENVIRONMENT", "invalid-env"))
 
@@ -223,6 +372,219 @@ func TestValidatePort(t *testing.T) {
 	}
 }
 
+func TestParseNonNegativeInt(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected int
+		wantErr  bool
+	}{
+		{"zero", "0", 0, false},
+		{"positive", "1000", 1000, false},
+		{"surrounding whitespace", " 25 ", 25, false},
+		{"negative", "-1", 0, true},
+		{"non-numeric", "lots", 0, true},
+		{"empty", "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := parseNonNegativeInt(EnvExportMaxRows, tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, n)
+		})
+	}
+}
+
+func TestParsePositiveInt(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected int
+		wantErr  bool
+	}{
+		{"positive", "5", 5, false},
+		{"zero", "0", 0, true},
+		{"negative", "-1", 0, true},
+		{"non-numeric", "five", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := parsePositiveInt(EnvNotifierCircuitThreshold, tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, n)
+		})
+	}
+}
+
+func TestParseNonNegativeDuration(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{"zero disables", "0", 0, false},
+		{"positive", "168h", 168 * time.Hour, false},
+		{"negative", "-1h", 0, true},
+		{"invalid", "a week", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := parseNonNegativeDuration(EnvEventMaxAge, tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, d)
+		})
+	}
+}
+
+func TestParseFactor(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected float64
+		wantErr  bool
+	}{
+		{"integer", "3", 3, false},
+		{"fraction", "2.5", 2.5, false},
+		{"one", "1", 0, true},
+		{"below one", "0.5", 0, true},
+		{"not a number", "NaN", 0, true},
+		{"invalid", "triple", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := parseFactor(EnvDetectionVolumeFactor, tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, f)
+		})
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected float64
+		wantErr  bool
+	}{
+		{"integer", "50", 50, false},
+		{"fraction", "0.5", 0.5, false},
+		{"zero", "0", 0, false},
+		{"negative", "-1", 0, true},
+		{"infinite", "Inf", 0, true},
+		{"invalid", "fast", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := parseRate(EnvRateLimitRPS, tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, f)
+		})
+	}
+}
+
+func TestParseMinLeakAmounts(t *testing.T) {
+	amounts, err := parseMinLeakAmounts(EnvDetectionMinLeakAmounts, " usd:1.00, JPY:150 ")
+	require.NoError(t, err)
+	require.Len(t, amounts, 2)
+	assert.Equal(t, "1.00", amounts["USD"].String())
+	assert.Equal(t, "150", amounts["JPY"].String())
+
+	amounts, err = parseMinLeakAmounts(EnvDetectionMinLeakAmounts, "")
+	require.NoError(t, err)
+	assert.Empty(t, amounts)
+
+	for _, value := range []string{"USD", "USD:cheap", "DOLLARS:1", "USD:-1"} {
+		_, err := parseMinLeakAmounts(EnvDetectionMinLeakAmounts, value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestParseOrigins(t *testing.T) {
+	valid := []struct {
+		value    string
+		expected []string
+	}{
+		{"*", []string{"*"}},
+		{" * ", []string{"*"}},
+		{"https://app.example.com", []string{"https://app.example.com"}},
+		{"https://App.Example.com, http://localhost:3000", []string{"https://app.example.com", "http://localhost:3000"}},
+		{"http://[::1]:8080", []string{"http://[::1]:8080"}},
+	}
+	for _, tt := range valid {
+		origins, err := parseOrigins(EnvCORSOrigins, tt.value)
+		require.NoError(t, err, tt.value)
+		assert.Equal(t, tt.expected, origins, tt.value)
+	}
+
+	malformed := []struct {
+		value  string
+		reason string
+	}{
+		{"https://app.example.com/", "trailing slash"},
+		{"app.example.com", "missing a scheme"},
+		{"localhost:3000", "missing a scheme"},
+		{"https://app.example.com/callback", "must not have a path"},
+		{"https://app.example.com?x=1", "must not have a path"},
+		{"https://:443", "missing a host"},
+		{"https://app.example.com:70000", "out of range"},
+		{"https://user@app.example.com", "user info"},
+		{"*,https://app.example.com", "must be the only entry"},
+		{" , ", ErrEmptyList},
+	}
+	for _, tt := range malformed {
+		_, err := parseOrigins(EnvCORSOrigins, tt.value)
+		require.Error(t, err, tt.value)
+		assert.ErrorContains(t, err, ErrInvalidCORSOrigin, tt.value)
+		assert.ErrorContains(t, err, tt.reason, tt.value)
+	}
+}
+
+func TestParseBool(t *testing.T) {
+	for _, value := range []string{"true", "1", " TRUE "} {
+		b, err := parseBool(EnvScrubPII, value)
+		require.NoError(t, err)
+		assert.True(t, b, value)
+	}
+	b, err := parseBool(EnvScrubPII, "false")
+	require.NoError(t, err)
+	assert.False(t, b)
+
+	_, err = parseBool(EnvScrubPII, "sometimes")
+	assert.ErrorContains(t, err, ErrInvalidBoolValue)
+}
+
+func TestParseList(t *testing.T) {
+	assert.Equal(t, []string{"customer_id", "amount"}, parseList("customer_id,amount"))
+	assert.Equal(t, []string{"customer_id", "amount"}, parseList(" customer_id , ,amount, "))
+	assert.Empty(t, parseList(" , "))
+}
+
 func TestParseLogLevel(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -248,6 +610,110 @@ func TestParseLogLevel(t *testing.T) {
 	}
 }
 
+func TestDefaultLogLevel(t *testing.T) {
+	tests := []struct {
+		env      string
+		expected slog.Level
+	}{
+		{"development", slog.LevelDebug},
+		{"dev", slog.LevelDebug},
+		{"staging", slog.LevelInfo},
+		{"test", slog.LevelInfo},
+		{"production", slog.LevelWarn},
+		{"PROD", slog.LevelWarn},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseLogLevel(defaultLogLevel(tt.env)))
+		})
+	}
+}
+
+func TestLoadConfig_LogLevelByEnvironment(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      string
+		logLevel string
+		expected slog.Level
+	}{
+		{"development unset", "development", "", slog.LevelDebug},
+		{"staging unset", "staging", "", slog.LevelInfo},
+		{"development explicit", "development", "ERROR", slog.LevelError},
+		{"staging explicit", "staging", "DEBUG", slog.LevelDebug},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ENVIRONMENT", tt.env)
+			t.Setenv("LOG_LEVEL", tt.logLevel)
+			t.Setenv("API_PORT", "3030")
+
+			cfg, err := LoadConfig("")
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, cfg.Environment.LogLevel)
+		})
+	}
+}
+
+func TestLoadConfig_TestEnvironment(t *testing.T) {
+	// minimalEnv leaves the database unset and turns features on without their credentials
+	minimalEnv := func(t *testing.T, env string) {
+		t.Setenv("ENVIRONMENT", env)
+		for _, key := range []string{
+			"API_HOST", "API_PORT", "LOG_LEVEL", "DEBUG", "CONFIG_VERSION", EnvPostgresURL, EnvPostgresHost,
+			EnvPostgresPort, EnvPostgresUser, EnvPostgresPassword, EnvPostgresDB, EnvPostgresSSL,
+		} {
+			t.Setenv(key, "")
+		}
+		t.Setenv(EnvStripeEnabled, "true")
+		t.Setenv(EnvSlackEnabled, "true")
+		t.Setenv(EnvJWTEnabled, "true")
+	}
+
+	t.Run("test environment loads with a minimal environment", func(t *testing.T) {
+		minimalEnv(t, "test")
+		cfg, err := LoadConfig(t.TempDir() + "/missing.env")
+		require.NoError(t, err)
+		assert.True(t, cfg.IsTest())
+		assert.Equal(t, DefaultTestDBName, cfg.Database.DBName, "tests get their own database by default")
+		assert.Equal(t, DefaultDBHost, cfg.Database.Host)
+	})
+
+	t.Run("test environment still checks what is set", func(t *testing.T) {
+		minimalEnv(t, "test")
+		t.Setenv(EnvSlackWebhookURL, "not a url")
+		_, err := LoadConfig("")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrInvalidSlackConfig)
+
+		t.Setenv(EnvSlackWebhookURL, "")
+		t.Setenv("API_PORT", "70000")
+		_, err = LoadConfig("")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrPortOutOfRange)
+	})
+
+	t.Run("development still requires feature settings and the env file", func(t *testing.T) {
+		minimalEnv(t, "development")
+		_, err := LoadConfig("")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrMissingFeatureSetting)
+
+		_, err = LoadConfig(t.TempDir() + "/missing.env")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrEnvFileNotFound)
+	})
+
+	t.Run("production still enforces required variables", func(t *testing.T) {
+		minimalEnv(t, "production")
+		_, err := LoadConfig("")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrMissingRequiredEnvVar)
+		assert.Contains(t, err.Error(), ErrMissingFeatureSetting)
+	})
+}
+
 func TestConfigEnvironmentMethods(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -278,6 +744,26 @@ func TestConfigEnvironmentMethods(t *testing.T) {
 	}
 }
 
+func TestShowErrorDetails(t *testing.T) {
+	tests := []struct {
+		env     string
+		enabled bool
+		want    bool
+	}{
+		{"development", true, true},
+		{"dev", true, true},
+		{"development", false, false},
+		{"production", true, false},
+		{"staging", true, false},
+		{"test", true, false},
+	}
+
+	for _, tt := range tests {
+		cfg := &Config{Environment: EnvironmentConfig{Environment: tt.env, DevErrorDetails: tt.enabled}}
+		assert.Equal(t, tt.want, cfg.ShowErrorDetails(), "env=%s enabled=%v", tt.env, tt.enabled)
+	}
+}
+
 func TestDatabaseURL(t *testing.T) {
 	t.Run("with POSTGRES_URL", func(t *testing.T) {
 		cfg := &Config{
@@ -332,10 +818,21 @@ func TestGetMethods(t *testing.T) {
 	assert.Equal(t, slog.LevelError, cfg.GetLogLevel())
 }
 
+// newValidHTTPConfig returns an HTTP configuration that passes validation
+func newValidHTTPConfig() HTTPConfig {
+	return HTTPConfig{
+		Port:       "8080",
+		HealthPath: DefaultHealthPath,
+		LivePath:   DefaultLivePath,
+		ReadyPath:  DefaultReadyPath,
+		TimeFormat: DefaultTimeFormat,
+	}
+}
+
 func TestConfigValidation(t *testing.T) {
 	t.Run("valid config", func(t *testing.T) {
 		cfg := &Config{
-			HTTP: HTTPConfig{Port: "8080"},
+			HTTP: newValidHTTPConfig(),
 			Database: DatabaseConfig{
 				Host:   "localhost",
 				Port:   "5432",
@@ -349,7 +846,7 @@ func TestConfigValidation(t *testing.T) {
 
 	t.Run("missing POSTGRES_HOST", func(t *testing.T) {
 		cfg := &Config{
-			HTTP: HTTPConfig{Port: "8080"},
+			HTTP: newValidHTTPConfig(),
 			Database: DatabaseConfig{
 				Port:   "5432",
 				User:   "postgres",
@@ -364,7 +861,7 @@ func TestConfigValidation(t *testing.T) {
 
 	t.Run("missing POSTGRES_USER", func(t *testing.T) {
 		cfg := &Config{
-			HTTP: HTTPConfig{Port: "8080"},
+			HTTP: newValidHTTPConfig(),
 			Database: DatabaseConfig{
 				Host:   "localhost",
 				Port:   "5432",
@@ -379,7 +876,7 @@ func TestConfigValidation(t *testing.T) {
 
 	t.Run("missing POSTGRES_DB", func(t *testing.T) {
 		cfg := &Config{
-			HTTP: HTTPConfig{Port: "8080"},
+			HTTP: newValidHTTPConfig(),
 			Database: DatabaseConfig{
 				Host: "localhost",
 				Port: "5432",
@@ -394,7 +891,7 @@ func TestConfigValidation(t *testing.T) {
 
 	t.Run("invalid environment", func(t *testing.T) {
 		cfg := &Config{
-			HTTP: HTTPConfig{Port: "8080"},
+			HTTP: newValidHTTPConfig(),
 			Database: DatabaseConfig{
 				Host:   "localhost",
 				Port:   "5432",
@@ -407,4 +904,207 @@ func TestConfigValidation(t *testing.T) {
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "invalid environment")
 	})
+
+	t.Run("relative endpoint path", func(t *testing.T) {
+		cfg := &Config{
+			HTTP: newValidHTTPConfig(),
+			Database: DatabaseConfig{
+				Host:   "localhost",
+				Port:   "5432",
+				User:   "postgres",
+				DBName: "testdb",
+			},
+			Environment: EnvironmentConfig{Environment: "development"},
+		}
+		cfg.HTTP.HealthPath = "healthz"
+		err := cfg.validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), ErrInvalidEndpointPath)
+	})
+
+	t.Run("duplicate endpoint paths", func(t *testing.T) {
+		cfg := &Config{
+			HTTP: newValidHTTPConfig(),
+			Database: DatabaseConfig{
+				Host:   "localhost",
+				Port:   "5432",
+				User:   "postgres",
+				DBName: "testdb",
+			},
+			Environment: EnvironmentConfig{Environment: "development"},
+		}
+		cfg.HTTP.LivePath = cfg.HTTP.ReadyPath
+		err := cfg.validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), ErrDuplicateEndpointPath)
+	})
+
+	t.Run("invalid time format", func(t *testing.T) {
+		cfg := &Config{
+			HTTP: newValidHTTPConfig(),
+			Database: DatabaseConfig{
+				Host:   "localhost",
+				Port:   "5432",
+				User:   "postgres",
+				DBName: "testdb",
+			},
+			Environment: EnvironmentConfig{Environment: "development"},
+		}
+		cfg.HTTP.TimeFormat = "iso8601"
+		err := cfg.validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), ErrInvalidTimeFormat)
+	})
+}
+
+func TestConfigValidation_FeatureSettings(t *testing.T) {
+	validConfig := func() *Config {
+		return &Config{
+			HTTP: newValidHTTPConfig(),
+			Database: DatabaseConfig{
+				Host:   "localhost",
+				Port:   "5432",
+				User:   "postgres",
+				DBName: "testdb",
+			},
+			Environment: EnvironmentConfig{Environment: "development"},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		configure func(cfg *Config)
+		// wantErr is the variable the error must name; empty means the config is valid
+		wantErr string
+	}{
+		{
+			name: "features off need nothing",
+		},
+		{
+			name: "stripe enabled without secret",
+			configure: func(cfg *Config) {
+				cfg.Stripe = StripeConfig{Enabled: true, ProviderID: "6f1c2d3e-4b5a-6978-8a9b-0c1d2e3f4a5b"}
+			},
+			wantErr: EnvStripeSecret,
+		},
+		{
+			name: "stripe enabled without provider",
+			configure: func(cfg *Config) {
+				cfg.Stripe = StripeConfig{Enabled: true, WebhookSecret: "whsec_test"}
+			},
+			wantErr: EnvStripeProviderID,
+		},
+		{
+			name: "stripe enabled and configured",
+			configure: func(cfg *Config) {
+				cfg.Stripe = StripeConfig{Enabled: true, WebhookSecret: "whsec_test", ProviderID: "6f1c2d3e-4b5a-6978-8a9b-0c1d2e3f4a5b"}
+			},
+		},
+		{
+			name: "slack enabled without webhook URL",
+			configure: func(cfg *Config) {
+				cfg.Notifier.SlackEnabled = true
+			},
+			wantErr: EnvSlackWebhookURL,
+		},
+		{
+			name: "slack enabled with a relative webhook URL",
+			configure: func(cfg *Config) {
+				cfg.Notifier.SlackEnabled = true
+				cfg.Notifier.SlackWebhookURL = "hooks.slack.com/services/T000"
+			},
+			wantErr: EnvSlackWebhookURL,
+		},
+		{
+			name: "slack enabled and configured",
+			configure: func(cfg *Config) {
+				cfg.Notifier.SlackEnabled = true
+				cfg.Notifier.SlackWebhookURL = "https://hooks.slack.com/services/T000/B000/XXXX"
+			},
+		},
+		{
+			name: "smtp host without a sender",
+			configure: func(cfg *Config) {
+				cfg.Notifier.SMTPHost = "smtp.example.com"
+				cfg.Notifier.SMTPPort = "587"
+			},
+			wantErr: EnvSMTPFrom,
+		},
+		{
+			name: "smtp user without a password",
+			configure: func(cfg *Config) {
+				cfg.Notifier.SMTPHost = "smtp.example.com"
+				cfg.Notifier.SMTPPort = "587"
+				cfg.Notifier.SMTPFrom = "alerts@example.com"
+				cfg.Notifier.SMTPUser = "alerts@example.com"
+			},
+			wantErr: EnvSMTPPassword,
+		},
+		{
+			name: "smtp port out of range",
+			configure: func(cfg *Config) {
+				cfg.Notifier.SMTPHost = "smtp.example.com"
+				cfg.Notifier.SMTPPort = "70000"
+				cfg.Notifier.SMTPFrom = "alerts@example.com"
+			},
+			wantErr: EnvSMTPPort,
+		},
+		{
+			name: "smtp configured",
+			configure: func(cfg *Config) {
+				cfg.Notifier.SMTPHost = "smtp.example.com"
+				cfg.Notifier.SMTPPort = "587"
+				cfg.Notifier.SMTPFrom = "Alerts <alerts@example.com>"
+				cfg.Notifier.SMTPUser = "alerts@example.com"
+				cfg.Notifier.SMTPPassword = "secret"
+			},
+		},
+		{
+			name: "jwt enabled without secret",
+			configure: func(cfg *Config) {
+				cfg.Auth.JWTEnabled = true
+			},
+			wantErr: EnvJWTSecret,
+		},
+		{
+			name: "jwt enabled and configured",
+			configure: func(cfg *Config) {
+				cfg.Auth = AuthConfig{JWTEnabled: true, JWTSecret: "secret"}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			if tt.configure != nil {
+				tt.configure(cfg)
+			}
+			err := cfg.validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestConfigRedacted(t *testing.T) {
+	cfg := &Config{Database: DatabaseConfig{
+		URL:        "postgresql://app:secret@db:5432/rld?sslmode=require",
+		ReplicaURL: "postgresql://replica:5432/rld?user=reader&password=secret",
+		Password:   "secret",
+	}}
+
+	redacted := cfg.Redacted()
+	assert.Equal(t, RedactedValue, redacted.Database.Password)
+	assert.Equal(t, "postgresql://app:xxxxx@db:5432/rld?sslmode=require", redacted.Database.URL)
+	assert.NotContains(t, redacted.Database.ReplicaURL, "secret")
+	assert.Contains(t, redacted.Database.ReplicaURL, "user=reader")
+	assert.Equal(t, "secret", cfg.Database.Password, "the original configuration must be left alone")
+
+	assert.Equal(t, RedactedValue, redactURL("postgresql://app:se%cret@db"), "an unparsable URL is withheld")
+	assert.Empty(t, redactURL(""))
 }
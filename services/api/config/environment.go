@@ -1,9 +1,16 @@
 package config
 
 import (
+	"fmt"
 	"log/slog"
+	"math"
+	"net/url"
 	"os"
+	"rdl-api/internal/domain/models"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // isProductionEnvironment checks if the given environment is production
@@ -12,6 +19,11 @@ func isProductionEnvironment(env string) bool {
 	return env == "production" || env == "prod"
 }
 
+// isTestEnvironment checks if the given environment is test
+func isTestEnvironment(env string) bool {
+	return strings.ToLower(env) == "test"
+}
+
 // getEnvValue gets an environment variable with different behavior based on environment
 // In production: requires the environment variable to be set, returns error if not found
 // In non-production: falls back to default value if not set
@@ -32,6 +44,216 @@ func getEnvValue(key string, isProduction bool, defaultValue string) string {
 	return defaultValue
 }
 
+// getOptionalEnvValue gets an optional environment variable, falling back to the default
+// value in every environment since optional settings are not required in production
+func getOptionalEnvValue(key string, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// parseNonNegativeInt parses an integer setting and rejects negative values
+func parseNonNegativeInt(key string, value string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %s=%q", ErrInvalidIntValue, key, value)
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("%s: %s=%d", ErrNegativeValue, key, n)
+	}
+	return n, nil
+}
+
+// parsePositiveInt parses an integer setting and rejects zero or negative values
+func parsePositiveInt(key string, value string) (int, error) {
+	n, err := parseNonNegativeInt(key, value)
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("%s: %s=%d", ErrNonPositiveValue, key, n)
+	}
+	return n, nil
+}
+
+// parseBool parses a true/false setting, accepting the forms strconv.ParseBool does
+func parseBool(key string, value string) (bool, error) {
+	b, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		return false, fmt.Errorf("%s: %s=%q", ErrInvalidBoolValue, key, value)
+	}
+	return b, nil
+}
+
+// parseList splits a comma-separated setting, trimming spaces and dropping empty entries
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseOrigins parses a comma-separated list of CORS origins. Each entry must be an origin
+// exactly as a browser sends it, scheme://host[:port], or "*" on its own to allow any origin.
+// Entries are lowercased, as origins compare case-insensitively.
+func parseOrigins(key string, value string) ([]string, error) {
+	entries := parseList(value)
+	origins := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry == "*" {
+			if len(entries) > 1 {
+				return nil, fmt.Errorf("%s: %s=%q (\"*\" allows any origin and must be the only entry)", ErrInvalidCORSOrigin, key, value)
+			}
+			return []string{"*"}, nil
+		}
+		if reason := originProblem(entry); reason != "" {
+			return nil, fmt.Errorf("%s: %s entry %q %s", ErrInvalidCORSOrigin, key, entry, reason)
+		}
+		origins = append(origins, strings.ToLower(entry))
+	}
+	if len(origins) == 0 {
+		return nil, fmt.Errorf("%s: %s: %s (use \"*\" to allow any origin)", ErrInvalidCORSOrigin, ErrEmptyList, key)
+	}
+	return origins, nil
+}
+
+// originProblem says why entry is not a scheme://host[:port] origin, or returns "" if it is
+func originProblem(entry string) string {
+	u, err := url.Parse(entry)
+	switch {
+	case err != nil:
+		return fmt.Sprintf("is not a valid URL: %v", err)
+	case u.Scheme == "" || u.Host == "":
+		return "is missing a scheme (must be scheme://host[:port], such as https://app.example.com)"
+	case u.User != nil:
+		return "must not contain user info"
+	case u.Path == "/" && u.RawQuery == "" && u.Fragment == "":
+		return "has a trailing slash, which browsers never send in an origin"
+	case u.Path != "" || u.RawQuery != "" || u.Fragment != "" || strings.HasSuffix(entry, "?") || strings.HasSuffix(entry, "#"):
+		return "must not have a path, query or fragment (must be scheme://host[:port])"
+	case u.Hostname() == "":
+		return "is missing a host (must be scheme://host[:port])"
+	}
+	if port := u.Port(); port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return fmt.Sprintf("has port %q out of range", port)
+		}
+	} else if strings.HasSuffix(u.Host, ":") {
+		return "has an empty port"
+	}
+	return ""
+}
+
+// parsePositiveDuration parses a duration setting such as "30s" and rejects zero or negative values
+func parsePositiveDuration(key string, value string) (time.Duration, error) {
+	d, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%s: %s=%q (must be a positive duration such as 30s)", ErrInvalidDuration, key, value)
+	}
+	return d, nil
+}
+
+// parseBuckets parses comma-separated histogram bucket bounds such as "1s,1m,1h", which must be
+// positive and in ascending order
+func parseBuckets(key string, value string) ([]time.Duration, error) {
+	var bounds []time.Duration
+	for _, entry := range parseList(value) {
+		d, err := time.ParseDuration(entry)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("%s: %s entry %q (must be a positive duration such as 30s)", ErrInvalidBuckets, key, entry)
+		}
+		if len(bounds) > 0 && d <= bounds[len(bounds)-1] {
+			return nil, fmt.Errorf("%s: %s=%q (bounds must be in ascending order)", ErrInvalidBuckets, key, value)
+		}
+		bounds = append(bounds, d)
+	}
+	if len(bounds) == 0 {
+		return nil, fmt.Errorf("%s: %s: %s must list at least one bound", ErrInvalidBuckets, ErrEmptyList, key)
+	}
+	return bounds, nil
+}
+
+// parseEventPipelineStages parses the comma-separated stages events are run through, in order.
+// Each stage may be listed once and persist is required. validate, which checks the event
+// before it is stored, must come before persist; detect, notify and metric work on the stored
+// event and must come after it, and notify announces what detect found so needs it first.
+func parseEventPipelineStages(key string, value string) ([]string, error) {
+	stages := parseList(strings.ToLower(value))
+	persistAt := slices.Index(stages, EventStagePersist)
+	if persistAt < 0 {
+		return nil, fmt.Errorf("%s: %s=%q (must include %s)", ErrInvalidPipelineStage, key, value, EventStagePersist)
+	}
+	for i, stage := range stages {
+		if !slices.Contains(ValidEventPipelineStages, stage) {
+			return nil, fmt.Errorf("%s: %s entry %q (valid: %v)", ErrInvalidPipelineStage, key, stage, ValidEventPipelineStages)
+		}
+		if slices.Index(stages, stage) != i {
+			return nil, fmt.Errorf("%s: %s lists %q twice", ErrInvalidPipelineStage, key, stage)
+		}
+		if (stage == EventStageValidate) != (i < persistAt) {
+			return nil, fmt.Errorf("%s: %s=%q (only %s runs before %s)", ErrInvalidPipelineStage, key, value, EventStageValidate, EventStagePersist)
+		}
+	}
+	if notifyAt := slices.Index(stages, EventStageNotify); notifyAt >= 0 {
+		if detectAt := slices.Index(stages, EventStageDetect); detectAt < 0 || detectAt > notifyAt {
+			return nil, fmt.Errorf("%s: %s=%q (%s must come after %s)", ErrInvalidPipelineStage, key, value, EventStageNotify, EventStageDetect)
+		}
+	}
+	return stages, nil
+}
+
+// parseNonNegativeDuration parses a duration setting such as "168h" where 0 means disabled
+func parseNonNegativeDuration(key string, value string) (time.Duration, error) {
+	d, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("%s: %s=%q (must be 0 or a positive duration such as 168h)", ErrInvalidDuration, key, value)
+	}
+	return d, nil
+}
+
+// parseFactor parses a multiplier setting such as "3" or "2.5" and rejects values of 1 or less
+func parseFactor(key string, value string) (float64, error) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) || f <= 1 {
+		return 0, fmt.Errorf("%s: %s=%q (must be a number greater than 1)", ErrInvalidFactor, key, value)
+	}
+	return f, nil
+}
+
+// parseRate parses a per-second rate such as "50" or "0.5" and rejects negative values
+func parseRate(key string, value string) (float64, error) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) || f < 0 {
+		return 0, fmt.Errorf("%s: %s=%q (must be 0 or a positive number)", ErrInvalidRate, key, value)
+	}
+	return f, nil
+}
+
+// parseMinLeakAmounts parses comma-separated CURRENCY:AMOUNT pairs such as "USD:1.00,JPY:150"
+func parseMinLeakAmounts(key string, value string) (map[string]models.Decimal, error) {
+	amounts := map[string]models.Decimal{}
+	for _, pair := range parseList(value) {
+		currency, raw, ok := strings.Cut(pair, ":")
+		if !ok {
+			return nil, fmt.Errorf("%s: %s=%q (must be CURRENCY:AMOUNT pairs such as USD:1.00)", ErrInvalidMinLeakAmount, key, value)
+		}
+		amount, err := models.ParseDecimal(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s=%q: %w", ErrInvalidMinLeakAmount, key, value, err)
+		}
+		amounts[currency] = amount
+	}
+	normalized, err := models.NormalizeMinLeakAmounts(amounts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s=%q: %w", ErrInvalidMinLeakAmount, key, value, err)
+	}
+	return normalized, nil
+}
+
 // parseLogLevel converts string log level to slog.Level
 func parseLogLevel(level string) slog.Level {
 	switch strings.ToUpper(level) {
@@ -48,12 +270,31 @@ func parseLogLevel(level string) slog.Level {
 	}
 }
 
+// defaultLogLevel returns the log level used when LOG_LEVEL is not set:
+// DEBUG in development, WARN in production and INFO everywhere else
+func defaultLogLevel(env string) string {
+	switch strings.ToLower(env) {
+	case "development", "dev":
+		return DefaultLogLevelDevelopment
+	case "production", "prod":
+		return DefaultLogLevelProduction
+	default:
+		return DefaultLogLevel
+	}
+}
+
 // IsDevelopment returns true if the environment is development
 func (c *Config) IsDevelopment() bool {
 	env := strings.ToLower(c.Environment.Environment)
 	return env == "development" || env == "dev"
 }
 
+// ShowErrorDetails reports whether 500 responses carry the error details: DEV_ERROR_DETAILS
+// is on and the environment is development. It is never true in any other environment.
+func (c *Config) ShowErrorDetails() bool {
+	return c.Environment.DevErrorDetails && c.IsDevelopment()
+}
+
 // IsProduction returns true if the environment is production
 func (c *Config) IsProduction() bool {
 	env := strings.ToLower(c.Environment.Environment)
@@ -3,14 +3,43 @@ package config
 // Error constants for configuration validation and loading
 const (
 	// Validation errors
-	ErrInvalidPort           = "invalid port"
-	ErrPortOutOfRange        = "port out of range"
-	ErrMissingDBHost         = "missing database host"
-	ErrMissingDBUser         = "missing database user"
-	ErrMissingDBName         = "missing database name"
-	ErrInvalidDBURL          = "invalid database URL"
-	ErrInvalidEnvironment    = "invalid environment"
-	ErrMissingRequiredEnvVar = "missing required environment variable"
+	ErrInvalidPort             = "invalid port"
+	ErrPortOutOfRange          = "port out of range"
+	ErrMissingDBHost           = "missing database host"
+	ErrMissingDBUser           = "missing database user"
+	ErrMissingDBName           = "missing database name"
+	ErrInvalidDBURL            = "invalid database URL"
+	ErrInvalidEnvironment      = "invalid environment"
+	ErrMissingRequiredEnvVar   = "missing required environment variable"
+	ErrInvalidIntValue         = "invalid integer value"
+	ErrInvalidBoolValue        = "invalid boolean value"
+	ErrNegativeValue           = "value must not be negative"
+	ErrInvalidEndpointPath     = "endpoint path must be absolute"
+	ErrInvalidDuration         = "invalid duration"
+	ErrInvalidStripeConfig     = "invalid Stripe configuration"
+	ErrInvalidTimeFormat       = "invalid API time format"
+	ErrInvalidListFormat       = "invalid API list format"
+	ErrDuplicateEndpointPath   = "endpoint paths must be distinct"
+	ErrNonPositiveValue        = "value must be positive"
+	ErrEmptyList               = "list must not be empty"
+	ErrInvalidStaleAction      = "invalid stale event action"
+	ErrInvalidFactor           = "invalid factor"
+	ErrInvalidLogFormat        = "invalid log format"
+	ErrInvalidOversizeAction   = "invalid batch oversize action"
+	ErrInvalidMinLeakAmount    = "invalid minimum leak amount"
+	ErrInvalidEnumPolicy       = "invalid unknown enum policy"
+	ErrMissingFeatureSetting   = "missing setting for an enabled feature"
+	ErrInvalidSlackConfig      = "invalid Slack configuration"
+	ErrInvalidSMTPConfig       = "invalid SMTP configuration"
+	ErrInvalidHealthComponent  = "invalid health component"
+	ErrInvalidCORSOrigin       = "invalid CORS allowed origin"
+	ErrInvalidTLSConfig        = "invalid TLS configuration"
+	ErrInvalidRate             = "invalid rate"
+	ErrInvalidIdempotencyStore = "invalid idempotency store"
+	ErrInvalidBuckets          = "invalid histogram buckets"
+
+	// Validation errors of the event pipeline
+	ErrInvalidPipelineStage = "invalid event pipeline stage"
 
 	// Loading errors
 	ErrEnvFileNotFound        = "environment file not found"
@@ -1,9 +1,11 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -17,22 +19,402 @@ func LoadConfig(envFilePath string) (*Config, error) {
 	env := os.Getenv(EnvEnvironment)
 	isProduction := isProductionEnvironment(env)
 
-	// Load environment file if specified AND not in production
+	// Load environment file if specified AND not in production. Tests run with whatever
+	// environment they set up, so a missing file is not an error in the test environment.
 	if envFilePath != "" && !isProduction {
-		if err := loadEnvFile(envFilePath); err != nil {
+		if err := loadEnvFile(envFilePath); err != nil && !(isTestEnvironment(env) && errors.Is(err, os.ErrNotExist)) {
 			return nil, fmt.Errorf("%s: %w", ErrEnvFileLoadFailed, err)
 		}
 	}
 
+	dbName := DefaultDBName
+	if isTestEnvironment(env) {
+		dbName = DefaultTestDBName
+	}
+
+	// An explicit LOG_LEVEL always wins; otherwise the default depends on the environment
+	logLevel := parseLogLevel(getOptionalEnvValue(EnvLogLevel, defaultLogLevel(getEnvValue(EnvEnvironment, isProduction, DefaultEnvironment))))
+
+	logFormat := strings.ToLower(strings.TrimSpace(getOptionalEnvValue(EnvLogFormat, DefaultLogFormat)))
+	if !slices.Contains(ValidLogFormats, logFormat) {
+		return nil, fmt.Errorf("%s: %s: %s=%q (valid: %v)", ErrConfigValidationFailed, ErrInvalidLogFormat, EnvLogFormat, logFormat, ValidLogFormats)
+	}
+	noColor := os.Getenv(EnvNoColor) != ""
+
+	scrubPII, err := parseBool(EnvScrubPII, getOptionalEnvValue(EnvScrubPII, DefaultScrubPII))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+	piiKeys := parseList(getOptionalEnvValue(EnvPIIKeys, DefaultPIIKeys))
+	logExcludePaths := parseList(getOptionalEnvValue(EnvLogExcludePaths, DefaultLogExclude))
+	if scrubPII && len(piiKeys) == 0 {
+		return nil, fmt.Errorf("%s: %s: %s must list at least one key when %s is on", ErrConfigValidationFailed, ErrEmptyList, EnvPIIKeys, EnvScrubPII)
+	}
+
+	logBodies, err := parseBool(EnvLogBodies, getOptionalEnvValue(EnvLogBodies, DefaultLogBodies))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+	logBodyPaths := parseList(getOptionalEnvValue(EnvLogBodyPaths, DefaultLogBodyPath))
+	logBodyMaxBytes, err := parsePositiveInt(EnvLogBodyMaxBytes, getOptionalEnvValue(EnvLogBodyMaxBytes, DefaultLogBodyMax))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	devErrorDetails, err := parseBool(EnvDevErrorDetails, getOptionalEnvValue(EnvDevErrorDetails, DefaultDevErrors))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	exportMaxRows, err := parseNonNegativeInt(EnvExportMaxRows, getOptionalEnvValue(EnvExportMaxRows, DefaultExportMax))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	maxRequestBytes, err := parseNonNegativeInt(EnvMaxRequestBytes, getOptionalEnvValue(EnvMaxRequestBytes, DefaultMaxRequest))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	webhookMaxBytes, err := parseNonNegativeInt(EnvWebhookMaxBytes, getOptionalEnvValue(EnvWebhookMaxBytes, DefaultWebhookMax))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	dbHealthCheckPeriod, err := parsePositiveDuration(EnvPostgresHealthCheckPeriod, getOptionalEnvValue(EnvPostgresHealthCheckPeriod, DefaultDBHealthCheckPeriod))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	dbMaxConnIdleTime, err := parsePositiveDuration(EnvPostgresMaxConnIdleTime, getOptionalEnvValue(EnvPostgresMaxConnIdleTime, DefaultDBMaxConnIdleTime))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	poolAcquireTimeout, err := parseNonNegativeDuration(EnvPoolAcquireTimeout, getOptionalEnvValue(EnvPoolAcquireTimeout, DefaultPoolAcquireTimeout))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	poolAcquireWarnThreshold, err := parseNonNegativeDuration(EnvPoolAcquireWarnThreshold, getOptionalEnvValue(EnvPoolAcquireWarnThreshold, DefaultPoolAcquireWarn))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	maxTxPerTenant, err := parseNonNegativeInt(EnvMaxTxPerTenant, getOptionalEnvValue(EnvMaxTxPerTenant, DefaultMaxTxPerTenant))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	requireMigrated, err := parseBool(EnvStartupRequireMigrated, getOptionalEnvValue(EnvStartupRequireMigrated, DefaultRequireMigrated))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	unknownEnumPolicy := strings.ToLower(strings.TrimSpace(getOptionalEnvValue(EnvUnknownEnumPolicy, DefaultUnknownEnumPolicy)))
+	if !slices.Contains(ValidUnknownEnumPolicies, unknownEnumPolicy) {
+		return nil, fmt.Errorf("%s: %s: %s=%q (valid: %v)", ErrConfigValidationFailed, ErrInvalidEnumPolicy, EnvUnknownEnumPolicy, unknownEnumPolicy, ValidUnknownEnumPolicies)
+	}
+
+	sigtermTimeout, err := parsePositiveDuration(EnvShutdownTimeoutSIGTERM, getOptionalEnvValue(EnvShutdownTimeoutSIGTERM, DefaultShutdownTimeoutSIGTERM))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	sigintTimeout, err := parsePositiveDuration(EnvShutdownTimeoutSIGINT, getOptionalEnvValue(EnvShutdownTimeoutSIGINT, DefaultShutdownTimeoutSIGINT))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	preShutdownDelay, err := parseNonNegativeDuration(EnvPreShutdownDelay, getOptionalEnvValue(EnvPreShutdownDelay, DefaultPreShutdownDelay))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	maxBatchSize, err := parsePositiveInt(EnvMaxBatchSize, getOptionalEnvValue(EnvMaxBatchSize, DefaultMaxBatchSize))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	batchOversizeAction := strings.ToLower(strings.TrimSpace(getOptionalEnvValue(EnvBatchOversizeAction, DefaultBatchOversizeAction)))
+	if !slices.Contains(ValidBatchOversizeActions, batchOversizeAction) {
+		return nil, fmt.Errorf("%s: %s: %s=%q (valid: %v)", ErrConfigValidationFailed, ErrInvalidOversizeAction, EnvBatchOversizeAction, batchOversizeAction, ValidBatchOversizeActions)
+	}
+
+	maxActionAttempts, err := parsePositiveInt(EnvMaxActionAttempts, getOptionalEnvValue(EnvMaxActionAttempts, DefaultMaxActionAttempts))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	actionClaimLease, err := parsePositiveDuration(EnvActionClaimLease, getOptionalEnvValue(EnvActionClaimLease, DefaultActionClaimLease))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	actionExecutorInterval, err := parseNonNegativeDuration(EnvActionExecutorInterval, getOptionalEnvValue(EnvActionExecutorInterval, DefaultActionExecutorInterval))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	correlationKeys := parseList(getOptionalEnvValue(EnvCorrelationKeys, DefaultCorrelationKeys))
+	if len(correlationKeys) == 0 {
+		return nil, fmt.Errorf("%s: %s: %s must list at least one key", ErrConfigValidationFailed, ErrEmptyList, EnvCorrelationKeys)
+	}
+
+	correlationWindow, err := parsePositiveDuration(EnvCorrelationWindow, getOptionalEnvValue(EnvCorrelationWindow, DefaultCorrelationWindow))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	notifierMaxRetries, err := parseNonNegativeInt(EnvNotifierMaxRetries, getOptionalEnvValue(EnvNotifierMaxRetries, DefaultNotifierMaxRetries))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	notifierCircuitThreshold, err := parsePositiveInt(EnvNotifierCircuitThreshold, getOptionalEnvValue(EnvNotifierCircuitThreshold, DefaultNotifierCircuitThreshold))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	notifierCircuitCooldown, err := parsePositiveDuration(EnvNotifierCircuitCooldown, getOptionalEnvValue(EnvNotifierCircuitCooldown, DefaultNotifierCircuitCooldown))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	outboxInterval, err := parsePositiveDuration(EnvOutboxInterval, getOptionalEnvValue(EnvOutboxInterval, DefaultOutboxInterval))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	outboxMaxAttempts, err := parsePositiveInt(EnvOutboxMaxAttempts, getOptionalEnvValue(EnvOutboxMaxAttempts, DefaultOutboxMaxAttempts))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	outboxBaseBackoff, err := parsePositiveDuration(EnvOutboxBaseBackoff, getOptionalEnvValue(EnvOutboxBaseBackoff, DefaultOutboxBaseBackoff))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	outboxMaxBackoff, err := parsePositiveDuration(EnvOutboxMaxBackoff, getOptionalEnvValue(EnvOutboxMaxBackoff, DefaultOutboxMaxBackoff))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	eventMaxAge, err := parseNonNegativeDuration(EnvEventMaxAge, getOptionalEnvValue(EnvEventMaxAge, DefaultEventMaxAge))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	eventStaleAction := strings.ToLower(strings.TrimSpace(getOptionalEnvValue(EnvEventStaleAction, DefaultEventStaleAction)))
+	if !slices.Contains(ValidStaleActions, eventStaleAction) {
+		return nil, fmt.Errorf("%s: %s: %s=%q (valid: %v)", ErrConfigValidationFailed, ErrInvalidStaleAction, EnvEventStaleAction, eventStaleAction, ValidStaleActions)
+	}
+
+	detectionVolumeWindow, err := parsePositiveDuration(EnvDetectionVolumeWindow, getOptionalEnvValue(EnvDetectionVolumeWindow, DefaultDetectionVolumeWindow))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	detectionVolumeBaselineWindows, err := parsePositiveInt(EnvDetectionVolumeBaselineWindows, getOptionalEnvValue(EnvDetectionVolumeBaselineWindows, DefaultDetectionVolumeBaselineWindows))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	detectionVolumeFactor, err := parseFactor(EnvDetectionVolumeFactor, getOptionalEnvValue(EnvDetectionVolumeFactor, DefaultDetectionVolumeFactor))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	detectionFailureRateFactor, err := parseFactor(EnvDetectionFailureRateFactor, getOptionalEnvValue(EnvDetectionFailureRateFactor, DefaultDetectionFailureRateFactor))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	detectionDuplicateWindow, err := parsePositiveDuration(EnvDetectionDuplicateWindow, getOptionalEnvValue(EnvDetectionDuplicateWindow, DefaultDetectionDuplicateWindow))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	detectionDunningWindow, err := parsePositiveDuration(EnvDetectionDunningWindow, getOptionalEnvValue(EnvDetectionDunningWindow, DefaultDetectionDunningWindow))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	detectionMinLeakAmounts, err := parseMinLeakAmounts(EnvDetectionMinLeakAmounts, getOptionalEnvValue(EnvDetectionMinLeakAmounts, DefaultDetectionMinLeakAmounts))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	stripeEnabled, err := parseBool(EnvStripeEnabled, getOptionalEnvValue(EnvStripeEnabled, DefaultFeatureFlag))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	webhookTolerance, err := parseNonNegativeDuration(EnvWebhookTolerance, getOptionalEnvValue(EnvWebhookTolerance, DefaultWebhookTolerance))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	slackEnabled, err := parseBool(EnvSlackEnabled, getOptionalEnvValue(EnvSlackEnabled, DefaultFeatureFlag))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	staleCacheTTL, err := parseNonNegativeDuration(EnvStaleCacheTTL, getOptionalEnvValue(EnvStaleCacheTTL, DefaultStaleTTL))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	staticCacheMaxAge, err := parseNonNegativeDuration(EnvStaticCacheAge, getOptionalEnvValue(EnvStaticCacheAge, DefaultStaticAge))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	responseMaxBytes, err := parseNonNegativeInt(EnvResponseMaxBytes, getOptionalEnvValue(EnvResponseMaxBytes, DefaultRespMax))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	corsOrigins, err := parseOrigins(EnvCORSOrigins, getOptionalEnvValue(EnvCORSOrigins, DefaultCORSOrigins))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	http2Cleartext, err := parseBool(EnvHTTP2Cleartext, getOptionalEnvValue(EnvHTTP2Cleartext, DefaultH2C))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	jwtEnabled, err := parseBool(EnvJWTEnabled, getOptionalEnvValue(EnvJWTEnabled, DefaultFeatureFlag))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	maxLeaksPerRun, err := parseNonNegativeInt(EnvMaxLeaksPerRun, getOptionalEnvValue(EnvMaxLeaksPerRun, DefaultMaxLeaksPerRun))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	detectionInterval, err := parseNonNegativeDuration(EnvDetectionInterval, getOptionalEnvValue(EnvDetectionInterval, DefaultDetectionInterval))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	detectionConcurrency, err := parsePositiveInt(EnvDetectionConcurrency, getOptionalEnvValue(EnvDetectionConcurrency, DefaultDetectionConcurrency))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	eventRetention, err := parseNonNegativeDuration(EnvEventRetention, getOptionalEnvValue(EnvEventRetention, DefaultEventRetention))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	eventPurgeInterval, err := parsePositiveDuration(EnvEventPurgeInterval, getOptionalEnvValue(EnvEventPurgeInterval, DefaultEventPurgeInterval))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	eventPurgeBatchSize, err := parsePositiveInt(EnvEventPurgeBatchSize, getOptionalEnvValue(EnvEventPurgeBatchSize, DefaultEventPurgeBatchSize))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	ingestQueueSize, err := parseNonNegativeInt(EnvIngestQueueSize, getOptionalEnvValue(EnvIngestQueueSize, DefaultIngestQueueSize))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	ingestQueueFlushInterval, err := parsePositiveDuration(EnvIngestQueueFlushInterval, getOptionalEnvValue(EnvIngestQueueFlushInterval, DefaultIngestQueueFlushInterval))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	rateLimitRPS, err := parseRate(EnvRateLimitRPS, getOptionalEnvValue(EnvRateLimitRPS, DefaultRateLimitRPS))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	rateLimitBurst, err := parseNonNegativeInt(EnvRateLimitBurst, getOptionalEnvValue(EnvRateLimitBurst, DefaultRateLimitBurst))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	rateLimitRefreshInterval, err := parsePositiveDuration(EnvRateLimitRefreshInterval, getOptionalEnvValue(EnvRateLimitRefreshInterval, DefaultRateLimitRefreshInterval))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	idempotencyStore := strings.ToLower(strings.TrimSpace(getOptionalEnvValue(EnvIdempotencyStore, DefaultIdempotencyStore)))
+	if !slices.Contains(ValidIdempotencyStores, idempotencyStore) {
+		return nil, fmt.Errorf("%s: %s: %s=%q (valid: %v)", ErrConfigValidationFailed, ErrInvalidIdempotencyStore, EnvIdempotencyStore, idempotencyStore, ValidIdempotencyStores)
+	}
+
+	idempotencyTTL, err := parsePositiveDuration(EnvIdempotencyTTL, getOptionalEnvValue(EnvIdempotencyTTL, DefaultIdempotencyTTL))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	idempotencyMaxKeys, err := parsePositiveInt(EnvIdempotencyMaxKeys, getOptionalEnvValue(EnvIdempotencyMaxKeys, DefaultIdempotencyMaxKeys))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	ingestionLagBuckets, err := parseBuckets(EnvIngestionLagBuckets, getOptionalEnvValue(EnvIngestionLagBuckets, DefaultIngestionLagBuckets))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	healthCriticalComponents := parseList(strings.ToLower(getOptionalEnvValue(EnvHealthCriticalComponents, DefaultHealthCriticalComponents)))
+	if len(healthCriticalComponents) == 0 {
+		return nil, fmt.Errorf("%s: %s: %s must list at least one component", ErrConfigValidationFailed, ErrEmptyList, EnvHealthCriticalComponents)
+	}
+	for _, component := range healthCriticalComponents {
+		if !slices.Contains(ValidHealthComponents, component) {
+			return nil, fmt.Errorf("%s: %s: %s=%q (valid: %v)", ErrConfigValidationFailed, ErrInvalidHealthComponent, EnvHealthCriticalComponents, component, ValidHealthComponents)
+		}
+	}
+
+	healthReadyWhenDegraded, err := parseBool(EnvHealthReadyWhenDegraded, getOptionalEnvValue(EnvHealthReadyWhenDegraded, DefaultHealthReadyWhenDegraded))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	eventPipelineStages, err := parseEventPipelineStages(EnvEventPipelineStages, getOptionalEnvValue(EnvEventPipelineStages, DefaultEventPipelineStages))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfigValidationFailed, err)
+	}
+
+	listFormat := strings.ToLower(strings.TrimSpace(getOptionalEnvValue(EnvAPIListFormat, DefaultListFormat)))
+	if !slices.Contains(ValidListFormats, listFormat) {
+		return nil, fmt.Errorf("%s: %s: %s=%q (valid: %v)", ErrConfigValidationFailed, ErrInvalidListFormat, EnvAPIListFormat, listFormat, ValidListFormats)
+	}
+
 	config := &Config{
 		HTTP: HTTPConfig{
 			Host: getEnvValue(EnvAPIHost, isProduction, DefaultAPIHost),
 			Port: getEnvValue(EnvAPIPort, isProduction, DefaultAPIPort),
 			// TODO: Should be loaded from config
-			ReadTimeout:       time.Duration(15),
-			ReadHeaderTimeout: 5,
-			WriteTimeout:      15,
-			IdleTimeout:       60,
+			ReadTimeout:        time.Duration(15),
+			ReadHeaderTimeout:  5,
+			WriteTimeout:       15,
+			IdleTimeout:        60,
+			HealthPath:         getOptionalEnvValue(EnvHealthPath, DefaultHealthPath),
+			LivePath:           getOptionalEnvValue(EnvLivePath, DefaultLivePath),
+			ReadyPath:          getOptionalEnvValue(EnvReadyPath, DefaultReadyPath),
+			MaxRequestBytes:    int64(maxRequestBytes),
+			WebhookMaxBytes:    int64(webhookMaxBytes),
+			TimeFormat:         strings.ToLower(getOptionalEnvValue(EnvAPITimeFormat, DefaultTimeFormat)),
+			ListFormat:         listFormat,
+			AdminAPIKeys:       parseList(getOptionalEnvValue(EnvAdminAPIKeys, DefaultAdminKeys)),
+			StaleCacheTTL:      staleCacheTTL,
+			StaticCacheMaxAge:  staticCacheMaxAge,
+			ResponseMaxBytes:   int64(responseMaxBytes),
+			CORSAllowedOrigins: corsOrigins,
+			TLSCertFile:        getOptionalEnvValue(EnvTLSCertFile, DefaultTLSFile),
+			TLSKeyFile:         getOptionalEnvValue(EnvTLSKeyFile, DefaultTLSFile),
+			HTTP2Cleartext:     http2Cleartext,
 		},
 		Database: DatabaseConfig{
 			URL:      os.Getenv(EnvPostgresURL),
@@ -40,27 +422,148 @@ func LoadConfig(envFilePath string) (*Config, error) {
 			Port:     getEnvValue(EnvPostgresPort, isProduction, DefaultDBPort),
 			User:     getEnvValue(EnvPostgresUser, isProduction, DefaultDBUser),
 			Password: getEnvValue(EnvPostgresPassword, isProduction, DefaultDBPassword),
-			DBName:   getEnvValue(EnvPostgresDB, isProduction, DefaultDBName),
+			DBName:   getEnvValue(EnvPostgresDB, isProduction, dbName),
 			SSLMode:  getEnvValue(EnvPostgresSSL, isProduction, DefaultSSLMode),
+
+			HealthCheckPeriod:    dbHealthCheckPeriod,
+			MaxConnIdleTime:      dbMaxConnIdleTime,
+			AcquireTimeout:       poolAcquireTimeout,
+			AcquireWarnThreshold: poolAcquireWarnThreshold,
+			MaxTxPerTenant:       maxTxPerTenant,
+			ReplicaURL:           os.Getenv(EnvPostgresReplicaURL),
+			ApplicationName:      getOptionalEnvValue(EnvPostgresApplicationName, DefaultDBApplicationName),
+			SearchPath:           parseList(getOptionalEnvValue(EnvPostgresSearchPath, DefaultDBSearchPath)),
+			RequireMigrated:      requireMigrated,
+			UnknownEnumPolicy:    unknownEnumPolicy,
 		},
 		Environment: func() EnvironmentConfig {
 			debugStr := getEnvValue(EnvDebug, isProduction, DefaultDebug)
 			debugVal, err := strconv.ParseBool(strings.ToLower(debugStr))
 			if err != nil {
 				return EnvironmentConfig{
-					Environment: getEnvValue(EnvEnvironment, isProduction, DefaultEnvironment),
-					Debug:       false,
-					LogLevel:    parseLogLevel(getEnvValue(EnvLogLevel, isProduction, DefaultLogLevel)),
-					ConfigVer:   getEnvValue(EnvConfigVer, isProduction, DefaultConfigVer),
+					Environment:     getEnvValue(EnvEnvironment, isProduction, DefaultEnvironment),
+					Debug:           false,
+					LogLevel:        logLevel,
+					LogFormat:       logFormat,
+					NoColor:         noColor,
+					ScrubPII:        scrubPII,
+					PIIKeys:         piiKeys,
+					LogExcludePaths: logExcludePaths,
+					LogBodies:       logBodies,
+					LogBodyPaths:    logBodyPaths,
+					LogBodyMaxBytes: logBodyMaxBytes,
+					DevErrorDetails: devErrorDetails,
+					ConfigVer:       getEnvValue(EnvConfigVer, isProduction, DefaultConfigVer),
 				}
 			}
 			return EnvironmentConfig{
-				Environment: getEnvValue(EnvEnvironment, isProduction, DefaultEnvironment),
-				Debug:       debugVal,
-				LogLevel:    parseLogLevel(getEnvValue(EnvLogLevel, isProduction, DefaultLogLevel)),
-				ConfigVer:   getEnvValue(EnvConfigVer, isProduction, DefaultConfigVer),
+				Environment:     getEnvValue(EnvEnvironment, isProduction, DefaultEnvironment),
+				Debug:           debugVal,
+				LogLevel:        logLevel,
+				LogFormat:       logFormat,
+				NoColor:         noColor,
+				ScrubPII:        scrubPII,
+				PIIKeys:         piiKeys,
+				LogExcludePaths: logExcludePaths,
+				LogBodies:       logBodies,
+				LogBodyPaths:    logBodyPaths,
+				LogBodyMaxBytes: logBodyMaxBytes,
+				DevErrorDetails: devErrorDetails,
+				ConfigVer:       getEnvValue(EnvConfigVer, isProduction, DefaultConfigVer),
 			}
 		}(),
+		Stripe: StripeConfig{
+			Enabled:          stripeEnabled,
+			WebhookSecret:    os.Getenv(EnvStripeSecret),
+			ProviderID:       os.Getenv(EnvStripeProviderID),
+			WebhookTolerance: webhookTolerance,
+		},
+		Shutdown: ShutdownConfig{
+			SIGTERMTimeout:   sigtermTimeout,
+			SIGINTTimeout:    sigintTimeout,
+			PreShutdownDelay: preShutdownDelay,
+		},
+		Export: ExportConfig{
+			MaxRows: exportMaxRows,
+		},
+		Batch: BatchConfig{
+			MaxSize:        maxBatchSize,
+			OversizeAction: batchOversizeAction,
+		},
+		Actions: ActionsConfig{
+			MaxAttempts:      maxActionAttempts,
+			ClaimLease:       actionClaimLease,
+			ExecutorInterval: actionExecutorInterval,
+		},
+		Correlation: CorrelationConfig{
+			Keys:   correlationKeys,
+			Window: correlationWindow,
+		},
+		Notifier: NotifierConfig{
+			MaxRetries:        notifierMaxRetries,
+			CircuitThreshold:  notifierCircuitThreshold,
+			CircuitCooldown:   notifierCircuitCooldown,
+			OutboxInterval:    outboxInterval,
+			OutboxMaxAttempts: outboxMaxAttempts,
+			OutboxBaseBackoff: outboxBaseBackoff,
+			OutboxMaxBackoff:  outboxMaxBackoff,
+			SlackEnabled:      slackEnabled,
+			SlackWebhookURL:   os.Getenv(EnvSlackWebhookURL),
+			SMTPHost:          os.Getenv(EnvSMTPHost),
+			SMTPPort:          getOptionalEnvValue(EnvSMTPPort, DefaultSMTPPort),
+			SMTPUser:          os.Getenv(EnvSMTPUser),
+			SMTPPassword:      os.Getenv(EnvSMTPPassword),
+			SMTPFrom:          os.Getenv(EnvSMTPFrom),
+		},
+		Auth: AuthConfig{
+			JWTEnabled: jwtEnabled,
+			JWTSecret:  os.Getenv(EnvJWTSecret),
+		},
+		EventAge: EventAgeConfig{
+			MaxAge:      eventMaxAge,
+			StaleAction: eventStaleAction,
+		},
+		Detection: DetectionConfig{
+			VolumeWindow:          detectionVolumeWindow,
+			VolumeBaselineWindows: detectionVolumeBaselineWindows,
+			VolumeFactor:          detectionVolumeFactor,
+			FailureRateFactor:     detectionFailureRateFactor,
+			DuplicateChargeWindow: detectionDuplicateWindow,
+			DunningWindow:         detectionDunningWindow,
+			MinLeakAmounts:        detectionMinLeakAmounts,
+			MaxLeaksPerRun:        maxLeaksPerRun,
+			Interval:              detectionInterval,
+			Concurrency:           detectionConcurrency,
+		},
+		Retention: RetentionConfig{
+			EventRetention: eventRetention,
+			PurgeInterval:  eventPurgeInterval,
+			PurgeBatchSize: eventPurgeBatchSize,
+		},
+		IngestQueue: IngestQueueConfig{
+			Size:          ingestQueueSize,
+			FlushInterval: ingestQueueFlushInterval,
+		},
+		Health: HealthConfig{
+			CriticalComponents: healthCriticalComponents,
+			ReadyWhenDegraded:  healthReadyWhenDegraded,
+		},
+		EventPipeline: EventPipelineConfig{
+			Stages: eventPipelineStages,
+		},
+		RateLimit: RateLimitConfig{
+			RPS:             rateLimitRPS,
+			Burst:           rateLimitBurst,
+			RefreshInterval: rateLimitRefreshInterval,
+		},
+		Idempotency: IdempotencyConfig{
+			Store:   idempotencyStore,
+			TTL:     idempotencyTTL,
+			MaxKeys: idempotencyMaxKeys,
+		},
+		Metrics: MetricsConfig{
+			IngestionLagBuckets: ingestionLagBuckets,
+		},
 		BuildInfo: BuildInfoConfig{
 			GIT_COMMIT_HASH:       getEnvValue("GIT_COMMIT_HASH", isProduction, "unknown"),
 			GIT_COMMIT_FULL:       getEnvValue("GIT_COMMIT_FULL", isProduction, "unknown"),
@@ -89,7 +592,7 @@ func LoadConfig(envFilePath string) (*Config, error) {
 func loadEnvFile(envFilePath string) error {
 	// Check if file exists
 	if _, err := os.Stat(envFilePath); err != nil {
-		return fmt.Errorf("%s: %s", ErrEnvFileNotFound, envFilePath)
+		return fmt.Errorf("%s: %s: %w", ErrEnvFileNotFound, envFilePath, err)
 	}
 
 	// Load the env file
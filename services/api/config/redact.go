@@ -0,0 +1,39 @@
+package config
+
+import (
+	"net/url"
+)
+
+// RedactedValue replaces a secret in Redacted output
+const RedactedValue = "[REDACTED]"
+
+// Redacted returns a copy of the configuration that is safe to print or log: the database
+// password is replaced with RedactedValue and passwords in database URLs are masked. The other
+// secrets, such as API keys and webhook secrets, are tagged json:"-" and never encode.
+func (c *Config) Redacted() Config {
+	redacted := *c
+	if redacted.Database.Password != "" {
+		redacted.Database.Password = RedactedValue
+	}
+	redacted.Database.URL = redactURL(redacted.Database.URL)
+	redacted.Database.ReplicaURL = redactURL(redacted.Database.ReplicaURL)
+	return redacted
+}
+
+// redactURL masks the password of a connection URL, whether in its user info or a password
+// query parameter, with "xxxxx" as url.URL.Redacted does. A URL that does not parse is
+// replaced entirely, since where its password is can't be told.
+func redactURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return RedactedValue
+	}
+	if query := u.Query(); query.Has("password") {
+		query.Set("password", "xxxxx")
+		u.RawQuery = query.Encode()
+	}
+	return u.Redacted()
+}
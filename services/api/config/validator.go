@@ -1,37 +1,48 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"net/mail"
 	"net/url"
 	"os"
 	"slices"
 	"strconv"
 	"strings"
+
+	"github.com/google/uuid"
 )
 
-// validate ensures all required configuration is present and valid
+// validate ensures all required configuration is present and valid. Every section is checked
+// and all the problems found are returned together, so a bad deploy is fixed in one pass.
 func (c *Config) validate() error {
+	var errs []error
+
 	// Validate required environment variables in production first
 	if err := c.validateRequiredEnvVars(); err != nil {
-		return fmt.Errorf("%s: %w", ErrMissingRequiredEnvVar, err)
-	}
-
-	// Validate HTTP configuration
-	if err := c.validateHTTP(); err != nil {
-		return fmt.Errorf("HTTP config: %w", err)
-	}
-
-	// Validate database configuration
-	if err := c.validateDatabase(); err != nil {
-		return fmt.Errorf("database config: %w", err)
-	}
-
-	// Validate environment configuration
-	if err := c.validateEnvironment(); err != nil {
-		return fmt.Errorf("environment config: %w", err)
+		errs = append(errs, fmt.Errorf("%s: %w", ErrMissingRequiredEnvVar, err))
+	}
+
+	sections := []struct {
+		name     string
+		validate func() error
+	}{
+		{"HTTP config", c.validateHTTP},
+		{"database config", c.validateDatabase},
+		{"environment config", c.validateEnvironment},
+		{"stripe config", c.validateStripe},
+		{"slack config", c.validateSlack},
+		{"smtp config", c.validateSMTP},
+		{"auth config", c.validateAuth},
+	}
+	for _, section := range sections {
+		if err := section.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", section.name, err))
+		}
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
 // validateHTTP validates HTTP server configuration
@@ -39,6 +50,35 @@ func (c *Config) validateHTTP() error {
 	if err := validatePort(c.HTTP.Port); err != nil {
 		return fmt.Errorf("invalid port: %w", err)
 	}
+	if err := validateEndpointPaths(map[string]string{
+		EnvHealthPath: c.HTTP.HealthPath,
+		EnvLivePath:   c.HTTP.LivePath,
+		EnvReadyPath:  c.HTTP.ReadyPath,
+	}); err != nil {
+		return err
+	}
+	if !slices.Contains(ValidTimeFormats, c.HTTP.TimeFormat) {
+		return fmt.Errorf("%s: %s=%q (valid: %v)", ErrInvalidTimeFormat, EnvAPITimeFormat, c.HTTP.TimeFormat, ValidTimeFormats)
+	}
+	if (c.HTTP.TLSCertFile == "") != (c.HTTP.TLSKeyFile == "") {
+		return fmt.Errorf("%s: %s and %s must be set together", ErrInvalidTLSConfig, EnvTLSCertFile, EnvTLSKeyFile)
+	}
+	return nil
+}
+
+// validateEndpointPaths ensures each endpoint path is absolute and no two endpoints share a path
+func validateEndpointPaths(paths map[string]string) error {
+	seen := make(map[string]string, len(paths))
+	for _, name := range slices.Sorted(maps.Keys(paths)) {
+		path := paths[name]
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("%s: %s=%q", ErrInvalidEndpointPath, name, path)
+		}
+		if other, exists := seen[path]; exists {
+			return fmt.Errorf("%s: %s and %s are both %q", ErrDuplicateEndpointPath, other, name, path)
+		}
+		seen[path] = name
+	}
 	return nil
 }
 
@@ -81,6 +121,73 @@ func (c *Config) validateEnvironment() error {
 	return fmt.Errorf("%s: %s (valid: %v)", ErrInvalidEnvironment, env, ValidEnvironments)
 }
 
+// validateStripe validates Stripe webhook configuration
+func (c *Config) validateStripe() error {
+	if c.Stripe.Enabled && c.Stripe.WebhookSecret == "" {
+		return c.missingFeatureSetting(EnvStripeSecret, EnvStripeEnabled)
+	}
+	if c.Stripe.Enabled && c.Stripe.ProviderID == "" {
+		return c.missingFeatureSetting(EnvStripeProviderID, EnvStripeEnabled)
+	}
+	if c.Stripe.WebhookSecret == "" {
+		return nil
+	}
+	if _, err := uuid.Parse(c.Stripe.ProviderID); err != nil {
+		return fmt.Errorf("%s: %s must be a valid UUID when %s is set", ErrInvalidStripeConfig, EnvStripeProviderID, EnvStripeSecret)
+	}
+	return nil
+}
+
+// validateSlack validates the Slack notification channel
+func (c *Config) validateSlack() error {
+	if !c.Notifier.SlackEnabled {
+		return nil
+	}
+	if c.Notifier.SlackWebhookURL == "" {
+		return c.missingFeatureSetting(EnvSlackWebhookURL, EnvSlackEnabled)
+	}
+	u, err := url.Parse(c.Notifier.SlackWebhookURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("%s: %s must be an absolute http(s) URL", ErrInvalidSlackConfig, EnvSlackWebhookURL)
+	}
+	return nil
+}
+
+// validateSMTP validates the SMTP server email notifications are sent through
+func (c *Config) validateSMTP() error {
+	if !c.Notifier.EmailEnabled() {
+		return nil
+	}
+	if err := validatePort(c.Notifier.SMTPPort); err != nil {
+		return fmt.Errorf("%s: %s: %w", ErrInvalidSMTPConfig, EnvSMTPPort, err)
+	}
+	if _, err := mail.ParseAddress(c.Notifier.SMTPFrom); err != nil {
+		return fmt.Errorf("%s: %s must be an email address when %s is set", ErrInvalidSMTPConfig, EnvSMTPFrom, EnvSMTPHost)
+	}
+	if (c.Notifier.SMTPUser == "") != (c.Notifier.SMTPPassword == "") {
+		return fmt.Errorf("%s: set both %s and %s, or neither", ErrInvalidSMTPConfig, EnvSMTPUser, EnvSMTPPassword)
+	}
+	return nil
+}
+
+// validateAuth validates request authentication configuration
+func (c *Config) validateAuth() error {
+	if c.Auth.JWTEnabled && c.Auth.JWTSecret == "" {
+		return c.missingFeatureSetting(EnvJWTSecret, EnvJWTEnabled)
+	}
+	return nil
+}
+
+// missingFeatureSetting reports a setting left empty although the feature flag needing it is on.
+// The test environment does not require such settings, so tests can turn a feature on without
+// real credentials; settings that are set are still checked there.
+func (c *Config) missingFeatureSetting(setting, flag string) error {
+	if c.IsTest() {
+		return nil
+	}
+	return fmt.Errorf("%s: %s must be set when %s is true", ErrMissingFeatureSetting, setting, flag)
+}
+
 // validateRequiredEnvVars validates that required environment variables are set in production
 func (c *Config) validateRequiredEnvVars() error {
 	// Only validate in production environment
@@ -92,7 +199,7 @@ func (c *Config) validateRequiredEnvVars() error {
 	requiredVars := []string{
 		EnvAPIHost, EnvAPIPort, EnvPostgresHost, EnvPostgresPort,
 		EnvPostgresUser, EnvPostgresPassword, EnvPostgresDB, EnvPostgresSSL,
-		EnvEnvironment, EnvDebug, EnvLogLevel, EnvConfigVer,
+		EnvEnvironment, EnvDebug, EnvConfigVer,
 	}
 
 	var missing []string
@@ -8,6 +8,7 @@ require (
 	github.com/joho/godotenv v1.5.1
 	github.com/lmittmann/tint v1.1.2
 	github.com/stretchr/testify v1.10.0
+	golang.org/x/net v0.39.0
 )
 
 require (
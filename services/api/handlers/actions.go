@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/domain/services"
+	"rdl-api/internal/metrics"
+	"rdl-api/internal/middleware"
+)
+
+// Page size limits for GET /actions
+const (
+	defaultActionsPageSize = 50
+	maxActionsPageSize     = 1000
+)
+
+// ListActionsHandler returns a handler for GET /actions, which lists the actions taken for all
+// of the tenant's leaks, newest first by default.
+// action_type, status and result accept several values, either repeated or comma-separated;
+// values of one filter are ORed and the filters are ANDed. created_from (inclusive) and
+// created_to (exclusive) bound the creation time and take RFC 3339 or Unix milliseconds.
+// sort is created_at, updated_at or priority, ascending unless prefixed with "-".
+// limit (default 50, max 1000) and offset page through the results.
+func ListActionsHandler(logger *slog.Logger, actionsService services.ActionsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		tenantID, ok := middleware.GetTenantID(r)
+		if !ok {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidTenant, ErrorCodeUnauthorized, middleware.ErrMissingOrInvalidTenantContext, http.StatusUnauthorized)
+			return
+		}
+
+		query := r.URL.Query()
+		filter, err := parseActionFilter(query)
+		if err != nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, err, http.StatusBadRequest)
+			return
+		}
+
+		sort, err := parseActionSort(query.Get("sort"))
+		if err != nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, err, http.StatusBadRequest)
+			return
+		}
+
+		params, err := parsePagination(query, defaultActionsPageSize, maxActionsPageSize)
+		if err != nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, err, http.StatusBadRequest)
+			return
+		}
+
+		page, err := actionsService.GetActionsFiltered(ctx, tenantID, filter, params, sort)
+		if err != nil {
+			logger.Log(ctx, serviceErrorLevel(err), "Failed to list actions", "error", err, "tenant_id", tenantID)
+			WriteServerError(ctx, w, logger, err)
+			return
+		}
+
+		items := make([]ActionResponse, 0, len(page.Items))
+		for _, action := range page.Items {
+			items = append(items, NewActionResponse(action, timeFormatFrom(ctx)))
+		}
+		WriteListResponse(ctx, w, logger, models.NewPaginatedResponse(items, page.TotalCount, page.Limit, page.Offset))
+	}
+}
+
+// parseActionFilter reads the action filters of GET /actions: action_type, status, result,
+// created_from and created_to
+func parseActionFilter(query url.Values) (models.ActionFilter, error) {
+	var filter models.ActionFilter
+	for _, value := range splitQueryValues(query["action_type"]) {
+		actionType := models.ActionTypeEnum(value)
+		if !isValidActionType(actionType) {
+			return models.ActionFilter{}, fmt.Errorf("%w: %q", ErrInvalidActionType, value)
+		}
+		filter.ActionTypes = append(filter.ActionTypes, actionType)
+	}
+	for _, value := range splitQueryValues(query["status"]) {
+		status := models.ActionStatusEnum(value)
+		if !isValidActionStatus(status) {
+			return models.ActionFilter{}, fmt.Errorf("%w: %q", ErrInvalidActionStatus, value)
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+	for _, value := range splitQueryValues(query["result"]) {
+		result := models.ActionResultEnum(value)
+		if !isValidActionResult(result) {
+			return models.ActionFilter{}, fmt.Errorf("%w: %q", ErrInvalidActionResult, value)
+		}
+		filter.Results = append(filter.Results, result)
+	}
+
+	var err error
+	if filter.CreatedFrom, err = parseQueryTime(query, "created_from"); err != nil {
+		return models.ActionFilter{}, err
+	}
+	if filter.CreatedTo, err = parseQueryTime(query, "created_to"); err != nil {
+		return models.ActionFilter{}, err
+	}
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && !filter.CreatedFrom.Before(*filter.CreatedTo) {
+		return models.ActionFilter{}, fmt.Errorf("%w: created_from must be before created_to", ErrInvalidTimeRange)
+	}
+	return filter, nil
+}
+
+// parseActionSort reads the sort parameter: a sort field, descending when prefixed with "-".
+// An empty value is models.DefaultActionSort.
+func parseActionSort(value string) (models.ActionSort, error) {
+	if value == "" {
+		return models.DefaultActionSort, nil
+	}
+	field, descending := strings.CutPrefix(value, "-")
+	sort := models.ActionSort{Field: models.ActionSortField(field), Descending: descending}
+	if !sort.Field.IsValid() {
+		return models.ActionSort{}, fmt.Errorf("%w: %q", ErrInvalidSort, value)
+	}
+	return sort, nil
+}
+
+// isValidActionType reports whether t is a known action type
+func isValidActionType(t models.ActionTypeEnum) bool {
+	switch t {
+	case models.ActionTypeEnumRetryPayment,
+		models.ActionTypeEnumOutreach,
+		models.ActionTypeEnumLinearTask,
+		models.ActionTypeEnumEmail,
+		models.ActionTypeEnumOther:
+		return true
+	}
+	return false
+}
+
+// isValidActionStatus reports whether s is a known action status
+func isValidActionStatus(s models.ActionStatusEnum) bool {
+	switch s {
+	case models.ActionStatusEnumPending,
+		models.ActionStatusEnumApproved,
+		models.ActionStatusEnumModified,
+		models.ActionStatusEnumDenied,
+		models.ActionStatusEnumInProgress,
+		models.ActionStatusEnumFailed:
+		return true
+	}
+	return false
+}
+
+// isValidActionResult reports whether r is a known action result
+func isValidActionResult(r models.ActionResultEnum) bool {
+	switch r {
+	case models.ActionResultEnumSuccess,
+		models.ActionResultEnumFailure,
+		models.ActionResultEnumPending,
+		models.ActionResultEnumOther:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/domain/services"
+	"rdl-api/internal/middleware"
+
+	"github.com/google/uuid"
+)
+
+// testListActionsService records the listing it was asked for; other methods panic via the nil embedded interface
+type testListActionsService struct {
+	services.ActionsService
+	actions []models.Action
+	filter  models.ActionFilter
+	params  models.PaginationParams
+	sort    models.ActionSort
+}
+
+func (s *testListActionsService) GetActionsFiltered(_ context.Context, _ uuid.UUID, filter models.ActionFilter, params models.PaginationParams, sort models.ActionSort) (models.PaginatedResponse[models.Action], error) {
+	s.filter, s.params, s.sort = filter, params, sort
+	return models.NewPaginatedResponse(s.actions, int64(len(s.actions)), params.Limit, params.Offset), nil
+}
+
+func TestListActionsHandler(t *testing.T) {
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	svc := &testListActionsService{actions: []models.Action{
+		{ID: uuid.New(), LeakID: uuid.New(), ActionType: models.ActionTypeEnumEmail, Status: models.ActionStatusEnumApproved, Result: models.ActionResultEnumSuccess},
+	}}
+	logger := newTestLogger()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /actions", ListActionsHandler(logger, svc))
+	handler := middleware.TenantContext(logger, true, nil)(mux)
+
+	list := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/actions?"+query, nil)
+		req.Header.Set("X-Tenant-ID", uuid.New().String())
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("combined filters", func(t *testing.T) {
+		w := list("action_type=email,outreach&status=approved&status=pending&result=success" +
+			"&created_from=" + from.Format(time.RFC3339) + "&created_to=" + to.Format(time.RFC3339) +
+			"&sort=-priority&limit=10&offset=20")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		want := models.ActionFilter{
+			ActionTypes: []models.ActionTypeEnum{models.ActionTypeEnumEmail, models.ActionTypeEnumOutreach},
+			Statuses:    []models.ActionStatusEnum{models.ActionStatusEnumApproved, models.ActionStatusEnumPending},
+			Results:     []models.ActionResultEnum{models.ActionResultEnumSuccess},
+			CreatedFrom: &from,
+			CreatedTo:   &to,
+		}
+		if !reflect.DeepEqual(svc.filter, want) {
+			t.Errorf("expected filter %+v, got %+v", want, svc.filter)
+		}
+		if svc.sort != (models.ActionSort{Field: models.ActionSortPriority, Descending: true}) {
+			t.Errorf("expected priority descending, got %+v", svc.sort)
+		}
+		if svc.params != (models.PaginationParams{Limit: 10, Offset: 20}) {
+			t.Errorf("expected limit 10 offset 20, got %+v", svc.params)
+		}
+
+		var body models.PaginatedResponse[ActionResponse]
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(body.Items) != 1 || body.Items[0].ID != svc.actions[0].ID {
+			t.Errorf("expected the service's action, got %+v", body.Items)
+		}
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		if w := list(""); w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if !reflect.DeepEqual(svc.filter, models.ActionFilter{}) {
+			t.Errorf("expected an empty filter, got %+v", svc.filter)
+		}
+		if svc.sort != models.DefaultActionSort {
+			t.Errorf("expected the default sort, got %+v", svc.sort)
+		}
+		if svc.params.Limit != defaultActionsPageSize {
+			t.Errorf("expected limit %d, got %d", defaultActionsPageSize, svc.params.Limit)
+		}
+	})
+
+	for _, query := range []string{
+		"action_type=call",
+		"status=done",
+		"result=maybe",
+		"sort=amount",
+		"sort=--priority",
+		"created_from=" + to.Format(time.RFC3339) + "&created_to=" + from.Format(time.RFC3339),
+		"limit=0",
+	} {
+		t.Run("rejects "+query, func(t *testing.T) {
+			if w := list(query); w.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// ReadAndRestoreBody reads the full request body, up to maxBytes, and replaces r.Body with a
+// fresh reader over the same bytes so downstream code can read it again. This lets webhook
+// handlers verify a signature over the raw payload and then decode exactly the bytes that were
+// verified. A maxBytes of 0 or less disables the limit.
+//
+// Returns ErrBodyTooLarge if the body exceeds maxBytes.
+func ReadAndRestoreBody(r *http.Request, maxBytes int64) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		r.Body = http.NoBody
+		return []byte{}, nil
+	}
+	defer r.Body.Close()
+
+	reader := io.Reader(r.Body)
+	if maxBytes > 0 {
+		// Read one byte past the limit so an oversized body can be told apart from one that is exactly maxBytes
+		reader = io.LimitReader(r.Body, maxBytes+1)
+	}
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if maxBytes > 0 && int64(len(body)) > maxBytes {
+		return nil, ErrBodyTooLarge
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
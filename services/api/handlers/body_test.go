@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadAndRestoreBody_VerifiableAndReReadable(t *testing.T) {
+	payload := `{"id":"evt_1","type":"charge.failed"}`
+	secret := "whsec_test"
+	timestamp := "1700000000"
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/stripe", strings.NewReader(payload))
+	req.Header.Set(StripeSignatureHeader, signStripePayload([]byte(payload), timestamp, secret))
+
+	body, err := ReadAndRestoreBody(req, 1024)
+	if err != nil {
+		t.Fatalf("ReadAndRestoreBody() error = %v", err)
+	}
+	if string(body) != payload {
+		t.Fatalf("expected body %q, got %q", payload, body)
+	}
+	if err := verifyStripeSignature(body, req.Header.Get(StripeSignatureHeader), secret, 0, time.Now()); err != nil {
+		t.Error("expected signature over the returned bytes to verify")
+	}
+
+	reread, err := io.ReadAll(req.Body)
+	if err != nil {
+		t.Fatalf("re-reading body: %v", err)
+	}
+	if string(reread) != payload {
+		t.Errorf("expected restored body %q, got %q", payload, reread)
+	}
+}
+
+func TestReadAndRestoreBody_Limits(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		maxBytes int64
+		wantErr  error
+	}{
+		{name: "exactly at limit", body: "12345", maxBytes: 5},
+		{name: "over limit", body: "123456", maxBytes: 5, wantErr: ErrBodyTooLarge},
+		{name: "no limit", body: strings.Repeat("x", 4096), maxBytes: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+
+			body, err := ReadAndRestoreBody(req, tt.maxBytes)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr == nil && string(body) != tt.body {
+				t.Errorf("expected body of length %d, got %d", len(tt.body), len(body))
+			}
+		})
+	}
+}
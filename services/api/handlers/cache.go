@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// VersionPath serves the build the service is running
+const VersionPath = "/version"
+
+// SetCacheHeaders sets Cache-Control on w. A maxAge above 0 lets clients and shared caches keep
+// the response for that long; 0 or less forbids storing it at all. WriteJSONResponse applies
+// no-store to every response whose handler did not call SetCacheHeaders first.
+func SetCacheHeaders(w http.ResponseWriter, maxAge time.Duration) {
+	if seconds := int(maxAge.Seconds()); seconds > 0 {
+		w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(seconds))
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"rdl-api/config"
+	"rdl-api/internal/middleware"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSetCacheHeaders(t *testing.T) {
+	for _, tt := range []struct {
+		maxAge time.Duration
+		want   string
+	}{
+		{5 * time.Minute, "public, max-age=300"},
+		{90 * time.Second, "public, max-age=90"},
+		{500 * time.Millisecond, "no-store"},
+		{0, "no-store"},
+		{-time.Second, "no-store"},
+	} {
+		w := httptest.NewRecorder()
+		SetCacheHeaders(w, tt.maxAge)
+		if got := w.Header().Get("Cache-Control"); got != tt.want {
+			t.Errorf("SetCacheHeaders(%s) = %q, want %q", tt.maxAge, got, tt.want)
+		}
+	}
+}
+
+func TestCacheControlPerEndpoint(t *testing.T) {
+	logger := newTestLogger()
+	buildInfo := &config.BuildInfoConfig{GIT_TAG: "v1.2.3"}
+
+	for _, tt := range []struct {
+		name    string
+		handler http.Handler
+		path    string
+		want    string
+	}{
+		{"openapi", OpenAPIHandler(logger, NewOpenAPISpec(newTestOpenAPIOptions()), 10*time.Minute), OpenAPIPath, "public, max-age=600"},
+		{"openapi caching off", OpenAPIHandler(logger, NewOpenAPISpec(newTestOpenAPIOptions()), 0), OpenAPIPath, "no-store"},
+		{"version", VersionHandler(buildInfo, logger, time.Minute), VersionPath, "public, max-age=60"},
+		{"providers", middleware.TenantContext(logger, true, nil)(ListProvidersHandler(logger, &testProvidersService{})), "/providers", "no-store"},
+		{"error", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			WriteServerError(r.Context(), w, logger, errors.New("boom"))
+		}), "/leaks", "no-store"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("X-Tenant-ID", uuid.New().String())
+			tt.handler.ServeHTTP(w, req)
+			if got := w.Header().Get("Cache-Control"); got != tt.want {
+				t.Errorf("expected Cache-Control %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// JSONMode decides what decodeJSON does with object fields the request type doesn't declare
+type JSONMode int
+
+const (
+	// JSONStrict rejects unknown fields, so a misspelled field in a request to our own API fails
+	// instead of being silently ignored. Routes decode strictly unless registered otherwise.
+	JSONStrict JSONMode = iota
+	// JSONLenient accepts unknown fields. Ingestion routes use it for provider payloads, which
+	// carry fields we don't model, and keep those fields in the stored event data.
+	JSONLenient
+)
+
+// jsonModeKey is the context key under which WithJSONMode stores a route's JSONMode
+type jsonModeKey struct{}
+
+// WithJSONMode makes handler decode its request bodies in mode
+func WithJSONMode(mode JSONMode, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), jsonModeKey{}, mode)))
+	})
+}
+
+// jsonModeFromContext returns the JSONMode set by WithJSONMode, or JSONStrict
+func jsonModeFromContext(ctx context.Context) JSONMode {
+	if mode, ok := ctx.Value(jsonModeKey{}).(JSONMode); ok {
+		return mode
+	}
+	return JSONStrict
+}
+
+// decodeJSON decodes one JSON value from body into v in the route's JSONMode. In strict mode
+// a field v doesn't declare fails with ErrUnknownField naming it.
+func decodeJSON(ctx context.Context, body io.Reader, v any) error {
+	decoder := json.NewDecoder(body)
+	if jsonModeFromContext(ctx) == JSONStrict {
+		decoder.DisallowUnknownFields()
+	}
+	err := decoder.Decode(v)
+	if err != nil {
+		// encoding/json has no error type for unknown fields, only this message
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			return fmt.Errorf("%w: %s", ErrUnknownField, field)
+		}
+	}
+	return err
+}
+
+// requestBodyError is the error a client is told about a body decodeJSON refused: the unknown
+// field when there is one, ErrInvalidRequestBody otherwise
+func requestBodyError(err error) error {
+	if errors.Is(err, ErrUnknownField) {
+		return err
+	}
+	return ErrInvalidRequestBody
+}
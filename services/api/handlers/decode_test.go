@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/middleware"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	var req CreateProviderRequest
+	strict := context.Background()
+	lenient := context.WithValue(context.Background(), jsonModeKey{}, JSONLenient)
+
+	err := decodeJSON(strict, strings.NewReader(`{"name":"Acme","colour":"red"}`), &req)
+	if !errors.Is(err, ErrUnknownField) || !strings.Contains(err.Error(), `"colour"`) {
+		t.Errorf("expected strict mode to reject the unknown field by name, got %v", err)
+	}
+	if err := decodeJSON(lenient, strings.NewReader(`{"name":"Acme","colour":"red"}`), &req); err != nil || req.Name != "Acme" {
+		t.Errorf("expected lenient mode to ignore the unknown field, got %+v, %v", req, err)
+	}
+	if err := decodeJSON(strict, strings.NewReader(`{"name":`), &req); err == nil || errors.Is(err, ErrUnknownField) {
+		t.Errorf("expected a syntax error, got %v", err)
+	}
+}
+
+func TestJSONMode_WebhookPreservesUnknownFields(t *testing.T) {
+	// Stripe envelopes carry fields stripeEvent doesn't declare
+	payload := `{"id":"evt_1","object":"event","api_version":"2024-06-20","livemode":false,"type":"invoice.payment_failed","created":1700000000,"data":{"object":{}}}`
+	signature := signStripePayload([]byte(payload), "1700000000", testWebhookSecret)
+	logger := newTestLogger()
+
+	for _, tt := range []struct {
+		name         string
+		mode         JSONMode
+		expectedCode int
+	}{
+		{"lenient route stores the payload", JSONLenient, http.StatusOK},
+		{"strict route rejects it", JSONStrict, http.StatusBadRequest},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			eventsService := newTestEventsService()
+			handler := middleware.TenantContext(logger, true, nil)(WithJSONMode(tt.mode,
+				StripeWebhookHandler(logger, eventsService, testWebhookSecret, 0, uuid.New(), 1024, EventAgePolicy{}, nil)))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, newStripeWebhookRequest(payload, signature))
+			if w.Code != tt.expectedCode {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if tt.mode != JSONLenient {
+				return
+			}
+			stored, ok := eventsService.created["evt_1"]
+			if !ok {
+				t.Fatal("expected the event to be stored")
+			}
+			if data, _ := stored.Data.([]byte); string(data) != payload {
+				t.Errorf("expected the stored data to keep every field, got %q", data)
+			}
+		})
+	}
+}
+
+func TestJSONMode_BatchMergesUnknownFieldsIntoData(t *testing.T) {
+	logger := newTestLogger()
+	post := func(t *testing.T, mode JSONMode, body string) (*testBatchEventsService, *httptest.ResponseRecorder) {
+		t.Helper()
+		svc := &testBatchEventsService{seen: map[string]bool{}}
+		handler := middleware.TenantContext(logger, true, nil)(WithJSONMode(mode,
+			CreateEventsBatchHandler(logger, svc, models.BatchPolicy{MaxSize: 10}, 1<<20)))
+		req := httptest.NewRequest(http.MethodPost, "/events/batch", strings.NewReader(body))
+		req.Header.Set("X-Tenant-ID", uuid.New().String())
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return svc, w
+	}
+	item := `{"provider_id":"` + uuid.NewString() + `","event_type":"payment_failed","event_id":"evt_1","data":{"amount":10,"currency":"usd"},"currency":"eur","customer_email":"a@example.com"}`
+
+	t.Run("lenient route keeps extra fields in data", func(t *testing.T) {
+		svc, w := post(t, JSONLenient, "["+item+"]")
+		if w.Code != http.StatusMultiStatus || len(svc.received) != 1 {
+			t.Fatalf("expected the item to be stored, got %d: %s", w.Code, w.Body.String())
+		}
+		var data map[string]any
+		if err := json.Unmarshal(svc.received[0].Data.(json.RawMessage), &data); err != nil {
+			t.Fatalf("expected object data, got %v", err)
+		}
+		if data["customer_email"] != "a@example.com" || data["amount"] != float64(10) {
+			t.Errorf("expected the extra field alongside the sent data, got %v", data)
+		}
+		if data["currency"] != "usd" {
+			t.Errorf("expected a key already in data to win, got %v", data["currency"])
+		}
+	})
+
+	t.Run("strict route rejects the batch", func(t *testing.T) {
+		svc, w := post(t, JSONStrict, "["+item+"]")
+		if w.Code != http.StatusBadRequest || len(svc.received) != 0 {
+			t.Fatalf("expected 400 and nothing stored, got %d: %s", w.Code, w.Body.String())
+		}
+		if !strings.Contains(w.Body.String(), "item 0") {
+			t.Errorf("expected the error to name the item, got %s", w.Body.String())
+		}
+	})
+}
+
+func TestJSONMode_FirstPartyRouteRejectsUnknownFields(t *testing.T) {
+	handler := CreateProviderHandler(newTestLogger(), &testProvidersService{})
+	req := httptest.NewRequest(http.MethodPost, "/admin/providers", strings.NewReader(`{"name":"Acme","provider_tpye":"stripe"}`))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	var body ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !strings.Contains(body.Error.Message, "provider_tpye") {
+		t.Errorf("expected the error to name the unknown field, got %q", body.Error.Message)
+	}
+}
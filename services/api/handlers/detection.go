@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"rdl-api/internal/detection"
+	"rdl-api/internal/metrics"
+	"rdl-api/internal/middleware"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// Warnings reported in DetectionResponse.Warnings
+const (
+	// WarningDetectionPartial is reported when some rules or stores failed
+	WarningDetectionPartial = "some detection rules failed; results are partial"
+	// WarningDetectionTruncated is reported when the run hit the per-run leak cap
+	WarningDetectionTruncated = "the run stopped at the maximum leaks per run; some candidates were not stored"
+)
+
+// LeakDetector runs leak detection for a tenant
+type LeakDetector interface {
+	DetectLeaks(ctx context.Context, tenantID uuid.UUID, dryRun bool) (detection.Report, error)
+}
+
+// DetectionResponse is the body of POST /detect. Candidates are every leak the rules found at or
+// above the minimum leak amount, and Suppressed the ones below it; Created are the ones stored by
+// this run and Updated the open leaks it found again by their dedup key, both always empty for a
+// dry run.
+type DetectionResponse struct {
+	DryRun     bool                  `json:"dry_run"`
+	Candidates []detection.Candidate `json:"candidates"`
+	Suppressed []detection.Candidate `json:"suppressed,omitempty"`
+	Created    []LeakResponse        `json:"created"`
+	Updated    []LeakResponse        `json:"updated,omitempty"`
+	Truncated  bool                  `json:"truncated,omitempty"`
+	Warnings   []string              `json:"warnings,omitempty"`
+}
+
+// NewDetectionResponse converts a detection report to its API representation
+func NewDetectionResponse(report detection.Report, format TimeFormat) DetectionResponse {
+	resp := DetectionResponse{
+		DryRun:     report.DryRun,
+		Candidates: report.Candidates,
+		Suppressed: report.Suppressed,
+		Created:    make([]LeakResponse, 0, len(report.Created)),
+		Truncated:  report.Truncated,
+	}
+	if resp.Candidates == nil {
+		resp.Candidates = []detection.Candidate{}
+	}
+	for _, leak := range report.Created {
+		resp.Created = append(resp.Created, NewLeakResponse(leak, format))
+	}
+	for _, leak := range report.Updated {
+		resp.Updated = append(resp.Updated, NewLeakResponse(leak, format))
+	}
+	return resp
+}
+
+// DetectLeaksHandler returns a handler for POST /detect, which runs leak detection for the
+// tenant. With ?dry_run=true the candidates are computed and returned but nothing is stored
+// and no notification is sent. Rule failures do not fail the request; what did succeed is
+// returned with a warning. A run that would store leaks while another run is already detecting
+// the tenant's leaks is refused with 409.
+func DetectLeaksHandler(logger *slog.Logger, detector LeakDetector) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		tenantID, ok := middleware.GetTenantID(r)
+		if !ok {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidTenant, ErrorCodeUnauthorized, middleware.ErrMissingOrInvalidTenantContext, http.StatusUnauthorized)
+			return
+		}
+
+		dryRun := false
+		if value := r.URL.Query().Get("dry_run"); value != "" {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, fmt.Errorf("%w: %q", ErrInvalidDryRun, value), http.StatusBadRequest)
+				return
+			}
+			dryRun = parsed
+		}
+
+		report, err := detector.DetectLeaks(ctx, tenantID, dryRun)
+		if errors.Is(err, detection.ErrDetectionInProgress) {
+			WriteJSONError(ctx, w, logger, ErrorCodeConflict, err, http.StatusConflict)
+			return
+		}
+		resp := NewDetectionResponse(report, timeFormatFrom(ctx))
+		if err != nil {
+			logger.WarnContext(ctx, "Leak detection finished with errors", "error", err, "tenant_id", tenantID, "dry_run", dryRun)
+			resp.Warnings = append(resp.Warnings, WarningDetectionPartial)
+		}
+		if report.Truncated {
+			resp.Warnings = append(resp.Warnings, WarningDetectionTruncated)
+		}
+
+		WriteJSONSuccessResponse(ctx, w, logger, resp)
+	}
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"rdl-api/internal/detection"
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/middleware"
+
+	"github.com/google/uuid"
+)
+
+// testLeakDetector records the dry-run flag it was called with and returns a canned report
+type testLeakDetector struct {
+	calls  int
+	dryRun bool
+	report detection.Report
+	err    error
+}
+
+func (d *testLeakDetector) DetectLeaks(_ context.Context, tenantID uuid.UUID, dryRun bool) (detection.Report, error) {
+	d.calls++
+	d.dryRun = dryRun
+	report := d.report
+	report.TenantID = tenantID
+	report.DryRun = dryRun
+	return report, d.err
+}
+
+func newDetectionTestHandler(detector *testLeakDetector) http.Handler {
+	logger := newTestLogger()
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /detect", DetectLeaksHandler(logger, detector))
+	return middleware.TenantContext(logger, true, nil)(mux)
+}
+
+func TestDetectLeaksHandler(t *testing.T) {
+	tenantID := uuid.New()
+	candidate := detection.Candidate{
+		Rule:       detection.VolumeAnomalyRuleName,
+		TenantID:   tenantID,
+		LeakType:   models.LeakTypeEnumVolumeAnomaly,
+		Confidence: 80,
+		Reason:     "event volume spiked",
+	}
+	leak := models.Leak{ID: uuid.New(), TenantID: tenantID, LeakType: models.LeakTypeEnumVolumeAnomaly, Confidence: 80}
+
+	tests := []struct {
+		name        string
+		query       string
+		report      detection.Report
+		err         error
+		wantStatus  int
+		wantCalled  bool
+		wantDryRun  bool
+		wantCreated int
+		wantWarning bool
+	}{
+		{
+			name:        "stores by default",
+			report:      detection.Report{Candidates: []detection.Candidate{candidate}, Created: []models.Leak{leak}},
+			wantStatus:  http.StatusOK,
+			wantCalled:  true,
+			wantCreated: 1,
+		},
+		{
+			name:       "dry run is passed through",
+			query:      "?dry_run=true",
+			report:     detection.Report{Candidates: []detection.Candidate{candidate}},
+			wantStatus: http.StatusOK,
+			wantCalled: true,
+			wantDryRun: true,
+		},
+		{
+			name:       "explicit false stores",
+			query:      "?dry_run=false",
+			wantStatus: http.StatusOK,
+			wantCalled: true,
+		},
+		{
+			name:       "invalid dry run is rejected",
+			query:      "?dry_run=maybe",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "rule failure returns partial results with a warning",
+			report:      detection.Report{Candidates: []detection.Candidate{candidate}, Created: []models.Leak{leak}},
+			err:         errors.New("rule volume_anomaly: boom"),
+			wantStatus:  http.StatusOK,
+			wantCalled:  true,
+			wantCreated: 1,
+			wantWarning: true,
+		},
+		{
+			name:        "truncated run is flagged with a warning",
+			report:      detection.Report{Candidates: []detection.Candidate{candidate, candidate}, Created: []models.Leak{leak}, Truncated: true},
+			wantStatus:  http.StatusOK,
+			wantCalled:  true,
+			wantCreated: 1,
+			wantWarning: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := &testLeakDetector{report: tt.report, err: tt.err}
+			req := httptest.NewRequest(http.MethodPost, "/detect"+tt.query, nil)
+			req.Header.Set("X-Tenant-ID", tenantID.String())
+			rec := httptest.NewRecorder()
+
+			newDetectionTestHandler(detector).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if (detector.calls > 0) != tt.wantCalled {
+				t.Fatalf("expected detector called=%v, got %d calls", tt.wantCalled, detector.calls)
+			}
+			if !tt.wantCalled {
+				return
+			}
+			if detector.dryRun != tt.wantDryRun {
+				t.Errorf("expected dry run %v, got %v", tt.wantDryRun, detector.dryRun)
+			}
+
+			var resp DetectionResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.DryRun != tt.wantDryRun {
+				t.Errorf("expected dry_run %v in response, got %v", tt.wantDryRun, resp.DryRun)
+			}
+			if len(resp.Candidates) != len(tt.report.Candidates) {
+				t.Errorf("expected %d candidates, got %d", len(tt.report.Candidates), len(resp.Candidates))
+			}
+			if len(resp.Created) != tt.wantCreated {
+				t.Errorf("expected %d created leaks, got %d", tt.wantCreated, len(resp.Created))
+			}
+			if resp.Truncated != tt.report.Truncated {
+				t.Errorf("expected truncated %v, got %v", tt.report.Truncated, resp.Truncated)
+			}
+			if hasWarning := len(resp.Warnings) > 0; hasWarning != tt.wantWarning {
+				t.Errorf("expected warning=%v, got %v", tt.wantWarning, resp.Warnings)
+			}
+		})
+	}
+}
+
+func TestDetectLeaksHandler_RunInProgress(t *testing.T) {
+	tenantID := uuid.New()
+	detector := &testLeakDetector{err: detection.ErrDetectionInProgress}
+	req := httptest.NewRequest(http.MethodPost, "/detect", nil)
+	req.Header.Set("X-Tenant-ID", tenantID.String())
+	rec := httptest.NewRecorder()
+
+	newDetectionTestHandler(detector).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusConflict, rec.Code, rec.Body.String())
+	}
+	var body ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode error response: %v", err)
+	}
+	if body.Error.Code != ErrorCodeConflict {
+		t.Errorf("expected error code %q, got %q", ErrorCodeConflict, body.Error.Code)
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"rdl-api/internal/domain/services"
+	"rdl-api/internal/middleware"
+	"strings"
+)
+
+// maxStackFrames is how many frames of a panic's stack are returned when error details are on
+const maxStackFrames = 10
+
+// ErrorDebug is the detail added to a 500 response when error details are on
+type ErrorDebug struct {
+	Error string   `json:"error"`
+	Stack []string `json:"stack,omitempty"`
+}
+
+// writeInternalError writes the generic 500 envelope, with debug attached when the request's
+// ResponseOptions turn error details on
+func writeInternalError(ctx context.Context, w http.ResponseWriter, logger *slog.Logger, debug *ErrorDebug) {
+	response := ErrorResponse{Error: ErrorDetail{Code: ErrorCodeInternal, Message: ErrInternalServerError.Error()}}
+	if responseOptionsFrom(ctx).ErrorDetails {
+		response.Error.Debug = debug
+	}
+	WriteJSONResponse(ctx, w, logger, response, http.StatusInternalServerError)
+}
+
+// PanicResponder answers a request whose handler panicked with the JSON error envelope.
+// With opts.ErrorDetails on, the envelope also carries the panic value and the top of the
+// stack. Recovery runs ahead of WithResponseOptions, so opts is given here rather than read
+// from the request.
+func PanicResponder(logger *slog.Logger, opts ResponseOptions) middleware.PanicResponder {
+	return func(w http.ResponseWriter, r *http.Request, recovered any, stack []byte) {
+		ctx := ContextWithResponseOptions(r.Context(), opts)
+		writeInternalError(ctx, w, logger, &ErrorDebug{
+			Error: fmt.Sprint(recovered),
+			Stack: trimStack(stack, maxStackFrames),
+		})
+	}
+}
+
+// ErrorResponder answers a request a middleware rejected or failed with the JSON error
+// envelope. An exhausted connection pool is reported as overloaded, any other 503 as
+// unavailable and a 500 like WriteServerError does, none of them exposing the cause; a 4xx
+// carries the cause as its message. opts is given for the same reason as in PanicResponder.
+func ErrorResponder(logger *slog.Logger, opts ResponseOptions) middleware.ErrorResponder {
+	return func(w http.ResponseWriter, r *http.Request, err error, status int) {
+		ctx := ContextWithResponseOptions(r.Context(), opts)
+		switch {
+		case errors.Is(err, services.ErrServiceOverloaded):
+			WriteJSONError(ctx, w, logger, ErrorCodeOverloaded, ErrServiceOverloaded, status)
+		case status == http.StatusServiceUnavailable:
+			WriteJSONError(ctx, w, logger, ErrorCodeUnavailable, ErrServiceUnavailable, status)
+		case status >= http.StatusInternalServerError:
+			writeInternalError(ctx, w, logger, &ErrorDebug{Error: err.Error()})
+		default:
+			WriteJSONError(ctx, w, logger, ErrorCodeInvalidRequest, err, status)
+		}
+	}
+}
+
+// trimStack turns a debug.Stack dump into at most max "function file:line" frames, starting
+// at the frame that panicked. The goroutine header and the recovery and runtime panic frames
+// above it are dropped.
+func trimStack(stack []byte, max int) []string {
+	lines := strings.Split(strings.TrimSpace(string(stack)), "\n")
+
+	// Frames come in pairs: the function, then its indented file:line
+	start := 1
+	for i := 1; i+1 < len(lines); i += 2 {
+		if strings.HasPrefix(lines[i], "panic(") {
+			start = i + 2
+			break
+		}
+	}
+
+	var frames []string
+	for i := start; i+1 < len(lines) && len(frames) < max; i += 2 {
+		location := strings.TrimSpace(lines[i+1])
+		if j := strings.LastIndex(location, " +0x"); j >= 0 {
+			location = location[:j]
+		}
+		frames = append(frames, lines[i]+" "+location)
+	}
+	return frames
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"rdl-api/internal/domain/services"
+	"rdl-api/internal/middleware"
+	"strings"
+	"testing"
+)
+
+func decodeErrorResponse(t *testing.T, w *httptest.ResponseRecorder) ErrorResponse {
+	t.Helper()
+	var response ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode error response: %v", err)
+	}
+	return response
+}
+
+func TestWriteServerError_ErrorDetails(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cause := errors.New("relation \"events\" does not exist")
+
+	t.Run("details in development", func(t *testing.T) {
+		ctx := ContextWithResponseOptions(context.Background(), ResponseOptions{ErrorDetails: true})
+		w := httptest.NewRecorder()
+		WriteServerError(ctx, w, logger, cause)
+
+		response := decodeErrorResponse(t, w)
+		if w.Code != http.StatusInternalServerError || response.Error.Code != ErrorCodeInternal {
+			t.Fatalf("expected a 500 internal error, got %d %q", w.Code, response.Error.Code)
+		}
+		if response.Error.Debug == nil || response.Error.Debug.Error != cause.Error() {
+			t.Errorf("expected the cause in the debug details, got %+v", response.Error.Debug)
+		}
+	})
+
+	t.Run("stripped in production", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		WriteServerError(context.Background(), w, logger, cause)
+
+		if body := w.Body.String(); strings.Contains(body, "debug") || strings.Contains(body, "relation") {
+			t.Errorf("expected the generic envelope only, got %s", body)
+		}
+		if response := decodeErrorResponse(t, w); response.Error.Message != ErrInternalServerError.Error() {
+			t.Errorf("expected the generic message, got %q", response.Error.Message)
+		}
+	})
+}
+
+func TestPanicResponder(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	newHandler := func(opts ResponseOptions) http.Handler {
+		return middleware.RecoveryWith(logger, PanicResponder(logger, opts))(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic("nil map write")
+		}))
+	}
+
+	t.Run("details in development", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		newHandler(ResponseOptions{ErrorDetails: true}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+		response := decodeErrorResponse(t, w)
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("expected 500, got %d", w.Code)
+		}
+		debug := response.Error.Debug
+		if debug == nil || debug.Error != "nil map write" {
+			t.Fatalf("expected the panic value in the debug details, got %+v", debug)
+		}
+		if len(debug.Stack) == 0 || len(debug.Stack) > maxStackFrames {
+			t.Fatalf("expected between 1 and %d stack frames, got %d", maxStackFrames, len(debug.Stack))
+		}
+		if !strings.Contains(debug.Stack[0], "TestPanicResponder") {
+			t.Errorf("expected the stack to start at the panicking handler, got %q", debug.Stack[0])
+		}
+	})
+
+	t.Run("stripped in production", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		newHandler(ResponseOptions{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("expected 500, got %d", w.Code)
+		}
+		if body := w.Body.String(); strings.Contains(body, "debug") || strings.Contains(body, "nil map write") {
+			t.Errorf("expected the generic envelope only, got %s", body)
+		}
+	})
+}
+
+func TestErrorResponder(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	respond := ErrorResponder(logger, ResponseOptions{})
+	cause := errors.New("connection refused")
+
+	tests := []struct {
+		name        string
+		err         error
+		status      int
+		wantCode    string
+		wantMessage string
+	}{
+		{"overloaded pool", services.ErrServiceOverloaded, http.StatusServiceUnavailable, ErrorCodeOverloaded, ErrServiceOverloaded.Error()},
+		{"other 503", cause, http.StatusServiceUnavailable, ErrorCodeUnavailable, ErrServiceUnavailable.Error()},
+		{"500 hides the cause", cause, http.StatusInternalServerError, ErrorCodeInternal, ErrInternalServerError.Error()},
+		{"4xx shows the cause", errors.New("bad key"), http.StatusBadRequest, ErrorCodeInvalidRequest, "bad key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			w.Header().Set("Retry-After", "1")
+			respond(w, httptest.NewRequest(http.MethodPost, "/events", nil), tt.err, tt.status)
+
+			response := decodeErrorResponse(t, w)
+			if w.Code != tt.status || response.Error.Code != tt.wantCode || response.Error.Message != tt.wantMessage {
+				t.Errorf("expected %d %q %q, got %d %q %q", tt.status, tt.wantCode, tt.wantMessage, w.Code, response.Error.Code, response.Error.Message)
+			}
+			if got := w.Header().Get("Retry-After"); got != "1" {
+				t.Errorf("expected a Retry-After set by the middleware to be kept, got %q", got)
+			}
+		})
+	}
+}
@@ -3,7 +3,74 @@ package handlers
 import "errors"
 
 var (
-	ErrMethodNotAllowed = errors.New("method not allowed")
-	ErrHealthCheckFailed = errors.New("health check failed")
-	ErrInternalServerError = errors.New("internal server error")
-)
\ No newline at end of file
+	ErrMethodNotAllowed      = errors.New("method not allowed")
+	ErrHealthCheckFailed     = errors.New("health check failed")
+	ErrInternalServerError   = errors.New("internal server error")
+	ErrNotFound              = errors.New("not found")
+	ErrInvalidEventID        = errors.New("invalid event id")
+	ErrInvalidRequestBody    = errors.New("invalid request body")
+	ErrUnknownField          = errors.New("invalid request body: unknown field")
+	ErrNullField             = errors.New("invalid request body: field cannot be null")
+	ErrInvalidEventType      = errors.New("invalid event type")
+	ErrInvalidEventStatus    = errors.New("invalid event status")
+	ErrEventNotFound         = errors.New("event not found")
+	ErrPreconditionFailed    = errors.New("event was modified since the given time")
+	ErrBodyTooLarge          = errors.New("request body too large")
+	ErrInvalidSignature      = errors.New("invalid webhook signature")
+	ErrInvalidLeakID         = errors.New("invalid leak id")
+	ErrLeakNotFound          = errors.New("leak not found")
+	ErrInvalidProviderID     = errors.New("invalid provider id")
+	ErrInvalidPagination     = errors.New("invalid pagination parameters")
+	ErrInvalidDryRun         = errors.New("invalid dry_run value")
+	ErrInvalidCursor         = errors.New("invalid cursor")
+	ErrServiceOverloaded     = errors.New("service is overloaded, retry later")
+	ErrServiceUnavailable    = errors.New("service unavailable, retry later")
+	ErrInvalidLeakStatus     = errors.New("invalid leak status")
+	ErrInvalidLeakType       = errors.New("invalid leak type")
+	ErrInvalidTimeRange      = errors.New("invalid time range")
+	ErrHealthServiceMissing  = errors.New("health service is not configured")
+	ErrBatchTooLarge         = errors.New("batch exceeds the maximum batch size")
+	ErrInvalidTenantID       = errors.New("invalid tenant id")
+	ErrAdminKeyRequired      = errors.New("a valid admin key is required to read another tenant")
+	ErrInvalidDataMode       = errors.New("invalid data_mode, expected merge or replace")
+	ErrRequestCanceled       = errors.New("request canceled by the client")
+	ErrRequestTimeout        = errors.New("request timed out")
+	ErrInvalidSnoozeDuration = errors.New("invalid snooze duration")
+	ErrInvalidIncludeSnoozed = errors.New("invalid include_snoozed value")
+	ErrDatabaseUnavailable   = errors.New("database unavailable, retry later")
+	ErrShuttingDown          = errors.New("shutting down")
+	ErrInvalidExportFormat   = errors.New("invalid export format, expected csv")
+	ErrInvalidBucketBounds   = errors.New("invalid bucket bounds")
+	ErrInvalidActionType     = errors.New("invalid action type")
+	ErrInvalidActionStatus   = errors.New("invalid action status")
+	ErrInvalidActionResult   = errors.New("invalid action result")
+	ErrInvalidSort           = errors.New("invalid sort")
+	ErrReattributionConflict = errors.New("the target provider already has an event with the same event id; no events were moved")
+
+	// ErrTimestampOutsideTolerance is returned for a validly signed webhook whose timestamp is too far from now
+	ErrTimestampOutsideTolerance = errors.New("webhook timestamp outside the allowed tolerance")
+)
+
+// Error codes returned in the JSON error envelope
+const (
+	ErrorCodeNotFound         = "not_found"
+	ErrorCodeMethodNotAllowed = "method_not_allowed"
+	ErrorCodeInvalidRequest   = "invalid_request"
+	ErrorCodeUnauthorized     = "unauthorized"
+	ErrorCodeInternal         = "internal_error"
+	ErrorCodeOverloaded       = "service_overloaded"
+	ErrorCodePreconditionFail = "precondition_failed"
+	ErrorCodeBodyTooLarge     = "body_too_large"
+	ErrorCodeInvalidSignature = "invalid_signature"
+	ErrorCodeEventTooOld      = "event_too_old"
+	ErrorCodeUnknownProvider  = "unknown_provider"
+	ErrorCodeBatchTooLarge    = "batch_too_large"
+	ErrorCodeForbidden        = "forbidden"
+	ErrorCodeCanceled         = "request_canceled"
+	ErrorCodeTimeout          = "timeout"
+	ErrorCodeUnavailable      = "service_unavailable"
+	ErrorCodeConflict         = "conflict"
+
+	// ErrorCodeTimestampOutsideTolerance rejects a webhook that may be a replay, unlike ErrorCodeInvalidSignature
+	ErrorCodeTimestampOutsideTolerance = "timestamp_out_of_tolerance"
+)
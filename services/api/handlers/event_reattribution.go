@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"rdl-api/internal/domain/services"
+	"rdl-api/internal/metrics"
+
+	"github.com/google/uuid"
+)
+
+// ReattributeEventsRequest is the body of POST /admin/events/reattribute
+type ReattributeEventsRequest struct {
+	TenantID       uuid.UUID `json:"tenant_id"`
+	FromProviderID uuid.UUID `json:"from_provider_id"`
+	ToProviderID   uuid.UUID `json:"to_provider_id"`
+}
+
+// ReattributeEventsResponse is the body of a successful POST /admin/events/reattribute
+type ReattributeEventsResponse struct {
+	TenantID       uuid.UUID `json:"tenant_id"`
+	FromProviderID uuid.UUID `json:"from_provider_id"`
+	ToProviderID   uuid.UUID `json:"to_provider_id"`
+	Reattributed   int64     `json:"reattributed"`
+}
+
+// ReattributeEventsHandler returns a handler for POST /admin/events/reattribute, an admin route
+// that moves every event of tenant_id from from_provider_id to to_provider_id, for events
+// ingested under the wrong provider during onboarding. Both providers must belong to the tenant,
+// or the request is rejected with 400. The move is all or nothing: when the target provider
+// already has one of the event IDs, nothing moves and 409 is returned.
+func ReattributeEventsHandler(logger *slog.Logger, eventsService services.EventsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		var req ReattributeEventsRequest
+		if err := decodeJSON(ctx, r.Body, &req); err != nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidJSON, ErrorCodeInvalidRequest, requestBodyError(err), http.StatusBadRequest)
+			return
+		}
+		if req.TenantID == uuid.Nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, fmt.Errorf("%w: tenant_id is required", ErrInvalidTenantID), http.StatusBadRequest)
+			return
+		}
+		if req.FromProviderID == uuid.Nil || req.ToProviderID == uuid.Nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, fmt.Errorf("%w: from_provider_id and to_provider_id are required", ErrInvalidProviderID), http.StatusBadRequest)
+			return
+		}
+
+		count, err := eventsService.ReattributeEvents(ctx, req.TenantID, req.FromProviderID, req.ToProviderID)
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrSameProvider):
+				WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, services.ErrSameProvider, http.StatusBadRequest)
+			case errors.Is(err, services.ErrUnknownProvider):
+				WriteRejection(ctx, w, logger, metrics.ReasonUnknownProvider, ErrorCodeUnknownProvider, err, http.StatusBadRequest)
+			case errors.Is(err, services.ErrEventAlreadyExists):
+				WriteJSONError(ctx, w, logger, ErrorCodeConflict, ErrReattributionConflict, http.StatusConflict)
+			default:
+				logger.Log(ctx, serviceErrorLevel(err), "Failed to re-attribute events", "error", err, "tenant_id", req.TenantID, "from_provider_id", req.FromProviderID, "to_provider_id", req.ToProviderID)
+				WriteServerError(ctx, w, logger, err)
+			}
+			return
+		}
+
+		WriteJSONSuccessResponse(ctx, w, logger, ReattributeEventsResponse{
+			TenantID:       req.TenantID,
+			FromProviderID: req.FromProviderID,
+			ToProviderID:   req.ToProviderID,
+			Reattributed:   count,
+		})
+	}
+}
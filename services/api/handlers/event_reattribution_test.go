@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"rdl-api/internal/domain/services"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// testReattributionService moves a fixed number of events unless err is set; other methods
+// panic via the nil embedded interface
+type testReattributionService struct {
+	services.EventsService
+	moved int64
+	err   error
+	calls int
+}
+
+func (s *testReattributionService) ReattributeEvents(context.Context, uuid.UUID, uuid.UUID, uuid.UUID) (int64, error) {
+	s.calls++
+	return s.moved, s.err
+}
+
+func TestReattributeEventsHandler(t *testing.T) {
+	tenantID, from, to := uuid.New(), uuid.New(), uuid.New()
+	validBody := fmt.Sprintf(`{"tenant_id":%q,"from_provider_id":%q,"to_provider_id":%q}`, tenantID, from, to)
+
+	post := func(svc *testReattributionService, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/events/reattribute", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		ReattributeEventsHandler(newTestLogger(), svc)(w, req)
+		return w
+	}
+
+	t.Run("returns the number of events moved", func(t *testing.T) {
+		w := post(&testReattributionService{moved: 12}, validBody)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var body ReattributeEventsResponse
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if body.Reattributed != 12 || body.TenantID != tenantID || body.FromProviderID != from || body.ToProviderID != to {
+			t.Errorf("unexpected response %+v", body)
+		}
+	})
+
+	for _, tt := range []struct {
+		name   string
+		body   string
+		err    error
+		status int
+	}{
+		{"missing tenant", fmt.Sprintf(`{"from_provider_id":%q,"to_provider_id":%q}`, from, to), nil, http.StatusBadRequest},
+		{"missing provider", fmt.Sprintf(`{"tenant_id":%q,"from_provider_id":%q}`, tenantID, from), nil, http.StatusBadRequest},
+		{"same provider", validBody, services.ErrSameProvider, http.StatusBadRequest},
+		{"provider of another tenant", validBody, fmt.Errorf("%w: %s", services.ErrUnknownProvider, to), http.StatusBadRequest},
+		{"event ID already under the target", validBody, services.ErrEventAlreadyExists, http.StatusConflict},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := post(&testReattributionService{err: tt.err}, tt.body)
+			if w.Code != tt.status {
+				t.Errorf("expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+}
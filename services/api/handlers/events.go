@@ -0,0 +1,620 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"rdl-api/internal/detection"
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/domain/services"
+	"rdl-api/internal/metrics"
+	"rdl-api/internal/middleware"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UpdateEventRequest is the body of PATCH /events/{id}; omitted fields are left unchanged.
+// None of an event's columns can be cleared, so a field sent as null is rejected rather than
+// read as omitted.
+type UpdateEventRequest struct {
+	EventType Optional[models.EventTypeEnum]   `json:"event_type"`
+	Status    Optional[models.EventStatusEnum] `json:"status"`
+	Data      Optional[json.RawMessage]        `json:"data"`
+}
+
+// nullField returns the JSON name of the first field sent as null, or "" when there is none
+func (r UpdateEventRequest) nullField() string {
+	switch {
+	case r.EventType.Null:
+		return "event_type"
+	case r.Status.Null:
+		return "status"
+	case r.Data.Null:
+		return "data"
+	}
+	return ""
+}
+
+// EventResponse is the API representation of an event; timestamps follow the configured time format
+type EventResponse struct {
+	ID         uuid.UUID              `json:"id"`
+	TenantID   uuid.UUID              `json:"tenant_id"`
+	ProviderID uuid.UUID              `json:"provider_id"`
+	EventType  models.EventTypeEnum   `json:"event_type"`
+	EventID    string                 `json:"event_id"`
+	Status     models.EventStatusEnum `json:"status"`
+	Data       *json.RawMessage       `json:"data"`
+	CreatedAt  APITime                `json:"created_at"`
+	UpdatedAt  APITime                `json:"updated_at"`
+	Source     models.EventSourceEnum `json:"source"`
+}
+
+// NewEventResponse converts a domain event to its API representation
+func NewEventResponse(event models.Event, format TimeFormat) EventResponse {
+	return EventResponse{
+		ID:         event.ID,
+		TenantID:   event.TenantID,
+		ProviderID: event.ProviderID,
+		EventType:  event.EventType,
+		EventID:    event.EventID,
+		Status:     event.Status,
+		Data:       event.Data,
+		CreatedAt:  NewAPITime(event.CreatedAt, format),
+		UpdatedAt:  NewAPITime(event.UpdatedAt, format),
+		Source:     event.Source,
+	}
+}
+
+// EventStatusChangeResponse is one entry of GET /events/{id}/history
+type EventStatusChangeResponse struct {
+	FromStatus models.EventStatusEnum `json:"from_status"`
+	ToStatus   models.EventStatusEnum `json:"to_status"`
+	ChangedAt  APITime                `json:"changed_at"`
+}
+
+// DeleteEventHandler returns a handler for DELETE /events/{id}.
+// Deletes are idempotent: deleting an event that is already gone also returns 204,
+// so clients can safely retry after a network failure.
+func DeleteEventHandler(logger *slog.Logger, eventsService services.EventsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, ok := middleware.GetTenantID(r)
+		if !ok {
+			WriteRejection(r.Context(), w, logger, metrics.ReasonInvalidTenant, ErrorCodeUnauthorized, middleware.ErrMissingOrInvalidTenantContext, http.StatusUnauthorized)
+			return
+		}
+
+		eventID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			WriteRejection(r.Context(), w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, ErrInvalidEventID, http.StatusBadRequest)
+			return
+		}
+
+		if _, err := eventsService.DeleteEventIdempotent(r.Context(), eventID, tenantID); err != nil {
+			logger.Log(r.Context(), serviceErrorLevel(err), "Failed to delete event", "error", err, "event_id", eventID, "tenant_id", tenantID)
+			WriteServerError(r.Context(), w, logger, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Page size limits for GET /events
+const (
+	defaultEventsPageSize = 50
+	maxEventsPageSize     = 1000
+)
+
+// ListEventsHandler returns a handler for GET /events, a page of the tenant's events, newest first.
+// event_type, status and provider_id filters accept several values, either repeated
+// (?status=pending&status=failed) or comma-separated (?event_type=payment_failed,payment_refunded);
+// values of one filter are ORed and the filters are ANDed. limit (default 50, max 1000)
+// and offset page through the results.
+func ListEventsHandler(logger *slog.Logger, eventsService services.EventsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		tenantID, ok := middleware.GetTenantID(r)
+		if !ok {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidTenant, ErrorCodeUnauthorized, middleware.ErrMissingOrInvalidTenantContext, http.StatusUnauthorized)
+			return
+		}
+
+		query := r.URL.Query()
+		filter, err := parseEventFilter(query)
+		if err != nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, err, http.StatusBadRequest)
+			return
+		}
+
+		params, err := parsePagination(query, defaultEventsPageSize, maxEventsPageSize)
+		if err != nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, err, http.StatusBadRequest)
+			return
+		}
+
+		page, err := eventsService.ListEvents(ctx, tenantID, filter, params)
+		if err != nil {
+			logger.Log(ctx, serviceErrorLevel(err), "Failed to list events", "error", err, "tenant_id", tenantID)
+			WriteServerError(ctx, w, logger, err)
+			return
+		}
+
+		items := make([]EventResponse, 0, len(page.Items))
+		for _, event := range page.Items {
+			items = append(items, NewEventResponse(event, timeFormatFrom(ctx)))
+		}
+		WriteListResponse(ctx, w, logger, models.NewPaginatedResponse(items, page.TotalCount, page.Limit, page.Offset))
+	}
+}
+
+// SearchEventsHandler returns a handler for GET /events/search, which lists the tenant's events
+// whose provider event ID starts with ?external_id=, in event ID order, with pagination. Support
+// uses it to find the event behind a charge or other provider ID a customer quotes.
+func SearchEventsHandler(logger *slog.Logger, eventsService services.EventsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		tenantID, ok := middleware.GetTenantID(r)
+		if !ok {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidTenant, ErrorCodeUnauthorized, middleware.ErrMissingOrInvalidTenantContext, http.StatusUnauthorized)
+			return
+		}
+
+		query := r.URL.Query()
+		params, err := parsePagination(query, defaultEventsPageSize, maxEventsPageSize)
+		if err != nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, err, http.StatusBadRequest)
+			return
+		}
+
+		page, err := eventsService.SearchEventsByExternalID(ctx, tenantID, query.Get("external_id"), params)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidSearchPrefix) {
+				WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, err, http.StatusBadRequest)
+				return
+			}
+			logger.Log(ctx, serviceErrorLevel(err), "Failed to search events", "error", err, "tenant_id", tenantID)
+			WriteServerError(ctx, w, logger, err)
+			return
+		}
+
+		items := make([]EventResponse, 0, len(page.Items))
+		for _, event := range page.Items {
+			items = append(items, NewEventResponse(event, timeFormatFrom(ctx)))
+		}
+		WriteListResponse(ctx, w, logger, models.NewPaginatedResponse(items, page.TotalCount, page.Limit, page.Offset))
+	}
+}
+
+// defaultRecentEvents is how many events GET /events/recent returns without ?n=
+const defaultRecentEvents = 10
+
+// RecentEventsHandler returns a handler for GET /events/recent, the tenant's n newest events,
+// newest first, for dashboards that show the latest activity on load. n defaults to 10 and
+// is capped at services.MaxRecentEvents.
+func RecentEventsHandler(logger *slog.Logger, eventsService services.EventsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		tenantID, ok := middleware.GetTenantID(r)
+		if !ok {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidTenant, ErrorCodeUnauthorized, middleware.ErrMissingOrInvalidTenantContext, http.StatusUnauthorized)
+			return
+		}
+
+		n := defaultRecentEvents
+		if raw := r.URL.Query().Get("n"); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed < 1 {
+				WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, fmt.Errorf("%w: n must be a positive integer", ErrInvalidPagination), http.StatusBadRequest)
+				return
+			}
+			n = min(parsed, services.MaxRecentEvents)
+		}
+
+		events, err := eventsService.GetRecentEvents(ctx, tenantID, n)
+		if err != nil {
+			logger.Log(ctx, serviceErrorLevel(err), "Failed to get recent events", "error", err, "tenant_id", tenantID)
+			WriteServerError(ctx, w, logger, err)
+			return
+		}
+
+		response := make([]EventResponse, 0, len(events))
+		for _, event := range events {
+			response = append(response, NewEventResponse(event, timeFormatFrom(ctx)))
+		}
+		WriteJSONSuccessResponse(ctx, w, logger, response)
+	}
+}
+
+// parseEventFilter reads the event filters shared by GET /events and GET
+// /admin/events/validate-stored: event_type, status and provider_id
+func parseEventFilter(query url.Values) (models.EventFilter, error) {
+	var filter models.EventFilter
+	for _, value := range splitQueryValues(query["event_type"]) {
+		eventType := models.EventTypeEnum(value)
+		if !isValidEventType(eventType) {
+			return models.EventFilter{}, fmt.Errorf("%w: %q", ErrInvalidEventType, value)
+		}
+		filter.EventTypes = append(filter.EventTypes, eventType)
+	}
+	for _, value := range splitQueryValues(query["status"]) {
+		status := models.EventStatusEnum(value)
+		if !isValidEventStatus(status) {
+			return models.EventFilter{}, fmt.Errorf("%w: %q", ErrInvalidEventStatus, value)
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+	for _, value := range splitQueryValues(query["provider_id"]) {
+		providerID, err := uuid.Parse(value)
+		if err != nil {
+			return models.EventFilter{}, fmt.Errorf("%w: %q", ErrInvalidProviderID, value)
+		}
+		filter.ProviderIDs = append(filter.ProviderIDs, providerID)
+	}
+	return filter, nil
+}
+
+// Limits on how many events one GET /admin/events/validate-stored checks
+const (
+	defaultValidateStoredMaxEvents = 10000
+	maxValidateStoredMaxEvents     = 100000
+)
+
+// ValidateStoredEventsResponse is the body of GET /admin/events/validate-stored
+type ValidateStoredEventsResponse struct {
+	TenantID  uuid.UUID                   `json:"tenant_id"`
+	Scanned   int                         `json:"scanned"`
+	Invalid   []models.InvalidStoredEvent `json:"invalid"`
+	Truncated bool                        `json:"truncated"`
+}
+
+// ValidateStoredEventsHandler returns a handler for GET /admin/events/validate-stored, an admin
+// route that re-runs the current ingestion validation over the stored events of the tenant in
+// tenant_id and reports the ones it would now reject, with the reason. Nothing is modified.
+// The events can be narrowed with the filters of GET /events, and at most max_events of them
+// (default 10000, max 100000) are checked; truncated is set when more matched.
+func ValidateStoredEventsHandler(logger *slog.Logger, eventsService services.EventsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		query := r.URL.Query()
+		raw := query.Get("tenant_id")
+		tenantID, err := uuid.Parse(raw)
+		if err != nil || tenantID == uuid.Nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, fmt.Errorf("%w: %q", ErrInvalidTenantID, raw), http.StatusBadRequest)
+			return
+		}
+
+		filter, err := parseEventFilter(query)
+		if err != nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, err, http.StatusBadRequest)
+			return
+		}
+
+		maxEvents := defaultValidateStoredMaxEvents
+		if raw := query.Get("max_events"); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed < 1 || parsed > maxValidateStoredMaxEvents {
+				WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, fmt.Errorf("%w: max_events must be between 1 and %d", ErrInvalidPagination, maxValidateStoredMaxEvents), http.StatusBadRequest)
+				return
+			}
+			maxEvents = parsed
+		}
+
+		report, err := eventsService.ValidateStoredEvents(ctx, tenantID, models.ValidateStoredEventsParams{Filter: filter, MaxEvents: maxEvents})
+		if err != nil {
+			logger.Log(ctx, serviceErrorLevel(err), "Failed to validate stored events", "error", err, "tenant_id", tenantID, "scanned", report.Scanned)
+			WriteServerError(ctx, w, logger, err)
+			return
+		}
+
+		WriteJSONSuccessResponse(ctx, w, logger, ValidateStoredEventsResponse{
+			TenantID:  tenantID,
+			Scanned:   report.Scanned,
+			Invalid:   report.Invalid,
+			Truncated: report.Truncated,
+		})
+	}
+}
+
+// Batch size limits for POST /events/reprocess-failed
+const (
+	defaultReprocessBatchSize = 100
+	maxReprocessBatchSize     = 500
+)
+
+// ReprocessFailedResponse is the body of POST /events/reprocess-failed. NextCursor is set
+// while failed events remain past this batch; pass it back as ?cursor= to continue.
+type ReprocessFailedResponse struct {
+	Scanned      int      `json:"scanned"`
+	Reprocessed  int      `json:"reprocessed"`
+	StillFailed  int      `json:"still_failed"`
+	LeaksCreated int      `json:"leaks_created"`
+	NextCursor   string   `json:"next_cursor,omitempty"`
+	Done         bool     `json:"done"`
+	Warnings     []string `json:"warnings,omitempty"`
+}
+
+// ReprocessFailedEventsHandler returns a handler for POST /events/reprocess-failed, which
+// re-validates a batch of the tenant's failed events and marks the ones that now pass as
+// processed. Each call handles at most limit events (default 100, max 500) after cursor, so
+// a large backlog is worked through by calling again with the returned next_cursor until
+// done is true. When any event was reprocessed, leak detection is run for the tenant so the
+// recovered events are considered; detector may be nil to skip that.
+func ReprocessFailedEventsHandler(logger *slog.Logger, eventsService services.EventsService, detector LeakDetector) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		tenantID, ok := middleware.GetTenantID(r)
+		if !ok {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidTenant, ErrorCodeUnauthorized, middleware.ErrMissingOrInvalidTenantContext, http.StatusUnauthorized)
+			return
+		}
+
+		query := r.URL.Query()
+		cursor := uuid.Nil
+		if raw := query.Get("cursor"); raw != "" {
+			parsed, err := uuid.Parse(raw)
+			if err != nil {
+				WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, fmt.Errorf("%w: %q", ErrInvalidCursor, raw), http.StatusBadRequest)
+				return
+			}
+			cursor = parsed
+		}
+
+		limit := defaultReprocessBatchSize
+		if raw := query.Get("limit"); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed < 1 || parsed > maxReprocessBatchSize {
+				WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidPagination, maxReprocessBatchSize), http.StatusBadRequest)
+				return
+			}
+			limit = parsed
+		}
+
+		result, err := eventsService.ReprocessFailedEvents(ctx, tenantID, cursor, limit)
+		if err != nil {
+			logger.Log(ctx, serviceErrorLevel(err), "Failed to reprocess failed events", "error", err, "tenant_id", tenantID, "cursor", cursor, "reprocessed", result.Reprocessed)
+			WriteServerError(ctx, w, logger, err)
+			return
+		}
+
+		resp := ReprocessFailedResponse{
+			Scanned:     result.Scanned,
+			Reprocessed: result.Reprocessed,
+			StillFailed: result.StillFailed,
+			Done:        result.Done,
+		}
+		if !result.Done {
+			resp.NextCursor = result.NextCursor.String()
+		}
+
+		if result.Reprocessed > 0 && detector != nil {
+			report, err := detector.DetectLeaks(ctx, tenantID, false)
+			resp.LeaksCreated = len(report.Created)
+			if errors.Is(err, detection.ErrDetectionInProgress) {
+				// The run already in progress, or the next scheduled one, picks up the recovered events
+				logger.DebugContext(ctx, "Leak detection after reprocessing skipped, another run is in progress", "tenant_id", tenantID)
+			} else if err != nil {
+				logger.WarnContext(ctx, "Leak detection after reprocessing finished with errors", "error", err, "tenant_id", tenantID)
+				resp.Warnings = append(resp.Warnings, WarningDetectionPartial)
+			}
+		}
+
+		WriteJSONSuccessResponse(ctx, w, logger, resp)
+	}
+}
+
+// RelatedEventsHandler returns a handler for GET /events/{id}/related, listing the tenant's
+// events from any provider that correlate with the given event under rule.
+func RelatedEventsHandler(logger *slog.Logger, eventsService services.EventsService, rule models.CorrelationRule) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		tenantID, ok := middleware.GetTenantID(r)
+		if !ok {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidTenant, ErrorCodeUnauthorized, middleware.ErrMissingOrInvalidTenantContext, http.StatusUnauthorized)
+			return
+		}
+
+		eventID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, ErrInvalidEventID, http.StatusBadRequest)
+			return
+		}
+
+		events, err := eventsService.GetRelatedEvents(ctx, tenantID, eventID, rule)
+		if err != nil {
+			if errors.Is(err, services.ErrEventNotFound) {
+				WriteJSONError(ctx, w, logger, ErrorCodeNotFound, ErrEventNotFound, http.StatusNotFound)
+				return
+			}
+			logger.Log(ctx, serviceErrorLevel(err), "Failed to get related events", "error", err, "event_id", eventID, "tenant_id", tenantID)
+			WriteServerError(ctx, w, logger, err)
+			return
+		}
+
+		response := make([]EventResponse, 0, len(events))
+		for _, event := range events {
+			response = append(response, NewEventResponse(event, timeFormatFrom(ctx)))
+		}
+		WriteJSONSuccessResponse(ctx, w, logger, response)
+	}
+}
+
+// EventStatusHistoryHandler returns a handler for GET /events/{id}/history, listing every
+// change of the event's status, oldest first, to show where it got stuck.
+func EventStatusHistoryHandler(logger *slog.Logger, eventsService services.EventsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		tenantID, ok := middleware.GetTenantID(r)
+		if !ok {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidTenant, ErrorCodeUnauthorized, middleware.ErrMissingOrInvalidTenantContext, http.StatusUnauthorized)
+			return
+		}
+
+		eventID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, ErrInvalidEventID, http.StatusBadRequest)
+			return
+		}
+
+		history, err := eventsService.GetEventStatusHistory(ctx, eventID, tenantID)
+		if err != nil {
+			if errors.Is(err, services.ErrEventNotFound) {
+				WriteJSONError(ctx, w, logger, ErrorCodeNotFound, ErrEventNotFound, http.StatusNotFound)
+				return
+			}
+			logger.Log(ctx, serviceErrorLevel(err), "Failed to get event status history", "error", err, "event_id", eventID, "tenant_id", tenantID)
+			WriteServerError(ctx, w, logger, err)
+			return
+		}
+
+		response := make([]EventStatusChangeResponse, 0, len(history))
+		for _, change := range history {
+			response = append(response, EventStatusChangeResponse{
+				FromStatus: change.FromStatus,
+				ToStatus:   change.ToStatus,
+				ChangedAt:  NewAPITime(change.ChangedAt, timeFormatFrom(ctx)),
+			})
+		}
+		WriteJSONSuccessResponse(ctx, w, logger, response)
+	}
+}
+
+// UpdateEventHandler returns a handler for PATCH /events/{id}.
+// When the request carries If-Unmodified-Since, the update only succeeds if the event has not
+// changed since that time; otherwise it responds 412 Precondition Failed. The response carries
+// Last-Modified so clients can send it back on their next update.
+// The data_mode query parameter chooses how data is applied: replace (the default) overwrites
+// the stored payload, merge adds its top-level keys to it. A field sent as null is rejected, since
+// no event field can be cleared.
+func UpdateEventHandler(logger *slog.Logger, eventsService services.EventsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		tenantID, ok := middleware.GetTenantID(r)
+		if !ok {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidTenant, ErrorCodeUnauthorized, middleware.ErrMissingOrInvalidTenantContext, http.StatusUnauthorized)
+			return
+		}
+
+		eventID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, ErrInvalidEventID, http.StatusBadRequest)
+			return
+		}
+
+		var req UpdateEventRequest
+		if err := decodeJSON(ctx, r.Body, &req); err != nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidJSON, ErrorCodeInvalidRequest, requestBodyError(err), http.StatusBadRequest)
+			return
+		}
+		if field := req.nullField(); field != "" {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, fmt.Errorf("%w: %s", ErrNullField, field), http.StatusBadRequest)
+			return
+		}
+		if req.EventType.Set && !isValidEventType(req.EventType.Value) {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, ErrInvalidEventType, http.StatusBadRequest)
+			return
+		}
+		if req.Status.Set && !isValidEventStatus(req.Status.Value) {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, ErrInvalidEventStatus, http.StatusBadRequest)
+			return
+		}
+
+		dataMode := models.DataMode(r.URL.Query().Get("data_mode"))
+		if !dataMode.IsValid() {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, ErrInvalidDataMode, http.StatusBadRequest)
+			return
+		}
+
+		params := models.UpdateEventParams{
+			ID:        eventID,
+			EventType: req.EventType.Ptr(),
+			Status:    req.Status.Ptr(),
+			Data:      req.Data.Ptr(),
+			DataMode:  dataMode,
+		}
+
+		var event models.Event
+		// Per RFC 9110 an invalid If-Unmodified-Since date is ignored
+		since, parseErr := http.ParseTime(r.Header.Get("If-Unmodified-Since"))
+		if parseErr == nil {
+			event, err = updateEventIfUnmodifiedSince(r, eventsService, params, since, tenantID)
+		} else {
+			event, err = eventsService.UpdateEvent(ctx, params, tenantID)
+		}
+
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrEventNotFound):
+				WriteJSONError(ctx, w, logger, ErrorCodeNotFound, ErrEventNotFound, http.StatusNotFound)
+			case errors.Is(err, services.ErrConcurrentModification):
+				WriteJSONError(ctx, w, logger, ErrorCodePreconditionFail, ErrPreconditionFailed, http.StatusPreconditionFailed)
+			default:
+				logger.Log(ctx, serviceErrorLevel(err), "Failed to update event", "error", err, "event_id", eventID, "tenant_id", tenantID)
+				WriteServerError(ctx, w, logger, err)
+			}
+			return
+		}
+
+		w.Header().Set("Last-Modified", event.UpdatedAt.UTC().Format(http.TimeFormat))
+		WriteJSONSuccessResponse(ctx, w, logger, NewEventResponse(event, timeFormatFrom(ctx)))
+	}
+}
+
+// updateEventIfUnmodifiedSince applies the update only if the event has not changed after since.
+// The stored updated_at is then used as the version, so a write that lands between the read
+// and the update is still detected.
+func updateEventIfUnmodifiedSince(
+	r *http.Request,
+	eventsService services.EventsService,
+	params models.UpdateEventParams,
+	since time.Time,
+	tenantID uuid.UUID,
+) (models.Event, error) {
+	current, err := eventsService.GetEventByID(r.Context(), params.ID, tenantID)
+	if err != nil {
+		return models.Event{}, err
+	}
+
+	// HTTP dates have second precision
+	if current.UpdatedAt.Truncate(time.Second).After(since) {
+		return models.Event{}, services.ErrConcurrentModification
+	}
+
+	return eventsService.UpdateEventIfVersion(r.Context(), params, current.UpdatedAt, tenantID)
+}
+
+// isValidEventType reports whether t is a known event type
+func isValidEventType(t models.EventTypeEnum) bool {
+	switch t {
+	case models.EventTypeEnumPaymentFailed,
+		models.EventTypeEnumPaymentSucceeded,
+		models.EventTypeEnumPaymentRefunded,
+		models.EventTypeEnumPaymentUpdated:
+		return true
+	}
+	return false
+}
+
+// isValidEventStatus reports whether s is a known event status
+func isValidEventStatus(s models.EventStatusEnum) bool {
+	switch s {
+	case models.EventStatusEnumPending,
+		models.EventStatusEnumProcessed,
+		models.EventStatusEnumFailed,
+		models.EventStatusEnumProcessing:
+		return true
+	}
+	return false
+}
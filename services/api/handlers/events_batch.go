@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/domain/services"
+	"rdl-api/internal/metrics"
+	"rdl-api/internal/middleware"
+
+	"github.com/google/uuid"
+)
+
+// Values of BatchItemResult.Status
+const (
+	BatchItemCreated = "created"
+	BatchItemSkipped = "skipped"
+	BatchItemError   = "error"
+)
+
+// BatchEventRequest is one item of the POST /events/batch body
+type BatchEventRequest struct {
+	ProviderID uuid.UUID            `json:"provider_id"`
+	EventType  models.EventTypeEnum `json:"event_type"`
+	// EventID is the provider's own identifier for the event, used for idempotency
+	EventID string          `json:"event_id"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// BatchItemResult is the outcome of one batch item, reported at the item's position in the request
+type BatchItemResult struct {
+	Index   int    `json:"index"`
+	EventID string `json:"event_id"`
+	// Status is "created", "skipped" (a duplicate, or a type the tenant doesn't accept) or "error"
+	Status string `json:"status"`
+	// ID is the stored event's ID; only set when Status is "created"
+	ID    *uuid.UUID `json:"id,omitempty"`
+	Error string     `json:"error,omitempty"`
+}
+
+// BatchEventsResponse is the 207 Multi-Status body of POST /events/batch
+type BatchEventsResponse struct {
+	Results []BatchItemResult `json:"results"`
+	Created int               `json:"created"`
+	Skipped int               `json:"skipped"`
+	Failed  int               `json:"failed"`
+}
+
+// ErrBatchNotStored is reported for items left unstored because an earlier chunk of the batch failed
+var ErrBatchNotStored = errors.New("not stored: the batch stopped at an earlier chunk")
+
+// CreateEventsBatchHandler returns a handler for POST /events/batch, which stores a JSON array
+// of events for the tenant. Each item is validated like a webhook event; invalid items are
+// reported as errors and the rest are stored with CreateEventsBatch, so one bad item does not
+// fail the batch. The response is always 207 Multi-Status with one result per item, in
+// request order. A batch larger than policy.MaxSize is refused with 413 unless policy.Chunk
+// is set. Items are decoded in the route's JSONMode, see decodeBatchEvent.
+func CreateEventsBatchHandler(logger *slog.Logger, eventsService services.EventsService, policy models.BatchPolicy, maxBytes int64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		tenantID, ok := middleware.GetTenantID(r)
+		if !ok {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidTenant, ErrorCodeUnauthorized, middleware.ErrMissingOrInvalidTenantContext, http.StatusUnauthorized)
+			return
+		}
+
+		body, err := ReadAndRestoreBody(r, maxBytes)
+		if err != nil {
+			if errors.Is(err, ErrBodyTooLarge) {
+				WriteRejection(ctx, w, logger, metrics.ReasonBodyTooLarge, ErrorCodeBodyTooLarge, ErrBodyTooLarge, http.StatusRequestEntityTooLarge)
+				return
+			}
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidJSON, ErrorCodeInvalidRequest, ErrInvalidRequestBody, http.StatusBadRequest)
+			return
+		}
+
+		var raws []json.RawMessage
+		if err := json.Unmarshal(body, &raws); err != nil || len(raws) == 0 {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidJSON, ErrorCodeInvalidRequest, ErrInvalidRequestBody, http.StatusBadRequest)
+			return
+		}
+		items := make([]BatchEventRequest, len(raws))
+		for i, raw := range raws {
+			item, err := decodeBatchEvent(ctx, raw)
+			if err != nil {
+				WriteRejection(ctx, w, logger, metrics.ReasonInvalidJSON, ErrorCodeInvalidRequest, fmt.Errorf("item %d: %w", i, requestBodyError(err)), http.StatusBadRequest)
+				return
+			}
+			items[i] = item
+		}
+		if policy.MaxSize > 0 && len(items) > policy.MaxSize && !policy.Chunk {
+			err := fmt.Errorf("%w: %d events, limit %d", ErrBatchTooLarge, len(items), policy.MaxSize)
+			WriteRejection(ctx, w, logger, metrics.ReasonBatchTooLarge, ErrorCodeBatchTooLarge, err, http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		response := BatchEventsResponse{Results: make([]BatchItemResult, len(items))}
+		params := make([]models.CreateEventParams, 0, len(items))
+		// positions maps each entry of params back to its index in items
+		positions := make([]int, 0, len(items))
+		for i, item := range items {
+			response.Results[i] = BatchItemResult{Index: i, EventID: item.EventID}
+			arg, err := newBatchEventParams(tenantID, item)
+			if err != nil {
+				response.Results[i].Status = BatchItemError
+				response.Results[i].Error = err.Error()
+				continue
+			}
+			params = append(params, arg)
+			positions = append(positions, i)
+		}
+
+		if len(params) > 0 {
+			stored, err := eventsService.CreateEventsBatch(ctx, params, tenantID, policy)
+			if err != nil && len(stored) == 0 {
+				if errors.Is(err, services.ErrBatchTooLarge) {
+					WriteRejection(ctx, w, logger, metrics.ReasonBatchTooLarge, ErrorCodeBatchTooLarge, ErrBatchTooLarge, http.StatusRequestEntityTooLarge)
+					return
+				}
+				logger.Log(ctx, serviceErrorLevel(err), "Failed to store event batch", "error", err, "size", len(params), "tenant_id", tenantID)
+				WriteServerError(ctx, w, logger, err)
+				return
+			}
+			if err != nil {
+				logger.ErrorContext(ctx, "Event batch stopped part way", "error", err, "stored", len(stored), "size", len(params), "tenant_id", tenantID)
+			}
+
+			for j, i := range positions {
+				if j >= len(stored) {
+					response.Results[i].Status = BatchItemError
+					response.Results[i].Error = ErrBatchNotStored.Error()
+					continue
+				}
+				response.Results[i] = batchItemResult(ctx, logger, response.Results[i], stored[j])
+			}
+		}
+
+		for _, result := range response.Results {
+			switch result.Status {
+			case BatchItemCreated:
+				response.Created++
+			case BatchItemSkipped:
+				response.Skipped++
+			default:
+				response.Failed++
+			}
+		}
+
+		WriteJSONResponse(ctx, w, logger, response, http.StatusMultiStatus)
+	}
+}
+
+// decodeBatchEvent decodes one batch item in the route's JSONMode. On a lenient route, fields the
+// item doesn't declare are added to its data object, so they are stored with the event; keys
+// already in data win, and data that is not an object is left as sent.
+func decodeBatchEvent(ctx context.Context, raw json.RawMessage) (BatchEventRequest, error) {
+	var item BatchEventRequest
+	if err := decodeJSON(ctx, bytes.NewReader(raw), &item); err != nil {
+		return BatchEventRequest{}, err
+	}
+	if jsonModeFromContext(ctx) != JSONLenient {
+		return item, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return BatchEventRequest{}, err
+	}
+	for _, known := range []string{"provider_id", "event_type", "event_id", "data"} {
+		delete(fields, known)
+	}
+	if len(fields) == 0 {
+		return item, nil
+	}
+
+	data := map[string]json.RawMessage{}
+	if len(item.Data) > 0 && string(item.Data) != "null" {
+		if err := json.Unmarshal(item.Data, &data); err != nil {
+			return item, nil
+		}
+	}
+	for key, value := range fields {
+		if _, ok := data[key]; !ok {
+			data[key] = value
+		}
+	}
+	merged, err := json.Marshal(data)
+	if err != nil {
+		return BatchEventRequest{}, err
+	}
+	item.Data = merged
+	return item, nil
+}
+
+// newBatchEventParams validates one batch item and builds its create parameters, marking the
+// event as sent through the API
+func newBatchEventParams(tenantID uuid.UUID, item BatchEventRequest) (models.CreateEventParams, error) {
+	if !isValidEventType(item.EventType) {
+		return models.CreateEventParams{}, fmt.Errorf("%w: %q", ErrInvalidEventType, item.EventType)
+	}
+	var data any
+	if len(item.Data) > 0 && string(item.Data) != "null" {
+		data = item.Data
+	}
+	params, err := models.NewCreateEventParams(tenantID, item.ProviderID, item.EventType, item.EventID, data)
+	if err != nil {
+		return models.CreateEventParams{}, err
+	}
+	params.Source = models.EventSourceEnumApi
+	return params, nil
+}
+
+// batchItemResult fills in result from the stored outcome of its item. Errors the client can
+// act on are reported as is; anything else is logged and reported as an internal error.
+func batchItemResult(ctx context.Context, logger *slog.Logger, result BatchItemResult, outcome models.BatchEventResult) BatchItemResult {
+	switch {
+	case outcome.Err == nil:
+		id := outcome.Event.ID
+		result.Status = BatchItemCreated
+		result.ID = &id
+	case errors.Is(outcome.Err, services.ErrEventAlreadyExists), errors.Is(outcome.Err, services.ErrEventSkipped):
+		result.Status = BatchItemSkipped
+		result.Error = outcome.Err.Error()
+	case errors.Is(outcome.Err, services.ErrUnknownProvider):
+		result.Status = BatchItemError
+		result.Error = outcome.Err.Error()
+	default:
+		logger.ErrorContext(ctx, "Failed to store batch item", "error", outcome.Err, "index", result.Index, "event_id", result.EventID)
+		result.Status = BatchItemError
+		result.Error = ErrInternalServerError.Error()
+	}
+	return result
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/domain/services"
+	"rdl-api/internal/middleware"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// testBatchEventsService stores batches in memory, treating event IDs it has seen as duplicates;
+// other methods panic via the nil embedded interface
+type testBatchEventsService struct {
+	services.EventsService
+	seen     map[string]bool
+	received []models.CreateEventParams
+	policy   models.BatchPolicy
+}
+
+func (s *testBatchEventsService) CreateEventsBatch(_ context.Context, args []models.CreateEventParams, _ uuid.UUID, policy models.BatchPolicy) ([]models.BatchEventResult, error) {
+	s.received = append(s.received, args...)
+	s.policy = policy
+	results := make([]models.BatchEventResult, 0, len(args))
+	for _, arg := range args {
+		if s.seen[arg.EventID] {
+			results = append(results, models.BatchEventResult{Err: services.ErrEventAlreadyExists})
+			continue
+		}
+		s.seen[arg.EventID] = true
+		results = append(results, models.BatchEventResult{Event: models.Event{ID: uuid.New(), EventID: arg.EventID, EventType: arg.EventType}})
+	}
+	return results, nil
+}
+
+func TestCreateEventsBatchHandler(t *testing.T) {
+	providerID := uuid.New()
+	item := func(eventID string, eventType models.EventTypeEnum) BatchEventRequest {
+		return BatchEventRequest{ProviderID: providerID, EventType: eventType, EventID: eventID, Data: json.RawMessage(`{"amount": 10}`)}
+	}
+
+	post := func(svc services.EventsService, policy models.BatchPolicy, body any) *httptest.ResponseRecorder {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		logger := newTestLogger()
+		mux := http.NewServeMux()
+		mux.HandleFunc("POST /events/batch", CreateEventsBatchHandler(logger, svc, policy, 1<<20))
+		req := httptest.NewRequest(http.MethodPost, "/events/batch", strings.NewReader(string(payload)))
+		req.Header.Set("X-Tenant-ID", uuid.New().String())
+		w := httptest.NewRecorder()
+		middleware.TenantContext(logger, true, nil)(mux).ServeHTTP(w, req)
+		return w
+	}
+
+	decode := func(t *testing.T, w *httptest.ResponseRecorder) BatchEventsResponse {
+		t.Helper()
+		if w.Code != http.StatusMultiStatus {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusMultiStatus, w.Code, w.Body.String())
+		}
+		var body BatchEventsResponse
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return body
+	}
+
+	t.Run("fully successful batch", func(t *testing.T) {
+		svc := &testBatchEventsService{seen: map[string]bool{}}
+		body := decode(t, post(svc, models.BatchPolicy{MaxSize: 10}, []BatchEventRequest{
+			item("evt_1", models.EventTypeEnumPaymentFailed),
+			item("evt_2", models.EventTypeEnumPaymentSucceeded),
+		}))
+
+		if body.Created != 2 || body.Skipped != 0 || body.Failed != 0 {
+			t.Errorf("expected 2 created, got %+v", body)
+		}
+		for i, result := range body.Results {
+			if result.Index != i || result.Status != BatchItemCreated || result.ID == nil {
+				t.Errorf("expected item %d to be created with an ID, got %+v", i, result)
+			}
+		}
+		if svc.policy.MaxSize != 10 {
+			t.Errorf("expected the batch policy to be passed through, got %+v", svc.policy)
+		}
+		for _, params := range svc.received {
+			if params.Source != models.EventSourceEnumApi {
+				t.Errorf("expected batch events to have source %q, got %q", models.EventSourceEnumApi, params.Source)
+			}
+		}
+	})
+
+	t.Run("mixed batch with an invalid item", func(t *testing.T) {
+		svc := &testBatchEventsService{seen: map[string]bool{"evt_dup": true}}
+		body := decode(t, post(svc, models.BatchPolicy{MaxSize: 10}, []BatchEventRequest{
+			item("evt_ok", models.EventTypeEnumPaymentFailed),
+			item("evt_bad", "payment_exploded"),
+			item("evt_dup", models.EventTypeEnumPaymentRefunded),
+		}))
+
+		want := []string{BatchItemCreated, BatchItemError, BatchItemSkipped}
+		for i, result := range body.Results {
+			if result.Status != want[i] {
+				t.Errorf("expected item %d to be %s, got %+v", i, want[i], result)
+			}
+		}
+		if !strings.Contains(body.Results[1].Error, "payment_exploded") {
+			t.Errorf("expected the invalid item's error to name its type, got %q", body.Results[1].Error)
+		}
+		if body.Created != 1 || body.Skipped != 1 || body.Failed != 1 {
+			t.Errorf("expected 1 created, 1 skipped and 1 failed, got %+v", body)
+		}
+		if len(svc.received) != 2 {
+			t.Errorf("expected only the valid items to reach the service, got %d", len(svc.received))
+		}
+	})
+
+	t.Run("oversized batch is refused", func(t *testing.T) {
+		svc := &testBatchEventsService{seen: map[string]bool{}}
+		w := post(svc, models.BatchPolicy{MaxSize: 1}, []BatchEventRequest{
+			item("evt_1", models.EventTypeEnumPaymentFailed),
+			item("evt_2", models.EventTypeEnumPaymentFailed),
+		})
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("expected status %d, got %d", http.StatusRequestEntityTooLarge, w.Code)
+		}
+		if len(svc.received) != 0 {
+			t.Error("expected nothing to be stored")
+		}
+	})
+
+	t.Run("oversized batch is chunked when allowed", func(t *testing.T) {
+		svc := &testBatchEventsService{seen: map[string]bool{}}
+		body := decode(t, post(svc, models.BatchPolicy{MaxSize: 1, Chunk: true}, []BatchEventRequest{
+			item("evt_1", models.EventTypeEnumPaymentFailed),
+			item("evt_2", models.EventTypeEnumPaymentFailed),
+		}))
+		if body.Created != 2 {
+			t.Errorf("expected 2 created, got %+v", body)
+		}
+	})
+
+	t.Run("body that is not an array", func(t *testing.T) {
+		w := post(&testBatchEventsService{}, models.BatchPolicy{}, map[string]string{"event_id": "evt_1"})
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("empty array", func(t *testing.T) {
+		w := post(&testBatchEventsService{}, models.BatchPolicy{}, []BatchEventRequest{})
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
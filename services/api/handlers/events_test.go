@@ -0,0 +1,1034 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"rdl-api/internal/detection"
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/domain/services"
+	"rdl-api/internal/middleware"
+
+	"github.com/google/uuid"
+)
+
+// testEventsService is an in-memory EventsService; methods not overridden panic via the nil embedded interface
+type testEventsService struct {
+	services.EventsService
+
+	mu        sync.Mutex
+	events    map[uuid.UUID]bool
+	eventList []models.Event
+	stored    map[uuid.UUID]models.Event
+	deleteErr error
+	// created holds events passed to CreateEvent, keyed by external event ID
+	created map[string]models.CreateEventParams
+	// acceptedTypes is the tenant allowlist applied by CreateEvent; empty accepts everything
+	acceptedTypes []models.EventTypeEnum
+	// allowedProviders is the tenant provider allowlist applied by CreateEvent; empty accepts everything
+	allowedProviders []uuid.UUID
+	// beforeVersionedUpdate runs just before a versioned update, to simulate a concurrent writer
+	beforeVersionedUpdate func()
+	// exportPages counts the ListEventsForExport calls
+	exportPages int
+}
+
+func newTestEventsService(ids ...uuid.UUID) *testEventsService {
+	events := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		events[id] = true
+	}
+	return &testEventsService{events: events}
+}
+
+func (s *testEventsService) CreateEvent(_ context.Context, args models.CreateEventParams, tenantID uuid.UUID) (models.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.acceptedTypes) > 0 && !slices.Contains(s.acceptedTypes, args.EventType) {
+		return models.Event{}, services.ErrEventSkipped
+	}
+	if len(s.allowedProviders) > 0 && !slices.Contains(s.allowedProviders, args.ProviderID) {
+		return models.Event{}, services.ErrUnknownProvider
+	}
+	if s.created == nil {
+		s.created = make(map[string]models.CreateEventParams)
+	}
+	if _, exists := s.created[args.EventID]; exists {
+		return models.Event{}, services.ErrEventAlreadyExists
+	}
+	s.created[args.EventID] = args
+	return models.Event{ID: uuid.New(), TenantID: tenantID, ProviderID: args.ProviderID, EventType: args.EventType, EventID: args.EventID, Status: args.Status}, nil
+}
+
+func (s *testEventsService) CreateEventIdempotent(ctx context.Context, args models.CreateEventParams, tenantID uuid.UUID) (models.Event, error) {
+	return s.CreateEvent(ctx, args, tenantID)
+}
+
+func (s *testEventsService) DeleteEventIdempotent(_ context.Context, eventID uuid.UUID, _ uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.deleteErr != nil {
+		return 0, s.deleteErr
+	}
+	if !s.events[eventID] {
+		return 0, nil
+	}
+	delete(s.events, eventID)
+	return 1, nil
+}
+
+func (s *testEventsService) GetAllEventsPaginated(_ context.Context, _ uuid.UUID, params models.PaginationParams) (models.PaginatedResponse[models.Event], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := min(int(params.Offset), len(s.eventList))
+	end := min(start+int(params.Limit), len(s.eventList))
+	return models.NewPaginatedResponse(s.eventList[start:end], int64(len(s.eventList)), params.Limit, params.Offset), nil
+}
+
+// ListEventsForExport treats eventList as newest first and continues after the cursor's event
+func (s *testEventsService) ListEventsForExport(_ context.Context, _ uuid.UUID, after models.EventCursor, limit int32) ([]models.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.exportPages++
+	start := 0
+	if after != (models.EventCursor{}) {
+		start = slices.IndexFunc(s.eventList, func(e models.Event) bool { return e.ID == after.ID }) + 1
+	}
+	end := min(start+int(limit), len(s.eventList))
+	return s.eventList[start:end], nil
+}
+
+func (s *testEventsService) GetEventByID(_ context.Context, eventID uuid.UUID, _ uuid.UUID) (models.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event, ok := s.stored[eventID]
+	if !ok {
+		return models.Event{}, services.ErrEventNotFound
+	}
+	return event, nil
+}
+
+func (s *testEventsService) UpdateEvent(_ context.Context, args models.UpdateEventParams, _ uuid.UUID) (models.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.applyUpdate(args)
+}
+
+func (s *testEventsService) UpdateEventIfVersion(_ context.Context, args models.UpdateEventParams, expectedUpdatedAt time.Time, _ uuid.UUID) (models.Event, error) {
+	if s.beforeVersionedUpdate != nil {
+		s.beforeVersionedUpdate()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event, ok := s.stored[args.ID]
+	if !ok {
+		return models.Event{}, services.ErrEventNotFound
+	}
+	if !event.UpdatedAt.Equal(expectedUpdatedAt) {
+		return models.Event{}, services.ErrConcurrentModification
+	}
+	return s.applyUpdate(args)
+}
+
+// applyUpdate must be called with s.mu held
+func (s *testEventsService) applyUpdate(args models.UpdateEventParams) (models.Event, error) {
+	event, ok := s.stored[args.ID]
+	if !ok {
+		return models.Event{}, services.ErrEventNotFound
+	}
+	if args.EventType != nil {
+		event.EventType = *args.EventType
+	}
+	if args.Status != nil {
+		event.Status = *args.Status
+	}
+	if args.Data != nil {
+		event.Data = mergeTestData(event.Data, args.Data, args.DataMode)
+	}
+	event.UpdatedAt = event.UpdatedAt.Add(time.Minute)
+	s.stored[args.ID] = event
+	return event, nil
+}
+
+// mergeTestData applies data to stored the way the update query does: merge adds the top-level
+// keys of an object to a stored object, anything else replaces
+func mergeTestData(stored, data *json.RawMessage, mode models.DataMode) *json.RawMessage {
+	var base, patch map[string]json.RawMessage
+	if mode != models.DataModeMerge || stored == nil ||
+		json.Unmarshal(*stored, &base) != nil || json.Unmarshal(*data, &patch) != nil || base == nil || patch == nil {
+		return data
+	}
+	for key, value := range patch {
+		base[key] = value
+	}
+	merged, _ := json.Marshal(base)
+	raw := json.RawMessage(merged)
+	return &raw
+}
+
+// newEventsTestHandler routes requests through the mux and tenant middleware the way the server does
+func newEventsTestHandler(eventsService services.EventsService) http.Handler {
+	logger := newTestLogger()
+	mux := http.NewServeMux()
+	mux.HandleFunc("PATCH /events/{id}", UpdateEventHandler(logger, eventsService))
+	mux.HandleFunc("DELETE /events/{id}", DeleteEventHandler(logger, eventsService))
+	return middleware.TenantContext(logger, true, nil)(mux)
+}
+
+func newDeleteEventRequest(eventID string, tenantID uuid.UUID) *http.Request {
+	req := httptest.NewRequest(http.MethodDelete, "/events/"+eventID, nil)
+	if tenantID != uuid.Nil {
+		req.Header.Set("X-Tenant-ID", tenantID.String())
+	}
+	return req
+}
+
+func TestDeleteEventHandler_Idempotent(t *testing.T) {
+	eventID := uuid.New()
+	tenantID := uuid.New()
+	handler := newEventsTestHandler(newTestEventsService(eventID))
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newDeleteEventRequest(eventID.String(), tenantID))
+
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("attempt %d: expected status %d, got %d", attempt, http.StatusNoContent, w.Code)
+		}
+		if w.Body.Len() != 0 {
+			t.Errorf("attempt %d: expected empty body, got %q", attempt, w.Body.String())
+		}
+	}
+}
+
+func TestDeleteEventHandler_Errors(t *testing.T) {
+	tests := []struct {
+		name           string
+		eventID        string
+		tenantID       uuid.UUID
+		deleteErr      error
+		expectedStatus int
+	}{
+		{
+			name:           "invalid event id",
+			eventID:        "not-a-uuid",
+			tenantID:       uuid.New(),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing tenant",
+			eventID:        uuid.NewString(),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "service failure",
+			eventID:        uuid.NewString(),
+			tenantID:       uuid.New(),
+			deleteErr:      errors.New("connection refused"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "pool exhausted",
+			eventID:        uuid.NewString(),
+			tenantID:       uuid.New(),
+			deleteErr:      fmt.Errorf("%w: 10 of 10 in use", services.ErrServiceOverloaded),
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:           "client disconnected",
+			eventID:        uuid.NewString(),
+			tenantID:       uuid.New(),
+			deleteErr:      services.ErrOperationCanceled,
+			expectedStatus: StatusClientClosedRequest,
+		},
+		{
+			name:           "deadline passed",
+			eventID:        uuid.NewString(),
+			tenantID:       uuid.New(),
+			deleteErr:      services.ErrOperationTimeout,
+			expectedStatus: http.StatusGatewayTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eventsService := newTestEventsService()
+			eventsService.deleteErr = tt.deleteErr
+			handler := newEventsTestHandler(eventsService)
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, newDeleteEventRequest(tt.eventID, tt.tenantID))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if retryAfter := w.Header().Get("Retry-After"); (retryAfter != "") != (tt.expectedStatus == http.StatusServiceUnavailable) {
+				t.Errorf("expected Retry-After only on 503, got %q with status %d", retryAfter, w.Code)
+			}
+		})
+	}
+}
+
+func newPatchEventRequest(eventID uuid.UUID, tenantID uuid.UUID, body string, ifUnmodifiedSince time.Time) *http.Request {
+	req := httptest.NewRequest(http.MethodPatch, "/events/"+eventID.String(), strings.NewReader(body))
+	req.Header.Set("X-Tenant-ID", tenantID.String())
+	if !ifUnmodifiedSince.IsZero() {
+		req.Header.Set("If-Unmodified-Since", ifUnmodifiedSince.UTC().Format(http.TimeFormat))
+	}
+	return req
+}
+
+func TestUpdateEventHandler_IfUnmodifiedSince(t *testing.T) {
+	lastModified := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name                  string
+		ifUnmodifiedSince     time.Time
+		beforeVersionedUpdate func(s *testEventsService, eventID uuid.UUID)
+		expectedStatus        int
+		expectedEventStatus   models.EventStatusEnum
+	}{
+		{
+			name:                "versioned update succeeds",
+			ifUnmodifiedSince:   lastModified,
+			expectedStatus:      http.StatusOK,
+			expectedEventStatus: models.EventStatusEnumProcessed,
+		},
+		{
+			name:                "unconditional update succeeds",
+			expectedStatus:      http.StatusOK,
+			expectedEventStatus: models.EventStatusEnumProcessed,
+		},
+		{
+			name:                "stale version is rejected",
+			ifUnmodifiedSince:   lastModified.Add(-time.Hour),
+			expectedStatus:      http.StatusPreconditionFailed,
+			expectedEventStatus: models.EventStatusEnumPending,
+		},
+		{
+			name:              "concurrent write between read and update is rejected",
+			ifUnmodifiedSince: lastModified,
+			beforeVersionedUpdate: func(s *testEventsService, eventID uuid.UUID) {
+				status := models.EventStatusEnumFailed
+				s.mu.Lock()
+				defer s.mu.Unlock()
+				_, _ = s.applyUpdate(models.UpdateEventParams{ID: eventID, Status: &status})
+			},
+			expectedStatus:      http.StatusPreconditionFailed,
+			expectedEventStatus: models.EventStatusEnumFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eventID := uuid.New()
+			eventsService := newTestEventsService()
+			eventsService.stored = map[uuid.UUID]models.Event{
+				eventID: {ID: eventID, Status: models.EventStatusEnumPending, UpdatedAt: lastModified},
+			}
+			if tt.beforeVersionedUpdate != nil {
+				eventsService.beforeVersionedUpdate = func() { tt.beforeVersionedUpdate(eventsService, eventID) }
+			}
+			handler := newEventsTestHandler(eventsService)
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, newPatchEventRequest(eventID, uuid.New(), `{"status":"processed"}`, tt.ifUnmodifiedSince))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if got := eventsService.stored[eventID].Status; got != tt.expectedEventStatus {
+				t.Errorf("expected stored status %q, got %q", tt.expectedEventStatus, got)
+			}
+			if w.Code == http.StatusOK && w.Header().Get("Last-Modified") == "" {
+				t.Error("expected Last-Modified header on success")
+			}
+		})
+	}
+}
+
+func TestUpdateEventHandler_Errors(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{name: "unknown event", body: `{"status":"processed"}`, expectedStatus: http.StatusNotFound},
+		{name: "malformed body", body: `{"status":`, expectedStatus: http.StatusBadRequest},
+		{name: "invalid status", body: `{"status":"done"}`, expectedStatus: http.StatusBadRequest},
+		{name: "invalid event type", body: `{"event_type":"charge"}`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newEventsTestHandler(newTestEventsService())
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, newPatchEventRequest(uuid.New(), uuid.New(), tt.body, time.Time{}))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestUpdateEventHandler_DataMode(t *testing.T) {
+	stored := json.RawMessage(`{"amount":100,"currency":"usd"}`)
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedData   string
+	}{
+		{name: "merge preserves existing keys", query: "?data_mode=merge", expectedStatus: http.StatusOK, expectedData: `{"amount":100,"currency":"eur","note":"retried"}`},
+		{name: "replace overwrites", query: "?data_mode=replace", expectedStatus: http.StatusOK, expectedData: `{"currency":"eur","note":"retried"}`},
+		{name: "replace is the default", expectedStatus: http.StatusOK, expectedData: `{"currency":"eur","note":"retried"}`},
+		{name: "unknown mode is rejected", query: "?data_mode=patch", expectedStatus: http.StatusBadRequest, expectedData: string(stored)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eventID := uuid.New()
+			eventsService := newTestEventsService()
+			eventsService.stored = map[uuid.UUID]models.Event{eventID: {ID: eventID, Data: &stored}}
+			handler := newEventsTestHandler(eventsService)
+
+			req := newPatchEventRequest(eventID, uuid.New(), `{"data":{"currency":"eur","note":"retried"}}`, time.Time{})
+			req.URL.RawQuery = strings.TrimPrefix(tt.query, "?")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			var got, want any
+			if err := json.Unmarshal(*eventsService.stored[eventID].Data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.expectedData), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("expected stored data %s, got %s", tt.expectedData, *eventsService.stored[eventID].Data)
+			}
+		})
+	}
+}
+
+func TestUpdateEventHandler_AbsentNullAndValue(t *testing.T) {
+	stored := json.RawMessage(`{"amount":100}`)
+
+	tests := []struct {
+		name                string
+		body                string
+		expectedStatus      int
+		expectedStatusValue models.EventStatusEnum
+		expectedData        string
+	}{
+		{name: "absent field is left unchanged", body: `{"status":"processed"}`, expectedStatus: http.StatusOK, expectedStatusValue: models.EventStatusEnumProcessed, expectedData: `{"amount":100}`},
+		{name: "explicit null is rejected", body: `{"status":"processed","data":null}`, expectedStatus: http.StatusBadRequest, expectedStatusValue: models.EventStatusEnumPending, expectedData: `{"amount":100}`},
+		{name: "value is applied", body: `{"data":{"amount":200}}`, expectedStatus: http.StatusOK, expectedStatusValue: models.EventStatusEnumPending, expectedData: `{"amount":200}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eventID := uuid.New()
+			eventsService := newTestEventsService()
+			eventsService.stored = map[uuid.UUID]models.Event{eventID: {ID: eventID, Status: models.EventStatusEnumPending, Data: &stored}}
+			handler := newEventsTestHandler(eventsService)
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, newPatchEventRequest(eventID, uuid.New(), tt.body, time.Time{}))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus == http.StatusBadRequest && !strings.Contains(w.Body.String(), "data") {
+				t.Errorf("expected the error to name the null field, got %s", w.Body.String())
+			}
+			event := eventsService.stored[eventID]
+			if event.Status != tt.expectedStatusValue {
+				t.Errorf("expected stored status %q, got %q", tt.expectedStatusValue, event.Status)
+			}
+			if string(*event.Data) != tt.expectedData {
+				t.Errorf("expected stored data %s, got %s", tt.expectedData, *event.Data)
+			}
+		})
+	}
+}
+
+// testRelatedEventsService serves related events; other methods panic via the nil embedded interface
+type testRelatedEventsService struct {
+	services.EventsService
+	related map[uuid.UUID][]models.Event
+	rule    models.CorrelationRule
+}
+
+func (s *testRelatedEventsService) GetRelatedEvents(_ context.Context, _ uuid.UUID, eventID uuid.UUID, rule models.CorrelationRule) ([]models.Event, error) {
+	s.rule = rule
+	related, ok := s.related[eventID]
+	if !ok {
+		return nil, services.ErrEventNotFound
+	}
+	return related, nil
+}
+
+func TestRelatedEventsHandler(t *testing.T) {
+	charge := uuid.New()
+	reversal := models.Event{ID: uuid.New(), ProviderID: uuid.New(), EventID: "rev_9"}
+	svc := &testRelatedEventsService{related: map[uuid.UUID][]models.Event{charge: {reversal}}}
+	rule := models.CorrelationRule{Keys: []string{"customer_id", "amount"}, Window: 24 * time.Hour}
+
+	logger := newTestLogger()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /events/{id}/related", RelatedEventsHandler(logger, svc, rule))
+	handler := middleware.TenantContext(logger, true, nil)(mux)
+
+	get := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/events/"+id+"/related", nil)
+		req.Header.Set("X-Tenant-ID", uuid.New().String())
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("returns related events", func(t *testing.T) {
+		w := get(charge.String())
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var body []EventResponse
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(body) != 1 || body[0].ID != reversal.ID {
+			t.Errorf("expected only the reversal, got %+v", body)
+		}
+		if !slices.Equal(svc.rule.Keys, rule.Keys) || svc.rule.Window != rule.Window {
+			t.Errorf("expected configured rule to be passed through, got %+v", svc.rule)
+		}
+	})
+
+	t.Run("unknown event", func(t *testing.T) {
+		if w := get(uuid.New().String()); w.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("invalid event id", func(t *testing.T) {
+		if w := get("not-a-uuid"); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+// testStatusHistoryService serves status histories; other methods panic via the nil embedded interface
+type testStatusHistoryService struct {
+	services.EventsService
+	history map[uuid.UUID][]models.EventStatusChange
+}
+
+func (s *testStatusHistoryService) GetEventStatusHistory(_ context.Context, eventID uuid.UUID, _ uuid.UUID) ([]models.EventStatusChange, error) {
+	history, ok := s.history[eventID]
+	if !ok {
+		return nil, services.ErrEventNotFound
+	}
+	return history, nil
+}
+
+func TestEventStatusHistoryHandler(t *testing.T) {
+	stuck := uuid.New()
+	changedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc := &testStatusHistoryService{history: map[uuid.UUID][]models.EventStatusChange{
+		stuck: {
+			{FromStatus: models.EventStatusEnumPending, ToStatus: models.EventStatusEnumFailed, ChangedAt: changedAt},
+			{FromStatus: models.EventStatusEnumFailed, ToStatus: models.EventStatusEnumPending, ChangedAt: changedAt.Add(time.Minute)},
+		},
+	}}
+
+	logger := newTestLogger()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /events/{id}/history", EventStatusHistoryHandler(logger, svc))
+	handler := middleware.TenantContext(logger, true, nil)(mux)
+
+	get := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/events/"+id+"/history", nil)
+		req.Header.Set("X-Tenant-ID", uuid.New().String())
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("returns changes in order", func(t *testing.T) {
+		w := get(stuck.String())
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var body []EventStatusChangeResponse
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(body) != 2 || body[0].ToStatus != models.EventStatusEnumFailed || body[1].ToStatus != models.EventStatusEnumPending {
+			t.Errorf("expected failed then pending, got %+v", body)
+		}
+	})
+
+	t.Run("unknown event", func(t *testing.T) {
+		if w := get(uuid.New().String()); w.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("invalid event id", func(t *testing.T) {
+		if w := get("not-a-uuid"); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+// testRecentEventsService records the requested count; other methods panic via the nil embedded interface
+type testRecentEventsService struct {
+	services.EventsService
+	events []models.Event
+	n      int
+}
+
+func (s *testRecentEventsService) GetRecentEvents(_ context.Context, _ uuid.UUID, n int) ([]models.Event, error) {
+	s.n = n
+	return s.events[:min(n, len(s.events))], nil
+}
+
+func TestRecentEventsHandler(t *testing.T) {
+	newest := models.Event{ID: uuid.New(), EventID: "evt_newest"}
+	older := models.Event{ID: uuid.New(), EventID: "evt_older"}
+	svc := &testRecentEventsService{events: []models.Event{newest, older}}
+
+	logger := newTestLogger()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /events/recent", RecentEventsHandler(logger, svc))
+	handler := middleware.TenantContext(logger, true, nil)(mux)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/events/recent"+query, nil)
+		req.Header.Set("X-Tenant-ID", uuid.New().String())
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("defaults to ten, keeping the service order", func(t *testing.T) {
+		w := get("")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var body []EventResponse
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if svc.n != defaultRecentEvents {
+			t.Errorf("expected n=%d, got %d", defaultRecentEvents, svc.n)
+		}
+		if len(body) != 2 || body[0].ID != newest.ID || body[1].ID != older.ID {
+			t.Errorf("expected newest then older, got %+v", body)
+		}
+	})
+
+	t.Run("n is capped", func(t *testing.T) {
+		if w := get("?n=5000"); w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if svc.n != services.MaxRecentEvents {
+			t.Errorf("expected n to be capped at %d, got %d", services.MaxRecentEvents, svc.n)
+		}
+	})
+
+	for _, query := range []string{"?n=0", "?n=-3", "?n=ten"} {
+		t.Run("invalid "+query, func(t *testing.T) {
+			if w := get(query); w.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+			}
+		})
+	}
+}
+
+// testListEventsService filters an in-memory event list; other methods panic via the nil embedded interface
+type testListEventsService struct {
+	services.EventsService
+	events []models.Event
+}
+
+func (s *testListEventsService) ListEvents(_ context.Context, _ uuid.UUID, filter models.EventFilter, params models.PaginationParams) (models.PaginatedResponse[models.Event], error) {
+	var matched []models.Event
+	for _, event := range s.events {
+		if len(filter.EventTypes) > 0 && !slices.Contains(filter.EventTypes, event.EventType) {
+			continue
+		}
+		if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, event.Status) {
+			continue
+		}
+		if len(filter.ProviderIDs) > 0 && !slices.Contains(filter.ProviderIDs, event.ProviderID) {
+			continue
+		}
+		matched = append(matched, event)
+	}
+	end := min(int(params.Offset+params.Limit), len(matched))
+	start := min(int(params.Offset), end)
+	return models.NewPaginatedResponse(matched[start:end], int64(len(matched)), params.Limit, params.Offset), nil
+}
+
+func TestListEventsHandler_MultiValueFilters(t *testing.T) {
+	stripeID, bankID := uuid.New(), uuid.New()
+	svc := &testListEventsService{events: []models.Event{
+		{ID: uuid.New(), ProviderID: stripeID, EventID: "failed", EventType: models.EventTypeEnumPaymentFailed, Status: models.EventStatusEnumPending},
+		{ID: uuid.New(), ProviderID: stripeID, EventID: "refunded", EventType: models.EventTypeEnumPaymentRefunded, Status: models.EventStatusEnumFailed},
+		{ID: uuid.New(), ProviderID: bankID, EventID: "succeeded", EventType: models.EventTypeEnumPaymentSucceeded, Status: models.EventStatusEnumPending},
+		{ID: uuid.New(), ProviderID: stripeID, EventID: "refunded-processed", EventType: models.EventTypeEnumPaymentRefunded, Status: models.EventStatusEnumProcessed},
+	}}
+	logger := newTestLogger()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /events", ListEventsHandler(logger, svc))
+	handler := middleware.TenantContext(logger, true, nil)(mux)
+
+	list := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/events?"+query, nil)
+		req.Header.Set("X-Tenant-ID", uuid.New().String())
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"no filter", "", []string{"failed", "refunded", "succeeded", "refunded-processed"}},
+		{"comma-separated types", "event_type=payment_failed,payment_refunded", []string{"failed", "refunded", "refunded-processed"}},
+		{"repeated statuses", "status=pending&status=failed", []string{"failed", "refunded", "succeeded"}},
+		{"types and statuses combined", "event_type=payment_failed,payment_refunded&status=pending,failed", []string{"failed", "refunded"}},
+		{"provider and status combined", "provider_id=" + bankID.String() + "&status=pending", []string{"succeeded"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := list(tt.query)
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+			var body models.PaginatedResponse[EventResponse]
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			var got []string
+			for _, event := range body.Items {
+				got = append(got, event.EventID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expected events %v, got %v", tt.want, got)
+			}
+			if body.TotalCount != int64(len(tt.want)) {
+				t.Errorf("expected total_count %d, got %d", len(tt.want), body.TotalCount)
+			}
+		})
+	}
+
+	t.Run("invalid value in list", func(t *testing.T) {
+		w := list("event_type=payment_failed,payment_exploded")
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+		if !strings.Contains(w.Body.String(), "payment_exploded") {
+			t.Errorf("expected the invalid value to be named in the error, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("invalid status in list", func(t *testing.T) {
+		if w := list("status=pending,archived"); w.Code != http.StatusBadRequest {
+			t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("invalid provider id", func(t *testing.T) {
+		if w := list("provider_id=stripe"); w.Code != http.StatusBadRequest {
+			t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+// testReprocessService returns a canned reprocess result and records the cursor and limit it was called with
+type testReprocessService struct {
+	services.EventsService
+	result models.ReprocessResult
+	err    error
+	calls  int
+	cursor uuid.UUID
+	limit  int
+}
+
+func (s *testReprocessService) ReprocessFailedEvents(_ context.Context, _ uuid.UUID, cursor uuid.UUID, limit int) (models.ReprocessResult, error) {
+	s.calls++
+	s.cursor, s.limit = cursor, limit
+	return s.result, s.err
+}
+
+func TestReprocessFailedEventsHandler(t *testing.T) {
+	cursor, next := uuid.New(), uuid.New()
+	leak := models.Leak{ID: uuid.New(), LeakType: models.LeakTypeEnumFailedPayments}
+
+	tests := []struct {
+		name           string
+		query          string
+		result         models.ReprocessResult
+		err            error
+		wantStatus     int
+		wantCursor     uuid.UUID
+		wantLimit      int
+		wantNextCursor string
+		wantDetections int
+	}{
+		{
+			name:           "first batch with more to go",
+			result:         models.ReprocessResult{Scanned: 100, Reprocessed: 60, StillFailed: 40, NextCursor: next},
+			wantStatus:     http.StatusOK,
+			wantLimit:      defaultReprocessBatchSize,
+			wantNextCursor: next.String(),
+			wantDetections: 1,
+		},
+		{
+			name:           "resumes from the cursor",
+			query:          "?cursor=" + cursor.String() + "&limit=10",
+			result:         models.ReprocessResult{Scanned: 3, StillFailed: 3, NextCursor: next, Done: true},
+			wantStatus:     http.StatusOK,
+			wantCursor:     cursor,
+			wantLimit:      10,
+			wantDetections: 0,
+		},
+		{name: "invalid cursor", query: "?cursor=nope", wantStatus: http.StatusBadRequest},
+		{name: "limit too large", query: "?limit=501", wantStatus: http.StatusBadRequest},
+		{name: "zero limit", query: "?limit=0", wantStatus: http.StatusBadRequest},
+		{name: "service failure", err: errors.New("db down"), wantStatus: http.StatusInternalServerError, wantLimit: defaultReprocessBatchSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &testReprocessService{result: tt.result, err: tt.err}
+			detector := &testLeakDetector{report: detection.Report{Created: []models.Leak{leak}}}
+			logger := newTestLogger()
+			mux := http.NewServeMux()
+			mux.HandleFunc("POST /events/reprocess-failed", ReprocessFailedEventsHandler(logger, svc, detector))
+
+			req := httptest.NewRequest(http.MethodPost, "/events/reprocess-failed"+tt.query, nil)
+			req.Header.Set("X-Tenant-ID", uuid.New().String())
+			rec := httptest.NewRecorder()
+			middleware.TenantContext(logger, true, nil)(mux).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if tt.wantLimit == 0 {
+				if svc.calls != 0 {
+					t.Errorf("expected the service not to be called for a bad request")
+				}
+				return
+			}
+			if svc.cursor != tt.wantCursor || svc.limit != tt.wantLimit {
+				t.Errorf("expected cursor %s limit %d, got %s %d", tt.wantCursor, tt.wantLimit, svc.cursor, svc.limit)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp ReprocessFailedResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Reprocessed != tt.result.Reprocessed || resp.StillFailed != tt.result.StillFailed || resp.Done != tt.result.Done {
+				t.Errorf("unexpected counts %+v for result %+v", resp, tt.result)
+			}
+			if resp.NextCursor != tt.wantNextCursor {
+				t.Errorf("expected next_cursor %q, got %q", tt.wantNextCursor, resp.NextCursor)
+			}
+			if detector.calls != tt.wantDetections || resp.LeaksCreated != tt.wantDetections {
+				t.Errorf("expected %d detection runs and leaks, got %d and %d", tt.wantDetections, detector.calls, resp.LeaksCreated)
+			}
+		})
+	}
+}
+
+// testValidateStoredService returns a canned validation report and records what it was asked to check
+type testValidateStoredService struct {
+	services.EventsService
+	report   models.StoredEventsValidation
+	err      error
+	calls    int
+	tenantID uuid.UUID
+	params   models.ValidateStoredEventsParams
+}
+
+func (s *testValidateStoredService) ValidateStoredEvents(_ context.Context, tenantID uuid.UUID, params models.ValidateStoredEventsParams) (models.StoredEventsValidation, error) {
+	s.calls++
+	s.tenantID, s.params = tenantID, params
+	return s.report, s.err
+}
+
+func TestValidateStoredEventsHandler(t *testing.T) {
+	tenantID := uuid.New()
+	invalid := models.InvalidStoredEvent{ID: uuid.New(), Reason: models.ErrInvalidEventData.Error()}
+
+	tests := []struct {
+		name          string
+		query         string
+		err           error
+		wantStatus    int
+		wantMaxEvents int
+		wantStatuses  []models.EventStatusEnum
+	}{
+		{name: "defaults", query: "?tenant_id=" + tenantID.String(), wantStatus: http.StatusOK, wantMaxEvents: defaultValidateStoredMaxEvents},
+		{
+			name:          "filter and cap",
+			query:         "?tenant_id=" + tenantID.String() + "&status=processed,failed&max_events=50",
+			wantStatus:    http.StatusOK,
+			wantMaxEvents: 50,
+			wantStatuses:  []models.EventStatusEnum{models.EventStatusEnumProcessed, models.EventStatusEnumFailed},
+		},
+		{name: "missing tenant", wantStatus: http.StatusBadRequest},
+		{name: "invalid tenant", query: "?tenant_id=nope", wantStatus: http.StatusBadRequest},
+		{name: "invalid status", query: "?tenant_id=" + tenantID.String() + "&status=nope", wantStatus: http.StatusBadRequest},
+		{name: "max_events too large", query: "?tenant_id=" + tenantID.String() + "&max_events=100001", wantStatus: http.StatusBadRequest},
+		{name: "service failure", query: "?tenant_id=" + tenantID.String(), err: errors.New("db down"), wantStatus: http.StatusInternalServerError, wantMaxEvents: defaultValidateStoredMaxEvents},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &testValidateStoredService{report: models.StoredEventsValidation{Scanned: 3, Invalid: []models.InvalidStoredEvent{invalid}}, err: tt.err}
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /admin/events/validate-stored", ValidateStoredEventsHandler(newTestLogger(), svc))
+
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/events/validate-stored"+tt.query, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if tt.wantMaxEvents == 0 {
+				if svc.calls != 0 {
+					t.Errorf("expected the service not to be called for a bad request")
+				}
+				return
+			}
+			if svc.tenantID != tenantID || svc.params.MaxEvents != tt.wantMaxEvents || !slices.Equal(svc.params.Filter.Statuses, tt.wantStatuses) {
+				t.Errorf("unexpected service call for tenant %s with %+v", svc.tenantID, svc.params)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp ValidateStoredEventsResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.TenantID != tenantID || resp.Scanned != 3 || len(resp.Invalid) != 1 || resp.Invalid[0] != invalid {
+				t.Errorf("unexpected response %+v", resp)
+			}
+		})
+	}
+}
+
+// testSearchEventsService matches event IDs by prefix in memory, validating the prefix like the real service
+type testSearchEventsService struct {
+	services.EventsService
+	events []models.Event
+}
+
+func (s *testSearchEventsService) SearchEventsByExternalID(_ context.Context, _ uuid.UUID, prefix string, params models.PaginationParams) (models.PaginatedResponse[models.Event], error) {
+	if prefix == "" || len(prefix) > services.MaxExternalIDPrefixLength {
+		return models.PaginatedResponse[models.Event]{}, services.ErrInvalidSearchPrefix
+	}
+	matched := []models.Event{}
+	for _, event := range s.events {
+		if strings.HasPrefix(event.EventID, prefix) {
+			matched = append(matched, event)
+		}
+	}
+	return models.NewPaginatedResponse(matched, int64(len(matched)), params.Limit, params.Offset), nil
+}
+
+func TestSearchEventsHandler(t *testing.T) {
+	svc := &testSearchEventsService{events: []models.Event{
+		{ID: uuid.New(), EventID: "evt_1"},
+		{ID: uuid.New(), EventID: "evt_2"},
+		{ID: uuid.New(), EventID: "ch_1"},
+	}}
+	logger := newTestLogger()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /events/search", SearchEventsHandler(logger, svc))
+	handler := middleware.TenantContext(logger, true, nil)(mux)
+
+	search := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/events/search?"+query, nil)
+		req.Header.Set("X-Tenant-ID", uuid.New().String())
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	decode := func(t *testing.T, w *httptest.ResponseRecorder) []string {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var body models.PaginatedResponse[EventResponse]
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		got := []string{}
+		for _, event := range body.Items {
+			got = append(got, event.EventID)
+		}
+		return got
+	}
+
+	t.Run("matching prefix", func(t *testing.T) {
+		if got := decode(t, search("external_id=evt_")); !slices.Equal(got, []string{"evt_1", "evt_2"}) {
+			t.Errorf("expected evt_1 and evt_2, got %v", got)
+		}
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		if got := decode(t, search("external_id=sub_")); len(got) != 0 {
+			t.Errorf("expected no events, got %v", got)
+		}
+	})
+
+	for name, query := range map[string]string{
+		"missing prefix":  "",
+		"empty prefix":    "external_id=",
+		"prefix too long": "external_id=" + strings.Repeat("x", services.MaxExternalIDPrefixLength+1),
+		"invalid limit":   "external_id=evt_&limit=-1",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if w := search(query); w.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+			}
+		})
+	}
+}
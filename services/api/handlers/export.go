@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/domain/services"
+	"rdl-api/internal/metrics"
+	"rdl-api/internal/middleware"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// exportPageSize is the number of events fetched from the database per page while streaming
+const exportPageSize int32 = 500
+
+// ExportFormatCSV is the only format GET /leaks/export writes, and the default
+const ExportFormatCSV = "csv"
+
+// leakExportColumns is the header row of the leaks CSV export
+var leakExportColumns = []string{"detected_at", "type", "status", "amount", "currency", "customer", "source_event_id"}
+
+// ExportMetadata is the trailing NDJSON line written when an export stops at the row cap
+type ExportMetadata struct {
+	Truncated bool `json:"truncated"`
+	MaxRows   int  `json:"max_rows"`
+}
+
+// ExportLine wraps ExportMetadata so it can be told apart from event lines in the stream
+type ExportLine struct {
+	Meta ExportMetadata `json:"_meta"`
+}
+
+// ExportEventsHandler returns a handler for GET /events/export that streams the tenant's
+// events as NDJSON, one event per line, newest first. Events are read in keyset pages, so the
+// export never holds the whole set in memory nor counts the events per page. When maxRows is
+// greater than zero the stream stops after maxRows events and, if more events exist, ends with
+// an ExportLine truncation marker.
+func ExportEventsHandler(logger *slog.Logger, eventsService services.EventsService, maxRows int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, ok := middleware.GetTenantID(r)
+		if !ok {
+			WriteRejection(r.Context(), w, logger, metrics.ReasonInvalidTenant, ErrorCodeUnauthorized, middleware.ErrMissingOrInvalidTenantContext, http.StatusUnauthorized)
+			return
+		}
+
+		flusher, _ := w.(http.Flusher)
+		encoder := json.NewEncoder(w)
+		var cursor models.EventCursor
+
+		written := 0
+		truncated := false
+		started := false
+
+	pages:
+		for {
+			page, err := eventsService.ListEventsForExport(r.Context(), tenantID, cursor, exportPageSize)
+			if err != nil {
+				logger.Log(r.Context(), serviceErrorLevel(err), "Failed to export events", "error", err, "tenant_id", tenantID, "rows_written", written)
+				if !started {
+					WriteServerError(r.Context(), w, logger, err)
+				}
+				// Headers are already sent; ending the stream early is all we can do
+				return
+			}
+
+			if !started {
+				w.Header().Set("Content-Type", "application/x-ndjson")
+				SetCacheHeaders(w, 0)
+				w.WriteHeader(http.StatusOK)
+				started = true
+			}
+
+			for _, event := range page {
+				if maxRows > 0 && written >= maxRows {
+					truncated = true
+					break pages
+				}
+				if err := encoder.Encode(NewEventResponse(event, timeFormatFrom(r.Context()))); err != nil {
+					logger.ErrorContext(r.Context(), "Failed to write export line", "error", err, "tenant_id", tenantID)
+					return
+				}
+				written++
+			}
+
+			if flusher != nil {
+				flusher.Flush()
+			}
+
+			if len(page) < int(exportPageSize) {
+				break
+			}
+			last := page[len(page)-1]
+			cursor = models.EventCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+		}
+
+		if truncated {
+			logger.WarnContext(r.Context(), "Export truncated at row cap", "tenant_id", tenantID, "max_rows", maxRows)
+			if err := encoder.Encode(ExportLine{Meta: ExportMetadata{Truncated: true, MaxRows: maxRows}}); err != nil {
+				logger.ErrorContext(r.Context(), "Failed to write export truncation marker", "error", err, "tenant_id", tenantID)
+				return
+			}
+		}
+
+		logger.InfoContext(r.Context(), "Events exported", "tenant_id", tenantID, "rows_written", written, "truncated", truncated)
+	}
+}
+
+// ExportLeaksHandler returns a handler for GET /leaks/export that streams the tenant's leaks as
+// CSV, oldest detection first, for finance teams to open in a spreadsheet. It takes the same
+// filters as GET /leaks and format=csv, the default. Leaks are read in keyset pages, so the
+// export never holds the whole set in memory. Times are RFC 3339 in UTC; customer and
+// source_event_id are empty for leaks without one.
+func ExportLeaksHandler(logger *slog.Logger, leaksService services.LeaksService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		tenantID, ok := middleware.GetTenantID(r)
+		if !ok {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidTenant, ErrorCodeUnauthorized, middleware.ErrMissingOrInvalidTenantContext, http.StatusUnauthorized)
+			return
+		}
+
+		query := r.URL.Query()
+		if format := query.Get("format"); format != "" && format != ExportFormatCSV {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, fmt.Errorf("%w: %q", ErrInvalidExportFormat, format), http.StatusBadRequest)
+			return
+		}
+		filter, err := parseLeakFilter(query)
+		if err != nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, err, http.StatusBadRequest)
+			return
+		}
+
+		flusher, _ := w.(http.Flusher)
+		writer := csv.NewWriter(w)
+		var cursor models.LeakCursor
+		written := 0
+		started := false
+
+		for {
+			page, err := leaksService.ListLeaksAfter(ctx, tenantID, filter, cursor, exportPageSize)
+			if err != nil {
+				logger.Log(ctx, serviceErrorLevel(err), "Failed to export leaks", "error", err, "tenant_id", tenantID, "rows_written", written)
+				if !started {
+					WriteServerError(ctx, w, logger, err)
+				}
+				// Headers are already sent; ending the stream early is all we can do
+				return
+			}
+
+			if !started {
+				w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+				w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="leaks-%s.csv"`, time.Now().UTC().Format("2006-01-02")))
+				SetCacheHeaders(w, 0)
+				w.WriteHeader(http.StatusOK)
+				started = true
+				_ = writer.Write(leakExportColumns)
+			}
+
+			for _, leak := range page {
+				_ = writer.Write(leakCSVRecord(leak))
+				written++
+			}
+			writer.Flush()
+			if err := writer.Error(); err != nil {
+				logger.ErrorContext(ctx, "Failed to write leak export", "error", err, "tenant_id", tenantID)
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+
+			if len(page) < int(exportPageSize) {
+				break
+			}
+			last := page[len(page)-1]
+			cursor = models.LeakCursor{DetectedAt: last.DetectedAt, ID: last.ID}
+		}
+
+		logger.InfoContext(ctx, "Leaks exported", "tenant_id", tenantID, "rows_written", written)
+	}
+}
+
+// leakCSVRecord is one leak as a row of the CSV export, in leakExportColumns order
+func leakCSVRecord(leak models.Leak) []string {
+	customer := ""
+	if leak.CustomerID != uuid.Nil {
+		customer = leak.CustomerID.String()
+	}
+	sourceEventID := ""
+	if leak.SourceEventID != nil {
+		sourceEventID = leak.SourceEventID.String()
+	}
+	return []string{
+		leak.DetectedAt.UTC().Format(time.RFC3339),
+		string(leak.LeakType),
+		string(leak.Status),
+		leak.Amount.String(),
+		leak.Currency,
+		customer,
+		sourceEventID,
+	}
+}
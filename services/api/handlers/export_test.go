@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/domain/services"
+	"rdl-api/internal/middleware"
+
+	"github.com/google/uuid"
+)
+
+func newExportTestService(count int) *testEventsService {
+	eventsService := newTestEventsService()
+	for i := 0; i < count; i++ {
+		eventsService.eventList = append(eventsService.eventList, models.Event{ID: uuid.New(), EventID: "evt_" + uuid.NewString()})
+	}
+	return eventsService
+}
+
+func runExport(t *testing.T, eventsService *testEventsService, maxRows int) []string {
+	t.Helper()
+
+	logger := newTestLogger()
+	handler := middleware.TenantContext(logger, true, nil)(ExportEventsHandler(logger, eventsService, maxRows))
+
+	req := httptest.NewRequest(http.MethodGet, "/events/export", nil)
+	req.Header.Set("X-Tenant-ID", uuid.NewString())
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/x-ndjson" {
+		t.Errorf("expected Content-Type application/x-ndjson, got %q", contentType)
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(w.Body.String()))
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
+
+func TestExportEventsHandler_StopsAtMaxRows(t *testing.T) {
+	lines := runExport(t, newExportTestService(5), 3)
+
+	if len(lines) != 4 {
+		t.Fatalf("expected 3 event lines and a truncation marker, got %d lines", len(lines))
+	}
+
+	for _, line := range lines[:3] {
+		var event models.Event
+		if err := json.Unmarshal([]byte(line), &event); err != nil || event.ID == uuid.Nil {
+			t.Errorf("expected an event line, got %q", line)
+		}
+	}
+
+	var marker ExportLine
+	if err := json.Unmarshal([]byte(lines[3]), &marker); err != nil {
+		t.Fatalf("failed to decode truncation marker: %v", err)
+	}
+	if !marker.Meta.Truncated || marker.Meta.MaxRows != 3 {
+		t.Errorf("expected truncation marker with max_rows 3, got %+v", marker.Meta)
+	}
+}
+
+func TestExportEventsHandler_StreamsKeysetPages(t *testing.T) {
+	count := 2*int(exportPageSize) + 1
+	eventsService := newExportTestService(count)
+	lines := runExport(t, eventsService, 0)
+
+	if len(lines) != count {
+		t.Fatalf("expected %d lines, got %d", count, len(lines))
+	}
+	for i, line := range lines {
+		var event models.Event
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("failed to decode line %d: %v", i, err)
+		}
+		if event.ID != eventsService.eventList[i].ID {
+			t.Fatalf("line %d: expected event %s, got %s", i, eventsService.eventList[i].ID, event.ID)
+		}
+	}
+	if eventsService.exportPages != 3 {
+		t.Errorf("expected 3 pages, got %d", eventsService.exportPages)
+	}
+}
+
+func TestExportEventsHandler_NoMarkerWithinCap(t *testing.T) {
+	tests := []struct {
+		name    string
+		count   int
+		maxRows int
+	}{
+		{name: "unlimited", count: 5, maxRows: 0},
+		{name: "exactly at cap", count: 3, maxRows: 3},
+		{name: "below cap", count: 2, maxRows: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := runExport(t, newExportTestService(tt.count), tt.maxRows)
+
+			if len(lines) != tt.count {
+				t.Fatalf("expected %d lines, got %d", tt.count, len(lines))
+			}
+			for _, line := range lines {
+				if strings.Contains(line, `"_meta"`) {
+					t.Errorf("unexpected truncation marker: %q", line)
+				}
+			}
+		})
+	}
+}
+
+// exportLeaksService serves ListLeaksAfter from an in-memory list, applying the status and
+// leak type filters and the keyset cursor like the repository does
+type exportLeaksService struct {
+	services.LeaksService
+	leaks []models.Leak
+	pages int
+}
+
+func (s *exportLeaksService) ListLeaksAfter(_ context.Context, _ uuid.UUID, filter models.LeakFilter, after models.LeakCursor, limit int32) ([]models.Leak, error) {
+	s.pages++
+	sort.Slice(s.leaks, func(i, j int) bool { return s.leaks[i].DetectedAt.Before(s.leaks[j].DetectedAt) })
+
+	var page []models.Leak
+	for _, leak := range s.leaks {
+		if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, leak.Status) {
+			continue
+		}
+		if len(filter.LeakTypes) > 0 && !slices.Contains(filter.LeakTypes, leak.LeakType) {
+			continue
+		}
+		if after != (models.LeakCursor{}) && !leak.DetectedAt.After(after.DetectedAt) {
+			continue
+		}
+		page = append(page, leak)
+		if len(page) == int(limit) {
+			break
+		}
+	}
+	return page, nil
+}
+
+func runLeaksExport(t *testing.T, leaksService services.LeaksService, query string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	logger := newTestLogger()
+	handler := middleware.TenantContext(logger, true, nil)(ExportLeaksHandler(logger, leaksService))
+
+	req := httptest.NewRequest(http.MethodGet, "/leaks/export"+query, nil)
+	req.Header.Set("X-Tenant-ID", uuid.NewString())
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func readCSV(t *testing.T, body string) [][]string {
+	t.Helper()
+	records, err := csv.NewReader(strings.NewReader(body)).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse CSV: %v", err)
+	}
+	return records
+}
+
+func TestExportLeaksHandler_WritesCSV(t *testing.T) {
+	detectedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	customerID := uuid.New()
+	sourceEventID := uuid.New()
+	leaksService := &exportLeaksService{leaks: []models.Leak{
+		{ID: uuid.New(), DetectedAt: detectedAt.Add(time.Hour), LeakType: models.LeakTypeEnumVolumeAnomaly, Status: models.LeakStatusEnumOpen, Amount: models.MustParseDecimal("0"), Currency: "USD"},
+		{ID: uuid.New(), DetectedAt: detectedAt, LeakType: models.LeakTypeEnumDuplicateCharge, Status: models.LeakStatusEnumOpen, Amount: models.MustParseDecimal("49.99"), Currency: "EUR", CustomerID: customerID, SourceEventID: &sourceEventID},
+	}}
+
+	w := runLeaksExport(t, leaksService, "?format=csv")
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/csv") {
+		t.Errorf("expected a text/csv Content-Type, got %q", contentType)
+	}
+	if disposition := w.Header().Get("Content-Disposition"); !strings.HasPrefix(disposition, `attachment; filename="leaks-`) || !strings.HasSuffix(disposition, `.csv"`) {
+		t.Errorf("expected an attachment filename, got %q", disposition)
+	}
+
+	records := readCSV(t, w.Body.String())
+	want := [][]string{
+		{"detected_at", "type", "status", "amount", "currency", "customer", "source_event_id"},
+		{"2025-03-01T12:00:00Z", "duplicate_charge", "open", "49.99", "EUR", customerID.String(), sourceEventID.String()},
+		{"2025-03-01T13:00:00Z", "volume_anomaly", "open", "0", "USD", "", ""},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("expected %d rows, got %d: %v", len(want), len(records), records)
+	}
+	for i := range want {
+		if !slices.Equal(records[i], want[i]) {
+			t.Errorf("row %d: expected %v, got %v", i, want[i], records[i])
+		}
+	}
+}
+
+func TestExportLeaksHandler_AppliesFilters(t *testing.T) {
+	detectedAt := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	leaksService := &exportLeaksService{leaks: []models.Leak{
+		{ID: uuid.New(), DetectedAt: detectedAt, LeakType: models.LeakTypeEnumDuplicateCharge, Status: models.LeakStatusEnumOpen, Currency: "USD"},
+		{ID: uuid.New(), DetectedAt: detectedAt.Add(time.Minute), LeakType: models.LeakTypeEnumDuplicateCharge, Status: models.LeakStatusEnumResolved, Currency: "USD"},
+		{ID: uuid.New(), DetectedAt: detectedAt.Add(2 * time.Minute), LeakType: models.LeakTypeEnumVolumeAnomaly, Status: models.LeakStatusEnumResolved, Currency: "USD"},
+	}}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{name: "open leaks by default", query: "", expected: []string{"duplicate_charge/open"}},
+		{name: "status filter", query: "?status=resolved", expected: []string{"duplicate_charge/resolved", "volume_anomaly/resolved"}},
+		{name: "status and type filters", query: "?status=open,resolved&leak_type=volume_anomaly", expected: []string{"volume_anomaly/resolved"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := runLeaksExport(t, leaksService, tt.query)
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+
+			var got []string
+			for _, record := range readCSV(t, w.Body.String())[1:] {
+				got = append(got, record[1]+"/"+record[2])
+			}
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestExportLeaksHandler_StreamsEveryPage(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	leaksService := &exportLeaksService{}
+	count := int(exportPageSize) + 3
+	for i := 0; i < count; i++ {
+		leaksService.leaks = append(leaksService.leaks, models.Leak{ID: uuid.New(), DetectedAt: start.Add(time.Duration(i) * time.Second), LeakType: models.LeakTypeEnumOther, Status: models.LeakStatusEnumOpen, Currency: "USD"})
+	}
+
+	w := runLeaksExport(t, leaksService, "")
+
+	if rows := len(readCSV(t, w.Body.String())) - 1; rows != count {
+		t.Fatalf("expected %d leak rows, got %d", count, rows)
+	}
+	if leaksService.pages != 2 {
+		t.Errorf("expected 2 pages to be read, got %d", leaksService.pages)
+	}
+}
+
+func TestExportLeaksHandler_RejectsInvalidParameters(t *testing.T) {
+	for _, query := range []string{"?format=xlsx", "?status=bogus", "?detected_from=yesterday"} {
+		t.Run(query, func(t *testing.T) {
+			w := runLeaksExport(t, &exportLeaksService{}, query)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+			}
+		})
+	}
+}
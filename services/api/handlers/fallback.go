@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// NotFoundHandler returns a handler that responds with a JSON 404 error envelope
+func NotFoundHandler(logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.DebugContext(r.Context(), "Route not found", "method", r.Method, "path", r.URL.Path)
+		WriteJSONError(r.Context(), w, logger, ErrorCodeNotFound, ErrNotFound, http.StatusNotFound)
+	}
+}
+
+// MethodNotAllowedHandler returns a handler that responds with a JSON 405 error envelope
+func MethodNotAllowedHandler(logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.DebugContext(r.Context(), "Method not allowed", "method", r.Method, "path", r.URL.Path)
+		WriteJSONError(r.Context(), w, logger, ErrorCodeMethodNotAllowed, ErrMethodNotAllowed, http.StatusMethodNotAllowed)
+	}
+}
+
+// WithJSONFallbacks wraps the mux so that requests it cannot route get a JSON error
+// envelope instead of the default plain-text responses. Registered routes are served
+// by the mux untouched, so the fallbacks never shadow a real route.
+func WithJSONFallbacks(mux *http.ServeMux, logger *slog.Logger) http.Handler {
+	notFound := NotFoundHandler(logger)
+	methodNotAllowed := MethodNotAllowedHandler(logger)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h, pattern := mux.Handler(r)
+		if pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		// The mux has no route for this request; run its internal handler against a
+		// recorder to learn whether the path exists for other methods (405) or not (404)
+		rec := &fallbackRecorder{header: http.Header{}}
+		h.ServeHTTP(rec, r)
+
+		if rec.statusCode == http.StatusMethodNotAllowed {
+			if allow := rec.header.Get("Allow"); allow != "" {
+				w.Header().Set("Allow", allow)
+			}
+			methodNotAllowed(w, r)
+			return
+		}
+
+		notFound(w, r)
+	})
+}
+
+// fallbackRecorder captures the status code and headers written by the mux's internal handlers
+type fallbackRecorder struct {
+	header     http.Header
+	statusCode int
+}
+
+func (f *fallbackRecorder) Header() http.Header {
+	return f.header
+}
+
+func (f *fallbackRecorder) Write(b []byte) (int, error) {
+	if f.statusCode == 0 {
+		f.statusCode = http.StatusOK
+	}
+	return len(b), nil
+}
+
+func (f *fallbackRecorder) WriteHeader(statusCode int) {
+	if f.statusCode == 0 {
+		f.statusCode = statusCode
+	}
+}
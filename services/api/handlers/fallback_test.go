@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newFallbackTestMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /live", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return mux
+}
+
+func TestWithJSONFallbacks(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		expectedStatus int
+		expectedCode   string
+		expectedAllow  string
+	}{
+		{
+			name:           "unknown path returns JSON 404",
+			method:         http.MethodGet,
+			path:           "/does-not-exist",
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   ErrorCodeNotFound,
+		},
+		{
+			name:           "wrong method returns JSON 405",
+			method:         http.MethodPost,
+			path:           "/live",
+			expectedStatus: http.StatusMethodNotAllowed,
+			expectedCode:   ErrorCodeMethodNotAllowed,
+			expectedAllow:  "GET, HEAD",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := WithJSONFallbacks(newFallbackTestMux(), newTestLogger())
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if contentType := w.Header().Get("Content-Type"); contentType != "application/json" {
+				t.Errorf("expected Content-Type application/json, got %q", contentType)
+			}
+			if allow := w.Header().Get("Allow"); allow != tt.expectedAllow {
+				t.Errorf("expected Allow %q, got %q", tt.expectedAllow, allow)
+			}
+
+			var response ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode JSON response: %v", err)
+			}
+			if response.Error.Code != tt.expectedCode {
+				t.Errorf("expected code %q, got %q", tt.expectedCode, response.Error.Code)
+			}
+		})
+	}
+}
+
+func TestWithJSONFallbacksDoesNotShadowRoutes(t *testing.T) {
+	handler := WithJSONFallbacks(newFallbackTestMux(), newTestLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/live", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
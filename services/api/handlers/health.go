@@ -3,27 +3,146 @@ package handlers
 import (
 	"log/slog"
 	"net/http"
+	"rdl-api/internal/detection"
 	"rdl-api/internal/domain/services"
 	"time"
 )
 
+// Values of HealthResponse.Status
+const (
+	HealthStatusOK       = "OK"
+	HealthStatusDegraded = "DEGRADED"
+)
+
+// Values of DetectionRunStatus.Status
+const (
+	DetectionRunOK    = "ok"
+	DetectionRunError = "error"
+)
+
+// Values of ComponentStatus.Status
+const (
+	ComponentUp   = "up"
+	ComponentDown = "down"
+)
+
 // HealthResponse represents the health check response
 type HealthResponse struct {
-	Status    string    `json:"status"`
-	Timestamp time.Time `json:"timestamp"`
-	Version   string    `json:"version,omitempty"`
+	// Status is "OK", or "DEGRADED" while a non-critical component is down
+	Status    string  `json:"status"`
+	Timestamp APITime `json:"timestamp"`
+	Version   string  `json:"version,omitempty"`
+	// Components is the status of each checked component, keyed by name; liveness leaves it out
+	Components map[string]ComponentStatus `json:"components,omitempty"`
+	// Detection is the last leak detection run; only the health detail endpoint reports it,
+	// and only once a run has happened
+	Detection *DetectionRunStatus `json:"detection,omitempty"`
 }
 
-// ReadyHandler returns a health check handler
-func ReadyHandler(logger *slog.Logger, healthService services.HealthService) http.HandlerFunc {
+// ComponentStatus describes one component checked by the health endpoints. The error itself is
+// left out because the endpoints are unauthenticated; it is logged instead.
+type ComponentStatus struct {
+	Status   string `json:"status"`
+	Critical bool   `json:"critical"`
+}
+
+// NewComponentStatuses converts the components of a health report to their API representation
+func NewComponentStatuses(components []services.ComponentHealth) map[string]ComponentStatus {
+	if len(components) == 0 {
+		return nil
+	}
+	statuses := make(map[string]ComponentStatus, len(components))
+	for _, component := range components {
+		status := ComponentUp
+		if component.Err != nil {
+			status = ComponentDown
+		}
+		statuses[component.Name] = ComponentStatus{Status: status, Critical: component.Critical}
+	}
+	return statuses
+}
+
+// DetectionRunStatus describes the most recent leak detection run. The tenant is left out
+// because the health endpoint is unauthenticated; per-tenant figures are in the metrics.
+type DetectionRunStatus struct {
+	// Status is "error" when any rule or store failed during the run, and "ok" otherwise
+	Status        string         `json:"status"`
+	StartedAt     APITime        `json:"started_at"`
+	DurationMs    int64          `json:"duration_ms"`
+	DryRun        bool           `json:"dry_run"`
+	EventsScanned int64          `json:"events_scanned"`
+	Candidates    int            `json:"candidates"`
+	Created       int            `json:"created"`
+	CreatedByType map[string]int `json:"created_by_type"`
+	// Truncated is set when the run stopped storing at the per-run leak cap
+	Truncated bool `json:"truncated"`
+}
+
+// NewDetectionRunStatus converts a detector's last-run status to its API representation
+func NewDetectionRunStatus(run detection.RunStatus, format TimeFormat) *DetectionRunStatus {
+	status := DetectionRunOK
+	if run.Err != nil {
+		status = DetectionRunError
+	}
+	return &DetectionRunStatus{
+		Status:        status,
+		StartedAt:     NewAPITime(run.StartedAt.UTC(), format),
+		DurationMs:    run.Duration.Milliseconds(),
+		DryRun:        run.DryRun,
+		EventsScanned: run.EventsScanned,
+		Candidates:    run.Candidates,
+		Created:       run.Created(),
+		CreatedByType: run.CreatedByType,
+		Truncated:     run.Truncated,
+	}
+}
+
+// DetectionStatusSource reports the most recent leak detection run
+type DetectionStatusSource interface {
+	LastRun() (detection.RunStatus, bool)
+}
+
+// ReadyHandler returns the readiness probe handler. Like HealthHandler it fails with 500 when a
+// critical component is down. A degraded service stays ready when readyWhenDegraded is set, and
+// answers 503 otherwise so it is taken out of rotation until the component recovers.
+// Once shutdown has begun it answers 503, so the load balancer stops routing here before the
+// server stops accepting connections.
+func ReadyHandler(logger *slog.Logger, healthService services.HealthService, readyWhenDegraded bool) http.HandlerFunc {
+	if healthService == nil {
+		return missingHealthServiceHandler(logger)
+	}
+	ready := healthHandler(logger, healthService, nil, readyWhenDegraded)
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Only allow GET requests
-		if r.Method != http.MethodGet {
+		if isGetOrHead(r) && healthService.ShuttingDown() {
+			WriteJSONError(r.Context(), w, logger, ErrorCodeUnavailable, ErrShuttingDown, http.StatusServiceUnavailable)
+			return
+		}
+		ready(w, r)
+	}
+}
+
+// HealthHandler returns the health detail handler. It reports the status of each component and
+// the last leak detection run from detections, which may be nil. A critical component being down
+// fails it with 500; a non-critical one only reports the service DEGRADED, still with 200.
+func HealthHandler(logger *slog.Logger, healthService services.HealthService, detections DetectionStatusSource) http.HandlerFunc {
+	return healthHandler(logger, healthService, detections, true)
+}
+
+// healthHandler serves the health and readiness endpoints; okWhenDegraded picks 200 or 503 for a
+// degraded service
+func healthHandler(logger *slog.Logger, healthService services.HealthService, detections DetectionStatusSource, okWhenDegraded bool) http.HandlerFunc {
+	if healthService == nil {
+		return missingHealthServiceHandler(logger)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only allow GET and HEAD requests
+		if !isGetOrHead(r) {
 			http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
 			return
 		}
 
-		if err := healthService.CheckReadiness(r.Context()); err != nil {
+		report := healthService.CheckHealth(r.Context())
+		if report.Status == services.HealthStatusDown {
 			WriteJSONErrorResponse(r.Context(), w, logger, ErrHealthCheckFailed, http.StatusInternalServerError)
 			return
 		}
@@ -31,18 +150,36 @@ func ReadyHandler(logger *slog.Logger, healthService services.HealthService) htt
 		logger.DebugContext(r.Context(), "Health check endpoint accessed", "remote_addr", r.RemoteAddr)
 
 		response := HealthResponse{
-			Status:    "OK",
-			Timestamp: time.Now().UTC(),
-			Version:   healthService.GetVersion(),
+			Status:     HealthStatusOK,
+			Timestamp:  NewAPITime(time.Now().UTC(), timeFormatFrom(r.Context())),
+			Version:    healthService.GetVersion(),
+			Components: NewComponentStatuses(report.Components),
+		}
+		if detections != nil {
+			if run, ok := detections.LastRun(); ok {
+				response.Detection = NewDetectionRunStatus(run, timeFormatFrom(r.Context()))
+			}
+		}
+
+		if report.Status == services.HealthStatusDegraded {
+			response.Status = HealthStatusDegraded
+			if !okWhenDegraded {
+				WriteJSONResponse(r.Context(), w, logger, response, http.StatusServiceUnavailable)
+				return
+			}
 		}
 
 		WriteJSONSuccessResponse(r.Context(), w, logger, response)
 	}
 }
 
+// LiveHandler returns the liveness probe handler
 func LiveHandler(logger *slog.Logger, healthService services.HealthService) http.HandlerFunc {
+	if healthService == nil {
+		return missingHealthServiceHandler(logger)
+	}
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
+		if !isGetOrHead(r) {
 			http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
 			return
 		}
@@ -57,11 +194,31 @@ func LiveHandler(logger *slog.Logger, healthService services.HealthService) http
 		}
 
 		response := HealthResponse{
-			Status:    "OK",
-			Timestamp: time.Now().UTC(),
+			Status:    HealthStatusOK,
+			Timestamp: NewAPITime(time.Now().UTC(), timeFormatFrom(r.Context())),
 			Version:   healthService.GetVersion(),
 		}
 
 		WriteJSONSuccessResponse(r.Context(), w, logger, response)
 	}
 }
+
+// isGetOrHead reports whether the request is a GET, or a HEAD answered like one. Probes are
+// registered for every method, so unlike routes with a GET pattern they check it themselves.
+func isGetOrHead(r *http.Request) bool {
+	return r.Method == http.MethodGet || r.Method == http.MethodHead
+}
+
+// missingHealthServiceHandler stands in for a health handler built without a HealthService.
+// The wiring mistake is logged once at construction, and every probe then fails with a clear
+// 500 instead of a nil-pointer panic.
+func missingHealthServiceHandler(logger *slog.Logger) http.HandlerFunc {
+	logger.Error("Health handler created without a health service; probes will fail")
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !isGetOrHead(r) {
+			http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+			return
+		}
+		WriteJSONErrorResponse(r.Context(), w, logger, ErrHealthServiceMissing, http.StatusInternalServerError)
+	}
+}
@@ -9,9 +9,13 @@ import (
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"rdl-api/internal/detection"
+	"rdl-api/internal/domain/services"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 // Test constants
@@ -43,7 +47,9 @@ type testCase struct {
 type testHealthService struct {
 	CheckReadinessFn func(ctx context.Context) error
 	CheckLivenessFn  func(ctx context.Context) error
+	CheckHealthFn    func(ctx context.Context) services.HealthReport
 	GetVersionFn     func() string
+	shuttingDown     bool
 }
 
 func (t *testHealthService) CheckReadiness(ctx context.Context) error {
@@ -60,6 +66,18 @@ func (t *testHealthService) CheckLiveness(ctx context.Context) error {
 	return nil
 }
 
+// CheckHealth reports the database down when CheckReadiness fails, unless CheckHealthFn is set
+func (t *testHealthService) CheckHealth(ctx context.Context) services.HealthReport {
+	if t.CheckHealthFn != nil {
+		return t.CheckHealthFn(ctx)
+	}
+	database := services.ComponentHealth{Name: services.ComponentDatabase, Critical: true, Err: t.CheckReadiness(ctx)}
+	if database.Err != nil {
+		return services.HealthReport{Status: services.HealthStatusDown, Components: []services.ComponentHealth{database}}
+	}
+	return services.HealthReport{Status: services.HealthStatusOK, Components: []services.ComponentHealth{database}}
+}
+
 func (t *testHealthService) GetVersion() string {
 	if t.GetVersionFn != nil {
 		return t.GetVersionFn()
@@ -67,6 +85,14 @@ func (t *testHealthService) GetVersion() string {
 	return expectedVersion
 }
 
+func (t *testHealthService) BeginShutdown() {
+	t.shuttingDown = true
+}
+
+func (t *testHealthService) ShuttingDown() bool {
+	return t.shuttingDown
+}
+
 // Test builders for creating test instances
 func newTestLogger() *slog.Logger {
 	return slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{
@@ -208,31 +234,16 @@ func runHealthCheckTest(t *testing.T, tc testCase) {
 	// Create response recorder
 	rr := httptest.NewRecorder()
 
-	// Create handler with test health service
-	handler := ReadyHandler(newTestLogger(), tc.healthService)
-
-	// Handle potential panic for nil service
-	panicked := false
-	defer func() {
-		if r := recover(); r != nil {
-			panicked = true
-			if tc.healthService == nil {
-				// Expected panic for nil service
-				t.Logf("Expected panic for nil service: %v", r)
-			} else {
-				t.Errorf("Unexpected panic: %v", r)
-			}
-		}
-	}()
+	// Create handler with test health service; a nil test service is passed as a nil interface
+	var healthService services.HealthService
+	if tc.healthService != nil {
+		healthService = tc.healthService
+	}
+	handler := ReadyHandler(newTestLogger(), healthService, true)
 
 	// Execute request
 	handler.ServeHTTP(rr, req)
 
-	// Skip verification if we panicked (expected for nil service)
-	if panicked && tc.healthService == nil {
-		return
-	}
-
 	// Verify response
 	assertHTTPResponse(t, rr, tc.expectedStatus, tc.expectedBody, tc.expectJSON)
 }
@@ -262,16 +273,16 @@ func generateGETTests() []testCase {
 			name:           "GET_nil_service",
 			method:         http.MethodGet,
 			healthService:  nil,
-			expectedStatus: 0, // Will panic before setting status
-			expectedBody:   "",
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   ErrHealthServiceMissing.Error(),
 			expectJSON:     false,
-			description:    "GET request with nil service should panic (expected behavior)",
+			description:    "GET request with nil service should return 500 Internal Server Error",
 		},
 	}
 }
 
 func generateMethodNotAllowedTests() []testCase {
-	methods := []string{http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch, http.MethodHead, http.MethodOptions}
+	methods := []string{http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch, http.MethodOptions}
 	services := []struct {
 		name    string
 		service *testHealthService
@@ -371,7 +382,7 @@ func TestHealthCheckHandler_EdgeCases(t *testing.T) {
 	t.Run("nil_logger_uses_default", func(t *testing.T) {
 		req := createTestRequest(http.MethodGet)
 		rr := httptest.NewRecorder()
-		handler := ReadyHandler(nil, newHealthyService())
+		handler := ReadyHandler(nil, newHealthyService(), true)
 		handler.ServeHTTP(rr, req)
 
 		assertHTTPResponse(t, rr, http.StatusOK, expectedHealthyStatus, true)
@@ -394,7 +405,7 @@ func TestHealthCheckHandler_EdgeCases(t *testing.T) {
 
 		req := createTestRequestWithContext(http.MethodGet, testEndpoint, ctx)
 		rr := httptest.NewRecorder()
-		handler := ReadyHandler(newTestLogger(), service)
+		handler := ReadyHandler(newTestLogger(), service, true)
 		handler.ServeHTTP(rr, req)
 
 		// Should return 500 due to context cancellation
@@ -404,7 +415,7 @@ func TestHealthCheckHandler_EdgeCases(t *testing.T) {
 	t.Run("json_encoding_validation", func(t *testing.T) {
 		req := createTestRequest(http.MethodGet)
 		rr := httptest.NewRecorder()
-		handler := ReadyHandler(newTestLogger(), newHealthyService())
+		handler := ReadyHandler(newTestLogger(), newHealthyService(), true)
 		handler.ServeHTTP(rr, req)
 
 		// Verify the response is valid JSON
@@ -418,12 +429,72 @@ func TestHealthCheckHandler_EdgeCases(t *testing.T) {
 	})
 }
 
+// testDetectionStatus returns a canned last-run status
+type testDetectionStatus struct {
+	run detection.RunStatus
+	ok  bool
+}
+
+func (s testDetectionStatus) LastRun() (detection.RunStatus, bool) { return s.run, s.ok }
+
+// TestHealthHandler_DetectionStatus tests that the health detail reports the last detection run
+func TestHealthHandler_DetectionStatus(t *testing.T) {
+	startedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	run := detection.RunStatus{
+		TenantID:      uuid.New(),
+		StartedAt:     startedAt,
+		Duration:      1500 * time.Millisecond,
+		EventsScanned: 124,
+		Candidates:    3,
+		CreatedByType: map[string]int{"failed_payments": 2, "volume_anomaly": 1},
+		Err:           errors.New("rule broken: query failed"),
+	}
+
+	tests := []struct {
+		name       string
+		source     DetectionStatusSource
+		wantDetail bool
+	}{
+		{name: "no source", source: nil},
+		{name: "no run yet", source: testDetectionStatus{}},
+		{name: "last run reported", source: testDetectionStatus{run: run, ok: true}, wantDetail: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			HealthHandler(newTestLogger(), newHealthyService(), tt.source).ServeHTTP(rr, createTestRequest(http.MethodGet))
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", rr.Code)
+			}
+			var body map[string]any
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			detail, ok := body["detection"].(map[string]any)
+			if ok != tt.wantDetail {
+				t.Fatalf("expected detection detail=%v, got %v", tt.wantDetail, body["detection"])
+			}
+			if !tt.wantDetail {
+				return
+			}
+			if detail["status"] != DetectionRunError || detail["created"] != float64(3) || detail["duration_ms"] != float64(1500) || detail["events_scanned"] != float64(124) {
+				t.Errorf("unexpected detection detail %v", detail)
+			}
+			if _, ok := detail["tenant_id"]; ok {
+				t.Error("expected the tenant to be left out of the public health detail")
+			}
+		})
+	}
+}
+
 // TestHealthCheckHandler_ConcurrentAccess tests concurrent access to health check endpoint
 func TestHealthCheckHandler_ConcurrentAccess(t *testing.T) {
 	const numGoroutines = 100
 	const numRequests = 10
 
-	handler := ReadyHandler(newTestLogger(), newHealthyService())
+	handler := ReadyHandler(newTestLogger(), newHealthyService(), true)
 
 	var wg sync.WaitGroup
 	results := make(chan int, numGoroutines*numRequests)
@@ -457,8 +528,8 @@ func TestHealthCheckHandler_ConcurrentAccessWithErrors(t *testing.T) {
 	const numGoroutines = 50
 
 	// Create handlers with different service states
-	healthyHandler := ReadyHandler(newTestLogger(), newHealthyService())
-	unhealthyHandler := ReadyHandler(newTestLogger(), newUnhealthyService(errTestService))
+	healthyHandler := ReadyHandler(newTestLogger(), newHealthyService(), true)
+	unhealthyHandler := ReadyHandler(newTestLogger(), newUnhealthyService(errTestService), true)
 
 	var wg sync.WaitGroup
 	healthyResults := make(chan int, numGoroutines)
@@ -509,7 +580,7 @@ func TestHealthCheckHandler_ConcurrentAccessWithErrors(t *testing.T) {
 
 // BenchmarkHealthCheckHandler benchmarks the health check handler performance
 func BenchmarkHealthCheckHandler(b *testing.B) {
-	handler := ReadyHandler(newTestLogger(), newHealthyService())
+	handler := ReadyHandler(newTestLogger(), newHealthyService(), true)
 	req := createTestRequest(http.MethodGet)
 
 	b.ResetTimer()
@@ -524,7 +595,7 @@ func BenchmarkHealthCheckHandler(b *testing.B) {
 
 // BenchmarkHealthCheckHandler_Unhealthy benchmarks the health check handler with unhealthy service
 func BenchmarkHealthCheckHandler_Unhealthy(b *testing.B) {
-	handler := ReadyHandler(newTestLogger(), newUnhealthyService(errTestService))
+	handler := ReadyHandler(newTestLogger(), newUnhealthyService(errTestService), true)
 	req := createTestRequest(http.MethodGet)
 
 	b.ResetTimer()
@@ -539,7 +610,7 @@ func BenchmarkHealthCheckHandler_Unhealthy(b *testing.B) {
 
 // BenchmarkHealthCheckHandler_Concurrent benchmarks concurrent health check requests
 func BenchmarkHealthCheckHandler_Concurrent(b *testing.B) {
-	handler := ReadyHandler(newTestLogger(), newHealthyService())
+	handler := ReadyHandler(newTestLogger(), newHealthyService(), true)
 
 	b.ResetTimer()
 	b.RunParallel(func(pb *testing.PB) {
@@ -553,3 +624,108 @@ func BenchmarkHealthCheckHandler_Concurrent(b *testing.B) {
 		}
 	})
 }
+
+func TestHealthHandlers_Degraded(t *testing.T) {
+	degraded := &testHealthService{CheckHealthFn: func(context.Context) services.HealthReport {
+		return services.HealthReport{Status: services.HealthStatusDegraded, Components: []services.ComponentHealth{
+			{Name: services.ComponentDatabase, Critical: true},
+			{Name: services.ComponentReplica, Err: errTestService},
+		}}
+	}}
+
+	serve := func(handler http.HandlerFunc) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, createTestRequest(http.MethodGet))
+		return rr
+	}
+	decode := func(t *testing.T, rr *httptest.ResponseRecorder) HealthResponse {
+		t.Helper()
+		var response HealthResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return response
+	}
+
+	t.Run("health detail reports degraded with 200", func(t *testing.T) {
+		rr := serve(HealthHandler(newTestLogger(), degraded, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+		response := decode(t, rr)
+		if response.Status != HealthStatusDegraded {
+			t.Errorf("expected status %q, got %q", HealthStatusDegraded, response.Status)
+		}
+		want := map[string]ComponentStatus{
+			services.ComponentDatabase: {Status: ComponentUp, Critical: true},
+			services.ComponentReplica:  {Status: ComponentDown, Critical: false},
+		}
+		if fmt.Sprint(response.Components) != fmt.Sprint(want) {
+			t.Errorf("expected components %v, got %v", want, response.Components)
+		}
+	})
+
+	t.Run("readiness passes when configured to", func(t *testing.T) {
+		rr := serve(ReadyHandler(newTestLogger(), degraded, true))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+		if response := decode(t, rr); response.Status != HealthStatusDegraded {
+			t.Errorf("expected status %q, got %q", HealthStatusDegraded, response.Status)
+		}
+	})
+
+	t.Run("readiness answers 503 otherwise", func(t *testing.T) {
+		rr := serve(ReadyHandler(newTestLogger(), degraded, false))
+		if rr.Code != http.StatusServiceUnavailable {
+			t.Fatalf("expected status %d, got %d", http.StatusServiceUnavailable, rr.Code)
+		}
+		if response := decode(t, rr); response.Status != HealthStatusDegraded {
+			t.Errorf("expected status %q, got %q", HealthStatusDegraded, response.Status)
+		}
+	})
+
+	t.Run("liveness is unaffected", func(t *testing.T) {
+		if rr := serve(LiveHandler(newTestLogger(), degraded)); rr.Code != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+	})
+
+	t.Run("healthy service stays ready", func(t *testing.T) {
+		rr := serve(ReadyHandler(newTestLogger(), newHealthyService(), false))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+		if response := decode(t, rr); response.Status != HealthStatusOK {
+			t.Errorf("expected status %q, got %q", HealthStatusOK, response.Status)
+		}
+	})
+}
+
+func TestReadyHandler_ShuttingDown(t *testing.T) {
+	service := newHealthyService()
+	handler := ReadyHandler(newTestLogger(), service, true)
+
+	serve := func() *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ready", nil))
+		return rr
+	}
+
+	if rr := serve(); rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d before shutdown, got %d", http.StatusOK, rr.Code)
+	}
+
+	service.BeginShutdown()
+	rr := serve()
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status %d once shutting down, got %d", http.StatusServiceUnavailable, rr.Code)
+	}
+	var body ErrorResponse
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if body.Error.Code != ErrorCodeUnavailable {
+		t.Errorf("expected error code %q, got %q", ErrorCodeUnavailable, body.Error.Code)
+	}
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"rdl-api/internal/domain/services"
+	"rdl-api/internal/metrics"
+	"rdl-api/internal/middleware"
+	"time"
+)
+
+// defaultIngestionLagPeriod is how far back GET /events/ingestion-lag looks when from is not given
+const defaultIngestionLagPeriod = 24 * time.Hour
+
+// IngestionLagResponse is the body of GET /events/ingestion-lag
+type IngestionLagResponse struct {
+	From      APITime                    `json:"from"`
+	To        APITime                    `json:"to"`
+	Providers []ProviderIngestionLagItem `json:"providers"`
+}
+
+// ProviderIngestionLagItem is the ingestion lag of the tenant's events of one provider type
+type ProviderIngestionLagItem struct {
+	ProviderType string `json:"provider_type"`
+	Events       int64  `json:"events"`
+	// MissingTimestamp is how many of the events carried no provider timestamp and so have no lag
+	MissingTimestamp int64 `json:"missing_timestamp"`
+	// P95LagSeconds is the 95th percentile time from the provider's timestamp to ingestion;
+	// null when no event had a timestamp
+	P95LagSeconds *float64 `json:"p95_lag_seconds"`
+}
+
+// IngestionLagHandler returns a handler for GET /events/ingestion-lag, the 95th percentile lag
+// between the provider's own timestamp of the tenant's events and when they were ingested, by
+// provider type, for the events ingested in [from, to). High lag means leaks are detected
+// late. from and to are RFC 3339 or Unix milliseconds; to defaults to now and from to 24 hours
+// before to.
+func IngestionLagHandler(logger *slog.Logger, eventsService services.EventsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		tenantID, ok := middleware.GetTenantID(r)
+		if !ok {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidTenant, ErrorCodeUnauthorized, middleware.ErrMissingOrInvalidTenantContext, http.StatusUnauthorized)
+			return
+		}
+
+		query := r.URL.Query()
+		to := time.Now().UTC()
+		parsedTo, err := parseQueryTime(query, "to")
+		if err != nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, err, http.StatusBadRequest)
+			return
+		}
+		if parsedTo != nil {
+			to = *parsedTo
+		}
+		from := to.Add(-defaultIngestionLagPeriod)
+		parsedFrom, err := parseQueryTime(query, "from")
+		if err != nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, err, http.StatusBadRequest)
+			return
+		}
+		if parsedFrom != nil {
+			from = *parsedFrom
+		}
+		if !from.Before(to) {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, fmt.Errorf("%w: from must be before to", ErrInvalidTimeRange), http.StatusBadRequest)
+			return
+		}
+
+		lags, err := eventsService.GetIngestionLag(ctx, tenantID, from, to)
+		if err != nil {
+			logger.Log(ctx, serviceErrorLevel(err), "Failed to compute ingestion lag", "error", err, "tenant_id", tenantID)
+			WriteServerError(ctx, w, logger, err)
+			return
+		}
+
+		response := IngestionLagResponse{From: NewAPITime(from, timeFormatFrom(ctx)), To: NewAPITime(to, timeFormatFrom(ctx)), Providers: make([]ProviderIngestionLagItem, 0, len(lags))}
+		for _, lag := range lags {
+			item := ProviderIngestionLagItem{
+				ProviderType:     lag.ProviderType,
+				Events:           lag.Events,
+				MissingTimestamp: lag.MissingTimestamp,
+			}
+			if lag.P95 != nil {
+				seconds := lag.P95.Seconds()
+				item.P95LagSeconds = &seconds
+			}
+			response.Providers = append(response.Providers, item)
+		}
+		WriteJSONSuccessResponse(ctx, w, logger, response)
+	}
+}
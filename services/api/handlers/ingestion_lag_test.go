@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/domain/services"
+	"rdl-api/internal/middleware"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// testIngestionLagService returns fixed lags and records the window it was asked for; other
+// methods panic via the nil embedded interface
+type testIngestionLagService struct {
+	services.EventsService
+	lags     []models.IngestionLag
+	from, to time.Time
+}
+
+func (s *testIngestionLagService) GetIngestionLag(_ context.Context, _ uuid.UUID, from time.Time, to time.Time) ([]models.IngestionLag, error) {
+	s.from, s.to = from, to
+	return s.lags, nil
+}
+
+func TestIngestionLagHandler(t *testing.T) {
+	p95 := 90 * time.Second
+	svc := &testIngestionLagService{lags: []models.IngestionLag{
+		{ProviderType: "stripe", Events: 10, MissingTimestamp: 2, P95: &p95},
+		{ProviderType: "webhook", Events: 3, MissingTimestamp: 3},
+	}}
+
+	logger := newTestLogger()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /events/ingestion-lag", IngestionLagHandler(logger, svc))
+	handler := middleware.TenantContext(logger, true, nil)(mux)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/events/ingestion-lag"+query, nil)
+		req.Header.Set("X-Tenant-ID", uuid.NewString())
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("lag per provider type over the default period", func(t *testing.T) {
+		w := get("")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var body IngestionLagResponse
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		if len(body.Providers) != 2 {
+			t.Fatalf("expected 2 provider types, got %+v", body.Providers)
+		}
+		stripe, webhook := body.Providers[0], body.Providers[1]
+		if stripe.ProviderType != "stripe" || stripe.Events != 10 || stripe.MissingTimestamp != 2 || stripe.P95LagSeconds == nil || *stripe.P95LagSeconds != 90 {
+			t.Errorf("expected stripe with a p95 of 90s, got %+v", stripe)
+		}
+		if webhook.P95LagSeconds != nil {
+			t.Errorf("expected no p95 when no event had a timestamp, got %v", *webhook.P95LagSeconds)
+		}
+		if period := svc.to.Sub(svc.from); period != defaultIngestionLagPeriod {
+			t.Errorf("expected the default period of %s, got %s", defaultIngestionLagPeriod, period)
+		}
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		for _, query := range []string{"?from=yesterday", "?to=soon", "?from=2025-02-01T00:00:00Z&to=2025-01-01T00:00:00Z"} {
+			if w := get(query); w.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d for %q, got %d", http.StatusBadRequest, query, w.Code)
+			}
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/domain/services"
+	"rdl-api/internal/metrics"
+	"rdl-api/internal/middleware"
+	"time"
+)
+
+// maxLeakAmountBounds caps the bounds of GET /leaks/amount-histogram, which make one more bucket
+// per currency than there are bounds
+const maxLeakAmountBounds = 50
+
+// LeakAmountHistogramResponse is the body of GET /leaks/amount-histogram
+type LeakAmountHistogramResponse struct {
+	Bounds  []models.Decimal          `json:"bounds"`
+	Since   *APITime                  `json:"since,omitempty"`
+	Buckets []models.LeakAmountBucket `json:"buckets"`
+}
+
+// LeakAmountHistogramHandler returns a handler for GET /leaks/amount-histogram, which shows
+// where the tenant's exposure is concentrated: the number and summed amount of its open,
+// unsnoozed leaks in each amount bucket, per currency. bounds lists the bucket bounds,
+// repeated or comma-separated, greater than 0 and ascending, at most 50; without it the
+// bounds are 10, 100, 1000 and 10000. since, RFC 3339 or Unix milliseconds, counts only the
+// leaks detected from then on.
+func LeakAmountHistogramHandler(logger *slog.Logger, leaksService services.LeaksService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		tenantID, ok := middleware.GetTenantID(r)
+		if !ok {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidTenant, ErrorCodeUnauthorized, middleware.ErrMissingOrInvalidTenantContext, http.StatusUnauthorized)
+			return
+		}
+
+		query := r.URL.Query()
+		bounds, err := parseBucketBounds(splitQueryValues(query["bounds"]))
+		if err != nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, err, http.StatusBadRequest)
+			return
+		}
+		since, err := parseQueryTime(query, "since")
+		if err != nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, err, http.StatusBadRequest)
+			return
+		}
+		var sinceTime time.Time
+		if since != nil {
+			sinceTime = *since
+		}
+
+		buckets, err := leaksService.GetLeakAmountHistogram(ctx, tenantID, bounds, sinceTime)
+		if err != nil {
+			logger.Log(ctx, serviceErrorLevel(err), "Failed to compute leak amount histogram", "error", err, "tenant_id", tenantID)
+			WriteServerError(ctx, w, logger, err)
+			return
+		}
+
+		response := LeakAmountHistogramResponse{Bounds: bounds, Since: NewAPITimePtr(since, timeFormatFrom(ctx)), Buckets: buckets}
+		if response.Buckets == nil {
+			response.Buckets = []models.LeakAmountBucket{}
+		}
+		WriteJSONSuccessResponse(ctx, w, logger, response)
+	}
+}
+
+// parseBucketBounds parses and validates histogram bounds, returning
+// models.DefaultLeakAmountBucketBounds when there are none
+func parseBucketBounds(values []string) ([]models.Decimal, error) {
+	if len(values) == 0 {
+		return models.DefaultLeakAmountBucketBounds, nil
+	}
+	if len(values) > maxLeakAmountBounds {
+		return nil, fmt.Errorf("%w: at most %d bounds", ErrInvalidBucketBounds, maxLeakAmountBounds)
+	}
+	bounds := make([]models.Decimal, 0, len(values))
+	for _, value := range values {
+		bound, err := models.ParseDecimal(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q is not a number", ErrInvalidBucketBounds, value)
+		}
+		bounds = append(bounds, bound)
+	}
+	if err := models.ValidateBucketBounds(bounds); err != nil {
+		return nil, err
+	}
+	return bounds, nil
+}
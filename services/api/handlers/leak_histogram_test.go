@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/domain/services"
+	"rdl-api/internal/middleware"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// testHistogramService returns one bucket per bound and records what it was asked for; other
+// methods panic via the nil embedded interface
+type testHistogramService struct {
+	services.LeaksService
+	bounds []models.Decimal
+	since  time.Time
+	calls  int
+}
+
+func (s *testHistogramService) GetLeakAmountHistogram(_ context.Context, _ uuid.UUID, bounds []models.Decimal, since time.Time) ([]models.LeakAmountBucket, error) {
+	s.bounds, s.since = bounds, since
+	s.calls++
+	return []models.LeakAmountBucket{{Currency: "USD", Upper: &bounds[0], Count: 2, Total: models.MustParseDecimal("12.50")}}, nil
+}
+
+func TestLeakAmountHistogramHandler(t *testing.T) {
+	svc := &testHistogramService{}
+	logger := newTestLogger()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /leaks/amount-histogram", LeakAmountHistogramHandler(logger, svc))
+	handler := middleware.TenantContext(logger, true, nil)(mux)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/leaks/amount-histogram"+query, nil)
+		req.Header.Set("X-Tenant-ID", uuid.NewString())
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("default bounds", func(t *testing.T) {
+		w := get("")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var body LeakAmountHistogramResponse
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(body.Bounds) != len(models.DefaultLeakAmountBucketBounds) || len(svc.bounds) != len(models.DefaultLeakAmountBucketBounds) {
+			t.Errorf("expected the default bounds, got %v in the response and %v in the call", body.Bounds, svc.bounds)
+		}
+		if !svc.since.IsZero() || body.Since != nil {
+			t.Errorf("expected no since, got %s", svc.since)
+		}
+		if len(body.Buckets) != 1 || body.Buckets[0].Count != 2 || body.Buckets[0].Total.String() != "12.50" {
+			t.Errorf("expected the service's bucket, got %+v", body.Buckets)
+		}
+	})
+
+	t.Run("explicit bounds and since", func(t *testing.T) {
+		if w := get("?bounds=5,50&bounds=500.5&since=2025-01-01T00:00:00Z"); w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		got := make([]string, 0, len(svc.bounds))
+		for _, b := range svc.bounds {
+			got = append(got, b.String())
+		}
+		if strings.Join(got, ",") != "5,50,500.5" {
+			t.Errorf("expected bounds 5,50,500.5, got %v", got)
+		}
+		if !svc.since.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("expected the given since, got %s", svc.since)
+		}
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		calls := svc.calls
+		tooMany := strings.Repeat("1,", maxLeakAmountBounds) + "1"
+		for _, query := range []string{"?bounds=ten", "?bounds=0", "?bounds=100,10", "?bounds=10,10", "?bounds=" + tooMany, "?bounds=1&bounds=1e2000000000", "?since=yesterday"} {
+			if w := get(query); w.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d for %q, got %d", http.StatusBadRequest, query, w.Code)
+			}
+		}
+		if svc.calls != calls {
+			t.Error("expected invalid requests not to reach the service")
+		}
+	})
+}
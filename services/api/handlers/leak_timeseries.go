@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/domain/services"
+	"rdl-api/internal/metrics"
+	"rdl-api/internal/middleware"
+	"time"
+)
+
+// defaultLeakTimeSeriesPeriod is how far back GET /leaks/timeseries looks when from is not given
+const defaultLeakTimeSeriesPeriod = 30 * 24 * time.Hour
+
+// LeakTimeSeriesResponse is the body of GET /leaks/timeseries
+type LeakTimeSeriesResponse struct {
+	Interval models.LeakTimeSeriesInterval `json:"interval"`
+	From     APITime                       `json:"from"`
+	To       APITime                       `json:"to"`
+	Points   []LeakTimeSeriesPointItem     `json:"points"`
+}
+
+// LeakTimeSeriesPointItem is one bucket of GET /leaks/timeseries
+type LeakTimeSeriesPointItem struct {
+	Start APITime `json:"start"`
+	Count int64   `json:"count"`
+	// Totals is the summed amount of the bucket's leaks by currency; empty when there were none
+	Totals map[string]models.Decimal `json:"totals"`
+}
+
+// LeakTimeSeriesHandler returns a handler for GET /leaks/timeseries, the trend of the tenant's
+// leaks: how many were detected and their summed amount by currency in each bucket of
+// [from, to), every bucket included so the series has no gaps. interval is the bucket width,
+// hour, day (the default), week or month, in UTC. from and to are RFC 3339 or Unix
+// milliseconds; to defaults to now and from to 30 days before to.
+func LeakTimeSeriesHandler(logger *slog.Logger, leaksService services.LeaksService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		tenantID, ok := middleware.GetTenantID(r)
+		if !ok {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidTenant, ErrorCodeUnauthorized, middleware.ErrMissingOrInvalidTenantContext, http.StatusUnauthorized)
+			return
+		}
+
+		query := r.URL.Query()
+		interval := models.LeakTimeSeriesDay
+		if value := query.Get("interval"); value != "" {
+			interval = models.LeakTimeSeriesInterval(value)
+		}
+		to := time.Now().UTC()
+		parsedTo, err := parseQueryTime(query, "to")
+		if err != nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, err, http.StatusBadRequest)
+			return
+		}
+		if parsedTo != nil {
+			to = *parsedTo
+		}
+		from := to.Add(-defaultLeakTimeSeriesPeriod)
+		parsedFrom, err := parseQueryTime(query, "from")
+		if err != nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, err, http.StatusBadRequest)
+			return
+		}
+		if parsedFrom != nil {
+			from = *parsedFrom
+		}
+		if err := models.ValidateLeakTimeSeries(interval, from, to); err != nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, err, http.StatusBadRequest)
+			return
+		}
+
+		points, err := leaksService.GetLeakTimeSeries(ctx, tenantID, interval, from, to)
+		if err != nil {
+			logger.Log(ctx, serviceErrorLevel(err), "Failed to compute leak time series", "error", err, "tenant_id", tenantID)
+			WriteServerError(ctx, w, logger, err)
+			return
+		}
+
+		response := LeakTimeSeriesResponse{Interval: interval, From: NewAPITime(from, timeFormatFrom(ctx)), To: NewAPITime(to, timeFormatFrom(ctx)), Points: make([]LeakTimeSeriesPointItem, 0, len(points))}
+		for _, point := range points {
+			response.Points = append(response.Points, LeakTimeSeriesPointItem{
+				Start:  NewAPITime(point.Start, timeFormatFrom(ctx)),
+				Count:  point.Count,
+				Totals: point.Totals,
+			})
+		}
+		WriteJSONSuccessResponse(ctx, w, logger, response)
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/domain/services"
+	"rdl-api/internal/middleware"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// testTimeSeriesService returns one point per call and records what it was asked for; other
+// methods panic via the nil embedded interface
+type testTimeSeriesService struct {
+	services.LeaksService
+	interval models.LeakTimeSeriesInterval
+	from, to time.Time
+	calls    int
+}
+
+func (s *testTimeSeriesService) GetLeakTimeSeries(_ context.Context, _ uuid.UUID, interval models.LeakTimeSeriesInterval, from time.Time, to time.Time) ([]models.LeakTimeSeriesPoint, error) {
+	s.interval, s.from, s.to = interval, from, to
+	s.calls++
+	return []models.LeakTimeSeriesPoint{
+		{Start: from, Count: 2, Totals: map[string]models.Decimal{"USD": models.MustParseDecimal("12.50")}},
+		{Start: from.Add(24 * time.Hour), Totals: map[string]models.Decimal{}},
+	}, nil
+}
+
+func TestLeakTimeSeriesHandler(t *testing.T) {
+	svc := &testTimeSeriesService{}
+	logger := newTestLogger()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /leaks/timeseries", LeakTimeSeriesHandler(logger, svc))
+	handler := middleware.TenantContext(logger, true, nil)(mux)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/leaks/timeseries"+query, nil)
+		req.Header.Set("X-Tenant-ID", uuid.NewString())
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		w := get("")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if svc.interval != models.LeakTimeSeriesDay {
+			t.Errorf("expected the day interval, got %q", svc.interval)
+		}
+		if got := svc.to.Sub(svc.from); got != defaultLeakTimeSeriesPeriod {
+			t.Errorf("expected a range of %s, got %s", defaultLeakTimeSeriesPeriod, got)
+		}
+		if time.Since(svc.to) > time.Minute {
+			t.Errorf("expected to to default to now, got %s", svc.to)
+		}
+
+		var body LeakTimeSeriesResponse
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(body.Points) != 2 || body.Points[0].Count != 2 || body.Points[0].Totals["USD"].String() != "12.50" {
+			t.Errorf("expected the service's points, got %+v", body.Points)
+		}
+		if body.Points[1].Count != 0 || body.Points[1].Totals == nil || len(body.Points[1].Totals) != 0 {
+			t.Errorf("expected an empty bucket with empty totals, got %+v", body.Points[1])
+		}
+	})
+
+	t.Run("explicit interval and range", func(t *testing.T) {
+		if w := get("?interval=week&from=2025-01-01T00:00:00Z&to=2025-03-01T00:00:00Z"); w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if svc.interval != models.LeakTimeSeriesWeek {
+			t.Errorf("expected the week interval, got %q", svc.interval)
+		}
+		if !svc.from.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) || !svc.to.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("expected the given range, got %s to %s", svc.from, svc.to)
+		}
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		calls := svc.calls
+		for _, query := range []string{
+			"?interval=minute",
+			"?interval=DAY",
+			"?from=yesterday",
+			"?to=tomorrow",
+			"?from=2025-03-01T00:00:00Z&to=2025-03-01T00:00:00Z",
+			"?from=2025-03-02T00:00:00Z&to=2025-03-01T00:00:00Z",
+			// 2000 hours is more than the bucket cap
+			"?interval=hour&from=2025-01-01T00:00:00Z&to=2025-03-25T08:00:00Z",
+		} {
+			if w := get(query); w.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d for %q, got %d", http.StatusBadRequest, query, w.Code)
+			}
+		}
+		if svc.calls != calls {
+			t.Error("expected invalid requests not to reach the service")
+		}
+	})
+}
@@ -0,0 +1,364 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/domain/services"
+	"rdl-api/internal/metrics"
+	"rdl-api/internal/middleware"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WarningActionsUnavailable is reported in LeakDetail.Warnings when the leak's actions failed to load
+const WarningActionsUnavailable = "actions could not be loaded"
+
+// LeakResponse is the API representation of a leak; timestamps follow the configured time format
+type LeakResponse struct {
+	ID            uuid.UUID             `json:"id"`
+	TenantID      uuid.UUID             `json:"tenant_id"`
+	CustomerID    uuid.UUID             `json:"customer_id"`
+	LeakType      models.LeakTypeEnum   `json:"leak_type"`
+	Status        models.LeakStatusEnum `json:"status"`
+	Amount        models.Decimal        `json:"amount"`
+	Currency      string                `json:"currency"`
+	Confidence    int32                 `json:"confidence"`
+	Occurrences   int32                 `json:"occurrences"`
+	SourceEventID *uuid.UUID            `json:"source_event_id,omitempty"`
+	Metadata      json.RawMessage       `json:"metadata,omitempty"`
+	DetectedAt    APITime               `json:"detected_at"`
+	ResolvedAt    *APITime              `json:"resolved_at,omitempty"`
+	SnoozedUntil  *APITime              `json:"snoozed_until,omitempty"`
+	CreatedAt     APITime               `json:"created_at"`
+	UpdatedAt     APITime               `json:"updated_at"`
+}
+
+// NewLeakResponse converts a domain leak to its API representation
+func NewLeakResponse(leak models.Leak, format TimeFormat) LeakResponse {
+	return LeakResponse{
+		ID:            leak.ID,
+		TenantID:      leak.TenantID,
+		CustomerID:    leak.CustomerID,
+		LeakType:      leak.LeakType,
+		Status:        leak.Status,
+		Amount:        leak.Amount,
+		Currency:      leak.Currency,
+		Confidence:    leak.Confidence,
+		Occurrences:   leak.Occurrences,
+		SourceEventID: leak.SourceEventID,
+		Metadata:      leak.Metadata,
+		DetectedAt:    NewAPITime(leak.DetectedAt, format),
+		ResolvedAt:    NewAPITimePtr(leak.ResolvedAt, format),
+		SnoozedUntil:  NewAPITimePtr(leak.SnoozedUntil, format),
+		CreatedAt:     NewAPITime(leak.CreatedAt, format),
+		UpdatedAt:     NewAPITime(leak.UpdatedAt, format),
+	}
+}
+
+// ActionResponse is the API representation of an action; timestamps follow the configured time format
+type ActionResponse struct {
+	ID         uuid.UUID               `json:"id"`
+	LeakID     uuid.UUID               `json:"leak_id"`
+	ActionType models.ActionTypeEnum   `json:"action_type"`
+	Status     models.ActionStatusEnum `json:"status"`
+	Result     models.ActionResultEnum `json:"result"`
+	Priority   int64                   `json:"priority"`
+	Attempts   int32                   `json:"attempts"`
+	CreatedAt  APITime                 `json:"created_at"`
+	UpdatedAt  APITime                 `json:"updated_at"`
+}
+
+// NewActionResponse converts a domain action to its API representation
+func NewActionResponse(action models.Action, format TimeFormat) ActionResponse {
+	return ActionResponse{
+		ID:         action.ID,
+		LeakID:     action.LeakID,
+		ActionType: action.ActionType,
+		Status:     action.Status,
+		Result:     action.Result,
+		Priority:   action.Priority,
+		Attempts:   action.Attempts,
+		CreatedAt:  NewAPITime(action.CreatedAt, format),
+		UpdatedAt:  NewAPITime(action.UpdatedAt, format),
+	}
+}
+
+// Page size limits for GET /leaks
+const (
+	defaultLeaksPageSize = 50
+	maxLeaksPageSize     = 1000
+)
+
+// ListLeaksHandler returns a handler for GET /leaks, which lists the tenant's leaks largest
+// amount first, so the biggest exposure leads.
+// status and leak_type accept several values, either repeated or comma-separated; values of one
+// filter are ORed and the filters are ANDed. Without a status only open leaks are listed.
+// Snoozed leaks are left out until their snooze expires unless include_snoozed=true.
+// detected_from (inclusive) and detected_to (exclusive) bound the detection time and take RFC 3339
+// or Unix milliseconds. limit (default 50, max 1000) and offset page through the results.
+func ListLeaksHandler(logger *slog.Logger, leaksService services.LeaksService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		tenantID, ok := middleware.GetTenantID(r)
+		if !ok {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidTenant, ErrorCodeUnauthorized, middleware.ErrMissingOrInvalidTenantContext, http.StatusUnauthorized)
+			return
+		}
+
+		query := r.URL.Query()
+		filter, err := parseLeakFilter(query)
+		if err != nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, err, http.StatusBadRequest)
+			return
+		}
+
+		params, err := parsePagination(query, defaultLeaksPageSize, maxLeaksPageSize)
+		if err != nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, err, http.StatusBadRequest)
+			return
+		}
+
+		page, err := leaksService.ListLeaks(ctx, tenantID, filter, params)
+		if err != nil {
+			logger.Log(ctx, serviceErrorLevel(err), "Failed to list leaks", "error", err, "tenant_id", tenantID)
+			WriteServerError(ctx, w, logger, err)
+			return
+		}
+
+		items := make([]LeakResponse, 0, len(page.Items))
+		for _, leak := range page.Items {
+			items = append(items, NewLeakResponse(leak, timeFormatFrom(ctx)))
+		}
+		WriteListResponse(ctx, w, logger, models.NewPaginatedResponse(items, page.TotalCount, page.Limit, page.Offset))
+	}
+}
+
+// parseLeakFilter reads the leak filters shared by GET /leaks and GET /leaks/export: status
+// (open when absent), leak_type, detected_from, detected_to and include_snoozed
+func parseLeakFilter(query url.Values) (models.LeakFilter, error) {
+	var filter models.LeakFilter
+	for _, value := range splitQueryValues(query["status"]) {
+		status := models.LeakStatusEnum(value)
+		if !isValidLeakStatus(status) {
+			return models.LeakFilter{}, fmt.Errorf("%w: %q", ErrInvalidLeakStatus, value)
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+	if len(filter.Statuses) == 0 {
+		filter.Statuses = []models.LeakStatusEnum{models.LeakStatusEnumOpen}
+	}
+	for _, value := range splitQueryValues(query["leak_type"]) {
+		leakType := models.LeakTypeEnum(value)
+		if !isValidLeakType(leakType) {
+			return models.LeakFilter{}, fmt.Errorf("%w: %q", ErrInvalidLeakType, value)
+		}
+		filter.LeakTypes = append(filter.LeakTypes, leakType)
+	}
+
+	var err error
+	if filter.DetectedFrom, err = parseQueryTime(query, "detected_from"); err != nil {
+		return models.LeakFilter{}, err
+	}
+	if filter.DetectedTo, err = parseQueryTime(query, "detected_to"); err != nil {
+		return models.LeakFilter{}, err
+	}
+	if filter.DetectedFrom != nil && filter.DetectedTo != nil && !filter.DetectedFrom.Before(*filter.DetectedTo) {
+		return models.LeakFilter{}, fmt.Errorf("%w: detected_from must be before detected_to", ErrInvalidTimeRange)
+	}
+
+	filter.ExcludeSnoozed = true
+	if value := query.Get("include_snoozed"); value != "" {
+		includeSnoozed, err := strconv.ParseBool(value)
+		if err != nil {
+			return models.LeakFilter{}, fmt.Errorf("%w: %q", ErrInvalidIncludeSnoozed, value)
+		}
+		filter.ExcludeSnoozed = !includeSnoozed
+	}
+	return filter, nil
+}
+
+// isValidLeakStatus reports whether s is a known leak status
+func isValidLeakStatus(s models.LeakStatusEnum) bool {
+	switch s {
+	case models.LeakStatusEnumOpen,
+		models.LeakStatusEnumResolved,
+		models.LeakStatusEnumIgnored:
+		return true
+	}
+	return false
+}
+
+// isValidLeakType reports whether t is a known leak type
+func isValidLeakType(t models.LeakTypeEnum) bool {
+	switch t {
+	case models.LeakTypeEnumFailedPayments,
+		models.LeakTypeEnumUnbilledUsage,
+		models.LeakTypeEnumQuietChurn,
+		models.LeakTypeEnumCouponDiscountMisuse,
+		models.LeakTypeEnumTrialForever,
+		models.LeakTypeEnumOther,
+		models.LeakTypeEnumVolumeAnomaly,
+		models.LeakTypeEnumDuplicateCharge,
+		models.LeakTypeEnumDunningGap,
+		models.LeakTypeEnumCurrencyMismatch,
+		models.LeakTypeEnumFailureRateSpike:
+		return true
+	}
+	return false
+}
+
+// LeakDetail is the body of GET /leaks/{id}: the leak with the events that triggered it
+// and the actions taken for it. Warnings lists the parts that could not be loaded.
+type LeakDetail struct {
+	Leak     LeakResponse     `json:"leak"`
+	Events   []EventResponse  `json:"events"`
+	Actions  []ActionResponse `json:"actions"`
+	Warnings []string         `json:"warnings,omitempty"`
+}
+
+// GetLeakHandler returns a handler for GET /leaks/{id}.
+// The leak, its triggering events and its actions are fetched concurrently. Actions are
+// supplementary: if they fail to load, the leak and events are still returned with a warning.
+func GetLeakHandler(
+	logger *slog.Logger,
+	leaksService services.LeaksService,
+	eventsService services.EventsService,
+	actionsService services.ActionsService,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		tenantID, ok := middleware.GetTenantID(r)
+		if !ok {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidTenant, ErrorCodeUnauthorized, middleware.ErrMissingOrInvalidTenantContext, http.StatusUnauthorized)
+			return
+		}
+
+		leakID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, ErrInvalidLeakID, http.StatusBadRequest)
+			return
+		}
+
+		var (
+			wg                             sync.WaitGroup
+			leak                           models.Leak
+			events                         []models.Event
+			actions                        []models.Action
+			leakErr, eventsErr, actionsErr error
+		)
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			leak, leakErr = leaksService.GetLeakByID(ctx, leakID, tenantID)
+		}()
+		go func() {
+			defer wg.Done()
+			events, eventsErr = eventsService.GetEventsByLeakID(ctx, leakID, tenantID)
+		}()
+		go func() {
+			defer wg.Done()
+			actions, actionsErr = actionsService.GetActionsByLeakID(ctx, leakID, tenantID)
+		}()
+		wg.Wait()
+
+		if leakErr != nil {
+			if errors.Is(leakErr, services.ErrLeakNotFound) {
+				WriteJSONError(ctx, w, logger, ErrorCodeNotFound, ErrLeakNotFound, http.StatusNotFound)
+				return
+			}
+			logger.Log(ctx, serviceErrorLevel(leakErr), "Failed to get leak", "error", leakErr, "leak_id", leakID, "tenant_id", tenantID)
+			WriteServerError(ctx, w, logger, leakErr)
+			return
+		}
+		if eventsErr != nil {
+			logger.Log(ctx, serviceErrorLevel(eventsErr), "Failed to get leak events", "error", eventsErr, "leak_id", leakID, "tenant_id", tenantID)
+			WriteServerError(ctx, w, logger, eventsErr)
+			return
+		}
+
+		detail := LeakDetail{
+			Leak:    NewLeakResponse(leak, timeFormatFrom(ctx)),
+			Events:  make([]EventResponse, 0, len(events)),
+			Actions: make([]ActionResponse, 0, len(actions)),
+		}
+		for _, event := range events {
+			detail.Events = append(detail.Events, NewEventResponse(event, timeFormatFrom(ctx)))
+		}
+		if actionsErr != nil {
+			logger.WarnContext(ctx, "Failed to get leak actions, returning leak without them", "error", actionsErr, "leak_id", leakID, "tenant_id", tenantID)
+			detail.Warnings = append(detail.Warnings, WarningActionsUnavailable)
+		} else {
+			for _, action := range actions {
+				detail.Actions = append(detail.Actions, NewActionResponse(action, timeFormatFrom(ctx)))
+			}
+		}
+
+		WriteJSONSuccessResponse(ctx, w, logger, detail)
+	}
+}
+
+// maxSnoozeDuration bounds a snooze so a forgotten leak cannot be hidden indefinitely
+const maxSnoozeDuration = 90 * 24 * time.Hour
+
+// SnoozeLeakRequest is the body of POST /leaks/{id}/snooze. Duration is a Go duration such as
+// "24h" or "90m".
+type SnoozeLeakRequest struct {
+	Duration string `json:"duration"`
+}
+
+// SnoozeLeakHandler returns a handler for POST /leaks/{id}/snooze, which mutes a known leak
+// without resolving it. Until snoozed_until passes the leak is left out of the open leak counts
+// and listings; it then reappears on its own. Snoozing again replaces the earlier time.
+// The duration must be positive and at most 90 days.
+func SnoozeLeakHandler(logger *slog.Logger, leaksService services.LeaksService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		tenantID, ok := middleware.GetTenantID(r)
+		if !ok {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidTenant, ErrorCodeUnauthorized, middleware.ErrMissingOrInvalidTenantContext, http.StatusUnauthorized)
+			return
+		}
+
+		leakID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, ErrInvalidLeakID, http.StatusBadRequest)
+			return
+		}
+
+		var req SnoozeLeakRequest
+		if err := decodeJSON(ctx, r.Body, &req); err != nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidJSON, ErrorCodeInvalidRequest, requestBodyError(err), http.StatusBadRequest)
+			return
+		}
+		duration, err := time.ParseDuration(req.Duration)
+		if err != nil || duration <= 0 || duration > maxSnoozeDuration {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest,
+				fmt.Errorf("%w: %q must be a positive duration of at most %s", ErrInvalidSnoozeDuration, req.Duration, maxSnoozeDuration), http.StatusBadRequest)
+			return
+		}
+
+		leak, err := leaksService.SnoozeLeak(ctx, leakID, time.Now().Add(duration), tenantID)
+		if err != nil {
+			if errors.Is(err, services.ErrLeakNotFound) {
+				WriteJSONError(ctx, w, logger, ErrorCodeNotFound, ErrLeakNotFound, http.StatusNotFound)
+				return
+			}
+			logger.Log(ctx, serviceErrorLevel(err), "Failed to snooze leak", "error", err, "leak_id", leakID, "tenant_id", tenantID)
+			WriteServerError(ctx, w, logger, err)
+			return
+		}
+
+		WriteJSONSuccessResponse(ctx, w, logger, NewLeakResponse(leak, timeFormatFrom(ctx)))
+	}
+}
@@ -0,0 +1,443 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/domain/services"
+	"rdl-api/internal/middleware"
+
+	"github.com/google/uuid"
+)
+
+// testLeaksService serves leaks from memory; other methods panic via the nil embedded interface
+type testLeaksService struct {
+	services.LeaksService
+	leaks map[uuid.UUID]models.Leak
+}
+
+func (s *testLeaksService) GetLeakByID(_ context.Context, id uuid.UUID, _ uuid.UUID) (models.Leak, error) {
+	leak, ok := s.leaks[id]
+	if !ok {
+		return models.Leak{}, services.ErrLeakNotFound
+	}
+	return leak, nil
+}
+
+// testLeakEventsService serves a leak's triggering events; other methods panic via the nil embedded interface
+type testLeakEventsService struct {
+	services.EventsService
+	events map[uuid.UUID][]models.Event
+	err    error
+}
+
+func (s *testLeakEventsService) GetEventsByLeakID(_ context.Context, leakID uuid.UUID, _ uuid.UUID) ([]models.Event, error) {
+	return s.events[leakID], s.err
+}
+
+// testLeakActionsService serves a leak's actions; other methods panic via the nil embedded interface
+type testLeakActionsService struct {
+	services.ActionsService
+	actions map[uuid.UUID][]models.Action
+	err     error
+}
+
+func (s *testLeakActionsService) GetActionsByLeakID(_ context.Context, leakID uuid.UUID, _ uuid.UUID) ([]models.Action, error) {
+	return s.actions[leakID], s.err
+}
+
+func newLeaksTestHandler(leaks *testLeaksService, events *testLeakEventsService, actions *testLeakActionsService) http.Handler {
+	logger := newTestLogger()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /leaks/{id}", GetLeakHandler(logger, leaks, events, actions))
+	return middleware.TenantContext(logger, true, nil)(mux)
+}
+
+func newGetLeakRequest(leakID string, tenantID uuid.UUID) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/leaks/"+leakID, nil)
+	req.Header.Set("X-Tenant-ID", tenantID.String())
+	return req
+}
+
+// seedLeakDetail returns fakes holding one leak with two events and one action
+func seedLeakDetail(tenantID uuid.UUID) (uuid.UUID, *testLeaksService, *testLeakEventsService, *testLeakActionsService) {
+	leakID := uuid.New()
+	created := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	leaks := &testLeaksService{leaks: map[uuid.UUID]models.Leak{
+		leakID: {ID: leakID, TenantID: tenantID, LeakType: models.LeakTypeEnumFailedPayments, Amount: models.MustParseDecimal("49.50"), Confidence: 90, CreatedAt: created, UpdatedAt: created},
+	}}
+	events := &testLeakEventsService{events: map[uuid.UUID][]models.Event{
+		leakID: {
+			{ID: uuid.New(), TenantID: tenantID, EventType: models.EventTypeEnumPaymentFailed, EventID: "evt_1", CreatedAt: created},
+			{ID: uuid.New(), TenantID: tenantID, EventType: models.EventTypeEnumPaymentFailed, EventID: "evt_2", CreatedAt: created.Add(time.Hour)},
+		},
+	}}
+	actions := &testLeakActionsService{actions: map[uuid.UUID][]models.Action{
+		leakID: {{ID: uuid.New(), LeakID: leakID, ActionType: models.ActionTypeEnumEmail, Status: models.ActionStatusEnumPending, CreatedAt: created}},
+	}}
+	return leakID, leaks, events, actions
+}
+
+func decodeLeakDetail(t *testing.T, w *httptest.ResponseRecorder) LeakDetail {
+	t.Helper()
+	var detail LeakDetail
+	if err := json.NewDecoder(w.Body).Decode(&detail); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return detail
+}
+
+func TestGetLeakHandler_AssemblesDetail(t *testing.T) {
+	tenantID := uuid.New()
+	leakID, leaks, events, actions := seedLeakDetail(tenantID)
+	handler := newLeaksTestHandler(leaks, events, actions)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newGetLeakRequest(leakID.String(), tenantID))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	detail := decodeLeakDetail(t, w)
+	if detail.Leak.ID != leakID || detail.Leak.Amount.String() != "49.50" {
+		t.Errorf("unexpected leak: %+v", detail.Leak)
+	}
+	if len(detail.Events) != 2 || detail.Events[0].EventID != "evt_1" || detail.Events[1].EventID != "evt_2" {
+		t.Errorf("expected events evt_1, evt_2 in order, got %+v", detail.Events)
+	}
+	if len(detail.Actions) != 1 || detail.Actions[0].LeakID != leakID {
+		t.Errorf("expected one action for the leak, got %+v", detail.Actions)
+	}
+	if len(detail.Warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", detail.Warnings)
+	}
+}
+
+func TestGetLeakHandler_ActionsFailureReturnsWarning(t *testing.T) {
+	tenantID := uuid.New()
+	leakID, leaks, events, actions := seedLeakDetail(tenantID)
+	actions.err = errors.New("connection reset")
+	handler := newLeaksTestHandler(leaks, events, actions)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newGetLeakRequest(leakID.String(), tenantID))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	detail := decodeLeakDetail(t, w)
+	if detail.Leak.ID != leakID || len(detail.Events) != 2 {
+		t.Errorf("expected leak and events despite actions failure, got %+v", detail)
+	}
+	if len(detail.Actions) != 0 {
+		t.Errorf("expected no actions, got %+v", detail.Actions)
+	}
+	if len(detail.Warnings) != 1 || detail.Warnings[0] != WarningActionsUnavailable {
+		t.Errorf("expected warning %q, got %v", WarningActionsUnavailable, detail.Warnings)
+	}
+}
+
+func TestGetLeakHandler_EventsFailure(t *testing.T) {
+	tenantID := uuid.New()
+	leakID, leaks, events, actions := seedLeakDetail(tenantID)
+	events.err = errors.New("connection reset")
+	handler := newLeaksTestHandler(leaks, events, actions)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newGetLeakRequest(leakID.String(), tenantID))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+}
+
+func TestGetLeakHandler_NotFound(t *testing.T) {
+	tenantID := uuid.New()
+	_, leaks, events, actions := seedLeakDetail(tenantID)
+	handler := newLeaksTestHandler(leaks, events, actions)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newGetLeakRequest(uuid.New().String(), tenantID))
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+	var body ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode error response: %v", err)
+	}
+	if body.Error.Code != ErrorCodeNotFound {
+		t.Errorf("expected error code %q, got %q", ErrorCodeNotFound, body.Error.Code)
+	}
+}
+
+func TestGetLeakHandler_InvalidID(t *testing.T) {
+	tenantID := uuid.New()
+	_, leaks, events, actions := seedLeakDetail(tenantID)
+	handler := newLeaksTestHandler(leaks, events, actions)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newGetLeakRequest("not-a-uuid", tenantID))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// testListLeaksService filters and orders leaks in memory the way the listing query does and
+// records the filter it was called with
+type testListLeaksService struct {
+	services.LeaksService
+	leaks  []models.Leak
+	filter models.LeakFilter
+	err    error
+}
+
+func (s *testListLeaksService) ListLeaks(_ context.Context, _ uuid.UUID, filter models.LeakFilter, params models.PaginationParams) (models.PaginatedResponse[models.Leak], error) {
+	s.filter = filter
+	if s.err != nil {
+		return models.PaginatedResponse[models.Leak]{}, s.err
+	}
+	var matched []models.Leak
+	for _, leak := range s.leaks {
+		if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, leak.Status) {
+			continue
+		}
+		if len(filter.LeakTypes) > 0 && !slices.Contains(filter.LeakTypes, leak.LeakType) {
+			continue
+		}
+		if filter.DetectedFrom != nil && leak.DetectedAt.Before(*filter.DetectedFrom) {
+			continue
+		}
+		if filter.DetectedTo != nil && !leak.DetectedAt.Before(*filter.DetectedTo) {
+			continue
+		}
+		if filter.ExcludeSnoozed && leak.IsSnoozed(time.Now()) {
+			continue
+		}
+		matched = append(matched, leak)
+	}
+	slices.SortStableFunc(matched, func(a, b models.Leak) int { return b.Amount.Cmp(a.Amount) })
+	end := min(int(params.Offset+params.Limit), len(matched))
+	start := min(int(params.Offset), end)
+	return models.NewPaginatedResponse(matched[start:end], int64(len(matched)), params.Limit, params.Offset), nil
+}
+
+func TestListLeaksHandler(t *testing.T) {
+	day := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	leak := func(amount string, status models.LeakStatusEnum, leakType models.LeakTypeEnum, detectedAt time.Time) models.Leak {
+		return models.Leak{ID: uuid.New(), Amount: models.MustParseDecimal(amount), Status: status, LeakType: leakType, Currency: "USD", DetectedAt: detectedAt}
+	}
+	svc := &testListLeaksService{leaks: []models.Leak{
+		leak("10.00", models.LeakStatusEnumOpen, models.LeakTypeEnumFailedPayments, day),
+		leak("250.00", models.LeakStatusEnumOpen, models.LeakTypeEnumQuietChurn, day.Add(24*time.Hour)),
+		leak("99.99", models.LeakStatusEnumResolved, models.LeakTypeEnumFailedPayments, day.Add(48*time.Hour)),
+		leak("75.50", models.LeakStatusEnumOpen, models.LeakTypeEnumFailedPayments, day.Add(72*time.Hour)),
+		leak("5000.00", models.LeakStatusEnumIgnored, models.LeakTypeEnumOther, day),
+		leak("400.00", models.LeakStatusEnumOpen, models.LeakTypeEnumOther, day),
+	}}
+	snoozedUntil := time.Now().Add(time.Hour)
+	svc.leaks[5].SnoozedUntil = &snoozedUntil
+	logger := newTestLogger()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /leaks", ListLeaksHandler(logger, svc))
+	handler := middleware.TenantContext(logger, true, nil)(mux)
+
+	list := func(t *testing.T, query string) (*httptest.ResponseRecorder, models.PaginatedResponse[LeakResponse]) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/leaks?"+query, nil)
+		req.Header.Set("X-Tenant-ID", uuid.New().String())
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		var body models.PaginatedResponse[LeakResponse]
+		if w.Code == http.StatusOK {
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+		}
+		return w, body
+	}
+	amounts := func(body models.PaginatedResponse[LeakResponse]) []string {
+		var out []string
+		for _, leak := range body.Items {
+			out = append(out, leak.Amount.String())
+		}
+		return out
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"defaults to open leaks, largest first", "", []string{"250.00", "75.50", "10.00"}},
+		{"explicit statuses", "status=resolved,ignored", []string{"5000.00", "99.99"}},
+		{"leak type", "leak_type=failed_payments", []string{"75.50", "10.00"}},
+		{"leak type across statuses", "leak_type=failed_payments&status=open&status=resolved", []string{"99.99", "75.50", "10.00"}},
+		{"detected range in RFC 3339", "detected_from=2025-03-02T00:00:00Z&detected_to=2025-03-04T00:00:00Z", []string{"250.00"}},
+		{"include snoozed", "include_snoozed=true", []string{"400.00", "250.00", "75.50", "10.00"}},
+		{"detected from in Unix milliseconds", "detected_from=" + fmt.Sprint(day.Add(72*time.Hour).UnixMilli()), []string{"75.50"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, body := list(t, tt.query)
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+			if got := amounts(body); !slices.Equal(got, tt.want) {
+				t.Errorf("expected leaks %v, got %v", tt.want, got)
+			}
+			if body.TotalCount != int64(len(tt.want)) {
+				t.Errorf("expected total_count %d, got %d", len(tt.want), body.TotalCount)
+			}
+		})
+	}
+
+	t.Run("pagination metadata", func(t *testing.T) {
+		w, body := list(t, "limit=2&offset=1")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if got := amounts(body); !slices.Equal(got, []string{"75.50", "10.00"}) {
+			t.Errorf("expected the second page, got %v", got)
+		}
+		if body.TotalCount != 3 || body.Limit != 2 || body.Offset != 1 || body.HasNext || !body.HasPrevious {
+			t.Errorf("unexpected pagination metadata %+v", body)
+		}
+
+		_, body = list(t, "limit=1")
+		if body.Limit != 1 || !body.HasNext {
+			t.Errorf("expected more pages after the first, got %+v", body)
+		}
+		if !slices.Equal(svc.filter.Statuses, []models.LeakStatusEnum{models.LeakStatusEnumOpen}) {
+			t.Errorf("expected the default open status to reach the service, got %v", svc.filter.Statuses)
+		}
+	})
+
+	for _, query := range []string{
+		"status=open,archived",
+		"leak_type=theft",
+		"detected_from=yesterday",
+		"detected_from=2025-03-04T00:00:00Z&detected_to=2025-03-02T00:00:00Z",
+		"limit=1001",
+		"include_snoozed=maybe",
+	} {
+		t.Run("rejects "+query, func(t *testing.T) {
+			if w, _ := list(t, query); w.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+			}
+		})
+	}
+
+	t.Run("service failure", func(t *testing.T) {
+		failing := &testListLeaksService{err: errors.New("database down")}
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /leaks", ListLeaksHandler(logger, failing))
+		req := httptest.NewRequest(http.MethodGet, "/leaks", nil)
+		req.Header.Set("X-Tenant-ID", uuid.New().String())
+		w := httptest.NewRecorder()
+		middleware.TenantContext(logger, true, nil)(mux).ServeHTTP(w, req)
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
+		}
+	})
+}
+
+// testSnoozeLeaksService snoozes leaks held in memory; other methods panic via the nil embedded interface
+type testSnoozeLeaksService struct {
+	services.LeaksService
+	leaks map[uuid.UUID]models.Leak
+	err   error
+}
+
+func (s *testSnoozeLeaksService) SnoozeLeak(_ context.Context, id uuid.UUID, until time.Time, _ uuid.UUID) (models.Leak, error) {
+	if s.err != nil {
+		return models.Leak{}, s.err
+	}
+	leak, ok := s.leaks[id]
+	if !ok {
+		return models.Leak{}, services.ErrLeakNotFound
+	}
+	leak.SnoozedUntil = &until
+	s.leaks[id] = leak
+	return leak, nil
+}
+
+func TestSnoozeLeakHandler(t *testing.T) {
+	leakID := uuid.New()
+	svc := &testSnoozeLeaksService{leaks: map[uuid.UUID]models.Leak{
+		leakID: {ID: leakID, Status: models.LeakStatusEnumOpen, Amount: models.MustParseDecimal("49.50")},
+	}}
+	logger := newTestLogger()
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /leaks/{id}/snooze", SnoozeLeakHandler(logger, svc))
+	handler := middleware.TenantContext(logger, true, nil)(mux)
+
+	snooze := func(id, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/leaks/"+id+"/snooze", strings.NewReader(body))
+		req.Header.Set("X-Tenant-ID", uuid.New().String())
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("sets snoozed_until from the duration", func(t *testing.T) {
+		before := time.Now()
+		w := snooze(leakID.String(), `{"duration":"24h"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var body LeakResponse
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if body.SnoozedUntil == nil {
+			t.Fatal("expected snoozed_until in the response")
+		}
+		until := *svc.leaks[leakID].SnoozedUntil
+		if until.Before(before.Add(24*time.Hour)) || until.After(time.Now().Add(24*time.Hour)) {
+			t.Errorf("expected the snooze to end 24h from now, got %v", until)
+		}
+		if body.Status != models.LeakStatusEnumOpen {
+			t.Errorf("expected the leak to stay open, got %s", body.Status)
+		}
+	})
+
+	for _, body := range []string{`{"duration":"0s"}`, `{"duration":"-1h"}`, `{"duration":"2161h"}`, `{"duration":"tomorrow"}`, `{}`, `not json`} {
+		t.Run("rejects "+body, func(t *testing.T) {
+			if w := snooze(leakID.String(), body); w.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+			}
+		})
+	}
+
+	t.Run("invalid leak id", func(t *testing.T) {
+		if w := snooze("not-a-uuid", `{"duration":"1h"}`); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("unknown leak", func(t *testing.T) {
+		if w := snooze(uuid.New().String(), `{"duration":"1h"}`); w.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("service failure", func(t *testing.T) {
+		svc.err = errors.New("database down")
+		defer func() { svc.err = nil }()
+		if w := snooze(leakID.String(), `{"duration":"1h"}`); w.Code != http.StatusInternalServerError {
+			t.Errorf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"rdl-api/internal/domain/models"
+	"sort"
+)
+
+// PageShrunkHeader is set to "true" on a list response whose page was cut to fit under the
+// limit set by ResponseOptions.MaxListBytes. The pagination fields describe the shorter page.
+const PageShrunkHeader = "X-Page-Shrunk"
+
+// ListFormat selects the top-level shape of list endpoint responses
+type ListFormat string
+
+const (
+	// ListFormatFlat is the original shape: the PaginatedResponse fields at the top level
+	ListFormatFlat ListFormat = "flat"
+	// ListFormatEnvelope wraps the page as {"data": [...], "pagination": {...}}
+	ListFormatEnvelope ListFormat = "envelope"
+)
+
+// Validate reports an error for a list shape WriteListResponse cannot write
+func (f ListFormat) Validate() error {
+	switch f {
+	case ListFormatFlat, ListFormatEnvelope:
+		return nil
+	default:
+		return fmt.Errorf("unsupported list format %q", f)
+	}
+}
+
+// ListResponse is the envelope list endpoints return under ListFormatEnvelope
+type ListResponse[T any] struct {
+	Data       []T            `json:"data"`
+	Pagination PaginationMeta `json:"pagination"`
+}
+
+// PaginationMeta describes where a page sits in the full result set
+type PaginationMeta struct {
+	TotalCount  int64 `json:"total_count"`
+	Limit       int32 `json:"limit"`
+	Offset      int32 `json:"offset"`
+	HasNext     bool  `json:"has_next"`
+	HasPrevious bool  `json:"has_previous"`
+}
+
+// NewListResponse converts a paginated page to the envelope shape
+func NewListResponse[T any](page models.PaginatedResponse[T]) ListResponse[T] {
+	data := page.Items
+	if data == nil {
+		data = []T{}
+	}
+	return ListResponse[T]{
+		Data: data,
+		Pagination: PaginationMeta{
+			TotalCount:  page.TotalCount,
+			Limit:       page.Limit,
+			Offset:      page.Offset,
+			HasNext:     page.HasNext,
+			HasPrevious: page.HasPrevious,
+		},
+	}
+}
+
+// WriteListResponse writes a page with 200 OK in the shape selected by the request's
+// ResponseOptions. A page larger than their MaxListBytes is cut to the items that fit and
+// flagged with PageShrunkHeader.
+func WriteListResponse[T any](
+	ctx context.Context,
+	w http.ResponseWriter,
+	logger *slog.Logger,
+	page models.PaginatedResponse[T],
+) {
+	opts := responseOptionsFrom(ctx)
+	if maxBytes := opts.MaxListBytes; maxBytes > 0 {
+		if shrunk, ok := shrinkPage(page, opts.ListFormat, maxBytes); ok {
+			logger.InfoContext(ctx, "List page shrunk to fit the response size limit",
+				"items", len(page.Items), "kept", len(shrunk.Items), "max_bytes", maxBytes)
+			w.Header().Set(PageShrunkHeader, "true")
+			page = shrunk
+		}
+	}
+	WriteJSONResponse(ctx, w, logger, listBody(page, opts.ListFormat), http.StatusOK)
+}
+
+// listBody returns what WriteListResponse encodes for page in format
+func listBody[T any](page models.PaginatedResponse[T], format ListFormat) any {
+	if format == ListFormatEnvelope {
+		return NewListResponse(page)
+	}
+	return page
+}
+
+// shrinkPage returns the longest prefix of page whose encoding is at most maxBytes, as a page
+// with that many items per page so HasNext and the next offset stay right, and reports whether
+// it dropped any items. At least one item is always kept, even when it alone is too large.
+func shrinkPage[T any](page models.PaginatedResponse[T], format ListFormat, maxBytes int64) (models.PaginatedResponse[T], bool) {
+	if len(page.Items) <= 1 || fitsIn(page, format, maxBytes) {
+		return page, false
+	}
+	// The first length that no longer fits; the whole page is known not to
+	kept := sort.Search(len(page.Items), func(n int) bool {
+		return n > 0 && !fitsIn(truncatePage(page, n), format, maxBytes)
+	}) - 1
+	return truncatePage(page, max(kept, 1)), true
+}
+
+// truncatePage returns the first n items of page as a page of limit n
+func truncatePage[T any](page models.PaginatedResponse[T], n int) models.PaginatedResponse[T] {
+	return models.NewPaginatedResponse(page.Items[:n], page.TotalCount, int32(n), page.Offset)
+}
+
+// fitsIn reports whether the response body for page in format, with the newline
+// WriteJSONResponse ends it with, is at most maxBytes. A page that fails to encode is left to WriteJSONResponse to report.
+func fitsIn[T any](page models.PaginatedResponse[T], format ListFormat, maxBytes int64) bool {
+	data, err := json.Marshal(listBody(page, format))
+	return err != nil || int64(len(data))+1 <= maxBytes
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"rdl-api/internal/domain/models"
+	"strings"
+	"testing"
+)
+
+func TestWriteListResponse(t *testing.T) {
+	page := models.NewPaginatedResponse([]string{"a", "b"}, 5, 2, 2)
+
+	tests := []struct {
+		format   ListFormat
+		expected string
+	}{
+		{ListFormatFlat, `{"items":["a","b"],"total_count":5,"limit":2,"offset":2,"has_next":true,"has_previous":true}`},
+		{ListFormatEnvelope, `{"data":["a","b"],"pagination":{"total_count":5,"limit":2,"offset":2,"has_next":true,"has_previous":true}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			ctx := ContextWithResponseOptions(context.Background(), ResponseOptions{ListFormat: tt.format})
+			w := httptest.NewRecorder()
+			WriteListResponse(ctx, w, newTestLogger(), page)
+
+			var got, want any
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			_ = json.Unmarshal([]byte(tt.expected), &want)
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(want)
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("expected %s, got %s", wantJSON, gotJSON)
+			}
+		})
+	}
+}
+
+func TestNewListResponse_EmptyPageHasEmptyData(t *testing.T) {
+	data, err := json.Marshal(NewListResponse(models.NewPaginatedResponse[string](nil, 0, 10, 0)))
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if got, want := string(data), `{"data":[],"pagination":{"total_count":0,"limit":10,"offset":0,"has_next":false,"has_previous":false}}`; got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestListFormat_Validate(t *testing.T) {
+	if err := ListFormat("nested").Validate(); err == nil {
+		t.Fatal("expected error for unknown list format")
+	}
+	if err := ListFormatEnvelope.Validate(); err != nil {
+		t.Errorf("Validate(%q) error = %v", ListFormatEnvelope, err)
+	}
+}
+
+func TestWriteListResponse_ShrinksOversizedPage(t *testing.T) {
+	data := json.RawMessage(`{"blob":"` + strings.Repeat("x", 1000) + `"}`)
+	items := make([]EventResponse, 10)
+	for i := range items {
+		items[i] = EventResponse{EventType: models.EventTypeEnumPaymentSucceeded, Data: &data}
+	}
+	page := models.NewPaginatedResponse(items, 100, 10, 20)
+
+	for _, format := range []ListFormat{ListFormatFlat, ListFormatEnvelope} {
+		t.Run(string(format), func(t *testing.T) {
+			ctx := ContextWithResponseOptions(context.Background(), ResponseOptions{ListFormat: format, MaxListBytes: 4000})
+			w := httptest.NewRecorder()
+			WriteListResponse(ctx, w, newTestLogger(), page)
+
+			if got := w.Header().Get(PageShrunkHeader); got != "true" {
+				t.Errorf("expected %s: true, got %q", PageShrunkHeader, got)
+			}
+			if w.Body.Len() > 4000 {
+				t.Errorf("expected a body of at most 4000 bytes, got %d", w.Body.Len())
+			}
+
+			var body struct {
+				Items      []EventResponse `json:"items"`
+				Data       []EventResponse `json:"data"`
+				Limit      int32           `json:"limit"`
+				HasNext    bool            `json:"has_next"`
+				Pagination PaginationMeta  `json:"pagination"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			kept, limit, hasNext := body.Items, body.Limit, body.HasNext
+			if format == ListFormatEnvelope {
+				kept, limit, hasNext = body.Data, body.Pagination.Limit, body.Pagination.HasNext
+			}
+			if len(kept) == 0 || len(kept) >= len(items) {
+				t.Errorf("expected some but not all of the %d items, got %d", len(items), len(kept))
+			}
+			if int(limit) != len(kept) {
+				t.Errorf("expected limit %d to match the items kept, got %d", len(kept), limit)
+			}
+			if !hasNext {
+				t.Error("expected has_next on a shrunk page")
+			}
+		})
+	}
+}
+
+func TestWriteListResponse_PageWithinLimitIsUnchanged(t *testing.T) {
+	ctx := ContextWithResponseOptions(context.Background(), ResponseOptions{MaxListBytes: 4000})
+	w := httptest.NewRecorder()
+	WriteListResponse(ctx, w, newTestLogger(), models.NewPaginatedResponse([]string{"a", "b"}, 2, 10, 0))
+
+	if got := w.Header().Get(PageShrunkHeader); got != "" {
+		t.Errorf("expected no %s header, got %q", PageShrunkHeader, got)
+	}
+	if got, want := strings.TrimSpace(w.Body.String()), `{"items":["a","b"],"total_count":2,"limit":10,"offset":0,"has_next":false,"has_previous":false}`; got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestShrinkPage_KeepsOneOversizedItem(t *testing.T) {
+	page := models.NewPaginatedResponse([]string{strings.Repeat("x", 100), "b"}, 2, 2, 0)
+
+	shrunk, ok := shrinkPage(page, ListFormatFlat, 50)
+	if !ok {
+		t.Fatal("expected the page to be shrunk")
+	}
+	if len(shrunk.Items) != 1 || shrunk.Limit != 1 || !shrunk.HasNext {
+		t.Errorf("expected one item with limit 1 and has_next, got %d items, limit %d, has_next %v", len(shrunk.Items), shrunk.Limit, shrunk.HasNext)
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/domain/services"
+	"rdl-api/internal/metrics"
+	"rdl-api/internal/middleware"
+
+	"github.com/google/uuid"
+)
+
+// NotificationChannelResponse is the API representation of a notification channel;
+// timestamps follow the configured time format
+type NotificationChannelResponse struct {
+	ID          uuid.UUID                          `json:"id"`
+	ChannelType models.NotificationChannelTypeEnum `json:"channel_type"`
+	Target      string                             `json:"target"`
+	Enabled     bool                               `json:"enabled"`
+	CreatedAt   APITime                            `json:"created_at"`
+	UpdatedAt   APITime                            `json:"updated_at"`
+}
+
+// NewNotificationChannelResponse converts a domain notification channel to its API representation
+func NewNotificationChannelResponse(channel models.NotificationChannel, format TimeFormat) NotificationChannelResponse {
+	return NotificationChannelResponse{
+		ID:          channel.ID,
+		ChannelType: channel.ChannelType,
+		Target:      channel.Target,
+		Enabled:     channel.Enabled,
+		CreatedAt:   NewAPITime(channel.CreatedAt, format),
+		UpdatedAt:   NewAPITime(channel.UpdatedAt, format),
+	}
+}
+
+// ListNotificationChannelsHandler returns a handler for GET /notification-channels, the
+// channels the tenant's leak notifications are delivered to, oldest first. Disabled channels
+// are listed too; they are skipped when notifying.
+func ListNotificationChannelsHandler(logger *slog.Logger, channelsService services.NotificationChannelsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		tenantID, ok := middleware.GetTenantID(r)
+		if !ok {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidTenant, ErrorCodeUnauthorized, middleware.ErrMissingOrInvalidTenantContext, http.StatusUnauthorized)
+			return
+		}
+
+		channels, err := channelsService.GetNotificationChannels(ctx, tenantID)
+		if err != nil {
+			logger.Log(ctx, serviceErrorLevel(err), "Failed to list notification channels", "error", err, "tenant_id", tenantID)
+			WriteServerError(ctx, w, logger, err)
+			return
+		}
+
+		response := make([]NotificationChannelResponse, len(channels))
+		for i, channel := range channels {
+			response[i] = NewNotificationChannelResponse(channel, timeFormatFrom(ctx))
+		}
+		WriteJSONSuccessResponse(ctx, w, logger, response)
+	}
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/domain/services"
+	"rdl-api/internal/middleware"
+
+	"github.com/google/uuid"
+)
+
+// testNotificationChannelsService lists fixed channels per tenant; other methods panic via the nil embedded interface
+type testNotificationChannelsService struct {
+	services.NotificationChannelsService
+	channels map[uuid.UUID][]models.NotificationChannel
+}
+
+func (s *testNotificationChannelsService) GetNotificationChannels(_ context.Context, tenantID uuid.UUID) ([]models.NotificationChannel, error) {
+	return s.channels[tenantID], nil
+}
+
+func TestListNotificationChannelsHandler(t *testing.T) {
+	tenantID := uuid.New()
+	svc := &testNotificationChannelsService{channels: map[uuid.UUID][]models.NotificationChannel{tenantID: {
+		{ID: uuid.New(), TenantID: tenantID, ChannelType: models.NotificationChannelTypeEnumSlack, Target: "https://hooks.slack.com/services/T/B/X", Enabled: true},
+		{ID: uuid.New(), TenantID: tenantID, ChannelType: models.NotificationChannelTypeEnumEmail, Target: "billing@example.com", Enabled: false},
+	}}}
+	logger := newTestLogger()
+	handler := middleware.TenantContext(logger, true, nil)(ListNotificationChannelsHandler(logger, svc))
+
+	list := func(tenant uuid.UUID) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/notification-channels", nil)
+		req.Header.Set("X-Tenant-ID", tenant.String())
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := list(tenantID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var body []NotificationChannelResponse
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(body) != 2 {
+		t.Fatalf("expected 2 channels, got %d", len(body))
+	}
+	if body[0].ChannelType != models.NotificationChannelTypeEnumSlack || !body[0].Enabled {
+		t.Errorf("expected the enabled Slack channel first, got %+v", body[0])
+	}
+	if body[1].ChannelType != models.NotificationChannelTypeEnumEmail || body[1].Enabled || body[1].Target != "billing@example.com" {
+		t.Errorf("expected the disabled email channel listed, got %+v", body[1])
+	}
+
+	w = list(uuid.New())
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != "[]" {
+		t.Errorf("expected an empty list for a tenant without channels, got %d: %q", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,722 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/domain/services"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OpenAPIPath serves the OpenAPI document describing the API
+const OpenAPIPath = "/openapi.json"
+
+// OpenAPISpec is an OpenAPI 3 document. Only the parts of the specification the API uses
+// are modeled.
+type OpenAPISpec struct {
+	OpenAPI    string                     `json:"openapi"`
+	Info       OpenAPIInfo                `json:"info"`
+	Paths      map[string]OpenAPIPathItem `json:"paths"`
+	Components OpenAPIComponents          `json:"components"`
+	Security   []map[string][]string      `json:"security,omitempty"`
+}
+
+// OpenAPIInfo is the document's title and API version
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// OpenAPIPathItem maps a lower-case HTTP method to its operation
+type OpenAPIPathItem map[string]OpenAPIOperation
+
+// OpenAPIOperation describes one method on one path
+type OpenAPIOperation struct {
+	Summary     string                     `json:"summary"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Parameters  []OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses"`
+	// Security overrides the document default; a pointer to an empty list marks a public operation
+	Security *[]map[string][]string `json:"security,omitempty"`
+}
+
+// OpenAPIParameter is a path, query or header parameter
+type OpenAPIParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+	Schema      *OpenAPISchema `json:"schema"`
+}
+
+// OpenAPIRequestBody is an operation's request body
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required,omitempty"`
+	Content  map[string]OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIResponse is one status code's response
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIMediaType holds the schema for one content type
+type OpenAPIMediaType struct {
+	Schema *OpenAPISchema `json:"schema"`
+}
+
+// OpenAPIComponents holds the reusable schemas and the security schemes
+type OpenAPIComponents struct {
+	Schemas         map[string]*OpenAPISchema        `json:"schemas"`
+	SecuritySchemes map[string]OpenAPISecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// OpenAPISecurityScheme describes how a request is authenticated
+type OpenAPISecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme,omitempty"`
+	In     string `json:"in,omitempty"`
+	Name   string `json:"name,omitempty"`
+}
+
+// OpenAPISchema is a JSON schema. The zero value matches any JSON value.
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Description          string                    `json:"description,omitempty"`
+	Enum                 []string                  `json:"enum,omitempty"`
+	Nullable             bool                      `json:"nullable,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+}
+
+// OpenAPIOptions are the deployment settings that change the document
+type OpenAPIOptions struct {
+	Version    string
+	HealthPath string
+	LivePath   string
+	ReadyPath  string
+	// StripeWebhook documents POST /webhooks/stripe; set when the webhook is registered
+	StripeWebhook bool
+	// Response holds the formats the documented timestamps and list bodies follow
+	Response ResponseOptions
+}
+
+// openAPIEnums lists the values of the string enums that appear in API payloads
+var openAPIEnums = map[reflect.Type][]string{
+	reflect.TypeOf(models.EventTypeEnum("")):    enumValues(models.EventTypeEnumPaymentFailed, models.EventTypeEnumPaymentSucceeded, models.EventTypeEnumPaymentRefunded, models.EventTypeEnumPaymentUpdated),
+	reflect.TypeOf(models.EventStatusEnum("")):  enumValues(models.EventStatusEnumPending, models.EventStatusEnumProcessed, models.EventStatusEnumFailed, models.EventStatusEnumProcessing),
+	reflect.TypeOf(models.EventSourceEnum("")):  enumValues(models.EventSourceEnumWebhook, models.EventSourceEnumImport, models.EventSourceEnumManual, models.EventSourceEnumApi),
+	reflect.TypeOf(models.LeakStatusEnum("")):   enumValues(models.LeakStatusEnumOpen, models.LeakStatusEnumResolved, models.LeakStatusEnumIgnored),
+	reflect.TypeOf(models.LeakTypeEnum("")):     enumValues(models.LeakTypeEnumFailedPayments, models.LeakTypeEnumUnbilledUsage, models.LeakTypeEnumQuietChurn, models.LeakTypeEnumCouponDiscountMisuse, models.LeakTypeEnumTrialForever, models.LeakTypeEnumOther, models.LeakTypeEnumVolumeAnomaly, models.LeakTypeEnumDuplicateCharge, models.LeakTypeEnumDunningGap, models.LeakTypeEnumCurrencyMismatch, models.LeakTypeEnumFailureRateSpike),
+	reflect.TypeOf(models.ActionTypeEnum("")):   enumValues(models.ActionTypeEnumRetryPayment, models.ActionTypeEnumOutreach, models.ActionTypeEnumLinearTask, models.ActionTypeEnumEmail, models.ActionTypeEnumOther),
+	reflect.TypeOf(models.ActionStatusEnum("")): enumValues(models.ActionStatusEnumPending, models.ActionStatusEnumApproved, models.ActionStatusEnumModified, models.ActionStatusEnumDenied, models.ActionStatusEnumInProgress, models.ActionStatusEnumFailed),
+	reflect.TypeOf(models.ActionResultEnum("")): enumValues(models.ActionResultEnumSuccess, models.ActionResultEnumFailure, models.ActionResultEnumPending, models.ActionResultEnumOther),
+}
+
+// actionSortValues lists every value GET /actions accepts for sort
+func actionSortValues() []string {
+	var values []string
+	for _, field := range []models.ActionSortField{models.ActionSortCreatedAt, models.ActionSortUpdatedAt, models.ActionSortPriority} {
+		values = append(values, string(field), "-"+string(field))
+	}
+	return values
+}
+
+func enumValues[T ~string](values ...T) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		out = append(out, string(v))
+	}
+	return out
+}
+
+var (
+	uuidType       = reflect.TypeOf(uuid.UUID{})
+	apiTimeType    = reflect.TypeOf(APITime{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	decimalType    = reflect.TypeOf(models.Decimal{})
+)
+
+// openAPISchemas builds schemas from Go types and collects named structs as components,
+// so the document follows the response DTOs instead of drifting from them
+type openAPISchemas struct {
+	components map[string]*OpenAPISchema
+	response   ResponseOptions
+}
+
+// ref returns the schema for v's type
+func (s *openAPISchemas) ref(v any) *OpenAPISchema {
+	return s.schemaFor(reflect.TypeOf(v))
+}
+
+func (s *openAPISchemas) schemaFor(t reflect.Type) *OpenAPISchema {
+	if values, ok := openAPIEnums[t]; ok {
+		return &OpenAPISchema{Type: "string", Enum: values}
+	}
+
+	switch t {
+	case uuidType:
+		return &OpenAPISchema{Type: "string", Format: "uuid"}
+	case apiTimeType:
+		if s.response.TimeFormat == TimeFormatUnixMs {
+			return &OpenAPISchema{Type: "integer", Format: "int64", Description: "milliseconds since the Unix epoch"}
+		}
+		return &OpenAPISchema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &OpenAPISchema{}
+	case decimalType:
+		return &OpenAPISchema{Type: "number", Description: "exact decimal amount"}
+	}
+	if t.Implements(optionalFieldType) {
+		return s.schemaFor(reflect.Zero(t).Interface().(optionalField).valueType())
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := *s.schemaFor(t.Elem())
+		if schema.Ref != "" {
+			// $ref siblings are ignored in OpenAPI 3.0, so the reference stays as is
+			return &schema
+		}
+		schema.Nullable = true
+		return &schema
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &OpenAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &OpenAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &OpenAPISchema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		return &OpenAPISchema{Type: "array", Items: s.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object", AdditionalProperties: s.schemaFor(t.Elem())}
+	case reflect.Struct:
+		// Instantiated generics have names like "ListResponse[...]" that aren't valid component keys
+		if t.Name() == "" || strings.Contains(t.Name(), "[") {
+			return s.structSchema(t)
+		}
+		if _, ok := s.components[t.Name()]; !ok {
+			// Reserve the name first so recursive types terminate
+			s.components[t.Name()] = &OpenAPISchema{}
+			s.components[t.Name()] = s.structSchema(t)
+		}
+		return &OpenAPISchema{Ref: "#/components/schemas/" + t.Name()}
+	default:
+		return &OpenAPISchema{}
+	}
+}
+
+// structSchema describes a struct's exported JSON fields. Fields without omitempty are required,
+// except Optional ones.
+func (s *openAPISchemas) structSchema(t reflect.Type) *OpenAPISchema {
+	schema := &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{}}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = s.schemaFor(field.Type)
+		if !strings.Contains(opts, "omitempty") && !field.Type.Implements(optionalFieldType) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// listSchema is the body of a list endpoint in the configured list shape
+func (s *openAPISchemas) listSchema(item any) *OpenAPISchema {
+	itemType := reflect.TypeOf(item)
+	if s.response.ListFormat == ListFormatEnvelope {
+		return &OpenAPISchema{
+			Type: "object",
+			Properties: map[string]*OpenAPISchema{
+				"data":       {Type: "array", Items: s.schemaFor(itemType)},
+				"pagination": s.ref(PaginationMeta{}),
+			},
+			Required: []string{"data", "pagination"},
+		}
+	}
+	schema := s.structSchema(reflect.TypeOf(models.PaginatedResponse[struct{}]{}))
+	schema.Properties["items"] = &OpenAPISchema{Type: "array", Items: s.schemaFor(itemType)}
+	return schema
+}
+
+// jsonContent wraps a schema as an application/json body
+func jsonContent(schema *OpenAPISchema) map[string]OpenAPIMediaType {
+	return map[string]OpenAPIMediaType{"application/json": {Schema: schema}}
+}
+
+// NewOpenAPISpec builds the OpenAPI document for the routes registered by the app.
+// Schemas are derived from the handlers' request and response types, and timestamps and
+// list bodies follow the time and list formats in opts.Response.
+func NewOpenAPISpec(opts OpenAPIOptions) OpenAPISpec {
+	s := &openAPISchemas{components: map[string]*OpenAPISchema{}, response: opts.Response}
+
+	errorResponse := func(description string) OpenAPIResponse {
+		return OpenAPIResponse{Description: description, Content: jsonContent(s.ref(ErrorResponse{}))}
+	}
+	ok := func(v any) OpenAPIResponse {
+		return OpenAPIResponse{Description: "OK", Content: jsonContent(s.ref(v))}
+	}
+	// staleOK is the 200 of an endpoint that may answer from the StaleCache
+	staleOK := func(v any) OpenAPIResponse {
+		return OpenAPIResponse{Description: "OK; while the database fails, the last result may be returned with " + StaleResultHeader + ": true and Age", Content: jsonContent(s.ref(v))}
+	}
+	// listOK is the 200 of a list endpoint, whose page WriteListResponse may cut short
+	listOK := func(item any) OpenAPIResponse {
+		return OpenAPIResponse{Description: "OK; a page over the configured size limit is cut to the items that fit and returned with " + PageShrunkHeader + ": true", Content: jsonContent(s.listSchema(item))}
+	}
+	idParam := func(description string) OpenAPIParameter {
+		return OpenAPIParameter{Name: "id", In: "path", Description: description, Required: true, Schema: &OpenAPISchema{Type: "string", Format: "uuid"}}
+	}
+	listParam := func(name, description string, items *OpenAPISchema) OpenAPIParameter {
+		return OpenAPIParameter{Name: name, In: "query", Description: description + "; repeat or comma-separate to match any of several", Schema: &OpenAPISchema{Type: "array", Items: items}}
+	}
+	public := &[]map[string][]string{}
+	admin := &[]map[string][]string{{"adminKey": {}}}
+	health := OpenAPIPathItem{"get": {
+		Summary:   "Health check",
+		Tags:      []string{"health"},
+		Responses: map[string]OpenAPIResponse{"200": ok(HealthResponse{}), "500": {Description: "Unhealthy"}},
+		Security:  public,
+	}}
+
+	paths := map[string]OpenAPIPathItem{
+		opts.HealthPath: health,
+		opts.LivePath:   health,
+		opts.ReadyPath: {"get": {
+			Summary:   "Readiness probe",
+			Tags:      []string{"health"},
+			Responses: map[string]OpenAPIResponse{"200": ok(HealthResponse{}), "500": {Description: "Unhealthy"}, "503": {Description: "Degraded, when HEALTH_READY_WHEN_DEGRADED is off", Content: jsonContent(s.ref(HealthResponse{}))}},
+			Security:  public,
+		}},
+		RootPath: {"get": {
+			Summary:   "Describe the service, its version and where to find health and this document",
+			Tags:      []string{"meta"},
+			Responses: map[string]OpenAPIResponse{"200": ok(RootResponse{})},
+			Security:  public,
+		}},
+		OpenAPIPath: {"get": {
+			Summary:   "This document",
+			Tags:      []string{"meta"},
+			Responses: map[string]OpenAPIResponse{"200": {Description: "OpenAPI 3 document", Content: jsonContent(&OpenAPISchema{Type: "object"})}},
+			Security:  public,
+		}},
+		VersionPath: {"get": {
+			Summary:   "The build the service is running",
+			Tags:      []string{"meta"},
+			Responses: map[string]OpenAPIResponse{"200": ok(VersionResponse{})},
+			Security:  public,
+		}},
+		"/events": {"get": {
+			Summary: "List events, newest first",
+			Tags:    []string{"events"},
+			Parameters: []OpenAPIParameter{
+				listParam("event_type", "Event types to match", s.ref(models.EventTypeEnum(""))),
+				listParam("status", "Statuses to match", s.ref(models.EventStatusEnum(""))),
+				listParam("provider_id", "Providers to match", s.ref(uuid.UUID{})),
+				{Name: "limit", In: "query", Description: "Page size, at most " + strconv.Itoa(maxEventsPageSize), Schema: &OpenAPISchema{Type: "integer", Format: "int32"}},
+				{Name: "offset", In: "query", Description: "Number of events to skip", Schema: &OpenAPISchema{Type: "integer", Format: "int32"}},
+			},
+			Responses: map[string]OpenAPIResponse{
+				"200": listOK(EventResponse{}),
+				"400": errorResponse("Invalid filter or pagination"),
+				"401": errorResponse("Missing or invalid tenant"),
+			},
+		}},
+		"/events/search": {"get": {
+			Summary: "Find events by the start of their provider event ID",
+			Tags:    []string{"events"},
+			Parameters: []OpenAPIParameter{
+				{Name: "external_id", In: "query", Required: true, Description: "Prefix of the provider event ID, 1 to " + strconv.Itoa(services.MaxExternalIDPrefixLength) + " characters, matched literally", Schema: &OpenAPISchema{Type: "string"}},
+				{Name: "limit", In: "query", Description: "Page size, at most " + strconv.Itoa(maxEventsPageSize), Schema: &OpenAPISchema{Type: "integer", Format: "int32"}},
+				{Name: "offset", In: "query", Description: "Number of events to skip", Schema: &OpenAPISchema{Type: "integer", Format: "int32"}},
+			},
+			Responses: map[string]OpenAPIResponse{
+				"200": listOK(EventResponse{}),
+				"400": errorResponse("Missing or overlong external_id, or invalid pagination"),
+				"401": errorResponse("Missing or invalid tenant"),
+			},
+		}},
+		"/events/export": {"get": {
+			Summary: "Export all events as NDJSON, one event per line",
+			Tags:    []string{"events"},
+			Responses: map[string]OpenAPIResponse{
+				"200": {
+					Description: "One EventResponse per line, ending with an ExportLine marker if the export was truncated",
+					Content:     map[string]OpenAPIMediaType{"application/x-ndjson": {Schema: s.ref(EventResponse{})}},
+				},
+				"401": errorResponse("Missing or invalid tenant"),
+			},
+		}},
+		"/events/batch": {"post": {
+			Summary:     "Store a JSON array of events and report the outcome of each",
+			Tags:        []string{"events"},
+			RequestBody: &OpenAPIRequestBody{Required: true, Content: jsonContent(s.ref([]BatchEventRequest{}))},
+			Responses: map[string]OpenAPIResponse{
+				"207": {Description: "One result per item, in request order", Content: jsonContent(s.ref(BatchEventsResponse{}))},
+				"400": errorResponse("Body is not a non-empty JSON array"),
+				"401": errorResponse("Missing or invalid tenant"),
+				"413": errorResponse("Body too large, or more events than MAX_BATCH_SIZE"),
+			},
+		}},
+		"/events/reprocess-failed": {"post": {
+			Summary: "Re-validate a batch of failed events and mark the ones that pass as processed",
+			Tags:    []string{"events"},
+			Parameters: []OpenAPIParameter{
+				{Name: "cursor", In: "query", Description: "next_cursor from the previous call; omit to start from the beginning", Schema: &OpenAPISchema{Type: "string", Format: "uuid"}},
+				{Name: "limit", In: "query", Description: "Failed events to handle in this call, at most " + strconv.Itoa(maxReprocessBatchSize), Schema: &OpenAPISchema{Type: "integer", Format: "int32"}},
+			},
+			Responses: map[string]OpenAPIResponse{
+				"200": ok(ReprocessFailedResponse{}),
+				"400": errorResponse("Invalid cursor or limit"),
+				"401": errorResponse("Missing or invalid tenant"),
+			},
+		}},
+		"/events/{id}": {
+			"patch": {
+				Summary: "Update an event; omitted fields are left unchanged",
+				Tags:    []string{"events"},
+				Parameters: []OpenAPIParameter{
+					idParam("Event ID"),
+					{Name: "If-Unmodified-Since", In: "header", Description: "Only update if the event has not changed since this HTTP date", Schema: &OpenAPISchema{Type: "string"}},
+					{Name: "data_mode", In: "query", Description: "How data is applied: replace (default) overwrites the payload, merge adds its top-level keys to it", Schema: &OpenAPISchema{Type: "string", Enum: []string{string(models.DataModeReplace), string(models.DataModeMerge)}}},
+				},
+				RequestBody: &OpenAPIRequestBody{Required: true, Content: jsonContent(s.ref(UpdateEventRequest{}))},
+				Responses: map[string]OpenAPIResponse{
+					"200": ok(EventResponse{}),
+					"400": errorResponse("Invalid event ID, body or data_mode"),
+					"401": errorResponse("Missing or invalid tenant"),
+					"404": errorResponse("Event not found"),
+					"412": errorResponse("Event changed since If-Unmodified-Since"),
+				},
+			},
+			"delete": {
+				Summary:    "Delete an event; deleting a missing event also succeeds",
+				Tags:       []string{"events"},
+				Parameters: []OpenAPIParameter{idParam("Event ID")},
+				Responses: map[string]OpenAPIResponse{
+					"204": {Description: "Deleted"},
+					"400": errorResponse("Invalid event ID"),
+					"401": errorResponse("Missing or invalid tenant"),
+				},
+			},
+		},
+		"/events/recent": {"get": {
+			Summary: "List the newest events",
+			Tags:    []string{"events"},
+			Parameters: []OpenAPIParameter{
+				{Name: "n", In: "query", Description: "Number of events, default " + strconv.Itoa(defaultRecentEvents) + ", capped at " + strconv.Itoa(services.MaxRecentEvents), Schema: &OpenAPISchema{Type: "integer"}},
+			},
+			Responses: map[string]OpenAPIResponse{
+				"200": ok([]EventResponse{}),
+				"400": errorResponse("Invalid n"),
+				"401": errorResponse("Missing or invalid tenant"),
+			},
+		}},
+		"/events/ingestion-lag": {"get": {
+			Summary: "Report the 95th percentile lag from provider timestamp to ingestion, by provider type",
+			Tags:    []string{"events"},
+			Parameters: []OpenAPIParameter{
+				{Name: "from", In: "query", Description: "Start of the period, inclusive; RFC 3339 or Unix milliseconds, 24 hours before to when omitted", Schema: &OpenAPISchema{Type: "string"}},
+				{Name: "to", In: "query", Description: "End of the period, exclusive; RFC 3339 or Unix milliseconds, now when omitted", Schema: &OpenAPISchema{Type: "string"}},
+			},
+			Responses: map[string]OpenAPIResponse{
+				"200": ok(IngestionLagResponse{}),
+				"400": errorResponse("Invalid period"),
+				"401": errorResponse("Missing or invalid tenant"),
+			},
+		}},
+		"/events/{id}/history": {"get": {
+			Summary:    "List the changes of the event's status, oldest first",
+			Tags:       []string{"events"},
+			Parameters: []OpenAPIParameter{idParam("Event ID")},
+			Responses: map[string]OpenAPIResponse{
+				"200": ok([]EventStatusChangeResponse{}),
+				"400": errorResponse("Invalid event ID"),
+				"401": errorResponse("Missing or invalid tenant"),
+				"404": errorResponse("Event not found"),
+			},
+		}},
+		"/events/{id}/related": {"get": {
+			Summary:    "List events from any provider that correlate with the event",
+			Tags:       []string{"events"},
+			Parameters: []OpenAPIParameter{idParam("Event ID")},
+			Responses: map[string]OpenAPIResponse{
+				"200": ok([]EventResponse{}),
+				"400": errorResponse("Invalid event ID"),
+				"401": errorResponse("Missing or invalid tenant"),
+				"404": errorResponse("Event not found"),
+			},
+		}},
+		"/providers": {"get": {
+			Summary: "List the tenant's providers with their last event and whether they are active or silent",
+			Tags:    []string{"providers"},
+			Responses: map[string]OpenAPIResponse{
+				"200": ok([]ProviderResponse{}),
+				"401": errorResponse("Missing or invalid tenant"),
+			},
+		}},
+		"/admin/providers": {"post": {
+			Summary:     "Register a provider for all tenants",
+			Tags:        []string{"providers", "admin"},
+			RequestBody: &OpenAPIRequestBody{Required: true, Content: jsonContent(s.ref(CreateProviderRequest{}))},
+			Responses: map[string]OpenAPIResponse{
+				"201": {Description: "Created", Content: jsonContent(s.ref(ProviderResponse{}))},
+				"400": errorResponse("Invalid body, name or provider type"),
+				"401": {Description: "Missing or invalid admin key"},
+			},
+			Security: admin,
+		}},
+		"/admin/events/validate-stored": {"get": {
+			Summary: "Report a tenant's stored events that current validation would reject, without changing them",
+			Tags:    []string{"events", "admin"},
+			Parameters: []OpenAPIParameter{
+				{Name: "tenant_id", In: "query", Required: true, Description: "Tenant whose events to check", Schema: &OpenAPISchema{Type: "string", Format: "uuid"}},
+				listParam("event_type", "Event types to check", s.ref(models.EventTypeEnum(""))),
+				listParam("status", "Statuses to check", s.ref(models.EventStatusEnum(""))),
+				listParam("provider_id", "Providers to check", s.ref(uuid.UUID{})),
+				{Name: "max_events", In: "query", Description: "Events to check, at most " + strconv.Itoa(maxValidateStoredMaxEvents), Schema: &OpenAPISchema{Type: "integer", Format: "int32"}},
+			},
+			Responses: map[string]OpenAPIResponse{
+				"200": ok(ValidateStoredEventsResponse{}),
+				"400": errorResponse("Invalid tenant ID, filter or max_events"),
+				"401": {Description: "Missing or invalid admin key"},
+			},
+			Security: admin,
+		}},
+		"/admin/events/reattribute": {"post": {
+			Summary:     "Move a tenant's events from one of its providers to another, all or nothing",
+			Tags:        []string{"events", "admin"},
+			RequestBody: &OpenAPIRequestBody{Required: true, Content: jsonContent(s.ref(ReattributeEventsRequest{}))},
+			Responses: map[string]OpenAPIResponse{
+				"200": ok(ReattributeEventsResponse{}),
+				"400": errorResponse("Invalid body, the same provider twice, or a provider that does not belong to the tenant"),
+				"401": {Description: "Missing or invalid admin key"},
+				"409": errorResponse("The target provider already has one of the event IDs"),
+			},
+			Security: admin,
+		}},
+		"/providers/{id}/event-stats": {"get": {
+			Summary:    "Count a provider's events per status",
+			Tags:       []string{"providers"},
+			Parameters: []OpenAPIParameter{idParam("Provider ID")},
+			Responses: map[string]OpenAPIResponse{
+				"200": staleOK(ProviderEventStatsResponse{}),
+				"400": errorResponse("Invalid provider ID"),
+				"401": errorResponse("Missing or invalid tenant"),
+			},
+		}},
+		"/notification-channels": {"get": {
+			Summary: "List the channels the tenant's leak notifications are delivered to, disabled ones included",
+			Tags:    []string{"notifications"},
+			Responses: map[string]OpenAPIResponse{
+				"200": ok([]NotificationChannelResponse{}),
+				"401": errorResponse("Missing or invalid tenant"),
+			},
+		}},
+		"/leaks": {"get": {
+			Summary: "List leaks, largest amount first",
+			Tags:    []string{"leaks"},
+			Parameters: []OpenAPIParameter{
+				listParam("status", "Statuses to match, open when omitted", s.ref(models.LeakStatusEnum(""))),
+				listParam("leak_type", "Leak types to match", s.ref(models.LeakTypeEnum(""))),
+				{Name: "detected_from", In: "query", Description: "Earliest detection time, inclusive; RFC 3339 or Unix milliseconds", Schema: &OpenAPISchema{Type: "string"}},
+				{Name: "detected_to", In: "query", Description: "Latest detection time, exclusive; RFC 3339 or Unix milliseconds", Schema: &OpenAPISchema{Type: "string"}},
+				{Name: "include_snoozed", In: "query", Description: "Include leaks whose snooze has not yet expired", Schema: &OpenAPISchema{Type: "boolean"}},
+				{Name: "limit", In: "query", Description: "Page size, at most " + strconv.Itoa(maxLeaksPageSize), Schema: &OpenAPISchema{Type: "integer", Format: "int32"}},
+				{Name: "offset", In: "query", Description: "Number of leaks to skip", Schema: &OpenAPISchema{Type: "integer", Format: "int32"}},
+			},
+			Responses: map[string]OpenAPIResponse{
+				"200": listOK(LeakResponse{}),
+				"400": errorResponse("Invalid filter, time range or pagination"),
+				"401": errorResponse("Missing or invalid tenant"),
+			},
+		}},
+		"/leaks/export": {"get": {
+			Summary: "Export leaks as CSV, oldest detection first",
+			Tags:    []string{"leaks"},
+			Parameters: []OpenAPIParameter{
+				{Name: "format", In: "query", Description: "Export format; only csv, the default", Schema: &OpenAPISchema{Type: "string", Enum: []string{ExportFormatCSV}}},
+				listParam("status", "Statuses to match, open when omitted", s.ref(models.LeakStatusEnum(""))),
+				listParam("leak_type", "Leak types to match", s.ref(models.LeakTypeEnum(""))),
+				{Name: "detected_from", In: "query", Description: "Earliest detection time, inclusive; RFC 3339 or Unix milliseconds", Schema: &OpenAPISchema{Type: "string"}},
+				{Name: "detected_to", In: "query", Description: "Latest detection time, exclusive; RFC 3339 or Unix milliseconds", Schema: &OpenAPISchema{Type: "string"}},
+				{Name: "include_snoozed", In: "query", Description: "Include leaks whose snooze has not yet expired", Schema: &OpenAPISchema{Type: "boolean"}},
+			},
+			Responses: map[string]OpenAPIResponse{
+				"200": {
+					Description: "A header row then one row per leak: " + strings.Join(leakExportColumns, ", "),
+					Content:     map[string]OpenAPIMediaType{"text/csv": {Schema: &OpenAPISchema{Type: "string"}}},
+				},
+				"400": errorResponse("Invalid format, filter or time range"),
+				"401": errorResponse("Missing or invalid tenant"),
+			},
+		}},
+		"/leaks/amount-histogram": {"get": {
+			Summary: "Count and sum open leaks by amount bucket, per currency",
+			Tags:    []string{"leaks"},
+			Parameters: []OpenAPIParameter{
+				listParam("bounds", "Bucket bounds, greater than 0 and ascending, at most "+strconv.Itoa(maxLeakAmountBounds)+"; 10, 100, 1000 and 10000 when omitted", &OpenAPISchema{Type: "string"}),
+				{Name: "since", In: "query", Description: "Earliest detection time, inclusive; RFC 3339 or Unix milliseconds, all open leaks when omitted", Schema: &OpenAPISchema{Type: "string"}},
+			},
+			Responses: map[string]OpenAPIResponse{
+				"200": ok(LeakAmountHistogramResponse{}),
+				"400": errorResponse("Invalid bounds or since"),
+				"401": errorResponse("Missing or invalid tenant"),
+			},
+		}},
+		"/leaks/timeseries": {"get": {
+			Summary: "Count and sum leaks per time bucket, zero-filled, for a trend line",
+			Tags:    []string{"leaks"},
+			Parameters: []OpenAPIParameter{
+				{Name: "interval", In: "query", Description: "Bucket width in UTC; day when omitted", Schema: &OpenAPISchema{Type: "string", Enum: enumValues(models.LeakTimeSeriesHour, models.LeakTimeSeriesDay, models.LeakTimeSeriesWeek, models.LeakTimeSeriesMonth)}},
+				{Name: "from", In: "query", Description: "Earliest detection time, inclusive; RFC 3339 or Unix milliseconds, 30 days before to when omitted", Schema: &OpenAPISchema{Type: "string"}},
+				{Name: "to", In: "query", Description: "Latest detection time, exclusive; RFC 3339 or Unix milliseconds, now when omitted", Schema: &OpenAPISchema{Type: "string"}},
+			},
+			Responses: map[string]OpenAPIResponse{
+				"200": ok(LeakTimeSeriesResponse{}),
+				"400": errorResponse("Invalid interval, from or to, or a range of more than " + strconv.Itoa(models.MaxLeakTimeSeriesBuckets) + " buckets"),
+				"401": errorResponse("Missing or invalid tenant"),
+			},
+		}},
+		"/leaks/{id}": {"get": {
+			Summary:    "Get a leak with its triggering events and actions",
+			Tags:       []string{"leaks"},
+			Parameters: []OpenAPIParameter{idParam("Leak ID")},
+			Responses: map[string]OpenAPIResponse{
+				"200": ok(LeakDetail{}),
+				"400": errorResponse("Invalid leak ID"),
+				"401": errorResponse("Missing or invalid tenant"),
+				"404": errorResponse("Leak not found"),
+			},
+		}},
+		"/leaks/{id}/snooze": {"post": {
+			Summary:     "Snooze a leak so it is left out of open counts until the snooze expires",
+			Tags:        []string{"leaks"},
+			Parameters:  []OpenAPIParameter{idParam("Leak ID")},
+			RequestBody: &OpenAPIRequestBody{Required: true, Content: jsonContent(s.ref(SnoozeLeakRequest{}))},
+			Responses: map[string]OpenAPIResponse{
+				"200": ok(LeakResponse{}),
+				"400": errorResponse("Invalid leak ID, body or duration"),
+				"401": errorResponse("Missing or invalid tenant"),
+				"404": errorResponse("Leak not found"),
+			},
+		}},
+		"/actions": {"get": {
+			Summary: "List actions across all of the tenant's leaks, newest first by default",
+			Tags:    []string{"actions"},
+			Parameters: []OpenAPIParameter{
+				listParam("action_type", "Action types to match", s.ref(models.ActionTypeEnum(""))),
+				listParam("status", "Action statuses to match", s.ref(models.ActionStatusEnum(""))),
+				listParam("result", "Action results to match", s.ref(models.ActionResultEnum(""))),
+				{Name: "created_from", In: "query", Description: "Earliest creation time, inclusive; RFC 3339 or Unix milliseconds", Schema: &OpenAPISchema{Type: "string"}},
+				{Name: "created_to", In: "query", Description: "Latest creation time, exclusive; RFC 3339 or Unix milliseconds", Schema: &OpenAPISchema{Type: "string"}},
+				{Name: "sort", In: "query", Description: "Sort field, descending when prefixed with -; -created_at when omitted", Schema: &OpenAPISchema{Type: "string", Enum: actionSortValues()}},
+				{Name: "limit", In: "query", Description: "Page size, at most " + strconv.Itoa(maxActionsPageSize), Schema: &OpenAPISchema{Type: "integer", Format: "int32"}},
+				{Name: "offset", In: "query", Description: "Number of actions to skip", Schema: &OpenAPISchema{Type: "integer", Format: "int32"}},
+			},
+			Responses: map[string]OpenAPIResponse{
+				"200": listOK(ActionResponse{}),
+				"400": errorResponse("Invalid filter, time range, sort or pagination"),
+				"401": errorResponse("Missing or invalid tenant"),
+			},
+		}},
+		"/detect": {"post": {
+			Summary: "Run leak detection for the tenant",
+			Tags:    []string{"leaks"},
+			Parameters: []OpenAPIParameter{
+				{Name: "dry_run", In: "query", Description: "Return candidates without storing leaks or sending notifications", Schema: &OpenAPISchema{Type: "boolean"}},
+			},
+			Responses: map[string]OpenAPIResponse{
+				"200": ok(DetectionResponse{}),
+				"400": errorResponse("Invalid dry_run"),
+				"401": errorResponse("Missing or invalid tenant"),
+				"409": errorResponse("Leak detection is already running for the tenant"),
+			},
+		}},
+		"/usage": {"get": {
+			Summary: "Count the tenant's events, leaks and API requests over a period",
+			Tags:    []string{"usage"},
+			Parameters: []OpenAPIParameter{
+				{Name: "from", In: "query", Description: "Start of the period, inclusive; RFC 3339 or Unix milliseconds, 30 days before to when omitted", Schema: &OpenAPISchema{Type: "string"}},
+				{Name: "to", In: "query", Description: "End of the period, exclusive; RFC 3339 or Unix milliseconds, now when omitted", Schema: &OpenAPISchema{Type: "string"}},
+				{Name: "tenant_id", In: "query", Description: "Another tenant to report on; requires an admin key in " + AdminKeyHeader, Schema: &OpenAPISchema{Type: "string", Format: "uuid"}},
+			},
+			Responses: map[string]OpenAPIResponse{
+				"200": staleOK(UsageResponse{}),
+				"400": errorResponse("Invalid period or tenant_id"),
+				"401": errorResponse("Missing or invalid tenant"),
+				"403": errorResponse("Another tenant was requested without a valid admin key"),
+			},
+		}},
+	}
+
+	if opts.StripeWebhook {
+		paths["/webhooks/stripe"] = OpenAPIPathItem{"post": {
+			Summary: "Receive a signed Stripe event",
+			Tags:    []string{"webhooks"},
+			Parameters: []OpenAPIParameter{
+				{Name: StripeSignatureHeader, In: "header", Required: true, Schema: &OpenAPISchema{Type: "string"}},
+			},
+			RequestBody: &OpenAPIRequestBody{Required: true, Content: jsonContent(&OpenAPISchema{Type: "object", Description: "Stripe event envelope"})},
+			Responses: map[string]OpenAPIResponse{
+				"200": ok(WebhookResponse{}),
+				"202": {Description: "Accepted but not stored, or queued to be stored while the database is unavailable", Content: jsonContent(s.ref(WebhookResponse{}))},
+				"400": errorResponse("Invalid signature, body, stale event or unknown provider"),
+				"401": errorResponse("Missing or invalid tenant"),
+				"413": errorResponse("Body too large"),
+				"503": errorResponse("Database unavailable and the ingest queue is full or disabled"),
+			},
+		}}
+	}
+
+	return OpenAPISpec{
+		OpenAPI: "3.0.3",
+		Info:    OpenAPIInfo{Title: "Revenue Leak Detective API", Version: opts.Version},
+		Paths:   paths,
+		Components: OpenAPIComponents{
+			Schemas: s.components,
+			SecuritySchemes: map[string]OpenAPISecurityScheme{
+				"bearerAuth":   {Type: "http", Scheme: "bearer"},
+				"tenantHeader": {Type: "apiKey", In: "header", Name: "X-Tenant-ID"},
+				"adminKey":     {Type: "apiKey", In: "header", Name: AdminKeyHeader},
+			},
+		},
+		Security: []map[string][]string{{"bearerAuth": {}}, {"tenantHeader": {}}},
+	}
+}
+
+// OpenAPIHandler returns a handler for GET /openapi.json serving spec. The document only
+// changes on deploy, so it may be cached for maxAge.
+func OpenAPIHandler(logger *slog.Logger, spec OpenAPISpec, maxAge time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		SetCacheHeaders(w, maxAge)
+		WriteJSONSuccessResponse(r.Context(), w, logger, spec)
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestOpenAPIOptions() OpenAPIOptions {
+	return OpenAPIOptions{Version: "v1.2.3", HealthPath: "/healthz", LivePath: "/live", ReadyPath: "/ready"}
+}
+
+// collectRefs returns every "$ref" value found anywhere in a decoded JSON document
+func collectRefs(v any) []string {
+	var refs []string
+	switch node := v.(type) {
+	case map[string]any:
+		for key, child := range node {
+			if ref, ok := child.(string); ok && key == "$ref" {
+				refs = append(refs, ref)
+				continue
+			}
+			refs = append(refs, collectRefs(child)...)
+		}
+	case []any:
+		for _, child := range node {
+			refs = append(refs, collectRefs(child)...)
+		}
+	}
+	return refs
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	handler := OpenAPIHandler(newTestLogger(), NewOpenAPISpec(newTestOpenAPIOptions()), 5*time.Minute)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, OpenAPIPath, nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected Content-Type application/json, got %q", ct)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("expected valid JSON, got %v", err)
+	}
+	if version, _ := doc["openapi"].(string); !strings.HasPrefix(version, "3.") {
+		t.Errorf("expected an OpenAPI 3 document, got openapi=%v", doc["openapi"])
+	}
+
+	paths, _ := doc["paths"].(map[string]any)
+	for path, methods := range map[string][]string{
+		"/events":                       {"get"},
+		"/events/export":                {"get"},
+		"/events/batch":                 {"post"},
+		"/events/recent":                {"get"},
+		"/events/search":                {"get"},
+		"/events/reprocess-failed":      {"post"},
+		"/events/{id}":                  {"patch", "delete"},
+		"/events/{id}/history":          {"get"},
+		"/events/{id}/related":          {"get"},
+		"/leaks":                        {"get"},
+		"/leaks/export":                 {"get"},
+		"/leaks/{id}":                   {"get"},
+		"/leaks/{id}/snooze":            {"post"},
+		"/actions":                      {"get"},
+		"/detect":                       {"post"},
+		"/usage":                        {"get"},
+		"/providers":                    {"get"},
+		"/admin/providers":              {"post"},
+		"/admin/events/validate-stored": {"get"},
+		"/notification-channels":        {"get"},
+		"/healthz":                      {"get"},
+		"/":                             {"get"},
+		"/version":                      {"get"},
+	} {
+		item, ok := paths[path].(map[string]any)
+		if !ok {
+			t.Errorf("expected path %s to be documented", path)
+			continue
+		}
+		for _, method := range methods {
+			if _, ok := item[method]; !ok {
+				t.Errorf("expected %s %s to be documented", strings.ToUpper(method), path)
+			}
+		}
+	}
+	if _, ok := paths["/webhooks/stripe"]; ok {
+		t.Error("expected the Stripe webhook to be left out when it is not registered")
+	}
+
+	components, _ := doc["components"].(map[string]any)
+	schemas, _ := components["schemas"].(map[string]any)
+	for _, name := range []string{"EventResponse", "UpdateEventRequest", "ErrorResponse", "ErrorDetail"} {
+		if _, ok := schemas[name]; !ok {
+			t.Errorf("expected schema %s in components", name)
+		}
+	}
+	for _, ref := range collectRefs(doc) {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		if _, ok := schemas[name]; !ok {
+			t.Errorf("reference %s does not resolve", ref)
+		}
+	}
+
+	event, _ := schemas["EventResponse"].(map[string]any)
+	properties, _ := event["properties"].(map[string]any)
+	eventType, _ := properties["event_type"].(map[string]any)
+	if enum, _ := eventType["enum"].([]any); len(enum) == 0 {
+		t.Errorf("expected event_type to list its values, got %v", eventType)
+	}
+}
+
+func TestNewOpenAPISpec_FollowsFormats(t *testing.T) {
+	opts := newTestOpenAPIOptions()
+	opts.StripeWebhook = true
+	opts.Response = ResponseOptions{TimeFormat: TimeFormatUnixMs, ListFormat: ListFormatEnvelope}
+	spec := NewOpenAPISpec(opts)
+
+	list := spec.Paths["/events"]["get"].Responses["200"].Content["application/json"].Schema
+	if _, ok := list.Properties["data"]; !ok {
+		t.Errorf("expected the envelope list shape, got properties %v", list.Properties)
+	}
+	if createdAt := spec.Components.Schemas["EventResponse"].Properties["created_at"]; createdAt.Type != "integer" {
+		t.Errorf("expected unix_ms timestamps to be integers, got %+v", createdAt)
+	}
+	if _, ok := spec.Paths["/webhooks/stripe"]; !ok {
+		t.Error("expected the Stripe webhook to be documented when registered")
+	}
+}
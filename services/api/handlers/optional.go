@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+// Optional is a field of a partial update body that tells apart the three things a client can
+// send: nothing (the field is left unchanged), an explicit null (the field is cleared) and a
+// value. A pointer cannot, since an omitted field and null both decode to nil.
+type Optional[T any] struct {
+	Value T
+	Set   bool // the field was present in the body, as null or a value
+	Null  bool // the field was present as null
+}
+
+// UnmarshalJSON records that the field was present. encoding/json only calls it for fields in
+// the body, including those that are null.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	var zero T
+	o.Set, o.Null, o.Value = true, false, zero
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		o.Null = true
+		return nil
+	}
+	return json.Unmarshal(data, &o.Value)
+}
+
+// Ptr returns the value, or nil when the field was omitted or null
+func (o Optional[T]) Ptr() *T {
+	if !o.Set || o.Null {
+		return nil
+	}
+	return &o.Value
+}
+
+// valueType is the type the field holds, which the OpenAPI document describes in its place
+func (o Optional[T]) valueType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// optionalField is implemented by every Optional
+type optionalField interface {
+	valueType() reflect.Type
+}
+
+var optionalFieldType = reflect.TypeOf((*optionalField)(nil)).Elem()
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestOptional_UnmarshalJSON(t *testing.T) {
+	retried, empty := "retried", ""
+
+	type body struct {
+		Note Optional[string] `json:"note"`
+	}
+
+	tests := []struct {
+		name      string
+		body      string
+		wantSet   bool
+		wantNull  bool
+		wantValue *string
+	}{
+		{name: "absent", body: `{}`},
+		{name: "explicit null", body: `{"note":null}`, wantSet: true, wantNull: true},
+		{name: "value", body: `{"note":"retried"}`, wantSet: true, wantValue: &retried},
+		{name: "empty value is still a value", body: `{"note":""}`, wantSet: true, wantValue: &empty},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got body
+			if err := json.Unmarshal([]byte(tt.body), &got); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if got.Note.Set != tt.wantSet || got.Note.Null != tt.wantNull {
+				t.Errorf("expected Set=%v Null=%v, got %+v", tt.wantSet, tt.wantNull, got.Note)
+			}
+			value := got.Note.Ptr()
+			if (value == nil) != (tt.wantValue == nil) || (value != nil && *value != *tt.wantValue) {
+				t.Errorf("expected Ptr() = %v, got %v", tt.wantValue, value)
+			}
+		})
+	}
+
+	t.Run("wrong type", func(t *testing.T) {
+		var got body
+		if err := json.Unmarshal([]byte(`{"note":5}`), &got); err == nil {
+			t.Error("expected an error for a number")
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/domain/services"
+	"rdl-api/internal/metrics"
+	"rdl-api/internal/middleware"
+
+	"github.com/google/uuid"
+)
+
+// ProviderResponse is the API representation of a provider as the calling tenant sees it;
+// timestamps follow the configured time format
+type ProviderResponse struct {
+	ID           uuid.UUID             `json:"id"`
+	Name         string                `json:"name"`
+	ProviderType string                `json:"provider_type"`
+	Status       models.ProviderStatus `json:"status,omitempty"`
+	LastEventAt  *APITime              `json:"last_event_at,omitempty"`
+	CreatedAt    APITime               `json:"created_at"`
+	UpdatedAt    APITime               `json:"updated_at"`
+}
+
+// NewProviderResponse converts a domain provider to its API representation
+func NewProviderResponse(provider models.Provider, format TimeFormat) ProviderResponse {
+	return ProviderResponse{
+		ID:           provider.ID,
+		Name:         provider.Name,
+		ProviderType: provider.ProviderType,
+		CreatedAt:    NewAPITime(provider.CreatedAt, format),
+		UpdatedAt:    NewAPITime(provider.UpdatedAt, format),
+	}
+}
+
+// NewTenantProviderResponse converts a tenant's provider, with its last event and status, to its API representation
+func NewTenantProviderResponse(provider models.TenantProvider, format TimeFormat) ProviderResponse {
+	response := NewProviderResponse(provider.Provider, format)
+	response.Status = provider.Status
+	response.LastEventAt = NewAPITimePtr(provider.LastEventAt, format)
+	return response
+}
+
+// CreateProviderRequest is the body of POST /admin/providers
+type CreateProviderRequest struct {
+	Name         string `json:"name"`
+	ProviderType string `json:"provider_type"`
+}
+
+// ListProvidersHandler returns a handler for GET /providers, the providers the tenant has an
+// integration with or has received events from, by name. Each is active when the tenant
+// received an event from it within services.ProviderSilentAfter, and silent otherwise.
+func ListProvidersHandler(logger *slog.Logger, providersService services.ProvidersService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		tenantID, ok := middleware.GetTenantID(r)
+		if !ok {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidTenant, ErrorCodeUnauthorized, middleware.ErrMissingOrInvalidTenantContext, http.StatusUnauthorized)
+			return
+		}
+
+		providers, err := providersService.GetProviders(ctx, tenantID)
+		if err != nil {
+			logger.Log(ctx, serviceErrorLevel(err), "Failed to list providers", "error", err, "tenant_id", tenantID)
+			WriteServerError(ctx, w, logger, err)
+			return
+		}
+
+		response := make([]ProviderResponse, len(providers))
+		for i, provider := range providers {
+			response[i] = NewTenantProviderResponse(provider, timeFormatFrom(ctx))
+		}
+		WriteJSONSuccessResponse(ctx, w, logger, response)
+	}
+}
+
+// CreateProviderHandler returns a handler for POST /admin/providers, which registers a provider
+// for all tenants. It is an admin route: the caller sends an admin key instead of a tenant.
+// provider_type defaults to models.DefaultProviderType.
+func CreateProviderHandler(logger *slog.Logger, providersService services.ProvidersService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		var req CreateProviderRequest
+		if err := decodeJSON(ctx, r.Body, &req); err != nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidJSON, ErrorCodeInvalidRequest, requestBodyError(err), http.StatusBadRequest)
+			return
+		}
+
+		provider, err := providersService.CreateProvider(ctx, models.CreateProviderParams{Name: req.Name, ProviderType: req.ProviderType})
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidProviderName) || errors.Is(err, services.ErrInvalidProviderType) {
+				WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, err, http.StatusBadRequest)
+				return
+			}
+			logger.Log(ctx, serviceErrorLevel(err), "Failed to create provider", "error", err, "name", req.Name)
+			WriteServerError(ctx, w, logger, err)
+			return
+		}
+
+		WriteJSONResponse(ctx, w, logger, NewProviderResponse(provider, timeFormatFrom(ctx)), http.StatusCreated)
+	}
+}
+
+// ProviderEventStatsResponse is the body of GET /providers/{id}/event-stats
+type ProviderEventStatsResponse struct {
+	ProviderID uuid.UUID                        `json:"provider_id"`
+	Counts     map[models.EventStatusEnum]int64 `json:"counts"`
+}
+
+// ProviderEventStatsHandler returns a handler for GET /providers/{id}/event-stats, the
+// tenant's event count per status for one provider. Every status is present, zero if unused.
+// While the count fails, the provider's last counts are answered from cache, marked stale.
+func ProviderEventStatsHandler(logger *slog.Logger, eventsService services.EventsService, cache *StaleCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		tenantID, ok := middleware.GetTenantID(r)
+		if !ok {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidTenant, ErrorCodeUnauthorized, middleware.ErrMissingOrInvalidTenantContext, http.StatusUnauthorized)
+			return
+		}
+
+		providerID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, ErrInvalidProviderID, http.StatusBadRequest)
+			return
+		}
+
+		cacheKey := "provider-event-stats/" + tenantID.String() + "/" + providerID.String()
+		counts, err := eventsService.CountEventsByStatusForProvider(ctx, tenantID, providerID)
+		if err != nil {
+			logger.Log(ctx, serviceErrorLevel(err), "Failed to count provider events", "error", err, "provider_id", providerID, "tenant_id", tenantID)
+			if !writeStale(ctx, w, logger, cache, cacheKey, err) {
+				WriteServerError(ctx, w, logger, err)
+			}
+			return
+		}
+
+		response := ProviderEventStatsResponse{ProviderID: providerID, Counts: counts}
+		cache.Store(cacheKey, response)
+		WriteJSONSuccessResponse(ctx, w, logger, response)
+	}
+}
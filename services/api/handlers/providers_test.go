@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/domain/services"
+	"rdl-api/internal/middleware"
+
+	"github.com/google/uuid"
+)
+
+// testProviderStatsService serves per-provider status counts; other methods panic via the nil embedded interface
+type testProviderStatsService struct {
+	services.EventsService
+	counts map[uuid.UUID]map[models.EventStatusEnum]int64
+	// err, when set, fails every count
+	err error
+}
+
+func (s *testProviderStatsService) CountEventsByStatusForProvider(_ context.Context, _ uuid.UUID, providerID uuid.UUID) (map[models.EventStatusEnum]int64, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.counts[providerID], nil
+}
+
+func TestProviderEventStatsHandler(t *testing.T) {
+	providerID := uuid.New()
+	counts := map[models.EventStatusEnum]int64{
+		models.EventStatusEnumPending:   4,
+		models.EventStatusEnumProcessed: 0,
+		models.EventStatusEnumFailed:    2,
+	}
+	logger := newTestLogger()
+	mux := http.NewServeMux()
+	svc := &testProviderStatsService{
+		counts: map[uuid.UUID]map[models.EventStatusEnum]int64{providerID: counts},
+	}
+	mux.HandleFunc("GET /providers/{id}/event-stats", ProviderEventStatsHandler(logger, svc, NewStaleCache(time.Minute)))
+	handler := middleware.TenantContext(logger, true, nil)(mux)
+
+	t.Run("returns counts per status", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/providers/"+providerID.String()+"/event-stats", nil)
+		req.Header.Set("X-Tenant-ID", uuid.New().String())
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var body ProviderEventStatsResponse
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if body.ProviderID != providerID {
+			t.Errorf("expected provider %s, got %s", providerID, body.ProviderID)
+		}
+		for status, want := range counts {
+			if got, ok := body.Counts[status]; !ok || got != want {
+				t.Errorf("status %q: expected %d, got %d (present=%v)", status, want, got, ok)
+			}
+		}
+	})
+
+	t.Run("answers with the last counts while counting fails", func(t *testing.T) {
+		tenantID := uuid.New().String()
+		get := func() *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, "/providers/"+providerID.String()+"/event-stats", nil)
+			req.Header.Set("X-Tenant-ID", tenantID)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			return w
+		}
+
+		if w := get(); w.Code != http.StatusOK || w.Header().Get(StaleResultHeader) != "" {
+			t.Fatalf("expected a fresh 200, got %d with %s=%q", w.Code, StaleResultHeader, w.Header().Get(StaleResultHeader))
+		}
+
+		svc.err = errors.New("connection refused")
+		defer func() { svc.err = nil }()
+		w := get()
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected the stale counts with status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if got := w.Header().Get(StaleResultHeader); got != "true" {
+			t.Errorf("expected %s: true, got %q", StaleResultHeader, got)
+		}
+		if w.Header().Get("Age") == "" {
+			t.Error("expected an Age header")
+		}
+		var body ProviderEventStatsResponse
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if body.Counts[models.EventStatusEnumPending] != 4 {
+			t.Errorf("expected the cached counts, got %v", body.Counts)
+		}
+
+		// Another tenant has nothing cached for the provider
+		req := httptest.NewRequest(http.MethodGet, "/providers/"+providerID.String()+"/event-stats", nil)
+		req.Header.Set("X-Tenant-ID", uuid.New().String())
+		other := httptest.NewRecorder()
+		handler.ServeHTTP(other, req)
+		if other.Code != http.StatusInternalServerError {
+			t.Errorf("expected status %d without a cached result, got %d", http.StatusInternalServerError, other.Code)
+		}
+	})
+
+	t.Run("invalid provider id", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/providers/not-a-uuid/event-stats", nil)
+		req.Header.Set("X-Tenant-ID", uuid.New().String())
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+// testProvidersService lists fixed providers and creates providers through the real validation
+type testProvidersService struct {
+	providers []models.TenantProvider
+}
+
+func (s *testProvidersService) CreateProvider(_ context.Context, args models.CreateProviderParams) (models.Provider, error) {
+	if args.ProviderType == "" {
+		args.ProviderType = models.DefaultProviderType
+	}
+	if err := args.Validate(); err != nil {
+		return models.Provider{}, err
+	}
+	return models.Provider{ID: uuid.New(), Name: args.Name, ProviderType: args.ProviderType}, nil
+}
+
+func (s *testProvidersService) GetProviders(context.Context, uuid.UUID) ([]models.TenantProvider, error) {
+	return s.providers, nil
+}
+
+func TestListProvidersHandler(t *testing.T) {
+	lastEventAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc := &testProvidersService{providers: []models.TenantProvider{
+		{Provider: models.Provider{ID: uuid.New(), Name: "Stripe", ProviderType: "stripe"}, LastEventAt: &lastEventAt, Status: models.ProviderStatusActive},
+		{Provider: models.Provider{ID: uuid.New(), Name: "Webhook", ProviderType: "webhook"}, Status: models.ProviderStatusSilent},
+	}}
+	logger := newTestLogger()
+	handler := middleware.TenantContext(logger, true, nil)(ListProvidersHandler(logger, svc))
+
+	req := httptest.NewRequest(http.MethodGet, "/providers", nil)
+	req.Header.Set("X-Tenant-ID", uuid.New().String())
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var body []map[string]any
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(body) != 2 {
+		t.Fatalf("expected 2 providers, got %d", len(body))
+	}
+	if body[0]["status"] != "active" || body[0]["last_event_at"] != "2025-03-01T12:00:00Z" || body[0]["provider_type"] != "stripe" {
+		t.Errorf("expected the active provider with its last event, got %v", body[0])
+	}
+	if _, ok := body[1]["last_event_at"]; ok || body[1]["status"] != "silent" {
+		t.Errorf("expected the silent provider without a last event, got %v", body[1])
+	}
+}
+
+func TestCreateProviderHandler(t *testing.T) {
+	logger := newTestLogger()
+	handler := CreateProviderHandler(logger, &testProvidersService{})
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/providers", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post(`{"name":"Acme Billing"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created ProviderResponse
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if created.Name != "Acme Billing" || created.ProviderType != models.DefaultProviderType {
+		t.Errorf("expected the created provider with the default type, got %+v", created)
+	}
+
+	for name, body := range map[string]string{
+		"missing name": `{"provider_type":"stripe"}`,
+		"invalid json": `{`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if w := post(body); w.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+			}
+		})
+	}
+}
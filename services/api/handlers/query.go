@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"rdl-api/internal/domain/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// splitQueryValues flattens repeated and comma-separated query values, trimming spaces
+// and dropping empty entries, so ?a=x,y&a=z yields [x y z]
+func splitQueryValues(values []string) []string {
+	var out []string
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+// parsePagination reads limit and offset from the query. limit defaults to defaultLimit
+// and must be between 1 and maxLimit; offset defaults to 0 and must not be negative.
+func parsePagination(query url.Values, defaultLimit, maxLimit int32) (models.PaginationParams, error) {
+	params := models.PaginationParams{Limit: defaultLimit}
+
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil {
+			return models.PaginationParams{}, fmt.Errorf("%w: limit must be an integer", ErrInvalidPagination)
+		}
+		params.Limit = int32(limit)
+	}
+
+	if raw := query.Get("offset"); raw != "" {
+		offset, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil {
+			return models.PaginationParams{}, fmt.Errorf("%w: offset must be an integer", ErrInvalidPagination)
+		}
+		params.Offset = int32(offset)
+	}
+
+	if err := params.Validate(maxLimit); err != nil {
+		return models.PaginationParams{}, fmt.Errorf("%w: %w", ErrInvalidPagination, err)
+	}
+	return params, nil
+}
+
+// parseQueryTime reads an optional timestamp from the query, given as RFC 3339 or Unix
+// milliseconds like the timestamps in request bodies. A missing key returns nil.
+func parseQueryTime(query url.Values, key string) (*time.Time, error) {
+	raw := strings.TrimSpace(query.Get(key))
+	if raw == "" {
+		return nil, nil
+	}
+	if ms, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		t := time.UnixMilli(ms).UTC()
+		return &t, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s must be an RFC 3339 time or Unix milliseconds", ErrInvalidTimeRange, key)
+	}
+	return &t, nil
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"errors"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+func TestSplitQueryValues(t *testing.T) {
+	got := splitQueryValues([]string{"payment_failed, payment_refunded", "", "payment_updated,"})
+	want := []string{"payment_failed", "payment_refunded", "payment_updated"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestParsePagination(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantLimit  int32
+		wantOffset int32
+		wantErr    bool
+	}{
+		{"defaults", "", 50, 0, false},
+		{"explicit", "limit=10&offset=20", 10, 20, false},
+		{"explicit zero offset", "limit=10&offset=0", 10, 0, false},
+		{"limit at max", "limit=1000", 1000, 0, false},
+		{"zero limit", "limit=0", 0, 0, true},
+		{"negative limit", "limit=-5", 0, 0, true},
+		{"limit over max", "limit=1001", 0, 0, true},
+		{"negative offset", "offset=-1", 0, 0, true},
+		{"non-numeric", "limit=ten", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			params, err := parsePagination(query, 50, 1000)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidPagination) {
+					t.Errorf("expected ErrInvalidPagination, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if params.Limit != tt.wantLimit || params.Offset != tt.wantOffset {
+				t.Errorf("expected limit=%d offset=%d, got limit=%d offset=%d", tt.wantLimit, tt.wantOffset, params.Limit, params.Offset)
+			}
+		})
+	}
+}
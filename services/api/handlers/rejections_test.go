@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"rdl-api/internal/metrics"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRejectionsAreCountedByReason(t *testing.T) {
+	payload := `{"id":"evt_1","type":"charge.failed"}`
+
+	tests := []struct {
+		name           string
+		reason         string
+		handler        http.Handler
+		request        func() *http.Request
+		expectedStatus int
+	}{
+		{
+			name:           "missing tenant",
+			reason:         metrics.ReasonInvalidTenant,
+			handler:        newEventsTestHandler(newTestEventsService()),
+			request:        func() *http.Request { return newDeleteEventRequest(uuid.NewString(), uuid.Nil) },
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "invalid event id",
+			reason:         metrics.ReasonInvalidParameter,
+			handler:        newEventsTestHandler(newTestEventsService()),
+			request:        func() *http.Request { return newDeleteEventRequest("not-a-uuid", uuid.New()) },
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:    "invalid json",
+			reason:  metrics.ReasonInvalidJSON,
+			handler: newEventsTestHandler(newTestEventsService()),
+			request: func() *http.Request {
+				return newPatchEventRequest(uuid.New(), uuid.New(), "{not json", time.Time{})
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:    "invalid event type",
+			reason:  metrics.ReasonInvalidParameter,
+			handler: newEventsTestHandler(newTestEventsService()),
+			request: func() *http.Request {
+				return newPatchEventRequest(uuid.New(), uuid.New(), `{"event_type":"bogus"}`, time.Time{})
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:    "body too large",
+			reason:  metrics.ReasonBodyTooLarge,
+			handler: newWebhookTestHandler(newTestEventsService(), 8),
+			request: func() *http.Request {
+				return newStripeWebhookRequest(payload, signStripePayload([]byte(payload), "1700000000", testWebhookSecret))
+			},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:    "invalid signature",
+			reason:  metrics.ReasonInvalidSignature,
+			handler: newWebhookTestHandler(newTestEventsService(), 1024),
+			request: func() *http.Request {
+				return newStripeWebhookRequest(payload, "t=1700000000,v1="+strings.Repeat("0", 64))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := metrics.RejectionCount(tt.reason)
+
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, tt.request())
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if got := metrics.RejectionCount(tt.reason) - before; got != 1 {
+				t.Errorf("expected %q count to increase by 1, got %d", tt.reason, got)
+			}
+		})
+	}
+}
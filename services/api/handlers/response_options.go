@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"rdl-api/internal/middleware"
+)
+
+// ResponseOptions are the configured settings that shape API responses. The zero value
+// renders second-precision RFC 3339 timestamps and flat lists, with no page size limit and
+// without error details.
+type ResponseOptions struct {
+	// TimeFormat is the format of every APITime in a response
+	TimeFormat TimeFormat
+	// ListFormat is the top-level shape of list responses
+	ListFormat ListFormat
+	// MaxListBytes caps the serialized size of a list page; 0 or less is no limit
+	MaxListBytes int64
+	// ErrorDetails makes 500 responses carry the error message and, for panics, a trimmed
+	// stack. It is only ever turned on in development.
+	ErrorDetails bool
+}
+
+// responseOptionsKey is the context key for the request's ResponseOptions
+type responseOptionsKey struct{}
+
+// WithResponseOptions returns middleware that makes opts the response settings of every
+// request it passes on
+func WithResponseOptions(opts ResponseOptions) middleware.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(ContextWithResponseOptions(r.Context(), opts)))
+		})
+	}
+}
+
+// ContextWithResponseOptions returns a copy of ctx carrying opts
+func ContextWithResponseOptions(ctx context.Context, opts ResponseOptions) context.Context {
+	return context.WithValue(ctx, responseOptionsKey{}, opts)
+}
+
+// responseOptionsFrom returns the response settings stored in ctx, or the zero value
+func responseOptionsFrom(ctx context.Context) ResponseOptions {
+	opts, _ := ctx.Value(responseOptionsKey{}).(ResponseOptions)
+	return opts
+}
+
+// timeFormatFrom returns the format for the timestamps of the response to the request in ctx
+func timeFormatFrom(ctx context.Context) TimeFormat {
+	return responseOptionsFrom(ctx).TimeFormat
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// RootPath is the service root, which answers smoke tests with a short description of the service
+const RootPath = "/"
+
+// ServiceName identifies this service in the root response
+const ServiceName = "rdl-api"
+
+// RootResponse is the body of GET /: what is running and where to look next
+type RootResponse struct {
+	Service string            `json:"service"`
+	Version string            `json:"version"`
+	Links   map[string]string `json:"links"`
+}
+
+// RootOptions configures the root response
+type RootOptions struct {
+	Service    string
+	Version    string
+	HealthPath string
+}
+
+// RootHandler returns a handler for GET / describing the service, its version and links to the
+// health check and the OpenAPI document. It is registered as "GET /{$}" so it matches only the
+// root itself and never shadows other routes or the JSON 404 for unknown paths.
+func RootHandler(logger *slog.Logger, opts RootOptions) http.HandlerFunc {
+	response := RootResponse{
+		Service: opts.Service,
+		Version: opts.Version,
+		Links: map[string]string{
+			"health":  opts.HealthPath,
+			"openapi": OpenAPIPath,
+		},
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		WriteJSONSuccessResponse(r.Context(), w, logger, response)
+	}
+}
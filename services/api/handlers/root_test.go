@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRootHandler(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+RootPath+"{$}", RootHandler(newTestLogger(), RootOptions{Service: ServiceName, Version: "v1.4.0", HealthPath: "/healthz"}))
+	handler := WithJSONFallbacks(mux, newTestLogger())
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var body RootResponse
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if body.Service != ServiceName || body.Version != "v1.4.0" {
+		t.Errorf("expected %s v1.4.0, got %s %s", ServiceName, body.Service, body.Version)
+	}
+	if body.Links["health"] != "/healthz" || body.Links["openapi"] != OpenAPIPath {
+		t.Errorf("unexpected links %v", body.Links)
+	}
+
+	t.Run("does not shadow unknown paths", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/does-not-exist", nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// StaleResultHeader is set to "true" on a response answered from the StaleCache because the
+// database failed. The standard Age header then holds how old the result is, in seconds.
+const StaleResultHeader = "X-Stale-Result"
+
+// maxStaleEntries caps the results a StaleCache holds; once full, new results are not kept
+// until older ones expire
+const maxStaleEntries = 10000
+
+// staleEntry is one cached result and when it was computed
+type staleEntry struct {
+	value    any
+	storedAt time.Time
+}
+
+// StaleCache keeps the last successful result of aggregate read endpoints for a short while,
+// so they can still answer, marked stale, while the database is briefly unavailable. Fresh
+// results are always computed; the cache is only read when computing one fails. A nil
+// *StaleCache is valid and keeps nothing.
+type StaleCache struct {
+	ttl     time.Duration
+	now     func() time.Time
+	mu      sync.Mutex
+	entries map[string]staleEntry
+}
+
+// NewStaleCache creates a StaleCache whose results can be served for ttl after they were
+// computed. A ttl of 0 or less returns nil, which disables stale answers.
+func NewStaleCache(ttl time.Duration) *StaleCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &StaleCache{ttl: ttl, now: time.Now, entries: map[string]staleEntry{}}
+}
+
+// Store keeps value as the latest result for key, dropping expired results first
+func (c *StaleCache) Store(key string, value any) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for k, entry := range c.entries {
+		if now.Sub(entry.storedAt) > c.ttl {
+			delete(c.entries, k)
+		}
+	}
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= maxStaleEntries {
+		return
+	}
+	c.entries[key] = staleEntry{value: value, storedAt: now}
+}
+
+// Load returns the latest result for key and its age, unless there is none or it is older than the ttl
+func (c *StaleCache) Load(key string) (any, time.Duration, bool) {
+	if c == nil {
+		return nil, 0, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, 0, false
+	}
+	age := c.now().Sub(entry.storedAt)
+	if age > c.ttl {
+		delete(c.entries, key)
+		return nil, 0, false
+	}
+	return entry.value, age, true
+}
+
+// writeStale answers with the cached result for key after err failed the request, and reports
+// whether it did. A canceled request is never answered from the cache.
+func writeStale(ctx context.Context, w http.ResponseWriter, logger *slog.Logger, cache *StaleCache, key string, err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	value, age, ok := cache.Load(key)
+	if !ok {
+		return false
+	}
+	logger.WarnContext(ctx, "Answering with a stale result", "error", err, "age", age.String())
+	w.Header().Set(StaleResultHeader, "true")
+	w.Header().Set("Age", strconv.Itoa(int(age.Seconds())))
+	WriteJSONSuccessResponse(ctx, w, logger, value)
+	return true
+}
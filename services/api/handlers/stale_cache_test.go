@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStaleCache(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	cache := NewStaleCache(time.Minute)
+	cache.now = func() time.Time { return now }
+
+	cache.Store("usage/a", 1)
+	now = now.Add(30 * time.Second)
+
+	value, age, ok := cache.Load("usage/a")
+	if !ok || value != 1 || age != 30*time.Second {
+		t.Fatalf("expected the stored value aged 30s, got %v, %s, %v", value, age, ok)
+	}
+	if _, _, ok := cache.Load("usage/b"); ok {
+		t.Error("expected nothing for a key never stored")
+	}
+
+	now = now.Add(time.Minute)
+	if _, _, ok := cache.Load("usage/a"); ok {
+		t.Error("expected a result older than the ttl to be gone")
+	}
+
+	disabled := NewStaleCache(0)
+	disabled.Store("usage/a", 1)
+	if _, _, ok := disabled.Load("usage/a"); ok {
+		t.Error("expected a disabled cache to keep nothing")
+	}
+}
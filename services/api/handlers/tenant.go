@@ -1,8 +1,6 @@
 package handlers
 
 import (
-	"time"
-
 	"github.com/google/uuid"
 )
 
@@ -23,6 +21,6 @@ type GetTenantResponse struct {
 	ID        uuid.UUID `json:"id"`
 	Email     string    `json:"email"`
 	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	CreatedAt APITime   `json:"created_at"`
+	UpdatedAt APITime   `json:"updated_at"`
 }
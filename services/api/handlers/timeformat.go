@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// TimeFormat selects how APITime values are serialized in API responses
+type TimeFormat string
+
+const (
+	// TimeFormatRFC3339 renders second-precision RFC 3339 timestamps in UTC, e.g. "2025-03-01T12:00:00Z"
+	TimeFormatRFC3339 TimeFormat = "rfc3339"
+	// TimeFormatRFC3339Nano renders RFC 3339 timestamps in UTC with fractional seconds
+	TimeFormatRFC3339Nano TimeFormat = "rfc3339nano"
+	// TimeFormatUnixMs renders timestamps as integer milliseconds since the Unix epoch
+	TimeFormatUnixMs TimeFormat = "unix_ms"
+)
+
+// Validate reports an error for a format APITime cannot render
+func (f TimeFormat) Validate() error {
+	switch f {
+	case TimeFormatRFC3339, TimeFormatRFC3339Nano, TimeFormatUnixMs:
+		return nil
+	default:
+		return fmt.Errorf("unsupported time format %q", f)
+	}
+}
+
+// APITime is a timestamp in an API response DTO, serialized in the format it was created with
+type APITime struct {
+	time.Time
+	format TimeFormat
+}
+
+// NewAPITime wraps t for use in a response DTO rendered in format
+func NewAPITime(t time.Time, format TimeFormat) APITime {
+	return APITime{Time: t, format: format}
+}
+
+// NewAPITimePtr wraps an optional timestamp; nil stays nil so the field can be omitted
+func NewAPITimePtr(t *time.Time, format TimeFormat) *APITime {
+	if t == nil {
+		return nil
+	}
+	return &APITime{Time: *t, format: format}
+}
+
+// MarshalJSON implements json.Marshaler using the time's format
+func (t APITime) MarshalJSON() ([]byte, error) {
+	switch t.format {
+	case TimeFormatUnixMs:
+		return []byte(strconv.FormatInt(t.UnixMilli(), 10)), nil
+	case TimeFormatRFC3339Nano:
+		return json.Marshal(t.UTC().Format(time.RFC3339Nano))
+	default:
+		return json.Marshal(t.UTC().Format(time.RFC3339))
+	}
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting either an RFC 3339 string or Unix milliseconds
+func (t *APITime) UnmarshalJSON(data []byte) error {
+	if ms, err := strconv.ParseInt(string(data), 10, 64); err == nil {
+		t.Time = time.UnixMilli(ms).UTC()
+		return nil
+	}
+	return t.Time.UnmarshalJSON(data)
+}
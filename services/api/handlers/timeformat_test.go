@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestAPITime_MarshalJSON(t *testing.T) {
+	ts := time.Date(2025, 3, 1, 12, 30, 45, 123456789, time.FixedZone("CET", 3600))
+
+	tests := []struct {
+		format   TimeFormat
+		expected string
+	}{
+		{TimeFormatRFC3339, `"2025-03-01T11:30:45Z"`},
+		{TimeFormatRFC3339Nano, `"2025-03-01T11:30:45.123456789Z"`},
+		{TimeFormatUnixMs, `1740828645123`},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			data, err := json.Marshal(struct {
+				At APITime `json:"at"`
+			}{At: NewAPITime(ts, tt.format)})
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if got, want := string(data), `{"at":`+tt.expected+`}`; got != want {
+				t.Errorf("expected %s, got %s", want, got)
+			}
+
+			var decoded APITime
+			if err := json.Unmarshal([]byte(tt.expected), &decoded); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if decoded.IsZero() {
+				t.Error("expected round-tripped time to be non-zero")
+			}
+		})
+	}
+}
+
+func TestTimeFormat_Validate(t *testing.T) {
+	for _, format := range []TimeFormat{TimeFormatRFC3339, TimeFormatRFC3339Nano, TimeFormatUnixMs} {
+		if err := format.Validate(); err != nil {
+			t.Errorf("Validate(%q) error = %v", format, err)
+		}
+	}
+	if err := TimeFormat("iso8601").Validate(); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+}
+
+func TestAPITime_ZeroFormatIsRFC3339(t *testing.T) {
+	data, err := json.Marshal(APITime{Time: time.Date(2025, 3, 1, 11, 30, 45, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if got, want := string(data), `"2025-03-01T11:30:45Z"`; got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
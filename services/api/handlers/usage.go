@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/domain/services"
+	"rdl-api/internal/metrics"
+	"rdl-api/internal/middleware"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AdminKeyHeader carries an admin API key, which lets a caller read another tenant's usage
+const AdminKeyHeader = middleware.AdminKeyHeader
+
+// defaultUsagePeriod is how far back GET /usage looks when from is not given
+const defaultUsagePeriod = 30 * 24 * time.Hour
+
+// UsageResponse is the body of GET /usage
+type UsageResponse struct {
+	TenantID uuid.UUID `json:"tenant_id"`
+	From     APITime   `json:"from"`
+	To       APITime   `json:"to"`
+	Events   int64     `json:"events"`
+	Leaks    int64     `json:"leaks"`
+	// AffectedCustomers is the number of distinct customers with an open leak detected between
+	// from and to
+	AffectedCustomers int64 `json:"affected_customers"`
+	// MTTRSeconds is the mean time from detection to resolution of the ResolvedLeaks leaks
+	// resolved between from and to, in seconds; 0 when none were
+	MTTRSeconds   int64 `json:"mttr_seconds"`
+	ResolvedLeaks int   `json:"resolved_leaks"`
+	// RevenueRecovered is the amount, by currency, of the leaks whose customer paid between
+	// from and to after a successful action on the leak
+	RevenueRecovered map[string]models.Decimal `json:"revenue_recovered"`
+	// APIRequests is counted in memory by this instance, to the hour, since it started
+	APIRequests int64 `json:"api_requests"`
+}
+
+// UsageHandler returns a handler for GET /usage, the tenant's events received, leaks detected
+// and API requests made in [from, to), how many customers have an open leak detected since
+// from, the mean time to recovery of the leaks resolved since from, and the revenue recovered
+// by actions since from. from and to are RFC 3339 or Unix milliseconds; to defaults to now and
+// from to 30 days before to. A caller sending one of adminKeys in X-Admin-Key may pass
+// tenant_id to read another tenant's usage. While the counts fail, the last usage returned for
+// the same query is answered from cache, marked stale.
+func UsageHandler(logger *slog.Logger, eventsService services.EventsService, leaksService services.LeaksService, adminKeys []string, cache *StaleCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		tenantID, ok := middleware.GetTenantID(r)
+		if !ok {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidTenant, ErrorCodeUnauthorized, middleware.ErrMissingOrInvalidTenantContext, http.StatusUnauthorized)
+			return
+		}
+
+		query := r.URL.Query()
+		if raw := query.Get("tenant_id"); raw != "" {
+			target, err := uuid.Parse(raw)
+			if err != nil {
+				WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, fmt.Errorf("%w: %q", ErrInvalidTenantID, raw), http.StatusBadRequest)
+				return
+			}
+			if target != tenantID && !middleware.IsAdminKey(r.Header.Get(AdminKeyHeader), adminKeys) {
+				WriteRejection(ctx, w, logger, metrics.ReasonForbidden, ErrorCodeForbidden, ErrAdminKeyRequired, http.StatusForbidden)
+				return
+			}
+			tenantID = target
+		}
+
+		to := time.Now().UTC()
+		parsedTo, err := parseQueryTime(query, "to")
+		if err != nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, err, http.StatusBadRequest)
+			return
+		}
+		if parsedTo != nil {
+			to = *parsedTo
+		}
+		from := to.Add(-defaultUsagePeriod)
+		parsedFrom, err := parseQueryTime(query, "from")
+		if err != nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, err, http.StatusBadRequest)
+			return
+		}
+		if parsedFrom != nil {
+			from = *parsedFrom
+		}
+		if !from.Before(to) {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidParameter, ErrorCodeInvalidRequest, fmt.Errorf("%w: from must be before to", ErrInvalidTimeRange), http.StatusBadRequest)
+			return
+		}
+
+		// Keyed by the query as sent, so a default to of now still finds the last result
+		cacheKey := "usage/" + tenantID.String() + "?from=" + query.Get("from") + "&to=" + query.Get("to")
+		events, err := eventsService.GetEventCountInWindow(ctx, tenantID, from, to)
+		if err != nil {
+			logger.Log(ctx, serviceErrorLevel(err), "Failed to count events for usage", "error", err, "tenant_id", tenantID)
+			if !writeStale(ctx, w, logger, cache, cacheKey, err) {
+				WriteServerError(ctx, w, logger, err)
+			}
+			return
+		}
+		leaks, err := leaksService.GetLeakCountInWindow(ctx, tenantID, from, to)
+		if err != nil {
+			logger.Log(ctx, serviceErrorLevel(err), "Failed to count leaks for usage", "error", err, "tenant_id", tenantID)
+			if !writeStale(ctx, w, logger, cache, cacheKey, err) {
+				WriteServerError(ctx, w, logger, err)
+			}
+			return
+		}
+
+		affectedCustomers, err := leaksService.GetAffectedCustomerCount(ctx, tenantID, from, to)
+		if err != nil {
+			logger.Log(ctx, serviceErrorLevel(err), "Failed to count affected customers for usage", "error", err, "tenant_id", tenantID)
+			if !writeStale(ctx, w, logger, cache, cacheKey, err) {
+				WriteServerError(ctx, w, logger, err)
+			}
+			return
+		}
+
+		mttr, resolvedLeaks, err := leaksService.GetLeakMTTR(ctx, tenantID, from, to)
+		if err != nil {
+			logger.Log(ctx, serviceErrorLevel(err), "Failed to compute leak MTTR for usage", "error", err, "tenant_id", tenantID)
+			if !writeStale(ctx, w, logger, cache, cacheKey, err) {
+				WriteServerError(ctx, w, logger, err)
+			}
+			return
+		}
+
+		recovered, err := leaksService.GetRevenueRecovered(ctx, tenantID, from, to)
+		if err != nil {
+			logger.Log(ctx, serviceErrorLevel(err), "Failed to compute revenue recovered for usage", "error", err, "tenant_id", tenantID)
+			if !writeStale(ctx, w, logger, cache, cacheKey, err) {
+				WriteServerError(ctx, w, logger, err)
+			}
+			return
+		}
+
+		response := UsageResponse{
+			TenantID:          tenantID,
+			From:              NewAPITime(from, timeFormatFrom(ctx)),
+			To:                NewAPITime(to, timeFormatFrom(ctx)),
+			Events:            events,
+			Leaks:             leaks,
+			AffectedCustomers: affectedCustomers,
+			MTTRSeconds:       int64(mttr / time.Second),
+			ResolvedLeaks:     resolvedLeaks,
+			RevenueRecovered:  recovered,
+			APIRequests:       metrics.TenantRequestCount(tenantID.String(), from, to),
+		}
+		cache.Store(cacheKey, response)
+		WriteJSONSuccessResponse(ctx, w, logger, response)
+	}
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/domain/services"
+	"rdl-api/internal/middleware"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// testUsageService counts fixed events and leaks per tenant and records the window it was asked
+// for; other methods panic via the nil embedded interfaces
+type testUsageService struct {
+	services.EventsService
+	services.LeaksService
+	events   map[uuid.UUID]int64
+	leaks    map[uuid.UUID]int64
+	affected map[uuid.UUID]int64
+	mttr     map[uuid.UUID]time.Duration
+	resolved map[uuid.UUID]int
+	// recovered is the revenue recovered per tenant
+	recovered map[uuid.UUID]map[string]models.Decimal
+	from, to  time.Time
+	// windows is the [from, to) each bounded aggregate was last asked for, by method
+	windows map[string][2]time.Time
+}
+
+func (s *testUsageService) GetEventCountInWindow(_ context.Context, tenantID uuid.UUID, from time.Time, to time.Time) (int64, error) {
+	s.from, s.to = from, to
+	return s.events[tenantID], nil
+}
+
+func (s *testUsageService) GetLeakCountInWindow(_ context.Context, tenantID uuid.UUID, _ time.Time, _ time.Time) (int64, error) {
+	return s.leaks[tenantID], nil
+}
+
+func (s *testUsageService) GetAffectedCustomerCount(_ context.Context, tenantID uuid.UUID, from time.Time, to time.Time) (int64, error) {
+	s.windows["GetAffectedCustomerCount"] = [2]time.Time{from, to}
+	return s.affected[tenantID], nil
+}
+
+func (s *testUsageService) GetLeakMTTR(_ context.Context, tenantID uuid.UUID, from time.Time, to time.Time) (time.Duration, int, error) {
+	s.windows["GetLeakMTTR"] = [2]time.Time{from, to}
+	return s.mttr[tenantID], s.resolved[tenantID], nil
+}
+
+func (s *testUsageService) GetRevenueRecovered(_ context.Context, tenantID uuid.UUID, from time.Time, to time.Time) (map[string]models.Decimal, error) {
+	s.windows["GetRevenueRecovered"] = [2]time.Time{from, to}
+	return s.recovered[tenantID], nil
+}
+
+func TestUsageHandler(t *testing.T) {
+	caller := uuid.New()
+	other := uuid.New()
+	svc := &testUsageService{
+		events:   map[uuid.UUID]int64{caller: 42, other: 7},
+		leaks:    map[uuid.UUID]int64{caller: 3, other: 1},
+		affected: map[uuid.UUID]int64{caller: 2},
+		mttr:     map[uuid.UUID]time.Duration{caller: 90 * time.Minute},
+		resolved: map[uuid.UUID]int{caller: 4},
+		recovered: map[uuid.UUID]map[string]models.Decimal{
+			caller: {"USD": models.MustParseDecimal("120.50"), "EUR": models.MustParseDecimal("30")},
+		},
+		windows: map[string][2]time.Time{},
+	}
+
+	logger := newTestLogger()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /usage", UsageHandler(logger, svc, svc, []string{"admin-secret"}, nil))
+	handler := middleware.TenantContext(logger, true, nil)(mux)
+
+	get := func(query, adminKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/usage"+query, nil)
+		req.Header.Set("X-Tenant-ID", caller.String())
+		if adminKey != "" {
+			req.Header.Set(AdminKeyHeader, adminKey)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	decode := func(t *testing.T, w *httptest.ResponseRecorder) UsageResponse {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var body UsageResponse
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return body
+	}
+
+	t.Run("own usage matches the underlying counts", func(t *testing.T) {
+		get("", "")
+		body := decode(t, get("", ""))
+
+		if body.TenantID != caller || body.Events != 42 || body.Leaks != 3 || body.AffectedCustomers != 2 {
+			t.Errorf("expected the caller's counts, got %+v", body)
+		}
+		if body.MTTRSeconds != 5400 || body.ResolvedLeaks != 4 {
+			t.Errorf("expected an MTTR of 5400s over 4 leaks, got %ds over %d", body.MTTRSeconds, body.ResolvedLeaks)
+		}
+		if len(body.RevenueRecovered) != 2 || body.RevenueRecovered["USD"].String() != "120.50" || body.RevenueRecovered["EUR"].String() != "30" {
+			t.Errorf("expected 120.50 USD and 30 EUR recovered, got %v", body.RevenueRecovered)
+		}
+		// Both requests went through the tenant middleware, which counts them
+		if body.APIRequests != 2 {
+			t.Errorf("expected 2 API requests, got %d", body.APIRequests)
+		}
+		if period := svc.to.Sub(svc.from); period != defaultUsagePeriod {
+			t.Errorf("expected the default period of %s, got %s", defaultUsagePeriod, period)
+		}
+	})
+
+	t.Run("explicit period", func(t *testing.T) {
+		decode(t, get("?from=2025-01-01T00:00:00Z&to=2025-02-01T00:00:00Z", ""))
+		if !svc.from.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) || !svc.to.Equal(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("expected the given period, got [%s, %s)", svc.from, svc.to)
+		}
+		for method, window := range svc.windows {
+			if !window[0].Equal(svc.from) || !window[1].Equal(svc.to) {
+				t.Errorf("expected %s over the given period, got [%s, %s)", method, window[0], window[1])
+			}
+		}
+	})
+
+	t.Run("admin reads another tenant", func(t *testing.T) {
+		body := decode(t, get("?tenant_id="+other.String(), "admin-secret"))
+		if body.TenantID != other || body.Events != 7 || body.Leaks != 1 || body.APIRequests != 0 {
+			t.Errorf("expected the other tenant's counts, got %+v", body)
+		}
+	})
+
+	t.Run("another tenant without an admin key", func(t *testing.T) {
+		for _, key := range []string{"", "wrong"} {
+			if w := get("?tenant_id="+other.String(), key); w.Code != http.StatusForbidden {
+				t.Errorf("expected status %d with key %q, got %d", http.StatusForbidden, key, w.Code)
+			}
+		}
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		for _, query := range []string{"?tenant_id=nope", "?from=yesterday", "?from=2025-02-01T00:00:00Z&to=2025-01-01T00:00:00Z"} {
+			if w := get(query, ""); w.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d for %s, got %d", http.StatusBadRequest, query, w.Code)
+			}
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"log/slog"
 	"net/http"
 	"rdl-api/config"
+	"time"
 )
 
 // VersionResponse represents the version information response
@@ -15,8 +16,8 @@ type VersionResponse struct {
 	Environment string `json:"environment,omitempty"`
 }
 
-// VersionHandler returns version information
-func VersionHandler(buildInfo *config.BuildInfoConfig, logger *slog.Logger) http.HandlerFunc {
+// VersionHandler returns version information. It only changes on deploy, so it may be cached for maxAge.
+func VersionHandler(buildInfo *config.BuildInfoConfig, logger *slog.Logger, maxAge time.Duration) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		response := VersionResponse{
 			Version:   buildInfo.GIT_TAG,
@@ -24,7 +25,8 @@ func VersionHandler(buildInfo *config.BuildInfoConfig, logger *slog.Logger) http
 			BuildDate: buildInfo.BUILD_TIMESTAMP,
 		}
 
-		WriteJSONSuccessResponse(r.Context(), w, slog.Default(), response)
+		SetCacheHeaders(w, maxAge)
+		WriteJSONSuccessResponse(r.Context(), w, logger, response)
 	}
 
 }
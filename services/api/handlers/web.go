@@ -3,10 +3,20 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
+	"rdl-api/internal/domain/services"
+	"rdl-api/internal/metrics"
 )
 
+// RetryAfterOverloaded is the Retry-After value, in seconds, sent with a 503 when the service is overloaded
+const RetryAfterOverloaded = "1"
+
+// StatusClientClosedRequest is the non-standard status, borrowed from nginx, recorded when the
+// client disconnected before the response was written
+const StatusClientClosedRequest = 499
+
 // WriteJSONResponse writes a JSON response with proper error handling and logging
 func WriteJSONResponse[T any](
 	ctx context.Context,
@@ -17,6 +27,10 @@ func WriteJSONResponse[T any](
 ) {
 	// Set content type header
 	w.Header().Set("Content-Type", "application/json")
+	// Data changes with every write; only handlers that set their own caching allow it
+	if w.Header().Get("Cache-Control") == "" {
+		SetCacheHeaders(w, 0)
+	}
 	w.WriteHeader(statusCode)
 
 	// Encode and write JSON response
@@ -49,3 +63,83 @@ func WriteJSONSuccessResponse[T any](
 ) {
 	WriteJSONResponse(ctx, w, logger, data, http.StatusOK)
 }
+
+// ErrorResponse represents the JSON error envelope returned by the API
+type ErrorResponse struct {
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail holds a stable machine-readable code and a human-readable message.
+// Debug is only set on 500 responses in development, see ResponseOptions.ErrorDetails.
+type ErrorDetail struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Debug   *ErrorDebug `json:"debug,omitempty"`
+}
+
+// WriteJSONError writes the JSON error envelope with the given code, message and status code
+func WriteJSONError(
+	ctx context.Context,
+	w http.ResponseWriter,
+	logger *slog.Logger,
+	code string,
+	err error,
+	statusCode int,
+) {
+	response := ErrorResponse{
+		Error: ErrorDetail{
+			Code:    code,
+			Message: err.Error(),
+		},
+	}
+
+	WriteJSONResponse(ctx, w, logger, response, statusCode)
+}
+
+// WriteRejection records a rejected request under the given metrics reason and writes the JSON error envelope.
+// Use it for client errors caught before any work is done (bad tenant, bad input, oversized body, ...).
+func WriteRejection(
+	ctx context.Context,
+	w http.ResponseWriter,
+	logger *slog.Logger,
+	reason string,
+	code string,
+	err error,
+	statusCode int,
+) {
+	metrics.RecordRejection(reason)
+	WriteJSONError(ctx, w, logger, code, err, statusCode)
+}
+
+// WriteServerError writes the JSON error envelope for a request that failed in the service
+// layer. An exhausted database pool becomes a 503 with Retry-After so load balancers and
+// clients back off; a passed deadline becomes a 504; a client that disconnected gets a 499
+// nobody will read; anything else is a 500. The cause is only returned to the client when
+// error details are on, which they never are outside development.
+func WriteServerError(
+	ctx context.Context,
+	w http.ResponseWriter,
+	logger *slog.Logger,
+	err error,
+) {
+	switch {
+	case errors.Is(err, services.ErrServiceOverloaded):
+		w.Header().Set("Retry-After", RetryAfterOverloaded)
+		WriteJSONError(ctx, w, logger, ErrorCodeOverloaded, ErrServiceOverloaded, http.StatusServiceUnavailable)
+	case errors.Is(err, context.Canceled):
+		WriteJSONError(ctx, w, logger, ErrorCodeCanceled, ErrRequestCanceled, StatusClientClosedRequest)
+	case errors.Is(err, context.DeadlineExceeded):
+		WriteJSONError(ctx, w, logger, ErrorCodeTimeout, ErrRequestTimeout, http.StatusGatewayTimeout)
+	default:
+		writeInternalError(ctx, w, logger, &ErrorDebug{Error: err.Error()})
+	}
+}
+
+// serviceErrorLevel is the level to log a service failure at. A client that disconnected
+// is not a server fault, so it is logged at INFO rather than ERROR.
+func serviceErrorLevel(err error) slog.Level {
+	if errors.Is(err, context.Canceled) {
+		return slog.LevelInfo
+	}
+	return slog.LevelError
+}
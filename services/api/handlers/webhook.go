@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/domain/services"
+	"rdl-api/internal/metrics"
+	"rdl-api/internal/middleware"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StripeSignatureHeader is the header Stripe uses to sign webhook payloads
+const StripeSignatureHeader = "Stripe-Signature"
+
+// stripeEventTypes maps the Stripe event types we ingest to our event types
+var stripeEventTypes = map[string]models.EventTypeEnum{
+	"charge.failed":                 models.EventTypeEnumPaymentFailed,
+	"invoice.payment_failed":        models.EventTypeEnumPaymentFailed,
+	"payment_intent.payment_failed": models.EventTypeEnumPaymentFailed,
+	"charge.succeeded":              models.EventTypeEnumPaymentSucceeded,
+	"invoice.payment_succeeded":     models.EventTypeEnumPaymentSucceeded,
+	"payment_intent.succeeded":      models.EventTypeEnumPaymentSucceeded,
+	"charge.refunded":               models.EventTypeEnumPaymentRefunded,
+	"charge.updated":                models.EventTypeEnumPaymentUpdated,
+	"invoice.updated":               models.EventTypeEnumPaymentUpdated,
+}
+
+// stripeEvent is the subset of a Stripe event envelope the webhook needs
+type stripeEvent struct {
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	Created int64           `json:"created"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// EventAgePolicy decides what happens to webhook events whose provider timestamp is older
+// than MaxAge. A zero MaxAge accepts events of any age.
+type EventAgePolicy struct {
+	MaxAge time.Duration
+	// Reject answers stale events with 400; otherwise they are acknowledged with 202 and dropped
+	Reject bool
+}
+
+// WebhookResponse is returned to the provider once a webhook has been accepted
+type WebhookResponse struct {
+	Received bool `json:"received"`
+	// Skipped is set when the event was accepted but not stored, because the tenant doesn't
+	// accept its type or because it is older than the configured maximum age
+	Skipped bool `json:"skipped,omitempty"`
+	// Queued is set when the database was unreachable and the event was queued to be stored
+	// once it is back
+	Queued bool `json:"queued,omitempty"`
+}
+
+// EventQueue holds events to be stored later while the database is unreachable
+type EventQueue interface {
+	Enqueue(params models.CreateEventParams, tenantID uuid.UUID) error
+}
+
+// StripeWebhookHandler returns a handler for POST /webhooks/stripe.
+// The raw body is buffered once with ReadAndRestoreBody so the signature is verified over
+// exactly the bytes that are then decoded and stored. Event types we don't track and
+// redeliveries of events we already stored are acknowledged with 200 so Stripe stops retrying;
+// events the tenant's allowlist does not accept are acknowledged with 202 and not stored.
+// A signature whose timestamp is more than tolerance from now is rejected with 400 and
+// ErrorCodeTimestampOutsideTolerance, so a captured request cannot be replayed later; a zero
+// tolerance disables the check.
+// Events created longer ago than agePolicy.MaxAge are skipped with 202 or rejected with 400.
+// With a queue, an event that cannot be stored because the database is unreachable is queued
+// and acknowledged with 202; once the queue is full such events get a 503 so Stripe retries.
+// A nil queue disables this.
+func StripeWebhookHandler(
+	logger *slog.Logger,
+	eventsService services.EventsService,
+	secret string,
+	tolerance time.Duration,
+	providerID uuid.UUID,
+	maxBytes int64,
+	agePolicy EventAgePolicy,
+	queue EventQueue,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		tenantID, ok := middleware.GetTenantID(r)
+		if !ok {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidTenant, ErrorCodeUnauthorized, middleware.ErrMissingOrInvalidTenantContext, http.StatusUnauthorized)
+			return
+		}
+
+		body, err := ReadAndRestoreBody(r, maxBytes)
+		if err != nil {
+			if errors.Is(err, ErrBodyTooLarge) {
+				WriteRejection(ctx, w, logger, metrics.ReasonBodyTooLarge, ErrorCodeBodyTooLarge, ErrBodyTooLarge, http.StatusRequestEntityTooLarge)
+				return
+			}
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidJSON, ErrorCodeInvalidRequest, ErrInvalidRequestBody, http.StatusBadRequest)
+			return
+		}
+
+		if err := verifyStripeSignature(body, r.Header.Get(StripeSignatureHeader), secret, tolerance, time.Now()); err != nil {
+			if errors.Is(err, ErrTimestampOutsideTolerance) {
+				logger.WarnContext(ctx, "Rejected Stripe webhook with a timestamp outside the tolerance", "error", err, "tenant_id", tenantID)
+				WriteRejection(ctx, w, logger, metrics.ReasonTimestampOutsideTolerance, ErrorCodeTimestampOutsideTolerance, err, http.StatusBadRequest)
+				return
+			}
+			logger.WarnContext(ctx, "Rejected Stripe webhook with invalid signature", "tenant_id", tenantID)
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidSignature, ErrorCodeInvalidSignature, ErrInvalidSignature, http.StatusBadRequest)
+			return
+		}
+
+		var event stripeEvent
+		if err := decodeJSON(ctx, r.Body, &event); err != nil || event.ID == "" || event.Type == "" {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidJSON, ErrorCodeInvalidRequest, requestBodyError(err), http.StatusBadRequest)
+			return
+		}
+
+		eventType, ok := stripeEventTypes[event.Type]
+		if !ok {
+			logger.DebugContext(ctx, "Ignoring untracked Stripe event type", "stripe_event_id", event.ID, "type", event.Type)
+			WriteJSONSuccessResponse(ctx, w, logger, WebhookResponse{Received: true})
+			return
+		}
+
+		if err := models.CheckEventAge(stripeEventTime(event.Created), agePolicy.MaxAge, time.Now()); err != nil {
+			logger.InfoContext(ctx, "Stale Stripe event", "stripe_event_id", event.ID, "created", event.Created, "tenant_id", tenantID, "rejected", agePolicy.Reject)
+			if agePolicy.Reject {
+				WriteRejection(ctx, w, logger, metrics.ReasonStaleEvent, ErrorCodeEventTooOld, err, http.StatusBadRequest)
+				return
+			}
+			WriteJSONResponse(ctx, w, logger, WebhookResponse{Received: true, Skipped: true}, http.StatusAccepted)
+			return
+		}
+
+		params, err := models.NewCreateEventParams(tenantID, providerID, eventType, event.ID, body)
+		if err != nil {
+			WriteRejection(ctx, w, logger, metrics.ReasonInvalidJSON, ErrorCodeInvalidRequest, ErrInvalidRequestBody, http.StatusBadRequest)
+			return
+		}
+		params.Source = models.EventSourceEnumWebhook
+
+		_, err = eventsService.CreateEventIdempotent(ctx, params, tenantID)
+		if queue != nil && services.IsDatabaseUnavailable(err) && ctx.Err() == nil {
+			if qErr := queue.Enqueue(params, tenantID); qErr != nil {
+				logger.ErrorContext(ctx, "Failed to queue Stripe event while the database is unavailable", "error", qErr, "stripe_event_id", event.ID, "tenant_id", tenantID)
+				WriteJSONError(ctx, w, logger, ErrorCodeUnavailable, ErrDatabaseUnavailable, http.StatusServiceUnavailable)
+				return
+			}
+			logger.WarnContext(ctx, "Database unavailable, queued Stripe event", "error", err, "stripe_event_id", event.ID, "tenant_id", tenantID)
+			WriteJSONResponse(ctx, w, logger, WebhookResponse{Received: true, Queued: true}, http.StatusAccepted)
+			return
+		}
+		if errors.Is(err, services.ErrEventSkipped) {
+			// The tenant's allowlist doesn't accept this type; acknowledge so the provider doesn't retry
+			WriteJSONResponse(ctx, w, logger, WebhookResponse{Received: true, Skipped: true}, http.StatusAccepted)
+			return
+		}
+		if errors.Is(err, services.ErrUnknownProvider) {
+			WriteRejection(ctx, w, logger, metrics.ReasonUnknownProvider, ErrorCodeUnknownProvider, services.ErrUnknownProvider, http.StatusBadRequest)
+			return
+		}
+		if err != nil && !errors.Is(err, services.ErrEventAlreadyExists) {
+			logger.Log(ctx, serviceErrorLevel(err), "Failed to store Stripe event", "error", err, "stripe_event_id", event.ID, "tenant_id", tenantID)
+			WriteServerError(ctx, w, logger, err)
+			return
+		}
+
+		WriteJSONSuccessResponse(ctx, w, logger, WebhookResponse{Received: true})
+	}
+}
+
+// stripeEventTime converts a Stripe "created" Unix timestamp, returning the zero time when
+// the envelope carried none
+func stripeEventTime(created int64) time.Time {
+	if created <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(created, 0)
+}
+
+// verifyStripeSignature checks a Stripe-Signature header of the form "t=<timestamp>,v1=<hex>[,v1=<hex>...]"
+// against an HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook secret. It returns
+// ErrInvalidSignature when no signature matches, and then ErrTimestampOutsideTolerance when the
+// signed timestamp is more than a positive tolerance before or after now.
+func verifyStripeSignature(body []byte, header, secret string, tolerance time.Duration, now time.Time) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	if timestamp == "" || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+
+	expected := computeStripeSignature(body, timestamp, secret)
+	matched := false
+	for _, signature := range signatures {
+		decoded, err := hex.DecodeString(signature)
+		if err != nil {
+			continue
+		}
+		if hmac.Equal(decoded, expected) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return ErrInvalidSignature
+	}
+
+	if tolerance > 0 {
+		signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return ErrInvalidSignature
+		}
+		if skew := now.Sub(time.Unix(signedAt, 0)).Abs(); skew > tolerance {
+			return fmt.Errorf("%w: signed %s from now, tolerance is %s", ErrTimestampOutsideTolerance, skew.Truncate(time.Second), tolerance)
+		}
+	}
+	return nil
+}
+
+// computeStripeSignature returns the HMAC-SHA256 of "<timestamp>.<body>" keyed with secret
+func computeStripeSignature(body []byte, timestamp, secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
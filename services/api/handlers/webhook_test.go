@@ -0,0 +1,398 @@
+package handlers
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"rdl-api/internal/db/repository"
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/ingestqueue"
+	"rdl-api/internal/middleware"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const testWebhookSecret = "whsec_test"
+
+func signStripePayload(body []byte, timestamp, secret string) string {
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(computeStripeSignature(body, timestamp, secret))
+}
+
+func newStripeWebhookRequest(body string, signature string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/stripe", strings.NewReader(body))
+	req.Header.Set("X-Tenant-ID", uuid.NewString())
+	req.Header.Set(StripeSignatureHeader, signature)
+	return req
+}
+
+func newWebhookTestHandler(eventsService *testEventsService, maxBytes int64) http.Handler {
+	logger := newTestLogger()
+	handler := StripeWebhookHandler(logger, eventsService, testWebhookSecret, 0, uuid.New(), maxBytes, EventAgePolicy{}, nil)
+	return middleware.TenantContext(logger, true, nil)(handler)
+}
+
+func TestStripeWebhookHandler(t *testing.T) {
+	payload := `{"id":"evt_1","type":"invoice.payment_failed","created":1700000000,"data":{"object":{}}}`
+	validSignature := signStripePayload([]byte(payload), "1700000000", testWebhookSecret)
+
+	tests := []struct {
+		name           string
+		body           string
+		signature      string
+		maxBytes       int64
+		expectedStatus int
+		expectStored   bool
+	}{
+		{
+			name:           "valid signature stores event",
+			body:           payload,
+			signature:      validSignature,
+			maxBytes:       1024,
+			expectedStatus: http.StatusOK,
+			expectStored:   true,
+		},
+		{
+			name:           "invalid signature",
+			body:           payload,
+			signature:      signStripePayload([]byte(payload), "1700000000", "wrong_secret"),
+			maxBytes:       1024,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing signature",
+			body:           payload,
+			maxBytes:       1024,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "body over limit",
+			body:           payload,
+			signature:      validSignature,
+			maxBytes:       16,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eventsService := newTestEventsService()
+			handler := newWebhookTestHandler(eventsService, tt.maxBytes)
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, newStripeWebhookRequest(tt.body, tt.signature))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			stored, ok := eventsService.created["evt_1"]
+			if ok != tt.expectStored {
+				t.Fatalf("expected stored=%v, got %v", tt.expectStored, ok)
+			}
+			if ok {
+				if stored.EventType != models.EventTypeEnumPaymentFailed {
+					t.Errorf("expected event type %q, got %q", models.EventTypeEnumPaymentFailed, stored.EventType)
+				}
+				if stored.Source != models.EventSourceEnumWebhook {
+					t.Errorf("expected source %q, got %q", models.EventSourceEnumWebhook, stored.Source)
+				}
+				if data, _ := stored.Data.([]byte); string(data) != payload {
+					t.Errorf("expected stored data to be the verified payload, got %q", data)
+				}
+			}
+		})
+	}
+}
+
+// TestWebhookBodyLimit checks that a body between MAX_REQUEST_BYTES and WEBHOOK_MAX_BYTES is
+// accepted by the webhook, which is given the webhook limit, and refused by a normal route
+func TestWebhookBodyLimit(t *testing.T) {
+	const requestMaxBytes, webhookMaxBytes = 1024, 4096
+	description := strings.Repeat("x", 2048)
+	payload := `{"id":"evt_1","type":"invoice.payment_failed","created":1700000000,"data":{"object":{"description":"` + description + `"}}}`
+	if len(payload) <= requestMaxBytes || len(payload) > webhookMaxBytes {
+		t.Fatalf("payload of %d bytes must fall between the two limits", len(payload))
+	}
+	signature := signStripePayload([]byte(payload), "1700000000", testWebhookSecret)
+
+	t.Run("accepted on the webhook route", func(t *testing.T) {
+		eventsService := newTestEventsService()
+		w := httptest.NewRecorder()
+		newWebhookTestHandler(eventsService, webhookMaxBytes).ServeHTTP(w, newStripeWebhookRequest(payload, signature))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if _, ok := eventsService.created["evt_1"]; !ok {
+			t.Error("expected the event to be stored")
+		}
+	})
+
+	t.Run("rejected on a normal route", func(t *testing.T) {
+		batch := `[{"provider_id":"` + uuid.NewString() + `","event_type":"payment_failed","event_id":"evt_1","data":{"description":"` + description + `"}}]`
+		logger := newTestLogger()
+		mux := http.NewServeMux()
+		mux.HandleFunc("POST /events/batch", CreateEventsBatchHandler(logger, &testBatchEventsService{seen: map[string]bool{}}, models.BatchPolicy{MaxSize: 10}, requestMaxBytes))
+		req := httptest.NewRequest(http.MethodPost, "/events/batch", strings.NewReader(batch))
+		req.Header.Set("X-Tenant-ID", uuid.NewString())
+		w := httptest.NewRecorder()
+		middleware.TenantContext(logger, true, nil)(mux).ServeHTTP(w, req)
+
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusRequestEntityTooLarge, w.Code, w.Body.String())
+		}
+	})
+}
+
+func TestStripeWebhookHandler_DuplicateDeliveryAcknowledged(t *testing.T) {
+	payload := `{"id":"evt_dup","type":"charge.failed"}`
+	signature := signStripePayload([]byte(payload), "1700000000", testWebhookSecret)
+	handler := newWebhookTestHandler(newTestEventsService(), 1024)
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newStripeWebhookRequest(payload, signature))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("attempt %d: expected status %d, got %d", attempt, http.StatusOK, w.Code)
+		}
+	}
+}
+
+func TestStripeWebhookHandler_TenantAllowlist(t *testing.T) {
+	tests := []struct {
+		name           string
+		stripeType     string
+		expectedStatus int
+		expectStored   bool
+	}{
+		{name: "allowed type is stored", stripeType: "charge.failed", expectedStatus: http.StatusOK, expectStored: true},
+		{name: "disallowed type is skipped", stripeType: "charge.succeeded", expectedStatus: http.StatusAccepted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eventsService := newTestEventsService()
+			eventsService.acceptedTypes = []models.EventTypeEnum{models.EventTypeEnumPaymentFailed}
+			handler := newWebhookTestHandler(eventsService, 1024)
+
+			payload := `{"id":"evt_allow","type":"` + tt.stripeType + `"}`
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, newStripeWebhookRequest(payload, signStripePayload([]byte(payload), "1700000000", testWebhookSecret)))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if _, ok := eventsService.created["evt_allow"]; ok != tt.expectStored {
+				t.Errorf("expected stored=%v, got %v", tt.expectStored, ok)
+			}
+		})
+	}
+}
+
+func TestStripeWebhookHandler_ProviderAllowlist(t *testing.T) {
+	registered := uuid.New()
+
+	tests := []struct {
+		name           string
+		providerID     uuid.UUID
+		expectedStatus int
+		expectStored   bool
+	}{
+		{name: "registered provider is stored", providerID: registered, expectedStatus: http.StatusOK, expectStored: true},
+		{name: "unknown provider is rejected", providerID: uuid.New(), expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eventsService := newTestEventsService()
+			eventsService.allowedProviders = []uuid.UUID{registered}
+			logger := newTestLogger()
+			handler := middleware.TenantContext(logger, true, nil)(
+				StripeWebhookHandler(logger, eventsService, testWebhookSecret, 0, tt.providerID, 1024, EventAgePolicy{}, nil),
+			)
+
+			payload := `{"id":"evt_provider","type":"charge.failed"}`
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, newStripeWebhookRequest(payload, signStripePayload([]byte(payload), "1700000000", testWebhookSecret)))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if _, ok := eventsService.created["evt_provider"]; ok != tt.expectStored {
+				t.Errorf("expected stored=%v, got %v", tt.expectStored, ok)
+			}
+			if !tt.expectStored && !strings.Contains(w.Body.String(), ErrorCodeUnknownProvider) {
+				t.Errorf("expected error code %q, got %s", ErrorCodeUnknownProvider, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestStripeWebhookHandler_EventAge(t *testing.T) {
+	fresh := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-30*24*time.Hour).Unix(), 10)
+
+	tests := []struct {
+		name           string
+		created        string
+		reject         bool
+		expectedStatus int
+		expectStored   bool
+	}{
+		{name: "fresh event is stored", created: fresh, expectedStatus: http.StatusOK, expectStored: true},
+		{name: "stale event is skipped", created: stale, expectedStatus: http.StatusAccepted},
+		{name: "stale event is rejected", created: stale, reject: true, expectedStatus: http.StatusBadRequest},
+		{name: "event without timestamp is stored", reject: true, expectedStatus: http.StatusOK, expectStored: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eventsService := newTestEventsService()
+			logger := newTestLogger()
+			policy := EventAgePolicy{MaxAge: 7 * 24 * time.Hour, Reject: tt.reject}
+			handler := middleware.TenantContext(logger, true, nil)(StripeWebhookHandler(logger, eventsService, testWebhookSecret, 0, uuid.New(), 1024, policy, nil))
+
+			payload := `{"id":"evt_age","type":"charge.failed"}`
+			if tt.created != "" {
+				payload = `{"id":"evt_age","type":"charge.failed","created":` + tt.created + `}`
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, newStripeWebhookRequest(payload, signStripePayload([]byte(payload), "1700000000", testWebhookSecret)))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if _, ok := eventsService.created["evt_age"]; ok != tt.expectStored {
+				t.Fatalf("expected stored=%v, got %v", tt.expectStored, ok)
+			}
+		})
+	}
+}
+
+func TestStripeWebhookHandler_Tolerance(t *testing.T) {
+	payload := `{"id":"evt_signed","type":"charge.failed"}`
+	signedAt := func(offset time.Duration) string {
+		return strconv.FormatInt(time.Now().Add(offset).Unix(), 10)
+	}
+
+	tests := []struct {
+		name           string
+		timestamp      string
+		tolerance      time.Duration
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "within the tolerance", timestamp: signedAt(-2 * time.Minute), tolerance: 5 * time.Minute, expectedStatus: http.StatusOK},
+		{name: "slightly ahead of our clock", timestamp: signedAt(time.Minute), tolerance: 5 * time.Minute, expectedStatus: http.StatusOK},
+		{name: "too old", timestamp: signedAt(-10 * time.Minute), tolerance: 5 * time.Minute, expectedStatus: http.StatusBadRequest, expectedCode: ErrorCodeTimestampOutsideTolerance},
+		{name: "too far ahead", timestamp: signedAt(10 * time.Minute), tolerance: 5 * time.Minute, expectedStatus: http.StatusBadRequest, expectedCode: ErrorCodeTimestampOutsideTolerance},
+		{name: "zero tolerance accepts any time", timestamp: "1700000000", expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eventsService := newTestEventsService()
+			logger := newTestLogger()
+			handler := middleware.TenantContext(logger, true, nil)(StripeWebhookHandler(logger, eventsService, testWebhookSecret, tt.tolerance, uuid.New(), 1024, EventAgePolicy{}, nil))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, newStripeWebhookRequest(payload, signStripePayload([]byte(payload), tt.timestamp, testWebhookSecret)))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if _, ok := eventsService.created["evt_signed"]; ok != (tt.expectedStatus == http.StatusOK) {
+				t.Errorf("expected stored=%v, got %v", tt.expectedStatus == http.StatusOK, ok)
+			}
+			if tt.expectedCode != "" && !strings.Contains(w.Body.String(), tt.expectedCode) {
+				t.Errorf("expected error code %q, got %s", tt.expectedCode, w.Body.String())
+			}
+		})
+	}
+
+	t.Run("an invalid signature is reported as such whatever its timestamp", func(t *testing.T) {
+		handler := middleware.TenantContext(newTestLogger(), true, nil)(StripeWebhookHandler(newTestLogger(), newTestEventsService(), testWebhookSecret, 5*time.Minute, uuid.New(), 1024, EventAgePolicy{}, nil))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newStripeWebhookRequest(payload, signStripePayload([]byte(payload), "1700000000", "wrong_secret")))
+		if !strings.Contains(w.Body.String(), ErrorCodeInvalidSignature) {
+			t.Errorf("expected error code %q, got %s", ErrorCodeInvalidSignature, w.Body.String())
+		}
+	})
+}
+
+// unavailableEventsService fails every write the way the repository does while the database is unreachable
+type unavailableEventsService struct {
+	*testEventsService
+}
+
+func (unavailableEventsService) CreateEventIdempotent(context.Context, models.CreateEventParams, uuid.UUID) (models.Event, error) {
+	return models.Event{}, repository.ErrFailedToAcquireConnection
+}
+
+// fakeEventQueue records queued events and refuses them once full
+type fakeEventQueue struct {
+	full   bool
+	queued []string
+}
+
+func (q *fakeEventQueue) Enqueue(params models.CreateEventParams, _ uuid.UUID) error {
+	if q.full {
+		return ingestqueue.ErrQueueFull
+	}
+	q.queued = append(q.queued, params.EventID)
+	return nil
+}
+
+func TestStripeWebhookHandler_DatabaseUnavailable(t *testing.T) {
+	payload := `{"id":"evt_queued","type":"charge.failed"}`
+	signature := signStripePayload([]byte(payload), "1700000000", testWebhookSecret)
+
+	tests := []struct {
+		name           string
+		queue          *fakeEventQueue
+		expectedStatus int
+		expectQueued   bool
+	}{
+		{name: "event is queued", queue: &fakeEventQueue{}, expectedStatus: http.StatusAccepted, expectQueued: true},
+		{name: "full queue answers 503", queue: &fakeEventQueue{full: true}, expectedStatus: http.StatusServiceUnavailable},
+		{name: "no queue fails", expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := newTestLogger()
+			var queue EventQueue
+			if tt.queue != nil {
+				queue = tt.queue
+			}
+			eventsService := unavailableEventsService{newTestEventsService()}
+			handler := middleware.TenantContext(logger, true, nil)(StripeWebhookHandler(logger, eventsService, testWebhookSecret, 0, uuid.New(), 1024, EventAgePolicy{}, queue))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, newStripeWebhookRequest(payload, signature))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if !tt.expectQueued {
+				return
+			}
+			var response WebhookResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if !response.Received || !response.Queued {
+				t.Fatalf("expected received and queued, got %+v", response)
+			}
+			if len(tt.queue.queued) != 1 || tt.queue.queued[0] != "evt_queued" {
+				t.Fatalf("expected evt_queued to be queued, got %v", tt.queue.queued)
+			}
+		})
+	}
+}
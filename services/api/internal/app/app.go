@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/signal"
 	"rdl-api/config"
+	"rdl-api/internal/middleware"
 	"syscall"
 	"time"
 )
@@ -18,88 +19,247 @@ type AppServer struct {
 	server *http.Server
 }
 
+// SignalSource returns the channel on which shutdown signals are delivered.
+// It is injectable so tests can drive shutdown without sending real signals.
+type SignalSource func() <-chan os.Signal
+
+// notifySignals delivers SIGINT and SIGTERM from the operating system
+func notifySignals() <-chan os.Signal {
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	return quit
+}
+
 // Application lifecycle manager
 type Application struct {
 	container *Container
 	server    *AppServer
+	signals   SignalSource
 }
 
-// NewApplication creates a new Application instance with minimal dependencies properly initialized.
+// NewApplication creates a new Application instance with minimal dependencies properly
+// initialized: the logger, the connection pools and the health service. The other services and
+// the HTTP server are built by StartUp, once the database is ready.
 func NewApplication(ctx context.Context, cfg *config.Config) (*Application, error) {
 	container, err := NewContainer(ctx, cfg)
 	if err != nil {
 		return nil, err
 	}
-
-	appServer := setupAppServer(container)
 	return &Application{
 		container: container,
-		server:    appServer,
+		signals:   notifySignals,
 	}, nil
 }
 
-// StartUp initializes and starts the application.
-// It sets up all dependencies and starts the HTTP server.
+// StartUp runs the startup phases in order: database, warmup, services, background workers and
+// HTTP. A phase that fails keeps the later ones from starting and shuts down the earlier ones,
+// so for example the scheduler never runs against a database that is not ready. Once the
+// server is up it waits for a shutdown signal and drains.
 func (a *Application) StartUp(ctx context.Context) error {
 	l := a.container.GetLogger()
 	c := a.container
-	server := a.server.server
 	l.Info("Starting application")
 	l.Info(fmt.Sprintf("Environment: %s", c.GetEnvironment()))
 
-	// Verify database connectivity with a short timeout
-	if err := a.container.GetServices().HealthService.CheckReadiness(ctx); err != nil {
+	if err := a.runStartupPhases(ctx, a.startupPhases()); err != nil {
 		return err
 	}
-	l.Info("Database connection verified")
+	l.Info("Server is ready to accept requests on port " + a.server.server.Addr)
 
-	// Start the server
-	Start(l, server)
-	l.Info("Server is ready to accept requests on port " + server.Addr)
+	// Wait for a shutdown signal and drain with the timeout configured for it
+	reason, timeout, signals := a.awaitShutdown(ctx)
+	l.Info("Shutting down Application", "reason", reason, "timeout", timeout.String())
+	a.preShutdown(ctx, signals)
+	if err := a.shutdown(ctx, timeout); err != nil {
+		return err
+	}
+	l.Info("Application shut down gracefully")
+	return nil
+}
 
-	// Channel to listen for interrupt signals
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+// startWorker runs run in the background until shutdown. Its shutdown hook cancels run's
+// context and waits for it to return, so the pool is not closed underneath the work in
+// flight; name identifies the worker when it does not stop in time.
+func (a *Application) startWorker(ctx context.Context, name string, run func(context.Context)) {
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		run(runCtx)
+	}()
+
+	a.container.RegisterShutdownHook(func(hookCtx context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-hookCtx.Done():
+			return fmt.Errorf("%s did not stop: %w", name, hookCtx.Err())
+		}
+	})
+}
+
+// startEventPurger runs the event purge job every PurgeInterval, waiting on shutdown for the
+// batch in flight
+func (a *Application) startEventPurger(ctx context.Context) {
+	purger := a.container.GetServices().EventPurger
+	if purger == nil {
+		return
+	}
+	interval := a.container.GetConfig().Retention.PurgeInterval
+	a.startWorker(ctx, "event purge job", func(runCtx context.Context) { purger.Run(runCtx, interval) })
+}
+
+// startRateLimiter loads the tenants' rate limit overrides and reloads them every
+// RefreshInterval
+func (a *Application) startRateLimiter(ctx context.Context) {
+	limiter := a.container.GetServices().RateLimiter
+	if limiter == nil {
+		return
+	}
+	interval := a.container.GetConfig().RateLimit.RefreshInterval
+	a.startWorker(ctx, "rate limit reloads", func(runCtx context.Context) { limiter.Run(runCtx, interval) })
+}
+
+// startNotificationOutbox retries the failed notification deliveries every OutboxInterval. A
+// delivery cut short by shutdown is retried by the next instance once its lease passes.
+func (a *Application) startNotificationOutbox(ctx context.Context) {
+	outbox := a.container.GetServices().NotificationOutbox
+	if outbox == nil {
+		return
+	}
+	interval := a.container.GetConfig().Notifier.OutboxInterval
+	a.startWorker(ctx, "notification outbox", func(runCtx context.Context) { outbox.Run(runCtx, interval) })
+}
+
+// startActionExecutor runs every tenant's pending actions every ExecutorInterval, unless the
+// interval is 0. An action cut short by shutdown is claimed again once its lease passes.
+func (a *Application) startActionExecutor(ctx context.Context) {
+	actionExecutor := a.container.GetServices().ActionExecutor
+	interval := a.container.GetConfig().Actions.ExecutorInterval
+	if actionExecutor == nil || interval <= 0 {
+		return
+	}
+	a.startWorker(ctx, "action executor", func(runCtx context.Context) { actionExecutor.Run(runCtx, interval) })
+}
+
+// startIdempotencySweeper drops the expired keys of the in-memory idempotency store every
+// minute. The Postgres store deletes a tenant's expired keys as it saves and needs no sweeper.
+func (a *Application) startIdempotencySweeper(ctx context.Context) {
+	store, ok := a.container.GetServices().IdempotencyStore.(*middleware.MemoryIdempotencyStore)
+	if !ok {
+		return
+	}
+	a.startWorker(ctx, "idempotency key sweeper", store.Run)
+}
+
+// startIngestQueue retries the events queued during a database outage every FlushInterval.
+// Once the flusher has stopped, shutdown makes one last attempt to store what is still
+// queued; events it cannot store are lost, and the hook reports how many.
+func (a *Application) startIngestQueue(ctx context.Context) {
+	queue := a.container.GetServices().IngestQueue
+	if queue == nil {
+		return
+	}
+	interval := a.container.GetConfig().IngestQueue.FlushInterval
+
+	// Registered before the flusher's hook so it runs after the flusher has stopped
+	a.container.RegisterShutdownHook(func(hookCtx context.Context) error {
+		if queue.Len() == 0 {
+			return nil
+		}
+		_, err := queue.Flush(hookCtx)
+		if waiting := queue.Len(); waiting > 0 {
+			return fmt.Errorf("ingest queue: %d events not stored: %w", waiting, err)
+		}
+		return nil
+	})
+	a.startWorker(ctx, "ingest queue flusher", func(runCtx context.Context) { queue.Run(runCtx, interval) })
+}
+
+// startDetectionScheduler runs leak detection for every tenant every detection Interval, unless
+// the interval is 0. Shutdown stops starting tenants and waits for the ones in flight.
+func (a *Application) startDetectionScheduler(ctx context.Context) {
+	scheduler := a.container.GetServices().DetectionScheduler
+	interval := a.container.GetConfig().Detection.Interval
+	if scheduler == nil || interval <= 0 {
+		return
+	}
+	a.startWorker(ctx, "detection scheduler", func(runCtx context.Context) { scheduler.Run(runCtx, interval) })
+}
+
+// awaitShutdown blocks until a shutdown signal arrives or ctx is done, and returns what
+// triggered it together with the drain timeout to use. SIGINT gets the shorter, more
+// immediate drain; SIGTERM and context cancellation get the longer one. It also returns the
+// channel it waited on, so a second signal can be watched for during the pre-shutdown delay.
+func (a *Application) awaitShutdown(ctx context.Context) (string, time.Duration, <-chan os.Signal) {
+	shutdownConfig := a.container.GetConfig().Shutdown
+	signals := a.signals()
 
-	// Wait for interrupt signal
 	select {
-	case sig := <-quit:
-		l.Info("Shutting down Application", "signal", sig.String())
-		err := a.Shutdown(ctx)
-		if err != nil {
-			return err
+	case sig := <-signals:
+		if sig == syscall.SIGINT {
+			return sig.String(), shutdownConfig.SIGINTTimeout, signals
 		}
+		return sig.String(), shutdownConfig.SIGTERMTimeout, signals
 	case <-ctx.Done():
-		l.Info("Shutting down server", "reason", "context canceled")
-		err := a.Shutdown(ctx)
-		if err != nil {
-			return err
-		}
+		return "context canceled", shutdownConfig.SIGTERMTimeout, signals
 	}
-	l.Info("Application shut down gracefully")
-	return nil
 }
 
-// Shutdown gracefully shuts down the application.
-// It closes database connections and stops the server.
+// Shutdown gracefully shuts down the application using the SIGTERM drain timeout.
+// It fails readiness for PRE_SHUTDOWN_DELAY, stops the server and then runs the container's
+// shutdown hooks.
 func (a *Application) Shutdown(ctx context.Context) error {
+	a.preShutdown(ctx, nil)
+	return a.shutdown(ctx, a.container.GetConfig().Shutdown.SIGTERMTimeout)
+}
+
+// preShutdown makes /ready answer 503 and keeps serving for PreShutdownDelay, so the load
+// balancer deregisters the instance before the server stops accepting connections. The delay
+// ends early when ctx is done or a signal arrives on signals, which may be nil; an operator
+// sends a second signal to stop without waiting for it.
+func (a *Application) preShutdown(ctx context.Context, signals <-chan os.Signal) {
+	a.container.GetServices().HealthService.BeginShutdown()
+
+	delay := a.container.GetConfig().Shutdown.PreShutdownDelay
+	if delay <= 0 {
+		return
+	}
+	l := a.container.GetLogger()
+	l.InfoContext(ctx, "Readiness failing, waiting for the load balancer to deregister", "delay", delay.String())
+	select {
+	case <-time.After(delay):
+	case <-ctx.Done():
+		l.InfoContext(ctx, "Context done, cutting the pre-shutdown delay short")
+	case sig := <-signals:
+		l.InfoContext(ctx, "Second signal received, cutting the pre-shutdown delay short", "signal", sig.String())
+	}
+}
+
+// shutdown stops the server, giving in-flight requests time to finish, and then runs the
+// container's shutdown hooks (which close the database pool last). Both share the timeout.
+func (a *Application) shutdown(ctx context.Context, timeout time.Duration) error {
 	l := a.container.GetLogger()
 
 	var shutdownErrors []error
 
-	a.container.Shutdown(ctx)
-	l.Info("Database connection pool closed")
+	// Draining gets the full timeout even when ctx is already done
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
 
-	// Shutdown server with timeout
+	// Stop accepting requests first so in-flight ones can still reach the database
 	if a.server != nil && a.server.server != nil {
-		serverCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
-		defer cancel()
-
-		if err := a.server.server.Shutdown(serverCtx); err != nil {
+		if err := a.server.server.Shutdown(shutdownCtx); err != nil {
 			shutdownErrors = append(shutdownErrors, fmt.Errorf("server shutdown failed: %w", err))
 		}
 	}
 
+	if err := a.container.Shutdown(shutdownCtx); err != nil {
+		shutdownErrors = append(shutdownErrors, fmt.Errorf("shutdown hooks failed: %w", err))
+	}
+	l.Info("Shutdown hooks completed, database connection pool closed")
+
 	if len(shutdownErrors) > 0 {
 		return fmt.Errorf("shutdown errors: %v", shutdownErrors)
 	}
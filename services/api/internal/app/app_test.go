@@ -0,0 +1,400 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"rdl-api/handlers"
+	"rdl-api/internal/detection"
+
+	"rdl-api/config"
+)
+
+// signalSourceOf returns a SignalSource that delivers sig immediately
+func signalSourceOf(sig os.Signal) SignalSource {
+	return func() <-chan os.Signal {
+		ch := make(chan os.Signal, 1)
+		ch <- sig
+		return ch
+	}
+}
+
+func TestAwaitShutdown_SelectsTimeoutPerSignal(t *testing.T) {
+	shutdownConfig := config.ShutdownConfig{
+		SIGTERMTimeout: 25 * time.Second,
+		SIGINTTimeout:  2 * time.Second,
+	}
+
+	tests := []struct {
+		name            string
+		signal          os.Signal
+		expectedReason  string
+		expectedTimeout time.Duration
+	}{
+		{
+			name:            "SIGTERM drains longer",
+			signal:          syscall.SIGTERM,
+			expectedReason:  syscall.SIGTERM.String(),
+			expectedTimeout: shutdownConfig.SIGTERMTimeout,
+		},
+		{
+			name:            "SIGINT drains shorter",
+			signal:          syscall.SIGINT,
+			expectedReason:  syscall.SIGINT.String(),
+			expectedTimeout: shutdownConfig.SIGINTTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestContainer(config.HTTPConfig{})
+			c.config.Shutdown = shutdownConfig
+			a := &Application{container: c, signals: signalSourceOf(tt.signal)}
+
+			reason, timeout, _ := a.awaitShutdown(context.Background())
+
+			if reason != tt.expectedReason {
+				t.Errorf("expected reason %q, got %q", tt.expectedReason, reason)
+			}
+			if timeout != tt.expectedTimeout {
+				t.Errorf("expected timeout %s, got %s", tt.expectedTimeout, timeout)
+			}
+		})
+	}
+}
+
+func TestAwaitShutdown_ContextCanceledUsesSIGTERMTimeout(t *testing.T) {
+	c := newTestContainer(config.HTTPConfig{})
+	c.config.Shutdown = config.ShutdownConfig{SIGTERMTimeout: 25 * time.Second, SIGINTTimeout: 2 * time.Second}
+	a := &Application{
+		container: c,
+		signals:   func() <-chan os.Signal { return make(chan os.Signal) },
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	reason, timeout, _ := a.awaitShutdown(ctx)
+
+	if reason != "context canceled" {
+		t.Errorf("expected reason %q, got %q", "context canceled", reason)
+	}
+	if timeout != 25*time.Second {
+		t.Errorf("expected timeout %s, got %s", 25*time.Second, timeout)
+	}
+}
+
+// unreadyHealthService reports the database as unreachable
+type unreadyHealthService struct {
+	testHealthService
+}
+
+var errDatabaseDown = errors.New("database is down")
+
+func (unreadyHealthService) CheckReadiness(context.Context) error { return errDatabaseDown }
+
+// recordingScheduler records whether it was ever run
+type recordingScheduler struct {
+	ran chan struct{}
+}
+
+func (s *recordingScheduler) RunOnce(context.Context) (detection.SchedulerRun, error) {
+	return detection.SchedulerRun{}, nil
+}
+
+func (s *recordingScheduler) Run(ctx context.Context, _ time.Duration) {
+	close(s.ran)
+	<-ctx.Done()
+}
+
+// unusedAddr returns a local address nothing listens on
+func unusedAddr(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	return addr
+}
+
+func TestStartUp_DatabaseFailureStopsLaterPhases(t *testing.T) {
+	c := newTestContainer(config.HTTPConfig{})
+	c.config.Detection.Interval = time.Millisecond
+	c.config.Shutdown.SIGTERMTimeout = time.Second
+	scheduler := &recordingScheduler{ran: make(chan struct{})}
+	c.services.HealthService = unreadyHealthService{}
+	c.services.DetectionScheduler = scheduler
+
+	cleanedUp := false
+	c.RegisterShutdownHook(func(context.Context) error {
+		cleanedUp = true
+		return nil
+	})
+
+	addr := unusedAddr(t)
+	a := &Application{
+		container: c,
+		server:    &AppServer{server: &http.Server{Addr: addr}},
+		signals:   func() <-chan os.Signal { return make(chan os.Signal) },
+	}
+
+	err := a.StartUp(context.Background())
+
+	if !errors.Is(err, ErrStartupPhase) || !errors.Is(err, errDatabaseDown) {
+		t.Fatalf("expected the database phase error, got %v", err)
+	}
+	if !cleanedUp {
+		t.Error("expected the shutdown hooks to run after the failed phase")
+	}
+	select {
+	case <-scheduler.ran:
+		t.Error("expected the detection scheduler not to start")
+	case <-time.After(20 * time.Millisecond):
+	}
+	if conn, err := net.Dial("tcp", addr); err == nil {
+		conn.Close()
+		t.Error("expected the HTTP server not to be listening")
+	}
+}
+
+func TestStartupPhases_BuildServicesOnceTheDatabaseIsReady(t *testing.T) {
+	a := &Application{container: newTestContainer(config.HTTPConfig{})}
+
+	var names []string
+	for _, phase := range a.startupPhases() {
+		names = append(names, phase.name)
+	}
+
+	if want := []string{PhaseDatabase, PhaseWarmup, PhaseServices, PhaseBackgroundWorkers, PhaseHTTP}; !slices.Equal(names, want) {
+		t.Errorf("expected phases %v, got %v", want, names)
+	}
+}
+
+func TestRunStartupPhases_RunsInOrderUntilFailure(t *testing.T) {
+	c := newTestContainer(config.HTTPConfig{})
+	a := &Application{container: c}
+	errWarmup := errors.New("warmup failed")
+
+	var ran, stopped []string
+	phase := func(name string, err error) startupPhase {
+		return startupPhase{name: name, run: func(context.Context) error {
+			ran = append(ran, name)
+			if err == nil {
+				c.RegisterShutdownHook(func(context.Context) error {
+					stopped = append(stopped, name)
+					return nil
+				})
+			}
+			return err
+		}}
+	}
+
+	err := a.runStartupPhases(context.Background(), []startupPhase{
+		phase(PhaseDatabase, nil),
+		phase(PhaseWarmup, errWarmup),
+		phase(PhaseServices, nil),
+		phase(PhaseBackgroundWorkers, nil),
+		phase(PhaseHTTP, nil),
+	})
+
+	if !errors.Is(err, ErrStartupPhase) || !errors.Is(err, errWarmup) {
+		t.Fatalf("expected the warmup error, got %v", err)
+	}
+	if want := []string{PhaseDatabase, PhaseWarmup}; !slices.Equal(ran, want) {
+		t.Errorf("expected phases %v to run, got %v", want, ran)
+	}
+	if want := []string{PhaseDatabase}; !slices.Equal(stopped, want) {
+		t.Errorf("expected the started phases %v to be stopped, got %v", want, stopped)
+	}
+}
+
+func TestStartWorker_ShutdownStopsTheWorker(t *testing.T) {
+	c := newTestContainer(config.HTTPConfig{})
+	a := &Application{container: c}
+
+	var stopped atomic.Bool
+	a.startWorker(context.Background(), "test worker", func(ctx context.Context) {
+		<-ctx.Done()
+		stopped.Store(true)
+	})
+
+	if err := c.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if !stopped.Load() {
+		t.Error("expected shutdown to wait for the worker to return")
+	}
+}
+
+func TestStartWorker_ReportsAWorkerThatDoesNotStop(t *testing.T) {
+	c := newTestContainer(config.HTTPConfig{})
+	a := &Application{container: c}
+
+	release := make(chan struct{})
+	defer close(release)
+	a.startWorker(context.Background(), "test worker", func(context.Context) { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := c.Shutdown(ctx)
+
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "test worker did not stop") {
+		t.Fatalf("expected the worker to be named in a deadline error, got %v", err)
+	}
+}
+
+// drainingHealthService is healthy and ready until BeginShutdown is called
+type drainingHealthService struct {
+	testHealthService
+	shuttingDown *atomic.Bool
+}
+
+func (s drainingHealthService) BeginShutdown()     { s.shuttingDown.Store(true) }
+func (s drainingHealthService) ShuttingDown() bool { return s.shuttingDown.Load() }
+
+func TestShutdown_FailsReadinessBeforeClosingListener(t *testing.T) {
+	const delay = 300 * time.Millisecond
+	c := newTestContainer(config.HTTPConfig{})
+	c.config.Shutdown = config.ShutdownConfig{SIGTERMTimeout: time.Second, PreShutdownDelay: delay}
+	health := drainingHealthService{shuttingDown: &atomic.Bool{}}
+	c.services.HealthService = health
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ready", handlers.ReadyHandler(c.GetLogger(), health, true))
+	addr := unusedAddr(t)
+	server := &http.Server{Addr: addr, Handler: mux}
+	if err := Start(c.GetLogger(), server); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	a := &Application{container: c, server: &AppServer{server: server}}
+
+	// Every probe opens a new connection, so an error means the listener is closed
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}, Timeout: time.Second}
+	ready := func() (int, error) {
+		resp, err := client.Get("http://" + addr + "/ready")
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+	if code, err := ready(); err != nil || code != http.StatusOK {
+		t.Fatalf("expected ready before shutdown, got %d, %v", code, err)
+	}
+
+	started := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- a.Shutdown(context.Background()) }()
+
+	for {
+		code, err := ready()
+		if err != nil {
+			t.Fatalf("expected connections to be accepted while readiness fails, got %v", err)
+		}
+		if code == http.StatusServiceUnavailable {
+			break
+		}
+		if time.Since(started) > delay {
+			t.Fatal("expected readiness to fail as soon as shutdown began")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case <-done:
+		t.Fatal("expected the server to keep serving for the pre-shutdown delay")
+	default:
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed < delay {
+		t.Errorf("expected shutdown to wait out the %s delay, took %s", delay, elapsed)
+	}
+	if _, err := ready(); err == nil {
+		t.Error("expected connections to be refused after shutdown")
+	}
+}
+
+func TestShutdown_DrainsWhenContextIsDone(t *testing.T) {
+	const delay = 100 * time.Millisecond
+	c := newTestContainer(config.HTTPConfig{})
+	c.config.Shutdown = config.ShutdownConfig{SIGTERMTimeout: time.Second, PreShutdownDelay: delay}
+	c.services.HealthService = drainingHealthService{shuttingDown: &atomic.Bool{}}
+
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) { <-release })
+	addr := unusedAddr(t)
+	server := &http.Server{Addr: addr, Handler: mux}
+	if err := Start(c.GetLogger(), server); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	a := &Application{container: c, server: &AppServer{server: server}}
+
+	// A request in flight when shutdown begins must be allowed to finish
+	inFlight := make(chan error, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/slow")
+		if err == nil {
+			resp.Body.Close()
+		}
+		inFlight <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	started := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- a.Shutdown(ctx) }()
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("expected shutdown to drain despite the done context, got %v", err)
+	}
+	// The done context cuts the pre-shutdown delay short, but not the drain
+	if elapsed := time.Since(started); elapsed >= delay {
+		t.Errorf("expected the done context to skip the %s delay, took %s", delay, elapsed)
+	}
+	if err := <-inFlight; err != nil {
+		t.Errorf("expected the in-flight request to finish, got %v", err)
+	}
+}
+
+func TestPreShutdown_SecondSignalCutsTheDelayShort(t *testing.T) {
+	c := newTestContainer(config.HTTPConfig{})
+	c.config.Shutdown = config.ShutdownConfig{SIGTERMTimeout: time.Second, PreShutdownDelay: time.Minute}
+	health := drainingHealthService{shuttingDown: &atomic.Bool{}}
+	c.services.HealthService = health
+	a := &Application{container: c}
+
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		a.preShutdown(context.Background(), signals)
+	}()
+
+	signals <- syscall.SIGTERM
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected a second signal to end the pre-shutdown delay")
+	}
+	if !health.shuttingDown.Load() {
+		t.Error("expected readiness to fail during the pre-shutdown delay")
+	}
+}
@@ -4,8 +4,13 @@ import "errors"
 
 var (
 	ErrFaildToCreateConnectionPool = errors.New("failed to create connection pool")
+	ErrInvalidDatabaseConfig       = errors.New("invalid database configuration")
 	ErrDatabaseNotInitialized      = errors.New("database not initialized")
 	ErrServerNotInitialized        = errors.New("server not initialized")
 	ErrDatabaseConnection          = errors.New("database connection failed")
 	ErrServerStartup               = errors.New("server startup failed")
+	ErrStartupPhase                = errors.New("startup phase failed")
+	ErrDuplicateRoute              = errors.New("duplicate route registration")
+	ErrMigrationsPending           = errors.New("database migrations are pending")
+	ErrTLSCertificate              = errors.New("failed to load TLS certificate")
 )
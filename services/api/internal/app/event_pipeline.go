@@ -0,0 +1,64 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"rdl-api/internal/db/repository"
+	"rdl-api/internal/detection"
+	"rdl-api/internal/domain/services"
+	"rdl-api/internal/notifier"
+	"strings"
+)
+
+// newDetectEventStage returns the detect stage of the event pipeline, which runs leak
+// detection for the event's tenant and hands the leaks it stored to the notify stage.
+// detector should have no notifier of its own, or its leaks are announced twice. When another
+// run is already detecting the tenant's leaks the stage does nothing.
+//
+// Detection waits until the request transaction that stores the event has committed, so it
+// sees the event and a failing rule cannot abort the transaction and lose it. Its errors are
+// logged rather than returned.
+func newDetectEventStage(detector *detection.Detector, logger *slog.Logger) services.EventStage {
+	return services.NewEventStage(services.EventStageDetect, false, func(ctx context.Context, state *services.EventPipelineState) error {
+		repository.AfterCommit(ctx, func(ctx context.Context) {
+			report, err := detector.DetectLeaks(ctx, state.TenantID, false)
+			if errors.Is(err, detection.ErrDetectionInProgress) {
+				// The run in progress sees this event too, or the next one will
+				return
+			}
+			state.Leaks = append(state.Leaks, report.Created...)
+			if err != nil {
+				logger.WarnContext(ctx, "Event pipeline stage failed", "error", err, "stage", services.EventStageDetect, "tenant_id", state.TenantID, "event_id", state.Event.ID)
+			}
+		})
+		return nil
+	})
+}
+
+// newNotifyEventStage returns the notify stage of the event pipeline, which sends one
+// notification listing the leaks the detect stage stored, if there are any. Like detection it
+// waits until the request transaction has committed, and logs its errors.
+func newNotifyEventStage(notify notifier.Notifier, logger *slog.Logger) services.EventStage {
+	return services.NewEventStage(services.EventStageNotify, false, func(ctx context.Context, state *services.EventPipelineState) error {
+		repository.AfterCommit(ctx, func(ctx context.Context) {
+			if len(state.Leaks) == 0 {
+				return
+			}
+			var body strings.Builder
+			for _, leak := range state.Leaks {
+				fmt.Fprintf(&body, "- %s: %s %s\n", leak.LeakType, leak.Amount, leak.Currency)
+			}
+			err := notify.Notify(ctx, notifier.Notification{
+				TenantID: state.TenantID,
+				Title:    fmt.Sprintf("%d new revenue leak(s) detected", len(state.Leaks)),
+				Body:     strings.TrimSuffix(body.String(), "\n"),
+			})
+			if err != nil {
+				logger.WarnContext(ctx, "Event pipeline stage failed", "error", err, "stage", services.EventStageNotify, "tenant_id", state.TenantID, "event_id", state.Event.ID)
+			}
+		})
+		return nil
+	})
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"rdl-api/internal/db/repository"
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/domain/services"
+	"rdl-api/internal/notifier"
+)
+
+// recordingNotifier keeps the notifications it is asked to send
+type recordingNotifier struct {
+	sent []notifier.Notification
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, notification notifier.Notification) error {
+	n.sent = append(n.sent, notification)
+	return nil
+}
+
+func TestNotifyEventStage(t *testing.T) {
+	ctx := context.Background()
+	notify := &recordingNotifier{}
+	stage := newNotifyEventStage(notify, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if stage.Name() != services.EventStageNotify || stage.Critical() {
+		t.Fatalf("expected a non-critical %s stage, got %s critical=%v", services.EventStageNotify, stage.Name(), stage.Critical())
+	}
+
+	if err := stage.Run(ctx, &services.EventPipelineState{TenantID: uuid.New()}); err != nil {
+		t.Fatalf("expected no error without leaks, got %v", err)
+	}
+	if len(notify.sent) != 0 {
+		t.Fatalf("expected no notification without leaks, got %+v", notify.sent)
+	}
+
+	tenantID := uuid.New()
+	err := stage.Run(ctx, &services.EventPipelineState{TenantID: tenantID, Leaks: []models.Leak{
+		{LeakType: models.LeakTypeEnumDuplicateCharge, Amount: models.MustParseDecimal("25.00"), Currency: "USD"},
+		{LeakType: models.LeakTypeEnumDunningGap, Amount: models.MustParseDecimal("9.99"), Currency: "EUR"},
+	}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(notify.sent) != 1 {
+		t.Fatalf("expected one notification, got %d", len(notify.sent))
+	}
+	sent := notify.sent[0]
+	if sent.TenantID != tenantID || sent.Title != "2 new revenue leak(s) detected" {
+		t.Errorf("unexpected notification %+v", sent)
+	}
+	if want := "- duplicate_charge: 25.00 USD\n- dunning_gap: 9.99 EUR"; sent.Body != want {
+		t.Errorf("expected body %q, got %q", want, sent.Body)
+	}
+}
+
+func TestNotifyEventStage_WaitsForCommit(t *testing.T) {
+	notify := &recordingNotifier{}
+	stage := newNotifyEventStage(notify, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	tenantID := uuid.New()
+	// A nil transaction stands in for the request transaction; the stage never touches it
+	txCtx := repository.ContextWithTx(context.Background(), tenantID, nil)
+
+	err := stage.Run(txCtx, &services.EventPipelineState{TenantID: tenantID, Leaks: []models.Leak{
+		{LeakType: models.LeakTypeEnumDuplicateCharge, Amount: models.MustParseDecimal("25.00"), Currency: "USD"},
+	}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(notify.sent) != 0 {
+		t.Fatalf("expected no notification before the transaction commits, got %d", len(notify.sent))
+	}
+
+	repository.RunAfterCommit(txCtx, context.Background())
+	if len(notify.sent) != 1 {
+		t.Fatalf("expected one notification after the commit, got %d", len(notify.sent))
+	}
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// undefinedTableCode is the PostgreSQL error code for a missing table
+const undefinedTableCode = "42P01"
+
+// MigrationStatus reports the migration state recorded in the database
+type MigrationStatus interface {
+	// CurrentMigration returns the last applied migration version, 0 when none has been
+	// applied, and whether that migration failed part way
+	CurrentMigration(ctx context.Context) (version uint, dirty bool, err error)
+}
+
+// poolMigrationStatus reads the schema_migrations table maintained by golang-migrate
+type poolMigrationStatus struct {
+	pool *pgxpool.Pool
+}
+
+func (s poolMigrationStatus) CurrentMigration(ctx context.Context) (uint, bool, error) {
+	var version int64
+	var dirty bool
+	err := s.pool.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.Is(err, pgx.ErrNoRows) || (errors.As(err, &pgErr) && pgErr.Code == undefinedTableCode) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	return uint(version), dirty, nil
+}
+
+// checkMigrations compares the database's migration state with the migrations shipped in the
+// binary. Pending or dirty migrations fail with ErrMigrationsPending when require is set, so
+// a half-migrated deploy never serves; otherwise they are logged as a warning.
+func checkMigrations(ctx context.Context, logger *slog.Logger, status MigrationStatus, shipped []uint, require bool) error {
+	current, dirty, err := status.CurrentMigration(ctx)
+	if err != nil {
+		err = fmt.Errorf("%w: failed to read migration status: %v", ErrMigrationsPending, err)
+		if require {
+			return err
+		}
+		logger.WarnContext(ctx, "Could not verify database migrations", "error", err)
+		return nil
+	}
+
+	var pending []uint
+	for _, version := range shipped {
+		if version > current {
+			pending = append(pending, version)
+		}
+	}
+	if len(pending) == 0 && !dirty {
+		logger.InfoContext(ctx, "Database migrations are up to date", "version", current)
+		return nil
+	}
+
+	if require {
+		return fmt.Errorf("%w: database is at version %d (dirty: %t), pending: %v", ErrMigrationsPending, current, dirty, pending)
+	}
+	logger.WarnContext(ctx, "Database migrations are pending", "version", current, "dirty", dirty, "pending", pending)
+	return nil
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"rdl-api/migrations"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeMigrationStatus reports a fixed migration state
+type fakeMigrationStatus struct {
+	version uint
+	dirty   bool
+	err     error
+}
+
+func (s fakeMigrationStatus) CurrentMigration(context.Context) (uint, bool, error) {
+	return s.version, s.dirty, s.err
+}
+
+func TestCheckMigrations(t *testing.T) {
+	shipped := []uint{1, 2, 3}
+
+	tests := []struct {
+		name     string
+		status   fakeMigrationStatus
+		require  bool
+		wantErr  bool
+		wantWarn bool
+	}{
+		{name: "up to date", status: fakeMigrationStatus{version: 3}, require: true},
+		{name: "pending and required", status: fakeMigrationStatus{version: 2}, require: true, wantErr: true},
+		{name: "pending and not required", status: fakeMigrationStatus{version: 2}, wantWarn: true},
+		{name: "nothing applied and required", status: fakeMigrationStatus{}, require: true, wantErr: true},
+		{name: "dirty and required", status: fakeMigrationStatus{version: 3, dirty: true}, require: true, wantErr: true},
+		{name: "dirty and not required", status: fakeMigrationStatus{version: 3, dirty: true}, wantWarn: true},
+		{name: "status unreadable and required", status: fakeMigrationStatus{err: errors.New("connection reset")}, require: true, wantErr: true},
+		{name: "status unreadable and not required", status: fakeMigrationStatus{err: errors.New("connection reset")}, wantWarn: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+			err := checkMigrations(context.Background(), logger, tt.status, shipped, tt.require)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil && !errors.Is(err, ErrMigrationsPending) {
+				t.Errorf("expected ErrMigrationsPending, got %v", err)
+			}
+			if warned := strings.Contains(buf.String(), "level=WARN"); warned != tt.wantWarn {
+				t.Errorf("expected warning %v, got log %q", tt.wantWarn, buf.String())
+			}
+		})
+	}
+}
+
+func TestMigrationVersions(t *testing.T) {
+	versions, err := migrations.Versions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) == 0 || versions[0] != 1 {
+		t.Fatalf("expected the embedded migrations to start at version 1, got %v", versions)
+	}
+	if !slices.IsSorted(versions) || len(slices.Compact(slices.Clone(versions))) != len(versions) {
+		t.Errorf("expected unique ascending versions, got %v", versions)
+	}
+}
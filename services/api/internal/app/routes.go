@@ -0,0 +1,106 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"rdl-api/internal/middleware"
+	"regexp"
+	"strings"
+)
+
+// wildcardPattern matches a path wildcard such as {id} or {rest...}
+var wildcardPattern = regexp.MustCompile(`\{[^}]*?(\.\.\.)?\}`)
+
+// routeRegistrar registers routes on a ServeMux while recording conflicts as errors.
+// ServeMux panics on a duplicate pattern; collecting the conflicts instead lets startup fail
+// with a message naming both registrations.
+//
+// It also records the authentication each route requires, which the auth middlewares read
+// through AuthFor. Routes registered with Handle need a tenant; public and admin routes are
+// registered through WithAuth.
+type routeRegistrar struct {
+	mux    *http.ServeMux
+	routes map[string]string               // normalized route -> pattern as first registered
+	auth   map[string]middleware.AuthLevel // pattern -> required authentication
+	errs   []error
+}
+
+func newRouteRegistrar(mux *http.ServeMux) *routeRegistrar {
+	return &routeRegistrar{mux: mux, routes: make(map[string]string), auth: make(map[string]middleware.AuthLevel)}
+}
+
+// routeGroup registers routes that share an authentication level
+type routeGroup struct {
+	rr    *routeRegistrar
+	level middleware.AuthLevel
+}
+
+// WithAuth returns a view of the registrar whose routes require level
+func (rr *routeRegistrar) WithAuth(level middleware.AuthLevel) routeGroup {
+	return routeGroup{rr: rr, level: level}
+}
+
+// Handle registers handler for pattern with the group's authentication level
+func (g routeGroup) Handle(pattern string, handler http.Handler) {
+	g.rr.handle(pattern, handler, g.level)
+}
+
+// HandleFunc registers handler for pattern with the group's authentication level
+func (g routeGroup) HandleFunc(pattern string, handler http.HandlerFunc) {
+	g.rr.handle(pattern, handler, g.level)
+}
+
+// Handle registers handler for pattern as a tenant route unless it conflicts with an earlier registration
+func (rr *routeRegistrar) Handle(pattern string, handler http.Handler) {
+	rr.handle(pattern, handler, middleware.AuthTenant)
+}
+
+// handle registers handler for pattern, requiring level, unless it conflicts with an earlier registration
+func (rr *routeRegistrar) handle(pattern string, handler http.Handler, level middleware.AuthLevel) {
+	key := routeKey(pattern)
+	if first, ok := rr.routes[key]; ok {
+		rr.errs = append(rr.errs, fmt.Errorf("%w: %q conflicts with %q", ErrDuplicateRoute, pattern, first))
+		return
+	}
+	rr.routes[key] = pattern
+	rr.auth[pattern] = level
+
+	// Any other conflict ServeMux detects is reported the same way
+	defer func() {
+		if r := recover(); r != nil {
+			rr.errs = append(rr.errs, fmt.Errorf("%w: %q: %v", ErrDuplicateRoute, pattern, r))
+		}
+	}()
+	rr.mux.Handle(pattern, handler)
+}
+
+// HandleFunc registers handler for pattern unless it conflicts with an earlier registration
+func (rr *routeRegistrar) HandleFunc(pattern string, handler http.HandlerFunc) {
+	rr.Handle(pattern, handler)
+}
+
+// AuthFor returns the authentication required by the route that serves r. Requests no route
+// matches, including a known path with the wrong method, need a tenant like any other route.
+func (rr *routeRegistrar) AuthFor(r *http.Request) middleware.AuthLevel {
+	_, pattern := rr.mux.Handler(r)
+	if level, ok := rr.auth[pattern]; ok {
+		return level
+	}
+	return middleware.AuthTenant
+}
+
+// Err returns every conflict found so far, or nil
+func (rr *routeRegistrar) Err() error {
+	return errors.Join(rr.errs...)
+}
+
+// routeKey normalizes a ServeMux pattern to method and path, with wildcard names dropped,
+// so that "GET /events/{id}" and "GET  /events/{eventID}" compare equal
+func routeKey(pattern string) string {
+	method, path := "", strings.TrimSpace(pattern)
+	if i := strings.IndexAny(path, " \t"); i >= 0 {
+		method, path = path[:i], strings.TrimSpace(path[i+1:])
+	}
+	return method + " " + wildcardPattern.ReplaceAllString(path, "{$1}")
+}
@@ -3,17 +3,40 @@
 package app
 
 import (
+	"context"
+	"crypto/tls"
+	"expvar"
+	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"rdl-api/config"
 	"rdl-api/handlers"
+	"rdl-api/internal/db/repository"
+	"rdl-api/internal/domain/models"
 	"rdl-api/internal/middleware"
 	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
-func setupAppServer(c *Container) *AppServer {
+// MetricsPath serves the process metrics published through expvar. It is an admin route.
+const MetricsPath = "/debug/vars"
+
+// LegacyHealthPath is the alternative health check path older probes still call, left out of
+// the request log alongside the configured health paths
+const LegacyHealthPath = "/health"
+
+func setupAppServer(c *Container) (*AppServer, error) {
 	mux := http.NewServeMux()
-	handler := SetupRoutes(mux, c)
+	handler, err := SetupRoutes(mux, c)
+	if err != nil {
+		return nil, err
+	}
 
 	httpConfig := c.GetConfig().HTTP
 
@@ -25,48 +48,233 @@ func setupAppServer(c *Container) *AppServer {
 		WriteTimeout:      httpConfig.WriteTimeout * time.Second,
 		IdleTimeout:       httpConfig.IdleTimeout * time.Second,
 	}
+	if httpConfig.TLSEnabled() {
+		server.TLSConfig, err = newTLSConfig(httpConfig.TLSCertFile, httpConfig.TLSKeyFile)
+		if err != nil {
+			return nil, err
+		}
+	} else if httpConfig.HTTP2Cleartext {
+		// h2c is for a trusted proxy in front of the server, see config/README.md
+		server.Handler = h2c.NewHandler(handler, &http2.Server{IdleTimeout: server.IdleTimeout})
+	}
 
 	return &AppServer{
 		server: server,
-	}
+	}, nil
 }
 
-func SetupRoutes(mux *http.ServeMux, c *Container) http.Handler {
-	// Define paths that should be excluded from tenant context validation
-	// These are typically health check endpoints that don't require authentication
-	excludedPaths := []string{
-		"/healthz", // Kubernetes health check
-		"/health",  // Alternative health check
-		"/live",    // Liveness probe
-		"/ready",   // Readiness probe
+// SetupRoutes registers every route on mux and returns it wrapped in the middleware chain.
+// Conflicting registrations are returned as ErrDuplicateRoute rather than panicking.
+func SetupRoutes(mux *http.ServeMux, c *Container) (http.Handler, error) {
+	httpConfig := c.GetConfig().HTTP
+
+	// Successful probe and metrics requests are left out of the request log unless configured otherwise
+	logExcludedPaths := c.GetConfig().Environment.LogExcludePaths
+	if len(logExcludedPaths) == 0 {
+		logExcludedPaths = []string{httpConfig.HealthPath, LegacyHealthPath, httpConfig.LivePath, httpConfig.ReadyPath, MetricsPath}
 	}
 
 	logger := c.GetLogger()
 	services := c.GetServices()
 
-	// Register routes
-	mux.HandleFunc("/live", handlers.LiveHandler(logger, services.HealthService))
-	mux.HandleFunc("/ready", handlers.ReadyHandler(logger, services.HealthService))
+	// The formats are validated at config load; a bad value here means config was built by hand
+	responseOpts := handlers.ResponseOptions{
+		TimeFormat:   handlers.TimeFormat(httpConfig.TimeFormat),
+		ListFormat:   handlers.ListFormat(httpConfig.ListFormat),
+		MaxListBytes: httpConfig.ResponseMaxBytes,
+		ErrorDetails: c.GetConfig().ShowErrorDetails(),
+	}
+	if err := responseOpts.TimeFormat.Validate(); err != nil {
+		logger.Warn("Falling back to default API time format", "error", err)
+		responseOpts.TimeFormat = handlers.TimeFormatRFC3339
+	}
+	if err := responseOpts.ListFormat.Validate(); err != nil {
+		logger.Warn("Falling back to flat list responses", "error", err)
+		responseOpts.ListFormat = handlers.ListFormatFlat
+	}
+	if c.GetConfig().ShowErrorDetails() {
+		logger.Warn("500 responses include error details; never enable DEV_ERROR_DETAILS outside development")
+	} else if c.GetConfig().Environment.DevErrorDetails {
+		logger.Warn("DEV_ERROR_DETAILS is ignored outside development", "environment", c.GetEnvironment())
+	}
+
+	// staleCache lets the aggregate read endpoints answer with their last result while the database fails
+	staleCache := handlers.NewStaleCache(httpConfig.StaleCacheTTL)
+
+	// withTx runs a handler in a single tenant transaction, for handlers that make several writes
+	beginTx := func(ctx context.Context, tenantID uuid.UUID) (pgx.Tx, func(), error) {
+		return repository.BeginTenantTx(ctx, c.GetPool(), tenantID)
+	}
+	// middlewareErrors answers the requests a middleware rejects or fails with the JSON error envelope
+	middlewareErrors := handlers.ErrorResponder(logger, responseOpts)
+	withTx := middleware.Transaction(logger, beginTx, middlewareErrors)
+
+	// Register routes. Routes need a tenant unless registered through public or an admin group.
+	routes := newRouteRegistrar(mux)
+	public := routes.WithAuth(middleware.AuthPublic)
+	public.HandleFunc(httpConfig.HealthPath, handlers.HealthHandler(logger, services.HealthService, services.LeakDetector))
+	public.HandleFunc(httpConfig.LivePath, handlers.LiveHandler(logger, services.HealthService))
+	public.HandleFunc(httpConfig.ReadyPath, handlers.ReadyHandler(logger, services.HealthService, c.GetConfig().Health.ReadyWhenDegraded))
+	// {$} limits the pattern to the root itself, so unknown paths still get the JSON 404
+	public.HandleFunc("GET "+handlers.RootPath+"{$}", handlers.RootHandler(logger, handlers.RootOptions{
+		Service:    handlers.ServiceName,
+		Version:    c.GetConfig().BuildInfo.GIT_TAG,
+		HealthPath: httpConfig.HealthPath,
+	}))
+	public.HandleFunc("GET "+handlers.OpenAPIPath, handlers.OpenAPIHandler(logger, handlers.NewOpenAPISpec(handlers.OpenAPIOptions{
+		Version:       c.GetConfig().BuildInfo.GIT_TAG,
+		HealthPath:    httpConfig.HealthPath,
+		LivePath:      httpConfig.LivePath,
+		ReadyPath:     httpConfig.ReadyPath,
+		StripeWebhook: c.GetConfig().Stripe.WebhookSecret != "",
+		Response:      responseOpts,
+	}), httpConfig.StaticCacheMaxAge))
+	public.HandleFunc("GET "+handlers.VersionPath, handlers.VersionHandler(&c.GetConfig().BuildInfo, logger, httpConfig.StaticCacheMaxAge))
+	routes.HandleFunc("GET /events", handlers.ListEventsHandler(logger, services.EventsService))
+	routes.HandleFunc("GET /events/search", handlers.SearchEventsHandler(logger, services.EventsService))
+	routes.HandleFunc("GET /events/recent", handlers.RecentEventsHandler(logger, services.EventsService))
+	routes.HandleFunc("GET /events/ingestion-lag", handlers.IngestionLagHandler(logger, services.EventsService))
+	routes.HandleFunc("GET /events/export", handlers.ExportEventsHandler(logger, services.EventsService, c.GetConfig().Export.MaxRows))
+	// Ingestion routes accept provider payloads as sent, unknown fields included; every other
+	// route decodes its body strictly
+	routes.Handle("POST /events/batch", handlers.WithJSONMode(handlers.JSONLenient, handlers.CreateEventsBatchHandler(logger, services.EventsService, models.BatchPolicy{
+		MaxSize: c.GetConfig().Batch.MaxSize,
+		Chunk:   c.GetConfig().Batch.OversizeAction == config.BatchOversizeChunk,
+	}, httpConfig.MaxRequestBytes)))
+	routes.HandleFunc("POST /events/reprocess-failed", handlers.ReprocessFailedEventsHandler(logger, services.EventsService, services.LeakDetector))
+	routes.HandleFunc("PATCH /events/{id}", handlers.UpdateEventHandler(logger, services.EventsService))
+	routes.HandleFunc("DELETE /events/{id}", handlers.DeleteEventHandler(logger, services.EventsService))
+	routes.HandleFunc("GET /events/{id}/history", handlers.EventStatusHistoryHandler(logger, services.EventsService))
+	routes.HandleFunc("GET /events/{id}/related", handlers.RelatedEventsHandler(logger, services.EventsService, models.CorrelationRule{
+		Keys:   c.GetConfig().Correlation.Keys,
+		Window: c.GetConfig().Correlation.Window,
+	}))
+	routes.HandleFunc("GET /providers", handlers.ListProvidersHandler(logger, services.ProvidersService))
+	routes.HandleFunc("GET /providers/{id}/event-stats", handlers.ProviderEventStatsHandler(logger, services.EventsService, staleCache))
+	routes.HandleFunc("GET /notification-channels", handlers.ListNotificationChannelsHandler(logger, services.NotificationChannelsService))
+	routes.HandleFunc("GET /leaks", handlers.ListLeaksHandler(logger, services.LeaksService))
+	routes.HandleFunc("GET /leaks/export", handlers.ExportLeaksHandler(logger, services.LeaksService))
+	routes.HandleFunc("GET /leaks/amount-histogram", handlers.LeakAmountHistogramHandler(logger, services.LeaksService))
+	routes.HandleFunc("GET /leaks/timeseries", handlers.LeakTimeSeriesHandler(logger, services.LeaksService))
+	routes.HandleFunc("GET /leaks/{id}", handlers.GetLeakHandler(logger, services.LeaksService, services.EventsService, services.ActionsService))
+	routes.HandleFunc("POST /leaks/{id}/snooze", handlers.SnoozeLeakHandler(logger, services.LeaksService))
+	routes.HandleFunc("GET /actions", handlers.ListActionsHandler(logger, services.ActionsService))
+	routes.HandleFunc("GET /usage", handlers.UsageHandler(logger, services.EventsService, services.LeaksService, httpConfig.AdminAPIKeys, staleCache))
+	routes.HandleFunc("POST /detect", handlers.DetectLeaksHandler(logger, services.LeakDetector))
+
+	admin := routes.WithAuth(middleware.AuthAdmin)
+	admin.Handle("GET "+MetricsPath, expvar.Handler())
+	admin.HandleFunc("POST /admin/providers", handlers.CreateProviderHandler(logger, services.ProvidersService))
+	admin.HandleFunc("GET /admin/events/validate-stored", handlers.ValidateStoredEventsHandler(logger, services.EventsService))
+	admin.HandleFunc("POST /admin/events/reattribute", handlers.ReattributeEventsHandler(logger, services.EventsService))
+
+	// The Stripe webhook is only exposed when a signing secret is configured
+	stripeConfig := c.GetConfig().Stripe
+	if stripeConfig.WebhookSecret != "" {
+		// ProviderID is validated as a UUID at config load time
+		providerID := uuid.MustParse(stripeConfig.ProviderID)
+		// The webhook's writes are applied atomically in one request transaction. With an ingest
+		// queue, the handler still runs while the database is unreachable so it can queue the event.
+		webhookTx := withTx
+		var queue handlers.EventQueue
+		if services.IngestQueue != nil {
+			queue = services.IngestQueue
+			webhookTx = middleware.TransactionOrDirect(logger, beginTx, middlewareErrors)
+		}
+		routes.Handle("POST /webhooks/stripe", webhookTx(handlers.WithJSONMode(handlers.JSONLenient, handlers.StripeWebhookHandler(logger, services.EventsService, stripeConfig.WebhookSecret, stripeConfig.WebhookTolerance, providerID, httpConfig.WebhookMaxBytes, handlers.EventAgePolicy{
+			MaxAge: c.GetConfig().EventAge.MaxAge,
+			Reject: c.GetConfig().EventAge.StaleAction == config.StaleActionReject,
+		}, queue))))
+	} else {
+		logger.Info("Stripe webhook disabled: STRIPE_WEBHOOK_SECRET not set")
+	}
+
+	if err := routes.Err(); err != nil {
+		return nil, err
+	}
+
+	// Unmatched routes get JSON 404/405 errors instead of plain text
+	handler := handlers.WithJSONFallbacks(mux, logger)
 
 	isDevelopment := c.IsDevelopment()
+	recovery := middleware.RecoveryWith(logger, handlers.PanicResponder(logger, responseOpts))
+	middlewares := []middleware.Middleware{
+		recovery, // 1. Outermost - catch all panics
+		handlers.WithResponseOptions(responseOpts),                            // 2. Make the response formats available to handlers
+		middleware.CORS(httpConfig.CORSAllowedOrigins),                        // 3. Handle CORS early
+		middleware.RequestID(),                                                // 4. Generate request ID early
+		middleware.AdminAuth(logger, httpConfig.AdminAPIKeys, routes.AuthFor), // 5. Check admin keys on admin routes
+		middleware.TenantContext(logger, isDevelopment, routes.AuthFor),       // 6. Extract tenant context on tenant routes
+		middleware.Logger(logger, logExcludedPaths),                           // 7. Log everything
+		middleware.HeadWithoutBody(),                                          // 8. Answer HEAD like GET, without the body
+	}
+	if services.RateLimiter != nil {
+		// 9. Reject tenants over their rate limit, after logging so rejections show in the request log
+		middlewares = append(middlewares, middleware.RateLimit(logger, services.RateLimiter))
+	}
+	if services.IdempotencyStore != nil {
+		// 10. Replay the stored response to a repeated Idempotency-Key
+		middlewares = append(middlewares, middleware.Idempotency(logger, services.IdempotencyStore, c.GetConfig().Idempotency.TTL, httpConfig.MaxRequestBytes, middlewareErrors))
+	}
+	if envConfig := c.GetConfig().Environment; envConfig.LogBodies {
+		// 11. Innermost - debug body logging, only when explicitly enabled
+		logger.Warn("Request and response body logging is enabled", "paths", envConfig.LogBodyPaths)
+		middlewares = append(middlewares, middleware.BodyLogger(logger, middleware.BodyLogOptions{
+			Paths:        envConfig.LogBodyPaths,
+			MaxBytes:     envConfig.LogBodyMaxBytes,
+			CaptureBytes: httpConfig.MaxRequestBytes,
+			PIIKeys:      envConfig.PIIKeys,
+		}))
+	}
+
 	// Apply middleware
-	return middleware.Chain(
-		mux,
-		middleware.Recovery(logger), // 1. Outermost - catch all panics
-		middleware.CORS(),           // 2. Handle CORS early
-		middleware.RequestID(),      // 3. Generate request ID early
-		middleware.TenantContext(logger, isDevelopment, excludedPaths), // 4. Extract tenant context
-		middleware.Logger(logger),                                      // 5. Innermost - log everything
-	)
+	return middleware.Chain(handler, middlewares...), nil
 }
 
-func Start(logger *slog.Logger, server *http.Server) {
-	// Start server in a goroutine
-	go func() {
+// newTLSConfig loads the server certificate and returns the TLS settings the server listens
+// with: TLS 1.2 or later, offering HTTP/2 ahead of HTTP/1.1 through ALPN. Without TLS, HTTP/2
+// is only served when HTTP2_CLEARTEXT is set, see config/README.md.
+func newTLSConfig(certFile, keyFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTLSCertificate, err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}, nil
+}
 
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Error("Failed to start server", "error", err)
+// Start binds the server's address and serves in the background, over TLS when the server
+// has a TLS config. The address is bound before Start returns, so a port already in use fails
+// startup with ErrServerStartup instead of bringing the process down from the goroutine.
+func Start(logger *slog.Logger, server *http.Server) error {
+	addr := server.Addr
+	if addr == "" {
+		addr = ":http"
+		if server.TLSConfig != nil {
+			addr = ":https"
+		}
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrServerStartup, err)
+	}
+
+	// Serve in a goroutine
+	go func() {
+		var err error
+		if server.TLSConfig != nil {
+			// The certificate is already loaded into TLSConfig
+			err = server.ServeTLS(listener, "", "")
+		} else {
+			err = server.Serve(listener)
+		}
+		if err != nil && err != http.ErrServerClosed {
+			logger.Error("Server stopped serving", "error", err)
 			os.Exit(1)
 		}
 	}()
+	return nil
 }
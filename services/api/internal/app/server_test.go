@@ -0,0 +1,469 @@
+package app
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"rdl-api/config"
+	"rdl-api/internal/domain/services"
+	"rdl-api/internal/middleware"
+
+	"golang.org/x/net/http2"
+)
+
+// testHealthService always reports healthy
+type testHealthService struct{}
+
+func (testHealthService) CheckReadiness(context.Context) error { return nil }
+func (testHealthService) CheckLiveness(context.Context) error  { return nil }
+func (testHealthService) GetVersion() string                   { return "test" }
+func (testHealthService) BeginShutdown()                       {}
+func (testHealthService) ShuttingDown() bool                   { return false }
+func (testHealthService) CheckHealth(context.Context) services.HealthReport {
+	return services.HealthReport{Status: services.HealthStatusOK}
+}
+
+func newTestContainer(httpConfig config.HTTPConfig) *Container {
+	return &Container{
+		config: &config.Config{
+			HTTP:        httpConfig,
+			Environment: config.EnvironmentConfig{Environment: "production"},
+		},
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+		services: Services{HealthService: testHealthService{}},
+	}
+}
+
+func TestSetupRoutes_CustomHealthPaths(t *testing.T) {
+	c := newTestContainer(config.HTTPConfig{
+		HealthPath: "/_health",
+		LivePath:   "/_live",
+		ReadyPath:  "/_ready",
+	})
+	handler, err := SetupRoutes(http.NewServeMux(), c)
+	if err != nil {
+		t.Fatalf("SetupRoutes failed: %v", err)
+	}
+
+	// No tenant header is sent: the configured paths must skip tenant validation
+	for _, path := range []string{"/_health", "/_live", "/_ready"} {
+		t.Run(path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Errorf("expected status %d for %s, got %d", http.StatusOK, path, w.Code)
+			}
+		})
+	}
+
+	t.Run("default paths are not registered", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, config.DefaultLivePath, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code == http.StatusOK {
+			t.Errorf("expected %s to be unavailable when LIVE_PATH is overridden", config.DefaultLivePath)
+		}
+	})
+}
+
+func TestSetupRoutes_Root(t *testing.T) {
+	c := newTestContainer(config.HTTPConfig{HealthPath: "/healthz", LivePath: "/live", ReadyPath: "/ready"})
+	c.config.BuildInfo.GIT_TAG = "v1.4.0"
+	handler, err := SetupRoutes(http.NewServeMux(), c)
+	if err != nil {
+		t.Fatalf("SetupRoutes failed: %v", err)
+	}
+
+	// No tenant header is sent: the root is public
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if body := w.Body.String(); !strings.Contains(body, `"version":"v1.4.0"`) || !strings.Contains(body, `"health":"/healthz"`) {
+		t.Errorf("expected the version and health link in the root response, got %s", body)
+	}
+
+	// Paths below the root still need a tenant and still fall through to the JSON 404
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/does-not-exist", nil))
+	if w.Code == http.StatusOK {
+		t.Errorf("expected the root not to match /does-not-exist, got %d", w.Code)
+	}
+}
+
+func TestSetupRoutes_TimeFormat(t *testing.T) {
+	c := newTestContainer(config.HTTPConfig{HealthPath: "/healthz", LivePath: "/live", ReadyPath: "/ready", TimeFormat: "unix_ms"})
+	handler, err := SetupRoutes(http.NewServeMux(), c)
+	if err != nil {
+		t.Fatalf("SetupRoutes failed: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/live", nil))
+	var body struct {
+		Timestamp json.RawMessage `json:"timestamp"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if _, err := strconv.ParseInt(string(body.Timestamp), 10, 64); err != nil {
+		t.Errorf("expected the timestamp in Unix milliseconds, got %s", body.Timestamp)
+	}
+}
+
+func TestSetupRoutes_DuplicateRoute(t *testing.T) {
+	// The health and readiness probes collide when configured to the same path
+	c := newTestContainer(config.HTTPConfig{
+		HealthPath: "/_probe",
+		LivePath:   "/_live",
+		ReadyPath:  "/_probe",
+	})
+
+	handler, err := SetupRoutes(http.NewServeMux(), c)
+	if !errors.Is(err, ErrDuplicateRoute) {
+		t.Fatalf("expected ErrDuplicateRoute, got %v", err)
+	}
+	if handler != nil {
+		t.Error("expected no handler when routes conflict")
+	}
+	if !strings.Contains(err.Error(), `"/_probe"`) {
+		t.Errorf("expected the conflicting pattern in the error, got %q", err)
+	}
+}
+
+func TestRouteRegistrar(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request) {}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		wantErr  string
+	}{
+		{"distinct routes", []string{"GET /events", "PATCH /events/{id}", "DELETE /events/{id}"}, ""},
+		{"same method and path", []string{"GET /events", "GET /events"}, `"GET /events" conflicts with "GET /events"`},
+		{"wildcard names differ", []string{"GET /events/{id}", "GET /events/{eventID}"}, `"GET /events/{eventID}" conflicts with "GET /events/{id}"`},
+		{"method-less duplicate", []string{"/health", "/health"}, `"/health" conflicts with "/health"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routes := newRouteRegistrar(http.NewServeMux())
+			for _, pattern := range tt.patterns {
+				routes.HandleFunc(pattern, noop)
+			}
+
+			err := routes.Err()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrDuplicateRoute) {
+				t.Fatalf("expected ErrDuplicateRoute, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error to contain %s, got %q", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRouteRegistrar_AuthFor(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request) {}
+	routes := newRouteRegistrar(http.NewServeMux())
+	routes.WithAuth(middleware.AuthPublic).HandleFunc("GET /openapi.json", noop)
+	routes.WithAuth(middleware.AuthAdmin).HandleFunc("POST /admin/reindex", noop)
+	routes.HandleFunc("GET /events/{id}", noop)
+
+	tests := []struct {
+		method string
+		path   string
+		want   middleware.AuthLevel
+	}{
+		{http.MethodGet, "/openapi.json", middleware.AuthPublic},
+		{http.MethodPost, "/admin/reindex", middleware.AuthAdmin},
+		{http.MethodGet, "/events/123", middleware.AuthTenant},
+		{http.MethodGet, "/unknown", middleware.AuthTenant},
+		{http.MethodPost, "/openapi.json", middleware.AuthTenant},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			if got := routes.AuthFor(httptest.NewRequest(tt.method, tt.path, nil)); got != tt.want {
+				t.Errorf("AuthFor() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetupRoutes_PublicAndTenantRoutes(t *testing.T) {
+	c := newTestContainer(config.HTTPConfig{HealthPath: "/healthz", LivePath: "/live", ReadyPath: "/ready"})
+	handler, err := SetupRoutes(http.NewServeMux(), c)
+	if err != nil {
+		t.Fatalf("SetupRoutes failed: %v", err)
+	}
+
+	// No tenant header is sent: the API description is public, the event listing is not
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected the public route to skip auth, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected the tenant route to require a tenant, got %d", w.Code)
+	}
+}
+
+func TestSetupRoutes_MetricsNeedAdminKey(t *testing.T) {
+	c := newTestContainer(config.HTTPConfig{HealthPath: "/healthz", LivePath: "/live", ReadyPath: "/ready", AdminAPIKeys: []string{"admin-key"}})
+	handler, err := SetupRoutes(http.NewServeMux(), c)
+	if err != nil {
+		t.Fatalf("SetupRoutes failed: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, MetricsPath, nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected metrics to require an admin key, got %d", w.Code)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, MetricsPath, nil)
+	r.Header.Set(middleware.AdminKeyHeader, "admin-key")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected metrics with an admin key, got %d", w.Code)
+	}
+}
+
+func TestSetupRoutes_Head(t *testing.T) {
+	c := newTestContainer(config.HTTPConfig{HealthPath: "/healthz", LivePath: "/live", ReadyPath: "/ready"})
+	handler, err := SetupRoutes(http.NewServeMux(), c)
+	if err != nil {
+		t.Fatalf("SetupRoutes failed: %v", err)
+	}
+
+	// The probes check the method themselves; the API description has a GET pattern
+	for _, path := range []string{"/healthz", "/live", "/openapi.json"} {
+		t.Run(path, func(t *testing.T) {
+			get := httptest.NewRecorder()
+			handler.ServeHTTP(get, httptest.NewRequest(http.MethodGet, path, nil))
+			head := httptest.NewRecorder()
+			handler.ServeHTTP(head, httptest.NewRequest(http.MethodHead, path, nil))
+
+			if head.Code != http.StatusOK {
+				t.Fatalf("expected status %d, got %d", http.StatusOK, head.Code)
+			}
+			if head.Body.Len() != 0 {
+				t.Errorf("expected no body, got %q", head.Body.String())
+			}
+			for _, header := range []string{"Content-Type", "Cache-Control"} {
+				if got, want := head.Header().Get(header), get.Header().Get(header); got != want {
+					t.Errorf("expected %s %q as on GET, got %q", header, want, got)
+				}
+			}
+		})
+	}
+
+	t.Run("HEAD is not answered on a POST route", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/detect", nil))
+		if w.Code == http.StatusOK {
+			t.Errorf("expected HEAD on a POST route to be rejected, got %d", w.Code)
+		}
+	})
+}
+
+// writeTestCertificate writes a self-signed certificate for 127.0.0.1 and its key to dir
+func writeTestCertificate(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+
+	certFile, keyFile = filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+	return certFile, keyFile
+}
+
+// serveTestServer serves server on a free local port until the test ends and returns its URL
+func serveTestServer(t *testing.T, server *http.Server) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	// ServeTLS writes to server.TLSConfig, so it is read once here, before serving starts
+	useTLS := server.TLSConfig != nil
+	go func() {
+		if useTLS {
+			_ = server.ServeTLS(listener, "", "")
+		} else {
+			_ = server.Serve(listener)
+		}
+	}()
+	t.Cleanup(func() { _ = server.Close() })
+
+	scheme := "http"
+	if useTLS {
+		scheme = "https"
+	}
+	return scheme + "://" + listener.Addr().String()
+}
+
+func TestSetupAppServer_HTTP2(t *testing.T) {
+	paths := config.HTTPConfig{HealthPath: "/healthz", LivePath: "/live", ReadyPath: "/ready"}
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true}, //nolint:gosec // the test server's certificate is self-signed
+		ForceAttemptHTTP2: true,
+	}}
+	get := func(t *testing.T, url string) *http.Response {
+		t.Helper()
+		resp, err := client.Get(url + "/live")
+		if err != nil {
+			t.Fatalf("GET /live: %v", err)
+		}
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+		return resp
+	}
+
+	t.Run("TLS negotiates h2", func(t *testing.T) {
+		httpConfig := paths
+		httpConfig.TLSCertFile, httpConfig.TLSKeyFile = writeTestCertificate(t, t.TempDir())
+		appServer, err := setupAppServer(newTestContainer(httpConfig))
+		if err != nil {
+			t.Fatalf("setupAppServer failed: %v", err)
+		}
+		if got := appServer.server.TLSConfig.NextProtos; len(got) == 0 || got[0] != "h2" {
+			t.Fatalf("expected h2 to be advertised first, got %v", got)
+		}
+
+		resp := get(t, serveTestServer(t, appServer.server))
+		if resp.ProtoMajor != 2 || resp.TLS == nil || resp.TLS.NegotiatedProtocol != "h2" {
+			t.Errorf("expected HTTP/2 over TLS, got %s", resp.Proto)
+		}
+	})
+
+	t.Run("plain HTTP stays HTTP/1.1", func(t *testing.T) {
+		appServer, err := setupAppServer(newTestContainer(paths))
+		if err != nil {
+			t.Fatalf("setupAppServer failed: %v", err)
+		}
+		if appServer.server.TLSConfig != nil {
+			t.Fatal("expected no TLS config without a certificate")
+		}
+
+		resp := get(t, serveTestServer(t, appServer.server))
+		if resp.ProtoMajor != 1 {
+			t.Errorf("expected HTTP/1.1 without TLS, got %s", resp.Proto)
+		}
+	})
+
+	// h2cClient speaks HTTP/2 over plain TCP by prior knowledge, as a proxy talking h2c would
+	h2cClient := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, addr)
+		},
+	}}
+
+	t.Run("HTTP2_CLEARTEXT serves h2c", func(t *testing.T) {
+		httpConfig := paths
+		httpConfig.HTTP2Cleartext = true
+		appServer, err := setupAppServer(newTestContainer(httpConfig))
+		if err != nil {
+			t.Fatalf("setupAppServer failed: %v", err)
+		}
+		url := serveTestServer(t, appServer.server)
+
+		resp, err := h2cClient.Get(url + "/live")
+		if err != nil {
+			t.Fatalf("h2c GET /live: %v", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK || resp.ProtoMajor != 2 {
+			t.Errorf("expected 200 over HTTP/2, got %d over %s", resp.StatusCode, resp.Proto)
+		}
+
+		// HTTP/1.1 clients are still served
+		if resp := get(t, url); resp.ProtoMajor != 1 {
+			t.Errorf("expected HTTP/1.1 for an HTTP/1.1 client, got %s", resp.Proto)
+		}
+	})
+
+	t.Run("h2c is refused by default", func(t *testing.T) {
+		appServer, err := setupAppServer(newTestContainer(paths))
+		if err != nil {
+			t.Fatalf("setupAppServer failed: %v", err)
+		}
+
+		resp, err := h2cClient.Get(serveTestServer(t, appServer.server) + "/live")
+		if err == nil {
+			_ = resp.Body.Close()
+			t.Errorf("expected the h2c request to fail without HTTP2_CLEARTEXT, got %d over %s", resp.StatusCode, resp.Proto)
+		}
+	})
+
+	t.Run("unreadable certificate", func(t *testing.T) {
+		httpConfig := paths
+		httpConfig.TLSCertFile, httpConfig.TLSKeyFile = filepath.Join(t.TempDir(), "missing.crt"), filepath.Join(t.TempDir(), "missing.key")
+		if _, err := setupAppServer(newTestContainer(httpConfig)); !errors.Is(err, ErrTLSCertificate) {
+			t.Errorf("expected ErrTLSCertificate, got %v", err)
+		}
+	})
+}
@@ -2,25 +2,60 @@ package app
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"log/slog"
+	"rdl-api/config"
+	"rdl-api/internal/db/repository"
+	"rdl-api/internal/detection"
 	"rdl-api/internal/domain/models"
 	"rdl-api/internal/domain/services"
+	"rdl-api/internal/executor"
+	"rdl-api/internal/ingestqueue"
+	"rdl-api/internal/middleware"
+	"rdl-api/internal/notifier"
+	"rdl-api/internal/ratelimit"
+	"rdl-api/internal/retention"
+	"time"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Services struct {
-	HealthService  HealthService
-	UsersService   UsersService
-	EventsService  EventsService
-	ActionsService ActionsService
+	HealthService    HealthService
+	UsersService     UsersService
+	EventsService    EventsService
+	ActionsService   ActionsService
+	LeaksService     LeaksService
+	ProvidersService ProvidersService
+	// NotificationChannelsService manages where each tenant's leak notifications are delivered
+	NotificationChannelsService NotificationChannelsService
+	LeakDetector                LeakDetector
+	EventPurger                 EventPurger
+	// DetectionScheduler runs leak detection for every tenant on an interval
+	DetectionScheduler DetectionScheduler
+	// IngestQueue holds webhook events while the database is unreachable; nil when disabled
+	IngestQueue IngestQueue
+	// TenantsService reads the settings tenants override
+	TenantsService TenantsService
+	// RateLimiter limits each tenant's requests, reloading their overrides on an interval
+	RateLimiter RateLimiter
+	// IdempotencyStore keeps the responses replayed for repeated Idempotency-Key headers, in
+	// memory or in Postgres as IDEMPOTENCY_STORE selects
+	IdempotencyStore IdempotencyStore
+	// NotificationOutbox delivers leak notifications and retries the failed deliveries on an interval
+	NotificationOutbox NotificationOutbox
+	// ActionExecutor runs every tenant's pending actions from the priority queue on an interval
+	ActionExecutor ActionExecutor
 }
 
 type HealthService interface {
 	CheckReadiness(ctx context.Context) error
 	CheckLiveness(ctx context.Context) error
+	CheckHealth(ctx context.Context) services.HealthReport
 	GetVersion() string
+	BeginShutdown()
+	ShuttingDown() bool
 }
 
 type UsersService interface {
@@ -34,12 +69,42 @@ type UsersService interface {
 
 type EventsService interface {
 	CreateEvent(ctx context.Context, args models.CreateEventParams, tenantID uuid.UUID) (models.Event, error)
+	CreateEventIdempotent(ctx context.Context, args models.CreateEventParams, tenantID uuid.UUID) (models.Event, error)
+	CreateEventsBatch(ctx context.Context, args []models.CreateEventParams, tenantID uuid.UUID, policy models.BatchPolicy) ([]models.BatchEventResult, error)
 	DeleteEvent(ctx context.Context, eventID uuid.UUID, tenantID uuid.UUID) (int64, error)
+	DeleteEventIdempotent(ctx context.Context, eventID uuid.UUID, tenantID uuid.UUID) (int64, error)
 	GetAllEvents(ctx context.Context, tenantID uuid.UUID) ([]models.Event, error)
 	GetAllEventsPaginated(ctx context.Context, tenantID uuid.UUID, params models.PaginationParams) (models.PaginatedResponse[models.Event], error)
+	ListEvents(ctx context.Context, tenantID uuid.UUID, filter models.EventFilter, params models.PaginationParams) (models.PaginatedResponse[models.Event], error)
+	ListEventsForExport(ctx context.Context, tenantID uuid.UUID, after models.EventCursor, limit int32) ([]models.Event, error)
+	SearchEventsByExternalID(ctx context.Context, tenantID uuid.UUID, prefix string, params models.PaginationParams) (models.PaginatedResponse[models.Event], error)
 	GetEventByID(ctx context.Context, eventID uuid.UUID, tenantID uuid.UUID) (models.Event, error)
+	GetEventStatusHistory(ctx context.Context, eventID uuid.UUID, tenantID uuid.UUID) ([]models.EventStatusChange, error)
+	GetEventsByIDs(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) (map[uuid.UUID]models.Event, error)
+	GetEventsByLeakID(ctx context.Context, leakID uuid.UUID, tenantID uuid.UUID) ([]models.Event, error)
+	GetEventsBySource(ctx context.Context, tenantID uuid.UUID, source models.EventSourceEnum, params models.PaginationParams) (models.PaginatedResponse[models.Event], error)
+	GetEventsWithoutLeak(ctx context.Context, tenantID uuid.UUID, eventType models.EventTypeEnum, params models.PaginationParams) (models.PaginatedResponse[models.Event], error)
+	GetPendingEventsOldestFirst(ctx context.Context, tenantID uuid.UUID, limit int) ([]models.Event, error)
+	GetRecentEvents(ctx context.Context, tenantID uuid.UUID, n int) ([]models.Event, error)
+	GetRelatedEvents(ctx context.Context, tenantID uuid.UUID, eventID uuid.UUID, rule models.CorrelationRule) ([]models.Event, error)
 	UpdateEvent(ctx context.Context, args models.UpdateEventParams, tenantID uuid.UUID) (models.Event, error)
+	UpdateEventIfVersion(ctx context.Context, args models.UpdateEventParams, expectedUpdatedAt time.Time, tenantID uuid.UUID) (models.Event, error)
+	UpdateEventStatusByFilter(ctx context.Context, tenantID uuid.UUID, filter models.EventFilter, newStatus models.EventStatusEnum) (int64, error)
+	ReattributeEvents(ctx context.Context, tenantID uuid.UUID, fromProviderID uuid.UUID, toProviderID uuid.UUID) (int64, error)
 	CountAllEvents(ctx context.Context, tenantID uuid.UUID) (int64, error)
+	HasAnyEvents(ctx context.Context, tenantID uuid.UUID) (bool, error)
+	CountEventsByStatusForProvider(ctx context.Context, tenantID uuid.UUID, providerID uuid.UUID) (map[models.EventStatusEnum]int64, error)
+	GetEventCountInWindow(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) (int64, error)
+	GetIngestionLag(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) ([]models.IngestionLag, error)
+	GetProviderFailureRates(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) ([]models.ProviderFailureRate, error)
+	FindDuplicateCharges(ctx context.Context, tenantID uuid.UUID, since time.Time, window time.Duration) ([]models.DuplicateCharge, error)
+	FindDunningGaps(ctx context.Context, tenantID uuid.UUID, now time.Time, defaultWindow time.Duration, lookback time.Duration) ([]models.DunningGap, error)
+	ReprocessFailedEvents(ctx context.Context, tenantID uuid.UUID, cursor uuid.UUID, limit int) (models.ReprocessResult, error)
+	ValidateStoredEvents(ctx context.Context, tenantID uuid.UUID, params models.ValidateStoredEventsParams) (models.StoredEventsValidation, error)
+	GetEventRetentionPolicies(ctx context.Context) ([]models.EventRetentionPolicy, error)
+	ArchiveEventsBefore(ctx context.Context, tenantID uuid.UUID, before time.Time, w io.Writer) (int64, error)
+	PurgeEventsBefore(ctx context.Context, tenantID uuid.UUID, before time.Time, limit int32) (int64, error)
+	ConfigurePipeline(names []string, stages ...services.EventStage) error
 }
 
 type ActionsService interface {
@@ -47,28 +112,245 @@ type ActionsService interface {
 	DeleteAction(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (int64, error)
 	GetAllActions(ctx context.Context, tenantID uuid.UUID) ([]models.Action, error)
 	GetAllActionsPaginated(ctx context.Context, tenantID uuid.UUID, params models.PaginationParams) (models.PaginatedResponse[models.Action], error)
+	GetActionsFiltered(ctx context.Context, tenantID uuid.UUID, filter models.ActionFilter, params models.PaginationParams, sort models.ActionSort) (models.PaginatedResponse[models.Action], error)
 	GetActionByID(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (models.Action, error)
+	GetActionWithLeak(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (models.Action, models.Leak, error)
+	GetActionsByLeakID(ctx context.Context, leakID uuid.UUID, tenantID uuid.UUID) ([]models.Action, error)
 	CountAllActions(ctx context.Context, tenantID uuid.UUID) (int64, error)
+	ClaimPendingActions(ctx context.Context, tenantID uuid.UUID, workerID string, limit int) ([]models.Action, error)
+	CompleteAction(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (models.Action, error)
+	FailActionAttempt(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (models.Action, error)
 }
 
-// setupDomainServices
-func setupDomainServices(pool *pgxpool.Pool, logger *slog.Logger, version string) Services {
+type LeaksService interface {
+	CreateLeak(ctx context.Context, args models.CreateLeakParams, tenantID uuid.UUID) (models.Leak, error)
+	UpsertLeakByDedupKey(ctx context.Context, args models.CreateLeakParams, tenantID uuid.UUID) (models.Leak, error)
+	GetLeakByID(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (models.Leak, error)
+	GetMinLeakAmounts(ctx context.Context, tenantID uuid.UUID) (map[string]models.Decimal, error)
+	GetLeakSources(ctx context.Context, tenantID uuid.UUID) (models.LeakSources, error)
+	GetLeakCountInWindow(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) (int64, error)
+	FindExistingLeaks(ctx context.Context, tenantID uuid.UUID, detectedAt time.Time, sourceEventIDs []uuid.UUID, dedupKeys []string) ([]models.Leak, error)
+	ListLeaks(ctx context.Context, tenantID uuid.UUID, filter models.LeakFilter, params models.PaginationParams) (models.PaginatedResponse[models.Leak], error)
+	ListLeaksAfter(ctx context.Context, tenantID uuid.UUID, filter models.LeakFilter, after models.LeakCursor, limit int32) ([]models.Leak, error)
+	ListTenantIDs(ctx context.Context) ([]uuid.UUID, error)
+	TryLockTenantDetection(ctx context.Context, tenantID uuid.UUID) (unlock func(), locked bool, err error)
+	GetOpenLeakCount(ctx context.Context, tenantID uuid.UUID) (int64, error)
+	GetAffectedCustomerCount(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) (int64, error)
+	GetLeakMTTR(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) (time.Duration, int, error)
+	GetRevenueRecovered(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) (map[string]models.Decimal, error)
+	GetLeakAmountHistogram(ctx context.Context, tenantID uuid.UUID, bounds []models.Decimal, since time.Time) ([]models.LeakAmountBucket, error)
+	GetLeakTimeSeries(ctx context.Context, tenantID uuid.UUID, interval models.LeakTimeSeriesInterval, from time.Time, to time.Time) ([]models.LeakTimeSeriesPoint, error)
+	SnoozeLeak(ctx context.Context, id uuid.UUID, until time.Time, tenantID uuid.UUID) (models.Leak, error)
+}
+
+type ProvidersService interface {
+	CreateProvider(ctx context.Context, args models.CreateProviderParams) (models.Provider, error)
+	GetProviders(ctx context.Context, tenantID uuid.UUID) ([]models.TenantProvider, error)
+}
+
+type NotificationChannelsService interface {
+	CreateNotificationChannel(ctx context.Context, args models.CreateNotificationChannelParams, tenantID uuid.UUID) (models.NotificationChannel, error)
+	GetNotificationChannelByID(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (models.NotificationChannel, error)
+	GetNotificationChannels(ctx context.Context, tenantID uuid.UUID) ([]models.NotificationChannel, error)
+	UpdateNotificationChannel(ctx context.Context, args models.UpdateNotificationChannelParams, tenantID uuid.UUID) (models.NotificationChannel, error)
+	DeleteNotificationChannel(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (int64, error)
+}
+
+type LeakDetector interface {
+	DetectLeaks(ctx context.Context, tenantID uuid.UUID, dryRun bool) (detection.Report, error)
+	BackfillLeaks(ctx context.Context, tenantID uuid.UUID, from, to time.Time, dryRun bool) (detection.BackfillReport, error)
+	LastRun() (detection.RunStatus, bool)
+}
+
+type EventPurger interface {
+	Purge(ctx context.Context) (retention.Result, error)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type IngestQueue interface {
+	Enqueue(params models.CreateEventParams, tenantID uuid.UUID) error
+	Len() int
+	Flush(ctx context.Context) (int, error)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type TenantsService interface {
+	GetTenantRateLimits(ctx context.Context) ([]models.TenantRateLimit, error)
+	ExportTenant(ctx context.Context, tenantID uuid.UUID, w io.Writer) (models.TenantSnapshotReport, error)
+	ImportTenant(ctx context.Context, tenantID uuid.UUID, r io.Reader, resumeAfter string) (models.TenantSnapshotReport, error)
+}
+
+type NotificationOutbox interface {
+	Notify(ctx context.Context, n notifier.Notification) error
+	RunOnce(ctx context.Context) error
+	Run(ctx context.Context, interval time.Duration)
+}
 
-	hService, err := services.NewHealthService(pool, logger, version)
+type ActionExecutor interface {
+	RunOnce(ctx context.Context) error
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type RateLimiter interface {
+	Allow(tenantID uuid.UUID) (bool, time.Duration)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type IdempotencyStore interface {
+	GetIdempotencyRecord(ctx context.Context, tenantID uuid.UUID, key string) (models.IdempotencyRecord, bool, error)
+	SaveIdempotencyRecord(ctx context.Context, record models.IdempotencyRecord) error
+}
+
+type DetectionScheduler interface {
+	RunOnce(ctx context.Context) (detection.SchedulerRun, error)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+// setupHealthService builds the health service on pool, checking readPool as the replica when
+// it is a different pool
+func setupHealthService(pool *repository.Pool, readPool *repository.Pool, logger *slog.Logger, cfg *config.Config) (HealthService, error) {
+	hService, err := services.NewHealthService(pool.Pool, readPool.Pool, logger, cfg.BuildInfo.GIT_TAG, cfg.Health.CriticalComponents)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("health service: %w", err)
 	}
+	return hService, nil
+}
+
+// setupDomainServices builds the domain services on pool, sending read-heavy queries to
+// readPool, with the settings in cfg; hService is the health service already built by
+// setupHealthService. It returns the error of the first service that cannot be built, naming
+// that service.
+func setupDomainServices(pool *repository.Pool, readPool *repository.Pool, logger *slog.Logger, cfg *config.Config, hService HealthService) (Services, error) {
+	detectionCfg := cfg.Detection
+	notifierCfg := cfg.Notifier
+
 	uService := services.NewUserService(pool, logger)
-	eService, err := services.NewEventService(pool, logger)
+	eService, err := services.NewEventService(pool, readPool, logger, cfg.Metrics.IngestionLagBuckets)
+	if err != nil {
+		return Services{}, fmt.Errorf("events service: %w", err)
+	}
+	aService := services.NewActionsService(pool, logger, cfg.Actions.MaxAttempts, cfg.Actions.ClaimLease)
+	lService, err := services.NewLeaksService(pool, readPool, logger)
 	if err != nil {
-		panic(err)
+		return Services{}, fmt.Errorf("leaks service: %w", err)
+	}
+	pService, err := services.NewProvidersService(pool, logger)
+	if err != nil {
+		return Services{}, fmt.Errorf("providers service: %w", err)
+	}
+	ncService, err := services.NewNotificationChannelsService(pool, logger)
+	if err != nil {
+		return Services{}, fmt.Errorf("notification channels service: %w", err)
+	}
+	tService, err := services.NewTenantsService(pool, logger)
+	if err != nil {
+		return Services{}, fmt.Errorf("tenants service: %w", err)
+	}
+	paymentsService, err := services.NewPaymentsService(pool, logger)
+	if err != nil {
+		return Services{}, fmt.Errorf("payments service: %w", err)
+	}
+	limiter := ratelimit.New(ratelimit.Limit{RPS: cfg.RateLimit.RPS, Burst: cfg.RateLimit.Burst}, tService, logger)
+	channelNotifier := notifier.NewChannelNotifier(ncService, notifier.Options{
+		MaxRetries:       notifierCfg.MaxRetries,
+		CircuitThreshold: notifierCfg.CircuitThreshold,
+		CircuitCooldown:  notifierCfg.CircuitCooldown,
+	}, logger)
+	if notifierCfg.EmailEnabled() {
+		channelNotifier = channelNotifier.WithEmail(notifier.EmailConfig{
+			Host:     notifierCfg.SMTPHost,
+			Port:     notifierCfg.SMTPPort,
+			Username: notifierCfg.SMTPUser,
+			Password: notifierCfg.SMTPPassword,
+			From:     notifierCfg.SMTPFrom,
+		})
+	}
+	outboxService, err := services.NewNotificationOutboxService(pool, logger)
+	if err != nil {
+		return Services{}, fmt.Errorf("notification outbox service: %w", err)
+	}
+	outbox := notifier.NewOutbox(outboxService, channelNotifier, lService, notifier.OutboxOptions{
+		MaxAttempts: notifierCfg.OutboxMaxAttempts,
+		BaseBackoff: notifierCfg.OutboxBaseBackoff,
+		MaxBackoff:  notifierCfg.OutboxMaxBackoff,
+	}, logger)
+	actionExecutor := executor.New(aService, lService, executor.NotifyRunner(outbox), executor.Options{}, logger)
+	volumeRule, err := detection.NewVolumeAnomalyRule(eService, detectionCfg.VolumeWindow, detectionCfg.VolumeBaselineWindows, detectionCfg.VolumeFactor)
+	if err != nil {
+		return Services{}, fmt.Errorf("volume anomaly rule: %w", err)
+	}
+	duplicateRule, err := detection.NewDuplicateChargeRule(eService, detectionCfg.DuplicateChargeWindow, max(detection.DefaultDuplicateChargeLookback, detectionCfg.DuplicateChargeWindow))
+	if err != nil {
+		return Services{}, fmt.Errorf("duplicate charge rule: %w", err)
+	}
+	dunningRule, err := detection.NewDunningGapRule(eService, detectionCfg.DunningWindow, detection.DefaultDunningLookback)
+	if err != nil {
+		return Services{}, fmt.Errorf("dunning gap rule: %w", err)
+	}
+	currencyRule, err := detection.NewCurrencyMismatchRule(paymentsService, detection.DefaultCurrencyMismatchLookback)
+	if err != nil {
+		return Services{}, fmt.Errorf("currency mismatch rule: %w", err)
+	}
+	failureRateRule, err := detection.NewFailureRateSpikeRule(eService, detection.DefaultFailureRateWindow, detection.DefaultFailureRateBaseline, detectionCfg.FailureRateFactor, detection.DefaultFailureRateMinAttempts)
+	if err != nil {
+		return Services{}, fmt.Errorf("failure rate spike rule: %w", err)
+	}
+	newDetector := func(notify notifier.Notifier) *detection.Detector {
+		return detection.NewDetector(lService, notify, logger, volumeRule, duplicateRule, dunningRule, currencyRule, failureRateRule).
+			WithMinLeakAmounts(detectionCfg.MinLeakAmounts, lService).
+			WithMaxLeaksPerRun(detectionCfg.MaxLeaksPerRun).
+			WithLeakSources(detection.DefaultLeakSources(), lService).
+			WithRunLocker(lService)
+	}
+	detector := newDetector(outbox)
+	// The event pipeline's detector leaves announcing its leaks to the notify stage, so that
+	// stage can be turned off on its own
+	err = eService.ConfigurePipeline(cfg.EventPipeline.Stages, newDetectEventStage(newDetector(nil), logger), newNotifyEventStage(outbox, logger))
+	if err != nil {
+		return Services{}, fmt.Errorf("event pipeline: %w", err)
+	}
+	scheduler, err := detection.NewScheduler(detector, lService, logger, detectionCfg.Concurrency)
+	if err != nil {
+		return Services{}, fmt.Errorf("detection scheduler: %w", err)
+	}
+	purger, err := retention.NewPurger(eService, logger, cfg.Retention.EventRetention, cfg.Retention.PurgeBatchSize)
+	if err != nil {
+		return Services{}, fmt.Errorf("event purger: %w", err)
 	}
-	aService := services.NewActionsService(pool, logger)
 
-	return Services{
-		HealthService:  hService,
-		UsersService:   uService,
-		EventsService:  eService,
-		ActionsService: aService,
+	var idempotencyStore IdempotencyStore = middleware.NewMemoryIdempotencyStore(cfg.Idempotency.MaxKeys)
+	if cfg.Idempotency.Store == config.IdempotencyStorePostgres {
+		idempotencyStore, err = services.NewIdempotencyKeysService(pool, logger)
+		if err != nil {
+			return Services{}, fmt.Errorf("idempotency store: %w", err)
+		}
 	}
+
+	var ingestQueue IngestQueue
+	if cfg.IngestQueue.Size > 0 {
+		queue, err := ingestqueue.New(eService, logger, cfg.IngestQueue.Size)
+		if err != nil {
+			return Services{}, fmt.Errorf("ingest queue: %w", err)
+		}
+		ingestQueue = queue
+	}
+
+	return Services{
+		HealthService:               hService,
+		UsersService:                uService,
+		EventsService:               eService,
+		ActionsService:              aService,
+		LeaksService:                lService,
+		ProvidersService:            pService,
+		NotificationChannelsService: ncService,
+		LeakDetector:                detector,
+		EventPurger:                 purger,
+		DetectionScheduler:          scheduler,
+		IngestQueue:                 ingestQueue,
+		TenantsService:              tService,
+		RateLimiter:                 limiter,
+		IdempotencyStore:            idempotencyStore,
+		NotificationOutbox:          outbox,
+		ActionExecutor:              actionExecutor,
+	}, nil
 }
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"rdl-api/migrations"
+)
+
+// Startup phases run by StartUp, in order. Configuration, the logger, the connection pools and
+// the health service the database phase checks with are built before them, by LoadConfig and
+// NewApplication.
+const (
+	// PhaseDatabase checks that the database answers
+	PhaseDatabase = "database"
+	// PhaseWarmup checks that the schema is migrated to the version this build expects
+	PhaseWarmup = "warmup"
+	// PhaseServices builds the domain services and the HTTP server with its routes
+	PhaseServices = "services"
+	// PhaseBackgroundWorkers starts the event purger, the detection scheduler, the ingest queue flusher
+	// and the rate limit reloads
+	PhaseBackgroundWorkers = "background workers"
+	// PhaseHTTP binds the listen address and starts serving requests
+	PhaseHTTP = "http"
+)
+
+// startupPhase is one step of StartUp. Whatever a phase starts registers a shutdown hook, so
+// a failure in a later phase can undo it.
+type startupPhase struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// startupPhases returns the phases StartUp runs, in order
+func (a *Application) startupPhases() []startupPhase {
+	c := a.container
+	return []startupPhase{
+		{name: PhaseDatabase, run: func(ctx context.Context) error {
+			// Verify database connectivity with a short timeout
+			return c.GetServices().HealthService.CheckReadiness(ctx)
+		}},
+		{name: PhaseWarmup, run: func(ctx context.Context) error {
+			// Refuse to serve against a schema older than the one this build expects
+			shipped, err := migrations.Versions()
+			if err != nil {
+				return err
+			}
+			return checkMigrations(ctx, c.GetLogger(), poolMigrationStatus{pool: c.pool.Pool}, shipped, c.config.Database.RequireMigrated)
+		}},
+		{name: PhaseServices, run: func(context.Context) error {
+			if err := c.setupServices(); err != nil {
+				return err
+			}
+			appServer, err := setupAppServer(c)
+			if err != nil {
+				return err
+			}
+			a.server = appServer
+			return nil
+		}},
+		{name: PhaseBackgroundWorkers, run: func(ctx context.Context) error {
+			a.startEventPurger(ctx)
+			a.startDetectionScheduler(ctx)
+			a.startIngestQueue(ctx)
+			a.startRateLimiter(ctx)
+			a.startIdempotencySweeper(ctx)
+			a.startNotificationOutbox(ctx)
+			a.startActionExecutor(ctx)
+			return nil
+		}},
+		{name: PhaseHTTP, run: func(context.Context) error {
+			return Start(c.GetLogger(), a.server.server)
+		}},
+	}
+}
+
+// runStartupPhases runs phases in order and stops at the first one that fails, so no later
+// phase starts. It then shuts down what the earlier phases started, through the container's
+// shutdown hooks, and returns the phase's error wrapped in ErrStartupPhase together with any
+// cleanup error.
+func (a *Application) runStartupPhases(ctx context.Context, phases []startupPhase) error {
+	l := a.container.GetLogger()
+	for _, phase := range phases {
+		if err := phase.run(ctx); err != nil {
+			l.ErrorContext(ctx, "Startup phase failed, stopping what already started", "phase", phase.name, "error", err)
+			phaseErr := fmt.Errorf("%w: %s: %w", ErrStartupPhase, phase.name, err)
+			if cleanupErr := a.shutdown(ctx, a.container.GetConfig().Shutdown.SIGTERMTimeout); cleanupErr != nil {
+				return errors.Join(phaseErr, cleanupErr)
+			}
+			return phaseErr
+		}
+		l.InfoContext(ctx, "Startup phase completed", "phase", phase.name)
+	}
+	return nil
+}
@@ -1,21 +1,147 @@
 -- name: GetActionByID :one
-SELECT id, leak_id, action_type, status, result, created_at, updated_at
+-- The tenant predicate holds independently of row-level security, so a misconfigured policy
+-- still cannot return another tenant's action
+SELECT id, leak_id, action_type, status, result, created_at, updated_at, priority, claimed_by, claimed_at, attempts
 FROM actions
-WHERE id = $1;
+WHERE id = $1
+  AND EXISTS (SELECT 1 FROM leaks WHERE leaks.id = actions.leak_id AND leaks.tenant_id = $2);
+
+-- name: GetActionWithLeak :one
+-- The tenant predicate holds independently of row-level security, as in GetActionByID
+SELECT sqlc.embed(actions), sqlc.embed(leaks)
+FROM actions
+JOIN leaks ON leaks.id = actions.leak_id
+WHERE actions.id = $1
+  AND leaks.tenant_id = $2;
+
+-- name: GetActionsByLeakID :many
+-- The tenant predicate holds independently of row-level security, as in GetActionByID
+SELECT id, leak_id, action_type, status, result, created_at, updated_at, priority, claimed_by, claimed_at, attempts
+FROM actions
+WHERE leak_id = $1
+  AND EXISTS (SELECT 1 FROM leaks WHERE leaks.id = actions.leak_id AND leaks.tenant_id = $2)
+ORDER BY created_at ASC;
 
 -- name: CreateAction :one
-INSERT INTO actions (leak_id, action_type, status, result) VALUES ($1, $2, $3, $4) RETURNING id, leak_id, action_type, status, result, created_at, updated_at;
+INSERT INTO actions (leak_id, action_type, status, result, priority)
+VALUES ($1, $2, $3, $4, COALESCE((SELECT ROUND(amount * 100)::BIGINT FROM leaks WHERE leaks.id = $1), 0))
+RETURNING id, leak_id, action_type, status, result, created_at, updated_at, priority, claimed_by, claimed_at, attempts;
 
 -- name: GetAllActions :many
-SELECT id, leak_id, action_type, status, result, created_at, updated_at
+SELECT id, leak_id, action_type, status, result, created_at, updated_at, priority, claimed_by, claimed_at, attempts
 FROM actions;
 
 -- name: GetAllActionsPaginated :many
-SELECT id, leak_id, action_type, status, result, created_at, updated_at
+SELECT id, leak_id, action_type, status, result, created_at, updated_at, priority, claimed_by, claimed_at, attempts
 FROM actions
 ORDER BY created_at DESC
 LIMIT $1 OFFSET $2;
 
+-- name: GetActionsFiltered :many
+-- Actions have no tenant_id; the tenant predicate goes through their leak, as in GetActionByID.
+-- sort_by is one of created_at, updated_at and priority, and only the matching pair of CASE
+-- expressions orders anything. id breaks ties so pages are stable.
+SELECT id, leak_id, action_type, status, result, created_at, updated_at, priority, claimed_by, claimed_at, attempts
+FROM actions
+WHERE EXISTS (SELECT 1 FROM leaks WHERE leaks.id = actions.leak_id AND leaks.tenant_id = @tenant_id)
+  AND (cardinality(@action_types::text[]) = 0 OR action_type::text = ANY(@action_types::text[]))
+  AND (cardinality(@statuses::text[]) = 0 OR status::text = ANY(@statuses::text[]))
+  AND (cardinality(@results::text[]) = 0 OR result::text = ANY(@results::text[]))
+  AND (sqlc.narg('created_from')::timestamptz IS NULL OR created_at >= sqlc.narg('created_from')::timestamptz)
+  AND (sqlc.narg('created_to')::timestamptz IS NULL OR created_at < sqlc.narg('created_to')::timestamptz)
+ORDER BY
+  CASE WHEN @sort_by::text = 'created_at' AND NOT @sort_desc::boolean THEN created_at END ASC,
+  CASE WHEN @sort_by::text = 'created_at' AND @sort_desc::boolean THEN created_at END DESC,
+  CASE WHEN @sort_by::text = 'updated_at' AND NOT @sort_desc::boolean THEN updated_at END ASC,
+  CASE WHEN @sort_by::text = 'updated_at' AND @sort_desc::boolean THEN updated_at END DESC,
+  CASE WHEN @sort_by::text = 'priority' AND NOT @sort_desc::boolean THEN priority END ASC,
+  CASE WHEN @sort_by::text = 'priority' AND @sort_desc::boolean THEN priority END DESC,
+  id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountActionsFiltered :one
+SELECT COUNT(*) FROM actions
+WHERE EXISTS (SELECT 1 FROM leaks WHERE leaks.id = actions.leak_id AND leaks.tenant_id = @tenant_id)
+  AND (cardinality(@action_types::text[]) = 0 OR action_type::text = ANY(@action_types::text[]))
+  AND (cardinality(@statuses::text[]) = 0 OR status::text = ANY(@statuses::text[]))
+  AND (cardinality(@results::text[]) = 0 OR result::text = ANY(@results::text[]))
+  AND (sqlc.narg('created_from')::timestamptz IS NULL OR created_at >= sqlc.narg('created_from')::timestamptz)
+  AND (sqlc.narg('created_to')::timestamptz IS NULL OR created_at < sqlc.narg('created_to')::timestamptz);
+
+-- name: GetPendingActionsByPriority :many
+-- The executor's queue: the pending actions, and the in_progress ones whose claim is older than
+-- lease_seconds because their worker died or hung, that have attempts left below max_attempts.
+-- priority is the leak amount in minor units of the leak's own currency, so it is only
+-- compared within a currency: actions are taken by their rank among the queued actions in
+-- their currency, which interleaves the currencies, then oldest first. Only the picked rows
+-- are locked, and SKIP LOCKED passes over the ones a concurrent claim holds instead of waiting
+-- on them.
+SELECT actions.id, actions.leak_id, actions.action_type, actions.status, actions.result, actions.created_at, actions.updated_at, actions.priority, actions.claimed_by, actions.claimed_at, actions.attempts
+FROM actions
+JOIN (
+    SELECT actions.id, rank() OVER (PARTITION BY leaks.currency ORDER BY actions.priority DESC) AS currency_rank
+    FROM actions
+    JOIN leaks ON leaks.id = actions.leak_id
+    WHERE (actions.status = 'pending'
+           OR (actions.status = 'in_progress' AND actions.claimed_at < NOW() - make_interval(secs => sqlc.arg('lease_seconds')::float8)))
+      AND actions.attempts < @max_attempts::integer
+      AND leaks.tenant_id = @tenant_id
+) queue ON queue.id = actions.id
+WHERE (actions.status = 'pending'
+       OR (actions.status = 'in_progress' AND actions.claimed_at < NOW() - make_interval(secs => sqlc.arg('lease_seconds')::float8)))
+  AND actions.attempts < @max_attempts::integer
+ORDER BY queue.currency_rank, actions.created_at, actions.id
+LIMIT sqlc.arg('limit')
+FOR UPDATE OF actions SKIP LOCKED;
+
+-- name: ClaimActions :many
+-- Claims the actions GetPendingActionsByPriority locked, in the same transaction, taking over
+-- the stale claims among them. Every claim starts an attempt, so an action whose worker dies
+-- still counts towards the cap.
+UPDATE actions
+SET status = 'in_progress', claimed_by = @worker_id, claimed_at = NOW(), attempts = attempts + 1
+WHERE id = ANY(@ids::uuid[])
+RETURNING id, leak_id, action_type, status, result, created_at, updated_at, priority, claimed_by, claimed_at, attempts;
+
+-- name: CompleteAction :one
+-- Ends a claimed action's attempt as a success: the action is approved and done
+UPDATE actions
+SET status = 'approved',
+    result = 'success',
+    claimed_by = NULL,
+    claimed_at = NULL
+WHERE id = @id
+  AND status = 'in_progress'
+  AND EXISTS (SELECT 1 FROM leaks WHERE leaks.id = actions.leak_id AND leaks.tenant_id = @tenant_id)
+RETURNING id, leak_id, action_type, status, result, created_at, updated_at, priority, claimed_by, claimed_at, attempts;
+
+-- name: FailActionAttempt :one
+-- Ends a claimed action's attempt as a failure. Below max_attempts the action goes back to
+-- pending to be claimed again; at the cap it is failed for good, which is never claimed.
+UPDATE actions
+SET status = CASE WHEN attempts >= @max_attempts::integer THEN 'failed'::action_status_enum ELSE 'pending'::action_status_enum END,
+    result = 'failure',
+    claimed_by = NULL,
+    claimed_at = NULL
+WHERE id = @id
+  AND status = 'in_progress'
+  AND EXISTS (SELECT 1 FROM leaks WHERE leaks.id = actions.leak_id AND leaks.tenant_id = @tenant_id)
+RETURNING id, leak_id, action_type, status, result, created_at, updated_at, priority, claimed_by, claimed_at, attempts;
+
+-- name: FailExhaustedActions :execrows
+-- Fails the tenant's actions that have used up max_attempts but are still queued: a stale claim
+-- on the last attempt, whose worker never reported back, or a pending action left over from a
+-- higher cap. The queue skips them, so without this they would never leave that state.
+UPDATE actions
+SET status = 'failed',
+    result = 'failure',
+    claimed_by = NULL,
+    claimed_at = NULL
+WHERE (status = 'pending'
+       OR (status = 'in_progress' AND claimed_at < NOW() - make_interval(secs => sqlc.arg('lease_seconds')::float8)))
+  AND attempts >= @max_attempts::integer
+  AND EXISTS (SELECT 1 FROM leaks WHERE leaks.id = actions.leak_id AND leaks.tenant_id = @tenant_id);
+
 -- name: CountAllActions :one
 SELECT COUNT(*) FROM actions;
 
@@ -27,7 +153,7 @@ SET
     status = CASE WHEN sqlc.narg('status')::action_status_enum IS NOT NULL THEN sqlc.narg('status')::action_status_enum ELSE status END, 
     result = CASE WHEN sqlc.narg('result')::action_result_enum IS NOT NULL THEN sqlc.narg('result')::action_result_enum ELSE result END 
 WHERE id = $1 
-RETURNING id, leak_id, action_type, status, result, created_at, updated_at;
+RETURNING id, leak_id, action_type, status, result, created_at, updated_at, priority, claimed_by, claimed_at, attempts;
 
 -- name: DeleteAction :execrows
 DELETE FROM actions WHERE id = $1;
\ No newline at end of file
@@ -1,38 +1,379 @@
 -- name: GetEventByID :one
 SELECT 
-  id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at 
+  id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source 
 FROM events 
 WHERE id = $1;
 
+-- name: GetEventByEventID :one
+SELECT
+  id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source
+FROM events
+WHERE provider_id = $1 AND event_id = $2;
+
+-- name: GetEventCountInWindow :one
+SELECT COUNT(*) FROM events
+WHERE created_at >= @window_start AND created_at < @window_end;
+
+-- name: GetIngestionLagByProviderType :many
+-- Lag from the provider's own timestamp, data.created in Unix seconds, to ingestion. Events
+-- without one are counted in missing_timestamp and left out of the percentile, which is 0
+-- when every event lacks one
+SELECT
+  providers.provider_type,
+  COUNT(*) AS events,
+  COUNT(*) FILTER (WHERE CASE WHEN jsonb_typeof(events.data->'created') = 'number' THEN (events.data->>'created')::float8 <= 0 ELSE true END) AS missing_timestamp,
+  COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY
+    CASE WHEN jsonb_typeof(events.data->'created') = 'number' THEN
+      CASE WHEN (events.data->>'created')::float8 > 0
+        THEN GREATEST(EXTRACT(EPOCH FROM events.created_at) - (events.data->>'created')::float8, 0)
+      END
+    END), 0)::float8 AS p95_lag_seconds
+FROM events
+JOIN providers ON providers.id = events.provider_id
+WHERE events.created_at >= @window_start AND events.created_at < @window_end
+GROUP BY providers.provider_type
+ORDER BY providers.provider_type;
+
+-- name: GetProviderFailureRates :many
+-- Payment attempts, the payment_failed and payment_succeeded events, per provider created in
+-- [window_start, window_end), and how many of them failed. Providers without attempts in the
+-- window are left out
+SELECT
+  events.provider_id,
+  providers.name AS provider_name,
+  COUNT(*) FILTER (WHERE events.event_type = 'payment_failed') AS failed,
+  COUNT(*) AS total
+FROM events
+JOIN providers ON providers.id = events.provider_id
+WHERE events.tenant_id = @tenant_id
+  AND events.event_type IN ('payment_failed', 'payment_succeeded')
+  AND events.created_at >= @window_start AND events.created_at < @window_end
+GROUP BY events.provider_id, providers.name
+ORDER BY events.provider_id;
+
+-- name: GetEventStatusHistory :many
+-- Oldest change first; id orders changes made at the same instant
+SELECT id, event_id, tenant_id, from_status, to_status, changed_at
+FROM event_status_history
+WHERE event_id = $1
+ORDER BY changed_at ASC, id ASC;
+
+-- name: GetRelatedEvents :many
+-- matches holds one containment document per object path the correlation keys may sit under
+SELECT
+  id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source
+FROM events
+WHERE id <> @id
+  AND created_at BETWEEN @window_start AND @window_end
+  AND data @> ANY(@matches::jsonb[])
+ORDER BY created_at ASC;
+
+-- name: GetEventsByLeakID :many
+SELECT
+  events.id, events.tenant_id, events.provider_id, events.event_type, events.event_id, events.status, events.data, events.created_at, events.updated_at, events.source
+FROM events
+JOIN leak_events ON leak_events.event_id = events.id
+WHERE leak_events.leak_id = $1
+ORDER BY events.created_at ASC;
+
+-- name: GetEventsBySource :many
+-- Events that entered the system one way, newest first; tenant_id is matched explicitly so
+-- idx_events_tenant_source_created_at serves the filter and sort
+SELECT
+  id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source
+FROM events
+WHERE tenant_id = @tenant_id AND source = @source
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountEventsBySource :one
+SELECT COUNT(*)
+FROM events
+WHERE tenant_id = @tenant_id AND source = @source;
+
+-- name: GetEventsWithoutLeak :many
+-- Events of one type that no leak is linked to, newest first, for detection coverage reports
+SELECT
+  events.id, events.tenant_id, events.provider_id, events.event_type, events.event_id, events.status, events.data, events.created_at, events.updated_at, events.source
+FROM events
+LEFT JOIN leak_events ON leak_events.event_id = events.id
+WHERE events.event_type = $1 AND leak_events.leak_id IS NULL
+ORDER BY events.created_at DESC, events.id
+LIMIT $2 OFFSET $3;
+
+-- name: CountEventsWithoutLeak :one
+SELECT COUNT(*)
+FROM events
+LEFT JOIN leak_events ON leak_events.event_id = events.id
+WHERE events.event_type = $1 AND leak_events.leak_id IS NULL;
+
+-- name: GetEventsBefore :many
+-- Keyset pagination on (created_at, id), so an archive can stream every event older than a cutoff page by page
+SELECT
+  id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source
+FROM events
+WHERE tenant_id = @tenant_id
+  AND created_at < @before
+  AND (created_at, id) > (@after_created_at::timestamptz, @after_id::uuid)
+ORDER BY created_at, id
+LIMIT @max_rows;
+
+-- name: GetEventsByIDs :many
+SELECT
+  id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source
+FROM events
+WHERE id = ANY(sqlc.arg('ids')::uuid[]);
+
+-- Keyset pagination on id, so a caller can resume after the last event it saw
+-- name: GetFailedEvents :many
+SELECT
+  id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source
+FROM events
+WHERE status = 'failed' AND id > @after_id
+ORDER BY id ASC
+LIMIT @max_rows;
+
+-- name: ClaimPendingEvents :many
+-- Oldest pending events first; SKIP LOCKED lets concurrent workers each claim a different set
+UPDATE events
+SET status = 'processing'
+WHERE id IN (
+    SELECT id FROM events
+    WHERE status = 'pending'
+    ORDER BY created_at ASC, id ASC
+    LIMIT sqlc.arg('limit')
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source;
+
+-- name: GetRecentEvents :many
+-- tenant_id is matched explicitly, not only through RLS, so idx_events_tenant_created_at serves the sort and limit
+SELECT
+  id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source
+FROM events
+WHERE tenant_id = @tenant_id
+ORDER BY created_at DESC, id DESC
+LIMIT @max_rows;
+
 -- name: CreateEvent :one
-INSERT INTO events (tenant_id, provider_id, event_type, event_id, status, data) 
-VALUES ($1, $2, $3, $4, $5, $6) 
-RETURNING id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at;
+INSERT INTO events (tenant_id, provider_id, event_type, event_id, status, data, source) 
+VALUES ($1, $2, $3, $4, $5, $6, $7) 
+RETURNING id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source;
 
 -- name: GetAllEvents :many
-SELECT id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at 
+SELECT id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source 
 FROM events
 ORDER BY created_at DESC
 LIMIT $1 OFFSET $2;
 
 -- name: GetAllEventsPaginated :many
-SELECT id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at 
+SELECT id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source 
 FROM events
 ORDER BY created_at DESC
 LIMIT $1 OFFSET $2;
 
+-- name: ListEventsByFilter :many
+-- The filters are cast to the enum types so the event type and status indexes can serve them,
+-- and id breaks created_at ties so offset pages neither skip nor repeat events
+SELECT id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source
+FROM events
+WHERE (cardinality(@event_types::text[]) = 0 OR event_type = ANY(@event_types::text[]::event_type_enum[]))
+  AND (cardinality(@statuses::text[]) = 0 OR status = ANY(@statuses::text[]::event_status_enum[]))
+  AND (cardinality(@provider_ids::uuid[]) = 0 OR provider_id = ANY(@provider_ids::uuid[]))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: ListEventsByFilterAfterID :many
+-- Keyset pages in ID order, so a pass over every matching event neither skips nor repeats events inserted meanwhile
+SELECT id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source
+FROM events
+WHERE id > @after_id
+  AND (cardinality(@event_types::text[]) = 0 OR event_type = ANY(@event_types::text[]::event_type_enum[]))
+  AND (cardinality(@statuses::text[]) = 0 OR status = ANY(@statuses::text[]::event_status_enum[]))
+  AND (cardinality(@provider_ids::uuid[]) = 0 OR provider_id = ANY(@provider_ids::uuid[]))
+ORDER BY id ASC
+LIMIT @max_rows;
+
+-- name: ListEventsForExport :many
+-- Keyset pagination on (created_at, id), newest first, so an export can stream every event page
+-- by page; idx_events_tenant_created_at serves it
+SELECT id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source
+FROM events
+WHERE (created_at, id) < (@after_created_at::timestamptz, @after_id::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT @max_rows;
+
+-- name: SearchEventsByExternalIDPrefix :many
+-- pattern is a LIKE prefix pattern with its wildcards escaped; idx_events_tenant_event_id_prefix serves it
+SELECT id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source
+FROM events
+WHERE tenant_id = @tenant_id AND event_id LIKE @pattern
+ORDER BY event_id, id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountEventsByExternalIDPrefix :one
+SELECT COUNT(*) FROM events
+WHERE tenant_id = @tenant_id AND event_id LIKE @pattern;
+
+-- name: CountEventsByFilter :one
+SELECT COUNT(*) FROM events
+WHERE (cardinality(@event_types::text[]) = 0 OR event_type = ANY(@event_types::text[]::event_type_enum[]))
+  AND (cardinality(@statuses::text[]) = 0 OR status = ANY(@statuses::text[]::event_status_enum[]))
+  AND (cardinality(@provider_ids::uuid[]) = 0 OR provider_id = ANY(@provider_ids::uuid[]));
+
+-- Callers must refuse an empty filter, which would update every event of the tenant
+-- name: UpdateEventStatusByFilter :execrows
+UPDATE events
+SET status = @new_status
+WHERE (cardinality(@event_types::text[]) = 0 OR event_type = ANY(@event_types::text[]::event_type_enum[]))
+  AND (cardinality(@statuses::text[]) = 0 OR status = ANY(@statuses::text[]::event_status_enum[]))
+  AND (cardinality(@provider_ids::uuid[]) = 0 OR provider_id = ANY(@provider_ids::uuid[]));
+
+-- name: ReattributeEvents :execrows
+-- Moves the tenant's events from one provider to another, for events ingested under the wrong
+-- provider. An event ID the target already has fails on idx_events_tenant_provider_event_id.
+UPDATE events
+SET provider_id = @to_provider_id
+WHERE tenant_id = @tenant_id AND provider_id = @from_provider_id;
+
 -- name: CountAllEvents :one
 SELECT COUNT(*) FROM events;
 
+-- name: HasAnyEvents :one
+-- EXISTS stops at the first matching row, unlike COUNT(*) which visits them all
+SELECT EXISTS (SELECT 1 FROM events WHERE tenant_id = $1);
+
+-- name: CountEventsByStatusForProvider :many
+SELECT status, COUNT(*) AS count
+FROM events
+WHERE provider_id = $1
+GROUP BY status;
+
 -- it is not business logic to update the tenant_id, provider_id, event_id
 -- name: UpdateEvent :one
+-- A NULL argument leaves its column unchanged, so retrying a partial update is safe.
+-- With merge_data, an object data is merged into a stored object instead of replacing it.
 UPDATE events
 SET
-  event_type = CASE WHEN sqlc.narg('event_type')::event_type_enum IS NOT NULL THEN sqlc.narg('event_type')::event_type_enum ELSE event_type END,
-  status = CASE WHEN sqlc.narg('status')::event_status_enum IS NOT NULL THEN sqlc.narg('status')::event_status_enum ELSE status END,
-  data = CASE WHEN sqlc.narg('data')::jsonb IS NOT NULL THEN sqlc.narg('data')::jsonb ELSE data END
+  event_type = COALESCE(sqlc.narg('event_type')::event_type_enum, event_type),
+  status = COALESCE(sqlc.narg('status')::event_status_enum, status),
+  data = CASE
+    WHEN sqlc.arg('merge_data')::boolean
+      AND jsonb_typeof(data) = 'object'
+      AND jsonb_typeof(sqlc.narg('data')::jsonb) = 'object'
+    THEN data || sqlc.narg('data')::jsonb
+    ELSE COALESCE(sqlc.narg('data')::jsonb, data)
+  END
 WHERE id = sqlc.arg('id')
-RETURNING id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at;
+RETURNING id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source;
+
+-- name: UpdateEventIfVersion :one
+UPDATE events
+SET
+  event_type = COALESCE(sqlc.narg('event_type')::event_type_enum, event_type),
+  status = COALESCE(sqlc.narg('status')::event_status_enum, status),
+  data = CASE
+    WHEN sqlc.arg('merge_data')::boolean
+      AND jsonb_typeof(data) = 'object'
+      AND jsonb_typeof(sqlc.narg('data')::jsonb) = 'object'
+    THEN data || sqlc.narg('data')::jsonb
+    ELSE COALESCE(sqlc.narg('data')::jsonb, data)
+  END
+WHERE id = sqlc.arg('id') AND updated_at = sqlc.arg('expected_updated_at')
+RETURNING id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source;
 
 -- name: DeleteEvent :execrows
 DELETE FROM events WHERE id = $1;
+
+-- name: PurgeEventsBefore :execrows
+-- Deletes at most $3 of the tenant's events created before $2, oldest first, so each call holds its locks briefly
+DELETE FROM events
+WHERE id IN (
+  SELECT id FROM events
+  WHERE tenant_id = $1 AND created_at < $2
+  ORDER BY created_at
+  LIMIT $3
+);
+
+-- name: FindDuplicateCharges :many
+-- A payment_succeeded event duplicates the one before it with the same customer_id, amount and
+-- currency in its data when it follows it within the window. Only numeric amounts are compared,
+-- and duplicates a duplicate_charge leak already points at are left out.
+WITH charges AS (
+  SELECT
+    id,
+    created_at,
+    data->>'customer_id' AS customer_ref,
+    (data->>'amount')::numeric AS amount,
+    UPPER(COALESCE(data->>'currency', '')) AS currency,
+    LAG(id) OVER charge_group AS original_id,
+    LAG(created_at) OVER charge_group AS original_created_at
+  FROM events
+  WHERE event_type = 'payment_succeeded'
+    AND created_at >= @since
+    AND data->>'customer_id' IS NOT NULL
+    AND jsonb_typeof(data->'amount') = 'number'
+  WINDOW charge_group AS (
+    PARTITION BY data->>'customer_id', (data->>'amount')::numeric, UPPER(COALESCE(data->>'currency', ''))
+    ORDER BY created_at, id
+  )
+)
+SELECT
+  charges.id, charges.original_id, charges.customer_ref, customers.id AS customer_id,
+  charges.amount, charges.currency, charges.created_at, charges.original_created_at
+FROM charges
+LEFT JOIN customers ON customers.external_id = charges.customer_ref
+WHERE charges.original_id IS NOT NULL
+  AND charges.created_at - charges.original_created_at <= make_interval(secs => @window_seconds::float8)
+  AND NOT EXISTS (
+    SELECT 1 FROM leaks
+    WHERE leaks.source_event_id = charges.id AND leaks.leak_type = 'duplicate_charge'
+  )
+ORDER BY charges.created_at, charges.id;
+
+-- name: FindDunningGaps :many
+-- A payment_failed event has a dunning gap when no payment_failed, payment_succeeded or
+-- payment_updated event with the same customer_id follows it within the dunning window, the
+-- tenant's dunning_window_hours or else the default. Only failures whose window has passed by
+-- @now and began within the lookback before that are checked, and failures a dunning_gap leak
+-- already points at or is linked to are left out.
+WITH settings AS (
+  SELECT COALESCE(
+    (SELECT make_interval(hours => tenants.dunning_window_hours) FROM tenants WHERE tenants.id = @tenant_id),
+    make_interval(secs => @default_window_seconds::float8)
+  ) AS dunning_window
+)
+SELECT
+  failed.id,
+  failed.data->>'customer_id' AS customer_ref,
+  failed.data->>'subscription_id' AS subscription_ref,
+  customers.id AS customer_id,
+  CASE WHEN jsonb_typeof(failed.data->'amount') = 'number' THEN (failed.data->>'amount')::numeric END AS amount,
+  UPPER(COALESCE(failed.data->>'currency', '')) AS currency,
+  failed.created_at,
+  EXTRACT(EPOCH FROM settings.dunning_window)::float8 AS window_seconds
+FROM events failed
+CROSS JOIN settings
+LEFT JOIN customers ON customers.external_id = failed.data->>'customer_id'
+WHERE failed.event_type = 'payment_failed'
+  AND failed.data->>'customer_id' IS NOT NULL
+  AND failed.created_at <= @now::timestamptz - settings.dunning_window
+  AND failed.created_at > @now::timestamptz - settings.dunning_window - make_interval(secs => @lookback_seconds::float8)
+  AND NOT EXISTS (
+    SELECT 1 FROM events follow_up
+    WHERE follow_up.event_type IN ('payment_failed', 'payment_succeeded', 'payment_updated')
+      AND follow_up.data->>'customer_id' = failed.data->>'customer_id'
+      AND follow_up.id <> failed.id
+      AND follow_up.created_at >= failed.created_at
+      AND follow_up.created_at <= failed.created_at + settings.dunning_window
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM leaks
+    WHERE leaks.source_event_id = failed.id AND leaks.leak_type = 'dunning_gap'
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM leak_events
+    JOIN leaks ON leaks.id = leak_events.leak_id
+    WHERE leak_events.event_id = failed.id AND leaks.leak_type = 'dunning_gap'
+  )
+ORDER BY failed.created_at, failed.id;
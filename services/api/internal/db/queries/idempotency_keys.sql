@@ -0,0 +1,17 @@
+-- name: GetIdempotencyKey :one
+-- An expired key is not returned, even before it is deleted
+SELECT tenant_id, idempotency_key, request_method, request_path, status_code, content_type, body, created_at, expires_at, request_hash, headers
+FROM idempotency_keys
+WHERE tenant_id = $1 AND idempotency_key = $2 AND expires_at > NOW();
+
+-- name: SaveIdempotencyKey :execrows
+-- The first response stored for a key wins: a second request with the same key that finished
+-- later, on this instance or another, affects no rows
+INSERT INTO idempotency_keys (tenant_id, idempotency_key, request_method, request_path, status_code, content_type, body, expires_at, request_hash, headers)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT (tenant_id, idempotency_key) DO NOTHING;
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+-- Frees the tenant's expired keys, so a key can be stored again once its response is no longer replayed
+DELETE FROM idempotency_keys
+WHERE tenant_id = $1 AND expires_at <= NOW();
@@ -0,0 +1,199 @@
+-- name: CreateLeak :one
+-- Stores a new leak unless its source event already has a leak of the same type, in which
+-- case nothing is stored and no row is returned
+INSERT INTO leaks (tenant_id, customer_id, leak_type, amount, confidence, status, currency, source_event_id, detected_at, metadata, dedup_key)
+VALUES (
+  sqlc.arg('tenant_id'), sqlc.narg('customer_id'), sqlc.arg('leak_type'), sqlc.arg('amount'), sqlc.arg('confidence'),
+  sqlc.arg('status'), sqlc.arg('currency'), sqlc.narg('source_event_id'),
+  COALESCE(sqlc.narg('detected_at')::timestamptz, NOW()), sqlc.arg('metadata'), sqlc.narg('dedup_key')
+)
+ON CONFLICT (tenant_id, leak_type, source_event_id) WHERE source_event_id IS NOT NULL DO NOTHING
+RETURNING id, tenant_id, customer_id, leak_type, amount, confidence, created_at, updated_at, payment_id, status, currency, source_event_id, detected_at, resolved_at, metadata, snoozed_until, dedup_key, occurrences;
+
+-- name: UpsertLeakByDedupKey :one
+-- Stores a new leak, unless the tenant already has an open leak with the same dedup key and
+-- currency: that leak then counts one more occurrence, its amount grows by the new amount and
+-- it keeps the higher confidence, while the rest of it, including when it was detected, is
+-- unchanged
+INSERT INTO leaks (tenant_id, customer_id, leak_type, amount, confidence, status, currency, source_event_id, detected_at, metadata, dedup_key)
+VALUES (
+  sqlc.arg('tenant_id'), sqlc.narg('customer_id'), sqlc.arg('leak_type'), sqlc.arg('amount'), sqlc.arg('confidence'),
+  sqlc.arg('status'), sqlc.arg('currency'), sqlc.narg('source_event_id'),
+  COALESCE(sqlc.narg('detected_at')::timestamptz, NOW()), sqlc.arg('metadata'), sqlc.arg('dedup_key')
+)
+ON CONFLICT (tenant_id, dedup_key, currency) WHERE status = 'open' AND dedup_key IS NOT NULL
+DO UPDATE SET
+  amount = leaks.amount + EXCLUDED.amount,
+  confidence = GREATEST(leaks.confidence, EXCLUDED.confidence),
+  occurrences = leaks.occurrences + 1
+RETURNING id, tenant_id, customer_id, leak_type, amount, confidence, created_at, updated_at, payment_id, status, currency, source_event_id, detected_at, resolved_at, metadata, snoozed_until, dedup_key, occurrences;
+
+-- name: LinkLeakEvent :exec
+-- Records that an event contributed to a leak; linking the same event twice is a no-op
+INSERT INTO leak_events (leak_id, event_id, tenant_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (leak_id, event_id) DO NOTHING;
+
+-- name: GetLeakByID :one
+SELECT id, tenant_id, customer_id, leak_type, amount, confidence, created_at, updated_at, payment_id, status, currency, source_event_id, detected_at, resolved_at, metadata, snoozed_until, dedup_key, occurrences
+FROM leaks
+WHERE id = $1;
+
+-- name: UpdateLeak :one
+-- A leak that is not resolved has no resolved_at; resolving it without a time stamps it now
+UPDATE leaks
+SET
+  leak_type = CASE WHEN sqlc.narg('leak_type')::leak_type_enum IS NOT NULL THEN sqlc.narg('leak_type')::leak_type_enum ELSE leak_type END,
+  amount = CASE WHEN sqlc.narg('amount')::numeric IS NOT NULL THEN sqlc.narg('amount')::numeric ELSE amount END,
+  currency = CASE WHEN sqlc.narg('currency')::varchar IS NOT NULL THEN sqlc.narg('currency')::varchar ELSE currency END,
+  confidence = CASE WHEN sqlc.narg('confidence')::integer IS NOT NULL THEN sqlc.narg('confidence')::integer ELSE confidence END,
+  metadata = CASE WHEN sqlc.narg('metadata')::jsonb IS NOT NULL THEN sqlc.narg('metadata')::jsonb ELSE metadata END,
+  status = CASE WHEN sqlc.narg('status')::leak_status_enum IS NOT NULL THEN sqlc.narg('status')::leak_status_enum ELSE status END,
+  resolved_at = CASE
+    WHEN COALESCE(sqlc.narg('status')::leak_status_enum, status) <> 'resolved' THEN NULL
+    ELSE COALESCE(sqlc.narg('resolved_at')::timestamptz, resolved_at, NOW())
+  END
+WHERE id = sqlc.arg('id')
+RETURNING id, tenant_id, customer_id, leak_type, amount, confidence, created_at, updated_at, payment_id, status, currency, source_event_id, detected_at, resolved_at, metadata, snoozed_until, dedup_key, occurrences;
+
+-- name: FindExistingLeaks :many
+-- Leaks detected at the given time, triggered by one of the given events or sharing one of the
+-- given dedup keys, whatever their status; a backfill uses them to skip candidates that are
+-- already stored
+SELECT id, tenant_id, customer_id, leak_type, amount, confidence, created_at, updated_at, payment_id, status, currency, source_event_id, detected_at, resolved_at, metadata, snoozed_until, dedup_key, occurrences
+FROM leaks
+WHERE detected_at = @detected_at::timestamptz
+   OR source_event_id = ANY(@source_event_ids::uuid[])
+   OR dedup_key = ANY(@dedup_keys::text[]);
+
+-- name: ListLeaksByFilter :many
+-- Largest amount first so the biggest exposure leads; id breaks ties so pages are stable
+SELECT id, tenant_id, customer_id, leak_type, amount, confidence, created_at, updated_at, payment_id, status, currency, source_event_id, detected_at, resolved_at, metadata, snoozed_until, dedup_key, occurrences
+FROM leaks
+WHERE (cardinality(@statuses::text[]) = 0 OR status::text = ANY(@statuses::text[]))
+  AND (cardinality(@leak_types::text[]) = 0 OR leak_type::text = ANY(@leak_types::text[]))
+  AND (sqlc.narg('detected_from')::timestamptz IS NULL OR detected_at >= sqlc.narg('detected_from')::timestamptz)
+  AND (sqlc.narg('detected_to')::timestamptz IS NULL OR detected_at < sqlc.narg('detected_to')::timestamptz)
+  AND (NOT sqlc.arg('exclude_snoozed')::boolean OR snoozed_until IS NULL OR snoozed_until <= NOW())
+ORDER BY amount DESC, detected_at DESC, id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: ListLeaksByFilterAfter :many
+-- Keyset pagination on (detected_at, id), so an export can stream every matching leak page by page
+SELECT id, tenant_id, customer_id, leak_type, amount, confidence, created_at, updated_at, payment_id, status, currency, source_event_id, detected_at, resolved_at, metadata, snoozed_until, dedup_key, occurrences
+FROM leaks
+WHERE (cardinality(@statuses::text[]) = 0 OR status::text = ANY(@statuses::text[]))
+  AND (cardinality(@leak_types::text[]) = 0 OR leak_type::text = ANY(@leak_types::text[]))
+  AND (sqlc.narg('detected_from')::timestamptz IS NULL OR detected_at >= sqlc.narg('detected_from')::timestamptz)
+  AND (sqlc.narg('detected_to')::timestamptz IS NULL OR detected_at < sqlc.narg('detected_to')::timestamptz)
+  AND (NOT sqlc.arg('exclude_snoozed')::boolean OR snoozed_until IS NULL OR snoozed_until <= NOW())
+  AND (detected_at, id) > (@after_detected_at::timestamptz, @after_id::uuid)
+ORDER BY detected_at, id
+LIMIT @max_rows;
+
+-- name: CountLeaksByFilter :one
+SELECT COUNT(*) FROM leaks
+WHERE (cardinality(@statuses::text[]) = 0 OR status::text = ANY(@statuses::text[]))
+  AND (cardinality(@leak_types::text[]) = 0 OR leak_type::text = ANY(@leak_types::text[]))
+  AND (sqlc.narg('detected_from')::timestamptz IS NULL OR detected_at >= sqlc.narg('detected_from')::timestamptz)
+  AND (sqlc.narg('detected_to')::timestamptz IS NULL OR detected_at < sqlc.narg('detected_to')::timestamptz)
+  AND (NOT sqlc.arg('exclude_snoozed')::boolean OR snoozed_until IS NULL OR snoozed_until <= NOW());
+
+-- name: SnoozeLeak :one
+-- A NULL snoozed_until wakes the leak immediately
+UPDATE leaks
+SET snoozed_until = sqlc.narg('snoozed_until')::timestamptz
+WHERE id = sqlc.arg('id')
+RETURNING id, tenant_id, customer_id, leak_type, amount, confidence, created_at, updated_at, payment_id, status, currency, source_event_id, detected_at, resolved_at, metadata, snoozed_until, dedup_key, occurrences;
+
+-- name: CountOpenLeaks :one
+-- A leak snoozed until a time still in the future is not counted
+SELECT COUNT(*) FROM leaks
+WHERE status = 'open'
+  AND (snoozed_until IS NULL OR snoozed_until <= NOW());
+
+-- name: CountAffectedCustomers :one
+-- Leaks without a customer, such as tenant-wide anomalies, are not counted
+SELECT COUNT(DISTINCT customer_id) FROM leaks
+WHERE status = 'open'
+  AND customer_id IS NOT NULL
+  AND detected_at >= @since
+  AND detected_at < @to;
+
+-- name: GetLeakAmountHistogram :many
+-- Buckets are numbered as by width_bucket: 0 holds the amounts below the first bound, i those
+-- from bound i up to bound i+1 and cardinality(@bounds) those from the last bound up. Only open,
+-- unsnoozed leaks are counted, detected no earlier than @since when it is not NULL.
+SELECT
+  currency,
+  width_bucket(amount, sqlc.arg('bounds')::numeric[])::int4 AS bucket,
+  COUNT(*) AS leaks,
+  SUM(amount)::numeric AS total
+FROM leaks
+WHERE status = 'open'
+  AND (snoozed_until IS NULL OR snoozed_until <= NOW())
+  AND (sqlc.narg('since')::timestamptz IS NULL OR detected_at >= sqlc.narg('since')::timestamptz)
+GROUP BY currency, bucket
+ORDER BY currency, bucket;
+
+-- name: GetLeakMTTR :one
+-- Only resolved leaks have a resolved_at; a leak resolved before it was detected counts as 0 seconds
+SELECT
+  COALESCE(AVG(GREATEST(EXTRACT(EPOCH FROM resolved_at - detected_at), 0)), 0)::float8 AS mean_seconds,
+  COUNT(*) AS resolved_count
+FROM leaks
+WHERE resolved_at IS NOT NULL
+  AND resolved_at >= @since
+  AND resolved_at < @to;
+
+-- name: GetLeakTimeSeries :many
+-- One row per bucket and currency of the leaks detected in [@from_time, @to_time), plus one
+-- row with a NULL currency and no leaks for each bucket that has none. Buckets are
+-- date_trunc(@bucket_interval) in UTC, the first one starting where @from_time truncates to.
+WITH buckets AS (
+  SELECT generate_series(
+    date_trunc(@bucket_interval::text, @from_time::timestamptz AT TIME ZONE 'UTC'),
+    @to_time::timestamptz AT TIME ZONE 'UTC',
+    ('1 ' || @bucket_interval::text)::interval
+  ) AS bucket
+),
+totals AS (
+  SELECT
+    date_trunc(@bucket_interval::text, detected_at AT TIME ZONE 'UTC') AS bucket,
+    currency,
+    COUNT(*) AS leaks,
+    SUM(amount) AS total
+  FROM leaks
+  WHERE detected_at >= @from_time::timestamptz
+    AND detected_at < @to_time::timestamptz
+  GROUP BY 1, 2
+)
+SELECT
+  (buckets.bucket AT TIME ZONE 'UTC')::timestamptz AS bucket_start,
+  totals.currency,
+  COALESCE(totals.leaks, 0)::int8 AS leaks,
+  COALESCE(totals.total, 0)::numeric AS total
+FROM buckets
+LEFT JOIN totals ON totals.bucket = buckets.bucket
+WHERE buckets.bucket < @to_time::timestamptz AT TIME ZONE 'UTC'
+ORDER BY buckets.bucket, totals.currency;
+
+-- name: GetRevenueRecovered :many
+-- A leak is recovered when an action on it succeeded and its customer then paid: a
+-- payment_succeeded event with the customer's external ID, created no earlier than the action
+-- and in [@since, @to). Each recovered leak counts once, for its full amount.
+SELECT leaks.currency, SUM(leaks.amount)::numeric AS recovered
+FROM leaks
+JOIN customers ON customers.id = leaks.customer_id
+WHERE EXISTS (
+  SELECT 1 FROM actions
+  JOIN events recovery ON recovery.event_type = 'payment_succeeded'
+    AND recovery.data->>'customer_id' = customers.external_id
+    AND recovery.created_at >= actions.created_at
+  WHERE actions.leak_id = leaks.id
+    AND actions.result = 'success'
+    AND recovery.created_at >= @since
+    AND recovery.created_at < @to
+)
+GROUP BY leaks.currency
+ORDER BY leaks.currency;
@@ -0,0 +1,25 @@
+-- name: CreateNotificationChannel :one
+INSERT INTO notification_channels (tenant_id, channel_type, target, enabled)
+VALUES (sqlc.arg('tenant_id'), sqlc.arg('channel_type'), sqlc.arg('target'), sqlc.arg('enabled'))
+RETURNING id, tenant_id, channel_type, target, enabled, created_at, updated_at;
+
+-- name: GetNotificationChannelByID :one
+SELECT id, tenant_id, channel_type, target, enabled, created_at, updated_at
+FROM notification_channels
+WHERE id = $1;
+
+-- name: ListNotificationChannels :many
+SELECT id, tenant_id, channel_type, target, enabled, created_at, updated_at
+FROM notification_channels
+ORDER BY created_at, id;
+
+-- name: UpdateNotificationChannel :one
+UPDATE notification_channels
+SET
+    target = COALESCE(sqlc.narg('target')::TEXT, target),
+    enabled = COALESCE(sqlc.narg('enabled')::BOOLEAN, enabled)
+WHERE id = sqlc.arg('id')
+RETURNING id, tenant_id, channel_type, target, enabled, created_at, updated_at;
+
+-- name: DeleteNotificationChannel :execrows
+DELETE FROM notification_channels WHERE id = $1;
@@ -0,0 +1,35 @@
+-- name: EnqueueNotification :one
+-- The row is stored with its first attempt already started, since the caller delivers it
+-- straight away; it is only retried once lease_seconds have passed
+INSERT INTO notification_outbox (tenant_id, channel_id, title, body, attempts, next_attempt_at)
+VALUES (sqlc.arg('tenant_id'), sqlc.arg('channel_id'), sqlc.arg('title'), sqlc.arg('body'), 1, NOW() + make_interval(secs => sqlc.arg('lease_seconds')::float8))
+RETURNING id, tenant_id, channel_id, title, body, attempts, next_attempt_at, last_error, failed_at, created_at;
+
+-- name: ClaimDueNotifications :many
+-- Starts the next attempt of the tenant's due deliveries, longest waiting first, and holds them
+-- for lease_seconds so no other worker retries them meanwhile. SKIP LOCKED lets concurrent
+-- workers each claim a different set of rows instead of waiting.
+UPDATE notification_outbox
+SET attempts = attempts + 1, next_attempt_at = NOW() + make_interval(secs => sqlc.arg('lease_seconds')::float8)
+WHERE id IN (
+    SELECT id FROM notification_outbox
+    WHERE tenant_id = sqlc.arg('tenant_id') AND failed_at IS NULL AND next_attempt_at <= NOW()
+    ORDER BY next_attempt_at, id
+    LIMIT sqlc.arg('limit')
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, tenant_id, channel_id, title, body, attempts, next_attempt_at, last_error, failed_at, created_at;
+
+-- name: FailOutboxAttempt :one
+-- Ends an attempt as a failure. Below max_attempts the delivery is due again after
+-- backoff_seconds; at the cap the outbox gives up on it and keeps the row for inspection.
+UPDATE notification_outbox
+SET last_error = sqlc.arg('last_error'),
+    next_attempt_at = NOW() + make_interval(secs => sqlc.arg('backoff_seconds')::float8),
+    failed_at = CASE WHEN attempts >= sqlc.arg('max_attempts')::integer THEN NOW() ELSE NULL END
+WHERE id = sqlc.arg('id')
+RETURNING id, tenant_id, channel_id, title, body, attempts, next_attempt_at, last_error, failed_at, created_at;
+
+-- name: DeleteOutboxNotification :execrows
+-- A delivered notification leaves the outbox
+DELETE FROM notification_outbox WHERE id = $1;
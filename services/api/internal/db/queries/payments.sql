@@ -0,0 +1,50 @@
+-- name: CreatePayment :one
+INSERT INTO payments (tenant_id, customer_id, provider_id, event_id, external_id, amount, currency, status, payment_type)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, tenant_id, customer_id, external_id, amount, currency, status, payment_type, created_at, updated_at, provider_id, event_id;
+
+-- name: FindCurrencyMismatches :many
+-- Payments with the same external ID belong to the same charge, such as a charge and its refund,
+-- so they should share its currency. The earliest is the original; a later one created since
+-- @since in another currency is a mismatch. Only payments read from an event are reported, and
+-- those a currency_mismatch leak already points at are left out.
+WITH related AS (
+  SELECT
+    id, external_id, customer_id, event_id, amount, currency, created_at,
+    FIRST_VALUE(id) OVER charge AS original_id,
+    FIRST_VALUE(amount) OVER charge AS original_amount,
+    FIRST_VALUE(currency) OVER charge AS original_currency
+  FROM payments
+  WHERE external_id IN (SELECT external_id FROM payments WHERE created_at >= @since)
+  WINDOW charge AS (PARTITION BY external_id ORDER BY created_at, id)
+)
+SELECT
+  related.id, related.original_id::uuid AS original_id, related.external_id, related.customer_id,
+  related.event_id::uuid AS event_id, events.event_type, related.amount, related.currency,
+  related.original_amount::numeric AS original_amount, related.original_currency::varchar AS original_currency,
+  related.created_at
+FROM related
+JOIN events ON events.id = related.event_id
+WHERE UPPER(related.currency) <> UPPER(related.original_currency)
+  AND related.created_at >= @since
+  AND NOT EXISTS (
+    SELECT 1 FROM leaks
+    WHERE leaks.source_event_id = related.event_id
+      AND leaks.leak_type = 'currency_mismatch'
+  )
+ORDER BY related.created_at, related.id;
+
+-- name: GetPaymentByID :one
+SELECT id, tenant_id, customer_id, external_id, amount, currency, status, payment_type, created_at, updated_at, provider_id, event_id
+FROM payments
+WHERE id = $1;
+
+-- name: UpdatePayment :one
+UPDATE payments
+SET
+  customer_id = CASE WHEN sqlc.narg('customer_id')::uuid IS NOT NULL THEN sqlc.narg('customer_id')::uuid ELSE customer_id END,
+  amount = CASE WHEN sqlc.narg('amount')::numeric IS NOT NULL THEN sqlc.narg('amount')::numeric ELSE amount END,
+  currency = CASE WHEN sqlc.narg('currency')::varchar IS NOT NULL THEN sqlc.narg('currency')::varchar ELSE currency END,
+  status = CASE WHEN sqlc.narg('status')::payment_status_enum IS NOT NULL THEN sqlc.narg('status')::payment_status_enum ELSE status END
+WHERE id = sqlc.arg('id')
+RETURNING id, tenant_id, customer_id, external_id, amount, currency, status, payment_type, created_at, updated_at, provider_id, event_id;
@@ -0,0 +1,30 @@
+-- name: CreateProvider :one
+INSERT INTO providers (name, provider_type)
+VALUES ($1, $2)
+RETURNING id, name, created_at, updated_at, provider_type;
+
+-- name: ListTenantProviders :many
+-- Providers the tenant has an integration with or has received events from, each with the
+-- tenant's latest event from it. idx_events_tenant_provider_created_at serves the lateral lookup.
+SELECT p.id, p.name, p.provider_type, p.created_at, p.updated_at, last_event.created_at::timestamptz AS last_event_at
+FROM providers p
+LEFT JOIN LATERAL (
+  SELECT e.created_at
+  FROM events e
+  WHERE e.tenant_id = @tenant_id AND e.provider_id = p.id
+  ORDER BY e.created_at DESC
+  LIMIT 1
+) last_event ON true
+WHERE last_event.created_at IS NOT NULL
+   OR EXISTS (SELECT 1 FROM integrations i WHERE i.tenant_id = @tenant_id AND i.provider_id = p.id)
+ORDER BY p.name, p.id;
+
+-- name: IsTenantProvider :one
+-- A provider belongs to the tenant when it is on the tenant's allowlist, or when the tenant has
+-- an integration with it or has received events from it as in ListTenantProviders
+SELECT EXISTS (SELECT 1 FROM tenants t WHERE t.id = @tenant_id AND @provider_id::uuid = ANY(t.allowed_provider_ids))
+    OR EXISTS (SELECT 1 FROM integrations i WHERE i.tenant_id = @tenant_id AND i.provider_id = @provider_id)
+    OR EXISTS (SELECT 1 FROM events e WHERE e.tenant_id = @tenant_id AND e.provider_id = @provider_id);
+
+-- name: GetProviderTypeByID :one
+SELECT provider_type FROM providers WHERE id = $1;
@@ -0,0 +1,33 @@
+-- name: GetTenantAcceptedEventTypes :one
+SELECT accepted_event_types::text[] AS accepted_event_types FROM tenants WHERE id = $1;
+
+-- name: GetTenantAllowedProviderIDs :one
+SELECT allowed_provider_ids FROM tenants WHERE id = $1;
+
+-- name: GetTenantLeakSources :one
+SELECT leak_sources FROM tenants WHERE id = $1;
+
+-- name: GetTenantMinLeakAmounts :one
+SELECT min_leak_amounts FROM tenants WHERE id = $1;
+
+-- name: ListTenantEventRetention :many
+-- Every tenant with its own event retention override, for the purge job
+SELECT id, event_retention_days FROM tenants ORDER BY id;
+
+-- name: ListTenantIDs :many
+-- Every tenant, for the detection scheduler
+SELECT id FROM tenants ORDER BY id;
+
+-- name: ListTenantRateLimits :many
+-- Every tenant with its own request rate limit, for the rate limiter
+SELECT id, rate_limit_rps, rate_limit_burst FROM tenants
+WHERE rate_limit_rps IS NOT NULL OR rate_limit_burst IS NOT NULL
+ORDER BY id;
+
+-- name: TryLockTenantDetection :one
+-- Takes the session advisory lock that lets one leak detection run at a time per tenant, across
+-- every instance, without waiting if another session holds it
+SELECT pg_try_advisory_lock(hashtextextended('leak_detection:' || @tenant_id::uuid::text, 0)) AS locked;
+
+-- name: UnlockTenantDetection :one
+SELECT pg_advisory_unlock(hashtextextended('leak_detection:' || @tenant_id::uuid::text, 0)) AS unlocked;
@@ -7,18 +7,20 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"math"
 	db "rdl-api/internal/db/sqlc"
 	"rdl-api/internal/domain/models"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 // ActionsRepositoryImplementation implements the ActionsRepository interface using sqlc-generated queries.
 type ActionsRepositoryImplementation struct {
-	Pool   *pgxpool.Pool
+	Pool   *Pool
 	Tx     pgx.Tx
 	Logger *slog.Logger
 }
@@ -45,7 +47,7 @@ func (r *ActionsRepositoryImplementation) CreateAction(ctx context.Context, arg
 			return r.handleDatabaseError(ctx, err, &arg.LeakID, &tenantID)
 		}
 
-		action = toActionDomain(dbAction)
+		action = toActionDomain(r.Pool.unknownEnums(), dbAction)
 		r.Logger.InfoContext(ctx, "Action created successfully", "action_id", action.ID, "leak_id", arg.LeakID, "tenant_id", tenantID)
 		return nil
 	})
@@ -119,7 +121,7 @@ func (r *ActionsRepositoryImplementation) GetAllActions(ctx context.Context, ten
 		// Convert to domain models
 		actions = make([]models.Action, 0, len(dbActions))
 		for _, dbAction := range dbActions {
-			actions = append(actions, toActionDomain(dbAction))
+			actions = append(actions, toActionDomain(r.Pool.unknownEnums(), dbAction))
 		}
 
 		r.Logger.DebugContext(ctx, "Retrieved actions successfully", "tenant_id", tenantID, "count", len(actions))
@@ -170,7 +172,7 @@ func (r *ActionsRepositoryImplementation) GetAllActionsPaginated(ctx context.Con
 		// Convert to domain models
 		actions = make([]models.Action, 0, len(dbActions))
 		for _, dbAction := range dbActions {
-			actions = append(actions, toActionDomain(dbAction))
+			actions = append(actions, toActionDomain(r.Pool.unknownEnums(), dbAction))
 		}
 
 		r.Logger.DebugContext(ctx, "Retrieved paginated actions successfully", "tenant_id", tenantID, "count", len(actions), "total_count", totalCount)
@@ -185,21 +187,92 @@ func (r *ActionsRepositoryImplementation) GetAllActionsPaginated(ctx context.Con
 	return models.NewPaginatedResponse(actions, totalCount, params.Limit, params.Offset), nil
 }
 
+// GetActionsFiltered returns a page of the tenant's actions across all its leaks matching filter,
+// ordered by sort. The total count uses the same filter, so it matches the pages.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant whose leaks the actions belong to.
+//   - filter: The action types, statuses, results and creation range to match.
+//   - params: The page to return.
+//   - sort: The order of the actions.
+//
+// Returns:
+//   - models.PaginatedResponse[models.Action]: The page with the total number of matching actions.
+//   - error: Any error encountered during retrieval.
+func (r *ActionsRepositoryImplementation) GetActionsFiltered(ctx context.Context, tenantID uuid.UUID, filter models.ActionFilter, params models.PaginationParams, sort models.ActionSort) (models.PaginatedResponse[models.Action], error) {
+	r.Logger.DebugContext(ctx, "Listing filtered actions", "tenant_id", tenantID, "action_types", filter.ActionTypes, "statuses", filter.Statuses, "results", filter.Results, "sort", sort.Field, "limit", params.Limit, "offset", params.Offset)
+
+	args := toActionFilterDBArgs(filter)
+
+	var actions []models.Action
+	var totalCount int64
+	err := WithTenantContext(ctx, r.Pool, tenantID, func(queries *db.Queries) error {
+		count, err := queries.CountActionsFiltered(ctx, db.CountActionsFilteredParams{
+			TenantID:    convertUUIDToPgtypeUUID(tenantID),
+			ActionTypes: args.actionTypes,
+			Statuses:    args.statuses,
+			Results:     args.results,
+			CreatedFrom: args.createdFrom,
+			CreatedTo:   args.createdTo,
+		})
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, nil, &tenantID)
+		}
+		totalCount = count
+
+		dbActions, err := queries.GetActionsFiltered(ctx, db.GetActionsFilteredParams{
+			TenantID:    convertUUIDToPgtypeUUID(tenantID),
+			ActionTypes: args.actionTypes,
+			Statuses:    args.statuses,
+			Results:     args.results,
+			CreatedFrom: args.createdFrom,
+			CreatedTo:   args.createdTo,
+			SortBy:      string(sort.Field),
+			SortDesc:    sort.Descending,
+			Limit:       params.Limit,
+			Offset:      params.Offset,
+		})
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, nil, &tenantID)
+		}
+
+		actions = make([]models.Action, 0, len(dbActions))
+		for _, dbAction := range dbActions {
+			actions = append(actions, toActionDomain(r.Pool.unknownEnums(), dbAction))
+		}
+		return nil
+	})
+
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "Failed to list filtered actions", "error", err, "tenant_id", tenantID)
+		return models.PaginatedResponse[models.Action]{}, err
+	}
+
+	return models.NewPaginatedResponse(actions, totalCount, params.Limit, params.Offset), nil
+}
+
 // GetActionByID retrieves an action from the database by its UUID.
+// Ownership is checked in the query itself as well as by row-level security, so an action of
+// another tenant is reported as not found rather than revealing that it exists.
 //
 // Parameters:
 //   - ctx: Context for request-scoped values, cancellation, and deadlines.
 //   - id: UUID of the action to retrieve.
+//   - tenantID: UUID of the tenant that must own the action's leak.
 //
 // Returns:
 //   - models.Action: The action as a domain model.
-//   - error: Any error encountered during retrieval.
+//   - error: ErrActionNotFound if the action does not exist or belongs to another tenant, or any other error encountered during retrieval.
 func (r *ActionsRepositoryImplementation) GetActionByID(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (models.Action, error) {
 	r.Logger.DebugContext(ctx, "Retrieving action by ID", "action_id", id, "tenant_id", tenantID)
 
 	var action models.Action
 	err := WithTenantContext(ctx, r.Pool, tenantID, func(queries *db.Queries) error {
-		dbAction, err := queries.GetActionByID(ctx, convertUUIDToPgtypeUUID(id))
+		dbAction, err := queries.GetActionByID(ctx, db.GetActionByIDParams{
+			ID:       convertUUIDToPgtypeUUID(id),
+			TenantID: convertUUIDToPgtypeUUID(tenantID),
+		})
 		if err != nil {
 			if errors.Is(err, pgx.ErrNoRows) {
 				errWraper := ErrActionNotFound
@@ -209,19 +282,287 @@ func (r *ActionsRepositoryImplementation) GetActionByID(ctx context.Context, id
 			return r.handleDatabaseError(ctx, err, &id, &tenantID)
 		}
 
-		action = toActionDomain(dbAction)
+		action = toActionDomain(r.Pool.unknownEnums(), dbAction)
 		r.Logger.DebugContext(ctx, "Retrieved action successfully", "action_id", id, "tenant_id", tenantID)
 		return nil
 	})
 
 	if err != nil {
-		r.Logger.ErrorContext(ctx, "Failed to retrieve action by ID", "error", err, "action_id", id, "tenant_id", tenantID)
+		// Not found is already logged as a warning; a probe for another tenant's action is not a failure
+		if !errors.Is(err, ErrActionNotFound) {
+			r.Logger.ErrorContext(ctx, "Failed to retrieve action by ID", "error", err, "action_id", id, "tenant_id", tenantID)
+		}
 		return models.Action{}, err
 	}
 
 	return action, nil
 }
 
+// GetActionWithLeak retrieves an action together with the leak it remediates in a single query.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - id: UUID of the action to retrieve.
+//   - tenantID: UUID of the tenant that owns the action.
+//
+// Returns:
+//   - models.Action: The action as a domain model.
+//   - models.Leak: The action's leak as a domain model.
+//   - error: ErrActionNotFound if the action does not exist, or any other error encountered during retrieval.
+func (r *ActionsRepositoryImplementation) GetActionWithLeak(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (models.Action, models.Leak, error) {
+	r.Logger.DebugContext(ctx, "Retrieving action with leak", "action_id", id, "tenant_id", tenantID)
+
+	var action models.Action
+	var leak models.Leak
+	err := WithTenantContext(ctx, r.Pool, tenantID, func(queries *db.Queries) error {
+		row, err := queries.GetActionWithLeak(ctx, db.GetActionWithLeakParams{
+			ID:       convertUUIDToPgtypeUUID(id),
+			TenantID: convertUUIDToPgtypeUUID(tenantID),
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				r.Logger.WarnContext(ctx, "Action not found", "action_id", id, "tenant_id", tenantID)
+				return ErrActionNotFound
+			}
+			return r.handleDatabaseError(ctx, err, &id, &tenantID)
+		}
+
+		action = toActionDomain(r.Pool.unknownEnums(), row.Action)
+		leak = toLeakDomain(r.Pool.unknownEnums(), row.Leak)
+		r.Logger.DebugContext(ctx, "Retrieved action with leak successfully", "action_id", id, "leak_id", leak.ID, "tenant_id", tenantID)
+		return nil
+	})
+
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "Failed to retrieve action with leak", "error", err, "action_id", id, "tenant_id", tenantID)
+		return models.Action{}, models.Leak{}, err
+	}
+
+	return action, leak, nil
+}
+
+// ClaimPendingActions moves up to limit pending actions to in_progress and records workerID
+// as their owner. It is the executor's fetch: the actions are taken from the tenant's queue,
+// GetPendingActionsByPriority, and claimed in the same transaction while they are locked.
+// An in_progress action claimed longer than lease ago is queued again, so the actions of a
+// worker that died are taken over. Only actions claimed fewer than maxAttempts times are
+// queued; the tenant's queued actions that have used up their attempts are failed first, in the
+// same transaction, since nothing else would end them. Priorities are only compared within a currency, so the
+// highest priority actions of each currency come first, oldest first among equal ranks. Rows
+// locked by a concurrent claim are skipped rather than waited on, so each action is claimed by
+// exactly one worker.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant that owns the actions.
+//   - workerID: Identifier of the claiming worker, stored in claimed_by.
+//   - limit: Maximum number of actions to claim.
+//   - lease: How long a claim holds an action before another worker may take it over.
+//   - maxAttempts: The number of attempts after which an action is no longer claimed.
+//
+// Returns:
+//   - []models.Action: The claimed actions in execution order; empty when nothing is pending.
+//   - error: ErrMissingWorkerID, ErrInvalidClaimLimit, ErrInvalidClaimLease or ErrInvalidMaxAttempts for bad arguments, or any other error encountered.
+func (r *ActionsRepositoryImplementation) ClaimPendingActions(ctx context.Context, tenantID uuid.UUID, workerID string, limit int, lease time.Duration, maxAttempts int) ([]models.Action, error) {
+	r.Logger.DebugContext(ctx, "Claiming pending actions", "tenant_id", tenantID, "worker_id", workerID, "limit", limit)
+
+	if workerID == "" {
+		return nil, ErrMissingWorkerID
+	}
+	if limit < 1 || limit > math.MaxInt32 {
+		return nil, ErrInvalidClaimLimit
+	}
+	if lease <= 0 {
+		return nil, ErrInvalidClaimLease
+	}
+	if maxAttempts < 1 || maxAttempts > math.MaxInt32 {
+		return nil, ErrInvalidMaxAttempts
+	}
+
+	actions := []models.Action{}
+	err := WithTenantContext(ctx, r.Pool, tenantID, func(queries *db.Queries) error {
+		exhausted, err := queries.FailExhaustedActions(ctx, db.FailExhaustedActionsParams{
+			LeaseSeconds: lease.Seconds(),
+			MaxAttempts:  int32(maxAttempts),
+			TenantID:     convertUUIDToPgtypeUUID(tenantID),
+		})
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, nil, &tenantID)
+		}
+		if exhausted > 0 {
+			r.Logger.WarnContext(ctx, "Failed actions that used up their attempts", "tenant_id", tenantID, "count", exhausted, "max_attempts", maxAttempts)
+		}
+
+		queued, err := queries.GetPendingActionsByPriority(ctx, db.GetPendingActionsByPriorityParams{
+			LeaseSeconds: lease.Seconds(),
+			MaxAttempts:  int32(maxAttempts),
+			TenantID:     convertUUIDToPgtypeUUID(tenantID),
+			Limit:        int32(limit),
+		})
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, nil, &tenantID)
+		}
+		if len(queued) == 0 {
+			return nil
+		}
+
+		ids := make([]pgtype.UUID, 0, len(queued))
+		for _, dbAction := range queued {
+			ids = append(ids, dbAction.ID)
+		}
+		claimed, err := queries.ClaimActions(ctx, db.ClaimActionsParams{
+			WorkerID: pgtype.Text{String: workerID, Valid: true},
+			Ids:      ids,
+		})
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, nil, &tenantID)
+		}
+
+		// RETURNING keeps no order, so the claimed rows are put back in queue order
+		byID := make(map[pgtype.UUID]db.Action, len(claimed))
+		for _, dbAction := range claimed {
+			byID[dbAction.ID] = dbAction
+		}
+		for _, id := range ids {
+			if dbAction, ok := byID[id]; ok {
+				actions = append(actions, toActionDomain(r.Pool.unknownEnums(), dbAction))
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "Failed to claim pending actions", "error", err, "tenant_id", tenantID, "worker_id", workerID)
+		return nil, err
+	}
+
+	r.Logger.DebugContext(ctx, "Claimed pending actions", "tenant_id", tenantID, "worker_id", workerID, "count", len(actions))
+	return actions, nil
+}
+
+// CompleteAction records that the attempt of a claimed action succeeded. The action is
+// approved with a success result, and its claim is released.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - id: UUID of the claimed action.
+//   - tenantID: UUID of the tenant that must own the action's leak.
+//
+// Returns:
+//   - models.Action: The completed action.
+//   - error: ErrActionNotClaimed if the action is not in progress or belongs to another tenant, or any other error encountered.
+func (r *ActionsRepositoryImplementation) CompleteAction(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (models.Action, error) {
+	var action models.Action
+	err := WithTenantContext(ctx, r.Pool, tenantID, func(queries *db.Queries) error {
+		dbAction, err := queries.CompleteAction(ctx, db.CompleteActionParams{
+			ID:       convertUUIDToPgtypeUUID(id),
+			TenantID: convertUUIDToPgtypeUUID(tenantID),
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrActionNotClaimed
+			}
+			return r.handleDatabaseError(ctx, err, &id, &tenantID)
+		}
+
+		action = toActionDomain(r.Pool.unknownEnums(), dbAction)
+		return nil
+	})
+
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "Failed to complete action", "error", err, "action_id", id, "tenant_id", tenantID)
+		return models.Action{}, err
+	}
+
+	r.Logger.DebugContext(ctx, "Completed action", "action_id", id, "tenant_id", tenantID, "attempts", action.Attempts)
+	return action, nil
+}
+
+// FailActionAttempt records that the attempt of a claimed action failed. An action claimed
+// fewer than maxAttempts times goes back to pending to be retried; otherwise it becomes failed,
+// which ClaimPendingActions never picks up again. Either way the claim is released.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - id: UUID of the claimed action.
+//   - tenantID: UUID of the tenant that must own the action's leak.
+//   - maxAttempts: The number of attempts after which the action is failed for good.
+//
+// Returns:
+//   - models.Action: The action after the failure was recorded.
+//   - error: ErrActionNotClaimed if the action is not in progress or belongs to another tenant, or any other error encountered.
+func (r *ActionsRepositoryImplementation) FailActionAttempt(ctx context.Context, id uuid.UUID, tenantID uuid.UUID, maxAttempts int) (models.Action, error) {
+	if maxAttempts < 1 || maxAttempts > math.MaxInt32 {
+		return models.Action{}, ErrInvalidMaxAttempts
+	}
+
+	var action models.Action
+	err := WithTenantContext(ctx, r.Pool, tenantID, func(queries *db.Queries) error {
+		dbAction, err := queries.FailActionAttempt(ctx, db.FailActionAttemptParams{
+			MaxAttempts: int32(maxAttempts),
+			ID:          convertUUIDToPgtypeUUID(id),
+			TenantID:    convertUUIDToPgtypeUUID(tenantID),
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrActionNotClaimed
+			}
+			return r.handleDatabaseError(ctx, err, &id, &tenantID)
+		}
+
+		action = toActionDomain(r.Pool.unknownEnums(), dbAction)
+		return nil
+	})
+
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "Failed to record failed action attempt", "error", err, "action_id", id, "tenant_id", tenantID)
+		return models.Action{}, err
+	}
+
+	r.Logger.DebugContext(ctx, "Recorded failed action attempt", "action_id", id, "tenant_id", tenantID, "attempts", action.Attempts, "status", action.Status)
+	return action, nil
+}
+
+// GetActionsByLeakID retrieves the actions taken for a leak, oldest first.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - leakID: UUID of the leak whose actions to retrieve.
+//   - tenantID: UUID of the tenant that owns the leak.
+//
+// Returns:
+//   - []models.Action: The leak's actions; empty if none exist.
+//   - error: Any error encountered during retrieval.
+func (r *ActionsRepositoryImplementation) GetActionsByLeakID(ctx context.Context, leakID uuid.UUID, tenantID uuid.UUID) ([]models.Action, error) {
+	r.Logger.DebugContext(ctx, "Retrieving actions by leak ID", "leak_id", leakID, "tenant_id", tenantID)
+
+	var actions []models.Action
+	err := WithTenantContext(ctx, r.Pool, tenantID, func(queries *db.Queries) error {
+		dbActions, err := queries.GetActionsByLeakID(ctx, db.GetActionsByLeakIDParams{
+			LeakID:   convertUUIDToPgtypeUUID(leakID),
+			TenantID: convertUUIDToPgtypeUUID(tenantID),
+		})
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, &leakID, &tenantID)
+		}
+
+		actions = make([]models.Action, 0, len(dbActions))
+		for _, dbAction := range dbActions {
+			actions = append(actions, toActionDomain(r.Pool.unknownEnums(), dbAction))
+		}
+
+		r.Logger.DebugContext(ctx, "Retrieved actions successfully", "leak_id", leakID, "tenant_id", tenantID, "count", len(actions))
+		return nil
+	})
+
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "Failed to retrieve actions by leak ID", "error", err, "leak_id", leakID, "tenant_id", tenantID)
+		return nil, err
+	}
+
+	return actions, nil
+}
+
 // CountAllActions counts the total number of actions for a specific tenant.
 //
 // Parameters:
@@ -255,7 +596,7 @@ func (r *ActionsRepositoryImplementation) CountAllActions(ctx context.Context, t
 }
 
 // handleDatabaseError handles database-specific errors and converts them to domain errors.
-func (r *ActionsRepositoryImplementation) handleDatabaseError(ctx context.Context, err error, resourceID *uuid.UUID, tenantID *uuid.UUID) error{
+func (r *ActionsRepositoryImplementation) handleDatabaseError(ctx context.Context, err error, resourceID *uuid.UUID, tenantID *uuid.UUID) error {
 	if err == nil {
 		return nil
 	}
@@ -294,14 +635,49 @@ func toCreateActionDBParams(arg models.CreateActionParams) db.CreateActionParams
 	}
 }
 
+// actionFilterDBArgs holds an ActionFilter in the form the filter queries take
+type actionFilterDBArgs struct {
+	actionTypes []string
+	statuses    []string
+	results     []string
+	createdFrom pgtype.Timestamptz
+	createdTo   pgtype.Timestamptz
+}
+
+// toActionFilterDBArgs converts a filter to the parameters the filter queries compare against.
+// Empty fields become empty, non-nil arrays and NULL bounds so the queries match everything.
+func toActionFilterDBArgs(filter models.ActionFilter) actionFilterDBArgs {
+	args := actionFilterDBArgs{
+		actionTypes: make([]string, 0, len(filter.ActionTypes)),
+		statuses:    make([]string, 0, len(filter.Statuses)),
+		results:     make([]string, 0, len(filter.Results)),
+		createdFrom: convertTimePtrToPgtypeTimestamptz(filter.CreatedFrom),
+		createdTo:   convertTimePtrToPgtypeTimestamptz(filter.CreatedTo),
+	}
+	for _, t := range filter.ActionTypes {
+		args.actionTypes = append(args.actionTypes, string(t))
+	}
+	for _, s := range filter.Statuses {
+		args.statuses = append(args.statuses, string(s))
+	}
+	for _, r := range filter.Results {
+		args.results = append(args.results, string(r))
+	}
+	return args
+}
+
 // toActionDomain converts SQLC Action to domain Action.
-func toActionDomain(dbAction db.Action) models.Action {
+func toActionDomain(enums *unknownEnums, dbAction db.Action) models.Action {
 	return models.Action{
 		ID:         convertPgtypeUUIDToUUID(dbAction.ID),
 		LeakID:     convertPgtypeUUIDToUUID(dbAction.LeakID),
-		ActionType: convertActionTypeEnumFromDB(dbAction.ActionType),
-		Status:     convertActionStatusEnumFromDB(dbAction.Status),
-		Result:     convertActionResultEnumFromDB(dbAction.Result),
+		ActionType: convertActionTypeEnumFromDB(enums, dbAction.ActionType),
+		Status:     convertActionStatusEnumFromDB(enums, dbAction.Status),
+		Result:     convertActionResultEnumFromDB(enums, dbAction.Result),
+		Priority:   dbAction.Priority,
+		ClaimedBy:  convertPgtypeTextToStringPtr(dbAction.ClaimedBy),
+		ClaimedAt:  convertPgtypeTimestamptzToTimePtr(dbAction.ClaimedAt),
+		Attempts:   dbAction.Attempts,
 		CreatedAt:  dbAction.CreatedAt.Time,
 		UpdatedAt:  dbAction.UpdatedAt.Time,
 	}
@@ -0,0 +1,552 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	db "rdl-api/internal/db/sqlc"
+	"rdl-api/internal/domain/models"
+)
+
+func TestClaimPendingActions_OrdersByPriorityThenAge(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, customerID := seedTenant(t, pool)
+
+	smallLeakID := seedLeak(t, pool, tenantID, customerID, "10.00")
+	largeLeakID := seedLeak(t, pool, tenantID, customerID, "500.00")
+
+	var olderLowPriority, newerHighPriority uuid.UUID
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		queries := db.New(tx)
+		newAction := func(leakID uuid.UUID) uuid.UUID {
+			action, err := queries.CreateAction(ctx, db.CreateActionParams{
+				LeakID:     convertUUIDToPgtypeUUID(leakID),
+				ActionType: db.ActionTypeEnumRetryPayment,
+				Status:     db.ActionStatusEnumPending,
+				Result:     db.ActionResultEnumPending,
+			})
+			require.NoError(t, err)
+			return convertPgtypeUUIDToUUID(action.ID)
+		}
+
+		olderLowPriority = newAction(smallLeakID)
+		newerHighPriority = newAction(largeLeakID)
+
+		_, err := tx.Exec(ctx, "UPDATE actions SET created_at = NOW() - INTERVAL '1 hour' WHERE id = $1", olderLowPriority)
+		require.NoError(t, err)
+	})
+
+	repo := &ActionsRepositoryImplementation{Pool: pool, Logger: createTestLogger()}
+	actions, err := repo.ClaimPendingActions(ctx, tenantID, "worker-a", 10, time.Minute, 5)
+	require.NoError(t, err)
+	require.Len(t, actions, 2)
+
+	assert.Equal(t, newerHighPriority, actions[0].ID)
+	assert.Equal(t, int64(50000), actions[0].Priority)
+	assert.Equal(t, olderLowPriority, actions[1].ID)
+	assert.Equal(t, int64(1000), actions[1].Priority)
+}
+
+func TestClaimPendingActions_RanksPrioritiesWithinCurrency(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, customerID := seedTenant(t, pool)
+
+	largeUSDLeakID := seedLeak(t, pool, tenantID, customerID, "500.00")
+	smallUSDLeakID := seedLeak(t, pool, tenantID, customerID, "10.00")
+	jpyLeakID := seedLeak(t, pool, tenantID, customerID, "15000")
+
+	var largeUSD, smallUSD, jpy uuid.UUID
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "UPDATE leaks SET currency = 'JPY' WHERE id = $1", jpyLeakID)
+		require.NoError(t, err)
+
+		queries := db.New(tx)
+		newAction := func(leakID uuid.UUID, age string) uuid.UUID {
+			action, err := queries.CreateAction(ctx, db.CreateActionParams{
+				LeakID:     convertUUIDToPgtypeUUID(leakID),
+				ActionType: db.ActionTypeEnumRetryPayment,
+				Status:     db.ActionStatusEnumPending,
+				Result:     db.ActionResultEnumPending,
+			})
+			require.NoError(t, err)
+			_, err = tx.Exec(ctx, "UPDATE actions SET created_at = NOW() - $2::interval WHERE id = $1", action.ID, age)
+			require.NoError(t, err)
+			return convertPgtypeUUIDToUUID(action.ID)
+		}
+
+		largeUSD = newAction(largeUSDLeakID, "2 hours")
+		smallUSD = newAction(smallUSDLeakID, "1 hour")
+		jpy = newAction(jpyLeakID, "0 seconds")
+	})
+
+	repo := &ActionsRepositoryImplementation{Pool: pool, Logger: createTestLogger()}
+	actions, err := repo.ClaimPendingActions(ctx, tenantID, "worker-a", 10, time.Minute, 5)
+	require.NoError(t, err)
+	require.Len(t, actions, 3)
+
+	// The JPY action's raw priority is the largest, but it only outranks other JPY actions: it
+	// shares the top rank with the large USD action, which is older
+	assert.Equal(t, []uuid.UUID{largeUSD, jpy, smallUSD}, []uuid.UUID{actions[0].ID, actions[1].ID, actions[2].ID})
+	for _, action := range actions {
+		assert.Equal(t, models.ActionStatusEnumInProgress, action.Status)
+		assert.Equal(t, int32(1), action.Attempts)
+	}
+}
+
+func TestCompleteAction(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, customerID := seedTenant(t, pool)
+	leakID := seedLeak(t, pool, tenantID, customerID, "25.00")
+
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := db.New(tx).CreateAction(ctx, db.CreateActionParams{
+			LeakID:     convertUUIDToPgtypeUUID(leakID),
+			ActionType: db.ActionTypeEnumEmail,
+			Status:     db.ActionStatusEnumPending,
+			Result:     db.ActionResultEnumPending,
+		})
+		require.NoError(t, err)
+	})
+
+	repo := &ActionsRepositoryImplementation{Pool: pool, Logger: createTestLogger()}
+	claimed, err := repo.ClaimPendingActions(ctx, tenantID, "worker-a", 1, time.Minute, 5)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+
+	t.Run("other tenant", func(t *testing.T) {
+		otherTenantID, _ := seedTenant(t, pool)
+		_, err := repo.CompleteAction(ctx, claimed[0].ID, otherTenantID)
+		assert.ErrorIs(t, err, ErrActionNotClaimed)
+	})
+
+	t.Run("claimed action", func(t *testing.T) {
+		action, err := repo.CompleteAction(ctx, claimed[0].ID, tenantID)
+		require.NoError(t, err)
+		assert.Equal(t, models.ActionStatusEnumApproved, action.Status)
+		assert.Equal(t, models.ActionResultEnumSuccess, action.Result)
+		assert.Nil(t, action.ClaimedBy)
+	})
+
+	t.Run("already completed", func(t *testing.T) {
+		_, err := repo.CompleteAction(ctx, claimed[0].ID, tenantID)
+		assert.ErrorIs(t, err, ErrActionNotClaimed)
+	})
+}
+
+func TestGetActionWithLeak(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, customerID := seedTenant(t, pool)
+	leakID := seedLeak(t, pool, tenantID, customerID, "42.50")
+
+	var actionID uuid.UUID
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		action, err := db.New(tx).CreateAction(ctx, db.CreateActionParams{
+			LeakID:     convertUUIDToPgtypeUUID(leakID),
+			ActionType: db.ActionTypeEnumRetryPayment,
+			Status:     db.ActionStatusEnumPending,
+			Result:     db.ActionResultEnumPending,
+		})
+		require.NoError(t, err)
+		actionID = convertPgtypeUUIDToUUID(action.ID)
+	})
+
+	repo := &ActionsRepositoryImplementation{Pool: pool, Logger: createTestLogger()}
+
+	t.Run("joined fetch", func(t *testing.T) {
+		action, leak, err := repo.GetActionWithLeak(ctx, actionID, tenantID)
+		require.NoError(t, err)
+
+		assert.Equal(t, actionID, action.ID)
+		assert.Equal(t, leakID, action.LeakID)
+		assert.Equal(t, leakID, leak.ID)
+		assert.Equal(t, tenantID, leak.TenantID)
+		assert.Equal(t, "42.50", leak.Amount.String())
+	})
+
+	t.Run("missing action", func(t *testing.T) {
+		_, _, err := repo.GetActionWithLeak(ctx, uuid.New(), tenantID)
+		assert.ErrorIs(t, err, ErrActionNotFound)
+	})
+}
+
+func TestClaimPendingActions_ConcurrentClaimsAreDisjoint(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, customerID := seedTenant(t, pool)
+	leakID := seedLeak(t, pool, tenantID, customerID, "25.00")
+
+	const pending = 10
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		queries := db.New(tx)
+		for i := 0; i < pending; i++ {
+			_, err := queries.CreateAction(ctx, db.CreateActionParams{
+				LeakID:     convertUUIDToPgtypeUUID(leakID),
+				ActionType: db.ActionTypeEnumRetryPayment,
+				Status:     db.ActionStatusEnumPending,
+				Result:     db.ActionResultEnumPending,
+			})
+			require.NoError(t, err)
+		}
+	})
+
+	repo := &ActionsRepositoryImplementation{Pool: pool, Logger: createTestLogger()}
+	workers := []string{"worker-a", "worker-b"}
+	claimed := make([][]models.Action, len(workers))
+	errs := make([]error, len(workers))
+
+	var wg sync.WaitGroup
+	for i, worker := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			claimed[i], errs[i] = repo.ClaimPendingActions(ctx, tenantID, worker, pending, time.Minute, 5)
+		}()
+	}
+	wg.Wait()
+
+	seen := make(map[uuid.UUID]string)
+	for i, worker := range workers {
+		require.NoError(t, errs[i])
+		for _, action := range claimed[i] {
+			owner, dup := seen[action.ID]
+			assert.False(t, dup, "action %s claimed by both %s and %s", action.ID, owner, worker)
+			seen[action.ID] = worker
+
+			assert.Equal(t, models.ActionStatusEnumInProgress, action.Status)
+			require.NotNil(t, action.ClaimedBy)
+			assert.Equal(t, worker, *action.ClaimedBy)
+			assert.NotNil(t, action.ClaimedAt)
+		}
+	}
+	assert.Len(t, seen, pending, "every pending action should be claimed exactly once")
+
+	again, err := repo.ClaimPendingActions(ctx, tenantID, "worker-c", pending, time.Minute, 5)
+	require.NoError(t, err)
+	assert.Empty(t, again, "claimed actions are no longer pending")
+}
+
+func TestClaimPendingActions_ReclaimsStaleClaims(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, customerID := seedTenant(t, pool)
+	leakID := seedLeak(t, pool, tenantID, customerID, "25.00")
+
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := db.New(tx).CreateAction(ctx, db.CreateActionParams{
+			LeakID:     convertUUIDToPgtypeUUID(leakID),
+			ActionType: db.ActionTypeEnumRetryPayment,
+			Status:     db.ActionStatusEnumPending,
+			Result:     db.ActionResultEnumPending,
+		})
+		require.NoError(t, err)
+	})
+
+	repo := &ActionsRepositoryImplementation{Pool: pool, Logger: createTestLogger()}
+	claimed, err := repo.ClaimPendingActions(ctx, tenantID, "worker-a", 10, time.Minute, 5)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+
+	again, err := repo.ClaimPendingActions(ctx, tenantID, "worker-b", 10, time.Minute, 5)
+	require.NoError(t, err)
+	assert.Empty(t, again, "a claim within its lease is not taken over")
+
+	_, err = repo.ClaimPendingActions(ctx, tenantID, "worker-b", 10, 0, 5)
+	assert.ErrorIs(t, err, ErrInvalidClaimLease)
+
+	// worker-a dies: its claim ages past the lease
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "UPDATE actions SET claimed_at = NOW() - INTERVAL '2 minutes' WHERE id = $1", claimed[0].ID)
+		require.NoError(t, err)
+	})
+
+	taken, err := repo.ClaimPendingActions(ctx, tenantID, "worker-b", 10, time.Minute, 5)
+	require.NoError(t, err)
+	require.Len(t, taken, 1)
+	assert.Equal(t, claimed[0].ID, taken[0].ID)
+	require.NotNil(t, taken[0].ClaimedBy)
+	assert.Equal(t, "worker-b", *taken[0].ClaimedBy)
+	assert.Equal(t, int32(2), taken[0].Attempts, "taking over a stale claim starts another attempt")
+}
+
+func TestFailActionAttempt_RetriesUntilMaxAttempts(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, customerID := seedTenant(t, pool)
+	leakID := seedLeak(t, pool, tenantID, customerID, "25.00")
+
+	var actionID uuid.UUID
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		action, err := db.New(tx).CreateAction(ctx, db.CreateActionParams{
+			LeakID:     convertUUIDToPgtypeUUID(leakID),
+			ActionType: db.ActionTypeEnumRetryPayment,
+			Status:     db.ActionStatusEnumPending,
+			Result:     db.ActionResultEnumPending,
+		})
+		require.NoError(t, err)
+		actionID = convertPgtypeUUIDToUUID(action.ID)
+	})
+
+	repo := &ActionsRepositoryImplementation{Pool: pool, Logger: createTestLogger()}
+
+	_, err := repo.FailActionAttempt(ctx, actionID, tenantID, 3)
+	assert.ErrorIs(t, err, ErrActionNotClaimed, "an unclaimed action cannot fail an attempt")
+
+	_, err = repo.FailActionAttempt(ctx, actionID, tenantID, 0)
+	assert.ErrorIs(t, err, ErrInvalidMaxAttempts)
+
+	const maxAttempts = 3
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		claimed, err := repo.ClaimPendingActions(ctx, tenantID, "worker-a", 10, time.Minute, maxAttempts)
+		require.NoError(t, err)
+		require.Len(t, claimed, 1, "attempt %d", attempt)
+		assert.Equal(t, int32(attempt), claimed[0].Attempts)
+
+		failed, err := repo.FailActionAttempt(ctx, actionID, tenantID, maxAttempts)
+		require.NoError(t, err)
+		assert.Equal(t, int32(attempt), failed.Attempts)
+		assert.Equal(t, models.ActionResultEnumFailure, failed.Result)
+		assert.Nil(t, failed.ClaimedBy)
+		if attempt < maxAttempts {
+			assert.Equal(t, models.ActionStatusEnumPending, failed.Status, "attempt %d should be retried", attempt)
+		} else {
+			assert.Equal(t, models.ActionStatusEnumFailed, failed.Status, "the last attempt should fail the action")
+		}
+	}
+
+	again, err := repo.ClaimPendingActions(ctx, tenantID, "worker-a", 10, time.Minute, maxAttempts)
+	require.NoError(t, err)
+	assert.Empty(t, again, "failed actions are not claimed again")
+}
+
+func TestClaimPendingActions_FailsStaleClaimOnLastAttempt(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, customerID := seedTenant(t, pool)
+	leakID := seedLeak(t, pool, tenantID, customerID, "25.00")
+
+	var actionID uuid.UUID
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		action, err := db.New(tx).CreateAction(ctx, db.CreateActionParams{
+			LeakID:     convertUUIDToPgtypeUUID(leakID),
+			ActionType: db.ActionTypeEnumRetryPayment,
+			Status:     db.ActionStatusEnumPending,
+			Result:     db.ActionResultEnumPending,
+		})
+		require.NoError(t, err)
+		actionID = convertPgtypeUUIDToUUID(action.ID)
+	})
+
+	repo := &ActionsRepositoryImplementation{Pool: pool, Logger: createTestLogger()}
+	const maxAttempts = 1
+	claimed, err := repo.ClaimPendingActions(ctx, tenantID, "worker-a", 10, time.Minute, maxAttempts)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+
+	// worker-a dies on the action's only attempt
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "UPDATE actions SET claimed_at = NOW() - INTERVAL '2 minutes' WHERE id = $1", actionID)
+		require.NoError(t, err)
+	})
+
+	again, err := repo.ClaimPendingActions(ctx, tenantID, "worker-b", 10, time.Minute, maxAttempts)
+	require.NoError(t, err)
+	assert.Empty(t, again, "an action out of attempts is not claimed again")
+
+	action, err := repo.GetActionByID(ctx, actionID, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ActionStatusEnumFailed, action.Status)
+	assert.Equal(t, models.ActionResultEnumFailure, action.Result)
+	assert.Nil(t, action.ClaimedBy)
+	assert.Equal(t, int32(1), action.Attempts)
+}
+
+func TestGetActionByID_TenantOwnership(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantA, customerA := seedTenant(t, pool)
+	tenantB, _ := seedTenant(t, pool)
+	leakID := seedLeak(t, pool, tenantA, customerA, "42.50")
+
+	var actionID uuid.UUID
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		action, err := db.New(tx).CreateAction(ctx, db.CreateActionParams{
+			LeakID:     convertUUIDToPgtypeUUID(leakID),
+			ActionType: db.ActionTypeEnumRetryPayment,
+			Status:     db.ActionStatusEnumPending,
+			Result:     db.ActionResultEnumPending,
+		})
+		require.NoError(t, err)
+		actionID = convertPgtypeUUIDToUUID(action.ID)
+	})
+
+	repo := &ActionsRepositoryImplementation{Pool: pool, Logger: createTestLogger()}
+
+	t.Run("owner can fetch", func(t *testing.T) {
+		action, err := repo.GetActionByID(ctx, actionID, tenantA)
+		require.NoError(t, err)
+		assert.Equal(t, actionID, action.ID)
+	})
+
+	t.Run("other tenant gets not found", func(t *testing.T) {
+		_, err := repo.GetActionByID(ctx, actionID, tenantB)
+		assert.ErrorIs(t, err, ErrActionNotFound)
+	})
+
+	t.Run("query refuses other tenant without RLS", func(t *testing.T) {
+		// The service account bypasses row-level security, standing in for a misconfigured policy
+		seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+			_, err := db.New(tx).GetActionByID(ctx, db.GetActionByIDParams{
+				ID:       convertUUIDToPgtypeUUID(actionID),
+				TenantID: convertUUIDToPgtypeUUID(tenantB),
+			})
+			assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+			action, err := db.New(tx).GetActionByID(ctx, db.GetActionByIDParams{
+				ID:       convertUUIDToPgtypeUUID(actionID),
+				TenantID: convertUUIDToPgtypeUUID(tenantA),
+			})
+			require.NoError(t, err)
+			assert.Equal(t, actionID, convertPgtypeUUIDToUUID(action.ID))
+
+			_, err = db.New(tx).GetActionWithLeak(ctx, db.GetActionWithLeakParams{
+				ID:       convertUUIDToPgtypeUUID(actionID),
+				TenantID: convertUUIDToPgtypeUUID(tenantB),
+			})
+			assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+			row, err := db.New(tx).GetActionWithLeak(ctx, db.GetActionWithLeakParams{
+				ID:       convertUUIDToPgtypeUUID(actionID),
+				TenantID: convertUUIDToPgtypeUUID(tenantA),
+			})
+			require.NoError(t, err)
+			assert.Equal(t, actionID, convertPgtypeUUIDToUUID(row.Action.ID))
+
+			actions, err := db.New(tx).GetActionsByLeakID(ctx, db.GetActionsByLeakIDParams{
+				LeakID:   convertUUIDToPgtypeUUID(leakID),
+				TenantID: convertUUIDToPgtypeUUID(tenantB),
+			})
+			require.NoError(t, err)
+			assert.Empty(t, actions)
+
+			actions, err = db.New(tx).GetActionsByLeakID(ctx, db.GetActionsByLeakIDParams{
+				LeakID:   convertUUIDToPgtypeUUID(leakID),
+				TenantID: convertUUIDToPgtypeUUID(tenantA),
+			})
+			require.NoError(t, err)
+			require.Len(t, actions, 1)
+			assert.Equal(t, actionID, convertPgtypeUUIDToUUID(actions[0].ID))
+		})
+	})
+
+	t.Run("other tenant gets no actions for the leak", func(t *testing.T) {
+		actions, err := repo.GetActionsByLeakID(ctx, leakID, tenantB)
+		require.NoError(t, err)
+		assert.Empty(t, actions)
+	})
+}
+
+func TestGetActionsFiltered(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, customerID := seedTenant(t, pool)
+	otherTenantID, otherCustomerID := seedTenant(t, pool)
+	smallLeakID := seedLeak(t, pool, tenantID, customerID, "10.00")
+	largeLeakID := seedLeak(t, pool, tenantID, customerID, "500.00")
+	otherLeakID := seedLeak(t, pool, otherTenantID, otherCustomerID, "99.00")
+
+	var emailApproved, emailPending, outreachApproved, retryDenied uuid.UUID
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		queries := db.New(tx)
+		newAction := func(leakID uuid.UUID, actionType db.ActionTypeEnum, status db.ActionStatusEnum, result db.ActionResultEnum, age string) uuid.UUID {
+			action, err := queries.CreateAction(ctx, db.CreateActionParams{
+				LeakID:     convertUUIDToPgtypeUUID(leakID),
+				ActionType: actionType,
+				Status:     status,
+				Result:     result,
+			})
+			require.NoError(t, err)
+			_, err = tx.Exec(ctx, "UPDATE actions SET created_at = NOW() - $2::interval WHERE id = $1", action.ID, age)
+			require.NoError(t, err)
+			return convertPgtypeUUIDToUUID(action.ID)
+		}
+
+		emailApproved = newAction(smallLeakID, db.ActionTypeEnumEmail, db.ActionStatusEnumApproved, db.ActionResultEnumSuccess, "3 hours")
+		emailPending = newAction(largeLeakID, db.ActionTypeEnumEmail, db.ActionStatusEnumPending, db.ActionResultEnumPending, "2 hours")
+		outreachApproved = newAction(largeLeakID, db.ActionTypeEnumOutreach, db.ActionStatusEnumApproved, db.ActionResultEnumSuccess, "1 hour")
+		retryDenied = newAction(smallLeakID, db.ActionTypeEnumRetryPayment, db.ActionStatusEnumDenied, db.ActionResultEnumFailure, "0 hours")
+		newAction(otherLeakID, db.ActionTypeEnumEmail, db.ActionStatusEnumApproved, db.ActionResultEnumSuccess, "1 hour")
+	})
+
+	repo := &ActionsRepositoryImplementation{Pool: pool, Logger: createTestLogger()}
+	ids := func(actions []models.Action) []uuid.UUID {
+		out := make([]uuid.UUID, 0, len(actions))
+		for _, action := range actions {
+			out = append(out, action.ID)
+		}
+		return out
+	}
+	since := time.Now().Add(-150 * time.Minute)
+
+	tests := []struct {
+		name   string
+		filter models.ActionFilter
+		sort   models.ActionSort
+		want   []uuid.UUID
+	}{
+		{
+			name: "no filter lists only the tenant's actions, newest first",
+			sort: models.DefaultActionSort,
+			want: []uuid.UUID{retryDenied, outreachApproved, emailPending, emailApproved},
+		},
+		{
+			name:   "type and status",
+			filter: models.ActionFilter{ActionTypes: []models.ActionTypeEnum{models.ActionTypeEnumEmail, models.ActionTypeEnumOutreach}, Statuses: []models.ActionStatusEnum{models.ActionStatusEnumApproved}},
+			sort:   models.ActionSort{Field: models.ActionSortCreatedAt},
+			want:   []uuid.UUID{emailApproved, outreachApproved},
+		},
+		{
+			name:   "result and creation range",
+			filter: models.ActionFilter{Results: []models.ActionResultEnum{models.ActionResultEnumSuccess, models.ActionResultEnumPending}, CreatedFrom: &since},
+			sort:   models.ActionSort{Field: models.ActionSortCreatedAt},
+			want:   []uuid.UUID{emailPending, outreachApproved},
+		},
+		{
+			name:   "sorted by priority",
+			filter: models.ActionFilter{ActionTypes: []models.ActionTypeEnum{models.ActionTypeEnumEmail}},
+			sort:   models.ActionSort{Field: models.ActionSortPriority, Descending: true},
+			want:   []uuid.UUID{emailPending, emailApproved},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			all, err := repo.GetActionsFiltered(ctx, tenantID, tt.filter, models.PaginationParams{Limit: 100}, tt.sort)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ids(all.Items))
+			assert.Equal(t, int64(len(tt.want)), all.TotalCount, "the count uses the same filter as the page")
+
+			// Paging one action at a time visits the same actions and reports the same total
+			var paged []uuid.UUID
+			for offset := int32(0); offset < int32(len(tt.want))+1; offset++ {
+				page, err := repo.GetActionsFiltered(ctx, tenantID, tt.filter, models.PaginationParams{Limit: 1, Offset: offset}, tt.sort)
+				require.NoError(t, err)
+				assert.Equal(t, all.TotalCount, page.TotalCount)
+				paged = append(paged, ids(page.Items)...)
+			}
+			assert.Equal(t, tt.want, paged)
+		})
+	}
+}
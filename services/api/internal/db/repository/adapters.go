@@ -24,6 +24,7 @@ package repository
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -52,7 +53,7 @@ func convertUUIDToPgtypeUUID(id uuid.UUID) pgtype.UUID {
 //
 // Returns:
 //   - pgtype.UUID: The corresponding pgtype.UUID, with Valid set appropriately.
-func convertNullableUUIDToPgtypeUUID(id *uuid.UUID) pgtype.UUID { //nolint: unused
+func convertNullableUUIDToPgtypeUUID(id *uuid.UUID) pgtype.UUID {
 	if id == nil {
 		return pgtype.UUID{Valid: false}
 	}
@@ -74,6 +75,61 @@ func convertPgtypeUUIDToUUID(pgUUID pgtype.UUID) uuid.UUID {
 	return pgUUID.Bytes
 }
 
+// convertDecimalToPgtypeNumeric converts an exact amount to a numeric column value
+func convertDecimalToPgtypeNumeric(amount models.Decimal) pgtype.Numeric {
+	return pgtype.Numeric{Int: amount.Coefficient(), Exp: amount.Exponent(), Valid: true}
+}
+
+// convertNullableDecimalToPgtypeNumeric converts an optional amount, NULL when nil
+func convertNullableDecimalToPgtypeNumeric(amount *models.Decimal) pgtype.Numeric {
+	if amount == nil {
+		return pgtype.Numeric{}
+	}
+	return convertDecimalToPgtypeNumeric(*amount)
+}
+
+// convertPgtypeNumericToDecimal converts a numeric column value to an exact amount.
+// NULL, NaN and infinite values, which a money column never holds, convert to 0.
+func convertPgtypeNumericToDecimal(n pgtype.Numeric) models.Decimal {
+	if !n.Valid || n.NaN || n.InfinityModifier != pgtype.Finite {
+		return models.Decimal{}
+	}
+	return models.NewDecimalFromBigInt(n.Int, n.Exp)
+}
+
+// convertPgtypeUUIDToUUIDPtr converts a nullable UUID column to a *uuid.UUID, nil when NULL
+func convertPgtypeUUIDToUUIDPtr(pgUUID pgtype.UUID) *uuid.UUID {
+	if !pgUUID.Valid {
+		return nil
+	}
+	id := uuid.UUID(pgUUID.Bytes)
+	return &id
+}
+
+// convertTimePtrToPgtypeTimestamptz converts an optional time to a timestamp column value, NULL when nil
+func convertTimePtrToPgtypeTimestamptz(t *time.Time) pgtype.Timestamptz {
+	if t == nil {
+		return pgtype.Timestamptz{}
+	}
+	return pgtype.Timestamptz{Time: *t, Valid: true}
+}
+
+// convertPgtypeTextToStringPtr converts a nullable text column to a *string, nil when NULL
+func convertPgtypeTextToStringPtr(text pgtype.Text) *string {
+	if !text.Valid {
+		return nil
+	}
+	return &text.String
+}
+
+// convertPgtypeTimestamptzToTimePtr converts a nullable timestamp column to a *time.Time, nil when NULL
+func convertPgtypeTimestamptzToTimePtr(ts pgtype.Timestamptz) *time.Time {
+	if !ts.Valid {
+		return nil
+	}
+	return &ts.Time
+}
+
 // convertInterfaceToBytes safely converts an input of type any (interface{})
 // to a byte slice ([]byte). This is useful for serializing data fields
 // that may be stored as JSON or binary in the database.
@@ -110,6 +166,7 @@ type convertableEnums interface {
 		*db.ActionTypeEnum |
 		*db.ActionStatusEnum |
 		*db.ActionResultEnum |
+		*db.LeakStatusEnum |
 		*db.LeakTypeEnum |
 		*db.PaymentTypeEnum |
 		*db.PaymentStatusEnum
@@ -126,6 +183,7 @@ type convertableEnums interface {
 //   - NullActionTypeEnum
 //   - NullActionStatusEnum
 //   - NullActionResultEnum
+//   - NullLeakStatusEnum
 //   - NullLeakTypeEnum
 //   - NullPaymentTypeEnum
 //   - NullPaymentStatusEnum
@@ -135,6 +193,7 @@ type convertedEnums interface {
 		db.NullActionTypeEnum |
 		db.NullActionStatusEnum |
 		db.NullActionResultEnum |
+		db.NullLeakStatusEnum |
 		db.NullLeakTypeEnum |
 		db.NullPaymentTypeEnum |
 		db.NullPaymentStatusEnum
@@ -202,6 +261,13 @@ func convertEnumsToNullableEnum[T convertableEnums, R convertedEnums](enumPtr T)
 		}
 		//nolint:errcheck // type assertion is safe due to generic constraints
 		return any(db.NullActionResultEnum{ActionResultEnum: *v, Valid: true}).(R), nil
+	case *db.LeakStatusEnum:
+		if v == nil {
+			//nolint:errcheck // type assertion is safe due to generic constraints
+			return any(db.NullLeakStatusEnum{Valid: false}).(R), nil
+		}
+		//nolint:errcheck // type assertion is safe due to generic constraints
+		return any(db.NullLeakStatusEnum{LeakStatusEnum: *v, Valid: true}).(R), nil
 	case *db.LeakTypeEnum:
 		if v == nil {
 			//nolint:errcheck // type assertion is safe due to generic constraints
@@ -255,6 +321,7 @@ func convertNullablePgtypeUUIDToUUID(pgUUID pgtype.UUID) *uuid.UUID { //nolint:
 //   - ActionTypeEnum     -> NullActionTypeEnum
 //   - ActionStatusEnum   -> NullActionStatusEnum
 //   - ActionResultEnum   -> NullActionResultEnum
+//   - LeakStatusEnum     -> NullLeakStatusEnum
 //   - LeakTypeEnum       -> NullLeakTypeEnum
 //   - PaymentTypeEnum    -> NullPaymentTypeEnum
 //   - PaymentStatusEnum  -> NullPaymentStatusEnum
@@ -277,6 +344,8 @@ func convertEnumToNullableEnum(enumValue any) (any, error) { //nolint: unused
 		return db.NullActionStatusEnum{ActionStatusEnum: v, Valid: true}, nil
 	case db.ActionResultEnum:
 		return db.NullActionResultEnum{ActionResultEnum: v, Valid: true}, nil
+	case db.LeakStatusEnum:
+		return db.NullLeakStatusEnum{LeakStatusEnum: v, Valid: true}, nil
 	case db.LeakTypeEnum:
 		return db.NullLeakTypeEnum{LeakTypeEnum: v, Valid: true}, nil
 	case db.PaymentTypeEnum:
@@ -296,8 +365,8 @@ func convertActionTypeEnumToDB(enum models.ActionTypeEnum) db.ActionTypeEnum {
 }
 
 // convertActionTypeEnumFromDB converts database ActionTypeEnum to domain ActionTypeEnum.
-func convertActionTypeEnumFromDB(enum db.ActionTypeEnum) models.ActionTypeEnum {
-	return models.ActionTypeEnum(enum)
+func convertActionTypeEnumFromDB(enums *unknownEnums, enum db.ActionTypeEnum) models.ActionTypeEnum {
+	return toDomainEnum(enums, "action_type", string(enum), actionTypes)
 }
 
 // convertActionStatusEnumToDB converts domain ActionStatusEnum to database ActionStatusEnum.
@@ -306,8 +375,8 @@ func convertActionStatusEnumToDB(enum models.ActionStatusEnum) db.ActionStatusEn
 }
 
 // convertActionStatusEnumFromDB converts database ActionStatusEnum to domain ActionStatusEnum.
-func convertActionStatusEnumFromDB(enum db.ActionStatusEnum) models.ActionStatusEnum {
-	return models.ActionStatusEnum(enum)
+func convertActionStatusEnumFromDB(enums *unknownEnums, enum db.ActionStatusEnum) models.ActionStatusEnum {
+	return toDomainEnum(enums, "action_status", string(enum), actionStatuses)
 }
 
 // convertActionResultEnumToDB converts domain ActionResultEnum to database ActionResultEnum.
@@ -316,6 +385,6 @@ func convertActionResultEnumToDB(enum models.ActionResultEnum) db.ActionResultEn
 }
 
 // convertActionResultEnumFromDB converts database ActionResultEnum to domain ActionResultEnum.
-func convertActionResultEnumFromDB(enum db.ActionResultEnum) models.ActionResultEnum {
-	return models.ActionResultEnum(enum)
+func convertActionResultEnumFromDB(enums *unknownEnums, enum db.ActionResultEnum) models.ActionResultEnum {
+	return toDomainEnum(enums, "action_result", string(enum), actionResults)
 }
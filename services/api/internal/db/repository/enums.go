@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"log/slog"
+	"slices"
+	"sync"
+
+	"rdl-api/internal/domain/models"
+)
+
+// UnknownEnumValue replaces enum values read from the database that this build does not know,
+// such as a type added by a migration that ran ahead of the deploy
+const UnknownEnumValue = "unknown"
+
+// Handling of unknown enum values; see PoolOptions.UnknownEnumPolicy
+const (
+	// UnknownEnumPolicyMap replaces the value with UnknownEnumValue
+	UnknownEnumPolicyMap = "unknown"
+	// UnknownEnumPolicyPassthrough hands the raw value on unchanged
+	UnknownEnumPolicyPassthrough = "passthrough"
+)
+
+// unknownEnums is a pool's handling of enum values it reads that this build does not know
+type unknownEnums struct {
+	passthrough bool
+	logger      *slog.Logger
+	// reported remembers the unknown values already logged, so a value is reported once per
+	// pool instead of once per row
+	reported sync.Map
+}
+
+// Known values of the enums read back from the database
+var (
+	eventTypes = []models.EventTypeEnum{
+		models.EventTypeEnumPaymentFailed,
+		models.EventTypeEnumPaymentSucceeded,
+		models.EventTypeEnumPaymentRefunded,
+		models.EventTypeEnumPaymentUpdated,
+	}
+	eventSources = []models.EventSourceEnum{
+		models.EventSourceEnumWebhook,
+		models.EventSourceEnumImport,
+		models.EventSourceEnumManual,
+		models.EventSourceEnumApi,
+	}
+	leakTypes = []models.LeakTypeEnum{
+		models.LeakTypeEnumFailedPayments,
+		models.LeakTypeEnumUnbilledUsage,
+		models.LeakTypeEnumQuietChurn,
+		models.LeakTypeEnumCouponDiscountMisuse,
+		models.LeakTypeEnumTrialForever,
+		models.LeakTypeEnumOther,
+		models.LeakTypeEnumVolumeAnomaly,
+		models.LeakTypeEnumDuplicateCharge,
+		models.LeakTypeEnumDunningGap,
+		models.LeakTypeEnumCurrencyMismatch,
+		models.LeakTypeEnumFailureRateSpike,
+	}
+	leakStatuses = []models.LeakStatusEnum{
+		models.LeakStatusEnumOpen,
+		models.LeakStatusEnumResolved,
+		models.LeakStatusEnumIgnored,
+	}
+	paymentStatuses = []models.PaymentStatusEnum{
+		models.PaymentStatusEnumPending,
+		models.PaymentStatusEnumSucceeded,
+		models.PaymentStatusEnumFailed,
+		models.PaymentStatusEnumOther,
+	}
+	paymentTypes = []models.PaymentTypeEnum{
+		models.PaymentTypeEnumWebhook,
+		models.PaymentTypeEnumHistory,
+	}
+	actionTypes = []models.ActionTypeEnum{
+		models.ActionTypeEnumRetryPayment,
+		models.ActionTypeEnumOutreach,
+		models.ActionTypeEnumLinearTask,
+		models.ActionTypeEnumEmail,
+		models.ActionTypeEnumOther,
+	}
+	actionStatuses = []models.ActionStatusEnum{
+		models.ActionStatusEnumPending,
+		models.ActionStatusEnumApproved,
+		models.ActionStatusEnumModified,
+		models.ActionStatusEnumDenied,
+		models.ActionStatusEnumInProgress,
+		models.ActionStatusEnumFailed,
+	}
+	actionResults = []models.ActionResultEnum{
+		models.ActionResultEnumSuccess,
+		models.ActionResultEnumFailure,
+		models.ActionResultEnumPending,
+		models.ActionResultEnumOther,
+	}
+)
+
+// toDomainEnum converts a database enum value to its domain type. A value missing from known
+// is logged once and, unless handling has the passthrough policy, replaced with
+// UnknownEnumValue. kind names the enum in the log, e.g. "event_type". A nil handling maps
+// unknown values and logs every one to slog.Default.
+func toDomainEnum[E ~string](handling *unknownEnums, kind string, value string, known []E) E {
+	if slices.Contains(known, E(value)) {
+		return E(value)
+	}
+
+	if handling == nil {
+		slog.Warn("Unknown enum value read from the database", "enum", kind, "value", value, "passthrough", false)
+		return E(UnknownEnumValue)
+	}
+	if _, seen := handling.reported.LoadOrStore(kind+"="+value, true); !seen {
+		handling.logger.Warn("Unknown enum value read from the database", "enum", kind, "value", value, "passthrough", handling.passthrough)
+	}
+	if handling.passthrough {
+		return E(value)
+	}
+	return E(UnknownEnumValue)
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	db "rdl-api/internal/db/sqlc"
+	"rdl-api/internal/domain/models"
+)
+
+// newUnknownEnums returns the handling of a pool with policy, logging to the returned buffer
+func newUnknownEnums(policy string) (*unknownEnums, *bytes.Buffer) {
+	var buf bytes.Buffer
+	pool := NewPool(nil, PoolOptions{UnknownEnumPolicy: policy, Logger: slog.New(slog.NewTextHandler(&buf, nil))})
+	return pool.unknownEnums(), &buf
+}
+
+func TestToEventDomain_UnknownEnums(t *testing.T) {
+	row := db.Event{EventType: "payment_disputed", Status: "archived"}
+
+	t.Run("mapped to unknown and logged once", func(t *testing.T) {
+		enums, logs := newUnknownEnums(UnknownEnumPolicyMap)
+
+		event := toEventDomain(enums, row)
+		_ = toEventDomain(enums, row)
+
+		assert.Equal(t, models.EventTypeEnum(UnknownEnumValue), event.EventType)
+		assert.Equal(t, models.EventStatusEnum(UnknownEnumValue), event.Status)
+		assert.Equal(t, 1, strings.Count(logs.String(), "value=payment_disputed"), "expected one warning per unknown value")
+		assert.Contains(t, logs.String(), "value=archived")
+	})
+
+	t.Run("passed through and logged", func(t *testing.T) {
+		enums, logs := newUnknownEnums(UnknownEnumPolicyPassthrough)
+
+		event := toEventDomain(enums, row)
+
+		assert.Equal(t, models.EventTypeEnum("payment_disputed"), event.EventType)
+		assert.Equal(t, models.EventStatusEnum("archived"), event.Status)
+		assert.Contains(t, logs.String(), "level=WARN")
+	})
+
+	t.Run("known values are untouched", func(t *testing.T) {
+		enums, logs := newUnknownEnums(UnknownEnumPolicyMap)
+
+		event := toEventDomain(enums, db.Event{EventType: db.EventTypeEnumPaymentFailed, Status: db.EventStatusEnumPending, Source: db.EventSourceEnumWebhook})
+
+		assert.Equal(t, models.EventTypeEnumPaymentFailed, event.EventType)
+		assert.Equal(t, models.EventStatusEnumPending, event.Status)
+		assert.Equal(t, models.EventSourceEnumWebhook, event.Source)
+		assert.Empty(t, logs.String())
+	})
+}
+
+func TestToLeakDomain_UnknownEnums(t *testing.T) {
+	enums, _ := newUnknownEnums(UnknownEnumPolicyMap)
+
+	leak := toLeakDomain(enums, db.Leak{LeakType: "double_billing", Status: db.LeakStatusEnumOpen})
+
+	assert.Equal(t, models.LeakTypeEnum(UnknownEnumValue), leak.LeakType)
+	assert.Equal(t, models.LeakStatusEnumOpen, leak.Status)
+}
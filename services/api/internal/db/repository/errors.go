@@ -1,7 +1,13 @@
 package repository
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"rdl-api/internal/domain/models"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 // Tenant scope errors
@@ -9,8 +15,31 @@ var (
 	ErrFailedToAcquireConnection = errors.New("failed to acquire connection")
 	ErrSettingTenantID           = errors.New("failed to set tenant ID")
 	ErrFailedToSetServiceAccount = errors.New("failed to set service account")
+	// ErrQueryTimeout is returned when too little of the request's deadline is left to run a query
+	ErrQueryTimeout = errors.New("query skipped: request deadline too close")
+	// ErrServiceOverloaded is returned when every pool connection is in use and none frees up within the acquire timeout
+	ErrServiceOverloaded = errors.New("service overloaded: database connection pool exhausted")
 )
 
+// IsDatabaseUnavailable reports whether err means the database could not be reached, as
+// opposed to a statement it rejected, so the same write is worth retrying later. An exhausted
+// pool does not count: the database is up, just busy.
+func IsDatabaseUnavailable(err error) bool {
+	if errors.Is(err, ErrServiceOverloaded) {
+		return false
+	}
+	if errors.Is(err, ErrFailedToAcquireConnection) || errors.Is(err, ErrDatabaseConnection) {
+		return true
+	}
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) {
+		return true
+	}
+	// Class 08 is connection exceptions
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && strings.HasPrefix(pgErr.Code, "08")
+}
+
 // Database repository errors
 var (
 	ErrDatabaseNotInitialized = errors.New("database not initialized")
@@ -19,6 +48,12 @@ var (
 	ErrForeignKeyViolation    = errors.New("foreign key violation")
 	ErrNotNullViolation       = errors.New("not null violation")
 	ErrCheckViolation         = errors.New("check violation")
+	// ErrOperationCanceled is returned when the caller's context was canceled, typically because
+	// the client disconnected; it matches context.Canceled with errors.Is
+	ErrOperationCanceled = fmt.Errorf("operation canceled: %w", context.Canceled)
+	// ErrOperationTimeout is returned when the caller's deadline passed; it matches
+	// context.DeadlineExceeded with errors.Is
+	ErrOperationTimeout = fmt.Errorf("operation timeout: %w", context.DeadlineExceeded)
 )
 
 // Repository construction errors
@@ -37,6 +72,18 @@ var (
 	ErrEventDeleteFailed     = errors.New("event delete failed")
 	ErrEventCreationFailed   = errors.New("event creation failed")
 	ErrEventRetrievalFailed  = errors.New("event retrieval failed")
+	// ErrConcurrentModification is returned by versioned updates when the event changed since it was read
+	ErrConcurrentModification = errors.New("event was modified concurrently")
+	// ErrEventSkipped is returned by CreateEvent when the tenant's allowlist does not accept the event type
+	ErrEventSkipped = errors.New("event type not accepted by tenant")
+	// ErrUnknownProvider is returned by CreateEvent when the tenant's provider allowlist does not contain the event's provider
+	ErrUnknownProvider = errors.New("provider is not registered for this tenant")
+	// ErrEmptyEventFilter is returned by bulk updates given a filter that would match every event
+	ErrEmptyEventFilter = errors.New("event filter must have at least one predicate")
+	// ErrBatchTooLarge is returned by CreateEventsBatch for a batch over the policy's MaxSize when chunking is off
+	ErrBatchTooLarge = errors.New("batch exceeds the maximum batch size")
+	// ErrSameProvider is returned by ReattributeEvents when asked to move events to the provider they are already under
+	ErrSameProvider = errors.New("events cannot be re-attributed to the provider they are already under")
 )
 
 // Actions repository errors
@@ -46,6 +93,53 @@ var (
 	ErrActionForeignKeyViolation = errors.New("action foreign key violation")
 	ErrActionNotNullViolation    = errors.New("action not null violation")
 	ErrDatabaseOperation         = errors.New("database operation")
+	ErrMissingWorkerID           = errors.New("worker id is required to claim actions")
+	ErrInvalidClaimLimit         = errors.New("claim limit must be positive")
+	ErrInvalidClaimLease         = errors.New("claim lease must be positive")
+	ErrInvalidMaxAttempts        = errors.New("max attempts must be positive")
+	ErrActionNotClaimed          = errors.New("action not found or not in progress")
+)
+
+// Idempotency keys repository errors
+var (
+	ErrIdempotencyKeyNotFound = errors.New("idempotency key not found")
+)
+
+// Leaks repository errors
+var (
+	ErrLeakNotFound      = errors.New("leak not found")
+	ErrMissingDedupKey   = errors.New("leak dedup key is required")
+	ErrLeakAlreadyExists = models.ErrLeakAlreadyExists
+)
+
+// Notification channels repository errors
+var (
+	ErrNotificationChannelNotFound = errors.New("notification channel not found")
+)
+
+// Notification outbox repository errors
+var (
+	ErrOutboxNotificationNotFound = errors.New("outbox notification not found")
+	ErrInvalidOutboxLease         = errors.New("outbox lease must be positive")
+)
+
+// Payments repository errors
+var (
+	ErrPaymentNotFound      = errors.New("payment not found")
+	ErrPaymentAlreadyExists = errors.New("payment already exists for event")
+)
+
+// Providers repository errors
+var (
+	ErrProviderNotFound = errors.New("provider not found")
+)
+
+// Tenants repository errors
+var (
+	ErrTenantNotFound = errors.New("tenant not found")
+	// ErrInvalidTenantSnapshot is returned by ImportTenant for an archive that is malformed, of an
+	// unsupported version, or holds rows of a tenant other than the one being imported
+	ErrInvalidTenantSnapshot = errors.New("invalid tenant snapshot")
 )
 
 // Users repository errors
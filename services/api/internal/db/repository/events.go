@@ -4,35 +4,44 @@
 package repository
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	"math"
 	db "rdl-api/internal/db/sqlc"
 	"rdl-api/internal/domain/models"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 // EventsRepositoryImplementation implements the EventsRepository interface using sqlc-generated queries.
 type EventsRepositoryImplementation struct {
-	pool   *pgxpool.Pool
-	logger *slog.Logger
+	pool *Pool
+	// readPool serves the read-heavy methods; nil means they use pool
+	readPool *Pool
+	logger   *slog.Logger
 }
 
-// NewEventsRepository creates a new instance of EventsRepository backed by the provided pgx.Tx and pgxpool.Pool.
+// NewEventsRepository creates a new instance of EventsRepository backed by the provided pgx.Tx and Pool.
 //
 // Parameters:
-//   - pool: Pointer to pgxpool.Pool, which provides access to the database.
+//   - pool: Pointer to Pool, which provides access to the database.
 //   - logger: Pointer to slog.Logger, which provides access to the logger.
 //
 // Returns:
 //   - EventsRepository: An implementation of the EventsRepository interface.
 //   - error: Any error encountered during initialization.
-func NewEventsRepository(pool *pgxpool.Pool, l *slog.Logger) (EventsRepositoryImplementation, error) {
+func NewEventsRepository(pool *Pool, l *slog.Logger) (EventsRepositoryImplementation, error) {
 	if pool == nil {
 		return EventsRepositoryImplementation{}, ErrPoolCannotBeNil
 	}
@@ -42,310 +51,1917 @@ func NewEventsRepository(pool *pgxpool.Pool, l *slog.Logger) (EventsRepositoryIm
 	return EventsRepositoryImplementation{pool: pool, logger: l}, nil
 }
 
-// CreateEvent persists a new event in the database.
+// WithReadPool returns a copy of the repository whose listing, counting, correlation and export
+// reads use readPool, typically a replica. Writes, single-event lookups, which callers use right
+// after a write, and the queries leak detection runs stay on the primary, so a lagging replica
+// cannot hide events from a rule. A nil readPool keeps every query on the primary.
+func (r EventsRepositoryImplementation) WithReadPool(readPool *Pool) EventsRepositoryImplementation {
+	r.readPool = readPool
+	return r
+}
+
+// reader returns the pool for read-heavy queries
+func (r EventsRepositoryImplementation) reader() *Pool {
+	if r.readPool != nil {
+		return r.readPool
+	}
+	return r.pool
+}
+
+// CreateEvent persists a new event in the database. An event type outside the tenant's
+// accepted types returns ErrEventSkipped; a provider outside the tenant's allowed providers
+// returns ErrUnknownProvider. Empty allowlists accept everything.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - arg: CreateEventParams containing the event details as a domain model.
+//   - tenantID: UUID of the tenant that owns the event.
+//
+// Returns:
+//   - models.Event: The created event as a domain model.
+//   - error: Any error encountered during creation.
+func (r EventsRepositoryImplementation) CreateEvent(ctx context.Context, arg models.CreateEventParams, tenantID uuid.UUID) (models.Event, error) {
+	return r.createEvent(ctx, arg, tenantID, false)
+}
+
+// CreateEventIdempotent persists a new event like CreateEvent, but first checks whether the
+// provider's event ID is already stored. Known duplicates, such as webhook redeliveries, return
+// ErrEventAlreadyExists with only a DEBUG log. The insert still catches the unique violation,
+// so concurrent duplicates that slip past the check are rejected too.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - arg: CreateEventParams containing the event details as a domain model.
+//   - tenantID: UUID of the tenant that owns the event.
+//
+// Returns:
+//   - models.Event: The created event as a domain model.
+//   - error: ErrEventAlreadyExists for a duplicate, or any other error encountered during creation.
+func (r EventsRepositoryImplementation) CreateEventIdempotent(ctx context.Context, arg models.CreateEventParams, tenantID uuid.UUID) (models.Event, error) {
+	return r.createEvent(ctx, arg, tenantID, true)
+}
+
+// errKnownDuplicate marks a duplicate found by the pre-check so it isn't logged as a failure
+var errKnownDuplicate = fmt.Errorf("%w: found by pre-check", ErrEventAlreadyExists)
+
+// createEvent performs the insert; when precheck is true, an existing event with the same
+// provider and event ID is reported as ErrEventAlreadyExists before attempting the insert.
+func (r EventsRepositoryImplementation) createEvent(ctx context.Context, arg models.CreateEventParams, tenantID uuid.UUID, precheck bool) (models.Event, error) {
+	r.logger.InfoContext(ctx, "Creating event", "event_id", arg.EventID, "tenant_id", tenantID, "event_type", arg.EventType)
+	r.logger.DebugContext(ctx, "Event data", "event_id", arg.EventID, "tenant_id", tenantID, "data", loggableData(arg.Data))
+
+	var event models.Event
+	err := withTenantTx(ctx, r.pool, tenantID, func(tx pgx.Tx) error {
+		// The insert gets its own savepoint so that a failure, such as a duplicate that raced
+		// past the pre-check, does not abort a request-scoped transaction it runs in
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, "begin savepoint", arg.EventID, tenantID.String())
+		}
+
+		event, err = r.insertEvent(ctx, db.New(savepoint), arg, tenantID, precheck)
+		if err != nil {
+			if rbErr := savepoint.Rollback(ctx); rbErr != nil {
+				return r.handleDatabaseError(ctx, rbErr, "roll back savepoint", arg.EventID, tenantID.String())
+			}
+			return err
+		}
+
+		if err := savepoint.Commit(ctx); err != nil {
+			return r.handleDatabaseError(ctx, err, "release savepoint", arg.EventID, tenantID.String())
+		}
+		return nil
+	})
+
+	if errors.Is(err, errKnownDuplicate) {
+		return models.Event{}, ErrEventAlreadyExists
+	}
+	if errors.Is(err, ErrEventSkipped) || errors.Is(err, ErrUnknownProvider) {
+		return models.Event{}, err
+	}
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to create event", "error", err, "event_id", arg.EventID, "tenant_id", tenantID)
+		return models.Event{}, err
+	}
+
+	return event, nil
+}
+
+// loggableData presents event data as raw JSON in logs, so it reads as JSON rather than
+// a quoted string or base64, and so LOG_SCRUB_PII can redact it key by key
+func loggableData(data any) any {
+	if b, err := convertInterfaceToBytes(data); err == nil {
+		return json.RawMessage(b)
+	}
+	return data
+}
+
+// insertEvent runs the tenant allowlist checks and the insert for one event on queries.
+// When precheck is true an already-stored provider event ID returns errKnownDuplicate.
+func (r EventsRepositoryImplementation) insertEvent(ctx context.Context, queries *db.Queries, arg models.CreateEventParams, tenantID uuid.UUID, precheck bool) (models.Event, error) {
+	acceptedTypes, err := queries.GetTenantAcceptedEventTypes(ctx, convertUUIDToPgtypeUUID(tenantID))
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return models.Event{}, r.handleDatabaseError(ctx, err, "get accepted event types", arg.EventID, tenantID.String())
+	}
+	if !isEventTypeAccepted(acceptedTypes, arg.EventType) {
+		r.logger.InfoContext(ctx, "Skipping event type not accepted by tenant", "event_id", arg.EventID, "tenant_id", tenantID, "event_type", arg.EventType)
+		return models.Event{}, ErrEventSkipped
+	}
+
+	allowedProviders, err := queries.GetTenantAllowedProviderIDs(ctx, convertUUIDToPgtypeUUID(tenantID))
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return models.Event{}, r.handleDatabaseError(ctx, err, "get allowed providers", arg.EventID, tenantID.String())
+	}
+	if !isProviderAllowed(allowedProviders, arg.ProviderID) {
+		r.logger.WarnContext(ctx, "Rejecting event from provider not registered for tenant", "event_id", arg.EventID, "tenant_id", tenantID, "provider_id", arg.ProviderID)
+		return models.Event{}, fmt.Errorf("%w: %s", ErrUnknownProvider, arg.ProviderID)
+	}
+
+	if precheck {
+		_, err := queries.GetEventByEventID(ctx, db.GetEventByEventIDParams{
+			ProviderID: convertUUIDToPgtypeUUID(arg.ProviderID),
+			EventID:    arg.EventID,
+		})
+		if err == nil {
+			r.logger.DebugContext(ctx, "Event already exists, skipping insert", "event_id", arg.EventID, "tenant_id", tenantID)
+			return models.Event{}, errKnownDuplicate
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return models.Event{}, r.handleDatabaseError(ctx, err, "check event exists", arg.EventID, tenantID.String())
+		}
+	}
+
+	params, err := toCreateEventDBParams(arg)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to convert event params", "error", err, "event_id", arg.EventID, "tenant_id", tenantID, "data", loggableData(arg.Data))
+		return models.Event{}, ErrConvertingDataToJSONb
+	}
+
+	dbEvent, err := queries.CreateEvent(ctx, params)
+	if err != nil {
+		return models.Event{}, r.handleDatabaseError(ctx, err, "create event", arg.EventID, tenantID.String())
+	}
+
+	event := toEventDomain(r.pool.unknownEnums(), dbEvent)
+	r.logger.InfoContext(ctx, "Event created successfully", "event_id", event.ID, "tenant_id", tenantID)
+	return event, nil
+}
+
+// CreateEventsBatch stores several events for a tenant and reports the outcome of each, in
+// input order. Every item goes through the same checks as CreateEventIdempotent; an item
+// that fails (skipped type, unknown provider, duplicate, invalid data) is rolled back to its
+// own savepoint without affecting the others.
+//
+// policy bounds the transaction size. A batch over policy.MaxSize returns ErrBatchTooLarge
+// without storing anything, unless policy.Chunk is set: then it is stored in transactions of
+// at most MaxSize events, and a chunk that cannot be committed stops the batch with its error
+// while the chunks before it stay stored.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - args: The events to store.
+//   - tenantID: UUID of the tenant that owns the events.
+//   - policy: The batch size limit and whether oversized batches are chunked.
+//
+// Returns:
+//   - []models.BatchEventResult: One result per item, in input order. When the batch is
+//     stopped by an error, only the items of the chunks stored before it.
+//   - error: ErrBatchTooLarge, or the error that stopped the batch.
+func (r EventsRepositoryImplementation) CreateEventsBatch(ctx context.Context, args []models.CreateEventParams, tenantID uuid.UUID, policy models.BatchPolicy) ([]models.BatchEventResult, error) {
+	chunkSize, err := batchChunkSize(len(args), policy)
+	if err != nil {
+		r.logger.WarnContext(ctx, "Rejecting oversized event batch", "size", len(args), "max_size", policy.MaxSize, "tenant_id", tenantID)
+		return nil, err
+	}
+	r.logger.InfoContext(ctx, "Creating event batch", "size", len(args), "chunk_size", chunkSize, "tenant_id", tenantID)
+
+	results := make([]models.BatchEventResult, 0, len(args))
+	for chunk := range slices.Chunk(args, chunkSize) {
+		chunkResults, err := r.createEventsChunk(ctx, chunk, tenantID)
+		if err != nil {
+			r.logger.ErrorContext(ctx, "Failed to store event batch chunk", "error", err, "stored", len(results), "size", len(args), "tenant_id", tenantID)
+			return results, err
+		}
+		results = append(results, chunkResults...)
+	}
+
+	return results, nil
+}
+
+// createEventsChunk stores args in one tenant transaction, giving each item its own savepoint
+func (r EventsRepositoryImplementation) createEventsChunk(ctx context.Context, args []models.CreateEventParams, tenantID uuid.UUID) ([]models.BatchEventResult, error) {
+	var results []models.BatchEventResult
+	err := withTenantTx(ctx, r.pool, tenantID, func(tx pgx.Tx) error {
+		results = make([]models.BatchEventResult, 0, len(args))
+		for _, arg := range args {
+			savepoint, err := tx.Begin(ctx)
+			if err != nil {
+				return r.handleDatabaseError(ctx, err, "begin savepoint", arg.EventID, tenantID.String())
+			}
+
+			event, err := r.insertEvent(ctx, db.New(savepoint), arg, tenantID, true)
+			if err != nil {
+				if rbErr := savepoint.Rollback(ctx); rbErr != nil {
+					return r.handleDatabaseError(ctx, rbErr, "roll back savepoint", arg.EventID, tenantID.String())
+				}
+				if errors.Is(err, errKnownDuplicate) {
+					err = ErrEventAlreadyExists
+				}
+				results = append(results, models.BatchEventResult{Err: err})
+				continue
+			}
+
+			if err := savepoint.Commit(ctx); err != nil {
+				return r.handleDatabaseError(ctx, err, "release savepoint", arg.EventID, tenantID.String())
+			}
+			results = append(results, models.BatchEventResult{Event: event})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// batchChunkSize returns how many events of a batch of size n go in one transaction, or
+// ErrBatchTooLarge when n exceeds the policy's limit and chunking is off
+func batchChunkSize(n int, policy models.BatchPolicy) (int, error) {
+	if policy.MaxSize <= 0 || n <= policy.MaxSize {
+		return max(n, 1), nil
+	}
+	if !policy.Chunk {
+		return 0, fmt.Errorf("%w: %d events, limit %d", ErrBatchTooLarge, n, policy.MaxSize)
+	}
+	return policy.MaxSize, nil
+}
+
+// UpdateEventIfVersion updates an event only if it has not been modified since it was read
+// (optimistic concurrency control), using updated_at as the version.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - arg: UpdateEventParams containing the fields to update.
+//   - expectedUpdatedAt: The updated_at value the caller last saw for the event.
+//   - tenantID: UUID of the tenant that owns the event.
+//
+// Returns:
+//   - models.Event: The updated event domain model.
+//   - error: ErrConcurrentModification if the event changed since expectedUpdatedAt,
+//     ErrEventNotFound if it does not exist, or any other error encountered during update.
+func (r EventsRepositoryImplementation) UpdateEventIfVersion(ctx context.Context, arg models.UpdateEventParams, expectedUpdatedAt time.Time, tenantID uuid.UUID) (models.Event, error) {
+	r.logger.InfoContext(ctx, "Updating event with version check", "event_id", arg.ID, "tenant_id", tenantID, "expected_updated_at", expectedUpdatedAt)
+
+	params, err := toUpdateEventDBParams(arg)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to convert update params", "error", err, "event_id", arg.ID, "tenant_id", tenantID)
+		return models.Event{}, ErrConvertingDataToJSONb
+	}
+
+	var domainEvent models.Event
+	err = WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		dbEvent, dbErr := queries.UpdateEventIfVersion(ctx, db.UpdateEventIfVersionParams{
+			EventType:         params.EventType,
+			Status:            params.Status,
+			Data:              params.Data,
+			MergeData:         params.MergeData,
+			ID:                params.ID,
+			ExpectedUpdatedAt: pgtype.Timestamptz{Time: expectedUpdatedAt, Valid: true},
+		})
+		if dbErr != nil {
+			if !errors.Is(dbErr, pgx.ErrNoRows) {
+				return r.handleDatabaseError(ctx, dbErr, "update event if version", arg.ID.String(), tenantID.String())
+			}
+
+			// No row matched: tell a missing event apart from a stale version
+			if _, getErr := queries.GetEventByID(ctx, params.ID); getErr != nil {
+				if errors.Is(getErr, pgx.ErrNoRows) {
+					r.logger.WarnContext(ctx, "Event not found for update", "event_id", arg.ID, "tenant_id", tenantID)
+					return ErrEventNotFound
+				}
+				return r.handleDatabaseError(ctx, getErr, "update event if version", arg.ID.String(), tenantID.String())
+			}
+
+			r.logger.WarnContext(ctx, "Event was modified concurrently", "event_id", arg.ID, "tenant_id", tenantID, "expected_updated_at", expectedUpdatedAt)
+			return ErrConcurrentModification
+		}
+
+		domainEvent = toEventDomain(r.pool.unknownEnums(), dbEvent)
+		r.logger.InfoContext(ctx, "Event updated successfully", "event_id", arg.ID, "tenant_id", tenantID)
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to update event", "error", err, "event_id", arg.ID, "tenant_id", tenantID)
+		return models.Event{}, err
+	}
+
+	return domainEvent, nil
+}
+
+// DeleteEvent removes an event from the database by its UUID.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - eventID: UUID of the event to delete.
+//   - tenantID: UUID of the tenant that owns the event.
+//
+// Returns:
+//   - int64: Number of rows affected (should be 1 if successful).
+//   - error: Any error encountered during deletion, ErrEventNotFound if no event matched.
+func (r EventsRepositoryImplementation) DeleteEvent(ctx context.Context, eventID uuid.UUID, tenantID uuid.UUID) (int64, error) {
+	return r.deleteEvent(ctx, eventID, tenantID, true)
+}
+
+// DeleteEventIdempotent removes an event from the database by its UUID, treating an
+// already-deleted (or never existing) event as success so retried deletes are safe.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - eventID: UUID of the event to delete.
+//   - tenantID: UUID of the tenant that owns the event.
+//
+// Returns:
+//   - int64: Number of rows affected (0 if the event was already gone).
+//   - error: Any error encountered during deletion.
+func (r EventsRepositoryImplementation) DeleteEventIdempotent(ctx context.Context, eventID uuid.UUID, tenantID uuid.UUID) (int64, error) {
+	return r.deleteEvent(ctx, eventID, tenantID, false)
+}
+
+// deleteEvent performs the delete; when strict is true, zero affected rows is reported as ErrEventNotFound.
+func (r EventsRepositoryImplementation) deleteEvent(ctx context.Context, eventID uuid.UUID, tenantID uuid.UUID, strict bool) (int64, error) {
+	r.logger.InfoContext(ctx, "Deleting event", "event_id", eventID, "tenant_id", tenantID, "strict", strict)
+
+	var rowsAffected int64
+	err := WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		rows, err := queries.DeleteEvent(ctx, convertUUIDToPgtypeUUID(eventID))
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, "delete event", eventID.String(), tenantID.String())
+		}
+
+		if rows == 0 {
+			if strict {
+				r.logger.WarnContext(ctx, "Event not found for deletion", "event_id", eventID, "tenant_id", tenantID)
+				return ErrEventNotFound
+			}
+			r.logger.InfoContext(ctx, "Event already deleted", "event_id", eventID, "tenant_id", tenantID)
+			return nil
+		}
+
+		rowsAffected = rows
+		r.logger.InfoContext(ctx, "Event deleted successfully", "event_id", eventID, "tenant_id", tenantID, "rows_affected", rowsAffected)
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to delete event", "error", err, "event_id", eventID, "tenant_id", tenantID)
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+// archivePageSize is how many events ArchiveEventsBefore reads per query
+const archivePageSize = 500
+
+// ArchiveEventsBefore writes every one of the tenant's events created before before to w as
+// NDJSON, one JSON event per line, oldest first. Events are read in keyset pages, each in its
+// own short transaction, so the archive never holds the whole set in memory or one long
+// transaction open. For gzip-NDJSON, pass a gzip.Writer and close it afterwards.
+// Nothing is deleted: archiving and purging are separate steps.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant whose events to archive.
+//   - before: Events created before this time are archived.
+//   - w: Destination of the NDJSON stream.
+//
+// Returns:
+//   - int64: Number of events written; on an error, the events written before it.
+//   - error: Any error encountered while reading or writing.
+func (r EventsRepositoryImplementation) ArchiveEventsBefore(ctx context.Context, tenantID uuid.UUID, before time.Time, w io.Writer) (int64, error) {
+	encoder := json.NewEncoder(w)
+	args := db.GetEventsBeforeParams{
+		TenantID:       convertUUIDToPgtypeUUID(tenantID),
+		Before:         pgtype.Timestamptz{Time: before, Valid: true},
+		AfterCreatedAt: pgtype.Timestamptz{InfinityModifier: pgtype.NegativeInfinity, Valid: true},
+		AfterID:        convertUUIDToPgtypeUUID(uuid.Nil),
+		MaxRows:        archivePageSize,
+	}
+
+	var written int64
+	for {
+		var page []db.Event
+		err := WithTenantReadContext(ctx, r.reader(), tenantID, func(queries *db.Queries) error {
+			var err error
+			page, err = queries.GetEventsBefore(ctx, args)
+			return err
+		})
+		if err != nil {
+			r.logger.ErrorContext(ctx, "Failed to read events to archive", "error", err, "tenant_id", tenantID, "written", written)
+			return written, err
+		}
+
+		for _, dbEvent := range page {
+			if err := encoder.Encode(toEventDomain(r.pool.unknownEnums(), dbEvent)); err != nil {
+				r.logger.ErrorContext(ctx, "Failed to write event archive", "error", err, "tenant_id", tenantID, "written", written)
+				return written, fmt.Errorf("write archive: %w", err)
+			}
+			written++
+		}
+
+		if len(page) < archivePageSize {
+			r.logger.InfoContext(ctx, "Archived events", "tenant_id", tenantID, "before", before, "archived", written)
+			return written, nil
+		}
+		last := page[len(page)-1]
+		args.AfterCreatedAt = last.CreatedAt
+		args.AfterID = last.ID
+	}
+}
+
+// PurgeEventsBefore deletes up to limit of the tenant's events created before before, oldest
+// first, in one short transaction. Callers purging a large backlog call it repeatedly until it
+// deletes fewer than limit events.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant whose events to purge.
+//   - before: Events created before this time are purged.
+//   - limit: The most events to delete in this call.
+//
+// Returns:
+//   - int64: Number of events deleted.
+//   - error: Any error encountered during deletion.
+func (r EventsRepositoryImplementation) PurgeEventsBefore(ctx context.Context, tenantID uuid.UUID, before time.Time, limit int32) (int64, error) {
+	var deleted int64
+	err := WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		rows, err := queries.PurgeEventsBefore(ctx, db.PurgeEventsBeforeParams{
+			TenantID:  convertUUIDToPgtypeUUID(tenantID),
+			CreatedAt: pgtype.Timestamptz{Time: before, Valid: true},
+			Limit:     limit,
+		})
+		if err != nil {
+			return err
+		}
+		deleted = rows
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to purge events", "error", err, "tenant_id", tenantID, "before", before)
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// GetEventRetentionPolicies returns every tenant with its own event retention override, if any.
+// The tenants table is not tenant-scoped, so this runs without a tenant context.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//
+// Returns:
+//   - []models.EventRetentionPolicy: One policy per tenant, ordered by tenant ID.
+//   - error: Any error encountered during retrieval.
+func (r EventsRepositoryImplementation) GetEventRetentionPolicies(ctx context.Context) ([]models.EventRetentionPolicy, error) {
+	conn, err := r.reader().acquire(ctx)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to retrieve event retention policies", "error", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := db.New(conn).ListTenantEventRetention(ctx)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to retrieve event retention policies", "error", err)
+		return nil, err
+	}
+
+	policies := make([]models.EventRetentionPolicy, len(rows))
+	for i, row := range rows {
+		policies[i] = models.EventRetentionPolicy{
+			TenantID:      convertPgtypeUUIDToUUID(row.ID),
+			RetentionDays: row.EventRetentionDays,
+		}
+	}
+	return policies, nil
+}
+
+// GetAllEvents retrieves all events from the database.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant that owns the events.
+//
+// Returns:
+//   - []models.Event: Slice of all event domain models.
+//   - error: Any error encountered during retrieval.
+func (r EventsRepositoryImplementation) GetAllEvents(ctx context.Context, tenantID uuid.UUID) ([]models.Event, error) {
+	r.logger.DebugContext(ctx, "Retrieving all events", "tenant_id", tenantID)
+
+	var events []models.Event
+	err := WithTenantReadContext(ctx, r.reader(), tenantID, func(queries *db.Queries) error {
+		// Use sensible defaults for pagination (limit 1000, offset 0)
+		dbEvents, err := queries.GetAllEvents(ctx, db.GetAllEventsParams{
+			Limit:  1000,
+			Offset: 0,
+		})
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, "get all events", "", tenantID.String())
+		}
+
+		// Pre-allocate slice with known capacity for better performance
+		events = make([]models.Event, 0, len(dbEvents))
+		for _, dbEvent := range dbEvents {
+			events = append(events, toEventDomain(r.pool.unknownEnums(), dbEvent))
+		}
+
+		r.logger.DebugContext(ctx, "Retrieved events successfully", "tenant_id", tenantID, "count", len(events))
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to retrieve events", "error", err, "tenant_id", tenantID)
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// GetAllEventsPaginated retrieves events from the database with pagination support.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant that owns the events.
+//   - params: Pagination parameters (limit and offset).
+//
+// Returns:
+//   - models.PaginatedResponse[models.Event]: Paginated response containing events and metadata.
+//   - error: Any error encountered during retrieval.
+func (r EventsRepositoryImplementation) GetAllEventsPaginated(ctx context.Context, tenantID uuid.UUID, params models.PaginationParams) (models.PaginatedResponse[models.Event], error) {
+	r.logger.DebugContext(ctx, "Retrieving events with pagination", "tenant_id", tenantID, "limit", params.Limit, "offset", params.Offset)
+
+	var events []models.Event
+	var totalCount int64
+
+	err := WithTenantReadContext(ctx, r.reader(), tenantID, func(queries *db.Queries) error {
+		// Get total count
+		count, err := queries.CountAllEvents(ctx)
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, "count events", "", tenantID.String())
+		}
+		totalCount = count
+
+		// Get paginated events
+		dbEvents, err := queries.GetAllEventsPaginated(ctx, db.GetAllEventsPaginatedParams{
+			Limit:  params.Limit,
+			Offset: params.Offset,
+		})
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, "get paginated events", "", tenantID.String())
+		}
+
+		// Convert to domain models
+		events = make([]models.Event, 0, len(dbEvents))
+		for _, dbEvent := range dbEvents {
+			events = append(events, toEventDomain(r.pool.unknownEnums(), dbEvent))
+		}
+
+		r.logger.DebugContext(ctx, "Retrieved paginated events successfully", "tenant_id", tenantID, "count", len(events), "total_count", totalCount)
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to retrieve paginated events", "error", err, "tenant_id", tenantID)
+		return models.PaginatedResponse[models.Event]{}, err
+	}
+
+	// Create paginated response
+	response := models.NewPaginatedResponse(events, totalCount, params.Limit, params.Offset)
+	return response, nil
+}
+
+// ListEvents retrieves the events matching filter, newest first, with pagination support.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant that owns the events.
+//   - filter: Event types, statuses and providers to match; empty fields match everything.
+//   - params: Pagination parameters (limit and offset).
+//
+// Returns:
+//   - models.PaginatedResponse[models.Event]: The matching page of events and the total number of matches.
+//   - error: Any error encountered during retrieval.
+func (r EventsRepositoryImplementation) ListEvents(ctx context.Context, tenantID uuid.UUID, filter models.EventFilter, params models.PaginationParams) (models.PaginatedResponse[models.Event], error) {
+	r.logger.DebugContext(ctx, "Listing events", "tenant_id", tenantID, "event_types", filter.EventTypes, "statuses", filter.Statuses, "provider_ids", filter.ProviderIDs, "limit", params.Limit, "offset", params.Offset)
+
+	args := toEventFilterDBArgs(filter)
+
+	var events []models.Event
+	var totalCount int64
+	err := WithTenantReadContext(ctx, r.reader(), tenantID, func(queries *db.Queries) error {
+		count, err := queries.CountEventsByFilter(ctx, db.CountEventsByFilterParams{
+			EventTypes:  args.eventTypes,
+			Statuses:    args.statuses,
+			ProviderIds: args.providerIDs,
+		})
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, "count filtered events", "", tenantID.String())
+		}
+		totalCount = count
+
+		dbEvents, err := queries.ListEventsByFilter(ctx, db.ListEventsByFilterParams{
+			EventTypes:  args.eventTypes,
+			Statuses:    args.statuses,
+			ProviderIds: args.providerIDs,
+			Limit:       params.Limit,
+			Offset:      params.Offset,
+		})
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, "list filtered events", "", tenantID.String())
+		}
+
+		events = make([]models.Event, 0, len(dbEvents))
+		for _, dbEvent := range dbEvents {
+			events = append(events, toEventDomain(r.pool.unknownEnums(), dbEvent))
+		}
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list events", "error", err, "tenant_id", tenantID)
+		return models.PaginatedResponse[models.Event]{}, err
+	}
+
+	return models.NewPaginatedResponse(events, totalCount, params.Limit, params.Offset), nil
+}
+
+// ListEventsAfter retrieves up to limit of the events matching filter with an ID greater than
+// after, in ID order, from the read pool. Pass uuid.Nil to start from the beginning and the
+// last ID returned to continue; unlike offset pages, events inserted in between don't shift
+// the pages of a pass over all of them.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant that owns the events.
+//   - filter: Event types, statuses and providers to match; empty fields match everything.
+//   - after: Only events with a greater ID are returned; uuid.Nil for the first page.
+//   - limit: Maximum number of events to return.
+//
+// Returns:
+//   - []models.Event: The matching events, in ID order; fewer than limit on the last page.
+//   - error: Any error encountered during retrieval.
+func (r EventsRepositoryImplementation) ListEventsAfter(ctx context.Context, tenantID uuid.UUID, filter models.EventFilter, after uuid.UUID, limit int32) ([]models.Event, error) {
+	r.logger.DebugContext(ctx, "Listing events after cursor", "tenant_id", tenantID, "event_types", filter.EventTypes, "statuses", filter.Statuses, "provider_ids", filter.ProviderIDs, "after", after, "limit", limit)
+
+	args := toEventFilterDBArgs(filter)
+
+	var events []models.Event
+	err := WithTenantReadContext(ctx, r.reader(), tenantID, func(queries *db.Queries) error {
+		dbEvents, err := queries.ListEventsByFilterAfterID(ctx, db.ListEventsByFilterAfterIDParams{
+			AfterID:     convertUUIDToPgtypeUUID(after),
+			EventTypes:  args.eventTypes,
+			Statuses:    args.statuses,
+			ProviderIds: args.providerIDs,
+			MaxRows:     limit,
+		})
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, "list filtered events after cursor", "", tenantID.String())
+		}
+
+		events = make([]models.Event, 0, len(dbEvents))
+		for _, dbEvent := range dbEvents {
+			events = append(events, toEventDomain(r.pool.unknownEnums(), dbEvent))
+		}
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list events after cursor", "error", err, "tenant_id", tenantID)
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// ListEventsForExport returns up to limit of the tenant's events that come after the cursor in
+// newest-first creation order, with id breaking ties, from the read pool. Passing the last event
+// of one page as the cursor of the next streams every event without the cost of deep offsets
+// or a count per page.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant that owns the events.
+//   - after: The position to continue after; the zero cursor starts from the newest event.
+//   - limit: Maximum number of events to return.
+//
+// Returns:
+//   - []models.Event: The events of the page, newest first; fewer than limit means there are no more.
+//   - error: Any error encountered during retrieval.
+func (r EventsRepositoryImplementation) ListEventsForExport(ctx context.Context, tenantID uuid.UUID, after models.EventCursor, limit int32) ([]models.Event, error) {
+	afterCreatedAt := pgtype.Timestamptz{Time: after.CreatedAt, Valid: true}
+	if after == (models.EventCursor{}) {
+		afterCreatedAt = pgtype.Timestamptz{InfinityModifier: pgtype.Infinity, Valid: true}
+	}
+
+	var events []models.Event
+	err := WithTenantReadContext(ctx, r.reader(), tenantID, func(queries *db.Queries) error {
+		dbEvents, err := queries.ListEventsForExport(ctx, db.ListEventsForExportParams{
+			AfterCreatedAt: afterCreatedAt,
+			AfterID:        convertUUIDToPgtypeUUID(after.ID),
+			MaxRows:        limit,
+		})
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, "list events for export", "", tenantID.String())
+		}
+
+		events = make([]models.Event, 0, len(dbEvents))
+		for _, dbEvent := range dbEvents {
+			events = append(events, toEventDomain(r.pool.unknownEnums(), dbEvent))
+		}
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list events for export", "error", err, "tenant_id", tenantID, "after_id", after.ID)
+		return nil, err
+	}
+	return events, nil
+}
+
+// SearchEventsByExternalID retrieves the tenant's events whose provider event ID starts with
+// prefix, in event ID order, with pagination support. The prefix is matched literally: LIKE
+// wildcards in it are escaped. It reads from the read pool.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant that owns the events.
+//   - prefix: The start of the provider event ID to match; callers reject an empty prefix.
+//   - params: Pagination parameters (limit and offset).
+//
+// Returns:
+//   - models.PaginatedResponse[models.Event]: The matching page of events and the total number of matches.
+//   - error: Any error encountered during retrieval.
+func (r EventsRepositoryImplementation) SearchEventsByExternalID(ctx context.Context, tenantID uuid.UUID, prefix string, params models.PaginationParams) (models.PaginatedResponse[models.Event], error) {
+	r.logger.DebugContext(ctx, "Searching events by external ID", "tenant_id", tenantID, "prefix", prefix, "limit", params.Limit, "offset", params.Offset)
+
+	pgTenantID := convertUUIDToPgtypeUUID(tenantID)
+	pattern := likePrefixPattern(prefix)
+
+	var events []models.Event
+	var totalCount int64
+	err := WithTenantReadContext(ctx, r.reader(), tenantID, func(queries *db.Queries) error {
+		count, err := queries.CountEventsByExternalIDPrefix(ctx, db.CountEventsByExternalIDPrefixParams{
+			TenantID: pgTenantID,
+			Pattern:  pattern,
+		})
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, "count events by external ID", "", tenantID.String())
+		}
+		totalCount = count
+
+		dbEvents, err := queries.SearchEventsByExternalIDPrefix(ctx, db.SearchEventsByExternalIDPrefixParams{
+			TenantID: pgTenantID,
+			Pattern:  pattern,
+			Limit:    params.Limit,
+			Offset:   params.Offset,
+		})
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, "search events by external ID", "", tenantID.String())
+		}
+
+		events = make([]models.Event, 0, len(dbEvents))
+		for _, dbEvent := range dbEvents {
+			events = append(events, toEventDomain(r.pool.unknownEnums(), dbEvent))
+		}
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to search events by external ID", "error", err, "tenant_id", tenantID)
+		return models.PaginatedResponse[models.Event]{}, err
+	}
+
+	return models.NewPaginatedResponse(events, totalCount, params.Limit, params.Offset), nil
+}
+
+// likeEscaper escapes the LIKE wildcards and the escape character itself
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// likePrefixPattern returns a LIKE pattern matching values that start with prefix literally
+func likePrefixPattern(prefix string) string {
+	return likeEscaper.Replace(prefix) + "%"
+}
+
+// GetEventsWithoutLeak retrieves the tenant's events of eventType that no leak is linked to
+// through leak_events, newest first, with pagination support. It reads from the read pool.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant that owns the events.
+//   - eventType: The event type to report on, typically payment_failed.
+//   - params: Pagination parameters (limit and offset).
+//
+// Returns:
+//   - models.PaginatedResponse[models.Event]: The page of unlinked events and their total number.
+//   - error: Any error encountered during retrieval.
+func (r EventsRepositoryImplementation) GetEventsWithoutLeak(ctx context.Context, tenantID uuid.UUID, eventType models.EventTypeEnum, params models.PaginationParams) (models.PaginatedResponse[models.Event], error) {
+	r.logger.DebugContext(ctx, "Listing events without a leak", "tenant_id", tenantID, "event_type", eventType, "limit", params.Limit, "offset", params.Offset)
+
+	var events []models.Event
+	var totalCount int64
+	err := WithTenantReadContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		count, err := queries.CountEventsWithoutLeak(ctx, db.EventTypeEnum(eventType))
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, "count events without leak", "", tenantID.String())
+		}
+		totalCount = count
+
+		dbEvents, err := queries.GetEventsWithoutLeak(ctx, db.GetEventsWithoutLeakParams{
+			EventType: db.EventTypeEnum(eventType),
+			Limit:     params.Limit,
+			Offset:    params.Offset,
+		})
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, "list events without leak", "", tenantID.String())
+		}
+
+		events = make([]models.Event, 0, len(dbEvents))
+		for _, dbEvent := range dbEvents {
+			events = append(events, toEventDomain(r.pool.unknownEnums(), dbEvent))
+		}
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list events without a leak", "error", err, "tenant_id", tenantID)
+		return models.PaginatedResponse[models.Event]{}, err
+	}
+
+	return models.NewPaginatedResponse(events, totalCount, params.Limit, params.Offset), nil
+}
+
+// GetEventsBySource retrieves the tenant's events that entered the system through source,
+// newest first, with pagination support. It reads from the read pool.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant that owns the events.
+//   - source: The ingestion path to list events of, e.g. webhook or import.
+//   - params: Pagination parameters (limit and offset).
+//
+// Returns:
+//   - models.PaginatedResponse[models.Event]: The page of events and their total number.
+//   - error: Any error encountered during retrieval.
+func (r EventsRepositoryImplementation) GetEventsBySource(ctx context.Context, tenantID uuid.UUID, source models.EventSourceEnum, params models.PaginationParams) (models.PaginatedResponse[models.Event], error) {
+	r.logger.DebugContext(ctx, "Listing events by source", "tenant_id", tenantID, "source", source, "limit", params.Limit, "offset", params.Offset)
+
+	var events []models.Event
+	var totalCount int64
+	err := WithTenantReadContext(ctx, r.reader(), tenantID, func(queries *db.Queries) error {
+		count, err := queries.CountEventsBySource(ctx, db.CountEventsBySourceParams{
+			TenantID: convertUUIDToPgtypeUUID(tenantID),
+			Source:   db.EventSourceEnum(source),
+		})
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, "count events by source", "", tenantID.String())
+		}
+		totalCount = count
+
+		dbEvents, err := queries.GetEventsBySource(ctx, db.GetEventsBySourceParams{
+			TenantID: convertUUIDToPgtypeUUID(tenantID),
+			Source:   db.EventSourceEnum(source),
+			Limit:    params.Limit,
+			Offset:   params.Offset,
+		})
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, "list events by source", "", tenantID.String())
+		}
+
+		events = make([]models.Event, 0, len(dbEvents))
+		for _, dbEvent := range dbEvents {
+			events = append(events, toEventDomain(r.pool.unknownEnums(), dbEvent))
+		}
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list events by source", "error", err, "tenant_id", tenantID, "source", source)
+		return models.PaginatedResponse[models.Event]{}, err
+	}
+
+	return models.NewPaginatedResponse(events, totalCount, params.Limit, params.Offset), nil
+}
+
+// eventFilterDBArgs holds a filter as the arrays the filter queries compare against
+type eventFilterDBArgs struct {
+	eventTypes  []string
+	statuses    []string
+	providerIDs []pgtype.UUID
+}
+
+// toEventFilterDBArgs converts a filter to the arrays the filter queries compare against.
+// Empty fields become empty, non-nil arrays so the queries' cardinality checks match everything.
+func toEventFilterDBArgs(filter models.EventFilter) eventFilterDBArgs {
+	args := eventFilterDBArgs{
+		eventTypes:  make([]string, 0, len(filter.EventTypes)),
+		statuses:    make([]string, 0, len(filter.Statuses)),
+		providerIDs: make([]pgtype.UUID, 0, len(filter.ProviderIDs)),
+	}
+	for _, t := range filter.EventTypes {
+		args.eventTypes = append(args.eventTypes, string(t))
+	}
+	for _, s := range filter.Statuses {
+		args.statuses = append(args.statuses, string(s))
+	}
+	for _, id := range filter.ProviderIDs {
+		args.providerIDs = append(args.providerIDs, convertUUIDToPgtypeUUID(id))
+	}
+	return args
+}
+
+// GetEventByID retrieves a single event by its UUID.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - eventID: UUID of the event to retrieve.
+//   - tenantID: UUID of the tenant that owns the event.
+//
+// Returns:
+//   - models.Event: The event domain model if found.
+//   - error: Any error encountered during retrieval.
+func (r EventsRepositoryImplementation) GetEventByID(ctx context.Context, eventID uuid.UUID, tenantID uuid.UUID) (models.Event, error) {
+	r.logger.DebugContext(ctx, "Retrieving event by ID", "event_id", eventID, "tenant_id", tenantID)
+
+	var event models.Event
+	err := WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		dbEvent, err := queries.GetEventByID(ctx, convertUUIDToPgtypeUUID(eventID))
+		if err != nil {
+			// Check if it's a "no rows" error
+			if errors.Is(err, pgx.ErrNoRows) {
+				r.logger.WarnContext(ctx, "Event not found", "event_id", eventID, "tenant_id", tenantID)
+				return ErrEventNotFound
+			}
+
+			return r.handleDatabaseError(ctx, err, "get event by ID", eventID.String(), tenantID.String())
+		}
+
+		event = toEventDomain(r.pool.unknownEnums(), dbEvent)
+		r.logger.DebugContext(ctx, "Event retrieved successfully", "event_id", eventID, "tenant_id", tenantID)
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to retrieve event", "error", err, "event_id", eventID, "tenant_id", tenantID)
+		return models.Event{}, err
+	}
+
+	return event, nil
+}
+
+// GetEventsByIDs retrieves several events by UUID in a single query.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant that owns the events.
+//   - ids: UUIDs of the events to retrieve.
+//
+// Returns:
+//   - map[uuid.UUID]models.Event: Found events keyed by ID; IDs that don't exist are absent.
+//   - error: Any error encountered during retrieval.
+func (r EventsRepositoryImplementation) GetEventsByIDs(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) (map[uuid.UUID]models.Event, error) {
+	events := make(map[uuid.UUID]models.Event, len(ids))
+	if len(ids) == 0 {
+		return events, nil
+	}
+
+	r.logger.DebugContext(ctx, "Retrieving events by IDs", "tenant_id", tenantID, "requested", len(ids))
+
+	pgIDs := make([]pgtype.UUID, 0, len(ids))
+	for _, id := range ids {
+		pgIDs = append(pgIDs, convertUUIDToPgtypeUUID(id))
+	}
+
+	err := WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		dbEvents, err := queries.GetEventsByIDs(ctx, pgIDs)
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, "get events by IDs", "", tenantID.String())
+		}
+
+		for _, dbEvent := range dbEvents {
+			event := toEventDomain(r.pool.unknownEnums(), dbEvent)
+			events[event.ID] = event
+		}
+
+		r.logger.DebugContext(ctx, "Events retrieved successfully", "tenant_id", tenantID, "requested", len(ids), "found", len(events))
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to retrieve events by IDs", "error", err, "tenant_id", tenantID)
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// GetEventsByLeakID retrieves the events linked to a leak through leak_events, oldest first.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - leakID: UUID of the leak whose triggering events to retrieve.
+//   - tenantID: UUID of the tenant that owns the leak.
+//
+// Returns:
+//   - []models.Event: The leak's triggering events; empty if none are linked.
+//   - error: Any error encountered during retrieval.
+func (r EventsRepositoryImplementation) GetEventsByLeakID(ctx context.Context, leakID uuid.UUID, tenantID uuid.UUID) ([]models.Event, error) {
+	r.logger.DebugContext(ctx, "Retrieving events by leak ID", "leak_id", leakID, "tenant_id", tenantID)
+
+	var events []models.Event
+	err := WithTenantReadContext(ctx, r.reader(), tenantID, func(queries *db.Queries) error {
+		dbEvents, err := queries.GetEventsByLeakID(ctx, convertUUIDToPgtypeUUID(leakID))
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, "get events by leak ID", "", tenantID.String())
+		}
+
+		events = make([]models.Event, 0, len(dbEvents))
+		for _, dbEvent := range dbEvents {
+			events = append(events, toEventDomain(r.pool.unknownEnums(), dbEvent))
+		}
+
+		r.logger.DebugContext(ctx, "Events retrieved successfully", "leak_id", leakID, "tenant_id", tenantID, "count", len(events))
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to retrieve events by leak ID", "error", err, "leak_id", leakID, "tenant_id", tenantID)
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// GetFailedEvents retrieves up to limit of the tenant's failed events with an ID greater than
+// after, in ID order. Pass uuid.Nil to start from the beginning and the last ID returned to
+// continue; events that leave the failed status in between are simply not seen again.
+// It reads from the primary, since callers typically update what it returns.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant that owns the events.
+//   - after: Only events with a greater ID are returned; uuid.Nil for the first page.
+//   - limit: Maximum number of events to return.
+//
+// Returns:
+//   - []models.Event: The failed events, in ID order; fewer than limit on the last page.
+//   - error: Any error encountered during retrieval.
+func (r EventsRepositoryImplementation) GetFailedEvents(ctx context.Context, tenantID uuid.UUID, after uuid.UUID, limit int32) ([]models.Event, error) {
+	r.logger.DebugContext(ctx, "Retrieving failed events", "tenant_id", tenantID, "after", after, "limit", limit)
+
+	var events []models.Event
+	err := WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		dbEvents, err := queries.GetFailedEvents(ctx, db.GetFailedEventsParams{
+			AfterID: convertUUIDToPgtypeUUID(after),
+			MaxRows: limit,
+		})
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, "get failed events", "", tenantID.String())
+		}
+
+		events = make([]models.Event, 0, len(dbEvents))
+		for _, dbEvent := range dbEvents {
+			events = append(events, toEventDomain(r.pool.unknownEnums(), dbEvent))
+		}
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to retrieve failed events", "error", err, "tenant_id", tenantID)
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// GetPendingEventsOldestFirst claims up to limit of the tenant's pending events for processing,
+// oldest first, by moving them to the processing status. Rows locked by a concurrent claim are
+// skipped rather than waited on, so workers draining the same tenant never get the same event.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant that owns the events.
+//   - limit: Maximum number of events to claim.
+//
+// Returns:
+//   - []models.Event: The claimed events, oldest first; empty when nothing is pending.
+//   - error: ErrInvalidClaimLimit for a limit below 1, or any error encountered while claiming.
+func (r EventsRepositoryImplementation) GetPendingEventsOldestFirst(ctx context.Context, tenantID uuid.UUID, limit int) ([]models.Event, error) {
+	r.logger.DebugContext(ctx, "Claiming pending events", "tenant_id", tenantID, "limit", limit)
+
+	if limit < 1 || limit > math.MaxInt32 {
+		return nil, ErrInvalidClaimLimit
+	}
+
+	events := []models.Event{}
+	err := WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		dbEvents, err := queries.ClaimPendingEvents(ctx, int32(limit))
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, "claim pending events", "", tenantID.String())
+		}
+
+		for _, dbEvent := range dbEvents {
+			events = append(events, toEventDomain(r.pool.unknownEnums(), dbEvent))
+		}
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to claim pending events", "error", err, "tenant_id", tenantID)
+		return nil, err
+	}
+
+	// RETURNING does not keep the subquery's order
+	slices.SortFunc(events, func(a, b models.Event) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID.String(), b.ID.String())
+	})
+
+	r.logger.DebugContext(ctx, "Claimed pending events", "tenant_id", tenantID, "count", len(events))
+	return events, nil
+}
+
+// GetRecentEvents retrieves the tenant's n newest events, newest first, from the read pool.
+// The query is a plain LIMIT over the (tenant_id, created_at) index, so it stays cheap no
+// matter how many events the tenant has.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant that owns the events.
+//   - n: How many events to return; callers bound it.
+//
+// Returns:
+//   - []models.Event: Up to n events, newest first.
+//   - error: Any error encountered during retrieval.
+func (r EventsRepositoryImplementation) GetRecentEvents(ctx context.Context, tenantID uuid.UUID, n int) ([]models.Event, error) {
+	r.logger.DebugContext(ctx, "Retrieving recent events", "tenant_id", tenantID, "n", n)
+
+	events := []models.Event{}
+	err := WithTenantReadContext(ctx, r.reader(), tenantID, func(queries *db.Queries) error {
+		dbEvents, err := queries.GetRecentEvents(ctx, db.GetRecentEventsParams{
+			TenantID: convertUUIDToPgtypeUUID(tenantID),
+			MaxRows:  int32(n),
+		})
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, "get recent events", "", tenantID.String())
+		}
+
+		for _, dbEvent := range dbEvents {
+			events = append(events, toEventDomain(r.pool.unknownEnums(), dbEvent))
+		}
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to retrieve recent events", "error", err, "tenant_id", tenantID)
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// GetEventStatusHistory retrieves the status changes of an event, oldest first. The history
+// is written by a database trigger whenever an update changes the event's status.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - eventID: UUID of the event whose history to retrieve.
+//   - tenantID: UUID of the tenant that owns the event.
+//
+// Returns:
+//   - []models.EventStatusChange: The event's status changes; empty if its status never changed.
+//   - error: ErrEventNotFound if the event does not exist, or any other error encountered during retrieval.
+func (r EventsRepositoryImplementation) GetEventStatusHistory(ctx context.Context, eventID uuid.UUID, tenantID uuid.UUID) ([]models.EventStatusChange, error) {
+	r.logger.DebugContext(ctx, "Retrieving event status history", "event_id", eventID, "tenant_id", tenantID)
+
+	history := []models.EventStatusChange{}
+	err := WithTenantReadContext(ctx, r.reader(), tenantID, func(queries *db.Queries) error {
+		id := convertUUIDToPgtypeUUID(eventID)
+		if _, err := queries.GetEventByID(ctx, id); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrEventNotFound
+			}
+			return r.handleDatabaseError(ctx, err, "get event status history", eventID.String(), tenantID.String())
+		}
+
+		rows, err := queries.GetEventStatusHistory(ctx, id)
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, "get event status history", eventID.String(), tenantID.String())
+		}
+
+		for _, row := range rows {
+			history = append(history, models.EventStatusChange{
+				FromStatus: models.EventStatusEnum(row.FromStatus),
+				ToStatus:   models.EventStatusEnum(row.ToStatus),
+				ChangedAt:  row.ChangedAt.Time,
+			})
+		}
+		return nil
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrEventNotFound) {
+			r.logger.WarnContext(ctx, "Event not found", "event_id", eventID, "tenant_id", tenantID)
+		} else {
+			r.logger.ErrorContext(ctx, "Failed to retrieve event status history", "error", err, "event_id", eventID, "tenant_id", tenantID)
+		}
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// GetRelatedEvents retrieves the events correlated with an event under the given rule,
+// across all of the tenant's providers, oldest first.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant that owns the events.
+//   - eventID: UUID of the event to find related events for.
+//   - rule: Data keys that must match and the time window around the event.
+//
+// Returns:
+//   - []models.Event: Related events, excluding the event itself; empty if the event lacks a correlation key.
+//   - error: ErrEventNotFound if the event does not exist, or any other error encountered during retrieval.
+func (r EventsRepositoryImplementation) GetRelatedEvents(ctx context.Context, tenantID uuid.UUID, eventID uuid.UUID, rule models.CorrelationRule) ([]models.Event, error) {
+	r.logger.DebugContext(ctx, "Retrieving related events", "event_id", eventID, "tenant_id", tenantID, "keys", rule.Keys)
+
+	events := []models.Event{}
+	err := WithTenantReadContext(ctx, r.reader(), tenantID, func(queries *db.Queries) error {
+		source, err := queries.GetEventByID(ctx, convertUUIDToPgtypeUUID(eventID))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrEventNotFound
+			}
+			return r.handleDatabaseError(ctx, err, "get related events", eventID.String(), tenantID.String())
+		}
+
+		matches, ok := correlationMatches(source.Data, rule.Keys)
+		if !ok {
+			r.logger.DebugContext(ctx, "Event has no correlation keys", "event_id", eventID, "tenant_id", tenantID)
+			return nil
+		}
+
+		dbEvents, err := queries.GetRelatedEvents(ctx, db.GetRelatedEventsParams{
+			ID:          source.ID,
+			WindowStart: pgtype.Timestamptz{Time: source.CreatedAt.Time.Add(-rule.Window), Valid: true},
+			WindowEnd:   pgtype.Timestamptz{Time: source.CreatedAt.Time.Add(rule.Window), Valid: true},
+			Matches:     matches,
+		})
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, "get related events", eventID.String(), tenantID.String())
+		}
+
+		for _, dbEvent := range dbEvents {
+			events = append(events, toEventDomain(r.pool.unknownEnums(), dbEvent))
+		}
+		return nil
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrEventNotFound) {
+			r.logger.WarnContext(ctx, "Event not found", "event_id", eventID, "tenant_id", tenantID)
+		} else {
+			r.logger.ErrorContext(ctx, "Failed to retrieve related events", "error", err, "event_id", eventID, "tenant_id", tenantID)
+		}
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// correlationObjectPaths are where providers put the object an event is about in its data: the
+// top level for plain webhooks and data.object in a Stripe event envelope
+var correlationObjectPaths = [][]string{nil, {"data", "object"}}
+
+// correlationMatches builds the JSONB containment filters for the given keys from an event's
+// data. The values are read from the first object path that holds every key, and one filter is
+// built per object path, so events from providers that nest their object differently match.
+// It reports false when there are no keys or no object path holds every key with a non-null value.
+func correlationMatches(data []byte, keys []string) ([][]byte, bool) {
+	if len(keys) == 0 {
+		return nil, false
+	}
+
+	var values map[string]json.RawMessage
+	for _, path := range correlationObjectPaths {
+		if found, ok := correlationValues(data, path, keys); ok {
+			values = found
+			break
+		}
+	}
+	if values == nil {
+		return nil, false
+	}
+
+	matches := make([][]byte, 0, len(correlationObjectPaths))
+	for _, path := range correlationObjectPaths {
+		var match any = values
+		for i := len(path) - 1; i >= 0; i-- {
+			match = map[string]any{path[i]: match}
+		}
+		encoded, err := json.Marshal(match)
+		if err != nil {
+			return nil, false
+		}
+		matches = append(matches, encoded)
+	}
+	return matches, true
+}
+
+// correlationValues returns the values of keys in the JSON object found at path in data. It
+// reports false when there is no object at path or a key is missing or null.
+func correlationValues(data []byte, path []string, keys []string) (map[string]json.RawMessage, bool) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, false
+	}
+	for _, name := range path {
+		var nested map[string]json.RawMessage
+		if err := json.Unmarshal(fields[name], &nested); err != nil || nested == nil {
+			return nil, false
+		}
+		fields = nested
+	}
+
+	values := make(map[string]json.RawMessage, len(keys))
+	for _, key := range keys {
+		value, ok := fields[key]
+		if !ok || string(value) == "null" {
+			return nil, false
+		}
+		values[key] = value
+	}
+	return values, true
+}
+
+// UpdateEvent updates an existing event in the database.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - arg: UpdateEventParams containing the fields to update.
+//   - tenantID: UUID of the tenant that owns the event.
+//
+// Returns:
+//   - models.Event: The updated event domain model.
+//   - error: Any error encountered during update.
+func (r EventsRepositoryImplementation) UpdateEvent(ctx context.Context, arg models.UpdateEventParams, tenantID uuid.UUID) (models.Event, error) {
+	r.logger.InfoContext(ctx, "Updating event", "event_id", arg.ID, "tenant_id", tenantID)
+
+	params, err := toUpdateEventDBParams(arg)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to convert update params", "error", err, "event_id", arg.ID, "tenant_id", tenantID)
+		return models.Event{}, ErrConvertingDataToJSONb
+	}
+
+	var domainEvent models.Event
+	err = WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		dbEvent, dbErr := queries.UpdateEvent(ctx, params)
+		if dbErr != nil {
+			// Check if it's a "no rows" error
+			if errors.Is(dbErr, pgx.ErrNoRows) {
+				r.logger.WarnContext(ctx, "Event not found for update", "event_id", arg.ID, "tenant_id", tenantID)
+				return ErrEventNotFound
+			}
+
+			return r.handleDatabaseError(ctx, dbErr, "update event", arg.ID.String(), tenantID.String())
+		}
+
+		domainEvent = toEventDomain(r.pool.unknownEnums(), dbEvent)
+		r.logger.InfoContext(ctx, "Event updated successfully", "event_id", arg.ID, "tenant_id", tenantID)
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to update event", "error", err, "event_id", arg.ID, "tenant_id", tenantID)
+		return models.Event{}, err
+	}
+
+	return domainEvent, nil
+}
+
+// UpdateEventStatusByFilter sets the status of every event matching filter in a single
+// UPDATE, for example marking a provider's pending events processed after a backfill.
+// An empty filter is refused with ErrEmptyEventFilter rather than updating every event.
 //
 // Parameters:
 //   - ctx: Context for request-scoped values, cancellation, and deadlines.
-//   - arg: CreateEventParams containing the event details as a domain model.
-//   - tenantID: UUID of the tenant that owns the event.
+//   - tenantID: UUID of the tenant that owns the events.
+//   - filter: Event types, statuses and providers to match; at least one must be set.
+//   - newStatus: Status to set on the matching events.
 //
 // Returns:
-//   - models.Event: The created event as a domain model.
-//   - error: Any error encountered during creation.
-func (r EventsRepositoryImplementation) CreateEvent(ctx context.Context, arg models.CreateEventParams, tenantID uuid.UUID) (models.Event, error) {
-	r.logger.InfoContext(ctx, "Creating event", "event_id", arg.EventID, "tenant_id", tenantID, "event_type", arg.EventType)
+//   - int64: Number of events updated.
+//   - error: ErrEmptyEventFilter, or any error encountered during the update.
+func (r EventsRepositoryImplementation) UpdateEventStatusByFilter(ctx context.Context, tenantID uuid.UUID, filter models.EventFilter, newStatus models.EventStatusEnum) (int64, error) {
+	if filter.IsEmpty() {
+		r.logger.WarnContext(ctx, "Refusing bulk status update with an empty filter", "tenant_id", tenantID, "status", newStatus)
+		return 0, ErrEmptyEventFilter
+	}
+	r.logger.InfoContext(ctx, "Updating event status by filter", "tenant_id", tenantID, "event_types", filter.EventTypes, "statuses", filter.Statuses, "provider_ids", filter.ProviderIDs, "status", newStatus)
 
-	var event models.Event
-	err := WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
-		params, err := toCreateEventDBParams(arg)
-		if err != nil {
-			r.logger.ErrorContext(ctx, "Failed to convert event params", "error", err, "event_id", arg.EventID, "tenant_id", tenantID)
-			return ErrConvertingDataToJSONb
-		}
+	args := toEventFilterDBArgs(filter)
 
-		dbEvent, err := queries.CreateEvent(ctx, params)
+	var rowsAffected int64
+	err := WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		rows, err := queries.UpdateEventStatusByFilter(ctx, db.UpdateEventStatusByFilterParams{
+			NewStatus:   db.EventStatusEnum(newStatus),
+			EventTypes:  args.eventTypes,
+			Statuses:    args.statuses,
+			ProviderIds: args.providerIDs,
+		})
 		if err != nil {
-			return r.handleDatabaseError(ctx, err, "create event", arg.EventID, tenantID.String())
+			return r.handleDatabaseError(ctx, err, "update event status by filter", "", tenantID.String())
 		}
-
-		event = toEventDomain(dbEvent)
-		r.logger.InfoContext(ctx, "Event created successfully", "event_id", event.ID, "tenant_id", tenantID)
+		rowsAffected = rows
 		return nil
 	})
 
 	if err != nil {
-		r.logger.ErrorContext(ctx, "Failed to create event", "error", err, "event_id", arg.EventID, "tenant_id", tenantID)
-		return models.Event{}, err
+		r.logger.ErrorContext(ctx, "Failed to update event status by filter", "error", err, "tenant_id", tenantID)
+		return 0, err
 	}
 
-	return event, nil
+	r.logger.InfoContext(ctx, "Event status updated by filter", "tenant_id", tenantID, "status", newStatus, "rows_affected", rowsAffected)
+	return rowsAffected, nil
 }
 
-// DeleteEvent removes an event from the database by its UUID.
+// ReattributeEvents moves the tenant's events from one provider to another, for events that
+// were ingested under the wrong provider during onboarding. Both providers must belong to the
+// tenant: be on its allowlist, or have an integration with it or events for it. The checks and
+// the update run in a single tenant transaction, so either every matching event moves or none
+// does; an event whose ID the target provider already has fails the move with
+// ErrEventAlreadyExists.
 //
 // Parameters:
 //   - ctx: Context for request-scoped values, cancellation, and deadlines.
-//   - eventID: UUID of the event to delete.
-//   - tenantID: UUID of the tenant that owns the event.
+//   - tenantID: UUID of the tenant that owns the events.
+//   - fromProviderID: UUID of the provider the events are under now.
+//   - toProviderID: UUID of the provider the events belong to.
 //
 // Returns:
-//   - int64: Number of rows affected (should be 1 if successful).
-//   - error: Any error encountered during deletion.
-func (r EventsRepositoryImplementation) DeleteEvent(ctx context.Context, eventID uuid.UUID, tenantID uuid.UUID) (int64, error) {
-	r.logger.InfoContext(ctx, "Deleting event", "event_id", eventID, "tenant_id", tenantID)
+//   - int64: Number of events moved.
+//   - error: ErrSameProvider, ErrUnknownProvider when a provider does not belong to the tenant,
+//     or any error encountered during the update.
+func (r EventsRepositoryImplementation) ReattributeEvents(ctx context.Context, tenantID uuid.UUID, fromProviderID uuid.UUID, toProviderID uuid.UUID) (int64, error) {
+	if fromProviderID == toProviderID {
+		return 0, ErrSameProvider
+	}
+	r.logger.InfoContext(ctx, "Re-attributing events", "tenant_id", tenantID, "from_provider_id", fromProviderID, "to_provider_id", toProviderID)
 
 	var rowsAffected int64
 	err := WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
-		rows, err := queries.DeleteEvent(ctx, convertUUIDToPgtypeUUID(eventID))
-		if err != nil {
-			return r.handleDatabaseError(ctx, err, "delete event", eventID.String(), tenantID.String())
+		for _, providerID := range []uuid.UUID{fromProviderID, toProviderID} {
+			ok, err := queries.IsTenantProvider(ctx, db.IsTenantProviderParams{
+				TenantID:   convertUUIDToPgtypeUUID(tenantID),
+				ProviderID: convertUUIDToPgtypeUUID(providerID),
+			})
+			if err != nil {
+				return r.handleDatabaseError(ctx, err, "check tenant provider", "", tenantID.String())
+			}
+			if !ok {
+				return fmt.Errorf("%w: %s", ErrUnknownProvider, providerID)
+			}
 		}
 
-		if rows == 0 {
-			r.logger.WarnContext(ctx, "Event not found for deletion", "event_id", eventID, "tenant_id", tenantID)
-			return ErrEventNotFound
+		rows, err := queries.ReattributeEvents(ctx, db.ReattributeEventsParams{
+			ToProviderID:   convertUUIDToPgtypeUUID(toProviderID),
+			TenantID:       convertUUIDToPgtypeUUID(tenantID),
+			FromProviderID: convertUUIDToPgtypeUUID(fromProviderID),
+		})
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, "re-attribute events", "", tenantID.String())
 		}
-
 		rowsAffected = rows
-		r.logger.InfoContext(ctx, "Event deleted successfully", "event_id", eventID, "tenant_id", tenantID, "rows_affected", rowsAffected)
 		return nil
 	})
 
 	if err != nil {
-		r.logger.ErrorContext(ctx, "Failed to delete event", "error", err, "event_id", eventID, "tenant_id", tenantID)
+		r.logger.ErrorContext(ctx, "Failed to re-attribute events", "error", err, "tenant_id", tenantID, "from_provider_id", fromProviderID, "to_provider_id", toProviderID)
 		return 0, err
 	}
 
+	r.logger.InfoContext(ctx, "Events re-attributed", "tenant_id", tenantID, "from_provider_id", fromProviderID, "to_provider_id", toProviderID, "rows_affected", rowsAffected)
 	return rowsAffected, nil
 }
 
-// GetAllEvents retrieves all events from the database.
+// CountAllEvents counts all events in the database.
 //
 // Parameters:
 //   - ctx: Context for request-scoped values, cancellation, and deadlines.
 //   - tenantID: UUID of the tenant that owns the events.
 //
 // Returns:
-//   - []models.Event: Slice of all event domain models.
-//   - error: Any error encountered during retrieval.
-func (r EventsRepositoryImplementation) GetAllEvents(ctx context.Context, tenantID uuid.UUID) ([]models.Event, error) {
-	r.logger.DebugContext(ctx, "Retrieving all events", "tenant_id", tenantID)
+//   - int64: Number of events.
+//   - error: Any error encountered during counting.
+func (r EventsRepositoryImplementation) CountAllEvents(ctx context.Context, tenantID uuid.UUID) (int64, error) {
+	r.logger.DebugContext(ctx, "Counting all events", "tenant_id", tenantID)
 
-	var events []models.Event
-	err := WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
-		// Use sensible defaults for pagination (limit 1000, offset 0)
-		dbEvents, err := queries.GetAllEvents(ctx, db.GetAllEventsParams{
-			Limit:  1000,
-			Offset: 0,
-		})
+	var count int64
+	err := WithTenantReadContext(ctx, r.reader(), tenantID, func(queries *db.Queries) error {
+		c, err := queries.CountAllEvents(ctx)
 		if err != nil {
-			return r.handleDatabaseError(ctx, err, "get all events", "", tenantID.String())
+			return r.handleDatabaseError(ctx, err, "count events", "", tenantID.String())
 		}
+		count = c
+		return nil
+	})
 
-		// Pre-allocate slice with known capacity for better performance
-		events = make([]models.Event, 0, len(dbEvents))
-		for _, dbEvent := range dbEvents {
-			events = append(events, toEventDomain(dbEvent))
-		}
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to count all events", "error", err, "tenant_id", tenantID)
+		return 0, err
+	}
 
-		r.logger.DebugContext(ctx, "Retrieved events successfully", "tenant_id", tenantID, "count", len(events))
+	return count, nil
+}
+
+// HasAnyEvents reports whether the tenant has stored at least one event, for example to
+// show an onboarding empty state. It uses EXISTS, so it is cheap however many events exist.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant that owns the events.
+//
+// Returns:
+//   - bool: Whether the tenant has any events.
+//   - error: Any error encountered during the check.
+func (r EventsRepositoryImplementation) HasAnyEvents(ctx context.Context, tenantID uuid.UUID) (bool, error) {
+	r.logger.DebugContext(ctx, "Checking for any events", "tenant_id", tenantID)
+
+	var exists bool
+	err := WithTenantReadContext(ctx, r.reader(), tenantID, func(queries *db.Queries) error {
+		e, err := queries.HasAnyEvents(ctx, convertUUIDToPgtypeUUID(tenantID))
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, "check for events", "", tenantID.String())
+		}
+		exists = e
 		return nil
 	})
 
 	if err != nil {
-		r.logger.ErrorContext(ctx, "Failed to retrieve events", "error", err, "tenant_id", tenantID)
-		return nil, err
+		r.logger.ErrorContext(ctx, "Failed to check for events", "error", err, "tenant_id", tenantID)
+		return false, err
 	}
 
-	return events, nil
+	return exists, nil
 }
 
-// GetAllEventsPaginated retrieves events from the database with pagination support.
+// GetEventCountInWindow counts the events created in the half-open window [from, to).
 //
 // Parameters:
 //   - ctx: Context for request-scoped values, cancellation, and deadlines.
 //   - tenantID: UUID of the tenant that owns the events.
-//   - params: Pagination parameters (limit and offset).
+//   - from: Start of the window, inclusive.
+//   - to: End of the window, exclusive.
 //
 // Returns:
-//   - models.PaginatedResponse[models.Event]: Paginated response containing events and metadata.
-//   - error: Any error encountered during retrieval.
-func (r EventsRepositoryImplementation) GetAllEventsPaginated(ctx context.Context, tenantID uuid.UUID, params models.PaginationParams) (models.PaginatedResponse[models.Event], error) {
-	r.logger.DebugContext(ctx, "Retrieving events with pagination", "tenant_id", tenantID, "limit", params.Limit, "offset", params.Offset)
-
-	var events []models.Event
-	var totalCount int64
+//   - int64: Number of events created in the window.
+//   - error: Any error encountered during counting.
+func (r EventsRepositoryImplementation) GetEventCountInWindow(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) (int64, error) {
+	r.logger.DebugContext(ctx, "Counting events in window", "tenant_id", tenantID, "from", from, "to", to)
 
-	err := WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
-		// Get total count
-		count, err := queries.CountAllEvents(ctx)
+	var count int64
+	err := WithTenantReadContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		c, err := queries.GetEventCountInWindow(ctx, db.GetEventCountInWindowParams{
+			WindowStart: pgtype.Timestamptz{Time: from, Valid: true},
+			WindowEnd:   pgtype.Timestamptz{Time: to, Valid: true},
+		})
 		if err != nil {
-			return r.handleDatabaseError(ctx, err, "count events", "", tenantID.String())
+			return r.handleDatabaseError(ctx, err, "count events in window", "", tenantID.String())
 		}
-		totalCount = count
+		count = c
+		return nil
+	})
 
-		// Get paginated events
-		dbEvents, err := queries.GetAllEventsPaginated(ctx, db.GetAllEventsPaginatedParams{
-			Limit:  params.Limit,
-			Offset: params.Offset,
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to count events in window", "error", err, "tenant_id", tenantID)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetIngestionLag summarizes, by provider type, the lag between the provider's own timestamp
+// and ingestion for the events created in the half-open window [from, to). The provider
+// timestamp is the "created" field of the event data, in Unix seconds.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant that owns the events.
+//   - from: Start of the window, inclusive.
+//   - to: End of the window, exclusive.
+//
+// Returns:
+//   - []models.IngestionLag: One entry per provider type with events in the window, by type.
+//   - error: Any error encountered during the query.
+func (r EventsRepositoryImplementation) GetIngestionLag(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) ([]models.IngestionLag, error) {
+	r.logger.DebugContext(ctx, "Computing ingestion lag", "tenant_id", tenantID, "from", from, "to", to)
+
+	lags := []models.IngestionLag{}
+	err := WithTenantReadContext(ctx, r.reader(), tenantID, func(queries *db.Queries) error {
+		rows, err := queries.GetIngestionLagByProviderType(ctx, db.GetIngestionLagByProviderTypeParams{
+			WindowStart: pgtype.Timestamptz{Time: from, Valid: true},
+			WindowEnd:   pgtype.Timestamptz{Time: to, Valid: true},
 		})
 		if err != nil {
-			return r.handleDatabaseError(ctx, err, "get paginated events", "", tenantID.String())
+			return r.handleDatabaseError(ctx, err, "get ingestion lag", "", tenantID.String())
 		}
 
-		// Convert to domain models
-		events = make([]models.Event, 0, len(dbEvents))
-		for _, dbEvent := range dbEvents {
-			events = append(events, toEventDomain(dbEvent))
+		for _, row := range rows {
+			lags = append(lags, toIngestionLagDomain(row))
 		}
-
-		r.logger.DebugContext(ctx, "Retrieved paginated events successfully", "tenant_id", tenantID, "count", len(events), "total_count", totalCount)
 		return nil
 	})
 
 	if err != nil {
-		r.logger.ErrorContext(ctx, "Failed to retrieve paginated events", "error", err, "tenant_id", tenantID)
-		return models.PaginatedResponse[models.Event]{}, err
+		r.logger.ErrorContext(ctx, "Failed to compute ingestion lag", "error", err, "tenant_id", tenantID)
+		return nil, err
 	}
 
-	// Create paginated response
-	response := models.NewPaginatedResponse(events, totalCount, params.Limit, params.Offset)
-	return response, nil
+	return lags, nil
 }
 
-// GetEventByID retrieves a single event by its UUID.
+// toIngestionLagDomain converts a GetIngestionLagByProviderType row. The percentile is only
+// meaningful when some event had a provider timestamp, so it is nil otherwise.
+func toIngestionLagDomain(row db.GetIngestionLagByProviderTypeRow) models.IngestionLag {
+	lag := models.IngestionLag{
+		ProviderType:     row.ProviderType,
+		Events:           row.Events,
+		MissingTimestamp: row.MissingTimestamp,
+	}
+	if row.Events > row.MissingTimestamp {
+		p95 := time.Duration(row.P95LagSeconds * float64(time.Second))
+		lag.P95 = &p95
+	}
+	return lag
+}
+
+// GetProviderFailureRates counts, per provider, the tenant's payment attempts created in the
+// half-open window [from, to) and how many of them failed. An attempt is a payment_failed or
+// payment_succeeded event; refunds and updates are not counted.
 //
 // Parameters:
 //   - ctx: Context for request-scoped values, cancellation, and deadlines.
-//   - eventID: UUID of the event to retrieve.
-//   - tenantID: UUID of the tenant that owns the event.
+//   - tenantID: UUID of the tenant that owns the events.
+//   - from: Start of the window, inclusive.
+//   - to: End of the window, exclusive.
 //
 // Returns:
-//   - models.Event: The event domain model if found.
-//   - error: Any error encountered during retrieval.
-func (r EventsRepositoryImplementation) GetEventByID(ctx context.Context, eventID uuid.UUID, tenantID uuid.UUID) (models.Event, error) {
-	r.logger.DebugContext(ctx, "Retrieving event by ID", "event_id", eventID, "tenant_id", tenantID)
-
-	var event models.Event
-	err := WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
-		dbEvent, err := queries.GetEventByID(ctx, convertUUIDToPgtypeUUID(eventID))
+//   - []models.ProviderFailureRate: One entry per provider with attempts in the window, by provider ID.
+//   - error: Any error encountered during the query.
+func (r EventsRepositoryImplementation) GetProviderFailureRates(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) ([]models.ProviderFailureRate, error) {
+	r.logger.DebugContext(ctx, "Computing provider failure rates", "tenant_id", tenantID, "from", from, "to", to)
+
+	rates := []models.ProviderFailureRate{}
+	err := WithTenantReadContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		rows, err := queries.GetProviderFailureRates(ctx, db.GetProviderFailureRatesParams{
+			TenantID:    convertUUIDToPgtypeUUID(tenantID),
+			WindowStart: pgtype.Timestamptz{Time: from, Valid: true},
+			WindowEnd:   pgtype.Timestamptz{Time: to, Valid: true},
+		})
 		if err != nil {
-			// Check if it's a "no rows" error
-			if errors.Is(err, pgx.ErrNoRows) {
-				r.logger.WarnContext(ctx, "Event not found", "event_id", eventID, "tenant_id", tenantID)
-				return ErrEventNotFound
-			}
-
-			return r.handleDatabaseError(ctx, err, "get event by ID", eventID.String(), tenantID.String())
+			return r.handleDatabaseError(ctx, err, "get provider failure rates", "", tenantID.String())
 		}
 
-		event = toEventDomain(dbEvent)
-		r.logger.DebugContext(ctx, "Event retrieved successfully", "event_id", eventID, "tenant_id", tenantID)
+		for _, row := range rows {
+			rates = append(rates, models.ProviderFailureRate{
+				ProviderID:   convertPgtypeUUIDToUUID(row.ProviderID),
+				ProviderName: row.ProviderName,
+				Failed:       row.Failed,
+				Total:        row.Total,
+			})
+		}
 		return nil
 	})
 
 	if err != nil {
-		r.logger.ErrorContext(ctx, "Failed to retrieve event", "error", err, "event_id", eventID, "tenant_id", tenantID)
-		return models.Event{}, err
+		r.logger.ErrorContext(ctx, "Failed to compute provider failure rates", "error", err, "tenant_id", tenantID)
+		return nil, err
 	}
 
-	return event, nil
+	return rates, nil
 }
 
-// UpdateEvent updates an existing event in the database.
+// FindDuplicateCharges finds the tenant's payment_succeeded events created since since that
+// duplicate the previous charge with the same customer_id, amount and currency in their data,
+// coming at most window after it. Amounts are compared as numbers, so 10 and 10.00 match, and
+// currencies case-insensitively. Events without a customer_id or a numeric amount are never
+// duplicates, and a duplicate already flagged by a duplicate_charge leak is not returned again.
 //
 // Parameters:
 //   - ctx: Context for request-scoped values, cancellation, and deadlines.
-//   - arg: UpdateEventParams containing the fields to update.
-//   - tenantID: UUID of the tenant that owns the event.
+//   - tenantID: UUID of the tenant that owns the events.
+//   - since: Earliest creation time of the charges to compare.
+//   - window: Longest gap between a charge and its duplicate.
 //
 // Returns:
-//   - models.Event: The updated event domain model.
-//   - error: Any error encountered during update.
-func (r EventsRepositoryImplementation) UpdateEvent(ctx context.Context, arg models.UpdateEventParams, tenantID uuid.UUID) (models.Event, error) {
-	r.logger.InfoContext(ctx, "Updating event", "event_id", arg.ID, "tenant_id", tenantID)
+//   - []models.DuplicateCharge: The duplicates, oldest first, each with the charge it repeats.
+//   - error: Any error encountered during retrieval.
+func (r EventsRepositoryImplementation) FindDuplicateCharges(ctx context.Context, tenantID uuid.UUID, since time.Time, window time.Duration) ([]models.DuplicateCharge, error) {
+	r.logger.DebugContext(ctx, "Finding duplicate charges", "tenant_id", tenantID, "since", since, "window", window)
+
+	duplicates := []models.DuplicateCharge{}
+	err := WithTenantReadContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		rows, err := queries.FindDuplicateCharges(ctx, db.FindDuplicateChargesParams{
+			Since:         pgtype.Timestamptz{Time: since, Valid: true},
+			WindowSeconds: window.Seconds(),
+		})
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, "find duplicate charges", "", tenantID.String())
+		}
+		for _, row := range rows {
+			duplicates = append(duplicates, toDuplicateChargeDomain(row))
+		}
+		return nil
+	})
 
-	params, err := toUpdateEventDBParams(arg)
 	if err != nil {
-		r.logger.ErrorContext(ctx, "Failed to convert update params", "error", err, "event_id", arg.ID, "tenant_id", tenantID)
-		return models.Event{}, ErrConvertingDataToJSONb
+		r.logger.ErrorContext(ctx, "Failed to find duplicate charges", "error", err, "tenant_id", tenantID)
+		return nil, err
 	}
 
-	var domainEvent models.Event
-	err = WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
-		dbEvent, dbErr := queries.UpdateEvent(ctx, params)
-		if dbErr != nil {
-			// Check if it's a "no rows" error
-			if errors.Is(dbErr, pgx.ErrNoRows) {
-				r.logger.WarnContext(ctx, "Event not found for update", "event_id", arg.ID, "tenant_id", tenantID)
-				return ErrEventNotFound
-			}
+	return duplicates, nil
+}
 
-			return r.handleDatabaseError(ctx, dbErr, "update event", arg.ID.String(), tenantID.String())
-		}
+// toDuplicateChargeDomain converts a FindDuplicateCharges row to a domain DuplicateCharge.
+// An unknown customer converts to uuid.Nil.
+func toDuplicateChargeDomain(row db.FindDuplicateChargesRow) models.DuplicateCharge {
+	return models.DuplicateCharge{
+		EventID:           convertPgtypeUUIDToUUID(row.ID),
+		OriginalEventID:   convertPgtypeUUIDToUUID(row.OriginalID),
+		CustomerRef:       row.CustomerRef.String,
+		CustomerID:        convertPgtypeUUIDToUUID(row.CustomerID),
+		Amount:            convertPgtypeNumericToDecimal(row.Amount),
+		Currency:          row.Currency,
+		ChargedAt:         row.CreatedAt.Time,
+		OriginalChargedAt: row.OriginalCreatedAt.Time,
+	}
+}
 
-		domainEvent = toEventDomain(dbEvent)
-		r.logger.InfoContext(ctx, "Event updated successfully", "event_id", arg.ID, "tenant_id", tenantID)
+// FindDunningGaps finds the tenant's payment_failed events that no payment_failed,
+// payment_succeeded or payment_updated event with the same customer_id followed within the
+// dunning window: the tenant's dunning_window_hours, or defaultWindow when it has none. Only
+// failures whose window had passed by now, and that happened within lookback before that, are
+// checked. Events without a customer_id are skipped, and a gap already flagged by a
+// dunning_gap leak, as its source event or one of its linked events, is not returned again.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant that owns the events.
+//   - now: Time the dunning windows are measured up to.
+//   - defaultWindow: Dunning window for a tenant without its own.
+//   - lookback: How far before the latest checkable failure to look.
+//
+// Returns:
+//   - []models.DunningGap: The failures without a follow-up, oldest first.
+//   - error: Any error encountered during retrieval.
+func (r EventsRepositoryImplementation) FindDunningGaps(ctx context.Context, tenantID uuid.UUID, now time.Time, defaultWindow time.Duration, lookback time.Duration) ([]models.DunningGap, error) {
+	r.logger.DebugContext(ctx, "Finding dunning gaps", "tenant_id", tenantID, "now", now, "default_window", defaultWindow, "lookback", lookback)
+
+	gaps := []models.DunningGap{}
+	err := WithTenantReadContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		rows, err := queries.FindDunningGaps(ctx, db.FindDunningGapsParams{
+			TenantID:             convertUUIDToPgtypeUUID(tenantID),
+			DefaultWindowSeconds: defaultWindow.Seconds(),
+			Now:                  pgtype.Timestamptz{Time: now, Valid: true},
+			LookbackSeconds:      lookback.Seconds(),
+		})
+		if err != nil {
+			return r.handleDatabaseError(ctx, err, "find dunning gaps", "", tenantID.String())
+		}
+		for _, row := range rows {
+			gaps = append(gaps, toDunningGapDomain(row))
+		}
 		return nil
 	})
 
 	if err != nil {
-		r.logger.ErrorContext(ctx, "Failed to update event", "error", err, "event_id", arg.ID, "tenant_id", tenantID)
-		return models.Event{}, err
+		r.logger.ErrorContext(ctx, "Failed to find dunning gaps", "error", err, "tenant_id", tenantID)
+		return nil, err
 	}
 
-	return domainEvent, nil
+	return gaps, nil
 }
 
-// CountAllEvents counts all events in the database.
+// toDunningGapDomain converts a FindDunningGaps row to a domain DunningGap.
+// An unknown customer converts to uuid.Nil and a missing amount to zero.
+func toDunningGapDomain(row db.FindDunningGapsRow) models.DunningGap {
+	return models.DunningGap{
+		EventID:         convertPgtypeUUIDToUUID(row.ID),
+		CustomerRef:     row.CustomerRef.String,
+		SubscriptionRef: row.SubscriptionRef.String,
+		CustomerID:      convertPgtypeUUIDToUUID(row.CustomerID),
+		Amount:          convertPgtypeNumericToDecimal(row.Amount),
+		Currency:        row.Currency,
+		FailedAt:        row.CreatedAt.Time,
+		Window:          time.Duration(row.WindowSeconds * float64(time.Second)),
+	}
+}
+
+// CountEventsByStatusForProvider counts a provider's events per status.
+// Every known status is present in the result; statuses with no events count 0.
 //
 // Parameters:
 //   - ctx: Context for request-scoped values, cancellation, and deadlines.
 //   - tenantID: UUID of the tenant that owns the events.
+//   - providerID: UUID of the provider whose events to count.
 //
 // Returns:
-//   - int64: Number of events.
+//   - map[models.EventStatusEnum]int64: Number of events per status.
 //   - error: Any error encountered during counting.
-func (r EventsRepositoryImplementation) CountAllEvents(ctx context.Context, tenantID uuid.UUID) (int64, error) {
-	r.logger.DebugContext(ctx, "Counting all events", "tenant_id", tenantID)
+func (r EventsRepositoryImplementation) CountEventsByStatusForProvider(ctx context.Context, tenantID uuid.UUID, providerID uuid.UUID) (map[models.EventStatusEnum]int64, error) {
+	r.logger.DebugContext(ctx, "Counting events by status for provider", "tenant_id", tenantID, "provider_id", providerID)
 
-	var count int64
-	err := WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
-		c, err := queries.CountAllEvents(ctx)
+	var counts map[models.EventStatusEnum]int64
+	err := WithTenantReadContext(ctx, r.reader(), tenantID, func(queries *db.Queries) error {
+		rows, err := queries.CountEventsByStatusForProvider(ctx, convertUUIDToPgtypeUUID(providerID))
 		if err != nil {
-			return r.handleDatabaseError(ctx, err, "count events", "", tenantID.String())
+			return r.handleDatabaseError(ctx, err, "count events by status for provider", "", tenantID.String())
 		}
-		count = c
+		counts = toEventStatusCounts(r.pool.unknownEnums(), rows)
 		return nil
 	})
 
 	if err != nil {
-		r.logger.ErrorContext(ctx, "Failed to count all events", "error", err, "tenant_id", tenantID)
-		return 0, err
+		r.logger.ErrorContext(ctx, "Failed to count events by status for provider", "error", err, "tenant_id", tenantID, "provider_id", providerID)
+		return nil, err
 	}
 
-	return count, nil
+	return counts, nil
+}
+
+// eventStatuses lists every event status, so per-status counts can be zero-filled
+var eventStatuses = []models.EventStatusEnum{
+	models.EventStatusEnumPending,
+	models.EventStatusEnumProcessed,
+	models.EventStatusEnumFailed,
+	models.EventStatusEnumProcessing,
+}
+
+// toEventStatusCounts converts grouped count rows to a map holding every known status
+func toEventStatusCounts(enums *unknownEnums, rows []db.CountEventsByStatusForProviderRow) map[models.EventStatusEnum]int64 {
+	counts := make(map[models.EventStatusEnum]int64, len(eventStatuses))
+	for _, status := range eventStatuses {
+		counts[status] = 0
+	}
+	for _, row := range rows {
+		counts[toDomainEnum(enums, "event_status", string(row.Status), eventStatuses)] += row.Count
+	}
+	return counts
 }
 
 // toEventDomain converts a db.Event (database model) to a models.Event (domain model).
 //
 // Parameters:
+//   - enums: The pool's handling of enum values this build does not know.
 //   - e: db.Event struct as returned by SQLC queries.
 //
 // Returns:
 //   - models.Event: The corresponding domain model.
-func toEventDomain(e db.Event) models.Event {
+func toEventDomain(enums *unknownEnums, e db.Event) models.Event {
 	return models.Event{
 		ID:         uuid.UUID(e.ID.Bytes),
 		TenantID:   uuid.UUID(e.TenantID.Bytes),
 		ProviderID: uuid.UUID(e.ProviderID.Bytes),
-		EventType:  models.EventTypeEnum(e.EventType),
+		EventType:  toDomainEnum(enums, "event_type", string(e.EventType), eventTypes),
 		EventID:    e.EventID,
-		Status:     models.EventStatusEnum(e.Status),
+		Status:     toDomainEnum(enums, "event_status", string(e.Status), eventStatuses),
 		Data:       (*json.RawMessage)(&e.Data),
 		CreatedAt:  e.CreatedAt.Time,
 		UpdatedAt:  e.UpdatedAt.Time,
+		Source:     toDomainEnum(enums, "event_source", string(e.Source), eventSources),
+	}
+}
+
+// isEventTypeAccepted reports whether a tenant's allowlist accepts the event type.
+// An empty allowlist accepts every event type.
+func isEventTypeAccepted(acceptedTypes []string, eventType models.EventTypeEnum) bool {
+	if len(acceptedTypes) == 0 {
+		return true
+	}
+	return slices.Contains(acceptedTypes, string(eventType))
+}
+
+// isProviderAllowed reports whether a tenant's provider allowlist contains the provider.
+// An empty allowlist accepts every provider.
+func isProviderAllowed(allowedProviders []pgtype.UUID, providerID uuid.UUID) bool {
+	if len(allowedProviders) == 0 {
+		return true
 	}
+	return slices.Contains(allowedProviders, convertUUIDToPgtypeUUID(providerID))
 }
 
 // toCreateEventDBParams converts a domain CreateEventParams to a db.CreateEventParams for persistence.
@@ -368,10 +1984,23 @@ func toCreateEventDBParams(arg models.CreateEventParams) (db.CreateEventParams,
 		EventID:    arg.EventID,
 		Status:     db.EventStatusEnum(arg.Status),
 		Data:       data,
+		Source:     db.EventSourceEnum(eventSourceOrManual(arg.Source)),
 	}, nil
 }
 
+// eventSourceOrManual returns source, or EventSourceEnumManual for an event whose creator did
+// not say how it entered the system
+func eventSourceOrManual(source models.EventSourceEnum) models.EventSourceEnum {
+	if source == "" {
+		return models.EventSourceEnumManual
+	}
+	return source
+}
+
 // toUpdateEventDBParams converts a domain UpdateEventParams to a db.UpdateEventParams for persistence.
+// Omitted fields become NULL arguments, which the query leaves unchanged. Data that is empty or
+// the JSON literal null counts as omitted, so it can never overwrite the stored payload.
+// DataModeMerge asks the query to merge Data into the stored payload rather than replace it.
 //
 // Parameters:
 //   - arg: models.UpdateEventParams containing the event update details.
@@ -383,11 +2012,8 @@ func toUpdateEventDBParams(arg models.UpdateEventParams) (db.UpdateEventParams,
 	var data []byte
 	var err error
 
-	if arg.Data != nil {
-		data, err = convertInterfaceToBytes(arg.Data)
-		if err != nil {
-			return db.UpdateEventParams{}, err
-		}
+	if arg.Data != nil && !isEmptyJSON(*arg.Data) {
+		data = []byte(*arg.Data)
 	}
 
 	resultEventType, err := convertEnumsToNullableEnum[*db.EventTypeEnum, db.NullEventTypeEnum]((*db.EventTypeEnum)(arg.EventType))
@@ -405,9 +2031,16 @@ func toUpdateEventDBParams(arg models.UpdateEventParams) (db.UpdateEventParams,
 		EventType: resultEventType,
 		Status:    resultEventStatus,
 		Data:      data,
+		MergeData: arg.DataMode == models.DataModeMerge,
 	}, nil
 }
 
+// isEmptyJSON reports whether data holds no value: nothing, whitespace or the literal null
+func isEmptyJSON(data json.RawMessage) bool {
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null"))
+}
+
 // handleDatabaseError processes database-specific errors and returns appropriate wrapped errors.
 // This method handles common PostgreSQL errors and converts them to domain-specific errors.
 // If the error doesn't need special handling, it returns the original error wrapped with context.
@@ -469,13 +2102,13 @@ func (r EventsRepositoryImplementation) handleDatabaseError(ctx context.Context,
 	// Handle context cancellation
 	if errors.Is(err, context.Canceled) {
 		r.logger.WarnContext(ctx, "Operation canceled", "operation", operation, "event_id", eventID, "tenant_id", tenantID)
-		return errors.New("operation canceled")
+		return ErrOperationCanceled
 	}
 
 	// Handle context timeout
 	if errors.Is(err, context.DeadlineExceeded) {
 		r.logger.WarnContext(ctx, "Operation timeout", "operation", operation, "event_id", eventID, "tenant_id", tenantID)
-		return errors.New("operation timeout")
+		return ErrOperationTimeout
 	}
 
 	// For other errors, return the original error
@@ -0,0 +1,1452 @@
+//go:build integration
+
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/logging"
+)
+
+func TestGetEventsByIDs_MixedExistingAndMissing(t *testing.T) {
+	pool := newIntegrationPool(t)
+	tenantID, _ := seedTenant(t, pool)
+	providerID := seedProvider(t, pool)
+
+	first := seedEvent(t, pool, tenantID, providerID)
+	second := seedEvent(t, pool, tenantID, providerID)
+	missing := uuid.New()
+
+	repo := EventsRepositoryImplementation{pool: pool, logger: createTestLogger()}
+	events, err := repo.GetEventsByIDs(context.Background(), tenantID, []uuid.UUID{first, missing, second})
+	require.NoError(t, err)
+
+	assert.Len(t, events, 2)
+	assert.Equal(t, first, events[first].ID)
+	assert.Equal(t, second, events[second].ID)
+	assert.NotContains(t, events, missing)
+}
+
+func TestUpdateEventIfVersion(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	providerID := seedProvider(t, pool)
+	eventID := seedEvent(t, pool, tenantID, providerID)
+
+	repo := EventsRepositoryImplementation{pool: pool, logger: createTestLogger()}
+	current, err := repo.GetEventByID(ctx, eventID, tenantID)
+	require.NoError(t, err)
+
+	status := models.EventStatusEnumProcessed
+	params := models.UpdateEventParams{ID: eventID, Status: &status}
+
+	t.Run("matching version updates", func(t *testing.T) {
+		updated, err := repo.UpdateEventIfVersion(ctx, params, current.UpdatedAt, tenantID)
+		require.NoError(t, err)
+		assert.Equal(t, models.EventStatusEnumProcessed, updated.Status)
+	})
+
+	t.Run("stale version conflicts", func(t *testing.T) {
+		_, err := repo.UpdateEventIfVersion(ctx, params, current.UpdatedAt.Add(-time.Second), tenantID)
+		assert.ErrorIs(t, err, ErrConcurrentModification)
+	})
+
+	t.Run("missing event is not found", func(t *testing.T) {
+		_, err := repo.UpdateEventIfVersion(ctx, models.UpdateEventParams{ID: uuid.New(), Status: &status}, current.UpdatedAt, tenantID)
+		assert.ErrorIs(t, err, ErrEventNotFound)
+	})
+}
+
+func TestUpdateEvent_PartialUpdateKeepsOmittedFields(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	providerID := seedProvider(t, pool)
+	eventID := seedEvent(t, pool, tenantID, providerID)
+
+	repo := EventsRepositoryImplementation{pool: pool, logger: createTestLogger()}
+	before, err := repo.GetEventByID(ctx, eventID, tenantID)
+	require.NoError(t, err)
+
+	status := models.EventStatusEnumProcessed
+	params := models.UpdateEventParams{ID: eventID, Status: &status}
+
+	// Running the same partial update twice must leave everything but the status untouched
+	for range 2 {
+		updated, err := repo.UpdateEvent(ctx, params, tenantID)
+		require.NoError(t, err)
+		assert.Equal(t, models.EventStatusEnumProcessed, updated.Status)
+	}
+
+	after, err := repo.GetEventByID(ctx, eventID, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, models.EventStatusEnumProcessed, after.Status)
+	assert.Equal(t, before.EventType, after.EventType)
+	assert.JSONEq(t, string(*before.Data), string(*after.Data))
+
+	null := json.RawMessage("null")
+	_, err = repo.UpdateEvent(ctx, models.UpdateEventParams{ID: eventID, Data: &null}, tenantID)
+	require.NoError(t, err)
+	after, err = repo.GetEventByID(ctx, eventID, tenantID)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(*before.Data), string(*after.Data), "expected a null payload to leave the stored data alone")
+}
+
+func TestUpdateEvent_DataMode(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	providerID := seedProvider(t, pool)
+	eventID := seedEvent(t, pool, tenantID, providerID)
+
+	repo := EventsRepositoryImplementation{pool: pool, logger: createTestLogger()}
+	update := func(data string, mode models.DataMode) models.Event {
+		t.Helper()
+		raw := json.RawMessage(data)
+		event, err := repo.UpdateEvent(ctx, models.UpdateEventParams{ID: eventID, Data: &raw, DataMode: mode}, tenantID)
+		require.NoError(t, err)
+		return event
+	}
+
+	update(`{"amount": 100, "currency": "usd"}`, models.DataModeReplace)
+
+	t.Run("merge preserves existing keys", func(t *testing.T) {
+		merged := update(`{"note": "customer retried", "currency": "eur"}`, models.DataModeMerge)
+		assert.JSONEq(t, `{"amount": 100, "currency": "eur", "note": "customer retried"}`, string(*merged.Data))
+	})
+
+	t.Run("replace overwrites", func(t *testing.T) {
+		replaced := update(`{"note": "rewritten"}`, models.DataModeReplace)
+		assert.JSONEq(t, `{"note": "rewritten"}`, string(*replaced.Data))
+	})
+
+	t.Run("merge of a non-object replaces", func(t *testing.T) {
+		replaced := update(`["a", "b"]`, models.DataModeMerge)
+		assert.JSONEq(t, `["a", "b"]`, string(*replaced.Data))
+	})
+
+	t.Run("merge applies to a versioned update", func(t *testing.T) {
+		update(`{"amount": 100}`, models.DataModeReplace)
+		current, err := repo.GetEventByID(ctx, eventID, tenantID)
+		require.NoError(t, err)
+
+		raw := json.RawMessage(`{"note": "resolved"}`)
+		merged, err := repo.UpdateEventIfVersion(ctx, models.UpdateEventParams{ID: eventID, Data: &raw, DataMode: models.DataModeMerge}, current.UpdatedAt, tenantID)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"amount": 100, "note": "resolved"}`, string(*merged.Data))
+	})
+}
+
+func TestCreateEvent_TenantAllowlist(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	providerID := seedProvider(t, pool)
+
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "UPDATE tenants SET accepted_event_types = '{payment_failed}' WHERE id = $1", tenantID)
+		require.NoError(t, err)
+	})
+
+	repo := EventsRepositoryImplementation{pool: pool, logger: createTestLogger()}
+	newParams := func(eventType models.EventTypeEnum) models.CreateEventParams {
+		return models.CreateEventParams{
+			TenantID:   tenantID,
+			ProviderID: providerID,
+			EventType:  eventType,
+			EventID:    "evt_" + uuid.NewString(),
+			Status:     models.EventStatusEnumPending,
+			Data:       `{}`,
+		}
+	}
+
+	t.Run("allowed type is stored", func(t *testing.T) {
+		event, err := repo.CreateEvent(ctx, newParams(models.EventTypeEnumPaymentFailed), tenantID)
+		require.NoError(t, err)
+		assert.NotEqual(t, uuid.Nil, event.ID)
+	})
+
+	t.Run("disallowed type is skipped", func(t *testing.T) {
+		_, err := repo.CreateEvent(ctx, newParams(models.EventTypeEnumPaymentSucceeded), tenantID)
+		assert.ErrorIs(t, err, ErrEventSkipped)
+	})
+}
+
+func TestCreateEvent_ProviderAllowlist(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	registered := seedProvider(t, pool)
+	unregistered := seedProvider(t, pool)
+
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "UPDATE tenants SET allowed_provider_ids = ARRAY[$2::uuid] WHERE id = $1", tenantID, registered)
+		require.NoError(t, err)
+	})
+
+	repo := EventsRepositoryImplementation{pool: pool, logger: createTestLogger()}
+	newParams := func(providerID uuid.UUID) models.CreateEventParams {
+		return models.CreateEventParams{
+			TenantID:   tenantID,
+			ProviderID: providerID,
+			EventType:  models.EventTypeEnumPaymentFailed,
+			EventID:    "evt_" + uuid.NewString(),
+			Status:     models.EventStatusEnumPending,
+			Data:       `{}`,
+		}
+	}
+
+	t.Run("registered provider is stored", func(t *testing.T) {
+		event, err := repo.CreateEvent(ctx, newParams(registered), tenantID)
+		require.NoError(t, err)
+		assert.Equal(t, registered, event.ProviderID)
+	})
+
+	t.Run("unregistered provider is rejected", func(t *testing.T) {
+		_, err := repo.CreateEvent(ctx, newParams(unregistered), tenantID)
+		assert.ErrorIs(t, err, ErrUnknownProvider)
+	})
+}
+
+func TestCreateEventIdempotent_KnownDuplicateDoesNotLogError(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	providerID := seedProvider(t, pool)
+	existingID := seedEvent(t, pool, tenantID, providerID)
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	repo := EventsRepositoryImplementation{pool: pool, logger: logger}
+
+	_, err := repo.CreateEventIdempotent(ctx, models.CreateEventParams{
+		TenantID:   tenantID,
+		ProviderID: providerID,
+		EventType:  models.EventTypeEnumPaymentFailed,
+		EventID:    "evt_" + existingID.String(),
+		Status:     models.EventStatusEnumPending,
+		Data:       `{}`,
+	}, tenantID)
+
+	assert.ErrorIs(t, err, ErrEventAlreadyExists)
+	assert.NotContains(t, logs.String(), "level=ERROR")
+	assert.Contains(t, logs.String(), "Event already exists")
+}
+
+func TestCreateEvent_DuplicateInRequestTransaction(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	providerID := seedProvider(t, pool)
+	existingID := seedEvent(t, pool, tenantID, providerID)
+
+	repo, err := NewEventsRepository(pool, createTestLogger())
+	require.NoError(t, err)
+
+	// The same request transaction middleware.Transaction opens for POST /webhooks/stripe
+	tx, release, err := BeginTenantTx(ctx, pool, tenantID)
+	require.NoError(t, err)
+	defer release()
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op after commit
+	txCtx := ContextWithTx(ctx, tenantID, tx)
+
+	created, err := repo.CreateEventIdempotent(txCtx, models.CreateEventParams{
+		TenantID:   tenantID,
+		ProviderID: providerID,
+		EventType:  models.EventTypeEnumPaymentFailed,
+		EventID:    "evt_" + uuid.NewString(),
+		Status:     models.EventStatusEnumPending,
+		Data:       `{}`,
+	}, tenantID)
+	require.NoError(t, err)
+
+	// A duplicate delivery that raced past the pre-check reaches the insert and hits the
+	// unique constraint
+	_, err = repo.CreateEvent(txCtx, models.CreateEventParams{
+		TenantID:   tenantID,
+		ProviderID: providerID,
+		EventType:  models.EventTypeEnumPaymentFailed,
+		EventID:    "evt_" + existingID.String(),
+		Status:     models.EventStatusEnumPending,
+		Data:       `{}`,
+	}, tenantID)
+	require.ErrorIs(t, err, ErrEventAlreadyExists)
+
+	require.NoError(t, tx.Commit(ctx), "the failed insert must not abort the request transaction")
+
+	_, err = repo.GetEventByID(ctx, created.ID, tenantID)
+	assert.NoError(t, err)
+}
+
+func TestCreateEvent_ScrubbedLogsDoNotChangeStoredData(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	providerID := seedProvider(t, pool)
+
+	var logs bytes.Buffer
+	handler := slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})
+	repo := EventsRepositoryImplementation{pool: pool, logger: slog.New(logging.NewPIIScrubber(handler, []string{"email", "customer_name"}))}
+
+	data := `{"amount":42,"email":"jane@example.com","customer":{"customer_name":"Jane Doe"}}`
+	event, err := repo.CreateEvent(ctx, models.CreateEventParams{
+		TenantID:   tenantID,
+		ProviderID: providerID,
+		EventType:  models.EventTypeEnumPaymentFailed,
+		EventID:    "evt_" + uuid.NewString(),
+		Status:     models.EventStatusEnumPending,
+		Data:       data,
+	}, tenantID)
+	require.NoError(t, err)
+
+	assert.Contains(t, logs.String(), "Event data")
+	assert.Contains(t, logs.String(), `"amount":42`)
+	assert.NotContains(t, logs.String(), "jane@example.com")
+	assert.NotContains(t, logs.String(), "Jane Doe")
+
+	stored, err := repo.GetEventByID(ctx, event.ID, tenantID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.Data)
+	assert.JSONEq(t, data, string(*stored.Data))
+}
+
+func TestCountEventsByStatusForProvider(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	providerID := seedProvider(t, pool)
+	otherProviderID := seedProvider(t, pool)
+
+	seedEvent(t, pool, tenantID, providerID)
+	seedEvent(t, pool, tenantID, providerID)
+	failed := seedEvent(t, pool, tenantID, providerID)
+	seedEvent(t, pool, tenantID, otherProviderID)
+
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "UPDATE events SET status = 'failed' WHERE id = $1", failed)
+		require.NoError(t, err)
+	})
+
+	repo := EventsRepositoryImplementation{pool: pool, logger: createTestLogger()}
+	counts, err := repo.CountEventsByStatusForProvider(ctx, tenantID, providerID)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[models.EventStatusEnum]int64{
+		models.EventStatusEnumPending:   2,
+		models.EventStatusEnumProcessed: 0,
+		models.EventStatusEnumFailed:    1,
+	}, counts)
+}
+
+func TestGetRelatedEvents(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	stripeID := seedProvider(t, pool)
+	bankID := seedProvider(t, pool)
+
+	insert := func(providerID uuid.UUID, data string, createdAt time.Time) uuid.UUID {
+		id := uuid.New()
+		seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+			_, err := tx.Exec(ctx,
+				"INSERT INTO events (id, tenant_id, provider_id, event_type, event_id, status, data, created_at) VALUES ($1, $2, $3, 'payment_failed', $4, 'pending', $5, $6)",
+				id, tenantID, providerID, "evt_"+id.String(), data, createdAt)
+			require.NoError(t, err)
+		})
+		return id
+	}
+
+	now := time.Now()
+	charge := insert(stripeID, `{"customer_id": "cus_1", "amount": 4950}`, now)
+	reversal := insert(bankID, `{"customer_id": "cus_1", "amount": 4950, "reference": "rev_9"}`, now.Add(2*time.Hour))
+	insert(bankID, `{"customer_id": "cus_2", "amount": 4950}`, now.Add(time.Hour))    // different customer
+	insert(bankID, `{"customer_id": "cus_1", "amount": 4950}`, now.Add(72*time.Hour)) // outside the window
+	keyless := insert(stripeID, `{"amount": 4950}`, now)
+	envelope := insert(stripeID, `{"id": "evt_2", "data": {"object": {"customer_id": "cus_3", "amount": 1200}}}`, now)
+	flat := insert(bankID, `{"customer_id": "cus_3", "amount": 1200}`, now.Add(time.Hour))
+
+	repo := EventsRepositoryImplementation{pool: pool, logger: createTestLogger()}
+	rule := models.CorrelationRule{Keys: []string{"customer_id", "amount"}, Window: 24 * time.Hour}
+
+	t.Run("correlated pair across providers", func(t *testing.T) {
+		related, err := repo.GetRelatedEvents(ctx, tenantID, charge, rule)
+		require.NoError(t, err)
+		require.Len(t, related, 1)
+		assert.Equal(t, reversal, related[0].ID)
+
+		related, err = repo.GetRelatedEvents(ctx, tenantID, reversal, rule)
+		require.NoError(t, err)
+		require.Len(t, related, 1)
+		assert.Equal(t, charge, related[0].ID)
+	})
+
+	t.Run("keys under the Stripe object match the same keys at the top level", func(t *testing.T) {
+		related, err := repo.GetRelatedEvents(ctx, tenantID, envelope, rule)
+		require.NoError(t, err)
+		require.Len(t, related, 1)
+		assert.Equal(t, flat, related[0].ID)
+
+		related, err = repo.GetRelatedEvents(ctx, tenantID, flat, rule)
+		require.NoError(t, err)
+		require.Len(t, related, 1)
+		assert.Equal(t, envelope, related[0].ID)
+	})
+
+	t.Run("event without correlation keys", func(t *testing.T) {
+		related, err := repo.GetRelatedEvents(ctx, tenantID, keyless, rule)
+		require.NoError(t, err)
+		assert.Empty(t, related)
+	})
+
+	t.Run("missing event", func(t *testing.T) {
+		_, err := repo.GetRelatedEvents(ctx, tenantID, uuid.New(), rule)
+		assert.ErrorIs(t, err, ErrEventNotFound)
+	})
+}
+
+func TestGetEventStatusHistory(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	eventID := seedEvent(t, pool, tenantID, seedProvider(t, pool))
+
+	repo := EventsRepositoryImplementation{pool: pool, logger: createTestLogger()}
+	setStatus := func(status models.EventStatusEnum) {
+		_, err := repo.UpdateEvent(ctx, models.UpdateEventParams{ID: eventID, Status: &status}, tenantID)
+		require.NoError(t, err)
+	}
+
+	t.Run("no changes yet", func(t *testing.T) {
+		history, err := repo.GetEventStatusHistory(ctx, eventID, tenantID)
+		require.NoError(t, err)
+		assert.Empty(t, history)
+	})
+
+	t.Run("changes in order", func(t *testing.T) {
+		setStatus(models.EventStatusEnumFailed)
+		setStatus(models.EventStatusEnumFailed) // unchanged, not recorded
+		setStatus(models.EventStatusEnumPending)
+		setStatus(models.EventStatusEnumProcessed)
+
+		history, err := repo.GetEventStatusHistory(ctx, eventID, tenantID)
+		require.NoError(t, err)
+		transitions := make([][2]models.EventStatusEnum, len(history))
+		for i, change := range history {
+			transitions[i] = [2]models.EventStatusEnum{change.FromStatus, change.ToStatus}
+		}
+		require.Equal(t, [][2]models.EventStatusEnum{
+			{models.EventStatusEnumPending, models.EventStatusEnumFailed},
+			{models.EventStatusEnumFailed, models.EventStatusEnumPending},
+			{models.EventStatusEnumPending, models.EventStatusEnumProcessed},
+		}, transitions)
+		assert.False(t, history[1].ChangedAt.Before(history[0].ChangedAt))
+		assert.False(t, history[2].ChangedAt.Before(history[1].ChangedAt))
+	})
+
+	t.Run("other tenant", func(t *testing.T) {
+		otherTenantID, _ := seedTenant(t, pool)
+		_, err := repo.GetEventStatusHistory(ctx, eventID, otherTenantID)
+		assert.ErrorIs(t, err, ErrEventNotFound)
+	})
+
+	t.Run("missing event", func(t *testing.T) {
+		_, err := repo.GetEventStatusHistory(ctx, uuid.New(), tenantID)
+		assert.ErrorIs(t, err, ErrEventNotFound)
+	})
+}
+
+func TestListEvents_MultiValueFilter(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	providerID := seedProvider(t, pool)
+
+	failed := seedEvent(t, pool, tenantID, providerID)
+	refunded := seedEvent(t, pool, tenantID, providerID)
+	succeeded := seedEvent(t, pool, tenantID, providerID)
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "UPDATE events SET event_type = 'payment_refunded', status = 'failed' WHERE id = $1", refunded)
+		require.NoError(t, err)
+		_, err = tx.Exec(ctx, "UPDATE events SET event_type = 'payment_succeeded' WHERE id = $1", succeeded)
+		require.NoError(t, err)
+	})
+
+	repo := EventsRepositoryImplementation{pool: pool, logger: createTestLogger()}
+	page := models.PaginationParams{Limit: 10}
+
+	ids := func(events []models.Event) []uuid.UUID {
+		out := make([]uuid.UUID, 0, len(events))
+		for _, event := range events {
+			out = append(out, event.ID)
+		}
+		return out
+	}
+
+	t.Run("types ORed", func(t *testing.T) {
+		result, err := repo.ListEvents(ctx, tenantID, models.EventFilter{
+			EventTypes: []models.EventTypeEnum{models.EventTypeEnumPaymentFailed, models.EventTypeEnumPaymentRefunded},
+		}, page)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []uuid.UUID{failed, refunded}, ids(result.Items))
+		assert.Equal(t, int64(2), result.TotalCount)
+	})
+
+	t.Run("types and statuses ANDed", func(t *testing.T) {
+		result, err := repo.ListEvents(ctx, tenantID, models.EventFilter{
+			EventTypes: []models.EventTypeEnum{models.EventTypeEnumPaymentFailed, models.EventTypeEnumPaymentRefunded},
+			Statuses:   []models.EventStatusEnum{models.EventStatusEnumPending},
+		}, page)
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{failed}, ids(result.Items))
+	})
+
+	t.Run("empty filter", func(t *testing.T) {
+		result, err := repo.ListEvents(ctx, tenantID, models.EventFilter{}, page)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []uuid.UUID{failed, refunded, succeeded}, ids(result.Items))
+	})
+}
+
+func TestGetEventCountInWindow(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	otherTenantID, _ := seedTenant(t, pool)
+	providerID := seedProvider(t, pool)
+
+	now := time.Now().Truncate(time.Second)
+	insert := func(tenantID uuid.UUID, createdAt time.Time) {
+		seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+			_, err := tx.Exec(ctx,
+				"INSERT INTO events (tenant_id, provider_id, event_type, event_id, status, data, created_at) VALUES ($1, $2, 'payment_failed', $3, 'pending', '{}', $4)",
+				tenantID, providerID, "evt_"+uuid.NewString(), createdAt)
+			require.NoError(t, err)
+		})
+	}
+
+	insert(tenantID, now.Add(-time.Hour))        // window start is inclusive
+	insert(tenantID, now.Add(-time.Minute))      // inside
+	insert(tenantID, now)                        // window end is exclusive
+	insert(tenantID, now.Add(-2*time.Hour))      // before the window
+	insert(otherTenantID, now.Add(-time.Minute)) // another tenant
+
+	repo := EventsRepositoryImplementation{pool: pool, logger: createTestLogger()}
+	count, err := repo.GetEventCountInWindow(ctx, tenantID, now.Add(-time.Hour), now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestGetProviderFailureRates(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	otherTenantID, _ := seedTenant(t, pool)
+	failing := seedProvider(t, pool)
+	healthy := seedProvider(t, pool)
+
+	now := time.Now().Truncate(time.Second)
+	insert := func(tenantID uuid.UUID, providerID uuid.UUID, eventType string, createdAt time.Time) {
+		seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+			_, err := tx.Exec(ctx,
+				"INSERT INTO events (tenant_id, provider_id, event_type, event_id, status, data, created_at) VALUES ($1, $2, $3, $4, 'pending', '{}', $5)",
+				tenantID, providerID, eventType, "evt_"+uuid.NewString(), createdAt)
+			require.NoError(t, err)
+		})
+	}
+
+	insert(tenantID, failing, "payment_failed", now.Add(-time.Hour))        // window start is inclusive
+	insert(tenantID, failing, "payment_failed", now.Add(-time.Minute))      // inside
+	insert(tenantID, failing, "payment_succeeded", now.Add(-time.Minute))   // inside
+	insert(tenantID, failing, "payment_refunded", now.Add(-time.Minute))    // not an attempt
+	insert(tenantID, failing, "payment_failed", now)                        // window end is exclusive
+	insert(tenantID, healthy, "payment_succeeded", now.Add(-time.Minute))   // inside
+	insert(tenantID, healthy, "payment_failed", now.Add(-2*time.Hour))      // before the window
+	insert(otherTenantID, healthy, "payment_failed", now.Add(-time.Minute)) // another tenant
+
+	repo := EventsRepositoryImplementation{pool: pool, logger: createTestLogger()}
+	rates, err := repo.GetProviderFailureRates(ctx, tenantID, now.Add(-time.Hour), now)
+	require.NoError(t, err)
+
+	byProvider := map[uuid.UUID]models.ProviderFailureRate{}
+	for _, rate := range rates {
+		byProvider[rate.ProviderID] = rate
+	}
+	require.Len(t, byProvider, 2)
+	assert.Equal(t, int64(2), byProvider[failing].Failed)
+	assert.Equal(t, int64(3), byProvider[failing].Total)
+	assert.Equal(t, "integration provider", byProvider[failing].ProviderName)
+	assert.Equal(t, int64(0), byProvider[healthy].Failed)
+	assert.Equal(t, int64(1), byProvider[healthy].Total)
+}
+
+func TestUpdateEventStatusByFilter(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	otherTenantID, _ := seedTenant(t, pool)
+	backfilled := seedProvider(t, pool)
+	untouched := seedProvider(t, pool)
+
+	pendingA := seedEvent(t, pool, tenantID, backfilled)
+	pendingB := seedEvent(t, pool, tenantID, backfilled)
+	failed := seedEvent(t, pool, tenantID, backfilled)
+	otherProvider := seedEvent(t, pool, tenantID, untouched)
+	otherTenant := seedEvent(t, pool, otherTenantID, backfilled)
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "UPDATE events SET status = 'failed' WHERE id = $1", failed)
+		require.NoError(t, err)
+	})
+
+	repo := EventsRepositoryImplementation{pool: pool, logger: createTestLogger()}
+
+	t.Run("filtered update", func(t *testing.T) {
+		rows, err := repo.UpdateEventStatusByFilter(ctx, tenantID, models.EventFilter{
+			ProviderIDs: []uuid.UUID{backfilled},
+			Statuses:    []models.EventStatusEnum{models.EventStatusEnumPending},
+		}, models.EventStatusEnumProcessed)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), rows)
+
+		status := func(id uuid.UUID, tenant uuid.UUID) models.EventStatusEnum {
+			event, err := repo.GetEventByID(ctx, id, tenant)
+			require.NoError(t, err)
+			return event.Status
+		}
+		assert.Equal(t, models.EventStatusEnumProcessed, status(pendingA, tenantID))
+		assert.Equal(t, models.EventStatusEnumProcessed, status(pendingB, tenantID))
+		assert.Equal(t, models.EventStatusEnumFailed, status(failed, tenantID))
+		assert.Equal(t, models.EventStatusEnumPending, status(otherProvider, tenantID))
+		assert.Equal(t, models.EventStatusEnumPending, status(otherTenant, otherTenantID))
+	})
+
+	t.Run("empty filter is refused", func(t *testing.T) {
+		rows, err := repo.UpdateEventStatusByFilter(ctx, tenantID, models.EventFilter{}, models.EventStatusEnumFailed)
+		assert.ErrorIs(t, err, ErrEmptyEventFilter)
+		assert.Zero(t, rows)
+
+		event, err := repo.GetEventByID(ctx, otherProvider, tenantID)
+		require.NoError(t, err)
+		assert.Equal(t, models.EventStatusEnumPending, event.Status)
+	})
+}
+
+func TestCreateEventsBatch(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	registered := seedProvider(t, pool)
+	unregistered := seedProvider(t, pool)
+	existingID := seedEvent(t, pool, tenantID, registered)
+
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "UPDATE tenants SET allowed_provider_ids = ARRAY[$2::uuid] WHERE id = $1", tenantID, registered)
+		require.NoError(t, err)
+	})
+
+	repo := EventsRepositoryImplementation{pool: pool, logger: createTestLogger()}
+	newParams := func(providerID uuid.UUID, eventID string) models.CreateEventParams {
+		return models.CreateEventParams{
+			TenantID:   tenantID,
+			ProviderID: providerID,
+			EventType:  models.EventTypeEnumPaymentFailed,
+			EventID:    eventID,
+			Status:     models.EventStatusEnumPending,
+			Data:       `{}`,
+		}
+	}
+
+	t.Run("failed items do not affect the others", func(t *testing.T) {
+		args := []models.CreateEventParams{
+			newParams(registered, "evt_"+uuid.NewString()),
+			newParams(unregistered, "evt_"+uuid.NewString()),
+			newParams(registered, "evt_"+existingID.String()),
+			newParams(registered, "evt_"+uuid.NewString()),
+		}
+
+		results, err := repo.CreateEventsBatch(ctx, args, tenantID, models.BatchPolicy{MaxSize: 10})
+		require.NoError(t, err)
+		require.Len(t, results, 4)
+		assert.NoError(t, results[0].Err)
+		assert.ErrorIs(t, results[1].Err, ErrUnknownProvider)
+		assert.ErrorIs(t, results[2].Err, ErrEventAlreadyExists)
+		assert.NoError(t, results[3].Err)
+
+		for _, i := range []int{0, 3} {
+			stored, err := repo.GetEventByID(ctx, results[i].Event.ID, tenantID)
+			require.NoError(t, err)
+			assert.Equal(t, args[i].EventID, stored.EventID)
+		}
+	})
+
+	t.Run("oversized batch is chunked when allowed", func(t *testing.T) {
+		var args []models.CreateEventParams
+		for range 5 {
+			args = append(args, newParams(registered, "evt_"+uuid.NewString()))
+		}
+
+		results, err := repo.CreateEventsBatch(ctx, args, tenantID, models.BatchPolicy{MaxSize: 2, Chunk: true})
+		require.NoError(t, err)
+		require.Len(t, results, 5)
+		for i, result := range results {
+			require.NoError(t, result.Err)
+			assert.Equal(t, args[i].EventID, result.Event.EventID, "results keep input order")
+		}
+	})
+
+	t.Run("oversized batch is rejected by default", func(t *testing.T) {
+		args := []models.CreateEventParams{
+			newParams(registered, "evt_"+uuid.NewString()),
+			newParams(registered, "evt_"+uuid.NewString()),
+			newParams(registered, "evt_"+uuid.NewString()),
+		}
+
+		_, err := repo.CreateEventsBatch(ctx, args, tenantID, models.BatchPolicy{MaxSize: 2})
+		assert.ErrorIs(t, err, ErrBatchTooLarge)
+
+		count, err := repo.CountAllEvents(ctx, tenantID)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1+2+5, count, "nothing from the rejected batch is stored")
+	})
+}
+
+func TestGetFailedEvents_ResumesFromCursor(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	providerID := seedProvider(t, pool)
+
+	failed := map[uuid.UUID]bool{}
+	for range 3 {
+		failed[seedEvent(t, pool, tenantID, providerID)] = true
+	}
+	pending := seedEvent(t, pool, tenantID, providerID)
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "UPDATE events SET status = 'failed' WHERE tenant_id = $1 AND id <> $2", tenantID, pending)
+		require.NoError(t, err)
+	})
+
+	repo := EventsRepositoryImplementation{pool: pool, logger: createTestLogger()}
+
+	first, err := repo.GetFailedEvents(ctx, tenantID, uuid.Nil, 2)
+	require.NoError(t, err)
+	require.Len(t, first, 2)
+	assert.Less(t, first[0].ID.String(), first[1].ID.String())
+
+	rest, err := repo.GetFailedEvents(ctx, tenantID, first[1].ID, 2)
+	require.NoError(t, err)
+	require.Len(t, rest, 1)
+
+	for _, event := range append(first, rest...) {
+		assert.True(t, failed[event.ID], "unexpected event %s", event.ID)
+		assert.Equal(t, models.EventStatusEnumFailed, event.Status)
+	}
+
+	// The UpdatedAt read back is precise enough to drive a versioned update
+	processed := models.EventStatusEnumProcessed
+	_, err = repo.UpdateEventIfVersion(ctx, models.UpdateEventParams{ID: first[0].ID, Status: &processed}, first[0].UpdatedAt, tenantID)
+	require.NoError(t, err)
+
+	remaining, err := repo.GetFailedEvents(ctx, tenantID, uuid.Nil, 10)
+	require.NoError(t, err)
+	assert.Len(t, remaining, 2)
+}
+
+func TestGetPendingEventsOldestFirst_ConcurrentDrainsAreDisjoint(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	otherTenantID, _ := seedTenant(t, pool)
+	providerID := seedProvider(t, pool)
+
+	const pending = 10
+	ids := make([]uuid.UUID, 0, pending)
+	for range pending {
+		ids = append(ids, seedEvent(t, pool, tenantID, providerID))
+	}
+	otherEvent := seedEvent(t, pool, otherTenantID, providerID)
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		// Spread creation times so oldest-first has a single right answer
+		for i, id := range ids {
+			_, err := tx.Exec(ctx, "UPDATE events SET created_at = NOW() - make_interval(mins => $2) WHERE id = $1", id, pending-i)
+			require.NoError(t, err)
+		}
+	})
+
+	repo := EventsRepositoryImplementation{pool: pool, logger: createTestLogger()}
+	const workers = 3
+	drained := make([][]models.Event, workers)
+	errs := make([]error, workers)
+
+	var wg sync.WaitGroup
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			drained[i], errs[i] = repo.GetPendingEventsOldestFirst(ctx, tenantID, 4)
+		}()
+	}
+	wg.Wait()
+
+	seen := make(map[uuid.UUID]int)
+	for i := range workers {
+		require.NoError(t, errs[i])
+		for j, event := range drained[i] {
+			owner, dup := seen[event.ID]
+			assert.False(t, dup, "event %s drained by workers %d and %d", event.ID, owner, i)
+			seen[event.ID] = i
+
+			assert.Equal(t, models.EventStatusEnumProcessing, event.Status)
+			assert.Equal(t, tenantID, event.TenantID)
+			if j > 0 {
+				assert.True(t, drained[i][j-1].CreatedAt.Before(event.CreatedAt), "events should be returned oldest first")
+			}
+		}
+	}
+	assert.Len(t, seen, pending, "every pending event should be drained exactly once")
+
+	again, err := repo.GetPendingEventsOldestFirst(ctx, tenantID, pending)
+	require.NoError(t, err)
+	assert.Empty(t, again, "drained events are no longer pending")
+
+	other, err := repo.GetEventByID(ctx, otherEvent, otherTenantID)
+	require.NoError(t, err)
+	assert.Equal(t, models.EventStatusEnumPending, other.Status, "another tenant's events are not drained")
+
+	t.Run("oldest first", func(t *testing.T) {
+		tenantID, _ := seedTenant(t, pool)
+		older := seedEvent(t, pool, tenantID, providerID)
+		newer := seedEvent(t, pool, tenantID, providerID)
+		seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+			_, err := tx.Exec(ctx, "UPDATE events SET created_at = NOW() - INTERVAL '1 hour' WHERE id = $1", older)
+			require.NoError(t, err)
+		})
+
+		first, err := repo.GetPendingEventsOldestFirst(ctx, tenantID, 1)
+		require.NoError(t, err)
+		require.Len(t, first, 1)
+		assert.Equal(t, older, first[0].ID)
+
+		second, err := repo.GetPendingEventsOldestFirst(ctx, tenantID, 1)
+		require.NoError(t, err)
+		require.Len(t, second, 1)
+		assert.Equal(t, newer, second[0].ID)
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		_, err := repo.GetPendingEventsOldestFirst(ctx, tenantID, 0)
+		assert.ErrorIs(t, err, ErrInvalidClaimLimit)
+	})
+}
+
+func TestListEventsAfter(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	providerID := seedProvider(t, pool)
+
+	pending := map[uuid.UUID]bool{}
+	for range 3 {
+		pending[seedEvent(t, pool, tenantID, providerID)] = true
+	}
+	failed := seedEvent(t, pool, tenantID, providerID)
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "UPDATE events SET status = 'failed' WHERE id = $1", failed)
+		require.NoError(t, err)
+	})
+
+	repo := EventsRepositoryImplementation{pool: pool, logger: createTestLogger()}
+	filter := models.EventFilter{Statuses: []models.EventStatusEnum{models.EventStatusEnumPending}}
+
+	first, err := repo.ListEventsAfter(ctx, tenantID, filter, uuid.Nil, 2)
+	require.NoError(t, err)
+	require.Len(t, first, 2)
+	assert.Less(t, first[0].ID.String(), first[1].ID.String())
+
+	rest, err := repo.ListEventsAfter(ctx, tenantID, filter, first[1].ID, 2)
+	require.NoError(t, err)
+	require.Len(t, rest, 1)
+
+	for _, event := range append(first, rest...) {
+		assert.True(t, pending[event.ID], "unexpected event %s", event.ID)
+	}
+
+	all, err := repo.ListEventsAfter(ctx, tenantID, models.EventFilter{}, uuid.Nil, 10)
+	require.NoError(t, err)
+	assert.Len(t, all, 4)
+}
+
+func TestListEventsForExport(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	providerID := seedProvider(t, pool)
+
+	// Five events a minute apart, the last two created at the same instant so id breaks the tie
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	var ids []uuid.UUID
+	for i := range 5 {
+		id := seedEvent(t, pool, tenantID, providerID)
+		createdAt := start.Add(time.Duration(min(i, 3)) * time.Minute)
+		seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+			_, err := tx.Exec(ctx, "UPDATE events SET created_at = $2 WHERE id = $1", id, createdAt)
+			require.NoError(t, err)
+		})
+		ids = append(ids, id)
+	}
+	// Newest first, with the larger id first among the two created together
+	want := []uuid.UUID{ids[4], ids[3], ids[2], ids[1], ids[0]}
+	if ids[3].String() > ids[4].String() {
+		want[0], want[1] = ids[3], ids[4]
+	}
+
+	repo := EventsRepositoryImplementation{pool: pool, logger: createTestLogger()}
+
+	t.Run("pages through every event newest first", func(t *testing.T) {
+		var got []uuid.UUID
+		var cursor models.EventCursor
+		for {
+			page, err := repo.ListEventsForExport(ctx, tenantID, cursor, 2)
+			require.NoError(t, err)
+			for _, event := range page {
+				got = append(got, event.ID)
+			}
+			if len(page) < 2 {
+				break
+			}
+			last := page[len(page)-1]
+			cursor = models.EventCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+		}
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("other tenants see nothing", func(t *testing.T) {
+		otherTenantID, _ := seedTenant(t, pool)
+		page, err := repo.ListEventsForExport(ctx, otherTenantID, models.EventCursor{}, 10)
+		require.NoError(t, err)
+		assert.Empty(t, page)
+	})
+}
+
+func TestGetEventsWithoutLeak(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, customerID := seedTenant(t, pool)
+	providerID := seedProvider(t, pool)
+
+	linked := seedEvent(t, pool, tenantID, providerID)
+	unlinked := seedEvent(t, pool, tenantID, providerID)
+	leakID := seedLeak(t, pool, tenantID, customerID, "25.00")
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "INSERT INTO leak_events (leak_id, event_id, tenant_id) VALUES ($1, $2, $3)", leakID, linked, tenantID)
+		require.NoError(t, err)
+		// An unlinked event of another type must not be reported as a payment_failed gap
+		_, err = tx.Exec(ctx,
+			"INSERT INTO events (tenant_id, provider_id, event_type, event_id, status, data) VALUES ($1, $2, 'payment_succeeded', $3, 'pending', '{}')",
+			tenantID, providerID, "evt_"+uuid.NewString())
+		require.NoError(t, err)
+	})
+
+	repo := EventsRepositoryImplementation{pool: pool, logger: createTestLogger()}
+	page, err := repo.GetEventsWithoutLeak(ctx, tenantID, models.EventTypeEnumPaymentFailed, models.PaginationParams{Limit: 10})
+	require.NoError(t, err)
+
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, unlinked, page.Items[0].ID)
+	assert.Equal(t, int64(1), page.TotalCount)
+}
+
+func TestGetEventsBySource(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	otherTenantID, _ := seedTenant(t, pool)
+	providerID := seedProvider(t, pool)
+
+	repo := EventsRepositoryImplementation{pool: pool, logger: createTestLogger()}
+	create := func(tenantID uuid.UUID, source models.EventSourceEnum) models.Event {
+		event, err := repo.CreateEvent(ctx, models.CreateEventParams{
+			TenantID:   tenantID,
+			ProviderID: providerID,
+			EventType:  models.EventTypeEnumPaymentFailed,
+			EventID:    "evt_" + uuid.NewString(),
+			Status:     models.EventStatusEnumPending,
+			Data:       `{}`,
+			Source:     source,
+		}, tenantID)
+		require.NoError(t, err)
+		return event
+	}
+
+	webhook := create(tenantID, models.EventSourceEnumWebhook)
+	api := create(tenantID, models.EventSourceEnumApi)
+	unstamped := create(tenantID, "")
+	manual := seedEvent(t, pool, tenantID, providerID)
+	create(otherTenantID, models.EventSourceEnumWebhook)
+
+	assert.Equal(t, models.EventSourceEnumWebhook, webhook.Source)
+	assert.Equal(t, models.EventSourceEnumApi, api.Source)
+	assert.Equal(t, models.EventSourceEnumManual, unstamped.Source, "an event created without a source is manual")
+
+	ids := func(source models.EventSourceEnum) []uuid.UUID {
+		page, err := repo.GetEventsBySource(ctx, tenantID, source, models.PaginationParams{Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(page.Items)), page.TotalCount)
+		var ids []uuid.UUID
+		for _, event := range page.Items {
+			assert.Equal(t, source, event.Source)
+			ids = append(ids, event.ID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []uuid.UUID{webhook.ID}, ids(models.EventSourceEnumWebhook), "another tenant's webhook events are not listed")
+	assert.Equal(t, []uuid.UUID{api.ID}, ids(models.EventSourceEnumApi))
+	assert.ElementsMatch(t, []uuid.UUID{unstamped.ID, manual}, ids(models.EventSourceEnumManual), "rows inserted directly are manual")
+	assert.Empty(t, ids(models.EventSourceEnumImport))
+}
+
+func TestGetRecentEvents(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	otherTenantID, _ := seedTenant(t, pool)
+	providerID := seedProvider(t, pool)
+
+	oldest := seedEvent(t, pool, tenantID, providerID)
+	middle := seedEvent(t, pool, tenantID, providerID)
+	newest := seedEvent(t, pool, tenantID, providerID)
+	foreign := seedEvent(t, pool, otherTenantID, providerID)
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		for age, id := range map[int]uuid.UUID{3: oldest, 2: middle, 1: newest, 0: foreign} {
+			_, err := tx.Exec(ctx, "UPDATE events SET created_at = NOW() - make_interval(mins => $1) WHERE id = $2", age, id)
+			require.NoError(t, err)
+		}
+	})
+
+	repo := EventsRepositoryImplementation{pool: pool, logger: createTestLogger()}
+	events, err := repo.GetRecentEvents(ctx, tenantID, 2)
+	require.NoError(t, err)
+
+	require.Len(t, events, 2)
+	assert.Equal(t, newest, events[0].ID)
+	assert.Equal(t, middle, events[1].ID)
+}
+
+func TestHasAnyEvents(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	emptyTenantID, _ := seedTenant(t, pool)
+	seedEvent(t, pool, tenantID, seedProvider(t, pool))
+
+	repo := EventsRepositoryImplementation{pool: pool, logger: createTestLogger()}
+
+	t.Run("tenant with events", func(t *testing.T) {
+		has, err := repo.HasAnyEvents(ctx, tenantID)
+		require.NoError(t, err)
+		assert.True(t, has)
+	})
+
+	t.Run("tenant without events", func(t *testing.T) {
+		has, err := repo.HasAnyEvents(ctx, emptyTenantID)
+		require.NoError(t, err)
+		assert.False(t, has, "expected another tenant's events not to count")
+	})
+}
+
+func TestPurgeEventsBefore(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	otherTenantID, _ := seedTenant(t, pool)
+	providerID := seedProvider(t, pool)
+
+	now := time.Now().Truncate(time.Second)
+	insert := func(tenantID uuid.UUID, createdAt time.Time) {
+		seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+			_, err := tx.Exec(ctx,
+				"INSERT INTO events (tenant_id, provider_id, event_type, event_id, status, data, created_at) VALUES ($1, $2, 'payment_failed', $3, 'pending', '{}', $4)",
+				tenantID, providerID, "evt_"+uuid.NewString(), createdAt)
+			require.NoError(t, err)
+		})
+	}
+
+	cutoff := now.Add(-30 * 24 * time.Hour)
+	for range 3 {
+		insert(tenantID, cutoff.Add(-time.Hour)) // past retention
+	}
+	insert(tenantID, cutoff.Add(time.Hour))       // still retained
+	insert(otherTenantID, cutoff.Add(-time.Hour)) // another tenant
+
+	repo := EventsRepositoryImplementation{pool: pool, logger: createTestLogger()}
+
+	deleted, err := repo.PurgeEventsBefore(ctx, tenantID, cutoff, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted, "expected the batch to be capped at the limit")
+
+	deleted, err = repo.PurgeEventsBefore(ctx, tenantID, cutoff, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	count, err := repo.CountAllEvents(ctx, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count, "expected the retained event to be kept")
+
+	count, err = repo.CountAllEvents(ctx, otherTenantID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count, "expected another tenant's events to be kept")
+}
+
+func TestGetEventRetentionPolicies(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	overrideTenantID, _ := seedTenant(t, pool)
+	defaultTenantID, _ := seedTenant(t, pool)
+
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "UPDATE tenants SET event_retention_days = 7 WHERE id = $1", overrideTenantID)
+		require.NoError(t, err)
+	})
+
+	repo := EventsRepositoryImplementation{pool: pool, logger: createTestLogger()}
+	policies, err := repo.GetEventRetentionPolicies(ctx)
+	require.NoError(t, err)
+
+	byTenant := map[uuid.UUID]models.EventRetentionPolicy{}
+	for _, policy := range policies {
+		byTenant[policy.TenantID] = policy
+	}
+	require.Contains(t, byTenant, overrideTenantID)
+	require.Contains(t, byTenant, defaultTenantID)
+	require.NotNil(t, byTenant[overrideTenantID].RetentionDays)
+	assert.Equal(t, int32(7), *byTenant[overrideTenantID].RetentionDays)
+	assert.Nil(t, byTenant[defaultTenantID].RetentionDays)
+}
+
+func TestArchiveEventsBefore(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	otherTenantID, _ := seedTenant(t, pool)
+	providerID := seedProvider(t, pool)
+
+	cutoff := time.Now().Add(-30 * 24 * time.Hour).Truncate(time.Second)
+	insert := func(tenantID uuid.UUID, createdAt time.Time, n int) {
+		seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+			_, err := tx.Exec(ctx,
+				`INSERT INTO events (tenant_id, provider_id, event_type, event_id, status, data, created_at)
+				 SELECT $1, $2, 'payment_failed', 'evt_' || uuid_generate_v4(), 'pending', '{}', $3
+				 FROM generate_series(1, $4)`,
+				tenantID, providerID, createdAt, n)
+			require.NoError(t, err)
+		})
+	}
+
+	// One more than a page, sharing a timestamp, so paging has to break ties on id
+	insert(tenantID, cutoff.Add(-time.Hour), archivePageSize+1)
+	insert(tenantID, cutoff.Add(time.Hour), 2)       // newer than the cutoff
+	insert(otherTenantID, cutoff.Add(-time.Hour), 1) // another tenant
+
+	repo := EventsRepositoryImplementation{pool: pool, logger: createTestLogger()}
+	var buf bytes.Buffer
+	archived, err := repo.ArchiveEventsBefore(ctx, tenantID, cutoff, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(archivePageSize+1), archived)
+
+	seen := map[uuid.UUID]bool{}
+	decoder := json.NewDecoder(&buf)
+	for decoder.More() {
+		var event models.Event
+		require.NoError(t, decoder.Decode(&event))
+		assert.Equal(t, tenantID, event.TenantID)
+		assert.True(t, event.CreatedAt.Before(cutoff), "expected only events older than the cutoff")
+		assert.False(t, seen[event.ID], "expected each event once")
+		seen[event.ID] = true
+	}
+	assert.Len(t, seen, archivePageSize+1)
+
+	count, err := repo.CountAllEvents(ctx, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(archivePageSize+3), count, "expected archiving not to delete anything")
+}
+
+func TestSearchEventsByExternalID(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	otherTenantID, _ := seedTenant(t, pool)
+	providerID := seedProvider(t, pool)
+
+	insert := func(tenantID uuid.UUID, eventID string) {
+		seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+			_, err := tx.Exec(ctx,
+				"INSERT INTO events (tenant_id, provider_id, event_type, event_id, status, data) VALUES ($1, $2, 'payment_failed', $3, 'pending', '{}')",
+				tenantID, providerID, eventID)
+			require.NoError(t, err)
+		})
+	}
+	insert(tenantID, "ch_2")
+	insert(tenantID, "ch_1")
+	insert(tenantID, "chX1") // matches "ch_" only if _ were a wildcard
+	insert(tenantID, "in_1")
+	insert(otherTenantID, "ch_3")
+
+	repo := EventsRepositoryImplementation{pool: pool, logger: createTestLogger()}
+
+	t.Run("matching prefix", func(t *testing.T) {
+		page, err := repo.SearchEventsByExternalID(ctx, tenantID, "ch_", models.PaginationParams{Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), page.TotalCount, "expected neither chX1 nor the other tenant's event")
+		require.Len(t, page.Items, 2)
+		assert.Equal(t, "ch_1", page.Items[0].EventID)
+		assert.Equal(t, "ch_2", page.Items[1].EventID)
+	})
+
+	t.Run("paginates", func(t *testing.T) {
+		page, err := repo.SearchEventsByExternalID(ctx, tenantID, "ch_", models.PaginationParams{Limit: 1, Offset: 1})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), page.TotalCount)
+		require.Len(t, page.Items, 1)
+		assert.Equal(t, "ch_2", page.Items[0].EventID)
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		page, err := repo.SearchEventsByExternalID(ctx, tenantID, "sub_", models.PaginationParams{Limit: 10})
+		require.NoError(t, err)
+		assert.Zero(t, page.TotalCount)
+		assert.Empty(t, page.Items)
+	})
+}
+
+func TestFindDuplicateCharges(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, customerID := seedTenant(t, pool)
+	providerID := seedProvider(t, pool)
+	now := time.Now()
+
+	charge := func(data string, createdAt time.Time) uuid.UUID {
+		id := uuid.New()
+		seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+			_, err := tx.Exec(ctx,
+				"INSERT INTO events (id, tenant_id, provider_id, event_type, event_id, status, data, created_at) VALUES ($1, $2, $3, 'payment_succeeded', $4, 'processed', $5::jsonb, $6)",
+				id, tenantID, providerID, "ch_"+id.String(), data, createdAt)
+			require.NoError(t, err)
+		})
+		return id
+	}
+
+	// seedTenant's customer has external ID cus_<uuid>
+	known := "cus_" + customerID.String()
+	original := charge(`{"customer_id":"`+known+`","amount":49.99,"currency":"usd"}`, now.Add(-30*time.Minute))
+	duplicate := charge(`{"customer_id":"`+known+`","amount":49.990,"currency":"USD"}`, now.Add(-28*time.Minute))
+
+	// The same amount moments later, but for another customer, in another currency, or a day apart
+	charge(`{"customer_id":"cus_other","amount":49.99,"currency":"usd"}`, now.Add(-27*time.Minute))
+	charge(`{"customer_id":"`+known+`","amount":49.99,"currency":"eur"}`, now.Add(-26*time.Minute))
+	charge(`{"customer_id":"cus_later","amount":10,"currency":"usd"}`, now.Add(-25*time.Hour))
+	charge(`{"customer_id":"cus_later","amount":10,"currency":"usd"}`, now.Add(-20*time.Minute))
+	// No customer or a non-numeric amount is never a duplicate
+	charge(`{"amount":5,"currency":"usd"}`, now.Add(-10*time.Minute))
+	charge(`{"amount":5,"currency":"usd"}`, now.Add(-9*time.Minute))
+	charge(`{"customer_id":"cus_text","amount":"5","currency":"usd"}`, now.Add(-8*time.Minute))
+	charge(`{"customer_id":"cus_text","amount":"5","currency":"usd"}`, now.Add(-7*time.Minute))
+
+	repo := EventsRepositoryImplementation{pool: pool, logger: createTestLogger()}
+	since := now.Add(-48 * time.Hour)
+
+	duplicates, err := repo.FindDuplicateCharges(ctx, tenantID, since, 10*time.Minute)
+	require.NoError(t, err)
+	require.Len(t, duplicates, 1)
+	assert.Equal(t, duplicate, duplicates[0].EventID)
+	assert.Equal(t, original, duplicates[0].OriginalEventID)
+	assert.Equal(t, known, duplicates[0].CustomerRef)
+	assert.Equal(t, customerID, duplicates[0].CustomerID)
+	assert.Equal(t, "USD", duplicates[0].Currency)
+	assert.Zero(t, duplicates[0].Amount.Cmp(models.NewDecimal(4999, -2)))
+
+	t.Run("a shorter window misses it", func(t *testing.T) {
+		duplicates, err := repo.FindDuplicateCharges(ctx, tenantID, since, time.Minute)
+		require.NoError(t, err)
+		assert.Empty(t, duplicates)
+	})
+
+	t.Run("not found again once flagged", func(t *testing.T) {
+		seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+			_, err := tx.Exec(ctx,
+				"INSERT INTO leaks (tenant_id, customer_id, leak_type, amount, confidence, source_event_id) VALUES ($1, $2, 'duplicate_charge', 49.99, 90, $3)",
+				tenantID, customerID, duplicate)
+			require.NoError(t, err)
+		})
+
+		duplicates, err := repo.FindDuplicateCharges(ctx, tenantID, since, 10*time.Minute)
+		require.NoError(t, err)
+		assert.Empty(t, duplicates)
+	})
+
+	t.Run("other tenants see none", func(t *testing.T) {
+		otherTenantID, _ := seedTenant(t, pool)
+		duplicates, err := repo.FindDuplicateCharges(ctx, otherTenantID, since, 10*time.Minute)
+		require.NoError(t, err)
+		assert.Empty(t, duplicates)
+	})
+}
+
+func TestFindDunningGaps(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, customerID := seedTenant(t, pool)
+	providerID := seedProvider(t, pool)
+	now := time.Now()
+
+	event := func(eventType, data string, createdAt time.Time) uuid.UUID {
+		id := uuid.New()
+		seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+			_, err := tx.Exec(ctx,
+				"INSERT INTO events (id, tenant_id, provider_id, event_type, event_id, status, data, created_at) VALUES ($1, $2, $3, $4, $5, 'processed', $6::jsonb, $7)",
+				id, tenantID, providerID, eventType, "evt_"+id.String(), data, createdAt)
+			require.NoError(t, err)
+		})
+		return id
+	}
+
+	// seedTenant's customer has external ID cus_<uuid>
+	known := "cus_" + customerID.String()
+	// Failed and never retried
+	gap := event("payment_failed", `{"customer_id":"`+known+`","amount":29,"currency":"usd"}`, now.Add(-100*time.Hour))
+	// Failed, then retried a day later
+	event("payment_failed", `{"customer_id":"cus_retried","amount":29,"currency":"usd"}`, now.Add(-100*time.Hour))
+	event("payment_succeeded", `{"customer_id":"cus_retried","amount":29,"currency":"usd"}`, now.Add(-76*time.Hour))
+	// Failed, then the payment method was updated
+	event("payment_failed", `{"customer_id":"cus_updated","amount":29,"currency":"usd"}`, now.Add(-100*time.Hour))
+	event("payment_updated", `{"customer_id":"cus_updated"}`, now.Add(-90*time.Hour))
+	// Failed too recently for the window to have closed, or without a customer
+	event("payment_failed", `{"customer_id":"cus_recent","amount":29,"currency":"usd"}`, now.Add(-10*time.Hour))
+	event("payment_failed", `{"amount":29,"currency":"usd"}`, now.Add(-100*time.Hour))
+
+	repo := EventsRepositoryImplementation{pool: pool, logger: createTestLogger()}
+
+	gaps, err := repo.FindDunningGaps(ctx, tenantID, now, 72*time.Hour, 48*time.Hour)
+	require.NoError(t, err)
+	require.Len(t, gaps, 1)
+	assert.Equal(t, gap, gaps[0].EventID)
+	assert.Equal(t, known, gaps[0].CustomerRef)
+	assert.Equal(t, customerID, gaps[0].CustomerID)
+	assert.Equal(t, "USD", gaps[0].Currency)
+	assert.Equal(t, 72*time.Hour, gaps[0].Window)
+	assert.Zero(t, gaps[0].Amount.Cmp(models.NewDecimal(29, 0)))
+
+	t.Run("a retry after the window does not count", func(t *testing.T) {
+		gaps, err := repo.FindDunningGaps(ctx, tenantID, now, 12*time.Hour, 100*time.Hour)
+		require.NoError(t, err)
+		var ids []uuid.UUID
+		for _, g := range gaps {
+			ids = append(ids, g.EventID)
+		}
+		assert.Contains(t, ids, gap)
+		assert.Len(t, gaps, 2, "the retried failure's follow-up came after a 12h window")
+	})
+
+	t.Run("the tenant's window overrides the default", func(t *testing.T) {
+		seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+			_, err := tx.Exec(ctx, "UPDATE tenants SET dunning_window_hours = 200 WHERE id = $1", tenantID)
+			require.NoError(t, err)
+		})
+		t.Cleanup(func() {
+			seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+				_, err := tx.Exec(ctx, "UPDATE tenants SET dunning_window_hours = NULL WHERE id = $1", tenantID)
+				require.NoError(t, err)
+			})
+		})
+
+		gaps, err := repo.FindDunningGaps(ctx, tenantID, now, 72*time.Hour, 48*time.Hour)
+		require.NoError(t, err)
+		assert.Empty(t, gaps, "no failure is 200 hours old yet")
+	})
+
+	t.Run("not found again once flagged", func(t *testing.T) {
+		seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+			_, err := tx.Exec(ctx,
+				"INSERT INTO leaks (tenant_id, customer_id, leak_type, amount, confidence, source_event_id) VALUES ($1, $2, 'dunning_gap', 29, 60, $3)",
+				tenantID, customerID, gap)
+			require.NoError(t, err)
+		})
+
+		gaps, err := repo.FindDunningGaps(ctx, tenantID, now, 72*time.Hour, 48*time.Hour)
+		require.NoError(t, err)
+		assert.Empty(t, gaps)
+	})
+
+	t.Run("other tenants see none", func(t *testing.T) {
+		otherTenantID, _ := seedTenant(t, pool)
+		gaps, err := repo.FindDunningGaps(ctx, otherTenantID, now, 72*time.Hour, 48*time.Hour)
+		require.NoError(t, err)
+		assert.Empty(t, gaps)
+	})
+}
+
+func TestReattributeEvents(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	wrong := seedProvider(t, pool)
+	correct := seedProvider(t, pool)
+	for range 3 {
+		seedEvent(t, pool, tenantID, wrong)
+	}
+	// The correct provider belongs to the tenant through an integration, before any of its events arrive
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "INSERT INTO integrations (tenant_id, provider_id) VALUES ($1, $2)", tenantID, correct)
+		require.NoError(t, err)
+	})
+
+	repo := EventsRepositoryImplementation{pool: pool, logger: createTestLogger()}
+
+	t.Run("a provider of another tenant is refused", func(t *testing.T) {
+		otherTenantID, _ := seedTenant(t, pool)
+		otherProvider := seedProvider(t, pool)
+		seedEvent(t, pool, otherTenantID, otherProvider)
+
+		_, err := repo.ReattributeEvents(ctx, tenantID, wrong, otherProvider)
+		assert.ErrorIs(t, err, ErrUnknownProvider)
+		_, err = repo.ReattributeEvents(ctx, tenantID, otherProvider, correct)
+		assert.ErrorIs(t, err, ErrUnknownProvider)
+		_, err = repo.ReattributeEvents(ctx, otherTenantID, wrong, correct)
+		assert.ErrorIs(t, err, ErrUnknownProvider, "the tenant's providers are not the other tenant's")
+
+		counts, err := repo.CountEventsByStatusForProvider(ctx, tenantID, wrong)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), counts[models.EventStatusEnumPending], "nothing moved")
+	})
+
+	t.Run("the same provider is refused", func(t *testing.T) {
+		_, err := repo.ReattributeEvents(ctx, tenantID, wrong, wrong)
+		assert.ErrorIs(t, err, ErrSameProvider)
+	})
+
+	t.Run("moves every event of the provider", func(t *testing.T) {
+		moved, err := repo.ReattributeEvents(ctx, tenantID, wrong, correct)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), moved)
+
+		counts, err := repo.CountEventsByStatusForProvider(ctx, tenantID, correct)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), counts[models.EventStatusEnumPending])
+
+		_, err = repo.ReattributeEvents(ctx, tenantID, correct, wrong)
+		assert.ErrorIs(t, err, ErrUnknownProvider, "without events or an integration the wrong provider no longer belongs to the tenant")
+	})
+}
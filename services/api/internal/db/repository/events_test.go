@@ -1,15 +1,19 @@
 package repository
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	db "rdl-api/internal/db/sqlc"
 	"rdl-api/internal/domain/models"
@@ -134,6 +138,7 @@ func TestToEventDomain(t *testing.T) {
 					Data:       data,
 					CreatedAt:  pgtype.Timestamptz{Time: now, Valid: true},
 					UpdatedAt:  pgtype.Timestamptz{Time: now, Valid: true},
+					Source:     db.EventSourceEnumWebhook,
 				}
 			},
 		},
@@ -154,6 +159,7 @@ func TestToEventDomain(t *testing.T) {
 					Data:       data,
 					CreatedAt:  pgtype.Timestamptz{Valid: false},
 					UpdatedAt:  pgtype.Timestamptz{Valid: false},
+					Source:     db.EventSourceEnumImport,
 				}
 			},
 		},
@@ -162,13 +168,14 @@ func TestToEventDomain(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			inputEvent := tt.input(t)
-			result := toEventDomain(inputEvent)
+			result := toEventDomain(nil, inputEvent)
 
 			// We can't directly compare the structs because of the UUID fields
 			// So we compare individual fields
 			assert.Equal(t, inputEvent.EventID, result.EventID)
 			assert.Equal(t, models.EventTypeEnum(inputEvent.EventType), result.EventType)
 			assert.Equal(t, models.EventStatusEnum(inputEvent.Status), result.Status)
+			assert.Equal(t, models.EventSourceEnum(inputEvent.Source), result.Source)
 
 			// Compare timestamps
 			if inputEvent.CreatedAt.Valid {
@@ -202,12 +209,14 @@ func TestToCreateEventDBParams(t *testing.T) {
 				EventID:    "evt_test_123",
 				Status:     models.EventStatusEnumPending,
 				Data:       `{"amount": 100.50, "currency": "USD"}`,
+				Source:     models.EventSourceEnumWebhook,
 			},
 			expectedError: nil,
 			validateResult: func(t *testing.T, result db.CreateEventParams) {
 				assert.Equal(t, "evt_test_123", result.EventID)
 				assert.Equal(t, db.EventTypeEnumPaymentFailed, result.EventType)
 				assert.Equal(t, db.EventStatusEnumPending, result.Status)
+				assert.Equal(t, db.EventSourceEnumWebhook, result.Source)
 				assert.NotNil(t, result.Data)
 			},
 		},
@@ -226,6 +235,7 @@ func TestToCreateEventDBParams(t *testing.T) {
 				assert.Equal(t, "evt_test_456", result.EventID)
 				assert.Equal(t, db.EventTypeEnumPaymentSucceeded, result.EventType)
 				assert.Equal(t, db.EventStatusEnumProcessed, result.Status)
+				assert.Equal(t, db.EventSourceEnumManual, result.Source, "an event without a source is manual")
 				assert.NotNil(t, result.Data)
 			},
 		},
@@ -327,6 +337,60 @@ func TestToUpdateEventDBParams(t *testing.T) {
 				assert.Nil(t, result.Data)
 			},
 		},
+		{
+			name: "status only leaves event type and data unset",
+			input: func() models.UpdateEventParams {
+				status := models.EventStatusEnumProcessed
+				return models.UpdateEventParams{ID: uuid.New(), Status: &status}
+			}(),
+			validateResult: func(t *testing.T, result db.UpdateEventParams) {
+				assert.True(t, result.Status.Valid)
+				assert.Equal(t, db.EventStatusEnumProcessed, result.Status.EventStatusEnum)
+				assert.False(t, result.EventType.Valid)
+				assert.Nil(t, result.Data)
+			},
+		},
+		{
+			name: "JSON null data is treated as omitted",
+			input: func() models.UpdateEventParams {
+				data := json.RawMessage(" null ")
+				return models.UpdateEventParams{ID: uuid.New(), Data: &data}
+			}(),
+			validateResult: func(t *testing.T, result db.UpdateEventParams) {
+				assert.Nil(t, result.Data)
+			},
+		},
+		{
+			name: "empty data is treated as omitted",
+			input: func() models.UpdateEventParams {
+				data := json.RawMessage{}
+				return models.UpdateEventParams{ID: uuid.New(), Data: &data}
+			}(),
+			validateResult: func(t *testing.T, result db.UpdateEventParams) {
+				assert.Nil(t, result.Data)
+			},
+		},
+		{
+			name: "merge mode asks the query to merge data",
+			input: func() models.UpdateEventParams {
+				data := json.RawMessage(`{"note": "resolved"}`)
+				return models.UpdateEventParams{ID: uuid.New(), Data: &data, DataMode: models.DataModeMerge}
+			}(),
+			validateResult: func(t *testing.T, result db.UpdateEventParams) {
+				assert.True(t, result.MergeData)
+				assert.JSONEq(t, `{"note": "resolved"}`, string(result.Data))
+			},
+		},
+		{
+			name: "data mode defaults to replace",
+			input: func() models.UpdateEventParams {
+				data := json.RawMessage(`{"note": "resolved"}`)
+				return models.UpdateEventParams{ID: uuid.New(), Data: &data}
+			}(),
+			validateResult: func(t *testing.T, result db.UpdateEventParams) {
+				assert.False(t, result.MergeData)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -344,6 +408,29 @@ func TestToUpdateEventDBParams(t *testing.T) {
 	}
 }
 
+func TestHandleDatabaseError_ContextErrors(t *testing.T) {
+	repo := EventsRepositoryImplementation{logger: createTestLogger()}
+
+	tests := []struct {
+		name     string
+		err      error
+		mapped   error
+		standard error
+	}{
+		{name: "canceled", err: context.Canceled, mapped: ErrOperationCanceled, standard: context.Canceled},
+		{name: "wrapped canceled", err: fmt.Errorf("query: %w", context.Canceled), mapped: ErrOperationCanceled, standard: context.Canceled},
+		{name: "deadline exceeded", err: context.DeadlineExceeded, mapped: ErrOperationTimeout, standard: context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := repo.handleDatabaseError(context.Background(), tt.err, "create event", "evt_1", uuid.NewString())
+			assert.ErrorIs(t, err, tt.mapped)
+			assert.ErrorIs(t, err, tt.standard, "expected the standard context error to still match")
+		})
+	}
+}
+
 // TestEdgeCases tests various edge cases and error conditions
 func TestEdgeCases(t *testing.T) {
 	t.Run("CreateEvent with invalid data type", func(t *testing.T) {
@@ -450,13 +537,191 @@ func TestUUIDConversion(t *testing.T) {
 	})
 }
 
+func TestGetEventsByIDs_EmptyInput(t *testing.T) {
+	// A nil pool would panic if a query were attempted
+	repo := EventsRepositoryImplementation{logger: createTestLogger()}
+
+	events, err := repo.GetEventsByIDs(context.Background(), uuid.New(), nil)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, events)
+	assert.Empty(t, events)
+}
+
+func TestIsEventTypeAccepted(t *testing.T) {
+	tests := []struct {
+		name          string
+		acceptedTypes []string
+		eventType     models.EventTypeEnum
+		expected      bool
+	}{
+		{"empty allowlist accepts everything", nil, models.EventTypeEnumPaymentSucceeded, true},
+		{"allowed type", []string{"payment_failed"}, models.EventTypeEnumPaymentFailed, true},
+		{"disallowed type", []string{"payment_failed"}, models.EventTypeEnumPaymentSucceeded, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isEventTypeAccepted(tt.acceptedTypes, tt.eventType))
+		})
+	}
+}
+
+func TestIsProviderAllowed(t *testing.T) {
+	allowed := uuid.New()
+
+	tests := []struct {
+		name             string
+		allowedProviders []pgtype.UUID
+		providerID       uuid.UUID
+		expected         bool
+	}{
+		{"empty allowlist accepts everything", nil, uuid.New(), true},
+		{"allowed provider", []pgtype.UUID{convertUUIDToPgtypeUUID(allowed)}, allowed, true},
+		{"disallowed provider", []pgtype.UUID{convertUUIDToPgtypeUUID(allowed)}, uuid.New(), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isProviderAllowed(tt.allowedProviders, tt.providerID))
+		})
+	}
+}
+
+func TestToEventStatusCounts(t *testing.T) {
+	t.Run("groups counts by status", func(t *testing.T) {
+		counts := toEventStatusCounts(nil, []db.CountEventsByStatusForProviderRow{
+			{Status: db.EventStatusEnumPending, Count: 3},
+			{Status: db.EventStatusEnumProcessed, Count: 7},
+			{Status: db.EventStatusEnumFailed, Count: 1},
+		})
+
+		assert.Equal(t, map[models.EventStatusEnum]int64{
+			models.EventStatusEnumPending:    3,
+			models.EventStatusEnumProcessed:  7,
+			models.EventStatusEnumFailed:     1,
+			models.EventStatusEnumProcessing: 0,
+		}, counts)
+	})
+
+	t.Run("zero-fills missing statuses", func(t *testing.T) {
+		counts := toEventStatusCounts(nil, []db.CountEventsByStatusForProviderRow{
+			{Status: db.EventStatusEnumFailed, Count: 2},
+		})
+
+		assert.Equal(t, map[models.EventStatusEnum]int64{
+			models.EventStatusEnumPending:    0,
+			models.EventStatusEnumProcessed:  0,
+			models.EventStatusEnumFailed:     2,
+			models.EventStatusEnumProcessing: 0,
+		}, counts)
+	})
+
+	t.Run("no events", func(t *testing.T) {
+		counts := toEventStatusCounts(nil, nil)
+
+		assert.Len(t, counts, 4)
+		for status, count := range counts {
+			assert.Zero(t, count, status)
+		}
+	})
+}
+
+func TestToEventFilterDBArgs(t *testing.T) {
+	t.Run("multiple values", func(t *testing.T) {
+		providerID := uuid.New()
+		args := toEventFilterDBArgs(models.EventFilter{
+			EventTypes:  []models.EventTypeEnum{models.EventTypeEnumPaymentFailed, models.EventTypeEnumPaymentRefunded},
+			Statuses:    []models.EventStatusEnum{models.EventStatusEnumPending},
+			ProviderIDs: []uuid.UUID{providerID},
+		})
+
+		assert.Equal(t, []string{"payment_failed", "payment_refunded"}, args.eventTypes)
+		assert.Equal(t, []string{"pending"}, args.statuses)
+		assert.Equal(t, []pgtype.UUID{convertUUIDToPgtypeUUID(providerID)}, args.providerIDs)
+	})
+
+	t.Run("empty filter matches everything", func(t *testing.T) {
+		args := toEventFilterDBArgs(models.EventFilter{})
+
+		// Non-nil so the queries receive empty arrays rather than NULL
+		assert.NotNil(t, args.eventTypes)
+		assert.Empty(t, args.eventTypes)
+		assert.NotNil(t, args.statuses)
+		assert.Empty(t, args.statuses)
+		assert.NotNil(t, args.providerIDs)
+		assert.Empty(t, args.providerIDs)
+	})
+}
+
+func TestUpdateEventStatusByFilter_EmptyFilterIsRefused(t *testing.T) {
+	// The guard runs before any query, so no pool is needed
+	repo := EventsRepositoryImplementation{logger: createTestLogger()}
+
+	rows, err := repo.UpdateEventStatusByFilter(context.Background(), uuid.New(), models.EventFilter{}, models.EventStatusEnumProcessed)
+
+	assert.ErrorIs(t, err, ErrEmptyEventFilter)
+	assert.Zero(t, rows)
+}
+
+func TestLikePrefixPattern(t *testing.T) {
+	tests := map[string]string{
+		"evt_":     `evt\_%`,
+		"ch_1":     `ch\_1%`,
+		"50%":      `50\%%`,
+		`a\b`:      `a\\b%`,
+		"plain123": "plain123%",
+	}
+	for prefix, want := range tests {
+		assert.Equal(t, want, likePrefixPattern(prefix), "prefix %q", prefix)
+	}
+}
+
+func TestCorrelationMatches(t *testing.T) {
+	keys := []string{"customer_id", "amount"}
+
+	tests := []struct {
+		name     string
+		data     string
+		keys     []string
+		expected []string
+		ok       bool
+	}{
+		{"all keys present", `{"customer_id": "cus_1", "amount": 4950, "currency": "usd"}`, keys, []string{
+			`{"amount":4950,"customer_id":"cus_1"}`,
+			`{"data":{"object":{"amount":4950,"customer_id":"cus_1"}}}`,
+		}, true},
+		{"keys under the Stripe object", `{"id": "evt_1", "data": {"object": {"customer_id": "cus_1", "amount": 4950}}}`, keys, []string{
+			`{"amount":4950,"customer_id":"cus_1"}`,
+			`{"data":{"object":{"amount":4950,"customer_id":"cus_1"}}}`,
+		}, true},
+		{"keys split across object paths", `{"customer_id": "cus_1", "data": {"object": {"amount": 4950}}}`, keys, nil, false},
+		{"missing key", `{"customer_id": "cus_1"}`, keys, nil, false},
+		{"null key", `{"customer_id": "cus_1", "amount": null}`, keys, nil, false},
+		{"null object", `{"data": {"object": null}}`, keys, nil, false},
+		{"no keys configured", `{"customer_id": "cus_1"}`, nil, nil, false},
+		{"not an object", `[1, 2]`, keys, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, ok := correlationMatches([]byte(tt.data), tt.keys)
+			assert.Equal(t, tt.ok, ok)
+			require.Len(t, matches, len(tt.expected))
+			for i, expected := range tt.expected {
+				assert.JSONEq(t, expected, string(matches[i]))
+			}
+		})
+	}
+}
+
 // Benchmark tests for performance
 func BenchmarkToEventDomain(b *testing.B) {
 	dbEvent := createTestDBEventForBenchmark()
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_ = toEventDomain(dbEvent)
+		_ = toEventDomain(nil, dbEvent)
 	}
 }
 
@@ -595,3 +860,88 @@ func TestPaginationHelperFunctions(t *testing.T) {
 		}
 	})
 }
+
+// newUnreachablePool returns a pool whose connection attempts fail immediately. Its
+// NewConnsCount still records each attempt, which shows which pool a query went to.
+func newUnreachablePool(t *testing.T) *Pool {
+	t.Helper()
+	pool, err := pgxpool.New(context.Background(), "postgresql://u:p@127.0.0.1:1/db?connect_timeout=1")
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	return NewPool(pool, PoolOptions{})
+}
+
+func TestEventsRepository_ReadPoolRouting(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	page := models.PaginationParams{Limit: 10}
+
+	primary, replica := newUnreachablePool(t), newUnreachablePool(t)
+	repo, err := NewEventsRepository(primary, createTestLogger())
+	require.NoError(t, err)
+	repo = repo.WithReadPool(replica)
+
+	_, err = repo.ListEvents(ctx, tenantID, models.EventFilter{}, page)
+	assert.Error(t, err)
+	_, err = repo.CountAllEvents(ctx, tenantID)
+	assert.Error(t, err)
+	assert.EqualValues(t, 2, replica.Stat().NewConnsCount(), "reads should target the replica")
+	assert.Zero(t, primary.Stat().NewConnsCount(), "reads should not touch the primary")
+
+	_, err = repo.DeleteEvent(ctx, uuid.New(), tenantID)
+	assert.Error(t, err)
+	assert.EqualValues(t, 1, primary.Stat().NewConnsCount(), "writes should target the primary")
+	assert.EqualValues(t, 2, replica.Stat().NewConnsCount(), "writes should not touch the replica")
+
+	t.Run("no replica falls back to the primary", func(t *testing.T) {
+		primary := newUnreachablePool(t)
+		repo, err := NewEventsRepository(primary, createTestLogger())
+		require.NoError(t, err)
+		repo = repo.WithReadPool(nil)
+
+		_, err = repo.ListEvents(ctx, tenantID, models.EventFilter{}, page)
+		assert.Error(t, err)
+		assert.EqualValues(t, 1, primary.Stat().NewConnsCount())
+	})
+}
+
+func TestBatchChunkSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		n       int
+		policy  models.BatchPolicy
+		want    int
+		wantErr bool
+	}{
+		{"empty batch", 0, models.BatchPolicy{MaxSize: 3}, 1, false},
+		{"below the limit", 2, models.BatchPolicy{MaxSize: 3}, 2, false},
+		{"exactly the limit", 3, models.BatchPolicy{MaxSize: 3}, 3, false},
+		{"one over the limit is rejected", 4, models.BatchPolicy{MaxSize: 3}, 0, true},
+		{"one over the limit is chunked", 4, models.BatchPolicy{MaxSize: 3, Chunk: true}, 3, false},
+		{"no limit", 10000, models.BatchPolicy{}, 10000, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := batchChunkSize(tt.n, tt.policy)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrBatchTooLarge)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCreateEventsBatch_OversizeIsRejectedBeforeAnyQuery(t *testing.T) {
+	// A nil pool would panic if a transaction were opened
+	repo := EventsRepositoryImplementation{logger: createTestLogger()}
+	args := make([]models.CreateEventParams, 4)
+
+	results, err := repo.CreateEventsBatch(context.Background(), args, uuid.New(), models.BatchPolicy{MaxSize: 3})
+
+	assert.ErrorIs(t, err, ErrBatchTooLarge)
+	assert.Contains(t, err.Error(), "4 events, limit 3")
+	assert.Empty(t, results)
+}
@@ -0,0 +1,143 @@
+// Package repository provides implementations of data access patterns for domain entities.
+// idempotency_keys.go stores the responses replayed to requests that repeat an Idempotency-Key,
+// shared by every instance of the API.
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	db "rdl-api/internal/db/sqlc"
+	"rdl-api/internal/domain/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// IdempotencyKeysRepositoryImplementation keeps each tenant's idempotency keys in Postgres
+type IdempotencyKeysRepositoryImplementation struct {
+	pool   *Pool
+	logger *slog.Logger
+}
+
+// NewIdempotencyKeysRepository creates a new instance of IdempotencyKeysRepository backed by the provided Pool.
+//
+// Parameters:
+//   - pool: Pointer to Pool, which provides access to the database.
+//   - logger: Pointer to slog.Logger, which provides access to the logger.
+//
+// Returns:
+//   - IdempotencyKeysRepositoryImplementation: The idempotency keys repository.
+//   - error: Any error encountered during initialization.
+func NewIdempotencyKeysRepository(pool *Pool, l *slog.Logger) (IdempotencyKeysRepositoryImplementation, error) {
+	if pool == nil {
+		return IdempotencyKeysRepositoryImplementation{}, ErrPoolCannotBeNil
+	}
+	if l == nil {
+		return IdempotencyKeysRepositoryImplementation{}, ErrLoggerCannotBeNil
+	}
+	return IdempotencyKeysRepositoryImplementation{pool: pool, logger: l}, nil
+}
+
+// GetIdempotencyRecord returns the response stored for the tenant's key. An expired key is
+// reported as not found.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant that sent the key.
+//   - key: The Idempotency-Key header value.
+//
+// Returns:
+//   - models.IdempotencyRecord: The stored response.
+//   - error: ErrIdempotencyKeyNotFound when no unexpired response is stored, or any error encountered during retrieval.
+func (r IdempotencyKeysRepositoryImplementation) GetIdempotencyRecord(ctx context.Context, tenantID uuid.UUID, key string) (models.IdempotencyRecord, error) {
+	var row db.IdempotencyKey
+	err := WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		var err error
+		row, err = queries.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{
+			TenantID:       convertUUIDToPgtypeUUID(tenantID),
+			IdempotencyKey: key,
+		})
+		return err
+	})
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.IdempotencyRecord{}, ErrIdempotencyKeyNotFound
+	}
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to retrieve idempotency key", "error", err, "tenant_id", tenantID)
+		return models.IdempotencyRecord{}, err
+	}
+
+	var headers map[string][]string
+	if err := json.Unmarshal(row.Headers, &headers); err != nil {
+		r.logger.ErrorContext(ctx, "Failed to decode idempotency key headers", "error", err, "tenant_id", tenantID)
+		return models.IdempotencyRecord{}, fmt.Errorf("decoding idempotency key headers: %w", err)
+	}
+
+	return models.IdempotencyRecord{
+		TenantID:    convertPgtypeUUIDToUUID(row.TenantID),
+		Key:         row.IdempotencyKey,
+		Method:      row.RequestMethod,
+		Path:        row.RequestPath,
+		StatusCode:  int(row.StatusCode),
+		ContentType: row.ContentType,
+		Body:        row.Body,
+		ExpiresAt:   row.ExpiresAt.Time,
+		RequestHash: row.RequestHash,
+		Headers:     headers,
+	}, nil
+}
+
+// SaveIdempotencyRecord stores the response for the record's key, first removing the tenant's
+// expired keys so an expired key can be used again. A key that already has an unexpired
+// response keeps it.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - record: The response to store, with the tenant and key it belongs to.
+//
+// Returns:
+//   - bool: Whether the record was stored; false when the key already had a response.
+//   - error: Any error encountered while storing.
+func (r IdempotencyKeysRepositoryImplementation) SaveIdempotencyRecord(ctx context.Context, record models.IdempotencyRecord) (bool, error) {
+	headers := []byte("{}")
+	if len(record.Headers) > 0 {
+		var err error
+		if headers, err = json.Marshal(record.Headers); err != nil {
+			return false, fmt.Errorf("encoding idempotency key headers: %w", err)
+		}
+	}
+
+	var stored int64
+	err := WithTenantContext(ctx, r.pool, record.TenantID, func(queries *db.Queries) error {
+		tenantID := convertUUIDToPgtypeUUID(record.TenantID)
+		if _, err := queries.DeleteExpiredIdempotencyKeys(ctx, tenantID); err != nil {
+			return err
+		}
+		var err error
+		stored, err = queries.SaveIdempotencyKey(ctx, db.SaveIdempotencyKeyParams{
+			TenantID:       tenantID,
+			IdempotencyKey: record.Key,
+			RequestMethod:  record.Method,
+			RequestPath:    record.Path,
+			StatusCode:     int32(record.StatusCode),
+			ContentType:    record.ContentType,
+			Body:           record.Body,
+			ExpiresAt:      pgtype.Timestamptz{Time: record.ExpiresAt, Valid: true},
+			RequestHash:    record.RequestHash,
+			Headers:        headers,
+		})
+		return err
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to store idempotency key", "error", err, "tenant_id", record.TenantID)
+		return false, err
+	}
+
+	return stored > 0, nil
+}
@@ -0,0 +1,90 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"rdl-api/internal/domain/models"
+)
+
+func TestIdempotencyKeysRepository(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	otherTenantID, _ := seedTenant(t, pool)
+
+	// Two repositories on the same database stand in for two API instances
+	instanceA, err := NewIdempotencyKeysRepository(pool, createTestLogger())
+	require.NoError(t, err)
+	instanceB, err := NewIdempotencyKeysRepository(pool, createTestLogger())
+	require.NoError(t, err)
+
+	record := func(key string, body string) models.IdempotencyRecord {
+		return models.IdempotencyRecord{
+			TenantID:    tenantID,
+			Key:         key,
+			Method:      http.MethodPost,
+			Path:        "/events/batch",
+			StatusCode:  http.StatusCreated,
+			ContentType: "application/json",
+			Body:        []byte(body),
+			ExpiresAt:   time.Now().Add(time.Hour),
+		}
+	}
+
+	t.Run("key stored by one instance is honored by another", func(t *testing.T) {
+		stored, err := instanceA.SaveIdempotencyRecord(ctx, record("key-1", `{"created":1}`))
+		require.NoError(t, err)
+		assert.True(t, stored)
+
+		got, err := instanceB.GetIdempotencyRecord(ctx, tenantID, "key-1")
+		require.NoError(t, err)
+		assert.Equal(t, http.MethodPost, got.Method)
+		assert.Equal(t, "/events/batch", got.Path)
+		assert.Equal(t, http.StatusCreated, got.StatusCode)
+		assert.Equal(t, "application/json", got.ContentType)
+		assert.JSONEq(t, `{"created":1}`, string(got.Body))
+	})
+
+	t.Run("the first response stored wins", func(t *testing.T) {
+		stored, err := instanceB.SaveIdempotencyRecord(ctx, record("key-1", `{"created":2}`))
+		require.NoError(t, err)
+		assert.False(t, stored)
+
+		got, err := instanceA.GetIdempotencyRecord(ctx, tenantID, "key-1")
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"created":1}`, string(got.Body))
+	})
+
+	t.Run("other tenants do not see the key", func(t *testing.T) {
+		_, err := instanceB.GetIdempotencyRecord(ctx, otherTenantID, "key-1")
+		assert.ErrorIs(t, err, ErrIdempotencyKeyNotFound)
+	})
+
+	t.Run("an expired key is not found and can be stored again", func(t *testing.T) {
+		_, err := instanceA.SaveIdempotencyRecord(ctx, record("key-2", `{"created":1}`))
+		require.NoError(t, err)
+		seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+			_, err := tx.Exec(ctx, "UPDATE idempotency_keys SET expires_at = NOW() - INTERVAL '1 minute' WHERE tenant_id = $1 AND idempotency_key = 'key-2'", tenantID)
+			require.NoError(t, err)
+		})
+
+		_, err = instanceB.GetIdempotencyRecord(ctx, tenantID, "key-2")
+		assert.ErrorIs(t, err, ErrIdempotencyKeyNotFound)
+
+		stored, err := instanceB.SaveIdempotencyRecord(ctx, record("key-2", `{"created":3}`))
+		require.NoError(t, err)
+		assert.True(t, stored)
+		got, err := instanceA.GetIdempotencyRecord(ctx, tenantID, "key-2")
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"created":3}`, string(got.Body))
+	})
+}
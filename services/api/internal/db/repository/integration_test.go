@@ -0,0 +1,130 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+)
+
+// Integration tests run against a migrated database pointed to by POSTGRES_URL:
+//
+//	POSTGRES_URL=postgresql://... go test -tags integration ./internal/db/repository/...
+
+// newIntegrationPool connects to the integration database or skips the test when none is configured
+func newIntegrationPool(t *testing.T) *Pool {
+	t.Helper()
+
+	url := os.Getenv("POSTGRES_URL")
+	if url == "" {
+		t.Skip("POSTGRES_URL not set, skipping integration test")
+	}
+
+	pool, err := pgxpool.New(context.Background(), url)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	return NewPool(pool, PoolOptions{})
+}
+
+// seedAsServiceAccount runs fn in a committed transaction that bypasses tenant isolation,
+// so fixtures are visible to the repository under test
+func seedAsServiceAccount(t *testing.T, pool *Pool, fn func(tx pgx.Tx)) {
+	t.Helper()
+	ctx := context.Background()
+
+	tx, err := pool.Begin(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op after commit
+
+	_, err = tx.Exec(ctx, "SET LOCAL app.is_service_account = true")
+	require.NoError(t, err)
+
+	fn(tx)
+
+	require.NoError(t, tx.Commit(ctx))
+}
+
+// seedTenant creates a tenant with a single customer and removes both when the test ends
+func seedTenant(t *testing.T, pool *Pool) (tenantID uuid.UUID, customerID uuid.UUID) {
+	t.Helper()
+	ctx := context.Background()
+
+	tenantID = uuid.New()
+	customerID = uuid.New()
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "INSERT INTO tenants (id, email, name) VALUES ($1, $2, $3)",
+			tenantID, tenantID.String()+"@example.com", "integration tenant")
+		require.NoError(t, err)
+
+		_, err = tx.Exec(ctx, "INSERT INTO customers (id, tenant_id, external_id, email, name) VALUES ($1, $2, $3, $4, $5)",
+			customerID, tenantID, "cus_"+customerID.String(), customerID.String()+"@example.com", "integration customer")
+		require.NoError(t, err)
+	})
+
+	t.Cleanup(func() {
+		seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+			_, err := tx.Exec(ctx, "DELETE FROM tenants WHERE id = $1", tenantID)
+			require.NoError(t, err)
+		})
+	})
+
+	return tenantID, customerID
+}
+
+// seedLeak creates a leak of the given amount for the tenant's customer
+func seedLeak(t *testing.T, pool *Pool, tenantID uuid.UUID, customerID uuid.UUID, amount string) uuid.UUID {
+	t.Helper()
+
+	leakID := uuid.New()
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := tx.Exec(context.Background(),
+			"INSERT INTO leaks (id, tenant_id, customer_id, leak_type, amount, confidence) VALUES ($1, $2, $3, 'failed_payments', $4::DECIMAL, 90)",
+			leakID, tenantID, customerID, amount)
+		require.NoError(t, err)
+	})
+
+	return leakID
+}
+
+// seedProvider creates a provider and removes it when the test ends
+func seedProvider(t *testing.T, pool *Pool) uuid.UUID {
+	t.Helper()
+	ctx := context.Background()
+
+	providerID := uuid.New()
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "INSERT INTO providers (id, name) VALUES ($1, $2)", providerID, "integration provider")
+		require.NoError(t, err)
+	})
+
+	t.Cleanup(func() {
+		seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+			_, err := tx.Exec(ctx, "DELETE FROM providers WHERE id = $1", providerID)
+			require.NoError(t, err)
+		})
+	})
+
+	return providerID
+}
+
+// seedEvent creates a pending payment_failed event for the tenant and provider
+func seedEvent(t *testing.T, pool *Pool, tenantID uuid.UUID, providerID uuid.UUID) uuid.UUID {
+	t.Helper()
+
+	id := uuid.New()
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := tx.Exec(context.Background(),
+			"INSERT INTO events (id, tenant_id, provider_id, event_type, event_id, status, data) VALUES ($1, $2, $3, 'payment_failed', $4, 'pending', '{}')",
+			id, tenantID, providerID, "evt_"+id.String())
+		require.NoError(t, err)
+	})
+
+	return id
+}
@@ -0,0 +1,1041 @@
+// Package repository provides implementations of data access patterns for domain entities.
+// leaks.go provides create, read and update operations for leaks and conversions between sqlc-generated leak rows and the domain Leak model.
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	db "rdl-api/internal/db/sqlc"
+	"rdl-api/internal/domain/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// LeaksRepositoryImplementation implements the LeaksRepository interface using sqlc-generated queries.
+type LeaksRepositoryImplementation struct {
+	pool *Pool
+	// readPool serves the read-heavy methods; nil means they use pool
+	readPool *Pool
+	logger   *slog.Logger
+}
+
+// NewLeaksRepository creates a new instance of LeaksRepository backed by the provided Pool.
+//
+// Parameters:
+//   - pool: Pointer to Pool, which provides access to the database.
+//   - logger: Pointer to slog.Logger, which provides access to the logger.
+//
+// Returns:
+//   - LeaksRepository: An implementation of the LeaksRepository interface.
+//   - error: Any error encountered during initialization.
+func NewLeaksRepository(pool *Pool, l *slog.Logger) (LeaksRepositoryImplementation, error) {
+	if pool == nil {
+		return LeaksRepositoryImplementation{}, ErrPoolCannotBeNil
+	}
+	if l == nil {
+		return LeaksRepositoryImplementation{}, ErrLoggerCannotBeNil
+	}
+	return LeaksRepositoryImplementation{pool: pool, logger: l}, nil
+}
+
+// WithReadPool returns a copy of the repository whose listing, counting and export reads use
+// readPool, typically a replica. Writes and the settings leak detection reads stay on the
+// primary. A nil readPool keeps every query on the primary.
+func (r LeaksRepositoryImplementation) WithReadPool(readPool *Pool) LeaksRepositoryImplementation {
+	r.readPool = readPool
+	return r
+}
+
+// reader returns the pool for read queries
+func (r LeaksRepositoryImplementation) reader() *Pool {
+	if r.readPool != nil {
+		return r.readPool
+	}
+	return r.pool
+}
+
+// GetLeakByID retrieves a leak by its UUID.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - id: UUID of the leak to retrieve.
+//   - tenantID: UUID of the tenant that owns the leak.
+//
+// Returns:
+//   - models.Leak: The leak as a domain model.
+//   - error: ErrLeakNotFound if the leak does not exist, or any other error encountered during retrieval.
+func (r LeaksRepositoryImplementation) GetLeakByID(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (models.Leak, error) {
+	r.logger.DebugContext(ctx, "Retrieving leak by ID", "leak_id", id, "tenant_id", tenantID)
+
+	var leak models.Leak
+	err := WithTenantReadContext(ctx, r.reader(), tenantID, func(queries *db.Queries) error {
+		dbLeak, err := queries.GetLeakByID(ctx, convertUUIDToPgtypeUUID(id))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrLeakNotFound
+			}
+			return err
+		}
+
+		leak = toLeakDomain(r.pool.unknownEnums(), dbLeak)
+		return nil
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrLeakNotFound) {
+			r.logger.WarnContext(ctx, "Leak not found", "leak_id", id, "tenant_id", tenantID)
+		} else {
+			r.logger.ErrorContext(ctx, "Failed to retrieve leak by ID", "error", err, "leak_id", id, "tenant_id", tenantID)
+		}
+		return models.Leak{}, err
+	}
+
+	return leak, nil
+}
+
+// CreateLeak persists a new leak. A uuid.Nil CustomerID stores a tenant-wide leak with no customer.
+// A source event has at most one leak of each type, so a second one is not stored.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - arg: CreateLeakParams containing the leak details as a domain model.
+//   - tenantID: UUID of the tenant that owns the leak.
+//
+// Returns:
+//   - models.Leak: The created leak as a domain model.
+//   - error: ErrLeakAlreadyExists if arg.SourceEventID already has a leak of arg.LeakType, or any
+//     other error encountered during creation.
+func (r LeaksRepositoryImplementation) CreateLeak(ctx context.Context, arg models.CreateLeakParams, tenantID uuid.UUID) (models.Leak, error) {
+	r.logger.DebugContext(ctx, "Creating leak", "leak_type", arg.LeakType, "customer_id", arg.CustomerID, "tenant_id", tenantID)
+
+	var leak models.Leak
+	err := WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		dbLeak, err := queries.CreateLeak(ctx, toCreateLeakDBParams(arg, tenantID))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrLeakAlreadyExists
+			}
+			return err
+		}
+
+		leak = toLeakDomain(r.pool.unknownEnums(), dbLeak)
+		return nil
+	})
+
+	if errors.Is(err, ErrLeakAlreadyExists) {
+		r.logger.DebugContext(ctx, "Leak already exists for source event", "leak_type", arg.LeakType, "source_event_id", arg.SourceEventID, "tenant_id", tenantID)
+		return models.Leak{}, err
+	}
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to create leak", "error", err, "leak_type", arg.LeakType, "tenant_id", tenantID)
+		return models.Leak{}, err
+	}
+
+	r.logger.InfoContext(ctx, "Leak created", "leak_id", leak.ID, "leak_type", leak.LeakType, "tenant_id", tenantID)
+	return leak, nil
+}
+
+// UpsertLeakByDedupKey stores a leak, or counts another occurrence of the tenant's open leak
+// with the same dedup key and currency: its amount grows by arg.Amount, it keeps the higher
+// confidence and its Occurrences goes up by one. A detection in another currency than the open
+// leak's gets a leak of its own, since the amounts cannot be added. A leak returned with one
+// occurrence is new. arg.SourceEventID,
+// when set, is linked to the leak either way, so the events behind every occurrence stay known.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - arg: CreateLeakParams containing the leak details as a domain model; DedupKey is required.
+//   - tenantID: UUID of the tenant that owns the leak.
+//
+// Returns:
+//   - models.Leak: The new or updated leak as a domain model.
+//   - error: ErrMissingDedupKey if arg has no DedupKey, or any other error encountered during storage.
+func (r LeaksRepositoryImplementation) UpsertLeakByDedupKey(ctx context.Context, arg models.CreateLeakParams, tenantID uuid.UUID) (models.Leak, error) {
+	if arg.DedupKey == "" {
+		return models.Leak{}, ErrMissingDedupKey
+	}
+	r.logger.DebugContext(ctx, "Upserting leak", "leak_type", arg.LeakType, "dedup_key", arg.DedupKey, "tenant_id", tenantID)
+
+	params := toCreateLeakDBParams(arg, tenantID)
+	var leak models.Leak
+	err := WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		dbLeak, err := queries.UpsertLeakByDedupKey(ctx, db.UpsertLeakByDedupKeyParams{
+			TenantID:      params.TenantID,
+			CustomerID:    params.CustomerID,
+			LeakType:      params.LeakType,
+			Amount:        params.Amount,
+			Confidence:    params.Confidence,
+			Status:        params.Status,
+			Currency:      params.Currency,
+			SourceEventID: params.SourceEventID,
+			DetectedAt:    params.DetectedAt,
+			Metadata:      params.Metadata,
+			DedupKey:      arg.DedupKey,
+		})
+		if err != nil {
+			return err
+		}
+		if params.SourceEventID.Valid {
+			err = queries.LinkLeakEvent(ctx, db.LinkLeakEventParams{
+				LeakID:   dbLeak.ID,
+				EventID:  params.SourceEventID,
+				TenantID: params.TenantID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		leak = toLeakDomain(r.pool.unknownEnums(), dbLeak)
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to upsert leak", "error", err, "leak_type", arg.LeakType, "dedup_key", arg.DedupKey, "tenant_id", tenantID)
+		return models.Leak{}, err
+	}
+
+	if leak.Occurrences > 1 {
+		r.logger.InfoContext(ctx, "Leak occurred again", "leak_id", leak.ID, "leak_type", leak.LeakType, "occurrences", leak.Occurrences, "tenant_id", tenantID)
+	} else {
+		r.logger.InfoContext(ctx, "Leak created", "leak_id", leak.ID, "leak_type", leak.LeakType, "tenant_id", tenantID)
+	}
+	return leak, nil
+}
+
+// UpdateLeak updates an existing leak; fields left nil in arg are unchanged.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - arg: UpdateLeakParams containing the fields to update.
+//   - tenantID: UUID of the tenant that owns the leak.
+//
+// Returns:
+//   - models.Leak: The updated leak as a domain model.
+//   - error: ErrLeakNotFound if the leak does not exist, or any other error encountered during update.
+func (r LeaksRepositoryImplementation) UpdateLeak(ctx context.Context, arg models.UpdateLeakParams, tenantID uuid.UUID) (models.Leak, error) {
+	r.logger.DebugContext(ctx, "Updating leak", "leak_id", arg.ID, "tenant_id", tenantID)
+
+	params, err := toUpdateLeakDBParams(arg)
+	if err != nil {
+		return models.Leak{}, err
+	}
+
+	var leak models.Leak
+	err = WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		dbLeak, err := queries.UpdateLeak(ctx, params)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrLeakNotFound
+			}
+			return err
+		}
+
+		leak = toLeakDomain(r.pool.unknownEnums(), dbLeak)
+		return nil
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrLeakNotFound) {
+			r.logger.WarnContext(ctx, "Leak not found for update", "leak_id", arg.ID, "tenant_id", tenantID)
+		} else {
+			r.logger.ErrorContext(ctx, "Failed to update leak", "error", err, "leak_id", arg.ID, "tenant_id", tenantID)
+		}
+		return models.Leak{}, err
+	}
+
+	r.logger.InfoContext(ctx, "Leak updated", "leak_id", leak.ID, "status", leak.Status, "tenant_id", tenantID)
+	return leak, nil
+}
+
+// GetMinLeakAmounts returns the tenant's own minimum leak amounts, keyed by upper-case currency code.
+// A tenant without overrides, or one the query cannot see, has an empty map.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant whose thresholds to read.
+//
+// Returns:
+//   - map[string]models.Decimal: The thresholds by currency.
+//   - error: Any error encountered during retrieval, or models.ErrInvalidMinLeakAmount if a stored threshold is malformed.
+func (r LeaksRepositoryImplementation) GetMinLeakAmounts(ctx context.Context, tenantID uuid.UUID) (map[string]models.Decimal, error) {
+	var amounts map[string]models.Decimal
+	err := WithTenantReadContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		raw, err := queries.GetTenantMinLeakAmounts(ctx, convertUUIDToPgtypeUUID(tenantID))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil
+			}
+			return err
+		}
+
+		amounts, err = toMinLeakAmountsDomain(raw)
+		return err
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to retrieve minimum leak amounts", "error", err, "tenant_id", tenantID)
+		return nil, err
+	}
+	if amounts == nil {
+		amounts = map[string]models.Decimal{}
+	}
+	return amounts, nil
+}
+
+// GetLeakSources returns the tenant's own leak sources, the event types feeding each leak type
+// it overrides. A tenant without overrides, or one the query cannot see, has an empty map.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant whose leak sources to read.
+//
+// Returns:
+//   - models.LeakSources: The tenant's leak sources by leak type.
+//   - error: Any error encountered during retrieval, or models.ErrInvalidLeakSources if the stored sources are malformed.
+func (r LeaksRepositoryImplementation) GetLeakSources(ctx context.Context, tenantID uuid.UUID) (models.LeakSources, error) {
+	var sources models.LeakSources
+	err := WithTenantReadContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		raw, err := queries.GetTenantLeakSources(ctx, convertUUIDToPgtypeUUID(tenantID))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil
+			}
+			return err
+		}
+
+		sources, err = toLeakSourcesDomain(raw)
+		return err
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to retrieve leak sources", "error", err, "tenant_id", tenantID)
+		return nil, err
+	}
+	if sources == nil {
+		sources = models.LeakSources{}
+	}
+	return sources, nil
+}
+
+// ListTenantIDs returns the ID of every tenant, for jobs that run per tenant.
+// The tenants table is not tenant-scoped, so this runs without a tenant context.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//
+// Returns:
+//   - []uuid.UUID: The tenant IDs, in order.
+//   - error: Any error encountered during retrieval.
+func (r LeaksRepositoryImplementation) ListTenantIDs(ctx context.Context) ([]uuid.UUID, error) {
+	conn, err := r.reader().acquire(ctx)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list tenants", "error", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := db.New(conn).ListTenantIDs(ctx)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list tenants", "error", err)
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, len(rows))
+	for i, row := range rows {
+		ids[i] = convertPgtypeUUIDToUUID(row)
+	}
+	return ids, nil
+}
+
+// TryLockTenantDetection takes the tenant's detection lock, a Postgres advisory lock shared by
+// every instance, so that only one leak detection run stores leaks for the tenant at a time.
+// The lock holds a connection until unlock is called. When another run holds it, TryLockTenantDetection
+// returns at once with locked false.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant to lock.
+//
+// Returns:
+//   - func(): Releases the lock and its connection; nil unless locked.
+//   - bool: Whether the lock was taken.
+//   - error: Any error encountered while taking the lock.
+func (r LeaksRepositoryImplementation) TryLockTenantDetection(ctx context.Context, tenantID uuid.UUID) (unlock func(), locked bool, err error) {
+	conn, err := r.pool.acquire(ctx)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to take tenant detection lock", "error", err, "tenant_id", tenantID)
+		return nil, false, err
+	}
+
+	pgTenantID := convertUUIDToPgtypeUUID(tenantID)
+	locked, err = db.New(conn).TryLockTenantDetection(ctx, pgTenantID)
+	if err != nil || !locked {
+		conn.Release()
+		if err != nil {
+			r.logger.ErrorContext(ctx, "Failed to take tenant detection lock", "error", err, "tenant_id", tenantID)
+		}
+		return nil, false, err
+	}
+
+	return func() {
+		// The lock belongs to the session, so it is released even when ctx is already done
+		unlockCtx := context.WithoutCancel(ctx)
+		if _, err := db.New(conn).UnlockTenantDetection(unlockCtx, pgTenantID); err != nil {
+			// Closing the session is the other way to release its lock
+			r.logger.WarnContext(ctx, "Failed to release tenant detection lock, closing its connection", "error", err, "tenant_id", tenantID)
+			_ = conn.Conn().Close(unlockCtx)
+		}
+		conn.Release()
+	}, true, nil
+}
+
+// ListLeaks returns a page of the tenant's leaks matching filter, largest amount first.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant whose leaks to list.
+//   - filter: The statuses, leak types and detection range to match.
+//   - params: The page to return.
+//
+// Returns:
+//   - models.PaginatedResponse[models.Leak]: The page with the total number of matching leaks.
+//   - error: Any error encountered during retrieval.
+func (r LeaksRepositoryImplementation) ListLeaks(ctx context.Context, tenantID uuid.UUID, filter models.LeakFilter, params models.PaginationParams) (models.PaginatedResponse[models.Leak], error) {
+	r.logger.DebugContext(ctx, "Listing leaks", "tenant_id", tenantID, "statuses", filter.Statuses, "leak_types", filter.LeakTypes, "limit", params.Limit, "offset", params.Offset)
+
+	args := toLeakFilterDBArgs(filter)
+
+	var leaks []models.Leak
+	var totalCount int64
+	err := WithTenantReadContext(ctx, r.reader(), tenantID, func(queries *db.Queries) error {
+		count, err := queries.CountLeaksByFilter(ctx, db.CountLeaksByFilterParams{
+			Statuses:       args.statuses,
+			LeakTypes:      args.leakTypes,
+			DetectedFrom:   args.detectedFrom,
+			DetectedTo:     args.detectedTo,
+			ExcludeSnoozed: args.excludeSnoozed,
+		})
+		if err != nil {
+			return err
+		}
+		totalCount = count
+
+		dbLeaks, err := queries.ListLeaksByFilter(ctx, db.ListLeaksByFilterParams{
+			Statuses:       args.statuses,
+			LeakTypes:      args.leakTypes,
+			DetectedFrom:   args.detectedFrom,
+			DetectedTo:     args.detectedTo,
+			ExcludeSnoozed: args.excludeSnoozed,
+			Limit:          params.Limit,
+			Offset:         params.Offset,
+		})
+		if err != nil {
+			return err
+		}
+
+		leaks = make([]models.Leak, 0, len(dbLeaks))
+		for _, dbLeak := range dbLeaks {
+			leaks = append(leaks, toLeakDomain(r.pool.unknownEnums(), dbLeak))
+		}
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list leaks", "error", err, "tenant_id", tenantID)
+		return models.PaginatedResponse[models.Leak]{}, err
+	}
+
+	return models.NewPaginatedResponse(leaks, totalCount, params.Limit, params.Offset), nil
+}
+
+// ListLeaksAfter returns up to limit of the tenant's leaks matching filter that come after the
+// cursor in detection order, oldest first, with id breaking ties. Passing the last leak of one
+// page as the cursor of the next streams every matching leak without the cost of deep offsets.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant whose leaks to list.
+//   - filter: The statuses, leak types and detection range to match.
+//   - after: The position to continue after; the zero cursor starts from the first leak.
+//   - limit: The most leaks to return.
+//
+// Returns:
+//   - []models.Leak: The leaks of the page; fewer than limit means there are no more.
+//   - error: Any error encountered during retrieval.
+func (r LeaksRepositoryImplementation) ListLeaksAfter(ctx context.Context, tenantID uuid.UUID, filter models.LeakFilter, after models.LeakCursor, limit int32) ([]models.Leak, error) {
+	args := toLeakFilterDBArgs(filter)
+	afterDetectedAt := pgtype.Timestamptz{Time: after.DetectedAt, Valid: true}
+	if after == (models.LeakCursor{}) {
+		afterDetectedAt = pgtype.Timestamptz{InfinityModifier: pgtype.NegativeInfinity, Valid: true}
+	}
+
+	var leaks []models.Leak
+	err := WithTenantReadContext(ctx, r.reader(), tenantID, func(queries *db.Queries) error {
+		dbLeaks, err := queries.ListLeaksByFilterAfter(ctx, db.ListLeaksByFilterAfterParams{
+			Statuses:        args.statuses,
+			LeakTypes:       args.leakTypes,
+			DetectedFrom:    args.detectedFrom,
+			DetectedTo:      args.detectedTo,
+			ExcludeSnoozed:  args.excludeSnoozed,
+			AfterDetectedAt: afterDetectedAt,
+			AfterID:         convertUUIDToPgtypeUUID(after.ID),
+			MaxRows:         limit,
+		})
+		if err != nil {
+			return err
+		}
+
+		leaks = make([]models.Leak, 0, len(dbLeaks))
+		for _, dbLeak := range dbLeaks {
+			leaks = append(leaks, toLeakDomain(r.pool.unknownEnums(), dbLeak))
+		}
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list leaks after cursor", "error", err, "tenant_id", tenantID, "after_id", after.ID)
+		return nil, err
+	}
+	return leaks, nil
+}
+
+// FindExistingLeaks returns the tenant's leaks detected exactly at detectedAt, triggered by any
+// of sourceEventIDs or carrying any of dedupKeys, whatever their status. A backfill uses them to
+// tell which of its candidates are already stored.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant whose leaks to search.
+//   - detectedAt: The detection time to match.
+//   - sourceEventIDs: UUIDs of the triggering events to match; may be empty.
+//   - dedupKeys: Dedup keys to match; may be empty.
+//
+// Returns:
+//   - []models.Leak: The matching leaks, in no particular order.
+//   - error: Any error encountered during retrieval.
+func (r LeaksRepositoryImplementation) FindExistingLeaks(ctx context.Context, tenantID uuid.UUID, detectedAt time.Time, sourceEventIDs []uuid.UUID, dedupKeys []string) ([]models.Leak, error) {
+	r.logger.DebugContext(ctx, "Finding existing leaks", "tenant_id", tenantID, "detected_at", detectedAt, "source_events", len(sourceEventIDs), "dedup_keys", len(dedupKeys))
+
+	pgIDs := make([]pgtype.UUID, 0, len(sourceEventIDs))
+	for _, id := range sourceEventIDs {
+		pgIDs = append(pgIDs, convertUUIDToPgtypeUUID(id))
+	}
+
+	var leaks []models.Leak
+	err := WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		dbLeaks, err := queries.FindExistingLeaks(ctx, db.FindExistingLeaksParams{
+			DetectedAt:     pgtype.Timestamptz{Time: detectedAt, Valid: true},
+			SourceEventIds: pgIDs,
+			DedupKeys:      append([]string{}, dedupKeys...),
+		})
+		if err != nil {
+			return err
+		}
+
+		leaks = make([]models.Leak, 0, len(dbLeaks))
+		for _, dbLeak := range dbLeaks {
+			leaks = append(leaks, toLeakDomain(r.pool.unknownEnums(), dbLeak))
+		}
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to find existing leaks", "error", err, "tenant_id", tenantID)
+		return nil, err
+	}
+
+	return leaks, nil
+}
+
+// GetLeakCountInWindow counts the tenant's leaks detected in the half-open window [from, to),
+// whatever their status or type.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant whose leaks to count.
+//   - from: Start of the window, inclusive.
+//   - to: End of the window, exclusive.
+//
+// Returns:
+//   - int64: Number of leaks detected in the window.
+//   - error: Any error encountered during counting.
+func (r LeaksRepositoryImplementation) GetLeakCountInWindow(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) (int64, error) {
+	r.logger.DebugContext(ctx, "Counting leaks in window", "tenant_id", tenantID, "from", from, "to", to)
+
+	args := toLeakFilterDBArgs(models.LeakFilter{DetectedFrom: &from, DetectedTo: &to})
+
+	var count int64
+	err := WithTenantReadContext(ctx, r.reader(), tenantID, func(queries *db.Queries) error {
+		c, err := queries.CountLeaksByFilter(ctx, db.CountLeaksByFilterParams{
+			Statuses:     args.statuses,
+			LeakTypes:    args.leakTypes,
+			DetectedFrom: args.detectedFrom,
+			DetectedTo:   args.detectedTo,
+		})
+		if err != nil {
+			return err
+		}
+		count = c
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to count leaks in window", "error", err, "tenant_id", tenantID)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// SnoozeLeak hides a leak from the open counts and listings until the given time. Snoozing
+// again replaces the earlier time; once it passes the leak reappears without further updates.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - id: UUID of the leak to snooze.
+//   - until: Time at which the snooze ends.
+//   - tenantID: UUID of the tenant that owns the leak.
+//
+// Returns:
+//   - models.Leak: The snoozed leak as a domain model.
+//   - error: ErrLeakNotFound if the leak does not exist, or any other error encountered during update.
+func (r LeaksRepositoryImplementation) SnoozeLeak(ctx context.Context, id uuid.UUID, until time.Time, tenantID uuid.UUID) (models.Leak, error) {
+	r.logger.DebugContext(ctx, "Snoozing leak", "leak_id", id, "snoozed_until", until, "tenant_id", tenantID)
+
+	var leak models.Leak
+	err := WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		dbLeak, err := queries.SnoozeLeak(ctx, db.SnoozeLeakParams{
+			SnoozedUntil: convertTimePtrToPgtypeTimestamptz(&until),
+			ID:           convertUUIDToPgtypeUUID(id),
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrLeakNotFound
+			}
+			return err
+		}
+
+		leak = toLeakDomain(r.pool.unknownEnums(), dbLeak)
+		return nil
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrLeakNotFound) {
+			r.logger.WarnContext(ctx, "Leak not found for snooze", "leak_id", id, "tenant_id", tenantID)
+		} else {
+			r.logger.ErrorContext(ctx, "Failed to snooze leak", "error", err, "leak_id", id, "tenant_id", tenantID)
+		}
+		return models.Leak{}, err
+	}
+
+	r.logger.InfoContext(ctx, "Leak snoozed", "leak_id", leak.ID, "snoozed_until", until, "tenant_id", tenantID)
+	return leak, nil
+}
+
+// GetOpenLeakCount counts the tenant's open leaks. A leak snoozed until a time still in the
+// future is not counted.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant whose leaks to count.
+//
+// Returns:
+//   - int64: Number of open leaks that are not snoozed.
+//   - error: Any error encountered during counting.
+func (r LeaksRepositoryImplementation) GetOpenLeakCount(ctx context.Context, tenantID uuid.UUID) (int64, error) {
+	var count int64
+	err := WithTenantReadContext(ctx, r.reader(), tenantID, func(queries *db.Queries) error {
+		c, err := queries.CountOpenLeaks(ctx)
+		if err != nil {
+			return err
+		}
+		count = c
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to count open leaks", "error", err, "tenant_id", tenantID)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetAffectedCustomerCount counts the distinct customers among the tenant's open leaks detected
+// in the half-open window [from, to), snoozed or not. A customer with several leaks is counted
+// once, and leaks without a customer are left out.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant whose leaks to count customers of.
+//   - from: Earliest detection time of the leaks to include, inclusive.
+//   - to: Latest detection time of the leaks to include, exclusive.
+//
+// Returns:
+//   - int64: Number of distinct customers with an open leak.
+//   - error: Any error encountered during counting.
+func (r LeaksRepositoryImplementation) GetAffectedCustomerCount(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) (int64, error) {
+	var count int64
+	err := WithTenantReadContext(ctx, r.reader(), tenantID, func(queries *db.Queries) error {
+		c, err := queries.CountAffectedCustomers(ctx, db.CountAffectedCustomersParams{
+			Since: pgtype.Timestamptz{Time: from, Valid: true},
+			To:    pgtype.Timestamptz{Time: to, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		count = c
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to count affected customers", "error", err, "tenant_id", tenantID)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetLeakMTTR computes the tenant's mean time to recovery: the average time from detection to
+// resolution of the leaks resolved in the half-open window [from, to). Open and ignored leaks
+// are left out.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant whose leaks to measure.
+//   - from: Earliest resolution time of the leaks to include, inclusive.
+//   - to: Latest resolution time of the leaks to include, exclusive.
+//
+// Returns:
+//   - time.Duration: Mean time to recovery, to the second; 0 when no leak was resolved.
+//   - int: Number of resolved leaks the mean is over.
+//   - error: Any error encountered during computation.
+func (r LeaksRepositoryImplementation) GetLeakMTTR(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) (time.Duration, int, error) {
+	var row db.GetLeakMTTRRow
+	err := WithTenantReadContext(ctx, r.reader(), tenantID, func(queries *db.Queries) error {
+		var err error
+		row, err = queries.GetLeakMTTR(ctx, db.GetLeakMTTRParams{
+			Since: pgtype.Timestamptz{Time: from, Valid: true},
+			To:    pgtype.Timestamptz{Time: to, Valid: true},
+		})
+		return err
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to compute leak MTTR", "error", err, "tenant_id", tenantID)
+		return 0, 0, err
+	}
+
+	mttr := time.Duration(row.MeanSeconds * float64(time.Second)).Round(time.Second)
+	return mttr, int(row.ResolvedCount), nil
+}
+
+// GetRevenueRecovered sums, by currency, the amounts of the tenant's leaks that remediation
+// recovered in the half-open window [from, to): leaks with a successful action whose customer
+// then made a payment in the window.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant whose leaks to sum.
+//   - from: Earliest time of the recovering payment, inclusive.
+//   - to: Latest time of the recovering payment, exclusive.
+//
+// Returns:
+//   - map[string]models.Decimal: Amount recovered, keyed by currency; empty when nothing was.
+//   - error: Any error encountered during computation.
+func (r LeaksRepositoryImplementation) GetRevenueRecovered(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) (map[string]models.Decimal, error) {
+	var rows []db.GetRevenueRecoveredRow
+	err := WithTenantReadContext(ctx, r.reader(), tenantID, func(queries *db.Queries) error {
+		var err error
+		rows, err = queries.GetRevenueRecovered(ctx, db.GetRevenueRecoveredParams{
+			Since: pgtype.Timestamptz{Time: from, Valid: true},
+			To:    pgtype.Timestamptz{Time: to, Valid: true},
+		})
+		return err
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to compute revenue recovered", "error", err, "tenant_id", tenantID)
+		return nil, err
+	}
+
+	recovered := make(map[string]models.Decimal, len(rows))
+	for _, row := range rows {
+		recovered[row.Currency] = convertPgtypeNumericToDecimal(row.Recovered)
+	}
+	return recovered, nil
+}
+
+// GetLeakAmountHistogram counts and sums the tenant's open, unsnoozed leaks by amount, in
+// buckets split at the given bounds, for each currency separately since amounts in different
+// currencies cannot be compared. Every currency with an open leak gets all len(bounds)+1
+// buckets, empty ones included.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant whose leaks to count.
+//   - bounds: Bucket bounds, greater than 0 and strictly ascending.
+//   - since: Earliest detection time of the leaks counted; the zero time counts them all.
+//
+// Returns:
+//   - []models.LeakAmountBucket: The buckets ordered by currency and then amount.
+//   - error: Any error encountered during computation.
+func (r LeaksRepositoryImplementation) GetLeakAmountHistogram(ctx context.Context, tenantID uuid.UUID, bounds []models.Decimal, since time.Time) ([]models.LeakAmountBucket, error) {
+	arg := db.GetLeakAmountHistogramParams{Bounds: make([]pgtype.Numeric, 0, len(bounds))}
+	for _, bound := range bounds {
+		arg.Bounds = append(arg.Bounds, convertDecimalToPgtypeNumeric(bound))
+	}
+	if !since.IsZero() {
+		arg.Since = pgtype.Timestamptz{Time: since, Valid: true}
+	}
+
+	var rows []db.GetLeakAmountHistogramRow
+	err := WithTenantReadContext(ctx, r.reader(), tenantID, func(queries *db.Queries) error {
+		var err error
+		rows, err = queries.GetLeakAmountHistogram(ctx, arg)
+		return err
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to compute leak amount histogram", "error", err, "tenant_id", tenantID)
+		return nil, err
+	}
+	return toLeakAmountHistogramDomain(bounds, rows), nil
+}
+
+// toLeakAmountHistogramDomain spreads the non-empty buckets returned by the query over the full
+// set of buckets of each currency. Rows must be ordered by currency.
+func toLeakAmountHistogramDomain(bounds []models.Decimal, rows []db.GetLeakAmountHistogramRow) []models.LeakAmountBucket {
+	var buckets []models.LeakAmountBucket
+	start := 0
+	for _, row := range rows {
+		if len(buckets) == 0 || buckets[start].Currency != row.Currency {
+			start = len(buckets)
+			for i := 0; i <= len(bounds); i++ {
+				bucket := models.LeakAmountBucket{Currency: row.Currency}
+				if i > 0 {
+					bucket.Lower = &bounds[i-1]
+				}
+				if i < len(bounds) {
+					bucket.Upper = &bounds[i]
+				}
+				buckets = append(buckets, bucket)
+			}
+		}
+		if row.Bucket < 0 || int(row.Bucket) > len(bounds) {
+			continue
+		}
+		bucket := &buckets[start+int(row.Bucket)]
+		bucket.Count = row.Leaks
+		bucket.Total = convertPgtypeNumericToDecimal(row.Total)
+	}
+	return buckets
+}
+
+// GetLeakTimeSeries counts and sums the tenant's leaks detected in [from, to) per bucket of
+// the given interval in UTC, the first bucket starting where from truncates to. Every bucket
+// up to to gets a point, those without leaks a zero one, so a chart of them needs no gap filling.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant whose leaks to count.
+//   - interval: Bucket width, one of the models.LeakTimeSeries intervals.
+//   - from: Earliest detection time of the leaks counted.
+//   - to: Detection time the leaks counted are before.
+//
+// Returns:
+//   - []models.LeakTimeSeriesPoint: One point per bucket, in time order.
+//   - error: Any error encountered during computation.
+func (r LeaksRepositoryImplementation) GetLeakTimeSeries(ctx context.Context, tenantID uuid.UUID, interval models.LeakTimeSeriesInterval, from time.Time, to time.Time) ([]models.LeakTimeSeriesPoint, error) {
+	arg := db.GetLeakTimeSeriesParams{
+		BucketInterval: string(interval),
+		FromTime:       pgtype.Timestamptz{Time: from, Valid: true},
+		ToTime:         pgtype.Timestamptz{Time: to, Valid: true},
+	}
+
+	var rows []db.GetLeakTimeSeriesRow
+	err := WithTenantReadContext(ctx, r.reader(), tenantID, func(queries *db.Queries) error {
+		var err error
+		rows, err = queries.GetLeakTimeSeries(ctx, arg)
+		return err
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to compute leak time series", "error", err, "tenant_id", tenantID, "interval", interval)
+		return nil, err
+	}
+	return toLeakTimeSeriesDomain(rows), nil
+}
+
+// toLeakTimeSeriesDomain folds the rows of each bucket, one per currency or a single one with
+// a NULL currency when the bucket is empty, into one point. Rows must be ordered by bucket.
+func toLeakTimeSeriesDomain(rows []db.GetLeakTimeSeriesRow) []models.LeakTimeSeriesPoint {
+	var points []models.LeakTimeSeriesPoint
+	for _, row := range rows {
+		start := row.BucketStart.Time.UTC()
+		if len(points) == 0 || !points[len(points)-1].Start.Equal(start) {
+			points = append(points, models.LeakTimeSeriesPoint{Start: start, Totals: map[string]models.Decimal{}})
+		}
+		if !row.Currency.Valid {
+			continue
+		}
+		point := &points[len(points)-1]
+		point.Count += row.Leaks
+		point.Totals[row.Currency.String] = convertPgtypeNumericToDecimal(row.Total)
+	}
+	return points
+}
+
+// leakFilterDBArgs holds a filter as the parameters the filter queries compare against
+type leakFilterDBArgs struct {
+	statuses       []string
+	leakTypes      []string
+	detectedFrom   pgtype.Timestamptz
+	detectedTo     pgtype.Timestamptz
+	excludeSnoozed bool
+}
+
+// toLeakFilterDBArgs converts a filter to the parameters the filter queries compare against.
+// Empty fields become empty, non-nil arrays and NULL bounds so the queries match everything.
+func toLeakFilterDBArgs(filter models.LeakFilter) leakFilterDBArgs {
+	args := leakFilterDBArgs{
+		statuses:       make([]string, 0, len(filter.Statuses)),
+		leakTypes:      make([]string, 0, len(filter.LeakTypes)),
+		detectedFrom:   convertTimePtrToPgtypeTimestamptz(filter.DetectedFrom),
+		detectedTo:     convertTimePtrToPgtypeTimestamptz(filter.DetectedTo),
+		excludeSnoozed: filter.ExcludeSnoozed,
+	}
+	for _, s := range filter.Statuses {
+		args.statuses = append(args.statuses, string(s))
+	}
+	for _, t := range filter.LeakTypes {
+		args.leakTypes = append(args.leakTypes, string(t))
+	}
+	return args
+}
+
+// toLeakDomain converts SQLC Leak to domain Leak.
+// An invalid amount converts to 0 and a NULL customer to uuid.Nil.
+func toLeakDomain(enums *unknownEnums, dbLeak db.Leak) models.Leak {
+	return models.Leak{
+		ID:            convertPgtypeUUIDToUUID(dbLeak.ID),
+		TenantID:      convertPgtypeUUIDToUUID(dbLeak.TenantID),
+		CustomerID:    convertPgtypeUUIDToUUID(dbLeak.CustomerID),
+		LeakType:      toDomainEnum(enums, "leak_type", string(dbLeak.LeakType), leakTypes),
+		Status:        toDomainEnum(enums, "leak_status", string(dbLeak.Status), leakStatuses),
+		Amount:        convertPgtypeNumericToDecimal(dbLeak.Amount),
+		Currency:      dbLeak.Currency,
+		Confidence:    dbLeak.Confidence,
+		SourceEventID: convertPgtypeUUIDToUUIDPtr(dbLeak.SourceEventID),
+		Metadata:      dbLeak.Metadata,
+		DetectedAt:    dbLeak.DetectedAt.Time,
+		ResolvedAt:    convertPgtypeTimestamptzToTimePtr(dbLeak.ResolvedAt),
+		SnoozedUntil:  convertPgtypeTimestamptzToTimePtr(dbLeak.SnoozedUntil),
+		DedupKey:      convertPgtypeTextToStringPtr(dbLeak.DedupKey),
+		Occurrences:   dbLeak.Occurrences,
+		CreatedAt:     dbLeak.CreatedAt.Time,
+		UpdatedAt:     dbLeak.UpdatedAt.Time,
+	}
+}
+
+// toCreateLeakDBParams converts a domain CreateLeakParams to a db.CreateLeakParams for persistence,
+// applying the defaults documented on models.CreateLeakParams. A zero DetectedAt is sent as NULL so
+// the database stamps its own time, and an empty DedupKey is sent as NULL.
+func toCreateLeakDBParams(arg models.CreateLeakParams, tenantID uuid.UUID) db.CreateLeakParams {
+	customerID := pgtype.UUID{}
+	if arg.CustomerID != uuid.Nil {
+		customerID = convertUUIDToPgtypeUUID(arg.CustomerID)
+	}
+	status := arg.Status
+	if status == "" {
+		status = models.LeakStatusEnumOpen
+	}
+	currency := arg.Currency
+	if currency == "" {
+		currency = models.DefaultLeakCurrency
+	}
+	metadata := arg.Metadata
+	if len(metadata) == 0 {
+		metadata = json.RawMessage(`{}`)
+	}
+	detectedAt := pgtype.Timestamptz{}
+	if !arg.DetectedAt.IsZero() {
+		detectedAt = pgtype.Timestamptz{Time: arg.DetectedAt, Valid: true}
+	}
+
+	return db.CreateLeakParams{
+		TenantID:      convertUUIDToPgtypeUUID(tenantID),
+		CustomerID:    customerID,
+		LeakType:      db.LeakTypeEnum(arg.LeakType),
+		Amount:        convertDecimalToPgtypeNumeric(arg.Amount),
+		Confidence:    arg.Confidence,
+		Status:        db.LeakStatusEnum(status),
+		Currency:      currency,
+		SourceEventID: convertNullableUUIDToPgtypeUUID(arg.SourceEventID),
+		DetectedAt:    detectedAt,
+		Metadata:      metadata,
+		DedupKey:      pgtype.Text{String: arg.DedupKey, Valid: arg.DedupKey != ""},
+	}
+}
+
+// toUpdateLeakDBParams converts a domain UpdateLeakParams to a db.UpdateLeakParams for persistence.
+// Nil fields become NULL parameters, which the query leaves unchanged.
+func toUpdateLeakDBParams(arg models.UpdateLeakParams) (db.UpdateLeakParams, error) {
+	leakType, err := convertEnumsToNullableEnum[*db.LeakTypeEnum, db.NullLeakTypeEnum]((*db.LeakTypeEnum)(arg.LeakType))
+	if err != nil {
+		return db.UpdateLeakParams{}, err
+	}
+
+	status, err := convertEnumsToNullableEnum[*db.LeakStatusEnum, db.NullLeakStatusEnum]((*db.LeakStatusEnum)(arg.Status))
+	if err != nil {
+		return db.UpdateLeakParams{}, err
+	}
+
+	var metadata []byte
+	if arg.Metadata != nil {
+		metadata = []byte(arg.Metadata)
+	}
+
+	return db.UpdateLeakParams{
+		ID:         convertUUIDToPgtypeUUID(arg.ID),
+		LeakType:   leakType,
+		Status:     status,
+		Amount:     convertNullableDecimalToPgtypeNumeric(arg.Amount),
+		Currency:   arg.Currency,
+		Confidence: arg.Confidence,
+		ResolvedAt: convertTimePtrToPgtypeTimestamptz(arg.ResolvedAt),
+		Metadata:   metadata,
+	}, nil
+}
+
+// toMinLeakAmountsDomain decodes the tenants.min_leak_amounts column, whose amounts may be JSON
+// numbers or strings
+func toMinLeakAmountsDomain(raw json.RawMessage) (map[string]models.Decimal, error) {
+	if len(raw) == 0 {
+		return map[string]models.Decimal{}, nil
+	}
+	var amounts map[string]models.Decimal
+	if err := json.Unmarshal(raw, &amounts); err != nil {
+		return nil, fmt.Errorf("%w: %w", models.ErrInvalidMinLeakAmount, err)
+	}
+	return models.NormalizeMinLeakAmounts(amounts)
+}
+
+// toLeakSourcesDomain decodes the tenants.leak_sources column; an empty value has no overrides
+func toLeakSourcesDomain(raw json.RawMessage) (models.LeakSources, error) {
+	if len(raw) == 0 {
+		return models.LeakSources{}, nil
+	}
+	var sources models.LeakSources
+	if err := json.Unmarshal(raw, &sources); err != nil {
+		return nil, fmt.Errorf("%w: %w", models.ErrInvalidLeakSources, err)
+	}
+	return sources, nil
+}
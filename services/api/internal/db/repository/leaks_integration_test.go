@@ -0,0 +1,872 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	db "rdl-api/internal/db/sqlc"
+	"rdl-api/internal/domain/models"
+)
+
+func TestLeakDetailQueries(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, customerID := seedTenant(t, pool)
+	providerID := seedProvider(t, pool)
+	leakID := seedLeak(t, pool, tenantID, customerID, "19.99")
+	linkedID := seedEvent(t, pool, tenantID, providerID)
+	seedEvent(t, pool, tenantID, providerID) // not linked to the leak
+
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "INSERT INTO leak_events (leak_id, event_id, tenant_id) VALUES ($1, $2, $3)", leakID, linkedID, tenantID)
+		require.NoError(t, err)
+		_, err = db.New(tx).CreateAction(ctx, db.CreateActionParams{
+			LeakID:     convertUUIDToPgtypeUUID(leakID),
+			ActionType: db.ActionTypeEnumRetryPayment,
+			Status:     db.ActionStatusEnumPending,
+			Result:     db.ActionResultEnumPending,
+		})
+		require.NoError(t, err)
+	})
+
+	logger := createTestLogger()
+	leaksRepo, err := NewLeaksRepository(pool, logger)
+	require.NoError(t, err)
+	eventsRepo, err := NewEventsRepository(pool, logger)
+	require.NoError(t, err)
+	actionsRepo := &ActionsRepositoryImplementation{Pool: pool, Logger: logger}
+
+	t.Run("leak", func(t *testing.T) {
+		leak, err := leaksRepo.GetLeakByID(ctx, leakID, tenantID)
+		require.NoError(t, err)
+		assert.Equal(t, leakID, leak.ID)
+		assert.Equal(t, "19.99", leak.Amount.String())
+	})
+
+	t.Run("missing leak", func(t *testing.T) {
+		_, err := leaksRepo.GetLeakByID(ctx, uuid.New(), tenantID)
+		assert.ErrorIs(t, err, ErrLeakNotFound)
+	})
+
+	t.Run("other tenant cannot see leak", func(t *testing.T) {
+		otherTenantID, _ := seedTenant(t, pool)
+		_, err := leaksRepo.GetLeakByID(ctx, leakID, otherTenantID)
+		assert.ErrorIs(t, err, ErrLeakNotFound)
+	})
+
+	t.Run("linked events only", func(t *testing.T) {
+		events, err := eventsRepo.GetEventsByLeakID(ctx, leakID, tenantID)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, linkedID, events[0].ID)
+	})
+
+	t.Run("actions", func(t *testing.T) {
+		actions, err := actionsRepo.GetActionsByLeakID(ctx, leakID, tenantID)
+		require.NoError(t, err)
+		require.Len(t, actions, 1)
+		assert.Equal(t, leakID, actions[0].LeakID)
+	})
+}
+
+func TestCreateLeak(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, customerID := seedTenant(t, pool)
+
+	leaksRepo, err := NewLeaksRepository(pool, createTestLogger())
+	require.NoError(t, err)
+
+	t.Run("customer leak", func(t *testing.T) {
+		leak, err := leaksRepo.CreateLeak(ctx, models.CreateLeakParams{
+			TenantID:   tenantID,
+			CustomerID: customerID,
+			LeakType:   models.LeakTypeEnumFailedPayments,
+			Amount:     models.MustParseDecimal("42.50"),
+			Confidence: 90,
+		}, tenantID)
+		require.NoError(t, err)
+		assert.Equal(t, customerID, leak.CustomerID)
+		assert.Equal(t, "42.50", leak.Amount.String())
+		assert.Equal(t, models.LeakStatusEnumOpen, leak.Status)
+		assert.Equal(t, models.DefaultLeakCurrency, leak.Currency)
+		assert.JSONEq(t, `{}`, string(leak.Metadata))
+		assert.False(t, leak.DetectedAt.IsZero())
+		assert.Nil(t, leak.ResolvedAt)
+
+		stored, err := leaksRepo.GetLeakByID(ctx, leak.ID, tenantID)
+		require.NoError(t, err)
+		assert.Equal(t, leak.ID, stored.ID)
+	})
+
+	t.Run("tenant-wide leak has no customer", func(t *testing.T) {
+		leak, err := leaksRepo.CreateLeak(ctx, models.CreateLeakParams{
+			TenantID:   tenantID,
+			LeakType:   models.LeakTypeEnumVolumeAnomaly,
+			Confidence: 60,
+		}, tenantID)
+		require.NoError(t, err)
+		assert.Equal(t, uuid.Nil, leak.CustomerID)
+		assert.True(t, leak.Amount.IsZero())
+	})
+}
+
+func TestUpsertLeakByDedupKey(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, customerID := seedTenant(t, pool)
+	providerID := seedProvider(t, pool)
+	firstEventID := seedEvent(t, pool, tenantID, providerID)
+	secondEventID := seedEvent(t, pool, tenantID, providerID)
+
+	logger := createTestLogger()
+	leaksRepo, err := NewLeaksRepository(pool, logger)
+	require.NoError(t, err)
+	eventsRepo, err := NewEventsRepository(pool, logger)
+	require.NoError(t, err)
+
+	detection := func(key string, amount string, confidence int32, eventID uuid.UUID) models.CreateLeakParams {
+		return models.CreateLeakParams{
+			TenantID:      tenantID,
+			CustomerID:    customerID,
+			LeakType:      models.LeakTypeEnumDunningGap,
+			Amount:        models.MustParseDecimal(amount),
+			Confidence:    confidence,
+			SourceEventID: &eventID,
+			DedupKey:      key,
+		}
+	}
+
+	t.Run("repeated detections update one leak", func(t *testing.T) {
+		key := "dunning_gap:cus_1:sub_1"
+		first, err := leaksRepo.UpsertLeakByDedupKey(ctx, detection(key, "10.00", 60, firstEventID), tenantID)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, first.Occurrences)
+		require.NotNil(t, first.DedupKey)
+		assert.Equal(t, key, *first.DedupKey)
+
+		second, err := leaksRepo.UpsertLeakByDedupKey(ctx, detection(key, "15.50", 80, secondEventID), tenantID)
+		require.NoError(t, err)
+		assert.Equal(t, first.ID, second.ID)
+		assert.EqualValues(t, 2, second.Occurrences)
+		assert.Equal(t, "25.50", second.Amount.String())
+		assert.EqualValues(t, 80, second.Confidence, "the higher confidence is kept")
+		assert.Equal(t, firstEventID, *second.SourceEventID, "the first detection stays the source")
+
+		third, err := leaksRepo.UpsertLeakByDedupKey(ctx, detection(key, "4.50", 70, secondEventID), tenantID)
+		require.NoError(t, err)
+		assert.Equal(t, first.ID, third.ID)
+		assert.EqualValues(t, 3, third.Occurrences)
+		assert.Equal(t, "30.00", third.Amount.String())
+		assert.EqualValues(t, 80, third.Confidence)
+
+		events, err := eventsRepo.GetEventsByLeakID(ctx, first.ID, tenantID)
+		require.NoError(t, err)
+		assert.Len(t, events, 2, "each contributing event is linked once")
+
+		page, err := leaksRepo.ListLeaks(ctx, tenantID, models.LeakFilter{LeakTypes: []models.LeakTypeEnum{models.LeakTypeEnumDunningGap}}, models.PaginationParams{Limit: 10})
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, page.TotalCount)
+	})
+
+	t.Run("another root cause gets its own leak", func(t *testing.T) {
+		first, err := leaksRepo.UpsertLeakByDedupKey(ctx, detection("dunning_gap:cus_1:sub_2", "10.00", 60, firstEventID), tenantID)
+		require.NoError(t, err)
+		other, err := leaksRepo.UpsertLeakByDedupKey(ctx, detection("dunning_gap:cus_1:sub_3", "10.00", 60, firstEventID), tenantID)
+		require.NoError(t, err)
+		assert.NotEqual(t, first.ID, other.ID)
+		assert.EqualValues(t, 1, other.Occurrences)
+	})
+
+	t.Run("another currency gets its own leak", func(t *testing.T) {
+		key := "dunning_gap:cus_1:sub_5"
+		usd := detection(key, "10.00", 60, firstEventID)
+		usd.Currency = "USD"
+		first, err := leaksRepo.UpsertLeakByDedupKey(ctx, usd, tenantID)
+		require.NoError(t, err)
+
+		eur := detection(key, "20.00", 60, secondEventID)
+		eur.Currency = "EUR"
+		other, err := leaksRepo.UpsertLeakByDedupKey(ctx, eur, tenantID)
+		require.NoError(t, err)
+		assert.NotEqual(t, first.ID, other.ID, "amounts in different currencies are not added up")
+		assert.EqualValues(t, 1, other.Occurrences)
+		assert.Equal(t, "EUR", other.Currency)
+		assert.Equal(t, "20.00", other.Amount.String())
+
+		again, err := leaksRepo.UpsertLeakByDedupKey(ctx, usd, tenantID)
+		require.NoError(t, err)
+		assert.Equal(t, first.ID, again.ID)
+		assert.EqualValues(t, 2, again.Occurrences)
+		assert.Equal(t, "20.00", again.Amount.String(), "only the USD amounts are summed")
+	})
+
+	t.Run("a resolved leak is not reopened", func(t *testing.T) {
+		key := "dunning_gap:cus_1:sub_4"
+		first, err := leaksRepo.UpsertLeakByDedupKey(ctx, detection(key, "10.00", 60, firstEventID), tenantID)
+		require.NoError(t, err)
+		resolved := models.LeakStatusEnumResolved
+		_, err = leaksRepo.UpdateLeak(ctx, models.UpdateLeakParams{ID: first.ID, Status: &resolved}, tenantID)
+		require.NoError(t, err)
+
+		again, err := leaksRepo.UpsertLeakByDedupKey(ctx, detection(key, "10.00", 60, secondEventID), tenantID)
+		require.NoError(t, err)
+		assert.NotEqual(t, first.ID, again.ID)
+		assert.EqualValues(t, 1, again.Occurrences)
+		assert.Equal(t, models.LeakStatusEnumOpen, again.Status)
+	})
+
+	t.Run("other tenant's key does not match", func(t *testing.T) {
+		otherTenantID, otherCustomerID := seedTenant(t, pool)
+		params := detection("dunning_gap:cus_1:sub_1", "10.00", 60, firstEventID)
+		params.TenantID, params.CustomerID, params.SourceEventID = otherTenantID, otherCustomerID, nil
+		leak, err := leaksRepo.UpsertLeakByDedupKey(ctx, params, otherTenantID)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, leak.Occurrences)
+	})
+
+	t.Run("dedup key is required", func(t *testing.T) {
+		_, err := leaksRepo.UpsertLeakByDedupKey(ctx, detection("", "10.00", 60, firstEventID), tenantID)
+		assert.ErrorIs(t, err, ErrMissingDedupKey)
+	})
+}
+
+func TestUpdateLeak(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, customerID := seedTenant(t, pool)
+
+	leaksRepo, err := NewLeaksRepository(pool, createTestLogger())
+	require.NoError(t, err)
+
+	detectedAt := time.Now().Add(-2 * time.Hour).Truncate(time.Microsecond)
+	leak, err := leaksRepo.CreateLeak(ctx, models.CreateLeakParams{
+		TenantID:   tenantID,
+		CustomerID: customerID,
+		LeakType:   models.LeakTypeEnumFailedPayments,
+		Amount:     models.MustParseDecimal("1234567890123.45"),
+		Currency:   "EUR",
+		Confidence: 80,
+		DetectedAt: detectedAt,
+		Metadata:   json.RawMessage(`{"rule":"static"}`),
+	}, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, "1234567890123.45", leak.Amount.String(), "amounts must not lose precision")
+	assert.True(t, detectedAt.Equal(leak.DetectedAt))
+
+	resolved := models.LeakStatusEnumResolved
+	updated, err := leaksRepo.UpdateLeak(ctx, models.UpdateLeakParams{ID: leak.ID, Status: &resolved}, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, models.LeakStatusEnumResolved, updated.Status)
+	require.NotNil(t, updated.ResolvedAt, "resolving stamps resolved_at")
+	assert.Equal(t, "EUR", updated.Currency, "fields left nil are unchanged")
+	assert.JSONEq(t, `{"rule":"static"}`, string(updated.Metadata))
+
+	open := models.LeakStatusEnumOpen
+	reopened, err := leaksRepo.UpdateLeak(ctx, models.UpdateLeakParams{ID: leak.ID, Status: &open}, tenantID)
+	require.NoError(t, err)
+	assert.Nil(t, reopened.ResolvedAt, "reopening clears resolved_at")
+
+	_, err = leaksRepo.UpdateLeak(ctx, models.UpdateLeakParams{ID: uuid.New(), Status: &open}, tenantID)
+	assert.ErrorIs(t, err, ErrLeakNotFound)
+}
+
+func TestListLeaks(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, customerID := seedTenant(t, pool)
+
+	leaksRepo, err := NewLeaksRepository(pool, createTestLogger())
+	require.NoError(t, err)
+
+	day := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	create := func(amount string, leakType models.LeakTypeEnum, status models.LeakStatusEnum, detectedAt time.Time) {
+		_, err := leaksRepo.CreateLeak(ctx, models.CreateLeakParams{
+			CustomerID: customerID,
+			LeakType:   leakType,
+			Status:     status,
+			Amount:     models.MustParseDecimal(amount),
+			Confidence: 80,
+			DetectedAt: detectedAt,
+		}, tenantID)
+		require.NoError(t, err)
+	}
+	create("10.00", models.LeakTypeEnumFailedPayments, models.LeakStatusEnumOpen, day)
+	create("250.00", models.LeakTypeEnumQuietChurn, models.LeakStatusEnumOpen, day.Add(24*time.Hour))
+	create("75.50", models.LeakTypeEnumFailedPayments, models.LeakStatusEnumOpen, day.Add(48*time.Hour))
+	create("9999.00", models.LeakTypeEnumFailedPayments, models.LeakStatusEnumIgnored, day)
+
+	amounts := func(page models.PaginatedResponse[models.Leak]) []string {
+		var out []string
+		for _, leak := range page.Items {
+			out = append(out, leak.Amount.String())
+		}
+		return out
+	}
+	page := models.PaginationParams{Limit: 10}
+
+	t.Run("largest amount first", func(t *testing.T) {
+		result, err := leaksRepo.ListLeaks(ctx, tenantID, models.LeakFilter{Statuses: []models.LeakStatusEnum{models.LeakStatusEnumOpen}}, page)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"250.00", "75.50", "10.00"}, amounts(result))
+		assert.EqualValues(t, 3, result.TotalCount)
+	})
+
+	t.Run("type and detection range", func(t *testing.T) {
+		from, to := day, day.Add(48*time.Hour)
+		result, err := leaksRepo.ListLeaks(ctx, tenantID, models.LeakFilter{
+			LeakTypes:    []models.LeakTypeEnum{models.LeakTypeEnumFailedPayments},
+			DetectedFrom: &from,
+			DetectedTo:   &to,
+		}, page)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"9999.00", "10.00"}, amounts(result), "the range end is exclusive")
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		result, err := leaksRepo.ListLeaks(ctx, tenantID, models.LeakFilter{}, models.PaginationParams{Limit: 2, Offset: 1})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"250.00", "75.50"}, amounts(result))
+		assert.EqualValues(t, 4, result.TotalCount)
+		assert.True(t, result.HasNext)
+	})
+
+	t.Run("other tenant sees nothing", func(t *testing.T) {
+		otherTenantID, _ := seedTenant(t, pool)
+		result, err := leaksRepo.ListLeaks(ctx, otherTenantID, models.LeakFilter{}, page)
+		require.NoError(t, err)
+		assert.Empty(t, result.Items)
+	})
+}
+
+func TestGetLeakCountInWindow(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, customerID := seedTenant(t, pool)
+
+	seedLeak(t, pool, tenantID, customerID, "10.00")
+	old := seedLeak(t, pool, tenantID, customerID, "20.00")
+	resolved := seedLeak(t, pool, tenantID, customerID, "30.00")
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "UPDATE leaks SET detected_at = NOW() - INTERVAL '3 days' WHERE id = $1", old)
+		require.NoError(t, err)
+		_, err = tx.Exec(ctx, "UPDATE leaks SET status = 'resolved', resolved_at = NOW() WHERE id = $1", resolved)
+		require.NoError(t, err)
+	})
+
+	repo := LeaksRepositoryImplementation{pool: pool, logger: createTestLogger()}
+	now := time.Now()
+	count, err := repo.GetLeakCountInWindow(ctx, tenantID, now.Add(-24*time.Hour), now.Add(time.Minute))
+	require.NoError(t, err)
+
+	// The recent leak and the resolved one, but not the leak detected before the window
+	assert.Equal(t, int64(2), count)
+}
+
+func TestGetAffectedCustomerCount(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, customerID := seedTenant(t, pool)
+
+	otherCustomerID := uuid.New()
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "INSERT INTO customers (id, tenant_id, external_id, email, name) VALUES ($1, $2, $3, $4, $5)",
+			otherCustomerID, tenantID, "cus_"+otherCustomerID.String(), otherCustomerID.String()+"@example.com", "other customer")
+		require.NoError(t, err)
+	})
+
+	// Three leaks for the first customer and one for the second count two customers
+	seedLeak(t, pool, tenantID, customerID, "10.00")
+	seedLeak(t, pool, tenantID, customerID, "20.00")
+	seedLeak(t, pool, tenantID, customerID, "30.00")
+	seedLeak(t, pool, tenantID, otherCustomerID, "40.00")
+	tenantWide := seedLeak(t, pool, tenantID, customerID, "0")
+	resolvedCustomerID := uuid.New()
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "UPDATE leaks SET customer_id = NULL WHERE id = $1", tenantWide)
+		require.NoError(t, err)
+		_, err = tx.Exec(ctx, "INSERT INTO customers (id, tenant_id, external_id, email, name) VALUES ($1, $2, $3, $4, $5)",
+			resolvedCustomerID, tenantID, "cus_"+resolvedCustomerID.String(), resolvedCustomerID.String()+"@example.com", "resolved customer")
+		require.NoError(t, err)
+	})
+	resolved := seedLeak(t, pool, tenantID, resolvedCustomerID, "50.00")
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "UPDATE leaks SET status = 'resolved', resolved_at = NOW() WHERE id = $1", resolved)
+		require.NoError(t, err)
+	})
+
+	repo := LeaksRepositoryImplementation{pool: pool, logger: createTestLogger()}
+
+	t.Run("each customer with an open leak counts once", func(t *testing.T) {
+		count, err := repo.GetAffectedCustomerCount(ctx, tenantID, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+	})
+
+	t.Run("leaks detected before from are left out", func(t *testing.T) {
+		count, err := repo.GetAffectedCustomerCount(ctx, tenantID, time.Now().Add(time.Hour), time.Now().Add(2*time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+
+	t.Run("leaks detected from to on are left out", func(t *testing.T) {
+		count, err := repo.GetAffectedCustomerCount(ctx, tenantID, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+
+	t.Run("other tenants are not counted", func(t *testing.T) {
+		otherTenantID, _ := seedTenant(t, pool)
+		count, err := repo.GetAffectedCustomerCount(ctx, otherTenantID, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+}
+
+func TestGetLeakMTTR(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, customerID := seedTenant(t, pool)
+	repo := LeaksRepositoryImplementation{pool: pool, logger: createTestLogger()}
+
+	t.Run("no resolved leaks", func(t *testing.T) {
+		seedLeak(t, pool, tenantID, customerID, "10.00") // still open
+		mttr, count, err := repo.GetLeakMTTR(ctx, tenantID, time.Now().Add(-24*time.Hour), time.Now())
+		require.NoError(t, err)
+		assert.Zero(t, mttr)
+		assert.Zero(t, count)
+	})
+
+	// Resolved after 1h, 2h and 6h: a mean of 3h over three leaks
+	now := time.Now()
+	resolve := func(detectedAt, resolvedAt time.Time) {
+		id := seedLeak(t, pool, tenantID, customerID, "20.00")
+		seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+			_, err := tx.Exec(ctx, "UPDATE leaks SET status = 'resolved', detected_at = $2, resolved_at = $3 WHERE id = $1", id, detectedAt, resolvedAt)
+			require.NoError(t, err)
+		})
+	}
+	resolve(now.Add(-10*time.Hour), now.Add(-9*time.Hour))
+	resolve(now.Add(-8*time.Hour), now.Add(-6*time.Hour))
+	resolve(now.Add(-7*time.Hour), now.Add(-1*time.Hour))
+	// Resolved before the window
+	resolve(now.Add(-72*time.Hour), now.Add(-48*time.Hour))
+
+	t.Run("mean over the leaks resolved in the window", func(t *testing.T) {
+		mttr, count, err := repo.GetLeakMTTR(ctx, tenantID, now.Add(-24*time.Hour), now)
+		require.NoError(t, err)
+		assert.Equal(t, 3*time.Hour, mttr)
+		assert.Equal(t, 3, count)
+	})
+
+	t.Run("leaks resolved from to on are left out", func(t *testing.T) {
+		// Only the leak resolved 9h ago, after 1h, is before to
+		mttr, count, err := repo.GetLeakMTTR(ctx, tenantID, now.Add(-24*time.Hour), now.Add(-8*time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, time.Hour, mttr)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("other tenants are not counted", func(t *testing.T) {
+		otherTenantID, _ := seedTenant(t, pool)
+		mttr, count, err := repo.GetLeakMTTR(ctx, otherTenantID, now.Add(-24*time.Hour), now)
+		require.NoError(t, err)
+		assert.Zero(t, mttr)
+		assert.Zero(t, count)
+	})
+}
+
+func TestGetRevenueRecovered(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, paidCustomerID := seedTenant(t, pool)
+	providerID := seedProvider(t, pool)
+	now := time.Now()
+
+	seedCustomer := func() uuid.UUID {
+		id := uuid.New()
+		seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+			_, err := tx.Exec(ctx, "INSERT INTO customers (id, tenant_id, external_id, email, name) VALUES ($1, $2, $3, $4, $5)",
+				id, tenantID, "cus_"+id.String(), id.String()+"@example.com", "integration customer")
+			require.NoError(t, err)
+		})
+		return id
+	}
+	seedAction := func(leakID uuid.UUID, result string, createdAt time.Time) {
+		seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+			_, err := tx.Exec(ctx, "INSERT INTO actions (leak_id, action_type, status, result, created_at) VALUES ($1, 'retry_payment', 'approved', $2, $3)",
+				leakID, result, createdAt)
+			require.NoError(t, err)
+		})
+	}
+	seedPayment := func(customerID uuid.UUID, createdAt time.Time) {
+		seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+			_, err := tx.Exec(ctx,
+				`INSERT INTO events (tenant_id, provider_id, event_type, event_id, status, data, created_at)
+				 VALUES ($1, $2, 'payment_succeeded', 'evt_' || uuid_generate_v4(), 'processed', jsonb_build_object('customer_id', $3::text), $4)`,
+				tenantID, providerID, "cus_"+customerID.String(), createdAt)
+			require.NoError(t, err)
+		})
+	}
+
+	// The paid customer paid an hour ago, after the actions on their leaks
+	seedPayment(paidCustomerID, now.Add(-time.Hour))
+	recoveredUSD := seedLeak(t, pool, tenantID, paidCustomerID, "100.00")
+	seedAction(recoveredUSD, "success", now.Add(-2*time.Hour))
+	recoveredEUR := seedLeak(t, pool, tenantID, paidCustomerID, "30.00")
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "UPDATE leaks SET currency = 'EUR' WHERE id = $1", recoveredEUR)
+		require.NoError(t, err)
+	})
+	seedAction(recoveredEUR, "failure", now.Add(-3*time.Hour))
+	seedAction(recoveredEUR, "success", now.Add(-2*time.Hour))
+	// The action on this leak failed, so the payment was not its doing
+	failedAction := seedLeak(t, pool, tenantID, paidCustomerID, "40.00")
+	seedAction(failedAction, "failure", now.Add(-2*time.Hour))
+	// No action at all
+	seedLeak(t, pool, tenantID, paidCustomerID, "45.00")
+
+	// A successful action that the customer never followed with a payment
+	unpaidCustomerID := seedCustomer()
+	unpaid := seedLeak(t, pool, tenantID, unpaidCustomerID, "25.00")
+	seedAction(unpaid, "success", now.Add(-2*time.Hour))
+
+	// A payment made before the action, which the action cannot have recovered
+	earlyCustomerID := seedCustomer()
+	seedPayment(earlyCustomerID, now.Add(-3*time.Hour))
+	early := seedLeak(t, pool, tenantID, earlyCustomerID, "60.00")
+	seedAction(early, "success", now.Add(-2*time.Hour))
+
+	repo := LeaksRepositoryImplementation{pool: pool, logger: createTestLogger()}
+
+	t.Run("only leaks recovered by a successful action count", func(t *testing.T) {
+		recovered, err := repo.GetRevenueRecovered(ctx, tenantID, now.Add(-24*time.Hour), now)
+		require.NoError(t, err)
+		require.Len(t, recovered, 2)
+		assert.Equal(t, "100.00", recovered["USD"].String())
+		assert.Equal(t, "30.00", recovered["EUR"].String())
+	})
+
+	t.Run("payments before since are left out", func(t *testing.T) {
+		recovered, err := repo.GetRevenueRecovered(ctx, tenantID, now.Add(-30*time.Minute), now)
+		require.NoError(t, err)
+		assert.Empty(t, recovered)
+	})
+
+	t.Run("payments from to on are left out", func(t *testing.T) {
+		recovered, err := repo.GetRevenueRecovered(ctx, tenantID, now.Add(-24*time.Hour), now.Add(-time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, recovered)
+	})
+
+	t.Run("other tenants are not counted", func(t *testing.T) {
+		otherTenantID, _ := seedTenant(t, pool)
+		recovered, err := repo.GetRevenueRecovered(ctx, otherTenantID, now.Add(-24*time.Hour), now)
+		require.NoError(t, err)
+		assert.Empty(t, recovered)
+	})
+}
+
+func TestGetLeakAmountHistogram(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, customerID := seedTenant(t, pool)
+
+	update := func(leakID uuid.UUID, set string, args ...any) {
+		seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+			_, err := tx.Exec(ctx, "UPDATE leaks SET "+set+" WHERE id = $1", append([]any{leakID}, args...)...)
+			require.NoError(t, err)
+		})
+	}
+
+	seedLeak(t, pool, tenantID, customerID, "5.00")
+	// A bound belongs to the bucket above it
+	seedLeak(t, pool, tenantID, customerID, "10.00")
+	seedLeak(t, pool, tenantID, customerID, "99.99")
+	seedLeak(t, pool, tenantID, customerID, "100.00")
+	seedLeak(t, pool, tenantID, customerID, "2500.00")
+	eur := seedLeak(t, pool, tenantID, customerID, "50.00")
+	update(eur, "currency = 'EUR'")
+	old := seedLeak(t, pool, tenantID, customerID, "20.00")
+	update(old, "detected_at = $2", time.Now().Add(-48*time.Hour))
+	// Neither resolved nor snoozed leaks are open exposure
+	resolved := seedLeak(t, pool, tenantID, customerID, "30.00")
+	update(resolved, "status = 'resolved', resolved_at = NOW()")
+	snoozed := seedLeak(t, pool, tenantID, customerID, "40.00")
+	update(snoozed, "snoozed_until = $2", time.Now().Add(time.Hour))
+
+	repo := LeaksRepositoryImplementation{pool: pool, logger: createTestLogger()}
+	bounds := []models.Decimal{models.MustParseDecimal("10"), models.MustParseDecimal("100"), models.MustParseDecimal("1000")}
+
+	summarize := func(buckets []models.LeakAmountBucket) []string {
+		var out []string
+		for _, b := range buckets {
+			out = append(out, fmt.Sprintf("%s %v-%v: %d %s", b.Currency, b.Lower, b.Upper, b.Count, b.Total))
+		}
+		return out
+	}
+
+	t.Run("amounts fall into their buckets per currency", func(t *testing.T) {
+		buckets, err := repo.GetLeakAmountHistogram(ctx, tenantID, bounds, time.Time{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"EUR <nil>-10: 0 0",
+			"EUR 10-100: 1 50.00",
+			"EUR 100-1000: 0 0",
+			"EUR 1000-<nil>: 0 0",
+			"USD <nil>-10: 1 5.00",
+			"USD 10-100: 3 129.99",
+			"USD 100-1000: 1 100.00",
+			"USD 1000-<nil>: 1 2500.00",
+		}, summarize(buckets))
+	})
+
+	t.Run("leaks detected before since are left out", func(t *testing.T) {
+		buckets, err := repo.GetLeakAmountHistogram(ctx, tenantID, bounds, time.Now().Add(-24*time.Hour))
+		require.NoError(t, err)
+		require.Len(t, buckets, 8)
+		assert.Equal(t, int64(2), buckets[5].Count)
+		assert.Equal(t, "109.99", buckets[5].Total.String())
+	})
+
+	t.Run("other tenants are not counted", func(t *testing.T) {
+		otherTenantID, _ := seedTenant(t, pool)
+		buckets, err := repo.GetLeakAmountHistogram(ctx, otherTenantID, bounds, time.Time{})
+		require.NoError(t, err)
+		assert.Empty(t, buckets)
+	})
+}
+
+func TestGetLeakTimeSeries(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, customerID := seedTenant(t, pool)
+
+	detectAt := func(amount string, at time.Time, currency string) {
+		leakID := seedLeak(t, pool, tenantID, customerID, amount)
+		seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+			_, err := tx.Exec(ctx, "UPDATE leaks SET detected_at = $2, currency = $3 WHERE id = $1", leakID, at, currency)
+			require.NoError(t, err)
+		})
+	}
+
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	detectAt("10.00", start.Add(2*time.Hour), "USD")
+	detectAt("2.50", start.Add(23*time.Hour), "USD")
+	detectAt("40.00", start.Add(5*time.Hour), "EUR")
+	// Nothing on March 2nd
+	detectAt("7.00", start.Add(48*time.Hour+time.Minute), "USD")
+	// Before from and at to, both outside the range
+	detectAt("100.00", start.Add(-time.Minute), "USD")
+	detectAt("100.00", start.Add(4*24*time.Hour), "USD")
+
+	repo := LeaksRepositoryImplementation{pool: pool, logger: createTestLogger()}
+
+	summarize := func(points []models.LeakTimeSeriesPoint) []string {
+		var out []string
+		for _, p := range points {
+			line := fmt.Sprintf("%s: %d", p.Start.Format(time.DateOnly), p.Count)
+			for _, currency := range []string{"EUR", "USD"} {
+				if total, ok := p.Totals[currency]; ok {
+					line += fmt.Sprintf(" %s %s", total, currency)
+				}
+			}
+			out = append(out, line)
+		}
+		return out
+	}
+
+	t.Run("days are zero-filled and amounts summed per bucket", func(t *testing.T) {
+		points, err := repo.GetLeakTimeSeries(ctx, tenantID, models.LeakTimeSeriesDay, start, start.Add(4*24*time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"2025-03-01: 3 40.00 EUR 12.50 USD",
+			"2025-03-02: 0",
+			"2025-03-03: 1 7.00 USD",
+			"2025-03-04: 0",
+		}, summarize(points))
+		for _, p := range points {
+			assert.Equal(t, time.UTC, p.Start.Location())
+		}
+	})
+
+	t.Run("from is truncated to the start of its bucket", func(t *testing.T) {
+		points, err := repo.GetLeakTimeSeries(ctx, tenantID, models.LeakTimeSeriesWeek, start.Add(12*time.Hour), start.Add(3*24*time.Hour))
+		require.NoError(t, err)
+		// March 1st 2025 is a Saturday, in the week starting Monday February 24th
+		assert.Equal(t, []string{
+			"2025-02-24: 1 2.50 USD",
+			"2025-03-03: 1 7.00 USD",
+		}, summarize(points))
+	})
+
+	t.Run("other tenants get only empty buckets", func(t *testing.T) {
+		otherTenantID, _ := seedTenant(t, pool)
+		points, err := repo.GetLeakTimeSeries(ctx, otherTenantID, models.LeakTimeSeriesDay, start, start.Add(2*24*time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"2025-03-01: 0", "2025-03-02: 0"}, summarize(points))
+	})
+}
+
+func TestListLeaksAfter(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, customerID := seedTenant(t, pool)
+	repo := LeaksRepositoryImplementation{pool: pool, logger: createTestLogger()}
+
+	// Five open leaks detected a minute apart and one resolved leak
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	var openIDs []uuid.UUID
+	for i := range 6 {
+		id := seedLeak(t, pool, tenantID, customerID, "10.00")
+		status := "open"
+		if i == 5 {
+			status = "resolved"
+		} else {
+			openIDs = append(openIDs, id)
+		}
+		seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+			_, err := tx.Exec(ctx, "UPDATE leaks SET detected_at = $2, status = $3 WHERE id = $1", id, start.Add(time.Duration(i)*time.Minute), status)
+			require.NoError(t, err)
+		})
+	}
+	filter := models.LeakFilter{Statuses: []models.LeakStatusEnum{models.LeakStatusEnumOpen}}
+
+	t.Run("pages through every matching leak in detection order", func(t *testing.T) {
+		var got []uuid.UUID
+		var cursor models.LeakCursor
+		for {
+			page, err := repo.ListLeaksAfter(ctx, tenantID, filter, cursor, 2)
+			require.NoError(t, err)
+			for _, leak := range page {
+				got = append(got, leak.ID)
+			}
+			if len(page) < 2 {
+				break
+			}
+			last := page[len(page)-1]
+			cursor = models.LeakCursor{DetectedAt: last.DetectedAt, ID: last.ID}
+		}
+		assert.Equal(t, openIDs, got)
+	})
+
+	t.Run("other tenants see nothing", func(t *testing.T) {
+		otherTenantID, _ := seedTenant(t, pool)
+		page, err := repo.ListLeaksAfter(ctx, otherTenantID, filter, models.LeakCursor{}, 10)
+		require.NoError(t, err)
+		assert.Empty(t, page)
+	})
+}
+
+func TestSnoozeLeak(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, customerID := seedTenant(t, pool)
+
+	seedLeak(t, pool, tenantID, customerID, "10.00")
+	snoozed := seedLeak(t, pool, tenantID, customerID, "20.00")
+
+	repo := LeaksRepositoryImplementation{pool: pool, logger: createTestLogger()}
+	count, err := repo.GetOpenLeakCount(ctx, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	until := time.Now().Add(time.Hour)
+	leak, err := repo.SnoozeLeak(ctx, snoozed, until, tenantID)
+	require.NoError(t, err)
+	require.NotNil(t, leak.SnoozedUntil)
+	assert.WithinDuration(t, until, *leak.SnoozedUntil, time.Millisecond)
+	assert.Equal(t, models.LeakStatusEnumOpen, leak.Status)
+
+	t.Run("hidden from open counts and listings while snoozed", func(t *testing.T) {
+		count, err := repo.GetOpenLeakCount(ctx, tenantID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+
+		page := models.PaginationParams{Limit: 10}
+		active, err := repo.ListLeaks(ctx, tenantID, models.LeakFilter{ExcludeSnoozed: true}, page)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), active.TotalCount)
+		all, err := repo.ListLeaks(ctx, tenantID, models.LeakFilter{}, page)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), all.TotalCount)
+	})
+
+	t.Run("reappears once snoozed_until passes", func(t *testing.T) {
+		_, err := repo.SnoozeLeak(ctx, snoozed, time.Now().Add(-time.Second), tenantID)
+		require.NoError(t, err)
+
+		count, err := repo.GetOpenLeakCount(ctx, tenantID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+	})
+
+	t.Run("unknown leak", func(t *testing.T) {
+		_, err := repo.SnoozeLeak(ctx, uuid.New(), until, tenantID)
+		assert.ErrorIs(t, err, ErrLeakNotFound)
+	})
+
+	t.Run("other tenant cannot snooze", func(t *testing.T) {
+		otherTenantID, _ := seedTenant(t, pool)
+		_, err := repo.SnoozeLeak(ctx, snoozed, until, otherTenantID)
+		assert.ErrorIs(t, err, ErrLeakNotFound)
+	})
+}
+
+func TestFindExistingLeaks(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, customerID := seedTenant(t, pool)
+	otherTenantID, otherCustomerID := seedTenant(t, pool)
+	providerID := seedProvider(t, pool)
+	eventID := seedEvent(t, pool, tenantID, providerID)
+
+	detectedAt := time.Date(2025, 3, 1, 2, 0, 0, 0, time.UTC)
+	atTime := seedLeak(t, pool, tenantID, customerID, "10.00")
+	fromEvent := seedLeak(t, pool, tenantID, customerID, "20.00")
+	seedLeak(t, pool, tenantID, customerID, "30.00") // detected now, with no source event
+	byDedupKey := seedLeak(t, pool, tenantID, customerID, "35.00")
+	foreign := seedLeak(t, pool, otherTenantID, otherCustomerID, "40.00")
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "UPDATE leaks SET detected_at = $1 WHERE id = ANY($2)", detectedAt, []uuid.UUID{atTime, foreign})
+		require.NoError(t, err)
+		_, err = tx.Exec(ctx, "UPDATE leaks SET source_event_id = $1 WHERE id = $2", eventID, fromEvent)
+		require.NoError(t, err)
+		_, err = tx.Exec(ctx, "UPDATE leaks SET dedup_key = 'volume_anomaly::2025-03-01T01:00:00Z', status = 'resolved' WHERE id = $1", byDedupKey)
+		require.NoError(t, err)
+	})
+
+	repo := LeaksRepositoryImplementation{pool: pool, logger: createTestLogger()}
+	leaks, err := repo.FindExistingLeaks(ctx, tenantID, detectedAt, []uuid.UUID{eventID}, []string{"volume_anomaly::2025-03-01T01:00:00Z"})
+	require.NoError(t, err)
+
+	found := map[uuid.UUID]bool{}
+	for _, leak := range leaks {
+		found[leak.ID] = true
+	}
+	assert.Equal(t, map[uuid.UUID]bool{atTime: true, fromEvent: true, byDedupKey: true}, found, "expected neither the unrelated leak nor another tenant's")
+}
+
+func TestListTenantIDs(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	otherTenantID, _ := seedTenant(t, pool)
+
+	repo := LeaksRepositoryImplementation{pool: pool, logger: createTestLogger()}
+	ids, err := repo.ListTenantIDs(ctx)
+	require.NoError(t, err)
+
+	assert.Contains(t, ids, tenantID)
+	assert.Contains(t, ids, otherTenantID)
+}
@@ -0,0 +1,322 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	db "rdl-api/internal/db/sqlc"
+	"rdl-api/internal/domain/models"
+)
+
+func TestToLeakDomain(t *testing.T) {
+	id := uuid.New()
+	tenantID := uuid.New()
+	customerID := uuid.New()
+	sourceEventID := uuid.New()
+	now := time.Now()
+
+	var amount pgtype.Numeric
+	require.NoError(t, amount.Scan("125.75"))
+
+	leak := toLeakDomain(nil, db.Leak{
+		ID:            convertUUIDToPgtypeUUID(id),
+		TenantID:      convertUUIDToPgtypeUUID(tenantID),
+		CustomerID:    convertUUIDToPgtypeUUID(customerID),
+		LeakType:      db.LeakTypeEnumFailedPayments,
+		Status:        db.LeakStatusEnumOpen,
+		Amount:        amount,
+		Currency:      "EUR",
+		Confidence:    90,
+		SourceEventID: convertUUIDToPgtypeUUID(sourceEventID),
+		Metadata:      []byte(`{"rule":"static"}`),
+		DetectedAt:    pgtype.Timestamptz{Time: now, Valid: true},
+		CreatedAt:     pgtype.Timestamptz{Time: now, Valid: true},
+		UpdatedAt:     pgtype.Timestamptz{Time: now, Valid: true},
+	})
+
+	assert.Equal(t, id, leak.ID)
+	assert.Equal(t, tenantID, leak.TenantID)
+	assert.Equal(t, customerID, leak.CustomerID)
+	assert.Equal(t, models.LeakTypeEnumFailedPayments, leak.LeakType)
+	assert.Equal(t, models.LeakStatusEnumOpen, leak.Status)
+	assert.Equal(t, "125.75", leak.Amount.String())
+	assert.Equal(t, "EUR", leak.Currency)
+	assert.Equal(t, int32(90), leak.Confidence)
+	require.NotNil(t, leak.SourceEventID)
+	assert.Equal(t, sourceEventID, *leak.SourceEventID)
+	assert.JSONEq(t, `{"rule":"static"}`, string(leak.Metadata))
+	assert.Equal(t, now, leak.DetectedAt)
+	assert.Nil(t, leak.ResolvedAt)
+	assert.Equal(t, now, leak.CreatedAt)
+}
+
+func TestToLeakDomain_NullableColumns(t *testing.T) {
+	resolvedAt := time.Now()
+
+	leak := toLeakDomain(nil, db.Leak{
+		Status:     db.LeakStatusEnumResolved,
+		ResolvedAt: pgtype.Timestamptz{Time: resolvedAt, Valid: true},
+	})
+	require.NotNil(t, leak.ResolvedAt)
+	assert.Equal(t, resolvedAt, *leak.ResolvedAt)
+	assert.Nil(t, leak.SourceEventID)
+	assert.Equal(t, uuid.Nil, leak.CustomerID)
+}
+
+func TestToLeakDomain_InvalidAmount(t *testing.T) {
+	leak := toLeakDomain(nil, db.Leak{Amount: pgtype.Numeric{Valid: false}})
+	assert.True(t, leak.Amount.IsZero())
+
+	leak = toLeakDomain(nil, db.Leak{Amount: pgtype.Numeric{NaN: true, Valid: true}})
+	assert.True(t, leak.Amount.IsZero())
+}
+
+func TestToCreateLeakDBParams(t *testing.T) {
+	tenantID := uuid.New()
+
+	t.Run("defaults", func(t *testing.T) {
+		params := toCreateLeakDBParams(models.CreateLeakParams{LeakType: models.LeakTypeEnumVolumeAnomaly}, tenantID)
+
+		assert.Equal(t, convertUUIDToPgtypeUUID(tenantID), params.TenantID)
+		assert.False(t, params.CustomerID.Valid, "a tenant-wide leak has no customer")
+		assert.Equal(t, db.LeakStatusEnumOpen, params.Status)
+		assert.Equal(t, models.DefaultLeakCurrency, params.Currency)
+		assert.False(t, params.SourceEventID.Valid)
+		assert.False(t, params.DetectedAt.Valid, "the database stamps the detection time")
+		assert.JSONEq(t, `{}`, string(params.Metadata))
+	})
+
+	t.Run("explicit values", func(t *testing.T) {
+		customerID := uuid.New()
+		sourceEventID := uuid.New()
+		detectedAt := time.Now().Add(-time.Hour)
+
+		params := toCreateLeakDBParams(models.CreateLeakParams{
+			CustomerID:    customerID,
+			LeakType:      models.LeakTypeEnumFailedPayments,
+			Status:        models.LeakStatusEnumIgnored,
+			Currency:      "JPY",
+			SourceEventID: &sourceEventID,
+			DetectedAt:    detectedAt,
+		}, tenantID)
+
+		assert.Equal(t, convertUUIDToPgtypeUUID(customerID), params.CustomerID)
+		assert.Equal(t, db.LeakStatusEnumIgnored, params.Status)
+		assert.Equal(t, "JPY", params.Currency)
+		assert.Equal(t, convertUUIDToPgtypeUUID(sourceEventID), params.SourceEventID)
+		assert.Equal(t, pgtype.Timestamptz{Time: detectedAt, Valid: true}, params.DetectedAt)
+	})
+}
+
+func TestLeakAmount_RoundTrip(t *testing.T) {
+	// The scale and every digit must survive, including past the ~7 significant digits of a float32
+	for _, value := range []string{"0.10", "19.99", "1234567890123.45", "0.01", "99999999999.99"} {
+		t.Run(value, func(t *testing.T) {
+			params := toCreateLeakDBParams(models.CreateLeakParams{Amount: models.MustParseDecimal(value)}, uuid.New())
+
+			// Go through the text form the driver sends and receives
+			encoded, err := params.Amount.Value()
+			require.NoError(t, err)
+			var stored pgtype.Numeric
+			require.NoError(t, stored.Scan(encoded))
+
+			leak := toLeakDomain(nil, db.Leak{Amount: stored})
+			assert.Equal(t, value, leak.Amount.String())
+		})
+	}
+}
+
+func TestToUpdateLeakDBParams(t *testing.T) {
+	id := uuid.New()
+
+	t.Run("nil fields stay NULL", func(t *testing.T) {
+		params, err := toUpdateLeakDBParams(models.UpdateLeakParams{ID: id})
+		require.NoError(t, err)
+
+		assert.Equal(t, convertUUIDToPgtypeUUID(id), params.ID)
+		assert.False(t, params.LeakType.Valid)
+		assert.False(t, params.Status.Valid)
+		assert.False(t, params.Amount.Valid)
+		assert.Nil(t, params.Currency)
+		assert.Nil(t, params.Confidence)
+		assert.False(t, params.ResolvedAt.Valid)
+		assert.Nil(t, params.Metadata)
+	})
+
+	t.Run("set fields", func(t *testing.T) {
+		status := models.LeakStatusEnumResolved
+		amount := models.MustParseDecimal("12.30")
+		resolvedAt := time.Now()
+
+		params, err := toUpdateLeakDBParams(models.UpdateLeakParams{
+			ID:         id,
+			Status:     &status,
+			Amount:     &amount,
+			ResolvedAt: &resolvedAt,
+			Metadata:   []byte(`{"note":"refunded"}`),
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, db.NullLeakStatusEnum{LeakStatusEnum: db.LeakStatusEnumResolved, Valid: true}, params.Status)
+		assert.Equal(t, "12.30", convertPgtypeNumericToDecimal(params.Amount).String())
+		assert.Equal(t, pgtype.Timestamptz{Time: resolvedAt, Valid: true}, params.ResolvedAt)
+		assert.JSONEq(t, `{"note":"refunded"}`, string(params.Metadata))
+	})
+}
+
+func TestLeaksRepository_ReadPoolRouting(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	primary, replica := newUnreachablePool(t), newUnreachablePool(t)
+	repo, err := NewLeaksRepository(primary, createTestLogger())
+	require.NoError(t, err)
+	repo = repo.WithReadPool(replica)
+
+	_, err = repo.GetLeakByID(ctx, uuid.New(), tenantID)
+	assert.Error(t, err)
+	assert.EqualValues(t, 1, replica.Stat().NewConnsCount(), "reads should target the replica")
+
+	_, err = repo.CreateLeak(ctx, models.CreateLeakParams{TenantID: tenantID, LeakType: models.LeakTypeEnumVolumeAnomaly, Confidence: 50}, tenantID)
+	assert.Error(t, err)
+	assert.EqualValues(t, 1, primary.Stat().NewConnsCount(), "writes should target the primary")
+}
+
+func TestToMinLeakAmountsDomain(t *testing.T) {
+	amounts, err := toMinLeakAmountsDomain([]byte(`{"usd": "1.00", "JPY": 150}`))
+	require.NoError(t, err)
+	assert.Equal(t, "1.00", amounts["USD"].String())
+	assert.Equal(t, "150", amounts["JPY"].String())
+
+	amounts, err = toMinLeakAmountsDomain(nil)
+	require.NoError(t, err)
+	assert.Empty(t, amounts)
+
+	_, err = toMinLeakAmountsDomain([]byte(`{"USD": "-1"}`))
+	assert.ErrorIs(t, err, models.ErrInvalidMinLeakAmount)
+	_, err = toMinLeakAmountsDomain([]byte(`{"USD": "cheap"}`))
+	assert.ErrorIs(t, err, models.ErrInvalidMinLeakAmount)
+}
+
+func TestToLeakSourcesDomain(t *testing.T) {
+	sources, err := toLeakSourcesDomain([]byte(`{"duplicate_charge": [], "volume_anomaly": ["payment_failed"]}`))
+	require.NoError(t, err)
+	assert.Equal(t, models.LeakSources{
+		models.LeakTypeEnumDuplicateCharge: {},
+		models.LeakTypeEnumVolumeAnomaly:   {models.EventTypeEnumPaymentFailed},
+	}, sources)
+
+	sources, err = toLeakSourcesDomain(nil)
+	require.NoError(t, err)
+	assert.Empty(t, sources)
+
+	_, err = toLeakSourcesDomain([]byte(`{"duplicate_charge": "payment_succeeded"}`))
+	assert.ErrorIs(t, err, models.ErrInvalidLeakSources)
+}
+
+func TestToLeakFilterDBArgs(t *testing.T) {
+	t.Run("multiple values", func(t *testing.T) {
+		from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+		args := toLeakFilterDBArgs(models.LeakFilter{
+			Statuses:       []models.LeakStatusEnum{models.LeakStatusEnumOpen, models.LeakStatusEnumIgnored},
+			LeakTypes:      []models.LeakTypeEnum{models.LeakTypeEnumFailedPayments},
+			DetectedFrom:   &from,
+			ExcludeSnoozed: true,
+		})
+
+		assert.Equal(t, []string{"open", "ignored"}, args.statuses)
+		assert.Equal(t, []string{"failed_payments"}, args.leakTypes)
+		assert.Equal(t, pgtype.Timestamptz{Time: from, Valid: true}, args.detectedFrom)
+		assert.False(t, args.detectedTo.Valid)
+		assert.True(t, args.excludeSnoozed)
+	})
+
+	t.Run("empty filter matches everything", func(t *testing.T) {
+		args := toLeakFilterDBArgs(models.LeakFilter{})
+
+		// Non-nil so the queries receive empty arrays rather than NULL
+		assert.NotNil(t, args.statuses)
+		assert.Empty(t, args.statuses)
+		assert.NotNil(t, args.leakTypes)
+		assert.Empty(t, args.leakTypes)
+		assert.False(t, args.detectedFrom.Valid)
+	})
+}
+
+func TestToLeakAmountHistogramDomain(t *testing.T) {
+	bounds := []models.Decimal{models.MustParseDecimal("10"), models.MustParseDecimal("100")}
+	numeric := func(s string) pgtype.Numeric {
+		var n pgtype.Numeric
+		require.NoError(t, n.Scan(s))
+		return n
+	}
+
+	buckets := toLeakAmountHistogramDomain(bounds, []db.GetLeakAmountHistogramRow{
+		{Currency: "EUR", Bucket: 2, Leaks: 1, Total: numeric("250.00")},
+		{Currency: "USD", Bucket: 0, Leaks: 2, Total: numeric("12.50")},
+		{Currency: "USD", Bucket: 1, Leaks: 1, Total: numeric("10.00")},
+	})
+
+	require.Len(t, buckets, 6, "each currency gets every bucket")
+	for i, currency := range []string{"EUR", "EUR", "EUR", "USD", "USD", "USD"} {
+		assert.Equal(t, currency, buckets[i].Currency)
+	}
+	assert.Nil(t, buckets[0].Lower)
+	assert.Equal(t, "10", buckets[0].Upper.String())
+	assert.Equal(t, "10", buckets[1].Lower.String())
+	assert.Equal(t, "100", buckets[1].Upper.String())
+	assert.Equal(t, "100", buckets[2].Lower.String())
+	assert.Nil(t, buckets[2].Upper)
+
+	assert.Zero(t, buckets[0].Count)
+	assert.True(t, buckets[0].Total.IsZero())
+	assert.Equal(t, int64(1), buckets[2].Count)
+	assert.Equal(t, "250.00", buckets[2].Total.String())
+	assert.Equal(t, int64(2), buckets[3].Count)
+	assert.Equal(t, "12.50", buckets[3].Total.String())
+	assert.Equal(t, int64(1), buckets[4].Count)
+	assert.Zero(t, buckets[5].Count)
+
+	assert.Empty(t, toLeakAmountHistogramDomain(bounds, nil))
+}
+
+func TestToLeakTimeSeriesDomain(t *testing.T) {
+	numeric := func(s string) pgtype.Numeric {
+		var n pgtype.Numeric
+		require.NoError(t, n.Scan(s))
+		return n
+	}
+	day := func(d int) pgtype.Timestamptz {
+		return pgtype.Timestamptz{Time: time.Date(2025, 3, d, 0, 0, 0, 0, time.UTC), Valid: true}
+	}
+
+	points := toLeakTimeSeriesDomain([]db.GetLeakTimeSeriesRow{
+		{BucketStart: day(1), Currency: pgtype.Text{String: "EUR", Valid: true}, Leaks: 1, Total: numeric("50.00")},
+		{BucketStart: day(1), Currency: pgtype.Text{String: "USD", Valid: true}, Leaks: 2, Total: numeric("12.50")},
+		{BucketStart: day(2), Total: numeric("0")},
+		{BucketStart: day(3), Currency: pgtype.Text{String: "USD", Valid: true}, Leaks: 1, Total: numeric("10.00")},
+	})
+
+	require.Len(t, points, 3, "one point per bucket")
+	assert.Equal(t, day(1).Time, points[0].Start)
+	assert.Equal(t, int64(3), points[0].Count, "counts add up across currencies")
+	require.Len(t, points[0].Totals, 2)
+	assert.Equal(t, "50.00", points[0].Totals["EUR"].String())
+	assert.Equal(t, "12.50", points[0].Totals["USD"].String())
+
+	assert.Equal(t, day(2).Time, points[1].Start)
+	assert.Zero(t, points[1].Count, "an empty bucket is a zero point")
+	assert.NotNil(t, points[1].Totals)
+	assert.Empty(t, points[1].Totals)
+
+	assert.Equal(t, int64(1), points[2].Count)
+	assert.Equal(t, "10.00", points[2].Totals["USD"].String())
+
+	assert.Empty(t, toLeakTimeSeriesDomain(nil))
+}
@@ -0,0 +1,238 @@
+// Package repository provides implementations of data access patterns for domain entities.
+// notification_channels.go provides CRUD operations for a tenant's notification channels and conversions between sqlc-generated channel rows and the domain NotificationChannel model.
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	db "rdl-api/internal/db/sqlc"
+	"rdl-api/internal/domain/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// NotificationChannelsRepositoryImplementation stores where each tenant's leak notifications are delivered
+type NotificationChannelsRepositoryImplementation struct {
+	pool   *Pool
+	logger *slog.Logger
+}
+
+// NewNotificationChannelsRepository creates a new instance of NotificationChannelsRepository backed by the provided Pool.
+//
+// Parameters:
+//   - pool: Pointer to Pool, which provides access to the database.
+//   - logger: Pointer to slog.Logger, which provides access to the logger.
+//
+// Returns:
+//   - NotificationChannelsRepositoryImplementation: The notification channels repository.
+//   - error: Any error encountered during initialization.
+func NewNotificationChannelsRepository(pool *Pool, l *slog.Logger) (NotificationChannelsRepositoryImplementation, error) {
+	if pool == nil {
+		return NotificationChannelsRepositoryImplementation{}, ErrPoolCannotBeNil
+	}
+	if l == nil {
+		return NotificationChannelsRepositoryImplementation{}, ErrLoggerCannotBeNil
+	}
+	return NotificationChannelsRepositoryImplementation{pool: pool, logger: l}, nil
+}
+
+// CreateNotificationChannel persists a new notification channel for the tenant.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - arg: CreateNotificationChannelParams containing the channel's type, target and whether it is enabled.
+//   - tenantID: UUID of the tenant that owns the channel.
+//
+// Returns:
+//   - models.NotificationChannel: The created channel as a domain model.
+//   - error: Any error encountered during creation.
+func (r NotificationChannelsRepositoryImplementation) CreateNotificationChannel(ctx context.Context, arg models.CreateNotificationChannelParams, tenantID uuid.UUID) (models.NotificationChannel, error) {
+	r.logger.DebugContext(ctx, "Creating notification channel", "channel_type", arg.ChannelType, "tenant_id", tenantID)
+
+	var channel models.NotificationChannel
+	err := WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		dbChannel, err := queries.CreateNotificationChannel(ctx, db.CreateNotificationChannelParams{
+			TenantID:    convertUUIDToPgtypeUUID(tenantID),
+			ChannelType: db.NotificationChannelTypeEnum(arg.ChannelType),
+			Target:      arg.Target,
+			Enabled:     arg.Enabled,
+		})
+		if err != nil {
+			return err
+		}
+
+		channel = toNotificationChannelDomain(dbChannel)
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to create notification channel", "error", err, "channel_type", arg.ChannelType, "tenant_id", tenantID)
+		return models.NotificationChannel{}, err
+	}
+
+	r.logger.InfoContext(ctx, "Notification channel created", "channel_id", channel.ID, "channel_type", channel.ChannelType, "tenant_id", tenantID)
+	return channel, nil
+}
+
+// GetNotificationChannelByID retrieves one of the tenant's notification channels.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - id: UUID of the channel to retrieve.
+//   - tenantID: UUID of the tenant that owns the channel.
+//
+// Returns:
+//   - models.NotificationChannel: The channel as a domain model.
+//   - error: ErrNotificationChannelNotFound if the channel does not exist, or any other error encountered during retrieval.
+func (r NotificationChannelsRepositoryImplementation) GetNotificationChannelByID(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (models.NotificationChannel, error) {
+	r.logger.DebugContext(ctx, "Retrieving notification channel by ID", "channel_id", id, "tenant_id", tenantID)
+
+	var channel models.NotificationChannel
+	err := WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		dbChannel, err := queries.GetNotificationChannelByID(ctx, convertUUIDToPgtypeUUID(id))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrNotificationChannelNotFound
+			}
+			return err
+		}
+
+		channel = toNotificationChannelDomain(dbChannel)
+		return nil
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrNotificationChannelNotFound) {
+			r.logger.WarnContext(ctx, "Notification channel not found", "channel_id", id, "tenant_id", tenantID)
+		} else {
+			r.logger.ErrorContext(ctx, "Failed to retrieve notification channel by ID", "error", err, "channel_id", id, "tenant_id", tenantID)
+		}
+		return models.NotificationChannel{}, err
+	}
+
+	return channel, nil
+}
+
+// GetNotificationChannels returns the tenant's notification channels, enabled or not, oldest first.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant whose channels to list.
+//
+// Returns:
+//   - []models.NotificationChannel: The tenant's channels.
+//   - error: Any error encountered during retrieval.
+func (r NotificationChannelsRepositoryImplementation) GetNotificationChannels(ctx context.Context, tenantID uuid.UUID) ([]models.NotificationChannel, error) {
+	r.logger.DebugContext(ctx, "Listing notification channels", "tenant_id", tenantID)
+
+	var channels []models.NotificationChannel
+	err := WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		rows, err := queries.ListNotificationChannels(ctx)
+		if err != nil {
+			return err
+		}
+
+		channels = make([]models.NotificationChannel, len(rows))
+		for i, row := range rows {
+			channels[i] = toNotificationChannelDomain(row)
+		}
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list notification channels", "error", err, "tenant_id", tenantID)
+		return nil, err
+	}
+	return channels, nil
+}
+
+// UpdateNotificationChannel updates a channel's target or enabled flag; fields left nil in arg are unchanged.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - arg: UpdateNotificationChannelParams containing the fields to update.
+//   - tenantID: UUID of the tenant that owns the channel.
+//
+// Returns:
+//   - models.NotificationChannel: The updated channel as a domain model.
+//   - error: ErrNotificationChannelNotFound if the channel does not exist, or any other error encountered during update.
+func (r NotificationChannelsRepositoryImplementation) UpdateNotificationChannel(ctx context.Context, arg models.UpdateNotificationChannelParams, tenantID uuid.UUID) (models.NotificationChannel, error) {
+	r.logger.DebugContext(ctx, "Updating notification channel", "channel_id", arg.ID, "tenant_id", tenantID)
+
+	var channel models.NotificationChannel
+	err := WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		dbChannel, err := queries.UpdateNotificationChannel(ctx, db.UpdateNotificationChannelParams{
+			Target:  arg.Target,
+			Enabled: arg.Enabled,
+			ID:      convertUUIDToPgtypeUUID(arg.ID),
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrNotificationChannelNotFound
+			}
+			return err
+		}
+
+		channel = toNotificationChannelDomain(dbChannel)
+		return nil
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrNotificationChannelNotFound) {
+			r.logger.WarnContext(ctx, "Notification channel not found for update", "channel_id", arg.ID, "tenant_id", tenantID)
+		} else {
+			r.logger.ErrorContext(ctx, "Failed to update notification channel", "error", err, "channel_id", arg.ID, "tenant_id", tenantID)
+		}
+		return models.NotificationChannel{}, err
+	}
+
+	r.logger.InfoContext(ctx, "Notification channel updated", "channel_id", channel.ID, "enabled", channel.Enabled, "tenant_id", tenantID)
+	return channel, nil
+}
+
+// DeleteNotificationChannel deletes one of the tenant's notification channels.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - id: UUID of the channel to delete.
+//   - tenantID: UUID of the tenant that owns the channel.
+//
+// Returns:
+//   - int64: The number of channels deleted, 0 when it did not exist.
+//   - error: Any error encountered during deletion.
+func (r NotificationChannelsRepositoryImplementation) DeleteNotificationChannel(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (int64, error) {
+	r.logger.DebugContext(ctx, "Deleting notification channel", "channel_id", id, "tenant_id", tenantID)
+
+	var rowsAffected int64
+	err := WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		rows, err := queries.DeleteNotificationChannel(ctx, convertUUIDToPgtypeUUID(id))
+		if err != nil {
+			return err
+		}
+		rowsAffected = rows
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to delete notification channel", "error", err, "channel_id", id, "tenant_id", tenantID)
+		return 0, err
+	}
+
+	r.logger.InfoContext(ctx, "Notification channel deleted", "channel_id", id, "rows_affected", rowsAffected, "tenant_id", tenantID)
+	return rowsAffected, nil
+}
+
+// toNotificationChannelDomain converts SQLC NotificationChannel to domain NotificationChannel
+func toNotificationChannelDomain(dbChannel db.NotificationChannel) models.NotificationChannel {
+	return models.NotificationChannel{
+		ID:          convertPgtypeUUIDToUUID(dbChannel.ID),
+		TenantID:    convertPgtypeUUIDToUUID(dbChannel.TenantID),
+		ChannelType: models.NotificationChannelTypeEnum(dbChannel.ChannelType),
+		Target:      dbChannel.Target,
+		Enabled:     dbChannel.Enabled,
+		CreatedAt:   dbChannel.CreatedAt.Time,
+		UpdatedAt:   dbChannel.UpdatedAt.Time,
+	}
+}
@@ -0,0 +1,69 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"rdl-api/internal/domain/models"
+)
+
+func TestNotificationChannelsRepository(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	otherTenantID, _ := seedTenant(t, pool)
+
+	repo, err := NewNotificationChannelsRepository(pool, createTestLogger())
+	require.NoError(t, err)
+
+	slack, err := repo.CreateNotificationChannel(ctx, models.CreateNotificationChannelParams{
+		ChannelType: models.NotificationChannelTypeEnumSlack,
+		Target:      "https://hooks.slack.com/services/T/B/X",
+		Enabled:     true,
+	}, tenantID)
+	require.NoError(t, err)
+	email, err := repo.CreateNotificationChannel(ctx, models.CreateNotificationChannelParams{
+		ChannelType: models.NotificationChannelTypeEnumEmail,
+		Target:      "billing@example.com",
+	}, tenantID)
+	require.NoError(t, err)
+	_, err = repo.CreateNotificationChannel(ctx, models.CreateNotificationChannelParams{
+		ChannelType: models.NotificationChannelTypeEnumWebhook,
+		Target:      "https://example.com/hook",
+		Enabled:     true,
+	}, otherTenantID)
+	require.NoError(t, err)
+
+	channels, err := repo.GetNotificationChannels(ctx, tenantID)
+	require.NoError(t, err)
+	require.Len(t, channels, 2, "another tenant's channel must not be listed")
+	assert.Equal(t, slack.ID, channels[0].ID)
+	assert.True(t, channels[0].Enabled)
+	assert.Equal(t, email.ID, channels[1].ID)
+	assert.False(t, channels[1].Enabled)
+
+	enabled := true
+	updated, err := repo.UpdateNotificationChannel(ctx, models.UpdateNotificationChannelParams{ID: email.ID, Enabled: &enabled}, tenantID)
+	require.NoError(t, err)
+	assert.True(t, updated.Enabled)
+	assert.Equal(t, "billing@example.com", updated.Target, "a nil target must leave it unchanged")
+
+	_, err = repo.GetNotificationChannelByID(ctx, slack.ID, otherTenantID)
+	assert.ErrorIs(t, err, ErrNotificationChannelNotFound)
+	_, err = repo.UpdateNotificationChannel(ctx, models.UpdateNotificationChannelParams{ID: uuid.New(), Enabled: &enabled}, tenantID)
+	assert.ErrorIs(t, err, ErrNotificationChannelNotFound)
+
+	deleted, err := repo.DeleteNotificationChannel(ctx, slack.ID, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	channels, err = repo.GetNotificationChannels(ctx, tenantID)
+	require.NoError(t, err)
+	require.Len(t, channels, 1)
+	assert.Equal(t, email.ID, channels[0].ID)
+}
@@ -0,0 +1,225 @@
+// Package repository provides implementations of data access patterns for domain entities.
+// notification_outbox.go stores the leak notifications still to be delivered, with their attempts and when each is next due, and converts sqlc-generated outbox rows to the domain OutboxNotification model.
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	db "rdl-api/internal/db/sqlc"
+	"rdl-api/internal/domain/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// NotificationOutboxRepositoryImplementation stores the notifications each tenant's channels have still to receive
+type NotificationOutboxRepositoryImplementation struct {
+	pool   *Pool
+	logger *slog.Logger
+}
+
+// NewNotificationOutboxRepository creates a new instance of NotificationOutboxRepository backed by the provided Pool.
+//
+// Parameters:
+//   - pool: Pointer to Pool, which provides access to the database.
+//   - logger: Pointer to slog.Logger, which provides access to the logger.
+//
+// Returns:
+//   - NotificationOutboxRepositoryImplementation: The notification outbox repository.
+//   - error: Any error encountered during initialization.
+func NewNotificationOutboxRepository(pool *Pool, l *slog.Logger) (NotificationOutboxRepositoryImplementation, error) {
+	if pool == nil {
+		return NotificationOutboxRepositoryImplementation{}, ErrPoolCannotBeNil
+	}
+	if l == nil {
+		return NotificationOutboxRepositoryImplementation{}, ErrLoggerCannotBeNil
+	}
+	return NotificationOutboxRepositoryImplementation{pool: pool, logger: l}, nil
+}
+
+// EnqueueNotification adds a notification for one of the tenant's channels to the outbox. The
+// row is stored with its first attempt started, for the caller to make straight away, and is
+// not retried until lease has passed.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - arg: CreateOutboxNotificationParams containing the channel and the notification's title and body.
+//   - tenantID: UUID of the tenant that owns the channel.
+//   - lease: How long the first attempt may take before the delivery is due again.
+//
+// Returns:
+//   - models.OutboxNotification: The stored notification.
+//   - error: ErrInvalidOutboxLease for a lease that is not positive, or any other error encountered.
+func (r NotificationOutboxRepositoryImplementation) EnqueueNotification(ctx context.Context, arg models.CreateOutboxNotificationParams, tenantID uuid.UUID, lease time.Duration) (models.OutboxNotification, error) {
+	if lease <= 0 {
+		return models.OutboxNotification{}, ErrInvalidOutboxLease
+	}
+
+	var notification models.OutboxNotification
+	err := WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		row, err := queries.EnqueueNotification(ctx, db.EnqueueNotificationParams{
+			TenantID:     convertUUIDToPgtypeUUID(tenantID),
+			ChannelID:    convertUUIDToPgtypeUUID(arg.ChannelID),
+			Title:        arg.Title,
+			Body:         arg.Body,
+			LeaseSeconds: lease.Seconds(),
+		})
+		if err != nil {
+			return err
+		}
+
+		notification = toOutboxNotificationDomain(row)
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to add notification to the outbox", "error", err, "channel_id", arg.ChannelID, "tenant_id", tenantID)
+		return models.OutboxNotification{}, err
+	}
+
+	r.logger.DebugContext(ctx, "Added notification to the outbox", "outbox_id", notification.ID, "channel_id", arg.ChannelID, "tenant_id", tenantID)
+	return notification, nil
+}
+
+// ClaimDueNotifications starts the next attempt of up to limit of the tenant's deliveries that
+// are due, longest waiting first, and holds them for lease so no other worker retries them
+// meanwhile. Rows locked by a concurrent claim are skipped rather than waited on.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant whose deliveries to claim.
+//   - limit: Maximum number of deliveries to claim.
+//   - lease: How long the attempt may take before the delivery is due again.
+//
+// Returns:
+//   - []models.OutboxNotification: The claimed deliveries; empty when none is due.
+//   - error: ErrInvalidClaimLimit or ErrInvalidOutboxLease for bad arguments, or any other error encountered.
+func (r NotificationOutboxRepositoryImplementation) ClaimDueNotifications(ctx context.Context, tenantID uuid.UUID, limit int, lease time.Duration) ([]models.OutboxNotification, error) {
+	if limit < 1 || limit > math.MaxInt32 {
+		return nil, ErrInvalidClaimLimit
+	}
+	if lease <= 0 {
+		return nil, ErrInvalidOutboxLease
+	}
+
+	notifications := []models.OutboxNotification{}
+	err := WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		rows, err := queries.ClaimDueNotifications(ctx, db.ClaimDueNotificationsParams{
+			LeaseSeconds: lease.Seconds(),
+			TenantID:     convertUUIDToPgtypeUUID(tenantID),
+			Limit:        int32(limit),
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			notifications = append(notifications, toOutboxNotificationDomain(row))
+		}
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to claim due outbox notifications", "error", err, "tenant_id", tenantID)
+		return nil, err
+	}
+
+	r.logger.DebugContext(ctx, "Claimed due outbox notifications", "tenant_id", tenantID, "count", len(notifications))
+	return notifications, nil
+}
+
+// FailOutboxAttempt records that an attempt to deliver a notification failed. A notification
+// attempted fewer than maxAttempts times is due again after backoff; otherwise the outbox gives
+// up on it, and the row is kept for inspection but never retried.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - id: UUID of the outbox notification.
+//   - tenantID: UUID of the tenant that owns the notification.
+//   - lastError: Why the attempt failed.
+//   - backoff: How long to wait before the next attempt.
+//   - maxAttempts: The number of attempts after which the outbox gives up.
+//
+// Returns:
+//   - models.OutboxNotification: The notification after the failure was recorded.
+//   - error: ErrOutboxNotificationNotFound if it is no longer in the outbox, or any other error encountered.
+func (r NotificationOutboxRepositoryImplementation) FailOutboxAttempt(ctx context.Context, id uuid.UUID, tenantID uuid.UUID, lastError string, backoff time.Duration, maxAttempts int) (models.OutboxNotification, error) {
+	if maxAttempts < 1 || maxAttempts > math.MaxInt32 {
+		return models.OutboxNotification{}, ErrInvalidMaxAttempts
+	}
+
+	var notification models.OutboxNotification
+	err := WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		row, err := queries.FailOutboxAttempt(ctx, db.FailOutboxAttemptParams{
+			LastError:      pgtype.Text{String: lastError, Valid: true},
+			BackoffSeconds: max(backoff, 0).Seconds(),
+			MaxAttempts:    int32(maxAttempts),
+			ID:             convertUUIDToPgtypeUUID(id),
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrOutboxNotificationNotFound
+			}
+			return err
+		}
+
+		notification = toOutboxNotificationDomain(row)
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to record failed outbox attempt", "error", err, "outbox_id", id, "tenant_id", tenantID)
+		return models.OutboxNotification{}, err
+	}
+
+	r.logger.DebugContext(ctx, "Recorded failed outbox attempt", "outbox_id", id, "tenant_id", tenantID, "attempts", notification.Attempts, "gave_up", notification.FailedAt != nil)
+	return notification, nil
+}
+
+// DeleteOutboxNotification removes a notification from the outbox once it is delivered.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - id: UUID of the outbox notification.
+//   - tenantID: UUID of the tenant that owns the notification.
+//
+// Returns:
+//   - int64: The number of notifications deleted, 0 when it was no longer in the outbox.
+//   - error: Any error encountered during deletion.
+func (r NotificationOutboxRepositoryImplementation) DeleteOutboxNotification(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (int64, error) {
+	var rowsAffected int64
+	err := WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		rows, err := queries.DeleteOutboxNotification(ctx, convertUUIDToPgtypeUUID(id))
+		if err != nil {
+			return err
+		}
+		rowsAffected = rows
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to delete outbox notification", "error", err, "outbox_id", id, "tenant_id", tenantID)
+		return 0, err
+	}
+	return rowsAffected, nil
+}
+
+// toOutboxNotificationDomain converts SQLC NotificationOutbox to domain OutboxNotification
+func toOutboxNotificationDomain(row db.NotificationOutbox) models.OutboxNotification {
+	return models.OutboxNotification{
+		ID:            convertPgtypeUUIDToUUID(row.ID),
+		TenantID:      convertPgtypeUUIDToUUID(row.TenantID),
+		ChannelID:     convertPgtypeUUIDToUUID(row.ChannelID),
+		Title:         row.Title,
+		Body:          row.Body,
+		Attempts:      row.Attempts,
+		NextAttemptAt: row.NextAttemptAt.Time,
+		LastError:     convertPgtypeTextToStringPtr(row.LastError),
+		FailedAt:      convertPgtypeTimestamptzToTimePtr(row.FailedAt),
+		CreatedAt:     row.CreatedAt.Time,
+	}
+}
@@ -0,0 +1,75 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"rdl-api/internal/domain/models"
+)
+
+func TestNotificationOutboxRepository(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+
+	channels, err := NewNotificationChannelsRepository(pool, createTestLogger())
+	require.NoError(t, err)
+	channel, err := channels.CreateNotificationChannel(ctx, models.CreateNotificationChannelParams{
+		ChannelType: models.NotificationChannelTypeEnumEmail,
+		Target:      "billing@example.com",
+		Enabled:     true,
+	}, tenantID)
+	require.NoError(t, err)
+
+	repo, err := NewNotificationOutboxRepository(pool, createTestLogger())
+	require.NoError(t, err)
+
+	stored, err := repo.EnqueueNotification(ctx, models.CreateOutboxNotificationParams{ChannelID: channel.ID, Title: "Leak", Body: "details"}, tenantID, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), stored.Attempts, "the first attempt starts as the row is stored")
+
+	t.Run("an attempt in flight is not claimed", func(t *testing.T) {
+		due, err := repo.ClaimDueNotifications(ctx, tenantID, 10, time.Minute)
+		require.NoError(t, err)
+		assert.Empty(t, due)
+	})
+
+	t.Run("a failed attempt is retried after its backoff", func(t *testing.T) {
+		failed, err := repo.FailOutboxAttempt(ctx, stored.ID, tenantID, "451 try again later", 0, 3)
+		require.NoError(t, err)
+		require.NotNil(t, failed.LastError)
+		assert.Equal(t, "451 try again later", *failed.LastError)
+		assert.Nil(t, failed.FailedAt)
+
+		due, err := repo.ClaimDueNotifications(ctx, tenantID, 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, due, 1)
+		assert.Equal(t, stored.ID, due[0].ID)
+		assert.Equal(t, int32(2), due[0].Attempts)
+	})
+
+	t.Run("the outbox gives up at max attempts", func(t *testing.T) {
+		failed, err := repo.FailOutboxAttempt(ctx, stored.ID, tenantID, "451 try again later", 0, 2)
+		require.NoError(t, err)
+		assert.NotNil(t, failed.FailedAt)
+
+		due, err := repo.ClaimDueNotifications(ctx, tenantID, 10, time.Minute)
+		require.NoError(t, err)
+		assert.Empty(t, due)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		deleted, err := repo.DeleteOutboxNotification(ctx, stored.ID, tenantID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+
+		_, err = repo.FailOutboxAttempt(ctx, stored.ID, tenantID, "gone", 0, 2)
+		assert.ErrorIs(t, err, ErrOutboxNotificationNotFound)
+	})
+}
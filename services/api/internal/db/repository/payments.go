@@ -0,0 +1,278 @@
+// Package repository provides implementations of data access patterns for domain entities.
+// payments.go provides create, read and update operations for payments, the search for payments in mismatched currencies, and conversions between sqlc-generated payment rows and the domain Payment model.
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	db "rdl-api/internal/db/sqlc"
+	"rdl-api/internal/domain/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// PaymentsRepositoryImplementation stores payments normalized from provider events, so revenue
+// calculations can query typed rows instead of Event.Data.
+type PaymentsRepositoryImplementation struct {
+	pool   *Pool
+	logger *slog.Logger
+}
+
+// NewPaymentsRepository creates a new instance of PaymentsRepository backed by the provided Pool.
+//
+// Parameters:
+//   - pool: Pointer to Pool, which provides access to the database.
+//   - logger: Pointer to slog.Logger, which provides access to the logger.
+//
+// Returns:
+//   - PaymentsRepositoryImplementation: The payments repository.
+//   - error: Any error encountered during initialization.
+func NewPaymentsRepository(pool *Pool, l *slog.Logger) (PaymentsRepositoryImplementation, error) {
+	if pool == nil {
+		return PaymentsRepositoryImplementation{}, ErrPoolCannotBeNil
+	}
+	if l == nil {
+		return PaymentsRepositoryImplementation{}, ErrLoggerCannotBeNil
+	}
+	return PaymentsRepositoryImplementation{pool: pool, logger: l}, nil
+}
+
+// GetPaymentByID retrieves a payment by its UUID.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - id: UUID of the payment to retrieve.
+//   - tenantID: UUID of the tenant that owns the payment.
+//
+// Returns:
+//   - models.Payment: The payment as a domain model.
+//   - error: ErrPaymentNotFound if the payment does not exist, or any other error encountered during retrieval.
+func (r PaymentsRepositoryImplementation) GetPaymentByID(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (models.Payment, error) {
+	r.logger.DebugContext(ctx, "Retrieving payment by ID", "payment_id", id, "tenant_id", tenantID)
+
+	var payment models.Payment
+	err := WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		dbPayment, err := queries.GetPaymentByID(ctx, convertUUIDToPgtypeUUID(id))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrPaymentNotFound
+			}
+			return err
+		}
+
+		payment = toPaymentDomain(r.pool.unknownEnums(), dbPayment)
+		return nil
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrPaymentNotFound) {
+			r.logger.WarnContext(ctx, "Payment not found", "payment_id", id, "tenant_id", tenantID)
+		} else {
+			r.logger.ErrorContext(ctx, "Failed to retrieve payment by ID", "error", err, "payment_id", id, "tenant_id", tenantID)
+		}
+		return models.Payment{}, err
+	}
+
+	return payment, nil
+}
+
+// CreatePayment persists a new payment.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - arg: CreatePaymentParams containing the payment details as a domain model.
+//   - tenantID: UUID of the tenant that owns the payment.
+//
+// Returns:
+//   - models.Payment: The created payment as a domain model.
+//   - error: ErrPaymentAlreadyExists if the event was already normalized into a payment, or any other error encountered during creation.
+func (r PaymentsRepositoryImplementation) CreatePayment(ctx context.Context, arg models.CreatePaymentParams, tenantID uuid.UUID) (models.Payment, error) {
+	r.logger.DebugContext(ctx, "Creating payment", "external_id", arg.ExternalID, "event_id", arg.EventID, "tenant_id", tenantID)
+
+	var payment models.Payment
+	err := WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		dbPayment, err := queries.CreatePayment(ctx, toCreatePaymentDBParams(arg, tenantID))
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				return ErrPaymentAlreadyExists
+			}
+			return err
+		}
+
+		payment = toPaymentDomain(r.pool.unknownEnums(), dbPayment)
+		return nil
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrPaymentAlreadyExists) {
+			r.logger.WarnContext(ctx, "Payment already exists for event", "event_id", arg.EventID, "tenant_id", tenantID)
+		} else {
+			r.logger.ErrorContext(ctx, "Failed to create payment", "error", err, "external_id", arg.ExternalID, "tenant_id", tenantID)
+		}
+		return models.Payment{}, err
+	}
+
+	r.logger.InfoContext(ctx, "Payment created", "payment_id", payment.ID, "status", payment.Status, "tenant_id", tenantID)
+	return payment, nil
+}
+
+// UpdatePayment updates an existing payment; fields left nil in arg are unchanged.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - arg: UpdatePaymentParams containing the fields to update.
+//   - tenantID: UUID of the tenant that owns the payment.
+//
+// Returns:
+//   - models.Payment: The updated payment as a domain model.
+//   - error: ErrPaymentNotFound if the payment does not exist, or any other error encountered during update.
+func (r PaymentsRepositoryImplementation) UpdatePayment(ctx context.Context, arg models.UpdatePaymentParams, tenantID uuid.UUID) (models.Payment, error) {
+	r.logger.DebugContext(ctx, "Updating payment", "payment_id", arg.ID, "tenant_id", tenantID)
+
+	params, err := toUpdatePaymentDBParams(arg)
+	if err != nil {
+		return models.Payment{}, err
+	}
+
+	var payment models.Payment
+	err = WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		dbPayment, err := queries.UpdatePayment(ctx, params)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrPaymentNotFound
+			}
+			return err
+		}
+
+		payment = toPaymentDomain(r.pool.unknownEnums(), dbPayment)
+		return nil
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrPaymentNotFound) {
+			r.logger.WarnContext(ctx, "Payment not found for update", "payment_id", arg.ID, "tenant_id", tenantID)
+		} else {
+			r.logger.ErrorContext(ctx, "Failed to update payment", "error", err, "payment_id", arg.ID, "tenant_id", tenantID)
+		}
+		return models.Payment{}, err
+	}
+
+	r.logger.InfoContext(ctx, "Payment updated", "payment_id", payment.ID, "status", payment.Status, "tenant_id", tenantID)
+	return payment, nil
+}
+
+// FindCurrencyMismatches finds the tenant's payments created since the given time in another
+// currency than the earliest payment with the same external ID, such as a refund in a
+// different currency from its charge. Only payments normalized from an event are returned, and
+// a mismatch already flagged by a currency_mismatch leak on that event is not returned again.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant that owns the payments.
+//   - since: Earliest creation time of the mismatched payments.
+//
+// Returns:
+//   - []models.CurrencyMismatch: The mismatched payments, oldest first.
+//   - error: Any error encountered during retrieval.
+func (r PaymentsRepositoryImplementation) FindCurrencyMismatches(ctx context.Context, tenantID uuid.UUID, since time.Time) ([]models.CurrencyMismatch, error) {
+	r.logger.DebugContext(ctx, "Finding currency mismatches", "tenant_id", tenantID, "since", since)
+
+	mismatches := []models.CurrencyMismatch{}
+	err := WithTenantReadContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		rows, err := queries.FindCurrencyMismatches(ctx, pgtype.Timestamptz{Time: since, Valid: true})
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			mismatches = append(mismatches, toCurrencyMismatchDomain(r.pool.unknownEnums(), row))
+		}
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to find currency mismatches", "error", err, "tenant_id", tenantID)
+		return nil, err
+	}
+
+	return mismatches, nil
+}
+
+// toCurrencyMismatchDomain converts a FindCurrencyMismatches row to a domain CurrencyMismatch.
+// A payment not matched to a customer converts to uuid.Nil.
+func toCurrencyMismatchDomain(enums *unknownEnums, row db.FindCurrencyMismatchesRow) models.CurrencyMismatch {
+	return models.CurrencyMismatch{
+		PaymentID:         convertPgtypeUUIDToUUID(row.ID),
+		OriginalPaymentID: convertPgtypeUUIDToUUID(row.OriginalID),
+		ExternalID:        row.ExternalID,
+		CustomerID:        convertPgtypeUUIDToUUID(row.CustomerID),
+		EventID:           convertPgtypeUUIDToUUID(row.EventID),
+		EventType:         toDomainEnum(enums, "event_type", string(row.EventType), eventTypes),
+		Amount:            convertPgtypeNumericToDecimal(row.Amount),
+		Currency:          row.Currency,
+		OriginalAmount:    convertPgtypeNumericToDecimal(row.OriginalAmount),
+		OriginalCurrency:  row.OriginalCurrency,
+		CreatedAt:         row.CreatedAt.Time,
+	}
+}
+
+// toPaymentDomain converts SQLC Payment to domain Payment.
+// An invalid amount converts to 0 and a NULL customer to uuid.Nil.
+func toPaymentDomain(enums *unknownEnums, dbPayment db.Payment) models.Payment {
+	return models.Payment{
+		ID:          convertPgtypeUUIDToUUID(dbPayment.ID),
+		TenantID:    convertPgtypeUUIDToUUID(dbPayment.TenantID),
+		CustomerID:  convertPgtypeUUIDToUUID(dbPayment.CustomerID),
+		ProviderID:  convertPgtypeUUIDToUUIDPtr(dbPayment.ProviderID),
+		EventID:     convertPgtypeUUIDToUUIDPtr(dbPayment.EventID),
+		ExternalID:  dbPayment.ExternalID,
+		Amount:      convertPgtypeNumericToDecimal(dbPayment.Amount),
+		Currency:    dbPayment.Currency,
+		Status:      toDomainEnum(enums, "payment_status", string(dbPayment.Status), paymentStatuses),
+		PaymentType: toDomainEnum(enums, "payment_type", string(dbPayment.PaymentType), paymentTypes),
+		CreatedAt:   dbPayment.CreatedAt.Time,
+		UpdatedAt:   dbPayment.UpdatedAt.Time,
+	}
+}
+
+// toCreatePaymentDBParams converts a domain CreatePaymentParams to a db.CreatePaymentParams for persistence
+func toCreatePaymentDBParams(arg models.CreatePaymentParams, tenantID uuid.UUID) db.CreatePaymentParams {
+	customerID := pgtype.UUID{}
+	if arg.CustomerID != uuid.Nil {
+		customerID = convertUUIDToPgtypeUUID(arg.CustomerID)
+	}
+
+	return db.CreatePaymentParams{
+		TenantID:    convertUUIDToPgtypeUUID(tenantID),
+		CustomerID:  customerID,
+		ProviderID:  convertNullableUUIDToPgtypeUUID(arg.ProviderID),
+		EventID:     convertNullableUUIDToPgtypeUUID(arg.EventID),
+		ExternalID:  arg.ExternalID,
+		Amount:      convertDecimalToPgtypeNumeric(arg.Amount),
+		Currency:    arg.Currency,
+		Status:      db.PaymentStatusEnum(arg.Status),
+		PaymentType: db.PaymentTypeEnum(arg.PaymentType),
+	}
+}
+
+// toUpdatePaymentDBParams converts a domain UpdatePaymentParams to a db.UpdatePaymentParams for persistence.
+// Nil fields become NULL parameters, which the query leaves unchanged.
+func toUpdatePaymentDBParams(arg models.UpdatePaymentParams) (db.UpdatePaymentParams, error) {
+	status, err := convertEnumsToNullableEnum[*db.PaymentStatusEnum, db.NullPaymentStatusEnum]((*db.PaymentStatusEnum)(arg.Status))
+	if err != nil {
+		return db.UpdatePaymentParams{}, err
+	}
+
+	return db.UpdatePaymentParams{
+		ID:         convertUUIDToPgtypeUUID(arg.ID),
+		CustomerID: convertNullableUUIDToPgtypeUUID(arg.CustomerID),
+		Amount:     convertNullableDecimalToPgtypeNumeric(arg.Amount),
+		Currency:   arg.Currency,
+		Status:     status,
+	}, nil
+}
@@ -0,0 +1,158 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"rdl-api/internal/domain/models"
+)
+
+func TestPaymentsRepository(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, customerID := seedTenant(t, pool)
+	providerID := seedProvider(t, pool)
+	eventID := seedEvent(t, pool, tenantID, providerID)
+
+	repo, err := NewPaymentsRepository(pool, createTestLogger())
+	require.NoError(t, err)
+
+	params := models.CreatePaymentParams{
+		ProviderID:  &providerID,
+		EventID:     &eventID,
+		ExternalID:  "pi_integration",
+		Amount:      models.MustParseDecimal("19.90"),
+		Currency:    "USD",
+		Status:      models.PaymentStatusEnumFailed,
+		PaymentType: models.PaymentTypeEnumWebhook,
+	}
+	created, err := repo.CreatePayment(ctx, params, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, uuid.Nil, created.CustomerID, "a payment from an event starts unmatched")
+	require.NotNil(t, created.EventID)
+	assert.Equal(t, eventID, *created.EventID)
+	assert.Equal(t, "19.90", created.Amount.String())
+
+	t.Run("event is normalized once", func(t *testing.T) {
+		_, err := repo.CreatePayment(ctx, params, tenantID)
+		assert.ErrorIs(t, err, ErrPaymentAlreadyExists)
+	})
+
+	t.Run("update matches customer and status", func(t *testing.T) {
+		status := models.PaymentStatusEnumSucceeded
+		updated, err := repo.UpdatePayment(ctx, models.UpdatePaymentParams{ID: created.ID, CustomerID: &customerID, Status: &status}, tenantID)
+		require.NoError(t, err)
+		assert.Equal(t, customerID, updated.CustomerID)
+		assert.Equal(t, models.PaymentStatusEnumSucceeded, updated.Status)
+		assert.Equal(t, "19.90", updated.Amount.String(), "fields left nil are unchanged")
+
+		got, err := repo.GetPaymentByID(ctx, created.ID, tenantID)
+		require.NoError(t, err)
+		assert.Equal(t, updated.Status, got.Status)
+	})
+
+	t.Run("missing payment", func(t *testing.T) {
+		_, err := repo.GetPaymentByID(ctx, uuid.New(), tenantID)
+		assert.ErrorIs(t, err, ErrPaymentNotFound)
+
+		_, err = repo.UpdatePayment(ctx, models.UpdatePaymentParams{ID: uuid.New()}, tenantID)
+		assert.ErrorIs(t, err, ErrPaymentNotFound)
+	})
+
+	t.Run("other tenant cannot see payment", func(t *testing.T) {
+		otherTenantID, _ := seedTenant(t, pool)
+		_, err := repo.GetPaymentByID(ctx, created.ID, otherTenantID)
+		assert.ErrorIs(t, err, ErrPaymentNotFound)
+	})
+}
+
+func TestFindCurrencyMismatches(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, customerID := seedTenant(t, pool)
+	providerID := seedProvider(t, pool)
+	since := time.Now().Add(-time.Hour)
+
+	repo, err := NewPaymentsRepository(pool, createTestLogger())
+	require.NoError(t, err)
+
+	// payment creates a payment normalized from a new event of the given type
+	payment := func(eventType, externalID, amount, currency string) models.Payment {
+		eventID := uuid.New()
+		seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+			_, err := tx.Exec(ctx,
+				"INSERT INTO events (id, tenant_id, provider_id, event_type, event_id, status, data) VALUES ($1, $2, $3, $4, $5, 'processed', '{}')",
+				eventID, tenantID, providerID, eventType, "evt_"+eventID.String())
+			require.NoError(t, err)
+		})
+		created, err := repo.CreatePayment(ctx, models.CreatePaymentParams{
+			ProviderID:  &providerID,
+			EventID:     &eventID,
+			ExternalID:  externalID,
+			Amount:      models.MustParseDecimal(amount),
+			Currency:    currency,
+			Status:      models.PaymentStatusEnumSucceeded,
+			PaymentType: models.PaymentTypeEnumWebhook,
+		}, tenantID)
+		require.NoError(t, err)
+		return created
+	}
+
+	// A charge refunded in its own currency, whatever the case
+	payment("payment_succeeded", "pi_matching", "50.00", "USD")
+	payment("payment_refunded", "pi_matching", "50.00", "usd")
+	// A charge refunded in another currency
+	charge := payment("payment_succeeded", "pi_mismatched", "50.00", "USD")
+	refund := payment("payment_refunded", "pi_mismatched", "46.10", "EUR")
+	_, err = repo.UpdatePayment(ctx, models.UpdatePaymentParams{ID: refund.ID, CustomerID: &customerID}, tenantID)
+	require.NoError(t, err)
+
+	mismatches, err := repo.FindCurrencyMismatches(ctx, tenantID, since)
+	require.NoError(t, err)
+	require.Len(t, mismatches, 1, "only the refund in another currency is a mismatch")
+	m := mismatches[0]
+	assert.Equal(t, refund.ID, m.PaymentID)
+	assert.Equal(t, charge.ID, m.OriginalPaymentID)
+	assert.Equal(t, "pi_mismatched", m.ExternalID)
+	assert.Equal(t, customerID, m.CustomerID)
+	assert.Equal(t, *refund.EventID, m.EventID)
+	assert.Equal(t, models.EventTypeEnumPaymentRefunded, m.EventType)
+	assert.Equal(t, "EUR", m.Currency)
+	assert.Equal(t, "46.10", m.Amount.String())
+	assert.Equal(t, "USD", m.OriginalCurrency)
+	assert.Equal(t, "50.00", m.OriginalAmount.String())
+
+	t.Run("nothing since a later time", func(t *testing.T) {
+		mismatches, err := repo.FindCurrencyMismatches(ctx, tenantID, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, mismatches)
+	})
+
+	t.Run("not found again once flagged", func(t *testing.T) {
+		seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+			_, err := tx.Exec(ctx,
+				"INSERT INTO leaks (tenant_id, customer_id, leak_type, amount, confidence, source_event_id) VALUES ($1, $2, 'currency_mismatch', 50, 75, $3)",
+				tenantID, customerID, *refund.EventID)
+			require.NoError(t, err)
+		})
+
+		mismatches, err := repo.FindCurrencyMismatches(ctx, tenantID, since)
+		require.NoError(t, err)
+		assert.Empty(t, mismatches)
+	})
+
+	t.Run("other tenants see none", func(t *testing.T) {
+		otherTenantID, _ := seedTenant(t, pool)
+		mismatches, err := repo.FindCurrencyMismatches(ctx, otherTenantID, since)
+		require.NoError(t, err)
+		assert.Empty(t, mismatches)
+	})
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	db "rdl-api/internal/db/sqlc"
+	"rdl-api/internal/domain/models"
+)
+
+func TestToPaymentDomain(t *testing.T) {
+	id := uuid.New()
+	tenantID := uuid.New()
+	customerID := uuid.New()
+	providerID := uuid.New()
+	eventID := uuid.New()
+	now := time.Now()
+
+	var amount pgtype.Numeric
+	require.NoError(t, amount.Scan("49.90"))
+
+	payment := toPaymentDomain(nil, db.Payment{
+		ID:          convertUUIDToPgtypeUUID(id),
+		TenantID:    convertUUIDToPgtypeUUID(tenantID),
+		CustomerID:  convertUUIDToPgtypeUUID(customerID),
+		ProviderID:  convertUUIDToPgtypeUUID(providerID),
+		EventID:     convertUUIDToPgtypeUUID(eventID),
+		ExternalID:  "pi_123",
+		Amount:      amount,
+		Currency:    "EUR",
+		Status:      db.PaymentStatusEnumSucceeded,
+		PaymentType: db.PaymentTypeEnumWebhook,
+		CreatedAt:   pgtype.Timestamptz{Time: now, Valid: true},
+		UpdatedAt:   pgtype.Timestamptz{Time: now, Valid: true},
+	})
+
+	assert.Equal(t, id, payment.ID)
+	assert.Equal(t, tenantID, payment.TenantID)
+	assert.Equal(t, customerID, payment.CustomerID)
+	require.NotNil(t, payment.ProviderID)
+	assert.Equal(t, providerID, *payment.ProviderID)
+	require.NotNil(t, payment.EventID)
+	assert.Equal(t, eventID, *payment.EventID)
+	assert.Equal(t, "pi_123", payment.ExternalID)
+	assert.Equal(t, "49.90", payment.Amount.String())
+	assert.Equal(t, "EUR", payment.Currency)
+	assert.Equal(t, models.PaymentStatusEnumSucceeded, payment.Status)
+	assert.Equal(t, models.PaymentTypeEnumWebhook, payment.PaymentType)
+	assert.Equal(t, now, payment.CreatedAt)
+}
+
+func TestToPaymentDomain_NullableColumns(t *testing.T) {
+	payment := toPaymentDomain(nil, db.Payment{
+		Status:      db.PaymentStatusEnumFailed,
+		PaymentType: db.PaymentTypeEnumHistory,
+	})
+
+	assert.Equal(t, uuid.Nil, payment.CustomerID)
+	assert.Nil(t, payment.ProviderID)
+	assert.Nil(t, payment.EventID)
+	assert.True(t, payment.Amount.IsZero())
+	assert.Equal(t, models.PaymentStatusEnumFailed, payment.Status)
+	assert.Equal(t, models.PaymentTypeEnumHistory, payment.PaymentType)
+}
+
+func TestToCreatePaymentDBParams(t *testing.T) {
+	tenantID := uuid.New()
+
+	t.Run("unmatched history payment", func(t *testing.T) {
+		params := toCreatePaymentDBParams(models.CreatePaymentParams{
+			ExternalID:  "pi_456",
+			Amount:      models.MustParseDecimal("0.10"),
+			Currency:    "USD",
+			Status:      models.PaymentStatusEnumPending,
+			PaymentType: models.PaymentTypeEnumHistory,
+		}, tenantID)
+
+		assert.Equal(t, convertUUIDToPgtypeUUID(tenantID), params.TenantID)
+		assert.False(t, params.CustomerID.Valid)
+		assert.False(t, params.ProviderID.Valid)
+		assert.False(t, params.EventID.Valid)
+		assert.Equal(t, "0.10", convertPgtypeNumericToDecimal(params.Amount).String())
+		assert.Equal(t, db.PaymentStatusEnumPending, params.Status)
+		assert.Equal(t, db.PaymentTypeEnumHistory, params.PaymentType)
+	})
+
+	t.Run("payment from an event", func(t *testing.T) {
+		customerID := uuid.New()
+		providerID := uuid.New()
+		eventID := uuid.New()
+
+		params := toCreatePaymentDBParams(models.CreatePaymentParams{
+			CustomerID:  customerID,
+			ProviderID:  &providerID,
+			EventID:     &eventID,
+			Status:      models.PaymentStatusEnumOther,
+			PaymentType: models.PaymentTypeEnumWebhook,
+		}, tenantID)
+
+		assert.Equal(t, convertUUIDToPgtypeUUID(customerID), params.CustomerID)
+		assert.Equal(t, convertUUIDToPgtypeUUID(providerID), params.ProviderID)
+		assert.Equal(t, convertUUIDToPgtypeUUID(eventID), params.EventID)
+		assert.Equal(t, db.PaymentStatusEnumOther, params.Status)
+		assert.Equal(t, db.PaymentTypeEnumWebhook, params.PaymentType)
+	})
+}
+
+func TestToUpdatePaymentDBParams(t *testing.T) {
+	id := uuid.New()
+
+	t.Run("nil fields stay NULL", func(t *testing.T) {
+		params, err := toUpdatePaymentDBParams(models.UpdatePaymentParams{ID: id})
+		require.NoError(t, err)
+
+		assert.Equal(t, convertUUIDToPgtypeUUID(id), params.ID)
+		assert.False(t, params.CustomerID.Valid)
+		assert.False(t, params.Amount.Valid)
+		assert.Nil(t, params.Currency)
+		assert.False(t, params.Status.Valid)
+	})
+
+	t.Run("set fields", func(t *testing.T) {
+		customerID := uuid.New()
+		amount := models.MustParseDecimal("1234567890123.45")
+		currency := "GBP"
+
+		for _, status := range []models.PaymentStatusEnum{
+			models.PaymentStatusEnumPending,
+			models.PaymentStatusEnumSucceeded,
+			models.PaymentStatusEnumFailed,
+			models.PaymentStatusEnumOther,
+		} {
+			params, err := toUpdatePaymentDBParams(models.UpdatePaymentParams{
+				ID:         id,
+				CustomerID: &customerID,
+				Amount:     &amount,
+				Currency:   &currency,
+				Status:     &status,
+			})
+			require.NoError(t, err)
+
+			assert.Equal(t, db.NullPaymentStatusEnum{PaymentStatusEnum: db.PaymentStatusEnum(status), Valid: true}, params.Status)
+			assert.Equal(t, convertUUIDToPgtypeUUID(customerID), params.CustomerID)
+			assert.Equal(t, "1234567890123.45", convertPgtypeNumericToDecimal(params.Amount).String())
+			assert.Equal(t, &currency, params.Currency)
+		}
+	})
+}
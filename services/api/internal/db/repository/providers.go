@@ -0,0 +1,159 @@
+// Package repository provides implementations of data access patterns for domain entities.
+// providers.go provides create, lookup and list operations for providers and conversions between sqlc-generated provider rows and the domain Provider model.
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	db "rdl-api/internal/db/sqlc"
+	"rdl-api/internal/domain/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ProvidersRepositoryImplementation stores the providers events come from. Providers are shared
+// by all tenants; a tenant's view of them adds the last event it received from each.
+type ProvidersRepositoryImplementation struct {
+	pool   *Pool
+	logger *slog.Logger
+}
+
+// NewProvidersRepository creates a new instance of ProvidersRepository backed by the provided Pool.
+//
+// Parameters:
+//   - pool: Pointer to Pool, which provides access to the database.
+//   - logger: Pointer to slog.Logger, which provides access to the logger.
+//
+// Returns:
+//   - ProvidersRepositoryImplementation: The providers repository.
+//   - error: Any error encountered during initialization.
+func NewProvidersRepository(pool *Pool, l *slog.Logger) (ProvidersRepositoryImplementation, error) {
+	if pool == nil {
+		return ProvidersRepositoryImplementation{}, ErrPoolCannotBeNil
+	}
+	if l == nil {
+		return ProvidersRepositoryImplementation{}, ErrLoggerCannotBeNil
+	}
+	return ProvidersRepositoryImplementation{pool: pool, logger: l}, nil
+}
+
+// CreateProvider persists a new provider. The providers table is not tenant-scoped, so this
+// runs without a tenant context.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - arg: CreateProviderParams containing the provider's name and type.
+//
+// Returns:
+//   - models.Provider: The created provider as a domain model.
+//   - error: Any error encountered during creation.
+func (r ProvidersRepositoryImplementation) CreateProvider(ctx context.Context, arg models.CreateProviderParams) (models.Provider, error) {
+	conn, err := r.pool.acquire(ctx)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to create provider", "error", err, "name", arg.Name)
+		return models.Provider{}, err
+	}
+	defer conn.Release()
+
+	dbProvider, err := db.New(conn).CreateProvider(ctx, db.CreateProviderParams{Name: arg.Name, ProviderType: arg.ProviderType})
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to create provider", "error", err, "name", arg.Name)
+		return models.Provider{}, err
+	}
+
+	provider := toProviderDomain(dbProvider)
+	r.logger.InfoContext(ctx, "Provider created", "provider_id", provider.ID, "name", provider.Name, "provider_type", provider.ProviderType)
+	return provider, nil
+}
+
+// GetProviders returns the providers the tenant has an integration with or has received events
+// from, by name, each with the time of the tenant's latest event from it. Status is left for
+// the caller to derive.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant whose providers to list.
+//
+// Returns:
+//   - []models.TenantProvider: The tenant's providers.
+//   - error: Any error encountered during retrieval.
+func (r ProvidersRepositoryImplementation) GetProviders(ctx context.Context, tenantID uuid.UUID) ([]models.TenantProvider, error) {
+	r.logger.DebugContext(ctx, "Listing providers", "tenant_id", tenantID)
+
+	var providers []models.TenantProvider
+	err := WithTenantContext(ctx, r.pool, tenantID, func(queries *db.Queries) error {
+		rows, err := queries.ListTenantProviders(ctx, convertUUIDToPgtypeUUID(tenantID))
+		if err != nil {
+			return err
+		}
+
+		providers = make([]models.TenantProvider, len(rows))
+		for i, row := range rows {
+			providers[i] = toTenantProviderDomain(row)
+		}
+		return nil
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list providers", "error", err, "tenant_id", tenantID)
+		return nil, err
+	}
+	return providers, nil
+}
+
+// GetProviderType returns the type of a provider. Providers are shared by all tenants, so this
+// runs without a tenant context.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - providerID: UUID of the provider.
+//
+// Returns:
+//   - string: The provider's type, such as stripe.
+//   - error: ErrProviderNotFound when there is no such provider, or any other error encountered.
+func (r ProvidersRepositoryImplementation) GetProviderType(ctx context.Context, providerID uuid.UUID) (string, error) {
+	conn, err := r.pool.acquire(ctx)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to get provider type", "error", err, "provider_id", providerID)
+		return "", err
+	}
+	defer conn.Release()
+
+	providerType, err := db.New(conn).GetProviderTypeByID(ctx, convertUUIDToPgtypeUUID(providerID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrProviderNotFound
+	}
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to get provider type", "error", err, "provider_id", providerID)
+		return "", err
+	}
+	return providerType, nil
+}
+
+// toProviderDomain converts SQLC Provider to domain Provider
+func toProviderDomain(dbProvider db.Provider) models.Provider {
+	return models.Provider{
+		ID:           convertPgtypeUUIDToUUID(dbProvider.ID),
+		Name:         dbProvider.Name,
+		ProviderType: dbProvider.ProviderType,
+		CreatedAt:    dbProvider.CreatedAt.Time,
+		UpdatedAt:    dbProvider.UpdatedAt.Time,
+	}
+}
+
+// toTenantProviderDomain converts a ListTenantProviders row to a domain TenantProvider.
+// A NULL last event time converts to nil.
+func toTenantProviderDomain(row db.ListTenantProvidersRow) models.TenantProvider {
+	return models.TenantProvider{
+		Provider: models.Provider{
+			ID:           convertPgtypeUUIDToUUID(row.ID),
+			Name:         row.Name,
+			ProviderType: row.ProviderType,
+			CreatedAt:    row.CreatedAt.Time,
+			UpdatedAt:    row.UpdatedAt.Time,
+		},
+		LastEventAt: convertPgtypeTimestamptzToTimePtr(row.LastEventAt),
+	}
+}
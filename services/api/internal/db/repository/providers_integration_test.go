@@ -0,0 +1,67 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"rdl-api/internal/domain/models"
+)
+
+func TestProvidersRepository(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+	otherTenantID, _ := seedTenant(t, pool)
+	withEvents := seedProvider(t, pool)
+	connected := seedProvider(t, pool)
+	unrelated := seedProvider(t, pool)
+
+	earlier := seedEvent(t, pool, tenantID, withEvents)
+	latest := seedEvent(t, pool, tenantID, withEvents)
+	seedEvent(t, pool, otherTenantID, unrelated)
+	lastEventAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "UPDATE events SET created_at = $1 WHERE id = $2", lastEventAt.Add(-time.Hour), earlier)
+		require.NoError(t, err)
+		_, err = tx.Exec(ctx, "UPDATE events SET created_at = $1 WHERE id = $2", lastEventAt, latest)
+		require.NoError(t, err)
+		_, err = tx.Exec(ctx, "INSERT INTO integrations (tenant_id, provider_id) VALUES ($1, $2)", tenantID, connected)
+		require.NoError(t, err)
+	})
+
+	repo, err := NewProvidersRepository(pool, createTestLogger())
+	require.NoError(t, err)
+
+	providers, err := repo.GetProviders(ctx, tenantID)
+	require.NoError(t, err)
+
+	byID := map[uuid.UUID]models.TenantProvider{}
+	for _, provider := range providers {
+		byID[provider.ID] = provider
+	}
+	require.Contains(t, byID, withEvents)
+	require.NotNil(t, byID[withEvents].LastEventAt)
+	assert.True(t, byID[withEvents].LastEventAt.Equal(lastEventAt), "expected the latest event time, got %s", byID[withEvents].LastEventAt)
+	require.Contains(t, byID, connected)
+	assert.Nil(t, byID[connected].LastEventAt)
+	assert.NotContains(t, byID, unrelated, "another tenant's provider must not be listed")
+
+	created, err := repo.CreateProvider(ctx, models.CreateProviderParams{Name: "integration created", ProviderType: "stripe"})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+			_, err := tx.Exec(ctx, "DELETE FROM providers WHERE id = $1", created.ID)
+			require.NoError(t, err)
+		})
+	})
+	assert.NotEqual(t, uuid.Nil, created.ID)
+	assert.Equal(t, "stripe", created.ProviderType)
+}
@@ -2,46 +2,375 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
 	db "rdl-api/internal/db/sqlc"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-// WithTenantContext executes a function with tenant context set
-func WithTenantContext(ctx context.Context, pool *pgxpool.Pool, tenantID uuid.UUID, fn func(*db.Queries) error) error {
+// minQueryBudget is the least time a request must have left before its deadline for a query to be issued.
+// Below this the query would almost certainly time out, so it is skipped rather than tying up a connection.
+const minQueryBudget = 50 * time.Millisecond
+
+// PoolOptions are the limits a Pool applies to the tenant transactions opened on it. The
+// zero value applies none.
+type PoolOptions struct {
+	// AcquireTimeout bounds how long a transaction waits for a free connection. When the pool
+	// is at its maximum size and none frees up in time, the transaction fails with
+	// ErrServiceOverloaded instead of queueing the request until its own deadline. Zero waits
+	// as long as the context allows.
+	AcquireTimeout time.Duration
+	// AcquireWarnThreshold makes a transaction log a warning on Logger whenever it waits longer
+	// than this for a connection. The wait is timed apart from the query itself, so a
+	// saturated pool shows up even when every query is fast. Zero disables the warning.
+	AcquireWarnThreshold time.Duration
+	// MaxTxPerTenant bounds the transactions opened by BeginTenantTx and WithTenantContext that
+	// one tenant may have open at once, so a tenant running many concurrent writes cannot
+	// starve the others of connections. A transaction over the limit fails at once with
+	// ErrServiceOverloaded rather than waiting for a connection. WithTenantReadContext is
+	// exempt. Zero is unlimited.
+	MaxTxPerTenant int
+	// UnknownEnumPolicy sets what rows read through the pool do with an enum value this build
+	// does not know: UnknownEnumPolicyMap, the default, replaces it with UnknownEnumValue so
+	// clients never see a value outside the documented set, and UnknownEnumPolicyPassthrough
+	// keeps it. Either way the value is logged as a warning the first time it is seen.
+	UnknownEnumPolicy string
+	// Logger receives the slow acquire, query budget and unknown enum warnings; nil logs to
+	// slog.Default
+	Logger *slog.Logger
+}
+
+// Pool is a connection pool together with the limits applied to the tenant transactions
+// opened on it. Every repository takes one.
+type Pool struct {
+	*pgxpool.Pool
+	opts    PoolOptions
+	txSlots *tenantTxSlots
+	enums   *unknownEnums
+}
+
+// NewPool wraps pool with the limits in opts
+func NewPool(pool *pgxpool.Pool, opts PoolOptions) *Pool {
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	return &Pool{
+		Pool:    pool,
+		opts:    opts,
+		txSlots: &tenantTxSlots{open: map[uuid.UUID]int{}},
+		enums:   &unknownEnums{passthrough: opts.UnknownEnumPolicy == UnknownEnumPolicyPassthrough, logger: opts.Logger},
+	}
+}
+
+// unknownEnums returns the pool's handling of unknown enum values, or nil for a nil pool
+func (p *Pool) unknownEnums() *unknownEnums {
+	if p == nil {
+		return nil
+	}
+	return p.enums
+}
+
+// logger returns the pool's logger, or slog.Default for a nil pool
+func (p *Pool) logger() *slog.Logger {
+	if p == nil {
+		return slog.Default()
+	}
+	return p.opts.Logger
+}
+
+// acquire takes a connection from the pool, waiting at most its AcquireTimeout
+func (p *Pool) acquire(ctx context.Context) (*pgxpool.Conn, error) {
+	return acquireConn(ctx, p.Pool, p.opts.AcquireTimeout)
+}
+
+// tenantTxSlots counts the write transactions each tenant has open
+type tenantTxSlots struct {
+	mu   sync.Mutex
+	open map[uuid.UUID]int
+}
+
+// takeTenantTxSlot counts a new transaction against the tenant's budget, returning the func
+// that gives the slot back, or ErrServiceOverloaded when the tenant has no slot left
+func (p *Pool) takeTenantTxSlot(tenantID uuid.UUID) (release func(), err error) {
+	limit := p.opts.MaxTxPerTenant
+	if limit <= 0 {
+		return func() {}, nil
+	}
+
+	slots := p.txSlots
+	slots.mu.Lock()
+	defer slots.mu.Unlock()
+	if slots.open[tenantID] >= limit {
+		return nil, fmt.Errorf("%w: tenant %s already has %d transactions open", ErrServiceOverloaded, tenantID, limit)
+	}
+	slots.open[tenantID]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			slots.mu.Lock()
+			defer slots.mu.Unlock()
+			if slots.open[tenantID]--; slots.open[tenantID] <= 0 {
+				delete(slots.open, tenantID)
+			}
+		})
+	}, nil
+}
+
+// connPool is the part of *pgxpool.Pool used to take a connection
+type connPool interface {
+	Acquire(ctx context.Context) (*pgxpool.Conn, error)
+	Stat() *pgxpool.Stat
+}
+
+// txContextKey is the context key for a request-scoped tenant transaction
+type txContextKey struct{}
+
+// requestTx is a transaction stored in a request context together with the tenant it was opened
+// for and the functions to run once it commits
+type requestTx struct {
+	tx          pgx.Tx
+	tenantID    uuid.UUID
+	afterCommit *commitHooks
+}
+
+// commitHooks are the functions registered with AfterCommit on one request-scoped transaction
+type commitHooks struct {
+	mu  sync.Mutex
+	fns []func(context.Context)
+}
+
+// ContextWithTx returns a copy of ctx carrying tx, a transaction already scoped to tenantID.
+// WithTenantContext calls for the same tenant made with the returned context run inside tx
+// instead of opening their own; the caller owns tx, decides whether it commits and, when it
+// does, calls RunAfterCommit.
+func ContextWithTx(ctx context.Context, tenantID uuid.UUID, tx pgx.Tx) context.Context {
+	return context.WithValue(ctx, txContextKey{}, requestTx{tx: tx, tenantID: tenantID, afterCommit: &commitHooks{}})
+}
+
+// AfterCommit defers fn until the request-scoped transaction in ctx has committed, for work
+// that must see what the transaction wrote and must not be able to abort it, such as leak
+// detection and notifications. fn is dropped if the transaction rolls back. Without a
+// request-scoped transaction fn runs at once with ctx.
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	rtx, ok := ctx.Value(txContextKey{}).(requestTx)
+	if !ok {
+		fn(ctx)
+		return
+	}
+	rtx.afterCommit.mu.Lock()
+	defer rtx.afterCommit.mu.Unlock()
+	rtx.afterCommit.fns = append(rtx.afterCommit.fns, fn)
+}
+
+// RunAfterCommit runs the functions registered with AfterCommit on the transaction in txCtx, in
+// the order they were registered, passing them ctx. The owner of the transaction calls it once
+// the transaction has committed; ctx should not carry the transaction.
+func RunAfterCommit(txCtx context.Context, ctx context.Context) {
+	rtx, ok := txCtx.Value(txContextKey{}).(requestTx)
+	if !ok {
+		return
+	}
+	rtx.afterCommit.mu.Lock()
+	fns := rtx.afterCommit.fns
+	rtx.afterCommit.fns = nil
+	rtx.afterCommit.mu.Unlock()
+	for _, fn := range fns {
+		fn(ctx)
+	}
+}
+
+// TxFromContext returns the request-scoped transaction stored in ctx, if any
+func TxFromContext(ctx context.Context) (pgx.Tx, bool) {
+	rtx, ok := ctx.Value(txContextKey{}).(requestTx)
+	if !ok {
+		return nil, false
+	}
+	return rtx.tx, true
+}
+
+// BeginTenantTx acquires a connection and opens a transaction with the tenant context set.
+// The caller must commit or roll back the transaction and then call release to return the
+// connection to the pool. The transaction counts against the tenant's budget set by the
+// pool's MaxTxPerTenant until release is called.
+func BeginTenantTx(ctx context.Context, pool *Pool, tenantID uuid.UUID) (tx pgx.Tx, release func(), err error) {
+	return beginTenantTx(ctx, pool, tenantID, true, pgx.TxOptions{})
+}
+
+// beginTenantTx is BeginTenantTx, opening the transaction with opts and counting it against
+// the tenant's budget only when budgeted is true
+func beginTenantTx(ctx context.Context, pool *Pool, tenantID uuid.UUID, budgeted bool, opts pgx.TxOptions) (tx pgx.Tx, release func(), err error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline); remaining < minQueryBudget {
+			pool.logger().WarnContext(ctx, "Skipping query too close to the request deadline",
+				"tenant_id", tenantID,
+				"remaining", remaining.Round(time.Millisecond),
+				"min_budget", minQueryBudget,
+			)
+			return nil, nil, fmt.Errorf("%w: %s remaining, need at least %s", ErrQueryTimeout, remaining.Round(time.Millisecond), minQueryBudget)
+		}
+	}
+
+	releaseSlot := func() {}
+	if budgeted {
+		if releaseSlot, err = pool.takeTenantTxSlot(tenantID); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	// Get a connection from the pool
-	conn, err := pool.Acquire(ctx)
+	conn, err := acquireTenantConn(ctx, pool.Pool, pool.opts, tenantID)
 	if err != nil {
-		return ErrFailedToAcquireConnection
+		releaseSlot()
+		return nil, nil, err
+	}
+	release = func() {
+		conn.Release()
+		releaseSlot()
 	}
-	defer conn.Release()
 
 	// Begin a transaction
-	tx, err := conn.Begin(ctx)
+	tx, err = conn.BeginTx(ctx, opts)
 	if err != nil {
-		return err
+		release()
+		return nil, nil, err
 	}
-	defer tx.Rollback(ctx) // Will be no-op if committed
 
 	// Set the tenant ID in the session
-	_, err = tx.Exec(ctx, "SET LOCAL app.current_tenant_id = $1", tenantID.String())
-	if err != nil {
-		return ErrSettingTenantID
+	if _, err = tx.Exec(ctx, "SET LOCAL app.current_tenant_id = $1", tenantID.String()); err != nil {
+		_ = tx.Rollback(ctx)
+		release()
+		return nil, nil, ErrSettingTenantID
 	}
 
 	// Set service account flag to false for regular operations
-	_, err = tx.Exec(ctx, "SET LOCAL app.is_service_account = false")
+	if _, err = tx.Exec(ctx, "SET LOCAL app.is_service_account = false"); err != nil {
+		_ = tx.Rollback(ctx)
+		release()
+		return nil, nil, ErrFailedToSetServiceAccount
+	}
+
+	return tx, release, nil
+}
+
+// acquireTenantConn is acquireConn for a tenant's transaction, logging a warning with the
+// pool's stats when the wait exceeds opts.AcquireWarnThreshold, whether or not a connection
+// was obtained in the end
+func acquireTenantConn(ctx context.Context, pool connPool, opts PoolOptions, tenantID uuid.UUID) (*pgxpool.Conn, error) {
+	start := time.Now()
+	conn, err := acquireConn(ctx, pool, opts.AcquireTimeout)
+	waited := time.Since(start)
+
+	if threshold := opts.AcquireWarnThreshold; threshold > 0 && waited > threshold {
+		stat := pool.Stat()
+		opts.Logger.WarnContext(ctx, "Slow database connection acquire",
+			"tenant_id", tenantID,
+			"wait", waited.Round(time.Millisecond),
+			"threshold", threshold,
+			"acquired", err == nil,
+			"pool_acquired_conns", stat.AcquiredConns(),
+			"pool_idle_conns", stat.IdleConns(),
+			"pool_total_conns", stat.TotalConns(),
+			"pool_max_conns", stat.MaxConns(),
+		)
+	}
+	return conn, err
+}
+
+// acquireConn takes a connection from pool, waiting at most timeout, or as long as ctx allows
+// when it is zero. Running out of that time while every connection is in use means the pool
+// is exhausted and is reported as ErrServiceOverloaded; any other failure is
+// ErrFailedToAcquireConnection.
+func acquireConn(ctx context.Context, pool connPool, timeout time.Duration) (*pgxpool.Conn, error) {
+	acquireCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		acquireCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	conn, err := pool.Acquire(acquireCtx)
+	if err == nil {
+		return conn, nil
+	}
+	if ctx.Err() == nil && acquireCtx.Err() != nil {
+		if stat := pool.Stat(); stat.TotalConns() >= stat.MaxConns() {
+			return nil, fmt.Errorf("%w: no connection freed up within %s (%d of %d in use)", ErrServiceOverloaded, timeout, stat.AcquiredConns(), stat.MaxConns())
+		}
+	}
+	return nil, ErrFailedToAcquireConnection
+}
+
+// WithTenantContext executes a function with tenant context set.
+// If ctx has less than minQueryBudget left before its deadline, it returns ErrQueryTimeout
+// (with the remaining budget in the message) without acquiring a connection.
+// If ctx carries a request-scoped transaction for the same tenant (see ContextWithTx), fn runs
+// inside it and committing is left to its owner; otherwise fn runs in its own transaction,
+// which is committed when fn succeeds and counts against the tenant's budget set by the pool's
+// MaxTxPerTenant.
+func WithTenantContext(ctx context.Context, pool *Pool, tenantID uuid.UUID, fn func(*db.Queries) error) error {
+	return runTenantTx(ctx, pool, tenantID, true, func(tx pgx.Tx) error {
+		// Create a new Queries instance with the connection that has the session context
+		return fn(db.New(tx))
+	})
+}
+
+// WithTenantReadContext is WithTenantContext for read-heavy queries, such as listings, counts
+// and exports. Its transaction does not count against the tenant's budget set by the pool's
+// MaxTxPerTenant, which exists to stop one tenant's writes from taking every connection.
+func WithTenantReadContext(ctx context.Context, pool *Pool, tenantID uuid.UUID, fn func(*db.Queries) error) error {
+	return runTenantTx(ctx, pool, tenantID, false, func(tx pgx.Tx) error {
+		return fn(db.New(tx))
+	})
+}
+
+// withTenantTx is WithTenantContext for callers that need the transaction itself, for
+// example to open savepoints
+func withTenantTx(ctx context.Context, pool *Pool, tenantID uuid.UUID, fn func(pgx.Tx) error) error {
+	return runTenantTx(ctx, pool, tenantID, true, fn)
+}
+
+// runTenantTx runs fn in the request-scoped transaction for the tenant if ctx has one, and in
+// a new transaction otherwise, counted against the tenant's budget when budgeted is true
+func runTenantTx(ctx context.Context, pool *Pool, tenantID uuid.UUID, budgeted bool, fn func(pgx.Tx) error) error {
+	if rtx, ok := ctx.Value(txContextKey{}).(requestTx); ok && rtx.tenantID == tenantID {
+		return fn(rtx.tx)
+	}
+
+	tx, release, err := beginTenantTx(ctx, pool, tenantID, budgeted, pgx.TxOptions{})
 	if err != nil {
-		return ErrFailedToSetServiceAccount
+		return err
 	}
+	defer release()
+	defer tx.Rollback(ctx) // Will be no-op if committed
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
 
-	// Create a new Queries instance with the connection that has the session context
-	tenantQueries := db.New(tx)
-	err = fn(tenantQueries)
+// withTenantSnapshot runs fn in a read-only repeatable-read transaction with the tenant
+// context set, so every query fn makes sees the database as of the same moment. Like
+// WithTenantReadContext it does not count against the tenant's budget, and it never joins a
+// request-scoped transaction, whose isolation level is already fixed.
+func withTenantSnapshot(ctx context.Context, pool *Pool, tenantID uuid.UUID, fn func(pgx.Tx) error) error {
+	tx, release, err := beginTenantTx(ctx, pool, tenantID, false, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
 	if err != nil {
 		return err
 	}
+	defer release()
+	defer tx.Rollback(ctx) // Will be no-op if committed
+
+	if err := fn(tx); err != nil {
+		return err
+	}
 
-	return nil
+	return tx.Commit(ctx)
 }
@@ -0,0 +1,162 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	db "rdl-api/internal/db/sqlc"
+	"rdl-api/internal/domain/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTenantContext_CommitsWrites(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, _ := seedTenant(t, pool)
+
+	providerID := uuid.New()
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "INSERT INTO providers (id, name) VALUES ($1, $2)", providerID, "integration provider")
+		require.NoError(t, err)
+	})
+	t.Cleanup(func() {
+		seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+			_, err := tx.Exec(ctx, "DELETE FROM providers WHERE id = $1", providerID)
+			require.NoError(t, err)
+		})
+	})
+
+	eventsRepo, err := NewEventsRepository(pool, createTestLogger())
+	require.NoError(t, err)
+
+	created, err := eventsRepo.CreateEvent(ctx, models.CreateEventParams{
+		TenantID:   tenantID,
+		ProviderID: providerID,
+		EventType:  models.EventTypeEnumPaymentFailed,
+		EventID:    "evt_" + uuid.NewString(),
+		Status:     models.EventStatusEnumPending,
+		Data:       `{"amount": 10}`,
+	}, tenantID)
+	require.NoError(t, err)
+
+	stored, err := eventsRepo.GetEventByID(ctx, created.ID, tenantID)
+	require.NoError(t, err, "a write through WithTenantContext must be committed")
+	assert.Equal(t, created.EventID, stored.EventID)
+}
+
+func TestRequestTransaction(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, customerID := seedTenant(t, pool)
+
+	leaksRepo, err := NewLeaksRepository(pool, createTestLogger())
+	require.NoError(t, err)
+
+	createTwoLeaks := func(ctx context.Context) []models.Leak {
+		var leaks []models.Leak
+		for range 2 {
+			leak, err := leaksRepo.CreateLeak(ctx, models.CreateLeakParams{
+				TenantID:   tenantID,
+				CustomerID: customerID,
+				LeakType:   models.LeakTypeEnumFailedPayments,
+				Amount:     models.NewDecimal(10, 0),
+				Confidence: 80,
+			}, tenantID)
+			require.NoError(t, err)
+			leaks = append(leaks, leak)
+		}
+		return leaks
+	}
+
+	t.Run("rollback discards every enlisted write", func(t *testing.T) {
+		tx, release, err := BeginTenantTx(ctx, pool, tenantID)
+		require.NoError(t, err)
+		defer release()
+
+		leaks := createTwoLeaks(ContextWithTx(ctx, tenantID, tx))
+		require.NoError(t, tx.Rollback(ctx))
+
+		for _, leak := range leaks {
+			_, err := leaksRepo.GetLeakByID(ctx, leak.ID, tenantID)
+			assert.ErrorIs(t, err, ErrLeakNotFound)
+		}
+	})
+
+	t.Run("commit keeps every enlisted write", func(t *testing.T) {
+		tx, release, err := BeginTenantTx(ctx, pool, tenantID)
+		require.NoError(t, err)
+		defer release()
+
+		leaks := createTwoLeaks(ContextWithTx(ctx, tenantID, tx))
+		require.NoError(t, tx.Commit(ctx))
+
+		for _, leak := range leaks {
+			_, err := leaksRepo.GetLeakByID(ctx, leak.ID, tenantID)
+			assert.NoError(t, err)
+		}
+	})
+}
+
+func TestBeginTenantTx_PoolExhausted(t *testing.T) {
+	url := os.Getenv("POSTGRES_URL")
+	if url == "" {
+		t.Skip("POSTGRES_URL not set, skipping integration test")
+	}
+	ctx := context.Background()
+
+	poolConfig, err := pgxpool.ParseConfig(url)
+	require.NoError(t, err)
+	poolConfig.MaxConns = 1
+	conns, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	require.NoError(t, err)
+	t.Cleanup(conns.Close)
+	pool := NewPool(conns, PoolOptions{AcquireTimeout: 50 * time.Millisecond})
+
+	held, err := pool.Acquire(ctx)
+	require.NoError(t, err)
+
+	_, _, err = BeginTenantTx(ctx, pool, uuid.New())
+	assert.ErrorIs(t, err, ErrServiceOverloaded)
+
+	held.Release()
+	tx, release, err := BeginTenantTx(ctx, pool, uuid.New())
+	require.NoError(t, err)
+	defer release()
+	assert.NoError(t, tx.Rollback(ctx))
+}
+
+func TestBeginTenantTx_MaxTxPerTenant(t *testing.T) {
+	pool := NewPool(newIntegrationPool(t).Pool, PoolOptions{MaxTxPerTenant: 1})
+	ctx := context.Background()
+
+	busy, other := uuid.New(), uuid.New()
+	tx, release, err := BeginTenantTx(ctx, pool, busy)
+	require.NoError(t, err)
+
+	_, _, err = BeginTenantTx(ctx, pool, busy)
+	assert.ErrorIs(t, err, ErrServiceOverloaded, "the tenant's second transaction exceeds its budget")
+
+	otherTx, otherRelease, err := BeginTenantTx(ctx, pool, other)
+	require.NoError(t, err, "another tenant is not held back")
+	assert.NoError(t, otherTx.Rollback(ctx))
+	otherRelease()
+
+	err = WithTenantReadContext(ctx, pool, busy, func(*db.Queries) error { return nil })
+	assert.NoError(t, err, "reads do not count against the budget")
+
+	assert.NoError(t, tx.Rollback(ctx))
+	release()
+	tx, release, err = BeginTenantTx(ctx, pool, busy)
+	require.NoError(t, err, "the slot is free again once released")
+	assert.NoError(t, tx.Rollback(ctx))
+	release()
+}
@@ -0,0 +1,468 @@
+// Package repository provides implementations of data access patterns for domain entities.
+// tenant_snapshot.go copies all of a tenant's data out to an archive and back in, for moving a
+// tenant between deployments or restoring one.
+package repository
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"rdl-api/internal/domain/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// snapshotManifestName is the archive entry holding the TenantSnapshotManifest
+	snapshotManifestName = "manifest.json"
+	// snapshotPartRows is the most rows one archive part holds. Parts are what an import
+	// commits one at a time and resumes after.
+	snapshotPartRows = 1000
+	// snapshotMaxPartBytes bounds the size of a part ImportTenant reads into memory
+	snapshotMaxPartBytes = 64 << 20
+)
+
+// snapshotEntity is one kind of row in a tenant archive
+type snapshotEntity struct {
+	name string
+	// tenantKey is the field that must hold the tenant's ID in every imported row; empty for
+	// rows that do not name their tenant
+	tenantKey string
+	// export selects the tenant's rows, as one JSON object each, given the tenant ID
+	export string
+	// check, if set, counts the rows of an imported part, given as a JSON array, that belong
+	// to a tenant other than the one given
+	check string
+	// insert stores a part given as a JSON array, skipping rows that already exist
+	insert string
+}
+
+// snapshotEntities lists what a tenant archive holds, in the order parts are written and
+// imported, so that the rows a row references are always imported before it. Rows are whole
+// table rows, so columns added by later migrations are carried without changes here. Event
+// status history, integrations and idempotency keys are not carried over.
+var snapshotEntities = []snapshotEntity{
+	{
+		name:      "tenants",
+		tenantKey: "id",
+		export:    `SELECT to_jsonb(t) FROM tenants t WHERE t.id = $1`,
+		insert:    snapshotInsert("tenants"),
+	},
+	{
+		// providers are shared between tenants; only those the tenant refers to are carried,
+		// and one that already exists under the same ID is left as it is
+		name: "providers",
+		export: `SELECT to_jsonb(p) FROM providers p
+			WHERE p.id IN (
+				SELECT provider_id FROM events WHERE tenant_id = $1
+				UNION SELECT provider_id FROM payments WHERE tenant_id = $1
+				UNION SELECT unnest(allowed_provider_ids) FROM tenants WHERE id = $1
+			)
+			ORDER BY p.id`,
+		insert: snapshotInsert("providers"),
+	},
+	snapshotTenantEntity("users", "id"),
+	snapshotTenantEntity("customers", "id"),
+	snapshotEventsEntity(),
+	snapshotTenantEntity("payments", "id"),
+	snapshotTenantEntity("leaks", "id"),
+	snapshotTenantEntity("leak_events", "leak_id, event_id"),
+	{
+		// actions name their tenant only through their leak
+		name: "actions",
+		export: `SELECT to_jsonb(a) FROM actions a
+			JOIN leaks l ON l.id = a.leak_id
+			WHERE l.tenant_id = $1
+			ORDER BY a.id`,
+		check: `SELECT count(*) FROM jsonb_array_elements($1::jsonb) e
+			WHERE NOT EXISTS (SELECT 1 FROM leaks l WHERE l.id = (e->>'leak_id')::uuid AND l.tenant_id = $2)`,
+		insert: snapshotInsert("actions"),
+	},
+	snapshotTenantEntity("notification_channels", "id"),
+}
+
+// snapshotTenantEntity describes a table whose rows carry the tenant ID in tenant_id, exported
+// in orderBy order
+func snapshotTenantEntity(table string, orderBy string) snapshotEntity {
+	return snapshotEntity{
+		name:      table,
+		tenantKey: "tenant_id",
+		export:    "SELECT to_jsonb(t) FROM " + table + " t WHERE t.tenant_id = $1 ORDER BY " + orderBy,
+		insert:    snapshotInsert(table),
+	}
+}
+
+// snapshotEventsEntity describes the events table. Imported events are stamped with the import
+// source, whatever source they had where they were exported, so they can be told apart from
+// events this deployment received itself.
+func snapshotEventsEntity() snapshotEntity {
+	entity := snapshotTenantEntity("events", "id")
+	entity.insert = `INSERT INTO events SELECT r.* FROM jsonb_array_elements($1::jsonb) e, jsonb_populate_record(NULL::events, e || '{"source": "import"}'::jsonb) r ON CONFLICT DO NOTHING`
+	return entity
+}
+
+// snapshotInsert returns the statement inserting a JSON array of whole rows into table
+func snapshotInsert(table string) string {
+	return "INSERT INTO " + table + " SELECT r.* FROM jsonb_array_elements($1::jsonb) e, jsonb_populate_record(NULL::" + table + ", e) r ON CONFLICT DO NOTHING"
+}
+
+// snapshotEntityByName returns the position in snapshotEntities of the entity named name
+func snapshotEntityByName(name string) (int, bool) {
+	for i, entity := range snapshotEntities {
+		if entity.name == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// ExportTenant writes every row of a tenant to w as a tar archive: manifest.json, then each
+// entity's rows as newline-delimited JSON in parts of at most 1000 rows, named like
+// events/000001.ndjson. All rows are read in one repeatable-read transaction, so the archive
+// is consistent even while the tenant keeps writing, and parts are written as they fill, so
+// memory use does not grow with the tenant. The transaction stays open until the last part is
+// written, so w should not block for long.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: The tenant to export.
+//   - w: Where the archive is written.
+//
+// Returns:
+//   - models.TenantSnapshotReport: The rows written per entity and the last part written.
+//   - error: ErrTenantNotFound if the tenant does not exist, or any error encountered while
+//     reading or writing.
+func (r TenantsRepositoryImplementation) ExportTenant(ctx context.Context, tenantID uuid.UUID, w io.Writer) (models.TenantSnapshotReport, error) {
+	report := models.TenantSnapshotReport{Rows: map[string]int64{}}
+	sw := newSnapshotWriter(w, snapshotPartRows, &report)
+
+	err := sw.writeManifest(models.TenantSnapshotManifest{
+		Version:    models.TenantSnapshotVersion,
+		TenantID:   tenantID,
+		ExportedAt: time.Now().UTC(),
+	})
+	if err == nil {
+		err = withTenantSnapshot(ctx, r.pool, tenantID, func(tx pgx.Tx) error {
+			for _, entity := range snapshotEntities {
+				if err := exportSnapshotEntity(ctx, tx, sw, entity, tenantID); err != nil {
+					return fmt.Errorf("export %s: %w", entity.name, err)
+				}
+			}
+			if report.Rows["tenants"] == 0 {
+				return ErrTenantNotFound
+			}
+			return nil
+		})
+	}
+	if err == nil {
+		err = sw.close()
+	}
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to export tenant", "error", err, "tenant_id", tenantID)
+		return report, err
+	}
+	return report, nil
+}
+
+// exportSnapshotEntity writes the tenant's rows of entity to sw
+func exportSnapshotEntity(ctx context.Context, tx pgx.Tx, sw *snapshotWriter, entity snapshotEntity, tenantID uuid.UUID) error {
+	rows, err := tx.Query(ctx, entity.export, convertUUIDToPgtypeUUID(tenantID))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	sw.startEntity(entity.name)
+	for rows.Next() {
+		var row []byte
+		if err := rows.Scan(&row); err != nil {
+			return err
+		}
+		if err := sw.writeRow(row); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return sw.flush()
+}
+
+// ImportTenant stores the rows of an archive written by ExportTenant. Each part is committed
+// in its own transaction and rows that already exist are skipped, so an import that failed
+// part way can be run again from the start, or resumed with resumeAfter set to the LastPart
+// of the failed attempt's report to skip the parts it committed. Every row must belong to
+// tenantID, which must be the tenant the archive was exported from.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: The tenant being imported.
+//   - rd: The archive.
+//   - resumeAfter: The part after which to start committing, or empty to import every part.
+//
+// Returns:
+//   - models.TenantSnapshotReport: The rows read and inserted per entity and the last part
+//     committed, also when the import fails.
+//   - error: ErrInvalidTenantSnapshot for a malformed archive, one of another version or
+//     tenant, or one without the part named by resumeAfter; any database error otherwise.
+func (r TenantsRepositoryImplementation) ImportTenant(ctx context.Context, tenantID uuid.UUID, rd io.Reader, resumeAfter string) (models.TenantSnapshotReport, error) {
+	report := models.TenantSnapshotReport{Rows: map[string]int64{}, Inserted: map[string]int64{}}
+	sr, err := newSnapshotReader(rd, tenantID)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to import tenant", "error", err, "tenant_id", tenantID)
+		return report, err
+	}
+
+	skipping := resumeAfter != ""
+	for {
+		part, err := sr.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			r.logger.ErrorContext(ctx, "Failed to import tenant", "error", err, "tenant_id", tenantID, "last_part", report.LastPart)
+			return report, err
+		}
+		report.Rows[part.entity.name] += part.count
+		if skipping {
+			if part.name == resumeAfter {
+				skipping = false
+				report.LastPart = part.name
+			}
+			continue
+		}
+
+		inserted, err := r.importSnapshotPart(ctx, tenantID, part)
+		if err != nil {
+			r.logger.ErrorContext(ctx, "Failed to import tenant", "error", err, "tenant_id", tenantID, "part", part.name, "last_part", report.LastPart)
+			return report, fmt.Errorf("import %s: %w", part.name, err)
+		}
+		report.Inserted[part.entity.name] += inserted
+		report.LastPart = part.name
+	}
+
+	if skipping {
+		err := fmt.Errorf("%w: no part %q to resume after", ErrInvalidTenantSnapshot, resumeAfter)
+		r.logger.ErrorContext(ctx, "Failed to import tenant", "error", err, "tenant_id", tenantID)
+		return report, err
+	}
+	return report, nil
+}
+
+// importSnapshotPart stores the rows of part in one transaction, returning how many were new
+func (r TenantsRepositoryImplementation) importSnapshotPart(ctx context.Context, tenantID uuid.UUID, part snapshotPart) (int64, error) {
+	var inserted int64
+	err := withTenantTx(ctx, r.pool, tenantID, func(tx pgx.Tx) error {
+		if part.entity.check != "" {
+			var foreign int64
+			if err := tx.QueryRow(ctx, part.entity.check, part.rows, convertUUIDToPgtypeUUID(tenantID)).Scan(&foreign); err != nil {
+				return err
+			}
+			if foreign > 0 {
+				return fmt.Errorf("%w: %d %s rows belong to another tenant", ErrInvalidTenantSnapshot, foreign, part.entity.name)
+			}
+		}
+		tag, err := tx.Exec(ctx, part.entity.insert, part.rows)
+		if err != nil {
+			return err
+		}
+		inserted = tag.RowsAffected()
+		return nil
+	})
+	return inserted, err
+}
+
+// snapshotWriter writes a tenant archive, cutting each entity's rows into parts of at most
+// partRows rows. A part is held in memory until it is full, as its tar header needs its size.
+type snapshotWriter struct {
+	archive  *tar.Writer
+	partRows int
+	report   *models.TenantSnapshotReport
+	modTime  time.Time
+
+	entity string
+	part   int
+	rows   int
+	buf    bytes.Buffer
+}
+
+func newSnapshotWriter(w io.Writer, partRows int, report *models.TenantSnapshotReport) *snapshotWriter {
+	return &snapshotWriter{archive: tar.NewWriter(w), partRows: partRows, report: report, modTime: time.Now().UTC()}
+}
+
+func (w *snapshotWriter) writeManifest(manifest models.TenantSnapshotManifest) error {
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	return w.writeFile(snapshotManifestName, data)
+}
+
+// startEntity starts the parts of the entity named name; the rows of the previous entity must
+// have been flushed
+func (w *snapshotWriter) startEntity(name string) {
+	w.entity, w.part, w.rows = name, 0, 0
+	w.buf.Reset()
+}
+
+// writeRow adds one row, given as a JSON object, to the current part, writing the part out
+// once it is full
+func (w *snapshotWriter) writeRow(row []byte) error {
+	w.buf.Write(row)
+	w.buf.WriteByte('\n')
+	w.rows++
+	w.report.Rows[w.entity]++
+	if w.rows < w.partRows {
+		return nil
+	}
+	return w.flush()
+}
+
+// flush writes out the current part, if it has any rows
+func (w *snapshotWriter) flush() error {
+	if w.rows == 0 {
+		return nil
+	}
+	w.part++
+	name := fmt.Sprintf("%s/%06d.ndjson", w.entity, w.part)
+	if err := w.writeFile(name, w.buf.Bytes()); err != nil {
+		return err
+	}
+	w.report.LastPart = name
+	w.rows = 0
+	w.buf.Reset()
+	return nil
+}
+
+// close flushes the current part and finishes the archive
+func (w *snapshotWriter) close() error {
+	if err := w.flush(); err != nil {
+		return err
+	}
+	return w.archive.Close()
+}
+
+func (w *snapshotWriter) writeFile(name string, data []byte) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: w.modTime,
+	}
+	if err := w.archive.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := w.archive.Write(data)
+	return err
+}
+
+// snapshotPart is one part of a tenant archive, read and checked
+type snapshotPart struct {
+	name   string
+	entity snapshotEntity
+	// rows are the part's rows as a JSON array
+	rows  string
+	count int64
+}
+
+// snapshotReader reads the parts of a tenant archive in order, checking that they are of the
+// expected tenant and come in the order snapshotEntities gives
+type snapshotReader struct {
+	archive  *tar.Reader
+	tenantID uuid.UUID
+	entity   int
+}
+
+// newSnapshotReader reads the manifest of the archive in rd, which must be of the current
+// version and of tenantID
+func newSnapshotReader(rd io.Reader, tenantID uuid.UUID) (*snapshotReader, error) {
+	archive := tar.NewReader(rd)
+	header, err := archive.Next()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTenantSnapshot, err)
+	}
+	if header.Name != snapshotManifestName {
+		return nil, fmt.Errorf("%w: first entry is %q, not %s", ErrInvalidTenantSnapshot, header.Name, snapshotManifestName)
+	}
+
+	var manifest models.TenantSnapshotManifest
+	if err := json.NewDecoder(io.LimitReader(archive, snapshotMaxPartBytes)).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("%w: manifest: %v", ErrInvalidTenantSnapshot, err)
+	}
+	if manifest.Version != models.TenantSnapshotVersion {
+		return nil, fmt.Errorf("%w: version %d, expected %d", ErrInvalidTenantSnapshot, manifest.Version, models.TenantSnapshotVersion)
+	}
+	if manifest.TenantID != tenantID {
+		return nil, fmt.Errorf("%w: archive is of tenant %s", ErrInvalidTenantSnapshot, manifest.TenantID)
+	}
+	return &snapshotReader{archive: archive, tenantID: tenantID}, nil
+}
+
+// next returns the next part of the archive, or io.EOF after the last one
+func (sr *snapshotReader) next() (snapshotPart, error) {
+	header, err := sr.archive.Next()
+	if errors.Is(err, io.EOF) {
+		return snapshotPart{}, io.EOF
+	}
+	if err != nil {
+		return snapshotPart{}, fmt.Errorf("%w: %v", ErrInvalidTenantSnapshot, err)
+	}
+
+	entity, ok := snapshotEntityByName(path.Dir(header.Name))
+	if !ok || path.Ext(header.Name) != ".ndjson" {
+		return snapshotPart{}, fmt.Errorf("%w: unexpected entry %q", ErrInvalidTenantSnapshot, header.Name)
+	}
+	if entity < sr.entity {
+		return snapshotPart{}, fmt.Errorf("%w: %q is out of order", ErrInvalidTenantSnapshot, header.Name)
+	}
+	if header.Size > snapshotMaxPartBytes {
+		return snapshotPart{}, fmt.Errorf("%w: %q is larger than %d bytes", ErrInvalidTenantSnapshot, header.Name, snapshotMaxPartBytes)
+	}
+	sr.entity = entity
+
+	data, err := io.ReadAll(sr.archive)
+	if err != nil {
+		return snapshotPart{}, fmt.Errorf("%w: %q: %v", ErrInvalidTenantSnapshot, header.Name, err)
+	}
+	rows, count, err := decodeSnapshotPart(snapshotEntities[entity], sr.tenantID, data)
+	if err != nil {
+		return snapshotPart{}, fmt.Errorf("%q: %w", header.Name, err)
+	}
+	return snapshotPart{name: header.Name, entity: snapshotEntities[entity], rows: rows, count: count}, nil
+}
+
+// decodeSnapshotPart turns the newline-delimited rows of a part of entity into a JSON array,
+// checking that every row is an object naming tenantID where entity rows name their tenant
+func decodeSnapshotPart(entity snapshotEntity, tenantID uuid.UUID, data []byte) (string, int64, error) {
+	var rows []json.RawMessage
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var row map[string]json.RawMessage
+		if err := json.Unmarshal(line, &row); err != nil {
+			return "", 0, fmt.Errorf("%w: row %d: %v", ErrInvalidTenantSnapshot, len(rows)+1, err)
+		}
+		if entity.tenantKey != "" {
+			var owner uuid.UUID
+			if err := json.Unmarshal(row[entity.tenantKey], &owner); err != nil || owner != tenantID {
+				return "", 0, fmt.Errorf("%w: row %d belongs to another tenant", ErrInvalidTenantSnapshot, len(rows)+1)
+			}
+		}
+		rows = append(rows, line)
+	}
+
+	array, err := json.Marshal(rows)
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: %v", ErrInvalidTenantSnapshot, err)
+	}
+	return string(array), int64(len(rows)), nil
+}
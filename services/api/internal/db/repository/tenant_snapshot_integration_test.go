@@ -0,0 +1,120 @@
+//go:build integration
+
+package repository
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// snapshotParts returns the contents of an archive's parts by name, leaving out the manifest
+func snapshotParts(t *testing.T, archive []byte) map[string]string {
+	t.Helper()
+	parts := map[string]string{}
+	tr := tar.NewReader(bytes.NewReader(archive))
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return parts
+		}
+		require.NoError(t, err)
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		if header.Name != snapshotManifestName {
+			parts[header.Name] = string(data)
+		}
+	}
+}
+
+func TestExportImportTenant(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	tenantID, customerID := seedTenant(t, pool)
+	providerID := seedProvider(t, pool)
+	eventID := seedEvent(t, pool, tenantID, providerID)
+	leakID := seedLeak(t, pool, tenantID, customerID, "49.99")
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "INSERT INTO leak_events (leak_id, event_id, tenant_id) VALUES ($1, $2, $3)", leakID, eventID, tenantID)
+		require.NoError(t, err)
+		_, err = tx.Exec(ctx, "INSERT INTO actions (leak_id, action_type, status) VALUES ($1, 'retry_payment', 'pending')", leakID)
+		require.NoError(t, err)
+	})
+	otherTenantID, _ := seedTenant(t, pool)
+
+	repo, err := NewTenantsRepository(pool, createTestLogger())
+	require.NoError(t, err)
+
+	var exported bytes.Buffer
+	report, err := repo.ExportTenant(ctx, tenantID, &exported)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{
+		"tenants": 1, "providers": 1, "customers": 1, "events": 1, "leaks": 1, "leak_events": 1, "actions": 1,
+	}, report.Rows)
+	assert.Equal(t, "actions/000001.ndjson", report.LastPart)
+
+	// Drop the tenant and everything under it, then bring it back from the archive
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "DELETE FROM tenants WHERE id = $1", tenantID)
+		require.NoError(t, err)
+	})
+
+	imported, err := repo.ImportTenant(ctx, tenantID, bytes.NewReader(exported.Bytes()), "")
+	require.NoError(t, err)
+	assert.Equal(t, report.Rows, imported.Rows)
+	assert.Equal(t, map[string]int64{
+		"tenants": 1, "providers": 0, "customers": 1, "events": 1, "leaks": 1, "leak_events": 1, "actions": 1,
+	}, imported.Inserted, "the provider still existed")
+	assert.Equal(t, report.LastPart, imported.LastPart)
+
+	var source string
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		require.NoError(t, tx.QueryRow(ctx, "SELECT source FROM events WHERE id = $1", eventID).Scan(&source))
+	})
+	assert.Equal(t, "import", source, "imported events are stamped with the import source")
+
+	var reexported bytes.Buffer
+	_, err = repo.ExportTenant(ctx, tenantID, &reexported)
+	require.NoError(t, err)
+	want := snapshotParts(t, exported.Bytes())
+	want["events/000001.ndjson"] = strings.Replace(want["events/000001.ndjson"], `"source": "manual"`, `"source": "import"`, 1)
+	assert.Equal(t, want, snapshotParts(t, reexported.Bytes()), "every other field comes back as it was")
+
+	t.Run("importing again changes nothing", func(t *testing.T) {
+		again, err := repo.ImportTenant(ctx, tenantID, bytes.NewReader(exported.Bytes()), "")
+		require.NoError(t, err)
+		for entity, inserted := range again.Inserted {
+			assert.Zero(t, inserted, entity)
+		}
+	})
+
+	t.Run("resuming skips the committed parts", func(t *testing.T) {
+		resumed, err := repo.ImportTenant(ctx, tenantID, bytes.NewReader(exported.Bytes()), "leaks/000001.ndjson")
+		require.NoError(t, err)
+		assert.Equal(t, report.Rows, resumed.Rows, "skipped parts are still read")
+		assert.Equal(t, map[string]int64{"leak_events": 0, "actions": 0}, resumed.Inserted)
+		assert.Equal(t, report.LastPart, resumed.LastPart)
+
+		_, err = repo.ImportTenant(ctx, tenantID, bytes.NewReader(exported.Bytes()), "leaks/000002.ndjson")
+		assert.ErrorIs(t, err, ErrInvalidTenantSnapshot)
+	})
+
+	t.Run("another tenant cannot import the archive", func(t *testing.T) {
+		_, err := repo.ImportTenant(ctx, otherTenantID, bytes.NewReader(exported.Bytes()), "")
+		assert.ErrorIs(t, err, ErrInvalidTenantSnapshot)
+	})
+
+	t.Run("unknown tenant", func(t *testing.T) {
+		_, err := repo.ExportTenant(ctx, uuid.New(), io.Discard)
+		assert.ErrorIs(t, err, ErrTenantNotFound)
+	})
+}
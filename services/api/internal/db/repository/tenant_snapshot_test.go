@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"rdl-api/internal/domain/models"
+)
+
+// writeTestSnapshot writes an archive with the given manifest and parts of at most two rows, the
+// given rows per entity written in snapshotEntities order
+func writeTestSnapshot(t *testing.T, manifest models.TenantSnapshotManifest, rows map[string][]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	report := models.TenantSnapshotReport{Rows: map[string]int64{}}
+	sw := newSnapshotWriter(&buf, 2, &report)
+	require.NoError(t, sw.writeManifest(manifest))
+	for _, entity := range snapshotEntities {
+		sw.startEntity(entity.name)
+		for _, row := range rows[entity.name] {
+			require.NoError(t, sw.writeRow([]byte(row)))
+		}
+		require.NoError(t, sw.flush())
+	}
+	require.NoError(t, sw.close())
+	return buf.Bytes()
+}
+
+func TestTenantSnapshotArchive(t *testing.T) {
+	tenantID := uuid.New()
+	manifest := models.TenantSnapshotManifest{Version: models.TenantSnapshotVersion, TenantID: tenantID}
+	customer := func(n int) string {
+		return fmt.Sprintf(`{"id":%q,"tenant_id":%q,"external_id":"cus_%d"}`, uuid.New(), tenantID, n)
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		archive := writeTestSnapshot(t, manifest, map[string][]string{
+			"tenants":   {fmt.Sprintf(`{"id":%q,"name":"Acme"}`, tenantID)},
+			"customers": {customer(1), customer(2), customer(3)},
+			"actions":   {fmt.Sprintf(`{"id":%q,"leak_id":%q}`, uuid.New(), uuid.New())},
+		})
+
+		sr, err := newSnapshotReader(bytes.NewReader(archive), tenantID)
+		require.NoError(t, err)
+		var names []string
+		counts := map[string]int64{}
+		for {
+			part, err := sr.next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+			names = append(names, part.name)
+			counts[part.entity.name] += part.count
+
+			var rows []map[string]any
+			require.NoError(t, json.Unmarshal([]byte(part.rows), &rows), "a part is handed to the insert as a JSON array")
+			assert.Len(t, rows, int(part.count))
+		}
+		assert.Equal(t, []string{"tenants/000001.ndjson", "customers/000001.ndjson", "customers/000002.ndjson", "actions/000001.ndjson"}, names)
+		assert.Equal(t, map[string]int64{"tenants": 1, "customers": 3, "actions": 1}, counts)
+	})
+
+	t.Run("rejected archives", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			manifest models.TenantSnapshotManifest
+			rows     map[string][]string
+		}{
+			{
+				name:     "other version",
+				manifest: models.TenantSnapshotManifest{Version: models.TenantSnapshotVersion + 1, TenantID: tenantID},
+			},
+			{
+				name:     "other tenant",
+				manifest: models.TenantSnapshotManifest{Version: models.TenantSnapshotVersion, TenantID: uuid.New()},
+			},
+			{
+				name:     "row of another tenant",
+				manifest: manifest,
+				rows:     map[string][]string{"customers": {customer(1), fmt.Sprintf(`{"id":%q,"tenant_id":%q}`, uuid.New(), uuid.New())}},
+			},
+			{
+				name:     "row without a tenant",
+				manifest: manifest,
+				rows:     map[string][]string{"users": {fmt.Sprintf(`{"id":%q}`, uuid.New())}},
+			},
+			{
+				name:     "row that is not JSON",
+				manifest: manifest,
+				rows:     map[string][]string{"customers": {"not json"}},
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				archive := writeTestSnapshot(t, tt.manifest, tt.rows)
+				sr, err := newSnapshotReader(bytes.NewReader(archive), tenantID)
+				for err == nil {
+					_, err = sr.next()
+				}
+				assert.ErrorIs(t, err, ErrInvalidTenantSnapshot)
+			})
+		}
+	})
+
+	t.Run("parts out of order", func(t *testing.T) {
+		var buf bytes.Buffer
+		report := models.TenantSnapshotReport{Rows: map[string]int64{}}
+		sw := newSnapshotWriter(&buf, 2, &report)
+		require.NoError(t, sw.writeManifest(manifest))
+		for _, name := range []string{"customers", "users"} {
+			sw.startEntity(name)
+			require.NoError(t, sw.writeRow([]byte(customer(1))))
+			require.NoError(t, sw.flush())
+		}
+		require.NoError(t, sw.close())
+
+		sr, err := newSnapshotReader(&buf, tenantID)
+		require.NoError(t, err)
+		_, err = sr.next()
+		require.NoError(t, err)
+		_, err = sr.next()
+		assert.ErrorIs(t, err, ErrInvalidTenantSnapshot, "entities must come in the order they are imported")
+	})
+}
@@ -0,0 +1,69 @@
+// Package repository provides implementations of data access patterns for domain entities.
+// tenants.go reads the per-tenant settings that are consulted outside any single tenant's requests.
+package repository
+
+import (
+	"context"
+	"log/slog"
+	db "rdl-api/internal/db/sqlc"
+	"rdl-api/internal/domain/models"
+)
+
+// TenantsRepositoryImplementation reads tenant settings. The tenants table is not
+// tenant-scoped, so its queries run without a tenant context.
+type TenantsRepositoryImplementation struct {
+	pool   *Pool
+	logger *slog.Logger
+}
+
+// NewTenantsRepository creates a new instance of TenantsRepository backed by the provided Pool.
+//
+// Parameters:
+//   - pool: Pointer to Pool, which provides access to the database.
+//   - logger: Pointer to slog.Logger, which provides access to the logger.
+//
+// Returns:
+//   - TenantsRepositoryImplementation: The tenants repository.
+//   - error: Any error encountered during initialization.
+func NewTenantsRepository(pool *Pool, l *slog.Logger) (TenantsRepositoryImplementation, error) {
+	if pool == nil {
+		return TenantsRepositoryImplementation{}, ErrPoolCannotBeNil
+	}
+	if l == nil {
+		return TenantsRepositoryImplementation{}, ErrLoggerCannotBeNil
+	}
+	return TenantsRepositoryImplementation{pool: pool, logger: l}, nil
+}
+
+// GetTenantRateLimits returns every tenant that overrides the global request rate limit.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//
+// Returns:
+//   - []models.TenantRateLimit: One entry per tenant with an override, ordered by tenant ID.
+//   - error: Any error encountered during retrieval.
+func (r TenantsRepositoryImplementation) GetTenantRateLimits(ctx context.Context) ([]models.TenantRateLimit, error) {
+	conn, err := r.pool.acquire(ctx)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to retrieve tenant rate limits", "error", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := db.New(conn).ListTenantRateLimits(ctx)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to retrieve tenant rate limits", "error", err)
+		return nil, err
+	}
+
+	limits := make([]models.TenantRateLimit, len(rows))
+	for i, row := range rows {
+		limits[i] = models.TenantRateLimit{
+			TenantID: convertPgtypeUUIDToUUID(row.ID),
+			RPS:      row.RateLimitRps,
+			Burst:    row.RateLimitBurst,
+		}
+	}
+	return limits, nil
+}
@@ -0,0 +1,43 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"rdl-api/internal/domain/models"
+)
+
+func TestGetTenantRateLimits(t *testing.T) {
+	pool := newIntegrationPool(t)
+	ctx := context.Background()
+	overrideTenantID, _ := seedTenant(t, pool)
+	defaultTenantID, _ := seedTenant(t, pool)
+
+	seedAsServiceAccount(t, pool, func(tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "UPDATE tenants SET rate_limit_rps = 50, rate_limit_burst = 100 WHERE id = $1", overrideTenantID)
+		require.NoError(t, err)
+	})
+
+	repo, err := NewTenantsRepository(pool, createTestLogger())
+	require.NoError(t, err)
+	limits, err := repo.GetTenantRateLimits(ctx)
+	require.NoError(t, err)
+
+	byTenant := map[uuid.UUID]models.TenantRateLimit{}
+	for _, limit := range limits {
+		byTenant[limit.TenantID] = limit
+	}
+	require.Contains(t, byTenant, overrideTenantID)
+	assert.NotContains(t, byTenant, defaultTenantID, "tenants without an override are left out")
+	require.NotNil(t, byTenant[overrideTenantID].RPS)
+	require.NotNil(t, byTenant[overrideTenantID].Burst)
+	assert.Equal(t, 50.0, *byTenant[overrideTenantID].RPS)
+	assert.Equal(t, int32(100), *byTenant[overrideTenantID].Burst)
+}
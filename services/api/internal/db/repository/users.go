@@ -11,15 +11,14 @@ import (
 	models "rdl-api/internal/domain/models"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type UserRepositoryImplementation struct {
-	pool   *pgxpool.Pool
+	pool   *Pool
 	logger *slog.Logger
 }
 
-func NewUserRepository(pool *pgxpool.Pool, l *slog.Logger) UserRepositoryImplementation {
+func NewUserRepository(pool *Pool, l *slog.Logger) UserRepositoryImplementation {
 	if pool == nil {
 		panic("pool cannot be nil")
 	}
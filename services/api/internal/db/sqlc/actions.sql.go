@@ -11,6 +11,123 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const claimActions = `-- name: ClaimActions :many
+UPDATE actions
+SET status = 'in_progress', claimed_by = $1, claimed_at = NOW(), attempts = attempts + 1
+WHERE id = ANY($2::uuid[])
+RETURNING id, leak_id, action_type, status, result, created_at, updated_at, priority, claimed_by, claimed_at, attempts
+`
+
+type ClaimActionsParams struct {
+	WorkerID pgtype.Text   `json:"worker_id"`
+	Ids      []pgtype.UUID `json:"ids"`
+}
+
+// Claims the actions GetPendingActionsByPriority locked, in the same transaction, taking over
+// the stale claims among them. Every claim starts an attempt, so an action whose worker dies
+// still counts towards the cap.
+func (q *Queries) ClaimActions(ctx context.Context, arg ClaimActionsParams) ([]Action, error) {
+	rows, err := q.db.Query(ctx, claimActions, arg.WorkerID, arg.Ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Action
+	for rows.Next() {
+		var i Action
+		if err := rows.Scan(
+			&i.ID,
+			&i.LeakID,
+			&i.ActionType,
+			&i.Status,
+			&i.Result,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Priority,
+			&i.ClaimedBy,
+			&i.ClaimedAt,
+			&i.Attempts,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const completeAction = `-- name: CompleteAction :one
+UPDATE actions
+SET status = 'approved',
+    result = 'success',
+    claimed_by = NULL,
+    claimed_at = NULL
+WHERE id = $1
+  AND status = 'in_progress'
+  AND EXISTS (SELECT 1 FROM leaks WHERE leaks.id = actions.leak_id AND leaks.tenant_id = $2)
+RETURNING id, leak_id, action_type, status, result, created_at, updated_at, priority, claimed_by, claimed_at, attempts
+`
+
+type CompleteActionParams struct {
+	ID       pgtype.UUID `json:"id"`
+	TenantID pgtype.UUID `json:"tenant_id"`
+}
+
+// Ends a claimed action's attempt as a success: the action is approved and done
+func (q *Queries) CompleteAction(ctx context.Context, arg CompleteActionParams) (Action, error) {
+	row := q.db.QueryRow(ctx, completeAction, arg.ID, arg.TenantID)
+	var i Action
+	err := row.Scan(
+		&i.ID,
+		&i.LeakID,
+		&i.ActionType,
+		&i.Status,
+		&i.Result,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Priority,
+		&i.ClaimedBy,
+		&i.ClaimedAt,
+		&i.Attempts,
+	)
+	return i, err
+}
+
+const countActionsFiltered = `-- name: CountActionsFiltered :one
+SELECT COUNT(*) FROM actions
+WHERE EXISTS (SELECT 1 FROM leaks WHERE leaks.id = actions.leak_id AND leaks.tenant_id = $1)
+  AND (cardinality($2::text[]) = 0 OR action_type::text = ANY($2::text[]))
+  AND (cardinality($3::text[]) = 0 OR status::text = ANY($3::text[]))
+  AND (cardinality($4::text[]) = 0 OR result::text = ANY($4::text[]))
+  AND ($5::timestamptz IS NULL OR created_at >= $5::timestamptz)
+  AND ($6::timestamptz IS NULL OR created_at < $6::timestamptz)
+`
+
+type CountActionsFilteredParams struct {
+	TenantID    pgtype.UUID        `json:"tenant_id"`
+	ActionTypes []string           `json:"action_types"`
+	Statuses    []string           `json:"statuses"`
+	Results     []string           `json:"results"`
+	CreatedFrom pgtype.Timestamptz `json:"created_from"`
+	CreatedTo   pgtype.Timestamptz `json:"created_to"`
+}
+
+func (q *Queries) CountActionsFiltered(ctx context.Context, arg CountActionsFilteredParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countActionsFiltered,
+		arg.TenantID,
+		arg.ActionTypes,
+		arg.Statuses,
+		arg.Results,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const countAllActions = `-- name: CountAllActions :one
 SELECT COUNT(*) FROM actions
 `
@@ -23,7 +140,7 @@ func (q *Queries) CountAllActions(ctx context.Context) (int64, error) {
 }
 
 const createAction = `-- name: CreateAction :one
-INSERT INTO actions (leak_id, action_type, status, result) VALUES ($1, $2, $3, $4) RETURNING id, leak_id, action_type, status, result, created_at, updated_at
+INSERT INTO actions (leak_id, action_type, status, result) VALUES ($1, $2, $3, $4) RETURNING id, leak_id, action_type, status, result, created_at, updated_at, priority, claimed_by, claimed_at, attempts
 `
 
 type CreateActionParams struct {
@@ -49,6 +166,10 @@ func (q *Queries) CreateAction(ctx context.Context, arg CreateActionParams) (Act
 		&i.Result,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Priority,
+		&i.ClaimedBy,
+		&i.ClaimedAt,
+		&i.Attempts,
 	)
 	return i, err
 }
@@ -65,14 +186,90 @@ func (q *Queries) DeleteAction(ctx context.Context, id pgtype.UUID) (int64, erro
 	return result.RowsAffected(), nil
 }
 
+const failActionAttempt = `-- name: FailActionAttempt :one
+UPDATE actions
+SET status = CASE WHEN attempts >= $1::integer THEN 'failed'::action_status_enum ELSE 'pending'::action_status_enum END,
+    result = 'failure',
+    claimed_by = NULL,
+    claimed_at = NULL
+WHERE id = $2
+  AND status = 'in_progress'
+  AND EXISTS (SELECT 1 FROM leaks WHERE leaks.id = actions.leak_id AND leaks.tenant_id = $3)
+RETURNING id, leak_id, action_type, status, result, created_at, updated_at, priority, claimed_by, claimed_at, attempts
+`
+
+type FailActionAttemptParams struct {
+	MaxAttempts int32       `json:"max_attempts"`
+	ID          pgtype.UUID `json:"id"`
+	TenantID    pgtype.UUID `json:"tenant_id"`
+}
+
+// Ends a claimed action's attempt as a failure. Below max_attempts the action goes back to
+// pending to be claimed again; at the cap it is failed for good, which is never claimed.
+func (q *Queries) FailActionAttempt(ctx context.Context, arg FailActionAttemptParams) (Action, error) {
+	row := q.db.QueryRow(ctx, failActionAttempt, arg.MaxAttempts, arg.ID, arg.TenantID)
+	var i Action
+	err := row.Scan(
+		&i.ID,
+		&i.LeakID,
+		&i.ActionType,
+		&i.Status,
+		&i.Result,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Priority,
+		&i.ClaimedBy,
+		&i.ClaimedAt,
+		&i.Attempts,
+	)
+	return i, err
+}
+
+const failExhaustedActions = `-- name: FailExhaustedActions :execrows
+UPDATE actions
+SET status = 'failed',
+    result = 'failure',
+    claimed_by = NULL,
+    claimed_at = NULL
+WHERE (status = 'pending'
+       OR (status = 'in_progress' AND claimed_at < NOW() - make_interval(secs => $1::float8)))
+  AND attempts >= $2::integer
+  AND EXISTS (SELECT 1 FROM leaks WHERE leaks.id = actions.leak_id AND leaks.tenant_id = $3)
+`
+
+type FailExhaustedActionsParams struct {
+	LeaseSeconds float64     `json:"lease_seconds"`
+	MaxAttempts  int32       `json:"max_attempts"`
+	TenantID     pgtype.UUID `json:"tenant_id"`
+}
+
+// Fails the tenant's actions that have used up max_attempts but are still queued: a stale claim
+// on the last attempt, whose worker never reported back, or a pending action left over from a
+// higher cap. The queue skips them, so without this they would never leave that state.
+func (q *Queries) FailExhaustedActions(ctx context.Context, arg FailExhaustedActionsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, failExhaustedActions, arg.LeaseSeconds, arg.MaxAttempts, arg.TenantID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getActionByID = `-- name: GetActionByID :one
-SELECT id, leak_id, action_type, status, result, created_at, updated_at
+SELECT id, leak_id, action_type, status, result, created_at, updated_at, priority, claimed_by, claimed_at, attempts
 FROM actions
 WHERE id = $1
+  AND EXISTS (SELECT 1 FROM leaks WHERE leaks.id = actions.leak_id AND leaks.tenant_id = $2)
 `
 
-func (q *Queries) GetActionByID(ctx context.Context, id pgtype.UUID) (Action, error) {
-	row := q.db.QueryRow(ctx, getActionByID, id)
+type GetActionByIDParams struct {
+	ID       pgtype.UUID `json:"id"`
+	TenantID pgtype.UUID `json:"tenant_id"`
+}
+
+// The tenant predicate holds independently of row-level security, so a misconfigured policy
+// still cannot return another tenant's action
+func (q *Queries) GetActionByID(ctx context.Context, arg GetActionByIDParams) (Action, error) {
+	row := q.db.QueryRow(ctx, getActionByID, arg.ID, arg.TenantID)
 	var i Action
 	err := row.Scan(
 		&i.ID,
@@ -82,12 +279,197 @@ func (q *Queries) GetActionByID(ctx context.Context, id pgtype.UUID) (Action, er
 		&i.Result,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Priority,
+		&i.ClaimedBy,
+		&i.ClaimedAt,
+		&i.Attempts,
+	)
+	return i, err
+}
+
+const getActionWithLeak = `-- name: GetActionWithLeak :one
+SELECT actions.id, actions.leak_id, actions.action_type, actions.status, actions.result, actions.created_at, actions.updated_at, actions.priority, actions.claimed_by, actions.claimed_at, actions.attempts, leaks.id, leaks.tenant_id, leaks.customer_id, leaks.leak_type, leaks.amount, leaks.confidence, leaks.created_at, leaks.updated_at, leaks.payment_id, leaks.status, leaks.currency, leaks.source_event_id, leaks.detected_at, leaks.resolved_at, leaks.metadata, leaks.snoozed_until, leaks.dedup_key, leaks.occurrences
+FROM actions
+JOIN leaks ON leaks.id = actions.leak_id
+WHERE actions.id = $1
+  AND leaks.tenant_id = $2
+`
+
+type GetActionWithLeakParams struct {
+	ID       pgtype.UUID `json:"id"`
+	TenantID pgtype.UUID `json:"tenant_id"`
+}
+
+type GetActionWithLeakRow struct {
+	Action Action `json:"action"`
+	Leak   Leak   `json:"leak"`
+}
+
+// The tenant predicate holds independently of row-level security, as in GetActionByID
+func (q *Queries) GetActionWithLeak(ctx context.Context, arg GetActionWithLeakParams) (GetActionWithLeakRow, error) {
+	row := q.db.QueryRow(ctx, getActionWithLeak, arg.ID, arg.TenantID)
+	var i GetActionWithLeakRow
+	err := row.Scan(
+		&i.Action.ID,
+		&i.Action.LeakID,
+		&i.Action.ActionType,
+		&i.Action.Status,
+		&i.Action.Result,
+		&i.Action.CreatedAt,
+		&i.Action.UpdatedAt,
+		&i.Action.Priority,
+		&i.Action.ClaimedBy,
+		&i.Action.ClaimedAt,
+		&i.Action.Attempts,
+		&i.Leak.ID,
+		&i.Leak.TenantID,
+		&i.Leak.CustomerID,
+		&i.Leak.LeakType,
+		&i.Leak.Amount,
+		&i.Leak.Confidence,
+		&i.Leak.CreatedAt,
+		&i.Leak.UpdatedAt,
+		&i.Leak.PaymentID,
+		&i.Leak.Status,
+		&i.Leak.Currency,
+		&i.Leak.SourceEventID,
+		&i.Leak.DetectedAt,
+		&i.Leak.ResolvedAt,
+		&i.Leak.Metadata,
+		&i.Leak.SnoozedUntil,
+		&i.Leak.DedupKey,
+		&i.Leak.Occurrences,
 	)
 	return i, err
 }
 
+const getActionsByLeakID = `-- name: GetActionsByLeakID :many
+SELECT id, leak_id, action_type, status, result, created_at, updated_at, priority, claimed_by, claimed_at, attempts
+FROM actions
+WHERE leak_id = $1
+  AND EXISTS (SELECT 1 FROM leaks WHERE leaks.id = actions.leak_id AND leaks.tenant_id = $2)
+ORDER BY created_at ASC
+`
+
+type GetActionsByLeakIDParams struct {
+	LeakID   pgtype.UUID `json:"leak_id"`
+	TenantID pgtype.UUID `json:"tenant_id"`
+}
+
+// The tenant predicate holds independently of row-level security, as in GetActionByID
+func (q *Queries) GetActionsByLeakID(ctx context.Context, arg GetActionsByLeakIDParams) ([]Action, error) {
+	rows, err := q.db.Query(ctx, getActionsByLeakID, arg.LeakID, arg.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Action
+	for rows.Next() {
+		var i Action
+		if err := rows.Scan(
+			&i.ID,
+			&i.LeakID,
+			&i.ActionType,
+			&i.Status,
+			&i.Result,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Priority,
+			&i.ClaimedBy,
+			&i.ClaimedAt,
+			&i.Attempts,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getActionsFiltered = `-- name: GetActionsFiltered :many
+SELECT id, leak_id, action_type, status, result, created_at, updated_at, priority, claimed_by, claimed_at, attempts
+FROM actions
+WHERE EXISTS (SELECT 1 FROM leaks WHERE leaks.id = actions.leak_id AND leaks.tenant_id = $1)
+  AND (cardinality($2::text[]) = 0 OR action_type::text = ANY($2::text[]))
+  AND (cardinality($3::text[]) = 0 OR status::text = ANY($3::text[]))
+  AND (cardinality($4::text[]) = 0 OR result::text = ANY($4::text[]))
+  AND ($5::timestamptz IS NULL OR created_at >= $5::timestamptz)
+  AND ($6::timestamptz IS NULL OR created_at < $6::timestamptz)
+ORDER BY
+  CASE WHEN $7::text = 'created_at' AND NOT $8::boolean THEN created_at END ASC,
+  CASE WHEN $7::text = 'created_at' AND $8::boolean THEN created_at END DESC,
+  CASE WHEN $7::text = 'updated_at' AND NOT $8::boolean THEN updated_at END ASC,
+  CASE WHEN $7::text = 'updated_at' AND $8::boolean THEN updated_at END DESC,
+  CASE WHEN $7::text = 'priority' AND NOT $8::boolean THEN priority END ASC,
+  CASE WHEN $7::text = 'priority' AND $8::boolean THEN priority END DESC,
+  id
+LIMIT $9 OFFSET $10
+`
+
+type GetActionsFilteredParams struct {
+	TenantID    pgtype.UUID        `json:"tenant_id"`
+	ActionTypes []string           `json:"action_types"`
+	Statuses    []string           `json:"statuses"`
+	Results     []string           `json:"results"`
+	CreatedFrom pgtype.Timestamptz `json:"created_from"`
+	CreatedTo   pgtype.Timestamptz `json:"created_to"`
+	SortBy      string             `json:"sort_by"`
+	SortDesc    bool               `json:"sort_desc"`
+	Limit       int32              `json:"limit"`
+	Offset      int32              `json:"offset"`
+}
+
+// Actions have no tenant_id; the tenant predicate goes through their leak, as in GetActionByID.
+// sort_by is one of created_at, updated_at and priority, and only the matching pair of CASE
+// expressions orders anything. id breaks ties so pages are stable.
+func (q *Queries) GetActionsFiltered(ctx context.Context, arg GetActionsFilteredParams) ([]Action, error) {
+	rows, err := q.db.Query(ctx, getActionsFiltered,
+		arg.TenantID,
+		arg.ActionTypes,
+		arg.Statuses,
+		arg.Results,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.SortBy,
+		arg.SortDesc,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Action
+	for rows.Next() {
+		var i Action
+		if err := rows.Scan(
+			&i.ID,
+			&i.LeakID,
+			&i.ActionType,
+			&i.Status,
+			&i.Result,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Priority,
+			&i.ClaimedBy,
+			&i.ClaimedAt,
+			&i.Attempts,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getAllActions = `-- name: GetAllActions :many
-SELECT id, leak_id, action_type, status, result, created_at, updated_at
+SELECT id, leak_id, action_type, status, result, created_at, updated_at, priority, claimed_by, claimed_at, attempts
 FROM actions
 `
 
@@ -108,6 +490,10 @@ func (q *Queries) GetAllActions(ctx context.Context) ([]Action, error) {
 			&i.Result,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Priority,
+			&i.ClaimedBy,
+			&i.ClaimedAt,
+			&i.Attempts,
 		); err != nil {
 			return nil, err
 		}
@@ -120,7 +506,7 @@ func (q *Queries) GetAllActions(ctx context.Context) ([]Action, error) {
 }
 
 const getAllActionsPaginated = `-- name: GetAllActionsPaginated :many
-SELECT id, leak_id, action_type, status, result, created_at, updated_at
+SELECT id, leak_id, action_type, status, result, created_at, updated_at, priority, claimed_by, claimed_at, attempts
 FROM actions
 ORDER BY created_at DESC
 LIMIT $1 OFFSET $2
@@ -148,6 +534,76 @@ func (q *Queries) GetAllActionsPaginated(ctx context.Context, arg GetAllActionsP
 			&i.Result,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Priority,
+			&i.ClaimedBy,
+			&i.ClaimedAt,
+			&i.Attempts,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPendingActionsByPriority = `-- name: GetPendingActionsByPriority :many
+SELECT actions.id, actions.leak_id, actions.action_type, actions.status, actions.result, actions.created_at, actions.updated_at, actions.priority, actions.claimed_by, actions.claimed_at, actions.attempts
+FROM actions
+JOIN (
+    SELECT actions.id, rank() OVER (PARTITION BY leaks.currency ORDER BY actions.priority DESC) AS currency_rank
+    FROM actions
+    JOIN leaks ON leaks.id = actions.leak_id
+    WHERE (actions.status = 'pending'
+           OR (actions.status = 'in_progress' AND actions.claimed_at < NOW() - make_interval(secs => $1::float8)))
+      AND actions.attempts < $2::integer
+      AND leaks.tenant_id = $3
+) queue ON queue.id = actions.id
+WHERE (actions.status = 'pending'
+       OR (actions.status = 'in_progress' AND actions.claimed_at < NOW() - make_interval(secs => $1::float8)))
+  AND actions.attempts < $2::integer
+ORDER BY queue.currency_rank, actions.created_at, actions.id
+LIMIT $4
+FOR UPDATE OF actions SKIP LOCKED
+`
+
+type GetPendingActionsByPriorityParams struct {
+	LeaseSeconds float64     `json:"lease_seconds"`
+	MaxAttempts  int32       `json:"max_attempts"`
+	TenantID     pgtype.UUID `json:"tenant_id"`
+	Limit        int32       `json:"limit"`
+}
+
+// The executor's queue: the pending actions, and the in_progress ones whose claim is older than
+// lease_seconds because their worker died or hung, that have attempts left below max_attempts.
+// priority is the leak amount in minor units of the leak's own currency, so it is only
+// compared within a currency: actions are taken by their rank among the queued actions in
+// their currency, which interleaves the currencies, then oldest first. Only the picked rows
+// are locked, and SKIP LOCKED passes over the ones a concurrent claim holds instead of waiting
+// on them.
+func (q *Queries) GetPendingActionsByPriority(ctx context.Context, arg GetPendingActionsByPriorityParams) ([]Action, error) {
+	rows, err := q.db.Query(ctx, getPendingActionsByPriority, arg.LeaseSeconds, arg.MaxAttempts, arg.TenantID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Action
+	for rows.Next() {
+		var i Action
+		if err := rows.Scan(
+			&i.ID,
+			&i.LeakID,
+			&i.ActionType,
+			&i.Status,
+			&i.Result,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Priority,
+			&i.ClaimedBy,
+			&i.ClaimedAt,
+			&i.Attempts,
 		); err != nil {
 			return nil, err
 		}
@@ -166,7 +622,7 @@ SET
     status = CASE WHEN $3::action_status_enum IS NOT NULL THEN $3::action_status_enum ELSE status END, 
     result = CASE WHEN $4::action_result_enum IS NOT NULL THEN $4::action_result_enum ELSE result END 
 WHERE id = $1 
-RETURNING id, leak_id, action_type, status, result, created_at, updated_at
+RETURNING id, leak_id, action_type, status, result, created_at, updated_at, priority, claimed_by, claimed_at, attempts
 `
 
 type UpdateActionParams struct {
@@ -192,6 +648,10 @@ func (q *Queries) UpdateAction(ctx context.Context, arg UpdateActionParams) (Act
 		&i.Result,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Priority,
+		&i.ClaimedBy,
+		&i.ClaimedAt,
+		&i.Attempts,
 	)
 	return i, err
 }
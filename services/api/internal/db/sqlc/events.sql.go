@@ -12,6 +12,51 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const claimPendingEvents = `-- name: ClaimPendingEvents :many
+UPDATE events
+SET status = 'processing'
+WHERE id IN (
+    SELECT id FROM events
+    WHERE status = 'pending'
+    ORDER BY created_at ASC, id ASC
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source
+`
+
+// Oldest pending events first; SKIP LOCKED lets concurrent workers each claim a different set
+func (q *Queries) ClaimPendingEvents(ctx context.Context, limit int32) ([]Event, error) {
+	rows, err := q.db.Query(ctx, claimPendingEvents, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.ProviderID,
+			&i.EventType,
+			&i.EventID,
+			&i.Status,
+			&i.Data,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Source,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const countAllEvents = `-- name: CountAllEvents :one
 SELECT COUNT(*) FROM events
 `
@@ -23,10 +68,111 @@ func (q *Queries) CountAllEvents(ctx context.Context) (int64, error) {
 	return count, err
 }
 
+const countEventsByExternalIDPrefix = `-- name: CountEventsByExternalIDPrefix :one
+SELECT COUNT(*) FROM events
+WHERE tenant_id = $1 AND event_id LIKE $2
+`
+
+type CountEventsByExternalIDPrefixParams struct {
+	TenantID pgtype.UUID `json:"tenant_id"`
+	Pattern  string      `json:"pattern"`
+}
+
+func (q *Queries) CountEventsByExternalIDPrefix(ctx context.Context, arg CountEventsByExternalIDPrefixParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countEventsByExternalIDPrefix, arg.TenantID, arg.Pattern)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countEventsByFilter = `-- name: CountEventsByFilter :one
+SELECT COUNT(*) FROM events
+WHERE (cardinality($1::text[]) = 0 OR event_type = ANY($1::text[]::event_type_enum[]))
+  AND (cardinality($2::text[]) = 0 OR status = ANY($2::text[]::event_status_enum[]))
+  AND (cardinality($3::uuid[]) = 0 OR provider_id = ANY($3::uuid[]))
+`
+
+type CountEventsByFilterParams struct {
+	EventTypes  []string      `json:"event_types"`
+	Statuses    []string      `json:"statuses"`
+	ProviderIds []pgtype.UUID `json:"provider_ids"`
+}
+
+func (q *Queries) CountEventsByFilter(ctx context.Context, arg CountEventsByFilterParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countEventsByFilter, arg.EventTypes, arg.Statuses, arg.ProviderIds)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countEventsBySource = `-- name: CountEventsBySource :one
+SELECT COUNT(*)
+FROM events
+WHERE tenant_id = $1 AND source = $2
+`
+
+type CountEventsBySourceParams struct {
+	TenantID pgtype.UUID     `json:"tenant_id"`
+	Source   EventSourceEnum `json:"source"`
+}
+
+func (q *Queries) CountEventsBySource(ctx context.Context, arg CountEventsBySourceParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countEventsBySource, arg.TenantID, arg.Source)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countEventsByStatusForProvider = `-- name: CountEventsByStatusForProvider :many
+SELECT status, COUNT(*) AS count
+FROM events
+WHERE provider_id = $1
+GROUP BY status
+`
+
+type CountEventsByStatusForProviderRow struct {
+	Status EventStatusEnum `json:"status"`
+	Count  int64           `json:"count"`
+}
+
+func (q *Queries) CountEventsByStatusForProvider(ctx context.Context, providerID pgtype.UUID) ([]CountEventsByStatusForProviderRow, error) {
+	rows, err := q.db.Query(ctx, countEventsByStatusForProvider, providerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountEventsByStatusForProviderRow
+	for rows.Next() {
+		var i CountEventsByStatusForProviderRow
+		if err := rows.Scan(&i.Status, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countEventsWithoutLeak = `-- name: CountEventsWithoutLeak :one
+SELECT COUNT(*)
+FROM events
+LEFT JOIN leak_events ON leak_events.event_id = events.id
+WHERE events.event_type = $1 AND leak_events.leak_id IS NULL
+`
+
+func (q *Queries) CountEventsWithoutLeak(ctx context.Context, eventType EventTypeEnum) (int64, error) {
+	row := q.db.QueryRow(ctx, countEventsWithoutLeak, eventType)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createEvent = `-- name: CreateEvent :one
-INSERT INTO events (tenant_id, provider_id, event_type, event_id, status, data) 
-VALUES ($1, $2, $3, $4, $5, $6) 
-RETURNING id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at
+INSERT INTO events (tenant_id, provider_id, event_type, event_id, status, data, source) 
+VALUES ($1, $2, $3, $4, $5, $6, $7) 
+RETURNING id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source
 `
 
 type CreateEventParams struct {
@@ -36,6 +182,7 @@ type CreateEventParams struct {
 	EventID    string          `json:"event_id"`
 	Status     EventStatusEnum `json:"status"`
 	Data       json.RawMessage `json:"data"`
+	Source     EventSourceEnum `json:"source"`
 }
 
 func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) (Event, error) {
@@ -46,6 +193,7 @@ func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) (Event
 		arg.EventID,
 		arg.Status,
 		arg.Data,
+		arg.Source,
 	)
 	var i Event
 	err := row.Scan(
@@ -58,6 +206,7 @@ func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) (Event
 		&i.Data,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Source,
 	)
 	return i, err
 }
@@ -74,8 +223,190 @@ func (q *Queries) DeleteEvent(ctx context.Context, id pgtype.UUID) (int64, error
 	return result.RowsAffected(), nil
 }
 
+const findDunningGaps = `-- name: FindDunningGaps :many
+WITH settings AS (
+  SELECT COALESCE(
+    (SELECT make_interval(hours => tenants.dunning_window_hours) FROM tenants WHERE tenants.id = $1),
+    make_interval(secs => $2::float8)
+  ) AS dunning_window
+)
+SELECT
+  failed.id,
+  failed.data->>'customer_id' AS customer_ref,
+  failed.data->>'subscription_id' AS subscription_ref,
+  customers.id AS customer_id,
+  CASE WHEN jsonb_typeof(failed.data->'amount') = 'number' THEN (failed.data->>'amount')::numeric END AS amount,
+  UPPER(COALESCE(failed.data->>'currency', '')) AS currency,
+  failed.created_at,
+  EXTRACT(EPOCH FROM settings.dunning_window)::float8 AS window_seconds
+FROM events failed
+CROSS JOIN settings
+LEFT JOIN customers ON customers.external_id = failed.data->>'customer_id'
+WHERE failed.event_type = 'payment_failed'
+  AND failed.data->>'customer_id' IS NOT NULL
+  AND failed.created_at <= $3::timestamptz - settings.dunning_window
+  AND failed.created_at > $3::timestamptz - settings.dunning_window - make_interval(secs => $4::float8)
+  AND NOT EXISTS (
+    SELECT 1 FROM events follow_up
+    WHERE follow_up.event_type IN ('payment_failed', 'payment_succeeded', 'payment_updated')
+      AND follow_up.data->>'customer_id' = failed.data->>'customer_id'
+      AND follow_up.id <> failed.id
+      AND follow_up.created_at >= failed.created_at
+      AND follow_up.created_at <= failed.created_at + settings.dunning_window
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM leaks
+    WHERE leaks.source_event_id = failed.id AND leaks.leak_type = 'dunning_gap'
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM leak_events
+    JOIN leaks ON leaks.id = leak_events.leak_id
+    WHERE leak_events.event_id = failed.id AND leaks.leak_type = 'dunning_gap'
+  )
+ORDER BY failed.created_at, failed.id
+`
+
+type FindDunningGapsParams struct {
+	TenantID             pgtype.UUID        `json:"tenant_id"`
+	DefaultWindowSeconds float64            `json:"default_window_seconds"`
+	Now                  pgtype.Timestamptz `json:"now"`
+	LookbackSeconds      float64            `json:"lookback_seconds"`
+}
+
+type FindDunningGapsRow struct {
+	ID              pgtype.UUID        `json:"id"`
+	CustomerRef     pgtype.Text        `json:"customer_ref"`
+	SubscriptionRef pgtype.Text        `json:"subscription_ref"`
+	CustomerID      pgtype.UUID        `json:"customer_id"`
+	Amount          pgtype.Numeric     `json:"amount"`
+	Currency        string             `json:"currency"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	WindowSeconds   float64            `json:"window_seconds"`
+}
+
+// A payment_failed event has a dunning gap when no payment_failed, payment_succeeded or
+// payment_updated event with the same customer_id follows it within the dunning window, the
+// tenant's dunning_window_hours or else the default. Only failures whose window has passed by
+// @now and began within the lookback before that are checked, and failures a dunning_gap leak
+// already points at or is linked to are left out.
+func (q *Queries) FindDunningGaps(ctx context.Context, arg FindDunningGapsParams) ([]FindDunningGapsRow, error) {
+	rows, err := q.db.Query(ctx, findDunningGaps,
+		arg.TenantID,
+		arg.DefaultWindowSeconds,
+		arg.Now,
+		arg.LookbackSeconds,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FindDunningGapsRow
+	for rows.Next() {
+		var i FindDunningGapsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CustomerRef,
+			&i.SubscriptionRef,
+			&i.CustomerID,
+			&i.Amount,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.WindowSeconds,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const findDuplicateCharges = `-- name: FindDuplicateCharges :many
+WITH charges AS (
+  SELECT
+    id,
+    created_at,
+    data->>'customer_id' AS customer_ref,
+    (data->>'amount')::numeric AS amount,
+    UPPER(COALESCE(data->>'currency', '')) AS currency,
+    LAG(id) OVER charge_group AS original_id,
+    LAG(created_at) OVER charge_group AS original_created_at
+  FROM events
+  WHERE event_type = 'payment_succeeded'
+    AND created_at >= $1
+    AND data->>'customer_id' IS NOT NULL
+    AND jsonb_typeof(data->'amount') = 'number'
+  WINDOW charge_group AS (
+    PARTITION BY data->>'customer_id', (data->>'amount')::numeric, UPPER(COALESCE(data->>'currency', ''))
+    ORDER BY created_at, id
+  )
+)
+SELECT
+  charges.id, charges.original_id, charges.customer_ref, customers.id AS customer_id,
+  charges.amount, charges.currency, charges.created_at, charges.original_created_at
+FROM charges
+LEFT JOIN customers ON customers.external_id = charges.customer_ref
+WHERE charges.original_id IS NOT NULL
+  AND charges.created_at - charges.original_created_at <= make_interval(secs => $2::float8)
+  AND NOT EXISTS (
+    SELECT 1 FROM leaks
+    WHERE leaks.source_event_id = charges.id AND leaks.leak_type = 'duplicate_charge'
+  )
+ORDER BY charges.created_at, charges.id
+`
+
+type FindDuplicateChargesParams struct {
+	Since         pgtype.Timestamptz `json:"since"`
+	WindowSeconds float64            `json:"window_seconds"`
+}
+
+type FindDuplicateChargesRow struct {
+	ID                pgtype.UUID        `json:"id"`
+	OriginalID        pgtype.UUID        `json:"original_id"`
+	CustomerRef       pgtype.Text        `json:"customer_ref"`
+	CustomerID        pgtype.UUID        `json:"customer_id"`
+	Amount            pgtype.Numeric     `json:"amount"`
+	Currency          string             `json:"currency"`
+	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+	OriginalCreatedAt pgtype.Timestamptz `json:"original_created_at"`
+}
+
+// A payment_succeeded event duplicates the one before it with the same customer_id, amount and
+// currency in its data when it follows it within the window. Only numeric amounts are compared,
+// and duplicates a duplicate_charge leak already points at are left out.
+func (q *Queries) FindDuplicateCharges(ctx context.Context, arg FindDuplicateChargesParams) ([]FindDuplicateChargesRow, error) {
+	rows, err := q.db.Query(ctx, findDuplicateCharges, arg.Since, arg.WindowSeconds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FindDuplicateChargesRow
+	for rows.Next() {
+		var i FindDuplicateChargesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.OriginalID,
+			&i.CustomerRef,
+			&i.CustomerID,
+			&i.Amount,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.OriginalCreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getAllEvents = `-- name: GetAllEvents :many
-SELECT id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at 
+SELECT id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source 
 FROM events
 ORDER BY created_at DESC
 LIMIT $1 OFFSET $2
@@ -105,6 +436,7 @@ func (q *Queries) GetAllEvents(ctx context.Context, arg GetAllEventsParams) ([]E
 			&i.Data,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Source,
 		); err != nil {
 			return nil, err
 		}
@@ -117,7 +449,7 @@ func (q *Queries) GetAllEvents(ctx context.Context, arg GetAllEventsParams) ([]E
 }
 
 const getAllEventsPaginated = `-- name: GetAllEventsPaginated :many
-SELECT id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at 
+SELECT id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source 
 FROM events
 ORDER BY created_at DESC
 LIMIT $1 OFFSET $2
@@ -147,6 +479,7 @@ func (q *Queries) GetAllEventsPaginated(ctx context.Context, arg GetAllEventsPag
 			&i.Data,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Source,
 		); err != nil {
 			return nil, err
 		}
@@ -158,15 +491,20 @@ func (q *Queries) GetAllEventsPaginated(ctx context.Context, arg GetAllEventsPag
 	return items, nil
 }
 
-const getEventByID = `-- name: GetEventByID :one
-SELECT 
-  id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at 
-FROM events 
-WHERE id = $1
+const getEventByEventID = `-- name: GetEventByEventID :one
+SELECT
+  id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source
+FROM events
+WHERE provider_id = $1 AND event_id = $2
 `
 
-func (q *Queries) GetEventByID(ctx context.Context, id pgtype.UUID) (Event, error) {
-	row := q.db.QueryRow(ctx, getEventByID, id)
+type GetEventByEventIDParams struct {
+	ProviderID pgtype.UUID `json:"provider_id"`
+	EventID    string      `json:"event_id"`
+}
+
+func (q *Queries) GetEventByEventID(ctx context.Context, arg GetEventByEventIDParams) (Event, error) {
+	row := q.db.QueryRow(ctx, getEventByEventID, arg.ProviderID, arg.EventID)
 	var i Event
 	err := row.Scan(
 		&i.ID,
@@ -178,35 +516,20 @@ func (q *Queries) GetEventByID(ctx context.Context, id pgtype.UUID) (Event, erro
 		&i.Data,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Source,
 	)
 	return i, err
 }
 
-const updateEvent = `-- name: UpdateEvent :one
-UPDATE events
-SET
-  event_type = CASE WHEN $1::event_type_enum IS NOT NULL THEN $1::event_type_enum ELSE event_type END,
-  status = CASE WHEN $2::event_status_enum IS NOT NULL THEN $2::event_status_enum ELSE status END,
-  data = CASE WHEN $3::jsonb IS NOT NULL THEN $3::jsonb ELSE data END
-WHERE id = $4
-RETURNING id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at
+const getEventByID = `-- name: GetEventByID :one
+SELECT 
+  id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source 
+FROM events 
+WHERE id = $1
 `
 
-type UpdateEventParams struct {
-	EventType NullEventTypeEnum   `json:"event_type"`
-	Status    NullEventStatusEnum `json:"status"`
-	Data      []byte              `json:"data"`
-	ID        pgtype.UUID         `json:"id"`
-}
-
-// it is not business logic to update the tenant_id, provider_id, event_id
-func (q *Queries) UpdateEvent(ctx context.Context, arg UpdateEventParams) (Event, error) {
-	row := q.db.QueryRow(ctx, updateEvent,
-		arg.EventType,
-		arg.Status,
-		arg.Data,
-		arg.ID,
-	)
+func (q *Queries) GetEventByID(ctx context.Context, id pgtype.UUID) (Event, error) {
+	row := q.db.QueryRow(ctx, getEventByID, id)
 	var i Event
 	err := row.Scan(
 		&i.ID,
@@ -218,6 +541,958 @@ func (q *Queries) UpdateEvent(ctx context.Context, arg UpdateEventParams) (Event
 		&i.Data,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Source,
 	)
 	return i, err
 }
+
+const getEventCountInWindow = `-- name: GetEventCountInWindow :one
+SELECT COUNT(*) FROM events
+WHERE created_at >= $1 AND created_at < $2
+`
+
+type GetEventCountInWindowParams struct {
+	WindowStart pgtype.Timestamptz `json:"window_start"`
+	WindowEnd   pgtype.Timestamptz `json:"window_end"`
+}
+
+func (q *Queries) GetEventCountInWindow(ctx context.Context, arg GetEventCountInWindowParams) (int64, error) {
+	row := q.db.QueryRow(ctx, getEventCountInWindow, arg.WindowStart, arg.WindowEnd)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getEventStatusHistory = `-- name: GetEventStatusHistory :many
+SELECT id, event_id, tenant_id, from_status, to_status, changed_at
+FROM event_status_history
+WHERE event_id = $1
+ORDER BY changed_at ASC, id ASC
+`
+
+// Oldest change first; id orders changes made at the same instant
+func (q *Queries) GetEventStatusHistory(ctx context.Context, eventID pgtype.UUID) ([]EventStatusHistory, error) {
+	rows, err := q.db.Query(ctx, getEventStatusHistory, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []EventStatusHistory
+	for rows.Next() {
+		var i EventStatusHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventID,
+			&i.TenantID,
+			&i.FromStatus,
+			&i.ToStatus,
+			&i.ChangedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEventsBefore = `-- name: GetEventsBefore :many
+SELECT
+  id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source
+FROM events
+WHERE tenant_id = $1
+  AND created_at < $2
+  AND (created_at, id) > ($3::timestamptz, $4::uuid)
+ORDER BY created_at, id
+LIMIT $5
+`
+
+type GetEventsBeforeParams struct {
+	TenantID       pgtype.UUID        `json:"tenant_id"`
+	Before         pgtype.Timestamptz `json:"before"`
+	AfterCreatedAt pgtype.Timestamptz `json:"after_created_at"`
+	AfterID        pgtype.UUID        `json:"after_id"`
+	MaxRows        int32              `json:"max_rows"`
+}
+
+// Keyset pagination on (created_at, id), so an archive can stream every event older than a cutoff page by page
+func (q *Queries) GetEventsBefore(ctx context.Context, arg GetEventsBeforeParams) ([]Event, error) {
+	rows, err := q.db.Query(ctx, getEventsBefore,
+		arg.TenantID,
+		arg.Before,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.ProviderID,
+			&i.EventType,
+			&i.EventID,
+			&i.Status,
+			&i.Data,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Source,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEventsByIDs = `-- name: GetEventsByIDs :many
+SELECT
+  id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source
+FROM events
+WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) GetEventsByIDs(ctx context.Context, ids []pgtype.UUID) ([]Event, error) {
+	rows, err := q.db.Query(ctx, getEventsByIDs, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.ProviderID,
+			&i.EventType,
+			&i.EventID,
+			&i.Status,
+			&i.Data,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Source,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEventsByLeakID = `-- name: GetEventsByLeakID :many
+SELECT
+  events.id, events.tenant_id, events.provider_id, events.event_type, events.event_id, events.status, events.data, events.created_at, events.updated_at, events.source
+FROM events
+JOIN leak_events ON leak_events.event_id = events.id
+WHERE leak_events.leak_id = $1
+ORDER BY events.created_at ASC
+`
+
+func (q *Queries) GetEventsByLeakID(ctx context.Context, leakID pgtype.UUID) ([]Event, error) {
+	rows, err := q.db.Query(ctx, getEventsByLeakID, leakID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.ProviderID,
+			&i.EventType,
+			&i.EventID,
+			&i.Status,
+			&i.Data,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Source,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEventsBySource = `-- name: GetEventsBySource :many
+SELECT
+  id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source
+FROM events
+WHERE tenant_id = $1 AND source = $2
+ORDER BY created_at DESC, id DESC
+LIMIT $3 OFFSET $4
+`
+
+type GetEventsBySourceParams struct {
+	TenantID pgtype.UUID     `json:"tenant_id"`
+	Source   EventSourceEnum `json:"source"`
+	Limit    int32           `json:"limit"`
+	Offset   int32           `json:"offset"`
+}
+
+// Events that entered the system one way, newest first; tenant_id is matched explicitly so
+// idx_events_tenant_source_created_at serves the filter and sort
+func (q *Queries) GetEventsBySource(ctx context.Context, arg GetEventsBySourceParams) ([]Event, error) {
+	rows, err := q.db.Query(ctx, getEventsBySource,
+		arg.TenantID,
+		arg.Source,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.ProviderID,
+			&i.EventType,
+			&i.EventID,
+			&i.Status,
+			&i.Data,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Source,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEventsWithoutLeak = `-- name: GetEventsWithoutLeak :many
+SELECT
+  events.id, events.tenant_id, events.provider_id, events.event_type, events.event_id, events.status, events.data, events.created_at, events.updated_at, events.source
+FROM events
+LEFT JOIN leak_events ON leak_events.event_id = events.id
+WHERE events.event_type = $1 AND leak_events.leak_id IS NULL
+ORDER BY events.created_at DESC, events.id
+LIMIT $2 OFFSET $3
+`
+
+type GetEventsWithoutLeakParams struct {
+	EventType EventTypeEnum `json:"event_type"`
+	Limit     int32         `json:"limit"`
+	Offset    int32         `json:"offset"`
+}
+
+// Events of one type that no leak is linked to, newest first, for detection coverage reports
+func (q *Queries) GetEventsWithoutLeak(ctx context.Context, arg GetEventsWithoutLeakParams) ([]Event, error) {
+	rows, err := q.db.Query(ctx, getEventsWithoutLeak, arg.EventType, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.ProviderID,
+			&i.EventType,
+			&i.EventID,
+			&i.Status,
+			&i.Data,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Source,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFailedEvents = `-- name: GetFailedEvents :many
+SELECT
+  id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source
+FROM events
+WHERE status = 'failed' AND id > $1
+ORDER BY id ASC
+LIMIT $2
+`
+
+type GetFailedEventsParams struct {
+	AfterID pgtype.UUID `json:"after_id"`
+	MaxRows int32       `json:"max_rows"`
+}
+
+// Keyset pagination on id, so a caller can resume after the last event it saw
+func (q *Queries) GetFailedEvents(ctx context.Context, arg GetFailedEventsParams) ([]Event, error) {
+	rows, err := q.db.Query(ctx, getFailedEvents, arg.AfterID, arg.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.ProviderID,
+			&i.EventType,
+			&i.EventID,
+			&i.Status,
+			&i.Data,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Source,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getIngestionLagByProviderType = `-- name: GetIngestionLagByProviderType :many
+SELECT
+  providers.provider_type,
+  COUNT(*) AS events,
+  COUNT(*) FILTER (WHERE CASE WHEN jsonb_typeof(events.data->'created') = 'number' THEN (events.data->>'created')::float8 <= 0 ELSE true END) AS missing_timestamp,
+  COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY
+    CASE WHEN jsonb_typeof(events.data->'created') = 'number' THEN
+      CASE WHEN (events.data->>'created')::float8 > 0
+        THEN GREATEST(EXTRACT(EPOCH FROM events.created_at) - (events.data->>'created')::float8, 0)
+      END
+    END), 0)::float8 AS p95_lag_seconds
+FROM events
+JOIN providers ON providers.id = events.provider_id
+WHERE events.created_at >= $1 AND events.created_at < $2
+GROUP BY providers.provider_type
+ORDER BY providers.provider_type
+`
+
+type GetIngestionLagByProviderTypeParams struct {
+	WindowStart pgtype.Timestamptz `json:"window_start"`
+	WindowEnd   pgtype.Timestamptz `json:"window_end"`
+}
+
+type GetIngestionLagByProviderTypeRow struct {
+	ProviderType     string  `json:"provider_type"`
+	Events           int64   `json:"events"`
+	MissingTimestamp int64   `json:"missing_timestamp"`
+	P95LagSeconds    float64 `json:"p95_lag_seconds"`
+}
+
+// Lag from the provider's own timestamp, data.created in Unix seconds, to ingestion. Events
+// without one are counted in missing_timestamp and left out of the percentile, which is 0
+// when every event lacks one
+func (q *Queries) GetIngestionLagByProviderType(ctx context.Context, arg GetIngestionLagByProviderTypeParams) ([]GetIngestionLagByProviderTypeRow, error) {
+	rows, err := q.db.Query(ctx, getIngestionLagByProviderType, arg.WindowStart, arg.WindowEnd)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetIngestionLagByProviderTypeRow
+	for rows.Next() {
+		var i GetIngestionLagByProviderTypeRow
+		if err := rows.Scan(
+			&i.ProviderType,
+			&i.Events,
+			&i.MissingTimestamp,
+			&i.P95LagSeconds,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getProviderFailureRates = `-- name: GetProviderFailureRates :many
+SELECT
+  events.provider_id,
+  providers.name AS provider_name,
+  COUNT(*) FILTER (WHERE events.event_type = 'payment_failed') AS failed,
+  COUNT(*) AS total
+FROM events
+JOIN providers ON providers.id = events.provider_id
+WHERE events.tenant_id = $1
+  AND events.event_type IN ('payment_failed', 'payment_succeeded')
+  AND events.created_at >= $2 AND events.created_at < $3
+GROUP BY events.provider_id, providers.name
+ORDER BY events.provider_id
+`
+
+type GetProviderFailureRatesParams struct {
+	TenantID    pgtype.UUID        `json:"tenant_id"`
+	WindowStart pgtype.Timestamptz `json:"window_start"`
+	WindowEnd   pgtype.Timestamptz `json:"window_end"`
+}
+
+type GetProviderFailureRatesRow struct {
+	ProviderID   pgtype.UUID `json:"provider_id"`
+	ProviderName string      `json:"provider_name"`
+	Failed       int64       `json:"failed"`
+	Total        int64       `json:"total"`
+}
+
+// Payment attempts, the payment_failed and payment_succeeded events, per provider created in
+// [window_start, window_end), and how many of them failed. Providers without attempts in the
+// window are left out
+func (q *Queries) GetProviderFailureRates(ctx context.Context, arg GetProviderFailureRatesParams) ([]GetProviderFailureRatesRow, error) {
+	rows, err := q.db.Query(ctx, getProviderFailureRates, arg.TenantID, arg.WindowStart, arg.WindowEnd)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetProviderFailureRatesRow
+	for rows.Next() {
+		var i GetProviderFailureRatesRow
+		if err := rows.Scan(
+			&i.ProviderID,
+			&i.ProviderName,
+			&i.Failed,
+			&i.Total,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRecentEvents = `-- name: GetRecentEvents :many
+SELECT
+  id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source
+FROM events
+WHERE tenant_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2
+`
+
+type GetRecentEventsParams struct {
+	TenantID pgtype.UUID `json:"tenant_id"`
+	MaxRows  int32       `json:"max_rows"`
+}
+
+// tenant_id is matched explicitly, not only through RLS, so idx_events_tenant_created_at serves the sort and limit
+func (q *Queries) GetRecentEvents(ctx context.Context, arg GetRecentEventsParams) ([]Event, error) {
+	rows, err := q.db.Query(ctx, getRecentEvents, arg.TenantID, arg.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.ProviderID,
+			&i.EventType,
+			&i.EventID,
+			&i.Status,
+			&i.Data,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Source,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRelatedEvents = `-- name: GetRelatedEvents :many
+SELECT
+  id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source
+FROM events
+WHERE id <> $1
+  AND created_at BETWEEN $2 AND $3
+  AND data @> ANY($4::jsonb[])
+ORDER BY created_at ASC
+`
+
+type GetRelatedEventsParams struct {
+	ID          pgtype.UUID        `json:"id"`
+	WindowStart pgtype.Timestamptz `json:"window_start"`
+	WindowEnd   pgtype.Timestamptz `json:"window_end"`
+	Matches     [][]byte           `json:"matches"`
+}
+
+// matches holds one containment document per object path the correlation keys may sit under
+func (q *Queries) GetRelatedEvents(ctx context.Context, arg GetRelatedEventsParams) ([]Event, error) {
+	rows, err := q.db.Query(ctx, getRelatedEvents,
+		arg.ID,
+		arg.WindowStart,
+		arg.WindowEnd,
+		arg.Matches,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.ProviderID,
+			&i.EventType,
+			&i.EventID,
+			&i.Status,
+			&i.Data,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Source,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const hasAnyEvents = `-- name: HasAnyEvents :one
+SELECT EXISTS (SELECT 1 FROM events WHERE tenant_id = $1)
+`
+
+// EXISTS stops at the first matching row, unlike COUNT(*) which visits them all
+func (q *Queries) HasAnyEvents(ctx context.Context, tenantID pgtype.UUID) (bool, error) {
+	row := q.db.QueryRow(ctx, hasAnyEvents, tenantID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listEventsByFilter = `-- name: ListEventsByFilter :many
+SELECT id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source
+FROM events
+WHERE (cardinality($1::text[]) = 0 OR event_type = ANY($1::text[]::event_type_enum[]))
+  AND (cardinality($2::text[]) = 0 OR status = ANY($2::text[]::event_status_enum[]))
+  AND (cardinality($3::uuid[]) = 0 OR provider_id = ANY($3::uuid[]))
+ORDER BY created_at DESC, id DESC
+LIMIT $4 OFFSET $5
+`
+
+type ListEventsByFilterParams struct {
+	EventTypes  []string      `json:"event_types"`
+	Statuses    []string      `json:"statuses"`
+	ProviderIds []pgtype.UUID `json:"provider_ids"`
+	Limit       int32         `json:"limit"`
+	Offset      int32         `json:"offset"`
+}
+
+// The filters are cast to the enum types so the event type and status indexes can serve them,
+// and id breaks created_at ties so offset pages neither skip nor repeat events
+func (q *Queries) ListEventsByFilter(ctx context.Context, arg ListEventsByFilterParams) ([]Event, error) {
+	rows, err := q.db.Query(ctx, listEventsByFilter,
+		arg.EventTypes,
+		arg.Statuses,
+		arg.ProviderIds,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.ProviderID,
+			&i.EventType,
+			&i.EventID,
+			&i.Status,
+			&i.Data,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Source,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEventsByFilterAfterID = `-- name: ListEventsByFilterAfterID :many
+SELECT id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source
+FROM events
+WHERE id > $1
+  AND (cardinality($2::text[]) = 0 OR event_type = ANY($2::text[]::event_type_enum[]))
+  AND (cardinality($3::text[]) = 0 OR status = ANY($3::text[]::event_status_enum[]))
+  AND (cardinality($4::uuid[]) = 0 OR provider_id = ANY($4::uuid[]))
+ORDER BY id ASC
+LIMIT $5
+`
+
+type ListEventsByFilterAfterIDParams struct {
+	AfterID     pgtype.UUID   `json:"after_id"`
+	EventTypes  []string      `json:"event_types"`
+	Statuses    []string      `json:"statuses"`
+	ProviderIds []pgtype.UUID `json:"provider_ids"`
+	MaxRows     int32         `json:"max_rows"`
+}
+
+// Keyset pages in ID order, so a pass over every matching event neither skips nor repeats events inserted meanwhile
+func (q *Queries) ListEventsByFilterAfterID(ctx context.Context, arg ListEventsByFilterAfterIDParams) ([]Event, error) {
+	rows, err := q.db.Query(ctx, listEventsByFilterAfterID,
+		arg.AfterID,
+		arg.EventTypes,
+		arg.Statuses,
+		arg.ProviderIds,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.ProviderID,
+			&i.EventType,
+			&i.EventID,
+			&i.Status,
+			&i.Data,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Source,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEventsForExport = `-- name: ListEventsForExport :many
+SELECT id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source
+FROM events
+WHERE (created_at, id) < ($1::timestamptz, $2::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT $3
+`
+
+type ListEventsForExportParams struct {
+	AfterCreatedAt pgtype.Timestamptz `json:"after_created_at"`
+	AfterID        pgtype.UUID        `json:"after_id"`
+	MaxRows        int32              `json:"max_rows"`
+}
+
+// Keyset pagination on (created_at, id), newest first, so an export can stream every event page
+// by page; idx_events_tenant_created_at serves it
+func (q *Queries) ListEventsForExport(ctx context.Context, arg ListEventsForExportParams) ([]Event, error) {
+	rows, err := q.db.Query(ctx, listEventsForExport, arg.AfterCreatedAt, arg.AfterID, arg.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.ProviderID,
+			&i.EventType,
+			&i.EventID,
+			&i.Status,
+			&i.Data,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Source,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeEventsBefore = `-- name: PurgeEventsBefore :execrows
+DELETE FROM events
+WHERE id IN (
+  SELECT id FROM events
+  WHERE tenant_id = $1 AND created_at < $2
+  ORDER BY created_at
+  LIMIT $3
+)
+`
+
+type PurgeEventsBeforeParams struct {
+	TenantID  pgtype.UUID        `json:"tenant_id"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	Limit     int32              `json:"limit"`
+}
+
+// Deletes at most $3 of the tenant's events created before $2, oldest first, so each call holds its locks briefly
+func (q *Queries) PurgeEventsBefore(ctx context.Context, arg PurgeEventsBeforeParams) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeEventsBefore, arg.TenantID, arg.CreatedAt, arg.Limit)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const reattributeEvents = `-- name: ReattributeEvents :execrows
+UPDATE events
+SET provider_id = $1
+WHERE tenant_id = $2 AND provider_id = $3
+`
+
+type ReattributeEventsParams struct {
+	ToProviderID   pgtype.UUID `json:"to_provider_id"`
+	TenantID       pgtype.UUID `json:"tenant_id"`
+	FromProviderID pgtype.UUID `json:"from_provider_id"`
+}
+
+// Moves the tenant's events from one provider to another, for events ingested under the wrong
+// provider. An event ID the target already has fails on idx_events_tenant_provider_event_id.
+func (q *Queries) ReattributeEvents(ctx context.Context, arg ReattributeEventsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, reattributeEvents, arg.ToProviderID, arg.TenantID, arg.FromProviderID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const searchEventsByExternalIDPrefix = `-- name: SearchEventsByExternalIDPrefix :many
+SELECT id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source
+FROM events
+WHERE tenant_id = $1 AND event_id LIKE $2
+ORDER BY event_id, id
+LIMIT $3 OFFSET $4
+`
+
+type SearchEventsByExternalIDPrefixParams struct {
+	TenantID pgtype.UUID `json:"tenant_id"`
+	Pattern  string      `json:"pattern"`
+	Limit    int32       `json:"limit"`
+	Offset   int32       `json:"offset"`
+}
+
+// pattern is a LIKE prefix pattern with its wildcards escaped; idx_events_tenant_event_id_prefix serves it
+func (q *Queries) SearchEventsByExternalIDPrefix(ctx context.Context, arg SearchEventsByExternalIDPrefixParams) ([]Event, error) {
+	rows, err := q.db.Query(ctx, searchEventsByExternalIDPrefix,
+		arg.TenantID,
+		arg.Pattern,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.ProviderID,
+			&i.EventType,
+			&i.EventID,
+			&i.Status,
+			&i.Data,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Source,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateEvent = `-- name: UpdateEvent :one
+UPDATE events
+SET
+  event_type = COALESCE($1::event_type_enum, event_type),
+  status = COALESCE($2::event_status_enum, status),
+  data = CASE
+    WHEN $4::boolean
+      AND jsonb_typeof(data) = 'object'
+      AND jsonb_typeof($3::jsonb) = 'object'
+    THEN data || $3::jsonb
+    ELSE COALESCE($3::jsonb, data)
+  END
+WHERE id = $5
+RETURNING id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source
+`
+
+type UpdateEventParams struct {
+	EventType NullEventTypeEnum   `json:"event_type"`
+	Status    NullEventStatusEnum `json:"status"`
+	Data      []byte              `json:"data"`
+	MergeData bool                `json:"merge_data"`
+	ID        pgtype.UUID         `json:"id"`
+}
+
+// it is not business logic to update the tenant_id, provider_id, event_id
+// A NULL argument leaves its column unchanged, so retrying a partial update is safe.
+// With merge_data, an object data is merged into a stored object instead of replacing it.
+func (q *Queries) UpdateEvent(ctx context.Context, arg UpdateEventParams) (Event, error) {
+	row := q.db.QueryRow(ctx, updateEvent,
+		arg.EventType,
+		arg.Status,
+		arg.Data,
+		arg.MergeData,
+		arg.ID,
+	)
+	var i Event
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.ProviderID,
+		&i.EventType,
+		&i.EventID,
+		&i.Status,
+		&i.Data,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Source,
+	)
+	return i, err
+}
+
+const updateEventIfVersion = `-- name: UpdateEventIfVersion :one
+UPDATE events
+SET
+  event_type = COALESCE($1::event_type_enum, event_type),
+  status = COALESCE($2::event_status_enum, status),
+  data = CASE
+    WHEN $4::boolean
+      AND jsonb_typeof(data) = 'object'
+      AND jsonb_typeof($3::jsonb) = 'object'
+    THEN data || $3::jsonb
+    ELSE COALESCE($3::jsonb, data)
+  END
+WHERE id = $5 AND updated_at = $6
+RETURNING id, tenant_id, provider_id, event_type, event_id, status, data, created_at, updated_at, source
+`
+
+type UpdateEventIfVersionParams struct {
+	EventType         NullEventTypeEnum   `json:"event_type"`
+	Status            NullEventStatusEnum `json:"status"`
+	Data              []byte              `json:"data"`
+	MergeData         bool                `json:"merge_data"`
+	ID                pgtype.UUID         `json:"id"`
+	ExpectedUpdatedAt pgtype.Timestamptz  `json:"expected_updated_at"`
+}
+
+func (q *Queries) UpdateEventIfVersion(ctx context.Context, arg UpdateEventIfVersionParams) (Event, error) {
+	row := q.db.QueryRow(ctx, updateEventIfVersion,
+		arg.EventType,
+		arg.Status,
+		arg.Data,
+		arg.MergeData,
+		arg.ID,
+		arg.ExpectedUpdatedAt,
+	)
+	var i Event
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.ProviderID,
+		&i.EventType,
+		&i.EventID,
+		&i.Status,
+		&i.Data,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Source,
+	)
+	return i, err
+}
+
+const updateEventStatusByFilter = `-- name: UpdateEventStatusByFilter :execrows
+UPDATE events
+SET status = $1
+WHERE (cardinality($2::text[]) = 0 OR event_type = ANY($2::text[]::event_type_enum[]))
+  AND (cardinality($3::text[]) = 0 OR status = ANY($3::text[]::event_status_enum[]))
+  AND (cardinality($4::uuid[]) = 0 OR provider_id = ANY($4::uuid[]))
+`
+
+type UpdateEventStatusByFilterParams struct {
+	NewStatus   EventStatusEnum `json:"new_status"`
+	EventTypes  []string        `json:"event_types"`
+	Statuses    []string        `json:"statuses"`
+	ProviderIds []pgtype.UUID   `json:"provider_ids"`
+}
+
+// Callers must refuse an empty filter, which would update every event of the tenant
+func (q *Queries) UpdateEventStatusByFilter(ctx context.Context, arg UpdateEventStatusByFilterParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateEventStatusByFilter,
+		arg.NewStatus,
+		arg.EventTypes,
+		arg.Statuses,
+		arg.ProviderIds,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
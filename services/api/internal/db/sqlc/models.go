@@ -59,10 +59,12 @@ func (ns NullActionResultEnum) Value() (driver.Value, error) {
 type ActionStatusEnum string
 
 const (
-	ActionStatusEnumPending  ActionStatusEnum = "pending"
-	ActionStatusEnumApproved ActionStatusEnum = "approved"
-	ActionStatusEnumModified ActionStatusEnum = "modified"
-	ActionStatusEnumDenied   ActionStatusEnum = "denied"
+	ActionStatusEnumPending    ActionStatusEnum = "pending"
+	ActionStatusEnumApproved   ActionStatusEnum = "approved"
+	ActionStatusEnumModified   ActionStatusEnum = "modified"
+	ActionStatusEnumDenied     ActionStatusEnum = "denied"
+	ActionStatusEnumInProgress ActionStatusEnum = "in_progress"
+	ActionStatusEnumFailed     ActionStatusEnum = "failed"
 )
 
 func (e *ActionStatusEnum) Scan(src interface{}) error {
@@ -145,12 +147,57 @@ func (ns NullActionTypeEnum) Value() (driver.Value, error) {
 	return string(ns.ActionTypeEnum), nil
 }
 
+type EventSourceEnum string
+
+const (
+	EventSourceEnumWebhook EventSourceEnum = "webhook"
+	EventSourceEnumImport  EventSourceEnum = "import"
+	EventSourceEnumManual  EventSourceEnum = "manual"
+	EventSourceEnumApi     EventSourceEnum = "api"
+)
+
+func (e *EventSourceEnum) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = EventSourceEnum(s)
+	case string:
+		*e = EventSourceEnum(s)
+	default:
+		return fmt.Errorf("unsupported scan type for EventSourceEnum: %T", src)
+	}
+	return nil
+}
+
+type NullEventSourceEnum struct {
+	EventSourceEnum EventSourceEnum `json:"event_source_enum"`
+	Valid           bool            `json:"valid"` // Valid is true if EventSourceEnum is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullEventSourceEnum) Scan(value interface{}) error {
+	if value == nil {
+		ns.EventSourceEnum, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.EventSourceEnum.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullEventSourceEnum) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.EventSourceEnum), nil
+}
+
 type EventStatusEnum string
 
 const (
-	EventStatusEnumPending   EventStatusEnum = "pending"
-	EventStatusEnumProcessed EventStatusEnum = "processed"
-	EventStatusEnumFailed    EventStatusEnum = "failed"
+	EventStatusEnumPending    EventStatusEnum = "pending"
+	EventStatusEnumProcessed  EventStatusEnum = "processed"
+	EventStatusEnumFailed     EventStatusEnum = "failed"
+	EventStatusEnumProcessing EventStatusEnum = "processing"
 )
 
 func (e *EventStatusEnum) Scan(src interface{}) error {
@@ -232,6 +279,49 @@ func (ns NullEventTypeEnum) Value() (driver.Value, error) {
 	return string(ns.EventTypeEnum), nil
 }
 
+type LeakStatusEnum string
+
+const (
+	LeakStatusEnumOpen     LeakStatusEnum = "open"
+	LeakStatusEnumResolved LeakStatusEnum = "resolved"
+	LeakStatusEnumIgnored  LeakStatusEnum = "ignored"
+)
+
+func (e *LeakStatusEnum) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = LeakStatusEnum(s)
+	case string:
+		*e = LeakStatusEnum(s)
+	default:
+		return fmt.Errorf("unsupported scan type for LeakStatusEnum: %T", src)
+	}
+	return nil
+}
+
+type NullLeakStatusEnum struct {
+	LeakStatusEnum LeakStatusEnum `json:"leak_status_enum"`
+	Valid          bool           `json:"valid"` // Valid is true if LeakStatusEnum is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullLeakStatusEnum) Scan(value interface{}) error {
+	if value == nil {
+		ns.LeakStatusEnum, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.LeakStatusEnum.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullLeakStatusEnum) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.LeakStatusEnum), nil
+}
+
 type LeakTypeEnum string
 
 const (
@@ -241,6 +331,11 @@ const (
 	LeakTypeEnumCouponDiscountMisuse LeakTypeEnum = "coupon_discount_misuse"
 	LeakTypeEnumTrialForever         LeakTypeEnum = "trial_forever"
 	LeakTypeEnumOther                LeakTypeEnum = "other"
+	LeakTypeEnumVolumeAnomaly        LeakTypeEnum = "volume_anomaly"
+	LeakTypeEnumDuplicateCharge      LeakTypeEnum = "duplicate_charge"
+	LeakTypeEnumDunningGap           LeakTypeEnum = "dunning_gap"
+	LeakTypeEnumCurrencyMismatch     LeakTypeEnum = "currency_mismatch"
+	LeakTypeEnumFailureRateSpike     LeakTypeEnum = "failure_rate_spike"
 )
 
 func (e *LeakTypeEnum) Scan(src interface{}) error {
@@ -278,6 +373,49 @@ func (ns NullLeakTypeEnum) Value() (driver.Value, error) {
 	return string(ns.LeakTypeEnum), nil
 }
 
+type NotificationChannelTypeEnum string
+
+const (
+	NotificationChannelTypeEnumSlack   NotificationChannelTypeEnum = "slack"
+	NotificationChannelTypeEnumEmail   NotificationChannelTypeEnum = "email"
+	NotificationChannelTypeEnumWebhook NotificationChannelTypeEnum = "webhook"
+)
+
+func (e *NotificationChannelTypeEnum) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = NotificationChannelTypeEnum(s)
+	case string:
+		*e = NotificationChannelTypeEnum(s)
+	default:
+		return fmt.Errorf("unsupported scan type for NotificationChannelTypeEnum: %T", src)
+	}
+	return nil
+}
+
+type NullNotificationChannelTypeEnum struct {
+	NotificationChannelTypeEnum NotificationChannelTypeEnum `json:"notification_channel_type_enum"`
+	Valid                       bool                        `json:"valid"` // Valid is true if NotificationChannelTypeEnum is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullNotificationChannelTypeEnum) Scan(value interface{}) error {
+	if value == nil {
+		ns.NotificationChannelTypeEnum, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.NotificationChannelTypeEnum.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullNotificationChannelTypeEnum) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.NotificationChannelTypeEnum), nil
+}
+
 type PaymentStatusEnum string
 
 const (
@@ -372,6 +510,10 @@ type Action struct {
 	Result     ActionResultEnum   `json:"result"`
 	CreatedAt  pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt  pgtype.Timestamptz `json:"updated_at"`
+	Priority   int64              `json:"priority"`
+	ClaimedBy  pgtype.Text        `json:"claimed_by"`
+	ClaimedAt  pgtype.Timestamptz `json:"claimed_at"`
+	Attempts   int32              `json:"attempts"`
 }
 
 type Customer struct {
@@ -394,6 +536,30 @@ type Event struct {
 	Data       json.RawMessage    `json:"data"`
 	CreatedAt  pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt  pgtype.Timestamptz `json:"updated_at"`
+	Source     EventSourceEnum    `json:"source"`
+}
+
+type EventStatusHistory struct {
+	ID         int64              `json:"id"`
+	EventID    pgtype.UUID        `json:"event_id"`
+	TenantID   pgtype.UUID        `json:"tenant_id"`
+	FromStatus EventStatusEnum    `json:"from_status"`
+	ToStatus   EventStatusEnum    `json:"to_status"`
+	ChangedAt  pgtype.Timestamptz `json:"changed_at"`
+}
+
+type IdempotencyKey struct {
+	TenantID       pgtype.UUID        `json:"tenant_id"`
+	IdempotencyKey string             `json:"idempotency_key"`
+	RequestMethod  string             `json:"request_method"`
+	RequestPath    string             `json:"request_path"`
+	StatusCode     int32              `json:"status_code"`
+	ContentType    string             `json:"content_type"`
+	Body           []byte             `json:"body"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	ExpiresAt      pgtype.Timestamptz `json:"expires_at"`
+	RequestHash    string             `json:"request_hash"`
+	Headers        json.RawMessage    `json:"headers"`
 }
 
 type Integration struct {
@@ -405,15 +571,54 @@ type Integration struct {
 }
 
 type Leak struct {
-	ID         pgtype.UUID        `json:"id"`
-	TenantID   pgtype.UUID        `json:"tenant_id"`
-	CustomerID pgtype.UUID        `json:"customer_id"`
-	LeakType   LeakTypeEnum       `json:"leak_type"`
-	Amount     pgtype.Numeric     `json:"amount"`
-	Confidence int32              `json:"confidence"`
-	CreatedAt  pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt  pgtype.Timestamptz `json:"updated_at"`
-	PaymentID  pgtype.UUID        `json:"payment_id"`
+	ID            pgtype.UUID        `json:"id"`
+	TenantID      pgtype.UUID        `json:"tenant_id"`
+	CustomerID    pgtype.UUID        `json:"customer_id"`
+	LeakType      LeakTypeEnum       `json:"leak_type"`
+	Amount        pgtype.Numeric     `json:"amount"`
+	Confidence    int32              `json:"confidence"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
+	PaymentID     pgtype.UUID        `json:"payment_id"`
+	Status        LeakStatusEnum     `json:"status"`
+	Currency      string             `json:"currency"`
+	SourceEventID pgtype.UUID        `json:"source_event_id"`
+	DetectedAt    pgtype.Timestamptz `json:"detected_at"`
+	ResolvedAt    pgtype.Timestamptz `json:"resolved_at"`
+	Metadata      json.RawMessage    `json:"metadata"`
+	SnoozedUntil  pgtype.Timestamptz `json:"snoozed_until"`
+	DedupKey      pgtype.Text        `json:"dedup_key"`
+	Occurrences   int32              `json:"occurrences"`
+}
+
+type LeakEvent struct {
+	LeakID    pgtype.UUID        `json:"leak_id"`
+	EventID   pgtype.UUID        `json:"event_id"`
+	TenantID  pgtype.UUID        `json:"tenant_id"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type NotificationChannel struct {
+	ID          pgtype.UUID                 `json:"id"`
+	TenantID    pgtype.UUID                 `json:"tenant_id"`
+	ChannelType NotificationChannelTypeEnum `json:"channel_type"`
+	Target      string                      `json:"target"`
+	Enabled     bool                        `json:"enabled"`
+	CreatedAt   pgtype.Timestamptz          `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz          `json:"updated_at"`
+}
+
+type NotificationOutbox struct {
+	ID            pgtype.UUID        `json:"id"`
+	TenantID      pgtype.UUID        `json:"tenant_id"`
+	ChannelID     pgtype.UUID        `json:"channel_id"`
+	Title         string             `json:"title"`
+	Body          string             `json:"body"`
+	Attempts      int32              `json:"attempts"`
+	NextAttemptAt pgtype.Timestamptz `json:"next_attempt_at"`
+	LastError     pgtype.Text        `json:"last_error"`
+	FailedAt      pgtype.Timestamptz `json:"failed_at"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
 }
 
 type Payment struct {
@@ -427,21 +632,32 @@ type Payment struct {
 	PaymentType PaymentTypeEnum    `json:"payment_type"`
 	CreatedAt   pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+	ProviderID  pgtype.UUID        `json:"provider_id"`
+	EventID     pgtype.UUID        `json:"event_id"`
 }
 
 type Provider struct {
-	ID        pgtype.UUID        `json:"id"`
-	Name      string             `json:"name"`
-	CreatedAt pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	ID           pgtype.UUID        `json:"id"`
+	Name         string             `json:"name"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
+	ProviderType string             `json:"provider_type"`
 }
 
 type Tenant struct {
-	ID        pgtype.UUID        `json:"id"`
-	Email     string             `json:"email"`
-	Name      string             `json:"name"`
-	CreatedAt pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	ID                 pgtype.UUID        `json:"id"`
+	Email              string             `json:"email"`
+	Name               string             `json:"name"`
+	CreatedAt          pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt          pgtype.Timestamptz `json:"updated_at"`
+	AcceptedEventTypes []EventTypeEnum    `json:"accepted_event_types"`
+	AllowedProviderIds []pgtype.UUID      `json:"allowed_provider_ids"`
+	MinLeakAmounts     json.RawMessage    `json:"min_leak_amounts"`
+	EventRetentionDays *int32             `json:"event_retention_days"`
+	LeakSources        json.RawMessage    `json:"leak_sources"`
+	DunningWindowHours *int32             `json:"dunning_window_hours"`
+	RateLimitRps       *float64           `json:"rate_limit_rps"`
+	RateLimitBurst     *int32             `json:"rate_limit_burst"`
 }
 
 type User struct {
@@ -6,32 +6,227 @@ package db
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
+	// Claims the actions GetPendingActionsByPriority locked, in the same transaction, taking over
+	// the stale claims among them. Every claim starts an attempt, so an action whose worker dies
+	// still counts towards the cap.
+	ClaimActions(ctx context.Context, arg ClaimActionsParams) ([]Action, error)
+	// Starts the next attempt of the tenant's due deliveries, longest waiting first, and holds them
+	// for lease_seconds so no other worker retries them meanwhile. SKIP LOCKED lets concurrent
+	// workers each claim a different set of rows instead of waiting.
+	ClaimDueNotifications(ctx context.Context, arg ClaimDueNotificationsParams) ([]NotificationOutbox, error)
+	// Oldest pending events first; SKIP LOCKED lets concurrent workers each claim a different set
+	ClaimPendingEvents(ctx context.Context, limit int32) ([]Event, error)
+	// Ends a claimed action's attempt as a success: the action is approved and done
+	CompleteAction(ctx context.Context, arg CompleteActionParams) (Action, error)
+	// Leaks without a customer, such as tenant-wide anomalies, are not counted
+	CountAffectedCustomers(ctx context.Context, arg CountAffectedCustomersParams) (int64, error)
+	CountActionsFiltered(ctx context.Context, arg CountActionsFilteredParams) (int64, error)
 	CountAllActions(ctx context.Context) (int64, error)
 	CountAllEvents(ctx context.Context) (int64, error)
+	CountEventsByExternalIDPrefix(ctx context.Context, arg CountEventsByExternalIDPrefixParams) (int64, error)
+	CountEventsByFilter(ctx context.Context, arg CountEventsByFilterParams) (int64, error)
+	CountEventsBySource(ctx context.Context, arg CountEventsBySourceParams) (int64, error)
+	CountEventsByStatusForProvider(ctx context.Context, providerID pgtype.UUID) ([]CountEventsByStatusForProviderRow, error)
+	CountEventsWithoutLeak(ctx context.Context, eventType EventTypeEnum) (int64, error)
+	CountLeaksByFilter(ctx context.Context, arg CountLeaksByFilterParams) (int64, error)
+	// A leak snoozed until a time still in the future is not counted
+	CountOpenLeaks(ctx context.Context) (int64, error)
 	CreateAction(ctx context.Context, arg CreateActionParams) (Action, error)
 	CreateEvent(ctx context.Context, arg CreateEventParams) (Event, error)
+	// Stores a new leak unless its source event already has a leak of the same type, in which
+	// case nothing is stored and no row is returned
+	CreateLeak(ctx context.Context, arg CreateLeakParams) (Leak, error)
+	CreateNotificationChannel(ctx context.Context, arg CreateNotificationChannelParams) (NotificationChannel, error)
+	CreatePayment(ctx context.Context, arg CreatePaymentParams) (Payment, error)
+	CreateProvider(ctx context.Context, arg CreateProviderParams) (Provider, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	DeleteAction(ctx context.Context, id pgtype.UUID) (int64, error)
 	DeleteEvent(ctx context.Context, id pgtype.UUID) (int64, error)
+	// Frees the tenant's expired keys, so a key can be stored again once its response is no longer replayed
+	DeleteExpiredIdempotencyKeys(ctx context.Context, tenantID pgtype.UUID) (int64, error)
+	DeleteNotificationChannel(ctx context.Context, id pgtype.UUID) (int64, error)
+	// A delivered notification leaves the outbox
+	DeleteOutboxNotification(ctx context.Context, id pgtype.UUID) (int64, error)
 	DeleteUser(ctx context.Context, id pgtype.UUID) (int64, error)
-	GetActionByID(ctx context.Context, id pgtype.UUID) (Action, error)
+	// The row is stored with its first attempt already started, since the caller delivers it
+	// straight away; it is only retried once lease_seconds have passed
+	EnqueueNotification(ctx context.Context, arg EnqueueNotificationParams) (NotificationOutbox, error)
+	// Ends a claimed action's attempt as a failure. Below max_attempts the action goes back to
+	// pending to be claimed again; at the cap it is failed for good, which is never claimed.
+	FailActionAttempt(ctx context.Context, arg FailActionAttemptParams) (Action, error)
+	// Fails the tenant's actions that have used up max_attempts but are still queued: a stale claim
+	// on the last attempt, whose worker never reported back, or a pending action left over from a
+	// higher cap. The queue skips them, so without this they would never leave that state.
+	FailExhaustedActions(ctx context.Context, arg FailExhaustedActionsParams) (int64, error)
+	// Ends an attempt as a failure. Below max_attempts the delivery is due again after
+	// backoff_seconds; at the cap the outbox gives up on it and keeps the row for inspection.
+	FailOutboxAttempt(ctx context.Context, arg FailOutboxAttemptParams) (NotificationOutbox, error)
+	// Payments with the same external ID belong to the same charge, such as a charge and its refund,
+	// so they should share its currency. The earliest is the original; a later one created since
+	// @since in another currency is a mismatch. Only payments read from an event are reported, and
+	// those a currency_mismatch leak already points at are left out.
+	FindCurrencyMismatches(ctx context.Context, since pgtype.Timestamptz) ([]FindCurrencyMismatchesRow, error)
+	// A payment_failed event has a dunning gap when no payment_failed, payment_succeeded or
+	// payment_updated event with the same customer_id follows it within the dunning window, the
+	// tenant's dunning_window_hours or else the default. Only failures whose window has passed by
+	// @now and began within the lookback before that are checked, and failures a dunning_gap leak
+	// already points at or is linked to are left out.
+	FindDunningGaps(ctx context.Context, arg FindDunningGapsParams) ([]FindDunningGapsRow, error)
+	// A payment_succeeded event duplicates the one before it with the same customer_id, amount and
+	// currency in its data when it follows it within the window. Only numeric amounts are compared,
+	// and duplicates a duplicate_charge leak already points at are left out.
+	FindDuplicateCharges(ctx context.Context, arg FindDuplicateChargesParams) ([]FindDuplicateChargesRow, error)
+	// Leaks detected at the given time, triggered by one of the given events or sharing one of the
+	// given dedup keys, whatever their status; a backfill uses them to skip candidates that are
+	// already stored
+	FindExistingLeaks(ctx context.Context, arg FindExistingLeaksParams) ([]Leak, error)
+	// The tenant predicate holds independently of row-level security, so a misconfigured policy
+	// still cannot return another tenant's action
+	GetActionByID(ctx context.Context, arg GetActionByIDParams) (Action, error)
+	// The tenant predicate holds independently of row-level security, as in GetActionByID
+	GetActionsByLeakID(ctx context.Context, arg GetActionsByLeakIDParams) ([]Action, error)
+	// Actions have no tenant_id; the tenant predicate goes through their leak, as in GetActionByID.
+	// sort_by is one of created_at, updated_at and priority, and only the matching pair of CASE
+	// expressions orders anything. id breaks ties so pages are stable.
+	GetActionsFiltered(ctx context.Context, arg GetActionsFilteredParams) ([]Action, error)
+	// The tenant predicate holds independently of row-level security, as in GetActionByID
+	GetActionWithLeak(ctx context.Context, arg GetActionWithLeakParams) (GetActionWithLeakRow, error)
 	GetAllActions(ctx context.Context) ([]Action, error)
 	GetAllActionsPaginated(ctx context.Context, arg GetAllActionsPaginatedParams) ([]Action, error)
 	GetAllEvents(ctx context.Context, arg GetAllEventsParams) ([]Event, error)
 	GetAllEventsPaginated(ctx context.Context, arg GetAllEventsPaginatedParams) ([]Event, error)
 	GetAllUsers(ctx context.Context) ([]User, error)
+	GetEventByEventID(ctx context.Context, arg GetEventByEventIDParams) (Event, error)
 	GetEventByID(ctx context.Context, id pgtype.UUID) (Event, error)
+	GetEventCountInWindow(ctx context.Context, arg GetEventCountInWindowParams) (int64, error)
+	// Oldest change first; id orders changes made at the same instant
+	GetEventStatusHistory(ctx context.Context, eventID pgtype.UUID) ([]EventStatusHistory, error)
+	// Keyset pagination on (created_at, id), so an archive can stream every event older than a cutoff page by page
+	GetEventsBefore(ctx context.Context, arg GetEventsBeforeParams) ([]Event, error)
+	GetEventsByIDs(ctx context.Context, ids []pgtype.UUID) ([]Event, error)
+	GetEventsByLeakID(ctx context.Context, leakID pgtype.UUID) ([]Event, error)
+	// Events that entered the system one way, newest first; tenant_id is matched explicitly so
+	// idx_events_tenant_source_created_at serves the filter and sort
+	GetEventsBySource(ctx context.Context, arg GetEventsBySourceParams) ([]Event, error)
+	// Events of one type that no leak is linked to, newest first, for detection coverage reports
+	GetEventsWithoutLeak(ctx context.Context, arg GetEventsWithoutLeakParams) ([]Event, error)
+	// Keyset pagination on id, so a caller can resume after the last event it saw
+	GetFailedEvents(ctx context.Context, arg GetFailedEventsParams) ([]Event, error)
+	// An expired key is not returned, even before it is deleted
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
+	// Lag from the provider's own timestamp, data.created in Unix seconds, to ingestion. Events
+	// without one are counted in missing_timestamp and left out of the percentile, which is 0
+	// when every event lacks one
+	GetIngestionLagByProviderType(ctx context.Context, arg GetIngestionLagByProviderTypeParams) ([]GetIngestionLagByProviderTypeRow, error)
+	// Buckets are numbered as by width_bucket: 0 holds the amounts below the first bound, i those
+	// from bound i up to bound i+1 and cardinality(@bounds) those from the last bound up. Only open,
+	// unsnoozed leaks are counted, detected no earlier than @since when it is not NULL.
+	GetLeakAmountHistogram(ctx context.Context, arg GetLeakAmountHistogramParams) ([]GetLeakAmountHistogramRow, error)
+	GetLeakByID(ctx context.Context, id pgtype.UUID) (Leak, error)
+	// Only resolved leaks have a resolved_at; a leak resolved before it was detected counts as 0 seconds
+	GetLeakMTTR(ctx context.Context, arg GetLeakMTTRParams) (GetLeakMTTRRow, error)
+	// One row per bucket and currency of the leaks detected in [@from_time, @to_time), plus one
+	// row with a NULL currency and no leaks for each bucket that has none. Buckets are
+	// date_trunc(@bucket_interval) in UTC, the first one starting where @from_time truncates to.
+	GetLeakTimeSeries(ctx context.Context, arg GetLeakTimeSeriesParams) ([]GetLeakTimeSeriesRow, error)
+	GetNotificationChannelByID(ctx context.Context, id pgtype.UUID) (NotificationChannel, error)
+	GetPaymentByID(ctx context.Context, id pgtype.UUID) (Payment, error)
+	// The executor's queue: the pending actions, and the in_progress ones whose claim is older than
+	// lease_seconds because their worker died or hung, that have attempts left below max_attempts.
+	// priority is the leak amount in minor units of the leak's own currency, so it is only
+	// compared within a currency: actions are taken by their rank among the queued actions in
+	// their currency, which interleaves the currencies, then oldest first. Only the picked rows
+	// are locked, and SKIP LOCKED passes over the ones a concurrent claim holds instead of waiting
+	// on them.
+	GetPendingActionsByPriority(ctx context.Context, arg GetPendingActionsByPriorityParams) ([]Action, error)
+	// Payment attempts, the payment_failed and payment_succeeded events, per provider created in
+	// [window_start, window_end), and how many of them failed. Providers without attempts in the
+	// window are left out
+	GetProviderFailureRates(ctx context.Context, arg GetProviderFailureRatesParams) ([]GetProviderFailureRatesRow, error)
+	GetProviderTypeByID(ctx context.Context, id pgtype.UUID) (string, error)
+	// tenant_id is matched explicitly, not only through RLS, so idx_events_tenant_created_at serves the sort and limit
+	GetRecentEvents(ctx context.Context, arg GetRecentEventsParams) ([]Event, error)
+	// matches holds one containment document per object path the correlation keys may sit under
+	GetRelatedEvents(ctx context.Context, arg GetRelatedEventsParams) ([]Event, error)
+	// A leak is recovered when an action on it succeeded and its customer then paid: a
+	// payment_succeeded event with the customer's external ID, created no earlier than the action
+	// and in [@since, @to). Each recovered leak counts once, for its full amount.
+	GetRevenueRecovered(ctx context.Context, arg GetRevenueRecoveredParams) ([]GetRevenueRecoveredRow, error)
+	GetTenantAcceptedEventTypes(ctx context.Context, id pgtype.UUID) ([]string, error)
+	GetTenantAllowedProviderIDs(ctx context.Context, id pgtype.UUID) ([]pgtype.UUID, error)
+	GetTenantLeakSources(ctx context.Context, id pgtype.UUID) (json.RawMessage, error)
+	GetTenantMinLeakAmounts(ctx context.Context, id pgtype.UUID) (json.RawMessage, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserByID(ctx context.Context, id pgtype.UUID) (User, error)
+	// EXISTS stops at the first matching row, unlike COUNT(*) which visits them all
+	HasAnyEvents(ctx context.Context, tenantID pgtype.UUID) (bool, error)
+	// A provider belongs to the tenant when it is on the tenant's allowlist, or when the tenant has
+	// an integration with it or has received events from it as in ListTenantProviders
+	IsTenantProvider(ctx context.Context, arg IsTenantProviderParams) (bool, error)
+	// The filters are cast to the enum types so the event type and status indexes can serve them,
+	// and id breaks created_at ties so offset pages neither skip nor repeat events
+	ListEventsByFilter(ctx context.Context, arg ListEventsByFilterParams) ([]Event, error)
+	// Keyset pages in ID order, so a pass over every matching event neither skips nor repeats events inserted meanwhile
+	ListEventsByFilterAfterID(ctx context.Context, arg ListEventsByFilterAfterIDParams) ([]Event, error)
+	// Keyset pagination on (created_at, id), newest first, so an export can stream every event page
+	// by page; idx_events_tenant_created_at serves it
+	ListEventsForExport(ctx context.Context, arg ListEventsForExportParams) ([]Event, error)
+	// Records that an event contributed to a leak; linking the same event twice is a no-op
+	LinkLeakEvent(ctx context.Context, arg LinkLeakEventParams) error
+	// Largest amount first so the biggest exposure leads; id breaks ties so pages are stable
+	ListLeaksByFilter(ctx context.Context, arg ListLeaksByFilterParams) ([]Leak, error)
+	// Keyset pagination on (detected_at, id), so an export can stream every matching leak page by page
+	ListLeaksByFilterAfter(ctx context.Context, arg ListLeaksByFilterAfterParams) ([]Leak, error)
+	ListNotificationChannels(ctx context.Context) ([]NotificationChannel, error)
+	// Every tenant with its own event retention override, for the purge job
+	ListTenantEventRetention(ctx context.Context) ([]ListTenantEventRetentionRow, error)
+	// Every tenant, for the detection scheduler
+	ListTenantIDs(ctx context.Context) ([]pgtype.UUID, error)
+	// Providers the tenant has an integration with or has received events from, each with the
+	// tenant's latest event from it. idx_events_tenant_provider_created_at serves the lateral lookup.
+	ListTenantProviders(ctx context.Context, tenantID pgtype.UUID) ([]ListTenantProvidersRow, error)
+	// Every tenant with its own request rate limit, for the rate limiter
+	ListTenantRateLimits(ctx context.Context) ([]ListTenantRateLimitsRow, error)
+	// Deletes at most $3 of the tenant's events created before $2, oldest first, so each call holds its locks briefly
+	PurgeEventsBefore(ctx context.Context, arg PurgeEventsBeforeParams) (int64, error)
+	// Moves the tenant's events from one provider to another, for events ingested under the wrong
+	// provider. An event ID the target already has fails on idx_events_tenant_provider_event_id.
+	ReattributeEvents(ctx context.Context, arg ReattributeEventsParams) (int64, error)
+	// The first response stored for a key wins: a second request with the same key that finished
+	// later, on this instance or another, affects no rows
+	SaveIdempotencyKey(ctx context.Context, arg SaveIdempotencyKeyParams) (int64, error)
+	// pattern is a LIKE prefix pattern with its wildcards escaped; idx_events_tenant_event_id_prefix serves it
+	SearchEventsByExternalIDPrefix(ctx context.Context, arg SearchEventsByExternalIDPrefixParams) ([]Event, error)
+	// A NULL snoozed_until wakes the leak immediately
+	SnoozeLeak(ctx context.Context, arg SnoozeLeakParams) (Leak, error)
+	// Takes the session advisory lock that lets one leak detection run at a time per tenant, across
+	// every instance, without waiting if another session holds it
+	TryLockTenantDetection(ctx context.Context, tenantID pgtype.UUID) (bool, error)
+	UnlockTenantDetection(ctx context.Context, tenantID pgtype.UUID) (bool, error)
 	UpdateAction(ctx context.Context, arg UpdateActionParams) (Action, error)
 	// it is not business logic to update the tenant_id, provider_id, event_id
+	// A NULL argument leaves its column unchanged, so retrying a partial update is safe.
+	// With merge_data, an object data is merged into a stored object instead of replacing it.
 	UpdateEvent(ctx context.Context, arg UpdateEventParams) (Event, error)
+	UpdateEventIfVersion(ctx context.Context, arg UpdateEventIfVersionParams) (Event, error)
+	// Callers must refuse an empty filter, which would update every event of the tenant
+	UpdateEventStatusByFilter(ctx context.Context, arg UpdateEventStatusByFilterParams) (int64, error)
+	// A leak that is not resolved has no resolved_at; resolving it without a time stamps it now
+	UpdateLeak(ctx context.Context, arg UpdateLeakParams) (Leak, error)
+	UpdateNotificationChannel(ctx context.Context, arg UpdateNotificationChannelParams) (NotificationChannel, error)
+	UpdatePayment(ctx context.Context, arg UpdatePaymentParams) (Payment, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	// Stores a new leak, unless the tenant already has an open leak with the same dedup key and
+	// currency: that leak then counts one more occurrence, its amount grows by the new amount and
+	// it keeps the higher confidence, while the rest of it, including when it was detected, is
+	// unchanged
+	UpsertLeakByDedupKey(ctx context.Context, arg UpsertLeakByDedupKeyParams) (Leak, error)
 }
 
 var _ Querier = (*Queries)(nil)
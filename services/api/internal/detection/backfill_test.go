@@ -0,0 +1,239 @@
+package detection
+
+import (
+	"context"
+	"errors"
+	"rdl-api/internal/domain/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// memoryLeakStore keeps leaks in memory and finds them the way the leaks repository does
+type memoryLeakStore struct {
+	leaks []models.Leak
+}
+
+func (s *memoryLeakStore) CreateLeak(_ context.Context, args models.CreateLeakParams, tenantID uuid.UUID) (models.Leak, error) {
+	leak := models.Leak{
+		ID:            uuid.New(),
+		TenantID:      tenantID,
+		CustomerID:    args.CustomerID,
+		LeakType:      args.LeakType,
+		SourceEventID: args.SourceEventID,
+		DetectedAt:    args.DetectedAt,
+		Currency:      args.Currency,
+		Status:        models.LeakStatusEnumOpen,
+	}
+	if leak.Currency == "" {
+		leak.Currency = models.DefaultLeakCurrency
+	}
+	if args.DedupKey != "" {
+		leak.DedupKey = &args.DedupKey
+	}
+	s.leaks = append(s.leaks, leak)
+	return leak, nil
+}
+
+// UpsertLeakByDedupKey adds to the open leak with the same dedup key, as scheduled detection
+// does; a backfill never calls it
+func (s *memoryLeakStore) UpsertLeakByDedupKey(ctx context.Context, args models.CreateLeakParams, tenantID uuid.UUID) (models.Leak, error) {
+	for i, leak := range s.leaks {
+		if leak.TenantID == tenantID && leak.Status == models.LeakStatusEnumOpen && leak.DedupKey != nil && *leak.DedupKey == args.DedupKey {
+			s.leaks[i].Occurrences++
+			return s.leaks[i], nil
+		}
+	}
+	return s.CreateLeak(ctx, args, tenantID)
+}
+
+func (s *memoryLeakStore) FindExistingLeaks(_ context.Context, tenantID uuid.UUID, detectedAt time.Time, sourceEventIDs []uuid.UUID, dedupKeys []string) ([]models.Leak, error) {
+	var found []models.Leak
+	for _, leak := range s.leaks {
+		if leak.TenantID != tenantID {
+			continue
+		}
+		match := leak.DetectedAt.Equal(detectedAt)
+		for _, id := range sourceEventIDs {
+			match = match || (leak.SourceEventID != nil && *leak.SourceEventID == id)
+		}
+		for _, key := range dedupKeys {
+			match = match || (leak.DedupKey != nil && *leak.DedupKey == key)
+		}
+		if match {
+			found = append(found, leak)
+		}
+	}
+	return found, nil
+}
+
+// hourlyRule finds a tenant-wide anomaly at every point in time, and one leak for sourceEvent
+// at every point after it happened. It fails when asked about failAt.
+type hourlyRule struct {
+	sourceEvent   uuid.UUID
+	sourceEventAt time.Time
+	failAt        time.Time
+}
+
+func (r hourlyRule) Name() string { return "hourly" }
+
+func (r hourlyRule) Detect(_ context.Context, tenantID uuid.UUID, now time.Time) ([]Candidate, error) {
+	if now.Equal(r.failAt) {
+		return nil, errors.New("boom")
+	}
+	candidates := []Candidate{{Rule: r.Name(), TenantID: tenantID, LeakType: models.LeakTypeEnumVolumeAnomaly, Confidence: 50}}
+	if now.After(r.sourceEventAt) {
+		id := r.sourceEvent
+		candidates = append(candidates, Candidate{Rule: r.Name(), TenantID: tenantID, CustomerID: uuid.New(), LeakType: models.LeakTypeEnumFailedPayments, SourceEventID: &id, Confidence: 90})
+	}
+	return candidates, nil
+}
+
+func TestDetector_BackfillLeaks(t *testing.T) {
+	tenantID := uuid.New()
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(4 * time.Hour)
+	rule := hourlyRule{sourceEvent: uuid.New(), sourceEventAt: from.Add(90 * time.Minute)}
+
+	newBackfillDetector := func(store LeakStore, rule Rule) *Detector {
+		d := newTestDetector(store, nil, rule)
+		d.now = func() time.Time { return to.Add(24 * time.Hour) }
+		return d
+	}
+
+	t.Run("creates only missing leaks", func(t *testing.T) {
+		store := &memoryLeakStore{}
+		// The anomaly at 02:00 was already backfilled
+		store.leaks = append(store.leaks, models.Leak{ID: uuid.New(), TenantID: tenantID, LeakType: models.LeakTypeEnumVolumeAnomaly, DetectedAt: from.Add(2 * time.Hour)})
+
+		report, err := newBackfillDetector(store, rule).BackfillLeaks(context.Background(), tenantID, from, to, false)
+		if err != nil {
+			t.Fatalf("BackfillLeaks() error = %v", err)
+		}
+		if report.Steps != 4 {
+			t.Errorf("expected 4 steps, got %d", report.Steps)
+		}
+		// Anomalies at 01:00, 03:00 and 04:00, and the failed payment once, at 02:00
+		if len(report.Created) != 4 || len(store.leaks) != 5 {
+			t.Fatalf("expected 4 leaks created and 5 stored, got %d and %d", len(report.Created), len(store.leaks))
+		}
+		for _, leak := range report.Created {
+			if leak.DetectedAt.IsZero() || leak.DetectedAt.After(to) {
+				t.Errorf("expected leaks detected within the window, got %s", leak.DetectedAt)
+			}
+		}
+		// The stored anomaly, and the failed payment found again at 03:00 and 04:00
+		if report.Existing != 3 {
+			t.Errorf("expected 3 existing, got %d", report.Existing)
+		}
+
+		again, err := newBackfillDetector(store, rule).BackfillLeaks(context.Background(), tenantID, from, to, false)
+		if err != nil {
+			t.Fatalf("second BackfillLeaks() error = %v", err)
+		}
+		if len(again.Created) != 0 || len(store.leaks) != 5 {
+			t.Errorf("expected a second backfill to create nothing, created %d", len(again.Created))
+		}
+	})
+
+	t.Run("dry run writes nothing", func(t *testing.T) {
+		store := &memoryLeakStore{}
+
+		report, err := newBackfillDetector(store, rule).BackfillLeaks(context.Background(), tenantID, from, to, true)
+		if err != nil {
+			t.Fatalf("BackfillLeaks() error = %v", err)
+		}
+		if len(store.leaks) != 0 || len(report.Created) != 0 {
+			t.Errorf("expected nothing stored, got %d", len(store.leaks))
+		}
+		if len(report.Candidates) != 5 {
+			t.Errorf("expected 5 candidates, got %d", len(report.Candidates))
+		}
+		for _, candidate := range report.Candidates {
+			if candidate.DetectedAt == nil {
+				t.Errorf("expected candidates to carry their detection time")
+			}
+		}
+	})
+
+	t.Run("stops at a failing step and resumes from it", func(t *testing.T) {
+		store := &memoryLeakStore{}
+		failing := rule
+		failing.failAt = from.Add(3 * time.Hour)
+
+		report, err := newBackfillDetector(store, failing).BackfillLeaks(context.Background(), tenantID, from, to, false)
+		if err == nil {
+			t.Fatal("expected the failing step to be returned")
+		}
+		if report.ResumeFrom == nil || !report.ResumeFrom.Equal(from.Add(2*time.Hour)) {
+			t.Fatalf("expected to resume from 02:00, got %v", report.ResumeFrom)
+		}
+		if report.Steps != 2 || len(store.leaks) != 3 {
+			t.Errorf("expected 2 steps and 3 leaks before the failure, got %d and %d", report.Steps, len(store.leaks))
+		}
+
+		resumed, err := newBackfillDetector(store, rule).BackfillLeaks(context.Background(), tenantID, *report.ResumeFrom, to, false)
+		if err != nil {
+			t.Fatalf("resumed BackfillLeaks() error = %v", err)
+		}
+		if resumed.ResumeFrom != nil || len(resumed.Created) != 2 || len(store.leaks) != 5 {
+			t.Errorf("expected the resumed backfill to create the 2 remaining leaks, created %d", len(resumed.Created))
+		}
+	})
+
+	t.Run("invalid window", func(t *testing.T) {
+		if _, err := newBackfillDetector(&memoryLeakStore{}, rule).BackfillLeaks(context.Background(), tenantID, to, from, false); !errors.Is(err, ErrInvalidBackfillWindow) {
+			t.Errorf("expected ErrInvalidBackfillWindow, got %v", err)
+		}
+	})
+
+	t.Run("store without existing-leak lookup", func(t *testing.T) {
+		if _, err := newBackfillDetector(&recordingStore{}, rule).BackfillLeaks(context.Background(), tenantID, from, to, false); !errors.Is(err, ErrBackfillUnsupported) {
+			t.Errorf("expected ErrBackfillUnsupported, got %v", err)
+		}
+	})
+}
+
+func TestDetector_BackfillSkipsWindowFindingsStoredByScheduledDetection(t *testing.T) {
+	tenantID := uuid.New()
+	// Scheduled detection ran at 12:10 and judged the 11:00 window
+	scheduledAt := time.Date(2025, 3, 1, 12, 10, 0, 0, time.UTC)
+	rule, err := NewVolumeAnomalyRule(fakeCounter{current: 100, baseline: 24 * 10, now: scheduledAt}, time.Hour, 24, 3)
+	if err != nil {
+		t.Fatalf("NewVolumeAnomalyRule() error = %v", err)
+	}
+	store := &memoryLeakStore{}
+	detector := newTestDetector(store, nil, rule)
+	detector.now = func() time.Time { return scheduledAt }
+
+	report, err := detector.DetectLeaks(context.Background(), tenantID, false)
+	if err != nil {
+		t.Fatalf("DetectLeaks() error = %v", err)
+	}
+	if len(report.Created) != 1 || len(store.leaks) != 1 {
+		t.Fatalf("expected scheduled detection to store the anomaly, got %d created", len(report.Created))
+	}
+
+	// The backfill's only step, at 12:00, judges the same 11:00 window
+	detector.now = func() time.Time { return scheduledAt.Add(24 * time.Hour) }
+	backfill, err := detector.BackfillLeaks(context.Background(), tenantID, scheduledAt.Add(-70*time.Minute), scheduledAt.Add(-10*time.Minute), false)
+	if err != nil {
+		t.Fatalf("BackfillLeaks() error = %v", err)
+	}
+	if backfill.Steps != 1 {
+		t.Fatalf("expected 1 step, got %d", backfill.Steps)
+	}
+	if len(backfill.Created) != 0 || backfill.Existing != 1 || len(store.leaks) != 1 {
+		t.Errorf("expected the backfill to find the stored anomaly and create nothing, created %d, existing %d", len(backfill.Created), backfill.Existing)
+	}
+
+	// A leak the backfill stores keeps its dedup key, so a later scheduled run recognises it
+	again, err := detector.BackfillLeaks(context.Background(), tenantID, scheduledAt.Add(-130*time.Minute), scheduledAt.Add(-10*time.Minute), false)
+	if err != nil {
+		t.Fatalf("second BackfillLeaks() error = %v", err)
+	}
+	if len(again.Created) != 1 || again.Created[0].DedupKey == nil {
+		t.Errorf("expected the 10:00 window's anomaly created with its dedup key, got %+v", again.Created)
+	}
+}
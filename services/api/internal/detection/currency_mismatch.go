@@ -0,0 +1,101 @@
+package detection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rdl-api/internal/domain/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CurrencyMismatchRuleName is the Name of CurrencyMismatchRule
+const CurrencyMismatchRuleName = "currency_mismatch"
+
+// DefaultCurrencyMismatchLookback is how far back each run of CurrencyMismatchRule looks for
+// mismatched payments: a week, so a refund issued days after its charge is still compared.
+const DefaultCurrencyMismatchLookback = 7 * 24 * time.Hour
+
+var ErrInvalidCurrencyMismatchRule = errors.New("invalid currency mismatch rule")
+
+// CurrencyMismatchFinder finds a tenant's payments in another currency than the earliest
+// payment with the same external ID
+type CurrencyMismatchFinder interface {
+	FindCurrencyMismatches(ctx context.Context, tenantID uuid.UUID, since time.Time) ([]models.CurrencyMismatch, error)
+}
+
+// CurrencyMismatchRule flags a payment settled in another currency than the payment it relates
+// to, such as a refund issued in EUR for a charge taken in USD. Payments are related when they
+// share an external ID. Unless the tenant converted on purpose, the difference is lost on FX.
+//
+// Each run looks at the payments created in the Lookback before now. A mismatch is flagged
+// once; later runs skip it because its leak already points at the payment's event.
+type CurrencyMismatchRule struct {
+	finder   CurrencyMismatchFinder
+	lookback time.Duration
+}
+
+// NewCurrencyMismatchRule creates the rule, returning ErrInvalidCurrencyMismatchRule when
+// lookback is not positive.
+func NewCurrencyMismatchRule(finder CurrencyMismatchFinder, lookback time.Duration) (*CurrencyMismatchRule, error) {
+	if lookback <= 0 {
+		return nil, fmt.Errorf("%w: lookback must be positive, got %s", ErrInvalidCurrencyMismatchRule, lookback)
+	}
+	return &CurrencyMismatchRule{finder: finder, lookback: lookback}, nil
+}
+
+// Name returns CurrencyMismatchRuleName
+func (r *CurrencyMismatchRule) Name() string {
+	return CurrencyMismatchRuleName
+}
+
+// LeakType returns models.LeakTypeEnumCurrencyMismatch
+func (r *CurrencyMismatchRule) LeakType() models.LeakTypeEnum {
+	return models.LeakTypeEnumCurrencyMismatch
+}
+
+// EventTypes returns the event types the rule reads: payment_succeeded and payment_refunded
+func (r *CurrencyMismatchRule) EventTypes() []models.EventTypeEnum {
+	return currencyMismatchEventTypes()
+}
+
+func currencyMismatchEventTypes() []models.EventTypeEnum {
+	return []models.EventTypeEnum{models.EventTypeEnumPaymentSucceeded, models.EventTypeEnumPaymentRefunded}
+}
+
+// Detect returns a currency_mismatch candidate for every mismatched payment, for the original
+// payment's amount and currency and triggered by the mismatched payment's event.
+func (r *CurrencyMismatchRule) Detect(ctx context.Context, tenantID uuid.UUID, now time.Time) ([]Candidate, error) {
+	mismatches, err := r.finder.FindCurrencyMismatches(ctx, tenantID, now.Add(-r.lookback))
+	if err != nil {
+		return nil, fmt.Errorf("find currency mismatches: %w", err)
+	}
+
+	candidates := make([]Candidate, 0, len(mismatches))
+	for _, m := range mismatches {
+		eventID := m.EventID
+		candidates = append(candidates, Candidate{
+			Rule:          r.Name(),
+			TenantID:      tenantID,
+			CustomerID:    m.CustomerID,
+			LeakType:      models.LeakTypeEnumCurrencyMismatch,
+			Amount:        m.OriginalAmount,
+			Currency:      m.OriginalCurrency,
+			SourceEventID: &eventID,
+			Confidence:    currencyMismatchConfidence(m.EventType),
+			Reason: fmt.Sprintf("%s for %s was in %s %s but the original payment was in %s %s (event %s)",
+				m.EventType, m.ExternalID, m.Amount, m.Currency, m.OriginalAmount, m.OriginalCurrency, m.EventID),
+		})
+	}
+	return candidates, nil
+}
+
+// currencyMismatchConfidence is 75 for a refund, which should always be in the currency of its
+// charge, and 60 for other payments, where a tenant may take a retry in another currency on purpose
+func currencyMismatchConfidence(eventType models.EventTypeEnum) int32 {
+	if eventType == models.EventTypeEnumPaymentRefunded {
+		return 75
+	}
+	return 60
+}
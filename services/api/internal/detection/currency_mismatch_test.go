@@ -0,0 +1,112 @@
+package detection
+
+import (
+	"context"
+	"errors"
+	"rdl-api/internal/domain/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeCurrencyMismatchFinder returns mismatches and records the time it was asked for
+type fakeCurrencyMismatchFinder struct {
+	mismatches []models.CurrencyMismatch
+	err        error
+	since      time.Time
+}
+
+func (f *fakeCurrencyMismatchFinder) FindCurrencyMismatches(_ context.Context, _ uuid.UUID, since time.Time) ([]models.CurrencyMismatch, error) {
+	f.since = since
+	return f.mismatches, f.err
+}
+
+func TestCurrencyMismatchRule_Detect(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tenantID := uuid.New()
+	customerID := uuid.New()
+	mismatch := models.CurrencyMismatch{
+		PaymentID:         uuid.New(),
+		OriginalPaymentID: uuid.New(),
+		ExternalID:        "pi_1",
+		CustomerID:        customerID,
+		EventID:           uuid.New(),
+		EventType:         models.EventTypeEnumPaymentRefunded,
+		Amount:            models.NewDecimal(4610, -2),
+		Currency:          "EUR",
+		OriginalAmount:    models.NewDecimal(5000, -2),
+		OriginalCurrency:  "USD",
+		CreatedAt:         now.Add(-time.Hour),
+	}
+	finder := &fakeCurrencyMismatchFinder{mismatches: []models.CurrencyMismatch{mismatch}}
+
+	rule, err := NewCurrencyMismatchRule(finder, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("NewCurrencyMismatchRule() error = %v", err)
+	}
+	candidates, err := rule.Detect(context.Background(), tenantID, now)
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+
+	if !finder.since.Equal(now.Add(-24 * time.Hour)) {
+		t.Errorf("expected payments since a day ago, got %s", finder.since)
+	}
+	if len(candidates) != 1 {
+		t.Fatalf("expected 1 candidate, got %d", len(candidates))
+	}
+	c := candidates[0]
+	if c.LeakType != models.LeakTypeEnumCurrencyMismatch || c.Rule != CurrencyMismatchRuleName || c.TenantID != tenantID || c.CustomerID != customerID {
+		t.Errorf("unexpected candidate %+v", c)
+	}
+	if c.Amount.Cmp(mismatch.OriginalAmount) != 0 || c.Currency != "USD" {
+		t.Errorf("expected the original payment's amount, got %s %s", c.Amount, c.Currency)
+	}
+	if c.SourceEventID == nil || *c.SourceEventID != mismatch.EventID {
+		t.Errorf("expected the mismatched payment's event as the source, got %v", c.SourceEventID)
+	}
+	if c.Confidence != 75 {
+		t.Errorf("expected confidence 75 for a refund, got %d", c.Confidence)
+	}
+	if !strings.Contains(c.Reason, "pi_1") || !strings.Contains(c.Reason, "EUR") || !strings.Contains(c.Reason, "USD") {
+		t.Errorf("expected the reason to name the external ID and both currencies, got %q", c.Reason)
+	}
+}
+
+func TestCurrencyMismatchRule_NoMismatches(t *testing.T) {
+	rule, err := NewCurrencyMismatchRule(&fakeCurrencyMismatchFinder{}, time.Hour)
+	if err != nil {
+		t.Fatalf("NewCurrencyMismatchRule() error = %v", err)
+	}
+	candidates, err := rule.Detect(context.Background(), uuid.New(), time.Now())
+	if err != nil || len(candidates) != 0 {
+		t.Errorf("expected no candidates, got %v (%v)", candidates, err)
+	}
+}
+
+func TestCurrencyMismatchRule_FinderError(t *testing.T) {
+	rule, err := NewCurrencyMismatchRule(&fakeCurrencyMismatchFinder{err: errors.New("db down")}, time.Hour)
+	if err != nil {
+		t.Fatalf("NewCurrencyMismatchRule() error = %v", err)
+	}
+	if _, err := rule.Detect(context.Background(), uuid.New(), time.Now()); err == nil {
+		t.Error("expected the finder's error")
+	}
+}
+
+func TestNewCurrencyMismatchRule_Invalid(t *testing.T) {
+	if _, err := NewCurrencyMismatchRule(&fakeCurrencyMismatchFinder{}, 0); !errors.Is(err, ErrInvalidCurrencyMismatchRule) {
+		t.Errorf("expected ErrInvalidCurrencyMismatchRule, got %v", err)
+	}
+}
+
+func TestCurrencyMismatchConfidence(t *testing.T) {
+	if got := currencyMismatchConfidence(models.EventTypeEnumPaymentRefunded); got != 75 {
+		t.Errorf("expected 75 for a refund, got %d", got)
+	}
+	if got := currencyMismatchConfidence(models.EventTypeEnumPaymentSucceeded); got != 60 {
+		t.Errorf("expected 60 for a payment, got %d", got)
+	}
+}
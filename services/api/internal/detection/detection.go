@@ -0,0 +1,86 @@
+// Package detection contains the leak rules that turn a tenant's stored events into
+// candidate leaks. A rule only reports what it found; persisting candidates and notifying
+// on them is left to the caller.
+package detection
+
+import (
+	"context"
+	"fmt"
+	"rdl-api/internal/domain/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Rule inspects one tenant's data and reports the leaks it finds as of now
+type Rule interface {
+	// Name identifies the rule in logs and candidate reasons
+	Name() string
+	Detect(ctx context.Context, tenantID uuid.UUID, now time.Time) ([]Candidate, error)
+}
+
+// ScanningRule is a Rule that also reports how many events it looked at, which the
+// Detector adds up into the run's events_scanned figure. Rules that don't implement it
+// count as scanning nothing.
+type ScanningRule interface {
+	Rule
+	DetectScanned(ctx context.Context, tenantID uuid.UUID, now time.Time) ([]Candidate, int64, error)
+}
+
+// SourcedRule is a Rule that declares the leak type it produces and the event types it reads,
+// so the Detector can skip it for a tenant whose leak sources don't feed it (see
+// Detector.WithLeakSources). Rules that don't implement it always run.
+type SourcedRule interface {
+	Rule
+	LeakType() models.LeakTypeEnum
+	EventTypes() []models.EventTypeEnum
+}
+
+// DefaultLeakSources maps the leak types of the built-in rules to the event types they read:
+// duplicate charges come from successful payments, dunning gaps from failed ones, currency
+// mismatches from successful and refunded ones, failure rate spikes from failed and successful
+// ones, and volume anomalies from every event.
+func DefaultLeakSources() models.LeakSources {
+	return models.LeakSources{
+		models.LeakTypeEnumDuplicateCharge:  duplicateChargeEventTypes(),
+		models.LeakTypeEnumDunningGap:       dunningGapEventTypes(),
+		models.LeakTypeEnumCurrencyMismatch: currencyMismatchEventTypes(),
+		models.LeakTypeEnumFailureRateSpike: failureRateEventTypes(),
+		models.LeakTypeEnumVolumeAnomaly:    volumeEventTypes(),
+	}
+}
+
+// Candidate is a leak a rule has found but that has not been stored yet.
+// CustomerID is uuid.Nil for tenant-wide findings such as a volume anomaly, and Amount
+// is 0 when the rule cannot put a figure on the loss. An empty Currency is stored as
+// models.DefaultLeakCurrency, and SourceEventID is set when a single event triggered the finding.
+// DetectedAt is only set by a backfill, to the past time the rules ran as of; otherwise the leak
+// is detected when it is stored. DedupKey, when set, names the candidate's root cause: the
+// Detector then adds it to the tenant's open leak with the same key instead of storing another.
+type Candidate struct {
+	Rule          string              `json:"rule"`
+	TenantID      uuid.UUID           `json:"tenant_id"`
+	CustomerID    uuid.UUID           `json:"customer_id"`
+	LeakType      models.LeakTypeEnum `json:"leak_type"`
+	Amount        models.Decimal      `json:"amount"`
+	Currency      string              `json:"currency,omitempty"`
+	SourceEventID *uuid.UUID          `json:"source_event_id,omitempty"`
+	Confidence    int32               `json:"confidence"`
+	Reason        string              `json:"reason"`
+	DetectedAt    *time.Time          `json:"detected_at,omitempty"`
+	DedupKey      string              `json:"dedup_key,omitempty"`
+}
+
+// dedupKey builds a Candidate.DedupKey from the leak type and the provider's references to
+// the customer and subscription the leak concerns
+func dedupKey(leakType models.LeakTypeEnum, customerRef string, subscriptionRef string) string {
+	return fmt.Sprintf("%s:%s:%s", leakType, customerRef, subscriptionRef)
+}
+
+// windowDedupKey builds a Candidate.DedupKey for a finding about a window of time rather than
+// particular events, from the leak type, the provider it concerns, empty for all of the
+// tenant's providers, and the window's start. The start is aligned to the window length, so
+// every run that judges the same window adds to one open leak.
+func windowDedupKey(leakType models.LeakTypeEnum, providerID string, windowStart time.Time, window time.Duration) string {
+	return fmt.Sprintf("%s:%s:%s", leakType, providerID, windowStart.Truncate(window).UTC().Format(time.RFC3339))
+}
@@ -0,0 +1,442 @@
+package detection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/metrics"
+	"rdl-api/internal/notifier"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrDetectionInProgress is returned by DetectLeaks when another run is already storing leaks
+// for the tenant
+var ErrDetectionInProgress = errors.New("leak detection already running for tenant")
+
+// RunLocker serializes the runs that store leaks for a tenant, across every instance.
+// TryLockTenantDetection takes the tenant's lock without waiting and reports whether it did;
+// unlock releases it.
+type RunLocker interface {
+	TryLockTenantDetection(ctx context.Context, tenantID uuid.UUID) (unlock func(), locked bool, err error)
+}
+
+// LeakStore persists the leaks a detection run finds. CreateLeak returns
+// models.ErrLeakAlreadyExists when the source event already has a leak of the type.
+// UpsertLeakByDedupKey stores a candidate with a dedup key, or adds it to the tenant's open leak
+// with that key and currency; a leak it returns with one occurrence is new.
+type LeakStore interface {
+	CreateLeak(ctx context.Context, args models.CreateLeakParams, tenantID uuid.UUID) (models.Leak, error)
+	UpsertLeakByDedupKey(ctx context.Context, args models.CreateLeakParams, tenantID uuid.UUID) (models.Leak, error)
+}
+
+// ThresholdStore looks up a tenant's own minimum leak amounts, keyed by upper-case currency code
+type ThresholdStore interface {
+	GetMinLeakAmounts(ctx context.Context, tenantID uuid.UUID) (map[string]models.Decimal, error)
+}
+
+// LeakSourceStore looks up a tenant's own leak sources, which replace the default sources of
+// the leak types they list
+type LeakSourceStore interface {
+	GetLeakSources(ctx context.Context, tenantID uuid.UUID) (models.LeakSources, error)
+}
+
+// Report describes one detection run for a tenant
+type Report struct {
+	TenantID uuid.UUID `json:"tenant_id"`
+	DryRun   bool      `json:"dry_run"`
+	// Candidates are every leak the rules found at or above the minimum leak amount, whether
+	// or not they were stored
+	Candidates []Candidate `json:"candidates"`
+	// Suppressed are the candidates whose amount fell below the minimum for their currency;
+	// they are never stored
+	Suppressed []Candidate `json:"suppressed"`
+	// Created are the leaks stored by this run; always empty for a dry run
+	Created []models.Leak `json:"created"`
+	// Updated are the open leaks this run found again by their dedup key and counted another
+	// occurrence of, instead of creating a duplicate; always empty for a dry run
+	Updated []models.Leak `json:"updated"`
+	// EventsScanned is the number of events the rules looked at, as reported by ScanningRules
+	EventsScanned int64 `json:"events_scanned"`
+	// Truncated is set when the run hit the per-run leak cap and left candidates unstored
+	Truncated bool `json:"truncated"`
+}
+
+// RunStatus summarizes the most recent detection run, for health reporting
+type RunStatus struct {
+	TenantID      uuid.UUID
+	StartedAt     time.Time
+	Duration      time.Duration
+	DryRun        bool
+	EventsScanned int64
+	Candidates    int
+	// CreatedByType counts the leaks the run stored, keyed by leak type
+	CreatedByType map[string]int
+	// Truncated is set when the run stopped storing at the per-run leak cap
+	Truncated bool
+	// Err is the joined rule and store failures of the run, or nil when it ran cleanly
+	Err error
+}
+
+// Created returns the total number of leaks the run stored
+func (s RunStatus) Created() int {
+	total := 0
+	for _, count := range s.CreatedByType {
+		total += count
+	}
+	return total
+}
+
+// Detector runs a set of rules for a tenant and stores and announces what they find
+type Detector struct {
+	rules    []Rule
+	leaks    LeakStore
+	notifier notifier.Notifier
+	logger   *slog.Logger
+	now      func() time.Time
+
+	// minLeakAmounts is the global threshold per currency, which thresholds may override per tenant
+	minLeakAmounts map[string]models.Decimal
+	thresholds     ThresholdStore
+
+	// maxLeaksPerRun caps the leaks one run stores; 0 means unlimited
+	maxLeaksPerRun int
+
+	// leakSources decides which SourcedRules run, and leakSourceStore may override it per tenant
+	leakSources     models.LeakSources
+	leakSourceStore LeakSourceStore
+
+	// runLocker, when set, keeps two runs from storing leaks for one tenant at once
+	runLocker RunLocker
+
+	// backfillStep is how far apart BackfillLeaks runs the rules
+	backfillStep time.Duration
+
+	mu      sync.RWMutex
+	lastRun *RunStatus
+}
+
+// NewDetector creates a Detector. notify may be nil, in which case new leaks are stored
+// without sending a notification.
+func NewDetector(leaks LeakStore, notify notifier.Notifier, logger *slog.Logger, rules ...Rule) *Detector {
+	return &Detector{rules: rules, leaks: leaks, notifier: notify, logger: logger, now: time.Now, backfillStep: DefaultBackfillStep}
+}
+
+// WithMinLeakAmounts makes the detector drop candidates whose amount is below the minimum for
+// their currency. defaults apply to every tenant; thresholds, when not nil, supplies per-tenant
+// amounts that replace the default for the currencies they list. A currency without a threshold
+// and a candidate without an amount are never dropped.
+func (d *Detector) WithMinLeakAmounts(defaults map[string]models.Decimal, thresholds ThresholdStore) *Detector {
+	d.minLeakAmounts = defaults
+	d.thresholds = thresholds
+	return d
+}
+
+// WithMaxLeaksPerRun caps how many leaks one run stores, so a misbehaving rule cannot flood the
+// leak list and notifications. 0 means unlimited.
+func (d *Detector) WithMaxLeaksPerRun(limit int) *Detector {
+	d.maxLeaksPerRun = limit
+	return d
+}
+
+// WithLeakSources makes the detector consult which event types feed each leak type before
+// running a SourcedRule: a rule runs for a tenant only when its leak type is fed by one of the
+// event types it reads. defaults apply to every tenant; store, when not nil, supplies per-tenant
+// sources that replace the default for the leak types they list, so a tenant can turn a leak
+// category off by mapping it to no event types. Without leak sources every rule runs.
+func (d *Detector) WithLeakSources(defaults models.LeakSources, store LeakSourceStore) *Detector {
+	d.leakSources = defaults
+	d.leakSourceStore = store
+	return d
+}
+
+// WithRunLocker makes every run that stores leaks hold the tenant's lock from locker, so the
+// scheduler, the API and the event pipeline never run detection for one tenant at the same time
+// and cannot both store a leak for the same finding. Dry runs take no lock.
+func (d *Detector) WithRunLocker(locker RunLocker) *Detector {
+	d.runLocker = locker
+	return d
+}
+
+// DetectLeaks runs every rule for the tenant. Unless dryRun is set, each candidate is stored
+// as a leak and a single notification lists the new leaks. A dry run only reports the
+// candidates: nothing is written and nothing is sent.
+//
+// Candidates below the minimum leak amount for their currency are moved to Suppressed before
+// anything is stored. A candidate with a dedup key that matches one of the tenant's open leaks
+// is added to that leak and reported in Updated rather than Created. Once the run has created
+// the per-run maximum it stops storing, logs a warning and marks the report Truncated; the
+// remaining candidates are still reported. A failing rule or store does not stop the others; their errors are joined and returned
+// alongside the report of everything that did succeed.
+//
+// With a run locker, a run that is not dry first takes the tenant's lock; when another run holds
+// it, DetectLeaks returns ErrDetectionInProgress without running any rule.
+//
+// Every run, dry or not, is logged with its duration, events scanned and leaks created by
+// type, added to the detection metrics for the tenant and kept as the LastRun status.
+func (d *Detector) DetectLeaks(ctx context.Context, tenantID uuid.UUID, dryRun bool) (Report, error) {
+	if !dryRun && d.runLocker != nil {
+		unlock, locked, err := d.runLocker.TryLockTenantDetection(ctx, tenantID)
+		if err != nil {
+			return Report{TenantID: tenantID}, fmt.Errorf("lock tenant detection: %w", err)
+		}
+		if !locked {
+			return Report{TenantID: tenantID}, ErrDetectionInProgress
+		}
+		defer unlock()
+	}
+
+	report := Report{TenantID: tenantID, DryRun: dryRun, Candidates: []Candidate{}, Suppressed: []Candidate{}, Created: []models.Leak{}, Updated: []models.Leak{}}
+	now := d.now()
+
+	var errs []error
+	for _, rule := range d.enabledRules(ctx, tenantID) {
+		candidates, scanned, err := detect(ctx, rule, tenantID, now)
+		report.EventsScanned += scanned
+		if err != nil {
+			d.logger.ErrorContext(ctx, "Leak rule failed", "error", err, "rule", rule.Name(), "tenant_id", tenantID)
+			errs = append(errs, fmt.Errorf("rule %s: %w", rule.Name(), err))
+			continue
+		}
+		report.Candidates = append(report.Candidates, candidates...)
+	}
+	d.suppressBelowThreshold(ctx, tenantID, &report)
+
+	if !dryRun {
+		// createdFrom are the candidates behind report.Created, which the notification lists
+		var createdFrom []Candidate
+		for i, candidate := range report.Candidates {
+			if d.maxLeaksPerRun > 0 && len(report.Created) >= d.maxLeaksPerRun {
+				report.Truncated = true
+				d.logger.WarnContext(ctx, "Leak detection stopped at the per-run leak cap",
+					"tenant_id", tenantID, "max_leaks_per_run", d.maxLeaksPerRun, "unstored_candidates", len(report.Candidates)-i)
+				break
+			}
+			leak, created, err := d.store(ctx, candidate, tenantID)
+			if errors.Is(err, models.ErrLeakAlreadyExists) {
+				// A concurrent run stored the leak for this source event first
+				d.logger.DebugContext(ctx, "Skipping leak already stored by another run", "tenant_id", tenantID, "leak_type", candidate.LeakType, "source_event_id", candidate.SourceEventID)
+				continue
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("store %s leak: %w", candidate.LeakType, err))
+				continue
+			}
+			if !created {
+				report.Updated = append(report.Updated, leak)
+				continue
+			}
+			report.Created = append(report.Created, leak)
+			createdFrom = append(createdFrom, candidate)
+		}
+		d.notify(ctx, tenantID, createdFrom, report.Truncated)
+	}
+
+	err := errors.Join(errs...)
+	d.finishRun(ctx, report, now, len(errs), err)
+	return report, err
+}
+
+// store persists a candidate, through UpsertLeakByDedupKey when it has a dedup key, and reports
+// whether that created a leak rather than adding to an open one
+func (d *Detector) store(ctx context.Context, candidate Candidate, tenantID uuid.UUID) (models.Leak, bool, error) {
+	if candidate.DedupKey == "" {
+		leak, err := d.leaks.CreateLeak(ctx, candidate.createLeakParams(), tenantID)
+		return leak, err == nil, err
+	}
+	leak, err := d.leaks.UpsertLeakByDedupKey(ctx, candidate.createLeakParams(), tenantID)
+	if err != nil {
+		return models.Leak{}, false, err
+	}
+	return leak, leak.Occurrences <= 1, nil
+}
+
+// suppressBelowThreshold moves the candidates below their currency's minimum leak amount from
+// report.Candidates to report.Suppressed. If the tenant's thresholds cannot be read the global
+// defaults are used, so an outage of the tenant lookup neither fails the run nor lets noise through.
+func (d *Detector) suppressBelowThreshold(ctx context.Context, tenantID uuid.UUID, report *Report) {
+	if len(d.minLeakAmounts) == 0 && d.thresholds == nil {
+		return
+	}
+
+	thresholds := make(map[string]models.Decimal, len(d.minLeakAmounts))
+	for currency, amount := range d.minLeakAmounts {
+		thresholds[currency] = amount
+	}
+	if d.thresholds != nil {
+		overrides, err := d.thresholds.GetMinLeakAmounts(ctx, tenantID)
+		if err != nil {
+			d.logger.WarnContext(ctx, "Failed to load tenant minimum leak amounts, using defaults", "error", err, "tenant_id", tenantID)
+		}
+		for currency, amount := range overrides {
+			thresholds[currency] = amount
+		}
+	}
+
+	kept := report.Candidates[:0]
+	for _, candidate := range report.Candidates {
+		if candidate.belowThreshold(thresholds) {
+			report.Suppressed = append(report.Suppressed, candidate)
+			continue
+		}
+		kept = append(kept, candidate)
+	}
+	report.Candidates = kept
+
+	if len(report.Suppressed) > 0 {
+		d.logger.DebugContext(ctx, "Suppressed leak candidates below the minimum amount", "tenant_id", tenantID, "suppressed", len(report.Suppressed))
+	}
+}
+
+// enabledRules returns the rules the tenant's leak sources feed. If the tenant's sources cannot
+// be read the defaults are used, so an outage of the tenant lookup doesn't fail the run.
+func (d *Detector) enabledRules(ctx context.Context, tenantID uuid.UUID) []Rule {
+	if d.leakSources == nil && d.leakSourceStore == nil {
+		return d.rules
+	}
+
+	sources := d.leakSources
+	if d.leakSourceStore != nil {
+		overrides, err := d.leakSourceStore.GetLeakSources(ctx, tenantID)
+		if err != nil {
+			d.logger.WarnContext(ctx, "Failed to load tenant leak sources, using defaults", "error", err, "tenant_id", tenantID)
+		}
+		sources = sources.Override(overrides)
+	}
+
+	rules := make([]Rule, 0, len(d.rules))
+	for _, rule := range d.rules {
+		if sourced, ok := rule.(SourcedRule); ok && !sources.Feeds(sourced.LeakType(), sourced.EventTypes()) {
+			d.logger.DebugContext(ctx, "Skipping leak rule not fed by the tenant's leak sources", "rule", rule.Name(), "tenant_id", tenantID, "leak_type", sourced.LeakType())
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// detect runs one rule, asking a ScanningRule for its scanned-event count as well
+func detect(ctx context.Context, rule Rule, tenantID uuid.UUID, now time.Time) ([]Candidate, int64, error) {
+	if scanning, ok := rule.(ScanningRule); ok {
+		return scanning.DetectScanned(ctx, tenantID, now)
+	}
+	candidates, err := rule.Detect(ctx, tenantID, now)
+	return candidates, 0, err
+}
+
+// finishRun logs, records metrics for and remembers a completed run
+func (d *Detector) finishRun(ctx context.Context, report Report, startedAt time.Time, errCount int, err error) {
+	status := RunStatus{
+		TenantID:      report.TenantID,
+		StartedAt:     startedAt,
+		Duration:      d.now().Sub(startedAt),
+		DryRun:        report.DryRun,
+		EventsScanned: report.EventsScanned,
+		Candidates:    len(report.Candidates),
+		CreatedByType: map[string]int{},
+		Truncated:     report.Truncated,
+		Err:           err,
+	}
+	for _, leak := range report.Created {
+		status.CreatedByType[string(leak.LeakType)]++
+	}
+
+	metrics.RecordDetectionRun(metrics.DetectionRun{
+		Duration:      status.Duration,
+		EventsScanned: status.EventsScanned,
+		CreatedByType: status.CreatedByType,
+		Errors:        errCount,
+		Truncated:     status.Truncated,
+	})
+
+	d.mu.Lock()
+	d.lastRun = &status
+	d.mu.Unlock()
+
+	d.logger.InfoContext(ctx, "Leak detection finished",
+		"tenant_id", report.TenantID,
+		"dry_run", report.DryRun,
+		"duration", status.Duration,
+		"events_scanned", status.EventsScanned,
+		"candidates", status.Candidates,
+		"created", status.Created(),
+		"updated", len(report.Updated),
+		"created_by_type", status.CreatedByType,
+		"errors", errCount,
+		"truncated", status.Truncated,
+	)
+}
+
+// LastRun returns the status of the most recent detection run, or false when none has run
+// since the Detector was created
+func (d *Detector) LastRun() (RunStatus, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.lastRun == nil {
+		return RunStatus{}, false
+	}
+	return *d.lastRun, true
+}
+
+// notify sends one notification summarizing the leaks a run created, listing the candidates
+// they were created from; candidates that only added to an open leak or were never stored are
+// left out. Delivery failures are logged rather than returned, because the leaks are already
+// stored.
+func (d *Detector) notify(ctx context.Context, tenantID uuid.UUID, created []Candidate, truncated bool) {
+	if d.notifier == nil || len(created) == 0 {
+		return
+	}
+
+	var body strings.Builder
+	for _, candidate := range created {
+		fmt.Fprintf(&body, "- %s: %s\n", candidate.LeakType, candidate.Reason)
+	}
+	if truncated {
+		fmt.Fprintf(&body, "Detection stopped after %d leaks; the remaining candidates were not stored.\n", len(created))
+	}
+	n := notifier.Notification{
+		TenantID: tenantID,
+		Title:    fmt.Sprintf("%d new revenue leak(s) detected", len(created)),
+		Body:     strings.TrimSuffix(body.String(), "\n"),
+	}
+	if err := d.notifier.Notify(ctx, n); err != nil {
+		d.logger.WarnContext(ctx, "Failed to send leak notification", "error", err, "tenant_id", tenantID)
+	}
+}
+
+// belowThreshold reports whether the candidate's amount is under the minimum for its currency.
+// A candidate without an amount, or in a currency without a threshold, is never below it.
+func (c Candidate) belowThreshold(thresholds map[string]models.Decimal) bool {
+	if c.Amount.IsZero() {
+		return false
+	}
+	currency := strings.ToUpper(c.Currency)
+	if currency == "" {
+		currency = models.DefaultLeakCurrency
+	}
+	minimum, ok := thresholds[currency]
+	return ok && c.Amount.Cmp(minimum) < 0
+}
+
+// createLeakParams converts a candidate to the parameters for storing it
+func (c Candidate) createLeakParams() models.CreateLeakParams {
+	params := models.CreateLeakParams{
+		TenantID:      c.TenantID,
+		CustomerID:    c.CustomerID,
+		LeakType:      c.LeakType,
+		Amount:        c.Amount,
+		Currency:      c.Currency,
+		SourceEventID: c.SourceEventID,
+		Confidence:    c.Confidence,
+		DedupKey:      c.DedupKey,
+	}
+	if c.DetectedAt != nil {
+		params.DetectedAt = *c.DetectedAt
+	}
+	return params
+}
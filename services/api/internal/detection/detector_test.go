@@ -0,0 +1,656 @@
+package detection
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/metrics"
+	"rdl-api/internal/notifier"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type staticRule struct {
+	name       string
+	candidates []Candidate
+	err        error
+}
+
+func (r staticRule) Name() string { return r.name }
+
+func (r staticRule) Detect(context.Context, uuid.UUID, time.Time) ([]Candidate, error) {
+	return r.candidates, r.err
+}
+
+type recordingStore struct {
+	created []models.CreateLeakParams
+	// open holds the leaks stored with a dedup key, by key and currency
+	open map[string]models.Leak
+}
+
+func (s *recordingStore) CreateLeak(_ context.Context, args models.CreateLeakParams, tenantID uuid.UUID) (models.Leak, error) {
+	s.created = append(s.created, args)
+	return models.Leak{ID: uuid.New(), TenantID: tenantID, LeakType: args.LeakType, Amount: args.Amount, Occurrences: 1}, nil
+}
+
+// UpsertLeakByDedupKey adds to the open leak with the same key and currency the way the leaks
+// repository does
+func (s *recordingStore) UpsertLeakByDedupKey(ctx context.Context, args models.CreateLeakParams, tenantID uuid.UUID) (models.Leak, error) {
+	if s.open == nil {
+		s.open = map[string]models.Leak{}
+	}
+	key := args.DedupKey + "/" + args.Currency
+	leak, ok := s.open[key]
+	if !ok {
+		leak, _ = s.CreateLeak(ctx, args, tenantID)
+	} else {
+		leak.Amount = leak.Amount.Add(args.Amount)
+		leak.Occurrences++
+	}
+	s.open[key] = leak
+	return leak, nil
+}
+
+// uniqueSourceStore refuses a second leak of a type for a source event, the way the leaks
+// repository does
+type uniqueSourceStore struct {
+	recordingStore
+	sources map[string]bool
+}
+
+func (s *uniqueSourceStore) CreateLeak(ctx context.Context, args models.CreateLeakParams, tenantID uuid.UUID) (models.Leak, error) {
+	if args.SourceEventID != nil {
+		key := string(args.LeakType) + "/" + args.SourceEventID.String()
+		if s.sources[key] {
+			return models.Leak{}, models.ErrLeakAlreadyExists
+		}
+		if s.sources == nil {
+			s.sources = map[string]bool{}
+		}
+		s.sources[key] = true
+	}
+	return s.recordingStore.CreateLeak(ctx, args, tenantID)
+}
+
+type recordingNotifier struct {
+	sent []notifier.Notification
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, notification notifier.Notification) error {
+	n.sent = append(n.sent, notification)
+	return nil
+}
+
+func newTestDetector(store LeakStore, notify notifier.Notifier, rules ...Rule) *Detector {
+	return NewDetector(store, notify, slog.New(slog.NewTextHandler(io.Discard, nil)), rules...)
+}
+
+func TestDetector_DetectLeaks(t *testing.T) {
+	tenantID := uuid.New()
+	rule := staticRule{name: "static", candidates: []Candidate{
+		{Rule: "static", TenantID: tenantID, LeakType: models.LeakTypeEnumVolumeAnomaly, Confidence: 80, Reason: "spike"},
+		{Rule: "static", TenantID: tenantID, CustomerID: uuid.New(), LeakType: models.LeakTypeEnumFailedPayments, Amount: models.MustParseDecimal("49.50"), Confidence: 90, Reason: "failed charge"},
+	}}
+
+	t.Run("stores candidates and notifies", func(t *testing.T) {
+		store := &recordingStore{}
+		notify := &recordingNotifier{}
+
+		report, err := newTestDetector(store, notify, rule).DetectLeaks(context.Background(), tenantID, false)
+		if err != nil {
+			t.Fatalf("DetectLeaks() error = %v", err)
+		}
+		if len(report.Candidates) != 2 || len(report.Created) != 2 {
+			t.Fatalf("expected 2 candidates and 2 created, got %d and %d", len(report.Candidates), len(report.Created))
+		}
+		if len(store.created) != 2 {
+			t.Errorf("expected 2 leaks stored, got %d", len(store.created))
+		}
+		if len(notify.sent) != 1 {
+			t.Errorf("expected 1 notification, got %d", len(notify.sent))
+		}
+	})
+
+	t.Run("dry run writes and sends nothing", func(t *testing.T) {
+		store := &recordingStore{}
+		notify := &recordingNotifier{}
+
+		report, err := newTestDetector(store, notify, rule).DetectLeaks(context.Background(), tenantID, true)
+		if err != nil {
+			t.Fatalf("DetectLeaks() error = %v", err)
+		}
+		if !report.DryRun {
+			t.Error("expected report to be marked as a dry run")
+		}
+		if len(report.Candidates) != 2 {
+			t.Errorf("expected 2 candidates, got %d", len(report.Candidates))
+		}
+		if len(report.Created) != 0 || len(store.created) != 0 {
+			t.Errorf("dry run stored leaks: report=%d store=%d", len(report.Created), len(store.created))
+		}
+		if len(notify.sent) != 0 {
+			t.Errorf("dry run sent %d notifications", len(notify.sent))
+		}
+	})
+}
+
+func TestDetector_FailingRuleDoesNotStopOthers(t *testing.T) {
+	tenantID := uuid.New()
+	errRule := errors.New("query failed")
+	store := &recordingStore{}
+
+	report, err := newTestDetector(store, nil,
+		staticRule{name: "broken", err: errRule},
+		staticRule{name: "working", candidates: []Candidate{{TenantID: tenantID, LeakType: models.LeakTypeEnumOther, Confidence: 50}}},
+	).DetectLeaks(context.Background(), tenantID, false)
+
+	if !errors.Is(err, errRule) {
+		t.Fatalf("expected rule error, got %v", err)
+	}
+	if len(report.Created) != 1 {
+		t.Errorf("expected the working rule's leak to be stored, got %d", len(report.Created))
+	}
+}
+
+func TestDetector_SkipsLeakAlreadyStored(t *testing.T) {
+	tenantID := uuid.New()
+	eventID := uuid.New()
+	rule := staticRule{name: "duplicates", candidates: []Candidate{
+		{TenantID: tenantID, LeakType: models.LeakTypeEnumDuplicateCharge, SourceEventID: &eventID, Confidence: 90},
+	}}
+	store := &uniqueSourceStore{}
+	notify := &recordingNotifier{}
+	detector := newTestDetector(store, notify, rule)
+
+	if _, err := detector.DetectLeaks(context.Background(), tenantID, false); err != nil {
+		t.Fatalf("DetectLeaks() error = %v", err)
+	}
+	// The second run stands in for a concurrent one that found the same event before the first stored its leak
+	report, err := detector.DetectLeaks(context.Background(), tenantID, false)
+	if err != nil {
+		t.Fatalf("expected a leak stored by another run not to fail the run, got %v", err)
+	}
+	if len(report.Created) != 0 || len(report.Updated) != 0 {
+		t.Errorf("expected nothing stored, got %d created and %d updated", len(report.Created), len(report.Updated))
+	}
+	if len(store.created) != 1 || len(notify.sent) != 1 {
+		t.Errorf("expected 1 leak and 1 notification, got %d and %d", len(store.created), len(notify.sent))
+	}
+}
+
+// tenantLocks holds each tenant's detection lock the way the advisory lock does, within one process
+type tenantLocks struct {
+	mu     sync.Mutex
+	held   map[uuid.UUID]bool
+	locked int
+}
+
+func (l *tenantLocks) TryLockTenantDetection(_ context.Context, tenantID uuid.UUID) (func(), bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held[tenantID] {
+		return nil, false, nil
+	}
+	if l.held == nil {
+		l.held = map[uuid.UUID]bool{}
+	}
+	l.held[tenantID] = true
+	l.locked++
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.held, tenantID)
+	}, true, nil
+}
+
+func TestDetector_RunLocker(t *testing.T) {
+	tenantID := uuid.New()
+	rule := staticRule{name: "static", candidates: []Candidate{{TenantID: tenantID, LeakType: models.LeakTypeEnumOther, Confidence: 50}}}
+
+	t.Run("run in progress is refused", func(t *testing.T) {
+		locks := &tenantLocks{}
+		unlock, _, _ := locks.TryLockTenantDetection(context.Background(), tenantID)
+		defer unlock()
+		store := &recordingStore{}
+
+		_, err := newTestDetector(store, nil, rule).WithRunLocker(locks).DetectLeaks(context.Background(), tenantID, false)
+		if !errors.Is(err, ErrDetectionInProgress) {
+			t.Fatalf("expected ErrDetectionInProgress, got %v", err)
+		}
+		if len(store.created) != 0 {
+			t.Errorf("expected nothing stored, got %d", len(store.created))
+		}
+	})
+
+	t.Run("lock is released after the run", func(t *testing.T) {
+		locks := &tenantLocks{}
+		detector := newTestDetector(&recordingStore{}, nil, rule).WithRunLocker(locks)
+
+		for range 2 {
+			if _, err := detector.DetectLeaks(context.Background(), tenantID, false); err != nil {
+				t.Fatalf("DetectLeaks() error = %v", err)
+			}
+		}
+		if locks.locked != 2 || len(locks.held) != 0 {
+			t.Errorf("expected 2 runs to take and release the lock, got %d taken and %d held", locks.locked, len(locks.held))
+		}
+	})
+
+	t.Run("dry run takes no lock", func(t *testing.T) {
+		locks := &tenantLocks{}
+		unlock, _, _ := locks.TryLockTenantDetection(context.Background(), tenantID)
+		defer unlock()
+
+		report, err := newTestDetector(&recordingStore{}, nil, rule).WithRunLocker(locks).DetectLeaks(context.Background(), tenantID, true)
+		if err != nil {
+			t.Fatalf("DetectLeaks() error = %v", err)
+		}
+		if len(report.Candidates) != 1 {
+			t.Errorf("expected 1 candidate, got %d", len(report.Candidates))
+		}
+	})
+}
+
+func TestDetector_DedupKeyUpdatesOpenLeak(t *testing.T) {
+	tenantID := uuid.New()
+	failure := func(amount string) Candidate {
+		return Candidate{TenantID: tenantID, CustomerID: uuid.New(), LeakType: models.LeakTypeEnumDunningGap, Amount: models.MustParseDecimal(amount), Confidence: 60, DedupKey: "dunning_gap:cus_1:sub_1", Reason: "unpaid " + amount}
+	}
+	store := &recordingStore{}
+	notify := &recordingNotifier{}
+	detector := newTestDetector(store, notify, staticRule{name: "dunning", candidates: []Candidate{failure("10.00"), failure("15.00")}})
+
+	report, err := detector.DetectLeaks(context.Background(), tenantID, false)
+	if err != nil {
+		t.Fatalf("DetectLeaks() error = %v", err)
+	}
+	if len(report.Created) != 1 || len(report.Updated) != 1 {
+		t.Fatalf("expected 1 created and 1 updated leak, got %d and %d", len(report.Created), len(report.Updated))
+	}
+	if len(store.created) != 1 {
+		t.Errorf("expected 1 leak stored, got %d", len(store.created))
+	}
+	// The notification lists the created leak only, not the candidate that added to it
+	if len(notify.sent) != 1 || notify.sent[0].Body != "- dunning_gap: unpaid 10.00" {
+		t.Errorf("expected a notification listing the created leak only, got %+v", notify.sent)
+	}
+
+	report, err = detector.DetectLeaks(context.Background(), tenantID, false)
+	if err != nil {
+		t.Fatalf("DetectLeaks() error = %v", err)
+	}
+	if len(report.Created) != 0 || len(report.Updated) != 2 {
+		t.Fatalf("expected a rerun to only update, got %d created and %d updated", len(report.Created), len(report.Updated))
+	}
+	leak := report.Updated[1]
+	if leak.Occurrences != 4 || leak.Amount.Cmp(models.MustParseDecimal("50.00")) != 0 {
+		t.Errorf("expected 4 occurrences adding up to 50.00, got %d and %s", leak.Occurrences, leak.Amount)
+	}
+	if len(notify.sent) != 1 {
+		t.Errorf("expected a notification only for the run that created the leak, got %d", len(notify.sent))
+	}
+}
+
+func TestDetector_RecordsRunObservability(t *testing.T) {
+	tenantID := uuid.New()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	volume, err := NewVolumeAnomalyRule(fakeCounter{current: 100, baseline: 24, now: now}, time.Hour, 24, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := []Rule{
+		volume,
+		staticRule{name: "broken", err: errors.New("query failed")},
+		staticRule{name: "payments", candidates: []Candidate{
+			{TenantID: tenantID, LeakType: models.LeakTypeEnumFailedPayments, Confidence: 90},
+			{TenantID: tenantID, LeakType: models.LeakTypeEnumFailedPayments, Confidence: 70},
+		}},
+	}
+
+	var logs bytes.Buffer
+	detector := NewDetector(&recordingStore{}, nil, slog.New(slog.NewJSONHandler(&logs, nil)), rules...)
+	clock := now
+	detector.now = func() time.Time {
+		at := clock
+		clock = clock.Add(1500 * time.Millisecond)
+		return at
+	}
+
+	if _, ok := detector.LastRun(); ok {
+		t.Fatal("expected no last run before the first run")
+	}
+
+	failedRuns, errs, scanned := metrics.DetectionRunCount(metrics.DetectionOutcomeFailed), metrics.DetectionRunErrors.Value(), metrics.DetectionEventsScanned.Value()
+	failedPayments := metrics.DetectionLeaksCreatedCount(string(models.LeakTypeEnumFailedPayments))
+	volumeAnomalies := metrics.DetectionLeaksCreatedCount(string(models.LeakTypeEnumVolumeAnomaly))
+
+	if _, err := detector.DetectLeaks(context.Background(), tenantID, false); err == nil {
+		t.Fatal("expected the broken rule's error")
+	}
+
+	if got := metrics.DetectionRunCount(metrics.DetectionOutcomeFailed) - failedRuns; got != 1 {
+		t.Errorf("expected 1 failed run, got %d", got)
+	}
+	if got := metrics.DetectionRunErrors.Value() - errs; got != 1 {
+		t.Errorf("expected 1 error, got %d", got)
+	}
+	if got := metrics.DetectionEventsScanned.Value() - scanned; got != 124 {
+		t.Errorf("expected 124 events scanned, got %d", got)
+	}
+	if got := metrics.DetectionLeaksCreatedCount(string(models.LeakTypeEnumFailedPayments)) - failedPayments; got != 2 {
+		t.Errorf("expected 2 failed_payments leaks, got %d", got)
+	}
+	if got := metrics.DetectionLeaksCreatedCount(string(models.LeakTypeEnumVolumeAnomaly)) - volumeAnomalies; got != 1 {
+		t.Errorf("expected 1 volume_anomaly leak, got %d", got)
+	}
+
+	var finished map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(logs.Bytes()), []byte("\n")) {
+		var entry map[string]any
+		if err := json.Unmarshal(line, &entry); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		if entry["msg"] == "Leak detection finished" {
+			finished = entry
+		}
+	}
+	if finished == nil {
+		t.Fatalf("expected a finished log entry, got %s", logs.String())
+	}
+	for field, want := range map[string]any{
+		"tenant_id":      tenantID.String(),
+		"dry_run":        false,
+		"duration":       float64(1500 * time.Millisecond),
+		"events_scanned": float64(124),
+		"candidates":     float64(3),
+		"created":        float64(3),
+		"errors":         float64(1),
+	} {
+		if finished[field] != want {
+			t.Errorf("expected log field %s=%v, got %v", field, want, finished[field])
+		}
+	}
+	byType, _ := finished["created_by_type"].(map[string]any)
+	if byType[string(models.LeakTypeEnumFailedPayments)] != float64(2) || byType[string(models.LeakTypeEnumVolumeAnomaly)] != float64(1) {
+		t.Errorf("expected created_by_type to split by leak type, got %v", finished["created_by_type"])
+	}
+
+	last, ok := detector.LastRun()
+	if !ok {
+		t.Fatal("expected a last run after DetectLeaks")
+	}
+	if last.Created() != 3 || last.EventsScanned != 124 || last.Err == nil || !last.StartedAt.Equal(now) {
+		t.Errorf("unexpected last run %+v", last)
+	}
+}
+
+type staticThresholds struct {
+	amounts map[string]models.Decimal
+	err     error
+}
+
+func (s staticThresholds) GetMinLeakAmounts(context.Context, uuid.UUID) (map[string]models.Decimal, error) {
+	return s.amounts, s.err
+}
+
+func TestDetector_MinLeakAmounts(t *testing.T) {
+	tenantID := uuid.New()
+	failure := func(amount, currency string) Candidate {
+		return Candidate{TenantID: tenantID, LeakType: models.LeakTypeEnumFailedPayments, Amount: models.MustParseDecimal(amount), Currency: currency, Confidence: 90, Reason: amount + " " + currency}
+	}
+	rule := staticRule{name: "payments", candidates: []Candidate{
+		failure("0.50", ""),    // below the USD default, and an empty currency is USD
+		failure("1.00", "USD"), // at the threshold
+		failure("99", "JPY"),   // 99 yen is well under a dollar
+		failure("150", "jpy"),  // at the yen threshold; codes are matched case-insensitively
+		failure("0.01", "EUR"), // no EUR threshold
+		{TenantID: tenantID, LeakType: models.LeakTypeEnumVolumeAnomaly, Confidence: 80, Reason: "no amount"},
+	}}
+	defaults := map[string]models.Decimal{"USD": models.MustParseDecimal("1.00"), "JPY": models.MustParseDecimal("150")}
+
+	reasons := func(candidates []Candidate) []string {
+		var out []string
+		for _, c := range candidates {
+			out = append(out, c.Reason)
+		}
+		return out
+	}
+
+	t.Run("global defaults", func(t *testing.T) {
+		store := &recordingStore{}
+		report, err := newTestDetector(store, nil, rule).WithMinLeakAmounts(defaults, nil).DetectLeaks(context.Background(), tenantID, false)
+		if err != nil {
+			t.Fatalf("DetectLeaks() error = %v", err)
+		}
+		if got := reasons(report.Suppressed); len(got) != 2 || got[0] != "0.50 " || got[1] != "99 JPY" {
+			t.Errorf("expected 0.50 USD and 99 JPY to be suppressed, got %v", got)
+		}
+		if len(report.Candidates) != 4 || len(store.created) != 4 {
+			t.Errorf("expected 4 leaks at or above the threshold, got %v", reasons(report.Candidates))
+		}
+	})
+
+	t.Run("tenant overrides a currency", func(t *testing.T) {
+		store := &recordingStore{}
+		tenant := staticThresholds{amounts: map[string]models.Decimal{"USD": models.MustParseDecimal("0.25")}}
+		report, err := newTestDetector(store, nil, rule).WithMinLeakAmounts(defaults, tenant).DetectLeaks(context.Background(), tenantID, false)
+		if err != nil {
+			t.Fatalf("DetectLeaks() error = %v", err)
+		}
+		if got := reasons(report.Suppressed); len(got) != 1 || got[0] != "99 JPY" {
+			t.Errorf("expected only 99 JPY to be suppressed, got %v", got)
+		}
+	})
+
+	t.Run("tenant lookup failure falls back to defaults", func(t *testing.T) {
+		tenant := staticThresholds{err: errors.New("database down")}
+		report, err := newTestDetector(&recordingStore{}, nil, rule).WithMinLeakAmounts(defaults, tenant).DetectLeaks(context.Background(), tenantID, true)
+		if err != nil {
+			t.Fatalf("DetectLeaks() error = %v", err)
+		}
+		if len(report.Suppressed) != 2 {
+			t.Errorf("expected the defaults to suppress 2 candidates, got %v", reasons(report.Suppressed))
+		}
+	})
+
+	t.Run("no thresholds", func(t *testing.T) {
+		report, err := newTestDetector(&recordingStore{}, nil, rule).DetectLeaks(context.Background(), tenantID, true)
+		if err != nil {
+			t.Fatalf("DetectLeaks() error = %v", err)
+		}
+		if len(report.Suppressed) != 0 || len(report.Candidates) != 6 {
+			t.Errorf("expected nothing suppressed, got %v", reasons(report.Suppressed))
+		}
+	})
+}
+
+// sourcedRule is a staticRule that declares its leak type and the event types it reads
+type sourcedRule struct {
+	staticRule
+	leakType   models.LeakTypeEnum
+	eventTypes []models.EventTypeEnum
+}
+
+func (r sourcedRule) LeakType() models.LeakTypeEnum { return r.leakType }
+
+func (r sourcedRule) EventTypes() []models.EventTypeEnum { return r.eventTypes }
+
+type staticLeakSources struct {
+	sources models.LeakSources
+	err     error
+}
+
+func (s staticLeakSources) GetLeakSources(context.Context, uuid.UUID) (models.LeakSources, error) {
+	return s.sources, s.err
+}
+
+func TestDetector_LeakSources(t *testing.T) {
+	tenantID := uuid.New()
+	duplicates := sourcedRule{
+		staticRule: staticRule{name: "duplicates", candidates: []Candidate{{TenantID: tenantID, LeakType: models.LeakTypeEnumDuplicateCharge, Confidence: 90, Reason: "duplicate"}}},
+		leakType:   models.LeakTypeEnumDuplicateCharge,
+		eventTypes: []models.EventTypeEnum{models.EventTypeEnumPaymentSucceeded},
+	}
+	volume := sourcedRule{
+		staticRule: staticRule{name: "volume", candidates: []Candidate{{TenantID: tenantID, LeakType: models.LeakTypeEnumVolumeAnomaly, Confidence: 80, Reason: "spike"}}},
+		leakType:   models.LeakTypeEnumVolumeAnomaly,
+		eventTypes: []models.EventTypeEnum{models.EventTypeEnumPaymentFailed, models.EventTypeEnumPaymentSucceeded},
+	}
+	unsourced := staticRule{name: "unsourced", candidates: []Candidate{{TenantID: tenantID, LeakType: models.LeakTypeEnumFailedPayments, Confidence: 90, Reason: "failed charge"}}}
+	defaults := models.LeakSources{
+		models.LeakTypeEnumDuplicateCharge: {models.EventTypeEnumPaymentSucceeded},
+		models.LeakTypeEnumVolumeAnomaly:   {models.EventTypeEnumPaymentFailed},
+	}
+
+	leakTypes := func(candidates []Candidate) []models.LeakTypeEnum {
+		var out []models.LeakTypeEnum
+		for _, c := range candidates {
+			out = append(out, c.LeakType)
+		}
+		return out
+	}
+	detect := func(t *testing.T, d *Detector) Report {
+		t.Helper()
+		report, err := d.DetectLeaks(context.Background(), tenantID, true)
+		if err != nil {
+			t.Fatalf("DetectLeaks() error = %v", err)
+		}
+		return report
+	}
+
+	t.Run("defaults run every fed rule", func(t *testing.T) {
+		report := detect(t, newTestDetector(&recordingStore{}, nil, duplicates, volume, unsourced).WithLeakSources(defaults, nil))
+		if got := leakTypes(report.Candidates); len(got) != 3 {
+			t.Errorf("expected all three rules to run, got %v", got)
+		}
+	})
+
+	t.Run("tenant disables a leak type", func(t *testing.T) {
+		tenant := staticLeakSources{sources: models.LeakSources{models.LeakTypeEnumDuplicateCharge: {}}}
+		report := detect(t, newTestDetector(&recordingStore{}, nil, duplicates, volume, unsourced).WithLeakSources(defaults, tenant))
+		for _, leakType := range leakTypes(report.Candidates) {
+			if leakType == models.LeakTypeEnumDuplicateCharge {
+				t.Fatal("expected duplicate charges to be suppressed for the tenant")
+			}
+		}
+		if len(report.Candidates) != 2 {
+			t.Errorf("expected the other rules to still run, got %v", leakTypes(report.Candidates))
+		}
+	})
+
+	t.Run("tenant narrows a leak type to events the rule doesn't read", func(t *testing.T) {
+		tenant := staticLeakSources{sources: models.LeakSources{models.LeakTypeEnumVolumeAnomaly: {models.EventTypeEnumPaymentRefunded}}}
+		report := detect(t, newTestDetector(&recordingStore{}, nil, duplicates, volume).WithLeakSources(defaults, tenant))
+		if got := leakTypes(report.Candidates); len(got) != 1 || got[0] != models.LeakTypeEnumDuplicateCharge {
+			t.Errorf("expected only duplicate charges, got %v", got)
+		}
+	})
+
+	t.Run("tenant enables a leak type the defaults disable", func(t *testing.T) {
+		disabled := models.LeakSources{models.LeakTypeEnumDuplicateCharge: {}}
+		tenant := staticLeakSources{sources: models.LeakSources{models.LeakTypeEnumDuplicateCharge: {models.EventTypeEnumPaymentSucceeded}}}
+		report := detect(t, newTestDetector(&recordingStore{}, nil, duplicates).WithLeakSources(disabled, tenant))
+		if len(report.Candidates) != 1 {
+			t.Errorf("expected the tenant to turn duplicate charges back on, got %v", leakTypes(report.Candidates))
+		}
+	})
+
+	t.Run("tenant lookup failure falls back to defaults", func(t *testing.T) {
+		disabled := models.LeakSources{models.LeakTypeEnumDuplicateCharge: {}}
+		tenant := staticLeakSources{err: errors.New("database down")}
+		report := detect(t, newTestDetector(&recordingStore{}, nil, duplicates, volume).WithLeakSources(disabled, tenant))
+		if got := leakTypes(report.Candidates); len(got) != 1 || got[0] != models.LeakTypeEnumVolumeAnomaly {
+			t.Errorf("expected the defaults to disable duplicate charges, got %v", got)
+		}
+	})
+
+	t.Run("backfill skips disabled leak types", func(t *testing.T) {
+		tenant := staticLeakSources{sources: models.LeakSources{models.LeakTypeEnumVolumeAnomaly: {}}}
+		d := newTestDetector(&memoryLeakStore{}, nil, duplicates, volume).WithLeakSources(defaults, tenant)
+		now := time.Now()
+		report, err := d.BackfillLeaks(context.Background(), tenantID, now.Add(-2*time.Hour), now, true)
+		if err != nil {
+			t.Fatalf("BackfillLeaks() error = %v", err)
+		}
+		for _, leakType := range leakTypes(report.Candidates) {
+			if leakType == models.LeakTypeEnumVolumeAnomaly {
+				t.Fatal("expected volume anomalies to be suppressed during backfill")
+			}
+		}
+	})
+}
+
+func TestDetector_MaxLeaksPerRun(t *testing.T) {
+	tenantID := uuid.New()
+	var candidates []Candidate
+	for range 5 {
+		candidates = append(candidates, Candidate{TenantID: tenantID, LeakType: models.LeakTypeEnumFailedPayments, Confidence: 90, Reason: "failed charge"})
+	}
+	rule := staticRule{name: "flood", candidates: candidates}
+
+	t.Run("creation stops at the cap", func(t *testing.T) {
+		store := &recordingStore{}
+		notify := &recordingNotifier{}
+		detector := newTestDetector(store, notify, rule).WithMaxLeaksPerRun(3)
+		truncated := metrics.DetectionRunsTruncated.Value()
+
+		report, err := detector.DetectLeaks(context.Background(), tenantID, false)
+		if err != nil {
+			t.Fatalf("DetectLeaks() error = %v", err)
+		}
+		if len(store.created) != 3 || len(report.Created) != 3 {
+			t.Errorf("expected 3 leaks stored, got store=%d report=%d", len(store.created), len(report.Created))
+		}
+		if len(report.Candidates) != 5 {
+			t.Errorf("expected every candidate to be reported, got %d", len(report.Candidates))
+		}
+		if !report.Truncated {
+			t.Error("expected the report to be marked truncated")
+		}
+		if len(notify.sent) != 1 {
+			t.Fatalf("expected 1 notification, got %d", len(notify.sent))
+		}
+		// Only the stored leaks are listed, followed by the truncation note
+		if lines := strings.Split(notify.sent[0].Body, "\n"); len(lines) != 4 || strings.Count(notify.sent[0].Body, "- failed_payments: failed charge") != 3 {
+			t.Errorf("expected the 3 stored leaks and a truncation note, got %q", notify.sent[0].Body)
+		}
+
+		last, ok := detector.LastRun()
+		if !ok || !last.Truncated {
+			t.Errorf("expected the last run to be recorded as truncated, got %+v", last)
+		}
+		if got := metrics.DetectionRunsTruncated.Value() - truncated; got != 1 {
+			t.Errorf("expected 1 truncated run, got %d", got)
+		}
+	})
+
+	t.Run("a run under the cap is not truncated", func(t *testing.T) {
+		store := &recordingStore{}
+		report, err := newTestDetector(store, nil, rule).WithMaxLeaksPerRun(5).DetectLeaks(context.Background(), uuid.New(), false)
+		if err != nil {
+			t.Fatalf("DetectLeaks() error = %v", err)
+		}
+		if len(store.created) != 5 || report.Truncated {
+			t.Errorf("expected all 5 leaks stored without truncation, got %d truncated=%v", len(store.created), report.Truncated)
+		}
+	})
+
+	t.Run("zero is unlimited", func(t *testing.T) {
+		store := &recordingStore{}
+		report, err := newTestDetector(store, nil, rule).WithMaxLeaksPerRun(0).DetectLeaks(context.Background(), uuid.New(), false)
+		if err != nil {
+			t.Fatalf("DetectLeaks() error = %v", err)
+		}
+		if len(store.created) != 5 || report.Truncated {
+			t.Errorf("expected all 5 leaks stored, got %d truncated=%v", len(store.created), report.Truncated)
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package detection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rdl-api/internal/domain/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DunningGapRuleName is the Name of DunningGapRule
+const DunningGapRuleName = "dunning_gap"
+
+// Defaults for DunningGapRule: a failed payment should see a retry or a payment update within
+// three days, and each run looks at the failures whose window closed in the last day.
+const (
+	DefaultDunningWindow   = 72 * time.Hour
+	DefaultDunningLookback = 24 * time.Hour
+)
+
+var ErrInvalidDunningGapRule = errors.New("invalid dunning gap rule")
+
+// DunningGapFinder finds a tenant's payment_failed events that nothing followed within the
+// dunning window
+type DunningGapFinder interface {
+	FindDunningGaps(ctx context.Context, tenantID uuid.UUID, now time.Time, defaultWindow time.Duration, lookback time.Duration) ([]models.DunningGap, error)
+}
+
+// DunningGapRule flags a failed payment that dunning never picked up: a payment_failed event
+// followed by no payment_failed, payment_succeeded or payment_updated event for the same
+// customer within the dunning window. Without a retry the failed amount is simply not
+// collected.
+//
+// The window is the tenant's dunning_window_hours when set and Window otherwise. Each run looks
+// at the failures whose window closed in the Lookback before now. A gap is flagged once; later
+// runs skip it because its leak already points at it. Gaps of a failure that names a
+// subscription share a dedup key with the other gaps of that customer and subscription, so
+// repeated failures of one subscription add up in a single leak.
+type DunningGapRule struct {
+	finder   DunningGapFinder
+	window   time.Duration
+	lookback time.Duration
+}
+
+// NewDunningGapRule creates the rule, returning ErrInvalidDunningGapRule when window or lookback
+// is not positive.
+func NewDunningGapRule(finder DunningGapFinder, window time.Duration, lookback time.Duration) (*DunningGapRule, error) {
+	if window <= 0 {
+		return nil, fmt.Errorf("%w: window must be positive, got %s", ErrInvalidDunningGapRule, window)
+	}
+	if lookback <= 0 {
+		return nil, fmt.Errorf("%w: lookback must be positive, got %s", ErrInvalidDunningGapRule, lookback)
+	}
+	return &DunningGapRule{finder: finder, window: window, lookback: lookback}, nil
+}
+
+// Name returns DunningGapRuleName
+func (r *DunningGapRule) Name() string {
+	return DunningGapRuleName
+}
+
+// LeakType returns models.LeakTypeEnumDunningGap
+func (r *DunningGapRule) LeakType() models.LeakTypeEnum {
+	return models.LeakTypeEnumDunningGap
+}
+
+// EventTypes returns the event types the rule reads: only payment_failed
+func (r *DunningGapRule) EventTypes() []models.EventTypeEnum {
+	return dunningGapEventTypes()
+}
+
+func dunningGapEventTypes() []models.EventTypeEnum {
+	return []models.EventTypeEnum{models.EventTypeEnumPaymentFailed}
+}
+
+// Detect returns a dunning_gap candidate for every failed payment without a follow-up, for the
+// failed amount and triggered by the failed payment event. A candidate whose failure names a
+// subscription is keyed by its customer and subscription.
+func (r *DunningGapRule) Detect(ctx context.Context, tenantID uuid.UUID, now time.Time) ([]Candidate, error) {
+	gaps, err := r.finder.FindDunningGaps(ctx, tenantID, now, r.window, r.lookback)
+	if err != nil {
+		return nil, fmt.Errorf("find dunning gaps: %w", err)
+	}
+
+	candidates := make([]Candidate, 0, len(gaps))
+	for _, gap := range gaps {
+		eventID := gap.EventID
+		var key string
+		if gap.SubscriptionRef != "" {
+			key = dedupKey(models.LeakTypeEnumDunningGap, gap.CustomerRef, gap.SubscriptionRef)
+		}
+		candidates = append(candidates, Candidate{
+			Rule:          r.Name(),
+			TenantID:      tenantID,
+			CustomerID:    gap.CustomerID,
+			LeakType:      models.LeakTypeEnumDunningGap,
+			Amount:        gap.Amount,
+			Currency:      gap.Currency,
+			SourceEventID: &eventID,
+			Confidence:    dunningGapConfidence(now.Sub(gap.FailedAt), gap.Window),
+			Reason: fmt.Sprintf("payment of %s %s by customer %s failed at %s and was not retried or updated within %s (event %s)",
+				gap.Amount, gap.Currency, gap.CustomerRef, gap.FailedAt.Format(time.RFC3339), gap.Window, gap.EventID),
+			DedupKey: key,
+		})
+	}
+	return candidates, nil
+}
+
+// dunningGapConfidence rises from 60 for a failure whose window has just closed to 90 for one
+// two windows old, since a late retry becomes less likely the longer nothing happens
+func dunningGapConfidence(age time.Duration, window time.Duration) int32 {
+	if window <= 0 {
+		return 90
+	}
+	overdue := min(max(age-window, 0), window)
+	return int32(60 + 30*overdue/window)
+}
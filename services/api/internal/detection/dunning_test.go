@@ -0,0 +1,162 @@
+package detection
+
+import (
+	"context"
+	"errors"
+	"rdl-api/internal/domain/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeDunningFinder returns gaps and records the time, window and lookback it was asked for
+type fakeDunningFinder struct {
+	gaps     []models.DunningGap
+	err      error
+	now      time.Time
+	window   time.Duration
+	lookback time.Duration
+}
+
+func (f *fakeDunningFinder) FindDunningGaps(_ context.Context, _ uuid.UUID, now time.Time, window time.Duration, lookback time.Duration) ([]models.DunningGap, error) {
+	f.now, f.window, f.lookback = now, window, lookback
+	return f.gaps, f.err
+}
+
+func TestDunningGapRule_Detect(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tenantID := uuid.New()
+	customerID := uuid.New()
+	gap := models.DunningGap{
+		EventID:     uuid.New(),
+		CustomerRef: "cus_1",
+		CustomerID:  customerID,
+		Amount:      models.NewDecimal(2900, -2),
+		Currency:    "USD",
+		FailedAt:    now.Add(-90 * time.Hour),
+		Window:      72 * time.Hour,
+	}
+	finder := &fakeDunningFinder{gaps: []models.DunningGap{gap}}
+
+	rule, err := NewDunningGapRule(finder, 72*time.Hour, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("NewDunningGapRule() error = %v", err)
+	}
+	candidates, err := rule.Detect(context.Background(), tenantID, now)
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+
+	if !finder.now.Equal(now) || finder.window != 72*time.Hour || finder.lookback != 24*time.Hour {
+		t.Errorf("expected now with a 72h window and 24h lookback, got %s, %s and %s", finder.now, finder.window, finder.lookback)
+	}
+	if len(candidates) != 1 {
+		t.Fatalf("expected 1 candidate, got %d", len(candidates))
+	}
+	c := candidates[0]
+	if c.LeakType != models.LeakTypeEnumDunningGap || c.Rule != DunningGapRuleName || c.TenantID != tenantID || c.CustomerID != customerID {
+		t.Errorf("unexpected candidate %+v", c)
+	}
+	if c.Amount.Cmp(gap.Amount) != 0 || c.Currency != "USD" {
+		t.Errorf("expected the failed payment's amount, got %s %s", c.Amount, c.Currency)
+	}
+	if c.SourceEventID == nil || *c.SourceEventID != gap.EventID {
+		t.Errorf("expected the failed payment event as the source, got %v", c.SourceEventID)
+	}
+	if c.Confidence != 67 {
+		t.Errorf("expected confidence 67 for a failure a quarter window overdue, got %d", c.Confidence)
+	}
+	if !strings.Contains(c.Reason, "cus_1") || !strings.Contains(c.Reason, "72h0m0s") {
+		t.Errorf("expected the reason to name the customer and the window, got %q", c.Reason)
+	}
+}
+
+func TestDunningGapRule_DedupKey(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	gap := func(customerRef, subscriptionRef string) models.DunningGap {
+		return models.DunningGap{EventID: uuid.New(), CustomerRef: customerRef, SubscriptionRef: subscriptionRef, FailedAt: now.Add(-80 * time.Hour), Window: 72 * time.Hour}
+	}
+	finder := &fakeDunningFinder{gaps: []models.DunningGap{
+		gap("cus_1", "sub_1"),
+		gap("cus_1", "sub_1"),
+		gap("cus_1", "sub_2"),
+		gap("cus_1", ""),
+	}}
+
+	rule, err := NewDunningGapRule(finder, 72*time.Hour, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("NewDunningGapRule() error = %v", err)
+	}
+	candidates, err := rule.Detect(context.Background(), uuid.New(), now)
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+
+	if len(candidates) != 4 {
+		t.Fatalf("expected 4 candidates, got %d", len(candidates))
+	}
+	if candidates[0].DedupKey == "" || candidates[0].DedupKey != candidates[1].DedupKey {
+		t.Errorf("expected failures of one subscription to share a dedup key, got %q and %q", candidates[0].DedupKey, candidates[1].DedupKey)
+	}
+	if candidates[2].DedupKey == candidates[0].DedupKey {
+		t.Errorf("expected another subscription to have its own dedup key, got %q", candidates[2].DedupKey)
+	}
+	if candidates[3].DedupKey != "" {
+		t.Errorf("expected no dedup key without a subscription, got %q", candidates[3].DedupKey)
+	}
+}
+
+func TestDunningGapRule_NoGaps(t *testing.T) {
+	rule, err := NewDunningGapRule(&fakeDunningFinder{}, time.Hour, time.Hour)
+	if err != nil {
+		t.Fatalf("NewDunningGapRule() error = %v", err)
+	}
+	candidates, err := rule.Detect(context.Background(), uuid.New(), time.Now())
+	if err != nil || len(candidates) != 0 {
+		t.Errorf("expected no candidates, got %v (%v)", candidates, err)
+	}
+}
+
+func TestDunningGapRule_FinderError(t *testing.T) {
+	rule, err := NewDunningGapRule(&fakeDunningFinder{err: errors.New("db down")}, time.Hour, time.Hour)
+	if err != nil {
+		t.Fatalf("NewDunningGapRule() error = %v", err)
+	}
+	if _, err := rule.Detect(context.Background(), uuid.New(), time.Now()); err == nil {
+		t.Error("expected the finder's error")
+	}
+}
+
+func TestNewDunningGapRule_Invalid(t *testing.T) {
+	for _, tt := range []struct {
+		name             string
+		window, lookback time.Duration
+	}{
+		{"zero window", 0, time.Hour},
+		{"zero lookback", time.Hour, 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewDunningGapRule(&fakeDunningFinder{}, tt.window, tt.lookback); !errors.Is(err, ErrInvalidDunningGapRule) {
+				t.Errorf("expected ErrInvalidDunningGapRule, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDunningGapConfidence(t *testing.T) {
+	for _, tt := range []struct {
+		age  time.Duration
+		want int32
+	}{
+		{72 * time.Hour, 60},
+		{108 * time.Hour, 75},
+		{144 * time.Hour, 90},
+		{30 * 24 * time.Hour, 90},
+	} {
+		if got := dunningGapConfidence(tt.age, 72*time.Hour); got != tt.want {
+			t.Errorf("dunningGapConfidence(%s) = %d, want %d", tt.age, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package detection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rdl-api/internal/domain/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DuplicateChargeRuleName is the Name of DuplicateChargeRule
+const DuplicateChargeRuleName = "duplicate_charge"
+
+// Defaults for DuplicateChargeRule: a repeat charge within ten minutes is a duplicate, and each
+// run looks at the charges of the last day.
+const (
+	DefaultDuplicateChargeWindow   = 10 * time.Minute
+	DefaultDuplicateChargeLookback = 24 * time.Hour
+)
+
+var ErrInvalidDuplicateChargeRule = errors.New("invalid duplicate charge rule")
+
+// DuplicateChargeFinder finds a tenant's payment_succeeded events that repeat an earlier charge
+type DuplicateChargeFinder interface {
+	FindDuplicateCharges(ctx context.Context, tenantID uuid.UUID, since time.Time, window time.Duration) ([]models.DuplicateCharge, error)
+}
+
+// DuplicateChargeRule flags a customer charged twice for the same thing: two payment_succeeded
+// events with the same customer, amount and currency no more than Window apart. The later
+// charge is the leak, a likely refund and support ticket.
+//
+// Each run looks at the charges created in the Lookback before now. A duplicate is flagged
+// once; later runs skip it because its leak already points at it.
+type DuplicateChargeRule struct {
+	finder   DuplicateChargeFinder
+	window   time.Duration
+	lookback time.Duration
+}
+
+// NewDuplicateChargeRule creates the rule, returning ErrInvalidDuplicateChargeRule when window
+// is not positive or lookback is shorter than window.
+func NewDuplicateChargeRule(finder DuplicateChargeFinder, window time.Duration, lookback time.Duration) (*DuplicateChargeRule, error) {
+	if window <= 0 {
+		return nil, fmt.Errorf("%w: window must be positive, got %s", ErrInvalidDuplicateChargeRule, window)
+	}
+	if lookback < window {
+		return nil, fmt.Errorf("%w: lookback %s is shorter than the window %s", ErrInvalidDuplicateChargeRule, lookback, window)
+	}
+	return &DuplicateChargeRule{finder: finder, window: window, lookback: lookback}, nil
+}
+
+// Name returns DuplicateChargeRuleName
+func (r *DuplicateChargeRule) Name() string {
+	return DuplicateChargeRuleName
+}
+
+// LeakType returns models.LeakTypeEnumDuplicateCharge
+func (r *DuplicateChargeRule) LeakType() models.LeakTypeEnum {
+	return models.LeakTypeEnumDuplicateCharge
+}
+
+// EventTypes returns the event types the rule reads: only payment_succeeded
+func (r *DuplicateChargeRule) EventTypes() []models.EventTypeEnum {
+	return duplicateChargeEventTypes()
+}
+
+func duplicateChargeEventTypes() []models.EventTypeEnum {
+	return []models.EventTypeEnum{models.EventTypeEnumPaymentSucceeded}
+}
+
+// Detect returns a duplicate_charge candidate for every charge that repeats an earlier one,
+// for the duplicate's amount and triggered by the duplicate event.
+func (r *DuplicateChargeRule) Detect(ctx context.Context, tenantID uuid.UUID, now time.Time) ([]Candidate, error) {
+	duplicates, err := r.finder.FindDuplicateCharges(ctx, tenantID, now.Add(-r.lookback), r.window)
+	if err != nil {
+		return nil, fmt.Errorf("find duplicate charges: %w", err)
+	}
+
+	candidates := make([]Candidate, 0, len(duplicates))
+	for _, duplicate := range duplicates {
+		eventID := duplicate.EventID
+		gap := duplicate.ChargedAt.Sub(duplicate.OriginalChargedAt)
+		candidates = append(candidates, Candidate{
+			Rule:          r.Name(),
+			TenantID:      tenantID,
+			CustomerID:    duplicate.CustomerID,
+			LeakType:      models.LeakTypeEnumDuplicateCharge,
+			Amount:        duplicate.Amount,
+			Currency:      duplicate.Currency,
+			SourceEventID: &eventID,
+			Confidence:    duplicateConfidence(gap, r.window),
+			Reason: fmt.Sprintf("customer %s charged %s %s twice, %s apart (events %s and %s)",
+				duplicate.CustomerRef, duplicate.Amount, duplicate.Currency, gap, duplicate.OriginalEventID, duplicate.EventID),
+		})
+	}
+	return candidates, nil
+}
+
+// duplicateConfidence falls from 100 for charges at the same moment to 50 for charges a full
+// window apart, since a quick repeat is more likely a double submit than a second purchase
+func duplicateConfidence(gap time.Duration, window time.Duration) int32 {
+	if gap <= 0 {
+		return 100
+	}
+	return int32(100 - 50*min(gap, window)/window)
+}
@@ -0,0 +1,116 @@
+package detection
+
+import (
+	"context"
+	"errors"
+	"rdl-api/internal/domain/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeDuplicateFinder returns duplicates and records the lookback start and window it was asked for
+type fakeDuplicateFinder struct {
+	duplicates []models.DuplicateCharge
+	err        error
+	since      time.Time
+	window     time.Duration
+}
+
+func (f *fakeDuplicateFinder) FindDuplicateCharges(_ context.Context, _ uuid.UUID, since time.Time, window time.Duration) ([]models.DuplicateCharge, error) {
+	f.since, f.window = since, window
+	return f.duplicates, f.err
+}
+
+func TestDuplicateChargeRule_Detect(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tenantID := uuid.New()
+	customerID := uuid.New()
+	duplicate := models.DuplicateCharge{
+		EventID:           uuid.New(),
+		OriginalEventID:   uuid.New(),
+		CustomerRef:       "cus_1",
+		CustomerID:        customerID,
+		Amount:            models.NewDecimal(4999, -2),
+		Currency:          "USD",
+		ChargedAt:         now.Add(-time.Hour),
+		OriginalChargedAt: now.Add(-time.Hour - time.Minute),
+	}
+	finder := &fakeDuplicateFinder{duplicates: []models.DuplicateCharge{duplicate}}
+
+	rule, err := NewDuplicateChargeRule(finder, 10*time.Minute, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("NewDuplicateChargeRule() error = %v", err)
+	}
+	candidates, err := rule.Detect(context.Background(), tenantID, now)
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+
+	if !finder.since.Equal(now.Add(-24*time.Hour)) || finder.window != 10*time.Minute {
+		t.Errorf("expected the last day with a 10m window, got since %s and window %s", finder.since, finder.window)
+	}
+	if len(candidates) != 1 {
+		t.Fatalf("expected 1 candidate, got %d", len(candidates))
+	}
+	c := candidates[0]
+	if c.LeakType != models.LeakTypeEnumDuplicateCharge || c.Rule != DuplicateChargeRuleName || c.TenantID != tenantID || c.CustomerID != customerID {
+		t.Errorf("unexpected candidate %+v", c)
+	}
+	if c.Amount.Cmp(duplicate.Amount) != 0 || c.Currency != "USD" {
+		t.Errorf("expected the duplicate's amount, got %s %s", c.Amount, c.Currency)
+	}
+	if c.SourceEventID == nil || *c.SourceEventID != duplicate.EventID {
+		t.Errorf("expected the duplicate event as the source, got %v", c.SourceEventID)
+	}
+	if c.Confidence != 95 {
+		t.Errorf("expected confidence 95 for a repeat a tenth of the window later, got %d", c.Confidence)
+	}
+	if !strings.Contains(c.Reason, "cus_1") || !strings.Contains(c.Reason, duplicate.OriginalEventID.String()) {
+		t.Errorf("expected the reason to name the customer and the original charge, got %q", c.Reason)
+	}
+}
+
+func TestDuplicateChargeRule_FinderError(t *testing.T) {
+	rule, err := NewDuplicateChargeRule(&fakeDuplicateFinder{err: errors.New("db down")}, time.Minute, time.Hour)
+	if err != nil {
+		t.Fatalf("NewDuplicateChargeRule() error = %v", err)
+	}
+	if _, err := rule.Detect(context.Background(), uuid.New(), time.Now()); err == nil {
+		t.Error("expected the finder's error")
+	}
+}
+
+func TestNewDuplicateChargeRule_Invalid(t *testing.T) {
+	for _, tt := range []struct {
+		name             string
+		window, lookback time.Duration
+	}{
+		{"zero window", 0, time.Hour},
+		{"lookback shorter than window", time.Hour, time.Minute},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewDuplicateChargeRule(&fakeDuplicateFinder{}, tt.window, tt.lookback); !errors.Is(err, ErrInvalidDuplicateChargeRule) {
+				t.Errorf("expected ErrInvalidDuplicateChargeRule, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDuplicateConfidence(t *testing.T) {
+	for _, tt := range []struct {
+		gap  time.Duration
+		want int32
+	}{
+		{0, 100},
+		{5 * time.Minute, 75},
+		{10 * time.Minute, 50},
+		{time.Hour, 50},
+	} {
+		if got := duplicateConfidence(tt.gap, 10*time.Minute); got != tt.want {
+			t.Errorf("duplicateConfidence(%s) = %d, want %d", tt.gap, got, tt.want)
+		}
+	}
+}
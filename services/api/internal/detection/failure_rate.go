@@ -0,0 +1,144 @@
+package detection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rdl-api/internal/domain/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FailureRateSpikeRuleName is the Name of FailureRateSpikeRule
+const FailureRateSpikeRuleName = "failure_rate_spike"
+
+// Defaults for FailureRateSpikeRule: compare each provider's failure rate over the last hour
+// with its rate over the day before it, and flag a three-fold rise once the hour had at least
+// 10 payment attempts.
+const (
+	DefaultFailureRateWindow      = time.Hour
+	DefaultFailureRateBaseline    = 24 * time.Hour
+	DefaultFailureRateFactor      = 3.0
+	DefaultFailureRateMinAttempts = 10
+)
+
+// failureRateFloor is the lowest baseline rate a window is compared against, so a provider
+// that had no failures in the baseline is not flagged for one or two
+const failureRateFloor = 0.01
+
+var ErrInvalidFailureRateRule = errors.New("invalid failure rate spike rule")
+
+// FailureRateCounter counts, per provider, a tenant's payment attempts created in [from, to)
+// and how many of them failed
+type FailureRateCounter interface {
+	GetProviderFailureRates(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) ([]models.ProviderFailureRate, error)
+}
+
+// FailureRateSpikeRule flags a provider whose payment failure rate suddenly jumps, which
+// usually means a configuration change on the provider's side rather than customers all
+// running out of funds at once.
+//
+// Each provider's rate in the current window, the Window before now, is compared with its rate
+// over the Baseline before that. A current rate more than Factor times the baseline rate, or
+// times 1% if the baseline rate is lower, is a spike. Providers with fewer than MinAttempts
+// attempts in the current window or none in the baseline are not judged. Runs that judge the
+// same provider and window, aligned to Window, add to a single open leak.
+type FailureRateSpikeRule struct {
+	counter     FailureRateCounter
+	window      time.Duration
+	baseline    time.Duration
+	factor      float64
+	minAttempts int64
+}
+
+// NewFailureRateSpikeRule creates the rule, returning ErrInvalidFailureRateRule when window or
+// baseline is not positive, factor is not greater than 1 or minAttempts is below 1.
+func NewFailureRateSpikeRule(counter FailureRateCounter, window time.Duration, baseline time.Duration, factor float64, minAttempts int64) (*FailureRateSpikeRule, error) {
+	if window <= 0 {
+		return nil, fmt.Errorf("%w: window must be positive, got %s", ErrInvalidFailureRateRule, window)
+	}
+	if baseline <= 0 {
+		return nil, fmt.Errorf("%w: baseline must be positive, got %s", ErrInvalidFailureRateRule, baseline)
+	}
+	if factor <= 1 {
+		return nil, fmt.Errorf("%w: factor must be greater than 1, got %g", ErrInvalidFailureRateRule, factor)
+	}
+	if minAttempts < 1 {
+		return nil, fmt.Errorf("%w: minimum attempts must be at least 1, got %d", ErrInvalidFailureRateRule, minAttempts)
+	}
+	return &FailureRateSpikeRule{counter: counter, window: window, baseline: baseline, factor: factor, minAttempts: minAttempts}, nil
+}
+
+// Name returns FailureRateSpikeRuleName
+func (r *FailureRateSpikeRule) Name() string {
+	return FailureRateSpikeRuleName
+}
+
+// LeakType returns models.LeakTypeEnumFailureRateSpike
+func (r *FailureRateSpikeRule) LeakType() models.LeakTypeEnum {
+	return models.LeakTypeEnumFailureRateSpike
+}
+
+// EventTypes returns the event types the rule reads: payment_failed and payment_succeeded
+func (r *FailureRateSpikeRule) EventTypes() []models.EventTypeEnum {
+	return failureRateEventTypes()
+}
+
+func failureRateEventTypes() []models.EventTypeEnum {
+	return []models.EventTypeEnum{models.EventTypeEnumPaymentFailed, models.EventTypeEnumPaymentSucceeded}
+}
+
+// Detect returns a failure_rate_spike candidate for every provider whose failure rate in the
+// current window exceeds its baseline by more than the factor.
+func (r *FailureRateSpikeRule) Detect(ctx context.Context, tenantID uuid.UUID, now time.Time) ([]Candidate, error) {
+	candidates, _, err := r.DetectScanned(ctx, tenantID, now)
+	return candidates, err
+}
+
+// DetectScanned is Detect that also returns the number of payment attempts counted across the
+// current window and the baseline.
+func (r *FailureRateSpikeRule) DetectScanned(ctx context.Context, tenantID uuid.UUID, now time.Time) ([]Candidate, int64, error) {
+	windowStart := now.Add(-r.window)
+
+	current, err := r.counter.GetProviderFailureRates(ctx, tenantID, windowStart, now)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count current window: %w", err)
+	}
+	baseline, err := r.counter.GetProviderFailureRates(ctx, tenantID, windowStart.Add(-r.baseline), windowStart)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count baseline: %w", err)
+	}
+
+	var scanned int64
+	baselineByProvider := make(map[uuid.UUID]models.ProviderFailureRate, len(baseline))
+	for _, rate := range baseline {
+		baselineByProvider[rate.ProviderID] = rate
+		scanned += rate.Total
+	}
+
+	var candidates []Candidate
+	for _, rate := range current {
+		scanned += rate.Total
+		base, ok := baselineByProvider[rate.ProviderID]
+		if rate.Total < r.minAttempts || !ok || base.Total == 0 {
+			continue
+		}
+		baseRate := max(base.Rate(), failureRateFloor)
+		if rate.Rate() <= baseRate*r.factor {
+			continue
+		}
+
+		candidates = append(candidates, Candidate{
+			Rule:     r.Name(),
+			TenantID: tenantID,
+			LeakType: models.LeakTypeEnumFailureRateSpike,
+			// scored like a volume spike: 2x the baseline is 50, 10x is 90
+			Confidence: volumeConfidence(rate.Rate() / baseRate),
+			Reason: fmt.Sprintf("payment failure rate of provider %s (%s) rose to %.1f%%, %d of %d attempts in the last %s, against a baseline of %.1f%%",
+				rate.ProviderName, rate.ProviderID, rate.Rate()*100, rate.Failed, rate.Total, r.window, base.Rate()*100),
+			DedupKey: windowDedupKey(models.LeakTypeEnumFailureRateSpike, rate.ProviderID.String(), windowStart, r.window),
+		})
+	}
+	return candidates, scanned, nil
+}
@@ -0,0 +1,182 @@
+package detection
+
+import (
+	"context"
+	"errors"
+	"rdl-api/internal/domain/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeFailureRates answers the current window with current and the baseline with baseline
+type fakeFailureRates struct {
+	current  []models.ProviderFailureRate
+	baseline []models.ProviderFailureRate
+	now      time.Time
+	err      error
+}
+
+func (f fakeFailureRates) GetProviderFailureRates(_ context.Context, _ uuid.UUID, _ time.Time, to time.Time) ([]models.ProviderFailureRate, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if to.Equal(f.now) {
+		return f.current, nil
+	}
+	return f.baseline, nil
+}
+
+func TestFailureRateSpikeRule_Detect(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	spiking := models.ProviderFailureRate{ProviderID: uuid.New(), ProviderName: "stripe"}
+	steady := models.ProviderFailureRate{ProviderID: uuid.New(), ProviderName: "chargebee"}
+	rates := func(provider models.ProviderFailureRate, failed int64, total int64) models.ProviderFailureRate {
+		provider.Failed, provider.Total = failed, total
+		return provider
+	}
+
+	tests := []struct {
+		name      string
+		current   []models.ProviderFailureRate
+		baseline  []models.ProviderFailureRate
+		wantLeaks []uuid.UUID
+	}{
+		{
+			name:      "one provider above the threshold, one below",
+			current:   []models.ProviderFailureRate{rates(spiking, 20, 50), rates(steady, 6, 50)},
+			baseline:  []models.ProviderFailureRate{rates(spiking, 50, 1000), rates(steady, 50, 1000)},
+			wantLeaks: []uuid.UUID{spiking.ProviderID},
+		},
+		{
+			name:     "just below the threshold",
+			current:  []models.ProviderFailureRate{rates(spiking, 15, 100)},
+			baseline: []models.ProviderFailureRate{rates(spiking, 50, 1000)},
+		},
+		{
+			name:     "too few attempts to judge",
+			current:  []models.ProviderFailureRate{rates(spiking, 5, 5)},
+			baseline: []models.ProviderFailureRate{rates(spiking, 50, 1000)},
+		},
+		{
+			name:    "no baseline",
+			current: []models.ProviderFailureRate{rates(spiking, 40, 50)},
+		},
+		{
+			name:     "a failure or two after a clean baseline",
+			current:  []models.ProviderFailureRate{rates(spiking, 1, 50)},
+			baseline: []models.ProviderFailureRate{rates(spiking, 0, 1000)},
+		},
+		{
+			name:      "many failures after a clean baseline",
+			current:   []models.ProviderFailureRate{rates(spiking, 10, 50)},
+			baseline:  []models.ProviderFailureRate{rates(spiking, 0, 1000)},
+			wantLeaks: []uuid.UUID{spiking.ProviderID},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := fakeFailureRates{current: tt.current, baseline: tt.baseline, now: now}
+			rule, err := NewFailureRateSpikeRule(counter, time.Hour, 24*time.Hour, 3, DefaultFailureRateMinAttempts)
+			if err != nil {
+				t.Fatalf("NewFailureRateSpikeRule() error = %v", err)
+			}
+			tenantID := uuid.New()
+
+			candidates, scanned, err := rule.DetectScanned(context.Background(), tenantID, now)
+			if err != nil {
+				t.Fatalf("DetectScanned() error = %v", err)
+			}
+			var want int64
+			for _, rate := range append(tt.current, tt.baseline...) {
+				want += rate.Total
+			}
+			if scanned != want {
+				t.Errorf("expected %d attempts scanned, got %d", want, scanned)
+			}
+			if len(candidates) != len(tt.wantLeaks) {
+				t.Fatalf("expected %d candidates, got %+v", len(tt.wantLeaks), candidates)
+			}
+			for i, c := range candidates {
+				if c.LeakType != models.LeakTypeEnumFailureRateSpike || c.TenantID != tenantID || c.Rule != FailureRateSpikeRuleName {
+					t.Errorf("unexpected candidate %+v", c)
+				}
+				if !strings.Contains(c.Reason, tt.wantLeaks[i].String()) {
+					t.Errorf("reason %q does not name provider %s", c.Reason, tt.wantLeaks[i])
+				}
+				if c.Confidence <= 0 || c.Confidence > 100 {
+					t.Errorf("confidence %d out of range", c.Confidence)
+				}
+			}
+		})
+	}
+
+	t.Run("dedup key per provider and window", func(t *testing.T) {
+		other := models.ProviderFailureRate{ProviderID: uuid.New(), ProviderName: "adyen"}
+		detect := func(at time.Time) []Candidate {
+			t.Helper()
+			counter := fakeFailureRates{
+				current:  []models.ProviderFailureRate{rates(spiking, 20, 50), rates(other, 20, 50)},
+				baseline: []models.ProviderFailureRate{rates(spiking, 50, 1000), rates(other, 50, 1000)},
+				now:      at,
+			}
+			rule, err := NewFailureRateSpikeRule(counter, time.Hour, 24*time.Hour, 3, DefaultFailureRateMinAttempts)
+			if err != nil {
+				t.Fatalf("NewFailureRateSpikeRule() error = %v", err)
+			}
+			candidates, err := rule.Detect(context.Background(), uuid.New(), at)
+			if err != nil || len(candidates) != 2 {
+				t.Fatalf("Detect() = %d candidates, %v", len(candidates), err)
+			}
+			return candidates
+		}
+
+		first := detect(now.Add(10 * time.Minute))
+		if first[0].DedupKey == "" || first[0].DedupKey == first[1].DedupKey {
+			t.Fatalf("expected a distinct dedup key per provider, got %q and %q", first[0].DedupKey, first[1].DedupKey)
+		}
+		if again := detect(now.Add(30 * time.Minute)); again[0].DedupKey != first[0].DedupKey {
+			t.Errorf("expected a run in the same window to share the key %q, got %q", first[0].DedupKey, again[0].DedupKey)
+		}
+		if next := detect(now.Add(70 * time.Minute)); next[0].DedupKey == first[0].DedupKey {
+			t.Errorf("expected the next window to get its own key, got %q", next[0].DedupKey)
+		}
+	})
+
+	t.Run("counter error", func(t *testing.T) {
+		errCount := errors.New("count failed")
+		rule, err := NewFailureRateSpikeRule(fakeFailureRates{err: errCount}, time.Hour, 24*time.Hour, 3, 1)
+		if err != nil {
+			t.Fatalf("NewFailureRateSpikeRule() error = %v", err)
+		}
+		if _, err := rule.Detect(context.Background(), uuid.New(), now); !errors.Is(err, errCount) {
+			t.Errorf("expected the counter error, got %v", err)
+		}
+	})
+}
+
+func TestNewFailureRateSpikeRule_Invalid(t *testing.T) {
+	tests := []struct {
+		name        string
+		window      time.Duration
+		baseline    time.Duration
+		factor      float64
+		minAttempts int64
+	}{
+		{"zero window", 0, time.Hour, 3, 10},
+		{"zero baseline", time.Hour, 0, 3, 10},
+		{"factor of 1", time.Hour, time.Hour, 1, 10},
+		{"no minimum attempts", time.Hour, time.Hour, 3, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewFailureRateSpikeRule(fakeFailureRates{}, tt.window, tt.baseline, tt.factor, tt.minAttempts)
+			if !errors.Is(err, ErrInvalidFailureRateRule) {
+				t.Errorf("expected ErrInvalidFailureRateRule, got %v", err)
+			}
+		})
+	}
+}
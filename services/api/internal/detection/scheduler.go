@@ -0,0 +1,174 @@
+package detection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"rdl-api/internal/metrics"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidConcurrency is returned by NewScheduler for a concurrency below 1
+var ErrInvalidConcurrency = errors.New("detection concurrency must be at least 1")
+
+// TenantLister lists the tenants a Scheduler runs detection for
+type TenantLister interface {
+	ListTenantIDs(ctx context.Context) ([]uuid.UUID, error)
+}
+
+// TenantDetector runs leak detection for one tenant; *Detector is one
+type TenantDetector interface {
+	DetectLeaks(ctx context.Context, tenantID uuid.UUID, dryRun bool) (Report, error)
+}
+
+// SchedulerRun describes one scheduled detection run across all tenants
+type SchedulerRun struct {
+	// Tenants is the number of tenants detection ran for
+	Tenants int
+	// Skipped is the number of tenants passed over because another run was already detecting
+	// their leaks
+	Skipped int
+	// Failed holds the error of every tenant whose run failed, keyed by tenant
+	Failed map[uuid.UUID]error
+	// Created is the number of leaks stored across all tenants
+	Created       int
+	Truncated     int
+	EventsScanned int64
+	Duration      time.Duration
+}
+
+// Scheduler runs leak detection for every tenant through a fixed number of workers, so a large
+// tenant list neither runs one tenant at a time nor opens a connection per tenant at once
+type Scheduler struct {
+	detector    TenantDetector
+	tenants     TenantLister
+	logger      *slog.Logger
+	concurrency int
+	now         func() time.Time
+}
+
+// NewScheduler creates a Scheduler that runs detection for at most concurrency tenants at once
+func NewScheduler(detector TenantDetector, tenants TenantLister, logger *slog.Logger, concurrency int) (*Scheduler, error) {
+	if concurrency < 1 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidConcurrency, concurrency)
+	}
+	return &Scheduler{detector: detector, tenants: tenants, logger: logger, concurrency: concurrency, now: time.Now}, nil
+}
+
+// RunOnce runs detection for every tenant. A tenant whose run fails does not stop the others:
+// its error is kept in Failed and the run carries on. The failures are also joined and
+// returned with the totals of everything that did succeed. A tenant whose detection is already
+// running elsewhere is counted in Skipped rather than Failed. When ctx is done no further tenants
+// are started, and RunOnce returns once the ones in flight have finished. Every run is added to
+// the scheduler metrics.
+func (s *Scheduler) RunOnce(ctx context.Context) (SchedulerRun, error) {
+	run := SchedulerRun{Failed: map[uuid.UUID]error{}}
+	start := s.now()
+	tenantIDs, err := s.tenants.ListTenantIDs(ctx)
+	if err != nil {
+		return run, fmt.Errorf("list tenants: %w", err)
+	}
+
+	jobs := make(chan uuid.UUID)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for range min(s.concurrency, len(tenantIDs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for tenantID := range jobs {
+				report, err := s.detectTenant(ctx, tenantID)
+				mu.Lock()
+				run.Tenants++
+				if errors.Is(err, ErrDetectionInProgress) {
+					run.Skipped++
+					mu.Unlock()
+					continue
+				}
+				run.Created += len(report.Created)
+				run.EventsScanned += report.EventsScanned
+				if report.Truncated {
+					run.Truncated++
+				}
+				if err != nil {
+					run.Failed[tenantID] = err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, tenantID := range tenantIDs {
+		select {
+		case <-ctx.Done():
+			break feed
+		case jobs <- tenantID:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	run.Duration = s.now().Sub(start)
+	metrics.RecordDetectionSchedulerRun(metrics.DetectionSchedulerRun{Tenants: run.Tenants, Failed: len(run.Failed), Duration: run.Duration})
+
+	var errs []error
+	for _, tenantID := range tenantIDs {
+		if err, ok := run.Failed[tenantID]; ok {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenantID, err))
+		}
+	}
+	if err := ctx.Err(); err != nil && run.Tenants < len(tenantIDs) {
+		errs = append(errs, fmt.Errorf("stopped after %d of %d tenants: %w", run.Tenants, len(tenantIDs), err))
+	}
+	return run, errors.Join(errs...)
+}
+
+// detectTenant runs detection for one tenant, turning a panic in a rule or store into an
+// error so it only fails that tenant
+func (s *Scheduler) detectTenant(ctx context.Context, tenantID uuid.UUID) (report Report, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("detection panicked: %v", recovered)
+		}
+		if errors.Is(err, ErrDetectionInProgress) {
+			s.logger.DebugContext(ctx, "Skipping tenant with leak detection already running", "tenant_id", tenantID)
+		} else if err != nil {
+			s.logger.ErrorContext(ctx, "Scheduled leak detection failed for tenant", "error", err, "tenant_id", tenantID)
+		}
+	}()
+	return s.detector.DetectLeaks(ctx, tenantID, false)
+}
+
+// Run detects leaks for every tenant once immediately and then every interval, until ctx is
+// done. Each run logs its totals; tenants that failed are logged and tried again next run.
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		run, err := s.RunOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			s.logger.ErrorContext(ctx, "Scheduled leak detection run failed", "error", err, "tenants", run.Tenants, "failed", len(run.Failed), "created", run.Created)
+		} else if err == nil {
+			s.logger.InfoContext(ctx, "Scheduled leak detection run finished",
+				"tenants", run.Tenants,
+				"skipped", run.Skipped,
+				"created", run.Created,
+				"truncated", run.Truncated,
+				"events_scanned", run.EventsScanned,
+				"concurrency", s.concurrency,
+				"duration", run.Duration.String(),
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
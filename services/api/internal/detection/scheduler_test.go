@@ -0,0 +1,188 @@
+package detection
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"rdl-api/internal/domain/models"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type tenantList []uuid.UUID
+
+func (l tenantList) ListTenantIDs(context.Context) ([]uuid.UUID, error) {
+	return l, nil
+}
+
+// slowDetector holds every run for delay and records the most runs it saw at once. It fails
+// for failTenant and blocks for blockTenant until ctx is done.
+type slowDetector struct {
+	delay       time.Duration
+	failTenant  uuid.UUID
+	blockTenant uuid.UUID
+
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+	mu          sync.Mutex
+	ran         map[uuid.UUID]bool
+}
+
+func (d *slowDetector) DetectLeaks(ctx context.Context, tenantID uuid.UUID, _ bool) (Report, error) {
+	n := d.inFlight.Add(1)
+	defer d.inFlight.Add(-1)
+	for {
+		seen := d.maxInFlight.Load()
+		if n <= seen || d.maxInFlight.CompareAndSwap(seen, n) {
+			break
+		}
+	}
+
+	d.mu.Lock()
+	if d.ran == nil {
+		d.ran = map[uuid.UUID]bool{}
+	}
+	d.ran[tenantID] = true
+	d.mu.Unlock()
+
+	if tenantID == d.blockTenant {
+		<-ctx.Done()
+		return Report{}, ctx.Err()
+	}
+	time.Sleep(d.delay)
+	if tenantID == d.failTenant {
+		return Report{}, errors.New("boom")
+	}
+	return Report{TenantID: tenantID, EventsScanned: 10, Created: []models.Leak{{TenantID: tenantID}}}, nil
+}
+
+func newTenants(n int) tenantList {
+	tenants := make(tenantList, n)
+	for i := range tenants {
+		tenants[i] = uuid.New()
+	}
+	return tenants
+}
+
+func newTestScheduler(t *testing.T, detector TenantDetector, tenants TenantLister, concurrency int) *Scheduler {
+	t.Helper()
+	s, err := NewScheduler(detector, tenants, slog.New(slog.NewTextHandler(io.Discard, nil)), concurrency)
+	if err != nil {
+		t.Fatalf("NewScheduler: %v", err)
+	}
+	return s
+}
+
+func TestScheduler_RunOnce(t *testing.T) {
+	t.Run("bounds concurrency", func(t *testing.T) {
+		tenants := newTenants(20)
+		detector := &slowDetector{delay: 10 * time.Millisecond}
+
+		run, err := newTestScheduler(t, detector, tenants, 3).RunOnce(context.Background())
+		if err != nil {
+			t.Fatalf("RunOnce() error = %v", err)
+		}
+		if got := detector.maxInFlight.Load(); got != 3 {
+			t.Errorf("expected at most 3 tenants at once and the pool to fill up, got %d", got)
+		}
+		if run.Tenants != 20 || run.Created != 20 || run.EventsScanned != 200 {
+			t.Errorf("expected totals for 20 tenants, got %+v", run)
+		}
+	})
+
+	t.Run("failing tenant does not block others", func(t *testing.T) {
+		tenants := newTenants(10)
+		detector := &slowDetector{failTenant: tenants[0]}
+
+		run, err := newTestScheduler(t, detector, tenants, 2).RunOnce(context.Background())
+		if err == nil {
+			t.Fatal("expected the failing tenant's error")
+		}
+		if len(run.Failed) != 1 || run.Failed[tenants[0]] == nil {
+			t.Errorf("expected only the failing tenant in Failed, got %v", run.Failed)
+		}
+		if run.Tenants != 10 || run.Created != 9 || len(detector.ran) != 10 {
+			t.Errorf("expected every tenant to run and 9 to create leaks, got %+v", run)
+		}
+	})
+
+	t.Run("panicking tenant does not block others", func(t *testing.T) {
+		tenants := newTenants(4)
+		panicking := tenants[1]
+		detector := detectorFunc(func(_ context.Context, tenantID uuid.UUID) (Report, error) {
+			if tenantID == panicking {
+				panic("rule bug")
+			}
+			return Report{TenantID: tenantID}, nil
+		})
+
+		run, err := newTestScheduler(t, detector, tenants, 2).RunOnce(context.Background())
+		if err == nil || run.Failed[panicking] == nil || run.Tenants != 4 {
+			t.Errorf("expected the panic to fail only its tenant, got %+v, %v", run, err)
+		}
+	})
+
+	t.Run("tenant with a run in progress is skipped", func(t *testing.T) {
+		tenants := newTenants(3)
+		busy := tenants[2]
+		detector := detectorFunc(func(_ context.Context, tenantID uuid.UUID) (Report, error) {
+			if tenantID == busy {
+				return Report{TenantID: tenantID}, ErrDetectionInProgress
+			}
+			return Report{TenantID: tenantID}, nil
+		})
+
+		run, err := newTestScheduler(t, detector, tenants, 2).RunOnce(context.Background())
+		if err != nil {
+			t.Fatalf("expected a skipped tenant not to fail the run, got %v", err)
+		}
+		if run.Tenants != 3 || run.Skipped != 1 || len(run.Failed) != 0 {
+			t.Errorf("expected 1 of 3 tenants skipped and none failed, got %+v", run)
+		}
+	})
+
+	t.Run("stops starting tenants when ctx is done", func(t *testing.T) {
+		tenants := newTenants(10)
+		detector := &slowDetector{blockTenant: tenants[0]}
+		ctx, cancel := context.WithCancel(context.Background())
+
+		done := make(chan struct{})
+		var run SchedulerRun
+		var err error
+		go func() {
+			defer close(done)
+			run, err = newTestScheduler(t, detector, tenants, 1).RunOnce(ctx)
+		}()
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("RunOnce did not return after ctx was done")
+		}
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		if run.Tenants != 1 {
+			t.Errorf("expected only the tenant in flight to run, got %d", run.Tenants)
+		}
+	})
+}
+
+func TestNewScheduler_InvalidConcurrency(t *testing.T) {
+	if _, err := NewScheduler(&slowDetector{}, tenantList{}, slog.New(slog.NewTextHandler(io.Discard, nil)), 0); !errors.Is(err, ErrInvalidConcurrency) {
+		t.Errorf("expected ErrInvalidConcurrency, got %v", err)
+	}
+}
+
+type detectorFunc func(ctx context.Context, tenantID uuid.UUID) (Report, error)
+
+func (f detectorFunc) DetectLeaks(ctx context.Context, tenantID uuid.UUID, _ bool) (Report, error) {
+	return f(ctx, tenantID)
+}
@@ -0,0 +1,138 @@
+package detection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rdl-api/internal/domain/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// VolumeAnomalyRuleName is the Name of VolumeAnomalyRule
+const VolumeAnomalyRuleName = "volume_anomaly"
+
+// Defaults for VolumeAnomalyRule: compare the last hour against the hourly average of the
+// day before it, and flag a three-fold spike or drop.
+const (
+	DefaultVolumeWindow          = time.Hour
+	DefaultVolumeBaselineWindows = 24
+	DefaultVolumeFactor          = 3.0
+)
+
+var ErrInvalidVolumeRule = errors.New("invalid volume anomaly rule")
+
+// EventCounter counts a tenant's events created in [from, to)
+type EventCounter interface {
+	GetEventCountInWindow(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) (int64, error)
+}
+
+// VolumeAnomalyRule flags a tenant whose event volume suddenly spikes or drops, which
+// usually means an integration broke rather than that business changed overnight.
+//
+// The count in the current window, the Window before now, is compared with the average
+// count per window over the BaselineWindows windows before it. A current count more than
+// Factor times the baseline is a spike; less than the baseline divided by Factor is a drop.
+// Tenants with no events in the baseline are not judged, since there is nothing to compare to.
+// Runs that judge the same window, aligned to Window, add to a single open leak.
+type VolumeAnomalyRule struct {
+	counter         EventCounter
+	window          time.Duration
+	baselineWindows int
+	factor          float64
+}
+
+// NewVolumeAnomalyRule creates the rule, returning ErrInvalidVolumeRule when window is not
+// positive, baselineWindows is below 1 or factor is not greater than 1.
+func NewVolumeAnomalyRule(counter EventCounter, window time.Duration, baselineWindows int, factor float64) (*VolumeAnomalyRule, error) {
+	if window <= 0 {
+		return nil, fmt.Errorf("%w: window must be positive, got %s", ErrInvalidVolumeRule, window)
+	}
+	if baselineWindows < 1 {
+		return nil, fmt.Errorf("%w: baseline windows must be at least 1, got %d", ErrInvalidVolumeRule, baselineWindows)
+	}
+	if factor <= 1 {
+		return nil, fmt.Errorf("%w: factor must be greater than 1, got %g", ErrInvalidVolumeRule, factor)
+	}
+	return &VolumeAnomalyRule{counter: counter, window: window, baselineWindows: baselineWindows, factor: factor}, nil
+}
+
+// Name returns VolumeAnomalyRuleName
+func (r *VolumeAnomalyRule) Name() string {
+	return VolumeAnomalyRuleName
+}
+
+// LeakType returns models.LeakTypeEnumVolumeAnomaly
+func (r *VolumeAnomalyRule) LeakType() models.LeakTypeEnum {
+	return models.LeakTypeEnumVolumeAnomaly
+}
+
+// EventTypes returns the event types the rule reads: it counts events of every type
+func (r *VolumeAnomalyRule) EventTypes() []models.EventTypeEnum {
+	return volumeEventTypes()
+}
+
+func volumeEventTypes() []models.EventTypeEnum {
+	return []models.EventTypeEnum{models.EventTypeEnumPaymentFailed, models.EventTypeEnumPaymentSucceeded, models.EventTypeEnumPaymentRefunded, models.EventTypeEnumPaymentUpdated}
+}
+
+// Detect returns a single volume_anomaly candidate when the current window deviates from
+// the baseline by more than the factor, and nothing otherwise.
+func (r *VolumeAnomalyRule) Detect(ctx context.Context, tenantID uuid.UUID, now time.Time) ([]Candidate, error) {
+	candidates, _, err := r.DetectScanned(ctx, tenantID, now)
+	return candidates, err
+}
+
+// DetectScanned is Detect that also returns the number of events counted across the
+// current window and the baseline.
+func (r *VolumeAnomalyRule) DetectScanned(ctx context.Context, tenantID uuid.UUID, now time.Time) ([]Candidate, int64, error) {
+	windowStart := now.Add(-r.window)
+	baselineStart := windowStart.Add(-time.Duration(r.baselineWindows) * r.window)
+
+	current, err := r.counter.GetEventCountInWindow(ctx, tenantID, windowStart, now)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count current window: %w", err)
+	}
+	baselineTotal, err := r.counter.GetEventCountInWindow(ctx, tenantID, baselineStart, windowStart)
+	if err != nil {
+		return nil, current, fmt.Errorf("count baseline: %w", err)
+	}
+	scanned := current + baselineTotal
+	if baselineTotal == 0 {
+		return nil, scanned, nil
+	}
+
+	baseline := float64(baselineTotal) / float64(r.baselineWindows)
+	var ratio float64
+	var direction string
+	switch {
+	case float64(current) > baseline*r.factor:
+		ratio, direction = float64(current)/baseline, "spike"
+	case float64(current) < baseline/r.factor:
+		direction = "drop"
+		if current > 0 {
+			ratio = baseline / float64(current)
+		}
+	default:
+		return nil, scanned, nil
+	}
+
+	return []Candidate{{
+		Rule:       r.Name(),
+		TenantID:   tenantID,
+		LeakType:   models.LeakTypeEnumVolumeAnomaly,
+		Confidence: volumeConfidence(ratio),
+		Reason:     fmt.Sprintf("event volume %s: %d events in the last %s against a baseline of %.1f", direction, current, r.window, baseline),
+		DedupKey:   windowDedupKey(models.LeakTypeEnumVolumeAnomaly, "", windowStart, r.window),
+	}}, scanned, nil
+}
+
+// volumeConfidence grows with how far past the baseline the volume moved: a 2x move
+// scores 50, 10x scores 90. A ratio of 0 means the volume dropped to nothing and scores 100.
+func volumeConfidence(ratio float64) int32 {
+	if ratio == 0 {
+		return 100
+	}
+	return int32(100 - 100/ratio)
+}
@@ -0,0 +1,143 @@
+package detection
+
+import (
+	"context"
+	"errors"
+	"rdl-api/internal/domain/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeCounter answers the current window with current and the baseline with baseline
+type fakeCounter struct {
+	current  int64
+	baseline int64
+	now      time.Time
+	err      error
+}
+
+func (f fakeCounter) GetEventCountInWindow(_ context.Context, _ uuid.UUID, _ time.Time, to time.Time) (int64, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	if to.Equal(f.now) {
+		return f.current, nil
+	}
+	return f.baseline, nil
+}
+
+func TestVolumeAnomalyRule_Detect(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		current    int64
+		baseline   int64 // total over 24 windows
+		wantLeak   bool
+		wantReason string
+	}{
+		{name: "spike", current: 100, baseline: 24 * 10, wantLeak: true, wantReason: "spike"},
+		{name: "drop", current: 2, baseline: 24 * 10, wantLeak: true, wantReason: "drop"},
+		{name: "drop to zero", current: 0, baseline: 24 * 10, wantLeak: true, wantReason: "drop"},
+		{name: "normal variance above", current: 15, baseline: 24 * 10},
+		{name: "normal variance below", current: 6, baseline: 24 * 10},
+		{name: "no baseline", current: 50, baseline: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := NewVolumeAnomalyRule(fakeCounter{current: tt.current, baseline: tt.baseline, now: now}, time.Hour, 24, 3)
+			if err != nil {
+				t.Fatalf("NewVolumeAnomalyRule() error = %v", err)
+			}
+			tenantID := uuid.New()
+
+			candidates, err := rule.Detect(context.Background(), tenantID, now)
+			if err != nil {
+				t.Fatalf("Detect() error = %v", err)
+			}
+			if !tt.wantLeak {
+				if len(candidates) != 0 {
+					t.Fatalf("expected no candidates, got %+v", candidates)
+				}
+				return
+			}
+			if len(candidates) != 1 {
+				t.Fatalf("expected 1 candidate, got %d", len(candidates))
+			}
+			c := candidates[0]
+			if c.LeakType != models.LeakTypeEnumVolumeAnomaly || c.TenantID != tenantID || c.Rule != VolumeAnomalyRuleName {
+				t.Errorf("unexpected candidate %+v", c)
+			}
+			if c.Confidence <= 0 || c.Confidence > 100 {
+				t.Errorf("confidence %d out of range", c.Confidence)
+			}
+			if !strings.Contains(c.Reason, tt.wantReason) {
+				t.Errorf("reason %q does not mention %q", c.Reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestVolumeAnomalyRule_DedupKey(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 10, 0, 0, time.UTC)
+	detect := func(at time.Time) Candidate {
+		t.Helper()
+		rule, err := NewVolumeAnomalyRule(fakeCounter{current: 100, baseline: 24 * 10, now: at}, time.Hour, 24, 3)
+		if err != nil {
+			t.Fatalf("NewVolumeAnomalyRule() error = %v", err)
+		}
+		candidates, err := rule.Detect(context.Background(), uuid.New(), at)
+		if err != nil || len(candidates) != 1 {
+			t.Fatalf("Detect() = %d candidates, %v", len(candidates), err)
+		}
+		return candidates[0]
+	}
+
+	first := detect(now)
+	if first.DedupKey == "" {
+		t.Fatal("expected a dedup key")
+	}
+	if again := detect(now.Add(20 * time.Minute)); again.DedupKey != first.DedupKey {
+		t.Errorf("expected a run in the same window to share the key %q, got %q", first.DedupKey, again.DedupKey)
+	}
+	if next := detect(now.Add(time.Hour)); next.DedupKey == first.DedupKey {
+		t.Errorf("expected the next window to get its own key, got %q", next.DedupKey)
+	}
+}
+
+func TestVolumeAnomalyRule_CounterError(t *testing.T) {
+	errDB := errors.New("db down")
+	rule, err := NewVolumeAnomalyRule(fakeCounter{err: errDB}, time.Hour, 24, 3)
+	if err != nil {
+		t.Fatalf("NewVolumeAnomalyRule() error = %v", err)
+	}
+
+	if _, err := rule.Detect(context.Background(), uuid.New(), time.Now()); !errors.Is(err, errDB) {
+		t.Fatalf("expected counter error, got %v", err)
+	}
+}
+
+func TestNewVolumeAnomalyRule_Invalid(t *testing.T) {
+	tests := []struct {
+		name            string
+		window          time.Duration
+		baselineWindows int
+		factor          float64
+	}{
+		{"zero window", 0, 24, 3},
+		{"no baseline windows", time.Hour, 0, 3},
+		{"factor of one", time.Hour, 24, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewVolumeAnomalyRule(fakeCounter{}, tt.window, tt.baselineWindows, tt.factor); !errors.Is(err, ErrInvalidVolumeRule) {
+				t.Fatalf("expected ErrInvalidVolumeRule, got %v", err)
+			}
+		})
+	}
+}
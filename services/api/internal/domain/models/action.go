@@ -17,6 +17,10 @@ type Action struct {
 	ActionType ActionTypeEnum   `json:"action_type"`
 	Status     ActionStatusEnum `json:"status"`
 	Result     ActionResultEnum `json:"result"`
+	Priority   int64            `json:"priority"`   // Leak amount in cents, higher runs first
+	ClaimedBy  *string          `json:"claimed_by"` // Worker executing the action, nil until claimed
+	ClaimedAt  *time.Time       `json:"claimed_at"`
+	Attempts   int32            `json:"attempts"` // Times the action has been claimed for execution
 	CreatedAt  time.Time        `json:"created_at"`
 	UpdatedAt  time.Time        `json:"updated_at"`
 }
@@ -37,3 +41,41 @@ type UpdateActionParams struct {
 	Status     *NullActionStatusEnum `json:"status"`
 	Result     *ActionResultEnum     `json:"result"`
 }
+
+// ActionFilter narrows an action listing. Values within a field are ORed and fields are ANDed;
+// an empty field matches everything. CreatedFrom is inclusive and CreatedTo exclusive.
+type ActionFilter struct {
+	ActionTypes []ActionTypeEnum   `json:"action_types"`
+	Statuses    []ActionStatusEnum `json:"statuses"`
+	Results     []ActionResultEnum `json:"results"`
+	CreatedFrom *time.Time         `json:"created_from"`
+	CreatedTo   *time.Time         `json:"created_to"`
+}
+
+// ActionSortField is a column an action listing can be ordered by
+type ActionSortField string
+
+const (
+	ActionSortCreatedAt ActionSortField = "created_at"
+	ActionSortUpdatedAt ActionSortField = "updated_at"
+	ActionSortPriority  ActionSortField = "priority"
+)
+
+// IsValid reports whether f is a known sort field
+func (f ActionSortField) IsValid() bool {
+	switch f {
+	case ActionSortCreatedAt, ActionSortUpdatedAt, ActionSortPriority:
+		return true
+	}
+	return false
+}
+
+// ActionSort orders an action listing by Field, ascending unless Descending. Actions that tie
+// are ordered by id so pages are stable.
+type ActionSort struct {
+	Field      ActionSortField `json:"field"`
+	Descending bool            `json:"descending"`
+}
+
+// DefaultActionSort lists the newest actions first
+var DefaultActionSort = ActionSort{Field: ActionSortCreatedAt, Descending: true}
@@ -0,0 +1,188 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidDecimal is returned when a string is not a base-10 number
+var ErrInvalidDecimal = errors.New("invalid decimal")
+
+// Limits of a parsed Decimal, well beyond any NUMERIC column of the schema. They bound the
+// cost of scaling and formatting a value: without them "1e2000000000" would make comparing it
+// compute a two-billion-digit power of ten.
+const (
+	// MaxDecimalDigits is the most significant digits a parsed Decimal may have
+	MaxDecimalDigits = 38
+	// MaxDecimalExponent is the largest power of ten, up or down, a parsed Decimal may be scaled by
+	MaxDecimalExponent = 32
+)
+
+// Decimal is an exact base-10 number, coefficient × 10^exponent. Money amounts use it so that
+// values such as 0.10 are stored and compared without float rounding. The scale is kept, so
+// "12.50" stays "12.50". The zero value is 0.
+type Decimal struct {
+	coef *big.Int
+	exp  int32
+}
+
+// NewDecimal returns coef × 10^exp, e.g. NewDecimal(1250, -2) is 12.50
+func NewDecimal(coef int64, exp int32) Decimal {
+	return Decimal{coef: big.NewInt(coef), exp: exp}
+}
+
+// NewDecimalFromBigInt returns coef × 10^exp. coef is copied; nil is 0.
+func NewDecimalFromBigInt(coef *big.Int, exp int32) Decimal {
+	if coef == nil {
+		return Decimal{exp: exp}
+	}
+	return Decimal{coef: new(big.Int).Set(coef), exp: exp}
+}
+
+// ParseDecimal parses a number such as "12.50", "-0.5" or "1e3". A number with more than
+// MaxDecimalDigits significant digits, or scaled by more than MaxDecimalExponent powers of ten,
+// is rejected as out of range.
+func ParseDecimal(s string) (Decimal, error) {
+	mantissa, exponent, hasExp := strings.Cut(strings.ToLower(strings.TrimSpace(s)), "e")
+
+	var exp int64
+	if hasExp {
+		parsed, err := strconv.ParseInt(exponent, 10, 32)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+		}
+		exp = parsed
+	}
+
+	sign := ""
+	if mantissa != "" && (mantissa[0] == '-' || mantissa[0] == '+') {
+		sign, mantissa = mantissa[:1], mantissa[1:]
+	}
+	whole, frac, _ := strings.Cut(mantissa, ".")
+	digits := whole + frac
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+
+	exp -= int64(len(frac))
+	if exp < -MaxDecimalExponent || exp > MaxDecimalExponent || len(strings.TrimLeft(digits, "0")) > MaxDecimalDigits {
+		return Decimal{}, fmt.Errorf("%w: %q is out of range", ErrInvalidDecimal, s)
+	}
+	coef, ok := new(big.Int).SetString(sign+digits, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+	return Decimal{coef: coef, exp: int32(exp)}, nil
+}
+
+// MustParseDecimal is ParseDecimal for constants; it panics on invalid input
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// Coefficient returns a copy of the unscaled value
+func (d Decimal) Coefficient() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(d.coef)
+}
+
+// Exponent returns the power of ten the coefficient is scaled by
+func (d Decimal) Exponent() int32 {
+	return d.exp
+}
+
+// Sign returns -1, 0 or +1
+func (d Decimal) Sign() int {
+	if d.coef == nil {
+		return 0
+	}
+	return d.coef.Sign()
+}
+
+// IsZero reports whether d is 0
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Cmp compares d and other and returns -1, 0 or +1. Scale is ignored, so 1.5 equals 1.50.
+func (d Decimal) Cmp(other Decimal) int {
+	exp := min(d.exp, other.exp)
+	return d.scaledTo(exp).Cmp(other.scaledTo(exp))
+}
+
+// Add returns d + other, at the finer of the two scales
+func (d Decimal) Add(other Decimal) Decimal {
+	exp := min(d.exp, other.exp)
+	return Decimal{coef: new(big.Int).Add(d.scaledTo(exp), other.scaledTo(exp)), exp: exp}
+}
+
+// Float64 returns the nearest float64, for metrics and display; never use it for money math
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// String formats d in plain notation, keeping its scale: NewDecimal(1250, -2) is "12.50"
+func (d Decimal) String() string {
+	coef := d.Coefficient()
+	if d.exp >= 0 {
+		return coef.Mul(coef, pow10(d.exp)).String()
+	}
+
+	digits := new(big.Int).Abs(coef).String()
+	places := int(-d.exp)
+	if len(digits) <= places {
+		digits = strings.Repeat("0", places-len(digits)+1) + digits
+	}
+	point := len(digits) - places
+
+	s := digits[:point] + "." + digits[point:]
+	if coef.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// MarshalJSON encodes d as a JSON number, keeping its scale
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a string holding one. null leaves d unchanged.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if s, err := strconv.Unquote(string(data)); err == nil {
+		data = []byte(s)
+	}
+	parsed, err := ParseDecimal(string(data))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// scaledTo returns the coefficient of d expressed at exp, which must not exceed d.exp
+func (d Decimal) scaledTo(exp int32) *big.Int {
+	coef := d.Coefficient()
+	if d.exp == exp {
+		return coef
+	}
+	return coef.Mul(coef, pow10(d.exp-exp))
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "12.50", want: "12.50"},
+		{input: "0.1", want: "0.1"},
+		{input: "-0.05", want: "-0.05"},
+		{input: "+7", want: "7"},
+		{input: ".5", want: "0.5"},
+		{input: "1e3", want: "1000"},
+		{input: "1.25E-1", want: "0.125"},
+		{input: "12345678901234.99", want: "12345678901234.99"},
+		{input: "", wantErr: true},
+		{input: "-", wantErr: true},
+		{input: "1.2.3", wantErr: true},
+		{input: "12abc", wantErr: true},
+		{input: "1e", wantErr: true},
+		{input: "NaN", wantErr: true},
+		{input: "1e32", want: "100000000000000000000000000000000"},
+		{input: "1e-32", want: "0.00000000000000000000000000000001"},
+		{input: "12345678901234567890123456789012345678", want: "12345678901234567890123456789012345678"},
+		{input: "0001.50", want: "1.50"},
+		{input: "1e33", wantErr: true},
+		{input: "1e-33", wantErr: true},
+		{input: "1e2000000000", wantErr: true},
+		{input: "1e50000000", wantErr: true},
+		{input: "123456789012345678901234567890123456789", wantErr: true},
+		{input: "0." + strings.Repeat("0", 40) + "1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDecimal(tt.input)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidDecimal) {
+					t.Fatalf("expected ErrInvalidDecimal, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got.String())
+			}
+		})
+	}
+}
+
+func TestDecimal_Arithmetic(t *testing.T) {
+	// 0.1 + 0.2 is the classic float failure
+	sum := MustParseDecimal("0.1").Add(MustParseDecimal("0.2"))
+	if sum.String() != "0.3" {
+		t.Errorf("expected 0.3, got %s", sum)
+	}
+	if sum.Cmp(MustParseDecimal("0.30")) != 0 {
+		t.Error("expected 0.3 and 0.30 to compare equal")
+	}
+	if MustParseDecimal("9.99").Cmp(NewDecimal(10, 0)) != -1 {
+		t.Error("expected 9.99 < 10")
+	}
+	if !(Decimal{}).IsZero() || (Decimal{}).String() != "0" {
+		t.Errorf("expected the zero value to be 0, got %s", Decimal{})
+	}
+	if NewDecimal(-125, -2).Sign() != -1 {
+		t.Error("expected -1.25 to be negative")
+	}
+}
+
+func TestDecimal_JSON(t *testing.T) {
+	var payload struct {
+		Amount Decimal `json:"amount"`
+	}
+	if err := json.Unmarshal([]byte(`{"amount": 19.90}`), &payload); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(out) != `{"amount":19.90}` {
+		t.Errorf("expected the scale to survive a round trip, got %s", out)
+	}
+
+	if err := json.Unmarshal([]byte(`{"amount": "0.07"}`), &payload); err != nil {
+		t.Fatalf("unexpected error for a quoted amount: %v", err)
+	}
+	if payload.Amount.String() != "0.07" {
+		t.Errorf("expected 0.07, got %s", payload.Amount)
+	}
+	if err := json.Unmarshal([]byte(`{"amount": "cheap"}`), &payload); !errors.Is(err, ErrInvalidDecimal) {
+		t.Errorf("expected ErrInvalidDecimal, got %v", err)
+	}
+	if err := json.Unmarshal([]byte(`{"amount": 1e2000000000}`), &payload); !errors.Is(err, ErrInvalidDecimal) {
+		t.Errorf("expected ErrInvalidDecimal for an out-of-range amount, got %v", err)
+	}
+}
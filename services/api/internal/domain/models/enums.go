@@ -18,10 +18,12 @@ const (
 type ActionStatusEnum string
 
 const (
-	ActionStatusEnumPending  ActionStatusEnum = "pending"
-	ActionStatusEnumApproved ActionStatusEnum = "approved"
-	ActionStatusEnumModified ActionStatusEnum = "modified"
-	ActionStatusEnumDenied   ActionStatusEnum = "denied"
+	ActionStatusEnumPending    ActionStatusEnum = "pending"
+	ActionStatusEnumApproved   ActionStatusEnum = "approved"
+	ActionStatusEnumModified   ActionStatusEnum = "modified"
+	ActionStatusEnumDenied     ActionStatusEnum = "denied"
+	ActionStatusEnumInProgress ActionStatusEnum = "in_progress"
+	ActionStatusEnumFailed     ActionStatusEnum = "failed"
 )
 
 type ActionTypeEnum string
@@ -34,12 +36,22 @@ const (
 	ActionTypeEnumOther        ActionTypeEnum = "other"
 )
 
+type EventSourceEnum string
+
+const (
+	EventSourceEnumWebhook EventSourceEnum = "webhook"
+	EventSourceEnumImport  EventSourceEnum = "import"
+	EventSourceEnumManual  EventSourceEnum = "manual"
+	EventSourceEnumApi     EventSourceEnum = "api"
+)
+
 type EventStatusEnum string
 
 const (
-	EventStatusEnumPending   EventStatusEnum = "pending"
-	EventStatusEnumProcessed EventStatusEnum = "processed"
-	EventStatusEnumFailed    EventStatusEnum = "failed"
+	EventStatusEnumPending    EventStatusEnum = "pending"
+	EventStatusEnumProcessed  EventStatusEnum = "processed"
+	EventStatusEnumFailed     EventStatusEnum = "failed"
+	EventStatusEnumProcessing EventStatusEnum = "processing"
 )
 
 type EventTypeEnum string
@@ -51,6 +63,14 @@ const (
 	EventTypeEnumPaymentUpdated   EventTypeEnum = "payment_updated"
 )
 
+type LeakStatusEnum string
+
+const (
+	LeakStatusEnumOpen     LeakStatusEnum = "open"
+	LeakStatusEnumResolved LeakStatusEnum = "resolved"
+	LeakStatusEnumIgnored  LeakStatusEnum = "ignored"
+)
+
 type LeakTypeEnum string
 
 const (
@@ -60,6 +80,19 @@ const (
 	LeakTypeEnumCouponDiscountMisuse LeakTypeEnum = "coupon_discount_misuse"
 	LeakTypeEnumTrialForever         LeakTypeEnum = "trial_forever"
 	LeakTypeEnumOther                LeakTypeEnum = "other"
+	LeakTypeEnumVolumeAnomaly        LeakTypeEnum = "volume_anomaly"
+	LeakTypeEnumDuplicateCharge      LeakTypeEnum = "duplicate_charge"
+	LeakTypeEnumDunningGap           LeakTypeEnum = "dunning_gap"
+	LeakTypeEnumCurrencyMismatch     LeakTypeEnum = "currency_mismatch"
+	LeakTypeEnumFailureRateSpike     LeakTypeEnum = "failure_rate_spike"
+)
+
+type NotificationChannelTypeEnum string
+
+const (
+	NotificationChannelTypeEnumSlack   NotificationChannelTypeEnum = "slack"
+	NotificationChannelTypeEnumEmail   NotificationChannelTypeEnum = "email"
+	NotificationChannelTypeEnumWebhook NotificationChannelTypeEnum = "webhook"
 )
 
 type PaymentStatusEnum string
@@ -5,6 +5,9 @@ package models
 
 import (
 	"encoding/json"
+	"errors"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -22,6 +25,7 @@ import (
 //   - Data: Flexible field containing event-specific payload data
 //   - CreatedAt: Timestamp when the event was first created
 //   - UpdatedAt: Timestamp when the event was last modified
+//   - Source: How the event entered the system (see EventSourceEnum)
 type Event struct {
 	ID         uuid.UUID        `json:"id"`
 	TenantID   uuid.UUID        `json:"tenant_id"`
@@ -32,6 +36,7 @@ type Event struct {
 	Data       *json.RawMessage `json:"data"`
 	CreatedAt  time.Time        `json:"created_at"`
 	UpdatedAt  time.Time        `json:"updated_at"`
+	Source     EventSourceEnum  `json:"source"`
 }
 
 // CreateEventParams represents parameters for creating a new Event.
@@ -46,6 +51,9 @@ type Event struct {
 //   - EventID: Business identifier, should be unique within the provider context
 //   - Status: Initial status, typically EventStatusPending or EventStatusReceived
 //   - Data: Event payload, can be any JSON-serializable data structure
+//
+// Source is set by the ingestion path that received the event; left empty, the event is
+// stored as EventSourceEnumManual.
 type CreateEventParams struct {
 	TenantID   uuid.UUID       `json:"tenant_id"`
 	ProviderID uuid.UUID       `json:"provider_id"`
@@ -53,6 +61,117 @@ type CreateEventParams struct {
 	EventID    string          `json:"event_id"`
 	Status     EventStatusEnum `json:"status"`
 	Data       any             `json:"data"`
+	Source     EventSourceEnum `json:"source"`
+}
+
+var (
+	ErrMissingTenantID   = errors.New("tenant id is required")
+	ErrMissingProviderID = errors.New("provider id is required")
+	ErrInvalidEventType  = errors.New("event type is not recognised")
+	ErrMissingExternalID = errors.New("external event id is required")
+	ErrMissingEventData  = errors.New("event data is required")
+	ErrEventTooOld       = errors.New("event is older than the maximum accepted age")
+	ErrInvalidEventData  = errors.New("event data must be a JSON object")
+)
+
+// CheckEventAge returns ErrEventTooOld when occurredAt, the provider's own timestamp for the
+// event, is more than maxAge before now. A zero occurredAt means the provider sent no
+// timestamp, and a zero maxAge disables the check; both are accepted.
+func CheckEventAge(occurredAt time.Time, maxAge time.Duration, now time.Time) error {
+	if occurredAt.IsZero() || maxAge <= 0 {
+		return nil
+	}
+	if now.Sub(occurredAt) > maxAge {
+		return ErrEventTooOld
+	}
+	return nil
+}
+
+// ProviderEventTime returns the provider's own timestamp for an event, read from the "created"
+// field of its data as Unix seconds, the way Stripe sends it. It returns false when the data
+// has no such field or it is not a positive number.
+func ProviderEventTime(data *json.RawMessage) (time.Time, bool) {
+	if data == nil {
+		return time.Time{}, false
+	}
+	var envelope struct {
+		Created float64 `json:"created"`
+	}
+	if err := json.Unmarshal(*data, &envelope); err != nil {
+		return time.Time{}, false
+	}
+	// Past the year 2262 the time no longer fits in nanoseconds
+	if envelope.Created <= 0 || envelope.Created > math.MaxInt64/float64(time.Second) {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(envelope.Created*float64(time.Second))), true
+}
+
+// NewCreateEventParams builds the parameters for storing a freshly received webhook event.
+// The event starts out pending so the detection pipeline picks it up. externalID is the
+// provider's own identifier for the event and is used for idempotency, so it must be set.
+func NewCreateEventParams(tenantID, providerID uuid.UUID, eventType EventTypeEnum, externalID string, data any) (CreateEventParams, error) {
+	if tenantID == uuid.Nil {
+		return CreateEventParams{}, ErrMissingTenantID
+	}
+	if providerID == uuid.Nil {
+		return CreateEventParams{}, ErrMissingProviderID
+	}
+	switch eventType {
+	case EventTypeEnumPaymentFailed, EventTypeEnumPaymentSucceeded, EventTypeEnumPaymentRefunded, EventTypeEnumPaymentUpdated:
+	default:
+		return CreateEventParams{}, ErrInvalidEventType
+	}
+	if strings.TrimSpace(externalID) == "" {
+		return CreateEventParams{}, ErrMissingExternalID
+	}
+	if isNilData(data) {
+		return CreateEventParams{}, ErrMissingEventData
+	}
+
+	return CreateEventParams{
+		TenantID:   tenantID,
+		ProviderID: providerID,
+		EventType:  eventType,
+		EventID:    externalID,
+		Status:     EventStatusEnumPending,
+		Data:       data,
+	}, nil
+}
+
+// ValidateStoredEvent re-runs the ingestion checks of NewCreateEventParams against an event
+// that is already stored, and also requires its data to be a JSON object. It is used to
+// decide whether a failed event can be reprocessed.
+func ValidateStoredEvent(event Event) error {
+	var data any
+	if event.Data != nil {
+		data = event.Data
+	}
+	if _, err := NewCreateEventParams(event.TenantID, event.ProviderID, event.EventType, event.EventID, data); err != nil {
+		return err
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(*event.Data, &object); err != nil || object == nil {
+		return ErrInvalidEventData
+	}
+	return nil
+}
+
+// isNilData reports whether data carries no payload, including typed nils such as an
+// empty byte slice that would otherwise slip past a plain nil check.
+func isNilData(data any) bool {
+	switch d := data.(type) {
+	case nil:
+		return true
+	case []byte:
+		return len(d) == 0
+	case json.RawMessage:
+		return len(d) == 0
+	case *json.RawMessage:
+		return d == nil || len(*d) == 0
+	}
+	return false
 }
 
 // UpdateEventParams represents parameters for updating an existing Event.
@@ -68,11 +187,200 @@ type CreateEventParams struct {
 //   - EventID: Optional - update the business identifier (use with caution)
 //   - Status: Optional - change the processing status (most common update)
 //   - Data: Optional - replace or update the event payload (use with caution)
+//   - DataMode: How Data is applied; the zero value replaces the stored payload
 //
 // Note: UpdatedAt timestamp is handled automatically by the persistence layer.
 type UpdateEventParams struct {
 	EventType *EventTypeEnum   `json:"event_type"`
 	Status    *EventStatusEnum `json:"status"`
 	Data      *json.RawMessage `json:"data"`
+	DataMode  DataMode         `json:"data_mode"`
 	ID        uuid.UUID        `json:"id"`
 }
+
+// DataMode selects how an update's Data is applied to the stored payload
+type DataMode string
+
+const (
+	// DataModeReplace overwrites the stored payload with Data
+	DataModeReplace DataMode = "replace"
+	// DataModeMerge adds Data's top-level keys to the stored payload, overwriting keys present
+	// in both. Nested objects are replaced, not merged. When either side is not a JSON object
+	// the update falls back to replace.
+	DataModeMerge DataMode = "merge"
+)
+
+// IsValid reports whether m is a known data mode; the empty mode counts as replace
+func (m DataMode) IsValid() bool {
+	return m == "" || m == DataModeReplace || m == DataModeMerge
+}
+
+// CorrelationRule decides which events are related to each other, for example a failed
+// Stripe charge and the matching bank reversal from another provider.
+//
+// Two events are related when their Data holds equal values for every key in Keys and
+// they were created no more than Window apart. The keys are looked up at the top level of
+// Data and under data.object, where a Stripe event envelope holds its object. A source event
+// missing any of the keys has no related events.
+type CorrelationRule struct {
+	Keys   []string      `json:"keys"`
+	Window time.Duration `json:"window"`
+}
+
+// EventFilter narrows an event listing. Within a field the values are ORed, for example
+// payment_failed OR payment_refunded; the fields are ANDed together. An empty field
+// matches every value.
+type EventFilter struct {
+	EventTypes  []EventTypeEnum   `json:"event_types"`
+	Statuses    []EventStatusEnum `json:"statuses"`
+	ProviderIDs []uuid.UUID       `json:"provider_ids"`
+}
+
+// IsEmpty reports whether the filter has no predicates and so matches every event
+func (f EventFilter) IsEmpty() bool {
+	return len(f.EventTypes) == 0 && len(f.Statuses) == 0 && len(f.ProviderIDs) == 0
+}
+
+// EventCursor is a position in newest-first creation order, the event an export page continues
+// after. The zero EventCursor comes before every event.
+type EventCursor struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        uuid.UUID `json:"id"`
+}
+
+// BatchPolicy bounds how many events a batch insert stores in one transaction.
+// A batch larger than MaxSize is refused unless Chunk is set, in which case it is stored
+// in consecutive transactions of at most MaxSize events each. Chunks commit independently:
+// if one fails, the chunks before it stay stored. A MaxSize of 0 means no limit.
+type BatchPolicy struct {
+	MaxSize int  `json:"max_size"`
+	Chunk   bool `json:"chunk"`
+}
+
+// BatchEventResult is the outcome of one item of a batch insert: the stored event, or the
+// error that kept it from being stored
+type BatchEventResult struct {
+	Event Event
+	Err   error
+}
+
+// ReprocessResult reports one bounded pass over a tenant's failed events.
+// Reprocessed events passed validation and are now processed; StillFailed ones did not
+// pass, or changed while being reprocessed, and keep the failed status. NextCursor is the
+// ID of the last event looked at; pass it as the cursor of the next call to continue.
+// Done is set when the pass reached the end of the failed events.
+type ReprocessResult struct {
+	Scanned     int       `json:"scanned"`
+	Reprocessed int       `json:"reprocessed"`
+	StillFailed int       `json:"still_failed"`
+	NextCursor  uuid.UUID `json:"next_cursor"`
+	Done        bool      `json:"done"`
+}
+
+// ValidateStoredEventsParams narrows a re-validation of stored events. Filter selects the
+// events to check; MaxEvents caps how many are checked, 0 meaning all of them.
+type ValidateStoredEventsParams struct {
+	Filter    EventFilter `json:"filter"`
+	MaxEvents int         `json:"max_events"`
+}
+
+// InvalidStoredEvent is a stored event that current validation rejects, and why
+type InvalidStoredEvent struct {
+	ID     uuid.UUID `json:"id"`
+	Reason string    `json:"reason"`
+}
+
+// StoredEventsValidation reports a re-validation of stored events. Truncated is set when
+// MaxEvents stopped the pass before every matching event was checked.
+type StoredEventsValidation struct {
+	Scanned   int                  `json:"scanned"`
+	Invalid   []InvalidStoredEvent `json:"invalid"`
+	Truncated bool                 `json:"truncated"`
+}
+
+// EventRetentionPolicy is how long one tenant's events are kept. RetentionDays is the
+// tenant's own override; nil means the global retention period applies.
+type EventRetentionPolicy struct {
+	TenantID      uuid.UUID `json:"tenant_id"`
+	RetentionDays *int32    `json:"retention_days,omitempty"`
+}
+
+// Retention returns the period after which the tenant's events are purged: its own override
+// when set, otherwise defaultRetention. 0 means the events are kept forever.
+func (p EventRetentionPolicy) Retention(defaultRetention time.Duration) time.Duration {
+	if p.RetentionDays != nil && *p.RetentionDays > 0 {
+		return time.Duration(*p.RetentionDays) * 24 * time.Hour
+	}
+	return defaultRetention
+}
+
+// DuplicateCharge is a payment_succeeded event that repeats the one before it: the same
+// customer, amount and currency in its data, shortly after it.
+type DuplicateCharge struct {
+	EventID         uuid.UUID `json:"event_id"`
+	OriginalEventID uuid.UUID `json:"original_event_id"`
+	// CustomerRef is the customer_id the provider sent in the event data
+	CustomerRef string `json:"customer_ref"`
+	// CustomerID is the tenant's customer with CustomerRef as its external ID, or uuid.Nil when
+	// the customer is not known
+	CustomerID        uuid.UUID `json:"customer_id"`
+	Amount            Decimal   `json:"amount"`
+	Currency          string    `json:"currency"`
+	ChargedAt         time.Time `json:"charged_at"`
+	OriginalChargedAt time.Time `json:"original_charged_at"`
+}
+
+// EventStatusChange is one change of an event's status, as recorded in its status history
+type EventStatusChange struct {
+	FromStatus EventStatusEnum `json:"from_status"`
+	ToStatus   EventStatusEnum `json:"to_status"`
+	ChangedAt  time.Time       `json:"changed_at"`
+}
+
+// DunningGap is a payment_failed event that no retry or payment update for the same customer
+// followed within the dunning window, so dunning never ran for it.
+type DunningGap struct {
+	EventID uuid.UUID `json:"event_id"`
+	// CustomerRef is the customer_id the provider sent in the event data
+	CustomerRef string `json:"customer_ref"`
+	// SubscriptionRef is the subscription_id the provider sent in the event data, empty when it
+	// sent none
+	SubscriptionRef string `json:"subscription_ref"`
+	// CustomerID is the tenant's customer with CustomerRef as its external ID, or uuid.Nil when
+	// the customer is not known
+	CustomerID uuid.UUID `json:"customer_id"`
+	// Amount is the failed payment's amount, zero when the event data has no numeric amount
+	Amount   Decimal   `json:"amount"`
+	Currency string    `json:"currency"`
+	FailedAt time.Time `json:"failed_at"`
+	// Window is the dunning window applied, the tenant's own or the default
+	Window time.Duration `json:"window"`
+}
+
+// IngestionLag summarizes, for one provider type, how long after the provider's own timestamp
+// a tenant's events were ingested
+type IngestionLag struct {
+	ProviderType string `json:"provider_type"`
+	Events       int64  `json:"events"`
+	// MissingTimestamp is how many of the events carried no provider timestamp
+	MissingTimestamp int64 `json:"missing_timestamp"`
+	// P95 is the 95th percentile lag of the events with a timestamp, nil when none had one
+	P95 *time.Duration `json:"p95"`
+}
+
+// ProviderFailureRate counts, for one provider, a tenant's payment attempts in a window and how
+// many of them failed. An attempt is a payment_failed or payment_succeeded event.
+type ProviderFailureRate struct {
+	ProviderID   uuid.UUID `json:"provider_id"`
+	ProviderName string    `json:"provider_name"`
+	Failed       int64     `json:"failed"`
+	Total        int64     `json:"total"`
+}
+
+// Rate returns the share of attempts that failed, 0 when there were none
+func (r ProviderFailureRate) Rate() float64 {
+	if r.Total == 0 {
+		return 0
+	}
+	return float64(r.Failed) / float64(r.Total)
+}
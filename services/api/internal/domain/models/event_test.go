@@ -0,0 +1,168 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestNewCreateEventParams(t *testing.T) {
+	tenantID := uuid.New()
+	providerID := uuid.New()
+	data := []byte(`{"amount":100}`)
+
+	tests := []struct {
+		name       string
+		tenantID   uuid.UUID
+		providerID uuid.UUID
+		eventType  EventTypeEnum
+		externalID string
+		data       any
+		wantErr    error
+	}{
+		{"valid", tenantID, providerID, EventTypeEnumPaymentFailed, "evt_1", data, nil},
+		{"nil tenant", uuid.Nil, providerID, EventTypeEnumPaymentFailed, "evt_1", data, ErrMissingTenantID},
+		{"nil provider", tenantID, uuid.Nil, EventTypeEnumPaymentFailed, "evt_1", data, ErrMissingProviderID},
+		{"unknown event type", tenantID, providerID, EventTypeEnum("chargeback"), "evt_1", data, ErrInvalidEventType},
+		{"empty external id", tenantID, providerID, EventTypeEnumPaymentFailed, "", data, ErrMissingExternalID},
+		{"blank external id", tenantID, providerID, EventTypeEnumPaymentFailed, "   ", data, ErrMissingExternalID},
+		{"nil data", tenantID, providerID, EventTypeEnumPaymentFailed, "evt_1", nil, ErrMissingEventData},
+		{"empty byte data", tenantID, providerID, EventTypeEnumPaymentFailed, "evt_1", []byte{}, ErrMissingEventData},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := NewCreateEventParams(tt.tenantID, tt.providerID, tt.eventType, tt.externalID, tt.data)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NewCreateEventParams() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if params.Status != EventStatusEnumPending {
+				t.Errorf("Status = %q, want %q", params.Status, EventStatusEnumPending)
+			}
+			if params.TenantID != tt.tenantID || params.ProviderID != tt.providerID {
+				t.Errorf("IDs = %v/%v, want %v/%v", params.TenantID, params.ProviderID, tt.tenantID, tt.providerID)
+			}
+			if params.EventType != tt.eventType || params.EventID != tt.externalID {
+				t.Errorf("EventType/EventID = %q/%q, want %q/%q", params.EventType, params.EventID, tt.eventType, tt.externalID)
+			}
+		})
+	}
+}
+
+func TestCheckEventAge(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	maxAge := 7 * 24 * time.Hour
+
+	tests := []struct {
+		name       string
+		occurredAt time.Time
+		maxAge     time.Duration
+		wantErr    error
+	}{
+		{"fresh", now.Add(-time.Hour), maxAge, nil},
+		{"stale", now.Add(-8 * 24 * time.Hour), maxAge, ErrEventTooOld},
+		{"no timestamp", time.Time{}, maxAge, nil},
+		{"check disabled", now.Add(-365 * 24 * time.Hour), 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckEventAge(tt.occurredAt, tt.maxAge, now); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CheckEventAge() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProviderEventTime(t *testing.T) {
+	rawData := func(s string) *json.RawMessage {
+		raw := json.RawMessage(s)
+		return &raw
+	}
+
+	tests := []struct {
+		name   string
+		data   *json.RawMessage
+		want   time.Time
+		wantOK bool
+	}{
+		{"unix seconds", rawData(`{"id":"evt_1","created":1717243200}`), time.Unix(1717243200, 0), true},
+		{"fractional seconds", rawData(`{"created":1717243200.5}`), time.Unix(1717243200, 5e8), true},
+		{"missing", rawData(`{"amount":100}`), time.Time{}, false},
+		{"zero", rawData(`{"created":0}`), time.Time{}, false},
+		{"not a number", rawData(`{"created":"yesterday"}`), time.Time{}, false},
+		{"not an object", rawData(`[1717243200]`), time.Time{}, false},
+		{"no data", nil, time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ProviderEventTime(tt.data)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("ProviderEventTime() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestValidateStoredEvent(t *testing.T) {
+	rawData := func(s string) *json.RawMessage {
+		raw := json.RawMessage(s)
+		return &raw
+	}
+	valid := Event{TenantID: uuid.New(), ProviderID: uuid.New(), EventType: EventTypeEnumPaymentFailed, EventID: "evt_1", Data: rawData(`{"amount":100}`)}
+
+	tests := []struct {
+		name    string
+		mutate  func(e *Event)
+		wantErr error
+	}{
+		{"valid", func(*Event) {}, nil},
+		{"unknown event type", func(e *Event) { e.EventType = "chargeback" }, ErrInvalidEventType},
+		{"blank external id", func(e *Event) { e.EventID = " " }, ErrMissingExternalID},
+		{"no data", func(e *Event) { e.Data = nil }, ErrMissingEventData},
+		{"null data", func(e *Event) { e.Data = rawData(`null`) }, ErrInvalidEventData},
+		{"array data", func(e *Event) { e.Data = rawData(`[{"amount":100}]`) }, ErrInvalidEventData},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := valid
+			tt.mutate(&event)
+			if err := ValidateStoredEvent(event); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateStoredEvent() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEventRetentionPolicy_Retention(t *testing.T) {
+	days := int32(7)
+	defaultRetention := 30 * 24 * time.Hour
+
+	tests := []struct {
+		name             string
+		policy           EventRetentionPolicy
+		defaultRetention time.Duration
+		want             time.Duration
+	}{
+		{"override", EventRetentionPolicy{RetentionDays: &days}, defaultRetention, 7 * 24 * time.Hour},
+		{"default", EventRetentionPolicy{}, defaultRetention, defaultRetention},
+		{"override with default disabled", EventRetentionPolicy{RetentionDays: &days}, 0, 7 * 24 * time.Hour},
+		{"keep forever", EventRetentionPolicy{}, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Retention(tt.defaultRetention); got != tt.want {
+				t.Errorf("Retention() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyRecord is the response to a request sent with an Idempotency-Key header, replayed
+// when the tenant sends the same key again until ExpiresAt
+type IdempotencyRecord struct {
+	TenantID uuid.UUID `json:"tenant_id"`
+	Key      string    `json:"key"`
+	// Method and Path are the request the key was first used for; the key may not be reused for another
+	Method      string    `json:"method"`
+	Path        string    `json:"path"`
+	StatusCode  int       `json:"status_code"`
+	ContentType string    `json:"content_type"`
+	Body        []byte    `json:"body"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	// RequestHash is the hex SHA-256 of the request body the key was first used with; the key
+	// may not be reused for another body. It is empty for records stored before it was kept.
+	RequestHash string `json:"request_hash"`
+	// Headers are the response headers replayed with Body, without hop-by-hop headers. They are
+	// nil for records stored before they were kept, which replay ContentType alone.
+	Headers map[string][]string `json:"headers"`
+}
@@ -5,50 +5,164 @@
 package models
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// DefaultLeakCurrency is the ISO 4217 code stored for a leak created without a currency
+const DefaultLeakCurrency = "USD"
+
 // Leak represents the domain model for Leak
 type Leak struct {
-	ID         uuid.UUID    `json:"id"`
-	TenantID   uuid.UUID    `json:"tenant_id"`
-	CustomerID uuid.UUID    `json:"customer_id"`
-	LeakType   LeakTypeEnum `json:"leak_type"`
-	Amount     float32      `json:"amount"`
-	Confidence int32        `json:"confidence"`
-	CreatedAt  time.Time    `json:"created_at"`
-	UpdatedAt  time.Time    `json:"updated_at"`
+	ID            uuid.UUID       `json:"id"`
+	TenantID      uuid.UUID       `json:"tenant_id"`
+	CustomerID    uuid.UUID       `json:"customer_id"`
+	LeakType      LeakTypeEnum    `json:"leak_type"`
+	Status        LeakStatusEnum  `json:"status"`
+	Amount        Decimal         `json:"amount"`
+	Currency      string          `json:"currency"`
+	Confidence    int32           `json:"confidence"`
+	SourceEventID *uuid.UUID      `json:"source_event_id"` // nil when no single event triggered the leak
+	Metadata      json.RawMessage `json:"metadata"`
+	DetectedAt    time.Time       `json:"detected_at"`
+	ResolvedAt    *time.Time      `json:"resolved_at"`   // nil until the leak is resolved
+	SnoozedUntil  *time.Time      `json:"snoozed_until"` // nil unless the leak has been snoozed; may be in the past
+	DedupKey      *string         `json:"dedup_key"`     // nil unless repeated detections of the leak's root cause update it
+	Occurrences   int32           `json:"occurrences"`   // how many detections the leak stands for, at least 1
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
 }
 
-// CreateLeakParams represents parameters for creating a Leak
+// CreateLeakParams represents parameters for creating a Leak.
+// An empty Status, Currency or Metadata and a zero DetectedAt default to open, DefaultLeakCurrency,
+// an empty object and the time of storage. DedupKey identifies the leak's root cause, such as its
+// type, customer and subscription; empty means the leak has none and is never deduplicated.
 type CreateLeakParams struct {
-	TenantID   uuid.UUID    `json:"tenant_id"`
-	CustomerID uuid.UUID    `json:"customer_id"`
-	LeakType   LeakTypeEnum `json:"leak_type"`
-	Amount     float32      `json:"amount"`
-	Confidence int32        `json:"confidence"`
+	TenantID      uuid.UUID       `json:"tenant_id"`
+	CustomerID    uuid.UUID       `json:"customer_id"`
+	LeakType      LeakTypeEnum    `json:"leak_type"`
+	Status        LeakStatusEnum  `json:"status"`
+	Amount        Decimal         `json:"amount"`
+	Currency      string          `json:"currency"`
+	Confidence    int32           `json:"confidence"`
+	SourceEventID *uuid.UUID      `json:"source_event_id"`
+	DetectedAt    time.Time       `json:"detected_at"`
+	Metadata      json.RawMessage `json:"metadata"`
+	DedupKey      string          `json:"dedup_key"`
 }
 
-// UpdateLeakParams represents parameters for updating a Leak
+// UpdateLeakParams represents parameters for updating a Leak; nil fields are left unchanged.
+// A status other than resolved clears ResolvedAt, and resolving without a ResolvedAt stamps the current time.
 type UpdateLeakParams struct {
-	ID         uuid.UUID     `json:"id"` // Primary key
-	TenantID   *uuid.UUID    `json:"tenant_id"`
-	CustomerID *uuid.UUID    `json:"customer_id"`
-	LeakType   *LeakTypeEnum `json:"leak_type"`
-	Amount     *float32      `json:"amount"`
-	Confidence *int32        `json:"confidence"`
+	ID         uuid.UUID       `json:"id"` // Primary key
+	LeakType   *LeakTypeEnum   `json:"leak_type"`
+	Status     *LeakStatusEnum `json:"status"`
+	Amount     *Decimal        `json:"amount"`
+	Currency   *string         `json:"currency"`
+	Confidence *int32          `json:"confidence"`
+	ResolvedAt *time.Time      `json:"resolved_at"`
+	Metadata   json.RawMessage `json:"metadata"`
+}
+
+// LeakFilter narrows a leak listing. Values within a field are ORed and fields are ANDed; an
+// empty field matches everything. DetectedFrom is inclusive and DetectedTo exclusive.
+// ExcludeSnoozed leaves out leaks whose snooze has not yet expired.
+type LeakFilter struct {
+	Statuses       []LeakStatusEnum `json:"statuses"`
+	LeakTypes      []LeakTypeEnum   `json:"leak_types"`
+	DetectedFrom   *time.Time       `json:"detected_from"`
+	DetectedTo     *time.Time       `json:"detected_to"`
+	ExcludeSnoozed bool             `json:"exclude_snoozed"`
+}
+
+// LeakCursor is a position in detection order, the leak a keyset page continues after. The
+// zero LeakCursor comes before every leak.
+type LeakCursor struct {
+	DetectedAt time.Time `json:"detected_at"`
+	ID         uuid.UUID `json:"id"`
 }
 
 var (
-	ErrInvalidAmount     = errors.New("amount must be greater than 0")
-	ErrInvalidConfidence = errors.New("confidence must be between 0 and 100")
+	ErrInvalidAmount        = errors.New("amount must be greater than 0")
+	ErrInvalidConfidence    = errors.New("confidence must be between 0 and 100")
+	ErrInvalidMinLeakAmount = errors.New("minimum leak amount must be a 3-letter currency code with an amount of 0 or more")
+	ErrInvalidLeakSources   = errors.New("leak sources must map leak types to lists of event types")
+	ErrInvalidBucketBounds  = errors.New("bucket bounds must be greater than 0 and strictly ascending")
+
+	// ErrLeakAlreadyExists is returned when a leak is stored for a source event that already has a
+	// leak of the same type
+	ErrLeakAlreadyExists = errors.New("source event already has a leak of this type")
+
+	// Errors of leak time series
+	ErrInvalidTimeSeriesInterval = errors.New("time series interval must be hour, day, week or month")
+	ErrInvalidTimeSeriesRange    = errors.New("time series range must start before it ends and span at most 1000 buckets")
 )
 
+// NormalizeMinLeakAmounts validates minimum leak amounts keyed by currency and returns them with
+// upper-case currency codes. Amounts are per currency because their magnitudes differ: 1.00 USD
+// is worth about 150 JPY.
+func NormalizeMinLeakAmounts(amounts map[string]Decimal) (map[string]Decimal, error) {
+	normalized := make(map[string]Decimal, len(amounts))
+	for currency, amount := range amounts {
+		code := strings.ToUpper(strings.TrimSpace(currency))
+		if len(code) != 3 || strings.Trim(code, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+			return nil, fmt.Errorf("%w: currency %q", ErrInvalidMinLeakAmount, currency)
+		}
+		if amount.Sign() < 0 {
+			return nil, fmt.Errorf("%w: %s %s", ErrInvalidMinLeakAmount, code, amount)
+		}
+		normalized[code] = amount
+	}
+	return normalized, nil
+}
+
+// LeakSources declares which event types feed each leak type, so a leak category can be turned
+// on or off, or narrowed, without code changes. A leak type mapped to no event types is
+// disabled; a leak type missing from the map is not restricted.
+type LeakSources map[LeakTypeEnum][]EventTypeEnum
+
+// Override returns a copy of s in which the leak types listed in overrides have their event
+// types replaced by the overriding ones
+func (s LeakSources) Override(overrides LeakSources) LeakSources {
+	merged := make(LeakSources, len(s)+len(overrides))
+	for leakType, eventTypes := range s {
+		merged[leakType] = eventTypes
+	}
+	for leakType, eventTypes := range overrides {
+		merged[leakType] = eventTypes
+	}
+	return merged
+}
+
+// Feeds reports whether any of eventTypes feeds leakType. It is always true for a leak type
+// the sources don't list.
+func (s LeakSources) Feeds(leakType LeakTypeEnum, eventTypes []EventTypeEnum) bool {
+	sources, ok := s[leakType]
+	if !ok {
+		return true
+	}
+	for _, eventType := range eventTypes {
+		if slices.Contains(sources, eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSnoozed reports whether the leak is snoozed at now. A snooze ends at SnoozedUntil, after
+// which the leak counts as active again without being updated.
+func (l *Leak) IsSnoozed(now time.Time) bool {
+	return l.SnoozedUntil != nil && l.SnoozedUntil.After(now)
+}
+
 func (l *Leak) Validate() error {
-	if l.Amount <= 0 {
+	if l.Amount.Sign() <= 0 {
 		return ErrInvalidAmount
 	}
 	if l.Confidence < 0 || l.Confidence > 100 {
@@ -56,3 +170,81 @@ func (l *Leak) Validate() error {
 	}
 	return nil
 }
+
+// DefaultLeakAmountBucketBounds are the bucket bounds of a leak amount histogram asked for
+// without any: one bucket per order of magnitude from 10 to 10,000
+var DefaultLeakAmountBucketBounds = []Decimal{NewDecimal(10, 0), NewDecimal(100, 0), NewDecimal(1000, 0), NewDecimal(10000, 0)}
+
+// LeakAmountBucket is one bucket of a histogram of open leak amounts in one currency: the
+// leaks whose amount is at least Lower and below Upper. Lower is nil for the first bucket and
+// Upper for the last, so n bounds make n+1 buckets.
+type LeakAmountBucket struct {
+	Currency string   `json:"currency"`
+	Lower    *Decimal `json:"lower"`
+	Upper    *Decimal `json:"upper"`
+	Count    int64    `json:"count"`
+	Total    Decimal  `json:"total"`
+}
+
+// ValidateBucketBounds returns ErrInvalidBucketBounds unless every bound is greater than 0
+// and greater than the one before it
+func ValidateBucketBounds(bounds []Decimal) error {
+	for i, bound := range bounds {
+		if bound.Sign() <= 0 {
+			return fmt.Errorf("%w: %s", ErrInvalidBucketBounds, bound)
+		}
+		if i > 0 && bound.Cmp(bounds[i-1]) <= 0 {
+			return fmt.Errorf("%w: %s after %s", ErrInvalidBucketBounds, bound, bounds[i-1])
+		}
+	}
+	return nil
+}
+
+// LeakTimeSeriesInterval is the width of the buckets of a leak time series, a date_trunc field
+type LeakTimeSeriesInterval string
+
+const (
+	LeakTimeSeriesHour  LeakTimeSeriesInterval = "hour"
+	LeakTimeSeriesDay   LeakTimeSeriesInterval = "day"
+	LeakTimeSeriesWeek  LeakTimeSeriesInterval = "week"
+	LeakTimeSeriesMonth LeakTimeSeriesInterval = "month"
+)
+
+// MaxLeakTimeSeriesBuckets caps how many buckets one leak time series may span
+const MaxLeakTimeSeriesBuckets = 1000
+
+// leakTimeSeriesMinWidths is the shortest each interval can be, which bounds how many buckets
+// a range spans; a month is at least 28 days
+var leakTimeSeriesMinWidths = map[LeakTimeSeriesInterval]time.Duration{
+	LeakTimeSeriesHour:  time.Hour,
+	LeakTimeSeriesDay:   24 * time.Hour,
+	LeakTimeSeriesWeek:  7 * 24 * time.Hour,
+	LeakTimeSeriesMonth: 28 * 24 * time.Hour,
+}
+
+// LeakTimeSeriesPoint is one bucket of a leak time series: the leaks detected from Start until
+// the next bucket starts, and their amounts summed by currency. Totals is empty when there
+// were none.
+type LeakTimeSeriesPoint struct {
+	Start  time.Time          `json:"start"`
+	Count  int64              `json:"count"`
+	Totals map[string]Decimal `json:"totals"`
+}
+
+// ValidateLeakTimeSeries returns ErrInvalidTimeSeriesInterval unless interval is one of the
+// LeakTimeSeries intervals, and ErrInvalidTimeSeriesRange unless from is before to and the range
+// spans at most MaxLeakTimeSeriesBuckets buckets
+func ValidateLeakTimeSeries(interval LeakTimeSeriesInterval, from time.Time, to time.Time) error {
+	width, ok := leakTimeSeriesMinWidths[interval]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrInvalidTimeSeriesInterval, interval)
+	}
+	if !from.Before(to) {
+		return fmt.Errorf("%w: from must be before to", ErrInvalidTimeSeriesRange)
+	}
+	// A partial bucket at each end can add up to two to the whole ones the range holds
+	if to.Sub(from)/width+2 > MaxLeakTimeSeriesBuckets {
+		return fmt.Errorf("%w: %s is too long for %s buckets", ErrInvalidTimeSeriesRange, to.Sub(from), interval)
+	}
+	return nil
+}
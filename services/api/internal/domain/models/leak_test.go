@@ -1,7 +1,9 @@
 package models
 
 import (
+	"errors"
 	"testing"
+	"time"
 )
 
 func TestLeak_Validate(t *testing.T) {
@@ -13,7 +15,7 @@ func TestLeak_Validate(t *testing.T) {
 		{
 			name: "valid leak",
 			leak: Leak{
-				Amount:     100,
+				Amount:     NewDecimal(100, 0),
 				Confidence: 50,
 			},
 			wantErr: nil,
@@ -21,7 +23,7 @@ func TestLeak_Validate(t *testing.T) {
 		{
 			name: "invalid amount (zero)",
 			leak: Leak{
-				Amount:     0,
+				Amount:     Decimal{},
 				Confidence: 50,
 			},
 			wantErr: ErrInvalidAmount,
@@ -29,7 +31,7 @@ func TestLeak_Validate(t *testing.T) {
 		{
 			name: "invalid amount (negative)",
 			leak: Leak{
-				Amount:     -10,
+				Amount:     NewDecimal(-10, 0),
 				Confidence: 50,
 			},
 			wantErr: ErrInvalidAmount,
@@ -37,7 +39,7 @@ func TestLeak_Validate(t *testing.T) {
 		{
 			name: "invalid confidence (negative)",
 			leak: Leak{
-				Amount:     100,
+				Amount:     NewDecimal(100, 0),
 				Confidence: -1,
 			},
 			wantErr: ErrInvalidConfidence,
@@ -45,7 +47,7 @@ func TestLeak_Validate(t *testing.T) {
 		{
 			name: "invalid confidence (over 100)",
 			leak: Leak{
-				Amount:     100,
+				Amount:     NewDecimal(100, 0),
 				Confidence: 101,
 			},
 			wantErr: ErrInvalidConfidence,
@@ -61,3 +63,76 @@ func TestLeak_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestLeak_IsSnoozed(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	later, earlier := now.Add(time.Hour), now.Add(-time.Hour)
+
+	if (&Leak{}).IsSnoozed(now) {
+		t.Error("a leak that was never snoozed should not be snoozed")
+	}
+	if !(&Leak{SnoozedUntil: &later}).IsSnoozed(now) {
+		t.Error("a leak snoozed until later should be snoozed")
+	}
+	if (&Leak{SnoozedUntil: &earlier}).IsSnoozed(now) {
+		t.Error("an expired snooze should not hide the leak")
+	}
+	if (&Leak{SnoozedUntil: &now}).IsSnoozed(now) {
+		t.Error("a snooze ends at snoozed_until")
+	}
+}
+
+func TestLeakSources(t *testing.T) {
+	defaults := LeakSources{
+		LeakTypeEnumDuplicateCharge: {EventTypeEnumPaymentSucceeded},
+		LeakTypeEnumVolumeAnomaly:   {EventTypeEnumPaymentFailed, EventTypeEnumPaymentSucceeded},
+	}
+	sources := defaults.Override(LeakSources{LeakTypeEnumVolumeAnomaly: {}})
+
+	if !sources.Feeds(LeakTypeEnumDuplicateCharge, []EventTypeEnum{EventTypeEnumPaymentSucceeded}) {
+		t.Error("a leak type the overrides don't list should keep its default sources")
+	}
+	if sources.Feeds(LeakTypeEnumVolumeAnomaly, []EventTypeEnum{EventTypeEnumPaymentFailed, EventTypeEnumPaymentSucceeded}) {
+		t.Error("a leak type mapped to no event types should be disabled")
+	}
+	if sources.Feeds(LeakTypeEnumDuplicateCharge, []EventTypeEnum{EventTypeEnumPaymentRefunded}) {
+		t.Error("event types outside a leak type's sources should not feed it")
+	}
+	if !sources.Feeds(LeakTypeEnumFailedPayments, nil) {
+		t.Error("a leak type the sources don't list should not be restricted")
+	}
+	if len(defaults[LeakTypeEnumVolumeAnomaly]) != 2 {
+		t.Error("Override should not change the sources it was called on")
+	}
+}
+
+func TestValidateBucketBounds(t *testing.T) {
+	tests := []struct {
+		name    string
+		bounds  []string
+		wantErr bool
+	}{
+		{"none", nil, false},
+		{"ascending", []string{"0.5", "10", "100"}, false},
+		{"zero", []string{"0", "10"}, true},
+		{"negative", []string{"-5"}, true},
+		{"repeated", []string{"10", "10"}, true},
+		{"descending", []string{"100", "10"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bounds := make([]Decimal, 0, len(tt.bounds))
+			for _, b := range tt.bounds {
+				bounds = append(bounds, MustParseDecimal(b))
+			}
+			err := ValidateBucketBounds(bounds)
+			if tt.wantErr != errors.Is(err, ErrInvalidBucketBounds) {
+				t.Errorf("ValidateBucketBounds(%v) = %v, want error: %v", tt.bounds, err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := ValidateBucketBounds(DefaultLeakAmountBucketBounds); err != nil {
+		t.Errorf("default bounds should be valid, got %v", err)
+	}
+}
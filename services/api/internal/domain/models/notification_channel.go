@@ -0,0 +1,68 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationChannel is where a tenant's leak notifications are delivered: a Slack incoming
+// webhook, an email address or a generic webhook, by ChannelType
+type NotificationChannel struct {
+	ID          uuid.UUID                   `json:"id"`
+	TenantID    uuid.UUID                   `json:"tenant_id"`
+	ChannelType NotificationChannelTypeEnum `json:"channel_type"`
+	Target      string                      `json:"target"`
+	// Enabled is false for a channel kept configured but not notified
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreateNotificationChannelParams represents parameters for creating a NotificationChannel
+type CreateNotificationChannelParams struct {
+	ChannelType NotificationChannelTypeEnum `json:"channel_type"`
+	Target      string                      `json:"target"`
+	Enabled     bool                        `json:"enabled"`
+}
+
+// UpdateNotificationChannelParams represents parameters for updating a NotificationChannel.
+// The channel type can't change, since the target only makes sense for its type.
+type UpdateNotificationChannelParams struct {
+	ID      uuid.UUID `json:"id"` // Primary key
+	Target  *string   `json:"target"`
+	Enabled *bool     `json:"enabled"`
+}
+
+var (
+	ErrInvalidNotificationChannelType   = errors.New("notification channel type must be slack, email or webhook")
+	ErrInvalidNotificationChannelTarget = errors.New("invalid notification channel target")
+)
+
+// Validate checks the channel type and that the target suits it
+func (p CreateNotificationChannelParams) Validate() error {
+	return ValidateNotificationChannelTarget(p.ChannelType, p.Target)
+}
+
+// ValidateNotificationChannelTarget checks that target is an address channelType can deliver
+// to: an http or https URL for Slack and webhook channels, an email address for email channels
+func ValidateNotificationChannelTarget(channelType NotificationChannelTypeEnum, target string) error {
+	switch channelType {
+	case NotificationChannelTypeEnumSlack, NotificationChannelTypeEnumWebhook:
+		u, err := url.Parse(target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: %s target must be an http or https URL", ErrInvalidNotificationChannelTarget, channelType)
+		}
+	case NotificationChannelTypeEnumEmail:
+		if _, err := mail.ParseAddress(target); err != nil {
+			return fmt.Errorf("%w: email target must be an email address", ErrInvalidNotificationChannelTarget)
+		}
+	default:
+		return fmt.Errorf("%w, got %q", ErrInvalidNotificationChannelType, channelType)
+	}
+	return nil
+}
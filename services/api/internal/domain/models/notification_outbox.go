@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxNotification is a leak notification waiting in the outbox to be delivered to one of its
+// tenant's channels. It leaves the outbox once delivered.
+type OutboxNotification struct {
+	ID        uuid.UUID `json:"id"`
+	TenantID  uuid.UUID `json:"tenant_id"`
+	ChannelID uuid.UUID `json:"channel_id"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	// Attempts counts the deliveries tried so far, including one in flight
+	Attempts int32 `json:"attempts"`
+	// NextAttemptAt is when the delivery is next due; while an attempt is in flight it holds the
+	// attempt's lease instead
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	LastError     *string   `json:"last_error"`
+	// FailedAt is set once the outbox gives up on the delivery; it is then never retried
+	FailedAt  *time.Time `json:"failed_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// CreateOutboxNotificationParams represents parameters for adding a notification to the outbox
+type CreateOutboxNotificationParams struct {
+	ChannelID uuid.UUID `json:"channel_id"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+}
@@ -3,12 +3,22 @@
 // the application.
 package models
 
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidLimit   = errors.New("limit is out of range")
+	ErrNegativeOffset = errors.New("offset must not be negative")
+)
+
 // PaginationParams represents parameters for paginated queries.
 // This struct is used to control pagination behavior for list operations.
 //
 // Fields:
 //   - Limit: Maximum number of items to return (required, must be > 0)
-//   - Offset: Number of items to skip (required, must be >= 0)
+//   - Offset: Number of items to skip (must be >= 0; 0 is the first page)
 //
 // Example usage:
 //   - First page (10 items): Limit=10, Offset=0
@@ -16,7 +26,19 @@ package models
 //   - Third page (10 items): Limit=10, Offset=20
 type PaginationParams struct {
 	Limit  int32 `json:"limit" validate:"required,min=1,max=1000"`
-	Offset int32 `json:"offset" validate:"required,min=0"`
+	Offset int32 `json:"offset" validate:"min=0"`
+}
+
+// Validate checks that Limit is between 1 and maxPageSize and that Offset is not negative.
+// Offset 0, the first page, is valid; tag-based "required" checks would treat it as missing.
+func (p PaginationParams) Validate(maxPageSize int32) error {
+	if p.Limit < 1 || p.Limit > maxPageSize {
+		return fmt.Errorf("%w: limit must be between 1 and %d, got %d", ErrInvalidLimit, maxPageSize, p.Limit)
+	}
+	if p.Offset < 0 {
+		return fmt.Errorf("%w: got %d", ErrNegativeOffset, p.Offset)
+	}
+	return nil
 }
 
 // PaginatedResponse represents a paginated response containing items and metadata.
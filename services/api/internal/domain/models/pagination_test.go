@@ -0,0 +1,30 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPaginationParams_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  PaginationParams
+		wantErr error
+	}{
+		{"first page", PaginationParams{Limit: 10, Offset: 0}, nil},
+		{"later page", PaginationParams{Limit: 10, Offset: 20}, nil},
+		{"limit at max", PaginationParams{Limit: 100, Offset: 0}, nil},
+		{"zero limit", PaginationParams{Limit: 0, Offset: 0}, ErrInvalidLimit},
+		{"negative limit", PaginationParams{Limit: -1, Offset: 0}, ErrInvalidLimit},
+		{"limit over max", PaginationParams{Limit: 101, Offset: 0}, ErrInvalidLimit},
+		{"negative offset", PaginationParams{Limit: 10, Offset: -1}, ErrNegativeOffset},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.params.Validate(100); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -14,9 +14,11 @@ import (
 type Payment struct {
 	ID          uuid.UUID         `json:"id"`
 	TenantID    uuid.UUID         `json:"tenant_id"`
-	CustomerID  uuid.UUID         `json:"customer_id"`
+	CustomerID  uuid.UUID         `json:"customer_id"` // uuid.Nil until the payment is matched to a customer
+	ProviderID  *uuid.UUID        `json:"provider_id"` // nil when the provider is unknown or was deleted
+	EventID     *uuid.UUID        `json:"event_id"`    // the event the payment was normalized from; nil for history
 	ExternalID  string            `json:"external_id"`
-	Amount      float32           `json:"amount"`
+	Amount      Decimal           `json:"amount"`
 	Currency    string            `json:"currency"`
 	Status      PaymentStatusEnum `json:"status"`
 	PaymentType PaymentTypeEnum   `json:"payment_type"`
@@ -24,25 +26,45 @@ type Payment struct {
 	UpdatedAt   time.Time         `json:"updated_at"`
 }
 
-// CreatePaymentParams represents parameters for creating a Payment
+// CreatePaymentParams represents parameters for creating a Payment.
+// A uuid.Nil CustomerID and nil ProviderID or EventID are stored as NULL.
 type CreatePaymentParams struct {
 	TenantID    uuid.UUID         `json:"tenant_id"`
 	CustomerID  uuid.UUID         `json:"customer_id"`
+	ProviderID  *uuid.UUID        `json:"provider_id"`
+	EventID     *uuid.UUID        `json:"event_id"`
 	ExternalID  string            `json:"external_id"`
-	Amount      float32           `json:"amount"`
+	Amount      Decimal           `json:"amount"`
 	Currency    string            `json:"currency"`
 	Status      PaymentStatusEnum `json:"status"`
 	PaymentType PaymentTypeEnum   `json:"payment_type"`
 }
 
-// UpdatePaymentParams represents parameters for updating a Payment
+// UpdatePaymentParams represents parameters for updating a Payment; nil fields are left unchanged.
+// The tenant, provider, event and payment type of a payment don't change once it is stored.
 type UpdatePaymentParams struct {
-	ID          uuid.UUID          `json:"id"` // Primary key
-	TenantID    *uuid.UUID         `json:"tenant_id"`
-	CustomerID  *uuid.UUID         `json:"customer_id"`
-	ExternalID  *string            `json:"external_id"`
-	Amount      *float32           `json:"amount"`
-	Currency    *string            `json:"currency"`
-	Status      *PaymentStatusEnum `json:"status"`
-	PaymentType *PaymentTypeEnum   `json:"payment_type"`
+	ID         uuid.UUID          `json:"id"` // Primary key
+	CustomerID *uuid.UUID         `json:"customer_id"`
+	Amount     *Decimal           `json:"amount"`
+	Currency   *string            `json:"currency"`
+	Status     *PaymentStatusEnum `json:"status"`
+}
+
+// CurrencyMismatch is a payment in another currency than the earliest payment with the same
+// external ID, such as a refund settled in a different currency from the charge it refunds.
+// Unless the tenant meant to convert, the difference is lost on FX.
+type CurrencyMismatch struct {
+	PaymentID uuid.UUID `json:"payment_id"`
+	// OriginalPaymentID is the earliest payment with the same external ID
+	OriginalPaymentID uuid.UUID `json:"original_payment_id"`
+	ExternalID        string    `json:"external_id"`
+	CustomerID        uuid.UUID `json:"customer_id"` // uuid.Nil until the payment is matched to a customer
+	// EventID is the event the mismatched payment was normalized from, and EventType its type
+	EventID          uuid.UUID     `json:"event_id"`
+	EventType        EventTypeEnum `json:"event_type"`
+	Amount           Decimal       `json:"amount"`
+	Currency         string        `json:"currency"`
+	OriginalAmount   Decimal       `json:"original_amount"`
+	OriginalCurrency string        `json:"original_currency"`
+	CreatedAt        time.Time     `json:"created_at"`
 }
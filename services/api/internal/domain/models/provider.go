@@ -5,22 +5,28 @@
 package models
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// DefaultProviderType is the type of a provider created without one
+const DefaultProviderType = "webhook"
+
 // Provider represents the domain model for Provider
 type Provider struct {
-	ID        uuid.UUID `json:"id"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID           uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	ProviderType string    `json:"provider_type"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
 }
 
 // CreateProviderParams represents parameters for creating a Provider
 type CreateProviderParams struct {
-	Name string `json:"name"`
+	Name         string `json:"name"`
+	ProviderType string `json:"provider_type"`
 }
 
 // UpdateProviderParams represents parameters for updating a Provider
@@ -28,3 +34,47 @@ type UpdateProviderParams struct {
 	ID   uuid.UUID `json:"id"` // Primary key
 	Name *string   `json:"name"`
 }
+
+// ProviderStatus is whether a provider is still sending a tenant events
+type ProviderStatus string
+
+const (
+	// ProviderStatusActive means the tenant received an event from the provider recently
+	ProviderStatusActive ProviderStatus = "active"
+	// ProviderStatusSilent means the tenant has not received an event from the provider
+	// recently, or never has
+	ProviderStatusSilent ProviderStatus = "silent"
+)
+
+// TenantProvider is a provider as one tenant sees it, with the last event the tenant received from it
+type TenantProvider struct {
+	Provider
+	// LastEventAt is nil when the tenant never received an event from the provider
+	LastEventAt *time.Time     `json:"last_event_at"`
+	Status      ProviderStatus `json:"status"`
+}
+
+var (
+	ErrInvalidProviderName = errors.New("provider name must be 1 to 255 characters")
+	ErrInvalidProviderType = errors.New("provider type must be 1 to 50 characters")
+)
+
+// ProviderStatusAt derives a provider's status at now: active when its last event is at most
+// silentAfter old, silent otherwise
+func ProviderStatusAt(lastEventAt *time.Time, now time.Time, silentAfter time.Duration) ProviderStatus {
+	if lastEventAt != nil && now.Sub(*lastEventAt) <= silentAfter {
+		return ProviderStatusActive
+	}
+	return ProviderStatusSilent
+}
+
+// Validate checks the lengths the providers table allows
+func (p CreateProviderParams) Validate() error {
+	if p.Name == "" || len(p.Name) > 255 {
+		return ErrInvalidProviderName
+	}
+	if p.ProviderType == "" || len(p.ProviderType) > 50 {
+		return ErrInvalidProviderType
+	}
+	return nil
+}
@@ -12,11 +12,24 @@ import (
 
 // Tenant represents the domain model for Tenant
 type Tenant struct {
-	ID        uuid.UUID `json:"id"`
-	Email     string    `json:"email"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                 uuid.UUID       `json:"id"`
+	Email              string          `json:"email"`
+	Name               string          `json:"name"`
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
+	AcceptedEventTypes []EventTypeEnum `json:"accepted_event_types"`
+	AllowedProviderIds []uuid.UUID     `json:"allowed_provider_ids"`
+	// MinLeakAmounts overrides the global minimum leak amount for the currencies it lists
+	MinLeakAmounts map[string]Decimal `json:"min_leak_amounts"`
+	// LeakSources overrides the default event types feeding the leak types it lists
+	LeakSources LeakSources `json:"leak_sources"`
+	// EventRetentionDays overrides the global event retention period; nil uses the global one
+	EventRetentionDays *int32 `json:"event_retention_days"`
+	// DunningWindowHours overrides the global dunning window; nil uses the global one
+	DunningWindowHours *int32 `json:"dunning_window_hours"`
+	// RateLimitRPS and RateLimitBurst override the global request rate limit; nil uses the global one
+	RateLimitRPS   *float64 `json:"rate_limit_rps"`
+	RateLimitBurst *int32   `json:"rate_limit_burst"`
 }
 
 // CreateTenantParams represents parameters for creating a Tenant
@@ -31,3 +44,13 @@ type UpdateTenantParams struct {
 	Email *string   `json:"email"`
 	Name  *string   `json:"name"`
 }
+
+// TenantRateLimit is a tenant's own request rate limit. A nil field uses the global one.
+type TenantRateLimit struct {
+	TenantID uuid.UUID `json:"tenant_id"`
+	// RPS is the tenant's sustained requests per second; 0 lifts the limit for the tenant
+	RPS *float64 `json:"rps,omitempty"`
+	// Burst is how many requests the tenant may make at once. It needs a rate to refill, so a
+	// Burst without RPS is ignored while the global limit is unlimited.
+	Burst *int32 `json:"burst,omitempty"`
+}
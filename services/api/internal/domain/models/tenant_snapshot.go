@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TenantSnapshotVersion is the format version of the tenant archives ExportTenant writes.
+// ImportTenant refuses archives of any other version.
+const TenantSnapshotVersion = 1
+
+// TenantSnapshotManifest is the first entry of a tenant archive, identifying what it holds
+type TenantSnapshotManifest struct {
+	Version    int       `json:"version"`
+	TenantID   uuid.UUID `json:"tenant_id"`
+	ExportedAt time.Time `json:"exported_at"`
+}
+
+// TenantSnapshotReport summarizes a tenant export or import
+type TenantSnapshotReport struct {
+	// Rows counts the rows of each entity written to or read from the archive
+	Rows map[string]int64 `json:"rows"`
+	// Inserted counts, for an import, the rows of each entity that were new. Rows already
+	// present, such as those committed by an earlier attempt, are left as they are.
+	Inserted map[string]int64 `json:"inserted,omitempty"`
+	// LastPart is the last archive part written, or for an import the last one committed.
+	// Importing again with it as resumeAfter continues after that part.
+	LastPart string `json:"last_part"`
+}
@@ -8,10 +8,10 @@ import (
 	"log/slog"
 	"rdl-api/internal/db/repository"
 	"rdl-api/internal/domain/models"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type ActionsService interface {
@@ -19,20 +19,26 @@ type ActionsService interface {
 	DeleteAction(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (int64, error)
 	GetAllActions(ctx context.Context, tenantID uuid.UUID) ([]models.Action, error)
 	GetAllActionsPaginated(ctx context.Context, tenantID uuid.UUID, params models.PaginationParams) (models.PaginatedResponse[models.Action], error)
+	GetActionsFiltered(ctx context.Context, tenantID uuid.UUID, filter models.ActionFilter, params models.PaginationParams, sort models.ActionSort) (models.PaginatedResponse[models.Action], error)
 	GetActionByID(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (models.Action, error)
+	GetActionWithLeak(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (models.Action, models.Leak, error)
+	GetActionsByLeakID(ctx context.Context, leakID uuid.UUID, tenantID uuid.UUID) ([]models.Action, error)
 	CountAllActions(ctx context.Context, tenantID uuid.UUID) (int64, error)
+	ClaimPendingActions(ctx context.Context, tenantID uuid.UUID, workerID string, limit int) ([]models.Action, error)
+	CompleteAction(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (models.Action, error)
+	FailActionAttempt(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (models.Action, error)
 }
 
-// NewActionsRepository creates a new instance of ActionsRepositoryImplementation backed by the provided pgx.Tx and pgxpool.Pool.
+// NewActionsRepository creates a new instance of ActionsRepositoryImplementation backed by the provided pgx.Tx and repository.Pool.
 //
 // Parameters:
-//   - pool: Pointer to pgxpool.Pool, which provides access to the database.
+//   - pool: Pointer to repository.Pool, which provides access to the database.
 //   - tx: Pointer to pgx.Tx, which provides access to the database transaction.
 //   - logger: Pointer to slog.Logger, which provides access to the logger.
 //
 // Returns:
 //   - ActionsRepository: An implementation of the ActionsRepository interface.
-func NewActionsRepository(pool *pgxpool.Pool, tx pgx.Tx, logger *slog.Logger) repository.ActionsRepositoryImplementation {
+func NewActionsRepository(pool *repository.Pool, tx pgx.Tx, logger *slog.Logger) repository.ActionsRepositoryImplementation {
 	return repository.ActionsRepositoryImplementation{Pool: pool, Tx: tx, Logger: logger}
 }
 
@@ -40,6 +46,10 @@ func NewActionsRepository(pool *pgxpool.Pool, tx pgx.Tx, logger *slog.Logger) re
 type actionsService struct {
 	actionsRepo repository.ActionsRepositoryImplementation
 	logger      *slog.Logger
+	// maxAttempts is how many claims an action gets before a failure marks it failed for good
+	maxAttempts int
+	// claimLease is how long a claim holds an action before another worker may take it over
+	claimLease time.Duration
 }
 
 // NewActionsService creates a new instance of ActionsService backed by the provided pool.
@@ -47,15 +57,19 @@ type actionsService struct {
 // Parameters:
 //   - pool: Database connection pool.
 //   - logger: Logger for structured logging.
+//   - maxAttempts: How many times an action is tried before it is marked failed (MAX_ACTION_ATTEMPTS).
+//   - claimLease: How long a claim holds an action before another worker may take it over (ACTION_CLAIM_LEASE).
 //
 // Returns:
 //   - ActionsService: An implementation of the ActionsService interface.
-func NewActionsService(pool *pgxpool.Pool, logger *slog.Logger) ActionsService {
+func NewActionsService(pool *repository.Pool, logger *slog.Logger, maxAttempts int, claimLease time.Duration) ActionsService {
 	// It needs to initialize an ActionsRepository with the dependencies injected from the app
 	aR := NewActionsRepository(pool, nil, logger)
 	return &actionsService{
 		actionsRepo: aR,
 		logger:      logger,
+		maxAttempts: maxAttempts,
+		claimLease:  claimLease,
 	}
 }
 
@@ -151,6 +165,27 @@ func (s *actionsService) GetAllActionsPaginated(ctx context.Context, tenantID uu
 	return response, nil
 }
 
+// GetActionsFiltered retrieves a page of the tenant's actions across all its leaks, filtered and sorted.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant that owns the actions.
+//   - filter: The action types, statuses, results and creation range to match.
+//   - params: Pagination parameters (limit and offset).
+//   - sort: The order of the actions.
+//
+// Returns:
+//   - models.PaginatedResponse[models.Action]: The page with the total number of matching actions.
+//   - error: Any error encountered during retrieval.
+func (s *actionsService) GetActionsFiltered(ctx context.Context, tenantID uuid.UUID, filter models.ActionFilter, params models.PaginationParams, sort models.ActionSort) (models.PaginatedResponse[models.Action], error) {
+	response, err := s.actionsRepo.GetActionsFiltered(ctx, tenantID, filter, params, sort)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to retrieve filtered actions for tenant", "error", err, "tenant_id", tenantID)
+		return models.PaginatedResponse[models.Action]{}, err
+	}
+	return response, nil
+}
+
 // GetActionByID retrieves an action by its UUID.
 //
 // Parameters:
@@ -174,6 +209,30 @@ func (s *actionsService) GetActionByID(ctx context.Context, id uuid.UUID, tenant
 	return action, nil
 }
 
+// GetActionWithLeak retrieves an action together with the leak it remediates.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - id: UUID of the action to retrieve.
+//   - tenantID: UUID of the tenant that owns the action.
+//
+// Returns:
+//   - models.Action: The requested action.
+//   - models.Leak: The action's leak.
+//   - error: Any error encountered during retrieval.
+func (s *actionsService) GetActionWithLeak(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (models.Action, models.Leak, error) {
+	s.logger.DebugContext(ctx, "Retrieving action with leak", "action_id", id, "tenant_id", tenantID)
+
+	action, leak, err := s.actionsRepo.GetActionWithLeak(ctx, id, tenantID)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to retrieve action with leak", "error", err, "action_id", id, "tenant_id", tenantID)
+		return models.Action{}, models.Leak{}, err
+	}
+
+	s.logger.DebugContext(ctx, "Retrieved action with leak successfully", "action_id", id, "leak_id", leak.ID, "tenant_id", tenantID)
+	return action, leak, nil
+}
+
 // CountAllActions counts the total number of actions for a specific tenant.
 //
 // Parameters:
@@ -195,3 +254,90 @@ func (s *actionsService) CountAllActions(ctx context.Context, tenantID uuid.UUID
 	s.logger.DebugContext(ctx, "Counted actions successfully", "tenant_id", tenantID, "count", count)
 	return count, nil
 }
+
+// ClaimPendingActions atomically moves up to limit pending actions to in_progress for
+// workerID and returns them in execution order: the highest priority actions of each currency
+// first, oldest first among equal ranks. Concurrent workers never receive the same action; an
+// action whose claim is older than ACTION_CLAIM_LEASE is taken over from its worker. Actions
+// that have been claimed MAX_ACTION_ATTEMPTS times are failed instead of claimed again.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - tenantID: UUID of the tenant that owns the actions.
+//   - workerID: Identifier of the claiming worker.
+//   - limit: Maximum number of actions to claim.
+//
+// Returns:
+//   - []models.Action: The claimed actions in execution order.
+//   - error: Any error encountered while claiming.
+func (s *actionsService) ClaimPendingActions(ctx context.Context, tenantID uuid.UUID, workerID string, limit int) ([]models.Action, error) {
+	actions, err := s.actionsRepo.ClaimPendingActions(ctx, tenantID, workerID, limit, s.claimLease, s.maxAttempts)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to claim pending actions", "error", err, "tenant_id", tenantID, "worker_id", workerID)
+		return nil, err
+	}
+
+	s.logger.DebugContext(ctx, "Claimed pending actions", "tenant_id", tenantID, "worker_id", workerID, "count", len(actions))
+	return actions, nil
+}
+
+// CompleteAction records that the attempt of a claimed action succeeded, approving it with a
+// success result.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - id: UUID of the claimed action.
+//   - tenantID: UUID of the tenant that owns the action.
+//
+// Returns:
+//   - models.Action: The completed action.
+//   - error: Any error encountered while recording the success.
+func (s *actionsService) CompleteAction(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (models.Action, error) {
+	action, err := s.actionsRepo.CompleteAction(ctx, id, tenantID)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to complete action", "error", err, "action_id", id, "tenant_id", tenantID)
+		return models.Action{}, err
+	}
+
+	s.logger.DebugContext(ctx, "Completed action", "action_id", id, "tenant_id", tenantID, "attempts", action.Attempts)
+	return action, nil
+}
+
+// FailActionAttempt records that the attempt of a claimed action failed. The action is retried
+// until it has been claimed MAX_ACTION_ATTEMPTS times, after which it is marked failed and no
+// longer claimed.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - id: UUID of the claimed action.
+//   - tenantID: UUID of the tenant that owns the action.
+//
+// Returns:
+//   - models.Action: The action, pending again or failed.
+//   - error: Any error encountered while recording the failure.
+func (s *actionsService) FailActionAttempt(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (models.Action, error) {
+	action, err := s.actionsRepo.FailActionAttempt(ctx, id, tenantID, s.maxAttempts)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to record failed action attempt", "error", err, "action_id", id, "tenant_id", tenantID)
+		return models.Action{}, err
+	}
+
+	if action.Status == models.ActionStatusEnumFailed {
+		s.logger.WarnContext(ctx, "Action failed after its last attempt", "action_id", id, "tenant_id", tenantID, "attempts", action.Attempts)
+	}
+	return action, nil
+}
+
+// GetActionsByLeakID retrieves the actions taken for a leak, oldest first.
+//
+// Parameters:
+//   - ctx: Context for request-scoped values, cancellation, and deadlines.
+//   - leakID: UUID of the leak whose actions to retrieve.
+//   - tenantID: UUID of the tenant that owns the leak.
+//
+// Returns:
+//   - []models.Action: The leak's actions.
+//   - error: Any error encountered during retrieval.
+func (s *actionsService) GetActionsByLeakID(ctx context.Context, leakID uuid.UUID, tenantID uuid.UUID) ([]models.Action, error) {
+	return s.actionsRepo.GetActionsByLeakID(ctx, leakID, tenantID)
+}
@@ -1,12 +1,61 @@
 package services
 
-import "errors"
+import (
+	"errors"
+	"rdl-api/internal/db/repository"
+	"rdl-api/internal/domain/models"
+)
 
 var (
 	ErrDatabaseNotInitialized = errors.New("database not initialized")
 	ErrDatabaseUnavailable    = errors.New("database unavailable")
+	// ErrShuttingDown is returned by CheckReadiness once shutdown has begun
+	ErrShuttingDown = errors.New("shutting down")
+
+	// ErrServiceOverloaded is returned when the database connection pool is exhausted; callers should retry later
+	ErrServiceOverloaded = repository.ErrServiceOverloaded
+
+	// ErrOperationCanceled and ErrOperationTimeout match context.Canceled and
+	// context.DeadlineExceeded with errors.Is
+	ErrOperationCanceled = repository.ErrOperationCanceled
+	ErrOperationTimeout  = repository.ErrOperationTimeout
 
 	// Service construction errors
 	ErrLoggerCannotBeNil = errors.New("logger cannot be nil")
 	ErrPoolCannotBeNil   = errors.New("pool cannot be nil")
+
+	// Event errors surfaced from the repository layer
+	ErrEventNotFound          = repository.ErrEventNotFound
+	ErrEventAlreadyExists     = repository.ErrEventAlreadyExists
+	ErrEventSkipped           = repository.ErrEventSkipped
+	ErrUnknownProvider        = repository.ErrUnknownProvider
+	ErrConcurrentModification = repository.ErrConcurrentModification
+	ErrBatchTooLarge          = repository.ErrBatchTooLarge
+	ErrSameProvider           = repository.ErrSameProvider
+
+	// ErrInvalidSearchPrefix is returned for an empty or overlong external ID search prefix
+	ErrInvalidSearchPrefix = errors.New("invalid external ID prefix")
+
+	// Provider validation errors
+	ErrInvalidProviderName = models.ErrInvalidProviderName
+	ErrInvalidProviderType = models.ErrInvalidProviderType
+	ErrProviderNotFound    = repository.ErrProviderNotFound
+
+	// Notification channel errors
+	ErrInvalidNotificationChannelType   = models.ErrInvalidNotificationChannelType
+	ErrInvalidNotificationChannelTarget = models.ErrInvalidNotificationChannelTarget
+	ErrNotificationChannelNotFound      = repository.ErrNotificationChannelNotFound
+
+	// Leak errors surfaced from the repository layer
+	ErrLeakNotFound = repository.ErrLeakNotFound
+
+	// Tenant snapshot errors surfaced from the repository layer
+	ErrTenantNotFound        = repository.ErrTenantNotFound
+	ErrInvalidTenantSnapshot = repository.ErrInvalidTenantSnapshot
 )
+
+// IsDatabaseUnavailable reports whether err means the database could not be reached, so the
+// write that failed is worth retrying later
+func IsDatabaseUnavailable(err error) bool {
+	return repository.IsDatabaseUnavailable(err)
+}
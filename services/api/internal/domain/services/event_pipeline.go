@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"rdl-api/internal/domain/models"
+
+	"github.com/google/uuid"
+)
+
+// Names of the stages an event pipeline is configured from. validate, persist and metric are
+// built into the events service; detect and notify are supplied by the caller, as they need
+// the leak detector and the notifier.
+const (
+	EventStageValidate = "validate"
+	EventStagePersist  = "persist"
+	EventStageDetect   = "detect"
+	EventStageNotify   = "notify"
+	EventStageMetric   = "metric"
+)
+
+// DefaultEventStages is what CreateEvent runs until ConfigurePipeline is called: store the
+// event and record its ingestion lag, leaving detection to the scheduler
+var DefaultEventStages = []string{EventStagePersist, EventStageMetric}
+
+var (
+	// ErrSkipEventStages is returned by a stage to end the pipeline early without failing it.
+	// Returned before the persist stage it drops the event.
+	ErrSkipEventStages = errors.New("skip the remaining event stages")
+	// ErrInvalidEventPipeline is returned by ConfigurePipeline for an unknown or repeated stage,
+	// or a pipeline that never stores the event
+	ErrInvalidEventPipeline = errors.New("invalid event pipeline")
+)
+
+// EventStage is one named step of the pipeline that runs for every event created
+type EventStage interface {
+	Name() string
+	// Critical reports whether a failure of the stage fails the event's creation. A failing
+	// non-critical stage is logged and the stages after it still run.
+	Critical() bool
+	Run(ctx context.Context, state *EventPipelineState) error
+}
+
+// EventPipelineState is what the stages of one pipeline run share
+type EventPipelineState struct {
+	TenantID uuid.UUID
+	Params   models.CreateEventParams
+	// Idempotent makes the persist stage report an already-stored provider event ID as
+	// ErrEventAlreadyExists without logging it as a failure
+	Idempotent bool
+	// Event is the stored event, set by the persist stage
+	Event models.Event
+	// Leaks are the leaks the detect stage stored, for the notify stage to announce
+	Leaks []models.Leak
+}
+
+// NewEventStage creates an EventStage that calls run
+func NewEventStage(name string, critical bool, run func(ctx context.Context, state *EventPipelineState) error) EventStage {
+	return eventStage{name: name, critical: critical, run: run}
+}
+
+type eventStage struct {
+	name     string
+	critical bool
+	run      func(ctx context.Context, state *EventPipelineState) error
+}
+
+func (s eventStage) Name() string   { return s.name }
+func (s eventStage) Critical() bool { return s.critical }
+func (s eventStage) Run(ctx context.Context, state *EventPipelineState) error {
+	return s.run(ctx, state)
+}
+
+// EventPipeline runs its stages in order for each event created
+type EventPipeline struct {
+	stages []EventStage
+	logger *slog.Logger
+}
+
+// NewEventPipeline creates an EventPipeline that runs stages in the order given
+func NewEventPipeline(logger *slog.Logger, stages ...EventStage) *EventPipeline {
+	return &EventPipeline{stages: stages, logger: logger}
+}
+
+// Stages returns the names of the stages in the order they run
+func (p *EventPipeline) Stages() []string {
+	names := make([]string, 0, len(p.stages))
+	for _, stage := range p.stages {
+		names = append(names, stage.Name())
+	}
+	return names
+}
+
+// Run runs the stages in order on state. A stage returning ErrSkipEventStages ends the run
+// successfully, a failing critical stage ends it with that stage's error, and a failing
+// non-critical stage is logged and passed over, so a late step such as detection or
+// notification cannot fail an event that was already stored.
+func (p *EventPipeline) Run(ctx context.Context, state *EventPipelineState) error {
+	for _, stage := range p.stages {
+		err := stage.Run(ctx, state)
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrSkipEventStages):
+			p.logger.DebugContext(ctx, "Event pipeline ended early", "stage", stage.Name(), "tenant_id", state.TenantID, "event_id", state.Event.ID)
+			return nil
+		case stage.Critical():
+			return err
+		default:
+			p.logger.WarnContext(ctx, "Event pipeline stage failed", "error", err, "stage", stage.Name(), "tenant_id", state.TenantID, "event_id", state.Event.ID)
+		}
+	}
+	return nil
+}
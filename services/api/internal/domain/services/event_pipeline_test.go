@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"rdl-api/internal/domain/models"
+)
+
+func TestEventPipeline_Run(t *testing.T) {
+	ctx := context.Background()
+	errStage := errors.New("stage failed")
+
+	// stage appends its name to ran and returns err
+	var ran []string
+	stage := func(name string, critical bool, err error) EventStage {
+		return NewEventStage(name, critical, func(context.Context, *EventPipelineState) error {
+			ran = append(ran, name)
+			return err
+		})
+	}
+	run := func(stages ...EventStage) error {
+		ran = nil
+		return NewEventPipeline(newTestLogger(), stages...).Run(ctx, &EventPipelineState{TenantID: uuid.New()})
+	}
+
+	t.Run("stages run in order", func(t *testing.T) {
+		pipeline := NewEventPipeline(newTestLogger(), stage("a", true, nil), stage("b", false, nil), stage("c", true, nil))
+		assert.Equal(t, []string{"a", "b", "c"}, pipeline.Stages())
+		ran = nil
+		require.NoError(t, pipeline.Run(ctx, &EventPipelineState{}))
+		assert.Equal(t, []string{"a", "b", "c"}, ran)
+	})
+
+	t.Run("a stage can skip the rest", func(t *testing.T) {
+		err := run(stage("a", true, nil), stage("b", true, ErrSkipEventStages), stage("c", true, nil))
+		require.NoError(t, err, "skipping is not a failure")
+		assert.Equal(t, []string{"a", "b"}, ran)
+	})
+
+	t.Run("a failing critical stage rejects the event", func(t *testing.T) {
+		err := run(stage("a", true, errStage), stage("b", true, nil))
+		require.ErrorIs(t, err, errStage)
+		assert.Equal(t, []string{"a"}, ran)
+	})
+
+	t.Run("a failing non-critical stage is passed over", func(t *testing.T) {
+		err := run(stage("a", true, nil), stage("b", false, errStage), stage("c", false, nil))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b", "c"}, ran)
+	})
+}
+
+func TestEventsService_ConfigurePipeline(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	repo := &ingestingEventsRepository{ingestedAt: time.Now()}
+	providerID := uuid.New()
+	providers := &fakeProvidersRepository{types: map[uuid.UUID]string{providerID: "pipeline_test"}}
+	params := models.CreateEventParams{ProviderID: providerID, EventType: models.EventTypeEnumPaymentFailed, EventID: "evt_1", Data: `{"id":"evt_1"}`}
+
+	// detect records the event it saw and fails, as a detection outage would
+	var detected []uuid.UUID
+	detect := NewEventStage(EventStageDetect, false, func(_ context.Context, state *EventPipelineState) error {
+		detected = append(detected, state.Event.ID)
+		return errors.New("detection unavailable")
+	})
+
+	t.Run("invalid pipelines", func(t *testing.T) {
+		svc := &eventsService{eventsRepository: repo, providersRepository: providers, logger: newTestLogger()}
+		for _, names := range [][]string{
+			{EventStageValidate, EventStageMetric},
+			{EventStagePersist, EventStageNotify},
+			{EventStagePersist, EventStagePersist},
+		} {
+			assert.ErrorIs(t, svc.ConfigurePipeline(names, detect), ErrInvalidEventPipeline, names)
+		}
+		assert.Nil(t, svc.pipeline, "a rejected configuration leaves the default pipeline")
+	})
+
+	t.Run("a failing non-critical stage does not fail ingestion", func(t *testing.T) {
+		svc := &eventsService{eventsRepository: repo, providersRepository: providers, logger: newTestLogger()}
+		require.NoError(t, svc.ConfigurePipeline([]string{EventStageValidate, EventStagePersist, EventStageDetect, EventStageMetric}, detect))
+		assert.Equal(t, []string{EventStageValidate, EventStagePersist, EventStageDetect, EventStageMetric}, svc.pipeline.Stages())
+
+		event, err := svc.CreateEvent(ctx, params, tenantID)
+		require.NoError(t, err)
+		assert.NotEqual(t, uuid.Nil, event.ID)
+		assert.Equal(t, []uuid.UUID{event.ID}, detected, "detect runs after the event is stored")
+
+		_, err = svc.CreateEventIdempotent(ctx, models.CreateEventParams{ProviderID: providerID, EventType: models.EventTypeEnumPaymentFailed, EventID: "evt_duplicate", Data: `{}`}, tenantID)
+		require.ErrorIs(t, err, ErrEventAlreadyExists)
+		assert.Len(t, detected, 1, "stages after a failed persist do not run")
+	})
+
+	t.Run("validate rejects an event before it is stored", func(t *testing.T) {
+		svc := &eventsService{eventsRepository: repo, providersRepository: providers, logger: newTestLogger()}
+		require.NoError(t, svc.ConfigurePipeline([]string{EventStageValidate, EventStagePersist}))
+		invalid := params
+		invalid.EventID = " "
+		_, err := svc.CreateEvent(ctx, invalid, tenantID)
+		require.ErrorIs(t, err, models.ErrMissingExternalID)
+	})
+
+	t.Run("a stage can drop an event before it is stored", func(t *testing.T) {
+		svc := &eventsService{eventsRepository: repo, providersRepository: providers, logger: newTestLogger()}
+		drop := NewEventStage("drop", true, func(context.Context, *EventPipelineState) error { return ErrSkipEventStages })
+		require.NoError(t, svc.ConfigurePipeline([]string{"drop", EventStagePersist}, drop))
+		event, err := svc.CreateEvent(ctx, params, tenantID)
+		require.NoError(t, err)
+		assert.Equal(t, uuid.Nil, event.ID)
+	})
+}
@@ -4,40 +4,99 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"rdl-api/internal/db/repository"
 	"rdl-api/internal/domain/models"
+	"rdl-api/internal/metrics"
+	"slices"
+	"sync"
+	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type EventsService interface {
 	CreateEvent(ctx context.Context, args models.CreateEventParams, tenantID uuid.UUID) (models.Event, error)
+	CreateEventIdempotent(ctx context.Context, args models.CreateEventParams, tenantID uuid.UUID) (models.Event, error)
+	CreateEventsBatch(ctx context.Context, args []models.CreateEventParams, tenantID uuid.UUID, policy models.BatchPolicy) ([]models.BatchEventResult, error)
 	DeleteEvent(ctx context.Context, eventID uuid.UUID, tenantID uuid.UUID) (int64, error)
+	DeleteEventIdempotent(ctx context.Context, eventID uuid.UUID, tenantID uuid.UUID) (int64, error)
 	GetAllEvents(ctx context.Context, tenantID uuid.UUID) ([]models.Event, error)
 	GetAllEventsPaginated(ctx context.Context, tenantID uuid.UUID, params models.PaginationParams) (models.PaginatedResponse[models.Event], error)
+	ListEvents(ctx context.Context, tenantID uuid.UUID, filter models.EventFilter, params models.PaginationParams) (models.PaginatedResponse[models.Event], error)
+	ListEventsForExport(ctx context.Context, tenantID uuid.UUID, after models.EventCursor, limit int32) ([]models.Event, error)
+	SearchEventsByExternalID(ctx context.Context, tenantID uuid.UUID, prefix string, params models.PaginationParams) (models.PaginatedResponse[models.Event], error)
 	GetEventByID(ctx context.Context, eventID uuid.UUID, tenantID uuid.UUID) (models.Event, error)
+	GetEventStatusHistory(ctx context.Context, eventID uuid.UUID, tenantID uuid.UUID) ([]models.EventStatusChange, error)
+	GetEventsByIDs(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) (map[uuid.UUID]models.Event, error)
+	GetEventsByLeakID(ctx context.Context, leakID uuid.UUID, tenantID uuid.UUID) ([]models.Event, error)
+	GetEventsBySource(ctx context.Context, tenantID uuid.UUID, source models.EventSourceEnum, params models.PaginationParams) (models.PaginatedResponse[models.Event], error)
+	GetEventsWithoutLeak(ctx context.Context, tenantID uuid.UUID, eventType models.EventTypeEnum, params models.PaginationParams) (models.PaginatedResponse[models.Event], error)
+	GetPendingEventsOldestFirst(ctx context.Context, tenantID uuid.UUID, limit int) ([]models.Event, error)
+	GetRecentEvents(ctx context.Context, tenantID uuid.UUID, n int) ([]models.Event, error)
+	GetRelatedEvents(ctx context.Context, tenantID uuid.UUID, eventID uuid.UUID, rule models.CorrelationRule) ([]models.Event, error)
 	UpdateEvent(ctx context.Context, args models.UpdateEventParams, tenantID uuid.UUID) (models.Event, error)
+	UpdateEventIfVersion(ctx context.Context, args models.UpdateEventParams, expectedUpdatedAt time.Time, tenantID uuid.UUID) (models.Event, error)
+	UpdateEventStatusByFilter(ctx context.Context, tenantID uuid.UUID, filter models.EventFilter, newStatus models.EventStatusEnum) (int64, error)
+	ReattributeEvents(ctx context.Context, tenantID uuid.UUID, fromProviderID uuid.UUID, toProviderID uuid.UUID) (int64, error)
 	CountAllEvents(ctx context.Context, tenantID uuid.UUID) (int64, error)
+	HasAnyEvents(ctx context.Context, tenantID uuid.UUID) (bool, error)
+	CountEventsByStatusForProvider(ctx context.Context, tenantID uuid.UUID, providerID uuid.UUID) (map[models.EventStatusEnum]int64, error)
+	GetEventCountInWindow(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) (int64, error)
+	GetIngestionLag(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) ([]models.IngestionLag, error)
+	GetProviderFailureRates(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) ([]models.ProviderFailureRate, error)
+	FindDuplicateCharges(ctx context.Context, tenantID uuid.UUID, since time.Time, window time.Duration) ([]models.DuplicateCharge, error)
+	FindDunningGaps(ctx context.Context, tenantID uuid.UUID, now time.Time, defaultWindow time.Duration, lookback time.Duration) ([]models.DunningGap, error)
+	ReprocessFailedEvents(ctx context.Context, tenantID uuid.UUID, cursor uuid.UUID, limit int) (models.ReprocessResult, error)
+	ValidateStoredEvents(ctx context.Context, tenantID uuid.UUID, params models.ValidateStoredEventsParams) (models.StoredEventsValidation, error)
+	GetEventRetentionPolicies(ctx context.Context) ([]models.EventRetentionPolicy, error)
+	ArchiveEventsBefore(ctx context.Context, tenantID uuid.UUID, before time.Time, w io.Writer) (int64, error)
+	PurgeEventsBefore(ctx context.Context, tenantID uuid.UUID, before time.Time, limit int32) (int64, error)
+	ConfigurePipeline(names []string, stages ...EventStage) error
 }
 
 type eventsService struct {
-	eventsRepository EventsRepository
-	logger           *slog.Logger
+	eventsRepository    EventsRepository
+	providersRepository ProvidersRepository
+	logger              *slog.Logger
+	// providerTypes caches provider ID to type for labeling ingestion lag; a provider's type
+	// never changes once it is created
+	providerTypes sync.Map
+	// pipeline is what CreateEvent runs; nil runs DefaultEventStages
+	pipeline *EventPipeline
+	// ingestionLag records the ingestion lag of stored events
+	ingestionLag metrics.IngestionLagRecorder
 }
 
-// - Pointer to an initialized EventService.
-func NewEventService(pool *pgxpool.Pool, l *slog.Logger) (EventsService, error) {
+// NewEventService creates an EventsService backed by pool.
+// Listing and counting queries use readPool when it is not nil, typically a read replica.
+// lagBuckets are the bucket bounds of the ingestion lag histograms; empty uses
+// metrics.DefaultIngestionLagBuckets.
+func NewEventService(pool *repository.Pool, readPool *repository.Pool, l *slog.Logger, lagBuckets []time.Duration) (EventsService, error) {
 	// It needs to initialze an EventsRepository with the dependencies injected from the app
 	eR, err := repository.NewEventsRepository(pool, l)
 	if err != nil {
 		return nil, err
 	}
-	return &eventsService{eventsRepository: eR, logger: l}, nil
+	pR, err := repository.NewProvidersRepository(pool, l)
+	if err != nil {
+		return nil, err
+	}
+	return &eventsService{
+		eventsRepository:    eR.WithReadPool(readPool),
+		providersRepository: pR,
+		logger:              l,
+		ingestionLag:        metrics.NewIngestionLagRecorder(lagBuckets),
+	}, nil
 }
 
-// CreateEvent creates a new event in the system.
+// CreateEvent creates a new event in the system by running the event pipeline: by default
+// the event is stored and its ingestion lag recorded. A stage that ends the pipeline before
+// the event is stored drops it, returning the zero Event and no error.
 //
 // Parameters:
 //   - ctx: Context for request-scoped values, cancellation, and deadlines.
@@ -48,13 +107,149 @@ func NewEventService(pool *pgxpool.Pool, l *slog.Logger) (EventsService, error)
 //   - The created Event domain model.
 //   - An error if the creation fails.
 func (s *eventsService) CreateEvent(ctx context.Context, args models.CreateEventParams, tenantID uuid.UUID) (models.Event, error) {
-	return s.eventsRepository.CreateEvent(ctx, args, tenantID)
+	state := &EventPipelineState{TenantID: tenantID, Params: args}
+	err := s.eventPipeline().Run(ctx, state)
+	return state.Event, err
+}
+
+// CreateEventIdempotent creates a new event like CreateEvent, reporting an already-stored
+// provider event ID as ErrEventAlreadyExists without logging it as a failure. Use it for
+// webhook ingestion, where redeliveries are routine.
+func (s *eventsService) CreateEventIdempotent(ctx context.Context, args models.CreateEventParams, tenantID uuid.UUID) (models.Event, error) {
+	state := &EventPipelineState{TenantID: tenantID, Params: args, Idempotent: true}
+	err := s.eventPipeline().Run(ctx, state)
+	return state.Event, err
+}
+
+// ConfigurePipeline replaces the pipeline CreateEvent and CreateEventIdempotent run with the
+// named stages, in order. Names are looked up among the built-in validate, persist and metric
+// stages and then among stages, which is how detect and notify are supplied. An unknown or
+// repeated name, or a pipeline without persist, returns ErrInvalidEventPipeline. Call it
+// before the service is used.
+func (s *eventsService) ConfigurePipeline(names []string, stages ...EventStage) error {
+	available := s.builtinStages()
+	for _, stage := range stages {
+		available[stage.Name()] = stage
+	}
+	pipeline := make([]EventStage, 0, len(names))
+	for _, name := range names {
+		stage, ok := available[name]
+		if !ok {
+			return fmt.Errorf("%w: unknown stage %q", ErrInvalidEventPipeline, name)
+		}
+		if slices.ContainsFunc(pipeline, func(added EventStage) bool { return added.Name() == name }) {
+			return fmt.Errorf("%w: stage %q is listed twice", ErrInvalidEventPipeline, name)
+		}
+		pipeline = append(pipeline, stage)
+	}
+	if !slices.Contains(names, EventStagePersist) {
+		return fmt.Errorf("%w: the %s stage is required", ErrInvalidEventPipeline, EventStagePersist)
+	}
+	s.pipeline = NewEventPipeline(s.logger, pipeline...)
+	return nil
+}
+
+// eventPipeline returns the configured pipeline, or one of DefaultEventStages
+func (s *eventsService) eventPipeline() *EventPipeline {
+	if s.pipeline != nil {
+		return s.pipeline
+	}
+	builtin := s.builtinStages()
+	stages := make([]EventStage, 0, len(DefaultEventStages))
+	for _, name := range DefaultEventStages {
+		stages = append(stages, builtin[name])
+	}
+	return NewEventPipeline(s.logger, stages...)
+}
+
+// builtinStages returns the stages the events service implements itself, by name. validate
+// and persist are critical; metric is not.
+func (s *eventsService) builtinStages() map[string]EventStage {
+	return map[string]EventStage{
+		EventStageValidate: NewEventStage(EventStageValidate, true, func(_ context.Context, state *EventPipelineState) error {
+			_, err := models.NewCreateEventParams(state.TenantID, state.Params.ProviderID, state.Params.EventType, state.Params.EventID, state.Params.Data)
+			return err
+		}),
+		EventStagePersist: NewEventStage(EventStagePersist, true, func(ctx context.Context, state *EventPipelineState) error {
+			var err error
+			if state.Idempotent {
+				state.Event, err = s.eventsRepository.CreateEventIdempotent(ctx, state.Params, state.TenantID)
+			} else {
+				state.Event, err = s.eventsRepository.CreateEvent(ctx, state.Params, state.TenantID)
+			}
+			return err
+		}),
+		EventStageMetric: NewEventStage(EventStageMetric, false, func(ctx context.Context, state *EventPipelineState) error {
+			s.recordIngestionLag(ctx, state.Event)
+			return nil
+		}),
+	}
+}
+
+// unknownProviderType labels the ingestion lag of events whose provider type could not be read
+const unknownProviderType = "unknown"
+
+// recordIngestionLag records how long after the provider's own timestamp a newly stored event
+// was ingested, labeled by provider type. An event without a provider timestamp is counted as
+// missing one instead.
+func (s *eventsService) recordIngestionLag(ctx context.Context, event models.Event) {
+	providerType := s.providerType(ctx, event.ProviderID)
+	occurredAt, ok := models.ProviderEventTime(event.Data)
+	if !ok {
+		metrics.RecordMissingIngestionTimestamp(providerType)
+		return
+	}
+	s.ingestionLag.Record(providerType, event.CreatedAt.Sub(occurredAt))
+}
+
+// providerType returns the provider's type, reading it once per provider
+func (s *eventsService) providerType(ctx context.Context, providerID uuid.UUID) string {
+	if providerType, ok := s.providerTypes.Load(providerID); ok {
+		return providerType.(string)
+	}
+	providerType, err := s.providersRepository.GetProviderType(ctx, providerID)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Failed to read provider type for ingestion lag", "error", err, "provider_id", providerID)
+		return unknownProviderType
+	}
+	s.providerTypes.Store(providerID, providerType)
+	return providerType
 }
 
 func (s *eventsService) DeleteEvent(ctx context.Context, eventID uuid.UUID, tenantID uuid.UUID) (int64, error) {
 	return s.eventsRepository.DeleteEvent(ctx, eventID, tenantID)
 }
 
+// GetEventsBySource returns a page of the tenant's events that entered the system through
+// source, newest first, for tracing a data quality problem back to its ingestion path.
+func (s *eventsService) GetEventsBySource(ctx context.Context, tenantID uuid.UUID, source models.EventSourceEnum, params models.PaginationParams) (models.PaginatedResponse[models.Event], error) {
+	return s.eventsRepository.GetEventsBySource(ctx, tenantID, source, params)
+}
+
+// GetEventsWithoutLeak returns a page of the tenant's events of eventType that no leak is
+// linked to, newest first. For payment_failed it lists the failures detection has not
+// explained, which is what a detection coverage report needs.
+func (s *eventsService) GetEventsWithoutLeak(ctx context.Context, tenantID uuid.UUID, eventType models.EventTypeEnum, params models.PaginationParams) (models.PaginatedResponse[models.Event], error) {
+	return s.eventsRepository.GetEventsWithoutLeak(ctx, tenantID, eventType, params)
+}
+
+// CreateEventsBatch stores several events, each checked like CreateEventIdempotent, and reports
+// the outcome of each. policy limits the events per transaction; see BatchPolicy.
+func (s *eventsService) CreateEventsBatch(ctx context.Context, args []models.CreateEventParams, tenantID uuid.UUID, policy models.BatchPolicy) ([]models.BatchEventResult, error) {
+	results, err := s.eventsRepository.CreateEventsBatch(ctx, args, tenantID, policy)
+	for _, result := range results {
+		if result.Err == nil {
+			s.recordIngestionLag(ctx, result.Event)
+		}
+	}
+	return results, err
+}
+
+// DeleteEventIdempotent deletes an event, treating an already-deleted event as success.
+func (s *eventsService) DeleteEventIdempotent(ctx context.Context, eventID uuid.UUID, tenantID uuid.UUID) (int64, error) {
+	return s.eventsRepository.DeleteEventIdempotent(ctx, eventID, tenantID)
+}
+
 func (s *eventsService) GetAllEvents(ctx context.Context, tenantID uuid.UUID) ([]models.Event, error) {
 	return s.eventsRepository.GetAllEvents(ctx, tenantID)
 }
@@ -63,14 +258,235 @@ func (s *eventsService) GetAllEventsPaginated(ctx context.Context, tenantID uuid
 	return s.eventsRepository.GetAllEventsPaginated(ctx, tenantID, params)
 }
 
+// ListEvents retrieves a page of the events matching filter, newest first.
+func (s *eventsService) ListEvents(ctx context.Context, tenantID uuid.UUID, filter models.EventFilter, params models.PaginationParams) (models.PaginatedResponse[models.Event], error) {
+	return s.eventsRepository.ListEvents(ctx, tenantID, filter, params)
+}
+
+// ListEventsForExport returns up to limit of the tenant's events after the cursor, newest first,
+// for streaming an export in keyset pages.
+func (s *eventsService) ListEventsForExport(ctx context.Context, tenantID uuid.UUID, after models.EventCursor, limit int32) ([]models.Event, error) {
+	return s.eventsRepository.ListEventsForExport(ctx, tenantID, after, limit)
+}
+
 func (s *eventsService) GetEventByID(ctx context.Context, eventID uuid.UUID, tenantID uuid.UUID) (models.Event, error) {
 	return s.eventsRepository.GetEventByID(ctx, eventID, tenantID)
 }
 
+// GetEventsByIDs retrieves several events in one round trip, keyed by ID.
+func (s *eventsService) GetEventsByIDs(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) (map[uuid.UUID]models.Event, error) {
+	return s.eventsRepository.GetEventsByIDs(ctx, tenantID, ids)
+}
+
 func (s *eventsService) UpdateEvent(ctx context.Context, args models.UpdateEventParams, tenantID uuid.UUID) (models.Event, error) {
 	return s.eventsRepository.UpdateEvent(ctx, args, tenantID)
 }
 
+// GetEventsByLeakID retrieves the events that triggered a leak, oldest first.
+func (s *eventsService) GetEventsByLeakID(ctx context.Context, leakID uuid.UUID, tenantID uuid.UUID) ([]models.Event, error) {
+	return s.eventsRepository.GetEventsByLeakID(ctx, leakID, tenantID)
+}
+
+// MaxExternalIDPrefixLength caps the prefix SearchEventsByExternalID accepts; provider event
+// IDs are far shorter, so a longer prefix is a client mistake rather than a real search
+const MaxExternalIDPrefixLength = 100
+
+// SearchEventsByExternalID returns the tenant's events whose provider event ID starts with
+// prefix. An empty prefix, or one longer than MaxExternalIDPrefixLength, fails with
+// ErrInvalidSearchPrefix.
+func (s *eventsService) SearchEventsByExternalID(ctx context.Context, tenantID uuid.UUID, prefix string, params models.PaginationParams) (models.PaginatedResponse[models.Event], error) {
+	if prefix == "" {
+		return models.PaginatedResponse[models.Event]{}, fmt.Errorf("%w: prefix must not be empty", ErrInvalidSearchPrefix)
+	}
+	if n := utf8.RuneCountInString(prefix); n > MaxExternalIDPrefixLength {
+		return models.PaginatedResponse[models.Event]{}, fmt.Errorf("%w: prefix is %d characters, at most %d allowed", ErrInvalidSearchPrefix, n, MaxExternalIDPrefixLength)
+	}
+	return s.eventsRepository.SearchEventsByExternalID(ctx, tenantID, prefix, params)
+}
+
+// MaxRecentEvents caps how many events GetRecentEvents returns
+const MaxRecentEvents = 100
+
+// GetRecentEvents returns the tenant's n newest events, newest first. n above MaxRecentEvents
+// is capped; n below 1 fails with models.ErrInvalidLimit.
+func (s *eventsService) GetRecentEvents(ctx context.Context, tenantID uuid.UUID, n int) ([]models.Event, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("%w: n must be at least 1, got %d", models.ErrInvalidLimit, n)
+	}
+	return s.eventsRepository.GetRecentEvents(ctx, tenantID, min(n, MaxRecentEvents))
+}
+
+// GetPendingEventsOldestFirst claims up to limit of the tenant's pending events, oldest first,
+// moving them to processing. Concurrent callers never receive the same event.
+func (s *eventsService) GetPendingEventsOldestFirst(ctx context.Context, tenantID uuid.UUID, limit int) ([]models.Event, error) {
+	return s.eventsRepository.GetPendingEventsOldestFirst(ctx, tenantID, limit)
+}
+
+// GetEventStatusHistory returns the status changes of an event, oldest first.
+func (s *eventsService) GetEventStatusHistory(ctx context.Context, eventID uuid.UUID, tenantID uuid.UUID) ([]models.EventStatusChange, error) {
+	return s.eventsRepository.GetEventStatusHistory(ctx, eventID, tenantID)
+}
+
+// GetRelatedEvents retrieves the events correlated with an event under the given rule, across providers.
+func (s *eventsService) GetRelatedEvents(ctx context.Context, tenantID uuid.UUID, eventID uuid.UUID, rule models.CorrelationRule) ([]models.Event, error) {
+	return s.eventsRepository.GetRelatedEvents(ctx, tenantID, eventID, rule)
+}
+
+// UpdateEventIfVersion updates an event only if its updated_at still equals expectedUpdatedAt.
+func (s *eventsService) UpdateEventIfVersion(ctx context.Context, args models.UpdateEventParams, expectedUpdatedAt time.Time, tenantID uuid.UUID) (models.Event, error) {
+	return s.eventsRepository.UpdateEventIfVersion(ctx, args, expectedUpdatedAt, tenantID)
+}
+
+// UpdateEventStatusByFilter sets the status of every event matching filter; an empty filter is refused.
+func (s *eventsService) UpdateEventStatusByFilter(ctx context.Context, tenantID uuid.UUID, filter models.EventFilter, newStatus models.EventStatusEnum) (int64, error) {
+	return s.eventsRepository.UpdateEventStatusByFilter(ctx, tenantID, filter, newStatus)
+}
+
+// ReattributeEvents moves the tenant's events from one provider to another in a single
+// transaction, after checking both providers belong to the tenant. It returns how many moved.
+func (s *eventsService) ReattributeEvents(ctx context.Context, tenantID uuid.UUID, fromProviderID uuid.UUID, toProviderID uuid.UUID) (int64, error) {
+	return s.eventsRepository.ReattributeEvents(ctx, tenantID, fromProviderID, toProviderID)
+}
+
 func (s *eventsService) CountAllEvents(ctx context.Context, tenantID uuid.UUID) (int64, error) {
 	return s.eventsRepository.CountAllEvents(ctx, tenantID)
 }
+
+// HasAnyEvents reports whether the tenant has ingested at least one event
+func (s *eventsService) HasAnyEvents(ctx context.Context, tenantID uuid.UUID) (bool, error) {
+	return s.eventsRepository.HasAnyEvents(ctx, tenantID)
+}
+
+// CountEventsByStatusForProvider counts a provider's events per status, including statuses with no events.
+func (s *eventsService) CountEventsByStatusForProvider(ctx context.Context, tenantID uuid.UUID, providerID uuid.UUID) (map[models.EventStatusEnum]int64, error) {
+	return s.eventsRepository.CountEventsByStatusForProvider(ctx, tenantID, providerID)
+}
+
+// GetEventCountInWindow counts the tenant's events created in [from, to).
+func (s *eventsService) GetEventCountInWindow(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) (int64, error) {
+	return s.eventsRepository.GetEventCountInWindow(ctx, tenantID, from, to)
+}
+
+// GetIngestionLag returns, by provider type, how many of the tenant's events were created in
+// [from, to), how many of them had no provider timestamp and the 95th percentile lag between
+// that timestamp and ingestion.
+func (s *eventsService) GetIngestionLag(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) ([]models.IngestionLag, error) {
+	return s.eventsRepository.GetIngestionLag(ctx, tenantID, from, to)
+}
+
+// GetProviderFailureRates returns, per provider, how many payment attempts the tenant had in
+// [from, to) and how many of them failed.
+func (s *eventsService) GetProviderFailureRates(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) ([]models.ProviderFailureRate, error) {
+	return s.eventsRepository.GetProviderFailureRates(ctx, tenantID, from, to)
+}
+
+// FindDuplicateCharges returns the tenant's payment_succeeded events created since since that
+// repeat an earlier charge of the same customer, amount and currency at most window after it.
+func (s *eventsService) FindDuplicateCharges(ctx context.Context, tenantID uuid.UUID, since time.Time, window time.Duration) ([]models.DuplicateCharge, error) {
+	return s.eventsRepository.FindDuplicateCharges(ctx, tenantID, since, window)
+}
+
+// FindDunningGaps returns the tenant's payment_failed events that no retry or payment update
+// for the same customer followed within the dunning window, as of now.
+func (s *eventsService) FindDunningGaps(ctx context.Context, tenantID uuid.UUID, now time.Time, defaultWindow time.Duration, lookback time.Duration) ([]models.DunningGap, error) {
+	return s.eventsRepository.FindDunningGaps(ctx, tenantID, now, defaultWindow, lookback)
+}
+
+// GetEventRetentionPolicies returns every tenant with its own event retention override, if any.
+func (s *eventsService) GetEventRetentionPolicies(ctx context.Context) ([]models.EventRetentionPolicy, error) {
+	return s.eventsRepository.GetEventRetentionPolicies(ctx)
+}
+
+// ArchiveEventsBefore writes the tenant's events created before before to w as NDJSON, without deleting them.
+func (s *eventsService) ArchiveEventsBefore(ctx context.Context, tenantID uuid.UUID, before time.Time, w io.Writer) (int64, error) {
+	return s.eventsRepository.ArchiveEventsBefore(ctx, tenantID, before, w)
+}
+
+// PurgeEventsBefore deletes up to limit of the tenant's events created before before, oldest first.
+func (s *eventsService) PurgeEventsBefore(ctx context.Context, tenantID uuid.UUID, before time.Time, limit int32) (int64, error) {
+	return s.eventsRepository.PurgeEventsBefore(ctx, tenantID, before, limit)
+}
+
+// validateStoredEventsPageSize is how many events ValidateStoredEvents reads at a time
+const validateStoredEventsPageSize = 500
+
+// ValidateStoredEvents re-runs the current ingestion validation over the tenant's stored events
+// matching params.Filter and reports the ones it rejects, to find events accepted under older,
+// looser rules. The events are read a page at a time in ID order and never modified.
+func (s *eventsService) ValidateStoredEvents(ctx context.Context, tenantID uuid.UUID, params models.ValidateStoredEventsParams) (models.StoredEventsValidation, error) {
+	report := models.StoredEventsValidation{Invalid: []models.InvalidStoredEvent{}}
+
+	after := uuid.Nil
+	for {
+		pageSize := validateStoredEventsPageSize
+		if params.MaxEvents > 0 {
+			// One more than remains, to tell whether the cap cut the pass short
+			pageSize = min(pageSize, params.MaxEvents-report.Scanned+1)
+		}
+		events, err := s.eventsRepository.ListEventsAfter(ctx, tenantID, params.Filter, after, int32(pageSize))
+		if err != nil {
+			return report, err
+		}
+
+		for _, event := range events {
+			if params.MaxEvents > 0 && report.Scanned >= params.MaxEvents {
+				report.Truncated = true
+				break
+			}
+			if err := models.ValidateStoredEvent(event); err != nil {
+				report.Invalid = append(report.Invalid, models.InvalidStoredEvent{ID: event.ID, Reason: err.Error()})
+			}
+			report.Scanned++
+			after = event.ID
+		}
+
+		if report.Truncated || len(events) < pageSize {
+			break
+		}
+	}
+
+	s.logger.InfoContext(ctx, "Validated stored events", "tenant_id", tenantID, "scanned", report.Scanned, "invalid", len(report.Invalid), "truncated", report.Truncated)
+	return report, nil
+}
+
+// ReprocessFailedEvents makes one bounded pass over the tenant's failed events: it takes up
+// to limit of them with an ID after cursor, re-runs validation on each and marks the ones
+// that pass as processed. Events that still fail validation keep the failed status.
+//
+// The update is conditional on the event not having changed since it was read, so an event
+// another writer touched in the meantime is left alone and counted as still failed. On an
+// error the result covers the events handled so far, and its NextCursor resumes after them.
+func (s *eventsService) ReprocessFailedEvents(ctx context.Context, tenantID uuid.UUID, cursor uuid.UUID, limit int) (models.ReprocessResult, error) {
+	result := models.ReprocessResult{NextCursor: cursor}
+
+	events, err := s.eventsRepository.GetFailedEvents(ctx, tenantID, cursor, int32(limit))
+	if err != nil {
+		return result, err
+	}
+	result.Done = len(events) < limit
+
+	processed := models.EventStatusEnumProcessed
+	for _, event := range events {
+		if err := models.ValidateStoredEvent(event); err != nil {
+			s.logger.InfoContext(ctx, "Failed event is still invalid", "event_id", event.ID, "tenant_id", tenantID, "reason", err)
+			result.StillFailed++
+		} else {
+			_, err := s.eventsRepository.UpdateEventIfVersion(ctx, models.UpdateEventParams{ID: event.ID, Status: &processed}, event.UpdatedAt, tenantID)
+			switch {
+			case errors.Is(err, ErrConcurrentModification), errors.Is(err, ErrEventNotFound):
+				s.logger.InfoContext(ctx, "Failed event changed while reprocessing, leaving it", "event_id", event.ID, "tenant_id", tenantID)
+				result.StillFailed++
+			case err != nil:
+				result.Done = false
+				return result, err
+			default:
+				result.Reprocessed++
+			}
+		}
+		result.Scanned++
+		result.NextCursor = event.ID
+	}
+
+	s.logger.InfoContext(ctx, "Reprocessed failed events", "tenant_id", tenantID, "scanned", result.Scanned, "reprocessed", result.Reprocessed, "still_failed", result.StillFailed, "done", result.Done)
+	return result, nil
+}
@@ -0,0 +1,340 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/metrics"
+)
+
+// fakeEventsRepository keeps events in memory; methods not overridden panic via the nil embedded interface
+type fakeEventsRepository struct {
+	EventsRepository
+	events map[uuid.UUID]models.Event
+	// touched events are modified by a concurrent writer just before a versioned update
+	touched map[uuid.UUID]bool
+}
+
+func (r *fakeEventsRepository) GetFailedEvents(_ context.Context, _ uuid.UUID, after uuid.UUID, limit int32) ([]models.Event, error) {
+	var failed []models.Event
+	for _, event := range r.events {
+		if event.Status == models.EventStatusEnumFailed && event.ID.String() > after.String() {
+			failed = append(failed, event)
+		}
+	}
+	slices.SortFunc(failed, func(a, b models.Event) int { return strings.Compare(a.ID.String(), b.ID.String()) })
+	return failed[:min(len(failed), int(limit))], nil
+}
+
+func (r *fakeEventsRepository) ListEventsAfter(_ context.Context, _ uuid.UUID, filter models.EventFilter, after uuid.UUID, limit int32) ([]models.Event, error) {
+	var matching []models.Event
+	for _, event := range r.events {
+		if event.ID.String() > after.String() && (len(filter.Statuses) == 0 || slices.Contains(filter.Statuses, event.Status)) {
+			matching = append(matching, event)
+		}
+	}
+	slices.SortFunc(matching, func(a, b models.Event) int { return strings.Compare(a.ID.String(), b.ID.String()) })
+	return matching[:min(len(matching), int(limit))], nil
+}
+
+func (r *fakeEventsRepository) UpdateEventIfVersion(_ context.Context, args models.UpdateEventParams, expectedUpdatedAt time.Time, _ uuid.UUID) (models.Event, error) {
+	event, ok := r.events[args.ID]
+	if !ok {
+		return models.Event{}, ErrEventNotFound
+	}
+	if r.touched[args.ID] || !event.UpdatedAt.Equal(expectedUpdatedAt) {
+		return models.Event{}, ErrConcurrentModification
+	}
+	event.Status = *args.Status
+	event.UpdatedAt = event.UpdatedAt.Add(time.Second)
+	r.events[args.ID] = event
+	return event, nil
+}
+
+func newFailedEvent(tenantID uuid.UUID, eventID string, data string) models.Event {
+	raw := json.RawMessage(data)
+	return models.Event{
+		ID:         uuid.New(),
+		TenantID:   tenantID,
+		ProviderID: uuid.New(),
+		EventType:  models.EventTypeEnumPaymentFailed,
+		EventID:    eventID,
+		Status:     models.EventStatusEnumFailed,
+		Data:       &raw,
+		UpdatedAt:  time.Now().UTC(),
+	}
+}
+
+func TestReprocessFailedEvents(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	good := []models.Event{
+		newFailedEvent(tenantID, "evt_good_1", `{"amount":100}`),
+		newFailedEvent(tenantID, "evt_good_2", `{"amount":250}`),
+	}
+	bad := []models.Event{
+		newFailedEvent(tenantID, "evt_array", `[1,2]`),
+		newFailedEvent(tenantID, "evt_null", `null`),
+		newFailedEvent(tenantID, " ", `{"amount":5}`),
+	}
+	raced := newFailedEvent(tenantID, "evt_raced", `{"amount":7}`)
+
+	repo := &fakeEventsRepository{events: map[uuid.UUID]models.Event{}, touched: map[uuid.UUID]bool{raced.ID: true}}
+	for _, event := range slices.Concat(good, bad, []models.Event{raced}) {
+		repo.events[event.ID] = event
+	}
+	svc := &eventsService{eventsRepository: repo, logger: newTestLogger()}
+
+	// Work through the backlog two events at a time, resuming from each cursor
+	var total models.ReprocessResult
+	cursor := uuid.Nil
+	for calls := 0; ; calls++ {
+		require.Less(t, calls, 10, "reprocessing did not finish")
+		result, err := svc.ReprocessFailedEvents(ctx, tenantID, cursor, 2)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, result.Scanned, 2)
+
+		total.Scanned += result.Scanned
+		total.Reprocessed += result.Reprocessed
+		total.StillFailed += result.StillFailed
+		if result.Done {
+			break
+		}
+		cursor = result.NextCursor
+	}
+
+	assert.Equal(t, 6, total.Scanned)
+	assert.Equal(t, 2, total.Reprocessed)
+	assert.Equal(t, 4, total.StillFailed)
+	for _, event := range good {
+		assert.Equal(t, models.EventStatusEnumProcessed, repo.events[event.ID].Status, event.EventID)
+	}
+	for _, event := range append(bad, raced) {
+		assert.Equal(t, models.EventStatusEnumFailed, repo.events[event.ID].Status, event.EventID)
+	}
+}
+
+func TestReprocessFailedEvents_RepositoryError(t *testing.T) {
+	errDB := errors.New("connection refused")
+	svc := &eventsService{eventsRepository: &failingEventsRepository{err: errDB}, logger: newTestLogger()}
+
+	result, err := svc.ReprocessFailedEvents(context.Background(), uuid.New(), uuid.Nil, 10)
+
+	assert.ErrorIs(t, err, errDB)
+	assert.False(t, result.Done)
+	assert.Equal(t, uuid.Nil, result.NextCursor)
+}
+
+func TestValidateStoredEvents(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	compliant := newFailedEvent(tenantID, "evt_good", `{"amount":100}`)
+	compliant.Status = models.EventStatusEnumProcessed
+	array := newFailedEvent(tenantID, "evt_array", `[1,2]`)
+	blankID := newFailedEvent(tenantID, " ", `{"amount":5}`)
+	badType := newFailedEvent(tenantID, "evt_type", `{"amount":5}`)
+	badType.EventType = "payment_disputed"
+
+	repo := &fakeEventsRepository{events: map[uuid.UUID]models.Event{}}
+	for _, event := range []models.Event{compliant, array, blankID, badType} {
+		repo.events[event.ID] = event
+	}
+	before := maps.Clone(repo.events)
+	svc := &eventsService{eventsRepository: repo, logger: newTestLogger()}
+
+	t.Run("reports events current validation rejects", func(t *testing.T) {
+		report, err := svc.ValidateStoredEvents(ctx, tenantID, models.ValidateStoredEventsParams{})
+		require.NoError(t, err)
+		assert.Equal(t, 4, report.Scanned)
+		assert.False(t, report.Truncated)
+
+		reasons := map[uuid.UUID]string{}
+		for _, invalid := range report.Invalid {
+			reasons[invalid.ID] = invalid.Reason
+		}
+		assert.Len(t, reasons, 3)
+		assert.NotContains(t, reasons, compliant.ID)
+		assert.Equal(t, models.ErrInvalidEventData.Error(), reasons[array.ID])
+		assert.Equal(t, models.ErrMissingExternalID.Error(), reasons[blankID.ID])
+		assert.Equal(t, models.ErrInvalidEventType.Error(), reasons[badType.ID])
+		assert.Equal(t, before, repo.events, "expected the events to be left untouched")
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		report, err := svc.ValidateStoredEvents(ctx, tenantID, models.ValidateStoredEventsParams{
+			Filter: models.EventFilter{Statuses: []models.EventStatusEnum{models.EventStatusEnumProcessed}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Scanned)
+		assert.Empty(t, report.Invalid)
+	})
+
+	t.Run("max events", func(t *testing.T) {
+		report, err := svc.ValidateStoredEvents(ctx, tenantID, models.ValidateStoredEventsParams{MaxEvents: 2})
+		require.NoError(t, err)
+		assert.Equal(t, 2, report.Scanned)
+		assert.True(t, report.Truncated)
+
+		report, err = svc.ValidateStoredEvents(ctx, tenantID, models.ValidateStoredEventsParams{MaxEvents: 4})
+		require.NoError(t, err)
+		assert.Equal(t, 4, report.Scanned)
+		assert.False(t, report.Truncated, "a cap the events fit in should not truncate")
+	})
+}
+
+// recentEventsRepository records the count it is asked for
+type recentEventsRepository struct {
+	EventsRepository
+	n int
+}
+
+func (r *recentEventsRepository) GetRecentEvents(_ context.Context, _ uuid.UUID, n int) ([]models.Event, error) {
+	r.n = n
+	return []models.Event{}, nil
+}
+
+func TestGetRecentEvents_CapsN(t *testing.T) {
+	repo := &recentEventsRepository{}
+	svc := &eventsService{eventsRepository: repo, logger: newTestLogger()}
+
+	_, err := svc.GetRecentEvents(context.Background(), uuid.New(), 10)
+	require.NoError(t, err)
+	assert.Equal(t, 10, repo.n)
+
+	_, err = svc.GetRecentEvents(context.Background(), uuid.New(), MaxRecentEvents+1)
+	require.NoError(t, err)
+	assert.Equal(t, MaxRecentEvents, repo.n)
+
+	_, err = svc.GetRecentEvents(context.Background(), uuid.New(), 0)
+	assert.ErrorIs(t, err, models.ErrInvalidLimit)
+}
+
+// ingestingEventsRepository stores every event at ingestedAt with the params' data
+type ingestingEventsRepository struct {
+	EventsRepository
+	ingestedAt time.Time
+}
+
+func (r *ingestingEventsRepository) event(args models.CreateEventParams) models.Event {
+	data := json.RawMessage(args.Data.(string))
+	return models.Event{ID: uuid.New(), ProviderID: args.ProviderID, Data: &data, CreatedAt: r.ingestedAt}
+}
+
+func (r *ingestingEventsRepository) CreateEvent(_ context.Context, args models.CreateEventParams, _ uuid.UUID) (models.Event, error) {
+	return r.event(args), nil
+}
+
+func (r *ingestingEventsRepository) CreateEventIdempotent(_ context.Context, args models.CreateEventParams, _ uuid.UUID) (models.Event, error) {
+	if args.EventID == "evt_duplicate" {
+		return models.Event{}, ErrEventAlreadyExists
+	}
+	return r.event(args), nil
+}
+
+func (r *ingestingEventsRepository) CreateEventsBatch(_ context.Context, args []models.CreateEventParams, _ uuid.UUID, _ models.BatchPolicy) ([]models.BatchEventResult, error) {
+	results := make([]models.BatchEventResult, len(args))
+	for i, arg := range args {
+		results[i] = models.BatchEventResult{Event: r.event(arg)}
+	}
+	return results, nil
+}
+
+func TestCreateEvent_RecordsIngestionLag(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	stripe, webhook, missing := uuid.New(), uuid.New(), uuid.New()
+	ingestedAt := time.Unix(1717243200, 0)
+	repo := &ingestingEventsRepository{ingestedAt: ingestedAt}
+	providers := &fakeProvidersRepository{types: map[uuid.UUID]string{stripe: "lag_test_stripe", webhook: "lag_test_webhook"}}
+	svc := &eventsService{eventsRepository: repo, providersRepository: providers, logger: newTestLogger()}
+
+	params := func(providerID uuid.UUID, eventID string, data string) models.CreateEventParams {
+		return models.CreateEventParams{ProviderID: providerID, EventType: models.EventTypeEnumPaymentFailed, EventID: eventID, Data: data}
+	}
+	createdAgo := func(d time.Duration) string {
+		return fmt.Sprintf(`{"created":%d}`, ingestedAt.Add(-d).Unix())
+	}
+
+	_, err := svc.CreateEventIdempotent(ctx, params(stripe, "evt_1", createdAgo(90*time.Second)), tenantID)
+	require.NoError(t, err)
+	_, err = svc.CreateEvent(ctx, params(stripe, "evt_2", createdAgo(2*time.Hour)), tenantID)
+	require.NoError(t, err)
+	_, err = svc.CreateEventsBatch(ctx, []models.CreateEventParams{
+		params(webhook, "evt_3", createdAgo(3*time.Second)),
+		params(webhook, "evt_4", `{"amount":100}`),
+	}, tenantID, models.BatchPolicy{})
+	require.NoError(t, err)
+	_, err = svc.CreateEventIdempotent(ctx, params(stripe, "evt_duplicate", createdAgo(time.Hour)), tenantID)
+	require.ErrorIs(t, err, ErrEventAlreadyExists)
+	_, err = svc.CreateEvent(ctx, params(missing, "evt_5", createdAgo(time.Second)), tenantID)
+	require.NoError(t, err)
+
+	stripeLag := svc.ingestionLag.Histogram("lag_test_stripe")
+	assert.EqualValues(t, 2, stripeLag.Count(), "a duplicate is not ingested, so has no lag")
+	assert.Equal(t, 2*time.Hour+90*time.Second, stripeLag.Sum())
+	assert.EqualValues(t, 0, stripeLag.CountAtMost(time.Minute))
+	assert.EqualValues(t, 1, stripeLag.CountAtMost(5*time.Minute))
+
+	webhookLag := svc.ingestionLag.Histogram("lag_test_webhook")
+	assert.EqualValues(t, 1, webhookLag.Count())
+	assert.Equal(t, 3*time.Second, webhookLag.Sum())
+	assert.EqualValues(t, 1, metrics.MissingIngestionTimestampCount("lag_test_webhook"))
+
+	assert.Equal(t, 3, providers.typeLookups, "each provider's type is read once, then cached")
+	assert.NotZero(t, svc.ingestionLag.Histogram(unknownProviderType).Count(), "a provider whose type cannot be read is labeled unknown")
+}
+
+// searchEventsRepository records the prefix it was asked to search for
+type searchEventsRepository struct {
+	EventsRepository
+	prefix string
+}
+
+func (r *searchEventsRepository) SearchEventsByExternalID(_ context.Context, _ uuid.UUID, prefix string, params models.PaginationParams) (models.PaginatedResponse[models.Event], error) {
+	r.prefix = prefix
+	return models.NewPaginatedResponse([]models.Event{}, 0, params.Limit, params.Offset), nil
+}
+
+func TestSearchEventsByExternalID_ValidatesPrefix(t *testing.T) {
+	repo := &searchEventsRepository{}
+	svc := &eventsService{eventsRepository: repo, logger: newTestLogger()}
+	params := models.PaginationParams{Limit: 10}
+
+	_, err := svc.SearchEventsByExternalID(context.Background(), uuid.New(), "evt_", params)
+	require.NoError(t, err)
+	assert.Equal(t, "evt_", repo.prefix)
+
+	_, err = svc.SearchEventsByExternalID(context.Background(), uuid.New(), strings.Repeat("é", MaxExternalIDPrefixLength), params)
+	assert.NoError(t, err, "expected the cap to count characters, not bytes")
+
+	repo.prefix = ""
+	for _, prefix := range []string{"", strings.Repeat("x", MaxExternalIDPrefixLength+1)} {
+		_, err = svc.SearchEventsByExternalID(context.Background(), uuid.New(), prefix, params)
+		assert.ErrorIs(t, err, ErrInvalidSearchPrefix)
+	}
+	assert.Empty(t, repo.prefix, "expected invalid prefixes never to reach the repository")
+}
+
+// failingEventsRepository fails every read of failed events
+type failingEventsRepository struct {
+	EventsRepository
+	err error
+}
+
+func (r *failingEventsRepository) GetFailedEvents(context.Context, uuid.UUID, uuid.UUID, int32) ([]models.Event, error) {
+	return nil, r.err
+}
@@ -2,8 +2,11 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"rdl-api/internal/db/repository"
+	"slices"
+	"sync/atomic"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -12,16 +15,70 @@ import (
 type HealthService interface {
 	CheckReadiness(ctx context.Context) error
 	CheckLiveness(ctx context.Context) error
+	CheckHealth(ctx context.Context) HealthReport
 	GetVersion() string
+	BeginShutdown()
+	ShuttingDown() bool
+}
+
+// Components checked by CheckHealth
+const (
+	// ComponentDatabase is the primary database, which every write goes to
+	ComponentDatabase = "database"
+	// ComponentReplica is the read replica; it is only checked when one is configured
+	ComponentReplica = "replica"
+)
+
+// Overall statuses of a HealthReport
+const (
+	// HealthStatusOK means every component is up
+	HealthStatusOK = "ok"
+	// HealthStatusDegraded means a non-critical component is down; the service still works,
+	// e.g. reads fail over or slow down, but is not fully healthy
+	HealthStatusDegraded = "degraded"
+	// HealthStatusDown means a critical component is down
+	HealthStatusDown = "down"
+)
+
+// DefaultCriticalComponents are the critical components when none are configured
+var DefaultCriticalComponents = []string{ComponentDatabase}
+
+// ComponentHealth is the result of checking one component
+type ComponentHealth struct {
+	Name     string
+	Critical bool
+	// Err is nil when the component is up
+	Err error
+}
+
+// HealthReport is the status of every component and the overall status they add up to
+type HealthReport struct {
+	Status     string
+	Components []ComponentHealth
+}
+
+// healthComponent is a component CheckHealth pings
+type healthComponent struct {
+	name string
+	repo HealthRepository
 }
 
 type healthService struct {
 	healthRepo HealthRepository
-	logger     *slog.Logger
-	version    string
+	// replicaRepo pings the read replica; nil when reads go to the primary
+	replicaRepo HealthRepository
+	// critical lists the components whose failure makes the service down; nil means DefaultCriticalComponents
+	critical []string
+	logger   *slog.Logger
+	version  string
+	// shuttingDown is set by BeginShutdown; shared by the copies of the service
+	shuttingDown *atomic.Bool
 }
 
-func NewHealthService(p *pgxpool.Pool, logger *slog.Logger, version string) (HealthService, error) {
+// NewHealthService creates the health service. readPool is checked as the replica component
+// unless it is nil or the primary pool itself. criticalComponents names the components whose
+// failure makes the service down; nil means DefaultCriticalComponents.
+func NewHealthService(p *pgxpool.Pool, readPool *pgxpool.Pool, logger *slog.Logger, version string, criticalComponents []string) (HealthService, error) {
 	if logger == nil {
 		return nil, ErrLoggerCannotBeNil
 	}
@@ -32,24 +89,78 @@ func NewHealthService(p *pgxpool.Pool, logger *slog.Logger, version string) (Hea
 	if err != nil {
 		return nil, err
 	}
-	return healthService{
-		healthRepo: &healthRepo,
-		logger:     logger,
-		version:    version,
-	}, nil
+	service := healthService{
+		healthRepo:   &healthRepo,
+		critical:     criticalComponents,
+		logger:       logger,
+		version:      version,
+		shuttingDown: &atomic.Bool{},
+	}
+	if readPool != nil && readPool != p {
+		replicaRepo, err := repository.NewHealthRepositoryImplementation(readPool, logger)
+		if err != nil {
+			return nil, err
+		}
+		service.replicaRepo = &replicaRepo
+	}
+	return service, nil
 }
 
+// CheckReadiness fails with ErrShuttingDown once BeginShutdown has been called, and with
+// ErrDatabaseUnavailable when a critical component is down.
+// A degraded service is still ready; CheckHealth tells the two apart.
 func (h healthService) CheckReadiness(ctx context.Context) error {
+	if h.ShuttingDown() {
+		return ErrShuttingDown
+	}
+	report := h.CheckHealth(ctx)
+	if report.Status != HealthStatusDown {
+		return nil
+	}
+	for _, component := range report.Components {
+		if component.Critical && component.Err != nil {
+			return fmt.Errorf("%w: %s", ErrDatabaseUnavailable, component.Name)
+		}
+	}
+	return ErrDatabaseUnavailable
+}
+
+// CheckHealth pings every component and reports the service down when a critical one is down,
+// degraded when only non-critical ones are, and ok otherwise
+func (h healthService) CheckHealth(ctx context.Context) HealthReport {
 
 	// Use a short timeout for readiness checks
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	if err := h.healthRepo.CheckReadiness(ctx); err != nil {
-		return ErrDatabaseUnavailable
+	components := []healthComponent{{name: ComponentDatabase, repo: h.healthRepo}}
+	if h.replicaRepo != nil {
+		components = append(components, healthComponent{name: ComponentReplica, repo: h.replicaRepo})
 	}
 
-	return nil
+	report := HealthReport{Status: HealthStatusOK, Components: make([]ComponentHealth, 0, len(components))}
+	for _, component := range components {
+		result := ComponentHealth{Name: component.name, Critical: h.isCritical(component.name)}
+		if err := component.repo.CheckReadiness(ctx); err != nil {
+			h.logger.WarnContext(ctx, "Health check component is down", "component", component.name, "critical", result.Critical, "error", err)
+			result.Err = err
+			if result.Critical {
+				report.Status = HealthStatusDown
+			} else if report.Status == HealthStatusOK {
+				report.Status = HealthStatusDegraded
+			}
+		}
+		report.Components = append(report.Components, result)
+	}
+	return report
+}
+
+// isCritical reports whether the component's failure makes the service down
+func (h healthService) isCritical(name string) bool {
+	if h.critical == nil {
+		return slices.Contains(DefaultCriticalComponents, name)
+	}
+	return slices.Contains(h.critical, name)
 }
 
 func (h healthService) CheckLiveness(ctx context.Context) error {
@@ -59,3 +170,16 @@ func (h healthService) CheckLiveness(ctx context.Context) error {
 func (h healthService) GetVersion() string {
 	return h.version
 }
+
+// BeginShutdown marks the service as shutting down, so it stops reporting ready while
+// requests are still served. It cannot be undone.
+func (h healthService) BeginShutdown() {
+	if h.shuttingDown != nil {
+		h.shuttingDown.Store(true)
+	}
+}
+
+// ShuttingDown reports whether BeginShutdown has been called
+func (h healthService) ShuttingDown() bool {
+	return h.shuttingDown != nil && h.shuttingDown.Load()
+}
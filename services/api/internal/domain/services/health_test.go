@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"os"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -58,7 +59,7 @@ func TestNewHealthService(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			service, err := NewHealthService(tt.pool, tt.logger, "test")
+			service, err := NewHealthService(tt.pool, nil, tt.logger, "test", nil)
 
 			assert.Equal(t, healthService{}, service)
 			assert.Equal(t, tt.expectedErr, err)
@@ -119,6 +120,51 @@ func TestHealthService_CheckReadiness(t *testing.T) {
 	}
 }
 
+// TestHealthService_CheckHealth tests how component failures add up to the overall status
+func TestHealthService_CheckHealth(t *testing.T) {
+	tests := []struct {
+		name        string
+		primary     HealthRepository
+		replica     HealthRepository
+		critical    []string
+		wantStatus  string
+		wantReadyOK bool
+	}{
+		{"all up", &mockHealthyRepository{}, &mockHealthyRepository{}, nil, HealthStatusOK, true},
+		{"no replica configured", &mockHealthyRepository{}, nil, nil, HealthStatusOK, true},
+		{"replica down is degraded", &mockHealthyRepository{}, &mockUnhealthyRepository{}, nil, HealthStatusDegraded, true},
+		{"critical replica down", &mockHealthyRepository{}, &mockUnhealthyRepository{}, []string{ComponentDatabase, ComponentReplica}, HealthStatusDown, false},
+		{"primary down", &mockUnhealthyRepository{}, &mockHealthyRepository{}, nil, HealthStatusDown, false},
+		{"non-critical primary down", &mockUnhealthyRepository{}, &mockHealthyRepository{}, []string{ComponentReplica}, HealthStatusDegraded, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &healthService{
+				healthRepo:  tt.primary,
+				replicaRepo: tt.replica,
+				critical:    tt.critical,
+				logger:      newTestLogger(),
+			}
+
+			report := service.CheckHealth(context.Background())
+			assert.Equal(t, tt.wantStatus, report.Status)
+			wantComponents := 1
+			if tt.replica != nil {
+				wantComponents = 2
+			}
+			assert.Len(t, report.Components, wantComponents)
+
+			err := service.CheckReadiness(context.Background())
+			if tt.wantReadyOK {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrDatabaseUnavailable)
+			}
+		})
+	}
+}
+
 // TestHealthService_CheckLiveness_ContextCancellation tests context cancellation
 func TestHealthService_CheckLiveness_ContextCancellation(t *testing.T) {
 	service := &healthService{
@@ -136,6 +182,25 @@ func TestHealthService_CheckLiveness_ContextCancellation(t *testing.T) {
 	}
 }
 
+// TestHealthService_BeginShutdown tests that readiness fails once shutdown has begun
+func TestHealthService_BeginShutdown(t *testing.T) {
+	service := healthService{
+		healthRepo:   &mockHealthyRepository{},
+		logger:       newTestLogger(),
+		shuttingDown: &atomic.Bool{},
+	}
+	assert.NoError(t, service.CheckReadiness(context.Background()))
+	assert.False(t, service.ShuttingDown())
+
+	// The flag is shared, so a copy of the service sees it too
+	var copied HealthService = service
+	copied.BeginShutdown()
+
+	assert.True(t, service.ShuttingDown())
+	assert.ErrorIs(t, service.CheckReadiness(context.Background()), ErrShuttingDown)
+	assert.NoError(t, service.CheckLiveness(context.Background()), "a shutting down service is still alive")
+}
+
 // TestHealthService_EdgeCases tests edge cases and error conditions
 func TestHealthService_EdgeCases(t *testing.T) {
 	t.Run("context_with_deadline", func(t *testing.T) {
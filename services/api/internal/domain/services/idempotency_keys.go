@@ -0,0 +1,58 @@
+// Package services provides business logic and orchestration for domain entities.
+// This file implements the IdempotencyKeysService, which keeps idempotent responses in the database.
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"rdl-api/internal/db/repository"
+	"rdl-api/internal/domain/models"
+
+	"github.com/google/uuid"
+)
+
+type IdempotencyKeysService interface {
+	GetIdempotencyRecord(ctx context.Context, tenantID uuid.UUID, key string) (models.IdempotencyRecord, bool, error)
+	SaveIdempotencyRecord(ctx context.Context, record models.IdempotencyRecord) error
+}
+
+type idempotencyKeysService struct {
+	idempotencyKeysRepository IdempotencyKeysRepository
+	logger                    *slog.Logger
+}
+
+// NewIdempotencyKeysService creates an IdempotencyKeysService backed by the provided pool.
+func NewIdempotencyKeysService(pool *repository.Pool, l *slog.Logger) (IdempotencyKeysService, error) {
+	iR, err := repository.NewIdempotencyKeysRepository(pool, l)
+	if err != nil {
+		return nil, err
+	}
+	return &idempotencyKeysService{idempotencyKeysRepository: iR, logger: l}, nil
+}
+
+// GetIdempotencyRecord returns the response stored for the tenant's key, and false when there
+// is none or it has expired
+func (s *idempotencyKeysService) GetIdempotencyRecord(ctx context.Context, tenantID uuid.UUID, key string) (models.IdempotencyRecord, bool, error) {
+	record, err := s.idempotencyKeysRepository.GetIdempotencyRecord(ctx, tenantID, key)
+	if errors.Is(err, repository.ErrIdempotencyKeyNotFound) {
+		return models.IdempotencyRecord{}, false, nil
+	}
+	if err != nil {
+		return models.IdempotencyRecord{}, false, err
+	}
+	return record, true, nil
+}
+
+// SaveIdempotencyRecord stores the response for its key. When another instance stored one for
+// the same key first, that one is kept.
+func (s *idempotencyKeysService) SaveIdempotencyRecord(ctx context.Context, record models.IdempotencyRecord) error {
+	stored, err := s.idempotencyKeysRepository.SaveIdempotencyRecord(ctx, record)
+	if err != nil {
+		return err
+	}
+	if !stored {
+		s.logger.DebugContext(ctx, "Idempotency key already had a response, keeping it", "tenant_id", record.TenantID)
+	}
+	return nil
+}
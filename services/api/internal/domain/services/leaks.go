@@ -0,0 +1,162 @@
+// Package services provides business logic and orchestration for domain entities.
+// This file implements the LeaksService, which handles leak-related operations.
+package services
+
+import (
+	"context"
+	"log/slog"
+	"rdl-api/internal/db/repository"
+	"rdl-api/internal/domain/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type LeaksService interface {
+	CreateLeak(ctx context.Context, args models.CreateLeakParams, tenantID uuid.UUID) (models.Leak, error)
+	UpsertLeakByDedupKey(ctx context.Context, args models.CreateLeakParams, tenantID uuid.UUID) (models.Leak, error)
+	GetLeakByID(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (models.Leak, error)
+	GetMinLeakAmounts(ctx context.Context, tenantID uuid.UUID) (map[string]models.Decimal, error)
+	GetLeakSources(ctx context.Context, tenantID uuid.UUID) (models.LeakSources, error)
+	GetLeakCountInWindow(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) (int64, error)
+	FindExistingLeaks(ctx context.Context, tenantID uuid.UUID, detectedAt time.Time, sourceEventIDs []uuid.UUID, dedupKeys []string) ([]models.Leak, error)
+	ListLeaks(ctx context.Context, tenantID uuid.UUID, filter models.LeakFilter, params models.PaginationParams) (models.PaginatedResponse[models.Leak], error)
+	ListLeaksAfter(ctx context.Context, tenantID uuid.UUID, filter models.LeakFilter, after models.LeakCursor, limit int32) ([]models.Leak, error)
+	ListTenantIDs(ctx context.Context) ([]uuid.UUID, error)
+	TryLockTenantDetection(ctx context.Context, tenantID uuid.UUID) (unlock func(), locked bool, err error)
+	GetOpenLeakCount(ctx context.Context, tenantID uuid.UUID) (int64, error)
+	GetAffectedCustomerCount(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) (int64, error)
+	GetLeakMTTR(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) (time.Duration, int, error)
+	GetRevenueRecovered(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) (map[string]models.Decimal, error)
+	GetLeakAmountHistogram(ctx context.Context, tenantID uuid.UUID, bounds []models.Decimal, since time.Time) ([]models.LeakAmountBucket, error)
+	GetLeakTimeSeries(ctx context.Context, tenantID uuid.UUID, interval models.LeakTimeSeriesInterval, from time.Time, to time.Time) ([]models.LeakTimeSeriesPoint, error)
+	SnoozeLeak(ctx context.Context, id uuid.UUID, until time.Time, tenantID uuid.UUID) (models.Leak, error)
+}
+
+type leaksService struct {
+	leaksRepository LeaksRepository
+	logger          *slog.Logger
+}
+
+// NewLeaksService creates a LeaksService backed by the provided pool. Reads use readPool when it is not nil.
+func NewLeaksService(pool *repository.Pool, readPool *repository.Pool, l *slog.Logger) (LeaksService, error) {
+	lR, err := repository.NewLeaksRepository(pool, l)
+	if err != nil {
+		return nil, err
+	}
+	return &leaksService{leaksRepository: lR.WithReadPool(readPool), logger: l}, nil
+}
+
+// GetLeakByID retrieves a leak by its UUID, returning ErrLeakNotFound if it does not exist.
+func (s *leaksService) GetLeakByID(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (models.Leak, error) {
+	return s.leaksRepository.GetLeakByID(ctx, id, tenantID)
+}
+
+// CreateLeak stores a detected leak. A uuid.Nil CustomerID stores a tenant-wide leak.
+func (s *leaksService) CreateLeak(ctx context.Context, args models.CreateLeakParams, tenantID uuid.UUID) (models.Leak, error) {
+	return s.leaksRepository.CreateLeak(ctx, args, tenantID)
+}
+
+// UpsertLeakByDedupKey stores a detected leak, or counts another occurrence of the tenant's open
+// leak with the same dedup key and currency, adding to its amount. A returned leak with one occurrence is new.
+func (s *leaksService) UpsertLeakByDedupKey(ctx context.Context, args models.CreateLeakParams, tenantID uuid.UUID) (models.Leak, error) {
+	return s.leaksRepository.UpsertLeakByDedupKey(ctx, args, tenantID)
+}
+
+// ListLeaks returns a page of the tenant's leaks matching filter, largest amount first.
+func (s *leaksService) ListLeaks(ctx context.Context, tenantID uuid.UUID, filter models.LeakFilter, params models.PaginationParams) (models.PaginatedResponse[models.Leak], error) {
+	return s.leaksRepository.ListLeaks(ctx, tenantID, filter, params)
+}
+
+// ListLeaksAfter returns up to limit of the tenant's leaks matching filter that come after the
+// cursor, oldest detection first.
+func (s *leaksService) ListLeaksAfter(ctx context.Context, tenantID uuid.UUID, filter models.LeakFilter, after models.LeakCursor, limit int32) ([]models.Leak, error) {
+	return s.leaksRepository.ListLeaksAfter(ctx, tenantID, filter, after, limit)
+}
+
+// ListTenantIDs returns the ID of every tenant.
+func (s *leaksService) ListTenantIDs(ctx context.Context) ([]uuid.UUID, error) {
+	return s.leaksRepository.ListTenantIDs(ctx)
+}
+
+// TryLockTenantDetection takes the tenant's detection lock without waiting, reporting whether
+// it did; unlock releases it.
+func (s *leaksService) TryLockTenantDetection(ctx context.Context, tenantID uuid.UUID) (unlock func(), locked bool, err error) {
+	return s.leaksRepository.TryLockTenantDetection(ctx, tenantID)
+}
+
+// GetMinLeakAmounts returns the tenant's own minimum leak amounts by currency; currencies it
+// doesn't list fall back to the global default.
+func (s *leaksService) GetMinLeakAmounts(ctx context.Context, tenantID uuid.UUID) (map[string]models.Decimal, error) {
+	return s.leaksRepository.GetMinLeakAmounts(ctx, tenantID)
+}
+
+// GetLeakSources returns the tenant's own leak sources; leak types it doesn't list keep the
+// built-in sources.
+func (s *leaksService) GetLeakSources(ctx context.Context, tenantID uuid.UUID) (models.LeakSources, error) {
+	return s.leaksRepository.GetLeakSources(ctx, tenantID)
+}
+
+// GetLeakCountInWindow counts the tenant's leaks detected in [from, to), whatever their status.
+func (s *leaksService) GetLeakCountInWindow(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) (int64, error) {
+	return s.leaksRepository.GetLeakCountInWindow(ctx, tenantID, from, to)
+}
+
+// FindExistingLeaks returns the tenant's leaks detected exactly at detectedAt, triggered by any
+// of sourceEventIDs or carrying any of dedupKeys.
+func (s *leaksService) FindExistingLeaks(ctx context.Context, tenantID uuid.UUID, detectedAt time.Time, sourceEventIDs []uuid.UUID, dedupKeys []string) ([]models.Leak, error) {
+	return s.leaksRepository.FindExistingLeaks(ctx, tenantID, detectedAt, sourceEventIDs, dedupKeys)
+}
+
+// GetOpenLeakCount counts the tenant's open leaks, leaving out those still snoozed.
+func (s *leaksService) GetOpenLeakCount(ctx context.Context, tenantID uuid.UUID) (int64, error) {
+	return s.leaksRepository.GetOpenLeakCount(ctx, tenantID)
+}
+
+// GetAffectedCustomerCount counts the distinct customers with an open leak detected in [from, to).
+func (s *leaksService) GetAffectedCustomerCount(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) (int64, error) {
+	return s.leaksRepository.GetAffectedCustomerCount(ctx, tenantID, from, to)
+}
+
+// GetLeakMTTR returns the mean time from detection to resolution of the leaks resolved in
+// [from, to), and how many leaks that is; 0, 0 when none were resolved.
+func (s *leaksService) GetLeakMTTR(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) (time.Duration, int, error) {
+	return s.leaksRepository.GetLeakMTTR(ctx, tenantID, from, to)
+}
+
+// GetRevenueRecovered returns, by currency, the amounts of the leaks that remediation recovered
+// in [from, to): a successful action on the leak followed by a payment from its customer.
+func (s *leaksService) GetRevenueRecovered(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) (map[string]models.Decimal, error) {
+	return s.leaksRepository.GetRevenueRecovered(ctx, tenantID, from, to)
+}
+
+// GetLeakAmountHistogram counts and sums the tenant's open leaks detected since the given time
+// (all of them when it is zero) by currency, in buckets split at bounds. Without bounds it uses
+// models.DefaultLeakAmountBucketBounds; bounds that are not positive and strictly ascending
+// return models.ErrInvalidBucketBounds.
+func (s *leaksService) GetLeakAmountHistogram(ctx context.Context, tenantID uuid.UUID, bounds []models.Decimal, since time.Time) ([]models.LeakAmountBucket, error) {
+	if len(bounds) == 0 {
+		bounds = models.DefaultLeakAmountBucketBounds
+	}
+	if err := models.ValidateBucketBounds(bounds); err != nil {
+		return nil, err
+	}
+	return s.leaksRepository.GetLeakAmountHistogram(ctx, tenantID, bounds, since)
+}
+
+// GetLeakTimeSeries counts and sums by currency the tenant's leaks detected in [from, to), per
+// interval-wide bucket in UTC, with a zero point for every bucket without leaks. An interval
+// other than hour, day, week or month returns models.ErrInvalidTimeSeriesInterval, and a range
+// that is empty or too long for it models.ErrInvalidTimeSeriesRange.
+func (s *leaksService) GetLeakTimeSeries(ctx context.Context, tenantID uuid.UUID, interval models.LeakTimeSeriesInterval, from time.Time, to time.Time) ([]models.LeakTimeSeriesPoint, error) {
+	if err := models.ValidateLeakTimeSeries(interval, from, to); err != nil {
+		return nil, err
+	}
+	return s.leaksRepository.GetLeakTimeSeries(ctx, tenantID, interval, from, to)
+}
+
+// SnoozeLeak hides a leak from the open counts and listings until the given time, returning
+// ErrLeakNotFound if it does not exist.
+func (s *leaksService) SnoozeLeak(ctx context.Context, id uuid.UUID, until time.Time, tenantID uuid.UUID) (models.Leak, error) {
+	return s.leaksRepository.SnoozeLeak(ctx, id, until, tenantID)
+}
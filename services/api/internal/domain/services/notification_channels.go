@@ -0,0 +1,72 @@
+// Package services provides business logic and orchestration for domain entities.
+// This file implements the NotificationChannelsService, which manages where a tenant's leak notifications go.
+package services
+
+import (
+	"context"
+	"log/slog"
+	"rdl-api/internal/db/repository"
+	"rdl-api/internal/domain/models"
+
+	"github.com/google/uuid"
+)
+
+type NotificationChannelsService interface {
+	CreateNotificationChannel(ctx context.Context, args models.CreateNotificationChannelParams, tenantID uuid.UUID) (models.NotificationChannel, error)
+	GetNotificationChannelByID(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (models.NotificationChannel, error)
+	GetNotificationChannels(ctx context.Context, tenantID uuid.UUID) ([]models.NotificationChannel, error)
+	UpdateNotificationChannel(ctx context.Context, args models.UpdateNotificationChannelParams, tenantID uuid.UUID) (models.NotificationChannel, error)
+	DeleteNotificationChannel(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (int64, error)
+}
+
+type notificationChannelsService struct {
+	channelsRepository NotificationChannelsRepository
+	logger             *slog.Logger
+}
+
+// NewNotificationChannelsService creates a NotificationChannelsService backed by the provided pool.
+func NewNotificationChannelsService(pool *repository.Pool, l *slog.Logger) (NotificationChannelsService, error) {
+	cR, err := repository.NewNotificationChannelsRepository(pool, l)
+	if err != nil {
+		return nil, err
+	}
+	return &notificationChannelsService{channelsRepository: cR, logger: l}, nil
+}
+
+// CreateNotificationChannel adds a channel after checking its target suits its type
+func (s *notificationChannelsService) CreateNotificationChannel(ctx context.Context, args models.CreateNotificationChannelParams, tenantID uuid.UUID) (models.NotificationChannel, error) {
+	if err := args.Validate(); err != nil {
+		return models.NotificationChannel{}, err
+	}
+	return s.channelsRepository.CreateNotificationChannel(ctx, args, tenantID)
+}
+
+// GetNotificationChannelByID returns one of the tenant's channels
+func (s *notificationChannelsService) GetNotificationChannelByID(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (models.NotificationChannel, error) {
+	return s.channelsRepository.GetNotificationChannelByID(ctx, id, tenantID)
+}
+
+// GetNotificationChannels returns the tenant's channels, enabled or not, oldest first
+func (s *notificationChannelsService) GetNotificationChannels(ctx context.Context, tenantID uuid.UUID) ([]models.NotificationChannel, error) {
+	return s.channelsRepository.GetNotificationChannels(ctx, tenantID)
+}
+
+// UpdateNotificationChannel changes a channel's target or enabled flag. A new target is checked
+// against the channel's stored type.
+func (s *notificationChannelsService) UpdateNotificationChannel(ctx context.Context, args models.UpdateNotificationChannelParams, tenantID uuid.UUID) (models.NotificationChannel, error) {
+	if args.Target != nil {
+		channel, err := s.channelsRepository.GetNotificationChannelByID(ctx, args.ID, tenantID)
+		if err != nil {
+			return models.NotificationChannel{}, err
+		}
+		if err := models.ValidateNotificationChannelTarget(channel.ChannelType, *args.Target); err != nil {
+			return models.NotificationChannel{}, err
+		}
+	}
+	return s.channelsRepository.UpdateNotificationChannel(ctx, args, tenantID)
+}
+
+// DeleteNotificationChannel removes one of the tenant's channels, returning how many were deleted
+func (s *notificationChannelsService) DeleteNotificationChannel(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (int64, error) {
+	return s.channelsRepository.DeleteNotificationChannel(ctx, id, tenantID)
+}
@@ -0,0 +1,64 @@
+// Package services provides business logic and orchestration for domain entities.
+// This file implements the NotificationOutboxService, which keeps the leak notifications still to be delivered.
+package services
+
+import (
+	"context"
+	"log/slog"
+	"rdl-api/internal/db/repository"
+	"rdl-api/internal/domain/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type NotificationOutboxService interface {
+	EnqueueNotification(ctx context.Context, args models.CreateOutboxNotificationParams, tenantID uuid.UUID, lease time.Duration) (models.OutboxNotification, error)
+	ClaimDueNotifications(ctx context.Context, tenantID uuid.UUID, limit int, lease time.Duration) ([]models.OutboxNotification, error)
+	FailOutboxAttempt(ctx context.Context, id uuid.UUID, tenantID uuid.UUID, lastError string, backoff time.Duration, maxAttempts int) (models.OutboxNotification, error)
+	DeleteOutboxNotification(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (int64, error)
+}
+
+type notificationOutboxService struct {
+	outboxRepository NotificationOutboxRepository
+	logger           *slog.Logger
+}
+
+// NewNotificationOutboxService creates a NotificationOutboxService backed by the provided pool.
+func NewNotificationOutboxService(pool *repository.Pool, l *slog.Logger) (NotificationOutboxService, error) {
+	oR, err := repository.NewNotificationOutboxRepository(pool, l)
+	if err != nil {
+		return nil, err
+	}
+	return &notificationOutboxService{outboxRepository: oR, logger: l}, nil
+}
+
+// EnqueueNotification adds a notification for one of the tenant's channels to the outbox, with
+// its first attempt started and held for lease
+func (s *notificationOutboxService) EnqueueNotification(ctx context.Context, args models.CreateOutboxNotificationParams, tenantID uuid.UUID, lease time.Duration) (models.OutboxNotification, error) {
+	return s.outboxRepository.EnqueueNotification(ctx, args, tenantID, lease)
+}
+
+// ClaimDueNotifications starts the next attempt of up to limit of the tenant's due deliveries,
+// holding them for lease
+func (s *notificationOutboxService) ClaimDueNotifications(ctx context.Context, tenantID uuid.UUID, limit int, lease time.Duration) ([]models.OutboxNotification, error) {
+	return s.outboxRepository.ClaimDueNotifications(ctx, tenantID, limit, lease)
+}
+
+// FailOutboxAttempt records a failed delivery, due again after backoff until maxAttempts is reached
+func (s *notificationOutboxService) FailOutboxAttempt(ctx context.Context, id uuid.UUID, tenantID uuid.UUID, lastError string, backoff time.Duration, maxAttempts int) (models.OutboxNotification, error) {
+	notification, err := s.outboxRepository.FailOutboxAttempt(ctx, id, tenantID, lastError, backoff, maxAttempts)
+	if err != nil {
+		return models.OutboxNotification{}, err
+	}
+
+	if notification.FailedAt != nil {
+		s.logger.WarnContext(ctx, "Gave up on notification after its last attempt", "outbox_id", id, "channel_id", notification.ChannelID, "tenant_id", tenantID, "attempts", notification.Attempts, "error", lastError)
+	}
+	return notification, nil
+}
+
+// DeleteOutboxNotification removes a delivered notification from the outbox
+func (s *notificationOutboxService) DeleteOutboxNotification(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (int64, error) {
+	return s.outboxRepository.DeleteOutboxNotification(ctx, id, tenantID)
+}
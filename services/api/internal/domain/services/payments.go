@@ -0,0 +1,37 @@
+// Package services provides business logic and orchestration for domain entities.
+// This file implements the PaymentsService, which searches the payments normalized from events.
+package services
+
+import (
+	"context"
+	"log/slog"
+	"rdl-api/internal/db/repository"
+	"rdl-api/internal/domain/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PaymentsService interface {
+	FindCurrencyMismatches(ctx context.Context, tenantID uuid.UUID, since time.Time) ([]models.CurrencyMismatch, error)
+}
+
+type paymentsService struct {
+	paymentsRepository PaymentsRepository
+	logger             *slog.Logger
+}
+
+// NewPaymentsService creates a PaymentsService backed by the provided pool.
+func NewPaymentsService(pool *repository.Pool, l *slog.Logger) (PaymentsService, error) {
+	pR, err := repository.NewPaymentsRepository(pool, l)
+	if err != nil {
+		return nil, err
+	}
+	return &paymentsService{paymentsRepository: pR, logger: l}, nil
+}
+
+// FindCurrencyMismatches returns the tenant's payments created since the given time in another
+// currency than the earliest payment with the same external ID, skipping those already flagged
+func (s *paymentsService) FindCurrencyMismatches(ctx context.Context, tenantID uuid.UUID, since time.Time) ([]models.CurrencyMismatch, error) {
+	return s.paymentsRepository.FindCurrencyMismatches(ctx, tenantID, since)
+}
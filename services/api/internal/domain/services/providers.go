@@ -0,0 +1,63 @@
+// Package services provides business logic and orchestration for domain entities.
+// This file implements the ProvidersService, which handles the provider registry.
+package services
+
+import (
+	"context"
+	"log/slog"
+	"rdl-api/internal/db/repository"
+	"rdl-api/internal/domain/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProviderSilentAfter is how long a provider may go without sending a tenant an event before
+// it is reported silent
+const ProviderSilentAfter = 24 * time.Hour
+
+type ProvidersService interface {
+	CreateProvider(ctx context.Context, args models.CreateProviderParams) (models.Provider, error)
+	GetProviders(ctx context.Context, tenantID uuid.UUID) ([]models.TenantProvider, error)
+}
+
+type providersService struct {
+	providersRepository ProvidersRepository
+	logger              *slog.Logger
+	now                 func() time.Time
+}
+
+// NewProvidersService creates a ProvidersService backed by the provided pool.
+func NewProvidersService(pool *repository.Pool, l *slog.Logger) (ProvidersService, error) {
+	pR, err := repository.NewProvidersRepository(pool, l)
+	if err != nil {
+		return nil, err
+	}
+	return &providersService{providersRepository: pR, logger: l, now: time.Now}, nil
+}
+
+// CreateProvider registers a provider after checking its name and type. An empty type is
+// stored as models.DefaultProviderType.
+func (s *providersService) CreateProvider(ctx context.Context, args models.CreateProviderParams) (models.Provider, error) {
+	if args.ProviderType == "" {
+		args.ProviderType = models.DefaultProviderType
+	}
+	if err := args.Validate(); err != nil {
+		return models.Provider{}, err
+	}
+	return s.providersRepository.CreateProvider(ctx, args)
+}
+
+// GetProviders returns the tenant's providers by name, each active when the tenant received an
+// event from it within ProviderSilentAfter and silent otherwise.
+func (s *providersService) GetProviders(ctx context.Context, tenantID uuid.UUID) ([]models.TenantProvider, error) {
+	providers, err := s.providersRepository.GetProviders(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	for i := range providers {
+		providers[i].Status = models.ProviderStatusAt(providers[i].LastEventAt, now, ProviderSilentAfter)
+	}
+	return providers, nil
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"rdl-api/internal/domain/models"
+)
+
+// fakeProvidersRepository returns fixed providers and records the provider it was asked to create
+type fakeProvidersRepository struct {
+	providers []models.TenantProvider
+	created   models.CreateProviderParams
+	// types maps provider ID to type for GetProviderType, which counts its calls in typeLookups
+	types       map[uuid.UUID]string
+	typeLookups int
+}
+
+func (r *fakeProvidersRepository) CreateProvider(_ context.Context, arg models.CreateProviderParams) (models.Provider, error) {
+	r.created = arg
+	return models.Provider{ID: uuid.New(), Name: arg.Name, ProviderType: arg.ProviderType}, nil
+}
+
+func (r *fakeProvidersRepository) GetProviders(context.Context, uuid.UUID) ([]models.TenantProvider, error) {
+	return r.providers, nil
+}
+
+func (r *fakeProvidersRepository) GetProviderType(_ context.Context, providerID uuid.UUID) (string, error) {
+	r.typeLookups++
+	providerType, ok := r.types[providerID]
+	if !ok {
+		return "", ErrProviderNotFound
+	}
+	return providerType, nil
+}
+
+func TestProvidersService_GetProviders(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	recent := now.Add(-time.Hour)
+	old := now.Add(-ProviderSilentAfter - time.Minute)
+	repo := &fakeProvidersRepository{providers: []models.TenantProvider{
+		{Provider: models.Provider{Name: "recent"}, LastEventAt: &recent},
+		{Provider: models.Provider{Name: "old"}, LastEventAt: &old},
+		{Provider: models.Provider{Name: "never"}},
+	}}
+	service := &providersService{providersRepository: repo, logger: newTestLogger(), now: func() time.Time { return now }}
+
+	providers, err := service.GetProviders(context.Background(), uuid.New())
+	require.NoError(t, err)
+	require.Len(t, providers, 3)
+	assert.Equal(t, models.ProviderStatusActive, providers[0].Status)
+	assert.Equal(t, models.ProviderStatusSilent, providers[1].Status)
+	assert.Equal(t, models.ProviderStatusSilent, providers[2].Status)
+}
+
+func TestProvidersService_CreateProvider(t *testing.T) {
+	repo := &fakeProvidersRepository{}
+	service := &providersService{providersRepository: repo, logger: newTestLogger(), now: time.Now}
+
+	provider, err := service.CreateProvider(context.Background(), models.CreateProviderParams{Name: "Acme Billing"})
+	require.NoError(t, err)
+	assert.Equal(t, models.DefaultProviderType, provider.ProviderType)
+
+	_, err = service.CreateProvider(context.Background(), models.CreateProviderParams{ProviderType: "stripe"})
+	assert.ErrorIs(t, err, ErrInvalidProviderName)
+	_, err = service.CreateProvider(context.Background(), models.CreateProviderParams{Name: "Acme", ProviderType: string(make([]byte, 51))})
+	assert.ErrorIs(t, err, ErrInvalidProviderType)
+}
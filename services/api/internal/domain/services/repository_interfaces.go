@@ -2,6 +2,8 @@ package services
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -34,18 +36,47 @@ type UserRepository interface {
 type EventsRepository interface {
 	// Create operations
 	CreateEvent(ctx context.Context, arg models.CreateEventParams, tenantID uuid.UUID) (models.Event, error)
+	CreateEventIdempotent(ctx context.Context, arg models.CreateEventParams, tenantID uuid.UUID) (models.Event, error)
+	CreateEventsBatch(ctx context.Context, args []models.CreateEventParams, tenantID uuid.UUID, policy models.BatchPolicy) ([]models.BatchEventResult, error)
 
 	// Read operations
 	GetAllEvents(ctx context.Context, tenantID uuid.UUID) ([]models.Event, error)
 	GetAllEventsPaginated(ctx context.Context, tenantID uuid.UUID, params models.PaginationParams) (models.PaginatedResponse[models.Event], error)
+	ListEvents(ctx context.Context, tenantID uuid.UUID, filter models.EventFilter, params models.PaginationParams) (models.PaginatedResponse[models.Event], error)
+	ListEventsAfter(ctx context.Context, tenantID uuid.UUID, filter models.EventFilter, after uuid.UUID, limit int32) ([]models.Event, error)
+	ListEventsForExport(ctx context.Context, tenantID uuid.UUID, after models.EventCursor, limit int32) ([]models.Event, error)
+	SearchEventsByExternalID(ctx context.Context, tenantID uuid.UUID, prefix string, params models.PaginationParams) (models.PaginatedResponse[models.Event], error)
 	GetEventByID(ctx context.Context, eventID uuid.UUID, tenantID uuid.UUID) (models.Event, error)
+	GetEventStatusHistory(ctx context.Context, eventID uuid.UUID, tenantID uuid.UUID) ([]models.EventStatusChange, error)
+	GetEventsByIDs(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) (map[uuid.UUID]models.Event, error)
+	GetEventsByLeakID(ctx context.Context, leakID uuid.UUID, tenantID uuid.UUID) ([]models.Event, error)
+	GetEventsBySource(ctx context.Context, tenantID uuid.UUID, source models.EventSourceEnum, params models.PaginationParams) (models.PaginatedResponse[models.Event], error)
+	GetEventsWithoutLeak(ctx context.Context, tenantID uuid.UUID, eventType models.EventTypeEnum, params models.PaginationParams) (models.PaginatedResponse[models.Event], error)
+	GetFailedEvents(ctx context.Context, tenantID uuid.UUID, after uuid.UUID, limit int32) ([]models.Event, error)
+	GetPendingEventsOldestFirst(ctx context.Context, tenantID uuid.UUID, limit int) ([]models.Event, error)
+	GetRecentEvents(ctx context.Context, tenantID uuid.UUID, n int) ([]models.Event, error)
+	GetRelatedEvents(ctx context.Context, tenantID uuid.UUID, eventID uuid.UUID, rule models.CorrelationRule) ([]models.Event, error)
 	CountAllEvents(ctx context.Context, tenantID uuid.UUID) (int64, error)
+	HasAnyEvents(ctx context.Context, tenantID uuid.UUID) (bool, error)
+	CountEventsByStatusForProvider(ctx context.Context, tenantID uuid.UUID, providerID uuid.UUID) (map[models.EventStatusEnum]int64, error)
+	GetEventCountInWindow(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) (int64, error)
+	GetIngestionLag(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) ([]models.IngestionLag, error)
+	GetProviderFailureRates(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) ([]models.ProviderFailureRate, error)
+	FindDuplicateCharges(ctx context.Context, tenantID uuid.UUID, since time.Time, window time.Duration) ([]models.DuplicateCharge, error)
+	FindDunningGaps(ctx context.Context, tenantID uuid.UUID, now time.Time, defaultWindow time.Duration, lookback time.Duration) ([]models.DunningGap, error)
+	GetEventRetentionPolicies(ctx context.Context) ([]models.EventRetentionPolicy, error)
+	ArchiveEventsBefore(ctx context.Context, tenantID uuid.UUID, before time.Time, w io.Writer) (int64, error)
 
 	// Update operations
 	UpdateEvent(ctx context.Context, arg models.UpdateEventParams, tenantID uuid.UUID) (models.Event, error)
+	UpdateEventIfVersion(ctx context.Context, arg models.UpdateEventParams, expectedUpdatedAt time.Time, tenantID uuid.UUID) (models.Event, error)
+	UpdateEventStatusByFilter(ctx context.Context, tenantID uuid.UUID, filter models.EventFilter, newStatus models.EventStatusEnum) (int64, error)
+	ReattributeEvents(ctx context.Context, tenantID uuid.UUID, fromProviderID uuid.UUID, toProviderID uuid.UUID) (int64, error)
 
 	// Delete operations
 	DeleteEvent(ctx context.Context, eventID uuid.UUID, tenantID uuid.UUID) (int64, error)
+	DeleteEventIdempotent(ctx context.Context, eventID uuid.UUID, tenantID uuid.UUID) (int64, error)
+	PurgeEventsBefore(ctx context.Context, tenantID uuid.UUID, before time.Time, limit int32) (int64, error)
 }
 
 // ActionsRepository defines the interface for actions-related database operations
@@ -54,8 +85,78 @@ type ActionsRepository interface {
 	DeleteAction(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (int64, error)
 	GetAllActions(ctx context.Context, tenantID uuid.UUID) ([]models.Action, error)
 	GetAllActionsPaginated(ctx context.Context, tenantID uuid.UUID, params models.PaginationParams) (models.PaginatedResponse[models.Action], error)
+	GetActionsFiltered(ctx context.Context, tenantID uuid.UUID, filter models.ActionFilter, params models.PaginationParams, sort models.ActionSort) (models.PaginatedResponse[models.Action], error)
 	GetActionByID(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (models.Action, error)
+	GetActionWithLeak(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (models.Action, models.Leak, error)
+	GetActionsByLeakID(ctx context.Context, leakID uuid.UUID, tenantID uuid.UUID) ([]models.Action, error)
 	CountAllActions(ctx context.Context, tenantID uuid.UUID) (int64, error)
+	ClaimPendingActions(ctx context.Context, tenantID uuid.UUID, workerID string, limit int, lease time.Duration, maxAttempts int) ([]models.Action, error)
+	CompleteAction(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (models.Action, error)
+	FailActionAttempt(ctx context.Context, id uuid.UUID, tenantID uuid.UUID, maxAttempts int) (models.Action, error)
+}
+
+// LeaksRepository defines the interface for leak-related database operations
+type LeaksRepository interface {
+	CreateLeak(ctx context.Context, arg models.CreateLeakParams, tenantID uuid.UUID) (models.Leak, error)
+	UpsertLeakByDedupKey(ctx context.Context, arg models.CreateLeakParams, tenantID uuid.UUID) (models.Leak, error)
+	GetLeakByID(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (models.Leak, error)
+	GetMinLeakAmounts(ctx context.Context, tenantID uuid.UUID) (map[string]models.Decimal, error)
+	GetLeakSources(ctx context.Context, tenantID uuid.UUID) (models.LeakSources, error)
+	GetLeakCountInWindow(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) (int64, error)
+	FindExistingLeaks(ctx context.Context, tenantID uuid.UUID, detectedAt time.Time, sourceEventIDs []uuid.UUID, dedupKeys []string) ([]models.Leak, error)
+	ListLeaks(ctx context.Context, tenantID uuid.UUID, filter models.LeakFilter, params models.PaginationParams) (models.PaginatedResponse[models.Leak], error)
+	ListLeaksAfter(ctx context.Context, tenantID uuid.UUID, filter models.LeakFilter, after models.LeakCursor, limit int32) ([]models.Leak, error)
+	ListTenantIDs(ctx context.Context) ([]uuid.UUID, error)
+	TryLockTenantDetection(ctx context.Context, tenantID uuid.UUID) (unlock func(), locked bool, err error)
+	GetOpenLeakCount(ctx context.Context, tenantID uuid.UUID) (int64, error)
+	GetAffectedCustomerCount(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) (int64, error)
+	GetLeakMTTR(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) (time.Duration, int, error)
+	GetRevenueRecovered(ctx context.Context, tenantID uuid.UUID, from time.Time, to time.Time) (map[string]models.Decimal, error)
+	GetLeakAmountHistogram(ctx context.Context, tenantID uuid.UUID, bounds []models.Decimal, since time.Time) ([]models.LeakAmountBucket, error)
+	GetLeakTimeSeries(ctx context.Context, tenantID uuid.UUID, interval models.LeakTimeSeriesInterval, from time.Time, to time.Time) ([]models.LeakTimeSeriesPoint, error)
+	SnoozeLeak(ctx context.Context, id uuid.UUID, until time.Time, tenantID uuid.UUID) (models.Leak, error)
+}
+
+// ProvidersRepository defines the interface for provider-related database operations
+type ProvidersRepository interface {
+	CreateProvider(ctx context.Context, arg models.CreateProviderParams) (models.Provider, error)
+	GetProviders(ctx context.Context, tenantID uuid.UUID) ([]models.TenantProvider, error)
+	GetProviderType(ctx context.Context, providerID uuid.UUID) (string, error)
+}
+
+// NotificationChannelsRepository defines the interface for notification channel database operations
+type NotificationChannelsRepository interface {
+	CreateNotificationChannel(ctx context.Context, arg models.CreateNotificationChannelParams, tenantID uuid.UUID) (models.NotificationChannel, error)
+	GetNotificationChannelByID(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (models.NotificationChannel, error)
+	GetNotificationChannels(ctx context.Context, tenantID uuid.UUID) ([]models.NotificationChannel, error)
+	UpdateNotificationChannel(ctx context.Context, arg models.UpdateNotificationChannelParams, tenantID uuid.UUID) (models.NotificationChannel, error)
+	DeleteNotificationChannel(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (int64, error)
+}
+
+// NotificationOutboxRepository defines the interface for the notification outbox database operations
+type NotificationOutboxRepository interface {
+	EnqueueNotification(ctx context.Context, arg models.CreateOutboxNotificationParams, tenantID uuid.UUID, lease time.Duration) (models.OutboxNotification, error)
+	ClaimDueNotifications(ctx context.Context, tenantID uuid.UUID, limit int, lease time.Duration) ([]models.OutboxNotification, error)
+	FailOutboxAttempt(ctx context.Context, id uuid.UUID, tenantID uuid.UUID, lastError string, backoff time.Duration, maxAttempts int) (models.OutboxNotification, error)
+	DeleteOutboxNotification(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (int64, error)
+}
+
+// IdempotencyKeysRepository defines the interface for storing responses replayed by idempotency key
+type IdempotencyKeysRepository interface {
+	GetIdempotencyRecord(ctx context.Context, tenantID uuid.UUID, key string) (models.IdempotencyRecord, error)
+	SaveIdempotencyRecord(ctx context.Context, record models.IdempotencyRecord) (bool, error)
+}
+
+// TenantsRepository defines the interface for reading tenant settings across all tenants
+type TenantsRepository interface {
+	GetTenantRateLimits(ctx context.Context) ([]models.TenantRateLimit, error)
+	ExportTenant(ctx context.Context, tenantID uuid.UUID, w io.Writer) (models.TenantSnapshotReport, error)
+	ImportTenant(ctx context.Context, tenantID uuid.UUID, r io.Reader, resumeAfter string) (models.TenantSnapshotReport, error)
+}
+
+// PaymentsRepository defines the interface for searching a tenant's payments
+type PaymentsRepository interface {
+	FindCurrencyMismatches(ctx context.Context, tenantID uuid.UUID, since time.Time) ([]models.CurrencyMismatch, error)
 }
 
 // Database abstracts the database connection pool
@@ -0,0 +1,50 @@
+// Package services provides business logic and orchestration for domain entities.
+// This file implements the TenantsService, which reads the settings tenants override.
+package services
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"rdl-api/internal/db/repository"
+	"rdl-api/internal/domain/models"
+
+	"github.com/google/uuid"
+)
+
+type TenantsService interface {
+	GetTenantRateLimits(ctx context.Context) ([]models.TenantRateLimit, error)
+	ExportTenant(ctx context.Context, tenantID uuid.UUID, w io.Writer) (models.TenantSnapshotReport, error)
+	ImportTenant(ctx context.Context, tenantID uuid.UUID, r io.Reader, resumeAfter string) (models.TenantSnapshotReport, error)
+}
+
+type tenantsService struct {
+	tenantsRepository TenantsRepository
+	logger            *slog.Logger
+}
+
+// NewTenantsService creates a TenantsService backed by the provided pool.
+func NewTenantsService(pool *repository.Pool, l *slog.Logger) (TenantsService, error) {
+	tR, err := repository.NewTenantsRepository(pool, l)
+	if err != nil {
+		return nil, err
+	}
+	return &tenantsService{tenantsRepository: tR, logger: l}, nil
+}
+
+// GetTenantRateLimits returns the request rate limit overrides of every tenant that has one
+func (s *tenantsService) GetTenantRateLimits(ctx context.Context) ([]models.TenantRateLimit, error) {
+	return s.tenantsRepository.GetTenantRateLimits(ctx)
+}
+
+// ExportTenant writes a consistent snapshot of every row of the tenant to w as a versioned
+// tar archive of newline-delimited JSON parts
+func (s *tenantsService) ExportTenant(ctx context.Context, tenantID uuid.UUID, w io.Writer) (models.TenantSnapshotReport, error) {
+	return s.tenantsRepository.ExportTenant(ctx, tenantID, w)
+}
+
+// ImportTenant stores the rows of an archive written by ExportTenant for the same tenant,
+// starting after the part resumeAfter when it is not empty
+func (s *tenantsService) ImportTenant(ctx context.Context, tenantID uuid.UUID, r io.Reader, resumeAfter string) (models.TenantSnapshotReport, error) {
+	return s.tenantsRepository.ImportTenant(ctx, tenantID, r, resumeAfter)
+}
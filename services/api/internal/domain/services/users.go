@@ -9,7 +9,6 @@ import (
 	"rdl-api/internal/domain/models"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type UsersService interface {
@@ -26,7 +25,7 @@ type userService struct {
 	userRepository UserRepository
 }
 
-func NewUserService(pool *pgxpool.Pool, l *slog.Logger) UsersService {
+func NewUserService(pool *repository.Pool, l *slog.Logger) UsersService {
 	return &userService{
 		userRepository: repository.NewUserRepository(pool, l),
 	}
@@ -0,0 +1,164 @@
+// Package executor carries out the remediation actions raised for leaks. Each run claims a
+// batch of every tenant's pending actions from the priority queue, runs them, and records
+// whether each attempt succeeded; a failed attempt goes back to the queue until the actions
+// service's attempt cap fails it for good.
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/notifier"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultBatchSize is the most actions of one tenant claimed per run when Options leaves it zero
+const DefaultBatchSize = 50
+
+// ActionStore claims the pending actions and records how their attempts ended
+type ActionStore interface {
+	ClaimPendingActions(ctx context.Context, tenantID uuid.UUID, workerID string, limit int) ([]models.Action, error)
+	CompleteAction(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (models.Action, error)
+	FailActionAttempt(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (models.Action, error)
+}
+
+// TenantLister lists the tenants whose actions an Executor runs
+type TenantLister interface {
+	ListTenantIDs(ctx context.Context) ([]uuid.UUID, error)
+}
+
+// Runner carries out one claimed action of tenantID. An error fails the attempt.
+type Runner interface {
+	Run(ctx context.Context, tenantID uuid.UUID, action models.Action) error
+}
+
+// RunnerFunc adapts a function to a Runner
+type RunnerFunc func(ctx context.Context, tenantID uuid.UUID, action models.Action) error
+
+// Run calls f
+func (f RunnerFunc) Run(ctx context.Context, tenantID uuid.UUID, action models.Action) error {
+	return f(ctx, tenantID, action)
+}
+
+// NotifyRunner hands each action to the tenant's team through notify, for the action types
+// this service cannot carry out itself
+func NotifyRunner(notify notifier.Notifier) Runner {
+	return RunnerFunc(func(ctx context.Context, tenantID uuid.UUID, action models.Action) error {
+		return notify.Notify(ctx, notifier.Notification{
+			TenantID: tenantID,
+			Title:    fmt.Sprintf("Action required: %s", action.ActionType),
+			Body:     fmt.Sprintf("Leak %s needs a %s action (action %s).", action.LeakID, action.ActionType, action.ID),
+		})
+	})
+}
+
+// Options configures an Executor
+type Options struct {
+	// BatchSize is the most actions of one tenant claimed per run
+	BatchSize int
+	// WorkerID identifies this executor in the claimed_by of its actions; defaults to the
+	// hostname and process ID
+	WorkerID string
+}
+
+// Executor runs the pending actions of every tenant, highest priority first
+type Executor struct {
+	store   ActionStore
+	tenants TenantLister
+	runner  Runner
+	opts    Options
+	logger  *slog.Logger
+}
+
+// New creates an Executor that claims the actions of the tenants listed by tenants from store
+// and carries them out with runner
+func New(store ActionStore, tenants TenantLister, runner Runner, opts Options, logger *slog.Logger) *Executor {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.WorkerID == "" {
+		opts.WorkerID = defaultWorkerID()
+	}
+	return &Executor{store: store, tenants: tenants, runner: runner, opts: opts, logger: logger}
+}
+
+// RunOnce claims and runs a batch of every tenant's pending actions. A tenant whose actions
+// cannot be claimed does not stop the others; the errors are joined.
+func (e *Executor) RunOnce(ctx context.Context) error {
+	tenantIDs, err := e.tenants.ListTenantIDs(ctx)
+	if err != nil {
+		return fmt.Errorf("list tenants: %w", err)
+	}
+
+	var errs []error
+	for _, tenantID := range tenantIDs {
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
+		if err := e.runTenant(ctx, tenantID); err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenantID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run runs the pending actions once immediately and then every interval, until ctx is done
+func (e *Executor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			e.logger.ErrorContext(ctx, "Action executor run failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runTenant claims up to BatchSize of the tenant's pending actions and runs them in the order
+// they were claimed
+func (e *Executor) runTenant(ctx context.Context, tenantID uuid.UUID) error {
+	actions, err := e.store.ClaimPendingActions(ctx, tenantID, e.opts.WorkerID, e.opts.BatchSize)
+	if err != nil {
+		return err
+	}
+	for _, action := range actions {
+		e.execute(ctx, tenantID, action)
+	}
+	return nil
+}
+
+// execute runs one claimed action and records the outcome of its attempt. An outcome that
+// cannot be recorded leaves the action in progress under this worker's claim.
+func (e *Executor) execute(ctx context.Context, tenantID uuid.UUID, action models.Action) {
+	if err := e.runner.Run(ctx, tenantID, action); err != nil {
+		e.logger.WarnContext(ctx, "Action attempt failed", "error", err, "action_id", action.ID, "action_type", action.ActionType, "tenant_id", tenantID, "attempts", action.Attempts)
+		if _, err := e.store.FailActionAttempt(ctx, action.ID, tenantID); err != nil {
+			e.logger.ErrorContext(ctx, "Failed to record failed action attempt", "error", err, "action_id", action.ID, "tenant_id", tenantID)
+		}
+		return
+	}
+	if _, err := e.store.CompleteAction(ctx, action.ID, tenantID); err != nil {
+		e.logger.ErrorContext(ctx, "Failed to complete action", "error", err, "action_id", action.ID, "tenant_id", tenantID)
+	}
+}
+
+// defaultWorkerID names this process by its hostname and process ID
+func defaultWorkerID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "executor"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
@@ -0,0 +1,203 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/notifier"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// memoryActionStore keeps each tenant's actions in memory, queued in slice order
+type memoryActionStore struct {
+	mu          sync.Mutex
+	maxAttempts int32
+	actions     map[uuid.UUID][]*models.Action
+	claimErr    map[uuid.UUID]error
+}
+
+func newMemoryActionStore(maxAttempts int32) *memoryActionStore {
+	return &memoryActionStore{maxAttempts: maxAttempts, actions: map[uuid.UUID][]*models.Action{}, claimErr: map[uuid.UUID]error{}}
+}
+
+func (s *memoryActionStore) add(tenantID uuid.UUID, actionType models.ActionTypeEnum) uuid.UUID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	action := &models.Action{ID: uuid.New(), LeakID: uuid.New(), ActionType: actionType, Status: models.ActionStatusEnumPending, Result: models.ActionResultEnumPending}
+	s.actions[tenantID] = append(s.actions[tenantID], action)
+	return action.ID
+}
+
+func (s *memoryActionStore) get(tenantID, id uuid.UUID) models.Action {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, action := range s.actions[tenantID] {
+		if action.ID == id {
+			return *action
+		}
+	}
+	return models.Action{}
+}
+
+func (s *memoryActionStore) ClaimPendingActions(_ context.Context, tenantID uuid.UUID, workerID string, limit int) ([]models.Action, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.claimErr[tenantID]; err != nil {
+		return nil, err
+	}
+	var claimed []models.Action
+	for _, action := range s.actions[tenantID] {
+		if action.Status != models.ActionStatusEnumPending || len(claimed) == limit {
+			continue
+		}
+		action.Status = models.ActionStatusEnumInProgress
+		action.ClaimedBy = &workerID
+		action.Attempts++
+		claimed = append(claimed, *action)
+	}
+	return claimed, nil
+}
+
+func (s *memoryActionStore) finish(tenantID, id uuid.UUID, fn func(*models.Action)) (models.Action, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, action := range s.actions[tenantID] {
+		if action.ID == id && action.Status == models.ActionStatusEnumInProgress {
+			fn(action)
+			action.ClaimedBy = nil
+			return *action, nil
+		}
+	}
+	return models.Action{}, errors.New("action not claimed")
+}
+
+func (s *memoryActionStore) CompleteAction(_ context.Context, id uuid.UUID, tenantID uuid.UUID) (models.Action, error) {
+	return s.finish(tenantID, id, func(action *models.Action) {
+		action.Status = models.ActionStatusEnumApproved
+		action.Result = models.ActionResultEnumSuccess
+	})
+}
+
+func (s *memoryActionStore) FailActionAttempt(_ context.Context, id uuid.UUID, tenantID uuid.UUID) (models.Action, error) {
+	return s.finish(tenantID, id, func(action *models.Action) {
+		action.Result = models.ActionResultEnumFailure
+		action.Status = models.ActionStatusEnumPending
+		if action.Attempts >= s.maxAttempts {
+			action.Status = models.ActionStatusEnumFailed
+		}
+	})
+}
+
+// staticTenantLister lists fixed tenants
+type staticTenantLister []uuid.UUID
+
+func (l staticTenantLister) ListTenantIDs(context.Context) ([]uuid.UUID, error) {
+	return l, nil
+}
+
+func newTestExecutor(store ActionStore, tenants TenantLister, runner Runner, batchSize int) *Executor {
+	return New(store, tenants, runner, Options{BatchSize: batchSize, WorkerID: "worker-1"}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestExecutor_RunsClaimedActionsInOrder(t *testing.T) {
+	tenantID := uuid.New()
+	store := newMemoryActionStore(3)
+	first := store.add(tenantID, models.ActionTypeEnumRetryPayment)
+	second := store.add(tenantID, models.ActionTypeEnumEmail)
+	third := store.add(tenantID, models.ActionTypeEnumOutreach)
+
+	var ran []uuid.UUID
+	runner := RunnerFunc(func(_ context.Context, gotTenant uuid.UUID, action models.Action) error {
+		if gotTenant != tenantID {
+			t.Errorf("expected tenant %s, got %s", tenantID, gotTenant)
+		}
+		if action.ClaimedBy == nil || *action.ClaimedBy != "worker-1" {
+			t.Errorf("expected the action to be claimed by worker-1, got %v", action.ClaimedBy)
+		}
+		ran = append(ran, action.ID)
+		return nil
+	})
+
+	if err := newTestExecutor(store, staticTenantLister{tenantID}, runner, 2).RunOnce(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(ran) != 2 || ran[0] != first || ran[1] != second {
+		t.Fatalf("expected the first batch of 2 in queue order, got %v", ran)
+	}
+	for _, id := range []uuid.UUID{first, second} {
+		if got := store.get(tenantID, id); got.Status != models.ActionStatusEnumApproved || got.Result != models.ActionResultEnumSuccess {
+			t.Errorf("expected action %s approved with success, got %s/%s", id, got.Status, got.Result)
+		}
+	}
+	if got := store.get(tenantID, third); got.Status != models.ActionStatusEnumPending {
+		t.Errorf("expected the action beyond the batch to stay pending, got %s", got.Status)
+	}
+}
+
+func TestExecutor_FailedAttemptsAreRetriedUntilTheCap(t *testing.T) {
+	tenantID := uuid.New()
+	store := newMemoryActionStore(2)
+	id := store.add(tenantID, models.ActionTypeEnumRetryPayment)
+	runner := RunnerFunc(func(context.Context, uuid.UUID, models.Action) error {
+		return errors.New("provider unavailable")
+	})
+	executor := newTestExecutor(store, staticTenantLister{tenantID}, runner, 10)
+
+	if err := executor.RunOnce(context.Background()); err != nil {
+		t.Fatalf("a failed attempt should not fail the run, got %v", err)
+	}
+	if got := store.get(tenantID, id); got.Status != models.ActionStatusEnumPending || got.Attempts != 1 {
+		t.Fatalf("expected the action back in the queue after 1 attempt, got %s after %d", got.Status, got.Attempts)
+	}
+
+	if err := executor.RunOnce(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := store.get(tenantID, id); got.Status != models.ActionStatusEnumFailed || got.Result != models.ActionResultEnumFailure {
+		t.Fatalf("expected the action failed at the cap, got %s/%s", got.Status, got.Result)
+	}
+}
+
+func TestExecutor_ClaimErrorDoesNotStopOtherTenants(t *testing.T) {
+	broken, healthy := uuid.New(), uuid.New()
+	store := newMemoryActionStore(3)
+	store.claimErr[broken] = errors.New("connection refused")
+	id := store.add(healthy, models.ActionTypeEnumEmail)
+
+	err := newTestExecutor(store, staticTenantLister{broken, healthy}, RunnerFunc(func(context.Context, uuid.UUID, models.Action) error { return nil }), 10).RunOnce(context.Background())
+	if err == nil {
+		t.Fatal("expected the broken tenant's claim error")
+	}
+	if got := store.get(healthy, id); got.Status != models.ActionStatusEnumApproved {
+		t.Errorf("expected the healthy tenant's action to run, got %s", got.Status)
+	}
+}
+
+// recordingNotifier keeps the notifications it is asked to deliver
+type recordingNotifier struct {
+	sent []notifier.Notification
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, notification notifier.Notification) error {
+	n.sent = append(n.sent, notification)
+	return nil
+}
+
+func TestNotifyRunner_NotifiesTheTenant(t *testing.T) {
+	tenantID := uuid.New()
+	notify := &recordingNotifier{}
+	action := models.Action{ID: uuid.New(), LeakID: uuid.New(), ActionType: models.ActionTypeEnumOutreach}
+
+	if err := NotifyRunner(notify).Run(context.Background(), tenantID, action); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(notify.sent) != 1 || notify.sent[0].TenantID != tenantID {
+		t.Fatalf("expected one notification for tenant %s, got %+v", tenantID, notify.sent)
+	}
+}
@@ -0,0 +1,158 @@
+// Package ingestqueue holds accepted events in memory while the database is unreachable and
+// writes them once it is back. It keeps ingestion answering through a short outage instead of
+// making every provider retry; the queue is bounded, and events still queued when the process
+// exits are lost, so it covers blips rather than long outages.
+package ingestqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/domain/services"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidCapacity is returned by New for a capacity below 1
+	ErrInvalidCapacity = errors.New("ingest queue capacity must be at least 1")
+	// ErrQueueFull is returned by Enqueue once the queue holds its capacity
+	ErrQueueFull = errors.New("ingest queue is full")
+)
+
+// EventStore writes one event, reporting a redelivery of an already stored event as
+// services.ErrEventAlreadyExists
+type EventStore interface {
+	CreateEventIdempotent(ctx context.Context, arg models.CreateEventParams, tenantID uuid.UUID) (models.Event, error)
+}
+
+// queuedEvent is an event waiting to be written and when it was queued
+type queuedEvent struct {
+	params   models.CreateEventParams
+	tenantID uuid.UUID
+	queuedAt time.Time
+}
+
+// Queue is a bounded, in-memory FIFO of events that could not be written because the database
+// was unreachable. Events are written in the order they were queued.
+type Queue struct {
+	store    EventStore
+	logger   *slog.Logger
+	capacity int
+	now      func() time.Time
+
+	mu     sync.Mutex
+	events []queuedEvent
+
+	// flushMu keeps flushes from overlapping, so events are written once and in order
+	flushMu sync.Mutex
+}
+
+// New creates a Queue that holds at most capacity events and writes them to store
+func New(store EventStore, logger *slog.Logger, capacity int) (*Queue, error) {
+	if capacity < 1 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidCapacity, capacity)
+	}
+	return &Queue{store: store, logger: logger, capacity: capacity, now: time.Now}, nil
+}
+
+// Enqueue queues an event for tenantID, returning ErrQueueFull when there is no room for it
+func (q *Queue) Enqueue(params models.CreateEventParams, tenantID uuid.UUID) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.events) >= q.capacity {
+		return fmt.Errorf("%w: %d events waiting", ErrQueueFull, len(q.events))
+	}
+	q.events = append(q.events, queuedEvent{params: params, tenantID: tenantID, queuedAt: q.now()})
+	return nil
+}
+
+// Len returns the number of events waiting to be written
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.events)
+}
+
+// Flush writes the queued events in order and returns how many it stored. It stops at the
+// first event the database is still unreachable for, leaving it and the rest queued, and
+// returns that error. Events already stored or not accepted by their tenant are dropped
+// quietly; an event the database rejects for any other reason is logged and dropped, since
+// writing it again would fail the same way.
+func (q *Queue) Flush(ctx context.Context) (int, error) {
+	q.flushMu.Lock()
+	defer q.flushMu.Unlock()
+
+	stored := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return stored, err
+		}
+		event, ok := q.peek()
+		if !ok {
+			return stored, nil
+		}
+
+		_, err := q.store.CreateEventIdempotent(ctx, event.params, event.tenantID)
+		switch {
+		case err == nil:
+			stored++
+		case services.IsDatabaseUnavailable(err) || ctx.Err() != nil:
+			return stored, err
+		case errors.Is(err, services.ErrEventAlreadyExists) || errors.Is(err, services.ErrEventSkipped):
+			// Nothing left to write for this event
+		default:
+			q.logger.ErrorContext(ctx, "Dropping queued event the database rejected", "error", err, "event_id", event.params.EventID, "tenant_id", event.tenantID, "queued_at", event.queuedAt)
+		}
+		q.pop()
+	}
+}
+
+// peek returns the oldest queued event without removing it
+func (q *Queue) peek() (queuedEvent, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.events) == 0 {
+		return queuedEvent{}, false
+	}
+	return q.events[0], true
+}
+
+// pop removes the oldest queued event
+func (q *Queue) pop() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.events[0] = queuedEvent{}
+	q.events = q.events[1:]
+}
+
+// Run flushes the queue every interval until ctx is done. Events still queued then stay
+// queued for a last Flush by the caller.
+func (q *Queue) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if q.Len() == 0 {
+			continue
+		}
+		stored, err := q.Flush(ctx)
+		if stored > 0 {
+			q.logger.InfoContext(ctx, "Flushed queued events", "stored", stored, "waiting", q.Len())
+		}
+		if err != nil && ctx.Err() == nil {
+			q.logger.WarnContext(ctx, "Database still unavailable, keeping queued events", "error", err, "waiting", q.Len())
+		}
+	}
+}
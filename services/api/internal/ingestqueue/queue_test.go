@@ -0,0 +1,178 @@
+package ingestqueue
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"rdl-api/internal/db/repository"
+	"rdl-api/internal/domain/models"
+	"rdl-api/internal/domain/services"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeEventStore fails every write while down, the way the repository does during an outage
+type fakeEventStore struct {
+	mu     sync.Mutex
+	down   bool
+	stored []string
+	// reject makes writes of that event ID fail with a non-transient error
+	reject string
+}
+
+func (s *fakeEventStore) CreateEventIdempotent(_ context.Context, arg models.CreateEventParams, _ uuid.UUID) (models.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.down {
+		return models.Event{}, repository.ErrFailedToAcquireConnection
+	}
+	if arg.EventID == s.reject {
+		return models.Event{}, repository.ErrCheckViolation
+	}
+	for _, id := range s.stored {
+		if id == arg.EventID {
+			return models.Event{}, services.ErrEventAlreadyExists
+		}
+	}
+	s.stored = append(s.stored, arg.EventID)
+	return models.Event{EventID: arg.EventID}, nil
+}
+
+func (s *fakeEventStore) setDown(down bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.down = down
+}
+
+func (s *fakeEventStore) storedIDs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.stored...)
+}
+
+func newTestQueue(t *testing.T, store EventStore, capacity int) *Queue {
+	t.Helper()
+	q, err := New(store, slog.New(slog.NewTextHandler(io.Discard, nil)), capacity)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return q
+}
+
+func TestQueue_FlushesEventsQueuedDuringOutage(t *testing.T) {
+	store := &fakeEventStore{down: true}
+	q := newTestQueue(t, store, 10)
+	tenantID := uuid.New()
+
+	for _, id := range []string{"evt_1", "evt_2", "evt_3"} {
+		if err := q.Enqueue(models.CreateEventParams{EventID: id}, tenantID); err != nil {
+			t.Fatalf("Enqueue(%s): %v", id, err)
+		}
+	}
+
+	stored, err := q.Flush(context.Background())
+	if !services.IsDatabaseUnavailable(err) {
+		t.Fatalf("expected an unavailable error while down, got %v", err)
+	}
+	if stored != 0 || q.Len() != 3 {
+		t.Fatalf("expected nothing stored and 3 queued while down, got stored=%d queued=%d", stored, q.Len())
+	}
+
+	store.setDown(false)
+	stored, err = q.Flush(context.Background())
+	if err != nil {
+		t.Fatalf("Flush after recovery: %v", err)
+	}
+	if stored != 3 || q.Len() != 0 {
+		t.Fatalf("expected 3 stored and none queued, got stored=%d queued=%d", stored, q.Len())
+	}
+	got := store.storedIDs()
+	want := []string{"evt_1", "evt_2", "evt_3"}
+	for i := range want {
+		if i >= len(got) || got[i] != want[i] {
+			t.Fatalf("expected events stored in order %v, got %v", want, got)
+		}
+	}
+}
+
+func TestQueue_RunFlushesOnRecovery(t *testing.T) {
+	store := &fakeEventStore{down: true}
+	q := newTestQueue(t, store, 10)
+	if err := q.Enqueue(models.CreateEventParams{EventID: "evt_1"}, uuid.New()); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		q.Run(ctx, time.Millisecond)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	if q.Len() != 1 {
+		t.Fatalf("expected the event to stay queued while down, got %d queued", q.Len())
+	}
+
+	store.setDown(false)
+	deadline := time.Now().Add(time.Second)
+	for q.Len() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("queued event was not flushed after recovery")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got := store.storedIDs(); len(got) != 1 || got[0] != "evt_1" {
+		t.Fatalf("expected evt_1 stored, got %v", got)
+	}
+}
+
+func TestQueue_DropsEventsThatCannotBeStored(t *testing.T) {
+	store := &fakeEventStore{stored: []string{"evt_dup"}, reject: "evt_bad"}
+	q := newTestQueue(t, store, 10)
+	tenantID := uuid.New()
+	for _, id := range []string{"evt_dup", "evt_bad", "evt_ok"} {
+		if err := q.Enqueue(models.CreateEventParams{EventID: id}, tenantID); err != nil {
+			t.Fatalf("Enqueue(%s): %v", id, err)
+		}
+	}
+
+	stored, err := q.Flush(context.Background())
+	if err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if stored != 1 || q.Len() != 0 {
+		t.Fatalf("expected 1 stored and none queued, got stored=%d queued=%d", stored, q.Len())
+	}
+}
+
+func TestQueue_Bounded(t *testing.T) {
+	q := newTestQueue(t, &fakeEventStore{}, 2)
+	tenantID := uuid.New()
+	for _, id := range []string{"evt_1", "evt_2"} {
+		if err := q.Enqueue(models.CreateEventParams{EventID: id}, tenantID); err != nil {
+			t.Fatalf("Enqueue(%s): %v", id, err)
+		}
+	}
+	if err := q.Enqueue(models.CreateEventParams{EventID: "evt_3"}, tenantID); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+	if q.Len() != 2 {
+		t.Fatalf("expected 2 queued, got %d", q.Len())
+	}
+}
+
+func TestNew_InvalidCapacity(t *testing.T) {
+	if _, err := New(&fakeEventStore{}, slog.New(slog.NewTextHandler(io.Discard, nil)), 0); !errors.Is(err, ErrInvalidCapacity) {
+		t.Fatalf("expected ErrInvalidCapacity, got %v", err)
+	}
+}
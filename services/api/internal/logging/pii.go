@@ -0,0 +1,150 @@
+// Package logging holds slog handlers that wrap the application's root log handler.
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+)
+
+// Redacted replaces the value of every sensitive key the PIIScrubber finds
+const Redacted = "[REDACTED]"
+
+// PIIScrubber is a slog.Handler that redacts sensitive keys before passing a record on.
+//
+// An attribute whose key is sensitive is redacted outright. Attributes holding event data,
+// raw JSON bytes or a decoded JSON map or slice, are redacted key by key at any depth.
+// The scrubber works on copies: the values handed to the logger, and so anything persisted
+// from them, are never modified.
+type PIIScrubber struct {
+	next slog.Handler
+	keys map[string]struct{}
+}
+
+// NewPIIScrubber wraps next, redacting the given keys. Keys match case-insensitively.
+func NewPIIScrubber(next slog.Handler, keys []string) *PIIScrubber {
+	set := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		set[strings.ToLower(key)] = struct{}{}
+	}
+	return &PIIScrubber{next: next, keys: set}
+}
+
+// Enabled reports whether the wrapped handler handles records at level
+func (s *PIIScrubber) Enabled(ctx context.Context, level slog.Level) bool {
+	return s.next.Enabled(ctx, level)
+}
+
+// Handle redacts the record's attributes and passes it to the wrapped handler
+func (s *PIIScrubber) Handle(ctx context.Context, r slog.Record) error {
+	scrubbed := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		scrubbed.AddAttrs(s.scrubAttr(a))
+		return true
+	})
+	return s.next.Handle(ctx, scrubbed)
+}
+
+// WithAttrs redacts attrs once, up front, and returns a scrubber over the extended handler
+func (s *PIIScrubber) WithAttrs(attrs []slog.Attr) slog.Handler {
+	scrubbed := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		scrubbed[i] = s.scrubAttr(a)
+	}
+	return &PIIScrubber{next: s.next.WithAttrs(scrubbed), keys: s.keys}
+}
+
+// WithGroup returns a scrubber over the grouped handler
+func (s *PIIScrubber) WithGroup(name string) slog.Handler {
+	return &PIIScrubber{next: s.next.WithGroup(name), keys: s.keys}
+}
+
+// ScrubJSON returns a copy of raw JSON with keys redacted at any depth, matched
+// case-insensitively. Input that is not valid JSON is withheld entirely.
+func ScrubJSON(raw []byte, keys []string) json.RawMessage {
+	return NewPIIScrubber(nil, keys).scrubJSON(raw)
+}
+
+func (s *PIIScrubber) sensitive(key string) bool {
+	_, ok := s.keys[strings.ToLower(key)]
+	return ok
+}
+
+func (s *PIIScrubber) scrubAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	if s.sensitive(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		group := a.Value.Group()
+		scrubbed := make([]slog.Attr, len(group))
+		for i, child := range group {
+			scrubbed[i] = s.scrubAttr(child)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(scrubbed...)}
+	case slog.KindAny:
+		return slog.Any(a.Key, s.scrubData(a.Value.Any()))
+	default:
+		return a
+	}
+}
+
+// scrubData returns a redacted copy of event data, or v itself when it is not event data
+func (s *PIIScrubber) scrubData(v any) any {
+	switch data := v.(type) {
+	case json.RawMessage:
+		return s.scrubJSON(data)
+	case []byte:
+		return s.scrubJSON(data)
+	case *json.RawMessage:
+		if data == nil {
+			return v
+		}
+		return s.scrubJSON(*data)
+	case map[string]any, []any:
+		return s.scrubValue(data)
+	default:
+		return v
+	}
+}
+
+// scrubJSON redacts raw JSON. A payload that does not decode is withheld entirely, since
+// there is no telling which parts of it are sensitive.
+func (s *PIIScrubber) scrubJSON(raw json.RawMessage) json.RawMessage {
+	var decoded any
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return json.RawMessage(`"` + Redacted + `"`)
+	}
+	scrubbed, err := json.Marshal(s.scrubValue(decoded))
+	if err != nil {
+		return json.RawMessage(`"` + Redacted + `"`)
+	}
+	return scrubbed
+}
+
+// scrubValue returns a copy of a decoded JSON value with sensitive keys redacted at any depth
+func (s *PIIScrubber) scrubValue(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		scrubbed := make(map[string]any, len(value))
+		for key, child := range value {
+			if s.sensitive(key) {
+				scrubbed[key] = Redacted
+				continue
+			}
+			scrubbed[key] = s.scrubValue(child)
+		}
+		return scrubbed
+	case []any:
+		scrubbed := make([]any, len(value))
+		for i, child := range value {
+			scrubbed[i] = s.scrubValue(child)
+		}
+		return scrubbed
+	default:
+		return v
+	}
+}
@@ -0,0 +1,80 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func newTestScrubber(buf *bytes.Buffer) *slog.Logger {
+	return slog.New(NewPIIScrubber(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}), []string{"email", "Customer_Name"}))
+}
+
+func TestPIIScrubber_RedactsEventData(t *testing.T) {
+	raw := json.RawMessage(`{"amount":42,"email":"jane@example.com","customer":{"customer_name":"Jane Doe","id":"cus_1"},"items":[{"email":"billing@example.com"}]}`)
+	original := append(json.RawMessage(nil), raw...)
+	decoded := map[string]any{"email": "jane@example.com", "plan": "pro"}
+
+	tests := []struct {
+		name string
+		data any
+	}{
+		{name: "raw message", data: raw},
+		{name: "raw message pointer", data: &raw},
+		{name: "webhook body", data: []byte(raw)},
+		{name: "decoded map", data: decoded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			newTestScrubber(&buf).Debug("Event data", "event_id", "evt_1", "data", tt.data)
+
+			out := buf.String()
+			for _, pii := range []string{"jane@example.com", "Jane Doe", "billing@example.com"} {
+				if strings.Contains(out, pii) {
+					t.Errorf("expected %q to be redacted, got %s", pii, out)
+				}
+			}
+			if !strings.Contains(out, Redacted) {
+				t.Errorf("expected redaction marker in %s", out)
+			}
+			if !strings.Contains(out, "evt_1") {
+				t.Errorf("expected non-sensitive fields to be kept, got %s", out)
+			}
+		})
+	}
+
+	if !bytes.Equal(raw, original) {
+		t.Errorf("scrubbing modified the logged data: %s", raw)
+	}
+	if decoded["email"] != "jane@example.com" {
+		t.Errorf("scrubbing modified the logged map: %v", decoded)
+	}
+}
+
+func TestPIIScrubber_RedactsAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newTestScrubber(&buf).With("email", "jane@example.com").WithGroup("customer")
+
+	logger.Error("Failed to notify", slog.Group("contact", "EMAIL", "ops@example.com", "channel", "slack"))
+
+	out := buf.String()
+	if strings.Contains(out, "jane@example.com") || strings.Contains(out, "ops@example.com") {
+		t.Errorf("expected emails to be redacted, got %s", out)
+	}
+	if !strings.Contains(out, "slack") {
+		t.Errorf("expected non-sensitive attrs to be kept, got %s", out)
+	}
+}
+
+func TestPIIScrubber_WithholdsUndecodableJSON(t *testing.T) {
+	var buf bytes.Buffer
+	newTestScrubber(&buf).Debug("Event data", "data", json.RawMessage(`{"email":"jane@example.com"`))
+
+	if strings.Contains(buf.String(), "jane@example.com") {
+		t.Errorf("expected undecodable data to be withheld, got %s", buf.String())
+	}
+}
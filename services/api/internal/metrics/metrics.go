@@ -0,0 +1,358 @@
+// Package metrics provides process-wide counters published through expvar.
+// Values are served as JSON by expvar.Handler, which the app mounts at /debug/vars.
+package metrics
+
+import (
+	"expvar"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rejection reasons used as the label on RejectedRequests.
+// These values are stable and are used by dashboards; don't rename them.
+const (
+	ReasonInvalidTenant    = "invalid_tenant"
+	ReasonInvalidParameter = "invalid_parameter"
+	ReasonInvalidJSON      = "invalid_json"
+	ReasonBodyTooLarge     = "body_too_large"
+	ReasonInvalidSignature = "invalid_signature"
+	ReasonStaleEvent       = "stale_event"
+	ReasonUnknownProvider  = "unknown_provider"
+	ReasonBatchTooLarge    = "batch_too_large"
+	ReasonForbidden        = "forbidden"
+	ReasonRateLimited      = "rate_limited"
+
+	// ReasonTimestampOutsideTolerance is a validly signed webhook rejected as a possible replay
+	ReasonTimestampOutsideTolerance = "timestamp_out_of_tolerance"
+)
+
+// RejectedRequests counts requests rejected before any work was done, keyed by reason
+var RejectedRequests = expvar.NewMap("rejected_requests_total")
+
+// RecordRejection increments the rejected-requests counter for the given reason
+func RecordRejection(reason string) {
+	RejectedRequests.Add(reason, 1)
+}
+
+// RejectionCount returns the current rejected-requests count for the given reason
+func RejectionCount(reason string) int64 {
+	return intValue(RejectedRequests.Get(reason))
+}
+
+// Detection run outcomes, used as the key of DetectionRuns
+const (
+	DetectionOutcomeSucceeded = "succeeded"
+	DetectionOutcomeFailed    = "failed"
+)
+
+// Detection run counters, totalled across tenants so that no tenant ID is published.
+// DetectionRuns is keyed by outcome and DetectionLeaksCreated by leak type.
+var (
+	DetectionRuns          = expvar.NewMap("detection_runs_total")
+	DetectionRunErrors     = expvar.NewInt("detection_run_errors_total")
+	DetectionEventsScanned = expvar.NewInt("detection_events_scanned_total")
+	DetectionDurationMs    = expvar.NewInt("detection_run_duration_ms_total")
+	DetectionLeaksCreated  = expvar.NewMap("detection_leaks_created_total")
+	DetectionRunsTruncated = expvar.NewInt("detection_runs_truncated_total")
+)
+
+// DetectionRun is what one leak detection run reports to RecordDetectionRun
+type DetectionRun struct {
+	Duration      time.Duration
+	EventsScanned int64
+	// CreatedByType counts the leaks the run stored, keyed by leak type
+	CreatedByType map[string]int
+	// Errors is the number of rules or stores that failed during the run
+	Errors int
+	// Truncated is set when the run stopped storing at the per-run leak cap
+	Truncated bool
+}
+
+// RecordDetectionRun adds one detection run to the detection counters. A run with any
+// errors is counted as failed.
+func RecordDetectionRun(run DetectionRun) {
+	outcome := DetectionOutcomeSucceeded
+	if run.Errors > 0 {
+		outcome = DetectionOutcomeFailed
+	}
+	DetectionRuns.Add(outcome, 1)
+	DetectionRunErrors.Add(int64(run.Errors))
+	DetectionEventsScanned.Add(run.EventsScanned)
+	DetectionDurationMs.Add(run.Duration.Milliseconds())
+	if run.Truncated {
+		DetectionRunsTruncated.Add(1)
+	}
+	for leakType, count := range run.CreatedByType {
+		DetectionLeaksCreated.Add(leakType, int64(count))
+	}
+}
+
+// DetectionRunCount returns the number of detection runs recorded with the outcome
+func DetectionRunCount(outcome string) int64 {
+	return intValue(DetectionRuns.Get(outcome))
+}
+
+// DetectionLeaksCreatedCount returns the number of leaks of the given type detection has stored
+func DetectionLeaksCreatedCount(leakType string) int64 {
+	return intValue(DetectionLeaksCreated.Get(leakType))
+}
+
+// Scheduled detection counters, each totalled across all tenants and scheduler runs. The
+// detection counters above are recorded by each tenant's run as usual.
+var (
+	DetectionSchedulerRuns           = expvar.NewInt("detection_scheduler_runs_total")
+	DetectionSchedulerTenants        = expvar.NewInt("detection_scheduler_tenants_total")
+	DetectionSchedulerTenantFailures = expvar.NewInt("detection_scheduler_tenant_failures_total")
+	DetectionSchedulerDurationMs     = expvar.NewInt("detection_scheduler_run_duration_ms_total")
+)
+
+// DetectionSchedulerRun is what one scheduled detection run across all tenants reports to
+// RecordDetectionSchedulerRun
+type DetectionSchedulerRun struct {
+	Tenants  int
+	Failed   int
+	Duration time.Duration
+}
+
+// RecordDetectionSchedulerRun adds one scheduled detection run to the scheduler counters
+func RecordDetectionSchedulerRun(run DetectionSchedulerRun) {
+	DetectionSchedulerRuns.Add(1)
+	DetectionSchedulerTenants.Add(int64(run.Tenants))
+	DetectionSchedulerTenantFailures.Add(int64(run.Failed))
+	DetectionSchedulerDurationMs.Add(run.Duration.Milliseconds())
+}
+
+// Tenant requests are kept in memory in hourly buckets, so usage can be reported for a period.
+// The buckets cover this process only, back to tenantRequestRetention. They are not published
+// through expvar; GET /usage reports them to admins.
+const (
+	tenantRequestBucket    = time.Hour
+	tenantRequestRetention = 90 * 24 * time.Hour
+	// maxTrackedTenants bounds the tenants holding buckets, since any well-formed tenant ID
+	// reaches RecordTenantRequest before the tenant is looked up
+	maxTrackedTenants = 10000
+)
+
+// TenantRequestsUntracked counts requests not recorded because maxTrackedTenants tenants
+// already held buckets
+var TenantRequestsUntracked = expvar.NewInt("tenant_requests_untracked_total")
+
+var (
+	tenantRequestsMu sync.Mutex
+	// tenantRequestBuckets maps tenant ID to bucket start, in Unix seconds, to request count
+	tenantRequestBuckets = map[string]map[int64]int64{}
+)
+
+// RecordTenantRequest counts one API request made by the tenant at the given time
+func RecordTenantRequest(tenantID string, at time.Time) {
+	bucket := at.Truncate(tenantRequestBucket).Unix()
+	cutoff := at.Add(-tenantRequestRetention).Unix()
+	tenantRequestsMu.Lock()
+	defer tenantRequestsMu.Unlock()
+
+	buckets, ok := tenantRequestBuckets[tenantID]
+	if !ok {
+		if len(tenantRequestBuckets) >= maxTrackedTenants {
+			dropExpiredTenantRequests(cutoff)
+		}
+		if len(tenantRequestBuckets) >= maxTrackedTenants {
+			TenantRequestsUntracked.Add(1)
+			return
+		}
+		buckets = map[int64]int64{}
+		tenantRequestBuckets[tenantID] = buckets
+	}
+	if _, ok := buckets[bucket]; !ok {
+		// Expired buckets are dropped when a new one starts, so each tenant holds a bounded number
+		for start := range buckets {
+			if start < cutoff {
+				delete(buckets, start)
+			}
+		}
+	}
+	buckets[bucket]++
+}
+
+// dropExpiredTenantRequests drops buckets that started before cutoff and the tenants left
+// without any. tenantRequestsMu must be held.
+func dropExpiredTenantRequests(cutoff int64) {
+	for tenantID, buckets := range tenantRequestBuckets {
+		for start := range buckets {
+			if start < cutoff {
+				delete(buckets, start)
+			}
+		}
+		if len(buckets) == 0 {
+			delete(tenantRequestBuckets, tenantID)
+		}
+	}
+}
+
+// TenantRequestCount returns the requests the tenant made in [from, to). Requests are kept per
+// hour, so the window is widened to whole hours at both ends.
+func TenantRequestCount(tenantID string, from, to time.Time) int64 {
+	tenantRequestsMu.Lock()
+	defer tenantRequestsMu.Unlock()
+
+	var total int64
+	for start, count := range tenantRequestBuckets[tenantID] {
+		if start >= from.Truncate(tenantRequestBucket).Unix() && start < to.Unix() {
+			total += count
+		}
+	}
+	return total
+}
+
+// DefaultIngestionLagBuckets are the upper bounds of the ingestion lag histogram buckets
+// unless an IngestionLagRecorder is given its own
+var DefaultIngestionLagBuckets = []time.Duration{
+	time.Second, 5 * time.Second, 30 * time.Second,
+	time.Minute, 5 * time.Minute, 15 * time.Minute,
+	time.Hour, 6 * time.Hour, 24 * time.Hour,
+}
+
+// Ingestion lag is the time between a provider's own timestamp for an event and when we stored
+// it. IngestionLag holds one Histogram per provider type; IngestionLagMissing counts, per
+// provider type, the events that carried no provider timestamp and so have no lag.
+var (
+	IngestionLag        = expvar.NewMap("event_ingestion_lag_seconds")
+	IngestionLagMissing = expvar.NewMap("event_ingestion_lag_missing_total")
+)
+
+// ingestionLagMu guards creating the per-provider-type histograms
+var ingestionLagMu sync.Mutex
+
+// IngestionLagRecorder records ingestion lag into the per-provider-type histograms of
+// IngestionLag. The zero value uses DefaultIngestionLagBuckets.
+type IngestionLagRecorder struct {
+	bounds []time.Duration
+}
+
+// NewIngestionLagRecorder creates a recorder whose histograms use bounds, which must be
+// ascending. Empty bounds use DefaultIngestionLagBuckets. A provider type's histogram is
+// created with the bounds of the recorder that first records for it.
+func NewIngestionLagRecorder(bounds []time.Duration) IngestionLagRecorder {
+	return IngestionLagRecorder{bounds: slices.Clone(bounds)}
+}
+
+// Record adds one event's ingestion lag to its provider type's histogram. A negative lag,
+// from a provider clock ahead of ours, is recorded as 0.
+func (r IngestionLagRecorder) Record(providerType string, lag time.Duration) {
+	r.Histogram(providerType).Observe(max(lag, 0))
+}
+
+// Histogram returns the provider type's ingestion lag histogram, creating it on first use
+func (r IngestionLagRecorder) Histogram(providerType string) *Histogram {
+	ingestionLagMu.Lock()
+	defer ingestionLagMu.Unlock()
+
+	if h, ok := IngestionLag.Get(providerType).(*Histogram); ok {
+		return h
+	}
+	bounds := r.bounds
+	if len(bounds) == 0 {
+		bounds = DefaultIngestionLagBuckets
+	}
+	h := NewHistogram(bounds)
+	IngestionLag.Set(providerType, h)
+	return h
+}
+
+// RecordMissingIngestionTimestamp counts an event of the provider type stored without a
+// provider timestamp
+func RecordMissingIngestionTimestamp(providerType string) {
+	IngestionLagMissing.Add(providerType, 1)
+}
+
+// MissingIngestionTimestampCount returns the events of the provider type stored without a
+// provider timestamp
+func MissingIngestionTimestampCount(providerType string) int64 {
+	return intValue(IngestionLagMissing.Get(providerType))
+}
+
+// Histogram counts observed durations in buckets by upper bound, with a final bucket for
+// anything above the last bound. It is an expvar.Var, published as JSON with cumulative
+// bucket counts keyed by bound in seconds, as Prometheus does.
+type Histogram struct {
+	bounds []time.Duration
+
+	mu     sync.Mutex
+	counts []int64
+	count  int64
+	sum    time.Duration
+}
+
+// NewHistogram creates an empty histogram; bounds must be ascending
+func NewHistogram(bounds []time.Duration) *Histogram {
+	return &Histogram{bounds: slices.Clone(bounds), counts: make([]int64, len(bounds)+1)}
+}
+
+// Observe adds d to the first bucket whose bound it does not exceed
+func (h *Histogram) Observe(d time.Duration) {
+	i, _ := slices.BinarySearch(h.bounds, d)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.counts[i]++
+	h.count++
+	h.sum += d
+}
+
+// Count returns the number of observations
+func (h *Histogram) Count() int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+// Sum returns the total of the observed durations
+func (h *Histogram) Sum() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.sum
+}
+
+// CountAtMost returns the number of observations no greater than bound, which must be one of
+// the histogram's bounds; any other bound returns the count of the buckets below it
+func (h *Histogram) CountAtMost(bound time.Duration) int64 {
+	n, _ := slices.BinarySearch(h.bounds, bound)
+	if n < len(h.bounds) && h.bounds[n] == bound {
+		n++
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var total int64
+	for _, c := range h.counts[:n] {
+		total += c
+	}
+	return total
+}
+
+// String returns the histogram as JSON, for expvar
+func (h *Histogram) String() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var b strings.Builder
+	b.WriteString(`{"buckets":{`)
+	var cumulative int64
+	for i, bound := range h.bounds {
+		cumulative += h.counts[i]
+		fmt.Fprintf(&b, "%q:%d,", strconv.FormatFloat(bound.Seconds(), 'f', -1, 64), cumulative)
+	}
+	fmt.Fprintf(&b, `"+Inf":%d},"count":%d,"sum":%s}`, h.count, h.count, strconv.FormatFloat(h.sum.Seconds(), 'f', -1, 64))
+	return b.String()
+}
+
+// intValue returns the value of an *expvar.Int, or 0 for anything else including nil
+func intValue(v expvar.Var) int64 {
+	if i, ok := v.(*expvar.Int); ok {
+		return i.Value()
+	}
+	return 0
+}
@@ -0,0 +1,190 @@
+package metrics
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRecordRejection(t *testing.T) {
+	before := RejectionCount(ReasonInvalidJSON)
+
+	RecordRejection(ReasonInvalidJSON)
+	RecordRejection(ReasonInvalidJSON)
+
+	if got := RejectionCount(ReasonInvalidJSON) - before; got != 2 {
+		t.Errorf("expected count to increase by 2, got %d", got)
+	}
+}
+
+func TestRejectionCount_UnknownReason(t *testing.T) {
+	if got := RejectionCount("never_recorded"); got != 0 {
+		t.Errorf("expected 0 for unrecorded reason, got %d", got)
+	}
+}
+
+func TestRecordDetectionRun(t *testing.T) {
+	succeeded, failed := DetectionRunCount(DetectionOutcomeSucceeded), DetectionRunCount(DetectionOutcomeFailed)
+	errs, scanned, truncated := DetectionRunErrors.Value(), DetectionEventsScanned.Value(), DetectionRunsTruncated.Value()
+	failedPayments, volume := DetectionLeaksCreatedCount("failed_payments"), DetectionLeaksCreatedCount("volume_anomaly")
+
+	RecordDetectionRun(DetectionRun{
+		Duration:      250 * time.Millisecond,
+		EventsScanned: 40,
+		CreatedByType: map[string]int{"failed_payments": 2},
+		Errors:        1,
+	})
+	RecordDetectionRun(DetectionRun{EventsScanned: 10, CreatedByType: map[string]int{"failed_payments": 1, "volume_anomaly": 1}, Truncated: true})
+
+	if got := DetectionRunCount(DetectionOutcomeSucceeded) - succeeded; got != 1 {
+		t.Errorf("expected 1 succeeded run, got %d", got)
+	}
+	if got := DetectionRunCount(DetectionOutcomeFailed) - failed; got != 1 {
+		t.Errorf("expected 1 failed run, got %d", got)
+	}
+	if got := DetectionRunErrors.Value() - errs; got != 1 {
+		t.Errorf("expected 1 error, got %d", got)
+	}
+	if got := DetectionEventsScanned.Value() - scanned; got != 50 {
+		t.Errorf("expected 50 events scanned, got %d", got)
+	}
+	if got := DetectionRunsTruncated.Value() - truncated; got != 1 {
+		t.Errorf("expected 1 truncated run, got %d", got)
+	}
+	if got := DetectionLeaksCreatedCount("failed_payments") - failedPayments; got != 3 {
+		t.Errorf("expected 3 failed_payments leaks, got %d", got)
+	}
+	if got := DetectionLeaksCreatedCount("volume_anomaly") - volume; got != 1 {
+		t.Errorf("expected 1 volume_anomaly leak, got %d", got)
+	}
+	if got := DetectionLeaksCreatedCount("never_recorded"); got != 0 {
+		t.Errorf("expected 0 for an unrecorded leak type, got %d", got)
+	}
+}
+
+func TestRecordDetectionSchedulerRun(t *testing.T) {
+	runs, tenants, failures := DetectionSchedulerRuns.Value(), DetectionSchedulerTenants.Value(), DetectionSchedulerTenantFailures.Value()
+
+	RecordDetectionSchedulerRun(DetectionSchedulerRun{Tenants: 5, Failed: 1, Duration: time.Second})
+	RecordDetectionSchedulerRun(DetectionSchedulerRun{Tenants: 5})
+
+	if got := DetectionSchedulerRuns.Value() - runs; got != 2 {
+		t.Errorf("expected 2 runs, got %d", got)
+	}
+	if got := DetectionSchedulerTenants.Value() - tenants; got != 10 {
+		t.Errorf("expected 10 tenants, got %d", got)
+	}
+	if got := DetectionSchedulerTenantFailures.Value() - failures; got != 1 {
+		t.Errorf("expected 1 tenant failure, got %d", got)
+	}
+}
+
+func TestTenantRequestCount(t *testing.T) {
+	tenantID := "22222222-2222-2222-2222-222222222222"
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	RecordTenantRequest(tenantID, day.Add(9*time.Hour+15*time.Minute))
+	RecordTenantRequest(tenantID, day.Add(9*time.Hour+45*time.Minute))
+	RecordTenantRequest(tenantID, day.Add(14*time.Hour))
+	RecordTenantRequest("33333333-3333-3333-3333-333333333333", day.Add(9*time.Hour))
+
+	if got := TenantRequestCount(tenantID, day, day.Add(24*time.Hour)); got != 3 {
+		t.Errorf("expected 3 requests in the day, got %d", got)
+	}
+	if got := TenantRequestCount(tenantID, day.Add(10*time.Hour), day.Add(24*time.Hour)); got != 1 {
+		t.Errorf("expected 1 request after 10:00, got %d", got)
+	}
+	if got := TenantRequestCount(tenantID, day.Add(24*time.Hour), day.Add(48*time.Hour)); got != 0 {
+		t.Errorf("expected no requests the next day, got %d", got)
+	}
+
+	// A request long after the retention period drops the old buckets
+	RecordTenantRequest(tenantID, day.Add(tenantRequestRetention+24*time.Hour))
+	if got := TenantRequestCount(tenantID, day, day.Add(24*time.Hour)); got != 0 {
+		t.Errorf("expected expired buckets to be dropped, got %d", got)
+	}
+}
+
+func TestRecordTenantRequest_CapsTrackedTenants(t *testing.T) {
+	saved := tenantRequestBuckets
+	tenantRequestBuckets = map[string]map[int64]int64{}
+	t.Cleanup(func() { tenantRequestBuckets = saved })
+
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	for i := range maxTrackedTenants {
+		RecordTenantRequest(strconv.Itoa(i), day)
+	}
+	untracked := TenantRequestsUntracked.Value()
+
+	RecordTenantRequest("one-too-many", day)
+	if got := TenantRequestCount("one-too-many", day, day.Add(time.Hour)); got != 0 {
+		t.Errorf("expected a tenant over the cap not to be tracked, got %d", got)
+	}
+	if got := TenantRequestsUntracked.Value() - untracked; got != 1 {
+		t.Errorf("expected 1 untracked request, got %d", got)
+	}
+
+	// A tracked tenant keeps counting at the cap
+	RecordTenantRequest("0", day)
+	if got := TenantRequestCount("0", day, day.Add(time.Hour)); got != 2 {
+		t.Errorf("expected 2 requests for a tracked tenant, got %d", got)
+	}
+
+	// Once the existing buckets expire, their tenants make room for new ones
+	later := day.Add(tenantRequestRetention + 24*time.Hour)
+	RecordTenantRequest("one-too-many", later)
+	if got := TenantRequestCount("one-too-many", later, later.Add(time.Hour)); got != 1 {
+		t.Errorf("expected the tenant to be tracked after expiry, got %d", got)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	h := NewHistogram([]time.Duration{time.Second, time.Minute})
+
+	h.Observe(500 * time.Millisecond)
+	h.Observe(time.Second)
+	h.Observe(30 * time.Second)
+	h.Observe(time.Hour)
+
+	if got := h.Count(); got != 4 {
+		t.Errorf("expected 4 observations, got %d", got)
+	}
+	if got := h.Sum(); got != time.Hour+31*time.Second+500*time.Millisecond {
+		t.Errorf("unexpected sum %s", got)
+	}
+	if got := h.CountAtMost(time.Second); got != 2 {
+		t.Errorf("expected a bound to include observations equal to it, got %d", got)
+	}
+	if got := h.CountAtMost(time.Minute); got != 3 {
+		t.Errorf("expected 3 observations of at most a minute, got %d", got)
+	}
+
+	want := `{"buckets":{"1":2,"60":3,"+Inf":4},"count":4,"sum":3631.5}`
+	if got := h.String(); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestRecordIngestionLag(t *testing.T) {
+	recorder := NewIngestionLagRecorder([]time.Duration{time.Second, time.Minute})
+	recorder.Record("test_lag_provider", 10*time.Second)
+	recorder.Record("test_lag_provider", -time.Second)
+	RecordMissingIngestionTimestamp("test_lag_provider")
+
+	h := recorder.Histogram("test_lag_provider")
+	if got := h.Count(); got != 2 {
+		t.Errorf("expected 2 observations, got %d", got)
+	}
+	if got := h.CountAtMost(time.Second); got != 1 {
+		t.Errorf("expected a negative lag to be recorded as 0, got %d in the first bucket", got)
+	}
+	if got := h.Sum(); got != 10*time.Second {
+		t.Errorf("expected a sum of 10s, got %s", got)
+	}
+	if got := MissingIngestionTimestampCount("test_lag_provider"); got != 1 {
+		t.Errorf("expected 1 event without a timestamp, got %d", got)
+	}
+	if recorder.Histogram("other_lag_provider") == h {
+		t.Error("expected a histogram per provider type")
+	}
+}
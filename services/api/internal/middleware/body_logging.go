@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"rdl-api/internal/logging"
+	"strings"
+)
+
+// defaultBodyCaptureBytes bounds body capture when no request body limit is configured
+const defaultBodyCaptureBytes = 1 << 20
+
+// BodyLogOptions configures BodyLogger
+type BodyLogOptions struct {
+	// Paths are the path prefixes whose bodies are logged; empty logs every path
+	Paths []string
+	// MaxBytes is how much of each scrubbed body is logged; longer bodies are cut and marked truncated
+	MaxBytes int
+	// CaptureBytes bounds how much of each body is held in memory for scrubbing, normally the
+	// request body limit. A body longer than this cannot be scrubbed and is withheld.
+	// 0 or less uses 1 MiB.
+	CaptureBytes int64
+	// PIIKeys are redacted from the bodies at any depth
+	PIIKeys []string
+}
+
+// BodyLogger logs request and response bodies at DEBUG level, for debugging integrations.
+// The request body is teed as the handler reads it, so only what the handler consumed is
+// logged, and nothing is buffered beyond opts.CaptureBytes. Bodies are redacted with
+// logging.ScrubJSON before being cut to opts.MaxBytes; bodies that are not JSON are withheld.
+// When the logger does not handle DEBUG, requests pass through untouched.
+func BodyLogger(logger *slog.Logger, opts BodyLogOptions) Middleware {
+	if opts.CaptureBytes <= 0 {
+		opts.CaptureBytes = defaultBodyCaptureBytes
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !logger.Enabled(r.Context(), slog.LevelDebug) || !hasPathPrefix(r.URL.Path, opts.Paths) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			request := &cappedBuffer{limit: opts.CaptureBytes}
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = teeReadCloser{Reader: io.TeeReader(r.Body, request), Closer: r.Body}
+			}
+			rw := &bodyCaptureWriter{
+				ResponseWriter: w,
+				statusCode:     http.StatusOK,
+				body:           &cappedBuffer{limit: opts.CaptureBytes},
+			}
+
+			next.ServeHTTP(rw, r)
+
+			logFields := []any{
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status_code", rw.statusCode),
+				slog.String("request_id", GetRequestID(r)),
+			}
+			logFields = append(logFields, bodyAttrs("request_body", request, opts)...)
+			logFields = append(logFields, bodyAttrs("response_body", rw.body, opts)...)
+			logger.DebugContext(r.Context(), "HTTP bodies", logFields...)
+		})
+	}
+}
+
+// bodyAttrs describes a captured body: its scrubbed, possibly truncated text, the size seen,
+// and whether the text was cut. An empty body has no attributes.
+func bodyAttrs(key string, body *cappedBuffer, opts BodyLogOptions) []any {
+	if body.total == 0 {
+		return nil
+	}
+	// A body cut at capture is partial JSON, which ScrubJSON withholds
+	scrubbed := logging.ScrubJSON(body.Bytes(), opts.PIIKeys)
+	truncated := len(scrubbed) > opts.MaxBytes
+	if truncated {
+		scrubbed = scrubbed[:opts.MaxBytes]
+	}
+	return []any{
+		slog.String(key, string(scrubbed)),
+		slog.Int64(key+"_bytes", body.total),
+		slog.Bool(key+"_truncated", truncated),
+	}
+}
+
+// hasPathPrefix reports whether path starts with one of prefixes; no prefixes match every path
+func hasPathPrefix(path string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// cappedBuffer keeps the first limit bytes written to it and counts the rest.
+// Writes never fail, so it can sit on a tee without disturbing the real reader or writer.
+type cappedBuffer struct {
+	bytes.Buffer
+	limit int64
+	total int64
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	b.total += int64(len(p))
+	if room := b.limit - int64(b.Len()); room > 0 {
+		b.Buffer.Write(p[:min(int64(len(p)), room)])
+	}
+	return len(p), nil
+}
+
+// teeReadCloser reads through a tee and closes the original body
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// bodyCaptureWriter copies what the handler writes into a capped buffer
+type bodyCaptureWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       *cappedBuffer
+}
+
+func (w *bodyCaptureWriter) WriteHeader(code int) {
+	w.statusCode = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *bodyCaptureWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	_, _ = w.body.Write(p[:n])
+	return n, err
+}
+
+// Flush keeps streaming responses such as the NDJSON export flushing through the wrapper
+func (w *bodyCaptureWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
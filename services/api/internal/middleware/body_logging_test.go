@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoHandler reads the request body and writes it back as the response
+var echoHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(body)
+})
+
+// bodyLogLine serves body through BodyLogger and returns the decoded "HTTP bodies" log line, or nil
+func bodyLogLine(t *testing.T, level slog.Level, opts BodyLogOptions, path, body string) map[string]any {
+	t.Helper()
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: level}))
+
+	rr := httptest.NewRecorder()
+	BodyLogger(logger, opts)(echoHandler).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+	require.Equal(t, http.StatusCreated, rr.Code)
+	require.Equal(t, body, rr.Body.String(), "expected the bodies to pass through unchanged")
+
+	if buf.Len() == 0 {
+		return nil
+	}
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	return line
+}
+
+func TestBodyLogger(t *testing.T) {
+	opts := BodyLogOptions{MaxBytes: 1024, PIIKeys: []string{"email"}}
+	body := `{"amount":42,"email":"jane@example.com"}`
+
+	t.Run("logs scrubbed bodies at debug", func(t *testing.T) {
+		line := bodyLogLine(t, slog.LevelDebug, opts, "/events/batch", body)
+		require.NotNil(t, line)
+		for _, key := range []string{"request_body", "response_body"} {
+			logged, _ := line[key].(string)
+			assert.Contains(t, logged, `"amount":42`)
+			assert.NotContains(t, logged, "jane@example.com")
+			assert.Equal(t, false, line[key+"_truncated"])
+		}
+		assert.EqualValues(t, len(body), line["request_body_bytes"])
+		assert.EqualValues(t, http.StatusCreated, line["status_code"])
+	})
+
+	t.Run("nothing is logged above debug", func(t *testing.T) {
+		assert.Nil(t, bodyLogLine(t, slog.LevelInfo, opts, "/events/batch", body))
+	})
+
+	t.Run("only the configured paths are logged", func(t *testing.T) {
+		scoped := opts
+		scoped.Paths = []string{"/webhooks/"}
+		assert.Nil(t, bodyLogLine(t, slog.LevelDebug, scoped, "/events/batch", body))
+		assert.NotNil(t, bodyLogLine(t, slog.LevelDebug, scoped, "/webhooks/stripe", body))
+	})
+
+	t.Run("long bodies are truncated after scrubbing", func(t *testing.T) {
+		short := opts
+		short.MaxBytes = 10
+		line := bodyLogLine(t, slog.LevelDebug, short, "/events/batch", body)
+		require.NotNil(t, line)
+		assert.Len(t, line["request_body"], 10)
+		assert.Equal(t, true, line["request_body_truncated"])
+		assert.EqualValues(t, len(body), line["request_body_bytes"])
+	})
+
+	t.Run("bodies over the capture limit are withheld", func(t *testing.T) {
+		capped := opts
+		capped.CaptureBytes = 8
+		line := bodyLogLine(t, slog.LevelDebug, capped, "/events/batch", body)
+		require.NotNil(t, line)
+		assert.NotContains(t, line["request_body"], "jane@example.com")
+		assert.NotContains(t, line["request_body"], "amount")
+	})
+}
@@ -0,0 +1,302 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"rdl-api/internal/domain/models"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// IdempotencyKeyHeader carries the client's key for a request it may retry
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on a response replayed for a repeated key
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	// MaxIdempotencyKeyLength is the longest key accepted
+	MaxIdempotencyKeyLength = 255
+)
+
+var (
+	ErrIdempotencyKeyTooLong = fmt.Errorf("%s must be at most %d characters", IdempotencyKeyHeader, MaxIdempotencyKeyLength)
+	ErrIdempotencyKeyReused  = fmt.Errorf("%s was already used for a different request", IdempotencyKeyHeader)
+	// ErrInvalidIdempotencyRecord is returned when saving a record without a tenant or a key
+	ErrInvalidIdempotencyRecord = errors.New("idempotency record needs a tenant and a key")
+)
+
+// hopByHopHeaders only describe the connection a response was first sent on, so they are not
+// stored for replay
+var hopByHopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// idempotentMethods are the methods whose responses are stored by key
+var idempotentMethods = map[string]bool{
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+// IdempotencyStore keeps the response to each tenant's idempotency keys
+type IdempotencyStore interface {
+	// GetIdempotencyRecord returns the unexpired response stored for the key, and false when there is none
+	GetIdempotencyRecord(ctx context.Context, tenantID uuid.UUID, key string) (models.IdempotencyRecord, bool, error)
+	// SaveIdempotencyRecord stores a response, keeping the one already stored for the key if any
+	SaveIdempotencyRecord(ctx context.Context, record models.IdempotencyRecord) error
+}
+
+// Idempotency replays the stored response when a tenant repeats a POST, PUT, PATCH or DELETE
+// with the same Idempotency-Key header, instead of running the request again. The first
+// response below 500 is stored for ttl with its headers, less the hop-by-hop ones; server
+// errors are not, so the client can retry them. A key reused for a different method, path or
+// request body is rejected with 422, and a key over MaxIdempotencyKeyLength with 400, both
+// written by respond.
+//
+// The body is compared by its SHA-256, read in full before the request runs. A body longer
+// than maxBodyBytes, normally the request body limit, is not hashed and the request runs as if
+// it had no key; 0 or less uses a 1 MiB limit.
+//
+// Requests are not locked while they run, so two requests with the same key that overlap
+// both run; the response stored first is the one replayed afterwards. When the store cannot
+// be read the request runs as if it had no key.
+//
+// It must run after TenantContext; requests without a tenant or without a key are passed
+// through untouched.
+func Idempotency(l *slog.Logger, store IdempotencyStore, ttl time.Duration, maxBodyBytes int64, respond ErrorResponder) Middleware {
+	if maxBodyBytes <= 0 {
+		maxBodyBytes = defaultBodyCaptureBytes
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			tenantID, ok := GetTenantID(r)
+			if key == "" || !ok || !idempotentMethods[r.Method] {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > MaxIdempotencyKeyLength {
+				respond.write(w, r, ErrIdempotencyKeyTooLong, http.StatusBadRequest)
+				return
+			}
+
+			ctx := r.Context()
+			requestHash, hashed := hashRequestBody(r, maxBodyBytes)
+			if !hashed {
+				l.WarnContext(ctx, "Request body too large to check against its idempotency key, running the request without it", "tenant_id", tenantID, "max_bytes", maxBodyBytes)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			record, found, err := store.GetIdempotencyRecord(ctx, tenantID, key)
+			if err != nil {
+				l.WarnContext(ctx, "Failed to read idempotency key, running the request without it", "error", err, "tenant_id", tenantID)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if found {
+				// Records stored before bodies were hashed have no hash and match any body
+				if record.Method != r.Method || record.Path != r.URL.Path || (record.RequestHash != "" && record.RequestHash != requestHash) {
+					respond.write(w, r, ErrIdempotencyKeyReused, http.StatusUnprocessableEntity)
+					return
+				}
+				l.DebugContext(ctx, "Replaying response for idempotency key", "tenant_id", tenantID, "status", record.StatusCode)
+				for key, values := range record.Headers {
+					w.Header()[key] = values
+				}
+				// Records stored before headers were kept have only their Content-Type
+				if len(record.Headers) == 0 && record.ContentType != "" {
+					w.Header().Set("Content-Type", record.ContentType)
+				}
+				w.Header().Set(IdempotentReplayedHeader, "true")
+				w.WriteHeader(record.StatusCode)
+				_, _ = w.Write(record.Body)
+				return
+			}
+
+			bw := &bufferedResponseWriter{header: make(http.Header), statusCode: http.StatusOK}
+			next.ServeHTTP(bw, r)
+
+			if bw.statusCode < http.StatusInternalServerError {
+				// Stored even when the client has gone, since it is likely to retry
+				err := store.SaveIdempotencyRecord(context.WithoutCancel(ctx), models.IdempotencyRecord{
+					TenantID:    tenantID,
+					Key:         key,
+					Method:      r.Method,
+					Path:        r.URL.Path,
+					StatusCode:  bw.statusCode,
+					ContentType: bw.header.Get("Content-Type"),
+					Body:        bw.body.Bytes(),
+					ExpiresAt:   time.Now().Add(ttl),
+					RequestHash: requestHash,
+					Headers:     replayableHeaders(bw.header),
+				})
+				if err != nil {
+					l.ErrorContext(ctx, "Failed to store response for idempotency key", "error", err, "tenant_id", tenantID)
+				}
+			}
+
+			bw.flushTo(w)
+		})
+	}
+}
+
+// replayableHeaders returns a copy of header without the hop-by-hop headers, including the
+// ones its Connection header names
+func replayableHeaders(header http.Header) map[string][]string {
+	replayable := header.Clone()
+	for _, value := range header.Values("Connection") {
+		for _, name := range strings.Split(value, ",") {
+			replayable.Del(strings.TrimSpace(name))
+		}
+	}
+	for _, name := range hopByHopHeaders {
+		replayable.Del(name)
+	}
+	return replayable
+}
+
+// hashRequestBody returns the hex SHA-256 of r's body and puts the body back for the handler
+// to read. hashed is false when the body is longer than maxBytes or cannot be read; what was
+// read is put back all the same.
+func hashRequestBody(r *http.Request, maxBytes int64) (hash string, hashed bool) {
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(io.LimitReader(r.Body, maxBytes+1))
+		r.Body = teeReadCloser{Reader: io.MultiReader(bytes.NewReader(body), r.Body), Closer: r.Body}
+		if err != nil || int64(len(body)) > maxBytes {
+			return "", false
+		}
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), true
+}
+
+// idempotencySweepInterval is how often MemoryIdempotencyStore.Run drops the expired keys
+const idempotencySweepInterval = time.Minute
+
+type memoryIdempotencyKey struct {
+	tenantID uuid.UUID
+	key      string
+}
+
+// storedIdempotencyKey is a key in the order MemoryIdempotencyStore stored it, with the expiry
+// it was stored with to tell it from a later record for the same key
+type storedIdempotencyKey struct {
+	key       memoryIdempotencyKey
+	expiresAt time.Time
+}
+
+// MemoryIdempotencyStore keeps idempotency keys in process memory. They are lost on restart
+// and not seen by other instances, so a retry that reaches another replica runs again. It
+// holds at most maxKeys keys: when it is full the oldest key is dropped to make room, and Run
+// drops the expired ones in the background.
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[memoryIdempotencyKey]models.IdempotencyRecord
+	// order holds the stored keys oldest first; it may still list keys since swept or replaced
+	order   []storedIdempotencyKey
+	maxKeys int
+	now     func() time.Time
+}
+
+// NewMemoryIdempotencyStore creates an empty MemoryIdempotencyStore holding at most maxKeys
+// keys; 0 or less is unlimited
+func NewMemoryIdempotencyStore(maxKeys int) *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{records: map[memoryIdempotencyKey]models.IdempotencyRecord{}, maxKeys: maxKeys, now: time.Now}
+}
+
+// GetIdempotencyRecord returns the unexpired response stored for the tenant's key
+func (s *MemoryIdempotencyStore) GetIdempotencyRecord(_ context.Context, tenantID uuid.UUID, key string) (models.IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[memoryIdempotencyKey{tenantID: tenantID, key: key}]
+	if !ok || !record.ExpiresAt.After(s.now()) {
+		return models.IdempotencyRecord{}, false, nil
+	}
+	return record, true, nil
+}
+
+// SaveIdempotencyRecord stores the response unless the key already has an unexpired one. When
+// the store is full it first drops the oldest keys to make room.
+func (s *MemoryIdempotencyStore) SaveIdempotencyRecord(_ context.Context, record models.IdempotencyRecord) error {
+	if record.TenantID == uuid.Nil || record.Key == "" {
+		return ErrInvalidIdempotencyRecord
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := memoryIdempotencyKey{tenantID: record.TenantID, key: record.Key}
+	stored, ok := s.records[k]
+	if ok && stored.ExpiresAt.After(s.now()) {
+		return nil
+	}
+	if !ok && s.maxKeys > 0 {
+		for len(s.records) >= s.maxKeys && len(s.order) > 0 {
+			oldest := s.order[0]
+			s.order = s.order[1:]
+			if current, ok := s.records[oldest.key]; ok && current.ExpiresAt.Equal(oldest.expiresAt) {
+				delete(s.records, oldest.key)
+			}
+		}
+	}
+	s.records[k] = record
+	s.order = append(s.order, storedIdempotencyKey{key: k, expiresAt: record.ExpiresAt})
+	return nil
+}
+
+// Run drops the expired keys every minute until ctx is canceled
+func (s *MemoryIdempotencyStore) Run(ctx context.Context) {
+	ticker := time.NewTicker(idempotencySweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sweep()
+		}
+	}
+}
+
+// sweep drops the expired keys, and the entries of order that no longer match a stored record
+func (s *MemoryIdempotencyStore) sweep() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for k, stored := range s.records {
+		if !stored.ExpiresAt.After(now) {
+			delete(s.records, k)
+		}
+	}
+	order := make([]storedIdempotencyKey, 0, len(s.records))
+	for _, entry := range s.order {
+		if current, ok := s.records[entry.key]; ok && current.ExpiresAt.Equal(entry.expiresAt) {
+			order = append(order, entry)
+		}
+	}
+	s.order = order
+}
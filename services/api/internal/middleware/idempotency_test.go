@@ -0,0 +1,288 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"rdl-api/internal/domain/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTenantID = "123e4567-e89b-12d3-a456-426614174000"
+
+// idempotentInstance is one API instance: its own handler behind Idempotency, counting the
+// requests that reached the handler
+type idempotentInstance struct {
+	handler http.Handler
+	calls   int
+}
+
+func newIdempotentInstance(store IdempotencyStore, status int) *idempotentInstance {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	instance := &idempotentInstance{}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		instance.calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", fmt.Sprintf("/events/%d", instance.calls))
+		w.Header().Set("Connection", "X-Hop")
+		w.Header().Set("X-Hop", "first connection only")
+		w.Header().Set("Keep-Alive", "timeout=5")
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"call":%d}`, instance.calls)
+	})
+	instance.handler = Chain(handler, TenantContext(logger, true, nil), Idempotency(logger, store, time.Hour, 64, jsonErrorResponder))
+	return instance
+}
+
+// jsonErrorResponder writes the error as a JSON object, standing in for the handlers' envelope
+func jsonErrorResponder(w http.ResponseWriter, _ *http.Request, err error, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `{"error":%q}`, err.Error())
+}
+
+func (i *idempotentInstance) serve(method, path, tenantID, key string) *httptest.ResponseRecorder {
+	return i.serveBody(method, path, tenantID, key, `{}`)
+}
+
+func (i *idempotentInstance) serveBody(method, path, tenantID, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("X-Tenant-ID", tenantID)
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	rec := httptest.NewRecorder()
+	i.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotency(t *testing.T) {
+	t.Run("repeated key replays the first response", func(t *testing.T) {
+		instance := newIdempotentInstance(NewMemoryIdempotencyStore(0), http.StatusCreated)
+
+		first := instance.serve(http.MethodPost, "/events", testTenantID, "key-1")
+		second := instance.serve(http.MethodPost, "/events", testTenantID, "key-1")
+
+		assert.Equal(t, 1, instance.calls)
+		assert.Equal(t, http.StatusCreated, second.Code)
+		assert.Equal(t, first.Body.String(), second.Body.String())
+		assert.Equal(t, "application/json", second.Header().Get("Content-Type"))
+		assert.Equal(t, "true", second.Header().Get(IdempotentReplayedHeader))
+		assert.Empty(t, first.Header().Get(IdempotentReplayedHeader))
+	})
+
+	t.Run("replay keeps the response headers but not the hop-by-hop ones", func(t *testing.T) {
+		instance := newIdempotentInstance(NewMemoryIdempotencyStore(0), http.StatusCreated)
+
+		instance.serve(http.MethodPost, "/events", testTenantID, "key-1")
+		replayed := instance.serve(http.MethodPost, "/events", testTenantID, "key-1")
+
+		assert.Equal(t, "/events/1", replayed.Header().Get("Location"))
+		assert.Empty(t, replayed.Header().Get("Connection"))
+		assert.Empty(t, replayed.Header().Get("Keep-Alive"))
+		assert.Empty(t, replayed.Header().Get("X-Hop"), "a header the Connection header names is hop-by-hop")
+	})
+
+	t.Run("record without headers replays its content type", func(t *testing.T) {
+		store := NewMemoryIdempotencyStore(0)
+		tenantID := uuid.MustParse(testTenantID)
+		require.NoError(t, store.SaveIdempotencyRecord(context.Background(), models.IdempotencyRecord{
+			TenantID: tenantID, Key: "key-1", Method: http.MethodPost, Path: "/events",
+			StatusCode: http.StatusCreated, ContentType: "application/json", Body: []byte(`{}`), ExpiresAt: time.Now().Add(time.Hour),
+		}))
+		instance := newIdempotentInstance(store, http.StatusCreated)
+
+		replayed := instance.serve(http.MethodPost, "/events", testTenantID, "key-1")
+
+		assert.Zero(t, instance.calls)
+		assert.Equal(t, "application/json", replayed.Header().Get("Content-Type"))
+	})
+
+	t.Run("key stored by one instance is honored by another", func(t *testing.T) {
+		shared := NewMemoryIdempotencyStore(0)
+		a := newIdempotentInstance(shared, http.StatusCreated)
+		b := newIdempotentInstance(shared, http.StatusCreated)
+
+		first := a.serve(http.MethodPost, "/events", testTenantID, "key-1")
+		retry := b.serve(http.MethodPost, "/events", testTenantID, "key-1")
+
+		assert.Equal(t, 1, a.calls)
+		assert.Zero(t, b.calls, "the retry must not run again on the second instance")
+		assert.Equal(t, first.Body.String(), retry.Body.String())
+		assert.Equal(t, "true", retry.Header().Get(IdempotentReplayedHeader))
+	})
+
+	t.Run("requests without a key or with a safe method always run", func(t *testing.T) {
+		instance := newIdempotentInstance(NewMemoryIdempotencyStore(0), http.StatusOK)
+
+		instance.serve(http.MethodPost, "/events", testTenantID, "")
+		instance.serve(http.MethodPost, "/events", testTenantID, "")
+		instance.serve(http.MethodGet, "/events", testTenantID, "key-1")
+		instance.serve(http.MethodGet, "/events", testTenantID, "key-1")
+
+		assert.Equal(t, 4, instance.calls)
+	})
+
+	t.Run("keys are per tenant", func(t *testing.T) {
+		instance := newIdempotentInstance(NewMemoryIdempotencyStore(0), http.StatusCreated)
+
+		instance.serve(http.MethodPost, "/events", testTenantID, "key-1")
+		instance.serve(http.MethodPost, "/events", uuid.NewString(), "key-1")
+
+		assert.Equal(t, 2, instance.calls)
+	})
+
+	t.Run("key reused for a different request", func(t *testing.T) {
+		instance := newIdempotentInstance(NewMemoryIdempotencyStore(0), http.StatusCreated)
+
+		instance.serve(http.MethodPost, "/events", testTenantID, "key-1")
+		rec := instance.serve(http.MethodPost, "/events/batch", testTenantID, "key-1")
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.JSONEq(t, fmt.Sprintf(`{"error":%q}`, ErrIdempotencyKeyReused.Error()), rec.Body.String())
+		assert.Equal(t, 1, instance.calls)
+	})
+
+	t.Run("key reused with a different body", func(t *testing.T) {
+		instance := newIdempotentInstance(NewMemoryIdempotencyStore(0), http.StatusCreated)
+
+		instance.serveBody(http.MethodPost, "/events", testTenantID, "key-1", `{"amount":100}`)
+		same := instance.serveBody(http.MethodPost, "/events", testTenantID, "key-1", `{"amount":100}`)
+		rec := instance.serveBody(http.MethodPost, "/events", testTenantID, "key-1", `{"amount":200}`)
+
+		assert.Equal(t, "true", same.Header().Get(IdempotentReplayedHeader))
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Equal(t, 1, instance.calls)
+	})
+
+	t.Run("body too large to hash runs the request", func(t *testing.T) {
+		instance := newIdempotentInstance(NewMemoryIdempotencyStore(0), http.StatusCreated)
+		body := `{"note":"` + strings.Repeat("x", 64) + `"}`
+
+		instance.serveBody(http.MethodPost, "/events", testTenantID, "key-1", body)
+		rec := instance.serveBody(http.MethodPost, "/events", testTenantID, "key-1", body)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Empty(t, rec.Header().Get(IdempotentReplayedHeader))
+		assert.Equal(t, 2, instance.calls)
+	})
+
+	t.Run("server errors are not stored", func(t *testing.T) {
+		instance := newIdempotentInstance(NewMemoryIdempotencyStore(0), http.StatusInternalServerError)
+
+		instance.serve(http.MethodPost, "/events", testTenantID, "key-1")
+		instance.serve(http.MethodPost, "/events", testTenantID, "key-1")
+
+		assert.Equal(t, 2, instance.calls)
+	})
+
+	t.Run("key too long", func(t *testing.T) {
+		instance := newIdempotentInstance(NewMemoryIdempotencyStore(0), http.StatusCreated)
+
+		rec := instance.serve(http.MethodPost, "/events", testTenantID, strings.Repeat("k", MaxIdempotencyKeyLength+1))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.JSONEq(t, fmt.Sprintf(`{"error":%q}`, ErrIdempotencyKeyTooLong.Error()), rec.Body.String())
+		assert.Zero(t, instance.calls)
+	})
+
+	t.Run("unreadable store runs the request", func(t *testing.T) {
+		instance := newIdempotentInstance(failingIdempotencyStore{}, http.StatusCreated)
+
+		rec := instance.serve(http.MethodPost, "/events", testTenantID, "key-1")
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, 1, instance.calls)
+	})
+}
+
+type failingIdempotencyStore struct{}
+
+func (failingIdempotencyStore) GetIdempotencyRecord(context.Context, uuid.UUID, string) (models.IdempotencyRecord, bool, error) {
+	return models.IdempotencyRecord{}, false, errors.New("database down")
+}
+
+func (failingIdempotencyStore) SaveIdempotencyRecord(context.Context, models.IdempotencyRecord) error {
+	return errors.New("database down")
+}
+
+func TestMemoryIdempotencyStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryIdempotencyStore(0)
+	store.now = func() time.Time { return now }
+	tenantID := uuid.New()
+
+	record := func(body string, expiresAt time.Time) models.IdempotencyRecord {
+		return models.IdempotencyRecord{TenantID: tenantID, Key: "key-1", Method: http.MethodPost, Path: "/events", StatusCode: http.StatusCreated, Body: []byte(body), ExpiresAt: expiresAt}
+	}
+
+	require.NoError(t, store.SaveIdempotencyRecord(ctx, record("first", now.Add(time.Hour))))
+	require.NoError(t, store.SaveIdempotencyRecord(ctx, record("second", now.Add(time.Hour))))
+	got, found, err := store.GetIdempotencyRecord(ctx, tenantID, "key-1")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, "first", string(got.Body), "the first response stored for a key is kept")
+
+	now = now.Add(2 * time.Hour)
+	_, found, err = store.GetIdempotencyRecord(ctx, tenantID, "key-1")
+	require.NoError(t, err)
+	assert.False(t, found, "an expired key is not returned")
+
+	require.NoError(t, store.SaveIdempotencyRecord(ctx, record("third", now.Add(time.Hour))))
+	got, found, err = store.GetIdempotencyRecord(ctx, tenantID, "key-1")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, "third", string(got.Body), "an expired key can be used again")
+
+	assert.ErrorIs(t, store.SaveIdempotencyRecord(ctx, models.IdempotencyRecord{Key: "key-1"}), ErrInvalidIdempotencyRecord)
+}
+
+func TestMemoryIdempotencyStore_MaxKeys(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryIdempotencyStore(2)
+	tenantID := uuid.New()
+	expiresAt := time.Now().Add(time.Hour)
+
+	for _, key := range []string{"key-1", "key-2", "key-3"} {
+		require.NoError(t, store.SaveIdempotencyRecord(ctx, models.IdempotencyRecord{TenantID: tenantID, Key: key, ExpiresAt: expiresAt}))
+	}
+
+	assert.Len(t, store.records, 2)
+	_, found, err := store.GetIdempotencyRecord(ctx, tenantID, "key-1")
+	require.NoError(t, err)
+	assert.False(t, found, "the oldest key is dropped to make room")
+	_, found, err = store.GetIdempotencyRecord(ctx, tenantID, "key-3")
+	require.NoError(t, err)
+	assert.True(t, found)
+}
+
+func TestMemoryIdempotencyStore_Sweep(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryIdempotencyStore(0)
+	store.now = func() time.Time { return now }
+	tenantID := uuid.New()
+
+	require.NoError(t, store.SaveIdempotencyRecord(ctx, models.IdempotencyRecord{TenantID: tenantID, Key: "expiring", ExpiresAt: now.Add(time.Minute)}))
+	require.NoError(t, store.SaveIdempotencyRecord(ctx, models.IdempotencyRecord{TenantID: tenantID, Key: "kept", ExpiresAt: now.Add(time.Hour)}))
+
+	now = now.Add(2 * time.Minute)
+	store.sweep()
+
+	assert.Len(t, store.records, 1)
+	assert.Len(t, store.order, 1)
+	_, found, err := store.GetIdempotencyRecord(ctx, tenantID, "kept")
+	require.NoError(t, err)
+	assert.True(t, found)
+}
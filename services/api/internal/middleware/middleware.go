@@ -4,6 +4,8 @@ import (
 	"log/slog"
 	"net/http"
 	"runtime/debug"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -18,8 +20,9 @@ func Chain(h http.Handler, middlewares ...Middleware) http.Handler {
 	return h
 }
 
-// Logger middleware logs HTTP requests
-func Logger(logger *slog.Logger) Middleware {
+// Logger middleware logs HTTP requests. Successful requests to excludedPaths are not logged,
+// so probes and metrics scrapes don't flood the logs; a 4xx or 5xx on those paths still is.
+func Logger(logger *slog.Logger, excludedPaths []string) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
@@ -32,6 +35,10 @@ func Logger(logger *slog.Logger) Middleware {
 
 			next.ServeHTTP(rw, r)
 
+			if rw.statusCode < http.StatusBadRequest && isPathExcluded(r.URL.Path, excludedPaths) {
+				return
+			}
+
 			tenantID, hasTenantID := GetTenantID(r)
 
 			duration := time.Since(start)
@@ -54,19 +61,51 @@ func Logger(logger *slog.Logger) Middleware {
 	}
 }
 
-// Recovery middleware recovers from panics
+// PanicResponder writes the response for a request whose handler panicked with recovered;
+// stack is the goroutine's stack at the point of recovery
+type PanicResponder func(w http.ResponseWriter, r *http.Request, recovered any, stack []byte)
+
+// ErrorResponder writes the response for a request a middleware rejects or fails with status.
+// err is the cause; a responder should only show it to the client for a 4xx status.
+type ErrorResponder func(w http.ResponseWriter, r *http.Request, err error, status int)
+
+// write answers with respond, or with plain text when respond is nil
+func (respond ErrorResponder) write(w http.ResponseWriter, r *http.Request, err error, status int) {
+	if respond != nil {
+		respond(w, r, err, status)
+		return
+	}
+	message := http.StatusText(status)
+	if status < http.StatusInternalServerError {
+		message = err.Error()
+	}
+	http.Error(w, message, status)
+}
+
+// Recovery middleware recovers from panics and answers with a plain-text 500
 func Recovery(logger *slog.Logger) Middleware {
+	return RecoveryWith(logger, nil)
+}
+
+// RecoveryWith is Recovery with the response written by respond; a nil respond writes the
+// plain-text 500 of Recovery
+func RecoveryWith(logger *slog.Logger, respond PanicResponder) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			defer func() {
 				if err := recover(); err != nil {
+					stack := debug.Stack()
 					logger.Error("Panic recovered",
 						slog.Any("error", err),
 						slog.String("path", r.URL.Path),
 						slog.String("method", r.Method),
-						slog.String("stack", string(debug.Stack())),
+						slog.String("stack", string(stack)),
 					)
 
+					if respond != nil {
+						respond(w, r, err, stack)
+						return
+					}
 					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 				}
 			}()
@@ -76,11 +115,21 @@ func Recovery(logger *slog.Logger) Middleware {
 	}
 }
 
-// CORS middleware adds CORS headers
-func CORS() Middleware {
+// CORS middleware adds CORS headers. allowedOrigins of just "*" allows any origin; otherwise
+// a request's Origin is echoed back only when it is in allowedOrigins, which config has
+// already validated and lowercased.
+func CORS(allowedOrigins []string) Middleware {
+	anyOrigin := slices.Contains(allowedOrigins, "*")
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Access-Control-Allow-Origin", "*")
+			if anyOrigin {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Add("Vary", "Origin")
+				if origin := r.Header.Get("Origin"); origin != "" && slices.Contains(allowedOrigins, strings.ToLower(origin)) {
+					w.Header().Set("Access-Control-Allow-Origin", origin)
+				}
+			}
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
@@ -54,7 +54,7 @@ func TestLogger(t *testing.T) {
 		require.NoError(t, err)
 	})
 
-	wrappedHandler := Logger(logger)(handler)
+	wrappedHandler := Logger(logger, nil)(handler)
 	req := httptest.NewRequest("GET", "/test?param=value", nil)
 	req.Header.Set("User-Agent", "test-agent")
 	rr := httptest.NewRecorder()
@@ -72,6 +72,36 @@ func TestLogger(t *testing.T) {
 	assert.Contains(t, logOutput, "200")
 }
 
+func TestLogger_ExcludedPaths(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		status  int
+		wantLog bool
+	}{
+		{name: "excluded path on success", path: "/healthz", status: http.StatusOK, wantLog: false},
+		{name: "excluded path on error", path: "/healthz", status: http.StatusInternalServerError, wantLog: true},
+		{name: "excluded path on client error", path: "/healthz", status: http.StatusNotFound, wantLog: true},
+		{name: "other path on success", path: "/events", status: http.StatusOK, wantLog: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+			handler := Logger(logger, []string{"/healthz", "/ready"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.status, rr.Code, "expected the request to be served either way")
+			assert.Equal(t, tt.wantLog, strings.Contains(buf.String(), "HTTP request"))
+		})
+	}
+}
+
 func TestRecovery(t *testing.T) {
 	var buf bytes.Buffer
 	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
@@ -103,7 +133,7 @@ func TestCORS(t *testing.T) {
 		require.NoError(t, err)
 	})
 
-	wrappedHandler := CORS()(handler)
+	wrappedHandler := CORS([]string{"*"})(handler)
 
 	t.Run("regular request", func(t *testing.T) {
 		req := httptest.NewRequest("GET", "/", nil)
@@ -127,6 +157,30 @@ func TestCORS(t *testing.T) {
 		assert.Equal(t, http.StatusNoContent, rr.Code)
 		assert.Empty(t, rr.Body.String())
 	})
+
+	t.Run("allowed origins", func(t *testing.T) {
+		allowlisted := CORS([]string{"https://app.example.com", "http://localhost:3000"})(handler)
+		request := func(origin string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", "/", nil)
+			if origin != "" {
+				req.Header.Set("Origin", origin)
+			}
+			rr := httptest.NewRecorder()
+			allowlisted.ServeHTTP(rr, req)
+			return rr
+		}
+
+		rr := request("https://App.Example.com")
+		assert.Equal(t, "https://App.Example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "Origin", rr.Header().Get("Vary"))
+
+		rr = request("https://evil.example.com")
+		assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "OK", rr.Body.String())
+
+		rr = request("")
+		assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+	})
 }
 
 func TestResponseWriter(t *testing.T) {
@@ -157,7 +211,7 @@ func TestMultipleMiddleware(t *testing.T) {
 		require.NoError(t, err)
 	})
 
-	chainedHandler := Chain(handler, Logger(logger), Recovery(logger), CORS())
+	chainedHandler := Chain(handler, Logger(logger, nil), Recovery(logger), CORS([]string{"*"}))
 	req := httptest.NewRequest("POST", "/api/test", strings.NewReader(`{"test": "data"}`))
 	req.Header.Set("Content-Type", "application/json")
 	rr := httptest.NewRecorder()
@@ -172,3 +226,22 @@ func TestMultipleMiddleware(t *testing.T) {
 	assert.Contains(t, logOutput, "HTTP request")
 	assert.Contains(t, logOutput, "POST")
 }
+
+func TestHeadWithoutBody(t *testing.T) {
+	handler := HeadWithoutBody()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Last-Modified", "Sat, 01 Mar 2025 12:00:00 GMT")
+		w.WriteHeader(http.StatusOK)
+		_, err := w.Write([]byte(`{"ok":true}`))
+		require.NoError(t, err)
+	}))
+
+	head := httptest.NewRecorder()
+	handler.ServeHTTP(head, httptest.NewRequest(http.MethodHead, "/events", nil))
+	assert.Equal(t, http.StatusOK, head.Code)
+	assert.Equal(t, "Sat, 01 Mar 2025 12:00:00 GMT", head.Header().Get("Last-Modified"))
+	assert.Empty(t, head.Body.String())
+
+	get := httptest.NewRecorder()
+	handler.ServeHTTP(get, httptest.NewRequest(http.MethodGet, "/events", nil))
+	assert.Equal(t, `{"ok":true}`, get.Body.String(), "other methods keep their body")
+}
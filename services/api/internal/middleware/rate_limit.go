@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"rdl-api/internal/metrics"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrRateLimited is the response body for a request over the tenant's rate limit
+const ErrRateLimited = "rate limit exceeded"
+
+// TenantLimiter decides whether a tenant's request may go ahead, and if not, how long the
+// tenant should wait before retrying
+type TenantLimiter interface {
+	Allow(tenantID uuid.UUID) (bool, time.Duration)
+}
+
+// RateLimit rejects requests over their tenant's rate limit with 429 Too Many Requests and a
+// Retry-After header. It must run after TenantContext; requests without a tenant, such as
+// public and admin routes, are not limited.
+func RateLimit(l *slog.Logger, limiter TenantLimiter) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenantID, ok := GetTenantID(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			allowed, retryAfter := limiter.Allow(tenantID)
+			if !allowed {
+				l.DebugContext(r.Context(), "Request rate limited", "tenant_id", tenantID, "retry_after", retryAfter.String())
+				metrics.RecordRejection(metrics.ReasonRateLimited)
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(retryAfter)))
+				http.Error(w, ErrRateLimited, http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// retryAfterSeconds rounds wait up to whole seconds, at least 1, as Retry-After takes seconds
+func retryAfterSeconds(wait time.Duration) int {
+	return max(1, int(math.Ceil(wait.Seconds())))
+}
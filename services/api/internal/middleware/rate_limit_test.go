@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeLimiter allows the first allowed requests and then rejects with retryAfter
+type fakeLimiter struct {
+	allowed    int
+	retryAfter time.Duration
+	calls      int
+}
+
+func (f *fakeLimiter) Allow(uuid.UUID) (bool, time.Duration) {
+	f.calls++
+	if f.calls <= f.allowed {
+		return true, 0
+	}
+	return false, f.retryAfter
+}
+
+func TestRateLimit(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	auth := func(r *http.Request) AuthLevel {
+		if r.URL.Path == "/public" {
+			return AuthPublic
+		}
+		return AuthTenant
+	}
+	limiter := &fakeLimiter{allowed: 1, retryAfter: 1500 * time.Millisecond}
+	handler := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), TenantContext(logger, true, auth), RateLimit(logger, limiter))
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if path != "/public" {
+			req.Header.Set("X-Tenant-ID", "123e4567-e89b-12d3-a456-426614174000")
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, serve("/events").Code)
+
+	rec := serve("/events")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusOK, serve("/public").Code, "requests without a tenant are not limited")
+	assert.Equal(t, 2, limiter.calls)
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	assert.Equal(t, 1, retryAfterSeconds(0))
+	assert.Equal(t, 1, retryAfterSeconds(10*time.Millisecond))
+	assert.Equal(t, 1, retryAfterSeconds(time.Second))
+	assert.Equal(t, 3, retryAfterSeconds(2100*time.Millisecond))
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"rdl-api/internal/metrics"
+)
+
+// AdminKeyHeader carries an admin API key
+const AdminKeyHeader = "X-Admin-Key"
+
+// AuthLevel is the authentication a route requires
+type AuthLevel string
+
+const (
+	// AuthTenant routes need a tenant; it is the level of every route not registered otherwise
+	AuthTenant AuthLevel = "tenant"
+	// AuthPublic routes need no credentials, e.g. health probes and the API description
+	AuthPublic AuthLevel = "public"
+	// AuthAdmin routes need one of the admin API keys in X-Admin-Key instead of a tenant
+	AuthAdmin AuthLevel = "admin"
+)
+
+// RouteAuth reports the authentication the route serving r requires.
+// A nil RouteAuth treats every route as AuthTenant.
+type RouteAuth func(r *http.Request) AuthLevel
+
+// authLevel returns the level auth reports for r, or AuthTenant when auth is nil
+func (auth RouteAuth) authLevel(r *http.Request) AuthLevel {
+	if auth == nil {
+		return AuthTenant
+	}
+	return auth(r)
+}
+
+// AdminAuth rejects requests to AuthAdmin routes that don't carry one of adminKeys in
+// X-Admin-Key. Requests to other routes pass through untouched.
+func AdminAuth(l *slog.Logger, adminKeys []string, auth RouteAuth) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if auth.authLevel(r) == AuthAdmin && !IsAdminKey(r.Header.Get(AdminKeyHeader), adminKeys) {
+				l.WarnContext(r.Context(), "Rejected admin request without a valid admin key", "path", r.URL.Path)
+				metrics.RecordRejection(metrics.ReasonForbidden)
+				http.Error(w, "missing or invalid admin key", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// IsAdminKey reports whether key is one of adminKeys, comparing in constant time
+func IsAdminKey(key string, adminKeys []string) bool {
+	if key == "" {
+		return false
+	}
+	match := 0
+	for _, adminKey := range adminKeys {
+		match |= subtle.ConstantTimeCompare([]byte(key), []byte(adminKey))
+	}
+	return match == 1
+}
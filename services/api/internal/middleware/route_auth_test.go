@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteAuth(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	levels := map[string]AuthLevel{"/public": AuthPublic, "/admin": AuthAdmin}
+	auth := func(r *http.Request) AuthLevel {
+		if level, ok := levels[r.URL.Path]; ok {
+			return level
+		}
+		return AuthTenant
+	}
+
+	handler := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), AdminAuth(logger, []string{"secret"}, auth), TenantContext(logger, true, auth))
+
+	tests := []struct {
+		name     string
+		path     string
+		tenantID string
+		adminKey string
+		want     int
+	}{
+		{"public route skips auth", "/public", "", "", http.StatusOK},
+		{"tenant route without tenant", "/events", "", "", http.StatusUnauthorized},
+		{"tenant route with tenant", "/events", "123e4567-e89b-12d3-a456-426614174000", "", http.StatusOK},
+		{"admin route without key", "/admin", "", "", http.StatusUnauthorized},
+		{"admin route with wrong key", "/admin", "", "wrong", http.StatusUnauthorized},
+		{"admin route with key", "/admin", "", "secret", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.tenantID != "" {
+				req.Header.Set("X-Tenant-ID", tt.tenantID)
+			}
+			if tt.adminKey != "" {
+				req.Header.Set(AdminKeyHeader, tt.adminKey)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+func TestTenantContext_NilRouteAuthRequiresTenant(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := TenantContext(logger, true, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"rdl-api/internal/metrics"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -30,15 +32,13 @@ func GetTenantID(r *http.Request) (uuid.UUID, bool) {
 }
 
 // TenantContext extracts the tenant ID from JWT token (or header in development)
-// and stores it in the request context. It skips tenant validation for excluded paths.
-//
-// excludedPaths: List of paths that should skip tenant validation (e.g., health endpoints)
-// Example: []string{"/healthz", "/health", "/live", "/ready"}
-func TenantContext(l *slog.Logger, isDevelopment bool, excludedPaths []string) Middleware {
+// and stores it in the request context. Only AuthTenant routes are validated; public and
+// admin routes, as reported by auth, pass through without a tenant.
+func TenantContext(l *slog.Logger, isDevelopment bool, auth RouteAuth) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Skip tenant validation for excluded paths
-			if isPathExcluded(r.URL.Path, excludedPaths) {
+			// Only tenant routes need a tenant
+			if auth.authLevel(r) != AuthTenant {
 				next.ServeHTTP(w, r)
 				return
 			}
@@ -48,10 +48,13 @@ func TenantContext(l *slog.Logger, isDevelopment bool, excludedPaths []string) M
 			tenantID := extractTenantID(l, r, isDevelopment)
 
 			if tenantID == uuid.Nil {
+				metrics.RecordRejection(metrics.ReasonInvalidTenant)
 				http.Error(w, ErrMissingOrInvalidTenantContext.Error(), http.StatusUnauthorized)
 				return
 			}
 
+			metrics.RecordTenantRequest(tenantID.String(), time.Now())
+
 			// Add tenant ID to request context
 			ctx := context.WithValue(r.Context(), tenantIDKey, tenantID)
 			r = r.WithContext(ctx)
@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"rdl-api/internal/db/repository"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// TxBeginner opens a transaction scoped to tenantID. release returns the underlying connection
+// and is called once the transaction has been committed or rolled back.
+type TxBeginner func(ctx context.Context, tenantID uuid.UUID) (tx pgx.Tx, release func(), err error)
+
+// Transaction runs the wrapped handler inside a single tenant transaction so handlers that make
+// several writes get all-or-nothing semantics. The transaction is stored in the request context,
+// where repository.WithTenantContext picks it up, and handlers can reach it directly through
+// repository.TxFromContext.
+//
+// The response is buffered: it is committed and then sent if the handler wrote a 2xx status,
+// and rolled back otherwise. A failed commit turns the response into a 500. Work the handler
+// deferred with repository.AfterCommit runs after a commit, once the response has been sent. If the handler
+// panics the transaction is rolled back before the panic continues to Recovery. A transaction
+// that cannot be opened is a 503, with Retry-After when the connection pool is exhausted.
+// Both errors are written by respond; a nil respond writes them as plain text.
+//
+// It must run after TenantContext; requests without a tenant are passed through untouched.
+func Transaction(logger *slog.Logger, begin TxBeginner, respond ErrorResponder) Middleware {
+	return transaction(logger, begin, respond, false)
+}
+
+// TransactionOrDirect is Transaction for handlers that can cope with the database being
+// unreachable themselves, such as ingestion with a degraded-write queue. When the transaction
+// cannot be opened because the database is unreachable, the handler runs without one instead
+// of the request failing with 503.
+func TransactionOrDirect(logger *slog.Logger, begin TxBeginner, respond ErrorResponder) Middleware {
+	return transaction(logger, begin, respond, true)
+}
+
+// transaction implements Transaction; direct runs the handler without a transaction when the
+// database is unreachable
+func transaction(logger *slog.Logger, begin TxBeginner, respond ErrorResponder, direct bool) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenantID, ok := GetTenantID(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+			tx, release, err := begin(ctx, tenantID)
+			if err != nil && direct && repository.IsDatabaseUnavailable(err) {
+				logger.WarnContext(ctx, "Database unreachable, running request without a transaction", "error", err, "tenant_id", tenantID)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if err != nil {
+				logger.ErrorContext(ctx, "Failed to begin request transaction", "error", err, "tenant_id", tenantID)
+				if errors.Is(err, repository.ErrServiceOverloaded) {
+					w.Header().Set("Retry-After", "1")
+				}
+				respond.write(w, r, err, http.StatusServiceUnavailable)
+				return
+			}
+
+			txCtx := repository.ContextWithTx(ctx, tenantID, tx)
+			if serveInTx(logger, respond, w, r.WithContext(txCtx), next, tx, release) {
+				// Work deferred with repository.AfterCommit runs once the client has its response
+				// and the connection is back in the pool, unaffected by the client going away
+				repository.RunAfterCommit(txCtx, context.WithoutCancel(ctx))
+			}
+		})
+	}
+}
+
+// serveInTx runs next inside tx, commits it if next wrote a 2xx status and rolls it back
+// otherwise, sends the response and releases the connection. It reports whether tx committed.
+func serveInTx(logger *slog.Logger, respond ErrorResponder, w http.ResponseWriter, r *http.Request, next http.Handler, tx pgx.Tx, release func()) bool {
+	ctx := r.Context()
+	tenantID, _ := GetTenantID(r)
+	defer release()
+
+	// Rollback is a no-op after a successful commit. It uses a fresh context so that a
+	// cancelled request still releases its locks promptly.
+	defer func() {
+		if rbErr := tx.Rollback(context.WithoutCancel(ctx)); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			logger.ErrorContext(ctx, "Failed to roll back request transaction", "error", rbErr, "tenant_id", tenantID)
+		}
+	}()
+
+	bw := &bufferedResponseWriter{header: make(http.Header), statusCode: http.StatusOK}
+	next.ServeHTTP(bw, r)
+
+	committed := false
+	if bw.statusCode >= 200 && bw.statusCode < 300 {
+		if err := tx.Commit(ctx); err != nil {
+			logger.ErrorContext(ctx, "Failed to commit request transaction", "error", err, "tenant_id", tenantID)
+			respond.write(w, r, err, http.StatusInternalServerError)
+			return false
+		}
+		committed = true
+	}
+
+	bw.flushTo(w)
+	return committed
+}
+
+// bufferedResponseWriter holds a response back until the transaction outcome is known
+type bufferedResponseWriter struct {
+	header      http.Header
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (bw *bufferedResponseWriter) Header() http.Header {
+	return bw.header
+}
+
+func (bw *bufferedResponseWriter) WriteHeader(code int) {
+	if bw.wroteHeader {
+		return
+	}
+	bw.wroteHeader = true
+	bw.statusCode = code
+}
+
+func (bw *bufferedResponseWriter) Write(b []byte) (int, error) {
+	bw.WriteHeader(http.StatusOK)
+	return bw.body.Write(b)
+}
+
+// flushTo copies the buffered response to w
+func (bw *bufferedResponseWriter) flushTo(w http.ResponseWriter) {
+	for key, values := range bw.header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(bw.statusCode)
+	_, _ = w.Write(bw.body.Bytes())
+}
@@ -0,0 +1,219 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"rdl-api/internal/db/repository"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTx records whether it was committed or rolled back; any other use panics
+type fakeTx struct {
+	pgx.Tx
+	committed  bool
+	rolledBack bool
+	commitErr  error
+}
+
+func (tx *fakeTx) Commit(context.Context) error {
+	if tx.commitErr != nil {
+		return tx.commitErr
+	}
+	tx.committed = true
+	return nil
+}
+
+func (tx *fakeTx) Rollback(context.Context) error {
+	if tx.committed {
+		return pgx.ErrTxClosed
+	}
+	tx.rolledBack = true
+	return nil
+}
+
+func newTransactionTestHandler(tx *fakeTx, released *bool, handler http.HandlerFunc) http.Handler {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	begin := func(context.Context, uuid.UUID) (pgx.Tx, func(), error) {
+		return tx, func() { *released = true }, nil
+	}
+	return Chain(handler, Recovery(logger), TenantContext(logger, true, nil), Transaction(logger, begin, nil))
+}
+
+func newTransactionTestRequest() *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/events", nil)
+	req.Header.Set("X-Tenant-ID", uuid.NewString())
+	return req
+}
+
+func TestTransaction(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         int
+		commitErr      error
+		wantStatus     int
+		wantCommitted  bool
+		wantRolledBack bool
+	}{
+		{"2xx commits", http.StatusCreated, nil, http.StatusCreated, true, false},
+		{"4xx rolls back", http.StatusConflict, nil, http.StatusConflict, false, true},
+		{"5xx rolls back", http.StatusInternalServerError, nil, http.StatusInternalServerError, false, true},
+		{"failed commit becomes 500", http.StatusOK, errors.New("serialization failure"), http.StatusInternalServerError, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := &fakeTx{commitErr: tt.commitErr}
+			released := false
+			handler := newTransactionTestHandler(tx, &released, func(w http.ResponseWriter, r *http.Request) {
+				got, ok := repository.TxFromContext(r.Context())
+				require.True(t, ok, "handler should see the request transaction")
+				assert.Same(t, tx, got)
+				w.Header().Set("X-Handled", "yes")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte("body"))
+			})
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, newTransactionTestRequest())
+
+			assert.Equal(t, tt.wantStatus, rr.Code)
+			assert.Equal(t, tt.wantCommitted, tx.committed)
+			assert.Equal(t, tt.wantRolledBack, tx.rolledBack)
+			assert.True(t, released, "connection should be released")
+			if tt.commitErr == nil {
+				assert.Equal(t, "body", rr.Body.String())
+				assert.Equal(t, "yes", rr.Header().Get("X-Handled"))
+			}
+		})
+	}
+}
+
+func TestTransaction_AfterCommit(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantRan bool
+	}{
+		{"runs after a commit", http.StatusCreated, true},
+		{"dropped on rollback", http.StatusBadRequest, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := &fakeTx{}
+			released := false
+			ran := false
+			handler := newTransactionTestHandler(tx, &released, func(w http.ResponseWriter, r *http.Request) {
+				repository.AfterCommit(r.Context(), func(ctx context.Context) {
+					ran = true
+					_, inTx := repository.TxFromContext(ctx)
+					assert.False(t, inTx, "deferred work must run outside the transaction")
+					assert.True(t, tx.committed && released, "deferred work must run after the commit and release")
+				})
+				assert.False(t, ran, "deferred work must not run inside the handler")
+				w.WriteHeader(tt.status)
+			})
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, newTransactionTestRequest())
+
+			assert.Equal(t, tt.status, rr.Code)
+			assert.Equal(t, tt.wantRan, ran)
+		})
+	}
+}
+
+func TestTransaction_PanicRollsBack(t *testing.T) {
+	tx := &fakeTx{}
+	released := false
+	handler := newTransactionTestHandler(tx, &released, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		panic("boom mid-write")
+	})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, newTransactionTestRequest())
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code, "Recovery should still answer the panic")
+	assert.False(t, tx.committed, "nothing may be committed after a panic")
+	assert.True(t, tx.rolledBack)
+	assert.True(t, released)
+}
+
+func TestTransaction_BeginFailure(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	begin := func(context.Context, uuid.UUID) (pgx.Tx, func(), error) {
+		return nil, nil, repository.ErrFailedToAcquireConnection
+	}
+	called := false
+	handler := Chain(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { called = true }),
+		TenantContext(logger, true, nil), Transaction(logger, begin, nil))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, newTransactionTestRequest())
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.False(t, called)
+}
+
+func TestTransaction_PoolExhausted(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	begin := func(context.Context, uuid.UUID) (pgx.Tx, func(), error) {
+		return nil, nil, fmt.Errorf("%w: 10 of 10 in use", repository.ErrServiceOverloaded)
+	}
+	handler := Chain(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}),
+		TenantContext(logger, true, nil), Transaction(logger, begin, nil))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, newTransactionTestRequest())
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.NotEmpty(t, rr.Header().Get("Retry-After"))
+}
+
+func TestTransactionOrDirect(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	t.Run("database unreachable runs the handler without a transaction", func(t *testing.T) {
+		begin := func(context.Context, uuid.UUID) (pgx.Tx, func(), error) {
+			return nil, nil, repository.ErrFailedToAcquireConnection
+		}
+		var inTx bool
+		handler := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, inTx = repository.TxFromContext(r.Context())
+			w.WriteHeader(http.StatusAccepted)
+		}), TenantContext(logger, true, nil), TransactionOrDirect(logger, begin, nil))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, newTransactionTestRequest())
+
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+		assert.False(t, inTx)
+	})
+
+	t.Run("pool exhausted still fails", func(t *testing.T) {
+		begin := func(context.Context, uuid.UUID) (pgx.Tx, func(), error) {
+			return nil, nil, fmt.Errorf("%w: 10 of 10 in use", repository.ErrServiceOverloaded)
+		}
+		called := false
+		handler := Chain(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { called = true }),
+			TenantContext(logger, true, nil), TransactionOrDirect(logger, begin, nil))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, newTransactionTestRequest())
+
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		assert.False(t, called)
+	})
+}
@@ -0,0 +1,105 @@
+package notifier
+
+import (
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of a CircuitBreaker
+type CircuitState int
+
+const (
+	// CircuitClosed lets every call through
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fast-fails every call until the cooldown elapses
+	CircuitOpen
+	// CircuitHalfOpen lets a single probe call through after the cooldown
+	CircuitHalfOpen
+)
+
+// String returns the state name used in logs
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreaker opens after threshold consecutive failures and fast-fails calls for the
+// cooldown. After the cooldown one probe is allowed: success closes the circuit, failure
+// opens it again for another cooldown.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	state    CircuitState
+	failures int
+	openedAt time.Time
+}
+
+// NewCircuitBreaker creates a closed breaker. A threshold below 1 is treated as 1.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// Allow reports whether a call may proceed, returning ErrCircuitOpen if not.
+// Once the cooldown has elapsed the breaker moves to half-open and admits one probe.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.state = CircuitHalfOpen
+		return nil
+	case CircuitHalfOpen:
+		// A probe is already in flight
+		return ErrCircuitOpen
+	default:
+		return nil
+	}
+}
+
+// RecordSuccess closes the circuit and resets the failure count
+func (b *CircuitBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = CircuitClosed
+	b.failures = 0
+}
+
+// RecordFailure counts a failure, opening the circuit when the threshold is reached
+// or when the half-open probe fails
+func (b *CircuitBreaker) RecordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.threshold {
+		b.state = CircuitOpen
+		b.openedAt = b.now()
+	}
+}
+
+// State returns the current state, without advancing an expired open circuit
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
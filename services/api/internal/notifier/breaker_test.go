@@ -0,0 +1,93 @@
+package notifier
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// newTestBreaker returns a breaker whose clock is advanced by the returned function
+func newTestBreaker(threshold int, cooldown time.Duration) (*CircuitBreaker, func(time.Duration)) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := NewCircuitBreaker(threshold, cooldown)
+	b.now = func() time.Time { return now }
+	return b, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestCircuitBreaker_OpensAfterThreshold(t *testing.T) {
+	b, _ := newTestBreaker(3, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		b.RecordFailure()
+		if b.State() != CircuitClosed {
+			t.Fatalf("after %d failures: expected closed, got %s", i+1, b.State())
+		}
+	}
+	b.RecordFailure()
+
+	if b.State() != CircuitOpen {
+		t.Fatalf("expected open after threshold, got %s", b.State())
+	}
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+}
+
+func TestCircuitBreaker_SuccessResetsFailures(t *testing.T) {
+	b, _ := newTestBreaker(2, time.Minute)
+
+	b.RecordFailure()
+	b.RecordSuccess()
+	b.RecordFailure()
+
+	if b.State() != CircuitClosed {
+		t.Errorf("expected failures to be consecutive, got %s", b.State())
+	}
+}
+
+func TestCircuitBreaker_HalfOpenProbe(t *testing.T) {
+	t.Run("probe success closes", func(t *testing.T) {
+		b, advance := newTestBreaker(1, time.Minute)
+		b.RecordFailure()
+
+		advance(time.Minute)
+		if err := b.Allow(); err != nil {
+			t.Fatalf("expected probe to be allowed after cooldown, got %v", err)
+		}
+		if b.State() != CircuitHalfOpen {
+			t.Fatalf("expected half-open, got %s", b.State())
+		}
+		if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("expected only one probe while half-open, got %v", err)
+		}
+
+		b.RecordSuccess()
+		if b.State() != CircuitClosed {
+			t.Errorf("expected closed after successful probe, got %s", b.State())
+		}
+		if err := b.Allow(); err != nil {
+			t.Errorf("expected calls allowed once closed, got %v", err)
+		}
+	})
+
+	t.Run("probe failure reopens", func(t *testing.T) {
+		b, advance := newTestBreaker(3, time.Minute)
+		for i := 0; i < 3; i++ {
+			b.RecordFailure()
+		}
+
+		advance(time.Minute)
+		if err := b.Allow(); err != nil {
+			t.Fatalf("expected probe to be allowed after cooldown, got %v", err)
+		}
+		b.RecordFailure()
+
+		if b.State() != CircuitOpen {
+			t.Fatalf("expected open after failed probe, got %s", b.State())
+		}
+		advance(30 * time.Second)
+		if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("expected a fresh cooldown after failed probe, got %v", err)
+		}
+	})
+}
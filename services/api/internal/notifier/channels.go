@@ -0,0 +1,121 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"rdl-api/internal/domain/models"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrMissingTenant is returned by ChannelNotifier for a notification without a TenantID
+	ErrMissingTenant = errors.New("notification has no tenant")
+	// ErrUnsupportedChannel is returned for a channel type this build cannot deliver to
+	ErrUnsupportedChannel = errors.New("unsupported notification channel type")
+)
+
+// ChannelStore lists a tenant's notification channels
+type ChannelStore interface {
+	GetNotificationChannels(ctx context.Context, tenantID uuid.UUID) ([]models.NotificationChannel, error)
+}
+
+// channelKey identifies a delivery endpoint; channels of any tenant sharing one share its notifier
+type channelKey struct {
+	channelType models.NotificationChannelTypeEnum
+	target      string
+}
+
+// ChannelNotifier delivers each notification to every enabled channel of its tenant. The
+// notifier for each endpoint is created on first use and kept, so its circuit breaker
+// remembers failures across notifications.
+type ChannelNotifier struct {
+	store  ChannelStore
+	opts   Options
+	logger *slog.Logger
+	// email is the SMTP server for email channels; nil leaves them unsupported
+	email *EmailConfig
+
+	mu        sync.Mutex
+	notifiers map[channelKey]Notifier
+}
+
+// NewChannelNotifier creates a ChannelNotifier that reads channels from store and delivers
+// with opts
+func NewChannelNotifier(store ChannelStore, opts Options, logger *slog.Logger) *ChannelNotifier {
+	return &ChannelNotifier{store: store, opts: opts, logger: logger, notifiers: make(map[channelKey]Notifier)}
+}
+
+// WithEmail delivers email channels through the SMTP server in cfg. Without it, email channels
+// fail with ErrUnsupportedChannel.
+func (c *ChannelNotifier) WithEmail(cfg EmailConfig) *ChannelNotifier {
+	c.email = &cfg
+	return c
+}
+
+// Notify delivers n to each enabled channel of n.TenantID, skipping disabled ones. A failing
+// channel does not stop delivery to the others; their errors are joined.
+func (c *ChannelNotifier) Notify(ctx context.Context, n Notification) error {
+	if n.TenantID == uuid.Nil {
+		return ErrMissingTenant
+	}
+	channels, err := c.store.GetNotificationChannels(ctx, n.TenantID)
+	if err != nil {
+		return fmt.Errorf("listing notification channels: %w", err)
+	}
+
+	var errs []error
+	for _, channel := range channels {
+		if !channel.Enabled {
+			continue
+		}
+		if err := c.NotifyChannel(ctx, channel, n); err != nil {
+			errs = append(errs, fmt.Errorf("%s channel %s: %w", channel.ChannelType, channel.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NotifyChannel delivers n to channel alone, whether or not it is enabled
+func (c *ChannelNotifier) NotifyChannel(ctx context.Context, channel models.NotificationChannel, n Notification) error {
+	notify, err := c.notifierFor(channel)
+	if err != nil {
+		return err
+	}
+	return notify.Notify(ctx, n)
+}
+
+// notifierFor returns the notifier delivering to channel's endpoint, creating it on first use
+func (c *ChannelNotifier) notifierFor(channel models.NotificationChannel) (Notifier, error) {
+	key := channelKey{channelType: channel.ChannelType, target: channel.Target}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if notify, ok := c.notifiers[key]; ok {
+		return notify, nil
+	}
+
+	var notify Notifier
+	switch channel.ChannelType {
+	case models.NotificationChannelTypeEnumSlack:
+		notify = NewSlackNotifier(channel.Target, c.opts, c.logger)
+	case models.NotificationChannelTypeEnumWebhook:
+		notify = NewHTTPNotifier(channel.Target, c.opts, c.logger)
+	case models.NotificationChannelTypeEnumEmail:
+		if c.email == nil {
+			return nil, fmt.Errorf("%w: email is not configured", ErrUnsupportedChannel)
+		}
+		emailNotifier, err := NewEmailNotifier(channel.Target, *c.email, c.opts, c.logger)
+		if err != nil {
+			return nil, err
+		}
+		notify = emailNotifier
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedChannel, channel.ChannelType)
+	}
+	c.notifiers[key] = notify
+	return notify, nil
+}
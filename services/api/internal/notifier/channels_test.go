@@ -0,0 +1,106 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"rdl-api/internal/domain/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// staticChannelStore returns fixed channels per tenant
+type staticChannelStore map[uuid.UUID][]models.NotificationChannel
+
+func (s staticChannelStore) GetNotificationChannels(_ context.Context, tenantID uuid.UUID) ([]models.NotificationChannel, error) {
+	return s[tenantID], nil
+}
+
+func TestChannelNotifier_SkipsDisabledChannels(t *testing.T) {
+	enabled, enabledCalls := newStatusServer(t, http.StatusOK)
+	disabled, disabledCalls := newStatusServer(t, http.StatusOK)
+	slack, slackCalls := newStatusServer(t, http.StatusOK)
+	tenantID := uuid.New()
+	store := staticChannelStore{tenantID: {
+		{ID: uuid.New(), ChannelType: models.NotificationChannelTypeEnumWebhook, Target: enabled.URL, Enabled: true},
+		{ID: uuid.New(), ChannelType: models.NotificationChannelTypeEnumWebhook, Target: disabled.URL, Enabled: false},
+		{ID: uuid.New(), ChannelType: models.NotificationChannelTypeEnumSlack, Target: slack.URL, Enabled: true},
+	}}
+	n := NewChannelNotifier(store, Options{CircuitThreshold: 1}, newTestLogger())
+
+	if err := n.Notify(context.Background(), Notification{TenantID: tenantID, Title: "Leak"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := enabledCalls.Load(); got != 1 {
+		t.Errorf("expected the enabled webhook notified once, got %d", got)
+	}
+	if got := slackCalls.Load(); got != 1 {
+		t.Errorf("expected the Slack channel notified once, got %d", got)
+	}
+	if got := disabledCalls.Load(); got != 0 {
+		t.Errorf("expected the disabled channel skipped, got %d requests", got)
+	}
+}
+
+func TestChannelNotifier_FailingChannelDoesNotStopOthers(t *testing.T) {
+	failing, _ := newStatusServer(t, http.StatusBadRequest)
+	healthy, healthyCalls := newStatusServer(t, http.StatusOK)
+	tenantID := uuid.New()
+	store := staticChannelStore{tenantID: {
+		{ID: uuid.New(), ChannelType: models.NotificationChannelTypeEnumWebhook, Target: failing.URL, Enabled: true},
+		{ID: uuid.New(), ChannelType: models.NotificationChannelTypeEnumWebhook, Target: healthy.URL, Enabled: true},
+	}}
+	n := NewChannelNotifier(store, Options{BaseBackoff: time.Millisecond, CircuitThreshold: 10}, newTestLogger())
+
+	err := n.Notify(context.Background(), Notification{TenantID: tenantID, Title: "Leak"})
+	if !errors.Is(err, ErrDeliveryFailed) {
+		t.Fatalf("expected the failing channel's ErrDeliveryFailed, got %v", err)
+	}
+	if got := healthyCalls.Load(); got != 1 {
+		t.Errorf("expected the healthy channel notified once, got %d", got)
+	}
+}
+
+func TestChannelNotifier_OnlyTheTenantsChannels(t *testing.T) {
+	srv, calls := newStatusServer(t, http.StatusOK)
+	store := staticChannelStore{uuid.New(): {
+		{ID: uuid.New(), ChannelType: models.NotificationChannelTypeEnumWebhook, Target: srv.URL, Enabled: true},
+	}}
+	n := NewChannelNotifier(store, Options{CircuitThreshold: 1}, newTestLogger())
+
+	if err := n.Notify(context.Background(), Notification{TenantID: uuid.New(), Title: "Leak"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := calls.Load(); got != 0 {
+		t.Errorf("expected another tenant's channel left alone, got %d requests", got)
+	}
+	if err := n.Notify(context.Background(), Notification{Title: "Leak"}); !errors.Is(err, ErrMissingTenant) {
+		t.Errorf("expected ErrMissingTenant without a tenant, got %v", err)
+	}
+}
+
+func TestChannelNotifier_EmailChannels(t *testing.T) {
+	tenantID := uuid.New()
+	store := staticChannelStore{tenantID: {
+		{ID: uuid.New(), ChannelType: models.NotificationChannelTypeEnumEmail, Target: "billing@example.com", Enabled: true},
+	}}
+
+	n := NewChannelNotifier(store, Options{CircuitThreshold: 1}, newTestLogger())
+	if err := n.Notify(context.Background(), Notification{TenantID: tenantID, Title: "Leak"}); !errors.Is(err, ErrUnsupportedChannel) {
+		t.Fatalf("expected ErrUnsupportedChannel without SMTP configured, got %v", err)
+	}
+
+	srv := newFakeSMTPServer(t, "250 OK")
+	host, port, _ := net.SplitHostPort(srv.addr)
+	n = NewChannelNotifier(store, Options{CircuitThreshold: 1}, newTestLogger()).
+		WithEmail(EmailConfig{Host: host, Port: port, From: "alerts@example.com"})
+	if err := n.Notify(context.Background(), Notification{TenantID: tenantID, Title: "Leak"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, messages := srv.received(); len(messages) != 1 {
+		t.Errorf("expected the email channel sent 1 message, got %d", len(messages))
+	}
+}
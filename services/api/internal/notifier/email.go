@@ -0,0 +1,251 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// ErrInvalidEmailAddress is returned by NewEmailNotifier for a sender or recipient that is not an email address
+var ErrInvalidEmailAddress = errors.New("invalid email address")
+
+// EmailConfig is the SMTP server email notifications are sent through
+type EmailConfig struct {
+	Host string
+	Port string
+	// Username and Password authenticate with PLAIN auth when Username is set. net/smtp only
+	// sends them over TLS or to localhost.
+	Username string
+	Password string
+	// From is the sender, a bare address or one with a display name
+	From string
+}
+
+// EmailNotifier sends notifications as email through an SMTP server, upgrading the
+// connection with STARTTLS when the server offers it
+type EmailNotifier struct {
+	retryPolicy
+	host string
+	addr string
+	auth smtp.Auth
+	from *mail.Address
+	to   *mail.Address
+	now  func() time.Time
+}
+
+// NewEmailNotifier creates a notifier that emails each Notification to the address to
+func NewEmailNotifier(to string, cfg EmailConfig, opts Options, logger *slog.Logger) (*EmailNotifier, error) {
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("%w: sender %q", ErrInvalidEmailAddress, cfg.From)
+	}
+	recipient, err := mail.ParseAddress(to)
+	if err != nil {
+		return nil, fmt.Errorf("%w: recipient %q", ErrInvalidEmailAddress, to)
+	}
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	return &EmailNotifier{
+		retryPolicy: newRetryPolicy(opts, logger),
+		host:        cfg.Host,
+		addr:        net.JoinHostPort(cfg.Host, cfg.Port),
+		auth:        auth,
+		from:        from,
+		to:          recipient,
+		now:         time.Now,
+	}, nil
+}
+
+// Notify emails n, retrying transient failures with exponential backoff.
+// It returns ErrCircuitOpen as soon as the circuit is open, including between retries.
+func (e *EmailNotifier) Notify(ctx context.Context, n Notification) error {
+	message, err := renderEmail(e.from, e.to, n, e.now())
+	if err != nil {
+		return fmt.Errorf("rendering notification email: %w", err)
+	}
+	return e.deliver(ctx, slog.String("smtp_server", e.addr), func(ctx context.Context) (bool, error) {
+		return e.send(ctx, message)
+	})
+}
+
+// State returns the notifier's circuit state
+func (e *EmailNotifier) State() CircuitState {
+	return e.breaker.State()
+}
+
+// send makes one delivery attempt and reports whether a failure is worth retrying. A 4xx SMTP
+// reply is transient; a 5xx reply is permanent and wraps ErrDeliveryFailed.
+func (e *EmailNotifier) send(ctx context.Context, message []byte) (bool, error) {
+	dialer := net.Dialer{Timeout: DefaultTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", e.addr)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	deadline := time.Now().Add(DefaultTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return true, err
+	}
+
+	client, err := smtp.NewClient(conn, e.host)
+	if err != nil {
+		conn.Close()
+		return smtpFailure(err)
+	}
+	defer client.Close()
+
+	if err := e.transfer(client, message); err != nil {
+		return smtpFailure(err)
+	}
+	return true, nil
+}
+
+// transfer runs one SMTP transaction on client, as smtp.SendMail does
+func (e *EmailNotifier) transfer(client *smtp.Client, message []byte) error {
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: e.host, MinVersion: tls.VersionTLS12}); err != nil {
+			return err
+		}
+	}
+	if e.auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("smtp server does not support AUTH")
+		}
+		if err := client.Auth(e.auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(e.from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(e.to.Address); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// smtpFailure classifies a failed SMTP exchange: replies in the 4xx range and connection
+// errors are worth retrying, other replies are not
+func smtpFailure(err error) (bool, error) {
+	var reply *textproto.Error
+	if errors.As(err, &reply) {
+		if reply.Code >= 400 && reply.Code < 500 {
+			return true, err
+		}
+		return false, fmt.Errorf("%w: %w", ErrDeliveryFailed, err)
+	}
+	return true, err
+}
+
+// emailContent is what the email templates render
+type emailContent struct {
+	Title string
+	// Items are the body lines starting with "- ", one per leak
+	Items []string
+	// Notes are the other body lines
+	Notes []string
+}
+
+var (
+	emailTextTemplate = texttemplate.Must(texttemplate.New("text").Parse(`{{.Title}}
+
+{{range .Items}}- {{.}}
+{{end}}{{range .Notes}}
+{{.}}
+{{end}}`))
+
+	emailHTMLTemplate = htmltemplate.Must(htmltemplate.New("html").Parse(`<!DOCTYPE html>
+<html>
+<body>
+<h2>{{.Title}}</h2>
+{{if .Items}}<ul>
+{{range .Items}}<li>{{.}}</li>
+{{end}}</ul>
+{{end}}{{range .Notes}}<p>{{.}}</p>
+{{end}}</body>
+</html>
+`))
+)
+
+// renderEmail renders n as a multipart/alternative message with a plain text and an HTML part
+func renderEmail(from, to *mail.Address, n Notification, now time.Time) ([]byte, error) {
+	content := emailContent{Title: n.Title}
+	for _, line := range strings.Split(n.Body, "\n") {
+		if item, ok := strings.CutPrefix(line, "- "); ok {
+			content.Items = append(content.Items, item)
+		} else if line != "" {
+			content.Notes = append(content.Notes, line)
+		}
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	if err := writeEmailPart(parts, "text/plain; charset=utf-8", func(w *quotedprintable.Writer) error {
+		return emailTextTemplate.Execute(w, content)
+	}); err != nil {
+		return nil, err
+	}
+	if err := writeEmailPart(parts, "text/html; charset=utf-8", func(w *quotedprintable.Writer) error {
+		return emailHTMLTemplate.Execute(w, content)
+	}); err != nil {
+		return nil, err
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", from)
+	fmt.Fprintf(&message, "To: %s\r\n", to)
+	fmt.Fprintf(&message, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", n.Title))
+	fmt.Fprintf(&message, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	message.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&message, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", parts.Boundary())
+	message.Write(body.Bytes())
+	return message.Bytes(), nil
+}
+
+// writeEmailPart adds a quoted-printable part of contentType to parts, written by render
+func writeEmailPart(parts *multipart.Writer, contentType string, render func(w *quotedprintable.Writer) error) error {
+	part, err := parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	w := quotedprintable.NewWriter(part)
+	if err := render(w); err != nil {
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,253 @@
+package notifier
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSMTPServer accepts SMTP sessions, answering MAIL and RCPT with the configured replies
+// and keeping the messages it receives
+type fakeSMTPServer struct {
+	addr string
+	// mailReplies answer each MAIL command in turn, repeating the last; "250 OK" when empty
+	mailReplies []string
+	rcptReply   string
+
+	mu       sync.Mutex
+	sessions int
+	messages []string
+}
+
+func newFakeSMTPServer(t *testing.T, rcptReply string, mailReplies ...string) *fakeSMTPServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	s := &fakeSMTPServer{addr: listener.Addr().String(), mailReplies: mailReplies, rcptReply: rcptReply}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	s.mu.Lock()
+	session := s.sessions
+	s.sessions++
+	s.mu.Unlock()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+	reply("220 fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		switch command := strings.ToUpper(strings.TrimSpace(line)); {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 fake")
+		case strings.HasPrefix(command, "MAIL"):
+			if len(s.mailReplies) == 0 {
+				reply("250 OK")
+			} else {
+				reply(s.mailReplies[min(session, len(s.mailReplies)-1)])
+			}
+		case strings.HasPrefix(command, "RCPT"):
+			reply(s.rcptReply)
+		case command == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, data.String())
+			s.mu.Unlock()
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func (s *fakeSMTPServer) received() (int, []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sessions, append([]string(nil), s.messages...)
+}
+
+func newTestEmailNotifier(t *testing.T, srv *fakeSMTPServer, opts Options) *EmailNotifier {
+	t.Helper()
+	host, port, err := net.SplitHostPort(srv.addr)
+	if err != nil {
+		t.Fatalf("split address: %v", err)
+	}
+	n, err := NewEmailNotifier("billing@example.com", EmailConfig{Host: host, Port: port, From: "Alerts <alerts@example.com>"}, opts, newTestLogger())
+	if err != nil {
+		t.Fatalf("NewEmailNotifier: %v", err)
+	}
+	return n
+}
+
+func TestRenderEmail(t *testing.T) {
+	from := &mail.Address{Name: "Alerts", Address: "alerts@example.com"}
+	to := &mail.Address{Address: "billing@example.com"}
+	n := Notification{
+		Title: "2 new revenue leak(s) detected",
+		Body:  "- duplicate_charge: customer cus_1 charged <USD> twice\n- volume_anomaly: 3x the usual events\nDetection stopped after 2 leaks; the remaining candidates were not stored.",
+	}
+	raw, err := renderEmail(from, to, n, time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("renderEmail: %v", err)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	if err != nil {
+		t.Fatalf("failed to parse message: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != n.Title {
+		t.Errorf("expected subject %q, got %q (%v)", n.Title, subject, err)
+	}
+	if got := msg.Header.Get("To"); got != "<billing@example.com>" {
+		t.Errorf("expected the recipient in To, got %q", got)
+	}
+	if got := msg.Header.Get("Date"); got != "Sat, 01 Mar 2025 12:00:00 +0000" {
+		t.Errorf("unexpected Date %q", got)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("expected multipart/alternative, got %q (%v)", mediaType, err)
+	}
+
+	bodies := map[string]string{}
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := parts.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read part: %v", err)
+		}
+		body, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatalf("failed to read part body: %v", err)
+		}
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		bodies[contentType] = string(body)
+	}
+
+	text := bodies["text/plain"]
+	for _, want := range []string{n.Title, "- duplicate_charge: customer cus_1 charged <USD> twice", "- volume_anomaly: 3x the usual events", "Detection stopped after 2 leaks"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected the text part to contain %q, got:\n%s", want, text)
+		}
+	}
+	html := bodies["text/html"]
+	for _, want := range []string{"<h2>2 new revenue leak(s) detected</h2>", "<li>duplicate_charge: customer cus_1 charged &lt;USD&gt; twice</li>", "<p>Detection stopped after 2 leaks; the remaining candidates were not stored.</p>"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected the HTML part to contain %q, got:\n%s", want, html)
+		}
+	}
+}
+
+func TestEmailNotifier_Delivers(t *testing.T) {
+	srv := newFakeSMTPServer(t, "250 OK")
+	n := newTestEmailNotifier(t, srv, Options{CircuitThreshold: 1})
+
+	if err := n.Notify(context.Background(), Notification{Title: "Leak", Body: "- failed_payments: $49.50"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, messages := srv.received()
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+	if !strings.Contains(messages[0], "Subject: Leak") {
+		t.Errorf("expected the notification title as subject, got:\n%s", messages[0])
+	}
+}
+
+func TestEmailNotifier_SMTPFailure(t *testing.T) {
+	t.Run("rejected recipient is not retried", func(t *testing.T) {
+		srv := newFakeSMTPServer(t, "550 No such user")
+		n := newTestEmailNotifier(t, srv, Options{MaxRetries: 3, BaseBackoff: time.Millisecond, CircuitThreshold: 10})
+
+		err := n.Notify(context.Background(), Notification{Title: "Leak"})
+		if !errors.Is(err, ErrDeliveryFailed) {
+			t.Fatalf("expected ErrDeliveryFailed, got %v", err)
+		}
+		if sessions, messages := srv.received(); sessions != 1 || len(messages) != 0 {
+			t.Errorf("expected a single attempt and no message, got %d attempts and %d messages", sessions, len(messages))
+		}
+	})
+
+	t.Run("transient failure is retried", func(t *testing.T) {
+		srv := newFakeSMTPServer(t, "250 OK", "451 Try again later", "250 OK")
+		n := newTestEmailNotifier(t, srv, Options{MaxRetries: 3, BaseBackoff: time.Millisecond, CircuitThreshold: 10})
+
+		if err := n.Notify(context.Background(), Notification{Title: "Leak"}); err != nil {
+			t.Fatalf("expected delivery to succeed after a retry, got %v", err)
+		}
+		if sessions, messages := srv.received(); sessions != 2 || len(messages) != 1 {
+			t.Errorf("expected 2 attempts and 1 message, got %d attempts and %d messages", sessions, len(messages))
+		}
+	})
+
+	t.Run("unreachable server returns the error to the caller", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("listen: %v", err)
+		}
+		host, port, _ := net.SplitHostPort(listener.Addr().String())
+		listener.Close()
+		n, err := NewEmailNotifier("billing@example.com", EmailConfig{Host: host, Port: port, From: "alerts@example.com"}, Options{MaxRetries: 1, BaseBackoff: time.Millisecond, CircuitThreshold: 2, CircuitCooldown: time.Hour}, newTestLogger())
+		if err != nil {
+			t.Fatalf("NewEmailNotifier: %v", err)
+		}
+
+		if err := n.Notify(context.Background(), Notification{Title: "Leak"}); err == nil {
+			t.Fatal("expected an error while the SMTP server is down")
+		}
+		if n.State() != CircuitOpen {
+			t.Errorf("expected the circuit open after repeated failures, got %s", n.State())
+		}
+	})
+}
+
+func TestNewEmailNotifier_InvalidAddress(t *testing.T) {
+	if _, err := NewEmailNotifier("not an address", EmailConfig{Host: "localhost", Port: "25", From: "alerts@example.com"}, Options{}, newTestLogger()); !errors.Is(err, ErrInvalidEmailAddress) {
+		t.Errorf("expected ErrInvalidEmailAddress for a bad recipient, got %v", err)
+	}
+}
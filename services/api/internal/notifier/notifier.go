@@ -0,0 +1,200 @@
+// Package notifier delivers leak notifications to external channels such as Slack
+// incoming webhooks, generic HTTP endpoints or email through SMTP. ChannelNotifier fans a
+// notification out to the channels its tenant has configured.
+//
+// Deliveries are retried with exponential backoff, and each endpoint sits behind a
+// circuit breaker so a flaky endpoint fails fast instead of backing up the caller.
+// A fast failure returns ErrCircuitOpen; callers should keep the notification and
+// retry it later. Outbox does so: it stores every notification until its channel has
+// received it, retrying failed deliveries with a longer backoff.
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrCircuitOpen is returned without contacting the endpoint while its circuit is open
+	ErrCircuitOpen = errors.New("notifier circuit open")
+	// ErrDeliveryFailed is returned when the endpoint responded with a non-2xx status
+	ErrDeliveryFailed = errors.New("notification delivery failed")
+)
+
+// Default delivery settings used when Options leaves them zero
+const (
+	DefaultBaseBackoff = 500 * time.Millisecond
+	DefaultTimeout     = 10 * time.Second
+)
+
+// Notification is a message about a detected leak
+type Notification struct {
+	// TenantID is the tenant the leak belongs to; ChannelNotifier delivers to its channels
+	TenantID uuid.UUID `json:"-"`
+	Title    string    `json:"title"`
+	Body     string    `json:"body"`
+}
+
+// Notifier delivers notifications to a single channel
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// Options configures retries and circuit breaking for an HTTPNotifier or EmailNotifier
+type Options struct {
+	// MaxRetries is how many times a failed delivery is retried; 0 sends once
+	MaxRetries int
+	// BaseBackoff is the wait before the first retry, doubling on each further retry
+	BaseBackoff time.Duration
+	// CircuitThreshold is the number of consecutive failed attempts that opens the circuit
+	CircuitThreshold int
+	// CircuitCooldown is how long the circuit stays open before a probe is allowed
+	CircuitCooldown time.Duration
+	// Client sends the requests; defaults to a client with DefaultTimeout
+	Client *http.Client
+}
+
+// retryPolicy retries failed deliveries with exponential backoff behind a circuit breaker
+type retryPolicy struct {
+	maxRetries  int
+	baseBackoff time.Duration
+	breaker     *CircuitBreaker
+	logger      *slog.Logger
+}
+
+func newRetryPolicy(opts Options, logger *slog.Logger) retryPolicy {
+	backoff := opts.BaseBackoff
+	if backoff <= 0 {
+		backoff = DefaultBaseBackoff
+	}
+	return retryPolicy{
+		maxRetries:  max(opts.MaxRetries, 0),
+		baseBackoff: backoff,
+		breaker:     NewCircuitBreaker(opts.CircuitThreshold, opts.CircuitCooldown),
+		logger:      logger,
+	}
+}
+
+// deliver calls attempt until it succeeds, reports a failure not worth retrying, or the
+// retries run out. It returns ErrCircuitOpen as soon as the circuit is open, including
+// between retries. endpoint identifies the destination in logs.
+func (p retryPolicy) deliver(ctx context.Context, endpoint slog.Attr, attempt func(ctx context.Context) (bool, error)) error {
+	var lastErr error
+	for n := 0; n <= p.maxRetries; n++ {
+		if n > 0 {
+			if err := sleep(ctx, p.baseBackoff<<(n-1)); err != nil {
+				return errors.Join(lastErr, err)
+			}
+		}
+		if err := p.breaker.Allow(); err != nil {
+			p.logger.WarnContext(ctx, "Notifier circuit open, skipping delivery", endpoint)
+			return errors.Join(err, lastErr)
+		}
+
+		retryable, err := attempt(ctx)
+		if err == nil {
+			p.breaker.RecordSuccess()
+			return nil
+		}
+		p.breaker.RecordFailure()
+		lastErr = err
+		p.logger.WarnContext(ctx, "Notification delivery attempt failed", "error", err, "attempt", n+1, endpoint, "circuit", p.breaker.State())
+		if !retryable {
+			break
+		}
+	}
+	return lastErr
+}
+
+// HTTPNotifier posts notifications as JSON to a URL
+type HTTPNotifier struct {
+	retryPolicy
+	url    string
+	client *http.Client
+	encode func(Notification) ([]byte, error)
+}
+
+// NewHTTPNotifier creates a notifier that posts each Notification as JSON to url
+func NewHTTPNotifier(url string, opts Options, logger *slog.Logger) *HTTPNotifier {
+	return newHTTPNotifier(url, opts, logger, func(n Notification) ([]byte, error) {
+		return json.Marshal(n)
+	})
+}
+
+// NewSlackNotifier creates a notifier that posts to a Slack incoming webhook
+func NewSlackNotifier(webhookURL string, opts Options, logger *slog.Logger) *HTTPNotifier {
+	return newHTTPNotifier(webhookURL, opts, logger, func(n Notification) ([]byte, error) {
+		return json.Marshal(map[string]string{"text": fmt.Sprintf("*%s*\n%s", n.Title, n.Body)})
+	})
+}
+
+func newHTTPNotifier(url string, opts Options, logger *slog.Logger, encode func(Notification) ([]byte, error)) *HTTPNotifier {
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{Timeout: DefaultTimeout}
+	}
+	return &HTTPNotifier{
+		retryPolicy: newRetryPolicy(opts, logger),
+		url:         url,
+		client:      client,
+		encode:      encode,
+	}
+}
+
+// Notify delivers n, retrying transient failures with exponential backoff.
+// It returns ErrCircuitOpen as soon as the circuit is open, including between retries.
+func (h *HTTPNotifier) Notify(ctx context.Context, n Notification) error {
+	payload, err := h.encode(n)
+	if err != nil {
+		return fmt.Errorf("encoding notification: %w", err)
+	}
+	return h.deliver(ctx, slog.String("url", h.url), func(ctx context.Context) (bool, error) {
+		return h.send(ctx, payload)
+	})
+}
+
+// State returns the notifier's circuit state
+func (h *HTTPNotifier) State() CircuitState {
+	return h.breaker.State()
+}
+
+// send makes one delivery attempt and reports whether a failure is worth retrying
+func (h *HTTPNotifier) send(ctx context.Context, payload []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(payload))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return true, nil
+	}
+	retryable := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retryable, fmt.Errorf("%w: status %d", ErrDeliveryFailed, resp.StatusCode)
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,98 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// newStatusServer responds with statuses in order, repeating the last one, and counts requests
+func newStatusServer(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := int(calls.Add(1))
+		w.WriteHeader(statuses[min(n, len(statuses))-1])
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestHTTPNotifier_RetriesTransientFailures(t *testing.T) {
+	srv, calls := newStatusServer(t, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK)
+	n := NewHTTPNotifier(srv.URL, Options{MaxRetries: 3, BaseBackoff: time.Millisecond, CircuitThreshold: 10, CircuitCooldown: time.Minute}, newTestLogger())
+
+	if err := n.Notify(context.Background(), Notification{Title: "Leak", Body: "details"}); err != nil {
+		t.Fatalf("expected delivery to succeed after retries, got %v", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("expected 3 attempts, got %d", got)
+	}
+	if n.State() != CircuitClosed {
+		t.Errorf("expected circuit closed after success, got %s", n.State())
+	}
+}
+
+func TestHTTPNotifier_DoesNotRetryClientErrors(t *testing.T) {
+	srv, calls := newStatusServer(t, http.StatusBadRequest)
+	n := NewHTTPNotifier(srv.URL, Options{MaxRetries: 3, BaseBackoff: time.Millisecond, CircuitThreshold: 10}, newTestLogger())
+
+	err := n.Notify(context.Background(), Notification{Title: "Leak"})
+	if !errors.Is(err, ErrDeliveryFailed) {
+		t.Fatalf("expected ErrDeliveryFailed, got %v", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("expected a single attempt, got %d", got)
+	}
+}
+
+func TestHTTPNotifier_CircuitFastFails(t *testing.T) {
+	srv, calls := newStatusServer(t, http.StatusInternalServerError)
+	n := NewHTTPNotifier(srv.URL, Options{MaxRetries: 5, BaseBackoff: time.Millisecond, CircuitThreshold: 2, CircuitCooldown: time.Hour}, newTestLogger())
+
+	err := n.Notify(context.Background(), Notification{Title: "Leak"})
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected retries to stop once the circuit opens, got %v", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("expected 2 attempts before the circuit opened, got %d", got)
+	}
+
+	err = n.Notify(context.Background(), Notification{Title: "Leak"})
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected fast failure while open, got %v", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("expected no request while open, got %d total", got)
+	}
+}
+
+func TestSlackNotifier_Payload(t *testing.T) {
+	var body map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode payload: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	n := NewSlackNotifier(srv.URL, Options{CircuitThreshold: 1}, newTestLogger())
+	if err := n.Notify(context.Background(), Notification{Title: "Failed payment", Body: "$49.50"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := "*Failed payment*\n$49.50"; body["text"] != want {
+		t.Errorf("expected text %q, got %q", want, body["text"])
+	}
+}
@@ -0,0 +1,215 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"rdl-api/internal/domain/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Default outbox settings used when OutboxOptions leaves them zero
+const (
+	DefaultOutboxMaxAttempts = 8
+	DefaultOutboxBaseBackoff = time.Minute
+	DefaultOutboxMaxBackoff  = time.Hour
+)
+
+const (
+	// outboxLease is how long an attempt may take before its delivery is due again. It covers
+	// the notifier's own retries, so a delivery whose worker died is picked up again, but one
+	// still in flight is not sent twice.
+	outboxLease = 5 * time.Minute
+	// outboxBatchSize is the most deliveries of one tenant retried per run
+	outboxBatchSize = 100
+)
+
+// OutboxStore keeps the notifications an Outbox has still to deliver, one per channel
+type OutboxStore interface {
+	EnqueueNotification(ctx context.Context, args models.CreateOutboxNotificationParams, tenantID uuid.UUID, lease time.Duration) (models.OutboxNotification, error)
+	ClaimDueNotifications(ctx context.Context, tenantID uuid.UUID, limit int, lease time.Duration) ([]models.OutboxNotification, error)
+	FailOutboxAttempt(ctx context.Context, id uuid.UUID, tenantID uuid.UUID, lastError string, backoff time.Duration, maxAttempts int) (models.OutboxNotification, error)
+	DeleteOutboxNotification(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (int64, error)
+}
+
+// TenantLister lists the tenants whose outbox deliveries an Outbox retries
+type TenantLister interface {
+	ListTenantIDs(ctx context.Context) ([]uuid.UUID, error)
+}
+
+// OutboxOptions configures how an Outbox retries failed deliveries
+type OutboxOptions struct {
+	// MaxAttempts is how many times a notification is delivered to a channel before the outbox
+	// gives up on it
+	MaxAttempts int
+	// BaseBackoff is the wait before the first retry, doubling on each further retry
+	BaseBackoff time.Duration
+	// MaxBackoff caps the wait between retries
+	MaxBackoff time.Duration
+}
+
+// Outbox delivers notifications through a ChannelNotifier, keeping each one in a store until
+// its channel has received it. Notify writes a row per enabled channel and delivers it straight
+// away; a delivery that fails stays in the store and Run retries it with exponential backoff,
+// until MaxAttempts deliveries have failed and the outbox gives up on it.
+type Outbox struct {
+	store    OutboxStore
+	channels *ChannelNotifier
+	tenants  TenantLister
+	opts     OutboxOptions
+	logger   *slog.Logger
+}
+
+// NewOutbox creates an Outbox that keeps notifications in store, delivers them through channels
+// and retries the failed deliveries of the tenants listed by tenants
+func NewOutbox(store OutboxStore, channels *ChannelNotifier, tenants TenantLister, opts OutboxOptions, logger *slog.Logger) *Outbox {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultOutboxMaxAttempts
+	}
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = DefaultOutboxBaseBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = DefaultOutboxMaxBackoff
+	}
+	return &Outbox{store: store, channels: channels, tenants: tenants, opts: opts, logger: logger}
+}
+
+// Notify stores n for each enabled channel of n.TenantID and delivers it. A delivery that fails
+// is left in the outbox for Run to retry, so only a notification that could not be stored for
+// a channel is reported; those errors are joined.
+func (o *Outbox) Notify(ctx context.Context, n Notification) error {
+	if n.TenantID == uuid.Nil {
+		return ErrMissingTenant
+	}
+	channels, err := o.channels.store.GetNotificationChannels(ctx, n.TenantID)
+	if err != nil {
+		return fmt.Errorf("listing notification channels: %w", err)
+	}
+
+	var errs []error
+	for _, channel := range channels {
+		if !channel.Enabled {
+			continue
+		}
+		stored, err := o.store.EnqueueNotification(ctx, models.CreateOutboxNotificationParams{ChannelID: channel.ID, Title: n.Title, Body: n.Body}, n.TenantID, outboxLease)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s channel %s: %w", channel.ChannelType, channel.ID, err))
+			continue
+		}
+		o.deliver(ctx, channel, stored)
+	}
+	return errors.Join(errs...)
+}
+
+// RunOnce retries the due deliveries of every tenant. A tenant whose deliveries cannot be read
+// does not stop the others; the errors are joined.
+func (o *Outbox) RunOnce(ctx context.Context) error {
+	tenantIDs, err := o.tenants.ListTenantIDs(ctx)
+	if err != nil {
+		return fmt.Errorf("list tenants: %w", err)
+	}
+
+	var errs []error
+	for _, tenantID := range tenantIDs {
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
+		if err := o.retryTenant(ctx, tenantID); err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenantID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run retries the due deliveries once immediately and then every interval, until ctx is done
+func (o *Outbox) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := o.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			o.logger.ErrorContext(ctx, "Notification outbox run failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// retryTenant claims up to outboxBatchSize of the tenant's due deliveries and delivers them.
+// Deliveries to a channel that has since been disabled are dropped.
+func (o *Outbox) retryTenant(ctx context.Context, tenantID uuid.UUID) error {
+	due, err := o.store.ClaimDueNotifications(ctx, tenantID, outboxBatchSize, outboxLease)
+	if err != nil || len(due) == 0 {
+		return err
+	}
+	// The claimed deliveries are due again once their lease passes, so bailing out loses nothing
+	channels, err := o.channels.store.GetNotificationChannels(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("listing notification channels: %w", err)
+	}
+	byID := make(map[uuid.UUID]models.NotificationChannel, len(channels))
+	for _, channel := range channels {
+		byID[channel.ID] = channel
+	}
+
+	for _, notification := range due {
+		channel, ok := byID[notification.ChannelID]
+		if !ok || !channel.Enabled {
+			o.logger.InfoContext(ctx, "Dropping outbox notification for a disabled channel", "outbox_id", notification.ID, "channel_id", notification.ChannelID, "tenant_id", tenantID)
+			o.remove(ctx, notification)
+			continue
+		}
+		o.deliver(ctx, channel, notification)
+	}
+	return nil
+}
+
+// deliver makes the attempt of notification the store has already counted. A delivered
+// notification leaves the outbox; a failed one is due again after its backoff. A channel type
+// this build cannot deliver to will not start working on a retry, so the outbox gives up on it
+// at once.
+func (o *Outbox) deliver(ctx context.Context, channel models.NotificationChannel, notification models.OutboxNotification) {
+	err := o.channels.NotifyChannel(ctx, channel, Notification{TenantID: notification.TenantID, Title: notification.Title, Body: notification.Body})
+	if err == nil {
+		o.remove(ctx, notification)
+		return
+	}
+
+	maxAttempts := o.opts.MaxAttempts
+	if errors.Is(err, ErrUnsupportedChannel) {
+		maxAttempts = int(notification.Attempts)
+	}
+	backoff := o.backoff(notification.Attempts)
+	o.logger.WarnContext(ctx, "Notification delivery failed, keeping it in the outbox", "error", err, "outbox_id", notification.ID, "channel_id", channel.ID, "tenant_id", notification.TenantID, "attempts", notification.Attempts, "retry_in", backoff.String())
+	if _, err := o.store.FailOutboxAttempt(ctx, notification.ID, notification.TenantID, err.Error(), backoff, maxAttempts); err != nil {
+		// The delivery is still due again once its lease passes
+		o.logger.ErrorContext(ctx, "Failed to record failed outbox attempt", "error", err, "outbox_id", notification.ID, "tenant_id", notification.TenantID)
+	}
+}
+
+// remove deletes notification from the outbox. One that cannot be deleted is delivered again
+// once its lease passes.
+func (o *Outbox) remove(ctx context.Context, notification models.OutboxNotification) {
+	if _, err := o.store.DeleteOutboxNotification(ctx, notification.ID, notification.TenantID); err != nil {
+		o.logger.ErrorContext(ctx, "Failed to delete outbox notification", "error", err, "outbox_id", notification.ID, "tenant_id", notification.TenantID)
+	}
+}
+
+// backoff returns the wait before the retry that follows attempt number attempts: BaseBackoff
+// after the first, doubling after each further one, and never more than MaxBackoff
+func (o *Outbox) backoff(attempts int32) time.Duration {
+	d := o.opts.BaseBackoff
+	for i := int32(1); i < attempts && d < o.opts.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, o.opts.MaxBackoff)
+}
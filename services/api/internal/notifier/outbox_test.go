@@ -0,0 +1,209 @@
+package notifier
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"rdl-api/internal/domain/models"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// memoryOutboxStore keeps outbox rows in memory, due by its own clock
+type memoryOutboxStore struct {
+	mu   sync.Mutex
+	now  time.Time
+	rows map[uuid.UUID]*models.OutboxNotification
+}
+
+func newMemoryOutboxStore() *memoryOutboxStore {
+	return &memoryOutboxStore{now: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC), rows: map[uuid.UUID]*models.OutboxNotification{}}
+}
+
+func (s *memoryOutboxStore) advance(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = s.now.Add(d)
+}
+
+func (s *memoryOutboxStore) only(t *testing.T) models.OutboxNotification {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.rows) != 1 {
+		t.Fatalf("expected 1 outbox row, got %d", len(s.rows))
+	}
+	for _, row := range s.rows {
+		return *row
+	}
+	return models.OutboxNotification{}
+}
+
+func (s *memoryOutboxStore) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.rows)
+}
+
+func (s *memoryOutboxStore) EnqueueNotification(_ context.Context, args models.CreateOutboxNotificationParams, tenantID uuid.UUID, lease time.Duration) (models.OutboxNotification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	row := &models.OutboxNotification{ID: uuid.New(), TenantID: tenantID, ChannelID: args.ChannelID, Title: args.Title, Body: args.Body, Attempts: 1, NextAttemptAt: s.now.Add(lease), CreatedAt: s.now}
+	s.rows[row.ID] = row
+	return *row, nil
+}
+
+func (s *memoryOutboxStore) ClaimDueNotifications(_ context.Context, tenantID uuid.UUID, limit int, lease time.Duration) ([]models.OutboxNotification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []models.OutboxNotification
+	for _, row := range s.rows {
+		if row.TenantID != tenantID || row.FailedAt != nil || row.NextAttemptAt.After(s.now) || len(due) == limit {
+			continue
+		}
+		row.Attempts++
+		row.NextAttemptAt = s.now.Add(lease)
+		due = append(due, *row)
+	}
+	return due, nil
+}
+
+func (s *memoryOutboxStore) FailOutboxAttempt(_ context.Context, id uuid.UUID, _ uuid.UUID, lastError string, backoff time.Duration, maxAttempts int) (models.OutboxNotification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	row := s.rows[id]
+	row.LastError = &lastError
+	row.NextAttemptAt = s.now.Add(backoff)
+	if int(row.Attempts) >= maxAttempts {
+		failedAt := s.now
+		row.FailedAt = &failedAt
+	}
+	return *row, nil
+}
+
+func (s *memoryOutboxStore) DeleteOutboxNotification(_ context.Context, id uuid.UUID, _ uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.rows[id]; !ok {
+		return 0, nil
+	}
+	delete(s.rows, id)
+	return 1, nil
+}
+
+// staticTenantLister lists fixed tenants
+type staticTenantLister []uuid.UUID
+
+func (l staticTenantLister) ListTenantIDs(context.Context) ([]uuid.UUID, error) {
+	return l, nil
+}
+
+func TestOutbox_FailedEmailStaysForRetry(t *testing.T) {
+	srv := newFakeSMTPServer(t, "250 OK", "451 Try again later", "250 OK")
+	host, port, _ := net.SplitHostPort(srv.addr)
+	tenantID := uuid.New()
+	channels := staticChannelStore{tenantID: {
+		{ID: uuid.New(), ChannelType: models.NotificationChannelTypeEnumEmail, Target: "billing@example.com", Enabled: true},
+	}}
+	store := newMemoryOutboxStore()
+	outbox := NewOutbox(store,
+		NewChannelNotifier(channels, Options{CircuitThreshold: 10}, newTestLogger()).WithEmail(EmailConfig{Host: host, Port: port, From: "alerts@example.com"}),
+		staticTenantLister{tenantID}, OutboxOptions{BaseBackoff: time.Minute}, newTestLogger())
+	ctx := context.Background()
+
+	if err := outbox.Notify(ctx, Notification{TenantID: tenantID, Title: "Leak"}); err != nil {
+		t.Fatalf("a failed delivery kept for retry should not fail Notify, got %v", err)
+	}
+	row := store.only(t)
+	if row.Attempts != 1 || row.LastError == nil || row.FailedAt != nil {
+		t.Fatalf("expected the failed delivery kept with 1 attempt and its error, got %+v", row)
+	}
+
+	if err := outbox.RunOnce(ctx); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if _, messages := srv.received(); len(messages) != 0 {
+		t.Fatalf("expected no retry before the backoff passed, got %d messages", len(messages))
+	}
+
+	store.advance(time.Minute)
+	if err := outbox.RunOnce(ctx); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if _, messages := srv.received(); len(messages) != 1 {
+		t.Errorf("expected the retry delivered, got %d messages", len(messages))
+	}
+	if n := store.len(); n != 0 {
+		t.Errorf("expected the delivered notification to leave the outbox, %d rows left", n)
+	}
+}
+
+func TestOutbox_GivesUpAfterMaxAttempts(t *testing.T) {
+	webhook, calls := newStatusServer(t, http.StatusBadGateway)
+	tenantID := uuid.New()
+	channels := staticChannelStore{tenantID: {
+		{ID: uuid.New(), ChannelType: models.NotificationChannelTypeEnumWebhook, Target: webhook.URL, Enabled: true},
+	}}
+	store := newMemoryOutboxStore()
+	outbox := NewOutbox(store, NewChannelNotifier(channels, Options{CircuitThreshold: 10}, newTestLogger()),
+		staticTenantLister{tenantID}, OutboxOptions{MaxAttempts: 2, BaseBackoff: time.Minute}, newTestLogger())
+	ctx := context.Background()
+
+	if err := outbox.Notify(ctx, Notification{TenantID: tenantID, Title: "Leak"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for range 3 {
+		store.advance(time.Hour)
+		if err := outbox.RunOnce(ctx); err != nil {
+			t.Fatalf("RunOnce: %v", err)
+		}
+	}
+
+	if got := calls.Load(); got != 2 {
+		t.Errorf("expected 2 attempts, got %d", got)
+	}
+	if row := store.only(t); row.FailedAt == nil {
+		t.Errorf("expected the outbox to give up and keep the row, got %+v", row)
+	}
+}
+
+func TestOutbox_DropsDisabledChannels(t *testing.T) {
+	webhook, calls := newStatusServer(t, http.StatusBadGateway)
+	tenantID := uuid.New()
+	channels := staticChannelStore{tenantID: {
+		{ID: uuid.New(), ChannelType: models.NotificationChannelTypeEnumWebhook, Target: webhook.URL, Enabled: true},
+	}}
+	store := newMemoryOutboxStore()
+	outbox := NewOutbox(store, NewChannelNotifier(channels, Options{CircuitThreshold: 10}, newTestLogger()),
+		staticTenantLister{tenantID}, OutboxOptions{BaseBackoff: time.Minute}, newTestLogger())
+	ctx := context.Background()
+
+	if err := outbox.Notify(ctx, Notification{TenantID: tenantID, Title: "Leak"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	channels[tenantID][0].Enabled = false
+	store.advance(time.Hour)
+	if err := outbox.RunOnce(ctx); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("expected no retry to a disabled channel, got %d attempts", got)
+	}
+	if n := store.len(); n != 0 {
+		t.Errorf("expected the delivery dropped, %d rows left", n)
+	}
+}
+
+func TestOutbox_Backoff(t *testing.T) {
+	outbox := NewOutbox(nil, nil, nil, OutboxOptions{BaseBackoff: time.Minute, MaxBackoff: 5 * time.Minute}, newTestLogger())
+	want := map[int32]time.Duration{1: time.Minute, 2: 2 * time.Minute, 3: 4 * time.Minute, 4: 5 * time.Minute, 60: 5 * time.Minute}
+	for attempts, backoff := range want {
+		if got := outbox.backoff(attempts); got != backoff {
+			t.Errorf("backoff after attempt %d: expected %s, got %s", attempts, backoff, got)
+		}
+	}
+}
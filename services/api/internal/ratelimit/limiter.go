@@ -0,0 +1,178 @@
+// Package ratelimit limits how many requests each tenant may make, with a token bucket per
+// tenant. Every tenant gets the global limit unless it has its own override in the tenants
+// table; overrides are reloaded periodically, so changing one needs no restart.
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"rdl-api/internal/domain/models"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Limit is a sustained rate with a burst allowance
+type Limit struct {
+	// RPS is the number of requests per second a tenant may sustain; 0 or less is unlimited
+	RPS float64
+	// Burst is how many requests a tenant may make at once; below 1 it is RPS rounded up
+	Burst int
+}
+
+// Unlimited reports whether the limit lets every request through
+func (l Limit) Unlimited() bool {
+	return l.RPS <= 0
+}
+
+// capacity returns the size of the limit's token bucket
+func (l Limit) capacity() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return max(1, math.Ceil(l.RPS))
+}
+
+// OverrideStore reads the tenants that override the global limit
+type OverrideStore interface {
+	GetTenantRateLimits(ctx context.Context) ([]models.TenantRateLimit, error)
+}
+
+// bucket holds a tenant's tokens, refilled at its limit's rate up to its capacity
+type bucket struct {
+	limit  Limit
+	tokens float64
+	last   time.Time
+}
+
+// refill adds the tokens earned since the bucket was last used
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = min(b.limit.capacity(), b.tokens+elapsed*b.limit.RPS)
+		b.last = now
+	}
+}
+
+// Limiter decides whether a tenant's request may go ahead
+type Limiter struct {
+	defaults Limit
+	store    OverrideStore
+	logger   *slog.Logger
+	now      func() time.Time
+
+	mu        sync.Mutex
+	overrides map[uuid.UUID]models.TenantRateLimit
+	buckets   map[uuid.UUID]*bucket
+}
+
+// New creates a Limiter that applies defaults to every tenant without an override. It has no
+// overrides until the first Refresh.
+func New(defaults Limit, store OverrideStore, logger *slog.Logger) *Limiter {
+	return &Limiter{
+		defaults:  defaults,
+		store:     store,
+		logger:    logger,
+		now:       time.Now,
+		overrides: map[uuid.UUID]models.TenantRateLimit{},
+		buckets:   map[uuid.UUID]*bucket{},
+	}
+}
+
+// LimitFor returns the limit that applies to the tenant: each field of its override when set,
+// the global one otherwise. An RPS override of 0 makes the tenant unlimited.
+func (l *Limiter) LimitFor(tenantID uuid.UUID) Limit {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limitFor(tenantID)
+}
+
+func (l *Limiter) limitFor(tenantID uuid.UUID) Limit {
+	limit := l.defaults
+	override, ok := l.overrides[tenantID]
+	if !ok {
+		return limit
+	}
+	if override.RPS != nil {
+		limit.RPS = max(0, *override.RPS)
+	}
+	if override.Burst != nil && *override.Burst > 0 && !limit.Unlimited() {
+		limit.Burst = int(*override.Burst)
+	}
+	return limit
+}
+
+// Allow takes a token from the tenant's bucket. When the bucket is empty it returns false and
+// how long until the next token is available.
+func (l *Limiter) Allow(tenantID uuid.UUID) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limit := l.limitFor(tenantID)
+	if limit.Unlimited() {
+		return true, 0
+	}
+
+	now := l.now()
+	b, ok := l.buckets[tenantID]
+	if !ok || b.limit != limit {
+		// A new limit starts from a full bucket rather than carrying over the old one's debt
+		b = &bucket{limit: limit, tokens: limit.capacity(), last: now}
+		l.buckets[tenantID] = b
+	}
+	b.refill(now)
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / limit.RPS * float64(time.Second))
+}
+
+// Refresh reloads the tenants' overrides. On failure the previous overrides stay in place.
+// Buckets that have refilled completely are dropped, since a new one would be identical.
+func (l *Limiter) Refresh(ctx context.Context) error {
+	limits, err := l.store.GetTenantRateLimits(ctx)
+	if err != nil {
+		return err
+	}
+	overrides := make(map[uuid.UUID]models.TenantRateLimit, len(limits))
+	for _, limit := range limits {
+		if limit.RPS == nil && l.defaults.Unlimited() {
+			l.logger.WarnContext(ctx, "Ignoring tenant rate limit burst without a rate while the global limit is unlimited", "tenant_id", limit.TenantID)
+			continue
+		}
+		overrides[limit.TenantID] = limit
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.overrides = overrides
+	now := l.now()
+	for tenantID, b := range l.buckets {
+		b.refill(now)
+		if b.tokens >= b.limit.capacity() {
+			delete(l.buckets, tenantID)
+		}
+	}
+	return nil
+}
+
+// Run refreshes the overrides once immediately and then every interval, until ctx is done. A
+// failed refresh is logged and the next one tries again.
+func (l *Limiter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := l.Refresh(ctx); err != nil && ctx.Err() == nil {
+			l.logger.ErrorContext(ctx, "Failed to reload tenant rate limits", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
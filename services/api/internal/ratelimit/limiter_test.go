@@ -0,0 +1,180 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"rdl-api/internal/domain/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type fakeOverrideStore struct {
+	limits []models.TenantRateLimit
+	err    error
+}
+
+func (s *fakeOverrideStore) GetTenantRateLimits(context.Context) ([]models.TenantRateLimit, error) {
+	return s.limits, s.err
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// newTestLimiter returns a limiter whose clock only moves when the returned func advances it
+func newTestLimiter(t *testing.T, defaults Limit, store OverrideStore) (*Limiter, func(time.Duration)) {
+	t.Helper()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	l := New(defaults, store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	l.now = func() time.Time { return now }
+	if err := l.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	return l, func(d time.Duration) { now = now.Add(d) }
+}
+
+// allowed makes n requests for the tenant at the current time and counts those let through
+func allowed(l *Limiter, tenantID uuid.UUID, n int) int {
+	count := 0
+	for range n {
+		if ok, _ := l.Allow(tenantID); ok {
+			count++
+		}
+	}
+	return count
+}
+
+func TestLimiter_GlobalLimitThrottles(t *testing.T) {
+	tenantID := uuid.New()
+	l, advance := newTestLimiter(t, Limit{RPS: 5, Burst: 5}, &fakeOverrideStore{})
+
+	if got := allowed(l, tenantID, 10); got != 5 {
+		t.Fatalf("expected the burst of 5 to be allowed, got %d", got)
+	}
+	ok, retryAfter := l.Allow(tenantID)
+	if ok {
+		t.Fatal("expected the request over the limit to be rejected")
+	}
+	if retryAfter != 200*time.Millisecond {
+		t.Errorf("expected to wait 200ms for the next token, got %s", retryAfter)
+	}
+
+	advance(time.Second)
+	if got := allowed(l, tenantID, 10); got != 5 {
+		t.Errorf("expected 5 requests after a second of refill, got %d", got)
+	}
+}
+
+func TestLimiter_HigherOverrideIsNotThrottledAtGlobalLimit(t *testing.T) {
+	regular, premium := uuid.New(), uuid.New()
+	store := &fakeOverrideStore{limits: []models.TenantRateLimit{
+		{TenantID: premium, RPS: ptr(50.0), Burst: ptr(int32(50))},
+	}}
+	l, _ := newTestLimiter(t, Limit{RPS: 5, Burst: 5}, store)
+
+	if got := allowed(l, premium, 50); got != 50 {
+		t.Errorf("expected all 50 requests of the tenant with an override allowed, got %d", got)
+	}
+	if got := allowed(l, regular, 50); got != 5 {
+		t.Errorf("expected the tenant without an override held to the global 5, got %d", got)
+	}
+}
+
+func TestLimiter_LimitFor(t *testing.T) {
+	rpsOnly, burstOnly := uuid.New(), uuid.New()
+	store := &fakeOverrideStore{limits: []models.TenantRateLimit{
+		{TenantID: rpsOnly, RPS: ptr(20.0)},
+		{TenantID: burstOnly, Burst: ptr(int32(40))},
+	}}
+	l, _ := newTestLimiter(t, Limit{RPS: 5, Burst: 10}, store)
+
+	tests := []struct {
+		name     string
+		tenantID uuid.UUID
+		want     Limit
+	}{
+		{"no override uses the global limit", uuid.New(), Limit{RPS: 5, Burst: 10}},
+		{"rps override keeps the global burst", rpsOnly, Limit{RPS: 20, Burst: 10}},
+		{"burst override keeps the global rps", burstOnly, Limit{RPS: 5, Burst: 40}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := l.LimitFor(tt.tenantID); got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLimiter_UnlimitedOverrides(t *testing.T) {
+	unlimited, burstOnly, rpsAndBurst := uuid.New(), uuid.New(), uuid.New()
+	store := &fakeOverrideStore{limits: []models.TenantRateLimit{
+		{TenantID: unlimited, RPS: ptr(0.0)},
+		{TenantID: burstOnly, Burst: ptr(int32(40))},
+		{TenantID: rpsAndBurst, RPS: ptr(20.0), Burst: ptr(int32(40))},
+	}}
+
+	t.Run("rps of 0 lifts the global limit", func(t *testing.T) {
+		l, _ := newTestLimiter(t, Limit{RPS: 5, Burst: 5}, store)
+		if got := l.LimitFor(unlimited); !got.Unlimited() {
+			t.Fatalf("expected the tenant to be unlimited, got %+v", got)
+		}
+		if got := allowed(l, unlimited, 50); got != 50 {
+			t.Errorf("expected all 50 requests allowed, got %d", got)
+		}
+	})
+
+	t.Run("burst without rps is ignored when the global limit is unlimited", func(t *testing.T) {
+		l, _ := newTestLimiter(t, Limit{}, store)
+		if got := l.LimitFor(burstOnly); got != (Limit{}) {
+			t.Fatalf("expected the unlimited global limit, got %+v", got)
+		}
+		if got := allowed(l, burstOnly, 50); got != 50 {
+			t.Errorf("expected all 50 requests allowed, got %d", got)
+		}
+	})
+
+	t.Run("rps override limits a tenant when the global limit is unlimited", func(t *testing.T) {
+		l, _ := newTestLimiter(t, Limit{}, store)
+		if got := l.LimitFor(rpsAndBurst); got != (Limit{RPS: 20, Burst: 40}) {
+			t.Fatalf("expected the override, got %+v", got)
+		}
+	})
+}
+
+func TestLimiter_RefreshAppliesChangedOverrides(t *testing.T) {
+	tenantID := uuid.New()
+	store := &fakeOverrideStore{}
+	l, _ := newTestLimiter(t, Limit{RPS: 2, Burst: 2}, store)
+
+	if got := allowed(l, tenantID, 10); got != 2 {
+		t.Fatalf("expected the global burst of 2, got %d", got)
+	}
+
+	store.limits = []models.TenantRateLimit{{TenantID: tenantID, RPS: ptr(10.0), Burst: ptr(int32(10))}}
+	if err := l.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if got := allowed(l, tenantID, 20); got != 10 {
+		t.Errorf("expected the new burst of 10 after the refresh, got %d", got)
+	}
+
+	store.err = errors.New("boom")
+	if err := l.Refresh(context.Background()); err == nil {
+		t.Fatal("expected the store error")
+	}
+	if got := l.LimitFor(tenantID); got.RPS != 10 {
+		t.Errorf("expected a failed refresh to keep the previous override, got %+v", got)
+	}
+}
+
+func TestLimiter_Unlimited(t *testing.T) {
+	l, _ := newTestLimiter(t, Limit{}, &fakeOverrideStore{})
+	if got := allowed(l, uuid.New(), 1000); got != 1000 {
+		t.Errorf("expected every request allowed without a limit, got %d", got)
+	}
+}
@@ -0,0 +1,136 @@
+// Package retention removes events that have outlived their tenant's retention period.
+// Deletes run in bounded batches, each in its own short transaction, so purging a large
+// backlog never holds locks on a big part of the events table at once.
+package retention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"rdl-api/internal/domain/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidBatchSize is returned by NewPurger for a batch size below 1
+var ErrInvalidBatchSize = errors.New("purge batch size must be at least 1")
+
+// EventStore reads the tenants' retention overrides and deletes their expired events
+type EventStore interface {
+	GetEventRetentionPolicies(ctx context.Context) ([]models.EventRetentionPolicy, error)
+	PurgeEventsBefore(ctx context.Context, tenantID uuid.UUID, before time.Time, limit int32) (int64, error)
+}
+
+// Result describes one purge run across all tenants
+type Result struct {
+	// Deleted counts the events deleted, keyed by tenant; tenants with nothing to purge are left out
+	Deleted map[uuid.UUID]int64
+	// Batches is the number of delete statements issued
+	Batches int
+}
+
+// Total returns the number of events deleted across all tenants
+func (r Result) Total() int64 {
+	var total int64
+	for _, count := range r.Deleted {
+		total += count
+	}
+	return total
+}
+
+// Purger deletes every tenant's events older than its retention period
+type Purger struct {
+	store            EventStore
+	logger           *slog.Logger
+	defaultRetention time.Duration
+	batchSize        int32
+	now              func() time.Time
+}
+
+// NewPurger creates a Purger. defaultRetention applies to tenants without their own override;
+// 0 keeps their events forever. batchSize caps the events deleted by one statement.
+func NewPurger(store EventStore, logger *slog.Logger, defaultRetention time.Duration, batchSize int) (*Purger, error) {
+	if batchSize < 1 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidBatchSize, batchSize)
+	}
+	return &Purger{store: store, logger: logger, defaultRetention: defaultRetention, batchSize: int32(batchSize), now: time.Now}, nil
+}
+
+// Purge deletes the expired events of every tenant. A tenant whose purge fails does not stop
+// the others; their errors are joined and returned with the result of everything that did succeed.
+func (p *Purger) Purge(ctx context.Context) (Result, error) {
+	result := Result{Deleted: map[uuid.UUID]int64{}}
+	policies, err := p.store.GetEventRetentionPolicies(ctx)
+	if err != nil {
+		return result, fmt.Errorf("load retention policies: %w", err)
+	}
+
+	now := p.now()
+	var errs []error
+	for _, policy := range policies {
+		retention := policy.Retention(p.defaultRetention)
+		if retention <= 0 {
+			continue
+		}
+		deleted, batches, err := p.purgeTenant(ctx, policy.TenantID, now.Add(-retention))
+		result.Batches += batches
+		if deleted > 0 {
+			result.Deleted[policy.TenantID] = deleted
+			p.logger.InfoContext(ctx, "Purged expired events", "tenant_id", policy.TenantID, "deleted", deleted, "batches", batches, "retention", retention.String())
+		}
+		if err != nil {
+			p.logger.ErrorContext(ctx, "Failed to purge expired events", "error", err, "tenant_id", policy.TenantID)
+			errs = append(errs, fmt.Errorf("tenant %s: %w", policy.TenantID, err))
+			if ctx.Err() != nil {
+				break
+			}
+		}
+	}
+	return result, errors.Join(errs...)
+}
+
+// purgeTenant deletes the tenant's events created before cutoff one batch at a time, until a
+// batch comes back short or ctx is done
+func (p *Purger) purgeTenant(ctx context.Context, tenantID uuid.UUID, cutoff time.Time) (int64, int, error) {
+	var deleted int64
+	batches := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return deleted, batches, err
+		}
+		n, err := p.store.PurgeEventsBefore(ctx, tenantID, cutoff, p.batchSize)
+		batches++
+		if err != nil {
+			return deleted, batches, err
+		}
+		deleted += n
+		if n < int64(p.batchSize) {
+			return deleted, batches, nil
+		}
+	}
+}
+
+// Run purges once immediately and then every interval, until ctx is done. Each run logs the
+// total it deleted; a failed run is logged and the next one tries again.
+func (p *Purger) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		start := p.now()
+		result, err := p.Purge(ctx)
+		if err != nil && ctx.Err() == nil {
+			p.logger.ErrorContext(ctx, "Event purge run failed", "error", err, "deleted", result.Total())
+		} else if err == nil {
+			p.logger.InfoContext(ctx, "Event purge run finished", "deleted", result.Total(), "tenants", len(result.Deleted), "batches", result.Batches, "duration", time.Since(start).String())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
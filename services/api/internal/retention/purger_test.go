@@ -0,0 +1,182 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"rdl-api/internal/domain/models"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type storedEvent struct {
+	tenantID  uuid.UUID
+	createdAt time.Time
+}
+
+type fakeEventStore struct {
+	policies []models.EventRetentionPolicy
+	events   []storedEvent
+	// batches records the number of events each PurgeEventsBefore call deleted
+	batches []int64
+	// failTenant makes PurgeEventsBefore fail for that tenant
+	failTenant uuid.UUID
+}
+
+func (s *fakeEventStore) GetEventRetentionPolicies(context.Context) ([]models.EventRetentionPolicy, error) {
+	return s.policies, nil
+}
+
+func (s *fakeEventStore) PurgeEventsBefore(_ context.Context, tenantID uuid.UUID, before time.Time, limit int32) (int64, error) {
+	if tenantID == s.failTenant {
+		return 0, errors.New("boom")
+	}
+	sort.Slice(s.events, func(i, j int) bool { return s.events[i].createdAt.Before(s.events[j].createdAt) })
+
+	var deleted int64
+	kept := s.events[:0]
+	for _, event := range s.events {
+		if event.tenantID == tenantID && event.createdAt.Before(before) && deleted < int64(limit) {
+			deleted++
+			continue
+		}
+		kept = append(kept, event)
+	}
+	s.events = kept
+	s.batches = append(s.batches, deleted)
+	return deleted, nil
+}
+
+func (s *fakeEventStore) count(tenantID uuid.UUID) int {
+	n := 0
+	for _, event := range s.events {
+		if event.tenantID == tenantID {
+			n++
+		}
+	}
+	return n
+}
+
+func addEvents(store *fakeEventStore, tenantID uuid.UUID, createdAt time.Time, n int) {
+	for range n {
+		store.events = append(store.events, storedEvent{tenantID: tenantID, createdAt: createdAt})
+	}
+}
+
+func newTestPurger(t *testing.T, store EventStore, retention time.Duration, batchSize int, now time.Time) *Purger {
+	t.Helper()
+	p, err := NewPurger(store, slog.New(slog.NewTextHandler(io.Discard, nil)), retention, batchSize)
+	if err != nil {
+		t.Fatalf("NewPurger: %v", err)
+	}
+	p.now = func() time.Time { return now }
+	return p
+}
+
+func TestPurger_Purge(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	t.Run("removes only events past retention", func(t *testing.T) {
+		tenantID := uuid.New()
+		store := &fakeEventStore{policies: []models.EventRetentionPolicy{{TenantID: tenantID}}}
+		addEvents(store, tenantID, now.Add(-40*day), 3)
+		addEvents(store, tenantID, now.Add(-10*day), 2)
+
+		result, err := newTestPurger(t, store, 30*day, 100, now).Purge(context.Background())
+		if err != nil {
+			t.Fatalf("Purge: %v", err)
+		}
+		if result.Deleted[tenantID] != 3 || result.Total() != 3 {
+			t.Errorf("deleted = %v, want 3 for the tenant", result.Deleted)
+		}
+		if got := store.count(tenantID); got != 2 {
+			t.Errorf("remaining events = %d, want 2", got)
+		}
+	})
+
+	t.Run("caps each batch at the batch size", func(t *testing.T) {
+		tenantID := uuid.New()
+		store := &fakeEventStore{policies: []models.EventRetentionPolicy{{TenantID: tenantID}}}
+		addEvents(store, tenantID, now.Add(-40*day), 7)
+
+		result, err := newTestPurger(t, store, 30*day, 3, now).Purge(context.Background())
+		if err != nil {
+			t.Fatalf("Purge: %v", err)
+		}
+		want := []int64{3, 3, 1}
+		if len(store.batches) != len(want) {
+			t.Fatalf("batches = %v, want %v", store.batches, want)
+		}
+		for i := range want {
+			if store.batches[i] != want[i] {
+				t.Errorf("batches = %v, want %v", store.batches, want)
+				break
+			}
+		}
+		if result.Batches != 3 || result.Total() != 7 {
+			t.Errorf("result = %+v, want 3 batches and 7 deleted", result)
+		}
+	})
+
+	t.Run("honors the tenant override", func(t *testing.T) {
+		shortTenant, defaultTenant := uuid.New(), uuid.New()
+		days := int32(7)
+		store := &fakeEventStore{policies: []models.EventRetentionPolicy{
+			{TenantID: shortTenant, RetentionDays: &days},
+			{TenantID: defaultTenant},
+		}}
+		addEvents(store, shortTenant, now.Add(-10*day), 2)
+		addEvents(store, defaultTenant, now.Add(-10*day), 2)
+
+		if _, err := newTestPurger(t, store, 30*day, 100, now).Purge(context.Background()); err != nil {
+			t.Fatalf("Purge: %v", err)
+		}
+		if got := store.count(shortTenant); got != 0 {
+			t.Errorf("override tenant kept %d events, want 0", got)
+		}
+		if got := store.count(defaultTenant); got != 2 {
+			t.Errorf("default tenant kept %d events, want 2", got)
+		}
+	})
+
+	t.Run("zero retention keeps events of tenants without an override", func(t *testing.T) {
+		tenantID := uuid.New()
+		store := &fakeEventStore{policies: []models.EventRetentionPolicy{{TenantID: tenantID}}}
+		addEvents(store, tenantID, now.Add(-1000*day), 2)
+
+		if _, err := newTestPurger(t, store, 0, 100, now).Purge(context.Background()); err != nil {
+			t.Fatalf("Purge: %v", err)
+		}
+		if got := store.count(tenantID); got != 2 || len(store.batches) != 0 {
+			t.Errorf("remaining = %d, batches = %v; want 2 and none", got, store.batches)
+		}
+	})
+
+	t.Run("a failing tenant does not stop the others", func(t *testing.T) {
+		failing, healthy := uuid.New(), uuid.New()
+		store := &fakeEventStore{
+			policies:   []models.EventRetentionPolicy{{TenantID: failing}, {TenantID: healthy}},
+			failTenant: failing,
+		}
+		addEvents(store, healthy, now.Add(-40*day), 2)
+
+		result, err := newTestPurger(t, store, 30*day, 100, now).Purge(context.Background())
+		if err == nil {
+			t.Fatal("expected an error for the failing tenant")
+		}
+		if result.Deleted[healthy] != 2 {
+			t.Errorf("healthy tenant deleted = %d, want 2", result.Deleted[healthy])
+		}
+	})
+}
+
+func TestNewPurger_InvalidBatchSize(t *testing.T) {
+	if _, err := NewPurger(&fakeEventStore{}, slog.New(slog.NewTextHandler(io.Discard, nil)), time.Hour, 0); !errors.Is(err, ErrInvalidBatchSize) {
+		t.Errorf("err = %v, want ErrInvalidBatchSize", err)
+	}
+}
@@ -0,0 +1,5 @@
+-- Drop the index
+DROP INDEX IF EXISTS idx_actions_status_priority;
+
+-- Drop the column
+ALTER TABLE actions DROP COLUMN priority;
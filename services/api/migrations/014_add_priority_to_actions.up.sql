@@ -0,0 +1,8 @@
+-- Add the priority column to the actions table, derived from the leak's amount in cents
+ALTER TABLE actions ADD COLUMN priority BIGINT NOT NULL DEFAULT 0;
+
+-- Backfill priority for existing actions
+UPDATE actions SET priority = ROUND(leaks.amount * 100)::BIGINT FROM leaks WHERE leaks.id = actions.leak_id;
+
+-- Add the index used to fetch pending actions by priority
+CREATE INDEX idx_actions_status_priority ON actions(status, priority DESC, created_at ASC);
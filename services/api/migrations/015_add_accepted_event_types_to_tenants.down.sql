@@ -0,0 +1,2 @@
+-- Drop the column
+ALTER TABLE tenants DROP COLUMN accepted_event_types;
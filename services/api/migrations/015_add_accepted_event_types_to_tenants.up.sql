@@ -0,0 +1,2 @@
+-- Add the per-tenant event acceptance allowlist; an empty array accepts every event type
+ALTER TABLE tenants ADD COLUMN accepted_event_types event_type_enum[] NOT NULL DEFAULT '{}';
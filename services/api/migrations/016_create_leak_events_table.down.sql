@@ -0,0 +1,5 @@
+-- Drop the policy
+DROP POLICY IF EXISTS tenant_isolation_leak_events ON leak_events;
+
+-- Drop the table
+DROP TABLE IF EXISTS leak_events;
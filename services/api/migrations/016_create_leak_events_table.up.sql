@@ -0,0 +1,25 @@
+-- Create leak_events table, linking each leak to the events that triggered it
+CREATE TABLE leak_events (
+    leak_id UUID NOT NULL,
+    event_id UUID NOT NULL,
+    tenant_id UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (leak_id, event_id),
+    FOREIGN KEY (leak_id) REFERENCES leaks(id) ON DELETE CASCADE,
+    FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE,
+    FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE
+);
+
+-- Create index for looking up the leaks an event triggered
+CREATE INDEX idx_leak_events_event_id ON leak_events(event_id);
+
+-- Enable RLS and tenant isolation policy
+ALTER TABLE leak_events ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_leak_events ON leak_events
+    FOR ALL
+    TO PUBLIC
+    USING (tenant_id = current_tenant_id() OR is_service_account())
+    WITH CHECK (tenant_id = current_tenant_id() OR is_service_account());
+
+GRANT SELECT, INSERT, UPDATE, DELETE ON leak_events TO service_account;
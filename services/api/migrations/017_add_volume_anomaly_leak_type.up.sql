@@ -0,0 +1,2 @@
+-- Add the leak type flagged when a tenant's event volume deviates sharply from its baseline
+ALTER TYPE leak_type_enum ADD VALUE IF NOT EXISTS 'volume_anomaly';
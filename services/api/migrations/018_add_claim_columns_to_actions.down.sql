@@ -0,0 +1,19 @@
+-- Drop the claim columns
+ALTER TABLE actions DROP COLUMN IF EXISTS claimed_at;
+ALTER TABLE actions DROP COLUMN IF EXISTS claimed_by;
+
+-- Postgres can't drop an enum value, so release claimed actions and rebuild the type without it
+UPDATE actions SET status = 'pending' WHERE status = 'in_progress';
+
+ALTER TYPE action_status_enum RENAME TO action_status_enum_old;
+
+CREATE TYPE action_status_enum AS ENUM (
+    'pending',
+    'approved',
+    'modified',
+    'denied'
+);
+
+ALTER TABLE actions ALTER COLUMN status TYPE action_status_enum USING status::text::action_status_enum;
+
+DROP TYPE action_status_enum_old;
@@ -0,0 +1,6 @@
+-- Add the status an action holds while a worker executes it
+ALTER TYPE action_status_enum ADD VALUE IF NOT EXISTS 'in_progress';
+
+-- Record which worker claimed an action and when
+ALTER TABLE actions ADD COLUMN claimed_by TEXT;
+ALTER TABLE actions ADD COLUMN claimed_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,7 @@
+-- Drop the tenant-wide leaks that the stricter constraints can't hold
+DELETE FROM leaks WHERE customer_id IS NULL OR amount = 0;
+
+ALTER TABLE leaks DROP CONSTRAINT IF EXISTS leaks_amount_check;
+ALTER TABLE leaks ADD CONSTRAINT leaks_amount_check CHECK (amount > 0);
+
+ALTER TABLE leaks ALTER COLUMN customer_id SET NOT NULL;
@@ -0,0 +1,5 @@
+-- Tenant-wide leaks, such as a volume anomaly, have no single customer and no measurable amount
+ALTER TABLE leaks ALTER COLUMN customer_id DROP NOT NULL;
+
+ALTER TABLE leaks DROP CONSTRAINT IF EXISTS leaks_amount_check;
+ALTER TABLE leaks ADD CONSTRAINT leaks_amount_check CHECK (amount >= 0);
@@ -0,0 +1,2 @@
+-- Add the per-tenant provider allowlist; an empty array accepts events from any provider
+ALTER TABLE tenants ADD COLUMN allowed_provider_ids UUID[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS idx_leaks_tenant_status;
+
+ALTER TABLE leaks DROP CONSTRAINT IF EXISTS leaks_resolved_at_check;
+
+ALTER TABLE leaks
+    DROP COLUMN metadata,
+    DROP COLUMN resolved_at,
+    DROP COLUMN detected_at,
+    DROP COLUMN source_event_id,
+    DROP COLUMN currency,
+    DROP COLUMN status;
+
+DROP TYPE leak_status_enum;
@@ -0,0 +1,20 @@
+-- Track a leak from detection to resolution: its status, currency, the event that triggered it,
+-- when it was detected and resolved, and free-form rule metadata
+CREATE TYPE leak_status_enum AS ENUM ('open', 'resolved', 'ignored');
+
+ALTER TABLE leaks
+    ADD COLUMN status leak_status_enum NOT NULL DEFAULT 'open',
+    ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT 'USD',
+    ADD COLUMN source_event_id UUID REFERENCES events(id) ON DELETE SET NULL,
+    ADD COLUMN detected_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    ADD COLUMN resolved_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN metadata JSONB NOT NULL DEFAULT '{}';
+
+-- Existing leaks were detected when they were stored
+UPDATE leaks SET detected_at = created_at WHERE created_at IS NOT NULL;
+
+-- Only a resolved leak has a resolution time
+ALTER TABLE leaks ADD CONSTRAINT leaks_resolved_at_check CHECK (resolved_at IS NULL OR status = 'resolved');
+
+-- Create index for listing a tenant's leaks by status
+CREATE INDEX idx_leaks_tenant_status ON leaks(tenant_id, status);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_payments_provider_id;
+DROP INDEX IF EXISTS idx_payments_event_id;
+
+-- Drop the payments that the stricter constraint can't hold
+DELETE FROM payments WHERE customer_id IS NULL;
+ALTER TABLE payments ALTER COLUMN customer_id SET NOT NULL;
+
+ALTER TABLE payments DROP COLUMN event_id;
+ALTER TABLE payments DROP COLUMN provider_id;
@@ -0,0 +1,9 @@
+-- Link payments to the provider and event they were normalized from. A payment fetched from
+-- history has no event, and one read from an event may not be matched to a customer yet.
+ALTER TABLE payments ADD COLUMN provider_id UUID REFERENCES providers(id) ON DELETE SET NULL;
+ALTER TABLE payments ADD COLUMN event_id UUID REFERENCES events(id) ON DELETE SET NULL;
+ALTER TABLE payments ALTER COLUMN customer_id DROP NOT NULL;
+
+-- An event is normalized into at most one payment
+CREATE UNIQUE INDEX idx_payments_event_id ON payments(event_id) WHERE event_id IS NOT NULL;
+CREATE INDEX idx_payments_provider_id ON payments(provider_id);
@@ -0,0 +1 @@
+ALTER TABLE tenants DROP COLUMN min_leak_amounts;
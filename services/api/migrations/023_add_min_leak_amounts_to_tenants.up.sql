@@ -0,0 +1,3 @@
+-- Per-tenant minimum leak amounts, keyed by ISO 4217 currency code, e.g. {"USD": "1.00", "JPY": "150"}.
+-- A currency listed here overrides the global DETECTION_MIN_LEAK_AMOUNTS threshold for that currency.
+ALTER TABLE tenants ADD COLUMN min_leak_amounts JSONB NOT NULL DEFAULT '{}';
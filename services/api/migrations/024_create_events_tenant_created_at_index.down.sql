@@ -0,0 +1,2 @@
+-- Drop the composite index for (tenant_id, created_at, id)
+DROP INDEX IF EXISTS idx_events_tenant_created_at;
@@ -0,0 +1,3 @@
+-- Create a composite index so a tenant's newest events are read in index order
+-- instead of sorting the tenant's whole history (GET /events/recent)
+CREATE INDEX idx_events_tenant_created_at ON events(tenant_id, created_at DESC, id DESC);
@@ -0,0 +1 @@
+ALTER TABLE leaks DROP COLUMN IF EXISTS snoozed_until;
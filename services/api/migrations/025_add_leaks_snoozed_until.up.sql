@@ -0,0 +1,3 @@
+-- Let operators snooze a known leak: until snoozed_until passes it is left out of the open
+-- leak counts and listings, then reappears without further action
+ALTER TABLE leaks ADD COLUMN snoozed_until TIMESTAMP WITH TIME ZONE;
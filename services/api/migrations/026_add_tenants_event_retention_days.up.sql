@@ -0,0 +1,2 @@
+-- Per-tenant event retention in days. NULL falls back to the global EVENT_RETENTION setting.
+ALTER TABLE tenants ADD COLUMN event_retention_days INTEGER CHECK (event_retention_days IS NULL OR event_retention_days > 0);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_events_tenant_event_id_prefix;
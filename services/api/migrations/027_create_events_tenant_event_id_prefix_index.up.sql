@@ -0,0 +1,3 @@
+-- Prefix searches on provider event IDs (GET /events/search) use LIKE 'prefix%', which a plain
+-- btree can only serve in the C collation; varchar_pattern_ops makes it work in any collation
+CREATE INDEX idx_events_tenant_event_id_prefix ON events(tenant_id, event_id varchar_pattern_ops);
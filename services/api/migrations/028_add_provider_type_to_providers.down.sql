@@ -0,0 +1 @@
+ALTER TABLE providers DROP COLUMN provider_type;
@@ -0,0 +1,2 @@
+-- What kind of integration a provider is, e.g. stripe or webhook, shown on the integrations page
+ALTER TABLE providers ADD COLUMN provider_type VARCHAR(50) NOT NULL DEFAULT 'webhook';
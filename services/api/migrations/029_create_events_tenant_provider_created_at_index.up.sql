@@ -0,0 +1,3 @@
+-- Finding a provider's latest event for a tenant (GET /providers) reads one entry from this
+-- index instead of every event the provider sent
+CREATE INDEX idx_events_tenant_provider_created_at ON events(tenant_id, provider_id, created_at DESC);
@@ -0,0 +1,2 @@
+-- Add the leak type flagged when a customer is charged the same amount twice in quick succession
+ALTER TYPE leak_type_enum ADD VALUE IF NOT EXISTS 'duplicate_charge';
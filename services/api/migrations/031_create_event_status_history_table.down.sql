@@ -0,0 +1,9 @@
+-- Drop the trigger and its function
+DROP TRIGGER IF EXISTS record_events_status_change ON events;
+DROP FUNCTION IF EXISTS record_event_status_change();
+
+-- Drop the policy
+DROP POLICY IF EXISTS tenant_isolation_event_status_history ON event_status_history;
+
+-- Drop the table
+DROP TABLE IF EXISTS event_status_history;
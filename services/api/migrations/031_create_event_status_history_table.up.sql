@@ -0,0 +1,41 @@
+-- Create event_status_history table, an append-only log of every change to an event's status
+CREATE TABLE event_status_history (
+    id BIGSERIAL PRIMARY KEY, -- Orders changes made within the same instant
+    event_id UUID NOT NULL,
+    tenant_id UUID NOT NULL,
+    from_status event_status_enum NOT NULL,
+    to_status event_status_enum NOT NULL,
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT clock_timestamp(),
+    FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE,
+    FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE
+);
+
+-- Create index for reading an event's history in order
+CREATE INDEX idx_event_status_history_event_id ON event_status_history(event_id, changed_at, id);
+
+-- Record a row whenever an update changes an event's status, whichever query made it
+CREATE OR REPLACE FUNCTION record_event_status_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO event_status_history (event_id, tenant_id, from_status, to_status)
+    VALUES (NEW.id, NEW.tenant_id, OLD.status, NEW.status);
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER record_events_status_change AFTER UPDATE OF status ON events
+    FOR EACH ROW WHEN (OLD.status IS DISTINCT FROM NEW.status)
+    EXECUTE FUNCTION record_event_status_change();
+
+-- Enable RLS and tenant isolation policy
+ALTER TABLE event_status_history ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_event_status_history ON event_status_history
+    FOR ALL
+    TO PUBLIC
+    USING (tenant_id = current_tenant_id() OR is_service_account())
+    WITH CHECK (tenant_id = current_tenant_id() OR is_service_account());
+
+-- History is only ever appended to; rows go away with their event
+GRANT SELECT, INSERT ON event_status_history TO service_account;
+GRANT USAGE, SELECT ON SEQUENCE event_status_history_id_seq TO service_account;
@@ -0,0 +1,3 @@
+-- Per-tenant leak sources: which event types feed each leak type, e.g. {"duplicate_charge": ["payment_succeeded"]}.
+-- A leak type listed here replaces the built-in sources for that type; an empty list turns the leak type off.
+ALTER TABLE tenants ADD COLUMN leak_sources JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,8 @@
+-- Drop the policy
+DROP POLICY IF EXISTS tenant_isolation_notification_channels ON notification_channels;
+
+-- Drop the table, its trigger and index go with it
+DROP TABLE IF EXISTS notification_channels;
+
+-- Drop the enum
+DROP TYPE IF EXISTS notification_channel_type_enum;
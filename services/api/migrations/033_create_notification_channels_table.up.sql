@@ -0,0 +1,32 @@
+-- Create notification_channel_type_enum
+CREATE TYPE notification_channel_type_enum AS ENUM ('slack', 'email', 'webhook');
+
+-- Create notification_channels table, where a tenant's leak notifications are delivered
+CREATE TABLE notification_channels (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL,
+    channel_type notification_channel_type_enum NOT NULL,
+    target TEXT NOT NULL, -- Slack webhook URL, email address or webhook URL, by channel_type
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE
+);
+
+-- Create index for listing a tenant's channels
+CREATE INDEX idx_notification_channels_tenant_id ON notification_channels(tenant_id);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_notification_channels_updated_at BEFORE UPDATE ON notification_channels
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Enable RLS and tenant isolation policy
+ALTER TABLE notification_channels ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_notification_channels ON notification_channels
+    FOR ALL
+    TO PUBLIC
+    USING (tenant_id = current_tenant_id() OR is_service_account())
+    WITH CHECK (tenant_id = current_tenant_id() OR is_service_account());
+
+GRANT SELECT, INSERT, UPDATE, DELETE ON notification_channels TO service_account;
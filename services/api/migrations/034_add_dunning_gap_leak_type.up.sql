@@ -0,0 +1,2 @@
+-- Add the leak type flagged when a failed payment is not followed by a dunning retry
+ALTER TYPE leak_type_enum ADD VALUE IF NOT EXISTS 'dunning_gap';
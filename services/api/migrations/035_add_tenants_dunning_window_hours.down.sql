@@ -0,0 +1 @@
+ALTER TABLE tenants DROP COLUMN dunning_window_hours;
@@ -0,0 +1,2 @@
+-- Per-tenant dunning window in hours. NULL falls back to the global DETECTION_DUNNING_WINDOW setting.
+ALTER TABLE tenants ADD COLUMN dunning_window_hours INTEGER CHECK (dunning_window_hours IS NULL OR dunning_window_hours > 0);
@@ -0,0 +1,3 @@
+-- Per-tenant request rate limit. NULL falls back to the global RATE_LIMIT_RPS and RATE_LIMIT_BURST settings.
+ALTER TABLE tenants ADD COLUMN rate_limit_rps DOUBLE PRECISION CHECK (rate_limit_rps IS NULL OR rate_limit_rps > 0);
+ALTER TABLE tenants ADD COLUMN rate_limit_burst INTEGER CHECK (rate_limit_burst IS NULL OR rate_limit_burst > 0);
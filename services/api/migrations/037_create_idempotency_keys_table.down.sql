@@ -0,0 +1,5 @@
+-- Drop the policy
+DROP POLICY IF EXISTS tenant_isolation_idempotency_keys ON idempotency_keys;
+
+-- Drop the table, its index goes with it
+DROP TABLE IF EXISTS idempotency_keys;
@@ -0,0 +1,28 @@
+-- Create idempotency_keys table, the responses replayed to requests that repeat an Idempotency-Key
+CREATE TABLE idempotency_keys (
+    tenant_id UUID NOT NULL,
+    idempotency_key TEXT NOT NULL,
+    request_method TEXT NOT NULL, -- The key may only be reused for the same method and path
+    request_path TEXT NOT NULL,
+    status_code INTEGER NOT NULL,
+    content_type TEXT NOT NULL DEFAULT '',
+    body BYTEA NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (tenant_id, idempotency_key),
+    FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE
+);
+
+-- Create index for removing a tenant's expired keys
+CREATE INDEX idx_idempotency_keys_tenant_expires_at ON idempotency_keys(tenant_id, expires_at);
+
+-- Enable RLS and tenant isolation policy
+ALTER TABLE idempotency_keys ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_idempotency_keys ON idempotency_keys
+    FOR ALL
+    TO PUBLIC
+    USING (tenant_id = current_tenant_id() OR is_service_account())
+    WITH CHECK (tenant_id = current_tenant_id() OR is_service_account());
+
+GRANT SELECT, INSERT, UPDATE, DELETE ON idempotency_keys TO service_account;
@@ -0,0 +1,18 @@
+-- Postgres can't drop an enum value, so rebuild the type without it.
+-- Claimed events go back to pending so a worker picks them up again.
+UPDATE events SET status = 'pending' WHERE status = 'processing';
+DELETE FROM event_status_history WHERE from_status = 'processing' OR to_status = 'processing';
+
+ALTER TYPE event_status_enum RENAME TO event_status_enum_old;
+
+CREATE TYPE event_status_enum AS ENUM (
+    'pending',
+    'processed',
+    'failed'
+);
+
+ALTER TABLE events ALTER COLUMN status TYPE event_status_enum USING status::text::event_status_enum;
+ALTER TABLE event_status_history ALTER COLUMN from_status TYPE event_status_enum USING from_status::text::event_status_enum;
+ALTER TABLE event_status_history ALTER COLUMN to_status TYPE event_status_enum USING to_status::text::event_status_enum;
+
+DROP TYPE event_status_enum_old;
@@ -0,0 +1,2 @@
+-- Add the status a worker sets on the pending events it has claimed for processing
+ALTER TYPE event_status_enum ADD VALUE IF NOT EXISTS 'processing';
@@ -0,0 +1,2 @@
+-- Add the leak type flagged when payments for the same charge are in different currencies
+ALTER TYPE leak_type_enum ADD VALUE IF NOT EXISTS 'currency_mismatch';
@@ -0,0 +1,21 @@
+-- Postgres can't drop an enum value, so rebuild the type without it
+DELETE FROM leaks WHERE leak_type = 'failure_rate_spike';
+
+ALTER TYPE leak_type_enum RENAME TO leak_type_enum_old;
+
+CREATE TYPE leak_type_enum AS ENUM (
+    'failed_payments',
+    'unbilled_usage',
+    'quiet_churn',
+    'coupon_discount_misuse',
+    'trial_forever',
+    'other',
+    'volume_anomaly',
+    'duplicate_charge',
+    'dunning_gap',
+    'currency_mismatch'
+);
+
+ALTER TABLE leaks ALTER COLUMN leak_type TYPE leak_type_enum USING leak_type::text::leak_type_enum;
+
+DROP TYPE leak_type_enum_old;
@@ -0,0 +1,2 @@
+-- Add the leak type flagged when a provider's payment failure rate jumps above its baseline
+ALTER TYPE leak_type_enum ADD VALUE IF NOT EXISTS 'failure_rate_spike';
@@ -0,0 +1,19 @@
+-- Drop the attempt count
+ALTER TABLE actions DROP COLUMN IF EXISTS attempts;
+
+-- Postgres can't drop an enum value, so keep failed actions as denied and rebuild the type without it
+UPDATE actions SET status = 'denied' WHERE status = 'failed';
+
+ALTER TYPE action_status_enum RENAME TO action_status_enum_old;
+
+CREATE TYPE action_status_enum AS ENUM (
+    'pending',
+    'approved',
+    'modified',
+    'denied',
+    'in_progress'
+);
+
+ALTER TABLE actions ALTER COLUMN status TYPE action_status_enum USING status::text::action_status_enum;
+
+DROP TYPE action_status_enum_old;
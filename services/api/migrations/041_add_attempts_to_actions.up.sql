@@ -0,0 +1,5 @@
+-- Add the status an action ends in once it has failed MAX_ACTION_ATTEMPTS times
+ALTER TYPE action_status_enum ADD VALUE IF NOT EXISTS 'failed';
+
+-- Count how many times each action has been claimed for execution
+ALTER TABLE actions ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_events_tenant_source_created_at;
+
+ALTER TABLE events DROP COLUMN source;
+
+DROP TYPE event_source_enum;
@@ -0,0 +1,8 @@
+-- Record how an event entered the system: a provider webhook, a tenant import, the API, or
+-- by hand. Events stored before this migration, and rows inserted directly, count as manual.
+CREATE TYPE event_source_enum AS ENUM ('webhook', 'import', 'manual', 'api');
+
+ALTER TABLE events ADD COLUMN source event_source_enum NOT NULL DEFAULT 'manual';
+
+-- Create index for listing a tenant's events by source, newest first
+CREATE INDEX idx_events_tenant_source_created_at ON events(tenant_id, source, created_at DESC);
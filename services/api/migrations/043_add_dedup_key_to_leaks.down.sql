@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_leaks_tenant_open_dedup_key;
+
+ALTER TABLE leaks DROP CONSTRAINT IF EXISTS leaks_occurrences_check;
+
+ALTER TABLE leaks
+    DROP COLUMN occurrences,
+    DROP COLUMN dedup_key;
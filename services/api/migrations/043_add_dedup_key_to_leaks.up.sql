@@ -0,0 +1,12 @@
+-- Let repeated detections of one root cause update a single leak instead of piling up
+-- duplicates: a leak may carry a dedup key, such as its type, customer and subscription, and
+-- counts how many detections it stands for
+ALTER TABLE leaks
+    ADD COLUMN dedup_key VARCHAR(255),
+    ADD COLUMN occurrences INTEGER NOT NULL DEFAULT 1;
+
+ALTER TABLE leaks ADD CONSTRAINT leaks_occurrences_check CHECK (occurrences > 0);
+
+-- A tenant has at most one open leak per dedup key; resolved and ignored leaks keep theirs
+CREATE UNIQUE INDEX idx_leaks_tenant_open_dedup_key ON leaks(tenant_id, dedup_key)
+    WHERE status = 'open' AND dedup_key IS NOT NULL;
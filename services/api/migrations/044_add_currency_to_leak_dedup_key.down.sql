@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_leaks_tenant_open_dedup_key;
+
+-- Fails if a tenant has open leaks sharing a dedup key in several currencies; resolve or
+-- ignore all but one of them first
+CREATE UNIQUE INDEX idx_leaks_tenant_open_dedup_key ON leaks(tenant_id, dedup_key)
+    WHERE status = 'open' AND dedup_key IS NOT NULL;
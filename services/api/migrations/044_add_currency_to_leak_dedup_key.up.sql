@@ -0,0 +1,6 @@
+-- Amounts in different currencies cannot be added up, so a repeated detection only updates an
+-- open leak in its own currency; one in another currency becomes a leak of its own
+DROP INDEX IF EXISTS idx_leaks_tenant_open_dedup_key;
+
+CREATE UNIQUE INDEX idx_leaks_tenant_open_dedup_key ON leaks(tenant_id, dedup_key, currency)
+    WHERE status = 'open' AND dedup_key IS NOT NULL;
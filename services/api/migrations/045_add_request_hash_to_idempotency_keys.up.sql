@@ -0,0 +1,3 @@
+-- Remember a hash of the request body a key was first used with, so the key cannot be reused for
+-- a different body. Keys stored before this have an empty hash and are not checked.
+ALTER TABLE idempotency_keys ADD COLUMN request_hash TEXT NOT NULL DEFAULT '';
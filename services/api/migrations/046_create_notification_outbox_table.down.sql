@@ -0,0 +1,5 @@
+-- Drop the policy
+DROP POLICY IF EXISTS tenant_isolation_notification_outbox ON notification_outbox;
+
+-- Drop the table, its index goes with it
+DROP TABLE IF EXISTS notification_outbox;
@@ -0,0 +1,29 @@
+-- Create notification_outbox table, the leak notifications still to be delivered, one row per channel
+CREATE TABLE notification_outbox (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL,
+    channel_id UUID NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0, -- Deliveries tried so far, including one in flight
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    failed_at TIMESTAMP WITH TIME ZONE, -- Set once the outbox gives up; the row is then never retried
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE,
+    FOREIGN KEY (channel_id) REFERENCES notification_channels(id) ON DELETE CASCADE
+);
+
+-- Create index for finding a tenant's deliveries that are due
+CREATE INDEX idx_notification_outbox_tenant_next_attempt_at ON notification_outbox(tenant_id, next_attempt_at) WHERE failed_at IS NULL;
+
+-- Enable RLS and tenant isolation policy
+ALTER TABLE notification_outbox ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_notification_outbox ON notification_outbox
+    FOR ALL
+    TO PUBLIC
+    USING (tenant_id = current_tenant_id() OR is_service_account())
+    WITH CHECK (tenant_id = current_tenant_id() OR is_service_account());
+
+GRANT SELECT, INSERT, UPDATE, DELETE ON notification_outbox TO service_account;
@@ -0,0 +1,2 @@
+-- Merged duplicate leaks are not restored
+DROP INDEX IF EXISTS idx_leaks_tenant_type_source_event;
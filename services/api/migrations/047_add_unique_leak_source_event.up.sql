@@ -0,0 +1,38 @@
+-- Detection checks that an event has no leak of a type before it stores one, a check two
+-- concurrent runs can both pass. A source event now has at most one leak of each type, so the
+-- database refuses the second insert instead.
+
+-- Leaks already stored twice that way are merged into the oldest, which takes over the
+-- duplicates' linked events and actions before the duplicates are removed
+WITH ranked AS (
+    SELECT id, first_value(id) OVER (PARTITION BY tenant_id, leak_type, source_event_id ORDER BY created_at, id) AS keep_id
+    FROM leaks
+    WHERE source_event_id IS NOT NULL
+)
+INSERT INTO leak_events (leak_id, event_id, tenant_id)
+SELECT ranked.keep_id, leak_events.event_id, leak_events.tenant_id
+FROM leak_events
+JOIN ranked ON ranked.id = leak_events.leak_id
+WHERE ranked.id <> ranked.keep_id
+ON CONFLICT (leak_id, event_id) DO NOTHING;
+
+WITH ranked AS (
+    SELECT id, first_value(id) OVER (PARTITION BY tenant_id, leak_type, source_event_id ORDER BY created_at, id) AS keep_id
+    FROM leaks
+    WHERE source_event_id IS NOT NULL
+)
+UPDATE actions SET leak_id = ranked.keep_id
+FROM ranked
+WHERE actions.leak_id = ranked.id AND ranked.id <> ranked.keep_id;
+
+WITH ranked AS (
+    SELECT id, first_value(id) OVER (PARTITION BY tenant_id, leak_type, source_event_id ORDER BY created_at, id) AS keep_id
+    FROM leaks
+    WHERE source_event_id IS NOT NULL
+)
+DELETE FROM leaks
+USING ranked
+WHERE leaks.id = ranked.id AND ranked.id <> ranked.keep_id;
+
+CREATE UNIQUE INDEX idx_leaks_tenant_type_source_event ON leaks(tenant_id, leak_type, source_event_id)
+    WHERE source_event_id IS NOT NULL;
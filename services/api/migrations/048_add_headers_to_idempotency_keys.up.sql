@@ -0,0 +1,3 @@
+-- Keep every response header replayed for a key, not only its Content-Type. Keys stored before
+-- this have no headers and replay their content_type alone.
+ALTER TABLE idempotency_keys ADD COLUMN headers JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,3 @@
+UPDATE tenants SET rate_limit_rps = NULL WHERE rate_limit_rps = 0;
+ALTER TABLE tenants DROP CONSTRAINT IF EXISTS tenants_rate_limit_rps_check;
+ALTER TABLE tenants ADD CONSTRAINT tenants_rate_limit_rps_check CHECK (rate_limit_rps IS NULL OR rate_limit_rps > 0);
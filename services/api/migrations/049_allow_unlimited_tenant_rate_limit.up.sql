@@ -0,0 +1,3 @@
+-- A rate_limit_rps of 0 lifts the limit for the tenant, even when the global RATE_LIMIT_RPS is set.
+ALTER TABLE tenants DROP CONSTRAINT IF EXISTS tenants_rate_limit_rps_check;
+ALTER TABLE tenants ADD CONSTRAINT tenants_rate_limit_rps_check CHECK (rate_limit_rps IS NULL OR rate_limit_rps >= 0);
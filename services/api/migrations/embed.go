@@ -0,0 +1,37 @@
+// Package migrations embeds the SQL migrations so the binary knows which schema version it expects
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.up.sql
+var files embed.FS
+
+// Versions returns the versions of the embedded up migrations in ascending order,
+// taken from the NNN prefix of each NNN_description.up.sql file
+func Versions() ([]uint, error) {
+	names, err := fs.Glob(files, "*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]uint, 0, len(names))
+	for _, name := range names {
+		prefix, _, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s has no version prefix", name)
+		}
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s has an invalid version prefix: %w", name, err)
+		}
+		versions = append(versions, uint(version))
+	}
+	slices.Sort(versions)
+	return versions, nil
+}